@@ -0,0 +1,221 @@
+package activity
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/wisp"
+)
+
+// DefaultIdleDays is how many days without commits, MRs or agent activity
+// make a rig idle.
+const DefaultIdleDays = 14
+
+// DefaultUsageDays is how many days of usage the heatmap covers.
+const DefaultUsageDays = 28
+
+// mrEventTypes are the events that count as merge queue activity; every
+// other event attributed to a rig counts as an agent interaction.
+var mrEventTypes = map[string]bool{
+	events.TypeMergeStarted: true,
+	events.TypeMerged:       true,
+	events.TypeMergeFailed:  true,
+	events.TypeMergeSkipped: true,
+	events.TypeDone:         true,
+}
+
+// DayUsage counts one day's activity in a rig.
+type DayUsage struct {
+	Date    string `json:"date"` // YYYY-MM-DD, local time
+	Commits int    `json:"commits"`
+	MRs     int    `json:"mrs"`
+	Agent   int    `json:"agent"` // Agent interactions: slings, hooks, mail, sessions...
+}
+
+// Total is the day's activity count.
+func (d DayUsage) Total() int {
+	return d.Commits + d.MRs + d.Agent
+}
+
+// RigUsage is a rig's daily activity, oldest day first.
+type RigUsage struct {
+	Rig        string     `json:"rig"`
+	Days       []DayUsage `json:"days"`
+	LastActive time.Time  `json:"last_active,omitempty"` // Zero if the rig never saw activity
+}
+
+// IdleDays returns the whole days since the rig was last active, or -1 if it
+// never was.
+func (u *RigUsage) IdleDays(now time.Time) int {
+	if u.LastActive.IsZero() {
+		return -1
+	}
+	d := int(now.Sub(u.LastActive) / (24 * time.Hour))
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Idle reports whether the rig has had no activity for at least days days.
+// A rig that never saw activity is idle.
+func (u *RigUsage) Idle(now time.Time, days int) bool {
+	idle := u.IdleDays(now)
+	return idle < 0 || idle >= days
+}
+
+// Heatmap renders one cell per day, oldest first, on a fixed scale so rigs
+// can be compared: · none, ░ 1-2, ▒ 3-9, ▓ 10-29, █ 30+.
+func (u *RigUsage) Heatmap() string {
+	var b strings.Builder
+	for _, d := range u.Days {
+		switch n := d.Total(); {
+		case n == 0:
+			b.WriteString("·")
+		case n < 3:
+			b.WriteString("░")
+		case n < 10:
+			b.WriteString("▒")
+		case n < 30:
+			b.WriteString("▓")
+		default:
+			b.WriteString("█")
+		}
+	}
+	return b.String()
+}
+
+func (u *RigUsage) day(t time.Time) *DayUsage {
+	date := t.Local().Format("2006-01-02")
+	for i := range u.Days {
+		if u.Days[i].Date == date {
+			return &u.Days[i]
+		}
+	}
+	return nil
+}
+
+func (u *RigUsage) seen(t time.Time) {
+	if t.After(u.LastActive) {
+		u.LastActive = t
+	}
+}
+
+// CollectUsage gathers the last days days of activity for the rigs in
+// rigPaths (rig name → rig path): commits on any branch of the rig's repo,
+// and the merge queue and agent events attributed to the rig in the town's
+// events log. Sources that can't be read are skipped.
+func CollectUsage(townRoot string, rigPaths map[string]string, days int, now time.Time) map[string]*RigUsage {
+	if days <= 0 {
+		days = DefaultUsageDays
+	}
+	y, m, d := now.Local().Date()
+	start := time.Date(y, m, d-days+1, 0, 0, 0, 0, time.Local)
+
+	usage := make(map[string]*RigUsage, len(rigPaths))
+	for name, path := range rigPaths {
+		u := &RigUsage{Rig: name}
+		for i := 0; i < days; i++ {
+			u.Days = append(u.Days, DayUsage{Date: start.AddDate(0, 0, i).Format("2006-01-02")})
+		}
+		collectCommits(u, path, start)
+		// A rig counts as active from when it was added, however old its
+		// repo's last commit is, and from when it was last parked or
+		// unparked, so an unparked rig isn't straight away idle again.
+		for _, marker := range []string{filepath.Join(path, "config.json"), wisp.NewConfig(townRoot, name).ConfigPath()} {
+			if info, err := os.Stat(marker); err == nil {
+				u.seen(info.ModTime())
+			}
+		}
+		usage[name] = u
+	}
+	collectEvents(usage, filepath.Join(townRoot, events.EventsFile))
+	return usage
+}
+
+// collectCommits counts the commits in the rig's repo: the shared bare repo
+// polecats work from, or the mayor's clone.
+func collectCommits(u *RigUsage, rigPath string, start time.Time) {
+	var g *git.Git
+	if bare := filepath.Join(rigPath, ".repo.git"); dirExists(bare) {
+		g = git.NewGitWithDir(bare, "")
+	} else if clone := filepath.Join(rigPath, "mayor", "rig"); dirExists(clone) {
+		g = git.NewGit(clone)
+	} else {
+		return
+	}
+	if last, err := g.LastCommitTime(); err == nil && !last.IsZero() {
+		u.seen(last)
+	}
+	times, err := g.CommitTimes(start)
+	if err != nil {
+		return
+	}
+	for _, t := range times {
+		if d := u.day(t); d != nil {
+			d.Commits++
+		}
+	}
+}
+
+// collectEvents counts the events in the events log at path. An event
+// belongs to the rig named in its payload, or else to the rig its actor is
+// addressed under (e.g. "gastown/polecats/nux").
+func collectEvents(usage map[string]*RigUsage, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			countEvent(usage, line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func countEvent(usage map[string]*RigUsage, line []byte) {
+	var ev events.Event
+	if json.Unmarshal(line, &ev) != nil {
+		return
+	}
+	rigName, _ := ev.Payload["rig"].(string)
+	if rigName == "" {
+		rigName, _, _ = strings.Cut(ev.Actor, "/")
+	}
+	u := usage[rigName]
+	if u == nil {
+		return
+	}
+	t, err := time.Parse(time.RFC3339, ev.Timestamp)
+	if err != nil {
+		return
+	}
+	u.seen(t)
+	d := u.day(t)
+	if d == nil {
+		return
+	}
+	if mrEventTypes[ev.Type] {
+		d.MRs++
+	} else {
+		d.Agent++
+	}
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
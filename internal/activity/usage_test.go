@@ -0,0 +1,92 @@
+package activity
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectUsage(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	townRoot := t.TempDir()
+	now := time.Now()
+
+	// Rig "busy" has a commit today, made in its mayor clone.
+	clone := filepath.Join(townRoot, "busy", "mayor", "rig")
+	if err := os.MkdirAll(clone, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = clone
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(townRoot, "quiet"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := func(d time.Duration) string { return now.Add(-d).UTC().Format(time.RFC3339) }
+	log := strings.Join([]string{
+		`{"ts":"` + ts(time.Hour) + `","type":"sling","actor":"mayor","payload":{"rig":"busy"}}`,
+		`{"ts":"` + ts(time.Hour) + `","type":"merged","actor":"busy/refinery"}`,
+		`{"ts":"` + ts(40*24*time.Hour) + `","type":"hook","actor":"quiet/polecats/nux"}`,
+		`not json`,
+		`{"ts":"` + ts(time.Hour) + `","type":"hook","actor":"elsewhere/crew/max"}`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(townRoot, ".events.jsonl"), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := CollectUsage(townRoot, map[string]string{
+		"busy":  filepath.Join(townRoot, "busy"),
+		"quiet": filepath.Join(townRoot, "quiet"),
+	}, 7, now)
+
+	busy := usage["busy"]
+	if len(busy.Days) != 7 {
+		t.Fatalf("busy has %d days, want 7", len(busy.Days))
+	}
+	var commits, mrs, agent int
+	for _, d := range busy.Days {
+		commits += d.Commits
+		mrs += d.MRs
+		agent += d.Agent
+	}
+	if commits != 1 || mrs != 1 || agent != 1 {
+		t.Errorf("busy counted %d commits, %d MRs, %d agent; want 1 each", commits, mrs, agent)
+	}
+	if busy.Idle(now, 14) {
+		t.Error("busy should not be idle")
+	}
+
+	quiet := usage["quiet"]
+	if quiet.Heatmap() != "·······" {
+		t.Errorf("quiet heatmap = %q, want no activity", quiet.Heatmap())
+	}
+	if got := quiet.IdleDays(now); got != 40 {
+		t.Errorf("quiet idle %d days, want 40", got)
+	}
+	if !quiet.Idle(now, 14) || quiet.Idle(now, 41) {
+		t.Error("quiet should be idle at 14 days but not at 41")
+	}
+}
+
+func TestRigUsage_Heatmap(t *testing.T) {
+	u := &RigUsage{Days: []DayUsage{{}, {Commits: 2}, {MRs: 5}, {Agent: 12}, {Commits: 10, Agent: 25}}}
+	if got := u.Heatmap(); got != "·░▒▓█" {
+		t.Errorf("Heatmap() = %q, want ·░▒▓█", got)
+	}
+	if !(&RigUsage{}).Idle(time.Now(), 14) {
+		t.Error("a rig with no recorded activity should be idle")
+	}
+}
@@ -237,6 +237,46 @@ func tailJSONL(ctx context.Context, path, projectDir string, since time.Time, se
 	}
 }
 
+// ClaudeTranscriptPath returns the newest Claude Code JSONL transcript for
+// workDir modified at or after since (zero for any).
+func ClaudeTranscriptPath(workDir string, since time.Time) (string, error) {
+	projectDir, err := claudeProjectDirFor(workDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving project dir: %w", err)
+	}
+	path, ok := newestJSONLIn(projectDir, since)
+	if !ok {
+		return "", fmt.Errorf("no transcript in %s", projectDir)
+	}
+	return path, nil
+}
+
+// ReadClaudeTranscript parses a whole Claude Code JSONL transcript into
+// events, in file order. Lines that aren't conversation turns are skipped.
+func ReadClaudeTranscript(path, sessionID string) ([]AgentEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	nativeID := nativeSessionIDFromPath(path)
+	reader := bufio.NewReaderSize(f, 256*1024)
+	var events []AgentEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			events = append(events, parseClaudeCodeLine(line, sessionID, "claudecode", nativeID)...)
+		}
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+}
+
 // ── Claude Code JSONL structures ──────────────────────────────────────────────
 
 // ccEntry is a top-level line in a Claude Code JSONL file.
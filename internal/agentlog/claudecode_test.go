@@ -115,3 +115,27 @@ func TestNewAdapter(t *testing.T) {
 		})
 	}
 }
+
+func TestReadClaudeTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test-uuid.jsonl")
+	data := `{"type":"user","message":{"role":"user","content":[{"type":"text","text":"Fix it"}]},"timestamp":"2026-02-23T10:00:00.250Z"}
+{"type":"summary","summary":"ignored"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]},"timestamp":"2026-02-23T10:00:03Z"}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := ReadClaudeTranscript(path, "gt-wyvern-toast")
+	if err != nil {
+		t.Fatalf("ReadClaudeTranscript: %v", err)
+	}
+	if len(events) != 2 || events[0].Content != "Fix it" || events[1].Content != "Done" {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[0].NativeSessionID != "test-uuid" {
+		t.Errorf("NativeSessionID = %q, want test-uuid", events[0].NativeSessionID)
+	}
+	if gap := events[1].Timestamp.Sub(events[0].Timestamp); gap.Milliseconds() != 2750 {
+		t.Errorf("gap = %v, want 2.75s", gap)
+	}
+}
@@ -0,0 +1,221 @@
+// Package approval relays agent plans that need human sign-off out of the
+// agent's tmux pane and types the human's decision back into the session.
+//
+// An agent asks for approval by printing its plan between PlanStartMarker
+// and PlanEndMarker and then waiting. The relay captures the pane, records
+// a pending Request, and notifies the configured channels. Once someone
+// approves or rejects it (gt approval, or a Slack button), the decision is
+// delivered to the session as a nudge.
+package approval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Markers delimiting a plan in agent output.
+const (
+	PlanStartMarker = "=== PLAN FOR APPROVAL ==="
+	PlanEndMarker   = "=== END PLAN ==="
+)
+
+// Request statuses.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// ErrAlreadyDecided is returned when deciding a request that is no longer pending.
+var ErrAlreadyDecided = errors.New("approval request already decided")
+
+// Request is a plan awaiting (or having received) human sign-off.
+type Request struct {
+	ID        string     `json:"id"`
+	Session   string     `json:"session"`         // tmux session the plan came from
+	Agent     string     `json:"agent,omitempty"` // Agent address, when known
+	Plan      string     `json:"plan"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	DecidedBy string     `json:"decided_by,omitempty"`
+	Note      string     `json:"note,omitempty"`
+	Delivered bool       `json:"delivered,omitempty"` // Decision typed back into the session
+}
+
+// ExtractPlan returns the last complete plan in captured pane output.
+func ExtractPlan(pane string) (string, bool) {
+	end := strings.LastIndex(pane, PlanEndMarker)
+	if end < 0 {
+		return "", false
+	}
+	start := strings.LastIndex(pane[:end], PlanStartMarker)
+	if start < 0 {
+		return "", false
+	}
+	plan := strings.TrimSpace(pane[start+len(PlanStartMarker) : end])
+	return plan, plan != ""
+}
+
+// RequestID derives a request ID from the session and plan text, so
+// relaying the same plan twice finds the existing request.
+func RequestID(session, plan string) string {
+	h := sha256.Sum256([]byte(session + "\x00" + plan))
+	return "ap-" + hex.EncodeToString(h[:4])
+}
+
+// Response is the text typed back into the agent's session for a decision.
+func Response(r *Request) string {
+	var b strings.Builder
+	switch r.Status {
+	case StatusApproved:
+		fmt.Fprintf(&b, "PLAN APPROVED (%s) by %s.", r.ID, r.DecidedBy)
+	case StatusRejected:
+		fmt.Fprintf(&b, "PLAN REJECTED (%s) by %s.", r.ID, r.DecidedBy)
+	default:
+		return ""
+	}
+	if r.Note != "" {
+		b.WriteString(" Note: " + r.Note)
+	}
+	if r.Status == StatusApproved {
+		b.WriteString(" Proceed with the plan.")
+	} else {
+		b.WriteString(" Do not proceed; revise the plan and request approval again.")
+	}
+	return b.String()
+}
+
+// Store persists approval requests as one JSON file each.
+type Store struct {
+	dir string
+}
+
+// NewStore returns the approval store for a town.
+func NewStore(townRoot string) *Store {
+	return &Store{dir: filepath.Join(townRoot, ".runtime", "approvals")}
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// Get loads a request by ID.
+func (s *Store) Get(id string) (*Request, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("approval request %s not found", id)
+		}
+		return nil, err
+	}
+	var r Request
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing approval request %s: %w", id, err)
+	}
+	return &r, nil
+}
+
+// Save writes a request.
+func (s *Store) Save(r *Request) error {
+	return util.EnsureDirAndWriteJSON(s.path(r.ID), r)
+}
+
+// List returns requests, oldest first. An empty status lists all.
+func (s *Store) List(status string) ([]*Request, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []*Request
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		r, err := s.Get(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		if status == "" || r.Status == status {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Open records a pending request for plan, or returns the existing request
+// for the same session and plan. created reports whether it is new.
+func (s *Store) Open(session, agent, plan string, now time.Time) (r *Request, created bool, err error) {
+	id := RequestID(session, plan)
+	if existing, err := s.Get(id); err == nil {
+		return existing, false, nil
+	}
+	r = &Request{
+		ID:        id,
+		Session:   session,
+		Agent:     agent,
+		Plan:      plan,
+		Status:    StatusPending,
+		CreatedAt: now.UTC(),
+	}
+	if err := s.Save(r); err != nil {
+		return nil, false, err
+	}
+	return r, true, nil
+}
+
+// Decide approves or rejects a pending request.
+func (s *Store) Decide(id string, approve bool, by, note string, now time.Time) (*Request, error) {
+	r, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != StatusPending {
+		return r, fmt.Errorf("%w: %s is %s", ErrAlreadyDecided, id, r.Status)
+	}
+	r.Status = StatusRejected
+	if approve {
+		r.Status = StatusApproved
+	}
+	t := now.UTC()
+	r.DecidedAt = &t
+	r.DecidedBy = by
+	r.Note = note
+	if err := s.Save(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Sender types text into an agent session. Satisfied by *tmux.Tmux.
+type Sender interface {
+	NudgeSession(session, message string) error
+}
+
+// Deliver types a decided request's response into its session and records
+// the delivery.
+func (s *Store) Deliver(r *Request, sender Sender) error {
+	msg := Response(r)
+	if msg == "" {
+		return fmt.Errorf("approval request %s is still pending", r.ID)
+	}
+	if err := sender.NudgeSession(r.Session, msg); err != nil {
+		return fmt.Errorf("delivering decision to %s: %w", r.Session, err)
+	}
+	r.Delivered = true
+	return s.Save(r)
+}
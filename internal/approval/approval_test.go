@@ -0,0 +1,81 @@
+package approval
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeSender struct {
+	session, message string
+}
+
+func (f *fakeSender) NudgeSession(session, message string) error {
+	f.session, f.message = session, message
+	return nil
+}
+
+func TestExtractPlan(t *testing.T) {
+	pane := "old output\n" + PlanStartMarker + "\nstale plan\n" + PlanEndMarker + "\n" +
+		"$ thinking...\n" + PlanStartMarker + "\n1. migrate schema\n2. backfill\n" + PlanEndMarker + "\n> "
+	plan, ok := ExtractPlan(pane)
+	if !ok || plan != "1. migrate schema\n2. backfill" {
+		t.Errorf("ExtractPlan = %q, %v; want the last plan", plan, ok)
+	}
+
+	if _, ok := ExtractPlan("no markers here"); ok {
+		t.Error("expected no plan without markers")
+	}
+	if _, ok := ExtractPlan(PlanStartMarker + "\nstill typing..."); ok {
+		t.Error("expected no plan without an end marker")
+	}
+}
+
+func TestStore_OpenDecideDeliver(t *testing.T) {
+	s := NewStore(t.TempDir())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	r, created, err := s.Open("gt-nux", "gastown/polecats/nux", "1. do it", now)
+	if err != nil || !created || r.Status != StatusPending {
+		t.Fatalf("Open = %+v, %v, %v", r, created, err)
+	}
+	again, created, err := s.Open("gt-nux", "gastown/polecats/nux", "1. do it", now.Add(time.Minute))
+	if err != nil || created || again.ID != r.ID {
+		t.Errorf("re-relaying the same plan should find %s, got %+v created=%v err=%v", r.ID, again, created, err)
+	}
+
+	pending, err := s.List(StatusPending)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("List(pending) = %v, %v", pending, err)
+	}
+
+	decided, err := s.Decide(r.ID, false, "overseer", "split the migration", now)
+	if err != nil || decided.Status != StatusRejected {
+		t.Fatalf("Decide = %+v, %v", decided, err)
+	}
+	if _, err := s.Decide(r.ID, true, "overseer", "", now); !errors.Is(err, ErrAlreadyDecided) {
+		t.Errorf("second decision: err = %v, want ErrAlreadyDecided", err)
+	}
+
+	sender := &fakeSender{}
+	if err := s.Deliver(decided, sender); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if sender.session != "gt-nux" || !strings.Contains(sender.message, "PLAN REJECTED") ||
+		!strings.Contains(sender.message, "split the migration") {
+		t.Errorf("delivered %q to %q", sender.message, sender.session)
+	}
+	stored, _ := s.Get(r.ID)
+	if !stored.Delivered {
+		t.Error("delivery not recorded")
+	}
+}
+
+func TestDeliver_PendingFails(t *testing.T) {
+	s := NewStore(t.TempDir())
+	r, _, _ := s.Open("gt-nux", "", "plan", time.Now())
+	if err := s.Deliver(r, &fakeSender{}); err == nil {
+		t.Error("expected error delivering a pending request")
+	}
+}
@@ -0,0 +1,133 @@
+package approval
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Slack block action IDs for the approve/reject buttons.
+const (
+	SlackActionApprove = "gt_approval_approve"
+	SlackActionReject  = "gt_approval_reject"
+)
+
+// slackMaxSkew bounds the age of a signed Slack request, to limit replays.
+const slackMaxSkew = 5 * time.Minute
+
+// SlackMessage builds a Slack message with the plan and approve/reject
+// buttons. The buttons post to the dashboard's /slack/approvals endpoint
+// when the Slack app's interactivity URL points there.
+func SlackMessage(r *Request) map[string]interface{} {
+	who := r.Agent
+	if who == "" {
+		who = r.Session
+	}
+	return map[string]interface{}{
+		"text": fmt.Sprintf("Plan approval requested by %s (%s)", who, r.ID),
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]string{
+					"type": "mrkdwn",
+					"text": fmt.Sprintf("*Plan approval requested* by %s (`%s`)\n```%s```", who, r.ID, r.Plan),
+				},
+			},
+			map[string]interface{}{
+				"type": "actions",
+				"elements": []interface{}{
+					slackButton("Approve", SlackActionApprove, r.ID, "primary"),
+					slackButton("Reject", SlackActionReject, r.ID, "danger"),
+				},
+			},
+			map[string]interface{}{
+				"type": "context",
+				"elements": []interface{}{
+					map[string]string{"type": "mrkdwn", "text": fmt.Sprintf("Or: `gt approval approve %s` / `gt approval reject %s -m <reason>`", r.ID, r.ID)},
+				},
+			},
+		},
+	}
+}
+
+func slackButton(label, actionID, value, style string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":      "button",
+		"text":      map[string]string{"type": "plain_text", "text": label},
+		"action_id": actionID,
+		"value":     value,
+		"style":     style,
+	}
+}
+
+// VerifySlackSignature checks a Slack request signature (v0 scheme):
+// sig must be "v0=" + hex(HMAC-SHA256(secret, "v0:<timestamp>:<body>")).
+func VerifySlackSignature(secret, timestamp string, body []byte, sig string, now time.Time) error {
+	if secret == "" {
+		return errors.New("slack signing secret not configured")
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid slack timestamp %q", timestamp)
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > slackMaxSkew || d < -slackMaxSkew {
+		return errors.New("slack request timestamp out of range")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(sig)) {
+		return errors.New("slack signature mismatch")
+	}
+	return nil
+}
+
+// SlackDecision is an approve/reject click parsed from a Slack interaction.
+type SlackDecision struct {
+	ID      string
+	Approve bool
+	User    string
+}
+
+// ParseSlackAction extracts the decision from a Slack interactivity request
+// body (form-encoded with a JSON "payload" field).
+func ParseSlackAction(body []byte) (*SlackDecision, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("parsing slack form: %w", err)
+	}
+	var payload struct {
+		User struct {
+			Username string `json:"username"`
+			Name     string `json:"name"`
+			ID       string `json:"id"`
+		} `json:"user"`
+		Actions []struct {
+			ActionID string `json:"action_id"`
+			Value    string `json:"value"`
+		} `json:"actions"`
+	}
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		return nil, fmt.Errorf("parsing slack payload: %w", err)
+	}
+	user := payload.User.Username
+	if user == "" {
+		user = payload.User.Name
+	}
+	if user == "" {
+		user = payload.User.ID
+	}
+	for _, a := range payload.Actions {
+		switch a.ActionID {
+		case SlackActionApprove, SlackActionReject:
+			return &SlackDecision{ID: a.Value, Approve: a.ActionID == SlackActionApprove, User: "slack:" + user}, nil
+		}
+	}
+	return nil, errors.New("no approval action in slack payload")
+}
@@ -0,0 +1,54 @@
+package approval
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signSlack returns the Slack v0 signature of body at ts, for tests.
+func signSlack(secret string, ts time.Time, body []byte) (timestamp, sig string) {
+	timestamp = strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + string(body)))
+	return timestamp, "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte("payload=x")
+	ts, sig := signSlack("s3cret", now, body)
+
+	if err := VerifySlackSignature("s3cret", ts, body, sig, now); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := VerifySlackSignature("other", ts, body, sig, now); err == nil {
+		t.Error("wrong secret accepted")
+	}
+	if err := VerifySlackSignature("s3cret", ts, []byte("payload=y"), sig, now); err == nil {
+		t.Error("tampered body accepted")
+	}
+	if err := VerifySlackSignature("s3cret", ts, body, sig, now.Add(10*time.Minute)); err == nil {
+		t.Error("stale timestamp accepted")
+	}
+}
+
+func TestParseSlackAction(t *testing.T) {
+	payload := `{"user":{"username":"ada"},"actions":[{"action_id":"` + SlackActionReject + `","value":"ap-1234"}]}`
+	body := []byte("payload=" + url.QueryEscape(payload))
+	d, err := ParseSlackAction(body)
+	if err != nil {
+		t.Fatalf("ParseSlackAction: %v", err)
+	}
+	if d.ID != "ap-1234" || d.Approve || d.User != "slack:ada" {
+		t.Errorf("decision = %+v", d)
+	}
+
+	if _, err := ParseSlackAction([]byte("payload=" + url.QueryEscape(`{"actions":[{"action_id":"other"}]}`))); err == nil {
+		t.Error("expected error for a payload without an approval action")
+	}
+}
@@ -0,0 +1,207 @@
+// Package asciicast reads and writes asciinema v2 recordings ("casts") of
+// agent sessions, so they can be replayed with asciinema play or
+// asciinema-player for demos, reviews and debugging dialog automation.
+//
+// A cast is a JSON header line followed by one JSON array per event:
+// [seconds since start, "o", output]. Casts come from two sources:
+//
+//   - Record turns a session's raw pane output, piped by tmux pipe-pane,
+//     into a cast with the timing of every write.
+//   - FromTranscript renders an agent's conversation transcript. Its
+//     timing is only as fine as the transcript's per-message timestamps.
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Default terminal size, used when the header doesn't give one.
+const (
+	DefaultWidth  = 80
+	DefaultHeight = 24
+)
+
+// EventOutput is the type of an event writing to the terminal.
+const EventOutput = "o"
+
+// Header is the first line of a cast.
+type Header struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Title     string `json:"title,omitempty"`
+
+	// IdleTimeLimit caps, in seconds, the pauses players show.
+	IdleTimeLimit float64 `json:"idle_time_limit,omitempty"`
+
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Event is one timed event of a cast.
+type Event struct {
+	Time float64 // seconds since the start of the recording
+	Type string  // EventOutput, or "i" for input
+	Data string
+}
+
+// MarshalJSON encodes e as asciinema's [time, type, data] array.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Type, e.Data})
+}
+
+// UnmarshalJSON decodes an asciinema [time, type, data] array.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("event has %d fields, want 3", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return fmt.Errorf("event time: %w", err)
+	}
+	if err := json.Unmarshal(raw[1], &e.Type); err != nil {
+		return fmt.Errorf("event type: %w", err)
+	}
+	if err := json.Unmarshal(raw[2], &e.Data); err != nil {
+		return fmt.Errorf("event data: %w", err)
+	}
+	return nil
+}
+
+// Writer writes a cast, one line per event.
+type Writer struct {
+	enc *json.Encoder
+}
+
+// NewWriter writes h to w and returns a Writer for the events that follow.
+// The version is always 2; a missing size defaults to 80x24.
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	h.Version = 2
+	if h.Width <= 0 {
+		h.Width = DefaultWidth
+	}
+	if h.Height <= 0 {
+		h.Height = DefaultHeight
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(h); err != nil {
+		return nil, fmt.Errorf("writing header: %w", err)
+	}
+	return &Writer{enc: enc}, nil
+}
+
+// Write writes e.
+func (cw *Writer) Write(e Event) error {
+	return cw.enc.Encode(e)
+}
+
+// Output writes data as output at the given offset from the start.
+func (cw *Writer) Output(at time.Duration, data string) error {
+	return cw.Write(Event{Time: at.Seconds(), Type: EventOutput, Data: data})
+}
+
+// Read reads a whole cast. A truncated last line, as left by a recording
+// that was cut off, is ignored.
+func Read(r io.Reader) (Header, []Event, error) {
+	var h Header
+	reader := bufio.NewReaderSize(r, 256*1024)
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return h, nil, fmt.Errorf("reading header: %w", err)
+	}
+	if err := json.Unmarshal([]byte(line), &h); err != nil {
+		return h, nil, fmt.Errorf("parsing header: %w", err)
+	}
+	if h.Version != 2 {
+		return h, nil, fmt.Errorf("unsupported cast version %d", h.Version)
+	}
+
+	var events []Event
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return h, events, nil
+		}
+		if err != nil {
+			return h, nil, err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return h, nil, fmt.Errorf("parsing event %d: %w", len(events)+1, err)
+		}
+		events = append(events, e)
+	}
+}
+
+// Record writes h, then everything read from r as output events timed by
+// now, until r is exhausted. It is the far end of a tmux pipe-pane: each
+// write to the pane becomes one event. h.Timestamp defaults to the start.
+func Record(r io.Reader, w io.Writer, h Header, now func() time.Time) error {
+	start := now()
+	if h.Timestamp == 0 {
+		h.Timestamp = start.Unix()
+	}
+	cw, err := NewWriter(w, h)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 32*1024)
+	var pending []byte
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			// Hold back a rune split across reads so each event is valid UTF-8.
+			cut := completeUTF8(pending)
+			if cut > 0 {
+				if err := cw.Output(now().Sub(start), string(pending[:cut])); err != nil {
+					return err
+				}
+				pending = append(pending[:0], pending[cut:]...)
+			}
+		}
+		if readErr == io.EOF {
+			if len(pending) > 0 {
+				return cw.Output(now().Sub(start), string(pending))
+			}
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// completeUTF8 returns the length of b without a trailing incomplete rune.
+func completeUTF8(b []byte) int {
+	// A rune is at most 4 bytes, so only the last 3 can start a partial one.
+	for i := 1; i <= 3 && i <= len(b); i++ {
+		c := b[len(b)-i]
+		if !utf8.RuneStart(c) {
+			continue
+		}
+		if !utf8.FullRune(b[len(b)-i:]) {
+			return len(b) - i
+		}
+		break
+	}
+	return len(b)
+}
+
+// ErrEmpty is returned when there is nothing to put in a cast.
+var ErrEmpty = errors.New("nothing to export")
@@ -0,0 +1,99 @@
+package asciicast
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/agentlog"
+)
+
+func TestRecord_RoundTrip(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := start
+	now := func() time.Time {
+		clock = clock.Add(500 * time.Millisecond)
+		return clock
+	}
+	// One byte per read splits "é" across reads; it must arrive whole.
+	src := iotest.OneByteReader(strings.NewReader("hé\r\n"))
+
+	var buf bytes.Buffer
+	if err := Record(src, &buf, Header{Width: 100, Title: "wyvern/Toast"}, now); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	h, events, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if h.Version != 2 || h.Width != 100 || h.Height != DefaultHeight || h.Title != "wyvern/Toast" {
+		t.Errorf("header = %+v", h)
+	}
+	if h.Timestamp != start.Add(500*time.Millisecond).Unix() {
+		t.Errorf("timestamp = %d", h.Timestamp)
+	}
+
+	var got strings.Builder
+	var last float64
+	for _, e := range events {
+		if e.Type != EventOutput || e.Time <= last {
+			t.Errorf("event %+v after %v", e, last)
+		}
+		last = e.Time
+		got.WriteString(e.Data)
+	}
+	if got.String() != "hé\r\n" || len(events) != 4 {
+		t.Errorf("events = %+v, want 4 spelling hé\\r\\n", events)
+	}
+}
+
+func TestRead_TruncatedLastLine(t *testing.T) {
+	cast := `{"version": 2, "width": 80, "height": 24}` + "\n" +
+		`[0.5, "o", "hello"]` + "\n" +
+		`[1.0, "o", "wor`
+	_, events, err := Read(strings.NewReader(cast))
+	if err != nil || len(events) != 1 || events[0].Data != "hello" {
+		t.Errorf("Read = %+v, %v", events, err)
+	}
+
+	if _, _, err := Read(strings.NewReader(`{"version": 1}` + "\n")); err == nil {
+		t.Error("version 1 cast accepted")
+	}
+}
+
+func TestFromTranscript(t *testing.T) {
+	t0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	transcript := []agentlog.AgentEvent{
+		{EventType: "text", Role: "user", Content: "Fix the build", Timestamp: t0},
+		{EventType: "text", Role: "assistant", Content: "Looking.\nFound it.", Timestamp: t0.Add(2 * time.Second)},
+		{EventType: "usage", Role: "assistant", Timestamp: t0.Add(2 * time.Second), OutputTokens: 10},
+		{EventType: "tool_use", Role: "assistant", Content: "Bash: {}", Timestamp: t0.Add(3 * time.Second)},
+		{EventType: "tool_result", Role: "user", Content: strings.Repeat("line\n", 20), Timestamp: t0.Add(time.Second)},
+	}
+	events, err := FromTranscript(transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4 (usage skipped)", len(events))
+	}
+	wantTimes := []float64{0, 2, 3, 3} // the out-of-order result is held at 3
+	for i, e := range events {
+		if e.Time != wantTimes[i] {
+			t.Errorf("event %d at %v, want %v", i, e.Time, wantTimes[i])
+		}
+	}
+	if !strings.Contains(events[0].Data, "Fix the build") || !strings.Contains(events[1].Data, "Looking.\r\nFound it.") {
+		t.Errorf("rendered text = %q, %q", events[0].Data, events[1].Data)
+	}
+	if !strings.Contains(events[3].Data, "… +12 lines") {
+		t.Errorf("long tool result not truncated: %q", events[3].Data)
+	}
+
+	if _, err := FromTranscript(transcript[2:3]); !errors.Is(err, ErrEmpty) {
+		t.Errorf("usage-only transcript: err = %v, want ErrEmpty", err)
+	}
+}
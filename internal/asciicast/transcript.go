@@ -0,0 +1,101 @@
+package asciicast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/agentlog"
+)
+
+// maxToolLines caps how much of a tool call or result is shown; the rest is
+// summarized, as the agent's own terminal does.
+const maxToolLines = 8
+
+// ANSI styles for the rendered transcript.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiCyan   = "\x1b[36m"
+	ansiYellow = "\x1b[33m"
+)
+
+// FromTranscript renders an agent transcript as output events, timed by
+// each message's timestamp relative to the first. Messages sharing a
+// timestamp appear together. Usage events are skipped. It returns ErrEmpty
+// if nothing is left to show.
+func FromTranscript(events []agentlog.AgentEvent) ([]Event, error) {
+	if len(events) == 0 {
+		return nil, ErrEmpty
+	}
+	start := events[firstTimed(events)].Timestamp
+	var out []Event
+	var last float64
+	for _, ev := range events {
+		text := renderEvent(ev)
+		if text == "" {
+			continue
+		}
+		at := last
+		if !ev.Timestamp.IsZero() {
+			at = ev.Timestamp.Sub(start).Seconds()
+		}
+		// Keep time monotonic when a transcript's clocks disagree.
+		if at < last {
+			at = last
+		}
+		last = at
+		out = append(out, Event{Time: at, Type: EventOutput, Data: text})
+	}
+	if len(out) == 0 {
+		return nil, ErrEmpty
+	}
+	return out, nil
+}
+
+// firstTimed returns the index of the first event with a timestamp.
+func firstTimed(events []agentlog.AgentEvent) int {
+	for i, ev := range events {
+		if !ev.Timestamp.IsZero() {
+			return i
+		}
+	}
+	return 0
+}
+
+// renderEvent returns the terminal output for one transcript event.
+func renderEvent(ev agentlog.AgentEvent) string {
+	content := strings.TrimSpace(ev.Content)
+	if content == "" {
+		return ""
+	}
+	switch ev.EventType {
+	case "text":
+		if ev.Role == "user" {
+			return terminal(ansiBold + ansiCyan + "> " + ansiReset + ansiBold + content + ansiReset + "\n\n")
+		}
+		return terminal(content + "\n\n")
+	case "thinking":
+		return terminal(ansiDim + "✻ " + content + ansiReset + "\n\n")
+	case "tool_use":
+		return terminal(ansiYellow + "● " + ansiReset + truncateLines(content) + "\n")
+	case "tool_result":
+		return terminal(ansiDim + "  ⎿ " + truncateLines(content) + ansiReset + "\n\n")
+	default:
+		return ""
+	}
+}
+
+// truncateLines keeps the first maxToolLines lines of s.
+func truncateLines(s string) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= maxToolLines {
+		return s
+	}
+	return strings.Join(lines[:maxToolLines], "\n") + fmt.Sprintf("\n… +%d lines", len(lines)-maxToolLines)
+}
+
+// terminal converts newlines to the CRLF a raw terminal needs.
+func terminal(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
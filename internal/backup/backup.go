@@ -0,0 +1,528 @@
+// Package backup snapshots a town's state — beads, merge queue state,
+// configuration, patrol history and journals — into a single archive with
+// a checksum per file, and restores it. Git repositories and clones are
+// left out: they live on their remotes and are re-cloned by `gt rig add`.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// FormatVersion is the archive format Create writes and Restore reads.
+const FormatVersion = 1
+
+// ManifestName is the archive entry holding the Manifest. File contents
+// are stored under filesPrefix, by their path relative to the town root.
+const (
+	ManifestName = "MANIFEST.json"
+	filesPrefix  = "files/"
+)
+
+// maxPasses bounds how often Create re-reads the town when files change
+// while it copies them.
+const maxPasses = 3
+
+// ErrInconsistent is returned by Create when town state kept changing
+// during every copy pass, so no consistent snapshot could be taken.
+var ErrInconsistent = errors.New("town state kept changing during backup")
+
+// ErrCorrupt is returned when an archive doesn't match its manifest.
+var ErrCorrupt = errors.New("backup archive is corrupt")
+
+// Manifest describes a backup: where and when it was taken, and every file
+// in it with its checksum.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Town      string    `json:"town"` // Town root the backup was taken from
+	Files     []File    `json:"files"`
+}
+
+// File is one file in a backup.
+type File struct {
+	Path   string      `json:"path"` // Slash-separated, relative to the town root
+	Size   int64       `json:"size"`
+	Mode   fs.FileMode `json:"mode"`
+	SHA256 string      `json:"sha256"`
+}
+
+// Size returns the total size of the backed-up files.
+func (m *Manifest) Size() int64 {
+	var n int64
+	for _, f := range m.Files {
+		n += f.Size
+	}
+	return n
+}
+
+// Town-level trees backed up whole, and rig-level ones. Anything else at
+// either level is backed up only if it's a regular file directly in it
+// (town and rig config, journals like .events.jsonl).
+var (
+	townTrees = []string{".beads", ".dolt-data", "settings", "config", "daemon", "deacon", "mayor"}
+	rigTrees  = []string{".beads", "settings", ".runtime", "mayor/rig/.beads"}
+)
+
+// skipDirs are never descended into: repositories, clones, and runtime
+// state that is only meaningful to the processes that wrote it.
+var skipDirs = map[string]bool{
+	".git":          true,
+	"locks":         true, // .runtime/locks: held flocks
+	"targets":       true, // .runtime/targets: integration worktrees
+	"refinery-work": true, // .runtime/refinery-work: isolated stacking worktree
+	"rig":           true, // mayor/rig, refinery/rig: clones (mayor/rig/.beads is listed explicitly)
+	"dogs":          true, // deacon/dogs: dog worktrees
+}
+
+// skipFile reports whether a file holds process state (pids, sockets,
+// locks) that must not be restored.
+func skipFile(name string) bool {
+	for _, ext := range []string{".pid", ".sock", ".lock", ".tmp"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Collect returns the files a backup of townRoot holds, as slash-separated
+// paths relative to it, sorted.
+func Collect(townRoot string) ([]string, error) {
+	var files []string
+	add := func(rel string) {
+		files = append(files, filepath.ToSlash(rel))
+	}
+
+	if err := addTopLevel(townRoot, "", add); err != nil {
+		return nil, err
+	}
+	for _, tree := range townTrees {
+		if err := addTree(townRoot, tree, add); err != nil {
+			return nil, err
+		}
+	}
+
+	rigs, err := config.LoadRigsConfig(filepath.Join(townRoot, "mayor", "rigs.json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading rigs: %w", err)
+	}
+	if rigs != nil {
+		names := make([]string, 0, len(rigs.Rigs))
+		for name := range rigs.Rigs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !filepath.IsLocal(name) {
+				continue
+			}
+			if err := addTopLevel(townRoot, name, add); err != nil {
+				return nil, err
+			}
+			for _, tree := range rigTrees {
+				if err := addTree(townRoot, filepath.Join(name, filepath.FromSlash(tree)), add); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return slices.Compact(files), nil
+}
+
+// addTopLevel adds the regular files directly in townRoot/dir.
+func addTopLevel(townRoot, dir string, add func(string)) error {
+	entries, err := os.ReadDir(filepath.Join(townRoot, dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", filepath.Join(townRoot, dir), err)
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !skipFile(entry.Name()) {
+			add(filepath.Join(dir, entry.Name()))
+		}
+	}
+	return nil
+}
+
+// addTree adds the regular files under townRoot/dir, skipping skipDirs.
+// Symlinks aren't followed.
+func addTree(townRoot, dir string, add func(string)) error {
+	root := filepath.Join(townRoot, dir)
+	if info, err := os.Lstat(root); err != nil || !info.IsDir() {
+		return nil
+	}
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walking %s: %w", p, err)
+		}
+		if d.IsDir() {
+			if p != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || skipFile(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(townRoot, p)
+		if err != nil {
+			return err
+		}
+		add(rel)
+		return nil
+	})
+}
+
+// CreateFile backs up townRoot to the archive at dest, written to a
+// temporary file first so a failed backup never leaves a partial archive.
+func CreateFile(townRoot, dest string) (*Manifest, error) {
+	dir := filepath.Dir(dest)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("creating backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	m, err := create(townRoot, tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("writing backup: %w", closeErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return nil, fmt.Errorf("writing backup: %w", err)
+	}
+	return m, nil
+}
+
+// create writes a backup of townRoot to f. Files are copied while the town
+// may be running, so after each pass every file is stat'ed again; if any
+// changed meanwhile the pass is redone from scratch, up to maxPasses, so
+// the archive never mixes states from before and after a write.
+func create(townRoot string, f *os.File) (*Manifest, error) {
+	abs, err := filepath.Abs(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	var changed string
+	for pass := 0; pass < maxPasses; pass++ {
+		if pass > 0 {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			if err := f.Truncate(0); err != nil {
+				return nil, err
+			}
+		}
+		files, err := Collect(abs)
+		if err != nil {
+			return nil, err
+		}
+		m, stats, err := writeArchive(abs, files, f)
+		if err != nil {
+			return nil, err
+		}
+		changed = ""
+		for i, rel := range files {
+			info, err := os.Lstat(filepath.Join(abs, filepath.FromSlash(rel)))
+			if err != nil || !sameFile(info, stats[i]) {
+				changed = rel
+				break
+			}
+		}
+		if changed == "" {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%w (last: %s); stop the town (gt down) and retry", ErrInconsistent, changed)
+}
+
+func sameFile(a, b fs.FileInfo) bool {
+	return b != nil && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime()) && a.Mode() == b.Mode()
+}
+
+// writeArchive writes files to w as a gzipped tar, the manifest last,
+// returning it and the files' stats taken before each was read. A file
+// that vanished since Collect is left out.
+func writeArchive(townRoot string, files []string, w io.Writer) (*Manifest, []fs.FileInfo, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	m := &Manifest{Version: FormatVersion, CreatedAt: time.Now().UTC(), Town: townRoot}
+	stats := make([]fs.FileInfo, len(files))
+
+	for i, rel := range files {
+		entry, info, err := addFile(tw, townRoot, rel)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		stats[i] = info
+		m.Files = append(m.Files, entry)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: ManifestName, Mode: 0644, Size: int64(len(data)), ModTime: m.CreatedAt}); err != nil {
+		return nil, nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("writing backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, nil, fmt.Errorf("writing backup: %w", err)
+	}
+	return m, stats, nil
+}
+
+// addFile copies one file into tw, hashing it on the way. Exactly the size
+// stat'ed up front is copied; a file that grew is caught by the re-stat.
+func addFile(tw *tar.Writer, townRoot, rel string) (File, fs.FileInfo, error) {
+	src, err := os.Open(filepath.Join(townRoot, filepath.FromSlash(rel)))
+	if err != nil {
+		return File{}, nil, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return File{}, nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+	hdr := &tar.Header{
+		Name:    filesPrefix + rel,
+		Mode:    int64(info.Mode().Perm()),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return File{}, nil, fmt.Errorf("writing %s: %w", rel, err)
+	}
+	h := sha256.New()
+	n, err := io.CopyN(io.MultiWriter(tw, h), src, info.Size())
+	if err != nil && !errors.Is(err, io.EOF) {
+		return File{}, nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+	if n < info.Size() {
+		// Truncated while we read it: pad so the tar stays well-formed.
+		// The checksum is of what was read, and the re-stat redoes the pass.
+		if _, err := tw.Write(make([]byte, info.Size()-n)); err != nil {
+			return File{}, nil, fmt.Errorf("writing %s: %w", rel, err)
+		}
+	}
+	return File{Path: rel, Size: info.Size(), Mode: info.Mode().Perm(), SHA256: hex.EncodeToString(h.Sum(nil))}, info, nil
+}
+
+// Verify reads the archive at archivePath through, checking every file
+// against the manifest, and returns the manifest.
+func Verify(archivePath string) (*Manifest, error) {
+	return scan(archivePath, nil)
+}
+
+// scan reads the archive, calling visit for each file entry with a reader
+// of its contents, then checks the contents against the manifest. Files
+// are visited before they are checked, so visit must not make them
+// visible until scan returns without error.
+func scan(archivePath string, visit func(rel string, r io.Reader) error) (*Manifest, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening backup: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	tr := tar.NewReader(gz)
+
+	type seen struct {
+		size int64
+		sum  string
+	}
+	got := make(map[string]seen)
+	var m *Manifest
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if hdr.Name == ManifestName {
+			m = new(Manifest)
+			if err := json.NewDecoder(tr).Decode(m); err != nil {
+				return nil, fmt.Errorf("%w: manifest: %v", ErrCorrupt, err)
+			}
+			continue
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, filesPrefix)
+		if !ok || hdr.Typeflag != tar.TypeReg || !localPath(rel) {
+			return nil, fmt.Errorf("%w: unexpected entry %q", ErrCorrupt, hdr.Name)
+		}
+		h := sha256.New()
+		cw := &countingWriter{}
+		r := io.TeeReader(tr, io.MultiWriter(h, cw))
+		if visit != nil {
+			if err := visit(rel, r); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrCorrupt, rel, err)
+		}
+		got[rel] = seen{cw.n, hex.EncodeToString(h.Sum(nil))}
+	}
+
+	if m == nil {
+		return nil, fmt.Errorf("%w: no manifest", ErrCorrupt)
+	}
+	if m.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d (want %d)", m.Version, FormatVersion)
+	}
+	for _, file := range m.Files {
+		s, ok := got[file.Path]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s missing", ErrCorrupt, file.Path)
+		}
+		if s.size != file.Size || s.sum != file.SHA256 {
+			return nil, fmt.Errorf("%w: %s checksum mismatch", ErrCorrupt, file.Path)
+		}
+		delete(got, file.Path)
+	}
+	for rel := range got {
+		return nil, fmt.Errorf("%w: %s not in manifest", ErrCorrupt, rel)
+	}
+	return m, nil
+}
+
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// localPath reports whether rel, a slash-separated archive path, stays
+// inside the directory it is restored into.
+func localPath(rel string) bool {
+	return rel != "" && path.Clean(rel) == rel && filepath.IsLocal(filepath.FromSlash(rel))
+}
+
+// RestoreOptions control Restore.
+type RestoreOptions struct {
+	// Force overwrites files that already exist in the town.
+	Force bool
+
+	// DryRun verifies the archive and checks for conflicts without
+	// writing anything.
+	DryRun bool
+}
+
+// ErrWouldOverwrite is returned by Restore when files in the archive
+// already exist in the town and RestoreOptions.Force isn't set.
+var ErrWouldOverwrite = errors.New("restore would overwrite existing files")
+
+// Restore restores the archive at archivePath into townRoot. The whole
+// archive is verified before anything is written, and files are staged in
+// a directory under townRoot and moved into place only once every
+// checksum matched, so a corrupt archive changes nothing. Files not in the
+// archive are left alone.
+func Restore(archivePath, townRoot string, opts RestoreOptions) (*Manifest, error) {
+	m, err := Verify(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []string
+	for _, file := range m.Files {
+		if _, err := os.Lstat(filepath.Join(townRoot, filepath.FromSlash(file.Path))); err == nil {
+			existing = append(existing, file.Path)
+		}
+	}
+	if len(existing) > 0 && !opts.Force {
+		return m, fmt.Errorf("%w: %d files, e.g. %s (use --force)", ErrWouldOverwrite, len(existing), existing[0])
+	}
+	if opts.DryRun {
+		return m, nil
+	}
+
+	if err := os.MkdirAll(townRoot, 0755); err != nil {
+		return nil, fmt.Errorf("creating town root: %w", err)
+	}
+	staging, err := os.MkdirTemp(townRoot, ".restore-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	modes := make(map[string]fs.FileMode, len(m.Files))
+	for _, file := range m.Files {
+		modes[file.Path] = file.Mode
+	}
+	// The archive may have changed since Verify; scan checks it again.
+	if _, err := scan(archivePath, func(rel string, r io.Reader) error {
+		return stageFile(filepath.Join(staging, filepath.FromSlash(rel)), r, modes[rel])
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, file := range m.Files {
+		rel := filepath.FromSlash(file.Path)
+		dest := filepath.Join(townRoot, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, fmt.Errorf("restoring %s: %w", file.Path, err)
+		}
+		if err := os.Rename(filepath.Join(staging, rel), dest); err != nil {
+			return nil, fmt.Errorf("restoring %s: %w", file.Path, err)
+		}
+	}
+	return m, nil
+}
+
+func stageFile(dest string, r io.Reader, mode fs.FileMode) error {
+	if mode == 0 {
+		mode = 0644
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
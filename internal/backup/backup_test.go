@@ -0,0 +1,233 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTown lays out a town with one rig, its state, and the clones and
+// process state a backup must leave out.
+func newTown(t *testing.T) string {
+	t.Helper()
+	town := t.TempDir()
+	files := map[string]string{
+		"mayor/town.json":                            `{"name":"test"}`,
+		"mayor/rigs.json":                            `{"version":1,"rigs":{"gastown":{"git_url":"https://example.com/g.git"}}}`,
+		".events.jsonl":                              `{"type":"patrol_done"}` + "\n",
+		".beads/issues.jsonl":                        `{"id":"hq-1"}` + "\n",
+		".dolt-data/hq/.dolt/noms/manifest":          "noms",
+		"settings/config.json":                       "{}",
+		"daemon/daemon.log":                          "started\n",
+		"gastown/config.json":                        `{"name":"gastown"}`,
+		"gastown/refinery.toml":                      "[merge_queue]\n",
+		"gastown/.runtime/merge-queue-history.jsonl": `{"batch":"b1"}` + "\n",
+		"gastown/mayor/rig/.beads/issues.jsonl":      `{"id":"gt-1"}` + "\n",
+	}
+	excluded := map[string]string{
+		"daemon/daemon.pid":                   "123",
+		".dolt-data/sql-server.lock":          "lock",
+		"gastown/mayor/rig/README.md":         "clone",
+		"gastown/mayor/rig/.git/HEAD":         "ref: refs/heads/main",
+		"gastown/refinery/rig/main.go":        "package main",
+		"gastown/polecats/nux/work.go":        "package work",
+		"gastown/.runtime/locks/slot.lock":    "",
+		"gastown/.runtime/targets/wt/x.go":    "package x",
+		"gastown/.runtime/refinery-work/y.go": "package y",
+		"unregistered/config.json":            "{}",
+		"unregistered/.runtime/state.json":    "{}",
+	}
+	for _, set := range []map[string]string{files, excluded} {
+		for rel, content := range set {
+			p := filepath.Join(town, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return town
+}
+
+func TestCollect_Exclusions(t *testing.T) {
+	town := newTown(t)
+	files, err := Collect(town)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(files, "\n")
+	for _, want := range []string{".events.jsonl", ".beads/issues.jsonl", ".dolt-data/hq/.dolt/noms/manifest", "mayor/rigs.json",
+		"daemon/daemon.log", "gastown/refinery.toml", "gastown/.runtime/merge-queue-history.jsonl", "gastown/mayor/rig/.beads/issues.jsonl"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%s not backed up", want)
+		}
+	}
+	for _, bad := range []string{".pid", ".lock", "README.md", ".git", "refinery/rig", "polecats", "targets", "refinery-work", "unregistered/.runtime"} {
+		if strings.Contains(got, bad) {
+			t.Errorf("backup holds %s:\n%s", bad, got)
+		}
+	}
+}
+
+func TestBackupRestore_RoundTrip(t *testing.T) {
+	town := newTown(t)
+	archive := filepath.Join(t.TempDir(), "town.tar.gz")
+	m, err := CreateFile(town, archive)
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	if len(m.Files) == 0 {
+		t.Fatal("empty backup")
+	}
+	if _, err := Verify(archive); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	restored := filepath.Join(t.TempDir(), "town")
+	if _, err := Restore(archive, restored, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	for _, f := range m.Files {
+		want, err := os.ReadFile(filepath.Join(town, filepath.FromSlash(f.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(restored, filepath.FromSlash(f.Path)))
+		if err != nil || string(got) != string(want) {
+			t.Errorf("restored %s = %q, %v; want %q", f.Path, got, err, want)
+		}
+	}
+	// Nothing but the backed-up files, and no staging leftovers.
+	again, err := Collect(restored)
+	if err != nil || len(again) != len(m.Files) {
+		t.Errorf("restored town holds %v, %v", again, err)
+	}
+	entries, _ := os.ReadDir(restored)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".restore-") {
+			t.Errorf("staging directory %s left behind", e.Name())
+		}
+	}
+
+	// A second restore over it needs --force.
+	if _, err := Restore(archive, restored, RestoreOptions{}); !errors.Is(err, ErrWouldOverwrite) {
+		t.Errorf("restore over existing town: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(restored, ".events.jsonl"), []byte("later\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(archive, restored, RestoreOptions{Force: true, DryRun: true}); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(restored, ".events.jsonl")); string(got) != "later\n" {
+		t.Error("dry run wrote files")
+	}
+	if _, err := Restore(archive, restored, RestoreOptions{Force: true}); err != nil {
+		t.Fatalf("forced restore: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(restored, ".events.jsonl")); string(got) == "later\n" {
+		t.Error("forced restore didn't overwrite")
+	}
+}
+
+// rewrite copies the archive at src to dst, passing each entry's contents
+// through edit.
+func rewrite(t *testing.T, src, dst string, edit func(name string, data []byte) []byte) {
+	t.Helper()
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	gr, err := gzip.NewReader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	gw := gzip.NewWriter(out)
+	tr, tw := tar.NewReader(gr), tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = edit(hdr.Name, data)
+		if data == nil {
+			continue
+		}
+		hdr.Size = int64(len(data))
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	town := newTown(t)
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "town.tar.gz")
+	if _, err := CreateFile(town, archive); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, edit := range map[string]func(string, []byte) []byte{
+		"modified": func(name string, data []byte) []byte {
+			if name == "files/.beads/issues.jsonl" {
+				return []byte(`{"id":"hq-2"}` + "\n")
+			}
+			return data
+		},
+		"missing": func(name string, data []byte) []byte {
+			if name == "files/gastown/refinery.toml" {
+				return nil
+			}
+			return data
+		},
+		"no manifest": func(name string, data []byte) []byte {
+			if name == ManifestName {
+				return nil
+			}
+			return data
+		},
+	} {
+		tampered := filepath.Join(dir, "tampered.tar.gz")
+		rewrite(t, archive, tampered, edit)
+		if _, err := Verify(tampered); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: Verify = %v, want ErrCorrupt", name, err)
+		}
+		restored := filepath.Join(t.TempDir(), "town")
+		if _, err := Restore(tampered, restored, RestoreOptions{}); err == nil {
+			t.Errorf("%s: restored", name)
+		}
+		if entries, _ := os.ReadDir(restored); len(entries) > 0 {
+			t.Errorf("%s: a failed restore wrote %d entries", name, len(entries))
+		}
+	}
+}
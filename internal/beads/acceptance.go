@@ -0,0 +1,65 @@
+package beads
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabelVerified marks an issue whose acceptance criteria passed on the
+// merged code.
+const LabelVerified = "gt:verified"
+
+// Acceptance criterion kinds.
+const (
+	AcceptanceCommand  = "cmd"      // Shell command that must exit 0
+	AcceptanceFile     = "file"     // Path (relative to the repo root) that must exist
+	AcceptanceContract = "contract" // API contract test command that must exit 0
+)
+
+// AcceptanceCriterion is one machine-checkable condition an issue declares
+// for being done.
+type AcceptanceCriterion struct {
+	Kind string // AcceptanceCommand, AcceptanceFile or AcceptanceContract
+	Spec string // Command or path, depending on Kind
+}
+
+// String renders the criterion in the form it is declared in.
+func (c AcceptanceCriterion) String() string {
+	return c.Kind + ": " + c.Spec
+}
+
+// ParseAcceptanceCriteria extracts acceptance criteria from an issue
+// description. Each criterion is a line of the form
+//
+//	acceptance: <kind>: <spec>
+//
+// e.g. "acceptance: cmd: go test ./internal/refinery/..." or
+// "acceptance: file: docs/refinery.md". Lines with an unknown kind or an
+// empty spec are reported as an error so a typo can't silently drop a check.
+func ParseAcceptanceCriteria(description string) ([]AcceptanceCriterion, error) {
+	var criteria []AcceptanceCriterion
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok || strings.ToLower(strings.TrimSpace(key)) != "acceptance" {
+			continue
+		}
+		kind, spec, ok := strings.Cut(rest, ":")
+		kind, spec = strings.ToLower(strings.TrimSpace(kind)), strings.TrimSpace(spec)
+		if !ok || spec == "" {
+			return nil, fmt.Errorf("invalid acceptance criterion %q: expected \"acceptance: <kind>: <spec>\"", line)
+		}
+		switch kind {
+		case AcceptanceCommand, AcceptanceFile, AcceptanceContract:
+			criteria = append(criteria, AcceptanceCriterion{Kind: kind, Spec: spec})
+		default:
+			return nil, fmt.Errorf("invalid acceptance criterion %q: unknown kind %q (want cmd, file, contract)", line, kind)
+		}
+	}
+	return criteria, nil
+}
+
+// MarkVerified labels an issue as having passed its acceptance criteria.
+func (b *Beads) MarkVerified(id string) error {
+	return b.Update(id, UpdateOptions{AddLabels: []string{LabelVerified}})
+}
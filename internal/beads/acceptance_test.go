@@ -0,0 +1,30 @@
+package beads
+
+import "testing"
+
+func TestParseAcceptanceCriteria(t *testing.T) {
+	desc := "Fix the thing.\n\nacceptance: cmd: go test ./internal/foo/...\nAcceptance: file: docs/foo.md\nacceptance: contract: ./scripts/api-contract.sh v2\nsource_issue: gt-1"
+	got, err := ParseAcceptanceCriteria(desc)
+	if err != nil {
+		t.Fatalf("ParseAcceptanceCriteria: %v", err)
+	}
+	want := []AcceptanceCriterion{
+		{Kind: AcceptanceCommand, Spec: "go test ./internal/foo/..."},
+		{Kind: AcceptanceFile, Spec: "docs/foo.md"},
+		{Kind: AcceptanceContract, Spec: "./scripts/api-contract.sh v2"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d criteria, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("criterion %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"acceptance: cmd:", "acceptance: lint: make lint", "acceptance: go test"} {
+		if _, err := ParseAcceptanceCriteria(bad); err == nil {
+			t.Errorf("ParseAcceptanceCriteria(%q): expected error", bad)
+		}
+	}
+}
@@ -1344,6 +1344,19 @@ func (b *Beads) CloseWithReason(reason string, ids ...string) error {
 	return err
 }
 
+// ReopenWithReason reopens one or more closed issues, recording why.
+// Used by the refinery when it reverts a landing that closed them.
+func (b *Beads) ReopenWithReason(reason string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := append([]string{"reopen"}, ids...)
+	args = append(args, "--reason="+reason)
+	_, err := b.run(args...)
+	return err
+}
+
 // ForceCloseWithReason closes one or more issues with --force, bypassing
 // dependency checks. Used by gt done where the polecat is about to be nuked
 // and open molecule wisps should not block issue closure.
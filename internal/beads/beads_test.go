@@ -726,6 +726,7 @@ func TestMRFieldsRoundTrip(t *testing.T) {
 		Rig:         "gastown",
 		MergeCommit: "abc123def789",
 		CloseReason: "merged",
+		GateLogs:    ".runtime/artifacts/batch-1/gt-mr1",
 	}
 
 	// Format to string
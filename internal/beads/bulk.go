@@ -0,0 +1,301 @@
+package beads
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BulkFilter selects the issues a bulk operation applies to.
+// All non-empty fields must match.
+type BulkFilter struct {
+	List   ListOptions // Server-side filters passed to bd list
+	Labels []string    // Additional labels that must all be present
+	Title  string      // Case-insensitive substring of the title
+}
+
+// ParseBulkFilter parses filter expressions of the form "key=value", each
+// optionally holding several comma-separated terms (e.g. "label=stale,status=open").
+//
+// Supported keys: label (repeatable), status, assignee, priority, parent, title.
+// Status defaults to "open" so closed history isn't rewritten by accident.
+func ParseBulkFilter(exprs []string) (BulkFilter, error) {
+	f := BulkFilter{List: ListOptions{Status: "open", Priority: -1}}
+	terms := splitBulkTerms(exprs)
+	if len(terms) == 0 {
+		return f, fmt.Errorf("at least one filter is required")
+	}
+	for _, term := range terms {
+		key, value, ok := strings.Cut(term, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return f, fmt.Errorf("invalid filter %q: expected key=value", term)
+		}
+		switch key {
+		case "label":
+			// The first label goes to bd; the rest are matched client-side.
+			if f.List.Label == "" {
+				f.List.Label = value
+			} else {
+				f.Labels = append(f.Labels, value)
+			}
+		case "status":
+			f.List.Status = value
+		case "assignee":
+			f.List.Assignee = value
+		case "priority":
+			p, err := parseBulkPriority(value)
+			if err != nil {
+				return f, err
+			}
+			f.List.Priority = p
+		case "parent":
+			f.List.Parent = value
+		case "title":
+			f.Title = value
+		default:
+			return f, fmt.Errorf("unknown filter key %q (want label, status, assignee, priority, parent, title)", key)
+		}
+	}
+	return f, nil
+}
+
+// ParseBulkSet parses update expressions of the form "key=value".
+//
+// Supported keys: status, priority, assignee, add-label, remove-label.
+// An empty assignee ("assignee=") clears the assignee.
+func ParseBulkSet(exprs []string) (UpdateOptions, error) {
+	var u UpdateOptions
+	terms := splitBulkTerms(exprs)
+	if len(terms) == 0 {
+		return u, fmt.Errorf("at least one --set is required")
+	}
+	for _, term := range terms {
+		key, value, ok := strings.Cut(term, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return u, fmt.Errorf("invalid update %q: expected key=value", term)
+		}
+		if value == "" && key != "assignee" {
+			return u, fmt.Errorf("invalid update %q: value is required", term)
+		}
+		switch key {
+		case "status":
+			u.Status = &value
+		case "priority":
+			p, err := parseBulkPriority(value)
+			if err != nil {
+				return u, err
+			}
+			u.Priority = &p
+		case "assignee":
+			u.Assignee = &value
+		case "add-label":
+			u.AddLabels = append(u.AddLabels, value)
+		case "remove-label":
+			u.RemoveLabels = append(u.RemoveLabels, value)
+		default:
+			return u, fmt.Errorf("unknown update key %q (want status, priority, assignee, add-label, remove-label)", key)
+		}
+	}
+	return u, nil
+}
+
+// Matches reports whether issue satisfies the client-side part of the filter.
+// The bd list part is assumed to have been applied already.
+func (f BulkFilter) Matches(issue *Issue) bool {
+	for _, label := range f.Labels {
+		if !HasLabel(issue, label) {
+			return false
+		}
+	}
+	if f.Title != "" && !strings.Contains(strings.ToLower(issue.Title), strings.ToLower(f.Title)) {
+		return false
+	}
+	return true
+}
+
+// BulkOptions controls how a bulk update is applied.
+type BulkOptions struct {
+	DryRun bool   // Report matches without changing anything
+	Actor  string // Who is performing the update (for the audit log)
+	Reason string // Optional reason recorded in the audit log
+	Limit  int    // Refuse to run if more than Limit issues match (0 = no limit)
+}
+
+// BulkResult reports the outcome of a bulk update.
+type BulkResult struct {
+	Matched []*Issue          // Issues selected by the filter
+	Updated []string          // IDs updated successfully
+	Failed  map[string]string // ID -> error for failed updates
+}
+
+// BulkAuditEntry records one issue changed by a bulk update.
+type BulkAuditEntry struct {
+	Timestamp      string `json:"timestamp"`
+	Operation      string `json:"operation"` // always "bulk-update"
+	IssueID        string `json:"issue_id"`
+	Actor          string `json:"actor,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+	Filter         string `json:"filter"`
+	Changes        string `json:"changes"`
+	PreviousStatus string `json:"previous_status,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// FindBulk returns the issues selected by filter.
+func (b *Beads) FindBulk(filter BulkFilter) ([]*Issue, error) {
+	issues, err := b.List(filter.List)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*Issue
+	for _, issue := range issues {
+		if filter.Matches(issue) {
+			matched = append(matched, issue)
+		}
+	}
+	return matched, nil
+}
+
+// BulkUpdate applies update to every issue selected by filter, writing one
+// audit entry per issue. With DryRun set, it only reports the matches.
+// Individual update failures are collected in the result rather than
+// aborting the run, so one bad issue doesn't leave the rest untouched.
+func (b *Beads) BulkUpdate(filter BulkFilter, update UpdateOptions, opts BulkOptions) (*BulkResult, error) {
+	matched, err := b.FindBulk(filter)
+	if err != nil {
+		return nil, fmt.Errorf("listing issues: %w", err)
+	}
+	result := &BulkResult{Matched: matched, Failed: make(map[string]string)}
+	if opts.DryRun || len(matched) == 0 {
+		return result, nil
+	}
+	if opts.Limit > 0 && len(matched) > opts.Limit {
+		return result, fmt.Errorf("%d issues match, exceeding limit of %d", len(matched), opts.Limit)
+	}
+
+	filterDesc := describeBulkFilter(filter)
+	changes := DescribeBulkUpdate(update)
+	for _, issue := range matched {
+		entry := BulkAuditEntry{
+			Timestamp:      currentTimestamp(),
+			Operation:      "bulk-update",
+			IssueID:        issue.ID,
+			Actor:          opts.Actor,
+			Reason:         opts.Reason,
+			Filter:         filterDesc,
+			Changes:        changes,
+			PreviousStatus: issue.Status,
+		}
+		if err := b.Update(issue.ID, update); err != nil {
+			result.Failed[issue.ID] = err.Error()
+			entry.Error = err.Error()
+		} else {
+			result.Updated = append(result.Updated, issue.ID)
+		}
+		if err := b.LogBulkAudit(entry); err != nil {
+			// Log error but don't fail the update
+			fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+		}
+	}
+	return result, nil
+}
+
+// LogBulkAudit appends a bulk update entry to the same audit.log used by
+// LogDetachAudit.
+func (b *Beads) LogBulkAudit(entry BulkAuditEntry) (retErr error) {
+	auditPath := filepath.Join(b.getResolvedBeadsDir(), "audit.log")
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshaling audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(auditPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && retErr == nil {
+			retErr = fmt.Errorf("closing audit log: %w", err)
+		}
+	}()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing audit entry: %w", err)
+	}
+	return nil
+}
+
+// DescribeBulkUpdate renders update as "key=value" terms for previews and
+// the audit log.
+func DescribeBulkUpdate(u UpdateOptions) string {
+	var parts []string
+	if u.Status != nil {
+		parts = append(parts, "status="+*u.Status)
+	}
+	if u.Priority != nil {
+		parts = append(parts, fmt.Sprintf("priority=%d", *u.Priority))
+	}
+	if u.Assignee != nil {
+		parts = append(parts, "assignee="+*u.Assignee)
+	}
+	for _, l := range u.AddLabels {
+		parts = append(parts, "add-label="+l)
+	}
+	for _, l := range u.RemoveLabels {
+		parts = append(parts, "remove-label="+l)
+	}
+	return strings.Join(parts, ",")
+}
+
+func describeBulkFilter(f BulkFilter) string {
+	var parts []string
+	if f.List.Label != "" {
+		parts = append(parts, "label="+f.List.Label)
+	}
+	for _, l := range f.Labels {
+		parts = append(parts, "label="+l)
+	}
+	if f.List.Status != "" {
+		parts = append(parts, "status="+f.List.Status)
+	}
+	if f.List.Assignee != "" {
+		parts = append(parts, "assignee="+f.List.Assignee)
+	}
+	if f.List.Priority >= 0 {
+		parts = append(parts, fmt.Sprintf("priority=%d", f.List.Priority))
+	}
+	if f.List.Parent != "" {
+		parts = append(parts, "parent="+f.List.Parent)
+	}
+	if f.Title != "" {
+		parts = append(parts, "title="+f.Title)
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitBulkTerms flattens repeated flags and comma-separated terms.
+func splitBulkTerms(exprs []string) []string {
+	var terms []string
+	for _, expr := range exprs {
+		for _, term := range strings.Split(expr, ",") {
+			if strings.TrimSpace(term) != "" {
+				terms = append(terms, strings.TrimSpace(term))
+			}
+		}
+	}
+	return terms
+}
+
+func parseBulkPriority(value string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(value), "P"))
+	if err != nil || p < 0 || p > 4 {
+		return 0, fmt.Errorf("invalid priority %q: want 0-4 or P0-P4", value)
+	}
+	return p, nil
+}
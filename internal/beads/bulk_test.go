@@ -0,0 +1,102 @@
+package beads
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseBulkFilter(t *testing.T) {
+	f, err := ParseBulkFilter([]string{"label=stale,priority=P2", "label=infra", "title=Flaky"})
+	if err != nil {
+		t.Fatalf("ParseBulkFilter: %v", err)
+	}
+	if f.List.Label != "stale" {
+		t.Errorf("List.Label = %q, want stale", f.List.Label)
+	}
+	if len(f.Labels) != 1 || f.Labels[0] != "infra" {
+		t.Errorf("Labels = %v, want [infra]", f.Labels)
+	}
+	if f.List.Priority != 2 {
+		t.Errorf("Priority = %d, want 2", f.List.Priority)
+	}
+	if f.List.Status != "open" {
+		t.Errorf("Status = %q, want default open", f.List.Status)
+	}
+
+	for _, bad := range [][]string{nil, {"label"}, {"label="}, {"color=red"}, {"priority=P9"}} {
+		if _, err := ParseBulkFilter(bad); err == nil {
+			t.Errorf("ParseBulkFilter(%v): expected error", bad)
+		}
+	}
+}
+
+func TestParseBulkSet(t *testing.T) {
+	u, err := ParseBulkSet([]string{"status=closed", "assignee=", "add-label=a,remove-label=b"})
+	if err != nil {
+		t.Fatalf("ParseBulkSet: %v", err)
+	}
+	if u.Status == nil || *u.Status != "closed" {
+		t.Errorf("Status = %v, want closed", u.Status)
+	}
+	if u.Assignee == nil || *u.Assignee != "" {
+		t.Errorf("Assignee = %v, want cleared", u.Assignee)
+	}
+	if got := DescribeBulkUpdate(u); got != "status=closed,assignee=,add-label=a,remove-label=b" {
+		t.Errorf("DescribeBulkUpdate = %q", got)
+	}
+
+	for _, bad := range [][]string{nil, {"status="}, {"title=x"}, {"priority=high"}} {
+		if _, err := ParseBulkSet(bad); err == nil {
+			t.Errorf("ParseBulkSet(%v): expected error", bad)
+		}
+	}
+}
+
+func TestBulkFilter_Matches(t *testing.T) {
+	f := BulkFilter{Labels: []string{"infra"}, Title: "flaky"}
+	if !f.Matches(&Issue{Title: "Fix FLAKY test", Labels: []string{"stale", "infra"}}) {
+		t.Error("expected match")
+	}
+	if f.Matches(&Issue{Title: "Fix flaky test", Labels: []string{"stale"}}) {
+		t.Error("expected no match without infra label")
+	}
+	if f.Matches(&Issue{Title: "Other", Labels: []string{"infra"}}) {
+		t.Error("expected no match on title")
+	}
+}
+
+func TestLogBulkAudit(t *testing.T) {
+	dir := t.TempDir()
+	beadsDir := filepath.Join(dir, ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	b := NewWithBeadsDir(dir, beadsDir)
+
+	entry := BulkAuditEntry{Operation: "bulk-update", IssueID: "gt-1", Filter: "label=stale", Changes: "status=closed"}
+	if err := b.LogBulkAudit(entry); err != nil {
+		t.Fatalf("LogBulkAudit: %v", err)
+	}
+	if err := b.LogBulkAudit(entry); err != nil {
+		t.Fatalf("LogBulkAudit: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(beadsDir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit lines, got %d", len(lines))
+	}
+	var got BulkAuditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got != entry {
+		t.Errorf("audit entry = %+v, want %+v", got, entry)
+	}
+}
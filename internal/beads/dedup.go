@@ -0,0 +1,217 @@
+package beads
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultDuplicateThreshold is the similarity above which an issue is
+// reported as a probable duplicate.
+const DefaultDuplicateThreshold = 0.6
+
+// titleWeight is how much the title contributes to similarity relative to
+// the description. Agents tend to file the same bug under near-identical
+// titles with very different descriptions.
+const titleWeight = 0.7
+
+// DuplicateLabel marks an issue that resembled open issues when it was
+// created, until gt bead dedup merges or links it.
+const DuplicateLabel = "possible-duplicate"
+
+// maxSearchTerms caps the bd search calls made per FindSimilar.
+const maxSearchTerms = 4
+
+// DuplicateCandidate is an existing issue that resembles a new one.
+type DuplicateCandidate struct {
+	Issue *Issue
+	Score float64 // 0..1, higher is more similar
+}
+
+// SimilarOptions controls FindSimilar.
+type SimilarOptions struct {
+	Threshold float64 // Minimum score to report (0 = DefaultDuplicateThreshold)
+	Limit     int     // Max candidates returned (0 = 5)
+	ExcludeID string  // Issue to ignore (e.g. the issue being checked)
+	Status    string  // Status to search (default "open")
+}
+
+// FindSimilar returns open issues whose title and description resemble the
+// given ones, best match first. Candidates come from the bd search index
+// (queried with the title's significant terms) and are then scored locally.
+func (b *Beads) FindSimilar(title, description string, opts SimilarOptions) ([]DuplicateCandidate, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultDuplicateThreshold
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 5
+	}
+	if opts.Status == "" {
+		opts.Status = "open"
+	}
+
+	terms := significantTokens(title)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+
+	// bd search matches the query as a substring, so search each term and
+	// pool the results rather than requiring the whole title to match.
+	seen := make(map[string]*Issue)
+	for _, term := range terms {
+		issues, err := b.Search(SearchOptions{Query: term, Status: opts.Status, Limit: 50})
+		if err != nil {
+			return nil, fmt.Errorf("searching for %q: %w", term, err)
+		}
+		for _, issue := range issues {
+			if issue.ID != opts.ExcludeID {
+				seen[issue.ID] = issue
+			}
+		}
+	}
+
+	var candidates []DuplicateCandidate
+	for _, issue := range seen {
+		score := IssueSimilarity(title, description, issue.Title, issue.Description)
+		if score >= opts.Threshold {
+			candidates = append(candidates, DuplicateCandidate{Issue: issue, Score: score})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Issue.ID < candidates[j].Issue.ID
+	})
+	if len(candidates) > opts.Limit {
+		candidates = candidates[:opts.Limit]
+	}
+	return candidates, nil
+}
+
+// CreateChecked creates an issue like Create, then flags it if it probably
+// duplicates an open issue (see FlagDuplicates). The check is best-effort:
+// a failed search never fails the create, and yields no candidates.
+func (b *Beads) CreateChecked(opts CreateOptions) (*Issue, []DuplicateCandidate, error) {
+	issue, err := b.Create(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	dups, _ := b.FlagDuplicates(issue)
+	return issue, dups, nil
+}
+
+// FlagDuplicates looks for open issues that issue probably duplicates and,
+// if there are any, labels it DuplicateLabel. It returns the candidates,
+// best match first.
+func (b *Beads) FlagDuplicates(issue *Issue) ([]DuplicateCandidate, error) {
+	dups, err := b.FindSimilar(issue.Title, issue.Description, SimilarOptions{ExcludeID: issue.ID})
+	if err != nil || len(dups) == 0 {
+		return nil, err
+	}
+	if err := b.Update(issue.ID, UpdateOptions{AddLabels: []string{DuplicateLabel}}); err != nil {
+		return dups, fmt.Errorf("labelling %s: %w", issue.ID, err)
+	}
+	issue.Labels = append(issue.Labels, DuplicateLabel)
+	return dups, nil
+}
+
+// IssueSimilarity scores how alike two issues are from their titles and
+// descriptions. Titles dominate; when either description is empty only the
+// titles are compared.
+func IssueSimilarity(titleA, descA, titleB, descB string) float64 {
+	titleScore := TextSimilarity(normalizeBugTitle(titleA), normalizeBugTitle(titleB))
+	if strings.TrimSpace(descA) == "" || strings.TrimSpace(descB) == "" {
+		return titleScore
+	}
+	return titleWeight*titleScore + (1-titleWeight)*TextSimilarity(descA, descB)
+}
+
+// TextSimilarity returns the Jaccard similarity of the significant tokens
+// in a and b.
+func TextSimilarity(a, b string) float64 {
+	ta, tb := significantTokens(a), significantTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(ta))
+	for _, t := range ta {
+		set[t] = true
+	}
+	shared := 0
+	for _, t := range tb {
+		if set[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// MarkDuplicate closes dupID as a duplicate of canonicalID, labelling it and
+// linking the two so history on either side points at the other.
+func (b *Beads) MarkDuplicate(dupID, canonicalID string) error {
+	if dupID == canonicalID {
+		return fmt.Errorf("cannot mark %s as a duplicate of itself", dupID)
+	}
+	if err := b.LinkRelated(dupID, canonicalID); err != nil {
+		return err
+	}
+	if err := b.Update(dupID, UpdateOptions{AddLabels: []string{"duplicate"}, RemoveLabels: []string{DuplicateLabel}}); err != nil {
+		return fmt.Errorf("labelling %s: %w", dupID, err)
+	}
+	if err := b.CloseWithReason("duplicate of "+canonicalID, dupID); err != nil {
+		return fmt.Errorf("closing %s: %w", dupID, err)
+	}
+	return nil
+}
+
+// LinkRelated records a non-blocking "related" dependency between two issues.
+func (b *Beads) LinkRelated(issue, related string) error {
+	if _, err := b.run("dep", "add", issue, related, "--type=related"); err != nil {
+		return fmt.Errorf("linking %s to %s: %w", issue, related, err)
+	}
+	return nil
+}
+
+// ResolveDuplicate records issue as related to, not a duplicate of,
+// related, clearing DuplicateLabel.
+func (b *Beads) ResolveDuplicate(issue, related string) error {
+	if err := b.LinkRelated(issue, related); err != nil {
+		return err
+	}
+	if err := b.Update(issue, UpdateOptions{RemoveLabels: []string{DuplicateLabel}}); err != nil {
+		return fmt.Errorf("unlabelling %s: %w", issue, err)
+	}
+	return nil
+}
+
+// dedupStopwords are ignored when comparing text; they carry no signal about
+// what an issue is about.
+var dedupStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "the": true, "to": true,
+	"when": true, "with": true, "should": true, "not": true, "fix": true,
+}
+
+// significantTokens lowercases s, splits it on non-alphanumerics (keeping _
+// so test names stay whole) and drops stopwords, short tokens and repeats.
+func significantTokens(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	seen := make(map[string]bool, len(fields))
+	var tokens []string
+	for _, f := range fields {
+		if len(f) < 3 || dedupStopwords[f] || seen[f] {
+			continue
+		}
+		seen[f] = true
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
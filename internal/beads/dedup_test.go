@@ -0,0 +1,93 @@
+package beads
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSignificantTokens(t *testing.T) {
+	got := significantTokens("Fix the TestFoo_Bar failure in refinery, refinery is flaky")
+	want := []string{"testfoo_bar", "failure", "refinery", "flaky"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("significantTokens = %v, want %v", got, want)
+	}
+}
+
+func TestIssueSimilarity(t *testing.T) {
+	tests := []struct {
+		name         string
+		titleA, decA string
+		titleB, decB string
+		min, max     float64
+	}{
+		{"identical titles", "Refinery crashes on empty queue", "", "refinery crashes on empty queue", "", 1, 1},
+		{"refinery prefix ignored", "Pre-existing failure: TestMerge times out", "", "TestMerge times out", "", 1, 1},
+		{"reworded", "Refinery crashes on empty queue", "", "Empty queue crashes the refinery daemon", "", 0.6, 0.9},
+		{"unrelated", "Refinery crashes on empty queue", "", "Add dark mode to dashboard", "", 0, 0.1},
+		{"description pulls score down", "Refinery crashes", "nil pointer in engineer loop", "Refinery crashes", "dashboard CSS broken", 0.69, 0.71},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IssueSimilarity(tt.titleA, tt.decA, tt.titleB, tt.decB)
+			if got < tt.min || got > tt.max {
+				t.Errorf("IssueSimilarity = %.2f, want in [%.2f, %.2f]", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestMarkDuplicate_RejectsSelf(t *testing.T) {
+	b := New(t.TempDir())
+	if err := b.MarkDuplicate("gt-1", "gt-1"); err == nil {
+		t.Error("expected error marking an issue as its own duplicate")
+	}
+}
+
+func TestCreateChecked_FlagsDuplicate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bd stub is a shell script")
+	}
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, "bd.log")
+	script := `#!/bin/sh
+printf '%s\n' "$*" >> '` + logPath + `'
+cmd=""
+for arg in "$@"; do
+  case "$arg" in
+    --*) ;;
+    *) cmd="$arg"; break ;;
+  esac
+done
+case "$cmd" in
+  create) echo '{"id":"gt-new","title":"Refinery crashes on empty queue"}' ;;
+  search) echo '[{"id":"gt-old","title":"Empty queue crashes the refinery","status":"open"},{"id":"gt-new","title":"Refinery crashes on empty queue","status":"open"}]' ;;
+esac
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(binDir, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	issue, dups, err := New(t.TempDir()).CreateChecked(CreateOptions{Title: "Refinery crashes on empty queue"})
+	if err != nil {
+		t.Fatalf("CreateChecked: %v", err)
+	}
+	if len(dups) != 1 || dups[0].Issue.ID != "gt-old" {
+		t.Fatalf("duplicates = %+v, want gt-old (and not the new issue itself)", dups)
+	}
+	if !HasLabel(issue, DuplicateLabel) {
+		t.Errorf("labels = %v, want %s", issue.Labels, DuplicateLabel)
+	}
+	log, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "update gt-new --add-label="+DuplicateLabel) {
+		t.Errorf("new issue not labelled in beads; bd calls:\n%s", log)
+	}
+}
@@ -462,6 +462,22 @@ type MRFields struct {
 	PreVerified     bool   // Polecat ran full gates after rebasing onto target
 	PreVerifiedAt   string // ISO 8601 timestamp when verification completed
 	PreVerifiedBase string // Target branch SHA at verification time
+
+	// QoS is the merge queue service class: interactive, batch or background.
+	// Empty means the refinery default (batch).
+	QoS string
+
+	// MergeStrategy is how the MR lands: squash, merge-commit, rebase-ff,
+	// cherry-pick or auto.
+	// Empty means the rig default.
+	MergeStrategy string
+
+	// SplitFrom is the MR this one was split out of (see gt mq split).
+	SplitFrom string
+
+	// GateLogs is the directory holding the gate logs of the batch run
+	// that blamed this MR (see gt mq logs).
+	GateLogs string
 }
 
 // ParseMRFields extracts structured merge-request fields from an issue's description.
@@ -545,6 +561,18 @@ func ParseMRFields(issue *Issue) *MRFields {
 		case "pre_verified_base", "pre-verified-base", "preverifiedbase":
 			fields.PreVerifiedBase = value
 			hasFields = true
+		case "qos", "qos_class", "qos-class":
+			fields.QoS = strings.ToLower(value)
+			hasFields = true
+		case "merge_strategy", "merge-strategy", "mergestrategy":
+			fields.MergeStrategy = strings.ToLower(value)
+			hasFields = true
+		case "split_from", "split-from", "splitfrom":
+			fields.SplitFrom = value
+			hasFields = true
+		case "gate_logs", "gate-logs", "gatelogs":
+			fields.GateLogs = value
+			hasFields = true
 		}
 	}
 
@@ -618,6 +646,18 @@ func FormatMRFields(fields *MRFields) string {
 	if fields.PreVerifiedBase != "" {
 		lines = append(lines, "pre_verified_base: "+fields.PreVerifiedBase)
 	}
+	if fields.QoS != "" {
+		lines = append(lines, "qos: "+fields.QoS)
+	}
+	if fields.MergeStrategy != "" {
+		lines = append(lines, "merge_strategy: "+fields.MergeStrategy)
+	}
+	if fields.SplitFrom != "" {
+		lines = append(lines, "split_from: "+fields.SplitFrom)
+	}
+	if fields.GateLogs != "" {
+		lines = append(lines, "gate_logs: "+fields.GateLogs)
+	}
 
 	return strings.Join(lines, "\n")
 }
@@ -673,6 +713,18 @@ func SetMRFields(issue *Issue, fields *MRFields) string {
 		"pre_verified_base":  true,
 		"pre-verified-base":  true,
 		"preverifiedbase":    true,
+		"qos":                true,
+		"qos_class":          true,
+		"qos-class":          true,
+		"merge_strategy":     true,
+		"merge-strategy":     true,
+		"mergestrategy":      true,
+		"split_from":         true,
+		"split-from":         true,
+		"splitfrom":          true,
+		"gate_logs":          true,
+		"gate-logs":          true,
+		"gatelogs":           true,
 	}
 
 	// Collect non-MR lines from existing description
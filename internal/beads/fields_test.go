@@ -547,3 +547,36 @@ func TestParseAgentFields_WithCompletionMetadata(t *testing.T) {
 		t.Errorf("MRID = %q, want empty (not in desc)", got.MRID)
 	}
 }
+
+func TestMRFieldsQoSRoundTrip(t *testing.T) {
+	issue := &Issue{Description: "branch: polecat/nux/gt-1\nQoS: Interactive"}
+	fields := ParseMRFields(issue)
+	if fields == nil || fields.QoS != "interactive" {
+		t.Fatalf("ParseMRFields QoS = %+v, want interactive", fields)
+	}
+	if got := FormatMRFields(fields); got != "branch: polecat/nux/gt-1\nqos: interactive" {
+		t.Errorf("FormatMRFields = %q", got)
+	}
+}
+
+func TestMRFieldsMergeStrategyRoundTrip(t *testing.T) {
+	issue := &Issue{Description: "branch: polecat/nux/gt-1\nMerge-Strategy: Rebase-FF"}
+	fields := ParseMRFields(issue)
+	if fields == nil || fields.MergeStrategy != "rebase-ff" {
+		t.Fatalf("ParseMRFields MergeStrategy = %+v, want rebase-ff", fields)
+	}
+	if got := FormatMRFields(fields); got != "branch: polecat/nux/gt-1\nmerge_strategy: rebase-ff" {
+		t.Errorf("FormatMRFields = %q", got)
+	}
+}
+
+func TestMRFieldsSplitFromRoundTrip(t *testing.T) {
+	issue := &Issue{Description: "branch: polecat/nux/gt-1-part-2\nsplit_from: gt-mr1\n\nPart 2 of 2 split from gt-mr1."}
+	fields := ParseMRFields(issue)
+	if fields == nil || fields.SplitFrom != "gt-mr1" {
+		t.Fatalf("ParseMRFields SplitFrom = %+v, want gt-mr1", fields)
+	}
+	if got := FormatMRFields(fields); got != "branch: polecat/nux/gt-1-part-2\nsplit_from: gt-mr1" {
+		t.Errorf("FormatMRFields = %q", got)
+	}
+}
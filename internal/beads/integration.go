@@ -62,6 +62,13 @@ func GetBaseBranchField(description string) string {
 	return getMetadataField(description, "base_branch")
 }
 
+// GetDescriptionField extracts a key: value field from an issue
+// description. The key match is case-insensitive. Returns empty string if
+// the field is not found.
+func GetDescriptionField(description, key string) string {
+	return getMetadataField(description, key)
+}
+
 // getMetadataField extracts a key: value field from a description string.
 // The key match is case-insensitive.
 func getMetadataField(description, key string) string {
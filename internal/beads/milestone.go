@@ -0,0 +1,122 @@
+package beads
+
+import (
+	"fmt"
+	"time"
+)
+
+// LabelMilestone marks an issue as a milestone. Work is attached to a
+// milestone by making it a child (bd create --parent <milestone>).
+const LabelMilestone = "gt:milestone"
+
+// MilestoneProgress is the rollup of a milestone's child issues.
+type MilestoneProgress struct {
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Status      string        `json:"status"`
+	Total       int           `json:"total"`
+	Done        int           `json:"done"`
+	InProgress  int           `json:"in_progress"`
+	MRsInFlight int           `json:"mrs_in_flight"`
+	Percent     int           `json:"percent"`
+	CycleTime   time.Duration `json:"cycle_time_ns,omitempty"` // Mean created→closed time of done children
+	ETA         *time.Time    `json:"eta,omitempty"`           // nil until a cycle time is known
+}
+
+// ComputeMilestoneProgress rolls up children into progress for milestone.
+// mrSources is the set of source issues with an open merge request.
+//
+// The ETA assumes the remaining work proceeds at the historical cycle time,
+// with as many issues moving in parallel as are in progress now (at least
+// one). It is nil until at least one child has closed.
+func ComputeMilestoneProgress(milestone *Issue, children []*Issue, mrSources map[string]bool, now time.Time) *MilestoneProgress {
+	p := &MilestoneProgress{ID: milestone.ID, Title: milestone.Title, Status: milestone.Status}
+
+	var cycleTotal time.Duration
+	var cycleCount int
+	for _, child := range children {
+		if HasLabel(child, "gt:merge-request") {
+			continue
+		}
+		p.Total++
+		status := IssueStatus(child.Status)
+		switch {
+		case status.IsTerminal():
+			p.Done++
+			created, err1 := time.Parse(time.RFC3339, child.CreatedAt)
+			closed, err2 := time.Parse(time.RFC3339, child.ClosedAt)
+			if err1 == nil && err2 == nil && closed.After(created) {
+				cycleTotal += closed.Sub(created)
+				cycleCount++
+			}
+		case status == StatusInProgress || child.Status == StatusHooked:
+			p.InProgress++
+		}
+		if mrSources[child.ID] {
+			p.MRsInFlight++
+		}
+	}
+
+	if p.Total > 0 {
+		p.Percent = p.Done * 100 / p.Total
+	}
+	if cycleCount > 0 {
+		p.CycleTime = cycleTotal / time.Duration(cycleCount)
+		remaining := p.Total - p.Done
+		lanes := max(p.InProgress, 1)
+		rounds := (remaining + lanes - 1) / lanes
+		eta := now.Add(time.Duration(rounds) * p.CycleTime)
+		p.ETA = &eta
+	}
+	return p
+}
+
+// ListMilestones returns milestones, open ones only unless all is set.
+func (b *Beads) ListMilestones(all bool) ([]*Issue, error) {
+	status := "open"
+	if all {
+		status = "all"
+	}
+	return b.List(ListOptions{Label: LabelMilestone, Status: status, Priority: -1})
+}
+
+// MilestoneRollup computes progress for each milestone.
+func (b *Beads) MilestoneRollup(milestones []*Issue, now time.Time) ([]*MilestoneProgress, error) {
+	mrSources := b.openMRSources()
+	rollup := make([]*MilestoneProgress, 0, len(milestones))
+	for _, m := range milestones {
+		children, err := b.List(ListOptions{Parent: m.ID, Status: "all", Priority: -1})
+		if err != nil {
+			return nil, fmt.Errorf("listing children of %s: %w", m.ID, err)
+		}
+		rollup = append(rollup, ComputeMilestoneProgress(m, children, mrSources, now))
+	}
+	return rollup, nil
+}
+
+// CreateMilestone creates a new milestone issue.
+func (b *Beads) CreateMilestone(title, description, actor string) (*Issue, error) {
+	return b.Create(CreateOptions{
+		Title:       title,
+		Description: description,
+		Labels:      []string{LabelMilestone},
+		Priority:    2,
+		Actor:       actor,
+	})
+}
+
+// openMRSources returns the source issues of open merge requests. Errors
+// yield an empty set: MR counts are informational.
+func (b *Beads) openMRSources() map[string]bool {
+	sources := make(map[string]bool)
+	mrs, err := b.ListMergeRequests(ListOptions{Status: "open", Label: "gt:merge-request", Priority: -1})
+	if err != nil {
+		return sources
+	}
+	for _, mr := range mrs {
+		if fields := ParseMRFields(mr); fields != nil && fields.SourceIssue != "" {
+			sources[fields.SourceIssue] = true
+		}
+	}
+	return sources
+}
@@ -0,0 +1,45 @@
+package beads
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeMilestoneProgress(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	ts := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+
+	milestone := &Issue{ID: "gt-m1", Title: "v1", Status: "open"}
+	children := []*Issue{
+		{ID: "gt-1", Status: "closed", CreatedAt: ts(-10 * day), ClosedAt: ts(-8 * day)}, // 2d
+		{ID: "gt-2", Status: "closed", CreatedAt: ts(-6 * day), ClosedAt: ts(-2 * day)},  // 4d
+		{ID: "gt-3", Status: "in_progress"},
+		{ID: "gt-4", Status: "hooked"},
+		{ID: "gt-5", Status: "open"},
+		{ID: "gt-6", Status: "open"},
+		{ID: "gt-mr", Status: "open", Labels: []string{"gt:merge-request"}},
+	}
+
+	p := ComputeMilestoneProgress(milestone, children, map[string]bool{"gt-3": true}, now)
+	if p.Total != 6 || p.Done != 2 || p.InProgress != 2 || p.MRsInFlight != 1 {
+		t.Errorf("counts = %+v", p)
+	}
+	if p.Percent != 33 {
+		t.Errorf("Percent = %d, want 33", p.Percent)
+	}
+	if p.CycleTime != 3*day {
+		t.Errorf("CycleTime = %v, want 72h", p.CycleTime)
+	}
+	// 4 remaining over 2 lanes = 2 rounds of 3 days.
+	if p.ETA == nil || !p.ETA.Equal(now.Add(6*day)) {
+		t.Errorf("ETA = %v, want %v", p.ETA, now.Add(6*day))
+	}
+}
+
+func TestComputeMilestoneProgress_NoHistory(t *testing.T) {
+	p := ComputeMilestoneProgress(&Issue{ID: "gt-m"}, []*Issue{{ID: "gt-1", Status: "open"}}, nil, time.Now())
+	if p.ETA != nil || p.Percent != 0 || p.Total != 1 {
+		t.Errorf("expected no ETA and 0%% for fresh milestone, got %+v", p)
+	}
+}
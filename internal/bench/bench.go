@@ -0,0 +1,254 @@
+// Package bench measures how fast the town's key operations run on this
+// machine — creating a worktree, stacking an MR, capturing a tmux pane,
+// querying beads — and compares the results against a stored baseline, so
+// a slowdown after an upgrade can be put in numbers.
+//
+// Each operation runs against scratch state it creates itself (a throwaway
+// repository, an isolated tmux server), except the beads query, which reads
+// the town's own database.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Operation names.
+const (
+	OpWorktree    = "worktree"     // git worktree add + remove
+	OpStack       = "stack"        // Stacking one MR onto a batch
+	OpCapturePane = "capture_pane" // tmux capture-pane of a live session
+	OpBeadsQuery  = "beads_query"  // bd list of open issues
+)
+
+// Operations lists every operation, in the order they run.
+var Operations = []string{OpWorktree, OpStack, OpCapturePane, OpBeadsQuery}
+
+// Options configures a run.
+type Options struct {
+	// Iterations is how many times each operation is timed. Default 5.
+	Iterations int
+
+	// Only restricts the run to these operations. Empty runs them all.
+	Only []string
+
+	// TownRoot is the town whose beads are queried. The beads query is
+	// skipped without one.
+	TownRoot string
+
+	// StackSize is the number of MRs stacked per iteration of the stack
+	// operation. Default 5.
+	StackSize int
+}
+
+// Result is the timing of one operation.
+type Result struct {
+	Name       string        `json:"name"`
+	Iterations int           `json:"iterations,omitempty"`
+	Mean       time.Duration `json:"mean_ns,omitempty"`
+	Min        time.Duration `json:"min_ns,omitempty"`
+	Max        time.Duration `json:"max_ns,omitempty"`
+
+	// Skipped says why the operation didn't run, e.g. tmux isn't installed.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// PerSecond is how many times the operation runs per second at its mean.
+func (r *Result) PerSecond() float64 {
+	if r.Mean <= 0 {
+		return 0
+	}
+	return float64(time.Second) / float64(r.Mean)
+}
+
+// Baseline is a stored set of results to compare later runs against.
+type Baseline struct {
+	RecordedAt time.Time `json:"recorded_at"`
+	Version    string    `json:"version,omitempty"` // gt version that recorded it
+	Results    []*Result `json:"results"`
+}
+
+// Lookup returns the baseline's result for the named operation, or nil.
+func (b *Baseline) Lookup(name string) *Result {
+	for _, r := range b.Results {
+		if r.Name == name && r.Skipped == "" {
+			return r
+		}
+	}
+	return nil
+}
+
+// fixture is the scratch state an operation is timed against.
+type fixture struct {
+	run     func() error // The timed operation
+	reset   func() error // Untimed, before each run but the first; may be nil
+	per     int          // Units run does; the result is per unit. 0 means 1.
+	cleanup func()
+}
+
+// setupFunc prepares an operation's fixture, or returns why the operation
+// can't run here.
+type setupFunc func(ctx context.Context, opts Options) (*fixture, error)
+
+var setups = map[string]setupFunc{
+	OpWorktree:    setupWorktree,
+	OpStack:       setupStack,
+	OpCapturePane: setupCapturePane,
+	OpBeadsQuery:  setupBeadsQuery,
+}
+
+// Run times the selected operations. An operation that can't run here is
+// returned as skipped; an unknown operation name is an error.
+func Run(ctx context.Context, opts Options) ([]*Result, error) {
+	if opts.Iterations <= 0 {
+		opts.Iterations = 5
+	}
+	if opts.StackSize <= 0 {
+		opts.StackSize = 5
+	}
+	names := opts.Only
+	if len(names) == 0 {
+		names = Operations
+	}
+	for _, name := range names {
+		if _, ok := setups[name]; !ok {
+			return nil, fmt.Errorf("unknown operation %q (have %v)", name, Operations)
+		}
+	}
+
+	results := make([]*Result, 0, len(names))
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, runOp(ctx, name, setups[name], opts))
+	}
+	return results, nil
+}
+
+func runOp(ctx context.Context, name string, setup setupFunc, opts Options) *Result {
+	result := &Result{Name: name}
+	f, err := setup(ctx, opts)
+	if err != nil {
+		result.Skipped = err.Error()
+		return result
+	}
+	defer f.cleanup()
+	per := time.Duration(max(f.per, 1))
+
+	times := make([]time.Duration, 0, opts.Iterations)
+	for i := 0; i < opts.Iterations && ctx.Err() == nil; i++ {
+		if i > 0 && f.reset != nil {
+			if err := f.reset(); err != nil {
+				result.Skipped = fmt.Sprintf("resetting for iteration %d: %v", i+1, err)
+				return result
+			}
+		}
+		start := time.Now()
+		if err := f.run(); err != nil {
+			result.Skipped = fmt.Sprintf("iteration %d: %v", i+1, err)
+			return result
+		}
+		times = append(times, time.Since(start)/per)
+	}
+	if len(times) == 0 {
+		result.Skipped = "cancelled"
+		return result
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	var total time.Duration
+	for _, d := range times {
+		total += d
+	}
+	result.Iterations = len(times)
+	result.Mean = total / time.Duration(len(times))
+	result.Min = times[0]
+	result.Max = times[len(times)-1]
+	return result
+}
+
+// LoadBaseline reads the baseline at path, returning nil if none was saved.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// SaveBaseline stores results as the baseline at path. Operations skipped
+// this run keep their previous baseline, if any.
+func SaveBaseline(path, version string, results []*Result) (*Baseline, error) {
+	prev, err := LoadBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &Baseline{RecordedAt: time.Now().UTC(), Version: version}
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if r.Skipped != "" {
+			continue
+		}
+		b.Results = append(b.Results, r)
+		seen[r.Name] = true
+	}
+	if prev != nil {
+		for _, r := range prev.Results {
+			if !seen[r.Name] && r.Skipped == "" {
+				b.Results = append(b.Results, r)
+			}
+		}
+	}
+	if err := util.EnsureDirAndWriteJSON(path, b); err != nil {
+		return nil, fmt.Errorf("saving baseline: %w", err)
+	}
+	return b, nil
+}
+
+// Change compares a result with its baseline.
+type Change struct {
+	Name      string        `json:"name"`
+	Baseline  time.Duration `json:"baseline_ns"`
+	Current   time.Duration `json:"current_ns"`
+	Percent   float64       `json:"percent"`   // Positive is slower
+	Regressed bool          `json:"regressed"` // Slower by more than the threshold
+}
+
+// Compare compares each result that ran with the baseline's. A result more
+// than threshold percent slower than its baseline has regressed. Results
+// without a baseline are left out.
+func Compare(b *Baseline, results []*Result, threshold float64) []*Change {
+	if b == nil {
+		return nil
+	}
+	var changes []*Change
+	for _, r := range results {
+		base := b.Lookup(r.Name)
+		if r.Skipped != "" || base == nil || base.Mean <= 0 {
+			continue
+		}
+		pct := (float64(r.Mean) - float64(base.Mean)) / float64(base.Mean) * 100
+		changes = append(changes, &Change{
+			Name:      r.Name,
+			Baseline:  base.Mean,
+			Current:   r.Mean,
+			Percent:   pct,
+			Regressed: pct > threshold,
+		})
+	}
+	return changes
+}
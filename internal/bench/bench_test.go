@@ -0,0 +1,93 @@
+package bench
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun_WorktreeAndStack(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	results, err := Run(context.Background(), Options{Iterations: 2, StackSize: 3, Only: []string{OpWorktree, OpStack}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if r.Skipped != "" {
+			t.Errorf("%s skipped: %s", r.Name, r.Skipped)
+			continue
+		}
+		if r.Iterations != 2 || r.Mean <= 0 || r.Min > r.Mean || r.Max < r.Mean {
+			t.Errorf("%s = %+v, want 2 timed iterations", r.Name, r)
+		}
+	}
+}
+
+func TestRun_UnknownOperation(t *testing.T) {
+	if _, err := Run(context.Background(), Options{Only: []string{"nope"}}); err == nil {
+		t.Fatal("Run accepted an unknown operation")
+	}
+}
+
+func TestRun_BeadsQuerySkippedOutsideTown(t *testing.T) {
+	results, err := Run(context.Background(), Options{Only: []string{OpBeadsQuery}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if results[0].Skipped == "" {
+		t.Errorf("beads query ran without a town: %+v", results[0])
+	}
+}
+
+func TestBaseline_SaveKeepsSkippedAndCompares(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench-baseline.json")
+	if _, err := SaveBaseline(path, "1.0", []*Result{
+		{Name: OpWorktree, Iterations: 5, Mean: 100 * time.Millisecond},
+		{Name: OpCapturePane, Iterations: 5, Mean: 10 * time.Millisecond},
+	}); err != nil {
+		t.Fatalf("SaveBaseline: %v", err)
+	}
+	// tmux is missing this time: the old capture_pane baseline survives.
+	if _, err := SaveBaseline(path, "1.1", []*Result{
+		{Name: OpWorktree, Iterations: 5, Mean: 80 * time.Millisecond},
+		{Name: OpCapturePane, Skipped: "tmux not installed"},
+	}); err != nil {
+		t.Fatalf("SaveBaseline: %v", err)
+	}
+	b, err := LoadBaseline(path)
+	if err != nil || b == nil {
+		t.Fatalf("LoadBaseline = %v, %v", b, err)
+	}
+	if b.Version != "1.1" || b.Lookup(OpWorktree).Mean != 80*time.Millisecond || b.Lookup(OpCapturePane) == nil {
+		t.Fatalf("baseline = %+v", b)
+	}
+
+	changes := Compare(b, []*Result{
+		{Name: OpWorktree, Mean: 120 * time.Millisecond},
+		{Name: OpCapturePane, Mean: 10500 * time.Microsecond},
+		{Name: OpStack, Mean: time.Second}, // No baseline
+	}, 20)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2", len(changes))
+	}
+	if c := changes[0]; c.Name != OpWorktree || !c.Regressed || c.Percent != 50 {
+		t.Errorf("worktree change = %+v, want a 50%% regression", c)
+	}
+	if c := changes[1]; c.Name != OpCapturePane || c.Regressed {
+		t.Errorf("capture_pane change = %+v, want within threshold", c)
+	}
+}
+
+func TestLoadBaseline_Missing(t *testing.T) {
+	b, err := LoadBaseline(filepath.Join(t.TempDir(), "none.json"))
+	if b != nil || err != nil {
+		t.Errorf("LoadBaseline = %v, %v; want nil, nil", b, err)
+	}
+}
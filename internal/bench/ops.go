@@ -0,0 +1,231 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// scratchFiles is how many files the scratch repository holds, so checkouts
+// do some real work.
+const scratchFiles = 200
+
+// setupWorktree times creating a worktree on a new branch, as a polecat
+// spawn does. The previous iteration's worktree is removed untimed.
+func setupWorktree(_ context.Context, _ Options) (*fixture, error) {
+	dir, err := os.MkdirTemp("", "gt-bench-worktree-")
+	if err != nil {
+		return nil, err
+	}
+	repo := filepath.Join(dir, "repo")
+	if err := initScratchRepo(repo); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	g := git.NewGit(repo)
+	n := 0
+	path := func() string { return filepath.Join(dir, fmt.Sprintf("wt-%d", n)) }
+	branch := func() string { return fmt.Sprintf("bench/%d", n) }
+	return &fixture{
+		run: func() error {
+			return g.WorktreeAddFromRef(path(), branch(), "main")
+		},
+		reset: func() error {
+			if err := g.WorktreeRemove(path(), true); err != nil {
+				return err
+			}
+			if err := g.DeleteBranch(branch(), true); err != nil {
+				return err
+			}
+			n++
+			return nil
+		},
+		cleanup: func() { _ = os.RemoveAll(dir) },
+	}, nil
+}
+
+// setupStack times the refinery stacking a batch of StackSize MRs onto main
+// in a scratch rig, reporting the time per MR. The stack is discarded
+// untimed between iterations.
+func setupStack(ctx context.Context, opts Options) (*fixture, error) {
+	dir, err := os.MkdirTemp("", "gt-bench-stack-")
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*fixture, error) {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	origin := filepath.Join(dir, "origin.git")
+	seed := filepath.Join(dir, "seed")
+	rigPath := filepath.Join(dir, "benchrig")
+	work := filepath.Join(rigPath, "refinery", "rig")
+	if err := gitIn(dir, "init", "-q", "--bare", "--initial-branch=main", origin); err != nil {
+		return fail(err)
+	}
+	if err := initScratchRepo(seed); err != nil {
+		return fail(err)
+	}
+	if err := gitIn(seed, "remote", "add", "origin", origin); err != nil {
+		return fail(err)
+	}
+	if err := gitIn(seed, "push", "-q", "-u", "origin", "main"); err != nil {
+		return fail(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(work), 0755); err != nil {
+		return fail(err)
+	}
+	if err := gitIn(dir, "clone", "-q", origin, work); err != nil {
+		return fail(err)
+	}
+	if err := configureScratchUser(work); err != nil {
+		return fail(err)
+	}
+
+	mrs := make([]*refinery.MRInfo, opts.StackSize)
+	for i := range mrs {
+		branch := fmt.Sprintf("polecat/bench-%d", i)
+		if err := gitIn(work, "checkout", "-q", "-b", branch, "main"); err != nil {
+			return fail(err)
+		}
+		name := fmt.Sprintf("bench-%d.txt", i)
+		if err := os.WriteFile(filepath.Join(work, name), []byte(name+"\n"), 0644); err != nil {
+			return fail(err)
+		}
+		if err := gitIn(work, "add", name); err != nil {
+			return fail(err)
+		}
+		if err := gitIn(work, "commit", "-q", "-m", "add "+name); err != nil {
+			return fail(err)
+		}
+		mrs[i] = &refinery.MRInfo{ID: fmt.Sprintf("bench-mr-%d", i), Branch: branch, Target: "main", Title: "add " + name}
+	}
+	if err := gitIn(work, "checkout", "-q", "main"); err != nil {
+		return fail(err)
+	}
+
+	e := refinery.NewEngineer(&rig.Rig{Name: "benchrig", Path: rigPath})
+	e.SetOutput(io.Discard)
+	e.UseLocalMergeSlot()
+	g := git.NewGit(work)
+	return &fixture{
+		run: func() error {
+			stacked, conflicts, err := e.BuildRebaseStack(ctx, mrs, "main")
+			if err != nil {
+				return err
+			}
+			if len(conflicts) > 0 || len(stacked) != len(mrs) {
+				return fmt.Errorf("stacked %d of %d MRs", len(stacked), len(mrs))
+			}
+			return nil
+		},
+		reset: func() error {
+			return g.ResetHard("origin/main")
+		},
+		per:     len(mrs),
+		cleanup: func() { _ = os.RemoveAll(dir) },
+	}, nil
+}
+
+// setupCapturePane times capturing the visible pane of a session, as the
+// witness and nudges do, on an isolated tmux server.
+func setupCapturePane(_ context.Context, _ Options) (*fixture, error) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return nil, errors.New("tmux not installed")
+	}
+	t := tmux.NewTmuxWithSocket(fmt.Sprintf("gt-bench-%d", os.Getpid()))
+	session := "gt-bench"
+	if err := t.NewSessionWithCommand(session, os.TempDir(), "sh -c 'seq 1 500; exec sleep 3600'"); err != nil {
+		_ = t.KillServer()
+		return nil, fmt.Errorf("starting tmux session: %w", err)
+	}
+	return &fixture{
+		run: func() error {
+			_, err := t.CapturePane(session, 100)
+			return err
+		},
+		cleanup: func() { _ = t.KillServer() },
+	}, nil
+}
+
+// setupBeadsQuery times listing the town's open issues, the query behind
+// most status displays.
+func setupBeadsQuery(_ context.Context, opts Options) (*fixture, error) {
+	if opts.TownRoot == "" {
+		return nil, errors.New("not in a Gas Town workspace")
+	}
+	if _, err := exec.LookPath("bd"); err != nil {
+		return nil, errors.New("bd not installed")
+	}
+	b := beads.New(opts.TownRoot)
+	return &fixture{
+		run: func() error {
+			_, err := b.List(beads.ListOptions{Status: "open", Priority: -1, Limit: 50})
+			return err
+		},
+		cleanup: func() {},
+	}, nil
+}
+
+// initScratchRepo creates a repository at dir with scratchFiles files
+// committed on main.
+func initScratchRepo(dir string) error {
+	if err := gitIn(filepath.Dir(dir), "init", "-q", "--initial-branch=main", dir); err != nil {
+		return err
+	}
+	if err := configureScratchUser(dir); err != nil {
+		return err
+	}
+	for i := 0; i < scratchFiles; i++ {
+		name := filepath.Join(dir, "src", fmt.Sprintf("file%03d.go", i))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		content := fmt.Sprintf("package src\n\nconst File%03d = %d\n", i, i)
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	if err := gitIn(dir, "add", "."); err != nil {
+		return err
+	}
+	return gitIn(dir, "commit", "-q", "-m", "scratch")
+}
+
+// configureScratchUser sets the identity scratch commits are made with, and
+// turns off signing, which the refinery's own commits would otherwise pick
+// up from the user's config.
+func configureScratchUser(dir string) error {
+	for _, kv := range [][2]string{
+		{"user.name", "gt bench"},
+		{"user.email", "bench@gastown.invalid"},
+		{"commit.gpgsign", "false"},
+	} {
+		if err := gitIn(dir, "config", kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gitIn(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Scratch repos must not pick up the user's hooks or signing config.
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL="+os.DevNull, "GIT_CONFIG_NOSYSTEM=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -38,7 +38,7 @@ type Boot struct {
 	townRoot   string
 	bootDir    string // ~/gt/deacon/dogs/boot/
 	deaconDir  string // ~/gt/deacon/
-	tmux       *tmux.Tmux
+	mux        tmux.Multiplexer
 	degraded   bool
 	lockHandle *flock.Flock // held during triage execution
 }
@@ -49,7 +49,7 @@ func New(townRoot string) *Boot {
 		townRoot:  townRoot,
 		bootDir:   filepath.Join(townRoot, "deacon", "dogs", "boot"),
 		deaconDir: filepath.Join(townRoot, "deacon"),
-		tmux:      tmux.NewTmux(),
+		mux:       tmux.Selected(),
 		degraded:  os.Getenv("GT_DEGRADED") == "true",
 	}
 }
@@ -77,7 +77,7 @@ func (b *Boot) IsRunning() bool {
 
 // IsSessionAlive checks if the Boot tmux session exists.
 func (b *Boot) IsSessionAlive() bool {
-	has, err := b.tmux.HasSession(session.BootSessionName())
+	has, err := b.mux.HasSession(session.BootSessionName())
 	return err == nil && has
 }
 
@@ -167,9 +167,14 @@ func (b *Boot) Spawn(agentOverride string) error {
 
 // spawnTmux spawns Boot in a tmux session.
 func (b *Boot) spawnTmux(agentOverride string) error {
+	t, ok := b.mux.(*tmux.Tmux)
+	if !ok {
+		return tmux.NeedsTmux("spawning Boot")
+	}
+
 	// Kill any stale session first (Boot is ephemeral).
 	if b.IsSessionAlive() {
-		_ = b.tmux.KillSessionWithProcesses(session.BootSessionName())
+		_ = t.KillSessionWithProcesses(session.BootSessionName())
 	}
 
 	// Ensure boot directory exists (it should have CLAUDE.md with Boot context)
@@ -178,7 +183,7 @@ func (b *Boot) spawnTmux(agentOverride string) error {
 	}
 
 	// Use unified session lifecycle for config → settings → command → create → env.
-	_, err := session.StartSession(b.tmux, session.SessionConfig{
+	_, err := session.StartSession(t, session.SessionConfig{
 		SessionID: session.BootSessionName(),
 		WorkDir:   b.bootDir,
 		Role:      "boot",
@@ -229,7 +234,7 @@ func (b *Boot) DeaconDir() string {
 	return b.deaconDir
 }
 
-// Tmux returns the tmux manager.
-func (b *Boot) Tmux() *tmux.Tmux {
-	return b.tmux
+// Multiplexer returns the multiplexer Boot's sessions run on.
+func (b *Boot) Multiplexer() tmux.Multiplexer {
+	return b.mux
 }
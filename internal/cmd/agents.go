@@ -183,7 +183,10 @@ func categorizeSession(name string) *AgentSession {
 
 // getAgentSessions returns all categorized Gas Town sessions from the town socket.
 func getAgentSessions(includePolecats bool) ([]*AgentSession, error) {
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return nil, err
+	}
 	sessions, err := t.ListSessions()
 	if err != nil {
 		return nil, err
@@ -443,6 +446,10 @@ func shortcutKey(index int) string {
 }
 
 func runAgents(cmd *cobra.Command, args []string) error {
+	// The menu is a tmux display-menu over tmux sockets.
+	if _, err := tmux.RequireTmux("the agents menu"); err != nil {
+		return err
+	}
 	groups := getAllSocketSessions(agentsAllFlag)
 
 	// Count total sessions across all groups
@@ -687,7 +694,10 @@ func buildCollisionReport(townRoot string) (*CollisionReport, error) {
 	}
 
 	// Get all tmux sessions
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return nil, err
+	}
 	sessions, err := t.ListSessions()
 	if err != nil {
 		sessions = []string{} // Continue even if tmux not running
@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/daemon"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/townspec"
+	"github.com/steveyegge/gastown/internal/util"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	applyDryRun    bool
+	applyReconcile bool
+)
+
+var applyCmd = &cobra.Command{
+	Use:     "apply [town.yaml]",
+	GroupID: GroupWorkspace,
+	Short:   "Reconcile the town toward a declarative description",
+	Long: `Apply a declarative town description.
+
+The file declares the rigs the town should have, which agents run in each,
+merge queue gates per rig, daemon patrols, and scheduler budgets:
+
+  apiVersion: gastown/v1
+  kind: Town
+  rigs:
+    - name: gastown
+      git_url: https://github.com/steveyegge/gastown
+      prefix: gt
+      agents:
+        witness: true        # default
+        refinery: true       # default
+        crew: [max, joe]     # sessions kept running
+      gates:
+        test: {cmd: "go test ./...", timeout: 10m}
+  patrols:
+    deacon: {enabled: true, interval: 5m}
+  budgets:
+    max_polecats: 6
+    batch_size: 2
+
+gt apply computes the difference between the declaration and the town,
+prints the plan, and carries it out: missing rigs and crew are created,
+declared crew are started, and agents the file no longer declares are
+stopped. Rigs and workspaces are never deleted.
+
+The applied file is stored in settings/town.yaml. While it exists the
+daemon re-applies it every heartbeat (gt apply --reconcile), so the town
+converges back to the declaration after drift.
+
+Examples:
+  gt apply town.yaml --dry-run   # Show the plan only
+  gt apply town.yaml             # Apply and keep reconciling
+  gt apply --reconcile           # Re-apply the stored declaration`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	applyCmd.Flags().BoolVarP(&applyDryRun, "dry-run", "n", false, "Show the plan without changing anything")
+	applyCmd.Flags().BoolVar(&applyReconcile, "reconcile", false, "Re-apply the stored declaration (used by the daemon)")
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	var spec *townspec.Spec
+	switch {
+	case len(args) == 1:
+		spec, err = townspec.Load(args[0])
+		if err != nil {
+			return err
+		}
+	case applyReconcile:
+		spec, err = townspec.Load(townspec.Path(townRoot))
+		if os.IsNotExist(err) {
+			return nil // Nothing declared, nothing to reconcile
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("specify a town file, or --reconcile to re-apply %s", townspec.Path(townRoot))
+	}
+
+	actual, err := observeTown(townRoot)
+	if err != nil {
+		return fmt.Errorf("observing town: %w", err)
+	}
+	actions := townspec.Plan(spec, actual)
+
+	if !applyDryRun && len(args) == 1 {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(townspec.Path(townRoot)), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(townspec.Path(townRoot), data, 0644); err != nil { //nolint:gosec // G306: not sensitive
+			return fmt.Errorf("storing declaration: %w", err)
+		}
+	}
+
+	if len(actions) == 0 {
+		if !applyReconcile {
+			fmt.Printf("%s Town matches the declaration\n", style.Bold.Render("✓"))
+		}
+		return nil
+	}
+
+	if applyDryRun {
+		fmt.Printf("Plan (%d action(s)):\n", len(actions))
+		for _, a := range actions {
+			fmt.Printf("  %s\n", a)
+		}
+		return nil
+	}
+
+	var failed int
+	for _, a := range actions {
+		if err := executeApplyAction(townRoot, a); err != nil {
+			failed++
+			style.PrintWarning("%s: %v", a, err)
+			continue
+		}
+		fmt.Printf("%s %s\n", style.Bold.Render("✓"), a)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d action(s) failed", failed, len(actions))
+	}
+	return nil
+}
+
+// observeTown collects the parts of town state a declaration covers.
+func observeTown(townRoot string) (*townspec.State, error) {
+	state := &townspec.State{
+		Rigs:    make(map[string]*townspec.RigState),
+		Patrols: make(map[string]townspec.PatrolState),
+	}
+
+	rigsCfg, err := config.LoadRigsConfig(constants.MayorRigsPath(townRoot))
+	if err != nil && !errors.Is(err, config.ErrNotFound) {
+		return nil, err
+	}
+	if rigsCfg != nil {
+		for name := range rigsCfg.Rigs {
+			rigPath := filepath.Join(townRoot, name)
+			gates, err := readRigGates(rigPath)
+			if err != nil {
+				return nil, err
+			}
+			rs := &townspec.RigState{Gates: gates}
+			if entries, err := os.ReadDir(filepath.Join(rigPath, "crew")); err == nil {
+				for _, e := range entries {
+					if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+						rs.Crew = append(rs.Crew, e.Name())
+					}
+				}
+			}
+			state.Rigs[name] = rs
+		}
+	}
+
+	if sessions, err := getAgentSessions(false); err == nil {
+		for _, s := range sessions {
+			rs := state.Rigs[s.Rig]
+			if rs == nil {
+				continue
+			}
+			switch s.Type {
+			case AgentCrew:
+				rs.RunningCrew = append(rs.RunningCrew, s.AgentName)
+			case AgentWitness:
+				rs.WitnessRunning = true
+			case AgentRefinery:
+				rs.RefineryRunning = true
+			}
+		}
+	}
+
+	if pc := daemon.LoadPatrolConfig(townRoot); pc != nil && pc.Patrols != nil {
+		for name, p := range map[string]*daemon.PatrolConfig{
+			"deacon":   pc.Patrols.Deacon,
+			"witness":  pc.Patrols.Witness,
+			"refinery": pc.Patrols.Refinery,
+			"handler":  pc.Patrols.Handler,
+		} {
+			if p != nil {
+				state.Patrols[name] = townspec.PatrolState{Enabled: p.Enabled, Interval: p.Interval, Rigs: p.Rigs}
+			}
+		}
+	}
+
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil {
+		return nil, err
+	}
+	if settings.Scheduler != nil {
+		state.MaxPolecats = settings.Scheduler.MaxPolecats
+		state.BatchSize = settings.Scheduler.BatchSize
+	}
+	return state, nil
+}
+
+// readRigGates reads merge_queue.gates from a rig's config.json.
+func readRigGates(rigPath string) (map[string]townspec.GateSpec, error) {
+	raw, err := readRigConfigRaw(rigPath)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var mq struct {
+		Gates map[string]townspec.GateSpec `json:"gates"`
+	}
+	if data, ok := raw["merge_queue"]; ok {
+		if err := json.Unmarshal(data, &mq); err != nil {
+			return nil, fmt.Errorf("parsing %s merge_queue: %w", rigPath, err)
+		}
+	}
+	return mq.Gates, nil
+}
+
+// readRigConfigRaw reads a rig's config.json keeping unknown keys intact.
+func readRigConfigRaw(rigPath string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(filepath.Join(rigPath, "config.json")) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s/config.json: %w", rigPath, err)
+	}
+	return raw, nil
+}
+
+// executeApplyAction performs one reconcile step. Agent lifecycle steps run
+// the corresponding gt command so they behave exactly as when run by hand.
+func executeApplyAction(townRoot string, a townspec.Action) error {
+	switch a.Kind {
+	case townspec.ActionAddRig:
+		args := []string{"rig", "add", a.Rig, a.GitURL}
+		if a.Prefix != "" {
+			args = append(args, "--prefix", a.Prefix)
+		}
+		return runGtSubcommand(townRoot, args...)
+	case townspec.ActionAddCrew:
+		return runGtSubcommand(townRoot, "crew", "add", a.Name, "--rig", a.Rig)
+	case townspec.ActionStartCrew:
+		return runGtSubcommand(townRoot, "crew", "start", a.Rig, a.Name)
+	case townspec.ActionStopCrew:
+		return runGtSubcommand(townRoot, "crew", "stop", a.Rig+"/"+a.Name)
+	case townspec.ActionStopWitness:
+		return runGtSubcommand(townRoot, "witness", "stop", a.Rig)
+	case townspec.ActionStopRefinery:
+		return runGtSubcommand(townRoot, "refinery", "stop", a.Rig)
+	case townspec.ActionSetGates:
+		return writeRigGates(filepath.Join(townRoot, a.Rig), a.Gates)
+	case townspec.ActionSetPatrol:
+		return writePatrol(townRoot, a.Name, a.Patrol)
+	case townspec.ActionSetBudget:
+		return writeBudget(townRoot, a.Budget)
+	}
+	return fmt.Errorf("unknown action %q", a.Kind)
+}
+
+func runGtSubcommand(townRoot string, args ...string) error {
+	gtPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	c := exec.Command(gtPath, args...) //nolint:gosec // G204: args are validated spec fields
+	c.Dir = townRoot
+	out, err := c.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gt %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func writeRigGates(rigPath string, gates map[string]townspec.GateSpec) error {
+	raw, err := readRigConfigRaw(rigPath)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return fmt.Errorf("%s/config.json not found", rigPath)
+	}
+	mq := make(map[string]json.RawMessage)
+	if data, ok := raw["merge_queue"]; ok {
+		if err := json.Unmarshal(data, &mq); err != nil {
+			return fmt.Errorf("parsing merge_queue: %w", err)
+		}
+	}
+	if len(gates) == 0 {
+		delete(mq, "gates")
+	} else {
+		data, err := json.Marshal(gates)
+		if err != nil {
+			return err
+		}
+		mq["gates"] = data
+	}
+	data, err := json.Marshal(mq)
+	if err != nil {
+		return err
+	}
+	raw["merge_queue"] = data
+	return util.AtomicWriteJSON(filepath.Join(rigPath, "config.json"), raw)
+}
+
+func writePatrol(townRoot, name string, p *townspec.PatrolState) error {
+	pc := daemon.LoadPatrolConfig(townRoot)
+	if pc == nil {
+		pc = &daemon.DaemonPatrolConfig{Type: "daemon-patrol-config", Version: 1}
+	}
+	if pc.Patrols == nil {
+		pc.Patrols = &daemon.PatrolsConfig{}
+	}
+	slot := map[string]**daemon.PatrolConfig{
+		"deacon":   &pc.Patrols.Deacon,
+		"witness":  &pc.Patrols.Witness,
+		"refinery": &pc.Patrols.Refinery,
+		"handler":  &pc.Patrols.Handler,
+	}[name]
+	if slot == nil {
+		return fmt.Errorf("unknown patrol %q", name)
+	}
+	if *slot == nil {
+		*slot = &daemon.PatrolConfig{}
+	}
+	(*slot).Enabled = p.Enabled
+	(*slot).Interval = p.Interval
+	(*slot).Rigs = p.Rigs
+	return daemon.SavePatrolConfig(townRoot, pc)
+}
+
+func writeBudget(townRoot string, b *townspec.BudgetSpec) error {
+	path := config.TownSettingsPath(townRoot)
+	settings, err := config.LoadOrCreateTownSettings(path)
+	if err != nil {
+		return err
+	}
+	if settings.Scheduler == nil {
+		settings.Scheduler = capacity.DefaultSchedulerConfig()
+	}
+	if b.MaxPolecats != nil {
+		settings.Scheduler.MaxPolecats = b.MaxPolecats
+	}
+	if b.BatchSize != nil {
+		settings.Scheduler.BatchSize = b.BatchSize
+	}
+	return config.SaveTownSettings(path, settings)
+}
@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/approval"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/desktop"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// approvalCaptureLines is how much pane history is searched for a plan.
+const approvalCaptureLines = 400
+
+var (
+	approvalNote     string
+	approvalAll      bool
+	approvalJSON     bool
+	approvalNoNotify bool
+)
+
+var approvalCmd = &cobra.Command{
+	Use:     "approval",
+	GroupID: GroupComm,
+	Short:   "Relay agent plans for human approval",
+	RunE:    requireSubcommand,
+	Long: `Relay agent plans that need human sign-off and type the decision back.
+
+An agent requests approval by printing its plan between the markers
+
+  === PLAN FOR APPROVAL ===
+  ...
+  === END PLAN ===
+
+and waiting. 'gt approval request' (or 'gt approval scan' across all agent
+sessions) captures the plan from the pane, records a pending request and
+notifies the channels in settings/escalation.json approval_route (default:
+mail:overseer; "slack" posts the plan with Approve/Reject buttons).
+
+Approving or rejecting types the decision into the agent's session.
+
+Examples:
+  gt approval request gastown/polecats/nux
+  gt approval scan
+  gt approval list
+  gt approval approve ap-1a2b3c4d -m "ship it"
+  gt approval reject ap-1a2b3c4d -m "split the migration out first"`,
+}
+
+var approvalRequestCmd = &cobra.Command{
+	Use:   "request <address|session>",
+	Short: "Relay the plan shown in an agent's pane",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runApprovalRequest,
+}
+
+var approvalScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Relay plans from every agent session",
+	Args:  cobra.NoArgs,
+	RunE:  runApprovalScan,
+}
+
+var approvalListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List approval requests",
+	Args:  cobra.NoArgs,
+	RunE:  runApprovalList,
+}
+
+var approvalShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a plan awaiting approval",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runApprovalShow,
+}
+
+var approvalApproveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a plan and tell the agent to proceed",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApprovalDecide(args[0], true)
+	},
+}
+
+var approvalRejectCmd = &cobra.Command{
+	Use:   "reject <id>",
+	Short: "Reject a plan and tell the agent to revise it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApprovalDecide(args[0], false)
+	},
+}
+
+func init() {
+	approvalRequestCmd.Flags().BoolVar(&approvalNoNotify, "no-notify", false, "Record the request without notifying")
+	approvalScanCmd.Flags().BoolVar(&approvalNoNotify, "no-notify", false, "Record requests without notifying")
+	approvalListCmd.Flags().BoolVar(&approvalAll, "all", false, "Include decided requests")
+	approvalListCmd.Flags().BoolVar(&approvalJSON, "json", false, "Output as JSON")
+	approvalShowCmd.Flags().BoolVar(&approvalJSON, "json", false, "Output as JSON")
+	approvalApproveCmd.Flags().StringVarP(&approvalNote, "message", "m", "", "Note passed to the agent")
+	approvalRejectCmd.Flags().StringVarP(&approvalNote, "message", "m", "", "Reason passed to the agent")
+
+	approvalCmd.AddCommand(approvalRequestCmd)
+	approvalCmd.AddCommand(approvalScanCmd)
+	approvalCmd.AddCommand(approvalListCmd)
+	approvalCmd.AddCommand(approvalShowCmd)
+	approvalCmd.AddCommand(approvalApproveCmd)
+	approvalCmd.AddCommand(approvalRejectCmd)
+	rootCmd.AddCommand(approvalCmd)
+}
+
+// resolveApprovalTarget maps an agent address or session name to a session
+// name and, when known, the agent address.
+func resolveApprovalTarget(target string) (sessionName, agent string) {
+	if strings.Contains(target, "/") || target == "mayor" || target == "deacon" {
+		if id, err := session.ParseAddress(target); err == nil {
+			return id.SessionName(), id.Address()
+		}
+	}
+	if id, err := session.ParseSessionName(target); err == nil {
+		return target, id.Address()
+	}
+	return target, ""
+}
+
+// relayPlan captures a session's pane and records the plan shown there.
+// It returns nil when the pane shows no plan.
+func relayPlan(townRoot string, t tmux.Multiplexer, sessionName, agent string) (*approval.Request, bool, error) {
+	pane, err := t.CapturePane(sessionName, approvalCaptureLines)
+	if err != nil {
+		return nil, false, fmt.Errorf("capturing %s: %w", sessionName, err)
+	}
+	plan, ok := approval.ExtractPlan(pane)
+	if !ok {
+		return nil, false, nil
+	}
+	r, created, err := approval.NewStore(townRoot).Open(sessionName, agent, plan, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	if created && !approvalNoNotify {
+		notifyApproval(townRoot, r)
+	}
+	return r, created, nil
+}
+
+func runApprovalRequest(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	sessionName, agent := resolveApprovalTarget(args[0])
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
+	r, created, err := relayPlan(townRoot, t, sessionName, agent)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return fmt.Errorf("no plan found in %s (expected %q ... %q)", sessionName, approval.PlanStartMarker, approval.PlanEndMarker)
+	}
+	if created {
+		fmt.Printf("%s Approval requested: %s (%s)\n", style.Bold.Render("✓"), r.ID, sessionName)
+	} else {
+		fmt.Printf("Approval request %s already exists (%s)\n", r.ID, r.Status)
+	}
+	return nil
+}
+
+func runApprovalScan(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
+	sessions, err := t.ListSessions()
+	if err != nil {
+		return fmt.Errorf("listing sessions: %w", err)
+	}
+	found := 0
+	for _, s := range sessions {
+		if !session.IsKnownSession(s) {
+			continue
+		}
+		_, agent := resolveApprovalTarget(s)
+		r, created, err := relayPlan(townRoot, t, s, agent)
+		if err != nil {
+			style.PrintWarning("%v", err)
+			continue
+		}
+		if created {
+			found++
+			fmt.Printf("%s Approval requested: %s (%s)\n", style.Bold.Render("✓"), r.ID, s)
+		}
+	}
+	if found == 0 {
+		fmt.Println("No new plans awaiting approval")
+	}
+	return nil
+}
+
+func runApprovalList(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	status := approval.StatusPending
+	if approvalAll {
+		status = ""
+	}
+	requests, err := approval.NewStore(townRoot).List(status)
+	if err != nil {
+		return err
+	}
+
+	if approvalJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(requests)
+	}
+	if len(requests) == 0 {
+		fmt.Println("No approval requests")
+		return nil
+	}
+	for _, r := range requests {
+		firstLine, _, _ := strings.Cut(r.Plan, "\n")
+		fmt.Printf("%s  %-9s %-20s %s\n", style.Bold.Render(r.ID), r.Status, r.Session, style.Dim.Render(firstLine))
+	}
+	return nil
+}
+
+func runApprovalShow(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	r, err := approval.NewStore(townRoot).Get(args[0])
+	if err != nil {
+		return err
+	}
+	if approvalJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	fmt.Printf("%s  %s  %s\n\n%s\n", style.Bold.Render(r.ID), r.Status, r.Session, r.Plan)
+	if r.DecidedBy != "" {
+		fmt.Printf("\nDecided by %s at %s", r.DecidedBy, r.DecidedAt.Local().Format(time.RFC3339))
+		if r.Note != "" {
+			fmt.Printf(": %s", r.Note)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func runApprovalDecide(id string, approve bool) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	r, err := decideApproval(townRoot, id, approve, detectSender(), approvalNote)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s %s; decision typed into %s\n", style.Bold.Render("✓"), r.ID, r.Status, r.Session)
+	return nil
+}
+
+// decideApproval records a decision and types it into the agent's session.
+// Shared by the CLI and the dashboard's Slack action endpoint.
+func decideApproval(townRoot, id string, approve bool, by, note string) (*approval.Request, error) {
+	store := approval.NewStore(townRoot)
+	r, err := store.Decide(id, approve, by, note, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Deliver(r, nudgeSender{tmux.Selected()}); err != nil {
+		return r, fmt.Errorf("%s recorded as %s, but %w", r.ID, r.Status, err)
+	}
+	return r, nil
+}
+
+// nudgeSender delivers decisions through the selected multiplexer.
+type nudgeSender struct {
+	mux tmux.Multiplexer
+}
+
+func (s nudgeSender) NudgeSession(session, message string) error {
+	return tmux.Nudge(s.mux, session, message)
+}
+
+// notifyApproval sends a new request to the configured approval route.
+// Failures are warnings: the request is recorded either way.
+func notifyApproval(townRoot string, r *approval.Request) {
+	cfg, err := config.LoadOrCreateEscalationConfig(config.EscalationConfigPath(townRoot))
+	if err != nil {
+		style.PrintWarning("loading escalation config: %v", err)
+		return
+	}
+
+	router := mail.NewRouter(townRoot)
+	defer router.WaitPendingNotifications()
+	for _, action := range cfg.GetApprovalRoute() {
+		switch {
+		case strings.HasPrefix(action, "mail:"):
+			msg := &mail.Message{
+				From:     "gt-approval",
+				To:       strings.TrimPrefix(action, "mail:"),
+				Subject:  fmt.Sprintf("Plan approval requested: %s (%s)", r.ID, r.Session),
+				Body:     fmt.Sprintf("%s\n\nApprove: gt approval approve %s\nReject:  gt approval reject %s -m <reason>\n", r.Plan, r.ID, r.ID),
+				Type:     mail.TypeTask,
+				Priority: mail.PriorityHigh,
+			}
+			if err := router.Send(msg); err != nil {
+				style.PrintWarning("failed to send approval request to %s: %v", msg.To, err)
+			}
+		case action == "slack":
+			if cfg.Contacts.SlackWebhook == "" {
+				style.PrintWarning("slack approval skipped: contacts.slack_webhook not configured in settings/escalation.json")
+				continue
+			}
+			if err := postApprovalSlack(cfg.Contacts.SlackWebhook, r); err != nil {
+				style.PrintWarning("slack post failed: %v", err)
+			}
+		}
+	}
+	if cfg.DesktopNotify(config.DesktopEventApproval) {
+		n := desktop.Notification{
+			Title: "Plan approval requested: " + r.Session,
+			Body:  fmt.Sprintf("gt approval approve %s", r.ID),
+		}
+		if err := desktop.Notify(n); err != nil {
+			style.PrintWarning("desktop notification failed: %v", err)
+		}
+	}
+}
+
+// postApprovalSlack posts the plan with approve/reject buttons to Slack.
+func postApprovalSlack(webhook string, r *approval.Request) error {
+	body, err := json.Marshal(approval.SlackMessage(r))
+	if err != nil {
+		return fmt.Errorf("marshaling slack payload: %w", err)
+	}
+	resp, err := http.Post(webhook, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("posting to slack: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("slack webhook returned %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -120,6 +121,13 @@ func runAssign(_ *cobra.Command, args []string) error {
 
 	fmt.Printf("  Created: %s\n", beadID)
 
+	// Flag probable duplicates so the caller can merge or link them.
+	// Best-effort: a failed search never blocks the assignment.
+	created := &beads.Issue{ID: beadID, Title: title, Description: assignDescription}
+	if dups, _ := beads.New(townRoot).FlagDuplicates(created); len(dups) > 0 {
+		printDuplicateCandidates(beadID, dups)
+	}
+
 	// Step 2: Hook the bead to the agent with retry logic
 	fmt.Printf("%s Hooking %s to %s...\n", style.Bold.Render("🪝"), beadID, agentID)
 
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/backup"
+	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	backupOutput  string
+	restoreTown   string
+	restoreForce  bool
+	restoreDryRun bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:     "backup",
+	GroupID: GroupWorkspace,
+	Short:   "Back up town state to a checksummed archive",
+	Long: `Back up the town's state to a single archive.
+
+The backup holds everything that isn't in a git remote: beads and the Dolt
+data directory, town and rig configuration, merge queue state and history
+(.runtime), daemon and patrol logs, and journals like .events.jsonl. Git
+repositories and clones (mayor/rig, refinery/rig, polecats, crew) are left
+out; 'gt rig add' re-clones them. Pid, socket and lock files are skipped.
+
+Every file is recorded with its SHA-256 in the archive's manifest. Files
+are copied while the town runs; if any change mid-copy the backup is taken
+again, and it fails after three tries. Run 'gt down' first for a quiet
+town, especially while Dolt is busy.
+
+Copy the archive off the machine: a backup on the same disk doesn't
+survive the disk.
+
+Examples:
+  gt backup                          # ./gt-backup-<town>-<time>.tar.gz
+  gt backup -o /mnt/nas/town.tar.gz
+  gt backup verify town.tar.gz       # Check every checksum`,
+	Args: cobra.NoArgs,
+	RunE: runBackup,
+}
+
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify <archive>",
+	Short: "Check a backup's files against its manifest",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBackupVerify,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:     "restore <archive>",
+	GroupID: GroupWorkspace,
+	Short:   "Restore town state from a backup",
+	Long: `Restore town state from an archive made by 'gt backup'.
+
+The whole archive is verified against its manifest before anything is
+written, so a corrupt or truncated backup changes nothing. Files are
+restored into the town found from the current directory, or into --town
+(which may not exist yet, for restoring onto a new machine). Files in the
+town that aren't in the backup are left alone.
+
+Restore refuses to overwrite existing files without --force, and refuses
+to run while the Dolt server is up: stop the town with 'gt down' first.
+Afterwards, re-clone rig repositories with 'gt rig add' where missing and
+start the town with 'gt up'.
+
+Examples:
+  gt restore town.tar.gz --dry-run
+  gt restore town.tar.gz --town ~/gt
+  gt restore town.tar.gz --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	backupCmd.Flags().StringVarP(&backupOutput, "output", "o", "", "Archive path (default ./gt-backup-<town>-<time>.tar.gz)")
+	restoreCmd.Flags().StringVar(&restoreTown, "town", "", "Town root to restore into (default: the current town)")
+	restoreCmd.Flags().BoolVarP(&restoreForce, "force", "f", false, "Overwrite existing files")
+	restoreCmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "Verify the archive and check for conflicts without writing")
+
+	backupCmd.AddCommand(backupVerifyCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	dest := backupOutput
+	if dest == "" {
+		dest = fmt.Sprintf("gt-backup-%s-%s.tar.gz", filepath.Base(townRoot), time.Now().Format("20060102-150405"))
+	}
+	if running, pid, _ := doltserver.IsRunning(townRoot); running {
+		fmt.Printf("%s Dolt server is running (PID %d); its data is copied live\n", style.WarningPrefix, pid)
+	}
+
+	m, err := backup.CreateFile(townRoot, dest)
+	if err != nil {
+		if errors.Is(err, backup.ErrInconsistent) {
+			return fmt.Errorf("%w\n  the town was too busy to snapshot; run 'gt down' and back up again", err)
+		}
+		return err
+	}
+	fmt.Printf("%s Backed up %d files (%s) to %s\n", style.SuccessPrefix, len(m.Files), formatBytes(m.Size()), dest)
+	fmt.Printf("  %s\n", style.Dim.Render("Copy it off this machine; verify with 'gt backup verify "+dest+"'"))
+	return nil
+}
+
+func runBackupVerify(cmd *cobra.Command, args []string) error {
+	m, err := backup.Verify(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s: %d files (%s) intact, taken %s from %s\n", style.SuccessPrefix, args[0],
+		len(m.Files), formatBytes(m.Size()), m.CreatedAt.Local().Format(time.RFC3339), m.Town)
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	townRoot := restoreTown
+	if townRoot == "" {
+		var err error
+		townRoot, err = workspace.FindFromCwdOrError()
+		if err != nil {
+			return fmt.Errorf("not in a Gas Town workspace (use --town): %w", err)
+		}
+	}
+	townRoot, err := filepath.Abs(townRoot)
+	if err != nil {
+		return err
+	}
+	if running, pid, _ := doltserver.IsRunning(townRoot); running && !restoreDryRun {
+		return fmt.Errorf("Dolt server is running (PID %d); stop the town with 'gt down' before restoring", pid)
+	}
+
+	m, err := backup.Restore(args[0], townRoot, backup.RestoreOptions{Force: restoreForce, DryRun: restoreDryRun})
+	if err != nil {
+		return err
+	}
+	if restoreDryRun {
+		fmt.Printf("%s %s is intact; would restore %d files (%s) into %s\n", style.SuccessPrefix, args[0],
+			len(m.Files), formatBytes(m.Size()), townRoot)
+		return nil
+	}
+	fmt.Printf("%s Restored %d files (%s) into %s from the backup taken %s\n", style.SuccessPrefix,
+		len(m.Files), formatBytes(m.Size()), townRoot, m.CreatedAt.Local().Format(time.RFC3339))
+	fmt.Printf("  %s\n", style.Dim.Render("Re-clone missing rig repositories with 'gt rig add', then 'gt up'"))
+	return nil
+}
@@ -26,7 +26,11 @@ prefix-based routing.
 Subcommands:
   move    Move a bead from one repository to another
   show    Show details of a bead (routes by prefix)
-  read    Alias for show`,
+  read    Alias for show
+  bulk    Update every bead matching a filter
+  dedup   Find and resolve probable duplicates
+  worklog Show per-bead agent work sessions
+  milestones Show milestone progress rollups`,
 }
 
 var beadMoveCmd = &cobra.Command{
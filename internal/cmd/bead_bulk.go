@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	beadBulkFilters []string
+	beadBulkSets    []string
+	beadBulkDryRun  bool
+	beadBulkReason  string
+	beadBulkLimit   int
+	beadBulkJSON    bool
+)
+
+var beadBulkCmd = &cobra.Command{
+	Use:   "bulk --filter <key=value> --set <key=value>",
+	Short: "Update every bead matching a filter",
+	Long: `Apply the same update to every bead matching a filter.
+
+Filters and updates are key=value terms. Both flags may be repeated or hold
+several comma-separated terms. Filters default to status=open so closed
+history isn't rewritten by accident; pass status=... to override.
+
+Filter keys: label, status, assignee, priority, parent, title (substring)
+Update keys: status, priority, assignee, add-label, remove-label
+
+Every changed bead is recorded in the beads audit log (.beads/audit.log)
+with its previous status, the filter and the actor. Use --dry-run to
+preview the matches first.
+
+Examples:
+  gt bead bulk --filter label=stale --set status=closed --dry-run
+  gt bead bulk --filter label=stale --set status=closed --reason "sweep"
+  gt bead bulk --filter assignee=gastown/polecats/nux --set assignee=
+  gt bead bulk --filter label=infra,priority=P3 --set priority=2,add-label=triaged`,
+	RunE: runBeadBulk,
+}
+
+func init() {
+	beadBulkCmd.Flags().StringArrayVar(&beadBulkFilters, "filter", nil, "Filter term(s) key=value (repeatable)")
+	beadBulkCmd.Flags().StringArrayVar(&beadBulkSets, "set", nil, "Update term(s) key=value (repeatable)")
+	beadBulkCmd.Flags().BoolVarP(&beadBulkDryRun, "dry-run", "n", false, "Show matching beads without changing them")
+	beadBulkCmd.Flags().StringVar(&beadBulkReason, "reason", "", "Reason recorded in the audit log")
+	beadBulkCmd.Flags().IntVar(&beadBulkLimit, "limit", 100, "Refuse to update more than this many beads (0 = no limit)")
+	beadBulkCmd.Flags().BoolVar(&beadBulkJSON, "json", false, "Output as JSON")
+	_ = beadBulkCmd.MarkFlagRequired("filter")
+	_ = beadBulkCmd.MarkFlagRequired("set")
+	beadCmd.AddCommand(beadBulkCmd)
+}
+
+// beadBulkOutput is the --json shape of gt bead bulk.
+type beadBulkOutput struct {
+	DryRun  bool              `json:"dry_run"`
+	Changes string            `json:"changes"`
+	Matched []string          `json:"matched"`
+	Updated []string          `json:"updated,omitempty"`
+	Failed  map[string]string `json:"failed,omitempty"`
+}
+
+func runBeadBulk(cmd *cobra.Command, args []string) error {
+	filter, err := beads.ParseBulkFilter(beadBulkFilters)
+	if err != nil {
+		return err
+	}
+	update, err := beads.ParseBulkSet(beadBulkSets)
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+	b := beads.New(cwd)
+
+	result, err := b.BulkUpdate(filter, update, beads.BulkOptions{
+		DryRun: beadBulkDryRun,
+		Actor:  detectSender(),
+		Reason: beadBulkReason,
+		Limit:  beadBulkLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	changes := beads.DescribeBulkUpdate(update)
+	if beadBulkJSON {
+		out := beadBulkOutput{
+			DryRun:  beadBulkDryRun,
+			Changes: changes,
+			Matched: make([]string, 0, len(result.Matched)),
+			Updated: result.Updated,
+			Failed:  result.Failed,
+		}
+		for _, issue := range result.Matched {
+			out.Matched = append(out.Matched, issue.ID)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(result.Matched) == 0 {
+		fmt.Println("No beads match the filter")
+		return nil
+	}
+
+	if beadBulkDryRun {
+		fmt.Printf("%s Would apply %s to %d bead(s):\n", style.Bold.Render("→"), changes, len(result.Matched))
+		for _, issue := range result.Matched {
+			fmt.Printf("  %s  [%s] %s\n", issue.ID, issue.Status, issue.Title)
+		}
+		return nil
+	}
+
+	fmt.Printf("%s Applied %s to %d of %d bead(s)\n", style.Bold.Render("✓"), changes, len(result.Updated), len(result.Matched))
+	for id, msg := range result.Failed {
+		fmt.Printf("  %s %s: %s\n", style.Warning.Render("⚠"), id, msg)
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d bead(s) failed to update", len(result.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	beadDedupTitle       string
+	beadDedupDescription string
+	beadDedupThreshold   float64
+	beadDedupJSON        bool
+)
+
+var beadDedupCmd = &cobra.Command{
+	Use:   "dedup [bead-id]",
+	Short: "Find probable duplicates of a bead",
+	Long: `Find open beads that look like duplicates of an existing bead or of a
+title you are about to file.
+
+Candidates come from the beads search index and are scored by title and
+description similarity (0-1). Resolve a duplicate with 'merge' (close it
+and link it to the canonical bead) or 'link' (keep both, record them as
+related).
+
+Beads filed through gt (gt assign, federated issues, recurring gate
+failures) are checked as they are created and labelled possible-duplicate
+when they resemble an open bead. With no arguments, list those beads.
+
+Examples:
+  gt bead dedup
+  gt bead dedup gt-abc123
+  gt bead dedup --title "Refinery crashes on empty queue"
+  gt bead dedup merge gt-dup456 gt-abc123
+  gt bead dedup link gt-def789 gt-abc123`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBeadDedup,
+}
+
+var beadDedupMergeCmd = &cobra.Command{
+	Use:   "merge <duplicate-id> <canonical-id>",
+	Short: "Close a bead as a duplicate of another",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := beadDedupBeads()
+		if err != nil {
+			return err
+		}
+		if err := b.MarkDuplicate(args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("%s Closed %s as duplicate of %s\n", style.Bold.Render("✓"), args[0], args[1])
+		return nil
+	},
+}
+
+var beadDedupLinkCmd = &cobra.Command{
+	Use:   "link <bead-id> <related-id>",
+	Short: "Record two beads as related without closing either",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := beadDedupBeads()
+		if err != nil {
+			return err
+		}
+		if err := b.ResolveDuplicate(args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("%s Linked %s ↔ %s\n", style.Bold.Render("✓"), args[0], args[1])
+		return nil
+	},
+}
+
+func init() {
+	beadDedupCmd.Flags().StringVar(&beadDedupTitle, "title", "", "Check a title instead of an existing bead")
+	beadDedupCmd.Flags().StringVarP(&beadDedupDescription, "description", "d", "", "Description to compare along with --title")
+	beadDedupCmd.Flags().Float64Var(&beadDedupThreshold, "threshold", beads.DefaultDuplicateThreshold, "Minimum similarity (0-1)")
+	beadDedupCmd.Flags().BoolVar(&beadDedupJSON, "json", false, "Output as JSON")
+	beadDedupCmd.AddCommand(beadDedupMergeCmd)
+	beadDedupCmd.AddCommand(beadDedupLinkCmd)
+	beadCmd.AddCommand(beadDedupCmd)
+}
+
+func beadDedupBeads() (*beads.Beads, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("getting working directory: %w", err)
+	}
+	return beads.New(cwd), nil
+}
+
+// beadDedupCandidate is the --json shape of one duplicate candidate.
+type beadDedupCandidate struct {
+	ID     string  `json:"id"`
+	Title  string  `json:"title"`
+	Status string  `json:"status"`
+	Score  float64 `json:"score"`
+}
+
+func runBeadDedup(cmd *cobra.Command, args []string) error {
+	b, err := beadDedupBeads()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 && beadDedupTitle == "" {
+		return listFlaggedDuplicates(b)
+	}
+
+	title, description, exclude := beadDedupTitle, beadDedupDescription, ""
+	if len(args) == 1 {
+		issue, err := b.Show(args[0])
+		if err != nil {
+			return fmt.Errorf("getting bead %s: %w", args[0], err)
+		}
+		title, description, exclude = issue.Title, issue.Description, issue.ID
+	}
+
+	candidates, err := b.FindSimilar(title, description, beads.SimilarOptions{
+		Threshold: beadDedupThreshold,
+		ExcludeID: exclude,
+	})
+	if err != nil {
+		return err
+	}
+
+	if beadDedupJSON {
+		out := make([]beadDedupCandidate, 0, len(candidates))
+		for _, c := range candidates {
+			out = append(out, beadDedupCandidate{ID: c.Issue.ID, Title: c.Issue.Title, Status: c.Issue.Status, Score: c.Score})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(candidates) == 0 {
+		fmt.Println("No probable duplicates found")
+		return nil
+	}
+	printDuplicateCandidates(exclude, candidates)
+	return nil
+}
+
+// listFlaggedDuplicates lists the open beads labelled possible-duplicate.
+func listFlaggedDuplicates(b *beads.Beads) error {
+	flagged, err := b.List(beads.ListOptions{Status: "open", Label: beads.DuplicateLabel, Priority: -1})
+	if err != nil {
+		return fmt.Errorf("listing flagged beads: %w", err)
+	}
+	if beadDedupJSON {
+		out := make([]beadDedupCandidate, 0, len(flagged))
+		for _, issue := range flagged {
+			out = append(out, beadDedupCandidate{ID: issue.ID, Title: issue.Title, Status: issue.Status})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	if len(flagged) == 0 {
+		fmt.Println("No beads flagged as possible duplicates")
+		return nil
+	}
+	fmt.Printf("%s Flagged as possible duplicates:\n", style.Warning.Render("⚠"))
+	for _, issue := range flagged {
+		fmt.Printf("  %s  %s\n", issue.ID, issue.Title)
+	}
+	fmt.Println("  Check one: gt bead dedup <bead-id>")
+	return nil
+}
+
+// printDuplicateCandidates lists candidates with the commands that resolve
+// them. newID may be empty when the bead has not been created yet.
+func printDuplicateCandidates(newID string, candidates []beads.DuplicateCandidate) {
+	fmt.Printf("%s Probable duplicates:\n", style.Warning.Render("⚠"))
+	for _, c := range candidates {
+		fmt.Printf("  %s  %.0f%%  %s\n", c.Issue.ID, c.Score*100, c.Issue.Title)
+	}
+	if newID == "" {
+		newID = "<new-id>"
+	}
+	best := candidates[0].Issue.ID
+	fmt.Printf("  Merge: gt bead dedup merge %s %s\n", newID, best)
+	fmt.Printf("  Link:  gt bead dedup link %s %s\n", newID, best)
+}
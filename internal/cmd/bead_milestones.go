@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	beadMilestonesAll        bool
+	beadMilestonesJSON       bool
+	beadMilestoneDescription string
+)
+
+var beadMilestonesCmd = &cobra.Command{
+	Use:   "milestones [milestone-id...]",
+	Short: "Show milestone progress rollups",
+	Long: `Show progress for milestones: child issues done, in progress, MRs in
+flight, and an ETA projected from the cycle time of children already closed.
+
+A milestone is a bead labelled gt:milestone. Attach work to it by creating
+children: bd create --parent <milestone-id> ...
+
+Examples:
+  gt bead milestones                 # Open milestones
+  gt bead milestones --all           # Include closed milestones
+  gt bead milestones gt-m1 --json
+  gt bead milestones create "Q3 release"`,
+	RunE: runBeadMilestones,
+}
+
+var beadMilestonesCreateCmd = &cobra.Command{
+	Use:   "create <title>",
+	Short: "Create a milestone",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("getting working directory: %w", err)
+		}
+		issue, err := beads.New(cwd).CreateMilestone(strings.Join(args, " "), beadMilestoneDescription, detectSender())
+		if err != nil {
+			return fmt.Errorf("creating milestone: %w", err)
+		}
+		fmt.Printf("%s Created milestone %s\n", style.Bold.Render("✓"), issue.ID)
+		fmt.Printf("  Attach work with: bd create --parent %s ...\n", issue.ID)
+		return nil
+	},
+}
+
+func init() {
+	beadMilestonesCmd.Flags().BoolVar(&beadMilestonesAll, "all", false, "Include closed milestones")
+	beadMilestonesCmd.Flags().BoolVar(&beadMilestonesJSON, "json", false, "Output as JSON")
+	beadMilestonesCreateCmd.Flags().StringVarP(&beadMilestoneDescription, "description", "d", "", "Milestone description")
+	beadMilestonesCmd.AddCommand(beadMilestonesCreateCmd)
+	beadCmd.AddCommand(beadMilestonesCmd)
+}
+
+func runBeadMilestones(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+	b := beads.New(cwd)
+
+	var milestones []*beads.Issue
+	if len(args) > 0 {
+		for _, id := range args {
+			issue, err := b.Show(id)
+			if err != nil {
+				return fmt.Errorf("getting milestone %s: %w", id, err)
+			}
+			milestones = append(milestones, issue)
+		}
+	} else {
+		milestones, err = b.ListMilestones(beadMilestonesAll)
+		if err != nil {
+			return fmt.Errorf("listing milestones: %w", err)
+		}
+	}
+
+	rollup, err := b.MilestoneRollup(milestones, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if beadMilestonesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rollup)
+	}
+
+	if len(rollup) == 0 {
+		fmt.Println("No milestones")
+		return nil
+	}
+
+	for _, p := range rollup {
+		fmt.Printf("%s  %s\n", style.Bold.Render(p.ID), p.Title)
+		fmt.Printf("    %s %3d%%  %d/%d done, %d in progress, %d MR(s) in flight\n",
+			milestoneBar(p.Percent, 20), p.Percent, p.Done, p.Total, p.InProgress, p.MRsInFlight)
+		if p.ETA != nil {
+			fmt.Printf("    ETA %s  %s\n", p.ETA.Local().Format("2006-01-02"),
+				style.Dim.Render(fmt.Sprintf("(cycle time %s)", formatWorklogDuration(p.CycleTime))))
+		} else if p.Done < p.Total {
+			fmt.Printf("    ETA %s\n", style.Dim.Render("unknown (no closed children yet)"))
+		}
+	}
+	return nil
+}
+
+// milestoneBar renders pct as a fixed-width text progress bar.
+func milestoneBar(pct, width int) string {
+	filled := pct * width / 100
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("·", width-filled) + "]"
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	beadWorklogAgent   string
+	beadWorklogSince   string
+	beadWorklogJSON    bool
+	beadWorklogCommits bool
+)
+
+var beadWorklogCmd = &cobra.Command{
+	Use:   "worklog [bead-id]",
+	Short: "Show where agent time went, per bead",
+	Long: `Show per-bead work sessions reconstructed from assignment events.
+
+A work session starts when a bead is slung, hooked or dispatched to an
+agent and ends when that agent runs 'gt done', unhooks it, or picks up
+different work. Sessions still running are shown as open and measured
+up to now. Each session is tagged with the agent's runtime session ID
+when one was recorded at startup.
+
+With --commits, commits mentioning the bead ID in the current repository
+are counted towards each bead.
+
+Examples:
+  gt bead worklog                       # All beads, last 7 days
+  gt bead worklog gt-abc123             # One bead
+  gt bead worklog --agent gastown/polecats/nux --since 24h
+  gt bead worklog --commits --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBeadWorklog,
+}
+
+func init() {
+	beadWorklogCmd.Flags().StringVar(&beadWorklogAgent, "agent", "", "Only sessions for this agent")
+	beadWorklogCmd.Flags().StringVar(&beadWorklogSince, "since", "7d", "Only sessions started within this window (e.g. 24h, 7d)")
+	beadWorklogCmd.Flags().BoolVar(&beadWorklogJSON, "json", false, "Output as JSON")
+	beadWorklogCmd.Flags().BoolVar(&beadWorklogCommits, "commits", false, "Count commits mentioning each bead in the current repo")
+	beadCmd.AddCommand(beadWorklogCmd)
+}
+
+// WorkSession is one stretch of an agent working on a bead.
+type WorkSession struct {
+	Bead      string        `json:"bead"`
+	Agent     string        `json:"agent"`
+	SessionID string        `json:"session_id,omitempty"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+	EndReason string        `json:"end_reason"` // done, unhook, reassigned, open
+	Branch    string        `json:"branch,omitempty"`
+}
+
+// BeadWorklog aggregates the sessions spent on one bead.
+type BeadWorklog struct {
+	Bead     string        `json:"bead"`
+	Total    time.Duration `json:"total_ns"`
+	Agents   []string      `json:"agents"`
+	Commits  int           `json:"commits,omitempty"`
+	Sessions []WorkSession `json:"sessions"`
+}
+
+func runBeadWorklog(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if beadWorklogSince != "" {
+		d, err := parseDuration(beadWorklogSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since value: %w", err)
+		}
+		since = time.Now().Add(-d)
+	}
+
+	evts, err := readWorklogEvents(filepath.Join(townRoot, events.EventsFile))
+	if err != nil {
+		return err
+	}
+	sessions := buildWorkSessions(evts, time.Now())
+
+	var filtered []WorkSession
+	for _, s := range sessions {
+		if len(args) == 1 && s.Bead != args[0] {
+			continue
+		}
+		if beadWorklogAgent != "" && s.Agent != beadWorklogAgent {
+			continue
+		}
+		if !since.IsZero() && s.Start.Before(since) {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+
+	logs := summarizeWorklog(filtered)
+	if beadWorklogCommits {
+		cwd, _ := os.Getwd()
+		for _, l := range logs {
+			l.Commits = countBeadCommits(cwd, l.Bead)
+		}
+	}
+
+	if beadWorklogJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(logs)
+	}
+
+	if len(logs) == 0 {
+		fmt.Println("No work sessions found")
+		return nil
+	}
+
+	fmt.Printf("%s\n\n", style.Bold.Render("Work Log"))
+	for _, l := range logs {
+		header := fmt.Sprintf("%s  %s  %d session(s)", style.Bold.Render(l.Bead), formatWorklogDuration(l.Total), len(l.Sessions))
+		if beadWorklogCommits {
+			header += fmt.Sprintf("  %d commit(s)", l.Commits)
+		}
+		fmt.Println(header)
+		for _, s := range l.Sessions {
+			line := fmt.Sprintf("    %s  %-8s %s  %s",
+				s.Start.Local().Format("2006-01-02 15:04"), formatWorklogDuration(s.Duration), s.EndReason, s.Agent)
+			if s.SessionID != "" {
+				line += "  " + style.Dim.Render(s.SessionID)
+			}
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+// readWorklogEvents reads the assignment-related events from the raw events log.
+func readWorklogEvents(path string) ([]events.Event, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is within the town root
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading events file: %w", err)
+	}
+	defer f.Close()
+
+	var out []events.Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e events.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		switch e.Type {
+		case events.TypeSling, events.TypeHook, events.TypeUnhook, events.TypeDone,
+			events.TypeSchedulerDispatch, events.TypeSessionStart:
+			out = append(out, e)
+		}
+	}
+	return out, scanner.Err()
+}
+
+// buildWorkSessions pairs assignment events into work sessions. An agent
+// works on one bead at a time, so a new assignment ends the previous one.
+// Sessions without an end are closed at now and marked "open".
+func buildWorkSessions(evts []events.Event, now time.Time) []WorkSession {
+	sorted := make([]events.Event, len(evts))
+	copy(sorted, evts)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	open := make(map[string]*WorkSession) // agent -> active session
+	runtimeSession := make(map[string]string)
+	var sessions []WorkSession
+
+	closeSession := func(agent string, at time.Time, reason string) {
+		s, ok := open[agent]
+		if !ok {
+			return
+		}
+		s.End = at
+		s.Duration = at.Sub(s.Start)
+		s.EndReason = reason
+		sessions = append(sessions, *s)
+		delete(open, agent)
+	}
+
+	for _, e := range sorted {
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		bead := payloadString(e.Payload, "bead")
+
+		switch e.Type {
+		case events.TypeSessionStart:
+			runtimeSession[e.Actor] = payloadString(e.Payload, "session_id")
+			continue
+		case events.TypeSling, events.TypeHook, events.TypeSchedulerDispatch:
+			agent := e.Actor
+			switch e.Type {
+			case events.TypeSling:
+				agent = payloadString(e.Payload, "target")
+			case events.TypeSchedulerDispatch:
+				agent = ""
+				if rig, polecat := payloadString(e.Payload, "rig"), payloadString(e.Payload, "polecat"); rig != "" && polecat != "" {
+					agent = rig + "/polecats/" + polecat
+				}
+			}
+			if agent == "" || bead == "" {
+				continue
+			}
+			if s, ok := open[agent]; ok {
+				if s.Bead == bead {
+					continue // sling followed by hook for the same work
+				}
+				closeSession(agent, ts, "reassigned")
+			}
+			open[agent] = &WorkSession{Bead: bead, Agent: agent, SessionID: runtimeSession[agent], Start: ts}
+		case events.TypeDone, events.TypeUnhook:
+			s, ok := open[e.Actor]
+			if !ok || (bead != "" && s.Bead != bead) {
+				continue
+			}
+			if e.Type == events.TypeDone {
+				s.Branch = payloadString(e.Payload, "branch")
+			}
+			closeSession(e.Actor, ts, e.Type)
+		}
+	}
+
+	for agent := range open {
+		closeSession(agent, now, "open")
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].Start.Before(sessions[j].Start) })
+	return sessions
+}
+
+// summarizeWorklog groups sessions by bead, most time spent first.
+func summarizeWorklog(sessions []WorkSession) []*BeadWorklog {
+	byBead := make(map[string]*BeadWorklog)
+	var order []*BeadWorklog
+	for _, s := range sessions {
+		l, ok := byBead[s.Bead]
+		if !ok {
+			l = &BeadWorklog{Bead: s.Bead}
+			byBead[s.Bead] = l
+			order = append(order, l)
+		}
+		l.Total += s.Duration
+		l.Sessions = append(l.Sessions, s)
+		found := false
+		for _, a := range l.Agents {
+			found = found || a == s.Agent
+		}
+		if !found {
+			l.Agents = append(l.Agents, s.Agent)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].Total > order[j].Total })
+	return order
+}
+
+// countBeadCommits counts commits on any ref in dir whose message mentions
+// beadID. Returns 0 when dir is not a git repository.
+func countBeadCommits(dir, beadID string) int {
+	out, err := exec.Command("git", "-C", dir, "log", "--all", "--fixed-strings", "--grep="+beadID, "--format=%H").Output()
+	if err != nil {
+		return 0
+	}
+	return len(strings.Fields(string(out)))
+}
+
+func payloadString(payload map[string]interface{}, key string) string {
+	if v, ok := payload[key]; ok && v != nil {
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+	return ""
+}
+
+func formatWorklogDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
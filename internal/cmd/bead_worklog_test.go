@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+func worklogEvent(ts time.Time, typ, actor string, payload map[string]interface{}) events.Event {
+	return events.Event{Timestamp: ts.UTC().Format(time.RFC3339), Type: typ, Actor: actor, Payload: payload}
+}
+
+func TestBuildWorkSessions(t *testing.T) {
+	t0 := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	nux := "gastown/polecats/nux"
+	evts := []events.Event{
+		worklogEvent(t0, events.TypeSessionStart, nux, events.SessionPayload("sess-1", nux, "", "")),
+		worklogEvent(t0, events.TypeSling, "mayor", events.SlingPayload("gt-a", nux)),
+		worklogEvent(t0.Add(time.Minute), events.TypeHook, nux, events.HookPayload("gt-a")), // same work, ignored
+		worklogEvent(t0.Add(30*time.Minute), events.TypeDone, nux, events.DonePayload("gt-a", "polecat/nux/gt-a")),
+		worklogEvent(t0.Add(40*time.Minute), events.TypeSchedulerDispatch, "scheduler", events.SchedulerDispatchPayload("gt-b", "gastown", "nux")),
+		worklogEvent(t0.Add(50*time.Minute), events.TypeHook, nux, events.HookPayload("gt-c")), // reassigns nux
+		worklogEvent(t0.Add(60*time.Minute), events.TypeUnhook, "other", events.UnhookPayload("gt-c")), // not nux's unhook
+	}
+
+	now := t0.Add(2 * time.Hour)
+	sessions := buildWorkSessions(evts, now)
+	if len(sessions) != 3 {
+		t.Fatalf("expected 3 sessions, got %+v", sessions)
+	}
+
+	want := []struct {
+		bead, reason string
+		dur          time.Duration
+	}{
+		{"gt-a", "done", 30 * time.Minute},
+		{"gt-b", "reassigned", 10 * time.Minute},
+		{"gt-c", "open", 70 * time.Minute},
+	}
+	for i, w := range want {
+		s := sessions[i]
+		if s.Bead != w.bead || s.EndReason != w.reason || s.Duration != w.dur || s.Agent != nux {
+			t.Errorf("session %d = %+v, want bead=%s reason=%s dur=%v", i, s, w.bead, w.reason, w.dur)
+		}
+		if s.SessionID != "sess-1" {
+			t.Errorf("session %d SessionID = %q, want sess-1", i, s.SessionID)
+		}
+	}
+	if sessions[0].Branch != "polecat/nux/gt-a" {
+		t.Errorf("expected branch from done event, got %q", sessions[0].Branch)
+	}
+}
+
+func TestSummarizeWorklog(t *testing.T) {
+	sessions := []WorkSession{
+		{Bead: "gt-a", Agent: "x", Duration: 10 * time.Minute},
+		{Bead: "gt-b", Agent: "x", Duration: 5 * time.Minute},
+		{Bead: "gt-b", Agent: "y", Duration: 20 * time.Minute},
+		{Bead: "gt-b", Agent: "y", Duration: time.Minute},
+	}
+	logs := summarizeWorklog(sessions)
+	if len(logs) != 2 || logs[0].Bead != "gt-b" {
+		t.Fatalf("expected gt-b first, got %+v", logs)
+	}
+	if logs[0].Total != 26*time.Minute || len(logs[0].Agents) != 2 || len(logs[0].Sessions) != 3 {
+		t.Errorf("unexpected gt-b summary: %+v", logs[0])
+	}
+}
+
+func TestReadWorklogEvents_FiltersTypes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".events.jsonl")
+	now := time.Now()
+	writeTrailEventsFile(t, path, []events.Event{
+		worklogEvent(now, events.TypeHook, "a", events.HookPayload("gt-1")),
+		worklogEvent(now, events.TypeMail, "a", events.MailPayload("b", "hi")),
+		worklogEvent(now, events.TypeDone, "a", events.DonePayload("gt-1", "br")),
+	})
+
+	got, err := readWorklogEvents(path)
+	if err != nil {
+		t.Fatalf("readWorklogEvents: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected hook and done events, got %d", len(got))
+	}
+
+	missing, err := readWorklogEvents(filepath.Join(t.TempDir(), "none.jsonl"))
+	if err != nil || missing != nil {
+		t.Errorf("missing file: got (%v, %v), want (nil, nil)", missing, err)
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/bench"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	benchIterations int
+	benchStackSize  int
+	benchOnly       []string
+	benchSave       bool
+	benchJSON       bool
+	benchThreshold  float64
+)
+
+var benchCmd = &cobra.Command{
+	Use:     "bench",
+	GroupID: GroupDiag,
+	Short:   "Benchmark key town operations on this machine",
+	Long: `Time the operations a town's speed depends on, on this machine:
+
+  worktree      git worktree add for a new branch (polecat spawn)
+  stack         stacking one MR onto a refinery batch
+  capture_pane  tmux capture-pane of a live session (witness, nudges)
+  beads_query   listing open issues from the town's beads
+
+Worktree, stack and capture_pane run against scratch state (a throwaway
+repository, an isolated tmux server), so results compare across towns.
+Operations that can't run here, e.g. without tmux, are skipped.
+
+With --save the results become the town's baseline. Later runs are compared
+against it, and gt bench exits 1 if any operation is more than --threshold
+percent slower — run it after an upgrade to see what got slower.
+
+Examples:
+  gt bench --save                 # Record a baseline
+  gt bench                        # Compare against it
+  gt bench --only worktree,stack --iterations 10`,
+	Args: cobra.NoArgs,
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().IntVarP(&benchIterations, "iterations", "n", 5, "Times to run each operation")
+	benchCmd.Flags().IntVar(&benchStackSize, "stack-size", 5, "MRs stacked per iteration of the stack operation")
+	benchCmd.Flags().StringSliceVar(&benchOnly, "only", nil, "Operations to run (default: all)")
+	benchCmd.Flags().BoolVar(&benchSave, "save", false, "Save the results as the town's baseline")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false, "Output as JSON")
+	benchCmd.Flags().Float64Var(&benchThreshold, "threshold", 20, "Percent slower than the baseline that counts as a regression")
+	rootCmd.AddCommand(benchCmd)
+}
+
+// benchReport is gt bench's JSON output.
+type benchReport struct {
+	Results  []*bench.Result `json:"results"`
+	Baseline *bench.Baseline `json:"baseline,omitempty"`
+	Changes  []*bench.Change `json:"changes,omitempty"`
+	Saved    bool            `json:"saved,omitempty"`
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	townRoot, _ := workspace.FindFromCwd()
+	if benchSave && townRoot == "" {
+		return fmt.Errorf("--save needs a Gas Town workspace to store the baseline in")
+	}
+	baselinePath := ""
+	var baseline *bench.Baseline
+	if townRoot != "" {
+		baselinePath = benchBaselinePath(townRoot)
+		var err error
+		if baseline, err = bench.LoadBaseline(baselinePath); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if !benchJSON {
+		fmt.Printf("%s Running %d iterations of each operation...\n", style.ArrowPrefix, benchIterations)
+	}
+	results, err := bench.Run(ctx, bench.Options{
+		Iterations: benchIterations,
+		Only:       benchOnly,
+		TownRoot:   townRoot,
+		StackSize:  benchStackSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	report := &benchReport{Results: results, Baseline: baseline, Changes: bench.Compare(baseline, results, benchThreshold)}
+	if benchSave {
+		if _, err := bench.SaveBaseline(baselinePath, Version, results); err != nil {
+			return err
+		}
+		report.Saved = true
+	}
+
+	if benchJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printBenchReport(report)
+	}
+	for _, c := range report.Changes {
+		if c.Regressed && !benchSave {
+			return NewSilentExit(1)
+		}
+	}
+	return nil
+}
+
+func benchBaselinePath(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "bench-baseline.json")
+}
+
+func printBenchReport(r *benchReport) {
+	changes := make(map[string]*bench.Change, len(r.Changes))
+	for _, c := range r.Changes {
+		changes[c.Name] = c
+	}
+	fmt.Println()
+	fmt.Printf("  %-14s %10s %10s %10s %10s  %s\n", "Operation", "Mean", "Min", "Max", "Per sec", "vs baseline")
+	regressed := 0
+	for _, res := range r.Results {
+		if res.Skipped != "" {
+			fmt.Printf("  %-14s %s\n", res.Name, style.Dim.Render("skipped: "+res.Skipped))
+			continue
+		}
+		vs := style.Dim.Render("-")
+		if c := changes[res.Name]; c != nil {
+			vs = fmt.Sprintf("%+.1f%% (was %s)", c.Percent, formatBenchDuration(c.Baseline))
+			if c.Regressed {
+				vs = style.Error.Render(vs)
+				regressed++
+			} else if c.Percent < 0 {
+				vs = style.Success.Render(vs)
+			}
+		}
+		fmt.Printf("  %-14s %10s %10s %10s %10.1f  %s\n", res.Name,
+			formatBenchDuration(res.Mean), formatBenchDuration(res.Min), formatBenchDuration(res.Max),
+			res.PerSecond(), vs)
+	}
+	fmt.Println()
+
+	switch {
+	case r.Saved:
+		fmt.Printf("%s Saved as baseline\n", style.Bold.Render("✓"))
+	case r.Baseline == nil:
+		fmt.Println(style.Dim.Render("No baseline yet; record one with gt bench --save"))
+	case regressed > 0:
+		fmt.Printf("%s %d operations more than %.0f%% slower than the baseline of %s\n",
+			style.Error.Render("✗"), regressed, benchThreshold, benchBaselineLabel(r.Baseline))
+	default:
+		fmt.Printf("%s Within %.0f%% of the baseline of %s\n",
+			style.Success.Render("✓"), benchThreshold, benchBaselineLabel(r.Baseline))
+	}
+}
+
+func benchBaselineLabel(b *bench.Baseline) string {
+	label := b.RecordedAt.Local().Format("2006-01-02")
+	if b.Version != "" {
+		label += " (gt " + b.Version + ")"
+	}
+	return label
+}
+
+// formatBenchDuration rounds d to three significant digits or so.
+func formatBenchDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
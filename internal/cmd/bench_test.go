@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatBenchDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{1234567 * time.Microsecond, "1.23s"},
+		{71132716 * time.Nanosecond, "71.13ms"},
+		{1747145 * time.Nanosecond, "1.75ms"},
+		{345678 * time.Nanosecond, "346µs"},
+	}
+	for _, tt := range tests {
+		if got := formatBenchDuration(tt.d); got != tt.want {
+			t.Errorf("formatBenchDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -102,7 +102,10 @@ func runBroadcast(cmd *cobra.Command, args []string) error {
 	}
 
 	// Send nudges
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	townRoot, _ := workspace.FindFromCwd()
 	var succeeded, failed, skipped int
 	var failures []string
@@ -121,7 +124,7 @@ func runBroadcast(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		if err := t.NudgeSession(agent.Name, message); err != nil {
+		if err := tmux.Nudge(t, agent.Name, message); err != nil {
 			failed++
 			failures = append(failures, fmt.Sprintf("%s: %v", agentName, err))
 			fmt.Printf("  %s %s %s\n", style.ErrorPrefix, AgentTypeIcons[agent.Type], agentName)
@@ -332,6 +332,9 @@ func batchFetchBeadInfoByIDs(townRoot string, ids []string) map[string]beadStatu
 //
 // Sling contexts are queried from HQ only (authoritative). Work bead readiness
 // is checked across all rig dirs since work beads live in rig-local DBs.
+//
+// Results are in enqueue order, or most urgent first when the scheduler maps
+// labels to priorities (scheduler.label_priorities).
 func getReadySlingContexts(townRoot string) ([]capacity.PendingBead, error) {
 	// 1. List all open sling context beads from HQ (authoritative)
 	allContexts, err := listAllSlingContexts(townRoot)
@@ -343,12 +346,13 @@ func getReadySlingContexts(townRoot string) ([]capacity.PendingBead, error) {
 		return nil, nil
 	}
 
-	// 2. Build readyWorkIDs set from bd ready across all dirs
+	// 2. Build the ready work beads from bd ready across all dirs
 	// (work beads live in rig-local DBs, so we need to check all dirs)
-	readyWorkIDs, readyErr := listReadyWorkBeadIDsWithError(townRoot)
+	readyWork, readyErr := listReadyWorkBeadsWithError(townRoot)
 	if readyErr != nil {
 		return nil, readyErr
 	}
+	labelPriorities := loadLabelPriorities(townRoot)
 
 	// 3. Build PendingBead list — pure filtering, no mutations.
 	// Sort by EnqueuedAt for deterministic deduplication: when concurrent
@@ -380,7 +384,8 @@ func getReadySlingContexts(townRoot string) ([]capacity.PendingBead, error) {
 		}
 
 		// Only include if work bead is ready (unblocked)
-		if !readyWorkIDs[fields.WorkBeadID] {
+		work, ready := readyWork[fields.WorkBeadID]
+		if !ready {
 			continue
 		}
 
@@ -397,13 +402,28 @@ func getReadySlingContexts(townRoot string) ([]capacity.PendingBead, error) {
 			TargetRig:   fields.TargetRig,
 			Description: ctx.Description,
 			Labels:      ctx.Labels,
+			Priority:    labelPriorities.Apply(work.Priority, work.Labels),
 			Context:     fields,
 		})
 	}
 
+	// Triage labels reorder dispatch; otherwise it stays in enqueue order.
+	if len(labelPriorities) > 0 {
+		capacity.SortByPriority(result)
+	}
 	return result, nil
 }
 
+// loadLabelPriorities returns the scheduler's label priority mapping, or nil
+// when none is configured or town settings can't be read.
+func loadLabelPriorities(townRoot string) capacity.LabelPriorities {
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil || settings.Scheduler == nil {
+		return nil
+	}
+	return settings.Scheduler.LabelPriorities
+}
+
 // dispatchSingleBead dispatches one scheduled bead via executeSling.
 // Context fields are already parsed (from PendingBead.Context).
 // Returns the SlingResult (including PolecatName) on success.
@@ -484,9 +504,29 @@ func listAllSlingContexts(townRoot string) ([]*beads.Issue, error) {
 }
 
 // listReadyWorkBeadIDsWithError returns a set of work bead IDs that are unblocked.
-// Returns an error only when ALL dirs fail (partial success is acceptable).
 func listReadyWorkBeadIDsWithError(townRoot string) (map[string]bool, error) {
-	readyIDs := make(map[string]bool)
+	work, err := listReadyWorkBeadsWithError(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	readyIDs := make(map[string]bool, len(work))
+	for id := range work {
+		readyIDs[id] = true
+	}
+	return readyIDs, nil
+}
+
+// readyWorkBead is the priority and labels of an unblocked work bead.
+type readyWorkBead struct {
+	Priority int
+	Labels   []string
+}
+
+// listReadyWorkBeadsWithError returns the unblocked work beads across all
+// beads dirs, keyed by ID.
+// Returns an error only when ALL dirs fail (partial success is acceptable).
+func listReadyWorkBeadsWithError(townRoot string) (map[string]readyWorkBead, error) {
+	ready := make(map[string]readyWorkBead)
 	dirs := beadsSearchDirs(townRoot)
 	failCount := 0
 	var lastErr error
@@ -505,18 +545,20 @@ func listReadyWorkBeadIDsWithError(townRoot string) (map[string]bool, error) {
 			continue
 		}
 		var readyBeads []struct {
-			ID string `json:"id"`
+			ID       string   `json:"id"`
+			Priority int      `json:"priority"`
+			Labels   []string `json:"labels"`
 		}
 		if err := json.Unmarshal(readyOut, &readyBeads); err == nil {
 			for _, b := range readyBeads {
-				readyIDs[b.ID] = true
+				ready[b.ID] = readyWorkBead{Priority: b.Priority, Labels: b.Labels}
 			}
 		}
 	}
 	if failCount == len(dirs) && failCount > 0 {
 		return nil, fmt.Errorf("all %d bd ready queries failed (last: %w)", failCount, lastErr)
 	}
-	return readyIDs, nil
+	return ready, nil
 }
 
 // listReadyWorkBeadIDs returns a set of work bead IDs that are unblocked.
@@ -15,6 +15,7 @@ import (
 	"github.com/steveyegge/gastown/internal/daemon"
 	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/wallclock"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -33,7 +34,8 @@ Commands:
   gt config agent get <name>         Show agent configuration
   gt config agent set <name> <cmd>   Set custom agent command
   gt config agent remove <name>      Remove custom agent
-  gt config default-agent [name]     Get or set default agent`,
+  gt config default-agent [name]     Get or set default agent
+  gt config show [--origin]          Show effective settings and their sources`,
 }
 
 // Agent subcommands
@@ -616,6 +618,9 @@ Supported keys:
   maintenance.window          Maintenance window start time in HH:MM (e.g., "03:00")
   maintenance.interval        How often: "daily", "weekly", "monthly", or duration
   maintenance.threshold       Commit count threshold (default: 1000)
+  maintenance.timezone        Time zone of the window (e.g., "America/New_York",
+                              default: local). DST changes never skip or
+                              repeat a run.
 
   Lifecycle (Dolt data maintenance):
   lifecycle.reaper.enabled     Enable/disable wisp reaper (true/false)
@@ -637,6 +642,7 @@ Examples:
   gt config set scheduler.max_polecats 5
   gt config set maintenance.window 03:00
   gt config set maintenance.interval daily
+  gt config set maintenance.timezone Europe/Berlin
   gt config set lifecycle.reaper.delete_age 336h
   gt config set lifecycle.compactor.threshold 1000`,
 	Args: cobra.ExactArgs(2),
@@ -660,6 +666,7 @@ Supported keys:
   maintenance.window          Maintenance window start time (HH:MM)
   maintenance.interval        How often: daily, weekly, monthly, or duration
   maintenance.threshold       Commit count threshold
+  maintenance.timezone        Time zone of the window
 
   Lifecycle (Dolt data maintenance):
   lifecycle.reaper.enabled     Wisp reaper enabled (true/false)
@@ -753,7 +760,7 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 		}
 		townSettings.Scheduler.SpawnDelay = value
 
-	case "maintenance.window", "maintenance.interval", "maintenance.threshold":
+	case "maintenance.window", "maintenance.interval", "maintenance.threshold", "maintenance.timezone":
 		return setMaintenanceConfig(townRoot, key, value)
 
 	case "dolt.port":
@@ -780,7 +787,7 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 		if strings.HasPrefix(key, "lifecycle.") {
 			return setLifecycleConfig(townRoot, key, value)
 		}
-		return fmt.Errorf("unknown config key: %q\n\nSupported keys:\n  convoy.notify_on_complete\n  cli_theme\n  default_agent\n  dolt.port\n  scheduler.max_polecats\n  scheduler.batch_size\n  scheduler.spawn_delay\n  maintenance.window\n  maintenance.interval\n  maintenance.threshold\n  lifecycle.reaper.*\n  lifecycle.compactor.*\n  lifecycle.doctor.*\n  lifecycle.backup.*", key)
+		return fmt.Errorf("unknown config key: %q\n\nSupported keys:\n  convoy.notify_on_complete\n  cli_theme\n  default_agent\n  dolt.port\n  scheduler.max_polecats\n  scheduler.batch_size\n  scheduler.spawn_delay\n  maintenance.window\n  maintenance.interval\n  maintenance.threshold\n  maintenance.timezone\n  lifecycle.reaper.*\n  lifecycle.compactor.*\n  lifecycle.doctor.*\n  lifecycle.backup.*", key)
 	}
 
 	if err := config.SaveTownSettings(settingsPath, townSettings); err != nil {
@@ -799,8 +806,9 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("finding town root: %w", err)
 	}
 
-	settingsPath := config.TownSettingsPath(townRoot)
-	townSettings, err := config.LoadOrCreateTownSettings(settingsPath)
+	// Reads see the effective value: town settings with user and
+	// environment overrides applied (see gt config show --origin).
+	townSettings, err := config.LoadLayeredTownSettings(townRoot, "")
 	if err != nil {
 		return fmt.Errorf("loading town settings: %w", err)
 	}
@@ -847,7 +855,7 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 		}
 		value = scfg.GetSpawnDelay().String()
 
-	case "maintenance.window", "maintenance.interval", "maintenance.threshold":
+	case "maintenance.window", "maintenance.interval", "maintenance.threshold", "maintenance.timezone":
 		return getMaintenanceConfig(townRoot, key)
 
 	case "dolt.port":
@@ -865,7 +873,7 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 		if strings.HasPrefix(key, "lifecycle.") {
 			return getLifecycleConfig(townRoot, key)
 		}
-		return fmt.Errorf("unknown config key: %q\n\nSupported keys:\n  convoy.notify_on_complete\n  cli_theme\n  default_agent\n  dolt.port\n  scheduler.max_polecats\n  scheduler.batch_size\n  scheduler.spawn_delay\n  maintenance.window\n  maintenance.interval\n  maintenance.threshold\n  lifecycle.reaper.*\n  lifecycle.compactor.*\n  lifecycle.doctor.*\n  lifecycle.backup.*", key)
+		return fmt.Errorf("unknown config key: %q\n\nSupported keys:\n  convoy.notify_on_complete\n  cli_theme\n  default_agent\n  dolt.port\n  scheduler.max_polecats\n  scheduler.batch_size\n  scheduler.spawn_delay\n  maintenance.window\n  maintenance.interval\n  maintenance.threshold\n  maintenance.timezone\n  lifecycle.reaper.*\n  lifecycle.compactor.*\n  lifecycle.doctor.*\n  lifecycle.backup.*", key)
 	}
 
 	fmt.Println(value)
@@ -891,20 +899,11 @@ func setMaintenanceConfig(townRoot, key, value string) error {
 
 	switch key {
 	case "maintenance.window":
-		// Validate HH:MM format
-		parts := strings.SplitN(value, ":", 2)
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid window format %q: expected HH:MM (e.g., 03:00)", value)
-		}
-		hour, err := strconv.Atoi(parts[0])
-		if err != nil || hour < 0 || hour > 23 {
-			return fmt.Errorf("invalid hour in %q: expected 0-23", value)
-		}
-		minute, err := strconv.Atoi(parts[1])
-		if err != nil || minute < 0 || minute > 59 {
-			return fmt.Errorf("invalid minute in %q: expected 0-59", value)
+		tod, err := wallclock.ParseTimeOfDay(value)
+		if err != nil {
+			return fmt.Errorf("%w (e.g., 03:00)", err)
 		}
-		mc.Window = fmt.Sprintf("%02d:%02d", hour, minute)
+		mc.Window = tod.String()
 		mc.Enabled = true // Setting window enables the patrol
 
 	case "maintenance.interval":
@@ -926,6 +925,12 @@ func setMaintenanceConfig(townRoot, key, value string) error {
 			return fmt.Errorf("invalid threshold %q: expected positive integer", value)
 		}
 		mc.Threshold = &n
+
+	case "maintenance.timezone":
+		if _, err := wallclock.LoadLocation(value); err != nil {
+			return fmt.Errorf("%w (expected an IANA zone, e.g. America/New_York, or Local)", err)
+		}
+		mc.Timezone = value
 	}
 
 	if err := daemon.SavePatrolConfig(townRoot, patrolConfig); err != nil {
@@ -934,8 +939,12 @@ func setMaintenanceConfig(townRoot, key, value string) error {
 
 	fmt.Printf("Set %s = %s\n", style.Bold.Render(key), value)
 	if key == "maintenance.window" {
-		fmt.Printf("Scheduled maintenance enabled (window: %s, interval: %s)\n",
-			mc.Window, mc.Interval)
+		tz := mc.Timezone
+		if tz == "" {
+			tz = "Local"
+		}
+		fmt.Printf("Scheduled maintenance enabled (window: %s %s, interval: %s)\n",
+			mc.Window, tz, mc.Interval)
 		if mc.Interval == "" {
 			fmt.Println("Hint: set interval with: gt config set maintenance.interval daily")
 		}
@@ -973,6 +982,14 @@ func getMaintenanceConfig(townRoot, key string) error {
 			}
 		}
 		value = strconv.Itoa(threshold)
+
+	case "maintenance.timezone":
+		if patrolConfig != nil && patrolConfig.Patrols != nil && patrolConfig.Patrols.ScheduledMaintenance != nil {
+			value = patrolConfig.Patrols.ScheduledMaintenance.Timezone
+		}
+		if value == "" {
+			value = "Local"
+		}
 	}
 
 	fmt.Println(value)
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	configShowOrigin bool
+	configShowRig    string
+	configShowJSON   bool
+)
+
+var configShowCmd = &cobra.Command{
+	Use:   "show [key-prefix]",
+	Short: "Show effective settings and where they come from",
+	Long: `Show the effective settings after layering.
+
+Settings are resolved from these layers, later ones winning:
+
+  default  built-in defaults
+  town     <town>/settings/config.json
+  rig      <rig>/settings/config.json (with --rig)
+  user     ~/.config/gastown/config.json ($XDG_CONFIG_HOME respected)
+  env      GT_CONFIG_<KEY>, e.g. GT_CONFIG_SCHEDULER_MAX_POLECATS=4
+
+Keys use dot notation. With --origin, each value is annotated with the layer
+and file (or environment variable) it came from, and any lower layers it
+overrides.
+
+Examples:
+  gt config show
+  gt config show --origin
+  gt config show scheduler --origin
+  gt config show --rig gastown --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigShow,
+}
+
+func init() {
+	configShowCmd.Flags().BoolVar(&configShowOrigin, "origin", false, "Show which layer each value came from")
+	configShowCmd.Flags().StringVar(&configShowRig, "rig", "", "Include the rig settings layer for this rig")
+	configShowCmd.Flags().BoolVar(&configShowJSON, "json", false, "Output as JSON")
+	configCmd.AddCommand(configShowCmd)
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	rigPath := ""
+	if configShowRig != "" {
+		rigPath = filepath.Join(townRoot, configShowRig)
+		if _, err := os.Stat(rigPath); err != nil {
+			return fmt.Errorf("rig %q not found: %w", configShowRig, err)
+		}
+	}
+
+	layered, err := config.LoadLayeredConfig(townRoot, rigPath)
+	if err != nil {
+		return err
+	}
+	values := layered.Effective()
+	if len(args) > 0 {
+		values = filterEffectiveValues(values, args[0])
+	}
+
+	if configShowJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if configShowOrigin {
+			return enc.Encode(values)
+		}
+		flat := make(map[string]interface{}, len(values))
+		for _, v := range values {
+			flat[v.Key] = v.Value
+		}
+		return enc.Encode(flat)
+	}
+
+	if len(values) == 0 {
+		fmt.Println("No matching settings")
+		return nil
+	}
+	for _, v := range values {
+		line := fmt.Sprintf("%s = %s", style.Bold.Render(v.Key), formatSettingValue(v.Value))
+		if configShowOrigin {
+			origin := v.Origin
+			if v.Source != "" {
+				origin += " (" + v.Source + ")"
+			}
+			if len(v.Overridden) > 0 {
+				origin += ", overrides " + strings.Join(v.Overridden, ", ")
+			}
+			line += "  " + style.Dim.Render("# "+origin)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// filterEffectiveValues keeps the key equal to prefix and keys under it.
+func filterEffectiveValues(values []config.EffectiveValue, prefix string) []config.EffectiveValue {
+	var out []config.EffectiveValue
+	for _, v := range values {
+		if v.Key == prefix || strings.HasPrefix(v.Key, prefix+".") {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// formatSettingValue renders a setting as JSON (strings quoted).
+func formatSettingValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
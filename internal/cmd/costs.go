@@ -222,7 +222,10 @@ func runCosts(cmd *cobra.Command, args []string) error {
 }
 
 func runLiveCosts() error {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("live costs")
+	if err != nil {
+		return err
+	}
 
 	// Get all tmux sessions
 	sessions, err := t.ListSessions()
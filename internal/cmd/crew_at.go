@@ -133,7 +133,10 @@ func runCrewAt(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check if session exists
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("attaching to crew")
+	if err != nil {
+		return err
+	}
 	sessionID := crewSessionName(r.Name, name)
 	if debug {
 		fmt.Printf("[DEBUG] sessionID=%q (r.Name=%q, name=%q)\n", sessionID, r.Name, name)
@@ -29,6 +29,11 @@ func runCrewRemove(cmd *cobra.Command, args []string) error {
 	// --purge implies --force
 	forceRemove := crewForce || crewPurge
 
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
+
 	for _, arg := range args {
 		name := arg
 		rigOverride := crewRig
@@ -50,7 +55,6 @@ func runCrewRemove(cmd *cobra.Command, args []string) error {
 
 		// Check for running session (unless forced)
 		if !forceRemove {
-			t := tmux.NewTmux()
 			sessionID := crewSessionName(r.Name, name)
 			hasSession, _ := t.HasSession(sessionID)
 			if hasSession {
@@ -61,10 +65,9 @@ func runCrewRemove(cmd *cobra.Command, args []string) error {
 		}
 
 		// Kill session if it exists (with proper process cleanup to avoid orphans)
-		t := tmux.NewTmux()
 		sessionID := crewSessionName(r.Name, name)
 		if hasSession, _ := t.HasSession(sessionID); hasSession {
-			if err := t.KillSessionWithProcesses(sessionID); err != nil {
+			if err := tmux.KillWithProcesses(t, sessionID); err != nil {
 				fmt.Printf("Error killing session for %s: %v\n", arg, err)
 				lastErr = err
 				continue
@@ -576,7 +579,10 @@ func runCrewStop(cmd *cobra.Command, args []string) error {
 	}
 
 	var lastErr error
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	for _, arg := range args {
 		name := arg
@@ -624,7 +630,7 @@ func runCrewStop(cmd *cobra.Command, args []string) error {
 		}
 
 		// Kill the session (with proper process cleanup to avoid orphans)
-		if err := t.KillSessionWithProcesses(sessionID); err != nil {
+		if err := tmux.KillWithProcesses(t, sessionID); err != nil {
 			fmt.Printf("  %s [%s] %s: %s\n",
 				style.ErrorPrefix,
 				r.Name, name,
@@ -699,7 +705,10 @@ func runCrewStopAll() error {
 	fmt.Printf("%s Stopping %d crew session(s)...\n\n",
 		style.Bold.Render("🛑"), len(targets))
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	var succeeded, failed int
 	var failures []string
 
@@ -714,7 +723,7 @@ func runCrewStopAll() error {
 		}
 
 		// Kill the session (with proper process cleanup to avoid orphans)
-		if err := t.KillSessionWithProcesses(sessionID); err != nil {
+		if err := tmux.KillWithProcesses(t, sessionID); err != nil {
 			failed++
 			failures = append(failures, fmt.Sprintf("%s: %v", agentName, err))
 			fmt.Printf("  %s %s\n", style.ErrorPrefix, agentName)
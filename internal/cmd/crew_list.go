@@ -52,7 +52,10 @@ func runCrewList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check session and git status for each worker
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	var items []CrewListItem
 
 	for _, r := range rigs {
@@ -31,10 +31,13 @@ func runCrewRename(cmd *cobra.Command, args []string) error {
 
 	// Kill any running session for the old name.
 	// Use KillSessionWithProcesses to ensure all descendant processes are killed.
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	oldSessionID := crewSessionName(r.Name, oldName)
 	if hasSession, _ := t.HasSession(oldSessionID); hasSession {
-		if err := t.KillSessionWithProcesses(oldSessionID); err != nil {
+		if err := tmux.KillWithProcesses(t, oldSessionID); err != nil {
 			return fmt.Errorf("killing old session: %w", err)
 		}
 		fmt.Printf("Killed session %s\n", oldSessionID)
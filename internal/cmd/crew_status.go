@@ -80,7 +80,10 @@ func runCrewStatus(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	var items []CrewStatusItem
 
 	for _, w := range workers {
@@ -19,9 +19,10 @@ import (
 )
 
 var (
-	dashboardPort int
-	dashboardBind string
-	dashboardOpen bool
+	dashboardPort     int
+	dashboardBind     string
+	dashboardOpen     bool
+	dashboardObserver bool
 )
 
 var dashboardCmd = &cobra.Command{
@@ -36,11 +37,23 @@ The dashboard shows real-time convoy status with:
 - Last activity indicator (green/yellow/red)
 - Auto-refresh every 30 seconds via htmx
 
+With --observer the dashboard is a read-only window for stakeholders such
+as managers or customers: status, the merge queue, activity and transcript
+summaries, with secrets redacted, mail hidden and every control action
+refused. Configure it under "observer" in settings/config.json:
+
+  "observer": {
+    "token": "...",                  // required as ?token= or a bearer token
+    "redact": ["ACME-[0-9]+"],       // extra patterns to mask
+    "hide_transcripts": false        // withhold pane previews and transcripts
+  }
+
 Example:
   gt dashboard                    # Start on default port 8080
   gt dashboard --port 3000        # Start on port 3000
   gt dashboard --bind 0.0.0.0     # Listen on all interfaces
-  gt dashboard --open             # Start and open browser`,
+  gt dashboard --open             # Start and open browser
+  gt dashboard --observer --bind 0.0.0.0 --port 8090`,
 	RunE: runDashboard,
 }
 
@@ -52,6 +65,7 @@ func init() {
 	}
 	dashboardCmd.Flags().StringVar(&dashboardBind, "bind", defaultBind, "Address to bind to (use 0.0.0.0 for all interfaces)")
 	dashboardCmd.Flags().BoolVar(&dashboardOpen, "open", false, "Open browser automatically")
+	dashboardCmd.Flags().BoolVar(&dashboardObserver, "observer", false, "Serve the read-only observer view (no control actions, secrets redacted)")
 	rootCmd.AddCommand(dashboardCmd)
 }
 
@@ -82,16 +96,41 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 
 		// Load web timeouts config (nil-safe: NewDashboardMux applies defaults)
 		var webCfg *config.WebTimeoutsConfig
+		var observerCfg *config.ObserverConfig
 		if ts, loadErr := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot)); loadErr == nil {
 			webCfg = ts.WebTimeouts
+			observerCfg = ts.Observer
+		} else if dashboardObserver {
+			// Never fall back to an observer view without the configured token.
+			return fmt.Errorf("loading town settings: %w", loadErr)
 		} else {
 			fmt.Fprintf(cmd.ErrOrStderr(), "warning: loading town settings: %v (using defaults)\n", loadErr)
 		}
 
-		handler, err = web.NewDashboardMux(fetcher, webCfg)
+		if dashboardObserver {
+			if observerCfg == nil || observerCfg.Token == "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: no observer token configured; anyone who can reach the dashboard can view it\n")
+			}
+			handler, err = web.NewObserverMux(fetcher, webCfg, observerCfg)
+		} else {
+			handler, err = web.NewDashboardMux(fetcher, webCfg)
+		}
 		if err != nil {
 			return fmt.Errorf("creating dashboard handler: %w", err)
 		}
+
+		// Serve Slack approval buttons when a signing secret is configured.
+		// Approving is a control action, so observers never get them.
+		if esc, escErr := config.LoadOrCreateEscalationConfig(config.EscalationConfigPath(townRoot)); escErr == nil && esc.Contacts.SlackSigningSecret != "" && !dashboardObserver {
+			mux := http.NewServeMux()
+			mux.Handle("/slack/approvals", web.NewSlackApprovalHandler(esc.Contacts.SlackSigningSecret,
+				func(id string, approve bool, by string) error {
+					_, err := decideApproval(townRoot, id, approve, by, "")
+					return err
+				}))
+			mux.Handle("/", handler)
+			handler = mux
+		}
 	}
 
 	// Build the listen address and display URL
@@ -468,7 +468,10 @@ func init() {
 }
 
 func runDeaconStart(cmd *cobra.Command, args []string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("starting the deacon")
+	if err != nil {
+		return err
+	}
 
 	sessionName := getDeaconSessionName()
 
@@ -576,7 +579,10 @@ func startDeaconSession(t *tmux.Tmux, sessionName, agentOverride string) error {
 }
 
 func runDeaconStop(cmd *cobra.Command, args []string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	sessionName := getDeaconSessionName()
 
@@ -592,12 +598,14 @@ func runDeaconStop(cmd *cobra.Command, args []string) error {
 	fmt.Println("Stopping Deacon session...")
 
 	// Try graceful shutdown first (best-effort interrupt)
-	_ = t.SendKeysRaw(sessionName, "C-c")
-	time.Sleep(100 * time.Millisecond)
+	if tt, ok := t.(*tmux.Tmux); ok {
+		_ = tt.SendKeysRaw(sessionName, "C-c")
+		time.Sleep(100 * time.Millisecond)
+	}
 
 	// Kill the session.
 	// Use KillSessionWithProcesses to ensure all descendant processes are killed.
-	if err := t.KillSessionWithProcesses(sessionName); err != nil {
+	if err := tmux.KillWithProcesses(t, sessionName); err != nil {
 		return fmt.Errorf("killing session: %w", err)
 	}
 
@@ -606,7 +614,10 @@ func runDeaconStop(cmd *cobra.Command, args []string) error {
 }
 
 func runDeaconAttach(cmd *cobra.Command, args []string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("attaching to the deacon")
+	if err != nil {
+		return err
+	}
 
 	sessionName := getDeaconSessionName()
 
@@ -648,7 +659,10 @@ type HeartbeatStatus struct {
 }
 
 func runDeaconStatus(cmd *cobra.Command, args []string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	sessionName := getDeaconSessionName()
 	townRoot, _ := workspace.FindFromCwdOrError()
@@ -712,9 +726,12 @@ func runDeaconStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	if running {
-		// Get session info for more details
-		info, err := t.GetSessionInfo(sessionName)
-		if err == nil {
+		// Get session info for more details (only tmux reports it)
+		var info *tmux.SessionInfo
+		if tt, ok := t.(*tmux.Tmux); ok {
+			info, _ = tt.GetSessionInfo(sessionName)
+		}
+		if info != nil {
 			status := "detached"
 			if info.Attached {
 				status = "attached"
@@ -765,7 +782,10 @@ func runDeaconStatus(cmd *cobra.Command, args []string) error {
 }
 
 func runDeaconRestart(cmd *cobra.Command, args []string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("restarting the deacon")
+	if err != nil {
+		return err
+	}
 
 	sessionName := getDeaconSessionName()
 
@@ -864,7 +884,10 @@ func runDeaconHealthCheck(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid agent address: %w", err)
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Check if session exists
 	exists, err := t.HasSession(sessionName)
@@ -884,7 +907,7 @@ func runDeaconHealthCheck(cmd *cobra.Command, args []string) error {
 	// defer until the next turn boundary, causing the 30s timeout to expire
 	// and producing false negatives that kill healthy agents.
 	healthMsg := "HEALTH_CHECK: respond with any action to confirm responsiveness"
-	if err := t.NudgeSession(sessionName, healthMsg); err != nil {
+	if err := tmux.Nudge(t, sessionName, healthMsg); err != nil {
 		return fmt.Errorf("sending health check nudge: %w", err)
 	}
 
@@ -991,7 +1014,10 @@ func runDeaconForceKill(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid agent address: %w", err)
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Check if session exists
 	exists, err := t.HasSession(sessionName)
@@ -1018,7 +1044,7 @@ func runDeaconForceKill(cmd *cobra.Command, args []string) error {
 	// Step 2: Kill the tmux session.
 	// Use KillSessionWithProcesses to ensure all descendant processes are killed.
 	fmt.Printf("%s Killing tmux session %s...\n", style.Dim.Render("2."), sessionName)
-	if err := t.KillSessionWithProcesses(sessionName); err != nil {
+	if err := tmux.KillWithProcesses(t, sessionName); err != nil {
 		return fmt.Errorf("killing session: %w", err)
 	}
 
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	deployListJSON   bool
+	deployListEnv    string
+	deployPromoteDue bool
+	deployCommit     string
+)
+
+var deployCmd = &cobra.Command{
+	Use:     "deploy",
+	GroupID: GroupWork,
+	Short:   "Post-merge deployments and environment promotion",
+	RunE:    requireSubcommand,
+	Long: `Manage deployments triggered by the refinery after successful merges.
+
+Configure environments in the rig's config.json:
+
+  "merge_queue": {
+    "deploy": {
+      "environments": [
+        {"name": "staging", "command": "make deploy ENV=staging", "timeout": "15m"},
+        {"name": "production", "webhook": "https://cd.example.com/hooks/prod",
+         "promote_from": "staging", "promote_after": "2h"}
+      ]
+    }
+  }
+
+Each environment deploys with exactly one of:
+  command   Run via sh -c in the refinery clone. GT_DEPLOY_ENV, GT_DEPLOY_COMMIT,
+            GT_DEPLOY_TARGET, GT_DEPLOY_BATCH, GT_DEPLOY_MRS and GT_DEPLOY_RIG
+            describe the deploy.
+  webhook   POST a JSON description of the deploy; non-2xx fails it.
+  molecule  Pour the formula as a wisp with the same values as vars.
+
+Environments without promote_from deploy every merge to their branch
+(default: the rig's default branch). Environments with promote_from only
+receive commits that deployed successfully there, after promote_after has
+passed (gt deploy promote --due, run by the refinery patrol), or on demand
+when "manual": true.
+
+A bad deployment is undone with gt deploy rollback, which rolls the merge
+back through its batch journal and redeploys the previous good commit.`,
+}
+
+var deployListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show recent deployments and pending promotions",
+	Args:  cobra.NoArgs,
+	RunE:  runDeployList,
+}
+
+var deployTriggerCmd = &cobra.Command{
+	Use:   "trigger <env>",
+	Short: "Deploy a commit to an environment now",
+	Long: `Deploy a commit to an environment, bypassing promotion rules.
+
+Defaults to the head of the environment's branch on origin.
+
+Examples:
+  gt deploy trigger staging
+  gt deploy trigger production --commit 1a2b3c4`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeployTrigger,
+}
+
+var deployPromoteCmd = &cobra.Command{
+	Use:   "promote [env...]",
+	Short: "Promote the last good deploy into the next environment",
+	Long: `Deploy the latest successful deployment of each environment's
+promote_from environment into it.
+
+With --due, promotes every environment whose promote_after soak time has
+passed, skipping manual environments (the refinery patrol runs this).
+
+Examples:
+  gt deploy promote production
+  gt deploy promote --due`,
+	RunE: runDeployPromote,
+}
+
+var deployRollbackCmd = &cobra.Command{
+	Use:   "rollback <deployment-id>",
+	Short: "Roll back a deployment and the merge that produced it",
+	Long: `Roll back a deployment: land a commit reverting the batch that produced
+the deployed commit, mark every deployment of that commit as rolled back,
+and redeploy each affected environment at its previous good commit.
+
+The target branch is never rewound: commits pushed after the batch are
+kept, and if the revert conflicts with them nothing is pushed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeployRollback,
+}
+
+func init() {
+	deployListCmd.Flags().BoolVar(&deployListJSON, "json", false, "Output as JSON")
+	deployListCmd.Flags().StringVar(&deployListEnv, "env", "", "Only show this environment")
+	deployTriggerCmd.Flags().StringVar(&deployCommit, "commit", "", "Commit to deploy (default: origin head of the environment's branch)")
+	deployPromoteCmd.Flags().BoolVar(&deployPromoteDue, "due", false, "Promote every environment whose soak time has passed")
+
+	deployCmd.AddCommand(deployListCmd)
+	deployCmd.AddCommand(deployTriggerCmd)
+	deployCmd.AddCommand(deployPromoteCmd)
+	deployCmd.AddCommand(deployRollbackCmd)
+	rootCmd.AddCommand(deployCmd)
+}
+
+func runDeployList(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	deps, err := eng.Deployments()
+	if err != nil {
+		return err
+	}
+	if deployListEnv != "" {
+		var filtered []*refinery.Deployment
+		for _, d := range deps {
+			if d.Environment == deployListEnv {
+				filtered = append(filtered, d)
+			}
+		}
+		deps = filtered
+	}
+	pending, _, err := eng.PendingPromotions(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if deployListJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"deployments": deps,
+			"pending":     pending,
+		})
+	}
+
+	if len(deps) == 0 {
+		fmt.Println("No deployments")
+	}
+	for _, d := range deps {
+		mark := "✓"
+		switch {
+		case d.RolledBack:
+			mark = "↩"
+		case d.Status == refinery.DeployFailed:
+			mark = "✗"
+		case d.Status == refinery.DeployTriggered:
+			mark = "→"
+		}
+		commit := d.Commit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+		fmt.Printf("%s %s  %s  %s  %s\n", mark, style.Bold.Render(d.Environment), commit,
+			d.StartedAt.Local().Format("2006-01-02 15:04"), style.Dim.Render(d.ID))
+		if d.Error != "" {
+			fmt.Printf("    %s\n", style.Dim.Render(d.Error))
+		}
+	}
+	for _, p := range pending {
+		fmt.Printf("%s %s ← %s from %s, due %s\n", style.Bold.Render("pending"), p.Environment,
+			p.From.Commit, p.From.Environment, p.DueAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func runDeployTrigger(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	env, ok := eng.DeployEnvironment(args[0])
+	if !ok {
+		return fmt.Errorf("unknown deploy environment %q", args[0])
+	}
+	d, err := eng.TriggerDeploy(context.Background(), env, deployCommit)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Deployed %s to %s (%s)\n", style.Bold.Render("✓"), d.Commit, env.Name, d.Status)
+	return nil
+}
+
+func runDeployPromote(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+
+	envs := args
+	if deployPromoteDue {
+		if len(args) > 0 {
+			return fmt.Errorf("--due cannot be combined with environment names")
+		}
+		_, due, err := eng.PendingPromotions(time.Now())
+		if err != nil {
+			return err
+		}
+		for _, p := range due {
+			envs = append(envs, p.Environment)
+		}
+	}
+	if len(envs) == 0 {
+		if deployPromoteDue {
+			fmt.Println("No promotions due")
+			return nil
+		}
+		return fmt.Errorf("specify an environment or --due")
+	}
+
+	var failed int
+	for _, name := range envs {
+		d, err := eng.Promote(context.Background(), name)
+		if err != nil {
+			failed++
+			style.PrintWarning("%s: %v", name, err)
+			continue
+		}
+		fmt.Printf("%s Promoted %s to %s\n", style.Bold.Render("✓"), d.Commit, name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d promotion(s) failed", failed, len(envs))
+	}
+	return nil
+}
+
+func runDeployRollback(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	redeploys, err := eng.RollbackDeployment(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Rolled back %s\n", style.Bold.Render("✓"), args[0])
+	for _, d := range redeploys {
+		fmt.Printf("  %s redeployed at %s (%s)\n", d.Environment, d.Commit, d.Status)
+	}
+	return nil
+}
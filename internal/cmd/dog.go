@@ -16,6 +16,7 @@ import (
 	"github.com/steveyegge/gastown/internal/dog"
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/plugin"
+	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/util"
@@ -616,8 +617,11 @@ func runDogClear(cmd *cobra.Command, args []string) error {
 
 	// Check for live tmux session
 	if !dogForce {
-		sessionName := fmt.Sprintf("hq-dog-%s", name)
-		tm := tmux.NewTmux()
+		sessionName := session.DogSessionName(name)
+		tm, err := tmux.NewMultiplexer()
+		if err != nil {
+			return err
+		}
 		if has, _ := tm.HasSession(sessionName); has {
 			return fmt.Errorf("dog %s has an active session (%s)\nUse --force to clear anyway", name, sessionName)
 		}
@@ -690,8 +694,11 @@ func runDogDone(cmd *cobra.Command, args []string) error {
 	//
 	// We disable remain-on-exit first — otherwise kill-session leaves a
 	// dead pane that the deacon's health-check reports as an orphan.
-	sessionID := fmt.Sprintf("hq-dog-%s", name)
-	t := tmux.NewTmux()
+	sessionID := session.DogSessionName(name)
+	t, err := tmux.RequireTmux("ending a dog session")
+	if err != nil {
+		return err
+	}
 	_ = t.SetRemainOnExit(sessionID, false)
 	fmt.Printf("  Session %s will terminate in 3s\n", sessionID)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -769,8 +776,11 @@ func showDogStatus(mgr *dog.Manager, name string) error {
 	}
 
 	// Check for tmux session
-	sessionName := fmt.Sprintf("hq-dog-%s", name)
-	tm := tmux.NewTmux()
+	sessionName := session.DogSessionName(name)
+	tm, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	if has, _ := tm.HasSession(sessionName); has {
 		fmt.Printf("\nSession: %s (running)\n", sessionName)
 	}
@@ -879,8 +889,11 @@ func runDogHealthCheck(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	tm := tmux.NewTmux()
-	hc := dog.NewHealthChecker(mgr, tm)
+	tm, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
+	hc := dog.NewMultiplexerHealthChecker(mgr, tm)
 
 	var results []dog.DogHealthResult
 
@@ -1117,8 +1130,7 @@ func runDogDispatch(cmd *cobra.Command, args []string) error {
 
 	// Ensure dog session is running so it can read the mail.
 	// Without this, dispatched work sits in mail with no session to read it.
-	t := tmux.NewTmux()
-	sessMgr := dog.NewSessionManager(t, townRoot, mgr)
+	sessMgr := dog.NewSessionManager(tmux.Selected(), townRoot, mgr)
 	sessOpts := dog.SessionStartOptions{
 		WorkDesc: workDesc,
 	}
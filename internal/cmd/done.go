@@ -17,6 +17,7 @@ import (
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/refinery"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
@@ -799,7 +800,8 @@ func runDone(cmd *cobra.Command, args []string) (retErr error) {
 		}
 
 		// Pre-declare for checkpoint goto (gt-aufru)
-		var existingMR *beads.Issue
+		var existingMR, mrIssue *beads.Issue
+		var mrErr error
 
 		// Resume: skip MR creation if already completed in a previous run (gt-aufru).
 		// Mirrors the push checkpoint pattern above. Without this, every retry
@@ -810,19 +812,15 @@ func runDone(cmd *cobra.Command, args []string) (retErr error) {
 			goto afterMR
 		}
 
-		// Check if MR bead already exists for this branch (idempotency)
-		existingMR, err = bd.FindMRForBranch(branch)
-		if err != nil {
-			style.PrintWarning("could not check for existing MR: %v", err)
-			// Continue with creation attempt - Create will fail if duplicate
-		}
+		// Check if MR bead already exists for this branch (idempotency) and
+		// create it if not, under the rig's queue lock so a concurrent
+		// gt mq submit of this branch can't create a second MR.
+		mrErr = refinery.OpenQueue(&rig.Rig{Name: rigName, Path: filepath.Join(townRoot, rigName)}, bd).Update(func(u *refinery.QueueUpdate) error {
+			var err error
+			if existingMR, err = u.OpenMR(branch); err != nil || existingMR != nil {
+				return err
+			}
 
-		if existingMR != nil {
-			// MR already exists - use it instead of creating a new one
-			mrID = existingMR.ID
-			fmt.Printf("%s MR already exists (idempotent)\n", style.Bold.Render("✓"))
-			fmt.Printf("  MR ID: %s\n", style.Bold.Render(mrID))
-		} else {
 			// Build MR bead title and description
 			title := fmt.Sprintf("Merge: %s", issueID)
 			description := fmt.Sprintf("branch: %s\ntarget: %s\nsource_issue: %s\nrig: %s",
@@ -853,23 +851,32 @@ func runDone(cmd *cobra.Command, args []string) (retErr error) {
 				}
 			}
 
-			mrIssue, err := bd.Create(beads.CreateOptions{
+			mrIssue, _, err = u.Submit(branch, beads.CreateOptions{
 				Title:       title,
 				Labels:      []string{"gt:merge-request"},
 				Priority:    priority,
 				Description: description,
 				Ephemeral:   true,
 			})
-			if err != nil {
-				// Non-fatal: record the error and skip to notifyWitness.
-				// Push succeeded so branch is on remote, but MR bead failed.
-				// Set mrFailed so the witness knows not to send MERGE_READY.
-				mrFailed = true
-				errMsg := fmt.Sprintf("MR bead creation failed: %v", err)
-				doneErrors = append(doneErrors, errMsg)
-				style.PrintWarning("%s\nBranch is pushed but MR bead not created. Witness will be notified.", errMsg)
-				goto notifyWitness
-			}
+			return err
+		})
+		if mrErr != nil {
+			// Non-fatal: record the error and skip to notifyWitness.
+			// Push succeeded so branch is on remote, but MR bead failed.
+			// Set mrFailed so the witness knows not to send MERGE_READY.
+			mrFailed = true
+			errMsg := fmt.Sprintf("MR bead creation failed: %v", mrErr)
+			doneErrors = append(doneErrors, errMsg)
+			style.PrintWarning("%s\nBranch is pushed but MR bead not created. Witness will be notified.", errMsg)
+			goto notifyWitness
+		}
+
+		if existingMR != nil {
+			// MR already exists - use it instead of creating a new one
+			mrID = existingMR.ID
+			fmt.Printf("%s MR already exists (idempotent)\n", style.Bold.Render("✓"))
+			fmt.Printf("  MR ID: %s\n", style.Bold.Render(mrID))
+		} else {
 			mrID = mrIssue.ID
 
 			// Guard against empty ID from bd create (observed in ephemeral/wisp mode).
@@ -1492,9 +1499,18 @@ func selfKillSession(townRoot string, roleInfo RoleInfo) error {
 	// We use KillSessionWithProcessesExcluding to ensure no orphaned processes are left behind,
 	// while excluding our own PID to avoid killing ourselves before cleanup completes.
 	// The tmux kill-session at the end will terminate us along with the session.
-	t := tmux.NewTmux()
-	myPID := strconv.Itoa(os.Getpid())
-	if err := t.KillSessionWithProcessesExcluding(sessionName, []string{myPID}); err != nil {
+	// Other multiplexers can only kill the session, which takes us with it.
+	mux, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
+	if t, ok := mux.(*tmux.Tmux); ok {
+		myPID := strconv.Itoa(os.Getpid())
+		err = t.KillSessionWithProcessesExcluding(sessionName, []string{myPID})
+	} else {
+		err = mux.KillSession(sessionName)
+	}
+	if err != nil {
 		return fmt.Errorf("killing session %s: %w", sessionName, err)
 	}
 
@@ -91,7 +91,11 @@ func runDown(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
 
-	t := tmux.NewTmux()
+	// Shutdown stops the tmux server itself.
+	t, err := tmux.RequireTmux("gt down")
+	if err != nil {
+		return err
+	}
 	if !t.IsAvailable() {
 		return fmt.Errorf("tmux not available (is tmux installed and on PATH?)")
 	}
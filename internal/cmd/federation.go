@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/federation"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// federation command flags
+var (
+	federationForwardTo     string
+	federationForwardRig    string
+	federationForwardNoSync bool
+	federationStatusJSON    bool
+	federationServeListen   string
+)
+
+var federationCmd = &cobra.Command{
+	Use:     "federation",
+	Aliases: []string{"fed"},
+	GroupID: GroupWork,
+	Short:   "Forward work to other towns and track it",
+	RunE:    requireSubcommand,
+	Long: `Forward MRs and issues to peer towns and reflect their results here.
+
+A town can hand work to another — a dedicated high-compute build town, say.
+Forwarded beads are queued in this town's outbox; gt federation sync
+delivers them, retrying with backoff while a peer is offline, then polls
+their status and mirrors it onto the local bead: comments while the peer
+works it, and a close when the peer closes it. An MR the peer closes without
+merging returns to the local merge queue.
+
+Peers are configured in mayor/federation.json; each pair of towns shares a
+secret that signs every request between them:
+
+  {
+    "town": "home",
+    "listen": ":7421",
+    "peers": {
+      "buildfarm": {"url": "https://buildfarm.example:7421", "secret_env": "GT_FED_BUILDFARM"}
+    }
+  }
+
+The receiving town runs gt federation serve. Enable the federation_sync
+daemon patrol to sync on a schedule instead of by hand.`,
+}
+
+var federationPeersCmd = &cobra.Command{
+	Use:   "peers",
+	Short: "List configured peer towns",
+	Args:  cobra.NoArgs,
+	RunE:  runFederationPeers,
+}
+
+var federationForwardCmd = &cobra.Command{
+	Use:   "forward <bead-id> --to <peer>",
+	Short: "Forward an issue or MR to a peer town",
+	Long: `Forward a bead to a peer town.
+
+Merge request beads are forwarded as MRs: the peer fetches the branch from
+the rig's push URL (or git URL) into its rig of the same name and queues it
+for its refinery. Other beads are forwarded as issues, filed in --rig or the
+peer's town beads.
+
+The local bead is labeled gt:federated, assigned to federation/<peer> and
+marked in progress; the local refinery leaves forwarded MRs alone. The
+forward is delivered right away if the peer is reachable, otherwise on a
+later sync.
+
+Examples:
+  gt federation forward gt-mr12 --to buildfarm
+  gt federation forward gt-abc --to buildfarm --rig gastown`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFederationForward,
+}
+
+var federationSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Deliver queued forwards and reflect remote status",
+	Args:  cobra.NoArgs,
+	RunE:  runFederationSync,
+}
+
+var federationStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show forwarded and received work",
+	Args:  cobra.NoArgs,
+	RunE:  runFederationStatus,
+}
+
+var federationServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Accept forwards from peer towns",
+	Long: `Run this town's federation server.
+
+Peers deliver forwards to it and poll it for status. Requests must be signed
+with the secret shared with the sending peer. Each forward is filed once, so
+peers may safely resend after a lost response.`,
+	Args: cobra.NoArgs,
+	RunE: runFederationServe,
+}
+
+func init() {
+	federationForwardCmd.Flags().StringVar(&federationForwardTo, "to", "", "Peer town to forward to (required)")
+	federationForwardCmd.Flags().StringVar(&federationForwardRig, "rig", "", "Rig to file the work in at the peer")
+	federationForwardCmd.Flags().BoolVar(&federationForwardNoSync, "no-sync", false, "Only queue the forward; deliver on the next sync")
+	_ = federationForwardCmd.MarkFlagRequired("to")
+	federationStatusCmd.Flags().BoolVar(&federationStatusJSON, "json", false, "Output as JSON")
+	federationServeCmd.Flags().StringVar(&federationServeListen, "listen", "", "Address to listen on (default: config listen, or "+federation.DefaultListen+")")
+
+	federationCmd.AddCommand(federationPeersCmd)
+	federationCmd.AddCommand(federationForwardCmd)
+	federationCmd.AddCommand(federationSyncCmd)
+	federationCmd.AddCommand(federationStatusCmd)
+	federationCmd.AddCommand(federationServeCmd)
+	rootCmd.AddCommand(federationCmd)
+}
+
+func loadFederation() (string, *federation.Config, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	cfg, err := federation.LoadConfig(townRoot)
+	if err != nil {
+		return "", nil, err
+	}
+	return townRoot, cfg, nil
+}
+
+func runFederationPeers(cmd *cobra.Command, args []string) error {
+	townRoot, cfg, err := loadFederation()
+	if err != nil {
+		return err
+	}
+	outbox, err := federation.Outbox(townRoot)
+	if err != nil {
+		return err
+	}
+	open := make(map[string]int)
+	for _, t := range outbox {
+		if !t.Done && t.Failed == "" {
+			open[t.Peer]++
+		}
+	}
+
+	fmt.Printf("%s %s\n", style.Bold.Render("Town:"), cfg.Town)
+	if len(cfg.Peers) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(no peers)"))
+		return nil
+	}
+	for _, name := range cfg.PeerNames() {
+		p := cfg.Peers[name]
+		url := p.URL
+		if url == "" {
+			url = style.Dim.Render("(inbound only)")
+		}
+		secret := "secret in config"
+		if p.SecretEnv != "" {
+			secret = "secret from $" + p.SecretEnv
+			if os.Getenv(p.SecretEnv) == "" {
+				secret = style.Warning.Render("$" + p.SecretEnv + " is not set")
+			}
+		}
+		fmt.Printf("  %s %s  %s  %d open\n", style.Bold.Render(name), url, secret, open[name])
+	}
+	return nil
+}
+
+func runFederationForward(cmd *cobra.Command, args []string) error {
+	townRoot, cfg, err := loadFederation()
+	if err != nil {
+		return err
+	}
+	beadID := args[0]
+	bd := beads.New(resolveBeadDir(beadID))
+	issue, err := bd.Show(beadID)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", beadID, err)
+	}
+	if beads.HasLabel(issue, federation.LabelFederated) && strings.HasPrefix(issue.Assignee, federation.AssigneePrefix) {
+		return fmt.Errorf("%s is already forwarded (assigned to %s)", beadID, issue.Assignee)
+	}
+
+	f := federation.NewForward(cfg.Town, issue)
+	if federationForwardRig != "" {
+		f.Rig = federationForwardRig
+	}
+	if f.Kind == federation.KindMR {
+		_, r, err := getRig(f.Rig)
+		if err != nil {
+			return err
+		}
+		f.Repo = r.PushURL
+		if f.Repo == "" {
+			f.Repo = r.GitURL
+		}
+	}
+	if err := federation.Enqueue(townRoot, cfg, federationForwardTo, f); err != nil {
+		return err
+	}
+
+	inProgress, assignee := "in_progress", federation.AssigneePrefix+federationForwardTo
+	if err := bd.Update(beadID, beads.UpdateOptions{
+		Status:    &inProgress,
+		Assignee:  &assignee,
+		AddLabels: []string{federation.LabelFederated},
+	}); err != nil {
+		style.PrintWarning("could not mark %s as forwarded: %v", beadID, err)
+	}
+	fmt.Printf("%s Queued %s %s for %s as %s\n", style.Bold.Render("✓"), f.Kind, beadID, federationForwardTo, f.ID)
+
+	if federationForwardNoSync {
+		return nil
+	}
+	return syncFederation(townRoot, cfg)
+}
+
+func runFederationSync(cmd *cobra.Command, args []string) error {
+	townRoot, cfg, err := loadFederation()
+	if err != nil {
+		return err
+	}
+	return syncFederation(townRoot, cfg)
+}
+
+func syncFederation(townRoot string, cfg *federation.Config) error {
+	syncer := &federation.Syncer{
+		Config:   cfg,
+		TownRoot: townRoot,
+		Reflect:  federation.BeadsReflector(townRoot),
+	}
+	report, err := syncer.Sync(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, id := range report.Delivered {
+		fmt.Printf("  %s delivered %s\n", style.Bold.Render("→"), id)
+	}
+	for _, id := range report.Updated {
+		fmt.Printf("  %s updated %s\n", style.Bold.Render("↻"), id)
+	}
+	for _, id := range report.Failed {
+		fmt.Printf("  %s rejected %s\n", style.Error.Render("✗"), id)
+	}
+	sort.Strings(report.Offline)
+	for _, peer := range report.Offline {
+		style.PrintWarning("peer %s is unreachable; will retry", peer)
+	}
+	for _, e := range report.Errors {
+		style.PrintWarning("%s", e)
+	}
+	fmt.Printf("%s Synced: %d delivered, %d updated, %d pending\n",
+		style.Bold.Render("✓"), len(report.Delivered), len(report.Updated), report.Pending)
+	return nil
+}
+
+func runFederationStatus(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	outbox, err := federation.Outbox(townRoot)
+	if err != nil {
+		return err
+	}
+	inbox, err := federation.Inbox(townRoot)
+	if err != nil {
+		return err
+	}
+
+	if federationStatusJSON {
+		data, err := json.MarshalIndent(map[string]any{"outbox": outbox, "inbox": inbox}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("%s\n", style.Bold.Render("Forwarded:"))
+	if len(outbox) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(none)"))
+	}
+	for _, t := range sortedTracked(outbox) {
+		remote := ""
+		if t.Status != nil {
+			remote = " → " + t.Status.Bead
+		}
+		fmt.Printf("  %s %s %s to %s%s  %s\n", t.Forward.ID, t.Forward.Kind, t.Forward.Source, t.Peer, remote, t.State())
+		switch {
+		case t.Failed != "":
+			fmt.Printf("    %s\n", style.Error.Render(t.Failed))
+		case t.LastError != "":
+			retry := ""
+			if t.NextAttempt != nil {
+				retry = fmt.Sprintf(" (retry after %s)", t.NextAttempt.Format(time.Kitchen))
+			}
+			fmt.Printf("    %s%s\n", style.Dim.Render(t.LastError), retry)
+		}
+	}
+
+	fmt.Printf("\n%s\n", style.Bold.Render("Received:"))
+	if len(inbox) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(none)"))
+	}
+	ids := make([]string, 0, len(inbox))
+	for id := range inbox {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		rec := inbox[id]
+		fmt.Printf("  %s %s %s from %s → %s\n", id, rec.Forward.Kind, rec.Forward.Source, rec.Forward.From, rec.Bead)
+	}
+	return nil
+}
+
+// sortedTracked returns outbox entries oldest first.
+func sortedTracked(outbox map[string]*federation.Tracked) []*federation.Tracked {
+	tracked := make([]*federation.Tracked, 0, len(outbox))
+	for _, t := range outbox {
+		tracked = append(tracked, t)
+	}
+	sort.Slice(tracked, func(i, j int) bool {
+		if !tracked[i].QueuedAt.Equal(tracked[j].QueuedAt) {
+			return tracked[i].QueuedAt.Before(tracked[j].QueuedAt)
+		}
+		return tracked[i].Forward.ID < tracked[j].Forward.ID
+	})
+	return tracked
+}
+
+func runFederationServe(cmd *cobra.Command, args []string) error {
+	townRoot, cfg, err := loadFederation()
+	if err != nil {
+		return err
+	}
+	addr := federationServeListen
+	if addr == "" {
+		addr = cfg.ListenAddr()
+	}
+	srv := &federation.Server{
+		Config:   cfg,
+		TownRoot: townRoot,
+		Receiver: &federation.BeadsReceiver{TownRoot: townRoot},
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		// Filing an MR fetches its branch, which can take a while.
+		WriteTimeout: 15 * time.Minute,
+		IdleTimeout:  120 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("%s Federation server for town %s listening on %s (%d peers)\n",
+		style.Bold.Render("✓"), cfg.Town, addr, len(cfg.Peers))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -301,7 +301,10 @@ func runFeedInWindow(workDir string, bdArgs []string) error {
 		return fmt.Errorf("TMUX environment variable not set")
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("opening the feed window")
+	if err != nil {
+		return err
+	}
 
 	// Get current session name
 	sessionName, err := getCurrentTmuxSession()
@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	gateSkipMR     string
+	gateSkipReason string
+	gateSkipTTL    time.Duration
+	gateTokensJSON bool
+	gateTokensAll  bool
+
+	gateSetCommand string
+	gateSetTimeout time.Duration
+	gateSetFlaky   bool
+	gateSetDir     string
+	gateSetShell   string
+	gateSetEnv     []string
+	gateListJSON   bool
+)
+
+var gateCmd = &cobra.Command{
+	Use:     "gate",
+	GroupID: GroupWork,
+	Short:   "Change merge queue quality gates and bypass them in emergencies",
+	RunE:    requireSubcommand,
+	Long: `Change a running refinery's quality gates, and issue gate skip tokens.
+
+Gates can be added, retuned and removed without restarting the refinery
+(list, set, rm, reset). Changes are kept in the rig's .runtime on top of
+the configured gates and apply from the next batch: a batch in flight
+keeps the gates it started with. Each batch records the version of the
+gate set it ran; show prints a recorded gate set, to reproduce a batch.
+
+A skip token lets one quality gate be bypassed for one MR, for emergencies
+where a known-flaky gate blocks a critical fix. Tokens are issued by humans
+only: they can't be issued from an agent session. Each is signed, so it
+can't be forged or altered by editing the token file, expires if unused,
+and is spent by the first batch that gates the MR. Every token, with who
+issued it and why, stays in the rig's .runtime/gate-skip-tokens.json, and
+batch logs record each skipped gate. Spending and revoking a token are
+signed events in ~/.config/gastown/gate-skip-log.jsonl, outside the rig.
+
+Tokens are signed with $GT_GATE_SKIP_SECRET when set (it must then be set
+for the refinery too), or else with a key generated in
+~/.config/gastown/gate-skip.key ($XDG_CONFIG_HOME respected).`,
+}
+
+var gateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the gates the next batch will run",
+	Args:  cobra.NoArgs,
+	RunE:  runGateList,
+}
+
+var gateSetCmd = &cobra.Command{
+	Use:   "set <gate>",
+	Short: "Add or retune a gate from the next batch on",
+	Long: `Add a gate, or retune an existing one, from the next batch on.
+
+Flags not given keep the gate's current settings.
+
+Examples:
+  gt gate set lint --cmd "golangci-lint run" --timeout 5m
+  gt gate set e2e --timeout 20m --flaky`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGateSet,
+}
+
+var gateRmCmd = &cobra.Command{
+	Use:   "rm <gate>",
+	Short: "Remove a gate from the next batch on",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGateRm,
+}
+
+var gateResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Drop live gate changes, back to the configured gates",
+	Args:  cobra.NoArgs,
+	RunE:  runGateReset,
+}
+
+var gateShowCmd = &cobra.Command{
+	Use:   "show <gate-set>",
+	Short: "Print a gate set recorded by a batch",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGateShow,
+}
+
+var gateSkipCmd = &cobra.Command{
+	Use:   "skip <gate>",
+	Short: "Let a gate be skipped for one MR",
+	Long: `Issue a single-use token letting a quality gate be skipped for one MR.
+
+Examples:
+  gt gate skip unit --mr gt-mr-42 --reason "flaky since #1234, hotfix for prod outage"
+  gt gate skip e2e --mr gt-mr-42 --reason "..." --ttl 2h`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGateSkip,
+}
+
+var gateTokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "List gate skip tokens",
+	Args:  cobra.NoArgs,
+	RunE:  runGateTokens,
+}
+
+var gateRevokeCmd = &cobra.Command{
+	Use:   "revoke <token>",
+	Short: "Revoke an unused gate skip token",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGateRevoke,
+}
+
+func init() {
+	gateListCmd.Flags().BoolVar(&gateListJSON, "json", false, "Output as JSON")
+	gateSetCmd.Flags().StringVar(&gateSetCommand, "cmd", "", "Shell command the gate runs (required for a new gate)")
+	gateSetCmd.Flags().DurationVar(&gateSetTimeout, "timeout", 0, "Time limit for the gate (0 = none)")
+	gateSetCmd.Flags().BoolVar(&gateSetFlaky, "flaky", false, "Mark the gate as known to fail intermittently")
+	gateSetCmd.Flags().StringVar(&gateSetDir, "dir", "", "Directory the gate runs in, relative to the tree")
+	gateSetCmd.Flags().StringVar(&gateSetShell, "shell", "", "Shell running the command (default sh)")
+	gateSetCmd.Flags().StringArrayVar(&gateSetEnv, "env", nil, "Environment variable for the gate, KEY=VALUE (repeatable; replaces the gate's)")
+	gateSkipCmd.Flags().StringVar(&gateSkipMR, "mr", "", "MR the gate is skipped for (required)")
+	gateSkipCmd.Flags().StringVar(&gateSkipReason, "reason", "", "Why the gate is skipped (required, audited)")
+	gateSkipCmd.Flags().DurationVar(&gateSkipTTL, "ttl", refinery.DefaultSkipTokenTTL, "How long the token stays valid if unused")
+	_ = gateSkipCmd.MarkFlagRequired("mr")
+	_ = gateSkipCmd.MarkFlagRequired("reason")
+	gateTokensCmd.Flags().BoolVar(&gateTokensJSON, "json", false, "Output as JSON")
+	gateTokensCmd.Flags().BoolVar(&gateTokensAll, "all", false, "Include used, revoked and expired tokens")
+
+	gateCmd.AddCommand(gateListCmd)
+	gateCmd.AddCommand(gateSetCmd)
+	gateCmd.AddCommand(gateRmCmd)
+	gateCmd.AddCommand(gateResetCmd)
+	gateCmd.AddCommand(gateShowCmd)
+	gateCmd.AddCommand(gateSkipCmd)
+	gateCmd.AddCommand(gateTokensCmd)
+	gateCmd.AddCommand(gateRevokeCmd)
+	rootCmd.AddCommand(gateCmd)
+}
+
+// requireHuman refuses to run from an agent session. Crew workspaces are
+// human.
+func requireHuman(action string) error {
+	envRole := os.Getenv(EnvGTRole)
+	if envRole == "" {
+		return nil
+	}
+	if role, _, _ := parseRoleString(envRole); role == RoleCrew {
+		return nil
+	}
+	return fmt.Errorf("%s is reserved for humans; run it outside agent sessions (GT_ROLE=%s)", action, envRole)
+}
+
+// humanActor names the human running the command, for the audit trail.
+func humanActor() string {
+	if actor := detectActor(); actor != "" && actor != string(RoleUnknown) {
+		return actor
+	}
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "unknown"
+}
+
+func runGateList(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	gates, err := eng.Gates()
+	if err != nil {
+		return err
+	}
+	changes, err := eng.LiveGateChanges()
+	if err != nil {
+		return err
+	}
+	if gateListJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(gates)
+	}
+	names := make([]string, 0, len(gates)+len(changes))
+	for name := range gates {
+		names = append(names, name)
+	}
+	for name, c := range changes {
+		if c.Config == nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Printf("No gates in %s\n", r.Name)
+		return nil
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := changes[name]
+		if c != nil && c.Config == nil {
+			fmt.Printf("%s  %s\n", style.Dim.Render(name), style.Dim.Render(fmt.Sprintf("removed by %s at %s", c.By, c.At.Local().Format(time.RFC3339))))
+			continue
+		}
+		g := gates[name]
+		var notes []string
+		if g.Timeout > 0 {
+			notes = append(notes, "timeout "+g.Timeout.String())
+		}
+		if g.Flaky {
+			notes = append(notes, "flaky")
+		}
+		if g.Dir != "" {
+			notes = append(notes, "in "+g.Dir)
+		}
+		if c != nil {
+			notes = append(notes, fmt.Sprintf("set by %s at %s", c.By, c.At.Local().Format(time.RFC3339)))
+		}
+		line := fmt.Sprintf("%s  %s", style.Bold.Render(name), g.Cmd)
+		if len(notes) > 0 {
+			line += "  " + style.Dim.Render("("+strings.Join(notes, ", ")+")")
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func runGateSet(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	gates, err := eng.Gates()
+	if err != nil {
+		return err
+	}
+	gate := &refinery.GateConfig{}
+	verb := "Added"
+	if cur := gates[args[0]]; cur != nil {
+		*gate = *cur
+		verb = "Retuned"
+	}
+	flags := cmd.Flags()
+	if flags.Changed("cmd") {
+		gate.Cmd = gateSetCommand
+	}
+	if flags.Changed("timeout") {
+		gate.Timeout = gateSetTimeout
+	}
+	if flags.Changed("flaky") {
+		gate.Flaky = gateSetFlaky
+	}
+	if flags.Changed("dir") {
+		gate.Dir = gateSetDir
+	}
+	if flags.Changed("shell") {
+		gate.Shell = gateSetShell
+	}
+	if flags.Changed("env") {
+		gate.Env = make(map[string]string, len(gateSetEnv))
+		for _, kv := range gateSetEnv {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return fmt.Errorf("invalid --env %q: want KEY=VALUE", kv)
+			}
+			gate.Env[k] = v
+		}
+	}
+	if err := eng.SetGate(args[0], gate, humanActor()); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s gate %s; applies from the next batch\n", style.Bold.Render("✓"), verb, args[0])
+	return nil
+}
+
+func runGateRm(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	if err := eng.RemoveGate(args[0], humanActor()); err != nil {
+		return err
+	}
+	fmt.Printf("%s Removed gate %s; applies from the next batch\n", style.Bold.Render("✓"), args[0])
+	return nil
+}
+
+func runGateReset(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	dropped, err := eng.ResetGates()
+	if err != nil {
+		return err
+	}
+	if len(dropped) == 0 {
+		fmt.Println("No live gate changes to drop")
+		return nil
+	}
+	fmt.Printf("%s Dropped live changes to %s; the configured gates apply from the next batch\n", style.Bold.Render("✓"), strings.Join(dropped, ", "))
+	return nil
+}
+
+func runGateShow(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	gates, err := eng.GateSet(args[0])
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(gates)
+}
+
+func runGateSkip(cmd *cobra.Command, args []string) error {
+	if err := requireHuman("issuing gate skip tokens"); err != nil {
+		return err
+	}
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	t, err := eng.IssueSkipToken(args[0], gateSkipMR, gateSkipReason, humanActor(), gateSkipTTL)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Issued %s: gate %s may be skipped once for %s\n", style.Bold.Render("✓"), t.ID, t.Gate, t.MR)
+	fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("expires %s; revoke with: gt gate revoke %s", t.ExpiresAt.Local().Format(time.RFC3339), t.ID)))
+	return nil
+}
+
+func runGateTokens(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	tokens, err := eng.SkipTokens()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var shown []*refinery.GateSkipToken
+	for _, t := range tokens {
+		if gateTokensAll || t.Status(now) == "unused" {
+			shown = append(shown, t)
+		}
+	}
+	if gateTokensJSON {
+		if shown == nil {
+			shown = []*refinery.GateSkipToken{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(shown)
+	}
+	if len(shown) == 0 {
+		fmt.Printf("No gate skip tokens in %s\n", r.Name)
+		return nil
+	}
+	for _, t := range shown {
+		status := t.Status(now)
+		switch status {
+		case "used":
+			status = fmt.Sprintf("used in %s at %s", t.UsedIn, t.UsedAt.Local().Format(time.RFC3339))
+		case "revoked":
+			status = fmt.Sprintf("revoked by %s", t.RevokedBy)
+		case "unused":
+			status = "unused, expires " + t.ExpiresAt.Local().Format(time.RFC3339)
+		}
+		fmt.Printf("%s  skip %s for %s — %s\n", style.Bold.Render(t.ID), t.Gate, t.MR, status)
+		fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("issued by %s at %s: %s", t.IssuedBy, t.IssuedAt.Local().Format(time.RFC3339), t.Reason)))
+	}
+	return nil
+}
+
+func runGateRevoke(cmd *cobra.Command, args []string) error {
+	if err := requireHuman("revoking gate skip tokens"); err != nil {
+		return err
+	}
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	t, err := eng.RevokeSkipToken(args[0], humanActor())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Revoked %s (skip %s for %s)\n", style.Bold.Render("✓"), t.ID, t.Gate, t.MR)
+	return nil
+}
@@ -179,8 +179,10 @@ func runHandoff(cmd *cobra.Command, args []string) error {
 	callerSocket := tmux.SocketFromEnv()
 	t := tmux.NewTmuxWithSocket(callerSocket)
 	// Town-socket Tmux for session-level queries (getSessionPane, etc.)
-	townTmux := tmux.NewTmux()
-	_ = townTmux // used later for remote handoff
+	townTmux, err := tmux.RequireTmux("handoff")
+	if err != nil {
+		return err
+	}
 
 	// Verify we're in tmux
 	if !tmux.IsInsideTmux() {
@@ -813,9 +815,10 @@ func buildRestartCommandWithOpts(sessionName string, opts buildRestartCommandOpt
 	if !agentInEnv {
 		// GT_AGENT not in process env at all — try tmux session environment
 		// as fallback, since exec env vars may not propagate through all runtimes.
-		t := tmux.NewTmux()
-		if val, err := t.GetEnvironment(sessionName, "GT_AGENT"); err == nil && val != "" {
-			currentAgent = val
+		if t, err := tmux.RequireTmux("reading the session environment"); err == nil {
+			if val, err := t.GetEnvironment(sessionName, "GT_AGENT"); err == nil && val != "" {
+				currentAgent = val
+			}
 		}
 	}
 	var runtimeCmd string
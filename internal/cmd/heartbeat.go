@@ -25,6 +25,12 @@ States:
   exiting  - In gt done flow
   stuck    - Self-reporting stuck (triggers witness escalation)
 
+Agents that can't run gt cheaply have two lighter heartbeats, which the
+daemon reads to tell an agent thinking for a long time from a hung one:
+
+  touch "$GT_HEARTBEAT_FILE"             - working (e.g. from a tool hook)
+  echo "GT_HEARTBEAT $(date +%s) [state]" - printed to the agent's pane
+
 Examples:
   gt heartbeat --state=stuck "blocked on auth issue"
   gt heartbeat --state=idle
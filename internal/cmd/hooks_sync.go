@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/githooks"
 	"github.com/steveyegge/gastown/internal/hooks"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -16,7 +17,7 @@ var hooksSyncDryRun bool
 
 var hooksSyncCmd = &cobra.Command{
 	Use:   "sync",
-	Short: "Regenerate all .claude/settings.json files",
+	Short: "Regenerate all .claude/settings.json files and rig git hooks",
 	Long: `Regenerate all .claude/settings.json files from the base config and overrides.
 
 For each target (mayor, deacon, rig/crew, rig/witness, etc.):
@@ -26,6 +27,12 @@ For each target (mayor, deacon, rig/crew, rig/witness, etc.):
 4. Merge hooks section into existing settings.json (preserving all fields)
 5. Write updated settings.json
 
+Then install or update the git hooks Gas Town manages in every rig repo
+(pre-push screening, commit-msg trailers, post-checkout worktree
+registration). Each is a version-stamped block inserted into the repo's
+hook, leaving the rest of the hook alone. Repos with core.hooksPath set
+are skipped.
+
 Examples:
   gt hooks sync             # Regenerate all settings.json files
   gt hooks sync --dry-run   # Show what would change without writing`,
@@ -123,6 +130,8 @@ func runHooksSync(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println(")")
 
+	gitErrors := syncGitHooks(townRoot, hooksSyncDryRun)
+
 	if errors > 0 {
 		if integrityErrors > 0 {
 			return fmt.Errorf(
@@ -137,10 +146,51 @@ func runHooksSync(cmd *cobra.Command, args []string) error {
 			strings.Join(failedTargets, ", "),
 		)
 	}
+	if gitErrors > 0 {
+		return fmt.Errorf("hooks sync failed: %d repo(s) failed git hook sync", gitErrors)
+	}
 
 	return nil
 }
 
+// syncGitHooks installs the managed git hooks in every rig repo and
+// returns the number of repos that failed.
+func syncGitHooks(townRoot string, dryRun bool) int {
+	fmt.Println()
+	fmt.Println("Syncing git hooks...")
+
+	failed := 0
+	for _, repo := range githooks.DiscoverRepos(townRoot) {
+		relPath, pathErr := filepath.Rel(townRoot, repo)
+		if pathErr != nil {
+			relPath = repo
+		}
+		result, err := githooks.Sync(repo, dryRun)
+		switch {
+		case err != nil:
+			fmt.Printf("  %s %s: %v\n", style.Error.Render("✖"), relPath, err)
+			failed++
+		case result.Skipped != "":
+			fmt.Printf("  %s %s %s\n", style.Dim.Render("·"), relPath, style.Dim.Render("(skipped: "+result.Skipped+")"))
+		case !result.Changed():
+			fmt.Printf("  %s %s %s\n", style.Dim.Render("·"), relPath, style.Dim.Render("(unchanged)"))
+		default:
+			var changed []string
+			for _, name := range githooks.Names {
+				if action := result.Hooks[name]; action != githooks.Unchanged {
+					changed = append(changed, name+" "+action.String())
+				}
+			}
+			if dryRun {
+				fmt.Printf("  %s %s %s\n", style.Warning.Render("~"), relPath, style.Dim.Render("(would be "+strings.Join(changed, ", ")+")"))
+			} else {
+				fmt.Printf("  %s %s %s\n", style.Success.Render("✓"), relPath, style.Dim.Render("("+strings.Join(changed, ", ")+")"))
+			}
+		}
+	}
+	return failed
+}
+
 type syncResult int
 
 const (
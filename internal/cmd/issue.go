@@ -69,7 +69,10 @@ func runIssueSet(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("tracking the session's issue")
+	if err != nil {
+		return err
+	}
 	if err := t.SetEnvironment(session, "GT_ISSUE", issueID); err != nil {
 		return fmt.Errorf("setting issue: %w", err)
 	}
@@ -87,7 +90,10 @@ func runIssueClear(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("tracking the session's issue")
+	if err != nil {
+		return err
+	}
 	// Set to empty string to clear
 	if err := t.SetEnvironment(session, "GT_ISSUE", ""); err != nil {
 		return fmt.Errorf("clearing issue: %w", err)
@@ -106,7 +112,10 @@ func runIssueShow(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("tracking the session's issue")
+	if err != nil {
+		return err
+	}
 	issue, err := t.GetEnvironment(session, "GT_ISSUE")
 	if err != nil {
 		return fmt.Errorf("getting issue: %w", err)
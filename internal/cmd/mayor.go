@@ -166,7 +166,10 @@ func runMayorAttach(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("attaching to the mayor")
+	if err != nil {
+		return err
+	}
 	sessionID := mgr.SessionName()
 
 	running, err := mgr.IsRunning()
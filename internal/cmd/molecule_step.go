@@ -329,7 +329,10 @@ func handleStepContinue(cwd, townRoot string, nextStep *beads.Issue, dryRun bool
 
 	fmt.Printf("\n%s Respawning for next step...\n", style.Bold.Render("🔄"))
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("respawning for the next step")
+	if err != nil {
+		return err
+	}
 
 	// Kill all processes in the pane before respawning to prevent process leaks
 	if err := t.KillPaneProcesses(pane); err != nil {
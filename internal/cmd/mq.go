@@ -21,7 +21,10 @@ var (
 	mqSubmitBranch    string
 	mqSubmitIssue     string
 	mqSubmitEpic      string
+	mqSubmitTarget    string
 	mqSubmitPriority  int
+	mqSubmitQoS       string
+	mqSubmitStrategy  string
 	mqSubmitNoCleanup bool
 
 	// Retry flags
@@ -37,6 +40,7 @@ var (
 	mqListStatus  string
 	mqListWorker  string
 	mqListEpic    string
+	mqListTarget  string
 	mqListJSON    bool
 	mqListVerify  bool
 
@@ -87,9 +91,11 @@ Auto-detection:
   - Priority: inherited from source issue
 
 Target branch auto-detection:
-  1. If --epic is specified: target the integration branch for <epic> (using configured template)
-  2. If source issue has a parent epic with an integration branch: target it
-  3. Otherwise: target main
+  1. If --target is specified: target that branch (the default branch or a
+     protected branch registered in merge_queue.protected_branches)
+  2. If --epic is specified: target the integration branch for <epic> (using configured template)
+  3. If source issue has a parent epic with an integration branch: target it
+  4. Otherwise: target main
 
 This ensures batch work on epics automatically flows to integration branches.
 
@@ -306,7 +312,10 @@ func init() {
 	mqSubmitCmd.Flags().StringVar(&mqSubmitBranch, "branch", "", "Source branch (default: current branch)")
 	mqSubmitCmd.Flags().StringVar(&mqSubmitIssue, "issue", "", "Source issue ID (default: parse from branch name)")
 	mqSubmitCmd.Flags().StringVar(&mqSubmitEpic, "epic", "", "Target epic's integration branch instead of main")
+	mqSubmitCmd.Flags().StringVar(&mqSubmitTarget, "target", "", "Target a protected branch instead of main")
 	mqSubmitCmd.Flags().IntVarP(&mqSubmitPriority, "priority", "p", -1, "Override priority (0-4, default: inherit from issue)")
+	mqSubmitCmd.Flags().StringVar(&mqSubmitQoS, "qos", "", "Queue service class: interactive, batch, background (default: batch)")
+	mqSubmitCmd.Flags().StringVar(&mqSubmitStrategy, "merge-strategy", "", "How the MR lands: squash, merge-commit, rebase-ff, cherry-pick, auto (default: rig's merge_strategy)")
 	mqSubmitCmd.Flags().BoolVar(&mqSubmitNoCleanup, "no-cleanup", false, "Don't auto-cleanup after submit (for polecats)")
 
 	// Retry flags
@@ -317,6 +326,7 @@ func init() {
 	mqListCmd.Flags().StringVar(&mqListStatus, "status", "", "Filter by status (open, in_progress, closed)")
 	mqListCmd.Flags().StringVar(&mqListWorker, "worker", "", "Filter by worker name")
 	mqListCmd.Flags().StringVar(&mqListEpic, "epic", "", "Show MRs targeting integration/<epic>")
+	mqListCmd.Flags().StringVar(&mqListTarget, "target", "", "Show only the queue for this target branch")
 	mqListCmd.Flags().BoolVar(&mqListJSON, "json", false, "Output as JSON")
 	mqListCmd.Flags().BoolVar(&mqListVerify, "verify", false, "Verify branches exist in git (shows MISSING for deleted branches)")
 
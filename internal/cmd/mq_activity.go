@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	mqActivityInterval time.Duration
+	mqActivitySince    time.Duration
+	mqActivityJSON     bool
+)
+
+var mqActivityCmd = &cobra.Command{
+	Use:   "activity [rig]",
+	Short: "Stream live merge queue activity",
+	Long: `Stream what the refinery is doing, as it happens, until interrupted.
+
+Prints a line per progress event of every MR and batch in the queue: MRs
+held, admitted and batched; stacking started and each MR stacked; each gate
+started, passed or failed; bisection steps; the stack pushed; and each MR's
+final stage. Use gt mq watch to follow a single MR to the end.
+
+Follows the given rig, else the current rig, else every rig in the town.
+
+Examples:
+  gt mq activity
+  gt mq activity gastown --since 10m   # Replay the last 10 minutes first
+  gt mq activity --json                # One JSON event per line`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMqActivity,
+}
+
+func init() {
+	mqActivityCmd.Flags().DurationVar(&mqActivityInterval, "interval", time.Second, "Polling interval")
+	mqActivityCmd.Flags().DurationVar(&mqActivitySince, "since", 0, "Replay events from this long ago before following")
+	mqActivityCmd.Flags().BoolVar(&mqActivityJSON, "json", false, "Output events as JSON lines")
+	mqCmd.AddCommand(mqActivityCmd)
+}
+
+// MQActivityEvent is a progress event tagged with its rig, as printed by
+// gt mq activity --json.
+type MQActivityEvent struct {
+	Rig string `json:"rig"`
+	refinery.ProgressEvent
+}
+
+// activityFollower tails the progress logs of a set of rigs.
+type activityFollower struct {
+	rigs    []*rig.Rig
+	offsets map[string]int64 // Rig name → progress log offset
+	since   time.Time        // Events before this aren't printed
+	out     io.Writer
+	json    bool
+}
+
+func runMqActivity(cmd *cobra.Command, args []string) error {
+	if mqActivityInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	rigs, err := activityRigs(args)
+	if err != nil {
+		return err
+	}
+	f := &activityFollower{
+		rigs:    rigs,
+		offsets: make(map[string]int64, len(rigs)),
+		since:   time.Now().Add(-mqActivitySince),
+		out:     os.Stdout,
+		json:    mqActivityJSON,
+	}
+	if !f.json {
+		names := make([]string, len(rigs))
+		for i, r := range rigs {
+			names[i] = r.Name
+		}
+		fmt.Printf("%s Following merge queue activity in %v (Ctrl-C to stop)\n", style.ArrowPrefix, names)
+	}
+
+	ticker := time.NewTicker(mqActivityInterval)
+	defer ticker.Stop()
+	for {
+		f.poll()
+		<-ticker.C
+	}
+}
+
+// activityRigs returns the rig named in args, else the current rig, else
+// every rig in the town.
+func activityRigs(args []string) ([]*rig.Rig, error) {
+	if len(args) > 0 {
+		_, r, err := getRig(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return []*rig.Rig{r}, nil
+	}
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	if _, r, err := findCurrentRig(townRoot); err == nil {
+		return []*rig.Rig{r}, nil
+	}
+	rigs, err := getAllRigs()
+	if err != nil {
+		return nil, err
+	}
+	if len(rigs) == 0 {
+		return nil, fmt.Errorf("no rigs in this town")
+	}
+	return rigs, nil
+}
+
+// poll prints the events appended to each rig's progress log since the
+// last poll.
+func (f *activityFollower) poll() {
+	for _, r := range f.rigs {
+		events, offset, err := refinery.ReadProgress(refinery.ProgressPath(r.Path), f.offsets[r.Name])
+		if err != nil {
+			continue
+		}
+		f.offsets[r.Name] = offset
+		for _, ev := range events {
+			if ev.Time.Before(f.since) {
+				continue
+			}
+			f.emit(r.Name, ev)
+		}
+	}
+}
+
+func (f *activityFollower) emit(rigName string, ev refinery.ProgressEvent) {
+	if f.json {
+		data, _ := json.Marshal(MQActivityEvent{Rig: rigName, ProgressEvent: ev})
+		_, _ = fmt.Fprintln(f.out, string(data))
+		return
+	}
+	mr := ev.MR
+	if mr == "" {
+		mr = "batch"
+	}
+	line := fmt.Sprintf("%s  %-10s %-14s %-14s %s", ev.Time.Local().Format("15:04:05"), rigName, mr, ev.Stage, ev.Detail)
+	if ev.BatchID != "" {
+		line += " " + style.Dim.Render("["+ev.BatchID+"]")
+	}
+	_, _ = fmt.Fprintln(f.out, line)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestActivityFollower_TailsEachRig(t *testing.T) {
+	a := &rig.Rig{Name: "alpha", Path: t.TempDir()}
+	b := &rig.Rig{Name: "beta", Path: t.TempDir()}
+	for _, r := range []*rig.Rig{a, b} {
+		if err := os.MkdirAll(filepath.Join(r.Path, ".runtime"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UTC()
+	appendWatchEvents(t, refinery.ProgressPath(a.Path),
+		refinery.ProgressEvent{Time: now.Add(-time.Hour), MR: "gt-mr-old", Stage: refinery.StageMerged},
+		refinery.ProgressEvent{Time: now, BatchID: "b-1", Stage: refinery.StageStacking, Detail: "2 MRs onto main"},
+	)
+
+	out := &bytes.Buffer{}
+	f := &activityFollower{rigs: []*rig.Rig{a, b}, offsets: map[string]int64{}, since: now.Add(-time.Minute), out: out, json: true}
+	f.poll()
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("first poll printed %d lines, want only the recent event:\n%s", len(lines), out)
+	}
+	var ev MQActivityEvent
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil || ev.Rig != "alpha" || ev.Stage != refinery.StageStacking || ev.BatchID != "b-1" {
+		t.Errorf("event = %+v (%v)", ev, err)
+	}
+
+	out.Reset()
+	appendWatchEvents(t, filepath.Join(b.Path, ".runtime", "mr-progress.jsonl"),
+		refinery.ProgressEvent{Time: now, MR: "gt-mr-2", Stage: refinery.StageGateFailed, Gate: "test", Detail: "test: exit 1"},
+	)
+	f.json = false
+	f.poll()
+	if got := out.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "beta") || !strings.Contains(got, "gate_failed") {
+		t.Errorf("second poll = %q, want beta's new event only", got)
+	}
+}
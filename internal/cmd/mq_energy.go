@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	mqEnergySince  time.Duration
+	mqEnergyTarget string
+	mqEnergyJSON   bool
+)
+
+var mqEnergyCmd = &cobra.Command{
+	Use:   "energy",
+	Short: "Summarize the energy and compute used by merge queue batches",
+	Long: `Summarize the energy, CPU time and carbon recorded in the batch log:
+in total, per merged MR, per target branch, and per gate.
+
+Enable energy accounting in the rig's config.json with one of the
+built-in meters:
+
+  "merge_queue": {
+    "energy": {"meter": "rapl", "carbon_intensity": 400}
+  }
+
+  rapl     CPU package energy from /sys/class/powercap (joules)
+  cgroup   CPU time of the refinery's cgroup, including its gates (v2 only)
+  command  an external meter: "command" runs via sh -c and prints a
+           cumulative reading, in joules or as {"joules": j, "cpu_seconds": s}
+
+carbon_intensity is the grid's intensity in gCO2e per kWh; with it set,
+each batch also records the carbon its energy stands for. Gates that run in
+parallel overlap, so a gate is charged with everything the meter saw while
+it ran; batch totals count everything once.
+
+Examples:
+  gt mq energy
+  gt mq energy --since 168h --target main
+  gt mq energy --json`,
+	Args: cobra.NoArgs,
+	RunE: runMqEnergy,
+}
+
+func init() {
+	mqEnergyCmd.Flags().DurationVar(&mqEnergySince, "since", 0, "Only batches finished within this long")
+	mqEnergyCmd.Flags().StringVar(&mqEnergyTarget, "target", "", "Only batches targeting this branch")
+	mqEnergyCmd.Flags().BoolVar(&mqEnergyJSON, "json", false, "Output as JSON")
+	mqCmd.AddCommand(mqEnergyCmd)
+}
+
+func runMqEnergy(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	q := refinery.HistoryQuery{Target: mqEnergyTarget}
+	if mqEnergySince > 0 {
+		q.Since = time.Now().Add(-mqEnergySince)
+	}
+	records, err := eng.History(q)
+	if err != nil {
+		return err
+	}
+	s := refinery.SummarizeEnergy(records)
+
+	if mqEnergyJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	if eng.Config().Energy == nil {
+		fmt.Printf("%s\n", style.Dim.Render("Energy accounting is disabled in "+r.Name+" (merge_queue.energy)"))
+	}
+	if s.MeteredBatches == 0 {
+		fmt.Printf("No metered batches in %s\n", r.Name)
+		return nil
+	}
+	fmt.Printf("%s  %s\n", style.Bold.Render("Total"), s.Total)
+	fmt.Printf("%s\n", style.Dim.Render(fmt.Sprintf("%d of %d batches metered, %d MRs merged", s.MeteredBatches, s.Batches, s.Merged)))
+	if s.PerMerged != nil {
+		fmt.Printf("%s  %s\n", style.Bold.Render("Per merged MR"), s.PerMerged)
+	}
+
+	targets := make([]string, 0, len(s.Targets))
+	for t := range s.Targets {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+	if len(targets) > 1 {
+		fmt.Printf("\n%s\n", style.Bold.Render("Targets"))
+		for _, t := range targets {
+			fmt.Printf("  %-20s %s\n", t, s.Targets[t])
+		}
+	}
+	if len(s.Gates) > 0 {
+		fmt.Printf("\n%s\n", style.Bold.Render("Gates"))
+		for _, g := range s.Gates {
+			fmt.Printf("  %-20s %s  %s\n", g.Gate, g.Total, style.Dim.Render(fmt.Sprintf("%d runs", g.Runs)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqFailuresJSON bool
+
+var mqFailuresCmd = &cobra.Command{
+	Use:   "failures",
+	Short: "List recurring gate failure patterns",
+	Long: `List gate failures clustered by signature: the gate and its error
+message with run-specific details (paths, hashes, numbers) normalized away.
+
+Enable failure patterns in the rig's config.json:
+
+  "merge_queue": {
+    "failure_patterns": {"enabled": true, "batches": 3, "window": "168h"}
+  }
+
+Once the same failure has occurred in batches distinct batches within the
+window, the refinery opens one beads issue tracking it, so a broken target
+or a flaky test is fixed once instead of being rediscovered on every MR it
+happens to fail. Closing the issue starts the count over.`,
+	Args: cobra.NoArgs,
+	RunE: runMqFailures,
+}
+
+func init() {
+	mqFailuresCmd.Flags().BoolVar(&mqFailuresJSON, "json", false, "Output as JSON")
+	mqCmd.AddCommand(mqFailuresCmd)
+}
+
+func runMqFailures(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	patterns, err := eng.FailurePatterns()
+	if err != nil {
+		return err
+	}
+
+	if mqFailuresJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(patterns)
+	}
+	if cfg := eng.Config().FailurePatterns; cfg == nil || !cfg.Enabled {
+		fmt.Printf("%s\n", style.Dim.Render("Failure patterns are disabled in "+r.Name+" (merge_queue.failure_patterns.enabled)"))
+	}
+	if len(patterns) == 0 {
+		fmt.Printf("No gate failures recorded in %s\n", r.Name)
+		return nil
+	}
+	for _, p := range patterns {
+		status := style.Dim.Render("untracked")
+		if p.Issue != "" {
+			status = style.Bold.Render(p.Issue)
+		}
+		fmt.Printf("%s  %d batches, last %s, %s\n", style.Bold.Render(p.Gate), len(p.Occurrences),
+			p.LastSeen().Local().Format(time.RFC3339), status)
+		fmt.Printf("  %s\n", style.Dim.Render(p.Message))
+	}
+	return nil
+}
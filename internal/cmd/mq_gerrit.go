@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqChangesJSON bool
+
+var mqChangesCmd = &cobra.Command{
+	Use:   "changes",
+	Short: "List Gerrit changes the queue would ingest",
+	Long: `List the open Gerrit changes the current rig's merge queue would ingest:
+non-WIP changes of the project carrying every ready label vote.
+
+Configure Gerrit ingestion in the rig's config.json:
+
+  "merge_queue": {
+    "gerrit": {"url": "https://review.example.com", "project": "tools",
+               "ready_labels": ["Code-Review=MAX", "Verified=MAX"]}
+  }
+
+HTTP credentials are read from GERRIT_USER and GERRIT_HTTP_PASSWORD (or the
+variables named by user_env and password_env). Ingested changes are fetched
+from origin into local gerrit/<number> branches. A change stacked on another
+open change is blocked by it, so relation chains are batched parent first;
+a change whose parent is not ready waits. Once gates pass, the changes are
+submitted through Gerrit's REST API rather than pushed. Changes that
+conflict or fail gates get a review message.
+
+Examples:
+  gt mq changes
+  gt mq changes --json`,
+	Args: cobra.NoArgs,
+	RunE: runMqChanges,
+}
+
+func init() {
+	mqChangesCmd.Flags().BoolVar(&mqChangesJSON, "json", false, "Output as JSON")
+	mqCmd.AddCommand(mqChangesCmd)
+}
+
+func runMqChanges(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	cfg := eng.Config().Gerrit
+	if cfg == nil {
+		return fmt.Errorf("Gerrit ingestion is not configured in %s (merge_queue.gerrit)", r.Name)
+	}
+	mrs, err := cfg.ListChanges(context.Background(), r.Name)
+	if err != nil {
+		return err
+	}
+
+	if mqChangesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(mrs)
+	}
+	if len(mrs) == 0 {
+		fmt.Printf("No changes ready for the queue in %s\n", cfg.Project)
+		return nil
+	}
+	for _, mr := range mrs {
+		ch := mr.Change
+		fmt.Printf("%s %s → %s\n", style.Bold.Render(fmt.Sprintf("%d,%d", ch.Number, ch.Patchset)), mr.Title, mr.Target)
+		detail := fmt.Sprintf("by %s, %s", ch.Owner, ch.URL)
+		if mr.BlockedBy != "" {
+			detail = fmt.Sprintf("by %s, after %s, %s", ch.Owner, mr.BlockedBy, ch.URL)
+		}
+		fmt.Printf("     %s\n", style.Dim.Render(detail))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqPRsJSON bool
+
+var mqPRsCmd = &cobra.Command{
+	Use:   "prs",
+	Short: "List GitHub pull requests the queue would ingest",
+	Long: `List the open GitHub pull requests the current rig's merge queue would
+ingest: non-draft PRs carrying the queue label that have enough approvals.
+
+Configure GitHub ingestion in the rig's config.json:
+
+  "merge_queue": {
+    "github": {"repo": "owner/name", "label": "merge-queue", "min_approvals": 1}
+  }
+
+The API token is read from GITHUB_TOKEN (or the variable named by
+token_env). Ingested PRs are fetched from origin into local pr/<number>
+branches and batched like any other MR. After each batch, the queue
+reports back on them: merged PRs get a success status and a comment and
+are closed; PRs that conflict or fail gates get a failure status and a
+comment; deferred PRs stay pending.
+
+Examples:
+  gt mq prs
+  gt mq prs --json`,
+	Args: cobra.NoArgs,
+	RunE: runMqPRs,
+}
+
+func init() {
+	mqPRsCmd.Flags().BoolVar(&mqPRsJSON, "json", false, "Output as JSON")
+	mqCmd.AddCommand(mqPRsCmd)
+}
+
+func runMqPRs(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	cfg := eng.Config().GitHub
+	if cfg == nil {
+		return fmt.Errorf("GitHub ingestion is not configured in %s (merge_queue.github)", r.Name)
+	}
+	mrs, err := cfg.ListPullRequests(context.Background(), r.Name)
+	if err != nil {
+		return err
+	}
+
+	if mqPRsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(mrs)
+	}
+	if len(mrs) == 0 {
+		fmt.Printf("No pull requests ready for the queue in %s\n", cfg.Repo)
+		return nil
+	}
+	for _, mr := range mrs {
+		pr := mr.PullRequest
+		fmt.Printf("%s %s → %s\n", style.Bold.Render(fmt.Sprintf("#%d", pr.Number)), mr.Title, mr.Target)
+		fmt.Printf("     %s\n", style.Dim.Render(fmt.Sprintf("by %s, %d approvals, %s", pr.Author, pr.Approvals, pr.URL)))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	mqHotfixJSON bool
+	mqHotfixAll  bool
+)
+
+var mqHotfixCmd = &cobra.Command{
+	Use:   "hotfix [mr-id]",
+	Short: "Show hotfixes and their backports to release branches",
+	Long: `Show hotfix MRs and the backports the refinery queued for them.
+
+When an MR labeled hotfix is first admitted, the refinery cherry-picks its
+commits onto every release branch in the rig's support matrix, queues one
+backport MR per branch, each waiting on the hotfix MR, and tracks them as a
+group until all have landed. A backport whose commits don't apply to its
+release is marked failed and must be backported by hand.
+
+Configure the support matrix in the rig's config.json:
+
+  "merge_queue": {
+    "hotfix": {"enabled": true, "releases": [
+      {"branch": "release/2.3", "until": "2026-12-31", "labels": ["security"]},
+      {"branch": "release/2.4"}
+    ]}
+  }
+
+A release past its until date gets no more hotfixes; one with labels only
+gets hotfixes carrying one of them.
+
+Examples:
+  gt mq hotfix            # Groups still landing
+  gt mq hotfix --all      # Including groups that have fully landed
+  gt mq hotfix gt-mr1     # One group`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMqHotfix,
+}
+
+func init() {
+	mqHotfixCmd.Flags().BoolVar(&mqHotfixJSON, "json", false, "Output as JSON")
+	mqHotfixCmd.Flags().BoolVar(&mqHotfixAll, "all", false, "Include groups that have fully landed")
+	mqCmd.AddCommand(mqHotfixCmd)
+}
+
+func runMqHotfix(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	all, err := eng.HotfixGroups()
+	if err != nil {
+		return err
+	}
+	groups := make([]*refinery.HotfixGroup, 0, len(all))
+	for _, g := range all {
+		switch {
+		case len(args) > 0 && g.MR != args[0]:
+		case len(args) == 0 && !mqHotfixAll && g.LandedAt != nil:
+		default:
+			groups = append(groups, g)
+		}
+	}
+	if len(args) > 0 && len(groups) == 0 {
+		return fmt.Errorf("no hotfix group for %s", args[0])
+	}
+
+	if mqHotfixJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(groups)
+	}
+	if len(groups) == 0 {
+		fmt.Printf("No hotfixes landing in %s\n", r.Name)
+		return nil
+	}
+	for _, g := range groups {
+		status := fmt.Sprintf("%d of %d branches to go", len(g.Pending()), len(g.Members))
+		if g.LandedAt != nil {
+			status = "landed everywhere " + g.LandedAt.Local().Format(time.RFC3339)
+		}
+		issue := ""
+		if g.SourceIssue != "" {
+			issue = " (" + g.SourceIssue + ")"
+		}
+		fmt.Printf("%s%s  %s\n", style.Bold.Render(g.MR), issue, style.Dim.Render(status))
+		for _, m := range g.Members {
+			mark := "○"
+			switch m.Status {
+			case refinery.HotfixLanded:
+				mark = "✓"
+			case refinery.HotfixFailed:
+				mark = "✗"
+			}
+			detail := m.MR
+			if m.Error != "" {
+				detail = m.Error + " — backport by hand"
+			}
+			fmt.Printf("  %s %-20s %s\n", mark, m.Target, detail)
+		}
+	}
+	return nil
+}
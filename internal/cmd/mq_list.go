@@ -130,6 +130,11 @@ func runMQList(cmd *cobra.Command, args []string) error {
 			}
 		}
 
+		// Filter by target branch (e.g. a protected branch's queue)
+		if mqListTarget != "" && (fields == nil || fields.Target != mqListTarget) {
+			continue
+		}
+
 		// Check branch existence if --verify is set (local + remote-tracking refs)
 		branchMissing, branchVerifyErr := verifyBranch(mqListVerify, gitClient, fields)
 
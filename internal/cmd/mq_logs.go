@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqLogsCmd = &cobra.Command{
+	Use:   "logs <batch-id> <mr-id> [gate]",
+	Short: "Show the captured output of a batch's gate runs",
+	Long: `Show what a quality gate printed when the refinery ran it in a batch.
+
+The refinery keeps the stdout and stderr of every gate run under
+.runtime/artifacts/<batch-id>/<mr-id>/<gate>.log, keyed by the last MR of
+the tree the gate ran on, for the last 100 batches. A culprit's MR bead
+records the directory of the logs that blamed it (gate_logs).
+
+Without a gate, lists the gates with logs for the MR.
+
+Examples:
+  gt mq logs batch-20260101T120000Z-3 gt-mr1
+  gt mq logs batch-20260101T120000Z-3 gt-mr1 test`,
+	Args: cobra.RangeArgs(2, 3),
+	RunE: runMqLogs,
+}
+
+func init() {
+	mqCmd.AddCommand(mqLogsCmd)
+}
+
+func runMqLogs(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	batchID, mrID := args[0], args[1]
+	if len(args) == 2 {
+		gates, err := eng.GateLogs(batchID, mrID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s Gate logs for %s in %s:\n", style.Bold.Render("●"), mrID, batchID)
+		for _, gate := range gates {
+			fmt.Printf("  %s\n", gate)
+		}
+		return nil
+	}
+	data, err := eng.GateLog(batchID, mrID, args[2])
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/daemon"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// Merge queue pause flags
+var (
+	mqPauseRig  string
+	mqResumeRig string
+)
+
+var mqPauseCmd = &cobra.Command{
+	Use:   "pause [reason...]",
+	Short: "Stop the refinery landing anything until resumed",
+	Long: `Pause a rig's merge queue, e.g. during an incident. Defaults to the
+current rig.
+
+While paused, the refinery lands nothing on any branch: each batch is
+deferred untouched and stays queued. Batches are still assembled, and the
+refinery log shows what resuming would pick up. The pause survives refinery
+restarts until 'gt mq resume'.
+
+The request goes through the daemon; if it isn't running, the pause is
+recorded directly in the rig. 'gt refinery pause|resume' do the same.
+
+Examples:
+  gt mq pause "prod incident, see #ops"
+  gt mq pause --rig greenplace "prod incident"
+  gt mq resume`,
+	RunE: runMqPause,
+}
+
+var mqResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Let the refinery land again after gt mq pause",
+	Args:  cobra.NoArgs,
+	RunE:  runMqResume,
+}
+
+func init() {
+	mqPauseCmd.Flags().StringVar(&mqPauseRig, "rig", "", "Rig to pause (default: current rig)")
+	mqResumeCmd.Flags().StringVar(&mqResumeRig, "rig", "", "Rig to resume (default: current rig)")
+
+	mqCmd.AddCommand(mqPauseCmd)
+	mqCmd.AddCommand(mqResumeCmd)
+}
+
+func runMqPause(cmd *cobra.Command, args []string) error {
+	return pauseMergeQueue(mqPauseRig, strings.Join(args, " "))
+}
+
+func runMqResume(cmd *cobra.Command, args []string) error {
+	return resumeMergeQueue(mqResumeRig)
+}
+
+// pauseMergeQueue pauses rigName's merge queue (the current rig if empty)
+// through the daemon, or directly in the rig if the daemon isn't running.
+func pauseMergeQueue(rigName, reason string) error {
+	r, ctl, err := refineryControl(rigName)
+	if err != nil {
+		return err
+	}
+	p, err := ctl.Pause(r.Name, detectActor(), reason)
+	if errors.Is(err, daemon.ErrDaemonUnreachable) {
+		eng, lerr := localRefineryEngineer(r)
+		if lerr != nil {
+			return lerr
+		}
+		p, err = eng.Pause(detectActor(), reason)
+	}
+	if err != nil {
+		return fmt.Errorf("pausing merge queue: %w", err)
+	}
+	fmt.Printf("%s Paused merge queue for %s\n", style.Bold.Render("✓"), r.Name)
+	if p.Reason != "" {
+		fmt.Printf("  %s\n", style.Dim.Render(p.Reason))
+	}
+	return nil
+}
+
+// resumeMergeQueue lifts the pause on rigName's merge queue (the current
+// rig if empty), as pauseMergeQueue set it.
+func resumeMergeQueue(rigName string) error {
+	r, ctl, err := refineryControl(rigName)
+	if err != nil {
+		return err
+	}
+	p, err := ctl.Resume(r.Name)
+	if errors.Is(err, daemon.ErrDaemonUnreachable) {
+		eng, lerr := localRefineryEngineer(r)
+		if lerr != nil {
+			return lerr
+		}
+		p, err = eng.Resume()
+	}
+	if errors.Is(err, refinery.ErrNotPaused) {
+		fmt.Printf("Merge queue for %s is not paused\n", r.Name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("resuming merge queue: %w", err)
+	}
+	fmt.Printf("%s Resumed merge queue for %s\n", style.Bold.Render("✓"), r.Name)
+	fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("was %s (%s)", p, time.Since(p.At).Round(time.Minute))))
+	return nil
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	mqProtectedJSON bool
+	mqProtectedDue  bool
+)
+
+var mqProtectedCmd = &cobra.Command{
+	Use:   "protected",
+	Short: "Manage protected long-lived feature branches",
+	RunE:  requireSubcommand,
+	Long: `Manage protected branches: long-lived feature branches that the refinery
+merges into alongside the default branch.
+
+Register them in the rig's config.json:
+
+  "merge_queue": {
+    "protected_branches": {
+      "feature/search": {
+        "gates": {"e2e": {"cmd": "make e2e", "timeout": "20m"}},
+        "sync_from": "main",
+        "sync_interval": "24h"
+      }
+    }
+  }
+
+Each protected branch has its own queue (submit with gt mq submit --target),
+its own gates (the rig's gates when none are set), and is kept current by
+merging sync_from (default: the rig's default branch) into it every
+sync_interval. A sync that conflicts or fails the branch's gates is not
+pushed.`,
+}
+
+var mqProtectedListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List protected branches with queue depth and sync status",
+	Args:  cobra.NoArgs,
+	RunE:  runMqProtectedList,
+}
+
+var mqProtectedSyncCmd = &cobra.Command{
+	Use:   "sync [branch...]",
+	Short: "Merge the default branch into protected branches",
+	Long: `Merge each protected branch's sync_from branch into it, run its gates,
+and push.
+
+With no branches, syncs every protected branch. With --due, only branches
+whose sync_interval has elapsed (the refinery patrol runs this).
+
+Examples:
+  gt mq protected sync feature/search
+  gt mq protected sync --due`,
+	RunE: runMqProtectedSync,
+}
+
+func init() {
+	mqProtectedListCmd.Flags().BoolVar(&mqProtectedJSON, "json", false, "Output as JSON")
+	mqProtectedSyncCmd.Flags().BoolVar(&mqProtectedDue, "due", false, "Only sync branches whose sync interval has elapsed")
+	mqProtectedCmd.AddCommand(mqProtectedListCmd)
+	mqProtectedCmd.AddCommand(mqProtectedSyncCmd)
+	mqCmd.AddCommand(mqProtectedCmd)
+}
+
+// currentRigEngineer returns an engineer with config loaded for the current rig.
+func currentRigEngineer() (*rig.Rig, *refinery.Engineer, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	_, r, err := findCurrentRig(townRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return nil, nil, fmt.Errorf("loading merge queue config: %w", err)
+	}
+	return r, eng, nil
+}
+
+type protectedBranchView struct {
+	Branch       string                        `json:"branch"`
+	SyncFrom     string                        `json:"sync_from"`
+	SyncInterval string                        `json:"sync_interval,omitempty"`
+	Gates        int                           `json:"gates"` // 0 = rig gates
+	Queued       int                           `json:"queued"`
+	Sync         *refinery.ProtectedSyncStatus `json:"sync,omitempty"`
+}
+
+func runMqProtectedList(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	statuses, err := eng.ProtectedSyncStatuses()
+	if err != nil {
+		return err
+	}
+
+	// Count open MRs per target for queue depth.
+	queued := make(map[string]int)
+	issues, err := beads.New(r.Path).ListMergeRequests(beads.ListOptions{Status: "open", Label: "gt:merge-request", Priority: -1})
+	if err == nil {
+		for _, issue := range issues {
+			if fields := beads.ParseMRFields(issue); fields != nil {
+				queued[fields.Target]++
+			}
+		}
+	}
+
+	cfg := eng.Config()
+	var views []protectedBranchView
+	for _, name := range eng.ProtectedBranches() {
+		pb := cfg.ProtectedBranches[name]
+		v := protectedBranchView{
+			Branch:   name,
+			SyncFrom: pb.SyncFrom,
+			Gates:    len(pb.Gates),
+			Queued:   queued[name],
+			Sync:     statuses[name],
+		}
+		if v.SyncFrom == "" {
+			v.SyncFrom = r.DefaultBranch()
+		}
+		if pb.SyncInterval > 0 {
+			v.SyncInterval = pb.SyncInterval.String()
+		}
+		views = append(views, v)
+	}
+
+	if mqProtectedJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(views)
+	}
+	if len(views) == 0 {
+		fmt.Printf("No protected branches in %s\n", r.Name)
+		return nil
+	}
+	for _, v := range views {
+		gates := "rig gates"
+		if v.Gates > 0 {
+			gates = fmt.Sprintf("%d gate(s)", v.Gates)
+		}
+		interval := "manual sync"
+		if v.SyncInterval != "" {
+			interval = "sync every " + v.SyncInterval
+		}
+		fmt.Printf("%s  %d queued, %s, from %s, %s\n", style.Bold.Render(v.Branch), v.Queued, gates, v.SyncFrom, interval)
+		if v.Sync != nil {
+			line := fmt.Sprintf("last sync %s: %s", v.Sync.LastAttempt.Local().Format(time.RFC3339), v.Sync.Result)
+			if v.Sync.Error != "" {
+				line += " (" + v.Sync.Error + ")"
+			}
+			fmt.Printf("  %s\n", style.Dim.Render(line))
+		}
+	}
+	return nil
+}
+
+func runMqProtectedSync(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+
+	branches := args
+	switch {
+	case mqProtectedDue:
+		if len(args) > 0 {
+			return fmt.Errorf("--due cannot be combined with branch names")
+		}
+		if branches, err = eng.DueProtectedSyncs(time.Now()); err != nil {
+			return err
+		}
+	case len(branches) == 0:
+		branches = eng.ProtectedBranches()
+	}
+	if len(branches) == 0 {
+		fmt.Println("No protected branches to sync")
+		return nil
+	}
+
+	var failed int
+	for _, branch := range branches {
+		status, err := eng.SyncProtectedBranch(context.Background(), branch)
+		if err != nil {
+			failed++
+			style.PrintWarning("%s: %v", branch, err)
+			continue
+		}
+		switch status.Result {
+		case refinery.SyncMerged, refinery.SyncUpToDate:
+			fmt.Printf("%s %s: %s\n", style.Bold.Render("✓"), branch, status.Result)
+		default:
+			failed++
+			fmt.Printf("%s %s: %s: %s\n", style.Bold.Render("✗"), branch, status.Result, status.Error)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d protected branch sync(s) failed", failed, len(branches))
+	}
+	return nil
+}
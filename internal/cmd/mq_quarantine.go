@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqQuarantineJSON bool
+
+var mqQuarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "Manage flaky gate quarantine",
+	RunE:  requireSubcommand,
+	Long: `Manage quarantined gates: quality gates demoted to non-blocking for
+being flaky.
+
+Enable quarantine in the rig's config.json:
+
+  "merge_queue": {
+    "quarantine": {"enabled": true, "flake_rate": 0.2, "min_runs": 10, "window": 50}
+  }
+
+With quarantine enabled, a failed gate is retried once. A gate that fails
+and then passes on retry has flaked; once flakes make up flake_rate of its
+last window runs (and it has at least min_runs), the gate is quarantined.
+Quarantined gates still run and their failures are reported, but they no
+longer fail merges or make bisection blame an MR. They stay quarantined
+until released.`,
+}
+
+var mqQuarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List gates with their flake rate and quarantine status",
+	Args:  cobra.NoArgs,
+	RunE:  runMqQuarantineList,
+}
+
+var mqQuarantineReleaseCmd = &cobra.Command{
+	Use:     "release <gate>...",
+	Aliases: []string{"unquarantine"},
+	Short:   "Release gates from quarantine",
+	Long: `Release gates from quarantine, making their failures block merges again.
+
+The gate's flake history is cleared, so it is only quarantined again if it
+keeps flaking.
+
+Examples:
+  gt mq quarantine release e2e`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runMqQuarantineRelease,
+}
+
+func init() {
+	mqQuarantineListCmd.Flags().BoolVar(&mqQuarantineJSON, "json", false, "Output as JSON")
+	mqQuarantineCmd.AddCommand(mqQuarantineListCmd)
+	mqQuarantineCmd.AddCommand(mqQuarantineReleaseCmd)
+	mqCmd.AddCommand(mqQuarantineCmd)
+}
+
+type quarantineGateView struct {
+	Gate          string    `json:"gate"`
+	Runs          int       `json:"runs"`
+	Flakes        int       `json:"flakes"`
+	Quarantined   bool      `json:"quarantined"`
+	QuarantinedAt time.Time `json:"quarantined_at,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+func runMqQuarantineList(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	statuses, err := eng.GateQuarantine()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(statuses))
+	for name := range statuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	views := make([]quarantineGateView, 0, len(names))
+	for _, name := range names {
+		s := statuses[name]
+		views = append(views, quarantineGateView{
+			Gate:          name,
+			Runs:          len(s.Outcomes),
+			Flakes:        s.Flakes(),
+			Quarantined:   s.Quarantined,
+			QuarantinedAt: s.QuarantinedAt,
+			Reason:        s.Reason,
+		})
+	}
+
+	if mqQuarantineJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(views)
+	}
+	if cfg := eng.Config().Quarantine; cfg == nil || !cfg.Enabled {
+		fmt.Printf("%s\n", style.Dim.Render("Quarantine is disabled in "+r.Name+" (merge_queue.quarantine.enabled)"))
+	}
+	if len(views) == 0 {
+		fmt.Printf("No gate runs recorded in %s\n", r.Name)
+		return nil
+	}
+	for _, v := range views {
+		status := "blocking"
+		if v.Quarantined {
+			status = style.Bold.Render("QUARANTINED") + " since " + v.QuarantinedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Printf("%s  %d of last %d runs flaky, %s\n", style.Bold.Render(v.Gate), v.Flakes, v.Runs, status)
+		if v.Reason != "" {
+			fmt.Printf("  %s\n", style.Dim.Render(v.Reason))
+		}
+	}
+	return nil
+}
+
+func runMqQuarantineRelease(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, gate := range args {
+		if err := eng.UnquarantineGate(gate); err != nil {
+			failed++
+			style.PrintWarning("%v", err)
+			continue
+		}
+		fmt.Printf("%s %s released from quarantine\n", style.Bold.Render("✓"), gate)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d gate(s) not released", failed, len(args))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqResolveCmd = &cobra.Command{
+	Use:   "resolve <mr-id>",
+	Short: "Re-queue an MR after resolving its conflicts in the scratch worktree",
+	Long: `Accept the resolution of an MR's merge conflicts.
+
+With conflict resolution enabled, the refinery merges an MR that conflicts
+with its target onto the target in a scratch worktree, stops at the
+conflicts, and slings a molecule to an agent to resolve them. Once the
+agent has committed the merge there, this command:
+
+  1. Checks the merge is committed, no conflict markers are left, and the
+     resolution builds on both the target and the MR branch
+  2. Runs the rig's gates for the target in the scratch worktree
+  3. Pushes the resolve branch and re-points the MR at it
+  4. Closes the resolution task, returning the MR to the queue
+
+If a check or gate fails nothing changes; fix the resolution and run it
+again.
+
+Enable conflict resolution in the rig's config.json:
+
+  "merge_queue": {
+    "conflict_resolution": {"enabled": true, "max_attempts": 2}
+  }
+
+Examples:
+  gt mq resolve gt-mr1`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMqResolve,
+}
+
+func init() {
+	mqCmd.AddCommand(mqResolveCmd)
+}
+
+func runMqResolve(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	mr, err := eng.ResolveConflict(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Re-queued %s on %s\n", style.Bold.Render("✓"), mr.ID, mr.Branch)
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqRevertReason string
+
+var mqRevertCmd = &cobra.Command{
+	Use:   "revert <mr-id>",
+	Short: "Land a revert of a merged MR",
+	Long: `Revert the commits a merged MR added to its target.
+
+The refinery records the commits each MR lands. This command reverts them
+on top of whatever has landed since, pushes the revert to the target like a
+landing, reopens the MR's source issue and nudges the polecat that did the
+work. Use it when something outside the refinery (a deploy check, a
+nightly run, a human) finds that a landing broke the target.
+
+If the revert conflicts with later changes, nothing is pushed.
+
+With auto_revert enabled, the refinery does this itself when the rig's
+post-merge gates fail after a landing:
+
+  "merge_queue": {
+    "auto_revert": {
+      "enabled": true,
+      "gates": {"e2e": {"cmd": "make e2e", "timeout": "30m"}}
+    }
+  }
+
+Examples:
+  gt mq revert gt-mr1 --reason "breaks login on staging"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMqRevert,
+}
+
+func init() {
+	mqRevertCmd.Flags().StringVar(&mqRevertReason, "reason", "", "Why the landing is reverted (required)")
+	_ = mqRevertCmd.MarkFlagRequired("reason")
+	mqCmd.AddCommand(mqRevertCmd)
+}
+
+func runMqRevert(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	landing, err := eng.RevertLanding(context.Background(), args[0], mqRevertReason)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Reverted %s on %s in %s\n", style.Bold.Render("✓"), landing.MR, landing.Target, landing.RevertCommit[:8])
+	if landing.SourceIssue != "" {
+		fmt.Printf("  Reopened %s\n", landing.SourceIssue)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqSplitPartial bool
+
+var mqSplitCmd = &cobra.Command{
+	Use:   "split <mr-id> <part-branch> <part-branch>...",
+	Short: "Replace an oversized MR with a stack of smaller MRs",
+	Long: `Replace an MR with one MR per part branch, in order.
+
+The part branches must form a stack: the first branches from the target and
+each later part from the one before. Unless --partial is given, the last
+part must make the same changes as the original branch, so nothing is lost
+in the split.
+
+Each part's MR copies the original's target, source issue, worker and
+merge settings, records split_from, and depends on the MR before it, so the
+parts land one after another in small batches. The original MR is closed
+as superseded, along with the split task the refinery's split policy raised
+for it, if any.
+
+Enable the split policy in the rig's config.json to have the refinery hold
+oversized MRs and sling a split molecule to their worker:
+
+  "merge_queue": {
+    "split_policy": {"enabled": true, "max_lines": 1000, "max_files": 40,
+                     "risk_patterns": ["migrations/**"], "max_risk_lines": 200}
+  }
+
+Examples:
+  gt mq split gt-mr1 polecat/nux/gt-1-part-1 polecat/nux/gt-1-part-2`,
+	Args: cobra.MinimumNArgs(3),
+	RunE: runMqSplit,
+}
+
+func init() {
+	mqSplitCmd.Flags().BoolVar(&mqSplitPartial, "partial", false, "Allow parts that cover only some of the original change")
+	mqCmd.AddCommand(mqSplitCmd)
+}
+
+func runMqSplit(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	parts, err := eng.SplitMR(args[0], args[1:], mqSplitPartial)
+	for i, mr := range parts {
+		after := ""
+		if mr.BlockedBy != "" {
+			after = style.Dim.Render(" (after " + mr.BlockedBy + ")")
+		}
+		fmt.Printf("  %d. %s %s%s\n", i+1, style.Bold.Render(mr.ID), mr.Branch, after)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Split %s into %d MRs\n", style.Bold.Render("✓"), args[0], len(parts))
+	return nil
+}
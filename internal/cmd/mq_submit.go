@@ -14,6 +14,7 @@ import (
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/refinery"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -163,7 +164,15 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 
 	// Determine target branch
 	target := defaultBranch
-	if mqSubmitEpic != "" {
+	if mqSubmitTarget != "" {
+		if mqSubmitEpic != "" {
+			return fmt.Errorf("--target and --epic are mutually exclusive")
+		}
+		if err := validateSubmitTarget(rigName, defaultBranch, mqSubmitTarget); err != nil {
+			return err
+		}
+		target = mqSubmitTarget
+	} else if mqSubmitEpic != "" {
 		// Explicit --epic flag: read stored branch name, fall back to template
 		rigPath := filepath.Join(townRoot, rigName)
 		target = resolveIntegrationBranchName(bd, rigPath, mqSubmitEpic)
@@ -202,6 +211,17 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	switch mqSubmitQoS {
+	case "", "interactive", "batch", "background":
+	default:
+		return fmt.Errorf("invalid --qos %q: want interactive, batch or background", mqSubmitQoS)
+	}
+	switch mqSubmitStrategy {
+	case "", refinery.MergeStrategySquash, refinery.MergeStrategyMergeCommit, refinery.MergeStrategyRebaseFF, refinery.MergeStrategyCherryPick, refinery.MergeStrategyAuto:
+	default:
+		return fmt.Errorf("invalid --merge-strategy %q: want squash, merge-commit, rebase-ff, cherry-pick or auto", mqSubmitStrategy)
+	}
+
 	// Build MR bead title and description
 	title := fmt.Sprintf("Merge: %s", issueID)
 	description := fmt.Sprintf("branch: %s\ntarget: %s\nsource_issue: %s\nrig: %s",
@@ -209,31 +229,37 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 	if worker != "" {
 		description += fmt.Sprintf("\nworker: %s", worker)
 	}
-
-	// Check if MR bead already exists for this branch (idempotency)
-	var mrIssue *beads.Issue
-	existingMR, err := bd.FindMRForBranch(branch)
-	if err != nil {
-		style.PrintWarning("could not check for existing MR: %v", err)
-		// FindMRForBranch failed — fall through to create a new MR
+	if mqSubmitQoS != "" {
+		description += fmt.Sprintf("\nqos: %s", mqSubmitQoS)
+	}
+	if mqSubmitStrategy != "" {
+		description += fmt.Sprintf("\nmerge_strategy: %s", mqSubmitStrategy)
 	}
 
-	if existingMR != nil {
-		mrIssue = existingMR
-		fmt.Printf("%s MR already exists (idempotent)\n", style.Bold.Render("✓"))
-	} else {
+	// Submit under the rig's queue lock, so a concurrent submit of the same
+	// branch (e.g. from gt done) can't create a second MR.
+	var mrIssue *beads.Issue
+	var created bool
+	queue := refinery.OpenQueue(&rig.Rig{Name: rigName, Path: filepath.Join(townRoot, rigName)}, bd)
+	err = queue.Update(func(u *refinery.QueueUpdate) error {
+		var err error
 		// Create MR bead (ephemeral wisp - will be cleaned up after merge)
-		mrIssue, err = bd.Create(beads.CreateOptions{
+		mrIssue, created, err = u.Submit(branch, beads.CreateOptions{
 			Title:       title,
 			Labels:      []string{"gt:merge-request"},
 			Priority:    priority,
 			Description: description,
 			Ephemeral:   true,
 		})
-		if err != nil {
-			return fmt.Errorf("creating merge request bead: %w", err)
-		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
 
+	if !created {
+		fmt.Printf("%s MR already exists (idempotent)\n", style.Bold.Render("✓"))
+	} else {
 		// Nudge refinery to pick up the new MR
 		nudgeRefinery(rigName, "MERGE_READY received - check inbox for pending work")
 
@@ -256,6 +282,12 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Worker: %s\n", worker)
 	}
 	fmt.Printf("  Priority: P%d\n", priority)
+	if mqSubmitQoS != "" {
+		fmt.Printf("  QoS: %s\n", mqSubmitQoS)
+	}
+	if mqSubmitStrategy != "" {
+		fmt.Printf("  Merge strategy: %s\n", mqSubmitStrategy)
+	}
 
 	// Auto-cleanup for polecats: if this is a polecat branch and cleanup not disabled,
 	// send lifecycle request and wait for termination
@@ -332,3 +364,27 @@ Please verify state and execute lifecycle action.
 		}
 	}
 }
+
+// validateSubmitTarget accepts the rig's default branch or one of its
+// protected branches as an explicit MR target.
+func validateSubmitTarget(rigName, defaultBranch, target string) error {
+	if target == defaultBranch {
+		return nil
+	}
+	_, r, err := getRig(rigName)
+	if err != nil {
+		return err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+	if !eng.IsProtectedBranch(target) {
+		protected := eng.ProtectedBranches()
+		if len(protected) == 0 {
+			return fmt.Errorf("%s is not a protected branch of %s (none registered in merge_queue.protected_branches)", target, rigName)
+		}
+		return fmt.Errorf("%s is not a protected branch of %s (protected: %s)", target, rigName, strings.Join(protected, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Watch command flags
+var (
+	mqWatchRig      string
+	mqWatchInterval time.Duration
+	mqWatchTimeout  time.Duration
+	mqWatchJSON     bool
+)
+
+// stageQueue marks watch lines reporting a change in the MR bead itself
+// rather than an event from the refinery's progress log.
+const stageQueue = "queue"
+
+// Watch outcomes, reported in the summary and mapped to the exit code.
+const (
+	watchMerged  = "merged"
+	watchFailed  = "failed"
+	watchTimeout = "timeout"
+)
+
+var mqWatchCmd = &cobra.Command{
+	Use:   "watch <id>",
+	Short: "Follow a merge request until it merges or fails",
+	Long: `Follow one merge request through the queue with live status lines.
+
+Prints a line as the MR is held or admitted by the test policy, batched,
+stacked, gated, bisected and finally merged or rejected, then prints a
+summary. Stages come from the refinery's progress log in the rig's
+.runtime directory; changes to the MR bead (claimed, closed) are shown
+as queue lines.
+
+If the MR's most recent run through the queue already ended, watch
+reports that run and exits.
+
+Exit codes:
+  0  merged
+  1  failed (conflict, failing gates, or closed without merging)
+  2  timed out (--timeout)
+
+Examples:
+  gt mq watch gp-mr-abc123
+  gt mq watch gp-mr-abc123 --timeout 30m
+  gt mq watch gp-mr-abc123 --json   # one JSON event per line, then a summary`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMqWatch,
+}
+
+func init() {
+	mqWatchCmd.Flags().StringVar(&mqWatchRig, "rig", "", "Rig the MR belongs to (default: from the MR, then current rig)")
+	mqWatchCmd.Flags().DurationVar(&mqWatchInterval, "interval", 2*time.Second, "Polling interval")
+	mqWatchCmd.Flags().DurationVar(&mqWatchTimeout, "timeout", 0, "Give up after this long (0 = wait indefinitely)")
+	mqWatchCmd.Flags().BoolVar(&mqWatchJSON, "json", false, "Output events and summary as JSON lines")
+	mqCmd.AddCommand(mqWatchCmd)
+}
+
+// MRWatchSummary is the final report of gt mq watch.
+type MRWatchSummary struct {
+	MR          string `json:"mr"`
+	Outcome     string `json:"outcome"`
+	Stage       string `json:"stage,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+	MergeCommit string `json:"merge_commit,omitempty"`
+	Elapsed     string `json:"elapsed"`
+}
+
+// mrWatcher tracks what has been reported for one MR between polls.
+type mrWatcher struct {
+	id           string
+	progressPath string
+	offset       int64
+	started      time.Time
+	out          io.Writer
+	json         bool
+
+	last   *refinery.ProgressEvent
+	status string
+	warned bool
+}
+
+func runMqWatch(cmd *cobra.Command, args []string) error {
+	mrID := args[0]
+	if mqWatchInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting current directory: %w", err)
+	}
+	bd := beads.New(workDir)
+
+	issue, err := bd.Show(mrID)
+	if err != nil {
+		if err == beads.ErrNotFound {
+			return fmt.Errorf("merge request '%s' not found", mrID)
+		}
+		return fmt.Errorf("fetching merge request: %w", err)
+	}
+
+	rigName := mqWatchRig
+	if fields := beads.ParseMRFields(issue); rigName == "" && fields != nil {
+		rigName = fields.Rig
+	}
+	if rigName == "" {
+		townRoot, err := workspace.FindFromCwdOrError()
+		if err != nil {
+			return fmt.Errorf("not in a Gas Town workspace: %w", err)
+		}
+		if rigName, _, err = findCurrentRig(townRoot); err != nil {
+			return fmt.Errorf("determining rig (use --rig): %w", err)
+		}
+	}
+	_, r, err := getRig(rigName)
+	if err != nil {
+		return err
+	}
+
+	w := &mrWatcher{
+		id:           mrID,
+		progressPath: refinery.ProgressPath(r.Path),
+		started:      time.Now(),
+		out:          os.Stdout,
+		json:         mqWatchJSON,
+	}
+	if !w.json {
+		fmt.Printf("%s Watching %s in %s\n", style.ArrowPrefix, mrID, rigName)
+	}
+
+	var deadline <-chan time.Time
+	if mqWatchTimeout > 0 {
+		deadline = time.After(mqWatchTimeout)
+	}
+	ticker := time.NewTicker(mqWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		if summary := w.poll(bd.Show); summary != nil {
+			return w.finish(summary)
+		}
+		select {
+		case <-deadline:
+			return w.finish(w.summary(watchTimeout, ""))
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reports new progress events and bead changes, and returns the
+// summary once the MR's run through the queue has ended.
+func (w *mrWatcher) poll(show func(string) (*beads.Issue, error)) *MRWatchSummary {
+	events, offset, err := refinery.ReadProgress(w.progressPath, w.offset)
+	if err != nil {
+		w.warn("reading progress log: %v", err)
+	}
+	w.offset = offset
+	for i := range events {
+		ev := events[i]
+		if ev.MR != w.id {
+			continue
+		}
+		// Stages re-recorded on every poll cycle (e.g. admitted) print once.
+		if w.last != nil && w.last.Stage == ev.Stage && w.last.Detail == ev.Detail {
+			continue
+		}
+		w.last = &ev
+		w.emit(ev)
+	}
+	if w.last != nil && w.last.Terminal() {
+		outcome := watchFailed
+		if w.last.Stage == refinery.StageMerged {
+			outcome = watchMerged
+		}
+		return w.summary(outcome, "")
+	}
+
+	issue, err := show(w.id)
+	if err != nil {
+		w.warn("fetching merge request: %v", err)
+		return nil
+	}
+	if status := watchStatus(issue); status != w.status {
+		w.status = status
+		w.emit(refinery.ProgressEvent{Time: time.Now().UTC(), MR: w.id, Stage: stageQueue, Detail: status})
+	}
+	if issue.Status != "closed" {
+		return nil
+	}
+	fields := beads.ParseMRFields(issue)
+	if fields != nil && fields.CloseReason == "merged" {
+		return w.summary(watchMerged, fields.MergeCommit)
+	}
+	return w.summary(watchFailed, "")
+}
+
+// watchStatus describes the MR bead's queue state for a queue line.
+func watchStatus(issue *beads.Issue) string {
+	status := issue.Status
+	if issue.Assignee != "" && issue.Status != "closed" {
+		status += " (" + issue.Assignee + ")"
+	}
+	if issue.Status == "closed" {
+		if fields := beads.ParseMRFields(issue); fields != nil && fields.CloseReason != "" {
+			status += ": " + fields.CloseReason
+		}
+	}
+	return status
+}
+
+func (w *mrWatcher) summary(outcome, mergeCommit string) *MRWatchSummary {
+	s := &MRWatchSummary{
+		MR:          w.id,
+		Outcome:     outcome,
+		MergeCommit: mergeCommit,
+		Elapsed:     time.Since(w.started).Round(time.Second).String(),
+	}
+	if w.last != nil {
+		s.Stage = w.last.Stage
+		s.Detail = w.last.Detail
+		if s.MergeCommit == "" && w.last.Stage == refinery.StageMerged {
+			s.MergeCommit = w.last.Detail
+		}
+	}
+	// A bead closed outside the refinery's progress (rejected, superseded)
+	// is described by its queue status.
+	if w.status != "" && (w.last == nil || (outcome != watchTimeout && !w.last.Terminal())) {
+		s.Stage, s.Detail = stageQueue, w.status
+	}
+	return s
+}
+
+func (w *mrWatcher) emit(ev refinery.ProgressEvent) {
+	if w.json {
+		data, _ := json.Marshal(ev)
+		_, _ = fmt.Fprintln(w.out, string(data))
+		return
+	}
+	line := fmt.Sprintf("%s  %-12s %s", ev.Time.Local().Format("15:04:05"), ev.Stage, ev.Detail)
+	if ev.BatchID != "" {
+		line += " " + style.Dim.Render("["+ev.BatchID+"]")
+	}
+	_, _ = fmt.Fprintln(w.out, line)
+}
+
+func (w *mrWatcher) warn(format string, args ...interface{}) {
+	// Repeat failures every poll would drown the stage lines.
+	if w.warned {
+		return
+	}
+	w.warned = true
+	style.PrintWarning(format, args...)
+}
+
+// finish prints the summary and returns the exit status for its outcome.
+func (w *mrWatcher) finish(s *MRWatchSummary) error {
+	if w.json {
+		data, _ := json.Marshal(s)
+		_, _ = fmt.Fprintln(w.out, string(data))
+	} else {
+		where := s.Stage
+		if s.Detail != "" {
+			where += ": " + s.Detail
+		}
+		switch s.Outcome {
+		case watchMerged:
+			_, _ = fmt.Fprintf(w.out, "%s %s merged %s after %s\n", style.SuccessPrefix, s.MR, s.MergeCommit, s.Elapsed)
+		case watchTimeout:
+			_, _ = fmt.Fprintf(w.out, "%s %s still in the queue after %s (last: %s)\n", style.WarningPrefix, s.MR, s.Elapsed, where)
+		default:
+			_, _ = fmt.Fprintf(w.out, "%s %s did not merge after %s (%s)\n", style.ErrorPrefix, s.MR, s.Elapsed, where)
+		}
+	}
+	return watchExit(s.Outcome)
+}
+
+// watchExit maps a watch outcome to the command's exit status.
+func watchExit(outcome string) error {
+	switch outcome {
+	case watchMerged:
+		return nil
+	case watchTimeout:
+		return NewSilentExit(2)
+	default:
+		return NewSilentExit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/refinery"
+)
+
+func newTestWatcher(t *testing.T) (*mrWatcher, *bytes.Buffer) {
+	t.Helper()
+	out := &bytes.Buffer{}
+	return &mrWatcher{
+		id:           "gt-mr-1",
+		progressPath: filepath.Join(t.TempDir(), "mr-progress.jsonl"),
+		started:      time.Now(),
+		out:          out,
+	}, out
+}
+
+func appendWatchEvents(t *testing.T, path string, events ...refinery.ProgressEvent) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, ev := range events {
+		data, _ := json.Marshal(ev)
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func openMR(string) (*beads.Issue, error) {
+	return &beads.Issue{ID: "gt-mr-1", Status: "open"}, nil
+}
+
+func TestMrWatcher_FollowsProgressToMerge(t *testing.T) {
+	w, out := newTestWatcher(t)
+
+	appendWatchEvents(t, w.progressPath,
+		refinery.ProgressEvent{MR: "gt-mr-1", Stage: refinery.StageAdmitted, Detail: "test task t-1 closed"},
+		refinery.ProgressEvent{MR: "gt-mr-1", Stage: refinery.StageAdmitted, Detail: "test task t-1 closed"},
+		refinery.ProgressEvent{MR: "gt-mr-2", Stage: refinery.StageBatched},
+		refinery.ProgressEvent{MR: "gt-mr-1", Stage: refinery.StageGating, BatchID: "b-1", Detail: "stack tip"},
+	)
+	if s := w.poll(openMR); s != nil {
+		t.Fatalf("poll ended early: %+v", s)
+	}
+	if got := out.String(); strings.Count(got, "admitted") != 1 || strings.Contains(got, "batched") || !strings.Contains(got, "gating") {
+		t.Errorf("unexpected watch output:\n%s", got)
+	}
+
+	appendWatchEvents(t, w.progressPath,
+		refinery.ProgressEvent{MR: "gt-mr-1", Stage: refinery.StageMerged, BatchID: "b-1", Detail: "abc12345"},
+	)
+	s := w.poll(openMR)
+	if s == nil || s.Outcome != watchMerged || s.MergeCommit != "abc12345" {
+		t.Fatalf("summary = %+v, want merged abc12345", s)
+	}
+	if err := watchExit(s.Outcome); err != nil {
+		t.Errorf("merged exit = %v, want nil", err)
+	}
+}
+
+func TestMrWatcher_CulpritFails(t *testing.T) {
+	w, _ := newTestWatcher(t)
+	appendWatchEvents(t, w.progressPath,
+		refinery.ProgressEvent{MR: "gt-mr-1", Stage: refinery.StageBisecting},
+		refinery.ProgressEvent{MR: "gt-mr-1", Stage: refinery.StageCulprit, Detail: "failed gates"},
+	)
+	s := w.poll(openMR)
+	if s == nil || s.Outcome != watchFailed || s.Stage != refinery.StageCulprit {
+		t.Fatalf("summary = %+v, want failed at culprit", s)
+	}
+	if err, ok := watchExit(s.Outcome).(*SilentExitError); !ok || err.Code != 1 {
+		t.Errorf("culprit exit = %v, want code 1", err)
+	}
+}
+
+func TestMrWatcher_BeadClosed(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   string
+	}{
+		{"merged", watchMerged},
+		{"rejected", watchFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			w, _ := newTestWatcher(t)
+			show := func(string) (*beads.Issue, error) {
+				return &beads.Issue{
+					ID:          "gt-mr-1",
+					Status:      "closed",
+					Description: "branch: polecat/a\nclose_reason: " + tt.reason,
+				}, nil
+			}
+			s := w.poll(show)
+			if s == nil || s.Outcome != tt.want {
+				t.Fatalf("summary = %+v, want %s", s, tt.want)
+			}
+			if s.Stage != stageQueue || !strings.Contains(s.Detail, tt.reason) {
+				t.Errorf("summary stage = %s: %s, want queue status with %q", s.Stage, s.Detail, tt.reason)
+			}
+		})
+	}
+}
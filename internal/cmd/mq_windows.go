@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mqWindowsJSON bool
+
+var mqWindowsCmd = &cobra.Command{
+	Use:   "windows",
+	Short: "Show merge windows and the batches held until they open",
+	Long: `Show the rig's merge windows, whether each is open, when it next opens,
+and the validated batches held until then.
+
+Merge windows are configured in the rig's config.json as cron schedules:
+
+  "merge_queue": {
+    "merge_windows": [
+      {"schedule": "0 22 * * mon-fri", "duration": "8h", "timezone": "Europe/Berlin"},
+      {"schedule": "* 9-16 * * *", "targets": ["release"]}
+    ]
+  }
+
+A target with merge windows only lands while one is open. Outside them the
+refinery still stacks and gates batches but holds the final push; a held
+batch lands without gating again when a window opens, unless the target or
+its branches have moved since. Targets no window applies to land any time.
+
+Examples:
+  gt mq windows
+  gt mq windows --json`,
+	Args: cobra.NoArgs,
+	RunE: runMqWindows,
+}
+
+func init() {
+	mqWindowsCmd.Flags().BoolVar(&mqWindowsJSON, "json", false, "Output as JSON")
+	mqCmd.AddCommand(mqWindowsCmd)
+}
+
+// mqWindowStatus is a merge window's state for --json output.
+type mqWindowStatus struct {
+	Schedule string    `json:"schedule"`
+	Duration string    `json:"duration,omitempty"`
+	Timezone string    `json:"timezone,omitempty"`
+	Targets  []string  `json:"targets,omitempty"`
+	Open     bool      `json:"open"`
+	NextOpen time.Time `json:"next_open,omitempty"`
+}
+
+func runMqWindows(cmd *cobra.Command, args []string) error {
+	r, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	windows := eng.Config().MergeWindows
+	statuses := make([]mqWindowStatus, 0, len(windows))
+	for _, w := range windows {
+		statuses = append(statuses, mqWindowStatus{
+			Schedule: w.Schedule,
+			Duration: w.Duration,
+			Timezone: w.Timezone,
+			Targets:  w.Targets,
+			Open:     w.Open(now),
+			NextOpen: w.NextOpen(now),
+		})
+	}
+	holds, err := eng.HeldLandings()
+	if err != nil {
+		return err
+	}
+
+	if mqWindowsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Windows []mqWindowStatus                 `json:"windows"`
+			Held    map[string]*refinery.HeldLanding `json:"held"`
+		}{statuses, holds})
+	}
+
+	if len(windows) == 0 {
+		fmt.Printf("No merge windows in %s: batches land at any time\n", r.Name)
+	} else {
+		fmt.Printf("%s\n", style.Bold.Render("Merge windows"))
+		for i, w := range windows {
+			targets := "all targets"
+			if len(w.Targets) > 0 {
+				targets = strings.Join(w.Targets, ", ")
+			}
+			state := style.Warning.Render("closed")
+			if statuses[i].Open {
+				state = style.Bold.Render("open")
+			}
+			next := ""
+			if !statuses[i].Open && !statuses[i].NextOpen.IsZero() {
+				next = style.Dim.Render(" opens " + statuses[i].NextOpen.Local().Format(time.RFC1123))
+			}
+			fmt.Printf("  %-6s %s  %s%s\n", state, w, style.Dim.Render(targets), next)
+		}
+	}
+
+	if len(holds) == 0 {
+		return nil
+	}
+	targets := make([]string, 0, len(holds))
+	for t := range holds {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+	fmt.Printf("\n%s\n", style.Bold.Render("Held"))
+	for _, t := range targets {
+		h := holds[t]
+		fmt.Printf("  %-20s %s  %s\n", t, strings.Join(h.MRs(), ", "),
+			style.Dim.Render("validated "+h.HeldAt.Local().Format(time.RFC1123)))
+	}
+	return nil
+}
@@ -137,10 +137,11 @@ var idleWatcherTimeout = 60 * time.Second
 var idleWatcherPollInterval = 1 * time.Second
 
 // deliverNudge routes a nudge based on the --mode flag.
-// For "immediate" mode: sends directly via tmux (current behavior).
+// For "immediate" mode: sends directly via the multiplexer (current behavior).
 // For "queue" mode: writes to the nudge queue for cooperative delivery.
 // For "wait-idle" mode: waits for idle, then delivers or falls back to queue.
-func deliverNudge(t *tmux.Tmux, sessionName, message, sender string) error {
+// Idle detection needs tmux; on other backends wait-idle queues instead.
+func deliverNudge(mux tmux.Multiplexer, sessionName, message, sender string) error {
 	townRoot, _ := workspace.FindFromCwd()
 
 	// For direct tmux delivery, prefix with sender attribution.
@@ -165,6 +166,15 @@ func deliverNudge(t *tmux.Tmux, sessionName, message, sender string) error {
 			// rather than silently degrading to immediate (destructive) delivery.
 			return fmt.Errorf("--mode=wait-idle requires a Gas Town workspace")
 		}
+		t, ok := mux.(*tmux.Tmux)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "wait-idle: idle detection needs tmux, using queue mode for %s\n", sessionName)
+			return nudge.Enqueue(townRoot, sessionName, nudge.QueuedNudge{
+				Sender:   sender,
+				Message:  message,
+				Priority: nudgePriorityFlag,
+			})
+		}
 		// Check if the target agent supports prompt-based idle detection.
 		// WaitForIdle uses Claude Code's prompt pattern (❯) and status bar (⏵⏵).
 		// Non-Claude agents (Gemini, Codex, etc.) have no ReadyPromptPrefix,
@@ -244,7 +254,7 @@ func deliverNudge(t *tmux.Tmux, sessionName, message, sender string) error {
 		return nil
 
 	default: // NudgeModeImmediate
-		return t.NudgeSession(sessionName, prefixedMessage)
+		return tmux.Nudge(mux, sessionName, prefixedMessage)
 	}
 }
 
@@ -335,7 +345,10 @@ func runNudge(cmd *cobra.Command, args []string) (retErr error) {
 	if nudgeIfFreshFlag {
 		sessionName := tmux.CurrentSessionName()
 		if sessionName != "" {
-			t := tmux.NewTmux()
+			t, err := tmux.RequireTmux("--if-fresh")
+			if err != nil {
+				return err
+			}
 			created, err := t.GetSessionCreatedUnix(sessionName)
 			if err == nil && created > 0 {
 				age := time.Since(time.Unix(created, 0))
@@ -416,7 +429,10 @@ func runNudge(cmd *cobra.Command, args []string) (retErr error) {
 		}
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Expand role shortcuts to session names
 	// These shortcuts let users type "mayor" instead of "gt-mayor"
@@ -611,7 +627,10 @@ func runNudgeChannel(channelName, message, sender string) error {
 	}
 
 	// Send nudges via deliverNudge (respects --mode flag)
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	var succeeded, failed, skipped int
 	var failures []string
 
@@ -62,7 +62,10 @@ func runNudgePoller(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid --idle-timeout: %w", err)
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Verify session exists before starting the loop.
 	if exists, _ := t.HasSession(sessionName); !exists {
@@ -98,7 +101,10 @@ func runNudgePoller(cmd *cobra.Command, args []string) error {
 			// their TUI doesn't match Claude's idle patterns. In that case we drain
 			// anyway — delivering a nudge mid-work is better than never delivering it.
 			// The poll interval (10s) provides natural rate limiting.
-			_ = t.WaitForIdle(sessionName, idleTimeout)
+			// Other multiplexers can't see the prompt, so they always drain.
+			if tt, ok := t.(*tmux.Tmux); ok {
+				_ = tt.WaitForIdle(sessionName, idleTimeout)
+			}
 
 			// Drain and inject.
 			drained, err := nudge.Drain(townRoot, sessionName)
@@ -111,7 +117,7 @@ func runNudgePoller(cmd *cobra.Command, args []string) error {
 			}
 
 			formatted := nudge.FormatForInjection(drained)
-			if err := t.NudgeSession(sessionName, formatted); err != nil {
+			if err := tmux.Nudge(t, sessionName, formatted); err != nil {
 				fmt.Fprintf(os.Stderr, "nudge-poller: injection error for %s: %v\n", sessionName, err)
 			}
 		}
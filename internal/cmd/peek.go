@@ -76,7 +76,10 @@ func runPeek(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("not in a Gas Town workspace: %w", err)
 		}
-		t := tmux.NewTmux()
+		t, err := tmux.NewMultiplexer()
+		if err != nil {
+			return err
+		}
 		output, err := t.CapturePane(sessionName, lines)
 		if err != nil {
 			return fmt.Errorf("capturing %s: %w", address, err)
@@ -222,7 +222,10 @@ func runPolecatIdentityAdd(cmd *cobra.Command, args []string) error {
 	// Generate name if not provided
 	if polecatName == "" {
 		polecatGit := git.NewGit(r.Path)
-		t := tmux.NewTmux()
+		t, err := tmux.NewMultiplexer()
+		if err != nil {
+			return err
+		}
 		mgr := polecat.NewManager(r, polecatGit, t)
 		polecatName, err = mgr.AllocateName()
 		if err != nil {
@@ -277,7 +280,10 @@ func runPolecatIdentityList(cmd *cobra.Command, args []string) error {
 
 	// Filter for polecat beads in this rig
 	identities := []IdentityInfo{} // Initialize to empty slice (not nil) for JSON
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	polecatMgr := polecat.NewSessionManager(t, r)
 
 	for id, issue := range agentBeads {
@@ -396,7 +402,10 @@ func runPolecatIdentityShow(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check worktree and session
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	polecatMgr := polecat.NewSessionManager(t, r)
 	mgr := polecat.NewManager(r, nil, t)
 
@@ -583,7 +592,10 @@ func runPolecatIdentityRename(cmd *cobra.Command, args []string) error {
 	}
 
 	// Safety check: no active session
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	polecatMgr := polecat.NewSessionManager(t, r)
 	running, _ := polecatMgr.IsRunning(oldName)
 	if running {
@@ -654,7 +666,10 @@ func runPolecatIdentityRemove(cmd *cobra.Command, args []string) error {
 		var reasons []string
 
 		// Check for active session
-		t := tmux.NewTmux()
+		t, err := tmux.NewMultiplexer()
+		if err != nil {
+			return err
+		}
 		polecatMgr := polecat.NewSessionManager(t, r)
 		running, _ := polecatMgr.IsRunning(polecatName)
 		if running {
@@ -83,7 +83,10 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 
 	// Get polecat manager (with tmux for session-aware allocation)
 	polecatGit := git.NewGit(r.Path)
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return nil, err
+	}
 	polecatMgr := polecat.NewManager(r, polecatGit, t)
 
 	// Pre-spawn Dolt health check (gt-94llt7): verify Dolt is reachable before
@@ -349,8 +352,11 @@ func (s *SpawnedPolecatInfo) StartSession() (string, error) {
 		return "", fmt.Errorf("resolving account: %w", err)
 	}
 
-	// Start session
-	t := tmux.NewTmux()
+	// Start session. Slung work is delivered to the session's pane.
+	t, err := tmux.RequireTmux("starting a slung polecat")
+	if err != nil {
+		return "", err
+	}
 	polecatSessMgr := polecat.NewSessionManager(t, r)
 
 	fmt.Printf("Starting session for %s/%s...\n", s.RigName, s.PolecatName)
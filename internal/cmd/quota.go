@@ -232,7 +232,10 @@ func runQuotaScan(cmd *cobra.Command, args []string) error {
 	// acctCfg can be nil if no accounts configured — scan still works
 
 	// Create scanner
-	t := ttmux.NewTmux()
+	t, err := ttmux.RequireTmux("quota scans")
+	if err != nil {
+		return err
+	}
 	scanner, err := quota.NewScanner(t, nil, acctCfg)
 	if err != nil {
 		return fmt.Errorf("creating scanner: %w", err)
@@ -409,7 +412,10 @@ func runQuotaRotate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create scanner and plan rotation
-	t := ttmux.NewTmux()
+	t, err := ttmux.RequireTmux("quota scans")
+	if err != nil {
+		return err
+	}
 	scanner, err := quota.NewScanner(t, nil, acctCfg)
 	if err != nil {
 		return fmt.Errorf("creating scanner: %w", err)
@@ -862,7 +868,11 @@ func runQuotaWatch(cmd *cobra.Command, args []string) error {
 }
 
 func runWatchCycle(townRoot string, acctCfg *config.AccountsConfig) {
-	t := ttmux.NewTmux()
+	t, err := ttmux.RequireTmux("quota scans")
+	if err != nil {
+		style.PrintWarning("%v", err)
+		return
+	}
 	scanner, err := quota.NewScanner(t, nil, acctCfg)
 	if err != nil {
 		style.PrintWarning("creating scanner: %v", err)
@@ -11,14 +11,15 @@ import (
 )
 
 var (
-	reaperDB       string
-	reaperPort     int
-	reaperMaxAge   string
-	reaperPurgeAge string
-	reaperMailAge  string
-	reaperStaleAge string
-	reaperDryRun   bool
-	reaperJSON     bool
+	reaperDB        string
+	reaperPort      int
+	reaperMaxAge    string
+	reaperPurgeAge  string
+	reaperMailAge   string
+	reaperStaleAge  string
+	reaperRetention string
+	reaperDryRun    bool
+	reaperJSON      bool
 )
 
 var reaperCmd = &cobra.Command{
@@ -35,7 +36,8 @@ When run by a Dog:
   gt reaper scan --db=gastown          # Discover candidates
   gt reaper reap --db=gastown          # Close stale wisps
   gt reaper purge --db=gastown         # Delete old closed wisps + mail
-  gt reaper auto-close --db=gastown    # Close stale issues`,
+  gt reaper auto-close --db=gastown    # Close stale issues
+  gt reaper gc --db=gastown            # Tombstone long-closed issues`,
 	RunE: requireSubcommand,
 }
 
@@ -263,6 +265,60 @@ Returns the count of closed issues. Use --dry-run to preview.`,
 	},
 }
 
+var reaperGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Tombstone closed issues past retention",
+	Long: `Tombstone issues closed longer than the retention period.
+
+Tombstoned issues keep their ID, title, labels, dependencies and events,
+so merge commits, MR beads and audit logs that reference them still
+resolve. Comments, notes, design and acceptance criteria are dropped and
+the description is compacted to its reference fields (merge_commit,
+source_issue, branch, attached_molecule, ...). Rows are never deleted.
+
+Skipped: agents, mail (see purge), issues labelled gt:keep,
+gt:standing-orders, gt:role or gt:rig, and issues an open issue depends on.
+
+The compactor_dog runs this before flattening history when gc_retention is
+set. Use --dry-run to preview.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if reaperDB == "" {
+			return fmt.Errorf("--db is required")
+		}
+
+		retention, err := time.ParseDuration(reaperRetention)
+		if err != nil {
+			return fmt.Errorf("invalid --retention: %w", err)
+		}
+
+		db, err := reaper.OpenDB("127.0.0.1", reaperPort, reaperDB, 30*time.Second, 30*time.Second)
+		if err != nil {
+			return fmt.Errorf("connect to %s: %w", reaperDB, err)
+		}
+		defer db.Close()
+
+		result, err := reaper.GC(db, reaperDB, retention, reaperDryRun)
+		if err != nil {
+			return fmt.Errorf("gc %s: %w", reaperDB, err)
+		}
+
+		if reaperJSON {
+			fmt.Println(reaper.FormatJSON(result))
+		} else {
+			prefix := ""
+			if result.DryRun {
+				prefix = "[DRY RUN] would "
+			}
+			fmt.Printf("%s: %stombstoned %d issues, dropped %d comments, compacted %d bytes\n",
+				result.Database, prefix, result.Tombstoned, result.CommentsDropped, result.BytesCompacted)
+			for _, a := range result.Anomalies {
+				fmt.Printf("  %s %s\n", style.Warning.Render("ANOMALY:"), a.Message)
+			}
+		}
+		return nil
+	},
+}
+
 var reaperRunCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run full reaper cycle across all databases",
@@ -375,14 +431,14 @@ Normally the daemon dispatches a Dog to execute the mol-dog-reaper formula.`,
 
 func init() {
 	// Shared flags
-	for _, cmd := range []*cobra.Command{reaperScanCmd, reaperReapCmd, reaperPurgeCmd, reaperAutoCloseCmd, reaperRunCmd} {
+	for _, cmd := range []*cobra.Command{reaperScanCmd, reaperReapCmd, reaperPurgeCmd, reaperAutoCloseCmd, reaperGCCmd, reaperRunCmd} {
 		cmd.Flags().StringVar(&reaperDB, "db", "", "Database name (required for single-db commands)")
 		cmd.Flags().IntVar(&reaperPort, "port", 3307, "Dolt server port")
 		cmd.Flags().BoolVar(&reaperDryRun, "dry-run", false, "Report what would happen without acting")
 	}
 
 	// JSON output flag for single-db commands
-	for _, cmd := range []*cobra.Command{reaperScanCmd, reaperReapCmd, reaperPurgeCmd, reaperAutoCloseCmd, reaperDatabasesCmd} {
+	for _, cmd := range []*cobra.Command{reaperScanCmd, reaperReapCmd, reaperPurgeCmd, reaperAutoCloseCmd, reaperGCCmd, reaperDatabasesCmd} {
 		cmd.Flags().BoolVar(&reaperJSON, "json", false, "Output as JSON")
 	}
 
@@ -397,12 +453,14 @@ func init() {
 	for _, cmd := range []*cobra.Command{reaperScanCmd, reaperAutoCloseCmd, reaperRunCmd} {
 		cmd.Flags().StringVar(&reaperStaleAge, "stale-age", "720h", "Max issue staleness before auto-close (30d)")
 	}
+	reaperGCCmd.Flags().StringVar(&reaperRetention, "retention", "2160h", "How long an issue stays closed before GC tombstones it (90d)")
 
 	reaperCmd.AddCommand(reaperDatabasesCmd)
 	reaperCmd.AddCommand(reaperScanCmd)
 	reaperCmd.AddCommand(reaperReapCmd)
 	reaperCmd.AddCommand(reaperPurgeCmd)
 	reaperCmd.AddCommand(reaperAutoCloseCmd)
+	reaperCmd.AddCommand(reaperGCCmd)
 	reaperCmd.AddCommand(reaperRunCmd)
 
 	rootCmd.AddCommand(reaperCmd)
@@ -191,6 +191,11 @@ var refineryReadyCmd = &cobra.Command{
 Shows MRs that are:
 - Not currently claimed by any worker (or claim is stale)
 - Not blocked by an open task (e.g., conflict resolution in progress)
+- Admitted by the rig's test policy, when merge_queue.test_policy is enabled
+
+MRs that change code without touching tests are held by the test policy:
+a write-tests task is created, the MR is blocked on it, and a molecule is
+slung to the MR's worker. Held MRs are listed separately.
 
 This is the preferred command for finding work to process.
 
@@ -357,6 +362,9 @@ type RefineryStatusOutput struct {
 	RigName     string `json:"rig_name"`
 	Session     string `json:"session,omitempty"`
 	QueueLength int    `json:"queue_length"`
+
+	// MergeQueue is the queue's pause, pending preemptions and last batch.
+	MergeQueue *refinery.QueueStatus `json:"merge_queue,omitempty"`
 }
 
 func runRefineryStatus(cmd *cobra.Command, args []string) error {
@@ -365,7 +373,7 @@ func runRefineryStatus(cmd *cobra.Command, args []string) error {
 		rigName = args[0]
 	}
 
-	mgr, _, rigName, err := getRefineryManager(rigName)
+	mgr, r, rigName, err := getRefineryManager(rigName)
 	if err != nil {
 		return err
 	}
@@ -378,12 +386,15 @@ func runRefineryStatus(cmd *cobra.Command, args []string) error {
 	queue, _ := mgr.Queue()
 	queueLen := len(queue)
 
+	mq, _ := refineryQueueStatus(r)
+
 	// JSON output
 	if refineryStatusJSON {
 		output := RefineryStatusOutput{
 			Running:     running,
 			RigName:     rigName,
 			QueueLength: queueLen,
+			MergeQueue:  mq,
 		}
 		if sessionInfo != nil {
 			output.Session = sessionInfo.Name
@@ -406,6 +417,7 @@ func runRefineryStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("\n  Queue: %d pending\n", queueLen)
+	printQueueStatus(mq)
 
 	return nil
 }
@@ -507,7 +519,10 @@ func runRefineryAttach(cmd *cobra.Command, args []string) error {
 	sessionID := session.RefinerySessionName(session.PrefixFor(rigName))
 
 	// Check if session exists
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	running, err := t.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
@@ -704,32 +719,59 @@ func runRefineryReady(cmd *cobra.Command, args []string) error {
 		return runRefineryReadyAll(eng, rigName)
 	}
 
-	// Get ready MRs (unclaimed AND unblocked)
+	// Get ready MRs (unclaimed AND unblocked), then apply admission policies.
+	// Engineer progress goes to stderr so it can't corrupt JSON output.
+	if refineryReadyJSON {
+		eng.SetOutput(os.Stderr)
+	}
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
 	ready, err := eng.ListReadyMRs()
 	if err != nil {
 		return fmt.Errorf("listing ready MRs: %w", err)
 	}
+	ready, held := eng.AdmitMRs(ready)
 	anomalies, err := eng.ListQueueAnomalies(time.Now())
 	if err != nil {
 		return fmt.Errorf("listing queue anomalies: %w", err)
 	}
+	paused, err := eng.Paused()
+	if err != nil {
+		return err
+	}
 
 	// JSON output
 	if refineryReadyJSON {
 		type readyOutput struct {
 			Ready     []*refinery.MRInfo    `json:"ready"`
+			Held      []*refinery.MRInfo    `json:"held,omitempty"`
 			Anomalies []*refinery.MRAnomaly `json:"anomalies,omitempty"`
+			Paused    *refinery.PauseState  `json:"paused,omitempty"`
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(readyOutput{
 			Ready:     ready,
+			Held:      held,
 			Anomalies: anomalies,
+			Paused:    paused,
 		})
 	}
 
 	// Human-readable output
 	fmt.Printf("%s Ready MRs for '%s':\n\n", style.Bold.Render("🚀"), rigName)
+	if paused != nil {
+		fmt.Printf("  %s %s\n\n", style.Bold.Render("⏸"), paused)
+	}
+
+	for _, mr := range held {
+		if mr.BlockedBy == "" {
+			fmt.Printf("  %s %s held for %s\n", style.Dim.Render("⏸"), mr.ID, mr.HeldFor)
+			continue
+		}
+		fmt.Printf("  %s %s held for %s (task %s)\n", style.Dim.Render("⏸"), mr.ID, mr.HeldFor, mr.BlockedBy)
+	}
 
 	if len(ready) == 0 {
 		fmt.Printf("  %s\n", style.Dim.Render("(none ready)"))
@@ -740,6 +782,9 @@ func runRefineryReady(cmd *cobra.Command, args []string) error {
 		priority := fmt.Sprintf("P%d", mr.Priority)
 		fmt.Printf("  %d. [%s] %s → %s\n", i+1, priority, mr.Branch, mr.Target)
 		fmt.Printf("     ID: %s  Worker: %s\n", mr.ID, mr.Worker)
+		if mr.InheritedFrom != "" {
+			fmt.Printf("     %s\n", style.Dim.Render("Priority inherited from "+mr.InheritedFrom+", which it blocks"))
+		}
 	}
 
 	if len(anomalies) > 0 {
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/daemon"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Refinery control flags
+var (
+	refineryPauseReason string
+	refineryEjectReason string
+)
+
+var refineryPauseCmd = &cobra.Command{
+	Use:   "pause [rig]",
+	Short: "Stop the merge queue landing anything until resumed",
+	Long: `Pause a rig's merge queue, e.g. during an incident. Same as
+'gt mq pause --rig <rig>'.
+
+While paused, the refinery lands nothing: each batch is deferred untouched
+and stays queued. The pause survives refinery restarts until
+'gt refinery resume'.
+
+The request goes through the daemon; if it isn't running, the pause is
+recorded directly in the rig.
+
+Examples:
+  gt refinery pause greenplace --reason "prod incident, see #ops"
+  gt refinery resume greenplace`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryPause,
+}
+
+var refineryResumeCmd = &cobra.Command{
+	Use:   "resume [rig]",
+	Short: "Let the merge queue land again after gt refinery pause",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runRefineryResume,
+}
+
+var refineryRetryCmd = &cobra.Command{
+	Use:   "retry <mr-id> [rig]",
+	Short: "Return a claimed MR to the merge queue",
+	Long: `Release a merge request's claim so it is queued again, e.g. after the
+worker processing it got stuck. It takes its place by priority on the next
+batch.
+
+Examples:
+  gt refinery retry gt-mr-abc123
+  gt refinery retry gt-mr-abc123 greenplace`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRefineryRetry,
+}
+
+var refineryEjectCmd = &cobra.Command{
+	Use:   "eject <mr-id> [rig]",
+	Short: "Take an MR out of the merge queue for good",
+	Long: `Reject a merge request, closing it without merging, and notify its
+worker. Use it for an MR that keeps breaking batches or should not land.
+
+Examples:
+  gt refinery eject gt-mr-abc123 --reason "breaks the build on arm64"`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRefineryEject,
+}
+
+func init() {
+	refineryPauseCmd.Flags().StringVar(&refineryPauseReason, "reason", "", "Why the queue is paused")
+	refineryEjectCmd.Flags().StringVarP(&refineryEjectReason, "reason", "r", "", "Why the MR is ejected (sent to its worker)")
+
+	refineryCmd.AddCommand(refineryPauseCmd)
+	refineryCmd.AddCommand(refineryResumeCmd)
+	refineryCmd.AddCommand(refineryRetryCmd)
+	refineryCmd.AddCommand(refineryEjectCmd)
+}
+
+// refineryControl resolves a rig for the refinery control commands and
+// returns a client for the town's daemon.
+func refineryControl(rigName string) (*rig.Rig, *daemon.RefineryControl, error) {
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return nil, nil, err
+	}
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	return r, daemon.NewRefineryControl(townRoot), nil
+}
+
+// localRefineryEngineer returns an engineer for r, for when the daemon
+// can't be reached.
+func localRefineryEngineer(r *rig.Rig) (*refinery.Engineer, error) {
+	fmt.Printf("%s\n", style.Dim.Render("(daemon not running; acting on the rig directly)"))
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return nil, fmt.Errorf("loading merge queue config: %w", err)
+	}
+	return eng, nil
+}
+
+func runRefineryPause(cmd *cobra.Command, args []string) error {
+	return pauseMergeQueue(optionalArg(args, 0), refineryPauseReason)
+}
+
+func runRefineryResume(cmd *cobra.Command, args []string) error {
+	return resumeMergeQueue(optionalArg(args, 0))
+}
+
+func runRefineryRetry(cmd *cobra.Command, args []string) error {
+	mrID := args[0]
+	r, ctl, err := refineryControl(optionalArg(args, 1))
+	if err != nil {
+		return err
+	}
+	err = ctl.Retry(r.Name, mrID)
+	if errors.Is(err, daemon.ErrDaemonUnreachable) {
+		eng, lerr := localRefineryEngineer(r)
+		if lerr != nil {
+			return lerr
+		}
+		err = eng.Requeue(mrID)
+	}
+	if err != nil {
+		return fmt.Errorf("retrying %s: %w", mrID, err)
+	}
+	fmt.Printf("%s Returned %s to the merge queue\n", style.Bold.Render("✓"), mrID)
+	return nil
+}
+
+func runRefineryEject(cmd *cobra.Command, args []string) error {
+	mrID := args[0]
+	r, ctl, err := refineryControl(optionalArg(args, 1))
+	if err != nil {
+		return err
+	}
+	err = ctl.Eject(r.Name, mrID, refineryEjectReason)
+	if errors.Is(err, daemon.ErrDaemonUnreachable) {
+		fmt.Printf("%s\n", style.Dim.Render("(daemon not running; acting on the rig directly)"))
+		_, err = daemon.EjectMR(r, mrID, refineryEjectReason)
+	}
+	if err != nil {
+		return fmt.Errorf("ejecting %s: %w", mrID, err)
+	}
+	fmt.Printf("%s Ejected %s from the merge queue\n", style.Bold.Render("✓"), mrID)
+	return nil
+}
+
+// refineryQueueStatus returns r's merge queue status, from the daemon when
+// it is running.
+func refineryQueueStatus(r *rig.Rig) (*refinery.QueueStatus, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, err
+	}
+	status, err := daemon.NewRefineryControl(townRoot).Status(r.Name)
+	if !errors.Is(err, daemon.ErrDaemonUnreachable) {
+		return status, err
+	}
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return nil, err
+	}
+	return eng.QueueStatus()
+}
+
+// printQueueStatus prints the merge queue part of gt refinery status.
+func printQueueStatus(mq *refinery.QueueStatus) {
+	if mq == nil {
+		return
+	}
+	if mq.Paused != nil {
+		fmt.Printf("  Paused: %s\n", style.Bold.Render(mq.Paused.String()))
+	}
+	for _, p := range mq.Preemptions {
+		fmt.Printf("  Preempting: %s on %s (%s ago)\n", p.MR.ID, p.Target, time.Since(p.At).Round(time.Second))
+	}
+	if b := mq.LastBatch; b != nil {
+		outcome := fmt.Sprintf("%d merged", len(b.Merged))
+		switch {
+		case b.Cancelled:
+			outcome = "cancelled"
+		case b.Error != "":
+			outcome += ", " + b.Error
+		case len(b.Culprits) > 0:
+			outcome += fmt.Sprintf(", %d rejected", len(b.Culprits))
+		}
+		fmt.Printf("  Last batch: %s on %s, %s (%s ago)\n", b.BatchID, b.Target, outcome,
+			time.Since(b.FinishedAt).Round(time.Second))
+	}
+}
+
+// optionalArg returns args[i], or "" if there is none.
+func optionalArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
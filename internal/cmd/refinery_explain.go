@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var refineryExplainJSON bool
+
+var refineryExplainCmd = &cobra.Command{
+	Use:   "explain <batch-id>",
+	Short: "Explain what happened to a batch",
+	Long: `Tell the story of a processed batch from the rig's batch log.
+
+Shows the batch's members and stacking order, every gate run in order (the
+stack tip, retries and bisection probes, with the gates that failed), what
+landed, why each culprit was blamed, and the gate logs kept for it (see
+gt mq logs).
+
+Batch IDs appear in the refinery's output, in gt mq watch, in webhook and
+pull request reports, and in the gate_logs field of a culprit's MR bead.
+
+Examples:
+  gt refinery explain batch-20260101T120000Z-3
+  gt refinery explain batch-20260101T120000Z-3 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRefineryExplain,
+}
+
+func init() {
+	refineryExplainCmd.Flags().BoolVar(&refineryExplainJSON, "json", false, "Output the batch record as JSON")
+	refineryCmd.AddCommand(refineryExplainCmd)
+}
+
+func runRefineryExplain(cmd *cobra.Command, args []string) error {
+	_, eng, err := currentRigEngineer()
+	if err != nil {
+		return err
+	}
+	if refineryExplainJSON {
+		record, err := eng.FindBatch(args[0])
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(record)
+	}
+	return eng.ExplainBatch(os.Stdout, args[0])
+}
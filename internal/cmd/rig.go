@@ -19,6 +19,7 @@ import (
 	"github.com/steveyegge/gastown/internal/deps"
 	"github.com/steveyegge/gastown/internal/doltserver"
 	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/githooks"
 	"github.com/steveyegge/gastown/internal/hooks"
 	"github.com/steveyegge/gastown/internal/polecat"
 	"github.com/steveyegge/gastown/internal/refinery"
@@ -326,7 +327,7 @@ var (
 	// Test seams for checkUncommittedWork.
 	listPolecatsForWorkCheck = func(r *rig.Rig) ([]*polecat.Polecat, error) {
 		polecatGit := git.NewGit(r.Path)
-		polecatMgr := polecat.NewManager(r, polecatGit, nil) // nil tmux: just listing
+		polecatMgr := polecat.NewManager(r, polecatGit, nil) // nil mux: just listing
 		return polecatMgr.List()
 	}
 	checkPolecatWorkStatus = func(clonePath string) (*git.UncommittedWorkStatus, error) {
@@ -746,7 +747,10 @@ func runRigList(cmd *cobra.Command, args []string) error {
 	// Create rig manager to get details
 	g := git.NewGit(townRoot)
 	mgr := rig.NewManager(townRoot, rigsConfig, g)
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	type rigInfo struct {
 		Name        string `json:"name"`
@@ -875,7 +879,10 @@ func runRigRemove(cmd *cobra.Command, args []string) error {
 	mgr := rig.NewManager(townRoot, rigsConfig, g)
 
 	// Check for running tmux sessions before removing
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	sessions, sessErr := findRigSessions(t, name)
 	if sessErr != nil {
 		if !rigRemoveForce {
@@ -901,7 +908,7 @@ func runRigRemove(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Killing %d tmux session(s) for rig %s...\n", len(sessions), name)
 		var killErrors []string
 		for _, s := range sessions {
-			if err := t.KillSessionWithProcesses(s); err != nil {
+			if err := tmux.KillWithProcesses(t, s); err != nil {
 				fmt.Printf("  %s Failed to kill session %s: %v\n", style.Warning.Render("!"), s, err)
 				killErrors = append(killErrors, s)
 			} else {
@@ -967,7 +974,10 @@ func runRigRemove(cmd *cobra.Command, args []string) error {
 // Non-fatal: failure only means existing sessions need a restart to pick up the
 // new prefix.
 func refreshCycleBindingsOnExistingSessions() {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("cycle bindings")
+	if err != nil {
+		return
+	}
 	sessions, err := t.ListSessions()
 	if err != nil || len(sessions) == 0 {
 		return
@@ -1310,7 +1320,10 @@ func runRigReset(cmd *cobra.Command, args []string) error {
 
 // runResetStale resets in_progress issues whose assigned agent no longer has a session.
 func runResetStale(bd *beads.Beads, dryRun bool) error {
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Get all in_progress issues
 	issues, err := bd.List(beads.ListOptions{
@@ -1475,7 +1488,10 @@ func runRigBoot(cmd *cobra.Command, args []string) error {
 	var started []string
 	var skipped []string
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// 1. Start the witness
 	// Check actual tmux session, not state file (may be stale)
@@ -1539,7 +1555,10 @@ func runRigStart(cmd *cobra.Command, args []string) error {
 
 	g := git.NewGit(townRoot)
 	rigMgr := rig.NewManager(townRoot, rigsConfig, g)
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	var successRigs []string
 	var failedRigs []string
@@ -1662,7 +1681,10 @@ func runRigShutdown(cmd *cobra.Command, args []string) error {
 	var errors []string
 
 	// 1. Stop all polecat sessions
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	polecatMgr := polecat.NewSessionManager(t, r)
 	infos, err := polecatMgr.ListPolecats()
 	if err == nil && len(infos) > 0 {
@@ -1751,7 +1773,10 @@ func runRigStatus(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Header
 	fmt.Printf("%s\n", style.Bold.Render(rigName))
@@ -1889,6 +1914,11 @@ func runRigStop(cmd *cobra.Command, args []string) error {
 	g := git.NewGit(townRoot)
 	rigMgr := rig.NewManager(townRoot, rigsConfig, g)
 
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
+
 	// Track results
 	var succeeded []string
 	var failed []string
@@ -1913,7 +1943,6 @@ func runRigStop(cmd *cobra.Command, args []string) error {
 		var errors []string
 
 		// 1. Stop all polecat sessions
-		t := tmux.NewTmux()
 		polecatMgr := polecat.NewSessionManager(t, r)
 		infos, err := polecatMgr.ListPolecats()
 		if err == nil && len(infos) > 0 {
@@ -1986,7 +2015,10 @@ func runRigRestart(cmd *cobra.Command, args []string) error {
 
 	g := git.NewGit(townRoot)
 	rigMgr := rig.NewManager(townRoot, rigsConfig, g)
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Track results
 	var succeeded []string
@@ -2205,13 +2237,19 @@ func syncRigHooks(townRoot, rigName string) error {
 	if synced > 0 {
 		fmt.Printf("  Synced hooks for %d target(s)\n", synced)
 	}
+
+	for _, repo := range githooks.RigRepos(filepath.Join(townRoot, rigName)) {
+		if _, err := githooks.Sync(repo, false); err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: failed to install git hooks in %s: %v\n", repo, err)
+		}
+	}
 	return nil
 }
 
 // findRigSessions returns all tmux sessions belonging to the given rig.
 // All rig sessions share the "<rigPrefix>-" prefix, so this catches witness,
 // refinery, polecat, and crew sessions in one pass.
-func findRigSessions(t *tmux.Tmux, rigName string) ([]string, error) {
+func findRigSessions(t tmux.Multiplexer, rigName string) ([]string, error) {
 	prefix := session.PrefixFor(rigName) + "-"
 	all, err := t.ListSessions()
 	if err != nil {
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/activity"
+	"github.com/steveyegge/gastown/internal/daemon"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	rigActivityDays int
+	rigActivityJSON bool
+)
+
+var rigActivityCmd = &cobra.Command{
+	Use:   "activity [rig]...",
+	Short: "Show a per-day activity heatmap for rigs and flag idle ones",
+	Long: `Show each rig's activity over recent days as a heatmap, and flag rigs
+that have been idle.
+
+Activity is counted per day from:
+  - Commits on any branch of the rig's repo
+  - Merge queue events (MRs submitted, merged, failed)
+  - Agent interactions attributed to the rig (slings, hooks, mail, sessions)
+
+Each heatmap cell is one day, oldest first:
+  · none   ░ 1-2   ▒ 3-9   ▓ 10-29   █ 30+
+
+A rig is idle when it has had no activity for idle_days days (the
+idle_rigs patrol setting in mayor/daemon.json, default 14). With the
+idle_rigs patrol enabled, the daemon parks or docks idle rigs.
+
+Examples:
+  gt rig activity
+  gt rig activity gastown --days 90
+  gt rig activity --json`,
+	RunE: runRigActivity,
+}
+
+func init() {
+	rigActivityCmd.Flags().IntVar(&rigActivityDays, "days", activity.DefaultUsageDays, "Number of days to show")
+	rigActivityCmd.Flags().BoolVar(&rigActivityJSON, "json", false, "Output as JSON")
+	rigCmd.AddCommand(rigActivityCmd)
+}
+
+// RigUsageInfo is a rig's activity as shown by 'gt rig activity' and
+// 'gt status'.
+type RigUsageInfo struct {
+	*activity.RigUsage
+	IdleDays int    `json:"idle_days"` // Days since last activity; -1 if never active
+	Idle     bool   `json:"idle"`
+	Heatmap  string `json:"heatmap"`
+}
+
+// collectRigUsage gathers days days of activity for rigs, keyed by rig
+// name, judging idleness by the daemon's idle_rigs threshold.
+func collectRigUsage(townRoot string, rigs []*rig.Rig, days int) map[string]*RigUsageInfo {
+	rigPaths := make(map[string]string, len(rigs))
+	for _, r := range rigs {
+		rigPaths[r.Name] = r.Path
+	}
+	idleDays := daemon.IdleRigDays(daemon.LoadPatrolConfig(townRoot))
+	now := time.Now()
+	views := make(map[string]*RigUsageInfo, len(rigs))
+	for name, u := range activity.CollectUsage(townRoot, rigPaths, days, now) {
+		views[name] = &RigUsageInfo{
+			RigUsage: u,
+			IdleDays: u.IdleDays(now),
+			Idle:     u.Idle(now, idleDays),
+			Heatmap:  u.Heatmap(),
+		}
+	}
+	return views
+}
+
+// formatIdle describes an idle rig's idleness, e.g. "idle 23d".
+func formatIdle(v *RigUsageInfo) string {
+	if v.IdleDays < 0 {
+		return "idle (no recorded activity)"
+	}
+	return fmt.Sprintf("idle %dd", v.IdleDays)
+}
+
+func runRigActivity(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	var rigs []*rig.Rig
+	if len(args) == 0 {
+		if rigs, err = getAllRigs(); err != nil {
+			return err
+		}
+	} else {
+		for _, name := range args {
+			_, r, err := getRig(name)
+			if err != nil {
+				return err
+			}
+			rigs = append(rigs, r)
+		}
+	}
+	if rigActivityDays <= 0 {
+		return fmt.Errorf("--days must be positive, got %d", rigActivityDays)
+	}
+
+	usage := collectRigUsage(townRoot, rigs, rigActivityDays)
+	views := make([]*RigUsageInfo, 0, len(rigs))
+	for _, r := range rigs {
+		views = append(views, usage[r.Name])
+	}
+
+	if rigActivityJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(views)
+	}
+	if len(views) == 0 {
+		fmt.Println("No rigs registered.")
+		return nil
+	}
+
+	width := 0
+	for _, v := range views {
+		if len(v.Rig) > width {
+			width = len(v.Rig)
+		}
+	}
+	fmt.Printf("%s\n", style.Dim.Render(fmt.Sprintf("Last %d days, oldest first (· none ░ 1-2 ▒ 3-9 ▓ 10-29 █ 30+)", rigActivityDays)))
+	for _, v := range views {
+		var commits, mrs, agent int
+		for _, d := range v.Days {
+			commits += d.Commits
+			mrs += d.MRs
+			agent += d.Agent
+		}
+		status := ""
+		if v.Idle {
+			status = "  " + style.Warning.Render(formatIdle(v))
+		}
+		fmt.Printf("%-*s  %s  %s%s\n", width, v.Rig, v.Heatmap,
+			style.Dim.Render(fmt.Sprintf("%d commits, %d MR events, %d agent", commits, mrs, agent)), status)
+	}
+	return nil
+}
@@ -121,7 +121,10 @@ func runRigDock(cmd *cobra.Command, args []string) error {
 
 	var stoppedAgents []string
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Stop witness if running
 	witnessSession := session.WitnessSessionName(session.PrefixFor(rigName))
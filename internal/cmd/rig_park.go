@@ -93,7 +93,10 @@ func parkOneRig(rigName string) error {
 
 	var stoppedAgents []string
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Stop witness if running
 	witnessSession := session.WitnessSessionName(session.PrefixFor(rigName))
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/readiness"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var rigReadyJSON bool
+
+var rigReadyCmd = &cobra.Command{
+	Use:   "ready [rig]...",
+	Short: "Check rig config against the repository and machine",
+	Long: `Check that what each rig's config claims holds on this machine:
+
+  - config          the rig's config and merge queue settings parse
+  - target-branches the default branch and protected branches exist in the
+                    refinery's clone (locally or on origin; nothing is fetched)
+  - gate-commands   each gate's command, shell or container runtime is found
+  - writable-paths  the rig, its clone, .runtime and polecats are writable
+  - agent-binary    the rig's agent command is in PATH
+
+The daemon runs the same checks at startup and whenever a rig's config
+files change, logging rigs that aren't ready and recording the results in
+daemon/rig-readiness.json. This command checks live, and exits non-zero
+if any rig isn't ready.
+
+Examples:
+  gt rig ready
+  gt rig ready gastown
+  gt rig ready --json`,
+	RunE: runRigReady,
+}
+
+func init() {
+	rigReadyCmd.Flags().BoolVar(&rigReadyJSON, "json", false, "Output as JSON")
+	rigCmd.AddCommand(rigReadyCmd)
+}
+
+func runRigReady(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	var rigs []*rig.Rig
+	if len(args) == 0 {
+		if rigs, err = getAllRigs(); err != nil {
+			return err
+		}
+	} else {
+		for _, name := range args {
+			_, r, err := getRig(name)
+			if err != nil {
+				return err
+			}
+			rigs = append(rigs, r)
+		}
+	}
+
+	reports := make([]*readiness.Report, 0, len(rigs))
+	notReady := 0
+	for _, r := range rigs {
+		report := readiness.CheckRig(townRoot, r)
+		if !report.Ready {
+			notReady++
+		}
+		reports = append(reports, report)
+	}
+
+	if rigReadyJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return err
+		}
+	} else {
+		if len(reports) == 0 {
+			fmt.Println("No rigs registered.")
+			return nil
+		}
+		for _, report := range reports {
+			if report.Ready {
+				fmt.Printf("%s %s\n", style.SuccessPrefix, style.Bold.Render(report.Rig))
+				continue
+			}
+			fmt.Printf("%s %s\n", style.ErrorPrefix, style.Bold.Render(report.Rig))
+			for _, c := range report.Checks {
+				for _, p := range c.Problems {
+					fmt.Printf("  %s %s\n", style.Dim.Render(c.Name+":"), p)
+				}
+			}
+		}
+	}
+	if notReady > 0 {
+		return NewSilentExit(1)
+	}
+	return nil
+}
@@ -235,8 +235,11 @@ func getSessionManager(rigName string) (*polecat.SessionManager, *rig.Rig, error
 		return nil, nil, err
 	}
 
-	t := tmux.NewTmux()
-	polecatMgr := polecat.NewSessionManager(t, r)
+	mux, err := tmux.NewMultiplexer()
+	if err != nil {
+		return nil, nil, err
+	}
+	polecatMgr := polecat.NewSessionManager(mux, r)
 
 	return polecatMgr, r, nil
 }
@@ -382,7 +385,10 @@ func runSessionList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Collect sessions from all rigs
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	var allSessions []SessionListItem
 
 	for _, r := range rigs {
@@ -653,7 +659,10 @@ func runSessionCheck(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("%s Session Health Check\n\n", style.Bold.Render("🔍"))
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	totalChecked := 0
 	totalHealthy := 0
 	totalCrashed := 0
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/agentlog"
+	"github.com/steveyegge/gastown/internal/asciicast"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Session recording flags
+var (
+	sessionRecordStop   bool
+	sessionCastOutput   string
+	sessionCastFrom     string
+	sessionCastIdle     float64
+	sessionCastTitle    string
+	recordPipeWidth     int
+	recordPipeHeight    int
+	recordPipeTitle     string
+	recordPipeStartedAt int64
+)
+
+var sessionRecordCmd = &cobra.Command{
+	Use:   "record <rig>/<polecat>",
+	Short: "Record session output for replay",
+	Long: `Record a polecat session's terminal output, with timing, for export
+with 'gt session cast'.
+
+Output is piped from tmux (pipe-pane) into an asciinema cast at
+<rig>/.runtime/recordings/<session>.cast, from now until --stop or the
+session ends. Recording again starts a fresh cast.
+
+Examples:
+  gt session record wyvern/Toast          # Start recording
+  gt session record wyvern/Toast --stop   # Stop recording`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionRecord,
+}
+
+var sessionCastCmd = &cobra.Command{
+	Use:   "cast <rig>/<polecat>",
+	Short: "Export a session as an asciinema cast",
+	Long: `Export a polecat session as an asciinema v2 cast, to replay with
+'asciinema play' or asciinema-player for demos, reviews and debugging.
+
+Sources (--from):
+  recording   The pane output captured by 'gt session record'. Replays the
+              terminal exactly, with the timing of every write.
+  transcript  The agent's conversation transcript (Claude Code JSONL),
+              rendered as text. Timing is per message only.
+  auto        recording if there is one, else transcript (default).
+
+Examples:
+  gt session cast wyvern/Toast                       # → gt-wyvern-Toast.cast
+  gt session cast wyvern/Toast -o review.cast --idle-limit 2
+  gt session cast wyvern/Toast --from transcript -o - | asciinema play -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionCast,
+}
+
+var sessionRecordPipeCmd = &cobra.Command{
+	Use:    "record-pipe <file>",
+	Short:  "Write piped pane output to a cast (invoked by tmux pipe-pane)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runSessionRecordPipe,
+}
+
+func init() {
+	sessionRecordCmd.Flags().BoolVar(&sessionRecordStop, "stop", false, "Stop recording")
+
+	sessionCastCmd.Flags().StringVarP(&sessionCastOutput, "output", "o", "", "Output file ('-' for stdout; default <session>.cast)")
+	sessionCastCmd.Flags().StringVar(&sessionCastFrom, "from", "auto", "Source: auto, recording, transcript")
+	sessionCastCmd.Flags().Float64Var(&sessionCastIdle, "idle-limit", 0, "Cap pauses during replay to this many seconds (0 = no cap)")
+	sessionCastCmd.Flags().StringVar(&sessionCastTitle, "title", "", "Cast title (default rig/polecat)")
+
+	sessionRecordPipeCmd.Flags().IntVar(&recordPipeWidth, "width", 0, "Terminal width")
+	sessionRecordPipeCmd.Flags().IntVar(&recordPipeHeight, "height", 0, "Terminal height")
+	sessionRecordPipeCmd.Flags().StringVar(&recordPipeTitle, "title", "", "Cast title")
+	sessionRecordPipeCmd.Flags().Int64Var(&recordPipeStartedAt, "started-at", 0, "Recording start (Unix seconds)")
+
+	sessionCmd.AddCommand(sessionRecordCmd)
+	sessionCmd.AddCommand(sessionCastCmd)
+	sessionCmd.AddCommand(sessionRecordPipeCmd)
+}
+
+// recordingPath is where 'gt session record' writes a session's cast.
+func recordingPath(r *rig.Rig, sessionName string) string {
+	return filepath.Join(r.Path, ".runtime", "recordings", sessionName+".cast")
+}
+
+func runSessionRecord(cmd *cobra.Command, args []string) error {
+	rigName, polecatName, err := parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+	polecatMgr, r, err := getSessionManager(rigName)
+	if err != nil {
+		return err
+	}
+	sessionName := polecatMgr.SessionName(polecatName)
+	t, err := tmux.RequireTmux("recording a session")
+	if err != nil {
+		return err
+	}
+	running, err := t.HasSession(sessionName)
+	if err != nil {
+		return fmt.Errorf("checking session: %w", err)
+	}
+	if !running {
+		return polecat.ErrSessionNotFound
+	}
+
+	path := recordingPath(r, sessionName)
+	if sessionRecordStop {
+		if err := t.StopPipePane(sessionName); err != nil {
+			return fmt.Errorf("stopping recording: %w", err)
+		}
+		fmt.Printf("%s Recording stopped: %s\n", style.Bold.Render("✓"), path)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating recordings dir: %w", err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolving executable: %w", err)
+	}
+	width, height, err := t.GetPaneSize(sessionName)
+	if err != nil {
+		width, height = asciicast.DefaultWidth, asciicast.DefaultHeight
+	}
+	pipe := fmt.Sprintf("exec %s session record-pipe --width %d --height %d --started-at %d --title %s %s",
+		config.ShellQuote(exe), width, height, time.Now().Unix(),
+		config.ShellQuote(rigName+"/"+polecatName), config.ShellQuote(path))
+	if err := t.PipePane(sessionName, pipe); err != nil {
+		return fmt.Errorf("starting recording: %w", err)
+	}
+
+	fmt.Printf("%s Recording %s/%s to %s\n", style.Bold.Render("✓"), rigName, polecatName, path)
+	fmt.Printf("  Stop with: %s\n", style.Dim.Render(fmt.Sprintf("gt session record %s/%s --stop", rigName, polecatName)))
+	return nil
+}
+
+func runSessionRecordPipe(cmd *cobra.Command, args []string) error {
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := asciicast.Header{
+		Width:     recordPipeWidth,
+		Height:    recordPipeHeight,
+		Timestamp: recordPipeStartedAt,
+		Title:     recordPipeTitle,
+		Env:       map[string]string{"TERM": "tmux-256color"},
+	}
+	return asciicast.Record(os.Stdin, f, h, time.Now)
+}
+
+func runSessionCast(cmd *cobra.Command, args []string) error {
+	rigName, polecatName, err := parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+	polecatMgr, r, err := getSessionManager(rigName)
+	if err != nil {
+		return err
+	}
+	sessionName := polecatMgr.SessionName(polecatName)
+
+	var h asciicast.Header
+	var events []asciicast.Event
+	recording := recordingPath(r, sessionName)
+	switch sessionCastFrom {
+	case "recording", "auto":
+		h, events, err = readRecording(recording)
+		if err == nil || sessionCastFrom == "recording" {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, asciicast.ErrEmpty) {
+			return err
+		}
+		fallthrough
+	case "transcript":
+		h, events, err = readTranscriptCast(sessionName, polecatMgr.WorkDir(polecatName))
+	default:
+		return fmt.Errorf("invalid --from %q: must be auto, recording, or transcript", sessionCastFrom)
+	}
+	if err != nil {
+		return err
+	}
+
+	h.Title = rigName + "/" + polecatName
+	if sessionCastTitle != "" {
+		h.Title = sessionCastTitle
+	}
+	if sessionCastIdle > 0 {
+		h.IdleTimeLimit = sessionCastIdle
+	}
+
+	out := sessionCastOutput
+	if out == "" {
+		out = sessionName + ".cast"
+	}
+	var w io.Writer = os.Stdout
+	if out != "-" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	cw, err := asciicast.NewWriter(w, h)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := cw.Write(e); err != nil {
+			return fmt.Errorf("writing cast: %w", err)
+		}
+	}
+	if out != "-" {
+		fmt.Printf("%s Exported %d events to %s\n", style.Bold.Render("✓"), len(events), out)
+		fmt.Printf("  Replay with: %s\n", style.Dim.Render("asciinema play "+out))
+	}
+	return nil
+}
+
+// readRecording reads a cast made by 'gt session record'.
+func readRecording(path string) (asciicast.Header, []asciicast.Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return asciicast.Header{}, nil, fmt.Errorf("no recording: %w (start one with 'gt session record')", err)
+	}
+	defer f.Close()
+	h, events, err := asciicast.Read(f)
+	if err != nil {
+		return h, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(events) == 0 {
+		return h, nil, fmt.Errorf("recording %s: %w", path, asciicast.ErrEmpty)
+	}
+	return h, events, nil
+}
+
+// readTranscriptCast renders the newest agent transcript in workDir.
+func readTranscriptCast(sessionName, workDir string) (asciicast.Header, []asciicast.Event, error) {
+	var h asciicast.Header
+	path, err := agentlog.ClaudeTranscriptPath(workDir, time.Time{})
+	if err != nil {
+		return h, nil, err
+	}
+	transcript, err := agentlog.ReadClaudeTranscript(path, sessionName)
+	if err != nil {
+		return h, nil, err
+	}
+	events, err := asciicast.FromTranscript(transcript)
+	if err != nil {
+		return h, nil, fmt.Errorf("transcript %s: %w", path, err)
+	}
+	for _, ev := range transcript {
+		if !ev.Timestamp.IsZero() {
+			h.Timestamp = ev.Timestamp.Unix()
+			break
+		}
+	}
+	// Rendered text wraps; a wide terminal keeps it readable.
+	h.Width, h.Height = 120, 40
+	return h, events, nil
+}
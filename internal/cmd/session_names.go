@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var sessionNamesJSON bool
+
+var sessionNamesCmd = &cobra.Command{
+	Use:   "names",
+	Short: "Show the agent → session name registry",
+	Long: `Show the town's session name registry.
+
+Each agent is bound to a tmux session name and a stable ID the first time
+its name is resolved. Bindings survive session restarts; a canonical name
+that collides with another agent's is disambiguated once and reused.
+
+Examples:
+  gt session names
+  gt session names --json`,
+	RunE: runSessionNames,
+}
+
+var sessionRenameCmd = &cobra.Command{
+	Use:   "rename <address> <new-session-name>",
+	Short: "Rename an agent's tmux session",
+	Long: `Bind an agent to a new tmux session name, keeping its stable ID.
+
+The live tmux session, if any, is renamed too. Names already bound to
+another agent, or that parse as another agent's session (e.g. gt-witness),
+are rejected.
+
+Examples:
+  gt session rename gastown/polecats/nux gt-nux-debug`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSessionRename,
+}
+
+func init() {
+	sessionNamesCmd.Flags().BoolVar(&sessionNamesJSON, "json", false, "Output as JSON")
+	sessionCmd.AddCommand(sessionNamesCmd)
+	sessionCmd.AddCommand(sessionRenameCmd)
+}
+
+func loadTownSessionNames() (*session.SessionNames, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	return session.LoadSessionNames(townRoot)
+}
+
+func runSessionNames(cmd *cobra.Command, args []string) error {
+	names, err := loadTownSessionNames()
+	if err != nil {
+		return err
+	}
+	bindings := names.Bindings()
+
+	if sessionNamesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(bindings)
+	}
+
+	if len(bindings) == 0 {
+		fmt.Println("No session names registered")
+		return nil
+	}
+	for _, b := range bindings {
+		fmt.Printf("%-32s %-24s %s\n", b.Address, style.Bold.Render(b.Session), style.Dim.Render(b.ID))
+	}
+	return nil
+}
+
+func runSessionRename(cmd *cobra.Command, args []string) error {
+	address, newName := args[0], args[1]
+	identity, err := session.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+	t, err := tmux.RequireTmux("renaming sessions")
+	if err != nil {
+		return err
+	}
+
+	names, err := loadTownSessionNames()
+	if err != nil {
+		return err
+	}
+	current, err := names.Resolve(identity)
+	if err != nil {
+		return err
+	}
+	oldName := current.Session
+
+	binding, err := names.Rename(identity.Address(), newName)
+	if err != nil {
+		return err
+	}
+
+	if running, _ := t.HasSession(oldName); running {
+		if err := t.RenameSession(oldName, newName); err != nil {
+			// Keep the registry in step with tmux.
+			_, _ = names.Rename(identity.Address(), oldName)
+			return fmt.Errorf("renaming tmux session %s: %w", oldName, err)
+		}
+	}
+
+	fmt.Printf("%s %s: %s → %s\n", style.Bold.Render("✓"), binding.Address, oldName, binding.Session)
+	return nil
+}
@@ -1,4 +1,4 @@
-// ABOUTME: Shell integration management commands.
+// ABOUTME: Interactive gt shell and shell integration management commands.
 // ABOUTME: Install/remove shell hooks without full HQ setup.
 
 package cmd
@@ -15,14 +15,28 @@ import (
 var shellCmd = &cobra.Command{
 	Use:     "shell",
 	GroupID: GroupConfig,
-	Short:   "Manage shell integration",
-	Long: `Manage the Gas Town shell integration hook.
+	Short:   "Interactive gt shell, and shell integration",
+	Long: `Start an interactive gt shell, or manage the Gas Town shell integration hook.
+
+Without a subcommand, 'gt shell' reads gt commands at a prompt, without the
+leading "gt":
+
+  gt> use gastown
+  gt:gastown> mq list gastown
+  gt:gastown> mq status gt-<TAB>
+
+Tab completes commands, subcommands and flags, and arguments from live
+entities: rigs, tmux sessions, merge request IDs and open issue IDs of the
+current rig. 'use <rig>' sets the current rig: commands run from the rig's
+directory with GT_RIG set, so commands that infer the rig use it. History
+is kept in .runtime/shell_history; 'help' lists the shell's builtins.
 
 The shell integration adds a cd hook to your shell RC file that automatically
 sets GT_TOWN_ROOT and GT_RIG environment variables when you enter a rig directory.
 
 Subcommands: install, remove, status.`,
-	RunE: requireSubcommand,
+	Args: cobra.ArbitraryArgs,
+	RunE: runShellREPL,
 }
 
 var shellInstallCmd = &cobra.Command{
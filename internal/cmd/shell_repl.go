@@ -0,0 +1,518 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/workspace"
+	"golang.org/x/term"
+)
+
+// replBuiltins are the commands handled by 'gt shell' itself rather than
+// run as gt commands.
+var replBuiltins = []string{"exit", "help", "history", "quit", "use"}
+
+const (
+	// replHistoryLimit bounds the shell history kept in memory and on disk.
+	replHistoryLimit = 1000
+
+	// replEntityTTL is how long live completion candidates are reused
+	// before they are listed again.
+	replEntityTTL = 15 * time.Second
+)
+
+// replHistoryPath is where 'gt shell' keeps its command history.
+func replHistoryPath(townRoot string) string {
+	return filepath.Join(townRoot, constants.DirRuntime, "shell_history")
+}
+
+// replSession is the state of an interactive 'gt shell'.
+type replSession struct {
+	townRoot string
+	rig      string // Current rig context; "" for the town
+	dir      string // Working directory commands run in
+	root     *cobra.Command
+	history  *replHistory
+	out      io.Writer
+
+	// listEntities lists the live completion candidates for the current
+	// context: rig names, tmux sessions, MR IDs and issue IDs.
+	listEntities func(townRoot, rig string) []string
+	entities     []string
+	entitiesRig  string
+	entitiesAt   time.Time
+}
+
+func newREPLSession(townRoot string, out io.Writer) *replSession {
+	return &replSession{
+		townRoot:     townRoot,
+		dir:          townRoot,
+		root:         rootCmd,
+		history:      loadREPLHistory(replHistoryPath(townRoot)),
+		out:          out,
+		listEntities: listREPLEntities,
+	}
+}
+
+func runShellREPL(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return requireSubcommand(cmd, args)
+	}
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	s := newREPLSession(townRoot, os.Stdout)
+	if cwd, err := os.Getwd(); err == nil {
+		s.dir = cwd
+		s.rig = detectRigFromPath(townRoot, cwd)
+	}
+
+	// A Ctrl-C aimed at a running command must not end the shell. Notify
+	// rather than Ignore, so commands started from the shell still get it.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return s.runScript(os.Stdin)
+	}
+	return s.runInteractive(fd)
+}
+
+// runInteractive reads commands from the terminal with line editing,
+// history and tab completion until exit, Ctrl-C or Ctrl-D.
+func (s *replSession) runInteractive(fd int) error {
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, s.prompt())
+	t.History = s.history
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		newLine, newPos, matches := s.complete(line, pos)
+		if len(matches) > 0 {
+			_, _ = fmt.Fprintf(t, "%s\r\n", strings.Join(matches, "  "))
+		}
+		return newLine, newPos, newLine != line
+	}
+
+	fmt.Fprintf(s.out, "Gas Town shell. Tab completes; 'help' for builtins, Ctrl-D to exit.\n")
+	for {
+		if w, h, err := term.GetSize(fd); err == nil {
+			_ = t.SetSize(w, h)
+		}
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("entering raw mode: %w", err)
+		}
+		line, err := t.ReadLine()
+		_ = term.Restore(fd, oldState)
+		if err == io.EOF {
+			fmt.Fprintln(s.out)
+			return nil
+		}
+		if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+			return err
+		}
+		if s.execute(line) {
+			return nil
+		}
+		t.SetPrompt(s.prompt())
+	}
+}
+
+// runScript runs commands read from r, one per line, as when the shell's
+// input is piped.
+func (s *replSession) runScript(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if s.execute(scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *replSession) prompt() string {
+	if s.rig == "" {
+		return "gt> "
+	}
+	return fmt.Sprintf("gt:%s> ", s.rig)
+}
+
+// execute runs one line of input, reporting whether the shell should exit.
+func (s *replSession) execute(line string) (exit bool) {
+	args, err := splitREPLLine(line)
+	if err != nil {
+		fmt.Fprintf(s.out, "%s %v\n", style.ErrorPrefix, err)
+		return false
+	}
+	if len(args) > 0 && args[0] == "gt" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "exit", "quit":
+		return true
+	case "help":
+		s.printHelp()
+	case "history":
+		for i := s.history.Len() - 1; i >= 0; i-- {
+			fmt.Fprintf(s.out, "%5d  %s\n", s.history.Len()-i, s.history.At(i))
+		}
+	case "use":
+		if err := s.use(args[1:]); err != nil {
+			fmt.Fprintf(s.out, "%s %v\n", style.ErrorPrefix, err)
+		}
+	default:
+		s.run(args)
+	}
+	return false
+}
+
+// use sets the rig context: commands then run from the rig's directory with
+// GT_RIG set, so they default to that rig. With no rig, the context is the
+// town.
+func (s *replSession) use(args []string) error {
+	switch len(args) {
+	case 0:
+		s.rig, s.dir = "", s.townRoot
+		return nil
+	case 1:
+	default:
+		return fmt.Errorf("usage: use [rig]")
+	}
+	name := args[0]
+	if _, err := os.Stat(filepath.Join(s.townRoot, name, "config.json")); err != nil {
+		return fmt.Errorf("rig %q not found", name)
+	}
+	s.rig, s.dir = name, filepath.Join(s.townRoot, name)
+	return nil
+}
+
+// run runs a gt command in a child process, so each command starts from
+// fresh flag state and a crash or os.Exit in one doesn't end the shell.
+func (s *replSession) run(args []string) {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(s.out, "%s %v\n", style.ErrorPrefix, err)
+		return
+	}
+	c := exec.Command(exe, args...)
+	c.Dir = s.dir
+	c.Env = s.env()
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		// The command reports its own failure; only say why it didn't run.
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			fmt.Fprintf(s.out, "%s %v\n", style.ErrorPrefix, err)
+		}
+	}
+}
+
+// env is the environment commands run with: the shell's own, with
+// GT_TOWN_ROOT and GT_RIG set to the current context.
+func (s *replSession) env() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "GT_RIG=") && !strings.HasPrefix(kv, "GT_TOWN_ROOT=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, "GT_TOWN_ROOT="+s.townRoot)
+	if s.rig != "" {
+		env = append(env, "GT_RIG="+s.rig)
+	}
+	return env
+}
+
+func (s *replSession) printHelp() {
+	fmt.Fprint(s.out, `Type any gt command without the leading "gt", e.g. "mq list gastown".
+
+Builtins:
+  use [rig]    Run commands in a rig's context (no rig: the town)
+  history      Show command history
+  help         Show this help
+  exit, quit   Leave the shell (also Ctrl-C or Ctrl-D at the prompt)
+
+Tab completes commands, subcommands and flags, and arguments from live
+rigs, tmux sessions, merge requests and open issues.
+`)
+}
+
+// complete completes the word before pos in line. It returns the completed
+// line and cursor position; when the word is ambiguous and can't be
+// extended, it returns line unchanged and the matches to show.
+func (s *replSession) complete(line string, pos int) (newLine string, newPos int, matches []string) {
+	head := line[:pos]
+	start := strings.LastIndexAny(head, " \t") + 1
+	word := head[start:]
+	before, err := splitREPLLine(head[:start])
+	if err != nil {
+		return line, pos, nil
+	}
+	if len(before) > 0 && before[0] == "gt" {
+		before = before[1:]
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range s.candidates(before, word) {
+		if strings.HasPrefix(c, word) && !seen[c] {
+			seen[c] = true
+			matches = append(matches, c)
+		}
+	}
+	sort.Strings(matches)
+
+	switch len(matches) {
+	case 0:
+		return line, pos, nil
+	case 1:
+		completed := matches[0]
+		if !strings.HasPrefix(line[pos:], " ") {
+			completed += " "
+		}
+		return head[:start] + completed + line[pos:], start + len(completed), nil
+	}
+	if prefix := commonPrefix(matches); len(prefix) > len(word) {
+		return head[:start] + prefix + line[pos:], start + len(prefix), nil
+	}
+	return line, pos, matches
+}
+
+// candidates lists the possible completions for a word following the
+// already-typed words before.
+func (s *replSession) candidates(before []string, word string) []string {
+	if len(before) == 0 {
+		names := append([]string(nil), replBuiltins...)
+		return append(names, subcommandNames(s.root)...)
+	}
+	switch before[0] {
+	case "use":
+		if len(before) == 1 {
+			return s.rigNames()
+		}
+		return nil
+	case "exit", "help", "history", "quit":
+		return nil
+	}
+
+	c, rest, err := s.root.Find(before)
+	if err != nil || c == s.root {
+		return nil
+	}
+	if strings.HasPrefix(word, "-") {
+		var flags []string
+		add := func(f *pflag.Flag) {
+			if !f.Hidden {
+				flags = append(flags, "--"+f.Name)
+			}
+		}
+		c.Flags().VisitAll(add)
+		c.InheritedFlags().VisitAll(add)
+		return flags
+	}
+	if c.HasAvailableSubCommands() && len(rest) == 0 {
+		return subcommandNames(c)
+	}
+	return s.liveEntities()
+}
+
+// liveEntities returns the live completion candidates for the current
+// context, listing them again once they are older than replEntityTTL.
+func (s *replSession) liveEntities() []string {
+	if s.entities == nil || s.entitiesRig != s.rig || time.Since(s.entitiesAt) > replEntityTTL {
+		s.entities = s.listEntities(s.townRoot, s.rig)
+		s.entitiesRig = s.rig
+		s.entitiesAt = time.Now()
+	}
+	return s.entities
+}
+
+func (s *replSession) rigNames() []string {
+	rigsConfig, err := config.LoadRigsConfig(filepath.Join(s.townRoot, "mayor", "rigs.json"))
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(rigsConfig.Rigs))
+	for name := range rigsConfig.Rigs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// listREPLEntities lists rig names, tmux sessions, and the open MR and
+// issue IDs of rig (or of the town, with no rig). Sources that fail are
+// skipped.
+func listREPLEntities(townRoot, rig string) []string {
+	s := &replSession{townRoot: townRoot}
+	entities := s.rigNames()
+	if sessions, err := tmux.Selected().ListSessions(); err == nil {
+		entities = append(entities, sessions...)
+	}
+
+	beadsDir := townRoot
+	if rig != "" {
+		beadsDir = filepath.Join(townRoot, rig)
+	}
+	b := beads.New(beadsDir)
+	if mrs, err := b.ListMergeRequests(beads.ListOptions{Label: "gt:merge-request", Status: "open", Priority: -1}); err == nil {
+		for _, mr := range mrs {
+			entities = append(entities, mr.ID)
+		}
+	}
+	if issues, err := b.List(beads.ListOptions{Status: "open", Priority: -1, Limit: 200}); err == nil {
+		for _, issue := range issues {
+			entities = append(entities, issue.ID)
+		}
+	}
+	return entities
+}
+
+func subcommandNames(c *cobra.Command) []string {
+	var names []string
+	for _, sub := range c.Commands() {
+		if sub.IsAvailableCommand() {
+			names = append(names, sub.Name())
+		}
+	}
+	return names
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// splitREPLLine splits a line of shell input into words, honoring single
+// and double quotes and backslash escapes.
+func splitREPLLine(line string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+// replHistory is the shell's command history, persisted one entry per line.
+// It implements term.History: index 0 is the most recent entry.
+type replHistory struct {
+	path    string
+	entries []string // Oldest first
+}
+
+// loadREPLHistory reads the history at path, keeping the most recent
+// replHistoryLimit entries. A missing file is an empty history.
+func loadREPLHistory(path string) *replHistory {
+	h := &replHistory{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if len(h.entries) > replHistoryLimit {
+		h.entries = h.entries[len(h.entries)-replHistoryLimit:]
+		_ = os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0644)
+	}
+	return h
+}
+
+// Add appends entry to the history and its file, skipping blank lines and
+// immediate repeats.
+func (h *replHistory) Add(entry string) {
+	if strings.TrimSpace(entry) == "" || strings.ContainsAny(entry, "\r\n") {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > replHistoryLimit {
+		h.entries = h.entries[1:]
+	}
+	if h.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = fmt.Fprintln(f, entry)
+}
+
+func (h *replHistory) Len() int { return len(h.entries) }
+
+func (h *replHistory) At(idx int) string { return h.entries[len(h.entries)-1-idx] }
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSplitREPLLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"  mq   list  gastown ", []string{"mq", "list", "gastown"}},
+		{`mail send mayor -m "hello there"`, []string{"mail", "send", "mayor", "-m", "hello there"}},
+		{`echo 'a "b"' c\ d`, []string{"echo", `a "b"`, "c d"}},
+		{`x ""`, []string{"x", ""}},
+	}
+	for _, tt := range tests {
+		got, err := splitREPLLine(tt.line)
+		if err != nil {
+			t.Errorf("splitREPLLine(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitREPLLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+	for _, bad := range []string{`say "hi`, `x \`} {
+		if _, err := splitREPLLine(bad); err == nil {
+			t.Errorf("splitREPLLine(%q) should fail", bad)
+		}
+	}
+}
+
+func newTestREPL(t *testing.T, entities ...string) *replSession {
+	t.Helper()
+	root := &cobra.Command{Use: "gt"}
+	mq := &cobra.Command{Use: "mq", RunE: requireSubcommand}
+	status := &cobra.Command{Use: "status <id>", Run: func(*cobra.Command, []string) {}}
+	status.Flags().Bool("json", false, "")
+	status.Flags().Bool("secret", false, "")
+	_ = status.Flags().MarkHidden("secret")
+	submit := &cobra.Command{Use: "submit", Run: func(*cobra.Command, []string) {}}
+	mq.AddCommand(status, submit)
+	mail := &cobra.Command{Use: "mail", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(mq, mail)
+
+	townRoot := t.TempDir()
+	var lists int
+	s := &replSession{
+		townRoot: townRoot,
+		dir:      townRoot,
+		root:     root,
+		history:  loadREPLHistory(replHistoryPath(townRoot)),
+		out:      &bytes.Buffer{},
+		listEntities: func(string, string) []string {
+			lists++
+			return entities
+		},
+	}
+	t.Cleanup(func() {
+		if lists > 1 {
+			t.Errorf("entities listed %d times, want them cached", lists)
+		}
+	})
+	return s
+}
+
+func TestREPLComplete(t *testing.T) {
+	s := newTestREPL(t, "gt-mr-abc", "gt-mr-abd", "gt-xyz", "gastown")
+
+	tests := []struct {
+		line    string
+		want    string
+		matches []string
+	}{
+		{"m", "m", []string{"mail", "mq"}},
+		{"mq", "mq ", nil},
+		{"gt mq s", "gt mq s", []string{"status", "submit"}},
+		{"mq st", "mq status ", nil},
+		{"mq status --", "mq status --json ", nil},
+		{"mq status gt-m", "mq status gt-mr-ab", nil},
+		{"mq status gt-mr-ab", "mq status gt-mr-ab", []string{"gt-mr-abc", "gt-mr-abd"}},
+		{"mq status gt-x", "mq status gt-xyz ", nil},
+		{"mq status zzz", "mq status zzz", nil},
+		{"hist", "history ", nil},
+		{"nosuch ", "nosuch ", nil},
+	}
+	for _, tt := range tests {
+		got, pos, matches := s.complete(tt.line, len(tt.line))
+		if got != tt.want || pos != len(tt.want) || !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("complete(%q) = %q@%d %q, want %q@%d %q", tt.line, got, pos, matches, tt.want, len(tt.want), tt.matches)
+		}
+	}
+
+	// Completion mid-line keeps the rest of the line.
+	line := "mq st gt-x"
+	got, pos, _ := s.complete(line, len("mq st"))
+	if got != "mq status gt-x" || pos != len("mq status") {
+		t.Errorf("mid-line complete = %q@%d", got, pos)
+	}
+}
+
+func TestREPLUseAndEnv(t *testing.T) {
+	s := newTestREPL(t)
+	if err := os.MkdirAll(filepath.Join(s.townRoot, "gastown"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(s.townRoot, "gastown", "config.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.execute("use nosuch") || s.rig != "" {
+		t.Errorf("use of an unknown rig changed context to %q", s.rig)
+	}
+	if !strings.Contains(s.out.(*bytes.Buffer).String(), `rig "nosuch" not found`) {
+		t.Errorf("expected unknown rig error, got %q", s.out.(*bytes.Buffer).String())
+	}
+	s.execute("gt use gastown")
+	if s.rig != "gastown" || s.dir != filepath.Join(s.townRoot, "gastown") || s.prompt() != "gt:gastown> " {
+		t.Errorf("after use: rig=%q dir=%q prompt=%q", s.rig, s.dir, s.prompt())
+	}
+	env := strings.Join(s.env(), "\n")
+	if !strings.Contains(env, "GT_RIG=gastown") || !strings.Contains(env, "GT_TOWN_ROOT="+s.townRoot) {
+		t.Errorf("env missing rig context:\n%s", env)
+	}
+
+	s.execute("use")
+	if s.rig != "" || s.dir != s.townRoot || strings.Contains(strings.Join(s.env(), "\n"), "GT_RIG=") {
+		t.Errorf("use with no rig should return to the town, rig=%q", s.rig)
+	}
+	if !s.execute("exit") {
+		t.Error("exit should end the shell")
+	}
+}
+
+func TestREPLHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".runtime", "shell_history")
+	h := loadREPLHistory(path)
+	for _, entry := range []string{"mq list", "mq list", "  ", "status"} {
+		h.Add(entry)
+	}
+	if h.Len() != 2 || h.At(0) != "status" || h.At(1) != "mq list" {
+		t.Fatalf("history = %q, want repeats and blanks skipped", h.entries)
+	}
+	if got := loadREPLHistory(path); !reflect.DeepEqual(got.entries, h.entries) {
+		t.Errorf("reloaded history = %q, want %q", got.entries, h.entries)
+	}
+
+	var lines []string
+	for i := 0; i < replHistoryLimit+5; i++ {
+		lines = append(lines, fmt.Sprintf("cmd %d", i))
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h = loadREPLHistory(path)
+	if h.Len() != replHistoryLimit || h.At(h.Len()-1) != "cmd 5" {
+		t.Errorf("history not trimmed to the %d most recent: len %d, oldest %q", replHistoryLimit, h.Len(), h.At(h.Len()-1))
+	}
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") != replHistoryLimit {
+		t.Error("history file not trimmed")
+	}
+}
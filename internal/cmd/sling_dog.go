@@ -166,8 +166,7 @@ func DispatchToDog(dogName string, opts DogDispatchOptions) (*DogDispatchInfo, e
 	}
 
 	// Ensure dog session is running (start if needed)
-	t := tmux.NewTmux()
-	sessMgr := dog.NewSessionManager(t, townRoot, mgr)
+	sessMgr := dog.NewSessionManager(tmux.Selected(), townRoot, mgr)
 
 	sessOpts := dog.SessionStartOptions{
 		WorkDesc:      opts.WorkDesc,
@@ -195,7 +194,10 @@ func (d *DogDispatchInfo) StartDelayedSession() (string, error) {
 		return d.Pane, nil // Session was already started
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return "", err
+	}
 	mgr := dog.NewManager(d.townRoot, d.rigsConfig)
 	sessMgr := dog.NewSessionManager(t, d.townRoot, mgr)
 
@@ -257,8 +257,11 @@ func runSlingFormula(ctx context.Context, args []string) error {
 	} else {
 		prompt = fmt.Sprintf("Formula %s slung. Run `"+cli.Name()+" hook` to see your hook, then execute the steps.", formulaName)
 	}
-	t := tmux.NewTmux()
-	if err := t.NudgePane(targetPane, prompt); err != nil {
+	t, err := tmux.RequireTmux("nudging a pane")
+	if err == nil {
+		err = t.NudgePane(targetPane, prompt)
+	}
+	if err != nil {
 		// Graceful fallback for no-tmux mode
 		fmt.Printf("%s Could not nudge (no tmux?): %v\n", style.Dim.Render("○"), err)
 		fmt.Printf("  Agent will discover work via gt prime / bd show\n")
@@ -389,7 +389,10 @@ func injectStartPrompt(pane, beadID, subject, args string) error {
 	}
 
 	// Use the reliable nudge pattern (same as gt nudge / tmux.NudgeSession)
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("nudging a pane")
+	if err != nil {
+		return err
+	}
 	return t.NudgePane(pane, prompt)
 }
 
@@ -418,7 +421,10 @@ func getSessionFromPane(pane string) string {
 // Uses a pragmatic approach: wait for the pane to leave a shell, then (Claude-only)
 // accept the bypass permissions warning and give it a moment to finish initializing.
 func ensureAgentReady(sessionName string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("waiting for the agent")
+	if err != nil {
+		return err
+	}
 
 	if t.IsAgentRunning(sessionName) {
 		// Agent process is detected, but it may have just started (fresh spawn).
@@ -584,8 +590,7 @@ func wakeRigAgents(rigName string) {
 	// nudges would be stuck forever. Direct delivery is safe: if the
 	// agent is busy, text buffers in tmux and is processed at next prompt.
 	witnessSession := session.WitnessSessionName(session.PrefixFor(rigName))
-	t := tmux.NewTmux()
-	if err := t.NudgeSession(witnessSession, "Polecat dispatched - check for work"); err != nil {
+	if err := tmux.Nudge(tmux.Selected(), witnessSession, "Polecat dispatched - check for work"); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to nudge witness %s: %v\n", witnessSession, err)
 	}
 }
@@ -616,8 +621,7 @@ func nudgeWitness(rigName, message string) {
 		})
 	}
 
-	t := tmux.NewTmux()
-	if err := t.NudgeSession(witnessSession, message); err != nil {
+	if err := tmux.Nudge(tmux.Selected(), witnessSession, message); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to nudge witness %s: %v\n", witnessSession, err)
 	}
 }
@@ -651,8 +655,7 @@ func nudgeRefinery(rigName, message string) {
 		})
 	}
 
-	t := tmux.NewTmux()
-	if err := t.NudgeSession(refinerySession, message); err != nil {
+	if err := tmux.Nudge(tmux.Selected(), refinerySession, message); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to nudge refinery %s: %v\n", refinerySession, err)
 	}
 }
@@ -972,7 +975,7 @@ func isHookedAgentDead(assignee string) bool {
 	if sessionName == "" {
 		return false // Unknown format, can't determine
 	}
-	t := tmux.NewTmux()
+	t := tmux.Selected()
 	alive, err := t.HasSession(sessionName)
 	if err != nil {
 		return false // tmux not available or error, be conservative
@@ -34,7 +34,10 @@ func resolveTargetAgent(target string) (agentID string, pane string, hookRoot st
 	}
 
 	// Get the target's working directory for hook storage
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("resolving the target's working directory")
+	if err != nil {
+		return "", "", "", err
+	}
 	hookRoot, err = t.GetPaneWorkDir(sessionName)
 	if err != nil {
 		return "", "", "", fmt.Errorf("getting working dir for %s: %w", sessionName, err)
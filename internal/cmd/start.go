@@ -195,7 +195,11 @@ func runStart(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  %s Could not ensure daemon config: %v\n", style.Dim.Render("○"), err)
 	}
 
-	t := tmux.NewTmux()
+	// Agents are themed, respawned and nudged through tmux.
+	t, err := tmux.RequireTmux("gt start")
+	if err != nil {
+		return err
+	}
 
 	// Clean up orphaned tmux sessions before starting new agents.
 	// This prevents session name conflicts and resource accumulation from
@@ -495,7 +499,10 @@ func discoverAllRigs(townRoot string) ([]*rig.Rig, error) {
 }
 
 func runShutdown(cmd *cobra.Command, args []string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	// Find workspace root for polecat cleanup
 	townRoot, _ := workspace.FindFromCwd()
@@ -596,14 +603,16 @@ func categorizeSessions(sessions []string) (toStop, preserved []string) {
 	return
 }
 
-func runGracefulShutdown(t *tmux.Tmux, gtSessions []string, townRoot string) error {
+func runGracefulShutdown(t tmux.Multiplexer, gtSessions []string, townRoot string) error {
 	fmt.Printf("Graceful shutdown of Gas Town (waiting up to %ds)...\n\n", shutdownWait)
 
 	// Phase 1: Send ESC to all agents to interrupt them
 	fmt.Printf("Phase 1: Sending ESC to %d agent(s)...\n", len(gtSessions))
 	for _, sess := range gtSessions {
 		fmt.Printf("  %s Interrupting %s\n", style.Bold.Render("→"), sess)
-		_ = t.SendKeysRaw(sess, "Escape") // best-effort interrupt
+		if tt, ok := t.(*tmux.Tmux); ok {
+			_ = tt.SendKeysRaw(sess, "Escape") // best-effort interrupt
+		}
 	}
 
 	// Phase 2: Send shutdown message asking agents to handoff
@@ -669,7 +678,7 @@ func runGracefulShutdown(t *tmux.Tmux, gtSessions []string, townRoot string) err
 	return nil
 }
 
-func runImmediateShutdown(t *tmux.Tmux, gtSessions []string, townRoot string) error {
+func runImmediateShutdown(t tmux.Multiplexer, gtSessions []string, townRoot string) error {
 	fmt.Println("Shutting down Gas Town...")
 
 	mayorSession := getMayorSessionName()
@@ -724,7 +733,7 @@ func runImmediateShutdown(t *tmux.Tmux, gtSessions []string, townRoot string) er
 //
 // Returns the count of sessions that were successfully stopped (verified by checking
 // if the session no longer exists after the kill attempt).
-func killSessionsInOrder(t *tmux.Tmux, sessions []string, mayorSession, deaconSession string) int {
+func killSessionsInOrder(t tmux.Multiplexer, sessions []string, mayorSession, deaconSession string) int {
 	stopped := 0
 	bootSession := session.BootSessionName()
 
@@ -768,7 +777,7 @@ func killSessionsInOrder(t *tmux.Tmux, sessions []string, mayorSession, deaconSe
 		}
 
 		// Attempt to kill the session and its processes
-		_ = t.KillSessionWithProcesses(sess)
+		_ = tmux.KillWithProcesses(t, sess)
 
 		// Verify the session is actually gone (ignore error, check existence)
 		// KillSessionWithProcesses might return an error even if it successfully
@@ -849,7 +858,7 @@ func cleanupPolecats(townRoot string) {
 
 	for _, r := range rigs {
 		polecatGit := git.NewGit(r.Path)
-		polecatMgr := polecat.NewManager(r, polecatGit, nil) // nil tmux: just listing, not allocating
+		polecatMgr := polecat.NewManager(r, polecatGit, nil) // nil mux: just listing, not allocating
 
 		polecats, err := polecatMgr.List()
 		if err != nil {
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/activity"
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
@@ -146,6 +147,7 @@ type RigStatus struct {
 	Hooks        []AgentHookInfo `json:"hooks,omitempty"`
 	Agents       []AgentRuntime  `json:"agents,omitempty"` // Runtime state of all agents in rig
 	MQ           *MQSummary      `json:"mq,omitempty"`     // Merge queue summary
+	Usage        *RigUsageInfo   `json:"usage,omitempty"`  // Recent activity and idleness
 }
 
 // MQSummary represents the merge queue status for a rig.
@@ -174,6 +176,7 @@ type StatusSum struct {
 	WitnessCount  int `json:"witness_count"`
 	RefineryCount int `json:"refinery_count"`
 	ActiveHooks   int `json:"active_hooks"`
+	IdleRigCount  int `json:"idle_rig_count"`
 }
 
 // resolveAgentDisplay inspects the actual running process in the tmux session
@@ -221,7 +224,10 @@ func resolveAgentDisplay(townSettings *config.TownSettings, role string, session
 // to determine what agent runtime and model are in use.
 func detectRuntimeFromSession(sessionName string) string {
 	// Get the PID of the shell process in the tmux pane
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("reading the pane process")
+	if err != nil {
+		return ""
+	}
 	pid, err := t.GetPanePID(sessionName)
 	if err != nil || pid == "" {
 		return ""
@@ -624,8 +630,11 @@ func gatherStatus() (TownStatus, error) {
 	g := git.NewGit(townRoot)
 	mgr := rig.NewManager(townRoot, rigsConfig, g)
 
-	// Create tmux instance for runtime checks
-	t := tmux.NewTmux()
+	// Create multiplexer instance for runtime checks
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return TownStatus{}, err
+	}
 
 	// Pre-fetch all tmux sessions and verify agent liveness for O(1) lookup.
 	// A Gas Town session is only considered "running" if the agent process is
@@ -641,7 +650,7 @@ func gatherStatus() (TownStatus, error) {
 				sessionWg.Add(1)
 				go func(name string) {
 					defer sessionWg.Done()
-					alive := t.IsAgentAlive(name)
+					alive := tmux.CheckHealth(t, name, 0) == tmux.SessionHealthy
 					sessionMu.Lock()
 					allSessions[name] = alive
 					sessionMu.Unlock()
@@ -831,7 +840,9 @@ func gatherStatus() (TownStatus, error) {
 	tmuxInfo.SocketPath = filepath.Join(tmux.SocketDir(), socketLabel)
 	if _, err := os.Stat(tmuxInfo.SocketPath); err == nil {
 		tmuxInfo.Running = true
-		tmuxInfo.PID = tmux.NewTmux().ServerPID()
+		if tt, ok := t.(*tmux.Tmux); ok {
+			tmuxInfo.PID = tt.ServerPID()
+		}
 	}
 	status.Tmux = tmuxInfo
 
@@ -898,6 +909,15 @@ func gatherStatus() (TownStatus, error) {
 
 	wg.Wait()
 
+	// Recent activity per rig, to flag idle rigs
+	// Skip in --fast mode: it walks each rig's git log and the events log
+	if !statusFast {
+		usage := collectRigUsage(townRoot, rigs, activity.DefaultUsageDays)
+		for i := range status.Rigs {
+			status.Rigs[i].Usage = usage[status.Rigs[i].Name]
+		}
+	}
+
 	// Enrich agents with runtime info — inspect actual running processes
 	for i := range status.Agents {
 		a := &status.Agents[i]
@@ -925,6 +945,9 @@ func gatherStatus() (TownStatus, error) {
 		if rs.HasRefinery {
 			status.Summary.RefineryCount++
 		}
+		if rs.Usage != nil && rs.Usage.Idle {
+			status.Summary.IdleRigCount++
+		}
 	}
 	status.Summary.RigCount = len(rigs)
 
@@ -1051,7 +1074,14 @@ func outputStatusText(w io.Writer, status TownStatus) error {
 	// Rigs
 	for _, r := range status.Rigs {
 		// Rig header with separator
-		fmt.Fprintf(w, "─── %s ───────────────────────────────────────────\n\n", style.Bold.Render(r.Name+"/"))
+		fmt.Fprintf(w, "─── %s ───────────────────────────────────────────\n", style.Bold.Render(r.Name+"/"))
+		if r.Usage != nil && r.Usage.Idle {
+			fmt.Fprintf(w, "%s %s\n", style.Warning.Render("⚠ "+formatIdle(r.Usage)), style.Dim.Render("no commits, MRs or agent activity"))
+		}
+		if r.Usage != nil && statusVerbose {
+			fmt.Fprintf(w, "Activity: %s %s\n", r.Usage.Heatmap, style.Dim.Render(fmt.Sprintf("(last %d days)", len(r.Usage.Days))))
+		}
+		fmt.Fprintln(w)
 
 		// Group agents by role
 		var witnesses, refineries, crews, polecats []AgentRuntime
@@ -38,7 +38,10 @@ func init() {
 }
 
 func runStatusLine(cmd *cobra.Command, args []string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("the status line")
+	if err != nil {
+		return err
+	}
 
 	// Get session environment
 	var rigName, polecat, crew, issue, role string
@@ -135,7 +135,10 @@ func runTheme(cmd *cobra.Command, args []string) error {
 }
 
 func runThemeApply(cmd *cobra.Command, args []string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("applying themes")
+	if err != nil {
+		return err
+	}
 
 	// Get all sessions
 	sessions, err := t.ListSessions()
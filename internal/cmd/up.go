@@ -904,7 +904,7 @@ func startPolecatsWithWork(townRoot, rigName string) ([]string, map[string]error
 	if err != nil {
 		return started, errors
 	}
-	t := tmux.NewTmux()
+	t := tmux.Selected()
 	polecatMgr := polecat.NewSessionManager(t, r)
 
 	for _, entry := range entries {
@@ -303,7 +303,10 @@ func runWarrantExecute(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	tm := tmux.NewTmux()
+	tm, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	if warrant != nil {
 		if err := executeOneWarrant(warrant, warrantPath, tm); err != nil {
@@ -318,7 +321,7 @@ func runWarrantExecute(cmd *cobra.Command, args []string) error {
 		if has, err := tm.HasSession(sessionName); err != nil {
 			return fmt.Errorf("checking session %s: %w", sessionName, err)
 		} else if has {
-			if err := tm.KillSessionWithProcesses(sessionName); err != nil {
+			if err := tmux.KillWithProcesses(tm, sessionName); err != nil {
 				return fmt.Errorf("killing session %s: %w", sessionName, err)
 			}
 			fmt.Printf("✓ Terminated session %s\n", sessionName)
@@ -335,7 +338,7 @@ func runWarrantExecute(cmd *cobra.Command, args []string) error {
 // session exists, kills it with full process tree cleanup, and marks the warrant
 // as executed on disk. Returns nil on success. On error, the warrant is NOT
 // marked as executed so it can be retried on the next triage cycle.
-func executeOneWarrant(w *Warrant, warrantPath string, tm *tmux.Tmux) error {
+func executeOneWarrant(w *Warrant, warrantPath string, tm tmux.Multiplexer) error {
 	sessionName, err := targetToSessionName(w.Target)
 	if err != nil {
 		return fmt.Errorf("invalid target %s: %w", w.Target, err)
@@ -347,7 +350,7 @@ func executeOneWarrant(w *Warrant, warrantPath string, tm *tmux.Tmux) error {
 	}
 
 	if has {
-		if err := tm.KillSessionWithProcesses(sessionName); err != nil {
+		if err := tmux.KillWithProcesses(tm, sessionName); err != nil {
 			return fmt.Errorf("killing session %s: %w", sessionName, err)
 		}
 		fmt.Printf("Warrant executed: terminated session %s (%s)\n", sessionName, w.Target)
@@ -390,7 +393,7 @@ func targetToSessionName(target string) (string, error) {
 		return "", fmt.Errorf("invalid target: need dog name (e.g., deacon/dogs/alpha)")
 	case len(parts) == 3 && parts[0] == "deacon" && parts[1] == "dogs":
 		// deacon/dogs/alpha -> hq-dog-alpha
-		return fmt.Sprintf("hq-dog-%s", parts[2]), nil
+		return session.DogSessionName(parts[2]), nil
 	default:
 		prefix := session.DefaultPrefix
 		if len(parts) > 0 {
@@ -198,11 +198,14 @@ func runWitnessStop(cmd *cobra.Command, args []string) error {
 
 	// Kill tmux session if it exists.
 	// Use KillSessionWithProcesses to ensure all descendant processes are killed.
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	sessionName := witnessSessionName(rigName)
 	running, _ := t.HasSession(sessionName)
 	if running {
-		if err := t.KillSessionWithProcesses(sessionName); err != nil {
+		if err := tmux.KillWithProcesses(t, sessionName); err != nil {
 			style.PrintWarning("failed to kill session: %v", err)
 		}
 	}
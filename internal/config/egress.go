@@ -0,0 +1,104 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// DefaultEgressListen is where the daemon's egress proxy listens when
+// EgressConfig.Listen is not set.
+const DefaultEgressListen = "127.0.0.1:18089"
+
+// DefaultEgressAllowHosts are the hosts agents may always reach through the
+// egress proxy: the model API and its telemetry. Git remotes of registered
+// rigs are allowed in addition (see the daemon's egress proxy).
+var DefaultEgressAllowHosts = []string{
+	"api.anthropic.com",
+	"statsig.anthropic.com",
+	"*.anthropic.com",
+}
+
+// DefaultEgressRoles are the roles whose sessions are routed through the
+// egress proxy when EgressConfig.Roles is empty: the workers that run
+// untrusted code from the repos they work on.
+var DefaultEgressRoles = []string{constants.RolePolecat, constants.RoleCrew}
+
+// EgressConfig restricts agent sessions' network egress. When enabled, the
+// daemon runs a local HTTP proxy that only forwards to allowlisted hosts,
+// and sessions of the covered roles get HTTP_PROXY/HTTPS_PROXY pointing at
+// it. This covers HTTP(S) clients that honor the proxy variables (the agent
+// runtime, git over https, package managers); it is not a firewall, and
+// git remotes reached over ssh bypass it.
+type EgressConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Listen is the proxy's listen address. Default: DefaultEgressListen.
+	Listen string `json:"listen,omitempty"`
+
+	// AllowHosts are hosts reachable in addition to DefaultEgressAllowHosts
+	// and the rigs' git remotes. "*.example.com" matches any subdomain.
+	AllowHosts []string `json:"allow_hosts,omitempty"`
+
+	// Roles lists the roles whose sessions are routed through the proxy.
+	// Default: DefaultEgressRoles.
+	Roles []string `json:"roles,omitempty"`
+}
+
+// ListenAddr returns the proxy's listen address.
+func (c *EgressConfig) ListenAddr() string {
+	if c.Listen != "" {
+		return c.Listen
+	}
+	return DefaultEgressListen
+}
+
+// AppliesTo reports whether sessions of role are routed through the proxy.
+func (c *EgressConfig) AppliesTo(role string) bool {
+	if c == nil || !c.Enabled {
+		return false
+	}
+	roles := c.Roles
+	if len(roles) == 0 {
+		roles = DefaultEgressRoles
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedHosts returns the configured allowlist: the defaults plus AllowHosts.
+func (c *EgressConfig) AllowedHosts() []string {
+	hosts := append([]string{}, DefaultEgressAllowHosts...)
+	for _, h := range c.AllowHosts {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// egressEnv returns the proxy variables for a session of role when the town
+// routes that role through its egress proxy, or nil.
+func egressEnv(townRoot, role string) map[string]string {
+	if townRoot == "" {
+		return nil
+	}
+	settings, err := LoadOrCreateTownSettings(TownSettingsPath(townRoot))
+	if err != nil || !settings.Egress.AppliesTo(role) {
+		return nil
+	}
+	proxyURL := "http://" + settings.Egress.ListenAddr()
+	noProxy := "localhost,127.0.0.1,::1"
+	return map[string]string{
+		"HTTP_PROXY":  proxyURL,
+		"HTTPS_PROXY": proxyURL,
+		"http_proxy":  proxyURL,
+		"https_proxy": proxyURL,
+		"NO_PROXY":    noProxy,
+		"no_proxy":    noProxy,
+	}
+}
@@ -162,6 +162,12 @@ func AgentEnv(cfg AgentEnvConfig) map[string]string {
 	// cost reports can correlate activity to a specific tmux session.
 	if cfg.SessionName != "" {
 		env["GT_SESSION"] = cfg.SessionName
+		// GT_HEARTBEAT_FILE is the session's pulse file (polecat.PulseFile):
+		// touching it tells the daemon the agent is alive, even while it
+		// runs no gt commands and prints nothing.
+		if cfg.TownRoot != "" {
+			env["GT_HEARTBEAT_FILE"] = filepath.Join(cfg.TownRoot, ".runtime", "heartbeats", cfg.SessionName+".pulse")
+		}
 	}
 
 	// Set GT_AGENT when an agent override is in use.
@@ -360,6 +366,18 @@ func AgentEnv(cfg AgentEnvConfig) map[string]string {
 		}
 	}
 
+	// Route egress through the town's egress proxy when its policy covers
+	// this role. Overrides any proxy passed through from the parent shell.
+	for k, v := range egressEnv(cfg.TownRoot, cfg.Role) {
+		env[k] = v
+	}
+
+	// Pin the rig's toolchain, so agents build and test with the versions
+	// the refinery's gates use.
+	for k, v := range toolchainEnv(cfg.TownRoot, cfg.Rig) {
+		env[k] = v
+	}
+
 	return env
 }
 
@@ -1219,3 +1219,22 @@ func TestAgentEnv_NoDoltPortWithoutConfig(t *testing.T) {
 	assertNotSet(t, env, "GT_DOLT_PORT")
 	assertNotSet(t, env, "BEADS_DOLT_PORT")
 }
+
+func TestAgentEnv_EgressPolicy(t *testing.T) {
+	t.Parallel()
+	townRoot := t.TempDir()
+	settings := NewTownSettings()
+	settings.Egress = &EgressConfig{Enabled: true, Listen: "127.0.0.1:19999"}
+	if err := SaveTownSettings(TownSettingsPath(townRoot), settings); err != nil {
+		t.Fatal(err)
+	}
+
+	env := AgentEnv(AgentEnvConfig{Role: "polecat", Rig: "myrig", AgentName: "Toast", TownRoot: townRoot})
+	assertEnv(t, env, "HTTPS_PROXY", "http://127.0.0.1:19999")
+	assertEnv(t, env, "http_proxy", "http://127.0.0.1:19999")
+	assertEnv(t, env, "NO_PROXY", "localhost,127.0.0.1,::1")
+
+	// Roles outside the policy keep direct egress.
+	env = AgentEnv(AgentEnvConfig{Role: "mayor", TownRoot: townRoot})
+	assertNotSet(t, env, "https_proxy")
+}
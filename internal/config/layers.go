@@ -0,0 +1,266 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
+)
+
+// Config layer names, lowest precedence first.
+const (
+	LayerDefault = "default"
+	LayerTown    = "town"
+	LayerRig     = "rig"
+	LayerUser    = "user"
+	LayerEnv     = "env"
+)
+
+// ConfigLayer is one source of settings, flattened to dot-notation keys
+// (e.g. "scheduler.max_polecats").
+type ConfigLayer struct {
+	Name   string                 `json:"name"`
+	Path   string                 `json:"path,omitempty"` // File or env var source
+	Values map[string]interface{} `json:"-"`
+}
+
+// EffectiveValue is a resolved setting and the layer it came from.
+type EffectiveValue struct {
+	Key        string      `json:"key"`
+	Value      interface{} `json:"value"`
+	Origin     string      `json:"origin"`               // Layer name
+	Source     string      `json:"source,omitempty"`     // File path or env var name
+	Overridden []string    `json:"overridden,omitempty"` // Lower layers that also set the key
+}
+
+// LayeredConfig stacks settings layers: built-in defaults < town < rig <
+// user (~/.config/gastown/config.json) < environment. Each key takes the
+// value of the highest layer that sets it.
+type LayeredConfig struct {
+	Layers []ConfigLayer
+}
+
+// layerMetaKeys are file-format keys that are not settings.
+var layerMetaKeys = map[string]bool{"type": true, "version": true}
+
+// UserSettingsPath returns the per-user settings override file,
+// $XDG_CONFIG_HOME/gastown/config.json or ~/.config/gastown/config.json.
+func UserSettingsPath() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "gastown", "config.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gastown", "config.json")
+}
+
+// SettingEnvVar returns the environment variable that overrides key:
+// "GT_CONFIG_" followed by the key upper-cased with dots and dashes as
+// underscores (scheduler.max_polecats → GT_CONFIG_SCHEDULER_MAX_POLECATS).
+// The GT_CONFIG_ prefix keeps settings apart from session variables such
+// as GT_AGENT and GT_ROLE.
+func SettingEnvVar(key string) string {
+	return "GT_CONFIG_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// LoadLayeredConfig loads every settings layer for a town and, if rigPath
+// is non-empty, a rig. Missing files are empty layers.
+func LoadLayeredConfig(townRoot, rigPath string) (*LayeredConfig, error) {
+	defaults, err := json.Marshal(defaultLayerSettings())
+	if err != nil {
+		return nil, err
+	}
+	lc := &LayeredConfig{}
+	if err := lc.addJSON(LayerDefault, "", defaults); err != nil {
+		return nil, err
+	}
+	if err := lc.addFile(LayerTown, TownSettingsPath(townRoot)); err != nil {
+		return nil, err
+	}
+	if rigPath != "" {
+		if err := lc.addFile(LayerRig, RigSettingsPath(rigPath)); err != nil {
+			return nil, err
+		}
+	}
+	if err := lc.addFile(LayerUser, UserSettingsPath()); err != nil {
+		return nil, err
+	}
+	lc.addEnv(os.LookupEnv)
+	return lc, nil
+}
+
+// defaultLayerSettings returns the built-in defaults, with the defaults
+// that consumers apply for unset sections filled in so every documented key
+// shows up (and can be overridden from the environment).
+func defaultLayerSettings() *TownSettings {
+	s := NewTownSettings()
+	s.CLITheme = "auto"
+	s.AgentEmailDomain = "gastown.local"
+	s.WebTimeouts = DefaultWebTimeoutsConfig()
+	s.Scheduler = capacity.DefaultSchedulerConfig()
+	return s
+}
+
+func (lc *LayeredConfig) addFile(name, path string) error {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			lc.Layers = append(lc.Layers, ConfigLayer{Name: name, Path: path, Values: map[string]interface{}{}})
+			return nil
+		}
+		return fmt.Errorf("reading %s settings: %w", name, err)
+	}
+	return lc.addJSON(name, path, data)
+}
+
+func (lc *LayeredConfig) addJSON(name, path string, data []byte) error {
+	values, err := FlattenSettings(data)
+	if err != nil {
+		return fmt.Errorf("parsing %s settings %s: %w", name, path, err)
+	}
+	lc.Layers = append(lc.Layers, ConfigLayer{Name: name, Path: path, Values: values})
+	return nil
+}
+
+// addEnv adds the environment layer. Only keys some lower layer knows about
+// are looked up, so unrelated GT_* variables are never mistaken for
+// settings. Values are parsed as JSON when possible (true, 5, ["a"]) and
+// taken as strings otherwise.
+func (lc *LayeredConfig) addEnv(lookup func(string) (string, bool)) {
+	layer := ConfigLayer{Name: LayerEnv, Values: map[string]interface{}{}}
+	var vars []string
+	for _, key := range lc.Keys() {
+		name := SettingEnvVar(key)
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			v = raw
+		}
+		layer.Values[key] = v
+		vars = append(vars, name)
+	}
+	layer.Path = strings.Join(vars, ",")
+	lc.Layers = append(lc.Layers, layer)
+}
+
+// Keys returns every key set by any layer, sorted.
+func (lc *LayeredConfig) Keys() []string {
+	seen := make(map[string]bool)
+	for _, l := range lc.Layers {
+		for k := range l.Values {
+			seen[k] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Lookup resolves a single key. ok is false if no layer sets it.
+func (lc *LayeredConfig) Lookup(key string) (ev EffectiveValue, ok bool) {
+	ev.Key = key
+	for _, l := range lc.Layers {
+		v, set := l.Values[key]
+		if !set {
+			continue
+		}
+		if ok {
+			ev.Overridden = append(ev.Overridden, ev.Origin)
+		}
+		ev.Value, ev.Origin, ev.Source, ok = v, l.Name, l.Path, true
+		if l.Name == LayerEnv {
+			ev.Source = SettingEnvVar(key)
+		}
+	}
+	return ev, ok
+}
+
+// Effective resolves every key.
+func (lc *LayeredConfig) Effective() []EffectiveValue {
+	keys := lc.Keys()
+	out := make([]EffectiveValue, 0, len(keys))
+	for _, k := range keys {
+		if ev, ok := lc.Lookup(k); ok {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// TownSettings builds town settings from the effective values. Keys that
+// are not town settings (e.g. rig-only settings) are ignored.
+func (lc *LayeredConfig) TownSettings() (*TownSettings, error) {
+	tree := make(map[string]interface{})
+	for _, ev := range lc.Effective() {
+		setPath(tree, strings.Split(ev.Key, "."), ev.Value)
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	settings := NewTownSettings()
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("applying layered settings: %w", err)
+	}
+	return settings, nil
+}
+
+// LoadLayeredTownSettings returns town settings with rig, user and
+// environment overrides applied.
+func LoadLayeredTownSettings(townRoot, rigPath string) (*TownSettings, error) {
+	lc, err := LoadLayeredConfig(townRoot, rigPath)
+	if err != nil {
+		return nil, err
+	}
+	return lc.TownSettings()
+}
+
+// FlattenSettings flattens a JSON settings object to dot-notation keys.
+// Nested objects are descended into; arrays and scalars are leaf values.
+// Empty objects and file-format keys (type, version) are dropped.
+func FlattenSettings(data []byte) (map[string]interface{}, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{})
+	for k, v := range root {
+		if layerMetaKeys[k] {
+			continue
+		}
+		flattenInto(out, k, v)
+	}
+	return out, nil
+}
+
+func flattenInto(out map[string]interface{}, prefix string, v interface{}) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		out[prefix] = v
+		return
+	}
+	for k, child := range obj {
+		flattenInto(out, prefix+"."+k, child)
+	}
+}
+
+func setPath(tree map[string]interface{}, path []string, v interface{}) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := tree[p].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			tree[p] = next
+		}
+		tree = next
+	}
+	tree[path[len(path)-1]] = v
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeLayerFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayeredConfig_Precedence(t *testing.T) {
+	town := t.TempDir()
+	rigPath := filepath.Join(town, "gastown")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	writeLayerFile(t, TownSettingsPath(town), `{"type":"town-settings","version":1,"cli_theme":"dark","default_agent":"codex","scheduler":{"max_polecats":3}}`)
+	writeLayerFile(t, RigSettingsPath(rigPath), `{"type":"rig-settings","role_agents":{"polecat":"gemini"}}`)
+	writeLayerFile(t, UserSettingsPath(), `{"cli_theme":"light","scheduler":{"max_polecats":5}}`)
+	t.Setenv("GT_CONFIG_SCHEDULER_MAX_POLECATS", "8")
+
+	lc, err := LoadLayeredConfig(town, rigPath)
+	if err != nil {
+		t.Fatalf("LoadLayeredConfig: %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		value  interface{}
+		origin string
+	}{
+		{"agent_email_domain", "gastown.local", LayerDefault},
+		{"default_agent", "codex", LayerTown},
+		{"role_agents.polecat", "gemini", LayerRig},
+		{"cli_theme", "light", LayerUser},
+		{"scheduler.max_polecats", float64(8), LayerEnv},
+	}
+	for _, tt := range tests {
+		ev, ok := lc.Lookup(tt.key)
+		if !ok || ev.Value != tt.value || ev.Origin != tt.origin {
+			t.Errorf("Lookup(%s) = %+v, want %v from %s", tt.key, ev, tt.value, tt.origin)
+		}
+	}
+
+	ev, _ := lc.Lookup("scheduler.max_polecats")
+	if ev.Source != "GT_CONFIG_SCHEDULER_MAX_POLECATS" || len(ev.Overridden) != 3 {
+		t.Errorf("env origin = %+v, want source var and default/town/user overridden", ev)
+	}
+	if _, ok := lc.Lookup("type"); ok {
+		t.Error("file-format keys should not be settings")
+	}
+
+	settings, err := lc.TownSettings()
+	if err != nil {
+		t.Fatalf("TownSettings: %v", err)
+	}
+	if settings.CLITheme != "light" || settings.Scheduler.GetMaxPolecats() != 8 || settings.RoleAgents["polecat"] != "gemini" {
+		t.Errorf("effective settings = theme %q, max_polecats %d, role_agents %v",
+			settings.CLITheme, settings.Scheduler.GetMaxPolecats(), settings.RoleAgents)
+	}
+}
+
+func TestLayeredConfig_EnvIgnoresUnknownKeys(t *testing.T) {
+	town := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GT_CONFIG_NOT_A_SETTING", "x")
+	t.Setenv("GT_CONFIG_CLI_THEME", "dark")
+
+	lc, err := LoadLayeredConfig(town, "")
+	if err != nil {
+		t.Fatalf("LoadLayeredConfig: %v", err)
+	}
+	if _, ok := lc.Lookup("not_a_setting"); ok {
+		t.Error("unknown env var became a setting")
+	}
+	if ev, _ := lc.Lookup("cli_theme"); ev.Value != "dark" || ev.Origin != LayerEnv {
+		t.Errorf("cli_theme = %+v, want dark from env (strings need no JSON quoting)", ev)
+	}
+}
@@ -219,6 +219,11 @@ func validateRigSettings(c *RigSettings) error {
 			return err
 		}
 	}
+	if c.Toolchain != nil {
+		if err := c.Toolchain.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -2441,6 +2446,13 @@ func validateEscalationConfig(c *EscalationConfig) error {
 		return fmt.Errorf("%w: max_reescalations must be non-negative", ErrMissingField)
 	}
 
+	// Validate desktop notification event types
+	for event := range c.DesktopNotifications {
+		if !IsValidDesktopEvent(event) {
+			return fmt.Errorf("%w: unknown desktop notification event '%s' (valid: approval, agent_stuck, batch_blocked)", ErrMissingField, event)
+		}
+	}
+
 	return nil
 }
 
@@ -2467,6 +2479,20 @@ func (c *EscalationConfig) GetRouteForSeverity(severity string) []string {
 	return []string{"bead", "mail:mayor"}
 }
 
+// GetApprovalRoute returns the notification actions for plan approval
+// requests. Falls back to ["mail:overseer"] if none are configured.
+func (c *EscalationConfig) GetApprovalRoute() []string {
+	if len(c.ApprovalRoute) > 0 {
+		return c.ApprovalRoute
+	}
+	return []string{"mail:overseer"}
+}
+
+// DesktopNotify reports whether desktop notifications are on for event.
+func (c *EscalationConfig) DesktopNotify(event string) bool {
+	return c.DesktopNotifications[event]
+}
+
 // GetMaxReescalations returns the maximum number of re-escalations allowed.
 // Returns 2 if not configured (nil). Explicit 0 means "never re-escalate".
 func (c *EscalationConfig) GetMaxReescalations() int {
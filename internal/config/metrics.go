@@ -0,0 +1,24 @@
+package config
+
+// DefaultMetricsListen is where the daemon serves metrics when
+// MetricsConfig.Listen is not set.
+const DefaultMetricsListen = "127.0.0.1:9464"
+
+// MetricsConfig makes the daemon serve the rigs' refinery metrics (queue
+// depth, batch sizes, gate durations, conflict and culprit rates,
+// time-to-land) at /metrics in the Prometheus text format.
+type MetricsConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Listen is the metrics server's listen address. Default:
+	// DefaultMetricsListen.
+	Listen string `json:"listen,omitempty"`
+}
+
+// ListenAddr returns the address the metrics server listens on.
+func (c *MetricsConfig) ListenAddr() string {
+	if c.Listen != "" {
+		return c.Listen
+	}
+	return DefaultMetricsListen
+}
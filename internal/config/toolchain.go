@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Toolchain version managers a rig can pin its tools with.
+const (
+	ToolchainMise = "mise"
+	ToolchainAsdf = "asdf"
+)
+
+// asdfPlugins maps common tool names to the asdf plugins that install them.
+var asdfPlugins = map[string]string{
+	"go":   "golang",
+	"node": "nodejs",
+}
+
+// ToolchainConfig pins the versions of a rig's tools (Go, Node, ...) for
+// every agent session in the rig and the refinery's gates, so an agent's
+// checks and the merge queue's run on the same toolchain.
+//
+// Pinning is done through the version manager's environment variables
+// (MISE_GO_VERSION, ASDF_NODEJS_VERSION, ...), so it overrides the repo's
+// own .tool-versions or mise.toml and only takes effect where the manager's
+// shims are on PATH.
+type ToolchainConfig struct {
+	// Manager is the version manager: "mise" (default) or "asdf".
+	Manager string `json:"manager,omitempty"`
+
+	// Tools maps tool names to versions, e.g. {"go": "1.22.3", "node": "20"}.
+	Tools map[string]string `json:"tools"`
+}
+
+// Validate checks the manager and that every tool has a name and version.
+func (c *ToolchainConfig) Validate() error {
+	switch c.Manager {
+	case "", ToolchainMise, ToolchainAsdf:
+	default:
+		return fmt.Errorf("toolchain manager must be %q or %q, got %q", ToolchainMise, ToolchainAsdf, c.Manager)
+	}
+	for tool, version := range c.Tools {
+		if strings.TrimSpace(tool) == "" {
+			return fmt.Errorf("toolchain tool name is empty")
+		}
+		if strings.TrimSpace(version) == "" {
+			return fmt.Errorf("toolchain tool %q has no version", tool)
+		}
+	}
+	return nil
+}
+
+// Env returns the variables that pin the configured tools for the version
+// manager. It is nil when nothing is pinned.
+func (c *ToolchainConfig) Env() map[string]string {
+	if c == nil || len(c.Tools) == 0 {
+		return nil
+	}
+	env := make(map[string]string, len(c.Tools))
+	for tool, version := range c.Tools {
+		tool = strings.ToLower(strings.TrimSpace(tool))
+		prefix := "MISE_"
+		if c.Manager == ToolchainAsdf {
+			prefix = "ASDF_"
+			if plugin, ok := asdfPlugins[tool]; ok {
+				tool = plugin
+			}
+		}
+		env[prefix+toolchainEnvName(tool)+"_VERSION"] = strings.TrimSpace(version)
+	}
+	return env
+}
+
+// toolchainEnvName upper-cases tool and replaces the characters environment
+// variable names can't hold.
+func toolchainEnvName(tool string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, tool)
+}
+
+// RigToolchainEnv returns the toolchain pins of the rig at rigPath (see
+// ToolchainConfig.Env), or nil when it pins nothing or its settings can't
+// be read.
+func RigToolchainEnv(rigPath string) map[string]string {
+	settings, err := LoadRigSettings(RigSettingsPath(rigPath))
+	if err != nil {
+		return nil
+	}
+	return settings.Toolchain.Env()
+}
+
+// toolchainEnv returns the toolchain pins for a session in rig.
+func toolchainEnv(townRoot, rig string) map[string]string {
+	if townRoot == "" || rig == "" {
+		return nil
+	}
+	return RigToolchainEnv(filepath.Join(townRoot, rig))
+}
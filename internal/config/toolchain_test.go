@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToolchainConfig_Env(t *testing.T) {
+	t.Parallel()
+	mise := &ToolchainConfig{Tools: map[string]string{"go": "1.22.3", "node": " 20 ", "golangci-lint": "1.59"}}
+	env := mise.Env()
+	assertEnv(t, env, "MISE_GO_VERSION", "1.22.3")
+	assertEnv(t, env, "MISE_NODE_VERSION", "20")
+	assertEnv(t, env, "MISE_GOLANGCI_LINT_VERSION", "1.59")
+
+	asdf := &ToolchainConfig{Manager: ToolchainAsdf, Tools: map[string]string{"go": "1.22.3", "node": "20", "python": "3.12"}}
+	env = asdf.Env()
+	assertEnv(t, env, "ASDF_GOLANG_VERSION", "1.22.3")
+	assertEnv(t, env, "ASDF_NODEJS_VERSION", "20")
+	assertEnv(t, env, "ASDF_PYTHON_VERSION", "3.12")
+
+	if env := (*ToolchainConfig)(nil).Env(); env != nil {
+		t.Errorf("nil config Env() = %v", env)
+	}
+}
+
+func TestToolchainConfig_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		cfg     ToolchainConfig
+		wantErr bool
+	}{
+		{"mise default", ToolchainConfig{Tools: map[string]string{"go": "1.22"}}, false},
+		{"asdf", ToolchainConfig{Manager: "asdf", Tools: map[string]string{"go": "1.22"}}, false},
+		{"unknown manager", ToolchainConfig{Manager: "nvm"}, true},
+		{"missing version", ToolchainConfig{Tools: map[string]string{"go": ""}}, true},
+		{"missing tool", ToolchainConfig{Tools: map[string]string{" ": "1"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAgentEnv_Toolchain(t *testing.T) {
+	t.Parallel()
+	townRoot := t.TempDir()
+	settingsPath := RigSettingsPath(filepath.Join(townRoot, "myrig"))
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"type": "rig-settings", "version": 1, "toolchain": {"tools": {"go": "1.22.3"}}}`)
+	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := AgentEnv(AgentEnvConfig{Role: "polecat", Rig: "myrig", AgentName: "Toast", TownRoot: townRoot})
+	assertEnv(t, env, "MISE_GO_VERSION", "1.22.3")
+	env = AgentEnv(AgentEnvConfig{Role: "polecat", Rig: "otherrig", AgentName: "Toast", TownRoot: townRoot})
+	assertNotSet(t, env, "MISE_GO_VERSION")
+}
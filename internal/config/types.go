@@ -102,6 +102,18 @@ type TownSettings struct {
 	// These were previously hardcoded as Go constants throughout the codebase.
 	// All values are optional — omitted values use compiled-in defaults.
 	Operational *OperationalConfig `json:"operational,omitempty"`
+
+	// Egress restricts agent sessions' network egress to allowlisted hosts
+	// through a local proxy run by the daemon. Nil disables it.
+	Egress *EgressConfig `json:"egress,omitempty"`
+
+	// Metrics serves the refinery metrics of every rig from the daemon.
+	// Nil disables it.
+	Metrics *MetricsConfig `json:"metrics,omitempty"`
+
+	// Observer configures the read-only dashboard for stakeholders
+	// (gt dashboard --observer).
+	Observer *ObserverConfig `json:"observer,omitempty"`
 }
 
 // NewTownSettings creates a new TownSettings with defaults.
@@ -131,6 +143,23 @@ type WebTimeoutsConfig struct {
 	MaxRunTimeout string `json:"max_run_timeout,omitempty"`
 }
 
+// ObserverConfig configures the read-only observer dashboard, a window
+// into the town for managers or customers: status, the merge queue,
+// activity and transcript summaries, with secrets redacted and no control
+// actions.
+type ObserverConfig struct {
+	// Token, if set, must be presented by observers, either as an
+	// "Authorization: Bearer" header or once as ?token= (the dashboard then
+	// remembers it in a cookie).
+	Token string `json:"token,omitempty"`
+	// Redact lists regular expressions to mask in everything observers see,
+	// on top of the built-in secret patterns (see util.Redactor).
+	Redact []string `json:"redact,omitempty"`
+	// HideTranscripts withholds agent transcript summaries and pane
+	// previews.
+	HideTranscripts bool `json:"hide_transcripts,omitempty"`
+}
+
 // DefaultWebTimeoutsConfig returns a WebTimeoutsConfig with sensible defaults.
 func DefaultWebTimeoutsConfig() *WebTimeoutsConfig {
 	return &WebTimeoutsConfig{
@@ -651,6 +680,10 @@ type RigSettings struct {
 	// Takes precedence over RoleAgents["crew"] but is overridden by explicit --agent flags.
 	// Example: {"denali": "codex", "glacier": "gemini"}
 	WorkerAgents map[string]string `json:"worker_agents,omitempty"`
+
+	// Toolchain pins tool versions (Go, Node, ...) for the rig's agent
+	// sessions and refinery gates.
+	Toolchain *ToolchainConfig `json:"toolchain,omitempty"`
 }
 
 // CrewConfig represents crew workspace settings for a rig.
@@ -1437,6 +1470,17 @@ type EscalationConfig struct {
 	// re-escalated. Default: 2 (low→medium→high, then stops)
 	// Pointer type to distinguish "not configured" (nil) from explicit 0.
 	MaxReescalations *int `json:"max_reescalations,omitempty"`
+
+	// ApprovalRoute lists the notification actions for agent plans awaiting
+	// human approval (gt approval). Supports "mail:<target>" and "slack".
+	// Default: ["mail:overseer"]
+	ApprovalRoute []string `json:"approval_route,omitempty"`
+
+	// DesktopNotifications turns native desktop notifications on or off
+	// per event type (DesktopEventApproval, DesktopEventAgentStuck,
+	// DesktopEventBatchBlocked), for events that need a human now.
+	// Events not listed are not shown.
+	DesktopNotifications map[string]bool `json:"desktop_notifications,omitempty"`
 }
 
 // EscalationContacts contains contact information for external notification channels.
@@ -1450,11 +1494,32 @@ type EscalationContacts struct {
 	SMTPUser     string `json:"smtp_user,omitempty"`     // SMTP auth username (optional)
 	SMTPPass     string `json:"smtp_pass,omitempty"`     // SMTP auth password (optional)
 	SMSWebhook   string `json:"sms_webhook,omitempty"`   // webhook URL for SMS delivery (e.g. Twilio)
+
+	// SlackSigningSecret verifies Slack interactivity requests (approval
+	// buttons) posted to the dashboard's /slack/approvals endpoint.
+	SlackSigningSecret string `json:"slack_signing_secret,omitempty"`
 }
 
 // CurrentEscalationVersion is the current schema version for EscalationConfig.
 const CurrentEscalationVersion = 1
 
+// Desktop notification event types (see EscalationConfig.DesktopNotifications).
+const (
+	DesktopEventApproval     = "approval"      // A plan or MR is waiting on human approval
+	DesktopEventAgentStuck   = "agent_stuck"   // An agent hung or reported itself stuck
+	DesktopEventBatchBlocked = "batch_blocked" // A refinery batch failed without a verdict
+)
+
+// IsValidDesktopEvent checks if a desktop notification event type is known.
+func IsValidDesktopEvent(event string) bool {
+	switch event {
+	case DesktopEventApproval, DesktopEventAgentStuck, DesktopEventBatchBlocked:
+		return true
+	default:
+		return false
+	}
+}
+
 // Escalation severity level constants.
 const (
 	SeverityCritical = "critical" // P0: immediate attention required
@@ -10,14 +10,18 @@ import (
 )
 
 // LocalConnection implements Connection for local file and command operations.
+// Sessions go through the multiplexer GT_MULTIPLEXER selects.
 type LocalConnection struct {
-	tmux *tmux.Tmux
+	mux    tmux.Multiplexer
+	muxErr error // Why mux is nil: GT_MULTIPLEXER names an unknown backend
 }
 
 // NewLocalConnection creates a new local connection.
 func NewLocalConnection() *LocalConnection {
+	mux, err := tmux.NewMultiplexer()
 	return &LocalConnection{
-		tmux: tmux.NewTmux(),
+		mux:    mux,
+		muxErr: err,
 	}
 }
 
@@ -157,33 +161,51 @@ func (c *LocalConnection) ExecEnv(env map[string]string, cmd string, args ...str
 
 // TmuxNewSession creates a new tmux session.
 func (c *LocalConnection) TmuxNewSession(name, dir string) error {
-	return c.tmux.NewSession(name, dir)
+	if c.muxErr != nil {
+		return c.muxErr
+	}
+	return c.mux.NewSession(name, dir)
 }
 
 // TmuxKillSession terminates a tmux session.
-// Uses KillSessionWithProcesses to ensure all descendant processes are killed.
+// Uses KillWithProcesses to ensure all descendant processes are killed.
 func (c *LocalConnection) TmuxKillSession(name string) error {
-	return c.tmux.KillSessionWithProcesses(name)
+	if c.muxErr != nil {
+		return c.muxErr
+	}
+	return tmux.KillWithProcesses(c.mux, name)
 }
 
 // TmuxSendKeys sends keys to a tmux session.
 func (c *LocalConnection) TmuxSendKeys(session, keys string) error {
-	return c.tmux.SendKeys(session, keys)
+	if c.muxErr != nil {
+		return c.muxErr
+	}
+	return c.mux.SendKeys(session, keys)
 }
 
 // TmuxCapturePane captures the last N lines from a tmux pane.
 func (c *LocalConnection) TmuxCapturePane(session string, lines int) (string, error) {
-	return c.tmux.CapturePane(session, lines)
+	if c.muxErr != nil {
+		return "", c.muxErr
+	}
+	return c.mux.CapturePane(session, lines)
 }
 
 // TmuxHasSession returns true if the session exists.
 func (c *LocalConnection) TmuxHasSession(name string) (bool, error) {
-	return c.tmux.HasSession(name)
+	if c.muxErr != nil {
+		return false, c.muxErr
+	}
+	return c.mux.HasSession(name)
 }
 
 // TmuxListSessions returns all tmux session names.
 func (c *LocalConnection) TmuxListSessions() ([]string, error) {
-	return c.tmux.ListSessions()
+	if c.muxErr != nil {
+		return nil, c.muxErr
+	}
+	return c.mux.ListSessions()
 }
 
 // Verify LocalConnection implements Connection.
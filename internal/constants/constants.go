@@ -305,6 +305,20 @@ const (
 
 	// MolConvoyCleanup is the convoy cleanup formula name.
 	MolConvoyCleanup = "mol-convoy-cleanup"
+
+	// MolWriteTests is the formula poured to an agent whose MR needs tests.
+	MolWriteTests = "mol-write-tests"
+
+	// MolSplitMR is the formula poured to an agent whose MR is too large.
+	MolSplitMR = "mol-split-mr"
+
+	// MolResolveConflict is the formula poured to an agent to resolve an
+	// MR's merge conflicts in a scratch worktree.
+	MolResolveConflict = "mol-resolve-conflict"
+
+	// MolRebaseMR is the formula poured to an agent whose MR conflicted
+	// while its batch was stacked, to rebase it within the grace window.
+	MolRebaseMR = "mol-rebase-mr"
 )
 
 // PatrolFormulas returns the list of patrol formula names.
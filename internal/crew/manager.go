@@ -730,6 +730,10 @@ func (m *Manager) Start(name string, opts StartOptions) error {
 	// IMPORTANT: All validation and command building happens BEFORE killing
 	// any existing session, so a validation failure cannot leave the user
 	// without a running session.
+	sessionID, err := session.ResolveSessionName(&session.AgentIdentity{Role: session.RoleCrew, Rig: m.rig.Name, Name: name, Prefix: session.PrefixFor(m.rig.Name)})
+	if err != nil {
+		return fmt.Errorf("resolving session name: %w", err)
+	}
 	var claudeCmd string
 	if opts.ResumeSessionID != "" {
 		// Validate session ID to prevent shell injection. The ID is interpolated
@@ -781,15 +785,17 @@ func (m *Manager) Start(name string, opts StartOptions) error {
 			TownRoot:    townRoot,
 			Prompt:      beacon,
 			Topic:       topic,
-			SessionName: m.SessionName(name),
+			SessionName: sessionID,
 		}, m.rig.Path, beacon, opts.AgentOverride)
 		if err != nil {
 			return fmt.Errorf("building startup command: %w", err)
 		}
 	}
 
-	t := tmux.NewTmux()
-	sessionID := m.SessionName(name)
+	t, err := tmux.RequireTmux("starting a crew session")
+	if err != nil {
+		return err
+	}
 
 	// Check if session already exists — kill AFTER command is fully built
 	// so validation failures don't destroy the user's running session.
@@ -887,7 +893,10 @@ func (m *Manager) Stop(name string) error {
 		return err
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	sessionID := m.SessionName(name)
 
 	// Check if session exists
@@ -909,7 +918,7 @@ func (m *Manager) Stop(name string) error {
 	// Kill the session.
 	// Use KillSessionWithProcesses to ensure all descendant processes are killed.
 	// This prevents orphan bash processes from Claude's Bash tool surviving session termination.
-	if err := t.KillSessionWithProcesses(sessionID); err != nil {
+	if err := tmux.KillWithProcesses(t, sessionID); err != nil {
 		return fmt.Errorf("killing session: %w", err)
 	}
 
@@ -918,7 +927,9 @@ func (m *Manager) Stop(name string) error {
 
 // IsRunning checks if a crew member's session is active.
 func (m *Manager) IsRunning(name string) (bool, error) {
-	t := tmux.NewTmux()
-	sessionID := m.SessionName(name)
-	return t.HasSession(sessionID)
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return false, err
+	}
+	return t.HasSession(m.SessionName(name))
 }
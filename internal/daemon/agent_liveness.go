@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/desktop"
+	"github.com/steveyegge/gastown/internal/polecat"
+)
+
+// heartbeatMarkerLines is how much of a pane's tail is scanned for
+// heartbeat markers (see polecat.HeartbeatMarker).
+const heartbeatMarkerLines = 50
+
+// liveness is the daemon's verdict on a live agent session.
+type liveness string
+
+const (
+	// livenessWorking: the agent emitted a heartbeat recently.
+	livenessWorking liveness = "working"
+	// livenessQuiet: the heartbeat is stale but younger than the hung
+	// threshold. The agent is thinking or in a long tool call; leave it.
+	livenessQuiet liveness = "quiet"
+	// livenessIdle: the agent reported idle or exiting. The idle reaper
+	// handles it.
+	livenessIdle liveness = "idle"
+	// livenessStuck: the agent reported itself stuck. The witness
+	// escalates it.
+	livenessStuck liveness = "stuck"
+	// livenessHung: the agent said it was working, then went silent for
+	// longer than the hung threshold.
+	livenessHung liveness = "hung"
+	// livenessUnknown: the agent never emitted a heartbeat. Pane activity
+	// alone is no evidence either way (an idle agent prints nothing; a hung
+	// one may still animate its spinner), so nothing is decided.
+	livenessUnknown liveness = "unknown"
+)
+
+// assessLiveness judges an agent session from its latest heartbeat. A
+// heartbeat older than stale means the agent went quiet; one older than
+// hung, while the agent last said it was working, means it is hung.
+func assessLiveness(hb *polecat.SessionHeartbeat, now time.Time, stale, hung time.Duration) liveness {
+	if hb == nil {
+		return livenessUnknown
+	}
+	switch hb.EffectiveState() {
+	case polecat.HeartbeatIdle, polecat.HeartbeatExiting:
+		return livenessIdle
+	case polecat.HeartbeatStuck:
+		return livenessStuck
+	}
+	switch age := now.Sub(hb.Timestamp); {
+	case age < stale:
+		return livenessWorking
+	case age < hung:
+		return livenessQuiet
+	default:
+		return livenessHung
+	}
+}
+
+// latestHeartbeat returns the latest heartbeat a session emitted, by file
+// (polecat.ReadAgentHeartbeat) or by a marker printed to its pane. The pane
+// is only scanned once the file heartbeats are stale, so agents that touch
+// files cost no tmux calls.
+func (d *Daemon) latestHeartbeat(sessionName string, now time.Time, stale time.Duration) *polecat.SessionHeartbeat {
+	hb := polecat.ReadAgentHeartbeat(d.config.TownRoot, sessionName)
+	if hb != nil && now.Sub(hb.Timestamp) < stale {
+		return hb
+	}
+	lines, err := d.tmux.CapturePaneLines(sessionName, heartbeatMarkerLines)
+	if err != nil {
+		return hb
+	}
+	if marker := polecat.LatestHeartbeatMarker(lines); marker != nil && (hb == nil || marker.Timestamp.After(hb.Timestamp)) {
+		return marker
+	}
+	return hb
+}
+
+// checkPolecatLiveness judges a polecat whose session is alive by its
+// heartbeats (see assessLiveness). A hung polecat with hooked work is
+// reported to the witness, whose stuck-agent-dog plugin decides on a
+// restart, once per silence: a new heartbeat re-arms the report.
+func (d *Daemon) checkPolecatLiveness(rigName, polecatName, sessionName string) {
+	opCfg := d.loadOperationalConfig()
+	stale := opCfg.GetPolecatConfig().HeartbeatStaleThresholdD()
+	hung := opCfg.GetSessionConfig().HungSessionThresholdD()
+	now := time.Now()
+
+	hb := d.latestHeartbeat(sessionName, now, stale)
+	if assessLiveness(hb, now, stale, hung) != livenessHung {
+		delete(d.hungReported, sessionName)
+		return
+	}
+	if reported, ok := d.hungReported[sessionName]; ok && reported.Equal(hb.Timestamp) {
+		return
+	}
+
+	prefix := beads.GetPrefixForRig(d.config.TownRoot, rigName)
+	info, err := d.getAgentBeadInfo(beads.PolecatBeadIDWithPrefix(prefix, rigName, polecatName))
+	if err != nil || info.HookBead == "" {
+		return // No hooked work: an idle polecat, for the reaper
+	}
+
+	silent := now.Sub(hb.Timestamp).Truncate(time.Second)
+	d.logger.Printf("HUNG AGENT: polecat %s/%s has hook_bead=%s but no heartbeat for %v (threshold %v)",
+		rigName, polecatName, info.HookBead, silent, hung)
+	d.notifyWitnessOfHungPolecat(rigName, polecatName, info.HookBead, hb, silent)
+	if err := desktop.NotifyEvent(d.config.TownRoot, config.DesktopEventAgentStuck, desktop.Notification{
+		Title: fmt.Sprintf("Agent stuck: %s/%s", rigName, polecatName),
+		Body:  fmt.Sprintf("No heartbeat for %v while working on %s", silent, info.HookBead),
+	}); err != nil {
+		d.logger.Printf("Warning: desktop notification: %v", err)
+	}
+	if d.hungReported == nil {
+		d.hungReported = make(map[string]time.Time)
+	}
+	d.hungReported[sessionName] = hb.Timestamp
+}
+
+// notifyWitnessOfHungPolecat tells the witness a polecat went silent while
+// working. Like a crash, the restart is left to the stuck-agent-dog plugin.
+func (d *Daemon) notifyWitnessOfHungPolecat(rigName, polecatName, hookBead string, hb *polecat.SessionHeartbeat, silent time.Duration) {
+	witnessAddr := rigName + "/witness"
+	subject := fmt.Sprintf("HUNG_POLECAT: %s/%s silent for %v", rigName, polecatName, silent)
+	body := fmt.Sprintf(`Polecat %s is alive but has sent no heartbeat for %v.
+
+hook_bead: %s
+last_heartbeat: %s
+last_context: %s
+
+Its session and agent process are still up, so this is not a crash. Check
+its pane before restarting: a polecat in a very long tool call looks the same.
+Restart deferred to stuck-agent-dog plugin for context-aware recovery.`,
+		polecatName, silent, hookBead, hb.Timestamp.Format(time.RFC3339), hb.Context)
+
+	cmd := exec.Command(d.gtPath, "mail", "send", witnessAddr, "-s", subject, "-m", body) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = d.config.TownRoot
+	cmd.Env = append(os.Environ(), "BD_ACTOR=daemon")
+	if err := cmd.Run(); err != nil {
+		d.logger.Printf("Warning: failed to notify witness of hung polecat: %v", err)
+	}
+}
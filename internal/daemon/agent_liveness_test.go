@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+func TestAssessLiveness(t *testing.T) {
+	now := time.Now()
+	stale, hung := 3*time.Minute, 30*time.Minute
+	hb := func(age time.Duration, state polecat.HeartbeatState) *polecat.SessionHeartbeat {
+		return &polecat.SessionHeartbeat{Timestamp: now.Add(-age), State: state}
+	}
+	tests := []struct {
+		name string
+		hb   *polecat.SessionHeartbeat
+		want liveness
+	}{
+		{"no heartbeat", nil, livenessUnknown},
+		{"fresh", hb(time.Minute, polecat.HeartbeatWorking), livenessWorking},
+		{"thinking", hb(10*time.Minute, polecat.HeartbeatWorking), livenessQuiet},
+		{"v1 silent", hb(time.Hour, ""), livenessHung},
+		{"silent", hb(31*time.Minute, polecat.HeartbeatWorking), livenessHung},
+		{"idle", hb(time.Hour, polecat.HeartbeatIdle), livenessIdle},
+		{"exiting", hb(time.Hour, polecat.HeartbeatExiting), livenessIdle},
+		{"stuck", hb(time.Hour, polecat.HeartbeatStuck), livenessStuck},
+	}
+	for _, tt := range tests {
+		if got := assessLiveness(tt.hb, now, stale, hung); got != tt.want {
+			t.Errorf("%s: assessLiveness = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestCheckPolecatHealth_ReportsHungPolecatOnce verifies that a live polecat
+// silent past the hung threshold is reported to the witness once, and that a
+// fresh pulse clears it.
+func TestCheckPolecatHealth_ReportsHungPolecatOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses Unix shell script mocks for tmux and bd")
+	}
+	binDir := t.TempDir()
+	// The session is alive and its pane prints no markers.
+	script := "#!/bin/sh\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(binDir, "tmux"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	bdPath := writeFakeTestBD(t, binDir, "working", "working", "gt-xyz", time.Now().UTC().Format(time.RFC3339))
+	gtLog := filepath.Join(t.TempDir(), "gt-invocations.log")
+	fakeGt := filepath.Join(binDir, "gt")
+	if err := os.WriteFile(fakeGt, []byte(fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\n", gtLog)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+
+	townRoot := t.TempDir()
+	sessionName := session.PolecatSessionName(session.PrefixFor("myr"), "mycat")
+	// The polecat last reported working an hour ago.
+	polecat.TouchSessionHeartbeat(townRoot, sessionName)
+	hbFile := filepath.Join(townRoot, ".runtime", "heartbeats", sessionName+".json")
+	silent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	if err := os.WriteFile(hbFile, []byte(fmt.Sprintf(`{"timestamp":%q,"state":"working"}`, silent)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var logBuf strings.Builder
+	d := &Daemon{
+		config: &Config{TownRoot: townRoot},
+		logger: log.New(&logBuf, "", 0),
+		tmux:   tmux.NewTmux(),
+		bdPath: bdPath,
+		gtPath: fakeGt,
+	}
+
+	d.checkPolecatHealth("myr", "mycat")
+	d.checkPolecatHealth("myr", "mycat")
+	if got := strings.Count(logBuf.String(), "HUNG AGENT"); got != 1 {
+		t.Fatalf("HUNG AGENT logged %d times, want once:\n%s", got, logBuf.String())
+	}
+	logData, err := os.ReadFile(gtLog)
+	if err != nil {
+		t.Fatalf("reading gt invocation log: %v", err)
+	}
+	if !strings.Contains(string(logData), "HUNG_POLECAT") || !strings.Contains(string(logData), "myr/witness") {
+		t.Errorf("expected HUNG_POLECAT mail to myr/witness, got: %q", logData)
+	}
+
+	// A pulse means the polecat is working again.
+	if err := os.WriteFile(polecat.PulseFile(townRoot, sessionName), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	d.checkPolecatHealth("myr", "mycat")
+	if _, ok := d.hungReported[sessionName]; ok {
+		t.Error("fresh pulse did not clear the hung report")
+	}
+	if got := strings.Count(logBuf.String(), "HUNG AGENT"); got != 1 {
+		t.Errorf("HUNG AGENT logged %d times after a pulse, want once", got)
+	}
+}
@@ -0,0 +1,215 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+const (
+	// defaultPatrolMaxRuntime is the wall-clock budget for one patrol run
+	// when none is configured. Generous: it catches hung runs, not slow ones.
+	defaultPatrolMaxRuntime = 2 * time.Hour
+	// patrolKillGrace is how long a patrol gets to return after its deadline
+	// kills its subprocesses before the daemon abandons the run.
+	patrolKillGrace = 30 * time.Second
+	// patrolBackoffBase is the first backoff after a budget overrun; each
+	// consecutive overrun doubles it, up to patrolBackoffMax.
+	patrolBackoffBase = 30 * time.Minute
+	patrolBackoffMax  = 24 * time.Hour
+	// patrolCmdWaitDelay bounds how long a killed subprocess may hold its
+	// output pipes open (e.g. via grandchildren) before Wait gives up.
+	patrolCmdWaitDelay = 10 * time.Second
+)
+
+// PatrolBudget caps the resources a single run of a patrol may use.
+// Configured per patrol under patrols.budgets, keyed by patrol name
+// ("compactor_dog", "jsonl_git_backup", ...).
+type PatrolBudget struct {
+	// MaxRuntimeStr is the wall-clock limit for one run (default 2h). When it
+	// passes, the run's subprocesses and queries are killed and the patrol
+	// backs off.
+	MaxRuntimeStr string `json:"max_runtime,omitempty"`
+	// MaxCPUStr is the CPU time the run's subprocesses may use. It is checked
+	// when the run ends; a run over budget backs the patrol off. Empty means
+	// unlimited. Unix only.
+	MaxCPUStr string `json:"max_cpu,omitempty"`
+	// Nice is the niceness (1-19) the run's subprocesses start at, so they
+	// yield the CPU to agents. 0 leaves priority unchanged. Unix only.
+	Nice int `json:"nice,omitempty"`
+}
+
+// patrolBudgetLimits is a PatrolBudget with durations parsed and defaults applied.
+type patrolBudgetLimits struct {
+	maxRuntime time.Duration
+	maxCPU     time.Duration
+	nice       int
+}
+
+// patrolBudget returns the budget for the named patrol.
+func patrolBudget(config *DaemonPatrolConfig, name string) patrolBudgetLimits {
+	limits := patrolBudgetLimits{maxRuntime: defaultPatrolMaxRuntime}
+	if config == nil || config.Patrols == nil || config.Patrols.Budgets[name] == nil {
+		return limits
+	}
+	b := config.Patrols.Budgets[name]
+	if d, err := time.ParseDuration(b.MaxRuntimeStr); err == nil && d > 0 {
+		limits.maxRuntime = d
+	}
+	if d, err := time.ParseDuration(b.MaxCPUStr); err == nil && d > 0 {
+		limits.maxCPU = d
+	}
+	if b.Nice > 0 && b.Nice <= 19 {
+		limits.nice = b.Nice
+	}
+	return limits
+}
+
+// patrolRun is a patrol run in progress.
+type patrolRun struct {
+	ctx  context.Context
+	done chan struct{}
+}
+
+// patrolBackoff tracks consecutive budget overruns of a patrol.
+type patrolBackoff struct {
+	overruns int
+	until    time.Time
+}
+
+type patrolBudgetKey struct{}
+
+// runPatrol runs fn as the named patrol under its budget. The run's context
+// (see patrolContext) expires at the max runtime, killing the patrol's
+// subprocesses. If fn has not returned patrolKillGrace later, the run is
+// abandoned: the daemon moves on, and the patrol is skipped until fn returns.
+// A run that overruns its runtime or CPU budget is logged, escalated, and
+// backed off exponentially; a run within budget clears the backoff.
+func (d *Daemon) runPatrol(name string, fn func()) {
+	d.patrolMu.Lock()
+	if d.patrolRuns == nil {
+		d.patrolRuns = make(map[string]*patrolRun)
+		d.patrolBackoffs = make(map[string]*patrolBackoff)
+	}
+	if d.patrolRuns[name] != nil {
+		d.patrolMu.Unlock()
+		d.logger.Printf("%s: previous run abandoned over budget has not exited, skipping", name)
+		return
+	}
+	if b := d.patrolBackoffs[name]; b != nil && time.Now().Before(b.until) {
+		d.patrolMu.Unlock()
+		d.logger.Printf("%s: backed off after exceeding its budget, skipping until %s", name, b.until.Format(time.RFC3339))
+		return
+	}
+
+	limits := patrolBudget(d.patrolConfig, name)
+	parent := d.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(context.WithValue(parent, patrolBudgetKey{}, limits), limits.maxRuntime)
+	run := &patrolRun{ctx: ctx, done: make(chan struct{})}
+	d.patrolRuns[name] = run
+	d.patrolMu.Unlock()
+
+	cpuBefore := childCPUTime()
+	go func() {
+		defer func() {
+			cancel()
+			d.patrolMu.Lock()
+			delete(d.patrolRuns, name)
+			d.patrolMu.Unlock()
+			close(run.done)
+		}()
+		fn()
+	}()
+
+	abandoned := false
+	select {
+	case <-run.done:
+	case <-ctx.Done():
+		select {
+		case <-run.done:
+		case <-time.After(patrolKillGrace):
+			abandoned = true
+		}
+	}
+
+	if parent.Err() != nil {
+		return // Daemon shutting down, not an overrun
+	}
+	var overrun string
+	switch {
+	case abandoned:
+		overrun = fmt.Sprintf("still running %v after its %v runtime budget, abandoned", patrolKillGrace, limits.maxRuntime)
+	case ctx.Err() == context.DeadlineExceeded:
+		overrun = fmt.Sprintf("exceeded its %v runtime budget, killed", limits.maxRuntime)
+	case limits.maxCPU > 0:
+		// Children of other goroutines may be counted too; the check
+		// is a coarse guard against runaway work, not accounting.
+		if used := childCPUTime() - cpuBefore; used > limits.maxCPU {
+			overrun = fmt.Sprintf("used %v CPU, over its %v budget", used.Round(time.Second), limits.maxCPU)
+		}
+	}
+
+	d.patrolMu.Lock()
+	b := d.patrolBackoffs[name]
+	if overrun == "" {
+		delete(d.patrolBackoffs, name)
+		d.patrolMu.Unlock()
+		return
+	}
+	if b == nil {
+		b = &patrolBackoff{}
+		d.patrolBackoffs[name] = b
+	}
+	b.overruns++
+	backoff := patrolBackoffBase << (b.overruns - 1)
+	if backoff > patrolBackoffMax || backoff <= 0 {
+		backoff = patrolBackoffMax
+	}
+	b.until = time.Now().Add(backoff)
+	d.patrolMu.Unlock()
+
+	d.logger.Printf("%s: %s; backing off %v", name, overrun, backoff)
+	d.escalate(name, fmt.Sprintf("patrol %s; backing off %v", overrun, backoff))
+}
+
+// patrolContext returns the context of the named patrol's current run, or
+// the daemon's context when it is not running under runPatrol. Patrols
+// derive their subprocess and query timeouts from it so that a budget
+// overrun cancels them.
+func (d *Daemon) patrolContext(name string) context.Context {
+	d.patrolMu.Lock()
+	defer d.patrolMu.Unlock()
+	if run := d.patrolRuns[name]; run != nil {
+		return run.ctx
+	}
+	if d.ctx != nil {
+		return d.ctx
+	}
+	return context.Background()
+}
+
+// patrolCommand is exec.CommandContext for patrol subprocesses. The command
+// runs in its own process group, so cancelling ctx kills everything it
+// spawned, and at the niceness of the patrol's budget.
+func patrolCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	var cmd *exec.Cmd
+	limits, _ := ctx.Value(patrolBudgetKey{}).(patrolBudgetLimits)
+	if limits.nice > 0 {
+		if nicePath, err := exec.LookPath("nice"); err == nil {
+			cmd = exec.CommandContext(ctx, nicePath, append([]string{"-n", strconv.Itoa(limits.nice), name}, args...)...)
+		}
+	}
+	if cmd == nil {
+		cmd = exec.CommandContext(ctx, name, args...)
+	}
+	util.SetProcessGroup(cmd)
+	cmd.WaitDelay = patrolCmdWaitDelay
+	return cmd
+}
@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPatrolBudget_Defaults(t *testing.T) {
+	limits := patrolBudget(nil, "compactor_dog")
+	if limits.maxRuntime != defaultPatrolMaxRuntime || limits.maxCPU != 0 || limits.nice != 0 {
+		t.Errorf("patrolBudget(nil) = %+v, want default runtime only", limits)
+	}
+
+	config := &DaemonPatrolConfig{Patrols: &PatrolsConfig{Budgets: map[string]*PatrolBudget{
+		"compactor_dog": {MaxRuntimeStr: "45m", MaxCPUStr: "10m", Nice: 10},
+		"doctor_dog":    {MaxRuntimeStr: "bogus", Nice: 40},
+	}}}
+	limits = patrolBudget(config, "compactor_dog")
+	if limits.maxRuntime != 45*time.Minute || limits.maxCPU != 10*time.Minute || limits.nice != 10 {
+		t.Errorf("patrolBudget(compactor_dog) = %+v", limits)
+	}
+	limits = patrolBudget(config, "doctor_dog")
+	if limits.maxRuntime != defaultPatrolMaxRuntime || limits.nice != 0 {
+		t.Errorf("invalid budget should fall back to defaults, got %+v", limits)
+	}
+}
+
+func TestRunPatrol_KillsOverrunAndBacksOff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process group kill is unix-only")
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	var logs bytes.Buffer
+	d := &Daemon{
+		config: &Config{TownRoot: t.TempDir()},
+		patrolConfig: &DaemonPatrolConfig{Patrols: &PatrolsConfig{Budgets: map[string]*PatrolBudget{
+			"test_patrol": {MaxRuntimeStr: "200ms", Nice: 5},
+		}}},
+		logger: log.New(&logs, "", 0),
+		ctx:    context.Background(),
+	}
+
+	runs := 0
+	start := time.Now()
+	d.runPatrol("test_patrol", func() {
+		runs++
+		_ = patrolCommand(d.patrolContext("test_patrol"), "sleep", "30").Run()
+	})
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("overrunning patrol was not killed (took %v)", elapsed)
+	}
+	if !strings.Contains(logs.String(), "exceeded its 200ms runtime budget") {
+		t.Errorf("expected overrun to be logged, got:\n%s", logs.String())
+	}
+
+	// Backed off: the next tick is skipped.
+	d.runPatrol("test_patrol", func() { runs++ })
+	if runs != 1 {
+		t.Errorf("patrol ran %d times, want 1 (second run should be backed off)", runs)
+	}
+	if b := d.patrolBackoffs["test_patrol"]; b == nil || b.overruns != 1 || time.Until(b.until) < patrolBackoffBase-time.Minute {
+		t.Errorf("backoff = %+v, want one overrun backed off ~%v", b, patrolBackoffBase)
+	}
+
+	// Once the backoff expires, a run within budget clears it.
+	d.patrolBackoffs["test_patrol"].until = time.Now().Add(-time.Second)
+	d.runPatrol("test_patrol", func() { runs++ })
+	if runs != 2 {
+		t.Errorf("patrol ran %d times, want 2 after backoff expired", runs)
+	}
+	if b := d.patrolBackoffs["test_patrol"]; b != nil {
+		t.Errorf("backoff = %+v, want cleared after a run within budget", b)
+	}
+	if ctx := d.patrolContext("test_patrol"); ctx.Err() != nil {
+		t.Errorf("patrolContext after run = %v, want the daemon context", ctx.Err())
+	}
+}
@@ -49,6 +49,10 @@ type CompactorDogConfig struct {
 	// picks during surgical rebase. Only used when Mode is "surgical".
 	// Defaults to 50 if not set.
 	KeepRecent int `json:"keep_recent,omitempty"`
+	// GCRetentionStr enables beads GC: before compacting, issues closed
+	// longer than this are tombstoned (see reaper.GC) so the flatten and
+	// dolt_gc that follow reclaim their bulk. Empty disables GC.
+	GCRetentionStr string `json:"gc_retention,omitempty"`
 }
 
 // compactorDogInterval returns the configured interval, or the default (24h).
@@ -93,6 +97,19 @@ func compactorDogKeepRecent(config *DaemonPatrolConfig) int {
 	return 50
 }
 
+// compactorDogGCRetention returns the configured GC retention, or 0 when GC
+// is disabled.
+func compactorDogGCRetention(config *DaemonPatrolConfig) time.Duration {
+	if config != nil && config.Patrols != nil && config.Patrols.CompactorDog != nil {
+		if config.Patrols.CompactorDog.GCRetentionStr != "" {
+			if d, err := time.ParseDuration(config.Patrols.CompactorDog.GCRetentionStr); err == nil && d > 0 {
+				return d
+			}
+		}
+	}
+	return 0
+}
+
 // runCompactorDog checks each production database's commit count and compacts
 // any that exceed the threshold. Two modes:
 //
@@ -104,6 +121,10 @@ func compactorDogKeepRecent(config *DaemonPatrolConfig) int {
 //
 // After successful compaction, runs dolt gc to reclaim unreferenced chunks.
 //
+// When gc_retention is set, long-closed issues are tombstoned first (see
+// compactorTombstone), so the compaction that follows drops their bulk
+// from history.
+//
 // ZFC Exemption: This dog executes imperatively in Go rather than via agent-driven
 // formula execution. The mol-dog-compactor formula is used for observability
 // tracking only (pourDogMolecule + closeStep/failStep). Agent execution is
@@ -139,7 +160,13 @@ func (d *Daemon) runCompactorDog() {
 	skipped := 0
 	errors := 0
 
+	gcRetention := compactorDogGCRetention(d.patrolConfig)
+
 	for _, dbName := range databases {
+		if gcRetention > 0 {
+			d.compactorTombstone(dbName, gcRetention)
+		}
+
 		commitCount, err := d.compactorCountCommits(dbName)
 		if err != nil {
 			d.logger.Printf("compactor_dog: %s: error counting commits: %v", dbName, err)
@@ -209,9 +236,33 @@ func (d *Daemon) compactorDatabases() []string {
 	return reaper.DefaultDatabases
 }
 
+// compactorTombstone runs beads GC on a database. Failures are logged and
+// never block compaction: GC only shrinks what compaction keeps.
+func (d *Daemon) compactorTombstone(dbName string, retention time.Duration) {
+	db, err := d.compactorOpenDB(dbName)
+	if err != nil {
+		d.logger.Printf("compactor_dog: %s: gc: %v", dbName, err)
+		return
+	}
+	defer db.Close()
+
+	result, err := reaper.GC(db, dbName, retention, false)
+	if err != nil {
+		d.logger.Printf("compactor_dog: %s: gc failed: %v", dbName, err)
+		return
+	}
+	for _, a := range result.Anomalies {
+		d.logger.Printf("compactor_dog: %s: gc anomaly: %s", dbName, a.Message)
+	}
+	if result.Tombstoned > 0 {
+		d.logger.Printf("compactor_dog: %s: gc tombstoned %d issues closed longer than %s (%d comments, %d bytes)",
+			dbName, result.Tombstoned, retention, result.CommentsDropped, result.BytesCompacted)
+	}
+}
+
 // compactorCountCommits counts the number of commits in the database's dolt_log.
 func (d *Daemon) compactorCountCommits(dbName string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), compactorQueryTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("compactor_dog"), compactorQueryTimeout)
 	defer cancel()
 
 	db, err := d.compactorOpenDB(dbName)
@@ -255,7 +306,7 @@ func (d *Daemon) compactDatabase(dbName string) error {
 	d.logger.Printf("compactor_dog: %s: root commit=%s", dbName, rootHash[:8])
 
 	// Step 3: USE database for session-scoped operations.
-	ctx, cancel := context.WithTimeout(context.Background(), compactorQueryTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("compactor_dog"), compactorQueryTimeout)
 	defer cancel()
 	if _, err := db.ExecContext(ctx, fmt.Sprintf("USE `%s`", dbName)); err != nil {
 		return fmt.Errorf("use database: %w", err)
@@ -376,7 +427,7 @@ func (d *Daemon) surgicalRebaseOnce(dbName string, keepRecent int) error {
 		return fmt.Errorf("find root commit: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(d.patrolContext("compactor_dog"), 10*time.Minute)
 	defer cancel()
 
 	if _, err := db.ExecContext(ctx, fmt.Sprintf("USE `%s`", dbName)); err != nil {
@@ -539,7 +590,7 @@ func (d *Daemon) compactorOpenDB(dbName string) (*sql.DB, error) {
 
 // compactorGetHead returns the current HEAD commit hash of the main branch.
 func (d *Daemon) compactorGetHead(db *sql.DB, dbName string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), compactorQueryTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("compactor_dog"), compactorQueryTimeout)
 	defer cancel()
 
 	var hash string
@@ -556,7 +607,7 @@ func (d *Daemon) compactorGetHead(db *sql.DB, dbName string) (string, error) {
 
 // compactorGetRootCommit returns the hash of the earliest commit in the database.
 func (d *Daemon) compactorGetRootCommit(db *sql.DB, dbName string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), compactorQueryTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("compactor_dog"), compactorQueryTimeout)
 	defer cancel()
 
 	var hash string
@@ -569,7 +620,7 @@ func (d *Daemon) compactorGetRootCommit(db *sql.DB, dbName string) (string, erro
 
 // compactorGetRowCounts returns a map of table -> row count for all user tables.
 func (d *Daemon) compactorGetRowCounts(db *sql.DB, dbName string) (map[string]int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), compactorQueryTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("compactor_dog"), compactorQueryTimeout)
 	defer cancel()
 
 	// Get list of user tables (excluding dolt system tables).
@@ -613,7 +664,7 @@ func (d *Daemon) compactorRunGC(dbName string) error {
 	}
 	defer db.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), compactorGCTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("compactor_dog"), compactorGCTimeout)
 	defer cancel()
 
 	start := time.Now()
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -24,6 +25,7 @@ import (
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/deacon"
 	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/egress"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/feed"
 	gitpkg "github.com/steveyegge/gastown/internal/git"
@@ -34,6 +36,7 @@ import (
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/townspec"
 	"github.com/steveyegge/gastown/internal/util"
 	"github.com/steveyegge/gastown/internal/wisp"
 	"github.com/steveyegge/gastown/internal/witness"
@@ -56,10 +59,27 @@ type Daemon struct {
 	doltServer *DoltServerManager
 	krcPruner  *KRCPruner
 
+	// egressProxy restricts agent egress to allowlisted hosts (nil when the
+	// town has no egress policy).
+	egressProxy *egress.Proxy
+
+	// metricsServer serves the rigs' refinery metrics (nil when the town
+	// doesn't enable metrics).
+	metricsServer *http.Server
+
+	// controlServer serves refinery control requests from gt refinery
+	// (see RefineryControl).
+	controlServer *http.Server
+
 	// Mass death detection: track recent session deaths
 	deathsMu     sync.Mutex
 	recentDeaths []sessionDeath
 
+	// hungReported maps polecat sessions reported hung to the heartbeat
+	// they went silent after, so each silence is reported once.
+	// Only accessed from heartbeat loop goroutine - no sync needed.
+	hungReported map[string]time.Time
+
 	// Deacon startup tracking: prevents race condition where newly started
 	// sessions are immediately killed by the heartbeat check.
 	// See: https://github.com/steveyegge/gastown/issues/567
@@ -99,6 +119,17 @@ type Daemon struct {
 	// lastMaintenanceRun tracks when scheduled maintenance last ran.
 	// Only accessed from heartbeat loop goroutine - no sync needed.
 	lastMaintenanceRun time.Time
+
+	// readinessStamps maps each rig to the state of its config files when
+	// its readiness was last checked (see checkRigReadiness).
+	// Only accessed from heartbeat loop goroutine - no sync needed.
+	readinessStamps map[string]string
+
+	// Patrol budgets: runs in progress and backoff after overruns (see runPatrol).
+	// Guarded by patrolMu: an abandoned run exits on its own goroutine.
+	patrolMu       sync.Mutex
+	patrolRuns     map[string]*patrolRun
+	patrolBackoffs map[string]*patrolBackoff
 }
 
 // sessionDeath records a detected session death for mass death analysis.
@@ -121,6 +152,13 @@ const (
 
 // New creates a new daemon instance.
 func New(config *Config) (*Daemon, error) {
+	// The daemon respawns, themes, and nudges agent sessions, all of which
+	// need tmux.
+	t, err := tmux.RequireTmux("the daemon")
+	if err != nil {
+		return nil, err
+	}
+
 	// Ensure daemon directory exists
 	daemonDir := filepath.Dir(config.LogFile)
 	if err := os.MkdirAll(daemonDir, 0755); err != nil {
@@ -147,7 +185,6 @@ func New(config *Config) (*Daemon, error) {
 	// Set GT_TOWN_ROOT in tmux global environment so run-shell subprocesses
 	// (e.g., gt cycle next/prev) can find the workspace even when CWD is $HOME.
 	// Non-fatal: tmux server may not be running yet — daemon creates sessions shortly.
-	t := tmux.NewTmux()
 	if err := t.SetGlobalEnvironment("GT_TOWN_ROOT", config.TownRoot); err != nil {
 		logger.Printf("Warning: failed to set GT_TOWN_ROOT in tmux global env: %v", err)
 	}
@@ -249,7 +286,7 @@ func New(config *Config) (*Daemon, error) {
 	return &Daemon{
 		config:         config,
 		patrolConfig:   patrolConfig,
-		tmux:           tmux.NewTmux(),
+		tmux:           t,
 		logger:         logger,
 		ctx:            ctx,
 		cancel:         cancel,
@@ -374,6 +411,16 @@ func (d *Daemon) Run() error {
 		}
 	}
 
+	// Start egress proxy if the town restricts agent egress
+	d.startEgressProxy()
+
+	// Serve refinery metrics if the town enables them
+	d.startMetricsServer()
+	d.startControlServer()
+
+	// Check each rig's config against its repository and machine
+	d.checkRigReadiness()
+
 	// Start dedicated Dolt health check ticker if Dolt server is configured.
 	// This runs at a much higher frequency (default 30s) than the general
 	// heartbeat (3 min) so Dolt crashes are detected quickly.
@@ -471,9 +518,36 @@ func (d *Daemon) Run() error {
 		scheduledMaintenanceChan = scheduledMaintenanceTicker.C
 		defer scheduledMaintenanceTicker.Stop()
 		window := maintenanceWindow(d.patrolConfig)
+		if loc, err := maintenanceLocation(d.patrolConfig); err == nil {
+			window += " " + loc.String()
+		}
 		d.logger.Printf("Scheduled maintenance ticker started (check interval %v, window %s)", interval, window)
 	}
 
+	// Start idle rigs ticker if configured.
+	// Parks or docks rigs with no commits, MRs or agent activity for days.
+	var idleRigsTicker *time.Ticker
+	var idleRigsChan <-chan time.Time
+	if IsPatrolEnabled(d.patrolConfig, "idle_rigs") {
+		interval := idleRigsInterval(d.patrolConfig)
+		idleRigsTicker = time.NewTicker(interval)
+		idleRigsChan = idleRigsTicker.C
+		defer idleRigsTicker.Stop()
+		d.logger.Printf("Idle rigs ticker started (interval %v, idle after %d days)", interval, IdleRigDays(d.patrolConfig))
+	}
+
+	// Start federation sync ticker if configured.
+	// Delivers forwards to peer towns and reflects their status locally.
+	var federationSyncTicker *time.Ticker
+	var federationSyncChan <-chan time.Time
+	if IsPatrolEnabled(d.patrolConfig, "federation_sync") {
+		interval := federationSyncInterval(d.patrolConfig)
+		federationSyncTicker = time.NewTicker(interval)
+		federationSyncChan = federationSyncTicker.C
+		defer federationSyncTicker.Stop()
+		d.logger.Printf("Federation sync ticker started (interval %v)", interval)
+	}
+
 	// Note: PATCH-010 uses per-session hooks in deacon/manager.go (SetAutoRespawnHook).
 	// Global pane-died hooks don't fire reliably in tmux 3.2a, so we rely on the
 	// per-session approach which has been tested to work for continuous recovery.
@@ -516,49 +590,63 @@ func (d *Daemon) Run() error {
 			// Periodic Dolt remote push — pushes databases to their configured
 			// git remotes on a 15-minute cadence (independent of heartbeat).
 			if !d.isShutdownInProgress() {
-				d.pushDoltRemotes()
+				d.runPatrol("dolt_remotes", d.pushDoltRemotes)
 			}
 
 		case <-doltBackupChan:
 			// Periodic Dolt filesystem backup — syncs production databases to
 			// local backup directory on a 15-minute cadence.
 			if !d.isShutdownInProgress() {
-				d.syncDoltBackups()
+				d.runPatrol("dolt_backup", d.syncDoltBackups)
 			}
 
 		case <-jsonlGitBackupChan:
 			// Periodic JSONL git backup — exports issues, scrubs ephemeral data,
 			// commits and pushes to git repo.
 			if !d.isShutdownInProgress() {
-				d.syncJsonlGitBackup()
+				d.runPatrol("jsonl_git_backup", d.syncJsonlGitBackup)
 			}
 
 		case <-wispReaperChan:
 			// Periodic wisp reaper — closes stale wisps (abandoned molecule steps,
 			// old patrol data) to prevent unbounded table growth (Clown Show audit).
 			if !d.isShutdownInProgress() {
-				d.reapWisps()
+				d.runPatrol("wisp_reaper", d.reapWisps)
 			}
 
 		case <-doctorDogChan:
 			// Doctor dog — comprehensive Dolt health monitor: connectivity, latency,
 			// gc, zombie detection, backup staleness, and disk usage checks.
 			if !d.isShutdownInProgress() {
-				d.runDoctorDog()
+				d.runPatrol("doctor_dog", d.runDoctorDog)
 			}
 
 		case <-compactorDogChan:
 			// Compactor dog — flattens Dolt commit history on production databases.
 			// Reclaims commit graph storage, then runs gc to reclaim chunks.
 			if !d.isShutdownInProgress() {
-				d.runCompactorDog()
+				d.runPatrol("compactor_dog", d.runCompactorDog)
 			}
 
 		case <-scheduledMaintenanceChan:
 			// Scheduled maintenance — checks if we're in the maintenance window
 			// and runs `gt maintain --force` when commit counts exceed threshold.
 			if !d.isShutdownInProgress() {
-				d.runScheduledMaintenance()
+				d.runPatrol("scheduled_maintenance", d.runScheduledMaintenance)
+			}
+
+		case <-idleRigsChan:
+			// Idle rigs — parks or docks rigs idle for the configured number
+			// of days, reclaiming their sessions.
+			if !d.isShutdownInProgress() {
+				d.runPatrol("idle_rigs", d.checkIdleRigs)
+			}
+
+		case <-federationSyncChan:
+			// Federation sync — delivers queued forwards and reflects
+			// peer towns' status onto local beads.
+			if !d.isShutdownInProgress() {
+				d.runPatrol("federation_sync", d.syncFederation)
 			}
 
 		case <-timer.C:
@@ -607,6 +695,16 @@ func (d *Daemon) heartbeat(state *State) {
 	// 0b. Kill ghost sessions left over from stale registry (default "gt" prefix).
 	d.killDefaultPrefixGhosts()
 
+	// 0c. Reconcile toward the declared town (gt apply), if one is stored.
+	// Runs before the agent checks so patrol changes apply this heartbeat.
+	d.reconcileTownSpec()
+
+	// 0d. Pick up new rigs and allowlist changes in the egress policy.
+	d.refreshEgressPolicy()
+
+	// 0e. Recheck the readiness of rigs whose config changed.
+	d.checkRigReadiness()
+
 	// 0. Ensure Dolt server is running (if configured)
 	// This must happen before beads operations that depend on Dolt.
 	d.ensureDoltServerRunning()
@@ -1555,6 +1653,16 @@ func (d *Daemon) shutdown(state *State) error { //nolint:unparam // error return
 		d.logger.Println("KRC pruner stopped")
 	}
 
+	// Stop egress proxy
+	if d.egressProxy != nil {
+		d.egressProxy.Stop()
+		d.logger.Println("Egress proxy stopped")
+	}
+
+	// Stop metrics server
+	d.stopControlServer()
+	d.stopMetricsServer()
+
 	// Push Dolt remotes before stopping the server (if patrol is enabled)
 	d.pushDoltRemotes()
 
@@ -1858,6 +1966,7 @@ func KillOrphanedDaemons(townRoot string) (int, error) {
 //
 // When a crash is detected, the polecat is automatically restarted.
 // This provides faster recovery than waiting for GUPP timeout or Witness detection.
+// Live sessions are judged by their heartbeats instead (checkPolecatLiveness).
 func (d *Daemon) checkPolecatSessionHealth() {
 	rigs := d.getKnownRigs()
 	for _, rigName := range rigs {
@@ -1914,7 +2023,8 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 	}
 
 	if sessionAlive {
-		// Session is alive - nothing to do
+		// Session is alive - check it is still making progress
+		d.checkPolecatLiveness(rigName, polecatName, sessionName)
 		return
 	}
 
@@ -2230,6 +2340,35 @@ func (d *Daemon) pruneStaleBranches() {
 	pruneInDir(d.config.TownRoot, "town-root")
 }
 
+// reconcileTownSpec shells out to `gt apply --reconcile` to converge the town
+// toward the declaration stored by `gt apply`. This avoids circular import
+// between the daemon and cmd packages. When the run changed anything, the
+// patrol config is reloaded since the declaration may have rewritten it.
+func (d *Daemon) reconcileTownSpec() {
+	if _, err := os.Stat(townspec.Path(d.config.TownRoot)); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gt", "apply", "--reconcile")
+	cmd.Dir = d.config.TownRoot
+	cmd.Env = append(os.Environ(), "GT_DAEMON=1")
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		d.logger.Printf("Town reconcile timed out after 5m")
+		return
+	}
+	if err != nil {
+		d.logger.Printf("Town reconcile failed: %v (output: %s)", err, string(out))
+	}
+	if len(out) > 0 {
+		d.logger.Printf("Town reconcile: %s", string(out))
+		if cfg := LoadPatrolConfig(d.config.TownRoot); cfg != nil {
+			d.patrolConfig = cfg
+		}
+	}
+}
+
 // dispatchQueuedWork shells out to `gt scheduler run` to dispatch scheduled beads.
 // This avoids circular import between the daemon and cmd packages.
 // Uses a 5m timeout to allow multi-bead dispatch with formula cooking and hook retries.
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -101,11 +100,11 @@ func (d *Daemon) syncDoltBackups() {
 
 // syncBackup runs `dolt backup sync <backup-name>` for a single database.
 func (d *Daemon) syncBackup(dataDir, db, backupName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), doltBackupTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("dolt_backup"), doltBackupTimeout)
 	defer cancel()
 
 	dbDir := dataDir + "/" + db
-	cmd := exec.CommandContext(ctx, "dolt", "backup", "sync", backupName)
+	cmd := patrolCommand(ctx, "dolt", "backup", "sync", backupName)
 	cmd.Dir = dbDir
 
 	output, err := cmd.CombinedOutput()
@@ -137,10 +136,10 @@ func (d *Daemon) syncOffsiteBackup() {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(d.patrolContext("dolt_backup"), 60*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "rsync", "-a", "--delete", backupDir+"/", icloudDir+"/")
+	cmd := patrolCommand(ctx, "rsync", "-a", "--delete", backupDir+"/", icloudDir+"/")
 	if output, err := cmd.CombinedOutput(); err != nil {
 		d.logger.Printf("dolt_backup: offsite sync failed: %v (%s)", err, strings.TrimSpace(string(output)))
 	} else {
@@ -178,11 +177,11 @@ func (d *Daemon) discoverDatabasesWithBackups(dataDir string) []string {
 
 // hasBackupRemote checks if a database has the specified backup remote configured.
 func (d *Daemon) hasBackupRemote(dataDir, db, backupName string) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(d.patrolContext("dolt_backup"), 10*time.Second)
 	defer cancel()
 
 	dbDir := dataDir + "/" + db
-	cmd := exec.CommandContext(ctx, "dolt", "backup")
+	cmd := patrolCommand(ctx, "dolt", "backup")
 	cmd.Dir = dbDir
 
 	output, err := cmd.Output()
@@ -5,7 +5,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -142,10 +141,10 @@ func (d *Daemon) pushDatabase(dataDir, db, remote, branch string) error {
 
 // runDoltSQL executes a SQL query against the Dolt data directory.
 func (d *Daemon) runDoltSQL(dataDir, query string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), doltPushTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("dolt_remotes"), doltPushTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "dolt", "sql", "-q", query)
+	cmd := patrolCommand(ctx, "dolt", "sql", "-q", query)
 	cmd.Dir = dataDir
 
 	var stderr bytes.Buffer
@@ -196,11 +195,11 @@ func (d *Daemon) discoverDatabasesWithRemotes(dataDir, remote string) ([]string,
 
 // databaseHasRemote checks if a database has the specified remote configured.
 func (d *Daemon) databaseHasRemote(dataDir, db, remote string) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), doltCmdTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("dolt_remotes"), doltCmdTimeout)
 	defer cancel()
 
 	query := fmt.Sprintf("USE `%s`; SELECT name FROM dolt_remotes WHERE name = '%s'", db, remote)
-	cmd := exec.CommandContext(ctx, "dolt", "sql", "-r", "csv", "-q", query)
+	cmd := patrolCommand(ctx, "dolt", "sql", "-r", "csv", "-q", query)
 	cmd.Dir = dataDir
 
 	output, err := cmd.Output()
@@ -215,11 +214,11 @@ func (d *Daemon) databaseHasRemote(dataDir, db, remote string) bool {
 
 // databaseHasAnyRemote checks if a database has any remote configured.
 func (d *Daemon) databaseHasAnyRemote(dataDir, db string) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), doltCmdTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("dolt_remotes"), doltCmdTimeout)
 	defer cancel()
 
 	query := fmt.Sprintf("USE `%s`; SELECT name FROM dolt_remotes LIMIT 1", db)
-	cmd := exec.CommandContext(ctx, "dolt", "sql", "-r", "csv", "-q", query)
+	cmd := patrolCommand(ctx, "dolt", "sql", "-r", "csv", "-q", query)
 	cmd.Dir = dataDir
 
 	output, err := cmd.Output()
@@ -234,11 +233,11 @@ func (d *Daemon) databaseHasAnyRemote(dataDir, db string) bool {
 // findDatabaseRemote returns the name of the first remote configured for a database.
 // Returns empty string if no remote is found.
 func (d *Daemon) findDatabaseRemote(dataDir, db string) string {
-	ctx, cancel := context.WithTimeout(context.Background(), doltCmdTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("dolt_remotes"), doltCmdTimeout)
 	defer cancel()
 
 	query := fmt.Sprintf("USE `%s`; SELECT name FROM dolt_remotes LIMIT 1", db)
-	cmd := exec.CommandContext(ctx, "dolt", "sql", "-r", "csv", "-q", query)
+	cmd := patrolCommand(ctx, "dolt", "sql", "-r", "csv", "-q", query)
 	cmd.Dir = dataDir
 
 	output, err := cmd.Output()
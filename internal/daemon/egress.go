@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"sort"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/egress"
+)
+
+// egressPolicyHosts returns the hosts agents may reach under the town's
+// egress policy: the configured allowlist plus the git remotes (fetch, push
+// and upstream) of every registered rig.
+func (d *Daemon) egressPolicyHosts(cfg *config.EgressConfig) []string {
+	hosts := cfg.AllowedHosts()
+	rigs, err := d.loadRigsConfig()
+	if err != nil {
+		return hosts
+	}
+	seen := make(map[string]bool)
+	var remotes []string
+	for _, entry := range rigs.Rigs {
+		for _, u := range []string{entry.GitURL, entry.PushURL, entry.UpstreamURL} {
+			if h := egress.RemoteHost(u); h != "" && !seen[h] {
+				seen[h] = true
+				remotes = append(remotes, h)
+			}
+		}
+	}
+	sort.Strings(remotes)
+	return append(hosts, remotes...)
+}
+
+// startEgressProxy starts the egress proxy when the town settings enable an
+// egress policy. Sessions of the covered roles are pointed at it by
+// config.AgentEnv; while it is down their HTTP(S) egress fails closed.
+func (d *Daemon) startEgressProxy() {
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot))
+	if err != nil {
+		d.logger.Printf("Warning: egress: loading town settings: %v", err)
+		return
+	}
+	cfg := settings.Egress
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+	proxy := egress.NewProxy(cfg.ListenAddr(), egress.NewPolicy(d.egressPolicyHosts(cfg)), d.logger.Printf)
+	if err := proxy.Start(); err != nil {
+		d.logger.Printf("Warning: failed to start egress proxy: %v", err)
+		return
+	}
+	d.egressProxy = proxy
+	d.logger.Printf("Egress proxy started on %s", proxy.Addr())
+}
+
+// refreshEgressPolicy reloads the egress allowlist, so rigs added or hosts
+// allowed since the daemon started are reachable without a restart.
+func (d *Daemon) refreshEgressPolicy() {
+	if d.egressProxy == nil {
+		return
+	}
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot))
+	if err != nil || settings.Egress == nil {
+		return
+	}
+	d.egressProxy.SetPolicy(egress.NewPolicy(d.egressPolicyHosts(settings.Egress)))
+}
@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultFederationSyncInterval is the federation_sync patrol interval.
+// Delivery to an offline peer backs off on its own, so a short interval
+// only costs a status poll per forward.
+const defaultFederationSyncInterval = 2 * time.Minute
+
+// FederationSyncConfig holds configuration for the federation_sync patrol,
+// which runs gt federation sync to deliver forwards queued for peer towns
+// and reflect their status locally.
+type FederationSyncConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// IntervalStr is how often to sync, as a string (e.g., "2m").
+	IntervalStr string `json:"interval,omitempty"`
+}
+
+// federationSyncInterval returns the configured interval, or the default (2m).
+func federationSyncInterval(config *DaemonPatrolConfig) time.Duration {
+	if config != nil && config.Patrols != nil && config.Patrols.FederationSync != nil {
+		if config.Patrols.FederationSync.IntervalStr != "" {
+			if d, err := time.ParseDuration(config.Patrols.FederationSync.IntervalStr); err == nil && d > 0 {
+				return d
+			}
+		}
+	}
+	return defaultFederationSyncInterval
+}
+
+// syncFederation is the federation_sync patrol.
+func (d *Daemon) syncFederation() {
+	if !IsPatrolEnabled(d.patrolConfig, "federation_sync") {
+		return
+	}
+	cmd := patrolCommand(d.patrolContext("federation_sync"), d.gtPath, "federation", "sync")
+	cmd.Dir = d.config.TownRoot
+	if out, err := cmd.CombinedOutput(); err != nil {
+		d.logger.Printf("federation_sync: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+}
@@ -0,0 +1,153 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/activity"
+)
+
+// defaultIdleRigsInterval is the idle_rigs patrol interval. Idleness is
+// measured in days, so a few checks a day are plenty.
+const defaultIdleRigsInterval = 6 * time.Hour
+
+// Idle rig actions.
+const (
+	IdleRigActionPark = "park" // Pause: stop witness and refinery, local to this town
+	IdleRigActionDock = "dock" // Archive: stop all agents, persistent across clones
+)
+
+// IdleRigsConfig holds configuration for the idle_rigs patrol, which parks
+// or docks rigs that have seen no commits, MRs or agent activity for
+// IdleDays, reclaiming their sessions.
+type IdleRigsConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// IntervalStr is how often to check, as a string (e.g., "6h").
+	IntervalStr string `json:"interval,omitempty"`
+
+	// IdleDays is how many days without activity make a rig idle.
+	// Default: activity.DefaultIdleDays.
+	IdleDays int `json:"idle_days,omitempty"`
+
+	// Action is "park" (default) or "dock".
+	Action string `json:"action,omitempty"`
+
+	// Prune also removes the idle rig's stale polecat worktrees (those with
+	// no session and no uncommitted work) to reclaim disk.
+	Prune bool `json:"prune,omitempty"`
+
+	// Exclude lists rigs never parked or docked for idleness.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// idleRigsInterval returns the configured interval, or the default (6h).
+func idleRigsInterval(config *DaemonPatrolConfig) time.Duration {
+	if config != nil && config.Patrols != nil && config.Patrols.IdleRigs != nil {
+		if config.Patrols.IdleRigs.IntervalStr != "" {
+			if d, err := time.ParseDuration(config.Patrols.IdleRigs.IntervalStr); err == nil && d > 0 {
+				return d
+			}
+		}
+	}
+	return defaultIdleRigsInterval
+}
+
+// IdleRigDays returns the configured idle threshold in days, or
+// activity.DefaultIdleDays.
+func IdleRigDays(config *DaemonPatrolConfig) int {
+	if config != nil && config.Patrols != nil && config.Patrols.IdleRigs != nil {
+		if config.Patrols.IdleRigs.IdleDays > 0 {
+			return config.Patrols.IdleRigs.IdleDays
+		}
+	}
+	return activity.DefaultIdleDays
+}
+
+// idleRigAction returns the configured action, or park.
+func idleRigAction(config *DaemonPatrolConfig) string {
+	if config != nil && config.Patrols != nil && config.Patrols.IdleRigs != nil {
+		if config.Patrols.IdleRigs.Action == IdleRigActionDock {
+			return IdleRigActionDock
+		}
+	}
+	return IdleRigActionPark
+}
+
+// checkIdleRigs is the idle_rigs patrol: it parks or docks operational rigs
+// that have been idle for the configured number of days.
+func (d *Daemon) checkIdleRigs() {
+	if !IsPatrolEnabled(d.patrolConfig, "idle_rigs") {
+		return
+	}
+	config := d.patrolConfig.Patrols.IdleRigs
+	idleDays := IdleRigDays(d.patrolConfig)
+	action := idleRigAction(d.patrolConfig)
+
+	excluded := make(map[string]bool, len(config.Exclude))
+	for _, name := range config.Exclude {
+		excluded[name] = true
+	}
+	rigPaths := make(map[string]string)
+	for _, name := range d.getKnownRigs() {
+		if excluded[name] {
+			continue
+		}
+		if ok, _ := d.isRigOperational(name); ok {
+			rigPaths[name] = filepath.Join(d.config.TownRoot, name)
+		}
+	}
+	if len(rigPaths) == 0 {
+		return
+	}
+
+	now := time.Now()
+	usage := activity.CollectUsage(d.config.TownRoot, rigPaths, idleDays, now)
+	var acted []string
+	for name, u := range usage {
+		if !u.Idle(now, idleDays) {
+			continue
+		}
+		if err := d.retireIdleRig(name, action, config.Prune); err != nil {
+			d.logger.Printf("idle_rigs: %s %s: %v", action, name, err)
+			continue
+		}
+		d.logger.Printf("idle_rigs: %sed %s (%s)", action, name, idleSince(u, now))
+		acted = append(acted, name)
+	}
+	if len(acted) > 0 {
+		sort.Strings(acted)
+		d.escalate("idle_rigs", fmt.Sprintf("%sed rigs idle for %d+ days: %s (gt rig un%s <rig> to resume)",
+			action, idleDays, strings.Join(acted, ", "), action))
+	}
+}
+
+// retireIdleRig parks or docks a rig through the gt CLI, which stops its
+// agents, then optionally prunes its stale polecats.
+func (d *Daemon) retireIdleRig(name, action string, prune bool) error {
+	ctx := d.patrolContext("idle_rigs")
+	cmd := patrolCommand(ctx, d.gtPath, "rig", action, name)
+	cmd.Dir = d.config.TownRoot
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	if prune {
+		cmd := patrolCommand(ctx, d.gtPath, "polecat", "stale", name, "--cleanup")
+		cmd.Dir = d.config.TownRoot
+		if out, err := cmd.CombinedOutput(); err != nil {
+			d.logger.Printf("idle_rigs: pruning %s: %v: %s", name, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// idleSince describes how long a rig has been idle.
+func idleSince(u *activity.RigUsage, now time.Time) string {
+	if days := u.IdleDays(now); days >= 0 {
+		return fmt.Sprintf("idle %d days", days)
+	}
+	return "no recorded activity"
+}
@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/activity"
+)
+
+func TestIdleRigsConfig(t *testing.T) {
+	if IsPatrolEnabled(nil, "idle_rigs") {
+		t.Error("idle_rigs should be opt-in")
+	}
+	if got := IdleRigDays(nil); got != activity.DefaultIdleDays {
+		t.Errorf("default IdleRigDays = %d, want %d", got, activity.DefaultIdleDays)
+	}
+	if got := idleRigAction(nil); got != IdleRigActionPark {
+		t.Errorf("default action = %q, want park", got)
+	}
+	if got := idleRigsInterval(nil); got != defaultIdleRigsInterval {
+		t.Errorf("default interval = %v, want %v", got, defaultIdleRigsInterval)
+	}
+
+	config := &DaemonPatrolConfig{Patrols: &PatrolsConfig{IdleRigs: &IdleRigsConfig{
+		Enabled:     true,
+		IntervalStr: "1h",
+		IdleDays:    30,
+		Action:      "dock",
+	}}}
+	if !IsPatrolEnabled(config, "idle_rigs") {
+		t.Error("idle_rigs should be enabled")
+	}
+	if got := IdleRigDays(config); got != 30 {
+		t.Errorf("IdleRigDays = %d, want 30", got)
+	}
+	if got := idleRigAction(config); got != IdleRigActionDock {
+		t.Errorf("action = %q, want dock", got)
+	}
+	if got := idleRigsInterval(config); got != time.Hour {
+		t.Errorf("interval = %v, want 1h", got)
+	}
+
+	config.Patrols.IdleRigs.Action = "delete"
+	if got := idleRigAction(config); got != IdleRigActionPark {
+		t.Errorf("unknown action should fall back to park, got %q", got)
+	}
+}
@@ -269,10 +269,10 @@ func (d *Daemon) exportDatabaseToJsonl(db, gitRepo, dataDir string, scrub bool)
 // exportTableToJsonl runs a query and writes the result as JSONL to {dir}/{table}.jsonl.
 // Returns the number of records exported.
 func (d *Daemon) exportTableToJsonl(table, query, dir, dataDir string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), jsonlExportTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("jsonl_git_backup"), jsonlExportTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "dolt", "sql", "-r", "json", "-q", query)
+	cmd := patrolCommand(ctx, "dolt", "sql", "-r", "json", "-q", query)
 	cmd.Dir = dataDir
 
 	var stdout, stderr bytes.Buffer
@@ -376,19 +376,19 @@ func (d *Daemon) commitAndPushJsonlBackup(gitRepo string, databases []string, co
 
 // hasGitRemote checks if the named remote exists in the git repo.
 func (d *Daemon) hasGitRemote(gitRepo, name string) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), gitCmdTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("jsonl_git_backup"), gitCmdTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "git", "-C", gitRepo, "remote", "get-url", name)
+	cmd := patrolCommand(ctx, "git", "-C", gitRepo, "remote", "get-url", name)
 	return cmd.Run() == nil
 }
 
 // currentGitBranch returns the current branch name, or empty string on error.
 func (d *Daemon) currentGitBranch(gitRepo string) string {
-	ctx, cancel := context.WithTimeout(context.Background(), gitCmdTimeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("jsonl_git_backup"), gitCmdTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "git", "-C", gitRepo, "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := patrolCommand(ctx, "git", "-C", gitRepo, "rev-parse", "--abbrev-ref", "HEAD")
 	var stdout bytes.Buffer
 	cmd.Stdout = &stdout
 	if err := cmd.Run(); err != nil {
@@ -399,10 +399,10 @@ func (d *Daemon) currentGitBranch(gitRepo string) string {
 
 // runGitCmd runs a git command in the specified directory with the given timeout.
 func (d *Daemon) runGitCmd(dir string, timeout time.Duration, args ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(d.patrolContext("jsonl_git_backup"), timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd := patrolCommand(ctx, "git", append([]string{"-C", dir}, args...)...)
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"syscall"
+	"time"
 )
 
 // setSysProcAttr sets platform-specific process attributes.
@@ -30,3 +31,13 @@ func sendTermSignal(p *os.Process) error {
 func sendKillSignal(p *os.Process) error {
 	return p.Signal(syscall.SIGKILL)
 }
+
+// childCPUTime returns the user+system CPU time used by terminated, waited-for
+// child processes of the daemon.
+func childCPUTime() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_CHILDREN, &ru); err != nil {
+		return 0
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
@@ -5,6 +5,7 @@ package daemon
 import (
 	"os"
 	"os/exec"
+	"time"
 )
 
 // setSysProcAttr sets platform-specific process attributes.
@@ -36,3 +37,9 @@ func sendTermSignal(p *os.Process) error {
 func sendKillSignal(p *os.Process) error {
 	return p.Kill()
 }
+
+// childCPUTime returns 0: child CPU accounting is not available on Windows,
+// so patrol CPU budgets are not enforced.
+func childCPUTime() time.Duration {
+	return 0
+}
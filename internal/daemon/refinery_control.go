@@ -0,0 +1,285 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// ErrDaemonUnreachable is returned by RefineryControl calls when no daemon
+// is serving the town's control socket.
+var ErrDaemonUnreachable = errors.New("daemon not reachable")
+
+// RefineryControlSocket returns the Unix socket the daemon serves refinery
+// control requests on.
+func RefineryControlSocket(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "refinery.sock")
+}
+
+// startControlServer serves refinery control requests (see
+// RefineryControl) on the town's control socket, which only the daemon's
+// user can connect to.
+func (d *Daemon) startControlServer() {
+	path := RefineryControlSocket(d.config.TownRoot)
+	_ = os.Remove(path) // Left behind by a daemon that didn't stop cleanly
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		d.logger.Printf("Warning: failed to start refinery control server: %v", err)
+		return
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		d.logger.Printf("Warning: refinery control socket permissions: %v", err)
+	}
+	d.controlServer = &http.Server{Handler: d.controlHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := d.controlServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.logger.Printf("Warning: refinery control server: %v", err)
+		}
+	}()
+	d.logger.Printf("Refinery control server started on %s", path)
+}
+
+// stopControlServer stops the control server, if running.
+func (d *Daemon) stopControlServer() {
+	if d.controlServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = d.controlServer.Shutdown(ctx)
+	d.controlServer = nil
+	_ = os.Remove(RefineryControlSocket(d.config.TownRoot))
+}
+
+// controlRequest is the body of pause and eject requests.
+type controlRequest struct {
+	By     string `json:"by,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// controlError is the body of a failed control request.
+type controlError struct {
+	Error string `json:"error"`
+}
+
+func (d *Daemon) controlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rigs/{rig}/status", d.withRigEngineer(func(w http.ResponseWriter, _ *http.Request, eng *refinery.Engineer, _ *rig.Rig) {
+		status, err := eng.QueueStatus()
+		writeControlResult(w, status, err)
+	}))
+	mux.HandleFunc("POST /rigs/{rig}/pause", d.withRigEngineer(func(w http.ResponseWriter, r *http.Request, eng *refinery.Engineer, _ *rig.Rig) {
+		var req controlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeControlError(w, http.StatusBadRequest, err)
+			return
+		}
+		p, err := eng.Pause(req.By, req.Reason)
+		writeControlResult(w, p, err)
+	}))
+	mux.HandleFunc("POST /rigs/{rig}/resume", d.withRigEngineer(func(w http.ResponseWriter, _ *http.Request, eng *refinery.Engineer, _ *rig.Rig) {
+		p, err := eng.Resume()
+		if errors.Is(err, refinery.ErrNotPaused) {
+			writeControlError(w, http.StatusConflict, err)
+			return
+		}
+		writeControlResult(w, p, err)
+	}))
+	mux.HandleFunc("POST /rigs/{rig}/mrs/{mr}/retry", d.withRigEngineer(func(w http.ResponseWriter, r *http.Request, eng *refinery.Engineer, _ *rig.Rig) {
+		writeControlResult(w, struct{}{}, eng.Requeue(r.PathValue("mr")))
+	}))
+	mux.HandleFunc("POST /rigs/{rig}/mrs/{mr}/eject", d.withRigEngineer(func(w http.ResponseWriter, r *http.Request, _ *refinery.Engineer, rg *rig.Rig) {
+		var req controlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeControlError(w, http.StatusBadRequest, err)
+			return
+		}
+		_, err := EjectMR(rg, r.PathValue("mr"), req.Reason)
+		if errors.Is(err, refinery.ErrMRNotFound) {
+			writeControlError(w, http.StatusNotFound, err)
+			return
+		}
+		writeControlResult(w, struct{}{}, err)
+	}))
+	return mux
+}
+
+// withRigEngineer resolves the request's rig against the town's rig
+// registry and passes handler an engineer for it.
+func (d *Daemon) withRigEngineer(handler func(http.ResponseWriter, *http.Request, *refinery.Engineer, *rig.Rig)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("rig")
+		rigs, err := d.loadRigsConfig()
+		if err != nil {
+			writeControlError(w, http.StatusInternalServerError, fmt.Errorf("loading rigs: %w", err))
+			return
+		}
+		if _, ok := rigs.Rigs[name]; !ok {
+			writeControlError(w, http.StatusNotFound, fmt.Errorf("rig %q not found", name))
+			return
+		}
+		rg := &rig.Rig{Name: name, Path: filepath.Join(d.config.TownRoot, name)}
+		eng := refinery.NewEngineer(rg)
+		eng.SetOutput(io.Discard)
+		if err := eng.LoadConfig(); err != nil {
+			writeControlError(w, http.StatusInternalServerError, fmt.Errorf("loading merge queue config: %w", err))
+			return
+		}
+		handler(w, r, eng, rg)
+	}
+}
+
+func writeControlResult(w http.ResponseWriter, v any, err error) {
+	if err != nil {
+		writeControlError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeControlError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(controlError{Error: err.Error()})
+}
+
+// EjectMR takes an MR out of rg's merge queue for good: it is rejected
+// with reason and its worker notified (see refinery.Manager.RejectMR).
+func EjectMR(rg *rig.Rig, mrID, reason string) (*refinery.MergeRequest, error) {
+	if reason == "" {
+		reason = "ejected from the merge queue"
+	}
+	mgr := refinery.NewManager(rg)
+	mgr.SetOutput(io.Discard)
+	return mgr.RejectMR(mrID, reason, true)
+}
+
+// RefineryControl is a client for the daemon's refinery control server, to
+// inspect and steer a rig's merge queue (see gt refinery). Every call
+// returns an error wrapping ErrDaemonUnreachable when the daemon isn't
+// running.
+type RefineryControl struct {
+	client *http.Client
+}
+
+// NewRefineryControl returns a client for the daemon of the town at
+// townRoot.
+func NewRefineryControl(townRoot string) *RefineryControl {
+	socket := RefineryControlSocket(townRoot)
+	return &RefineryControl{client: &http.Client{
+		Timeout: time.Minute,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}}
+}
+
+// Status returns the rig's merge queue status.
+func (c *RefineryControl) Status(rigName string) (*refinery.QueueStatus, error) {
+	var status refinery.QueueStatus
+	if err := c.call(http.MethodGet, rigPath(rigName, "status"), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Pause pauses the rig's merge queue (see refinery.Engineer.Pause).
+func (c *RefineryControl) Pause(rigName, by, reason string) (*refinery.PauseState, error) {
+	var p refinery.PauseState
+	if err := c.call(http.MethodPost, rigPath(rigName, "pause"), &controlRequest{By: by, Reason: reason}, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Resume lifts the rig's merge queue pause, returning the pause lifted. It
+// returns refinery.ErrNotPaused if the queue isn't paused.
+func (c *RefineryControl) Resume(rigName string) (*refinery.PauseState, error) {
+	var p refinery.PauseState
+	err := c.call(http.MethodPost, rigPath(rigName, "resume"), nil, &p)
+	var se *controlStatusError
+	if errors.As(err, &se) && se.code == http.StatusConflict {
+		return nil, refinery.ErrNotPaused
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Retry returns a claimed MR to the queue (see refinery.Engineer.Requeue).
+func (c *RefineryControl) Retry(rigName, mrID string) error {
+	return c.call(http.MethodPost, rigPath(rigName, "mrs", mrID, "retry"), nil, nil)
+}
+
+// Eject takes an MR out of the queue for good (see EjectMR).
+func (c *RefineryControl) Eject(rigName, mrID, reason string) error {
+	return c.call(http.MethodPost, rigPath(rigName, "mrs", mrID, "eject"), &controlRequest{Reason: reason}, nil)
+}
+
+// controlStatusError is a request the control server failed.
+type controlStatusError struct {
+	code int
+	msg  string
+}
+
+func (e *controlStatusError) Error() string { return e.msg }
+
+func rigPath(rigName string, elems ...string) string {
+	p := "/rigs/" + url.PathEscape(rigName)
+	for _, e := range elems {
+		p += "/" + url.PathEscape(e)
+	}
+	return p
+}
+
+func (c *RefineryControl) call(method, path string, body, out any) error {
+	var in io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		in = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, "http://daemon"+path, in)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return fmt.Errorf("%w: %v", ErrDaemonUnreachable, err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var ce controlError
+		if json.NewDecoder(resp.Body).Decode(&ce) != nil || ce.Error == "" {
+			ce.Error = resp.Status
+		}
+		return &controlStatusError{code: resp.StatusCode, msg: ce.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
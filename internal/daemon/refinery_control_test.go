@@ -0,0 +1,82 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/refinery"
+)
+
+func testControlDaemon(t *testing.T) (*Daemon, *RefineryControl) {
+	t.Helper()
+	d, _ := testDaemonWithTown(t, "town")
+	townRoot := d.config.TownRoot
+	for _, dir := range []string{"daemon", "gastown"} {
+		if err := os.MkdirAll(filepath.Join(townRoot, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rigs := &config.RigsConfig{Version: 1, Rigs: map[string]config.RigEntry{"gastown": {}}}
+	if err := config.SaveRigsConfig(filepath.Join(townRoot, "mayor", "rigs.json"), rigs); err != nil {
+		t.Fatal(err)
+	}
+	d.startControlServer()
+	if d.controlServer == nil {
+		t.Fatal("control server did not start")
+	}
+	t.Cleanup(d.stopControlServer)
+	return d, NewRefineryControl(townRoot)
+}
+
+func TestRefineryControl_PauseResume(t *testing.T) {
+	_, ctl := testControlDaemon(t)
+
+	if _, err := ctl.Resume("gastown"); !errors.Is(err, refinery.ErrNotPaused) {
+		t.Fatalf("Resume() of an unpaused queue = %v, want ErrNotPaused", err)
+	}
+	p, err := ctl.Pause("gastown", "ops", "incident")
+	if err != nil {
+		t.Fatalf("Pause() error: %v", err)
+	}
+	if p.By != "ops" || p.Reason != "incident" {
+		t.Errorf("Pause() = %+v, want by ops for incident", p)
+	}
+
+	status, err := ctl.Status("gastown")
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if status.Rig != "gastown" || status.Paused == nil || status.Paused.Reason != "incident" {
+		t.Errorf("Status() = %+v, want gastown paused for incident", status)
+	}
+
+	if _, err := ctl.Resume("gastown"); err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	status, err = ctl.Status("gastown")
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if status.Paused != nil {
+		t.Errorf("Status().Paused = %+v after Resume, want nil", status.Paused)
+	}
+}
+
+func TestRefineryControl_UnknownRig(t *testing.T) {
+	_, ctl := testControlDaemon(t)
+
+	if _, err := ctl.Status("nope"); err == nil || errors.Is(err, ErrDaemonUnreachable) {
+		t.Errorf("Status() of an unknown rig = %v, want a not found error", err)
+	}
+}
+
+func TestRefineryControl_DaemonNotRunning(t *testing.T) {
+	ctl := NewRefineryControl(t.TempDir())
+
+	if _, err := ctl.Status("gastown"); !errors.Is(err, ErrDaemonUnreachable) {
+		t.Errorf("Status() without a daemon = %v, want ErrDaemonUnreachable", err)
+	}
+}
@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/refinery"
+)
+
+// startMetricsServer serves the rigs' refinery metrics at /metrics when
+// the town settings enable it. Each scrape reads every registered rig's
+// persisted metrics (see refinery.Metrics), so rigs added since the daemon
+// started are picked up without a restart.
+func (d *Daemon) startMetricsServer() {
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot))
+	if err != nil {
+		d.logger.Printf("Warning: metrics: loading town settings: %v", err)
+		return
+	}
+	cfg := settings.Metrics
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+	ln, err := net.Listen("tcp", cfg.ListenAddr())
+	if err != nil {
+		d.logger.Printf("Warning: failed to start metrics server: %v", err)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", d.serveMetrics)
+	d.metricsServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := d.metricsServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.logger.Printf("Warning: metrics server: %v", err)
+		}
+	}()
+	d.logger.Printf("Metrics server started on %s", ln.Addr())
+}
+
+// stopMetricsServer stops the metrics server, if running.
+func (d *Daemon) stopMetricsServer() {
+	if d.metricsServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = d.metricsServer.Shutdown(ctx)
+	d.metricsServer = nil
+}
+
+// serveMetrics writes the refinery metrics of every registered rig. A rig
+// whose metrics can't be read is logged and left out rather than failing
+// the scrape.
+func (d *Daemon) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	rigs, err := d.loadRigsConfig()
+	if err != nil {
+		http.Error(w, "loading rigs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	names := make([]string, 0, len(rigs.Rigs))
+	for name := range rigs.Rigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	registries := make([]*refinery.Metrics, 0, len(names))
+	for _, name := range names {
+		m, err := refinery.LoadMetrics(filepath.Join(d.config.TownRoot, name))
+		if err != nil {
+			d.logger.Printf("Warning: metrics: rig %s: %v", name, err)
+			continue
+		}
+		registries = append(registries, m)
+	}
+	var buf bytes.Buffer
+	if err := refinery.WritePrometheus(&buf, registries...); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/readiness"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// readinessStamp identifies the current state of the config files a rig's
+// readiness depends on, by size and modification time.
+func (d *Daemon) readinessStamp(rigName string) string {
+	rigPath := filepath.Join(d.config.TownRoot, rigName)
+	paths := []string{
+		filepath.Join(d.config.TownRoot, "mayor", "rigs.json"),
+		config.TownSettingsPath(d.config.TownRoot),
+		filepath.Join(rigPath, "config.json"),
+		filepath.Join(rigPath, "refinery.json"),
+		filepath.Join(rigPath, "refinery.toml"),
+		config.RigSettingsPath(rigPath),
+	}
+	var stamp string
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err == nil {
+			stamp += fmt.Sprintf("%s:%d:%d;", p, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return stamp
+}
+
+// checkRigReadiness validates every known rig's config against its
+// repository and machine (see package readiness) at startup and again
+// whenever the rig's config files change, logging rigs that aren't ready
+// and storing the reports for gt rig ready.
+func (d *Daemon) checkRigReadiness() {
+	rigs := d.getKnownRigs()
+	sort.Strings(rigs)
+	if d.readinessStamps == nil {
+		d.readinessStamps = make(map[string]string)
+	}
+
+	reports, err := readiness.Load(d.config.TownRoot)
+	if err != nil {
+		d.logger.Printf("Warning: rig readiness: %v", err)
+		reports = make(map[string]*readiness.Report)
+	}
+	known := make(map[string]bool, len(rigs))
+	changed := false
+	for _, name := range rigs {
+		known[name] = true
+		stamp := d.readinessStamp(name)
+		if stamp == d.readinessStamps[name] {
+			continue
+		}
+		d.readinessStamps[name] = stamp
+		report := readiness.CheckRig(d.config.TownRoot, &rig.Rig{Name: name, Path: filepath.Join(d.config.TownRoot, name)})
+		reports[name] = report
+		changed = true
+		if report.Ready {
+			d.logger.Printf("Rig %s ready", name)
+		} else {
+			d.logger.Printf("Warning: rig %s not ready: %s", name, strings.Join(report.Problems(), "; "))
+		}
+	}
+	for name := range reports {
+		if !known[name] {
+			delete(reports, name)
+			delete(d.readinessStamps, name)
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := readiness.Save(d.config.TownRoot, reports); err != nil {
+		d.logger.Printf("Warning: saving rig readiness: %v", err)
+	}
+}
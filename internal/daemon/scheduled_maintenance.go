@@ -2,10 +2,11 @@ package daemon
 
 import (
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/wallclock"
 )
 
 const (
@@ -33,9 +34,14 @@ type ScheduledMaintenanceConfig struct {
 	Enabled bool `json:"enabled"`
 
 	// Window is the time of day to start maintenance (e.g., "03:00").
-	// Uses 24-hour format HH:MM in local time.
+	// Uses 24-hour format HH:MM in Timezone.
 	Window string `json:"window,omitempty"`
 
+	// Timezone is the IANA time zone the window is in (e.g.,
+	// "America/New_York"). Default: the machine's local zone.
+	// Around DST changes the window still opens exactly once a day.
+	Timezone string `json:"timezone,omitempty"`
+
 	// Interval controls how often maintenance runs.
 	// Supported values: "daily", "weekly", "monthly", or a Go duration (e.g., "48h").
 	// Default: "daily".
@@ -71,6 +77,14 @@ func maintenanceWindow(config *DaemonPatrolConfig) string {
 	return ""
 }
 
+// maintenanceLocation returns the time zone the window is evaluated in.
+func maintenanceLocation(config *DaemonPatrolConfig) (*time.Location, error) {
+	if config != nil && config.Patrols != nil && config.Patrols.ScheduledMaintenance != nil {
+		return wallclock.LoadLocation(config.Patrols.ScheduledMaintenance.Timezone)
+	}
+	return time.Local, nil
+}
+
 // maintenanceInterval returns the configured interval string, or "daily".
 func maintenanceInterval(config *DaemonPatrolConfig) string {
 	if config != nil && config.Patrols != nil && config.Patrols.ScheduledMaintenance != nil {
@@ -83,33 +97,30 @@ func maintenanceInterval(config *DaemonPatrolConfig) string {
 
 // parseWindowTime parses an HH:MM string and returns the hour and minute.
 func parseWindowTime(window string) (hour, minute int, err error) {
-	parts := strings.SplitN(window, ":", 2)
-	if len(parts) != 2 {
-		return 0, 0, fmt.Errorf("invalid window format %q: expected HH:MM", window)
-	}
-	hour, err = strconv.Atoi(parts[0])
-	if err != nil || hour < 0 || hour > 23 {
-		return 0, 0, fmt.Errorf("invalid hour in window %q: expected 0-23", window)
-	}
-	minute, err = strconv.Atoi(parts[1])
-	if err != nil || minute < 0 || minute > 59 {
-		return 0, 0, fmt.Errorf("invalid minute in window %q: expected 0-59", window)
+	tod, err := wallclock.ParseTimeOfDay(window)
+	if err != nil {
+		return 0, 0, err
 	}
-	return hour, minute, nil
+	return tod.Hour, tod.Minute, nil
 }
 
 // isInMaintenanceWindow checks if the given time falls within the maintenance window.
-// The window is 1 hour starting at the configured HH:MM.
+// The window is 1 hour starting at the configured HH:MM in now's location.
 func isInMaintenanceWindow(now time.Time, window string) bool {
-	hour, minute, err := parseWindowTime(window)
+	_, ok := maintenanceWindowStart(now, window)
+	return ok
+}
+
+// maintenanceWindowStart returns when the maintenance window containing now
+// opened, evaluating the window in now's location. The window lasts an hour
+// of elapsed time, and opens once per calendar day even across DST changes.
+func maintenanceWindowStart(now time.Time, window string) (time.Time, bool) {
+	tod, err := wallclock.ParseTimeOfDay(window)
 	if err != nil {
-		return false
+		return time.Time{}, false
 	}
-
-	windowStart := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
-	windowEnd := windowStart.Add(1 * time.Hour)
-
-	return !now.Before(windowStart) && now.Before(windowEnd)
+	w := &wallclock.Window{Start: tod, Duration: time.Hour, Location: now.Location()}
+	return w.Current(now)
 }
 
 // shouldRunMaintenance checks if maintenance should run based on the interval
@@ -153,12 +164,21 @@ func (d *Daemon) runScheduledMaintenance() {
 		return
 	}
 
-	now := time.Now()
+	loc, err := maintenanceLocation(d.patrolConfig)
+	if err != nil {
+		d.logger.Printf("scheduled_maintenance: %v, skipping", err)
+		return
+	}
+	now := time.Now().In(loc)
 
 	// Check if we're in the maintenance window.
-	if !isInMaintenanceWindow(now, window) {
+	windowStart, ok := maintenanceWindowStart(now, window)
+	if !ok {
 		return // Not in window — silent skip (this fires every 5 minutes)
 	}
+	if !d.lastMaintenanceRun.Before(windowStart) {
+		return // Already ran in this window
+	}
 
 	// Check if we already ran recently (respect interval).
 	interval := maintenanceInterval(d.patrolConfig)
@@ -202,7 +222,7 @@ func (d *Daemon) runScheduledMaintenance() {
 	// Run gt maintain --force --threshold <threshold>
 	d.logger.Printf("scheduled_maintenance: running gt maintain --force --threshold %d", threshold)
 
-	cmd := exec.CommandContext(d.ctx, d.gtPath, "maintain", "--force",
+	cmd := patrolCommand(d.patrolContext("scheduled_maintenance"), d.gtPath, "maintain", "--force",
 		"--threshold", strconv.Itoa(threshold))
 	cmd.Dir = d.config.TownRoot
 	output, err := cmd.CombinedOutput()
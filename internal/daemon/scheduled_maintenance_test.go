@@ -287,3 +287,32 @@ func TestIsPatrolEnabledScheduledMaintenance(t *testing.T) {
 		t.Error("expected scheduled_maintenance enabled when Enabled=true")
 	}
 }
+
+func TestMaintenanceWindowTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	config := &DaemonPatrolConfig{
+		Patrols: &PatrolsConfig{
+			ScheduledMaintenance: &ScheduledMaintenanceConfig{Window: "03:00", Timezone: "Asia/Tokyo"},
+		},
+	}
+	loc, err := maintenanceLocation(config)
+	if err != nil || loc.String() != "Asia/Tokyo" {
+		t.Fatalf("maintenanceLocation = %v, %v", loc, err)
+	}
+
+	// 18:30 UTC is 03:30 the next day in Tokyo: inside the window there,
+	// whatever the machine's zone.
+	now := time.Date(2026, 2, 27, 18, 30, 0, 0, time.UTC).In(loc)
+	start, ok := maintenanceWindowStart(now, "03:00")
+	if !ok || !start.Equal(time.Date(2026, 2, 28, 3, 0, 0, 0, tokyo)) {
+		t.Errorf("maintenanceWindowStart = %v, %v; want 03:00 Tokyo", start, ok)
+	}
+
+	config.Patrols.ScheduledMaintenance.Timezone = "Nowhere/Special"
+	if _, err := maintenanceLocation(config); err == nil {
+		t.Error("expected an invalid timezone to be rejected")
+	}
+}
@@ -128,6 +128,10 @@ type PatrolsConfig struct {
 	CompactorDog           *CompactorDogConfig            `json:"compactor_dog,omitempty"`
 	ScheduledMaintenance   *ScheduledMaintenanceConfig    `json:"scheduled_maintenance,omitempty"`
 	RestartTracker         *RestartTrackerConfig          `json:"restart_tracker,omitempty"`
+	IdleRigs               *IdleRigsConfig                `json:"idle_rigs,omitempty"`
+	FederationSync         *FederationSyncConfig          `json:"federation_sync,omitempty"`
+	// Budgets caps each patrol's runtime and CPU, keyed by patrol name.
+	Budgets map[string]*PatrolBudget `json:"budgets,omitempty"`
 }
 
 // DoltRemotesConfig holds configuration for the dolt_remotes patrol.
@@ -287,6 +291,18 @@ func IsPatrolEnabled(config *DaemonPatrolConfig, patrol string) bool {
 		}
 		return config.Patrols.ScheduledMaintenance.Enabled
 	}
+	if patrol == "idle_rigs" {
+		if config == nil || config.Patrols == nil || config.Patrols.IdleRigs == nil {
+			return false
+		}
+		return config.Patrols.IdleRigs.Enabled
+	}
+	if patrol == "federation_sync" {
+		if config == nil || config.Patrols == nil || config.Patrols.FederationSync == nil {
+			return false
+		}
+		return config.Patrols.FederationSync.Enabled
+	}
 
 	if config == nil || config.Patrols == nil {
 		return true // Default: enabled
@@ -2,7 +2,6 @@ package daemon
 
 import (
 	"fmt"
-	"os/exec"
 	"strings"
 	"time"
 
@@ -126,7 +125,7 @@ func (d *Daemon) dispatchReaperDog(vars map[string]string) error {
 		args = append(args, "--var", fmt.Sprintf("%s=%s", k, v))
 	}
 
-	cmd := exec.Command("gt", args...)
+	cmd := patrolCommand(d.patrolContext("wisp_reaper"), "gt", args...)
 	cmd.Dir = d.config.TownRoot
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("gt sling: %w", err)
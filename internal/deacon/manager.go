@@ -42,15 +42,28 @@ type tmuxOps interface {
 // Manager handles deacon lifecycle operations.
 type Manager struct {
 	townRoot string
-	tmux     tmuxOps
+	mux      tmux.Multiplexer
+	tmux     tmuxOps // nil unless mux is tmux
 }
 
 // NewManager creates a new deacon manager for a town.
 func NewManager(townRoot string) *Manager {
-	return &Manager{
+	m := &Manager{
 		townRoot: townRoot,
-		tmux:     tmux.NewTmux(),
+		mux:      tmux.Selected(),
 	}
+	if t, ok := m.mux.(*tmux.Tmux); ok {
+		m.tmux = t
+	}
+	return m
+}
+
+// hasSession reports whether the named session exists.
+func (m *Manager) hasSession(name string) (bool, error) {
+	if m.tmux != nil {
+		return m.tmux.HasSession(name)
+	}
+	return m.mux.HasSession(name)
 }
 
 // SessionName returns the tmux session name for the deacon.
@@ -73,6 +86,9 @@ func (m *Manager) deaconDir() string {
 // agentOverride allows specifying an alternate agent alias (e.g., for testing).
 // Restarts are handled by daemon via ensureDeaconRunning on each heartbeat.
 func (m *Manager) Start(agentOverride string) error {
+	if m.tmux == nil {
+		return tmux.NeedsTmux("starting the deacon")
+	}
 	t := m.tmux
 	sessionID := m.SessionName()
 
@@ -190,13 +206,19 @@ func (m *Manager) Stop() error {
 	sessionID := m.SessionName()
 
 	// Check if session exists
-	running, err := t.HasSession(sessionID)
+	running, err := m.hasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
 	}
 	if !running {
 		return ErrNotRunning
 	}
+	if t == nil {
+		if err := tmux.KillWithProcesses(m.mux, sessionID); err != nil {
+			return fmt.Errorf("killing session: %w", err)
+		}
+		return nil
+	}
 
 	// Try graceful shutdown first (best-effort interrupt)
 	_ = t.SendKeysRaw(sessionID, "C-c")
@@ -214,7 +236,7 @@ func (m *Manager) Stop() error {
 
 // IsRunning checks if the deacon session is active.
 func (m *Manager) IsRunning() (bool, error) {
-	return m.tmux.HasSession(m.SessionName())
+	return m.hasSession(m.SessionName())
 }
 
 // Status returns information about the deacon session.
@@ -222,13 +244,16 @@ func (m *Manager) Status() (*tmux.SessionInfo, error) {
 	t := m.tmux
 	sessionID := m.SessionName()
 
-	running, err := t.HasSession(sessionID)
+	running, err := m.hasSession(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("checking session: %w", err)
 	}
 	if !running {
 		return nil, ErrNotRunning
 	}
+	if t == nil {
+		return nil, tmux.NeedsTmux("deacon session info")
+	}
 
 	return t.GetSessionInfo(sessionID)
 }
@@ -92,7 +92,10 @@ func ScanStaleHooks(townRoot string, cfg *StaleHookConfig) (*StaleHookScanResult
 	result.TotalHooked = len(hookedBeads)
 
 	threshold := time.Now().Add(-cfg.MaxAge)
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return nil, err
+	}
 
 	for _, bead := range hookedBeads {
 		hookResult := &StaleHookResult{
@@ -0,0 +1,108 @@
+// Package desktop shows native desktop notifications for events that need
+// a human now: an approval waiting, an agent stuck, a refinery batch
+// blocked. Which events are shown is configured per event type in
+// settings/escalation.json (desktop_notifications).
+//
+// The backend depends on the platform: terminal-notifier, falling back to
+// osascript, on macOS; a PowerShell toast on Windows; notify-send elsewhere.
+// Notifications are best-effort: without a backend, or a desktop session,
+// they are dropped with an error the caller may log.
+package desktop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// ErrUnavailable is returned when no notification backend is installed.
+var ErrUnavailable = errors.New("no desktop notification backend")
+
+// appName labels notifications where the backend supports it.
+const appName = "Gas Town"
+
+// notifyTimeout bounds a backend run, which some desktops block on.
+const notifyTimeout = 10 * time.Second
+
+// windowsToast shows a toast with the title and body from the environment,
+// which keeps them out of the script's quoting.
+const windowsToast = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$x = $t.GetElementsByTagName('text')
+$x.Item(0).AppendChild($t.CreateTextNode($env:GT_NOTIFY_TITLE)) > $null
+$x.Item(1).AppendChild($t.CreateTextNode($env:GT_NOTIFY_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('` + appName + `').Show([Windows.UI.Notifications.ToastNotification]::new($t))`
+
+// Notification is one desktop notification.
+type Notification struct {
+	Title string
+	Body  string
+}
+
+// NotifyEvent shows n if the town at townRoot has desktop notifications on
+// for event (see config.EscalationConfig.DesktopNotifications). It returns
+// nil when they are off.
+func NotifyEvent(townRoot, event string, n Notification) error {
+	cfg, err := config.LoadOrCreateEscalationConfig(config.EscalationConfigPath(townRoot))
+	if err != nil {
+		return err
+	}
+	if !cfg.DesktopNotify(event) {
+		return nil
+	}
+	return Notify(n)
+}
+
+// Notify shows n with the platform's backend.
+func Notify(n Notification) error {
+	argv, env, err := command(runtime.GOOS, exec.LookPath, n)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...) //nolint:gosec // G204: backend and flags are fixed; text goes in args or env
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", argv[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// command returns the argv, and extra environment, showing n on goos with
+// the first backend lookPath finds.
+func command(goos string, lookPath func(string) (string, error), n Notification) ([]string, []string, error) {
+	has := func(name string) bool {
+		_, err := lookPath(name)
+		return err == nil
+	}
+	env := []string{"GT_NOTIFY_TITLE=" + n.Title, "GT_NOTIFY_BODY=" + n.Body}
+	switch goos {
+	case "darwin":
+		if has("terminal-notifier") {
+			return []string{"terminal-notifier", "-title", appName, "-subtitle", n.Title, "-message", n.Body, "-group", "gastown"}, nil, nil
+		}
+		if has("osascript") {
+			script := `display notification (system attribute "GT_NOTIFY_BODY") with title "` + appName + `" subtitle (system attribute "GT_NOTIFY_TITLE")`
+			return []string{"osascript", "-e", script}, env, nil
+		}
+	case "windows":
+		for _, shell := range []string{"powershell", "pwsh"} {
+			if has(shell) {
+				return []string{shell, "-NoProfile", "-NonInteractive", "-Command", windowsToast}, env, nil
+			}
+		}
+	default:
+		if has("notify-send") {
+			return []string{"notify-send", "--app-name=" + appName, "--urgency=critical", n.Title, n.Body}, nil, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w on %s", ErrUnavailable, goos)
+}
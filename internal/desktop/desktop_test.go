@@ -0,0 +1,85 @@
+package desktop
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installed returns a lookPath finding only names.
+func installed(names ...string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		for _, n := range names {
+			if n == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestCommand_Backends(t *testing.T) {
+	n := Notification{Title: "Approval waiting", Body: `MR gt-1 "fix"`}
+	tests := []struct {
+		goos      string
+		installed []string
+		want      string
+		wantEnv   bool
+	}{
+		{"darwin", []string{"terminal-notifier", "osascript"}, "terminal-notifier", false},
+		{"darwin", []string{"osascript"}, "osascript", true},
+		{"linux", []string{"notify-send"}, "notify-send", false},
+		{"freebsd", []string{"notify-send"}, "notify-send", false},
+		{"windows", []string{"pwsh"}, "pwsh", true},
+	}
+	for _, tt := range tests {
+		argv, env, err := command(tt.goos, installed(tt.installed...), n)
+		if err != nil {
+			t.Errorf("%s %v: %v", tt.goos, tt.installed, err)
+			continue
+		}
+		if argv[0] != tt.want {
+			t.Errorf("%s %v: backend %s, want %s", tt.goos, tt.installed, argv[0], tt.want)
+		}
+		// Text goes either in its own args or in the environment, never
+		// into a script.
+		inArgs := strings.Contains(strings.Join(argv, "\x00"), "\x00"+n.Body)
+		if tt.wantEnv == inArgs || (tt.wantEnv && len(env) != 2) {
+			t.Errorf("%s: argv %q, env %q", tt.want, argv, env)
+		}
+	}
+
+	if _, _, err := command("linux", installed(), n); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("no backend: err = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestNotifyEvent_Off(t *testing.T) {
+	townRoot := t.TempDir()
+	// Nothing configured: every event is off, so no backend is needed.
+	if err := NotifyEvent(townRoot, "approval", Notification{Title: "t"}); err != nil {
+		t.Errorf("NotifyEvent with no config = %v", err)
+	}
+
+	settings := filepath.Join(townRoot, "settings")
+	if err := os.MkdirAll(settings, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"type": "escalation", "version": 1, "desktop_notifications": {"approval": false, "agent_stuck": true}}`
+	if err := os.WriteFile(filepath.Join(settings, "escalation.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NotifyEvent(townRoot, "approval", Notification{Title: "t"}); err != nil {
+		t.Errorf("NotifyEvent for a disabled event = %v", err)
+	}
+
+	data = `{"type": "escalation", "version": 1, "desktop_notifications": {"merged": true}}`
+	if err := os.WriteFile(filepath.Join(settings, "escalation.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NotifyEvent(townRoot, "merged", Notification{Title: "t"}); err == nil {
+		t.Error("unknown event type in config accepted")
+	}
+}
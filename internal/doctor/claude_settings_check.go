@@ -654,7 +654,10 @@ func (c *ClaudeSettingsCheck) Fix(ctx *CheckContext) error {
 	var errors []string
 	var skipped []string
 	var needsRestart bool
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 
 	for _, sf := range c.staleSettings {
 		// Skip files that aren't stale (correct settings.json files)
@@ -750,8 +753,8 @@ func (c *ClaudeSettingsCheck) Fix(ctx *CheckContext) error {
 				running, _ := t.HasSession(sf.sessionName)
 				if running {
 					// Cycle the agent by killing and letting gt up restart it.
-					// Use KillSessionWithProcesses to ensure all descendant processes are killed.
-					_ = t.KillSessionWithProcesses(sf.sessionName)
+					// Use KillWithProcesses to ensure all descendant processes are killed.
+					_ = tmux.KillWithProcesses(t, sf.sessionName)
 				}
 			}
 		}
@@ -81,7 +81,11 @@ func NewEnvVarsCheckWithAccessor(accessor SessionEnvAccessor) *EnvVarsCheck {
 func (c *EnvVarsCheck) Run(ctx *CheckContext) *CheckResult {
 	reader := c.reader
 	if reader == nil {
-		reader = &tmuxEnvReaderWriter{t: tmux.NewTmux()}
+		t, skip := tmuxOnlyCheck(c.Name())
+		if skip != nil {
+			return skip
+		}
+		reader = &tmuxEnvReaderWriter{t: t}
 	}
 
 	sessions, err := reader.ListSessions()
@@ -215,7 +219,11 @@ func (c *EnvVarsCheck) Run(ctx *CheckContext) *CheckResult {
 func (c *EnvVarsCheck) Fix(ctx *CheckContext) error {
 	accessor := c.accessor
 	if accessor == nil {
-		accessor = &tmuxEnvReaderWriter{t: tmux.NewTmux()}
+		t, err := tmux.RequireTmux("fixing session environments")
+		if err != nil {
+			return err
+		}
+		accessor = &tmuxEnvReaderWriter{t: t}
 	}
 
 	sessions, err := accessor.ListSessions()
@@ -5,7 +5,6 @@ import (
 	"strings"
 
 	"github.com/steveyegge/gastown/internal/lock"
-	"github.com/steveyegge/gastown/internal/tmux"
 )
 
 // IdentityCollisionCheck checks for agent identity collisions and stale locks.
@@ -50,7 +49,10 @@ func (c *IdentityCollisionCheck) Run(ctx *CheckContext) *CheckResult {
 	// Get active tmux sessions for cross-reference
 	// Build a set containing both session names AND session IDs
 	// because locks may store either format
-	t := tmux.NewTmux()
+	t, skip := tmuxOnlyCheck(c.Name())
+	if skip != nil {
+		return skip
+	}
 	sessionSet := make(map[string]bool)
 
 	// Get session names
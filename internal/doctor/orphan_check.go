@@ -26,7 +26,7 @@ type SessionLister interface {
 }
 
 type realSessionLister struct {
-	t *tmux.Tmux
+	t tmux.Multiplexer
 }
 
 func (r *realSessionLister) ListSessions() ([]string, error) {
@@ -57,7 +57,16 @@ func NewOrphanSessionCheckWithSessionLister(lister SessionLister) *OrphanSession
 func (c *OrphanSessionCheck) Run(ctx *CheckContext) *CheckResult {
 	lister := c.sessionLister
 	if lister == nil {
-		lister = &realSessionLister{t: tmux.NewTmux()}
+		mux, err := tmux.NewMultiplexer()
+		if err != nil {
+			return &CheckResult{
+				Name:    c.Name(),
+				Status:  StatusWarning,
+				Message: "Could not list sessions",
+				Details: []string{err.Error()},
+			}
+		}
+		lister = &realSessionLister{t: mux}
 	}
 
 	sessions, err := lister.ListSessions()
@@ -137,7 +146,10 @@ func (c *OrphanSessionCheck) Fix(ctx *CheckContext) error {
 		return nil
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	var lastErr error
 
 	for _, sess := range c.orphanSessions {
@@ -149,8 +161,8 @@ func (c *OrphanSessionCheck) Fix(ctx *CheckContext) error {
 		// Log pre-death event for crash investigation (before killing)
 		_ = events.LogFeed(events.TypeSessionDeath, sess,
 			events.SessionDeathPayload(sess, "unknown", "orphan cleanup", "gt doctor"))
-		// Use KillSessionWithProcesses to ensure all descendant processes are killed.
-		if err := t.KillSessionWithProcesses(sess); err != nil {
+		// Use KillWithProcesses to ensure all descendant processes are killed.
+		if err := tmux.KillWithProcesses(t, sess); err != nil {
 			lastErr = err
 		}
 	}
@@ -296,8 +308,14 @@ func NewOrphanProcessCheck() *OrphanProcessCheck {
 
 // Run checks for runtime processes running outside tmux.
 func (c *OrphanProcessCheck) Run(ctx *CheckContext) *CheckResult {
+	// Without tmux there are no pane PIDs to tell agents' processes apart.
+	t, skip := tmuxOnlyCheck(c.Name())
+	if skip != nil {
+		return skip
+	}
+
 	// Get list of tmux session PIDs
-	tmuxPIDs, err := c.getTmuxSessionPIDs()
+	tmuxPIDs, err := c.getTmuxSessionPIDs(t)
 	if err != nil {
 		return &CheckResult{
 			Name:    c.Name(),
@@ -368,7 +386,7 @@ type processInfo struct {
 }
 
 // getTmuxSessionPIDs returns PIDs of all tmux server processes and pane shell PIDs.
-func (c *OrphanProcessCheck) getTmuxSessionPIDs() (map[int]bool, error) { //nolint:unparam // error return kept for future use
+func (c *OrphanProcessCheck) getTmuxSessionPIDs(t *tmux.Tmux) (map[int]bool, error) { //nolint:unparam // error return kept for future use
 	// Get tmux server PID and all pane PIDs
 	pids := make(map[int]bool)
 
@@ -390,7 +408,6 @@ func (c *OrphanProcessCheck) getTmuxSessionPIDs() (map[int]bool, error) { //noli
 	}
 
 	// Also get shell PIDs inside tmux panes
-	t := tmux.NewTmux()
 	sessions, _ := t.ListSessions()
 	for _, session := range sessions {
 		// Get pane PIDs for this session
@@ -62,7 +62,16 @@ func NewMalformedSessionNameCheck() *MalformedSessionNameCheck {
 func (c *MalformedSessionNameCheck) Run(ctx *CheckContext) *CheckResult {
 	lister := c.sessionListerForTest
 	if lister == nil {
-		lister = &realSessionLister{t: tmux.NewTmux()}
+		mux, err := tmux.NewMultiplexer()
+		if err != nil {
+			return &CheckResult{
+				Name:    c.Name(),
+				Status:  StatusWarning,
+				Message: "Could not list sessions",
+				Details: []string{err.Error()},
+			}
+		}
+		lister = &realSessionLister{t: mux}
 	}
 
 	reg := c.registryForTest
@@ -137,7 +146,11 @@ func (c *MalformedSessionNameCheck) Fix(ctx *CheckContext) error {
 	if c.tmuxForTest != nil {
 		t = c.tmuxForTest
 	} else {
-		t = tmux.NewTmux()
+		tm, err := tmux.RequireTmux("renaming sessions")
+		if err != nil {
+			return err
+		}
+		t = tm
 	}
 	var lastErr error
 
@@ -29,7 +29,10 @@ func NewThemeCheck() *ThemeCheck {
 
 // Run checks if tmux sessions have themes applied correctly.
 func (c *ThemeCheck) Run(ctx *CheckContext) *CheckResult {
-	t := tmux.NewTmux()
+	t, skip := tmuxOnlyCheck(c.Name())
+	if skip != nil {
+		return skip
+	}
 
 	// List all sessions
 	sessions, err := t.ListSessions()
@@ -28,9 +28,27 @@ func NewLinkedPaneCheck() *LinkedPaneCheck {
 	}
 }
 
+// tmuxOnlyCheck returns the tmux backend for a check that inspects tmux
+// itself. Under another multiplexer backend the check doesn't apply, and
+// skip is the result to return instead.
+func tmuxOnlyCheck(name string) (t *tmux.Tmux, skip *CheckResult) {
+	t, err := tmux.RequireTmux(name)
+	if err != nil {
+		return nil, &CheckResult{
+			Name:    name,
+			Status:  StatusOK,
+			Message: fmt.Sprintf("%v (skipped)", err),
+		}
+	}
+	return t, nil
+}
+
 // Run checks for linked panes across Gas Town tmux sessions.
 func (c *LinkedPaneCheck) Run(ctx *CheckContext) *CheckResult {
-	t := tmux.NewTmux()
+	t, skip := tmuxOnlyCheck(c.Name())
+	if skip != nil {
+		return skip
+	}
 
 	sessions, err := t.ListSessions()
 	if err != nil {
@@ -118,7 +136,10 @@ func (c *LinkedPaneCheck) Fix(ctx *CheckContext) error {
 		return nil
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("fixing linked panes")
+	if err != nil {
+		return err
+	}
 	var lastErr error
 
 	for _, session := range c.linkedSessions {
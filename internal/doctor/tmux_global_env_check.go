@@ -45,7 +45,11 @@ func NewTmuxGlobalEnvCheckWithAccessor(accessor GlobalEnvAccessor) *TmuxGlobalEn
 func (c *TmuxGlobalEnvCheck) Run(ctx *CheckContext) *CheckResult {
 	accessor := c.accessor
 	if accessor == nil {
-		accessor = tmux.NewTmux()
+		t, skip := tmuxOnlyCheck(c.Name())
+		if skip != nil {
+			return skip
+		}
+		accessor = t
 	}
 
 	val, err := accessor.GetGlobalEnvironment("GT_TOWN_ROOT")
@@ -95,7 +99,11 @@ func (c *TmuxGlobalEnvCheck) Run(ctx *CheckContext) *CheckResult {
 func (c *TmuxGlobalEnvCheck) Fix(ctx *CheckContext) error {
 	accessor := c.accessor
 	if accessor == nil {
-		accessor = tmux.NewTmux()
+		t, err := tmux.RequireTmux("setting the tmux global environment")
+		if err != nil {
+			return err
+		}
+		accessor = t
 	}
 	return accessor.SetGlobalEnvironment("GT_TOWN_ROOT", ctx.TownRoot)
 }
@@ -43,7 +43,10 @@ func (c *SocketSplitBrainCheck) Run(ctx *CheckContext) *CheckResult {
 	}
 
 	// List sessions on both sockets
-	townTmux := tmux.NewTmux()
+	townTmux, skip := tmuxOnlyCheck(c.Name())
+	if skip != nil {
+		return skip
+	}
 	defaultTmux := tmux.NewTmuxWithSocket("default")
 
 	townSessions, err := townTmux.ListSessions()
@@ -31,7 +31,10 @@ func NewZombieSessionCheck() *ZombieSessionCheck {
 
 // Run checks for zombie Gas Town sessions (tmux alive but Claude dead).
 func (c *ZombieSessionCheck) Run(ctx *CheckContext) *CheckResult {
-	t := tmux.NewTmux()
+	t, skip := tmuxOnlyCheck(c.Name())
+	if skip != nil {
+		return skip
+	}
 
 	sessions, err := t.ListSessions()
 	if err != nil {
@@ -115,7 +118,10 @@ func (c *ZombieSessionCheck) Fix(ctx *CheckContext) error {
 		return nil
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("fixing zombie sessions")
+	if err != nil {
+		return err
+	}
 	var lastErr error
 
 	for _, sess := range c.zombieSessions {
@@ -1,6 +1,8 @@
 package doctor
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -64,7 +66,14 @@ func TestZombieSessionCheck_FixProtectsCrewSessions(t *testing.T) {
 		"gt-gastown-witness",  // Would be killed (if real)
 	}
 
-	ctx := &CheckContext{TownRoot: t.TempDir()}
+	// Fix logs session deaths to the town found from cwd; run it from a temp
+	// town so the events don't land in the source tree.
+	town := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(town, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(town)
+	ctx := &CheckContext{TownRoot: town}
 
 	// Fix should skip crew sessions due to safeguard
 	// (We can't fully test this without mocking tmux, but the safeguard is in place)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
 
@@ -37,9 +38,25 @@ func NewHealthChecker(mgr *Manager, checker sessionChecker) *HealthChecker {
 	return &HealthChecker{mgr: mgr, checker: checker}
 }
 
+// NewMultiplexerHealthChecker creates a HealthChecker over the sessions of
+// mux. Only tmux can tell a dead or hung agent from a live one; on other
+// backends a dog whose session exists counts as healthy.
+func NewMultiplexerHealthChecker(mgr *Manager, mux tmux.Multiplexer) *HealthChecker {
+	return NewHealthChecker(mgr, muxChecker{mux})
+}
+
+// muxChecker adapts a Multiplexer to sessionChecker.
+type muxChecker struct {
+	tmux.Multiplexer
+}
+
+func (c muxChecker) CheckSessionHealth(session string, maxInactivity time.Duration) tmux.ZombieStatus {
+	return tmux.CheckHealth(c.Multiplexer, session, maxInactivity)
+}
+
 // dogSessionName returns the tmux session name for a dog.
 func dogSessionName(name string) string {
-	return fmt.Sprintf("hq-dog-%s", name)
+	return session.DogSessionName(name)
 }
 
 // Check performs a health check on a single dog.
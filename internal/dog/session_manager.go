@@ -21,9 +21,11 @@ var (
 	ErrSessionNotFound = errors.New("session not found")
 )
 
-// SessionManager handles dog session lifecycle.
+// SessionManager handles dog session lifecycle. Sessions are checked and
+// stopped through the Multiplexer; starting one needs tmux.
 type SessionManager struct {
-	tmux     *tmux.Tmux
+	mux      tmux.Multiplexer
+	tmux     *tmux.Tmux // nil unless mux is tmux
 	mgr      *Manager
 	townRoot string
 }
@@ -31,8 +33,10 @@ type SessionManager struct {
 // NewSessionManager creates a new dog session manager.
 // The Manager parameter is used to sync persistent dog state (idle/working)
 // when sessions start and stop.
-func NewSessionManager(t *tmux.Tmux, townRoot string, mgr *Manager) *SessionManager {
+func NewSessionManager(mux tmux.Multiplexer, townRoot string, mgr *Manager) *SessionManager {
+	t, _ := mux.(*tmux.Tmux)
 	return &SessionManager{
+		mux:      mux,
 		tmux:     t,
 		mgr:      mgr,
 		townRoot: townRoot,
@@ -72,7 +76,7 @@ type SessionInfo struct {
 // We use "hq-dog-" instead of "hq-deacon-" to avoid tmux prefix-matching
 // collisions with the "hq-deacon" session.
 func (m *SessionManager) SessionName(dogName string) string {
-	return fmt.Sprintf("hq-dog-%s", dogName)
+	return session.DogSessionName(dogName)
 }
 
 // kennelPath returns the path to the dog's kennel directory.
@@ -88,6 +92,9 @@ func (m *SessionManager) Start(dogName string, opts SessionStartOptions) error {
 		return fmt.Errorf("%w: %s", ErrDogNotFound, dogName)
 	}
 
+	if m.tmux == nil {
+		return tmux.NeedsTmux("starting a dog session")
+	}
 	sessionID := m.SessionName(dogName)
 
 	// Kill any existing zombie session (tmux alive but agent dead).
@@ -154,7 +161,7 @@ func (m *SessionManager) Start(dogName string, opts SessionStartOptions) error {
 func (m *SessionManager) Stop(dogName string, force bool) error {
 	sessionID := m.SessionName(dogName)
 
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
 	}
@@ -163,12 +170,12 @@ func (m *SessionManager) Stop(dogName string, force bool) error {
 	}
 
 	// Try graceful shutdown first
-	if !force {
+	if !force && m.tmux != nil {
 		_ = m.tmux.SendKeysRaw(sessionID, "C-c")
 		session.WaitForSessionExit(m.tmux, sessionID, constants.GracefulShutdownTimeout)
 	}
 
-	if err := m.tmux.KillSessionWithProcesses(sessionID); err != nil {
+	if err := tmux.KillWithProcesses(m.mux, sessionID); err != nil {
 		return fmt.Errorf("killing session: %w", err)
 	}
 
@@ -185,14 +192,14 @@ func (m *SessionManager) Stop(dogName string, force bool) error {
 // IsRunning checks if a dog session is active.
 func (m *SessionManager) IsRunning(dogName string) (bool, error) {
 	sessionID := m.SessionName(dogName)
-	return m.tmux.HasSession(sessionID)
+	return m.mux.HasSession(sessionID)
 }
 
 // Status returns detailed status for a dog session.
 func (m *SessionManager) Status(dogName string) (*SessionInfo, error) {
 	sessionID := m.SessionName(dogName)
 
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("checking session: %w", err)
 	}
@@ -203,7 +210,7 @@ func (m *SessionManager) Status(dogName string) (*SessionInfo, error) {
 		Running:   running,
 	}
 
-	if !running {
+	if !running || m.tmux == nil {
 		return info, nil
 	}
 
@@ -219,6 +226,9 @@ func (m *SessionManager) Status(dogName string) (*SessionInfo, error) {
 
 // GetPane returns the pane ID for a dog session.
 func (m *SessionManager) GetPane(dogName string) (string, error) {
+	if m.tmux == nil {
+		return "", tmux.NeedsTmux("dog panes")
+	}
 	sessionID := m.SessionName(dogName)
 
 	running, err := m.tmux.HasSession(sessionID)
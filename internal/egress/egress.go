@@ -0,0 +1,253 @@
+// Package egress implements the allowlisting HTTP proxy that agent sessions'
+// network egress is routed through when a town enables an egress policy.
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dialTimeout bounds connecting to an allowed upstream host.
+const dialTimeout = 30 * time.Second
+
+// Policy is a host allowlist. Entries are host names, matched exactly, or
+// "*.domain" patterns, matching any subdomain of domain.
+type Policy struct {
+	exact    map[string]bool
+	suffixes []string // ".domain" for each "*.domain" entry
+}
+
+// NewPolicy returns a policy allowing hosts.
+func NewPolicy(hosts []string) *Policy {
+	p := &Policy{exact: make(map[string]bool)}
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimSpace(h))
+		switch {
+		case h == "":
+		case strings.HasPrefix(h, "*."):
+			p.suffixes = append(p.suffixes, h[1:])
+		default:
+			p.exact[h] = true
+		}
+	}
+	return p
+}
+
+// Allows reports whether host (optionally with a port) may be reached.
+func (p *Policy) Allows(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if p.exact[host] {
+		return true
+	}
+	for _, s := range p.suffixes {
+		if strings.HasSuffix(host, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteHost returns the host of a git remote URL: https://host/path,
+// ssh://user@host:port/path, or scp-like user@host:path. Local paths and
+// file:// URLs return "".
+func RemoteHost(remote string) string {
+	remote = strings.TrimSpace(remote)
+	if remote == "" {
+		return ""
+	}
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil || u.Scheme == "file" {
+			return ""
+		}
+		return u.Hostname()
+	}
+	// scp-like syntax: [user@]host:path, with no slash before the colon.
+	colon := strings.Index(remote, ":")
+	if colon <= 0 || strings.Contains(remote[:colon], "/") {
+		return ""
+	}
+	host := remote[:colon]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	return host
+}
+
+// Proxy is an HTTP proxy that forwards CONNECT tunnels and plain HTTP
+// requests to hosts its policy allows, and refuses the rest with 403.
+type Proxy struct {
+	listen string
+	logf   func(format string, args ...interface{})
+
+	mu     sync.RWMutex
+	policy *Policy
+
+	transport *http.Transport
+	server    *http.Server
+	ln        net.Listener
+}
+
+// NewProxy creates a proxy listening on listen once started.
+func NewProxy(listen string, policy *Policy, logf func(format string, args ...interface{})) *Proxy {
+	return &Proxy{
+		listen: listen,
+		logf:   logf,
+		policy: policy,
+		transport: &http.Transport{
+			Proxy:       nil, // Never chain to the parent's proxy
+			DialContext: (&net.Dialer{Timeout: dialTimeout}).DialContext,
+		},
+	}
+}
+
+// SetPolicy replaces the proxy's policy. Tunnels already open are kept.
+func (p *Proxy) SetPolicy(policy *Policy) {
+	p.mu.Lock()
+	p.policy = policy
+	p.mu.Unlock()
+}
+
+func (p *Proxy) allows(host string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.policy.Allows(host)
+}
+
+// Start listens and serves in the background.
+func (p *Proxy) Start() error {
+	ln, err := net.Listen("tcp", p.listen)
+	if err != nil {
+		return fmt.Errorf("egress proxy listen on %s: %w", p.listen, err)
+	}
+	p.ln = ln
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		if err := p.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.logf("egress: proxy stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the proxy listens on, once started.
+func (p *Proxy) Addr() string {
+	if p.ln == nil {
+		return ""
+	}
+	return p.ln.Addr().String()
+}
+
+// Stop closes the listener and idle connections. Open tunnels run until
+// either end closes them.
+func (p *Proxy) Stop() {
+	if p.server != nil {
+		_ = p.server.Close()
+	}
+	p.transport.CloseIdleConnections()
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if r.Method != http.MethodConnect && r.URL.Host != "" {
+		host = r.URL.Host
+	}
+	if !p.allows(host) {
+		p.logf("egress: denied %s %s from %s", r.Method, host, r.RemoteAddr)
+		http.Error(w, fmt.Sprintf("gastown egress policy: %s is not an allowed host", host), http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	p.forward(w, r)
+}
+
+// tunnel serves a CONNECT request by splicing the client to the host.
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := net.DialTimeout("tcp", r.Host, dialTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		_ = upstream.Close()
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		_ = client.Close()
+		_ = upstream.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		// Bytes the client sent after the CONNECT head are already buffered.
+		_, _ = io.Copy(upstream, io.MultiReader(buf.Reader, client))
+		closeWrite(upstream)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(client, upstream)
+		closeWrite(client)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+	_ = client.Close()
+	_ = upstream.Close()
+}
+
+// forward serves a plain HTTP proxy request.
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request) {
+	if !r.URL.IsAbs() {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Connection")
+	out.Header.Del("Proxy-Authorization")
+
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// closeWrite half-closes conn so the other side sees EOF.
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = conn.Close()
+}
@@ -0,0 +1,146 @@
+package egress
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	p := NewPolicy([]string{"api.anthropic.com", "*.github.com", " GitLab.example.org "})
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.anthropic.com", true},
+		{"api.anthropic.com:443", true},
+		{"API.Anthropic.com.", true},
+		{"codeload.github.com:443", true},
+		{"github.com", false}, // *.domain matches subdomains only
+		{"gitlab.example.org", true},
+		{"evil.com", false},
+		{"api.anthropic.com.evil.com", false},
+		{"notgithub.com", false},
+	}
+	for _, tt := range tests {
+		if got := p.Allows(tt.host); got != tt.want {
+			t.Errorf("Allows(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestRemoteHost(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/steveyegge/gastown.git": "github.com",
+		"ssh://git@gitlab.com:2222/team/repo.git":   "gitlab.com",
+		"git@github.com:steveyegge/gastown.git":     "github.com",
+		"file:///srv/git/repo.git":                  "",
+		"/srv/git/repo.git":                         "",
+		"../relative/repo":                          "",
+		"":                                          "",
+	}
+	for remote, want := range tests {
+		if got := RemoteHost(remote); got != want {
+			t.Errorf("RemoteHost(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
+
+func startProxy(t *testing.T, hosts ...string) *url.URL {
+	t.Helper()
+	p := NewProxy("127.0.0.1:0", NewPolicy(hosts), t.Logf)
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Stop)
+	return &url.URL{Scheme: "http", Host: p.Addr()}
+}
+
+func TestProxyForwardsAllowedHTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(startProxy(t, "127.0.0.1"))}}
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Errorf("got %d %q, want 200 \"hello\"", resp.StatusCode, body)
+	}
+}
+
+func TestProxyDeniesUnlistedHost(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("denied request reached upstream")
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(startProxy(t, "api.anthropic.com"))}}
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "not an allowed host") {
+		t.Errorf("got %d %q, want 403 from egress policy", resp.StatusCode, body)
+	}
+}
+
+func TestProxyTunnelsAllowedConnect(t *testing.T) {
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	go func() {
+		conn, err := upstream.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		_, _ = conn.Write([]byte("echo " + line))
+	}()
+
+	for _, tt := range []struct {
+		allow string
+		want  string
+	}{
+		{"127.0.0.1", "200"},
+		{"example.com", "403"},
+	} {
+		proxyURL := startProxy(t, tt.allow)
+		conn, err := net.Dial("tcp", proxyURL.Host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", upstream.Addr(), upstream.Addr())
+		r := bufio.NewReader(conn)
+		status, _ := r.ReadString('\n')
+		if !strings.Contains(status, " "+tt.want+" ") {
+			t.Errorf("allow %s: CONNECT status %q, want %s", tt.allow, status, tt.want)
+			conn.Close()
+			continue
+		}
+		if tt.want == "200" {
+			_, _ = r.ReadString('\n') // blank line ending the response head
+			fmt.Fprint(conn, "ping\n")
+			if got, _ := r.ReadString('\n'); got != "echo ping\n" {
+				t.Errorf("tunnel echoed %q, want %q", got, "echo ping\n")
+			}
+		}
+		conn.Close()
+	}
+}
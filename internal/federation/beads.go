@@ -0,0 +1,236 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// LabelFederated marks a bead that was forwarded to another town or
+// received from one. The refinery leaves forwarded MRs to the peer.
+const LabelFederated = "gt:federated"
+
+// AssigneePrefix prefixes the assignee of a bead forwarded to a peer, and
+// the worker of an MR received from one.
+const AssigneePrefix = "federation/"
+
+// fetchTimeout bounds fetching a forwarded MR's branch.
+const fetchTimeout = 10 * time.Minute
+
+// NewForward builds a forward of a local bead: an MR forward for merge
+// request beads, an issue forward otherwise.
+func NewForward(town string, issue *beads.Issue) *Forward {
+	f := &Forward{
+		ID:          NewForwardID(town),
+		Kind:        KindIssue,
+		From:        town,
+		Source:      issue.ID,
+		Title:       issue.Title,
+		Description: issue.Description,
+		Priority:    issue.Priority,
+		CreatedAt:   time.Now().UTC(),
+	}
+	for _, l := range issue.Labels {
+		if !strings.HasPrefix(l, "gt:") {
+			f.Labels = append(f.Labels, l)
+		}
+	}
+	if fields := beads.ParseMRFields(issue); fields != nil && beads.HasLabel(issue, "gt:merge-request") {
+		f.Kind = KindMR
+		f.Rig = fields.Rig
+		f.Branch = fields.Branch
+		f.Target = fields.Target
+	}
+	return f
+}
+
+// BeadsReceiver files forwards in a town's beads: issues in the requested
+// rig (or the town beads), MRs in the rig's merge queue after fetching
+// their branch into the rig's repo.
+type BeadsReceiver struct {
+	TownRoot string
+}
+
+func (r *BeadsReceiver) rigPath(rig string) (string, error) {
+	if rig == "" {
+		return r.TownRoot, nil
+	}
+	if strings.ContainsAny(rig, `/\`) || strings.HasPrefix(rig, ".") {
+		return "", fmt.Errorf("invalid rig name %q", rig)
+	}
+	path := filepath.Join(r.TownRoot, rig)
+	if _, err := os.Stat(filepath.Join(path, "config.json")); err != nil {
+		return "", fmt.Errorf("rig %s not found in this town", rig)
+	}
+	return path, nil
+}
+
+// File implements Receiver.
+func (r *BeadsReceiver) File(f *Forward) (string, error) {
+	path, err := r.rigPath(f.Rig)
+	if err != nil {
+		return "", err
+	}
+	origin := fmt.Sprintf("Forwarded from town %s (%s, %s).", f.From, f.Source, f.ID)
+	opts := beads.CreateOptions{
+		Title:    f.Title,
+		Priority: f.Priority,
+		Labels:   append([]string{LabelFederated}, f.Labels...),
+		Actor:    AssigneePrefix + f.From,
+	}
+	if f.Kind == KindIssue {
+		opts.Description = strings.TrimSpace(f.Description + "\n\n" + origin)
+	} else {
+		branch, err := r.fetchBranch(path, f)
+		if err != nil {
+			return "", err
+		}
+		target := f.Target
+		if target == "" {
+			target = "main"
+		}
+		// A regular (not ephemeral) bead, so it's still there to report
+		// how it closed when the sender asks.
+		opts.Labels = append(opts.Labels, "gt:merge-request")
+		opts.Description = beads.FormatMRFields(&beads.MRFields{
+			Branch: branch,
+			Target: target,
+			Worker: AssigneePrefix + f.From,
+			Rig:    f.Rig,
+		}) + "\n\n" + origin
+	}
+	b := beads.New(path)
+	var issue *beads.Issue
+	if f.Kind == KindIssue {
+		// Towns often forward the same problem; flag it if already filed.
+		issue, _, err = b.CreateChecked(opts)
+	} else {
+		issue, err = b.Create(opts)
+	}
+	if err != nil {
+		return "", err
+	}
+	return issue.ID, nil
+}
+
+// fetchBranch fetches a forwarded MR's branch into the rig's repo as
+// federation/<town>/<branch>, where the refinery can merge it.
+func (r *BeadsReceiver) fetchBranch(rigPath string, f *Forward) (string, error) {
+	g := git.NewGit(filepath.Join(rigPath, "mayor", "rig"))
+	if info, err := os.Stat(filepath.Join(rigPath, ".repo.git")); err == nil && info.IsDir() {
+		g = git.NewGitWithDir(filepath.Join(rigPath, ".repo.git"), "")
+	}
+	branch := AssigneePrefix + f.From + "/" + f.Branch
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	if err := g.FetchRefToBranch(ctx, f.Repo, "refs/heads/"+f.Branch, branch); err != nil {
+		return "", fmt.Errorf("fetching %s from %s: %w", f.Branch, f.Repo, err)
+	}
+	return branch, nil
+}
+
+// Status implements Receiver.
+func (r *BeadsReceiver) Status(f *Forward, bead string) (*Status, error) {
+	path, err := r.rigPath(f.Rig)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := beads.New(path).Show(bead)
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{State: issue.Status, Assignee: issue.Assignee, UpdatedAt: time.Now().UTC()}
+	if issue.Status != StateOpen && issue.Status != StateInProgress {
+		st.State = StateClosed
+	}
+	if fields := beads.ParseMRFields(issue); fields != nil {
+		st.CloseReason = fields.CloseReason
+		st.MergeCommit = fields.MergeCommit
+	}
+	return st, nil
+}
+
+// BeadsReflector returns a Reflector that mirrors a peer's status onto the
+// forwarded bead in this town: it comments as the forward progresses and
+// closes the bead when the peer closes it. An MR the peer closes without
+// merging is handed back to the local merge queue.
+func BeadsReflector(townRoot string) Reflector {
+	return func(t *Tracked, st *Status) error {
+		bd, err := sourceBeads(townRoot, t.Forward.Source)
+		if err != nil {
+			return err
+		}
+		where := fmt.Sprintf("town %s (%s)", t.Peer, st.Bead)
+
+		if st.State != StateClosed {
+			msg := "Filed in " + where
+			if st.State == StateInProgress {
+				msg = "In progress in " + where
+			}
+			if st.Assignee != "" {
+				msg += ", assigned to " + st.Assignee
+			}
+			_, err := bd.Run("comments", "add", t.Forward.Source, msg)
+			return err
+		}
+
+		if t.Forward.Kind == KindMR && st.CloseReason != "merged" {
+			reason := st.CloseReason
+			if reason == "" {
+				reason = "closed"
+			}
+			open, unassigned := "open", ""
+			if err := bd.Update(t.Forward.Source, beads.UpdateOptions{
+				Status:       &open,
+				Assignee:     &unassigned,
+				RemoveLabels: []string{LabelFederated},
+			}); err != nil {
+				return err
+			}
+			_, err := bd.Run("comments", "add", t.Forward.Source,
+				fmt.Sprintf("Not merged in %s (%s); returned to the local merge queue", where, reason))
+			return err
+		}
+
+		if t.Forward.Kind == KindMR {
+			issue, err := bd.Show(t.Forward.Source)
+			if err != nil {
+				return err
+			}
+			fields := beads.ParseMRFields(issue)
+			if fields == nil {
+				fields = &beads.MRFields{}
+			}
+			fields.MergeCommit = st.MergeCommit
+			fields.CloseReason = "merged"
+			desc := beads.SetMRFields(issue, fields)
+			if err := bd.Update(t.Forward.Source, beads.UpdateOptions{Description: &desc}); err != nil {
+				return err
+			}
+		}
+		reason := "closed in " + where
+		if st.CloseReason != "" {
+			reason += ": " + st.CloseReason
+		}
+		if t.Forward.Kind == KindMR {
+			reason = "merged in " + where
+		}
+		return bd.CloseWithReason(reason, t.Forward.Source)
+	}
+}
+
+// sourceBeads returns the beads holding a local bead, found by its prefix.
+func sourceBeads(townRoot, id string) (*beads.Beads, error) {
+	if prefix := beads.ExtractPrefix(id); prefix != "" {
+		if rigPath := beads.GetRigPathForPrefix(townRoot, prefix); rigPath != "" {
+			return beads.New(rigPath), nil
+		}
+	}
+	return beads.New(townRoot), nil
+}
@@ -0,0 +1,146 @@
+// Package federation links Gas Towns so that one town can hand MRs and
+// issues to another (for example a dedicated high-compute build town) and
+// see how they end.
+//
+// Each town lists its peers in mayor/federation.json, sharing a secret with
+// each. Forwarding a bead queues it in the sender's outbox; Sync delivers
+// queued forwards, retrying with backoff while a peer is unreachable, and
+// polls the status of delivered ones. Receivers file each forward once
+// (forwards are idempotent by ID) and report the filed bead's status, which
+// Sync hands to a Reflector to mirror onto the local bead.
+//
+// Requests are authenticated with an HMAC-SHA256 signature over the sending
+// town, a timestamp and the request (see Sign).
+package federation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrNotConfigured indicates the town has no federation config.
+var ErrNotConfigured = errors.New("federation is not configured")
+
+// DefaultListen is the address gt federation serve listens on by default.
+const DefaultListen = ":7421"
+
+// Config is a town's federation configuration.
+type Config struct {
+	// Town is this town's name as its peers know it.
+	Town string `json:"town"`
+
+	// Listen is the address the federation server listens on.
+	// Default: DefaultListen.
+	Listen string `json:"listen,omitempty"`
+
+	// Peers are the towns this town exchanges work with, keyed by name.
+	Peers map[string]*Peer `json:"peers"`
+}
+
+// Peer is another town.
+type Peer struct {
+	// URL is the base URL of the peer's federation server. A peer without
+	// one can forward work here but can't be forwarded to.
+	URL string `json:"url,omitempty"`
+
+	// Secret is shared with the peer and signs requests both ways.
+	// SecretEnv names an environment variable holding it instead, keeping
+	// it out of the config file.
+	Secret    string `json:"secret,omitempty"`
+	SecretEnv string `json:"secret_env,omitempty"`
+}
+
+func (p *Peer) secret() string {
+	if p.SecretEnv != "" {
+		return os.Getenv(p.SecretEnv)
+	}
+	return p.Secret
+}
+
+// ConfigPath returns the path to the federation config file for a town.
+func ConfigPath(townRoot string) string {
+	return filepath.Join(townRoot, "mayor", "federation.json")
+}
+
+// LoadConfig loads and validates the federation config of a town.
+func LoadConfig(townRoot string) (*Config, error) {
+	data, err := os.ReadFile(ConfigPath(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w (create %s)", ErrNotConfigured, ConfigPath(townRoot))
+		}
+		return nil, fmt.Errorf("reading federation config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing federation config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// SaveConfig writes the federation config of a town.
+func SaveConfig(townRoot string, cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling federation config: %w", err)
+	}
+	data = append(data, '\n')
+	return os.WriteFile(ConfigPath(townRoot), data, 0600)
+}
+
+// Validate checks the config: the town must be named and every peer needs
+// a secret and, if it has a URL, an http(s) one.
+func (c *Config) Validate() error {
+	if c.Town == "" {
+		return fmt.Errorf("federation config: town is required")
+	}
+	for name, p := range c.Peers {
+		if p == nil {
+			return fmt.Errorf("federation peer %s: empty config", name)
+		}
+		if name == c.Town {
+			return fmt.Errorf("federation peer %s: a town can't peer with itself", name)
+		}
+		if p.Secret == "" && p.SecretEnv == "" {
+			return fmt.Errorf("federation peer %s: secret or secret_env is required", name)
+		}
+		if p.URL != "" {
+			u, err := url.Parse(p.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("federation peer %s: url %q must be an http(s) URL", name, p.URL)
+			}
+		}
+	}
+	return nil
+}
+
+// ListenAddr returns the address the federation server listens on.
+func (c *Config) ListenAddr() string {
+	if c.Listen != "" {
+		return c.Listen
+	}
+	return DefaultListen
+}
+
+// PeerNames returns the names of the configured peers, sorted.
+func (c *Config) PeerNames() []string {
+	names := make([]string, 0, len(c.Peers))
+	for name := range c.Peers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runtimeDir is where a town keeps its federation state.
+func runtimeDir(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "federation")
+}
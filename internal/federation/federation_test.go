@@ -0,0 +1,300 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReceiver files forwards in memory.
+type fakeReceiver struct {
+	mu     sync.Mutex
+	filed  []string
+	states map[string]*Status
+}
+
+func (r *fakeReceiver) File(f *Forward) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bead := "bf-" + f.Source
+	r.filed = append(r.filed, f.ID)
+	if r.states == nil {
+		r.states = make(map[string]*Status)
+	}
+	r.states[bead] = &Status{State: StateOpen}
+	return bead, nil
+}
+
+func (r *fakeReceiver) Status(f *Forward, bead string) (*Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := *r.states[bead]
+	return &st, nil
+}
+
+func (r *fakeReceiver) set(bead string, st *Status) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[bead] = st
+}
+
+// testPair returns the sending town's config and root, and a server for
+// the receiving town "buildfarm", which peers with "home".
+func testPair(t *testing.T) (*Config, string, *httptest.Server, *fakeReceiver) {
+	t.Helper()
+	recv := &fakeReceiver{}
+	srv := &Server{
+		Config: &Config{Town: "buildfarm", Peers: map[string]*Peer{
+			"home":  {Secret: "s3cret"},
+			"other": {Secret: "other-secret"},
+		}},
+		TownRoot: t.TempDir(),
+		Receiver: recv,
+	}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	home := &Config{Town: "home", Peers: map[string]*Peer{
+		"buildfarm": {URL: ts.URL, Secret: "s3cret"},
+	}}
+	return home, t.TempDir(), ts, recv
+}
+
+func issueForward(town, source string) *Forward {
+	return &Forward{ID: NewForwardID(town), Kind: KindIssue, From: town, Source: source, Title: "Build the thing"}
+}
+
+func TestLoadConfig(t *testing.T) {
+	townRoot := t.TempDir()
+	if _, err := LoadConfig(townRoot); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("LoadConfig without a file = %v, want ErrNotConfigured", err)
+	}
+	if err := os.MkdirAll(filepath.Join(townRoot, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Town: "home", Peers: map[string]*Peer{"buildfarm": {URL: "https://bf.example:7421", SecretEnv: "GT_FED_BF"}}}
+	if err := SaveConfig(townRoot, cfg); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadConfig(townRoot)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if got.ListenAddr() != DefaultListen || got.Peers["buildfarm"].SecretEnv != "GT_FED_BF" {
+		t.Errorf("LoadConfig = %+v", got)
+	}
+
+	for name, bad := range map[string]*Config{
+		"no town":   {Peers: map[string]*Peer{"bf": {Secret: "x"}}},
+		"no secret": {Town: "home", Peers: map[string]*Peer{"bf": {URL: "https://bf.example"}}},
+		"bad url":   {Town: "home", Peers: map[string]*Peer{"bf": {URL: "bf.example", Secret: "x"}}},
+		"self":      {Town: "home", Peers: map[string]*Peer{"home": {Secret: "x"}}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%s: Validate accepted %+v", name, bad)
+		}
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	cfg := &Config{Town: "buildfarm", Peers: map[string]*Peer{"home": {Secret: "s3cret"}}}
+	now := time.Now()
+	body := []byte(`{"id":"fwd-1"}`)
+	sign := func(secret, town string, ts time.Time, path string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		signRequest(req, secret, town, ts, body)
+		return req
+	}
+
+	if town, err := verifyRequest(cfg, sign("s3cret", "home", now, "/v1/forwards"), body, now); err != nil || town != "home" {
+		t.Errorf("valid request: town %q, err %v", town, err)
+	}
+	for name, req := range map[string]*http.Request{
+		"wrong secret": sign("guess", "home", now, "/v1/forwards"),
+		"unknown town": sign("s3cret", "stranger", now, "/v1/forwards"),
+		"stale":        sign("s3cret", "home", now.Add(-MaxClockSkew-time.Minute), "/v1/forwards"),
+	} {
+		if _, err := verifyRequest(cfg, req, body, now); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: err = %v, want ErrUnauthorized", name, err)
+		}
+	}
+	// The signature covers the path and body.
+	req := sign("s3cret", "home", now, "/v1/forwards")
+	req.URL.Path = "/v1/forwards/fwd-1"
+	if _, err := verifyRequest(cfg, req, body, now); err == nil {
+		t.Error("accepted a signature for another path")
+	}
+	if _, err := verifyRequest(cfg, sign("s3cret", "home", now, "/v1/forwards"), []byte(`{}`), now); err == nil {
+		t.Error("accepted a signature for another body")
+	}
+}
+
+func TestServer_FilesForwardOnce(t *testing.T) {
+	home, _, ts, recv := testPair(t)
+	c := NewClient("home", home.Peers["buildfarm"])
+	f := issueForward("home", "gt-1")
+
+	for i := 0; i < 2; i++ {
+		st, err := c.Send(context.Background(), f)
+		if err != nil {
+			t.Fatalf("Send #%d: %v", i+1, err)
+		}
+		if st.ID != f.ID || st.Bead != "bf-gt-1" || st.State != StateOpen {
+			t.Errorf("Send #%d status = %+v", i+1, st)
+		}
+	}
+	if len(recv.filed) != 1 {
+		t.Errorf("filed %d times, want once", len(recv.filed))
+	}
+
+	// Only the sending town can see the forward.
+	other := &Client{Town: "other", URL: ts.URL, Secret: "other-secret", HTTP: http.DefaultClient}
+	var he *HTTPError
+	if _, err := other.Status(context.Background(), f.ID); !errors.As(err, &he) || he.Code != http.StatusNotFound {
+		t.Errorf("status from another town: %v, want 404", err)
+	}
+	// A town can't send forwards in another's name.
+	forged := issueForward("home", "gt-2")
+	if _, err := other.Send(context.Background(), forged); !errors.As(err, &he) || he.Code != http.StatusForbidden {
+		t.Errorf("forged forward: %v, want 403", err)
+	}
+	bad := &Client{Town: "home", URL: ts.URL, Secret: "guess", HTTP: http.DefaultClient}
+	if _, err := bad.Send(context.Background(), f); !errors.As(err, &he) || he.Code != http.StatusUnauthorized {
+		t.Errorf("bad secret: %v, want 401", err)
+	}
+}
+
+func TestSync_DeliversAfterPeerComesBack(t *testing.T) {
+	home, townRoot, _, recv := testPair(t)
+	url := home.Peers["buildfarm"].URL
+	home.Peers["buildfarm"].URL = "http://127.0.0.1:1" // Offline
+	f := issueForward("home", "gt-1")
+	if err := Enqueue(townRoot, home, "buildfarm", f); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	var reflected []string
+	syncer := &Syncer{
+		Config:   home,
+		TownRoot: townRoot,
+		Reflect: func(t *Tracked, st *Status) error {
+			reflected = append(reflected, st.State)
+			return nil
+		},
+		Now: func() time.Time { return now },
+	}
+
+	report, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Offline) != 1 || report.Pending != 1 {
+		t.Fatalf("offline sync report = %+v", report)
+	}
+	outbox, _ := Outbox(townRoot)
+	if tr := outbox[f.ID]; tr.Attempts != 1 || tr.NextAttempt == nil || tr.State() != "queued" {
+		t.Fatalf("after offline sync: %+v", tr)
+	}
+
+	// Back online, but the retry isn't due yet.
+	home.Peers["buildfarm"].URL = url
+	if report, _ := syncer.Sync(context.Background()); len(report.Delivered) != 0 || report.Pending != 1 {
+		t.Errorf("sync before retry = %+v, want it held back", report)
+	}
+
+	now = now.Add(RetryBackoff)
+	report, err = syncer.Sync(context.Background())
+	if err != nil || len(report.Delivered) != 1 {
+		t.Fatalf("sync after retry = %+v, %v", report, err)
+	}
+	if len(recv.filed) != 1 || strings.Join(reflected, ",") != StateOpen {
+		t.Errorf("filed %v, reflected %v", recv.filed, reflected)
+	}
+
+	// Unchanged status is not reflected again; a close is, once.
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	recv.set("bf-gt-1", &Status{State: StateClosed, CloseReason: "done"})
+	for i := 0; i < 2; i++ {
+		if _, err := syncer.Sync(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Join(reflected, ",") != "open,closed" {
+		t.Errorf("reflected %v, want open then closed", reflected)
+	}
+	outbox, _ = Outbox(townRoot)
+	if tr := outbox[f.ID]; !tr.Done || tr.Status.CloseReason != "done" {
+		t.Errorf("after close: %+v", tr)
+	}
+}
+
+func TestSync_RetriesFailedReflect(t *testing.T) {
+	home, townRoot, _, _ := testPair(t)
+	f := issueForward("home", "gt-1")
+	if err := Enqueue(townRoot, home, "buildfarm", f); err != nil {
+		t.Fatal(err)
+	}
+	fail := true
+	syncer := &Syncer{Config: home, TownRoot: townRoot, Reflect: func(*Tracked, *Status) error {
+		if fail {
+			return errors.New("bd unavailable")
+		}
+		return nil
+	}}
+	report, _ := syncer.Sync(context.Background())
+	if len(report.Delivered) != 1 || len(report.Errors) != 1 || len(report.Updated) != 0 {
+		t.Fatalf("report = %+v", report)
+	}
+	fail = false
+	if report, _ := syncer.Sync(context.Background()); len(report.Updated) != 1 {
+		t.Errorf("retry report = %+v, want the status reflected", report)
+	}
+}
+
+func TestSync_RejectedForwardFails(t *testing.T) {
+	home, townRoot, _, _ := testPair(t)
+	f := &Forward{ID: NewForwardID("home"), Kind: KindMR, From: "home", Source: "gt-mr1", Rig: "gastown", Repo: "/no/such/repo", Branch: "b"}
+	if err := Enqueue(townRoot, home, "buildfarm", f); err != nil {
+		t.Fatal(err)
+	}
+	// The peer checks forwards too; break this one after queueing.
+	if err := updateState(OutboxPath(townRoot), func(outbox map[string]*Tracked) (bool, error) {
+		outbox[f.ID].Forward.Kind = "patch"
+		return true, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	syncer := &Syncer{Config: home, TownRoot: townRoot}
+	if report, _ := syncer.Sync(context.Background()); len(report.Failed) != 1 {
+		t.Fatalf("report = %+v, want the forward failed", report)
+	}
+	outbox, _ := Outbox(townRoot)
+	if tr := outbox[f.ID]; tr.State() != "failed" || !strings.Contains(tr.Failed, "400") {
+		t.Errorf("tracked = %+v", tr)
+	}
+	if report, _ := syncer.Sync(context.Background()); len(report.Failed) != 0 || report.Pending != 0 {
+		t.Errorf("failed forward retried: %+v", report)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  RetryBackoff,
+		2:  2 * RetryBackoff,
+		3:  4 * RetryBackoff,
+		20: MaxRetryBackoff,
+	} {
+		if got := retryBackoff(attempts); got != want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kinds of forward.
+const (
+	KindIssue = "issue" // An issue for the peer to work
+	KindMR    = "mr"    // A branch for the peer's refinery to merge
+)
+
+// Remote bead states reported in a Status.
+const (
+	StateOpen       = "open"
+	StateInProgress = "in_progress"
+	StateClosed     = "closed"
+)
+
+// Forward is a unit of work one town hands another.
+type Forward struct {
+	// ID identifies the forward across both towns. Receivers file each
+	// ID once, so a sender may deliver a forward as often as it likes.
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+
+	// From is the sending town and Source the bead the work came from.
+	From   string `json:"from"`
+	Source string `json:"source"`
+
+	// Rig asks the receiver to file the work in one of its rigs; empty
+	// files it with the receiver's town beads (issues only).
+	Rig string `json:"rig,omitempty"`
+
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Priority    int      `json:"priority"`
+	Labels      []string `json:"labels,omitempty"`
+
+	// Repo, Branch and Target describe an MR forward: the receiver fetches
+	// Branch from Repo and queues it to merge into Target.
+	Repo   string `json:"repo,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Target string `json:"target,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that a forward carries what its kind needs.
+func (f *Forward) Validate() error {
+	if f.ID == "" || f.From == "" || f.Source == "" {
+		return fmt.Errorf("forward needs an id, from and source")
+	}
+	switch f.Kind {
+	case KindIssue:
+	case KindMR:
+		if f.Rig == "" || f.Repo == "" || f.Branch == "" {
+			return fmt.Errorf("mr forward %s needs a rig, repo and branch", f.ID)
+		}
+	default:
+		return fmt.Errorf("forward %s: unknown kind %q", f.ID, f.Kind)
+	}
+	return nil
+}
+
+// Status is the receiver's view of a forward.
+type Status struct {
+	ID string `json:"id"`
+
+	// Bead is the receiver's bead for the forward.
+	Bead        string `json:"bead"`
+	State       string `json:"state"`
+	Assignee    string `json:"assignee,omitempty"`
+	CloseReason string `json:"close_reason,omitempty"`
+
+	// MergeCommit is set once an MR forward has merged.
+	MergeCommit string `json:"merge_commit,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Changed reports whether s differs from prev in a way worth reflecting.
+func (s *Status) Changed(prev *Status) bool {
+	if prev == nil {
+		return true
+	}
+	return s.Bead != prev.Bead || s.State != prev.State || s.Assignee != prev.Assignee ||
+		s.CloseReason != prev.CloseReason || s.MergeCommit != prev.MergeCommit
+}
+
+// NewForwardID returns a new forward ID for a forward sent by town.
+func NewForwardID(town string) string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return "fwd-" + town + "-" + hex.EncodeToString(b)
+}
+
+// HTTPError is a non-2xx response from a peer.
+type HTTPError struct {
+	Code    int
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("peer returned %d: %s", e.Code, e.Message)
+}
+
+// Retryable reports whether err is worth retrying later: network failures
+// and server-side or throttling responses are, rejections are not.
+// Authentication failures are retried too: they come from a secret or a
+// clock out of step, which the towns' operators can fix.
+func Retryable(err error) bool {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		switch he.Code {
+		case http.StatusUnauthorized, http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		}
+		return he.Code >= 500
+	}
+	return err != nil
+}
+
+// Client talks to one peer's federation server.
+type Client struct {
+	Town   string // This town
+	URL    string // Peer base URL
+	Secret string
+	HTTP   *http.Client
+	Now    func() time.Time
+}
+
+// NewClient returns a client for sending to peer as town.
+func NewClient(town string, peer *Peer) *Client {
+	return &Client{
+		Town:   town,
+		URL:    peer.URL,
+		Secret: peer.secret(),
+		HTTP:   &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Send delivers a forward and returns the receiver's status for it.
+func (c *Client) Send(ctx context.Context, f *Forward) (*Status, error) {
+	body, err := json.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling forward: %w", err)
+	}
+	var st Status
+	if err := c.do(ctx, http.MethodPost, "/v1/forwards", body, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Status fetches the receiver's status for a forward.
+func (c *Client) Status(ctx context.Context, id string) (*Status, error) {
+	var st Status
+	if err := c.do(ctx, http.MethodGet, "/v1/forwards/"+url.PathEscape(id), nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any) error {
+	if c.URL == "" {
+		return &HTTPError{Code: http.StatusBadRequest, Message: "peer has no url"}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+	signRequest(req, c.Secret, c.Town, now(), body)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		msg := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			msg = e.Error
+		}
+		return &HTTPError{Code: resp.StatusCode, Message: msg}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parsing peer response: %w", err)
+	}
+	return nil
+}
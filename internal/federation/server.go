@@ -0,0 +1,185 @@
+package federation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// maxBody caps request and response bodies.
+const maxBody = 1 << 20
+
+// Receiver files forwarded work in the receiving town's beads.
+type Receiver interface {
+	// File files a forward and returns the bead it was filed as.
+	File(f *Forward) (string, error)
+
+	// Status reports the state of the bead a forward was filed as.
+	Status(f *Forward, bead string) (*Status, error)
+}
+
+// Received is an inbox entry: a forward this town has filed.
+type Received struct {
+	Forward    *Forward  `json:"forward"`
+	Bead       string    `json:"bead"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// InboxPath returns the path of a town's federation inbox.
+func InboxPath(townRoot string) string {
+	return filepath.Join(runtimeDir(townRoot), "inbox.json")
+}
+
+// Inbox returns the forwards a town has received, keyed by forward ID.
+func Inbox(townRoot string) (map[string]*Received, error) {
+	inbox := make(map[string]*Received)
+	if err := readState(InboxPath(townRoot), &inbox); err != nil {
+		return nil, err
+	}
+	return inbox, nil
+}
+
+// Server is a town's federation endpoint. It files forwards from peers
+// and answers their status queries:
+//
+//	POST /v1/forwards        file a Forward, returning its Status
+//	GET  /v1/forwards/{id}   the Status of a forward
+type Server struct {
+	Config   *Config
+	TownRoot string
+	Receiver Receiver
+
+	// Now returns the current time; nil uses time.Now.
+	Now func() time.Time
+}
+
+// Handler returns the server's HTTP handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/forwards", s.handleForward)
+	mux.HandleFunc("GET /v1/forwards/{id}", s.handleStatus)
+	return mux
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// authenticate reads and verifies a request, returning the sending town
+// and the body. It writes the error response itself when it fails.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (string, []byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return "", nil, false
+	}
+	town, err := verifyRequest(s.Config, r, body, s.now())
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return "", nil, false
+	}
+	return town, body, true
+}
+
+func (s *Server) handleForward(w http.ResponseWriter, r *http.Request) {
+	town, body, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var f Forward
+	if err := json.Unmarshal(body, &f); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parsing forward: %w", err))
+		return
+	}
+	if f.From != town {
+		writeError(w, http.StatusForbidden, fmt.Errorf("forward from %q sent by %s", f.From, town))
+		return
+	}
+	if err := f.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rec, err := s.file(&f)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeStatus(w, rec)
+}
+
+// file files f unless the inbox shows it was filed already, so senders
+// can retry a forward whose response they never saw.
+func (s *Server) file(f *Forward) (*Received, error) {
+	var rec *Received
+	err := updateState(InboxPath(s.TownRoot), func(inbox map[string]*Received) (bool, error) {
+		if prev, ok := inbox[f.ID]; ok {
+			if prev.Forward.From != f.From {
+				return false, fmt.Errorf("forward %s already received from %s", f.ID, prev.Forward.From)
+			}
+			rec = prev
+			return false, nil
+		}
+		bead, err := s.Receiver.File(f)
+		if err != nil {
+			return false, fmt.Errorf("filing forward %s: %w", f.ID, err)
+		}
+		rec = &Received{Forward: f, Bead: bead, ReceivedAt: s.now()}
+		inbox[f.ID] = rec
+		return true, nil
+	})
+	return rec, err
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	town, _, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+	inbox, err := Inbox(s.TownRoot)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	id := r.PathValue("id")
+	rec, ok := inbox[id]
+	// Only the sender may see a forward; others get the same answer as
+	// for one that doesn't exist.
+	if !ok || rec.Forward.From != town {
+		writeError(w, http.StatusNotFound, fmt.Errorf("forward %s not found", id))
+		return
+	}
+	s.writeStatus(w, rec)
+}
+
+func (s *Server) writeStatus(w http.ResponseWriter, rec *Received) {
+	st, err := s.Receiver.Status(rec.Forward, rec.Bead)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("status of %s: %w", rec.Bead, err))
+		return
+	}
+	st.ID = rec.Forward.ID
+	st.Bead = rec.Bead
+	writeJSON(w, http.StatusOK, st)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	if errors.Is(err, ErrUnauthorized) {
+		// Don't tell an unauthenticated caller which check failed.
+		err = ErrUnauthorized
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,65 @@
+package federation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/steveyegge/gastown/internal/lock"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// readState reads a JSON state file into v, leaving v alone if the file
+// doesn't exist yet.
+func readState(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// updateState runs fn on the map in a state file while holding the file's
+// lock, so the CLI, the daemon and the server can share it. The map is
+// written back when fn reports a change, even if fn also fails, so work
+// recorded before a failure isn't lost.
+func updateState[T any](path string, fn func(map[string]T) (bool, error)) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating federation state dir: %w", err)
+	}
+	unlock, err := lock.FlockAcquire(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m := make(map[string]T)
+	if err := readState(path, &m); err != nil {
+		return err
+	}
+	changed, fnErr := fn(m)
+	if changed {
+		if err := util.AtomicWriteJSON(path, m); err != nil {
+			return err
+		}
+	}
+	return fnErr
+}
+
+// sortedIDs returns the keys of a state map, sorted.
+func sortedIDs[T any](m map[string]T) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
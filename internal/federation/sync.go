@@ -0,0 +1,265 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// Delivery retry backoff: the first retry waits RetryBackoff, each later
+// one twice as long as the last, up to MaxRetryBackoff.
+const (
+	RetryBackoff    = 30 * time.Second
+	MaxRetryBackoff = time.Hour
+)
+
+// Tracked is an outbox entry: a forward this town sent, or will send once
+// the peer is reachable, and what the peer last reported about it.
+type Tracked struct {
+	Forward  *Forward  `json:"forward"`
+	Peer     string    `json:"peer"`
+	QueuedAt time.Time `json:"queued_at"`
+
+	// Delivered is when the peer accepted the forward.
+	Delivered *time.Time `json:"delivered,omitempty"`
+
+	// Status is the last remote status reflected locally. Done is set once
+	// a closed status has been reflected; the forward is then left alone.
+	Status *Status `json:"status,omitempty"`
+	Done   bool    `json:"done,omitempty"`
+
+	// Failed records a delivery the peer rejected outright. Failed
+	// forwards aren't retried.
+	Failed string `json:"failed,omitempty"`
+
+	Attempts    int        `json:"attempts,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+}
+
+// State summarizes where a tracked forward stands.
+func (t *Tracked) State() string {
+	switch {
+	case t.Failed != "":
+		return "failed"
+	case t.Delivered == nil:
+		return "queued"
+	case t.Status == nil:
+		return "delivered"
+	default:
+		return t.Status.State
+	}
+}
+
+// OutboxPath returns the path of a town's federation outbox.
+func OutboxPath(townRoot string) string {
+	return filepath.Join(runtimeDir(townRoot), "outbox.json")
+}
+
+// Outbox returns the forwards a town has sent or queued, keyed by ID.
+func Outbox(townRoot string) (map[string]*Tracked, error) {
+	outbox := make(map[string]*Tracked)
+	if err := readState(OutboxPath(townRoot), &outbox); err != nil {
+		return nil, err
+	}
+	return outbox, nil
+}
+
+// Enqueue queues a forward for delivery to peer on the next sync.
+func Enqueue(townRoot string, cfg *Config, peer string, f *Forward) error {
+	p, ok := cfg.Peers[peer]
+	if !ok {
+		return fmt.Errorf("unknown federation peer %q (known: %v)", peer, cfg.PeerNames())
+	}
+	if p.URL == "" {
+		return fmt.Errorf("federation peer %s has no url to forward to", peer)
+	}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	return updateState(OutboxPath(townRoot), func(outbox map[string]*Tracked) (bool, error) {
+		if _, ok := outbox[f.ID]; ok {
+			return false, fmt.Errorf("forward %s is already queued", f.ID)
+		}
+		outbox[f.ID] = &Tracked{Forward: f, Peer: peer, QueuedAt: time.Now()}
+		return true, nil
+	})
+}
+
+// Reflector mirrors a peer's status for a forward onto the local bead.
+// It is called once per status change; if it fails, the change is offered
+// again on the next sync.
+type Reflector func(t *Tracked, st *Status) error
+
+// SyncReport summarizes a sync.
+type SyncReport struct {
+	Delivered []string // Forwards delivered this sync
+	Updated   []string // Forwards whose remote status changed
+	Failed    []string // Forwards a peer rejected
+	Pending   int      // Forwards still waiting for delivery
+	Offline   []string // Peers that couldn't be reached
+	Errors    []string // Reflect and status errors
+}
+
+// Syncer delivers queued forwards and reflects the status of delivered
+// ones. Peers that can't be reached are skipped for the rest of the sync,
+// so one offline town doesn't hold up the others.
+type Syncer struct {
+	Config   *Config
+	TownRoot string
+	Reflect  Reflector
+
+	// Client returns the client for a peer; nil uses NewClient.
+	Client func(peer string) *Client
+
+	// Now returns the current time; nil uses time.Now.
+	Now func() time.Time
+}
+
+func (s *Syncer) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *Syncer) client(peer string) *Client {
+	if s.Client != nil {
+		return s.Client(peer)
+	}
+	return NewClient(s.Config.Town, s.Config.Peers[peer])
+}
+
+// Sync runs one pass over the outbox.
+func (s *Syncer) Sync(ctx context.Context) (*SyncReport, error) {
+	report := &SyncReport{}
+	offline := make(map[string]bool)
+	err := updateState(OutboxPath(s.TownRoot), func(outbox map[string]*Tracked) (bool, error) {
+		changed := false
+		for _, id := range sortedIDs(outbox) {
+			if ctx.Err() != nil {
+				return changed, ctx.Err()
+			}
+			t := outbox[id]
+			if t.Done || t.Failed != "" || offline[t.Peer] {
+				if t.Delivered == nil && t.Failed == "" {
+					report.Pending++
+				}
+				continue
+			}
+			if _, ok := s.Config.Peers[t.Peer]; !ok {
+				t.Failed = fmt.Sprintf("peer %s is no longer configured", t.Peer)
+				report.Failed = append(report.Failed, id)
+				changed = true
+				continue
+			}
+			if t.Delivered == nil {
+				if s.deliver(ctx, t, report, offline) {
+					changed = true
+				}
+				continue
+			}
+			if s.poll(ctx, t, report, offline) {
+				changed = true
+			}
+		}
+		return changed, nil
+	})
+	for peer := range offline {
+		report.Offline = append(report.Offline, peer)
+	}
+	return report, err
+}
+
+// deliver sends a queued forward whose retry time has come, reporting
+// whether t changed.
+func (s *Syncer) deliver(ctx context.Context, t *Tracked, report *SyncReport, offline map[string]bool) bool {
+	now := s.now()
+	if t.NextAttempt != nil && now.Before(*t.NextAttempt) {
+		report.Pending++
+		return false
+	}
+	t.Attempts++
+	st, err := s.client(t.Peer).Send(ctx, t.Forward)
+	if err != nil {
+		t.LastError = err.Error()
+		if !Retryable(err) {
+			t.Failed = err.Error()
+			t.NextAttempt = nil
+			report.Failed = append(report.Failed, t.Forward.ID)
+			return true
+		}
+		next := now.Add(retryBackoff(t.Attempts))
+		t.NextAttempt = &next
+		offline[t.Peer] = true
+		report.Pending++
+		return true
+	}
+	t.Delivered = &now
+	t.LastError = ""
+	t.NextAttempt = nil
+	report.Delivered = append(report.Delivered, t.Forward.ID)
+	s.reflect(t, st, report)
+	return true
+}
+
+// poll fetches a delivered forward's status and reflects it if it
+// changed, reporting whether t changed.
+func (s *Syncer) poll(ctx context.Context, t *Tracked, report *SyncReport, offline map[string]bool) bool {
+	st, err := s.client(t.Peer).Status(ctx, t.Forward.ID)
+	if err != nil {
+		var he *HTTPError
+		if errors.As(err, &he) && he.Code == http.StatusNotFound {
+			// The peer lost the forward (a wiped inbox, say). Forwards
+			// are idempotent, so deliver it again.
+			t.Delivered = nil
+			t.LastError = "peer no longer knows this forward; redelivering"
+			report.Pending++
+			return true
+		}
+		if Retryable(err) {
+			offline[t.Peer] = true
+		}
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: status: %v", t.Forward.ID, err))
+		if t.LastError != err.Error() {
+			t.LastError = err.Error()
+			return true
+		}
+		return false
+	}
+	t.LastError = ""
+	s.reflect(t, st, report)
+	return true
+}
+
+// reflect hands a changed status to the Reflector and records it once
+// reflected.
+func (s *Syncer) reflect(t *Tracked, st *Status, report *SyncReport) {
+	if !st.Changed(t.Status) {
+		return
+	}
+	if s.Reflect != nil {
+		if err := s.Reflect(t, st); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: reflect: %v", t.Forward.ID, err))
+			return
+		}
+	}
+	t.Status = st
+	t.Done = st.State == StateClosed
+	report.Updated = append(report.Updated, t.Forward.ID)
+}
+
+// retryBackoff returns how long to wait before delivery attempt n+1.
+func retryBackoff(attempts int) time.Duration {
+	d := RetryBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= MaxRetryBackoff {
+			return MaxRetryBackoff
+		}
+	}
+	return d
+}
@@ -3,13 +3,16 @@ package git
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // GitError contains raw output from a git command for agent observation.
@@ -107,6 +110,27 @@ func (g *Git) run(args ...string) (string, error) {
 	return strings.TrimSpace(stdout.String()), nil
 }
 
+// runContext executes a git command that is killed when ctx is done.
+func (g *Git) runContext(ctx context.Context, args ...string) (string, error) {
+	if g.gitDir != "" {
+		args = append([]string{"--git-dir=" + g.gitDir}, args...)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if g.workDir != "" {
+		cmd.Dir = g.workDir
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", g.wrapError(err, stdout.String(), stderr.String(), args)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
 // runWithEnv executes a git command with additional environment variables.
 func (g *Git) runWithEnv(args []string, extraEnv []string) (_ string, _ error) { //nolint:unparam // string return kept for consistency with Run()
 	if g.gitDir != "" {
@@ -393,6 +417,13 @@ func (g *Git) Checkout(ref string) error {
 	return err
 }
 
+// CheckoutDetach detaches HEAD at the current commit, leaving the index and
+// working tree as they are.
+func (g *Git) CheckoutDetach() error {
+	_, err := g.run("checkout", "--detach")
+	return err
+}
+
 // CheckoutNewBranch creates a new branch from startPoint and checks it out.
 // Equivalent to: git checkout -b <branch> <startPoint>
 func (g *Git) CheckoutNewBranch(branch, startPoint string) error {
@@ -419,6 +450,27 @@ func (g *Git) FetchBranch(remote, branch string) error {
 	return err
 }
 
+// FetchBranchToLocal fetches a branch from the remote directly into the local
+// branch of the same name, creating it if needed. The refspec is not forced,
+// so an existing local branch is only fast-forwarded; git refuses the update
+// if the branch has diverged or is checked out in a worktree.
+// The git process is killed if ctx is done before it finishes.
+func (g *Git) FetchBranchToLocal(ctx context.Context, remote, branch string) error {
+	refspec := "refs/heads/" + branch + ":refs/heads/" + branch
+	_, err := g.runContext(ctx, "fetch", remote, refspec)
+	return err
+}
+
+// FetchRefToBranch fetches a remote ref (e.g. "refs/pull/12/head") into the
+// local branch, creating or force-updating it: the ref may have been
+// rewritten since the last fetch.
+// The git process is killed if ctx is done before it finishes.
+func (g *Git) FetchRefToBranch(ctx context.Context, remote, ref, branch string) error {
+	refspec := "+" + ref + ":refs/heads/" + branch
+	_, err := g.runContext(ctx, "fetch", remote, refspec)
+	return err
+}
+
 // FetchBranchShallow fetches a single branch with --depth 1 and creates the
 // remote tracking ref (e.g. origin/<branch>). Use this on shallow single-branch
 // clones to add a branch that wasn't included in the initial clone.
@@ -483,6 +535,15 @@ func (g *Git) Push(remote, branch string, force bool) error {
 	return err
 }
 
+// PushForceWithLease force-pushes sha to the remote branch, but only if the
+// remote branch still points at expect. This guards rewinds against
+// clobbering commits that landed after expect was observed.
+func (g *Git) PushForceWithLease(remote, sha, branch, expect string) error {
+	ref := "refs/heads/" + branch
+	_, err := g.run("push", "--force-with-lease="+ref+":"+expect, remote, sha+":"+ref)
+	return err
+}
+
 // PushWithEnv pushes with additional environment variables.
 // Used by gt mq integration land to set GT_INTEGRATION_LAND=1, which the
 // pre-push hook checks to allow integration branch content landing on main.
@@ -508,6 +569,12 @@ func (g *Git) Commit(message string) error {
 	return err
 }
 
+// CommitSigned is Commit with the commit signed by key (see SignFlag).
+func (g *Git) CommitSigned(message, key string) error {
+	_, err := g.run("commit", SignFlag(key), "-m", message)
+	return err
+}
+
 // CommitAll stages all changes and commits.
 func (g *Git) CommitAll(message string) error {
 	_, err := g.run("commit", "-am", message)
@@ -707,6 +774,45 @@ func (g *Git) ConfigGet(key string) (string, error) {
 	return out, nil
 }
 
+// ConfigSet sets a git config key in the repository's local config.
+func (g *Git) ConfigSet(key, value string) error {
+	_, err := g.run("config", key, value)
+	return err
+}
+
+// GitPath returns the absolute path of name inside the git directory
+// (git rev-parse --git-path), which resolves correctly in worktrees.
+func (g *Git) GitPath(name string) (string, error) {
+	out, err := g.run("rev-parse", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(g.workDir, out)
+	}
+	return out, nil
+}
+
+// AmendTracked stages changes to tracked files and folds them into HEAD,
+// keeping its message.
+func (g *Git) AmendTracked() error {
+	if _, err := g.run("add", "-u"); err != nil {
+		return err
+	}
+	_, err := g.run("commit", "--amend", "--no-edit")
+	return err
+}
+
+// AmendTrackedSigned is AmendTracked with the amended commit signed by key
+// (see SignFlag).
+func (g *Git) AmendTrackedSigned(key string) error {
+	if _, err := g.run("add", "-u"); err != nil {
+		return err
+	}
+	_, err := g.run("commit", "--amend", "--no-edit", SignFlag(key))
+	return err
+}
+
 // Merge merges the given branch into the current branch.
 func (g *Git) Merge(branch string) error {
 	_, err := g.run("merge", branch)
@@ -719,6 +825,12 @@ func (g *Git) MergeNoFF(branch, message string) error {
 	return err
 }
 
+// MergeNoFFSigned is MergeNoFF with the merge commit signed by key (see SignFlag).
+func (g *Git) MergeNoFFSigned(branch, message, key string) error {
+	_, err := g.run("merge", "--no-ff", SignFlag(key), "-m", message, branch)
+	return err
+}
+
 // MergeFFOnly performs a fast-forward-only merge of the given ref into the current branch.
 // This ensures what you tested is exactly what lands — no merge commits are created.
 // Returns an error if the merge cannot be performed as a fast-forward.
@@ -741,6 +853,31 @@ func (g *Git) MergeSquash(branch, message string) error {
 	return err
 }
 
+// MergeSquashSigned is MergeSquash with the squash commit signed by key
+// (see SignFlag).
+func (g *Git) MergeSquashSigned(branch, message, key string) error {
+	if _, err := g.run("merge", "--squash", branch); err != nil {
+		return err
+	}
+	_, err := g.run("commit", SignFlag(key), "-m", message)
+	return err
+}
+
+// SignFlag returns the flag signing a commit with key: -S<key>, or -S for
+// the user.signingkey default when key is empty. key is a GPG key ID or,
+// with gpg.format=ssh, an SSH key (file).
+func SignFlag(key string) string {
+	return "-S" + key
+}
+
+// CanSign checks that commits can be signed with key (see SignFlag) by
+// signing a throwaway commit of HEAD's tree. Nothing references it, so it
+// is left for gc. The error carries git's (or the signer's) complaint.
+func (g *Git) CanSign(key string) error {
+	_, err := g.run("commit-tree", SignFlag(key), "-m", "signing probe", "HEAD^{tree}")
+	return err
+}
+
 // GetBranchCommitMessage returns the commit message of the HEAD commit on the given branch.
 // This is useful for preserving the original conventional commit message (feat:/fix:) when
 // performing squash merges.
@@ -748,12 +885,198 @@ func (g *Git) GetBranchCommitMessage(branch string) (string, error) {
 	return g.run("log", "-1", "--format=%B", branch)
 }
 
+// CommitMessages returns the full messages of the commits on branch that
+// are not on base, oldest first.
+func (g *Git) CommitMessages(base, branch string) ([]string, error) {
+	out, err := g.run("log", "--reverse", "--format=%B%x00", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []string
+	for _, msg := range strings.Split(out, "\x00") {
+		if msg = strings.TrimSpace(msg); msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+// CommitAuthors returns the authors of the commits on branch that are not
+// on base, oldest first, as "Name <email>".
+func (g *Git) CommitAuthors(base, branch string) ([]string, error) {
+	out, err := g.run("log", "--reverse", "--format=%aN <%aE>", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	var authors []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			authors = append(authors, line)
+		}
+	}
+	return authors, nil
+}
+
+// SeriesCommit is a commit on a branch with the files it changed.
+type SeriesCommit struct {
+	SHA     string
+	Message string
+	Files   []string // Empty for merge commits
+	Merge   bool
+}
+
+// CommitSeries returns the commits on branch that are not on base, oldest
+// first, with their full messages and the files each changed.
+func (g *Git) CommitSeries(base, branch string) ([]SeriesCommit, error) {
+	out, err := g.run("log", "--reverse", "--name-only", "--no-renames", "--format=%x1e%H %P%x00%B%x00", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	var commits []SeriesCommit
+	for _, rec := range strings.Split(out, "\x1e") {
+		parts := strings.SplitN(rec, "\x00", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		ids := strings.Fields(parts[0])
+		if len(ids) == 0 {
+			continue
+		}
+		c := SeriesCommit{SHA: ids[0], Message: strings.TrimSpace(parts[1]), Merge: len(ids) > 2}
+		for _, f := range strings.Split(parts[2], "\n") {
+			if f = strings.TrimSpace(f); f != "" {
+				c.Files = append(c.Files, f)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// MergeCommits returns the merge commits on branch that are not on base.
+// None means the branch's history since base is linear.
+func (g *Git) MergeCommits(base, branch string) ([]string, error) {
+	out, err := g.run("rev-list", "--merges", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// FirstParentCommits returns the commits on tip's first-parent line that
+// are not on base, newest first: the commits a squash, merge or rebase
+// added to the branch it landed on.
+func (g *Git) FirstParentCommits(base, tip string) ([]string, error) {
+	out, err := g.run("rev-list", "--first-parent", base+".."+tip)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// RevertNoCommit applies the inverse of commit to the index and working
+// tree without committing, so several reverts can be committed as one. A
+// merge commit is reverted against its first parent.
+func (g *Git) RevertNoCommit(commit string) error {
+	args := []string{"revert", "--no-commit"}
+	parents, err := g.run("rev-list", "--parents", "-n", "1", commit)
+	if err != nil {
+		return err
+	}
+	if len(strings.Fields(parents)) > 2 {
+		args = append(args, "-m", "1")
+	}
+	_, err = g.run(append(args, commit)...)
+	return err
+}
+
+// BranchCommits returns the non-merge commits on branch that are not on
+// base, oldest first.
+func (g *Git) BranchCommits(base, branch string) ([]string, error) {
+	out, err := g.run("rev-list", "--reverse", "--no-merges", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// UnpickedCommits returns the commits on branch that are not on the
+// current branch, oldest first, leaving out merges and commits whose change
+// the current branch already has (as git rebase would).
+func (g *Git) UnpickedCommits(branch string) ([]string, error) {
+	out, err := g.run("rev-list", "--reverse", "--no-merges", "--cherry-pick", "--right-only", "HEAD..."+branch)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// CherryPick applies commits onto the current branch in order, keeping
+// their messages and authors. Commits whose parent is already HEAD are
+// fast-forwarded to rather than recreated.
+func (g *Git) CherryPick(commits ...string) error {
+	_, err := g.run(append([]string{"cherry-pick", "--ff"}, commits...)...)
+	return err
+}
+
+// CherryPickSigned is CherryPick with every commit recreated and signed by
+// key (see SignFlag). Nothing is fast-forwarded, as that would keep
+// commits signed by someone else, or not at all.
+func (g *Git) CherryPickSigned(key string, commits ...string) error {
+	_, err := g.run(append([]string{"cherry-pick", SignFlag(key)}, commits...)...)
+	return err
+}
+
+// CherryPickRecorded applies commits onto the current branch in order,
+// recreating each with a "(cherry picked from commit ...)" line appended to
+// its message, as backports carry.
+func (g *Git) CherryPickRecorded(commits ...string) error {
+	_, err := g.run(append([]string{"cherry-pick", "-x"}, commits...)...)
+	return err
+}
+
+// AbortCherryPick aborts a cherry-pick in progress.
+func (g *Git) AbortCherryPick() error {
+	_, err := g.run("cherry-pick", "--abort")
+	return err
+}
+
 // RecentCommits returns the last n commits as one-line summaries (hash + subject).
 // Returns empty string if there are no commits or the repo is empty.
 func (g *Git) RecentCommits(n int) (string, error) {
 	return g.run("log", "--oneline", fmt.Sprintf("-%d", n))
 }
 
+// CommitTimes returns the committer times of the commits on any ref made at
+// or after since, newest first. An empty repo has none.
+func (g *Git) CommitTimes(since time.Time) ([]time.Time, error) {
+	out, err := g.run("log", "--all", "--format=%ct", fmt.Sprintf("--since=%d", since.Unix()))
+	if err != nil {
+		return nil, err
+	}
+	var times []time.Time
+	for _, line := range strings.Fields(out) {
+		if sec, err := strconv.ParseInt(line, 10, 64); err == nil {
+			times = append(times, time.Unix(sec, 0))
+		}
+	}
+	return times, nil
+}
+
+// LastCommitTime returns the committer time of the newest commit on any ref,
+// or the zero time if the repo has no commits.
+func (g *Git) LastCommitTime() (time.Time, error) {
+	out, err := g.run("log", "--all", "-1", "--format=%ct")
+	if err != nil || out == "" {
+		return time.Time{}, err
+	}
+	sec, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing commit time %q: %w", out, err)
+	}
+	return time.Unix(sec, 0), nil
+}
+
 // DeleteRemoteBranch deletes a branch on the remote.
 func (g *Git) DeleteRemoteBranch(remote, branch string) error {
 	_, err := g.run("push", remote, "--delete", branch)
@@ -850,6 +1173,50 @@ func (g *Git) CheckConflicts(source, target string) ([]string, error) {
 	return nil, nil
 }
 
+// ErrMergeTreeUnsupported is returned by CheckConflictsMergeTree when the
+// installed git predates `git merge-tree --write-tree` (git 2.38).
+var ErrMergeTreeUnsupported = errors.New("git merge-tree --write-tree not supported")
+
+// CheckConflictsMergeTree is CheckConflicts done in the object store with
+// `git merge-tree --write-tree`: it merges source into target (both
+// revisions) in memory and returns the conflicting files, or an empty
+// slice if the merge is clean. Unlike CheckConflicts it touches neither
+// the index nor the working tree, so it is cheap on large repositories and
+// safe to call with a dirty or busy working directory.
+func (g *Git) CheckConflictsMergeTree(source, target string) ([]string, error) {
+	_, err := g.run("merge-tree", "--write-tree", "--name-only", "--no-messages", target, source)
+	if err == nil {
+		return nil, nil
+	}
+	var ge *GitError
+	var exitErr *exec.ExitError
+	if !errors.As(err, &ge) || !errors.As(ge.Err, &exitErr) {
+		return nil, err
+	}
+	switch exitErr.ExitCode() {
+	case 1:
+		// Conflicts: the merged tree's ID, then one conflicting file per
+		// line. An unknown revision exits 1 too, with no tree.
+		lines := strings.Split(strings.TrimSpace(ge.Stdout), "\n")
+		if lines[0] == "" {
+			return nil, err
+		}
+		var conflicts []string
+		seen := make(map[string]bool)
+		for _, f := range lines[1:] {
+			if f != "" && !seen[f] {
+				seen[f] = true
+				conflicts = append(conflicts, f)
+			}
+		}
+		return conflicts, nil
+	case 129:
+		// Usage error: merge-tree without --write-tree.
+		return nil, ErrMergeTreeUnsupported
+	}
+	return nil, err
+}
+
 // runMergeCheck runs a git merge command and returns error info from both stdout and stderr.
 // ZFC: Returns GitError with raw output for agent observation.
 func (g *Git) runMergeCheck(args ...string) (string, error) {
@@ -894,6 +1261,12 @@ func (g *Git) GetConflictingFiles() ([]string, error) {
 	return result, nil
 }
 
+// DiffUnmerged returns the conflict hunks of a merge stopped on conflicts:
+// the combined diff of the unmerged files, conflict markers included.
+func (g *Git) DiffUnmerged() (string, error) {
+	return g.run("diff", "--diff-filter=U")
+}
+
 // AbortRebase aborts a rebase in progress.
 func (g *Git) AbortRebase() error {
 	_, err := g.run("rebase", "--abort")
@@ -979,6 +1352,18 @@ func (g *Git) RemoteBranchExists(remote, branch string) (bool, error) {
 	return out != "", nil
 }
 
+// RemoteBranchSHA returns the commit a branch points to on the remote, read
+// with ls-remote rather than from the remote-tracking ref. It returns an
+// empty string if the branch doesn't exist on the remote.
+func (g *Git) RemoteBranchSHA(remote, branch string) (string, error) {
+	out, err := g.run("ls-remote", "--heads", remote, "refs/heads/"+branch)
+	if err != nil {
+		return "", err
+	}
+	sha, _, _ := strings.Cut(out, "\t")
+	return strings.TrimSpace(sha), nil
+}
+
 // RemoteTrackingBranchExists checks if a remote-tracking branch ref exists locally
 // (e.g. refs/remotes/origin/main), without hitting the network.
 func (g *Git) RemoteTrackingBranchExists(remote, branch string) (bool, error) {
@@ -1042,6 +1427,138 @@ func (g *Git) Rev(ref string) (string, error) {
 	return g.run("rev-parse", ref)
 }
 
+// UpdateRef points ref at sha, creating the ref if needed.
+func (g *Git) UpdateRef(ref, sha string) error {
+	_, err := g.run("update-ref", ref, sha)
+	return err
+}
+
+// DeleteRef deletes ref.
+func (g *Git) DeleteRef(ref string) error {
+	_, err := g.run("update-ref", "-d", ref)
+	return err
+}
+
+// ListRefs returns all refs under prefix (e.g. "refs/gastown/") mapped to
+// the SHA they point at.
+func (g *Git) ListRefs(prefix string) (map[string]string, error) {
+	out, err := g.run("for-each-ref", "--format=%(refname) %(objectname)", prefix)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		name, sha, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if ok {
+			refs[name] = sha
+		}
+	}
+	return refs, nil
+}
+
+// DiffStat is one file's line counts in a diff.
+type DiffStat struct {
+	Path    string
+	Added   int
+	Deleted int
+	Binary  bool
+}
+
+// DiffNumstat returns per-file line counts for the changes on head since it
+// diverged from base (git diff base...head).
+func (g *Git) DiffNumstat(base, head string) ([]DiffStat, error) {
+	out, err := g.run("diff", "--numstat", "--no-renames", base+"..."+head)
+	if err != nil {
+		return nil, err
+	}
+	var stats []DiffStat
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		st := DiffStat{Path: fields[2]}
+		if fields[0] == "-" {
+			st.Binary = true
+		} else {
+			st.Added, _ = strconv.Atoi(fields[0])
+			st.Deleted, _ = strconv.Atoi(fields[1])
+		}
+		stats = append(stats, st)
+	}
+	return stats, nil
+}
+
+// DiffNameOnly returns the paths changed on head since it diverged from
+// base (git diff --name-only base...head). A rename lists both paths.
+func (g *Git) DiffNameOnly(base, head string) ([]string, error) {
+	out, err := g.run("diff", "--name-only", "--no-renames", base+"..."+head)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// FileSizes returns the size in bytes of each of paths in the tree of ref.
+// Paths not in the tree (deleted files) are left out.
+func (g *Git) FileSizes(ref string, paths []string) (map[string]int64, error) {
+	sizes := make(map[string]int64, len(paths))
+	if len(paths) == 0 {
+		return sizes, nil
+	}
+	out, err := g.run(append([]string{"ls-tree", "-l", "-z", ref, "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range strings.Split(out, "\x00") {
+		meta, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta) // mode type object size
+		if len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[path] = size
+	}
+	return sizes, nil
+}
+
+// LFSMigrateImport rewrites the commits on branch that are not on exclude
+// so files matching patterns are stored in Git LFS, adding the matching
+// .gitattributes entries. Requires git-lfs.
+func (g *Git) LFSMigrateImport(branch, exclude string, patterns []string) error {
+	_, err := g.run("lfs", "migrate", "import",
+		"--include="+strings.Join(patterns, ","),
+		"--include-ref=refs/heads/"+branch,
+		"--exclude-ref="+exclude)
+	return err
+}
+
+// Unlanded returns the commits in limit..head with no equivalent change (by
+// patch ID) in upstream, oldest first, as listed by git cherry. A commit
+// rebased onto upstream and landed there doesn't count as unlanded.
+func (g *Git) Unlanded(upstream, head, limit string) ([]string, error) {
+	out, err := g.run("cherry", upstream, head, limit)
+	if err != nil {
+		return nil, err
+	}
+	var shas []string
+	for _, line := range strings.Split(out, "\n") {
+		if sha, ok := strings.CutPrefix(line, "+ "); ok {
+			shas = append(shas, sha)
+		}
+	}
+	return shas, nil
+}
+
 // IsAncestor checks if ancestor is an ancestor of descendant.
 func (g *Git) IsAncestor(ancestor, descendant string) (bool, error) {
 	_, err := g.run("merge-base", "--is-ancestor", ancestor, descendant)
@@ -1317,6 +1834,25 @@ func (g *Git) CommitsAhead(base, branch string) (int, error) {
 	return count, nil
 }
 
+// BehindSince returns the number of commits on upstream that branch lacks,
+// and the commit time of the oldest of them: how long upstream has been
+// moving on without branch. The time is zero when branch isn't behind.
+func (g *Git) BehindSince(branch, upstream string) (int, time.Time, error) {
+	out, err := g.run("log", "--format=%ct", branch+".."+upstream)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if out == "" {
+		return 0, time.Time{}, nil
+	}
+	lines := strings.Split(out, "\n")
+	var secs int64
+	if _, err := fmt.Sscanf(lines[len(lines)-1], "%d", &secs); err != nil {
+		return 0, time.Time{}, fmt.Errorf("parsing commit time: %w", err)
+	}
+	return len(lines), time.Unix(secs, 0), nil
+}
+
 // CountCommitsBehind returns the number of commits that HEAD is behind the given ref.
 // For example, CountCommitsBehind("origin/main") returns how many commits
 // are on origin/main that are not on the current HEAD.
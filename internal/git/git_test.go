@@ -1,6 +1,8 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -365,6 +367,105 @@ func TestFetchBranch(t *testing.T) {
 	}
 }
 
+func TestFetchBranchToLocal(t *testing.T) {
+	localDir, _, mainBranch := initTestRepoWithRemote(t)
+	g := NewGit(localDir)
+
+	for _, args := range [][]string{
+		{"checkout", "-b", "feature"},
+		{"commit", "--allow-empty", "-m", "feature work"},
+		{"push", "origin", "feature"},
+		{"checkout", mainBranch},
+		{"branch", "-D", "feature"},
+	} {
+		if _, err := g.run(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	if err := g.FetchBranchToLocal(context.Background(), "origin", "feature"); err != nil {
+		t.Fatalf("FetchBranchToLocal: %v", err)
+	}
+	if exists, _ := g.BranchExists("feature"); !exists {
+		t.Error("expected feature to be created locally")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.FetchBranchToLocal(ctx, "origin", "feature"); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
+
+func TestUpdateRefListRefsDeleteRef(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+
+	head, err := g.Rev("HEAD")
+	if err != nil {
+		t.Fatalf("Rev: %v", err)
+	}
+	if err := g.UpdateRef("refs/gastown/test/a", head); err != nil {
+		t.Fatalf("UpdateRef: %v", err)
+	}
+	refs, err := g.ListRefs("refs/gastown/")
+	if err != nil {
+		t.Fatalf("ListRefs: %v", err)
+	}
+	if len(refs) != 1 || refs["refs/gastown/test/a"] != head {
+		t.Errorf("ListRefs = %v, want refs/gastown/test/a -> %s", refs, head)
+	}
+	if err := g.DeleteRef("refs/gastown/test/a"); err != nil {
+		t.Fatalf("DeleteRef: %v", err)
+	}
+	if refs, _ := g.ListRefs("refs/gastown/"); len(refs) != 0 {
+		t.Errorf("expected no refs after delete, got %v", refs)
+	}
+}
+
+func TestDiffNumstat(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	mainBranch, _ := g.CurrentBranch()
+
+	if err := g.CreateBranch("feature"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := g.Checkout("feature"); err != nil {
+		t.Fatalf("Checkout feature: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "feature.go"), []byte("package x\n\nvar A = 1\n"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := g.Add("feature.go"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := g.Commit("add feature file"); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	stats, err := g.DiffNumstat(mainBranch, "feature")
+	if err != nil {
+		t.Fatalf("DiffNumstat: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Path != "feature.go" || stats[0].Added != 3 || stats[0].Deleted != 0 {
+		t.Errorf("DiffNumstat = %+v, want feature.go +3", stats)
+	}
+
+	// Only changes since the branch point count: main has none.
+	if stats, _ := g.DiffNumstat("feature", mainBranch); len(stats) != 0 {
+		t.Errorf("DiffNumstat(feature, %s) = %+v, want none", mainBranch, stats)
+	}
+
+	paths, err := g.DiffNameOnly(mainBranch, "feature")
+	if err != nil || len(paths) != 1 || paths[0] != "feature.go" {
+		t.Errorf("DiffNameOnly = %v, %v, want [feature.go]", paths, err)
+	}
+	if paths, _ := g.DiffNameOnly("feature", mainBranch); len(paths) != 0 {
+		t.Errorf("DiffNameOnly(feature, %s) = %v, want none", mainBranch, paths)
+	}
+}
+
 func TestCheckConflicts_NoConflict(t *testing.T) {
 	dir := initTestRepo(t)
 	g := NewGit(dir)
@@ -485,6 +586,109 @@ func TestCheckConflicts_WithConflict(t *testing.T) {
 	}
 }
 
+func TestCheckConflictsMergeTree(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	mainBranch, _ := g.CurrentBranch()
+	commit := func(branch, file, content string) {
+		t.Helper()
+		if err := g.Checkout(branch); err != nil {
+			t.Fatalf("Checkout %s: %v", branch, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if err := g.Add(file); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if err := g.Commit("change " + file + " on " + branch); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+	}
+	for _, b := range []string{"clean", "conflict"} {
+		if err := g.CreateBranch(b); err != nil {
+			t.Fatalf("CreateBranch: %v", err)
+		}
+	}
+	commit("clean", "feature.txt", "feature content")
+	commit("conflict", "README.md", "# Feature changes\n")
+	commit(mainBranch, "README.md", "# Main changes\n")
+	head, _ := g.Rev("HEAD")
+
+	conflicts, err := g.CheckConflictsMergeTree("clean", mainBranch)
+	if errors.Is(err, ErrMergeTreeUnsupported) {
+		t.Skip("git merge-tree --write-tree not available")
+	}
+	if err != nil || len(conflicts) != 0 {
+		t.Errorf("CheckConflictsMergeTree(clean) = %v, %v; want none", conflicts, err)
+	}
+	conflicts, err = g.CheckConflictsMergeTree("conflict", mainBranch)
+	if err != nil || len(conflicts) != 1 || conflicts[0] != "README.md" {
+		t.Errorf("CheckConflictsMergeTree(conflict) = %v, %v; want [README.md]", conflicts, err)
+	}
+	if _, err := g.CheckConflictsMergeTree("no-such-branch", mainBranch); err == nil {
+		t.Error("CheckConflictsMergeTree accepted a missing branch")
+	}
+
+	// Neither the branch, HEAD nor the working tree moved.
+	if branch, _ := g.CurrentBranch(); branch != mainBranch {
+		t.Errorf("branch = %q, want %q", branch, mainBranch)
+	}
+	if after, _ := g.Rev("HEAD"); after != head {
+		t.Errorf("HEAD moved from %s to %s", head, after)
+	}
+	if status, _ := g.Status(); !status.Clean {
+		t.Error("expected clean working directory after CheckConflictsMergeTree")
+	}
+}
+
+func TestUnlanded(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+	base, _ := g.Rev("HEAD")
+	commit := func(file string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(file+"\n"), 0644); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if err := g.Add(file); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if err := g.Commit("add " + file); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+		sha, _ := g.Rev("HEAD")
+		return sha
+	}
+	if err := g.CreateBranch("stack"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := g.Checkout("stack"); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	picked := commit("feature.txt")
+
+	// upstream lands something else first, then the stack's commit rebased.
+	if _, err := g.run("checkout", "-b", "upstream", base); err != nil {
+		t.Fatalf("checkout upstream: %v", err)
+	}
+	commit("other.txt")
+	if _, err := g.run("cherry-pick", picked); err != nil {
+		t.Fatalf("cherry-pick: %v", err)
+	}
+	if unlanded, err := g.Unlanded("upstream", "stack", base); err != nil || len(unlanded) != 0 {
+		t.Errorf("Unlanded = %v, %v; want none", unlanded, err)
+	}
+
+	if err := g.Checkout("stack"); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	extra := commit("extra.txt")
+	if unlanded, err := g.Unlanded("upstream", "stack", base); err != nil || len(unlanded) != 1 || unlanded[0] != extra {
+		t.Errorf("Unlanded = %v, %v; want [%s]", unlanded, err, extra)
+	}
+}
+
 // TestCloneBareHasOriginRefs verifies that after CloneBare, origin/* refs
 // are available for worktree creation. This was broken before the fix:
 // bare clones had refspec configured but no fetch was run, so origin/main
@@ -0,0 +1,308 @@
+// Package githooks installs the git hooks Gas Town manages in rig repos:
+//
+//   - pre-push screens the pushed commits for unresolved conflict markers
+//     and unsquashed fixup!/squash! commits.
+//   - commit-msg adds Gastown-Actor and Gastown-Bead trailers from the
+//     agent's BD_ACTOR and GT_WORK_BEAD.
+//   - post-checkout registers linked worktrees, and the branch each has
+//     checked out, in <common dir>/gastown/worktrees.
+//
+// Each hook is a managed block, stamped with Version, inserted after the
+// shebang of the repo's hook. Whatever else the hook runs is left alone, so
+// installing is idempotent and safe over hooks users wrote themselves.
+package githooks
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version stamps the managed blocks. Bump it whenever a script changes so
+// Sync rewrites the blocks already installed.
+const Version = 1
+
+// Names lists the managed hooks.
+var Names = []string{"pre-push", "commit-msg", "post-checkout"}
+
+//go:embed scripts/*
+var scriptFS embed.FS
+
+const blockEnd = "# <<< gastown managed hook <<<"
+
+// blockRe matches a managed block of any version, with its trailing newline.
+var blockRe = regexp.MustCompile(`(?s)# >>> gastown managed hook v(\d+) >>>\n.*?` + regexp.QuoteMeta(blockEnd) + `\n?`)
+
+// Action is what Sync did, or would do, to one hook.
+type Action int
+
+const (
+	Unchanged Action = iota
+	Updated
+	Created
+)
+
+func (a Action) String() string {
+	switch a {
+	case Updated:
+		return "updated"
+	case Created:
+		return "created"
+	default:
+		return "unchanged"
+	}
+}
+
+// Result is the outcome of syncing one repo.
+type Result struct {
+	// Repo is the path Sync was given.
+	Repo string
+	// HooksDir is where the hooks were installed.
+	HooksDir string
+	// Hooks maps each hook name to what was done to it.
+	Hooks map[string]Action
+	// Skipped, when set, says why nothing was installed.
+	Skipped string
+}
+
+// Changed reports whether any hook was created or updated.
+func (r *Result) Changed() bool {
+	for _, a := range r.Hooks {
+		if a != Unchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// Sync installs or updates the managed hooks of the repo at repoPath, a
+// clone, worktree or bare repo. Hooks live in the common git dir, so one
+// Sync covers every worktree of a repo. With dryRun, Sync reports what it
+// would do without writing.
+//
+// Repos with core.hooksPath set are skipped: git ignores the common hooks
+// dir then, and the configured dir is usually tracked (.githooks).
+func Sync(repoPath string, dryRun bool) (*Result, error) {
+	result := &Result{Repo: repoPath, Hooks: make(map[string]Action)}
+	if hooksPath, _ := gitOutput(repoPath, "config", "--get", "core.hooksPath"); hooksPath != "" {
+		result.Skipped = "core.hooksPath is set to " + hooksPath
+		return result, nil
+	}
+	commonDir, err := CommonDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	result.HooksDir = filepath.Join(commonDir, "hooks")
+
+	for _, name := range Names {
+		action, err := syncHook(filepath.Join(result.HooksDir, name), name, dryRun)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", name, err)
+		}
+		result.Hooks[name] = action
+	}
+	return result, nil
+}
+
+// syncHook brings the managed block of the hook at path up to date.
+func syncHook(path, name string, dryRun bool) (Action, error) {
+	block, err := managedBlock(name)
+	if err != nil {
+		return Unchanged, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return Unchanged, fmt.Errorf("reading hook: %w", err)
+	}
+	existing := string(data)
+
+	action := Updated
+	var content string
+	switch {
+	case err != nil:
+		action = Created
+		content = "#!/bin/sh\n" + block
+	case blockRe.MatchString(existing):
+		loc := blockRe.FindStringIndex(existing)
+		content = existing[:loc[0]] + block + existing[loc[1]:]
+	case strings.HasPrefix(existing, "#!"):
+		nl := strings.Index(existing, "\n")
+		if nl == -1 {
+			content = existing + "\n" + block
+		} else {
+			content = existing[:nl+1] + block + existing[nl+1:]
+		}
+	default:
+		content = "#!/bin/sh\n" + block + existing
+	}
+	if content == existing {
+		return Unchanged, nil
+	}
+	if dryRun {
+		return action, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Unchanged, fmt.Errorf("creating hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		return Unchanged, fmt.Errorf("writing hook: %w", err)
+	}
+	// WriteFile keeps the mode of a hook that already existed.
+	if err := os.Chmod(path, 0755); err != nil {
+		return Unchanged, fmt.Errorf("making hook executable: %w", err)
+	}
+	return action, nil
+}
+
+// managedBlock returns the versioned block for hook name.
+func managedBlock(name string) (string, error) {
+	script, err := scriptFS.ReadFile("scripts/" + name)
+	if err != nil {
+		return "", fmt.Errorf("no managed script for %s", name)
+	}
+	return fmt.Sprintf("# >>> gastown managed hook v%d >>>\n%s%s\n", Version, script, blockEnd), nil
+}
+
+// InstalledVersion returns the version of the managed block in the hook at
+// path, or 0 when it has none.
+func InstalledVersion(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	m := blockRe.FindSubmatch(data)
+	if m == nil {
+		return 0
+	}
+	v, _ := strconv.Atoi(string(m[1]))
+	return v
+}
+
+// CommonDir returns the absolute git common dir of the repo at repoPath.
+func CommonDir(repoPath string) (string, error) {
+	dir, err := gitOutput(repoPath, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	return dir, nil
+}
+
+// Worktrees returns the linked worktrees registered by the post-checkout
+// hook in the repo at repoPath, mapped to the branch each last checked out
+// ("HEAD" when detached).
+func Worktrees(repoPath string) (map[string]string, error) {
+	commonDir, err := CommonDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(commonDir, "gastown", "worktrees"))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	worktrees := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if path, branch, ok := strings.Cut(scanner.Text(), "\t"); ok {
+			worktrees[path] = branch
+		}
+	}
+	return worktrees, scanner.Err()
+}
+
+// DiscoverRepos returns one path per rig repo in the town, whatever the
+// number of clones and worktrees sharing it.
+func DiscoverRepos(townRoot string) []string {
+	entries, err := os.ReadDir(townRoot)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			paths = append(paths, rigRepos(filepath.Join(townRoot, entry.Name()))...)
+		}
+	}
+	return uniqueRepos(paths)
+}
+
+// RigRepos returns one path per repo of the rig at rigPath.
+func RigRepos(rigPath string) []string {
+	return uniqueRepos(rigRepos(rigPath))
+}
+
+// uniqueRepos keeps the first of paths sharing a common git dir.
+func uniqueRepos(paths []string) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	for _, path := range paths {
+		commonDir, err := CommonDir(path)
+		if err != nil || seen[commonDir] {
+			continue
+		}
+		seen[commonDir] = true
+		repos = append(repos, path)
+	}
+	return repos
+}
+
+// rigRepos lists the places a rig keeps git repos: the shared bare repo,
+// the mayor and refinery clones, crew clones and polecat worktrees.
+func rigRepos(rigPath string) []string {
+	var paths []string
+	if isDir(filepath.Join(rigPath, ".repo.git")) {
+		paths = append(paths, filepath.Join(rigPath, ".repo.git"))
+	}
+	for _, clone := range []string{filepath.Join(rigPath, "mayor", "rig"), filepath.Join(rigPath, "refinery", "rig")} {
+		if exists(filepath.Join(clone, ".git")) {
+			paths = append(paths, clone)
+		}
+	}
+	crew, _ := filepath.Glob(filepath.Join(rigPath, "crew", "*", ".git"))
+	polecats, _ := filepath.Glob(filepath.Join(rigPath, "polecats", "*", "*", ".git"))
+	for _, gitPath := range append(crew, polecats...) {
+		paths = append(paths, filepath.Dir(gitPath))
+	}
+	return paths
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
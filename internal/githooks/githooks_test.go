@@ -0,0 +1,213 @@
+package githooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// initRepo creates a repo with one commit, pushed to a bare origin.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("managed hooks are POSIX shell scripts")
+	}
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	dir := filepath.Join(root, "repo")
+	git(t, root, nil, "init", "--bare", "-b", "main", origin)
+	git(t, root, nil, "init", "-b", "main", dir)
+	git(t, dir, nil, "config", "user.email", "test@test.com")
+	git(t, dir, nil, "config", "user.name", "Test")
+	git(t, dir, nil, "remote", "add", "origin", origin)
+	commitFile(t, dir, nil, "README.md", "# test\n", "initial")
+	git(t, dir, nil, "push", "-u", "origin", "main")
+	return dir
+}
+
+func git(t *testing.T, dir string, env []string, args ...string) string {
+	t.Helper()
+	out, err := gitRun(dir, env, args...)
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+func gitRun(dir string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+func commitFile(t *testing.T, dir string, env []string, name, content, msg string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, nil, "add", name)
+	git(t, dir, env, "commit", "-m", msg)
+}
+
+func mustSync(t *testing.T, dir string) *Result {
+	t.Helper()
+	result, err := Sync(dir, false)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	return result
+}
+
+func TestSync_Idempotent(t *testing.T) {
+	dir := initRepo(t)
+
+	dry, err := Sync(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", "hooks", "pre-push")); !os.IsNotExist(err) {
+		t.Fatal("dry run wrote a hook")
+	}
+	first := mustSync(t, dir)
+	for _, name := range Names {
+		if dry.Hooks[name] != Created || first.Hooks[name] != Created {
+			t.Errorf("%s: dry run %v, sync %v, want created", name, dry.Hooks[name], first.Hooks[name])
+		}
+		path := filepath.Join(first.HooksDir, name)
+		if v := InstalledVersion(path); v != Version {
+			t.Errorf("%s: version %d, want %d", name, v, Version)
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode()&0111 == 0 {
+			t.Errorf("%s: not executable (%v)", name, err)
+		}
+	}
+	if second := mustSync(t, dir); second.Changed() {
+		t.Errorf("second sync changed hooks: %v", second.Hooks)
+	}
+}
+
+func TestSync_KeepsUserHook(t *testing.T) {
+	dir := initRepo(t)
+	path := filepath.Join(dir, ".git", "hooks", "post-checkout")
+	user := "#!/bin/bash\necho user hook\n"
+	if err := os.WriteFile(path, []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mustSync(t, dir).Hooks["post-checkout"]; got != Updated {
+		t.Fatalf("post-checkout = %v, want updated", got)
+	}
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if !strings.HasPrefix(content, "#!/bin/bash\n# >>> gastown managed hook v") || !strings.HasSuffix(content, blockEnd+"\necho user hook\n") {
+		t.Errorf("hook not merged after the shebang:\n%s", content)
+	}
+
+	// An older block is replaced in place.
+	stale := strings.Replace(content, "managed hook v1 >>>", "managed hook v0 >>>\necho old", 1)
+	if err := os.WriteFile(path, []byte(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustSync(t, dir).Hooks["post-checkout"]; got != Updated {
+		t.Fatalf("post-checkout over v0 = %v, want updated", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("v0 block not replaced:\n%s", data)
+	}
+}
+
+func TestSync_SkipsHooksPath(t *testing.T) {
+	dir := initRepo(t)
+	git(t, dir, nil, "config", "core.hooksPath", ".githooks")
+	result := mustSync(t, dir)
+	if result.Skipped == "" || len(result.Hooks) != 0 {
+		t.Errorf("result = %+v, want skipped", result)
+	}
+}
+
+func TestCommitMsg_AddsTrailers(t *testing.T) {
+	dir := initRepo(t)
+	mustSync(t, dir)
+
+	env := []string{"BD_ACTOR=gastown/polecats/toast", "GT_WORK_BEAD=gt-abc"}
+	commitFile(t, dir, env, "a.txt", "a\n", "feat: add a")
+	msg := git(t, dir, nil, "log", "-1", "--format=%B")
+	for _, want := range []string{"Gastown-Actor: gastown/polecats/toast", "Gastown-Bead: gt-abc"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+
+	git(t, dir, env, "commit", "--amend", "--no-edit")
+	if msg := git(t, dir, nil, "log", "-1", "--format=%B"); strings.Count(msg, "Gastown-Actor:") != 1 {
+		t.Errorf("amend repeated the trailer:\n%s", msg)
+	}
+}
+
+func TestPrePush_Screens(t *testing.T) {
+	dir := initRepo(t)
+	hook := filepath.Join(dir, ".git", "hooks", "pre-push")
+	seen := filepath.Join(t.TempDir(), "seen")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\ncat > "+seen+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mustSync(t, dir)
+
+	commitFile(t, dir, nil, "b.txt", "b\n", "feat: add b")
+	git(t, dir, nil, "push", "origin", "main")
+	if data, _ := os.ReadFile(seen); !strings.Contains(string(data), "refs/heads/main") {
+		t.Errorf("user hook got stdin %q, want the pushed refs", data)
+	}
+
+	commitFile(t, dir, nil, "c.txt", "<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> other\n", "feat: add c")
+	if out, err := gitRun(dir, nil, "push", "origin", "main"); err == nil || !strings.Contains(out, "conflict markers") {
+		t.Errorf("push with conflict markers: err %v\n%s", err, out)
+	}
+	git(t, dir, []string{"GT_SKIP_PUSH_SCREEN=1"}, "push", "origin", "main")
+
+	git(t, dir, nil, "checkout", "-b", "polecat/toast")
+	commitFile(t, dir, nil, "d.txt", "d\n", "fixup! feat: add c")
+	if out, err := gitRun(dir, nil, "push", "origin", "polecat/toast"); err == nil || !strings.Contains(out, "fixup!") {
+		t.Errorf("push of a new branch with a fixup: err %v\n%s", err, out)
+	}
+}
+
+func TestPostCheckout_RegistersWorktrees(t *testing.T) {
+	dir := initRepo(t)
+	mustSync(t, dir)
+
+	wt := filepath.Join(t.TempDir(), "wt")
+	git(t, dir, nil, "worktree", "add", "-b", "polecat/toast", wt)
+	wt, _ = filepath.EvalSymlinks(wt)
+	worktrees, err := Worktrees(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if worktrees[wt] != "polecat/toast" || len(worktrees) != 1 {
+		t.Fatalf("worktrees = %v, want %s on polecat/toast", worktrees, wt)
+	}
+
+	git(t, wt, nil, "checkout", "-b", "polecat/toast-2")
+	if worktrees, _ := Worktrees(dir); worktrees[wt] != "polecat/toast-2" || len(worktrees) != 1 {
+		t.Errorf("after checkout, worktrees = %v", worktrees)
+	}
+}
+
+func TestDiscoverRepos_OnePerCommonDir(t *testing.T) {
+	dir := initRepo(t)
+	town := t.TempDir()
+	rig := filepath.Join(town, "gastown")
+	git(t, town, nil, "clone", dir, filepath.Join(rig, "refinery", "rig"))
+	git(t, town, nil, "clone", dir, filepath.Join(rig, "crew", "max"))
+	git(t, filepath.Join(rig, "refinery", "rig"), nil, "worktree", "add", filepath.Join(rig, "polecats", "toast", "gastown"))
+
+	repos := DiscoverRepos(town)
+	if len(repos) != 2 {
+		t.Errorf("repos = %v, want the refinery and crew clones", repos)
+	}
+}
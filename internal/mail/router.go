@@ -41,7 +41,7 @@ const DefaultIdleNotifyTimeout = 3 * time.Second
 type Router struct {
 	workDir  string // fallback directory to run bd commands in
 	townRoot string // town root directory (e.g., ~/gt)
-	tmux     *tmux.Tmux
+	mux      tmux.Multiplexer
 
 	// IdleNotifyTimeout controls how long to wait for a session to become
 	// idle before falling back to a queued nudge. Zero uses the default.
@@ -60,7 +60,7 @@ func NewRouter(workDir string) *Router {
 	return &Router{
 		workDir:  workDir,
 		townRoot: townRoot,
-		tmux:     tmux.NewTmux(),
+		mux:      tmux.Selected(),
 	}
 }
 
@@ -69,7 +69,7 @@ func NewRouterWithTownRoot(workDir, townRoot string) *Router {
 	return &Router{
 		workDir:  workDir,
 		townRoot: townRoot,
-		tmux:     tmux.NewTmux(),
+		mux:      tmux.Selected(),
 	}
 }
 
@@ -1583,8 +1583,9 @@ func (r *Router) notifyRecipient(msg *Message) error {
 	// Try each possible session ID until we find one that exists.
 	// This handles the ambiguity where canonical addresses (rig/name) don't
 	// distinguish between crew workers (gt-rig-crew-name) and polecats (gt-rig-name).
+	t, isTmux := r.mux.(*tmux.Tmux)
 	for _, sessionID := range sessionIDs {
-		hasSession, err := r.tmux.HasSession(sessionID)
+		hasSession, err := r.mux.HasSession(sessionID)
 		if err != nil || !hasSession {
 			continue
 		}
@@ -1592,7 +1593,10 @@ func (r *Router) notifyRecipient(msg *Message) error {
 		// Overseer is a human operator - use a visible banner instead of NudgeSession
 		// (which types into Claude's input and would disrupt the human's terminal).
 		if msg.To == "overseer" {
-			return r.tmux.SendNotificationBanner(sessionID, msg.From, msg.Subject)
+			if !isTmux {
+				return tmux.NeedsTmux("notifying the overseer")
+			}
+			return t.SendNotificationBanner(sessionID, msg.From, msg.Subject)
 		}
 
 		notification := fmt.Sprintf("📬 You have new mail from %s. Subject: %s. Run 'gt mail inbox' to read.", msg.From, msg.Subject)
@@ -1602,10 +1606,14 @@ func (r *Router) notifyRecipient(msg *Message) error {
 		// consecutive idle polls (prompt visible + no "esc to interrupt"
 		// in the status bar) to distinguish genuine idle from brief
 		// inter-tool-call gaps. See: https://github.com/steveyegge/gastown/issues/2032
-		waitErr := r.tmux.WaitForIdle(sessionID, timeout)
+		// Idle detection needs tmux; other backends treat the agent as busy.
+		waitErr := tmux.ErrIdleTimeout
+		if isTmux {
+			waitErr = t.WaitForIdle(sessionID, timeout)
+		}
 		if waitErr == nil {
 			// Agent is idle — deliver directly for immediate wakeup.
-			if err := r.tmux.NudgeSession(sessionID, notification); err == nil {
+			if err := t.NudgeSession(sessionID, notification); err == nil {
 				r.enqueueReplyReminder(msg, sessionID)
 				return nil
 			} else if errors.Is(err, tmux.ErrSessionNotFound) {
@@ -1630,7 +1638,7 @@ func (r *Router) notifyRecipient(msg *Message) error {
 			return nil
 		}
 		// No town root available — last resort direct delivery.
-		err = r.tmux.NudgeSession(sessionID, notification)
+		err = tmux.Nudge(r.mux, sessionID, notification)
 		if err == nil {
 			r.enqueueReplyReminder(msg, sessionID)
 		}
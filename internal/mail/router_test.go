@@ -1535,7 +1535,7 @@ func TestNotifyRecipient_IdleAgent(t *testing.T) {
 	r := &Router{
 		workDir:           t.TempDir(),
 		townRoot:          townRoot,
-		tmux:              tmux.NewTmuxWithSocket(socket),
+		mux:               tmux.NewTmuxWithSocket(socket),
 		IdleNotifyTimeout: 3 * time.Second,
 	}
 
@@ -1581,7 +1581,7 @@ func TestNotifyRecipient_BusyAgent(t *testing.T) {
 	r := &Router{
 		workDir:           t.TempDir(),
 		townRoot:          townRoot,
-		tmux:              tmux.NewTmuxWithSocket(socket),
+		mux:               tmux.NewTmuxWithSocket(socket),
 		IdleNotifyTimeout: 1 * time.Second, // short timeout for test speed
 	}
 
@@ -48,12 +48,15 @@ func (m *Manager) mayorDir() string {
 // Start starts the mayor session.
 // agentOverride optionally specifies a different agent alias to use.
 func (m *Manager) Start(agentOverride string) error {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("starting the mayor")
+	if err != nil {
+		return err
+	}
 	sessionID := m.SessionName()
 
 	// Kill any existing zombie session (tmux alive but agent dead).
 	// Returns error if session is healthy and already running.
-	_, err := session.KillExistingSession(t, sessionID, true)
+	_, err = session.KillExistingSession(t, sessionID, true)
 	if err != nil {
 		return ErrAlreadyRunning
 	}
@@ -95,11 +98,14 @@ func (m *Manager) Start(agentOverride string) error {
 
 // Stop stops the mayor session.
 func (m *Manager) Stop() error {
-	t := tmux.NewTmux()
+	mux, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	sessionID := m.SessionName()
 
 	// Check if session exists
-	running, err := t.HasSession(sessionID)
+	running, err := mux.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
 	}
@@ -108,11 +114,13 @@ func (m *Manager) Stop() error {
 	}
 
 	// Try graceful shutdown first (best-effort interrupt)
-	_ = t.SendKeysRaw(sessionID, "C-c")
-	time.Sleep(100 * time.Millisecond)
+	if t, ok := mux.(*tmux.Tmux); ok {
+		_ = t.SendKeysRaw(sessionID, "C-c")
+		time.Sleep(100 * time.Millisecond)
+	}
 
 	// Kill the session and all its processes
-	if err := t.KillSessionWithProcesses(sessionID); err != nil {
+	if err := tmux.KillWithProcesses(mux, sessionID); err != nil {
 		return fmt.Errorf("killing session: %w", err)
 	}
 
@@ -121,13 +129,19 @@ func (m *Manager) Stop() error {
 
 // IsRunning checks if the mayor session is active.
 func (m *Manager) IsRunning() (bool, error) {
-	t := tmux.NewTmux()
-	return t.HasSession(m.SessionName())
+	mux, err := tmux.NewMultiplexer()
+	if err != nil {
+		return false, err
+	}
+	return mux.HasSession(m.SessionName())
 }
 
 // Status returns information about the mayor session.
 func (m *Manager) Status() (*tmux.SessionInfo, error) {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("mayor session info")
+	if err != nil {
+		return nil, err
+	}
 	sessionID := m.SessionName()
 
 	running, err := t.HasSession(sessionID)
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -53,6 +55,14 @@ func (h *SessionHeartbeat) IsV2() bool {
 	return h.State != ""
 }
 
+// HeartbeatMarker starts a heartbeat line an agent prints to its pane:
+//
+//	GT_HEARTBEAT <unix-seconds> [state] [context...]
+//
+// for agents that can print but can't run gt or touch files. The state
+// defaults to working.
+const HeartbeatMarker = "GT_HEARTBEAT"
+
 // heartbeatsDir returns the directory for polecat session heartbeat files.
 // Heartbeats live under <townRoot>/.runtime/heartbeats/, parallel to .runtime/pids/.
 func heartbeatsDir(townRoot string) string {
@@ -64,6 +74,13 @@ func heartbeatFile(townRoot, sessionName string) string {
 	return filepath.Join(heartbeatsDir(townRoot), sessionName+".json")
 }
 
+// PulseFile returns the path of a session's pulse file. Touching it is the
+// lightest heartbeat an agent can emit (e.g. from a tool hook): its mtime
+// counts as a working heartbeat. Sessions get it as GT_HEARTBEAT_FILE.
+func PulseFile(townRoot, sessionName string) string {
+	return filepath.Join(heartbeatsDir(townRoot), sessionName+".pulse")
+}
+
 // TouchSessionHeartbeat writes or updates the heartbeat file for a polecat session.
 // Writes state="working" by default (heartbeat v2, gt-3vr5).
 // This is best-effort: errors are silently ignored because heartbeat signals
@@ -112,6 +129,64 @@ func ReadSessionHeartbeat(townRoot, sessionName string) *SessionHeartbeat {
 	return &hb
 }
 
+// ReadAgentHeartbeat returns the latest heartbeat a session emitted by any
+// file means: its heartbeat file (see ReadSessionHeartbeat) or its pulse
+// file (see PulseFile), whichever is newer. A pulse reads as a v2 working
+// heartbeat. Returns nil if the session has emitted neither.
+func ReadAgentHeartbeat(townRoot, sessionName string) *SessionHeartbeat {
+	hb := ReadSessionHeartbeat(townRoot, sessionName)
+	info, err := os.Stat(PulseFile(townRoot, sessionName))
+	if err != nil {
+		return hb
+	}
+	if hb == nil || info.ModTime().After(hb.Timestamp) {
+		return &SessionHeartbeat{Timestamp: info.ModTime().UTC(), State: HeartbeatWorking}
+	}
+	return hb
+}
+
+// ParseHeartbeatMarker parses a HeartbeatMarker line printed by an agent.
+// The marker may follow whatever the agent's UI prefixes output with. It
+// returns false for any other line, or a marker with a bad timestamp or
+// state.
+func ParseHeartbeatMarker(line string) (*SessionHeartbeat, bool) {
+	i := strings.Index(line, HeartbeatMarker+" ")
+	if i < 0 {
+		return nil, false
+	}
+	fields := strings.Fields(line[i:])
+	if len(fields) < 2 {
+		return nil, false
+	}
+	unix, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || unix <= 0 {
+		return nil, false
+	}
+	hb := &SessionHeartbeat{Timestamp: time.Unix(unix, 0).UTC(), State: HeartbeatWorking}
+	if len(fields) > 2 {
+		switch state := HeartbeatState(fields[2]); state {
+		case HeartbeatWorking, HeartbeatIdle, HeartbeatExiting, HeartbeatStuck:
+			hb.State = state
+		default:
+			return nil, false
+		}
+		hb.Context = strings.Join(fields[3:], " ")
+	}
+	return hb, true
+}
+
+// LatestHeartbeatMarker returns the newest heartbeat marker among lines,
+// such as the tail of an agent's pane, or nil if there is none.
+func LatestHeartbeatMarker(lines []string) *SessionHeartbeat {
+	var latest *SessionHeartbeat
+	for _, line := range lines {
+		if hb, ok := ParseHeartbeatMarker(line); ok && (latest == nil || hb.Timestamp.After(latest.Timestamp)) {
+			latest = hb
+		}
+	}
+	return latest
+}
+
 // IsSessionHeartbeatStale returns true if the session's heartbeat is older than
 // the stale threshold, or if no heartbeat file exists.
 //
@@ -126,8 +201,9 @@ func IsSessionHeartbeatStale(townRoot, sessionName string) (stale bool, exists b
 	return time.Since(hb.Timestamp) >= SessionHeartbeatStaleThreshold, true
 }
 
-// RemoveSessionHeartbeat removes the heartbeat file for a session.
+// RemoveSessionHeartbeat removes the heartbeat and pulse files for a session.
 // Called during session cleanup.
 func RemoveSessionHeartbeat(townRoot, sessionName string) {
 	_ = os.Remove(heartbeatFile(townRoot, sessionName))
+	_ = os.Remove(PulseFile(townRoot, sessionName))
 }
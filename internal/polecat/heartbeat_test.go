@@ -307,3 +307,61 @@ func TestReadSessionHeartbeat_V2AllStates(t *testing.T) {
 		})
 	}
 }
+
+func TestReadAgentHeartbeat_Pulse(t *testing.T) {
+	townRoot := t.TempDir()
+	if hb := ReadAgentHeartbeat(townRoot, "gt-pulse"); hb != nil {
+		t.Fatalf("expected nil before any heartbeat, got %+v", hb)
+	}
+
+	TouchSessionHeartbeatWithState(townRoot, "gt-pulse", HeartbeatStuck, "auth", "")
+	pulse := PulseFile(townRoot, "gt-pulse")
+	if err := os.WriteFile(pulse, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An older pulse doesn't mask the heartbeat file.
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(pulse, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if hb := ReadAgentHeartbeat(townRoot, "gt-pulse"); hb == nil || hb.State != HeartbeatStuck {
+		t.Errorf("with an older pulse: %+v, want the stuck heartbeat", hb)
+	}
+
+	// A newer pulse reads as working.
+	newer := time.Now().Add(time.Minute)
+	if err := os.Chtimes(pulse, newer, newer); err != nil {
+		t.Fatal(err)
+	}
+	if hb := ReadAgentHeartbeat(townRoot, "gt-pulse"); hb == nil || hb.State != HeartbeatWorking || !hb.Timestamp.After(time.Now()) {
+		t.Errorf("with a newer pulse: %+v, want working at the pulse's mtime", hb)
+	}
+
+	RemoveSessionHeartbeat(townRoot, "gt-pulse")
+	if _, err := os.Stat(pulse); !os.IsNotExist(err) {
+		t.Errorf("pulse file not removed: %v", err)
+	}
+}
+
+func TestParseHeartbeatMarker(t *testing.T) {
+	hb, ok := ParseHeartbeatMarker("⎿  GT_HEARTBEAT 1700000000 stuck waiting on auth")
+	if !ok || !hb.Timestamp.Equal(time.Unix(1700000000, 0)) || hb.State != HeartbeatStuck || hb.Context != "waiting on auth" {
+		t.Errorf("ParseHeartbeatMarker = %+v, %v", hb, ok)
+	}
+	for _, line := range []string{
+		"GT_HEARTBEAT",
+		`echo "GT_HEARTBEAT $(date +%s)"`,
+		"GT_HEARTBEAT 1700000000 napping",
+		"no marker here",
+	} {
+		if hb, ok := ParseHeartbeatMarker(line); ok {
+			t.Errorf("ParseHeartbeatMarker(%q) = %+v, want no marker", line, hb)
+		}
+	}
+
+	latest := LatestHeartbeatMarker([]string{"GT_HEARTBEAT 1700000100", "output", "GT_HEARTBEAT 1700000000 idle"})
+	if latest == nil || latest.Timestamp.Unix() != 1700000100 || latest.State != HeartbeatWorking {
+		t.Errorf("LatestHeartbeatMarker = %+v, want the 1700000100 working marker", latest)
+	}
+}
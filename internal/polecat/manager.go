@@ -123,11 +123,14 @@ type Manager struct {
 	git      *git.Git
 	beads    *beads.Beads
 	namePool *NamePool
-	tmux     *tmux.Tmux
+	mux      tmux.Multiplexer // nil = don't check or kill sessions
+	tmux     *tmux.Tmux       // nil unless mux is tmux
 }
 
-// NewManager creates a new polecat manager.
-func NewManager(r *rig.Rig, g *git.Git, t *tmux.Tmux) *Manager {
+// NewManager creates a new polecat manager. Sessions are checked and
+// killed through mux; pass nil when only listing polecats.
+func NewManager(r *rig.Rig, g *git.Git, mux tmux.Multiplexer) *Manager {
+	t, _ := mux.(*tmux.Tmux)
 	// Use the resolved beads directory to find where bd commands should run.
 	// For tracked beads: rig/.beads/redirect -> mayor/rig/.beads, so use mayor/rig
 	// For local beads: rig/.beads is the database, so use rig root
@@ -173,6 +176,7 @@ func NewManager(r *rig.Rig, g *git.Git, t *tmux.Tmux) *Manager {
 		git:      g,
 		beads:    beads.NewWithBeadsDir(beadsPath, resolvedBeads),
 		namePool: pool,
+		mux:      mux,
 		tmux:     t,
 	}
 }
@@ -662,10 +666,10 @@ func (m *Manager) AllocateAndAdd(opts AddOptions) (string, *Polecat, error) {
 	}
 
 	// Kill any lingering tmux session for this name (gt-pqf9x)
-	if m.tmux != nil {
+	if m.mux != nil {
 		sessionName := session.PolecatSessionName(session.PrefixFor(m.rig.Name), name)
-		if alive, _ := m.tmux.HasSession(sessionName); alive {
-			_ = m.tmux.KillSessionWithProcesses(sessionName)
+		if alive, _ := m.mux.HasSession(sessionName); alive {
+			_ = tmux.KillWithProcesses(m.mux, sessionName)
 		}
 	}
 
@@ -1178,6 +1182,12 @@ func (m *Manager) RemoveWithOptions(name string, force, nuclear, selfNuke bool)
 	m.namePool.Release(name)
 	_ = m.namePool.Save()
 
+	// Drop the session name binding so a new polecat with this name starts
+	// from the canonical session name (non-fatal: state file update)
+	if names := session.DefaultSessionNames(); names != nil {
+		_ = names.Release(fmt.Sprintf("%s/polecats/%s", m.rig.Name, name))
+	}
+
 	return nil
 }
 
@@ -1285,10 +1295,10 @@ func (m *Manager) AllocateName() (string, error) {
 	// can be allocated after its directory was cleaned up while the tmux session
 	// lingers (race between cleanup and allocation). This extra check ensures
 	// no stale session blocks the new polecat's session creation.
-	if m.tmux != nil {
+	if m.mux != nil {
 		sessionName := session.PolecatSessionName(session.PrefixFor(m.rig.Name), name)
-		if alive, _ := m.tmux.HasSession(sessionName); alive {
-			_ = m.tmux.KillSessionWithProcesses(sessionName)
+		if alive, _ := m.mux.HasSession(sessionName); alive {
+			_ = tmux.KillWithProcesses(m.mux, sessionName)
 		}
 	}
 
@@ -1641,11 +1651,11 @@ func (m *Manager) reconcilePoolInternal() {
 
 	// Get names with tmux sessions
 	var namesWithSessions []string
-	if m.tmux != nil {
+	if m.mux != nil {
 		poolNames := m.namePool.getNames()
 		for _, name := range poolNames {
 			sessionName := session.PolecatSessionName(session.PrefixFor(m.rig.Name), name)
-			hasSession, _ := m.tmux.HasSession(sessionName)
+			hasSession, _ := m.mux.HasSession(sessionName)
 			if hasSession {
 				namesWithSessions = append(namesWithSessions, name)
 			}
@@ -1677,18 +1687,18 @@ func (m *Manager) ReconcilePoolWith(namesWithDirs, namesWithSessions []string) {
 	// Kill orphaned or stale sessions.
 	// - No directory: orphan session, always kill (worktree was removed but tmux lingered)
 	// - Has directory but dead process: stale session from crashed startup (gt-jn40ft)
-	// Use KillSessionWithProcesses to ensure all descendant processes are killed.
-	if m.tmux != nil {
+	// Use KillWithProcesses to ensure all descendant processes are killed.
+	if m.mux != nil {
 		townRoot := filepath.Dir(m.rig.Path)
 		for _, name := range namesWithSessions {
 			sessionName := session.PolecatSessionName(session.PrefixFor(m.rig.Name), name)
 			if !dirSet[name] {
 				// Orphan: session exists but no directory
-				_ = m.tmux.KillSessionWithProcesses(sessionName)
+				_ = tmux.KillWithProcesses(m.mux, sessionName)
 				RemoveSessionHeartbeat(townRoot, sessionName)
 			} else if isSessionProcessDead(m.tmux, sessionName, townRoot) {
 				// Stale: directory exists but session's process has died
-				_ = m.tmux.KillSessionWithProcesses(sessionName)
+				_ = tmux.KillWithProcesses(m.mux, sessionName)
 				RemoveSessionHeartbeat(townRoot, sessionName)
 			}
 		}
@@ -1724,6 +1734,10 @@ func isSessionProcessDead(t *tmux.Tmux, sessionName string, townRoot string) boo
 	}
 
 	// Fallback: PID signal probing (legacy, for sessions without heartbeat support).
+	// Only tmux exposes the pane PID; without it, don't assume dead.
+	if t == nil {
+		return false
+	}
 	pidStr, err := t.GetPanePID(sessionName)
 	if err != nil {
 		// Tmux query failed — could be permission denied, server busy, etc.
@@ -2124,9 +2138,9 @@ func (m *Manager) loadFromBeads(name string) (*Polecat, error) {
 	if issue != nil {
 		issueID = issue.ID
 		state = StateWorking
-	} else if m.tmux != nil {
+	} else if m.mux != nil {
 		sessionName := session.PolecatSessionName(session.PrefixFor(m.rig.Name), name)
-		if running, _ := m.tmux.HasSession(sessionName); running {
+		if running, _ := m.mux.HasSession(sessionName); running {
 			state = StateWorking
 		}
 	}
@@ -2231,7 +2245,7 @@ type StalenessInfo struct {
 
 // DetectStalePolecats identifies polecats that are candidates for cleanup.
 // A polecat is considered stale if:
-// - No active tmux session AND
+// - No active session AND
 // - Either: way behind main (>threshold commits) OR no agent bead/activity
 // - Has no uncommitted work that could be lost
 //
@@ -2258,10 +2272,14 @@ func (m *Manager) DetectStalePolecats(threshold int) ([]*StalenessInfo, error) {
 			Name: p.Name,
 		}
 
-		// Check for active tmux session
+		// Check for active session
 		// Session name follows pattern: gt-<rig>-<polecat>
+		// Without a multiplexer to ask, assume the session is alive.
 		sessionName := session.PolecatSessionName(session.PrefixFor(m.rig.Name), p.Name)
-		info.HasActiveSession = checkTmuxSession(sessionName)
+		info.HasActiveSession = true
+		if m.mux != nil {
+			info.HasActiveSession, _ = m.mux.HasSession(sessionName)
+		}
 
 		// Check how far behind main
 		polecatGit := git.NewGit(p.ClonePath)
@@ -2288,13 +2306,6 @@ func (m *Manager) DetectStalePolecats(threshold int) ([]*StalenessInfo, error) {
 	return results, nil
 }
 
-// checkTmuxSession checks if a tmux session exists.
-func checkTmuxSession(sessionName string) bool {
-	// Use has-session command which returns 0 if session exists
-	cmd := tmux.BuildCommand("has-session", "-t", sessionName)
-	return cmd.Run() == nil
-}
-
 // countCommitsBehind counts how many commits a worktree is behind origin/<defaultBranch>.
 func countCommitsBehind(g *git.Git, defaultBranch string) int {
 	// Use rev-list to count commits: origin/main..HEAD shows commits ahead,
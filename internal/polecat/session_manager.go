@@ -40,14 +40,20 @@ var (
 )
 
 // SessionManager handles polecat session lifecycle.
+// Sessions are created, listed, read, nudged and killed through the
+// Multiplexer; tmux-only extras (session environment, theme, pane-died hook,
+// readiness detection) are applied only when the backend is tmux.
 type SessionManager struct {
-	tmux *tmux.Tmux
+	mux  tmux.Multiplexer
+	tmux *tmux.Tmux // nil unless mux is tmux
 	rig  *rig.Rig
 }
 
 // NewSessionManager creates a new polecat session manager for a rig.
-func NewSessionManager(t *tmux.Tmux, r *rig.Rig) *SessionManager {
+func NewSessionManager(mux tmux.Multiplexer, r *rig.Rig) *SessionManager {
+	t, _ := mux.(*tmux.Tmux)
 	return &SessionManager{
+		mux:  mux,
 		tmux: t,
 		rig:  r,
 	}
@@ -173,6 +179,11 @@ func (m *SessionManager) clonePath(polecat string) string {
 	return newPath
 }
 
+// WorkDir returns the directory a polecat's agent runs in: its worktree.
+func (m *SessionManager) WorkDir(polecat string) string {
+	return m.clonePath(polecat)
+}
+
 // hasPolecat checks if the polecat exists in this rig.
 func (m *SessionManager) hasPolecat(polecat string) bool {
 	polecatPath := m.polecatDir(polecat)
@@ -211,18 +222,21 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 		return fmt.Errorf("%w: %s", ErrPolecatNotFound, polecat)
 	}
 
-	sessionID := m.SessionName(polecat)
+	sessionID, err := session.ResolveSessionName(&session.AgentIdentity{Role: session.RolePolecat, Rig: m.rig.Name, Name: polecat, Prefix: session.PrefixFor(m.rig.Name)})
+	if err != nil {
+		return fmt.Errorf("resolving session name: %w", err)
+	}
 
 	// Check if session already exists.
 	// If an existing session's pane process has died, kill the stale session
 	// and proceed rather than returning ErrSessionRunning (gt-jn40ft).
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
 	}
 	if running {
 		if m.isSessionStale(sessionID) {
-			if err := m.tmux.KillSessionWithProcesses(sessionID); err != nil {
+			if err := m.killSession(sessionID); err != nil {
 				return fmt.Errorf("killing stale session %s: %w", sessionID, err)
 			}
 		} else {
@@ -346,62 +360,64 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 
 	// Create session with command directly to avoid send-keys race condition.
 	// See: https://github.com/anthropics/gastown/issues/280
-	if err := m.tmux.NewSessionWithCommand(sessionID, workDir, command); err != nil {
+	if err := m.mux.NewSessionWithCommand(sessionID, workDir, command); err != nil {
 		return fmt.Errorf("creating session: %w", err)
 	}
 
-	// Set environment (non-fatal: session works without these)
-	// Use centralized AgentEnv for consistency across all role startup paths
-	// Note: townRoot already defined above for ResolveRoleAgentConfig
-	envVars := config.AgentEnv(config.AgentEnvConfig{
-		Role:             "polecat",
-		Rig:              m.rig.Name,
-		AgentName:        polecat,
-		TownRoot:         townRoot,
-		RuntimeConfigDir: opts.RuntimeConfigDir,
-		Agent:            opts.Agent,
-		SessionName:      sessionID,
-	})
-	for k, v := range envVars {
-		debugSession("SetEnvironment "+k, m.tmux.SetEnvironment(sessionID, k, v))
-	}
-
-	// Fallback: set GT_AGENT from resolved config when no explicit --agent override.
-	// AgentEnv only emits GT_AGENT when opts.Agent is non-empty (explicit override).
-	// Without this fallback, the default path (no --agent flag) leaves GT_AGENT
-	// unset in the tmux session table, causing the validation below to fail and
-	// kill the session. BuildStartupCommand sets GT_AGENT in process env via
-	// exec env, but tmux show-environment reads the session table, not process env.
-	// This mirrors the daemon's compensating logic (daemon.go ~line 1593-1595).
-	if _, hasGTAgent := envVars["GT_AGENT"]; !hasGTAgent && runtimeConfig.ResolvedAgent != "" {
-		debugSession("SetEnvironment GT_AGENT (resolved)", m.tmux.SetEnvironment(sessionID, "GT_AGENT", runtimeConfig.ResolvedAgent))
-	}
-
-	// Set GT_BRANCH and GT_POLECAT_PATH in tmux session environment.
-	// This ensures respawned processes also inherit these for gt done fallback.
-	if polecatGitBranch != "" {
-		debugSession("SetEnvironment GT_BRANCH", m.tmux.SetEnvironment(sessionID, "GT_BRANCH", polecatGitBranch))
-	}
-	debugSession("SetEnvironment GT_POLECAT_PATH", m.tmux.SetEnvironment(sessionID, "GT_POLECAT_PATH", workDir))
-	debugSession("SetEnvironment GT_TOWN_ROOT", m.tmux.SetEnvironment(sessionID, "GT_TOWN_ROOT", townRoot))
-	// Set GT_RUN in the session environment so respawned processes also inherit it.
-	debugSession("SetEnvironment GT_RUN", m.tmux.SetEnvironment(sessionID, "GT_RUN", runID))
-
-	// Disable Dolt auto-commit in tmux session environment (gt-5cc2p).
-	// This ensures respawned processes also inherit the setting.
-	debugSession("SetEnvironment BD_DOLT_AUTO_COMMIT", m.tmux.SetEnvironment(sessionID, "BD_DOLT_AUTO_COMMIT", "off"))
+	if m.tmux != nil {
+		// Set environment (non-fatal: session works without these)
+		// Use centralized AgentEnv for consistency across all role startup paths
+		// Note: townRoot already defined above for ResolveRoleAgentConfig
+		envVars := config.AgentEnv(config.AgentEnvConfig{
+			Role:             "polecat",
+			Rig:              m.rig.Name,
+			AgentName:        polecat,
+			TownRoot:         townRoot,
+			RuntimeConfigDir: opts.RuntimeConfigDir,
+			Agent:            opts.Agent,
+			SessionName:      sessionID,
+		})
+		for k, v := range envVars {
+			debugSession("SetEnvironment "+k, m.tmux.SetEnvironment(sessionID, k, v))
+		}
 
-	// Set GT_PROCESS_NAMES for accurate liveness detection. Custom agents may
-	// shadow built-in preset names (e.g., custom "codex" running "opencode"),
-	// so we resolve process names from both agent name and actual command.
-	processNames := config.ResolveProcessNames(runtimeConfig.ResolvedAgent, runtimeConfig.Command)
-	debugSession("SetEnvironment GT_PROCESS_NAMES", m.tmux.SetEnvironment(sessionID, "GT_PROCESS_NAMES", strings.Join(processNames, ",")))
+		// Fallback: set GT_AGENT from resolved config when no explicit --agent override.
+		// AgentEnv only emits GT_AGENT when opts.Agent is non-empty (explicit override).
+		// Without this fallback, the default path (no --agent flag) leaves GT_AGENT
+		// unset in the tmux session table, causing the validation below to fail and
+		// kill the session. BuildStartupCommand sets GT_AGENT in process env via
+		// exec env, but tmux show-environment reads the session table, not process env.
+		// This mirrors the daemon's compensating logic (daemon.go ~line 1593-1595).
+		if _, hasGTAgent := envVars["GT_AGENT"]; !hasGTAgent && runtimeConfig.ResolvedAgent != "" {
+			debugSession("SetEnvironment GT_AGENT (resolved)", m.tmux.SetEnvironment(sessionID, "GT_AGENT", runtimeConfig.ResolvedAgent))
+		}
 
-	// Record agent's pane_id for ZFC-compliant liveness checks (gt-qmsx).
-	// Declared pane identity replaces process-tree inference in IsRuntimeRunning
-	// and FindAgentPane. Legacy sessions without GT_PANE_ID fall back to scanning.
-	if paneID, err := m.tmux.GetPaneID(sessionID); err == nil {
-		debugSession("SetEnvironment GT_PANE_ID", m.tmux.SetEnvironment(sessionID, "GT_PANE_ID", paneID))
+		// Set GT_BRANCH and GT_POLECAT_PATH in tmux session environment.
+		// This ensures respawned processes also inherit these for gt done fallback.
+		if polecatGitBranch != "" {
+			debugSession("SetEnvironment GT_BRANCH", m.tmux.SetEnvironment(sessionID, "GT_BRANCH", polecatGitBranch))
+		}
+		debugSession("SetEnvironment GT_POLECAT_PATH", m.tmux.SetEnvironment(sessionID, "GT_POLECAT_PATH", workDir))
+		debugSession("SetEnvironment GT_TOWN_ROOT", m.tmux.SetEnvironment(sessionID, "GT_TOWN_ROOT", townRoot))
+		// Set GT_RUN in the session environment so respawned processes also inherit it.
+		debugSession("SetEnvironment GT_RUN", m.tmux.SetEnvironment(sessionID, "GT_RUN", runID))
+
+		// Disable Dolt auto-commit in tmux session environment (gt-5cc2p).
+		// This ensures respawned processes also inherit the setting.
+		debugSession("SetEnvironment BD_DOLT_AUTO_COMMIT", m.tmux.SetEnvironment(sessionID, "BD_DOLT_AUTO_COMMIT", "off"))
+
+		// Set GT_PROCESS_NAMES for accurate liveness detection. Custom agents may
+		// shadow built-in preset names (e.g., custom "codex" running "opencode"),
+		// so we resolve process names from both agent name and actual command.
+		processNames := config.ResolveProcessNames(runtimeConfig.ResolvedAgent, runtimeConfig.Command)
+		debugSession("SetEnvironment GT_PROCESS_NAMES", m.tmux.SetEnvironment(sessionID, "GT_PROCESS_NAMES", strings.Join(processNames, ",")))
+
+		// Record agent's pane_id for ZFC-compliant liveness checks (gt-qmsx).
+		// Declared pane identity replaces process-tree inference in IsRuntimeRunning
+		// and FindAgentPane. Legacy sessions without GT_PANE_ID fall back to scanning.
+		if paneID, err := m.tmux.GetPaneID(sessionID); err == nil {
+			debugSession("SetEnvironment GT_PANE_ID", m.tmux.SetEnvironment(sessionID, "GT_PANE_ID", paneID))
+		}
 	}
 
 	// Hook the issue to the polecat if provided via --issue flag
@@ -412,47 +428,56 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 		}
 	}
 
-	// Apply theme (non-fatal)
-	theme := tmux.AssignTheme(m.rig.Name)
-	debugSession("ConfigureGasTownSession", m.tmux.ConfigureGasTownSession(sessionID, theme, m.rig.Name, polecat, "polecat"))
+	if m.tmux != nil {
+		// Apply theme (non-fatal)
+		theme := tmux.AssignTheme(m.rig.Name)
+		debugSession("ConfigureGasTownSession", m.tmux.ConfigureGasTownSession(sessionID, theme, m.rig.Name, polecat, "polecat"))
 
-	// Set pane-died hook for crash detection (non-fatal)
-	agentID := fmt.Sprintf("%s/%s", m.rig.Name, polecat)
-	debugSession("SetPaneDiedHook", m.tmux.SetPaneDiedHook(sessionID, agentID))
+		// Set pane-died hook for crash detection (non-fatal)
+		agentID := fmt.Sprintf("%s/%s", m.rig.Name, polecat)
+		debugSession("SetPaneDiedHook", m.tmux.SetPaneDiedHook(sessionID, agentID))
 
-	// Wait for Claude to start (non-fatal)
-	debugSession("WaitForCommand", m.tmux.WaitForCommand(sessionID, constants.SupportedShells, constants.ClaudeStartTimeout))
+		// Wait for Claude to start (non-fatal)
+		debugSession("WaitForCommand", m.tmux.WaitForCommand(sessionID, constants.SupportedShells, constants.ClaudeStartTimeout))
 
-	// Accept startup dialogs (workspace trust + bypass permissions) if they appear
-	debugSession("AcceptStartupDialogs", m.tmux.AcceptStartupDialogs(sessionID))
+		// Accept startup dialogs (workspace trust + bypass permissions) if they appear
+		debugSession("AcceptStartupDialogs", m.tmux.AcceptStartupDialogs(sessionID))
 
-	// Wait for runtime to be fully ready at the prompt (not just started).
-	// Uses prompt-based polling for agents with ReadyPromptPrefix (e.g., Claude "❯ "),
-	// falling back to ReadyDelayMs sleep for agents without prompt detection.
-	debugSession("WaitForRuntimeReady", m.tmux.WaitForRuntimeReady(sessionID, runtimeConfig, constants.ClaudeStartTimeout))
+		// Wait for runtime to be fully ready at the prompt (not just started).
+		// Uses prompt-based polling for agents with ReadyPromptPrefix (e.g., Claude "❯ "),
+		// falling back to ReadyDelayMs sleep for agents without prompt detection.
+		debugSession("WaitForRuntimeReady", m.tmux.WaitForRuntimeReady(sessionID, runtimeConfig, constants.ClaudeStartTimeout))
+	} else if runtimeConfig.Tmux != nil {
+		// Other backends can't watch for the prompt; wait out the ready delay.
+		time.Sleep(time.Duration(runtimeConfig.Tmux.ReadyDelayMs) * time.Millisecond)
+	}
 
 	// Handle fallback nudges for non-hook agents.
 	// See StartupFallbackInfo in runtime package for the fallback matrix.
 	if fallbackInfo.SendBeaconNudge && fallbackInfo.SendStartupNudge && fallbackInfo.StartupNudgeDelayMs == 0 {
 		// Hooks + no prompt: Single combined nudge (hook already ran gt prime synchronously)
 		combined := beacon + "\n\n" + runtime.StartupNudgeContent()
-		debugSession("SendCombinedNudge", m.tmux.NudgeSession(sessionID, combined))
+		debugSession("SendCombinedNudge", tmux.Nudge(m.mux, sessionID, combined))
 	} else {
 		if fallbackInfo.SendBeaconNudge {
 			// Agent doesn't support CLI prompt - send beacon via nudge
-			debugSession("SendBeaconNudge", m.tmux.NudgeSession(sessionID, beacon))
+			debugSession("SendBeaconNudge", tmux.Nudge(m.mux, sessionID, beacon))
 		}
 
 		if fallbackInfo.StartupNudgeDelayMs > 0 {
 			// Wait for agent to finish processing beacon + gt prime before sending work instructions.
 			// Uses prompt-based detection where available; falls back to max(ReadyDelayMs, StartupNudgeDelayMs).
 			primeWaitRC := runtime.RuntimeConfigWithMinDelay(runtimeConfig, fallbackInfo.StartupNudgeDelayMs)
-			debugSession("WaitForPrimeReady", m.tmux.WaitForRuntimeReady(sessionID, primeWaitRC, constants.ClaudeStartTimeout))
+			if m.tmux != nil {
+				debugSession("WaitForPrimeReady", m.tmux.WaitForRuntimeReady(sessionID, primeWaitRC, constants.ClaudeStartTimeout))
+			} else {
+				time.Sleep(time.Duration(primeWaitRC.Tmux.ReadyDelayMs) * time.Millisecond)
+			}
 		}
 
 		if fallbackInfo.SendStartupNudge {
 			// Send work instructions via nudge
-			debugSession("SendStartupNudge", m.tmux.NudgeSession(sessionID, runtime.StartupNudgeContent()))
+			debugSession("SendStartupNudge", tmux.Nudge(m.mux, sessionID, runtime.StartupNudgeContent()))
 		}
 	}
 
@@ -464,11 +489,13 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 	}
 
 	// Legacy fallback for other startup paths (non-fatal)
-	_ = runtime.RunStartupFallback(m.tmux, sessionID, "polecat", runtimeConfig)
+	if m.tmux != nil {
+		_ = runtime.RunStartupFallback(m.tmux, sessionID, "polecat", runtimeConfig)
+	}
 
 	// Verify session survived startup - if the command crashed, the session may have died.
 	// Without this check, Start() would return success even if the pane died during initialization.
-	running, err = m.tmux.HasSession(sessionID)
+	running, err = m.mux.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("verifying session: %w", err)
 	}
@@ -476,20 +503,22 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 		return fmt.Errorf("session %s died during startup (agent command may have failed)", sessionID)
 	}
 
-	// Validate GT_AGENT is set. Without GT_AGENT, IsAgentAlive falls back to
-	// ["node", "claude"] process detection and witness patrol will auto-nuke
-	// polecats running non-Claude agents (e.g., opencode). Fail fast.
-	gtAgent, _ := m.tmux.GetEnvironment(sessionID, "GT_AGENT")
-	if gtAgent == "" {
-		_ = m.tmux.KillSessionWithProcesses(sessionID)
-		return fmt.Errorf("GT_AGENT not set in session %s (command=%q); "+
-			"witness patrol will misidentify this polecat as a zombie and auto-nuke it. "+
-			"Ensure RuntimeConfig.ResolvedAgent is set during agent config resolution",
-			sessionID, runtimeConfig.Command)
-	}
+	if m.tmux != nil {
+		// Validate GT_AGENT is set. Without GT_AGENT, IsAgentAlive falls back to
+		// ["node", "claude"] process detection and witness patrol will auto-nuke
+		// polecats running non-Claude agents (e.g., opencode). Fail fast.
+		gtAgent, _ := m.tmux.GetEnvironment(sessionID, "GT_AGENT")
+		if gtAgent == "" {
+			_ = m.killSession(sessionID)
+			return fmt.Errorf("GT_AGENT not set in session %s (command=%q); "+
+				"witness patrol will misidentify this polecat as a zombie and auto-nuke it. "+
+				"Ensure RuntimeConfig.ResolvedAgent is set during agent config resolution",
+				sessionID, runtimeConfig.Command)
+		}
 
-	// Track PID for defense-in-depth orphan cleanup (non-fatal)
-	_ = session.TrackSessionPID(townRoot, sessionID, m.tmux)
+		// Track PID for defense-in-depth orphan cleanup (non-fatal)
+		_ = session.TrackSessionPID(townRoot, sessionID, m.tmux)
+	}
 
 	// Touch initial heartbeat so liveness detection works from the start (gt-qjtq).
 	// Subsequent touches happen on every gt command via persistentPreRun.
@@ -514,15 +543,22 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 // A stale session exists in tmux but its main process (the agent) is no longer running.
 // This happens when the agent crashes during startup but tmux keeps the dead pane.
 // Delegates to isSessionProcessDead to avoid duplicating process-check logic (gt-qgzj1h).
+// Without tmux only the heartbeat is checked.
 func (m *SessionManager) isSessionStale(sessionID string) bool {
 	return isSessionProcessDead(m.tmux, sessionID, filepath.Dir(m.rig.Path))
 }
 
+// killSession kills a session. On tmux every descendant process is killed
+// too, so no orphan bash processes from the agent's Bash tool survive.
+func (m *SessionManager) killSession(sessionID string) error {
+	return tmux.KillWithProcesses(m.mux, sessionID)
+}
+
 // Stop terminates a polecat session.
 func (m *SessionManager) Stop(polecat string, force bool) error {
 	sessionID := m.SessionName(polecat)
 
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
 	}
@@ -531,14 +567,12 @@ func (m *SessionManager) Stop(polecat string, force bool) error {
 	}
 
 	// Try graceful shutdown first
-	if !force {
+	if !force && m.tmux != nil {
 		_ = m.tmux.SendKeysRaw(sessionID, "C-c")
 		session.WaitForSessionExit(m.tmux, sessionID, constants.GracefulShutdownTimeout)
 	}
 
-	// Use KillSessionWithProcesses to ensure all descendant processes are killed.
-	// This prevents orphan bash processes from Claude's Bash tool surviving session termination.
-	if err := m.tmux.KillSessionWithProcesses(sessionID); err != nil {
+	if err := m.killSession(sessionID); err != nil {
 		return fmt.Errorf("killing session: %w", err)
 	}
 
@@ -548,8 +582,12 @@ func (m *SessionManager) Stop(polecat string, force bool) error {
 // IsRunning checks if a polecat session is active and healthy.
 // Checks both tmux session existence AND agent process liveness to avoid
 // reporting zombie sessions (tmux alive but Claude dead) as "running".
+// Other backends can only report whether the session exists.
 func (m *SessionManager) IsRunning(polecat string) (bool, error) {
 	sessionID := m.SessionName(polecat)
+	if m.tmux == nil {
+		return m.mux.HasSession(sessionID)
+	}
 	status := m.tmux.CheckSessionHealth(sessionID, 0)
 	return status == tmux.SessionHealthy, nil
 }
@@ -558,7 +596,7 @@ func (m *SessionManager) IsRunning(polecat string) (bool, error) {
 func (m *SessionManager) Status(polecat string) (*SessionInfo, error) {
 	sessionID := m.SessionName(polecat)
 
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("checking session: %w", err)
 	}
@@ -570,7 +608,7 @@ func (m *SessionManager) Status(polecat string) (*SessionInfo, error) {
 		RigName:   m.rig.Name,
 	}
 
-	if !running {
+	if !running || m.tmux == nil {
 		return info, nil
 	}
 
@@ -612,7 +650,7 @@ func (m *SessionManager) Status(polecat string) (*SessionInfo, error) {
 // This includes polecats, witness, refinery, and crew sessions.
 // Use ListPolecats() to get only polecat sessions.
 func (m *SessionManager) List() ([]SessionInfo, error) {
-	sessions, err := m.tmux.ListSessions()
+	sessions, err := m.mux.ListSessions()
 	if err != nil {
 		return nil, err
 	}
@@ -661,13 +699,16 @@ func (m *SessionManager) ListPolecats() ([]SessionInfo, error) {
 func (m *SessionManager) Attach(polecat string) error {
 	sessionID := m.SessionName(polecat)
 
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
 	}
 	if !running {
 		return ErrSessionNotFound
 	}
+	if m.tmux == nil {
+		return fmt.Errorf("attaching to %s: only supported with tmux", sessionID)
+	}
 
 	return m.tmux.AttachSession(sessionID)
 }
@@ -676,7 +717,7 @@ func (m *SessionManager) Attach(polecat string) error {
 func (m *SessionManager) Capture(polecat string, lines int) (string, error) {
 	sessionID := m.SessionName(polecat)
 
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return "", fmt.Errorf("checking session: %w", err)
 	}
@@ -684,12 +725,12 @@ func (m *SessionManager) Capture(polecat string, lines int) (string, error) {
 		return "", ErrSessionNotFound
 	}
 
-	return m.tmux.CapturePane(sessionID, lines)
+	return m.mux.CapturePane(sessionID, lines)
 }
 
 // CaptureSession returns the recent output from a session by raw session ID.
 func (m *SessionManager) CaptureSession(sessionID string, lines int) (string, error) {
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return "", fmt.Errorf("checking session: %w", err)
 	}
@@ -697,14 +738,14 @@ func (m *SessionManager) CaptureSession(sessionID string, lines int) (string, er
 		return "", ErrSessionNotFound
 	}
 
-	return m.tmux.CapturePane(sessionID, lines)
+	return m.mux.CapturePane(sessionID, lines)
 }
 
 // Inject sends a message to a polecat session.
 func (m *SessionManager) Inject(polecat, message string) error {
 	sessionID := m.SessionName(polecat)
 
-	running, err := m.tmux.HasSession(sessionID)
+	running, err := m.mux.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
 	}
@@ -712,6 +753,10 @@ func (m *SessionManager) Inject(polecat, message string) error {
 		return ErrSessionNotFound
 	}
 
+	if m.tmux == nil {
+		return m.mux.SendKeys(sessionID, message)
+	}
+
 	debounceMs := 200 + (len(message)/1024)*100
 	if debounceMs > 1500 {
 		debounceMs = 1500
@@ -789,7 +834,7 @@ func (m *SessionManager) validateIssue(issueID, workDir string) error {
 func (m *SessionManager) verifyStartupNudgeDelivery(sessionID string, rc *config.RuntimeConfig) {
 	// Only verify for agents with prompt detection. Without ReadyPromptPrefix,
 	// we can't distinguish "idle at prompt" from "busy processing".
-	if rc == nil || rc.Tmux == nil || rc.Tmux.ReadyPromptPrefix == "" {
+	if m.tmux == nil || rc == nil || rc.Tmux == nil || rc.Tmux.ReadyPromptPrefix == "" {
 		return
 	}
 
@@ -800,7 +845,7 @@ func (m *SessionManager) verifyStartupNudgeDelivery(sessionID string, rc *config
 		time.Sleep(constants.StartupNudgeVerifyDelay)
 
 		// Check if session is still alive
-		running, err := m.tmux.HasSession(sessionID)
+		running, err := m.mux.HasSession(sessionID)
 		if err != nil || !running {
 			return // Session died, nothing to verify
 		}
@@ -813,7 +858,7 @@ func (m *SessionManager) verifyStartupNudgeDelivery(sessionID string, rc *config
 		// Agent is at the idle prompt — nudge was likely lost. Retry.
 		fmt.Fprintf(os.Stderr, "[startup-nudge] attempt %d/%d: agent %s idle at prompt, retrying nudge\n",
 			attempt, constants.StartupNudgeMaxRetries, sessionID)
-		if err := m.tmux.NudgeSession(sessionID, nudgeContent); err != nil {
+		if err := tmux.Nudge(m.mux, sessionID, nudgeContent); err != nil {
 			fmt.Fprintf(os.Stderr, "[startup-nudge] retry nudge failed for %s: %v\n", sessionID, err)
 			return
 		}
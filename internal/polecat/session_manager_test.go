@@ -14,6 +14,7 @@ import (
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/testutil/fakemux"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
 
@@ -113,13 +114,11 @@ func TestStartPolecatNotFound(t *testing.T) {
 }
 
 func TestIsRunningNoSession(t *testing.T) {
-	requireTmux(t)
-
 	r := &rig.Rig{
 		Name:     "gastown",
 		Polecats: []string{"Toast"},
 	}
-	m := NewSessionManager(tmux.NewTmux(), r)
+	m := NewSessionManager(fakemux.New(), r)
 
 	running, err := m.IsRunning("Toast")
 	if err != nil {
@@ -131,21 +130,11 @@ func TestIsRunningNoSession(t *testing.T) {
 }
 
 func TestSessionManagerListEmpty(t *testing.T) {
-	requireTmux(t)
-
-	// Register a unique prefix so List() won't match real sessions.
-	// Without this, PrefixFor returns "gt" (default) and matches running gastown sessions.
-	reg := session.NewPrefixRegistry()
-	reg.Register("xz", "test-rig-unlikely-name")
-	old := session.DefaultRegistry()
-	session.SetDefaultRegistry(reg)
-	t.Cleanup(func() { session.SetDefaultRegistry(old) })
-
 	r := &rig.Rig{
 		Name:     "test-rig-unlikely-name",
 		Polecats: []string{},
 	}
-	m := NewSessionManager(tmux.NewTmux(), r)
+	m := NewSessionManager(fakemux.New(), r)
 
 	infos, err := m.List()
 	if err != nil {
@@ -156,14 +145,32 @@ func TestSessionManagerListEmpty(t *testing.T) {
 	}
 }
 
-func TestStopNotFound(t *testing.T) {
-	requireTmux(t)
+func TestSessionManagerListPolecats(t *testing.T) {
+	setupTestRegistryForSession(t)
 
+	mux := fakemux.New()
+	for _, name := range []string{"gt-Toast", "gt-witness", "gt-crew-max", "bd-Cheedo"} {
+		if err := mux.NewSession(name, t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewSessionManager(mux, &rig.Rig{Name: "gastown"})
+
+	infos, err := m.ListPolecats()
+	if err != nil {
+		t.Fatalf("ListPolecats: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Polecat != "Toast" || infos[0].SessionID != "gt-Toast" {
+		t.Errorf("ListPolecats = %+v, want only gt-Toast", infos)
+	}
+}
+
+func TestStopNotFound(t *testing.T) {
 	r := &rig.Rig{
 		Name:     "test-rig",
 		Polecats: []string{"Toast"},
 	}
-	m := NewSessionManager(tmux.NewTmux(), r)
+	m := NewSessionManager(fakemux.New(), r)
 
 	err := m.Stop("Toast", false)
 	if err != ErrSessionNotFound {
@@ -171,14 +178,32 @@ func TestStopNotFound(t *testing.T) {
 	}
 }
 
-func TestCaptureNotFound(t *testing.T) {
-	requireTmux(t)
+func TestStopKillsSession(t *testing.T) {
+	setupTestRegistryForSession(t)
+
+	mux := fakemux.New()
+	if err := mux.NewSession("gt-Toast", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	m := NewSessionManager(mux, &rig.Rig{Name: "gastown", Polecats: []string{"Toast"}})
 
+	if running, err := m.IsRunning("Toast"); err != nil || !running {
+		t.Fatalf("IsRunning = %v, %v; want true", running, err)
+	}
+	if err := m.Stop("Toast", false); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if ok, _ := mux.HasSession("gt-Toast"); ok {
+		t.Error("session still exists after Stop")
+	}
+}
+
+func TestCaptureNotFound(t *testing.T) {
 	r := &rig.Rig{
 		Name:     "test-rig",
 		Polecats: []string{"Toast"},
 	}
-	m := NewSessionManager(tmux.NewTmux(), r)
+	m := NewSessionManager(fakemux.New(), r)
 
 	_, err := m.Capture("Toast", 50)
 	if err != ErrSessionNotFound {
@@ -186,14 +211,39 @@ func TestCaptureNotFound(t *testing.T) {
 	}
 }
 
-func TestInjectNotFound(t *testing.T) {
-	requireTmux(t)
+func TestCaptureAndInject(t *testing.T) {
+	setupTestRegistryForSession(t)
+
+	mux := fakemux.New()
+	if err := mux.NewSession("gt-Toast", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	mux.OnKeys(func(m *fakemux.Mux, session, keys string) {
+		_ = m.AppendPane(session, "> "+keys, "ack")
+	})
+	m := NewSessionManager(mux, &rig.Rig{Name: "gastown", Polecats: []string{"Toast"}})
 
+	if err := m.Inject("Toast", "check your hook"); err != nil {
+		t.Fatalf("Inject: %v", err)
+	}
+	if keys := mux.Keys("gt-Toast"); len(keys) != 1 || keys[0] != "check your hook" {
+		t.Errorf("keys sent = %q", keys)
+	}
+	out, err := m.Capture("Toast", 1)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if strings.TrimSpace(out) != "ack" {
+		t.Errorf("Capture = %q, want the last pane line", out)
+	}
+}
+
+func TestInjectNotFound(t *testing.T) {
 	r := &rig.Rig{
 		Name:     "test-rig",
 		Polecats: []string{"Toast"},
 	}
-	m := NewSessionManager(tmux.NewTmux(), r)
+	m := NewSessionManager(fakemux.New(), r)
 
 	err := m.Inject("Toast", "hello")
 	if err != ErrSessionNotFound {
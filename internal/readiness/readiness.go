@@ -0,0 +1,279 @@
+// Package readiness checks what a rig's configuration claims against the
+// machine and repository it runs on: that its target branches exist, its
+// gate commands resolve, its working directories are writable and its agent
+// is installed. A rig whose config parses can still be unable to do any
+// work; these checks say so before its agents find out.
+package readiness
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Check names.
+const (
+	CheckConfig   = "config"
+	CheckBranches = "target-branches"
+	CheckGates    = "gate-commands"
+	CheckPaths    = "writable-paths"
+	CheckAgent    = "agent-binary"
+)
+
+// Check is the outcome of one readiness check.
+type Check struct {
+	Name     string   `json:"name"`
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Report is a rig's readiness.
+type Report struct {
+	Rig       string    `json:"rig"`
+	CheckedAt time.Time `json:"checked_at"`
+	Ready     bool      `json:"ready"` // Every check passed
+	Checks    []Check   `json:"checks"`
+}
+
+// Problems returns every problem found, prefixed with its check's name.
+func (r *Report) Problems() []string {
+	var problems []string
+	for _, c := range r.Checks {
+		for _, p := range c.Problems {
+			problems = append(problems, c.Name+": "+p)
+		}
+	}
+	return problems
+}
+
+// CheckRig checks the readiness of r, in the town at townRoot.
+func CheckRig(townRoot string, r *rig.Rig) *Report {
+	report := &Report{Rig: r.Name, CheckedAt: time.Now().UTC()}
+	add := func(name string, problems []string) {
+		report.Checks = append(report.Checks, Check{Name: name, OK: len(problems) == 0, Problems: problems})
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		add(CheckConfig, []string{err.Error()})
+	} else {
+		add(CheckConfig, nil)
+	}
+	mq := eng.Config()
+	clone := cloneDir(r.Path)
+
+	add(CheckBranches, checkBranches(clone, targets(r, mq)))
+	add(CheckGates, checkGates(clone, mq))
+	add(CheckPaths, checkPaths(r.Path, clone))
+	add(CheckAgent, checkAgent(townRoot, r.Path))
+
+	report.Ready = true
+	for _, c := range report.Checks {
+		report.Ready = report.Ready && c.OK
+	}
+	return report
+}
+
+// cloneDir returns the clone the refinery works in, as the Engineer picks
+// it.
+func cloneDir(rigPath string) string {
+	dir := filepath.Join(rigPath, "refinery", "rig")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		dir = filepath.Join(rigPath, "mayor", "rig")
+	}
+	return dir
+}
+
+// targets returns the branches the rig's merge queue lands on.
+func targets(r *rig.Rig, mq *refinery.MergeQueueConfig) []string {
+	branches := []string{r.DefaultBranch()}
+	for b := range mq.ProtectedBranches {
+		branches = append(branches, b)
+	}
+	sort.Strings(branches[1:])
+	return branches
+}
+
+// checkBranches checks that each target exists in the clone, as a local
+// branch or as origin's. Nothing is fetched.
+func checkBranches(clone string, branches []string) []string {
+	if _, err := os.Stat(clone); err != nil {
+		return []string{fmt.Sprintf("no clone at %s", clone)}
+	}
+	g := git.NewGit(clone)
+	var problems []string
+	for _, b := range branches {
+		local, err := g.BranchExists(b)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", b, err))
+			continue
+		}
+		remote, _ := g.RemoteTrackingBranchExists("origin", b)
+		if !local && !remote {
+			problems = append(problems, fmt.Sprintf("target branch %s not found in %s (neither local nor origin/%s)", b, clone, b))
+		}
+	}
+	return problems
+}
+
+// shellBuiltins are commands a gate's shell runs itself.
+var shellBuiltins = map[string]bool{
+	"cd": true, "test": true, "[": true, "true": true, "false": true, "echo": true,
+	"export": true, "set": true, "exit": true, ":": true, "source": true, ".": true,
+	"eval": true, "exec": true, "command": true, "if": true, "for": true, "while": true,
+	"!": true, "{": true, "(": true, "unset": true, "printf": true, "read": true,
+}
+
+// checkGates checks that the program each gate starts can be found: its
+// shell and the first word of its command, or its container runtime.
+func checkGates(clone string, mq *refinery.MergeQueueConfig) []string {
+	gates := make(map[string]*refinery.GateConfig)
+	for name, g := range mq.Gates {
+		gates[name] = g
+	}
+	for branch, pb := range mq.ProtectedBranches {
+		for name, g := range pb.Gates {
+			gates[branch+"/"+name] = g
+		}
+	}
+	if len(mq.Gates) == 0 && mq.RunTests && mq.TestCommand != "" {
+		gates["test_command"] = &refinery.GateConfig{Cmd: mq.TestCommand}
+	}
+	names := make([]string, 0, len(gates))
+	for name := range gates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		g := gates[name]
+		if g.Image != "" {
+			if !anyInPath(g.Runtime, "docker", "podman") {
+				problems = append(problems, fmt.Sprintf("gate %s: no container runtime for image %s", name, g.Image))
+			}
+			continue
+		}
+		if shell := strings.Fields(g.Shell); len(shell) > 0 {
+			if _, err := exec.LookPath(shell[0]); err != nil {
+				problems = append(problems, fmt.Sprintf("gate %s: shell %s not found", name, shell[0]))
+			}
+		}
+		prog := commandName(g.Cmd)
+		switch {
+		case prog == "" || shellBuiltins[prog]:
+		case strings.ContainsRune(prog, '/'):
+			path := prog
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(clone, g.Dir, prog)
+			}
+			if _, err := os.Stat(path); err != nil {
+				problems = append(problems, fmt.Sprintf("gate %s: %s not found", name, prog))
+			}
+		default:
+			if _, err := exec.LookPath(prog); err != nil {
+				problems = append(problems, fmt.Sprintf("gate %s: %s not found in PATH", name, prog))
+			}
+		}
+	}
+	return problems
+}
+
+// anyInPath reports whether want, or failing that any of fallbacks, is in
+// PATH.
+func anyInPath(want string, fallbacks ...string) bool {
+	if want != "" {
+		fallbacks = []string{want}
+	}
+	for _, p := range fallbacks {
+		if _, err := exec.LookPath(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// commandName returns the program a shell command line starts with,
+// skipping leading variable assignments.
+func commandName(cmd string) string {
+	for _, f := range strings.Fields(cmd) {
+		if i := strings.IndexByte(f, '='); i > 0 && !strings.ContainsAny(f[:i], "/$\"'") {
+			continue
+		}
+		return strings.Trim(f, "\"'")
+	}
+	return ""
+}
+
+// checkPaths checks that the directories the rig's agents write to are
+// writable by writing a file to each.
+func checkPaths(rigPath, clone string) []string {
+	dirs := []string{rigPath, clone, filepath.Join(rigPath, ".runtime")}
+	polecats := filepath.Join(rigPath, "polecats")
+	if _, err := os.Stat(polecats); err == nil {
+		dirs = append(dirs, polecats)
+	}
+	var problems []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) && dir != clone {
+			continue // Created on first use
+		}
+		f, err := os.CreateTemp(dir, ".gt-write-check-*")
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not writable: %v", dir, err))
+			continue
+		}
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+	return problems
+}
+
+// checkAgent checks that the rig's agent command is installed.
+func checkAgent(townRoot, rigPath string) []string {
+	rc := config.ResolveAgentConfig(townRoot, rigPath)
+	if rc == nil || rc.Command == "" {
+		return []string{"no agent command configured"}
+	}
+	if _, err := exec.LookPath(rc.Command); err != nil {
+		return []string{fmt.Sprintf("agent %s: %s not found in PATH", rc.ResolvedAgent, rc.Command)}
+	}
+	return nil
+}
+
+// Path returns where the daemon keeps the town's readiness reports.
+func Path(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "rig-readiness.json")
+}
+
+// Load returns the stored readiness reports, by rig.
+func Load(townRoot string) (map[string]*Report, error) {
+	reports := make(map[string]*Report)
+	data, err := os.ReadFile(Path(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return reports, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return nil, fmt.Errorf("parsing rig readiness: %w", err)
+	}
+	return reports, nil
+}
+
+// Save stores readiness reports, by rig, replacing those stored.
+func Save(townRoot string, reports map[string]*Report) error {
+	return util.EnsureDirAndWriteJSON(Path(townRoot), reports)
+}
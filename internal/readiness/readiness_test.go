@@ -0,0 +1,167 @@
+package readiness
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// newTestRig creates a rig whose refinery clone is a git repo with one
+// commit on main, and whose config.json holds config.
+func newTestRig(t *testing.T, config string) (townRoot string, r *rig.Rig) {
+	t.Helper()
+	townRoot = t.TempDir()
+	rigPath := filepath.Join(townRoot, "testrig")
+	clone := filepath.Join(rigPath, "refinery", "rig")
+	if err := os.MkdirAll(clone, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", clone}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(rigPath, "config.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return townRoot, &rig.Rig{Name: "testrig", Path: rigPath}
+}
+
+// fakeAgent puts an executable claude on a PATH that otherwise holds only
+// git's directory and sh's.
+func fakeAgent(t *testing.T) {
+	t.Helper()
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "claude"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := []string{bin}
+	for _, prog := range []string{"git", "sh"} {
+		p, err := exec.LookPath(prog)
+		if err != nil {
+			t.Skipf("%s not found", prog)
+		}
+		path = append(path, filepath.Dir(p))
+	}
+	t.Setenv("PATH", strings.Join(path, string(os.PathListSeparator)))
+}
+
+func checkNamed(t *testing.T, report *Report, name string) Check {
+	t.Helper()
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no %s check in %+v", name, report.Checks)
+	return Check{}
+}
+
+func TestCheckRig_Ready(t *testing.T) {
+	fakeAgent(t)
+	townRoot, r := newTestRig(t, `{"type":"rig","name":"testrig","merge_queue":{
+		"gates":{"test":{"cmd":"GOFLAGS=-count=1 git status"},"noop":{"cmd":"cd . && true"}}}}`)
+
+	report := CheckRig(townRoot, r)
+	if !report.Ready {
+		t.Fatalf("rig not ready: %v", report.Problems())
+	}
+	if report.Rig != "testrig" || len(report.Checks) != 5 {
+		t.Errorf("report = %+v, want five checks of testrig", report)
+	}
+}
+
+func TestCheckRig_NotReady(t *testing.T) {
+	fakeAgent(t)
+	townRoot, r := newTestRig(t, `{"type":"rig","name":"testrig","default_branch":"develop","merge_queue":{
+		"gates":{"lint":{"cmd":"no-such-linter --strict"},"build":{"cmd":"./scripts/build.sh"}},
+		"protected_branches":{"release":{}}}}`)
+
+	report := CheckRig(townRoot, r)
+	if report.Ready {
+		t.Fatal("rig with missing branches and gate commands is ready")
+	}
+
+	branches := checkNamed(t, report, CheckBranches)
+	if len(branches.Problems) != 2 || !strings.Contains(branches.Problems[0], "develop") ||
+		!strings.Contains(branches.Problems[1], "release") {
+		t.Errorf("branch problems = %q, want develop and release missing", branches.Problems)
+	}
+	gates := checkNamed(t, report, CheckGates)
+	if len(gates.Problems) != 2 || !strings.Contains(gates.Problems[0], "./scripts/build.sh") ||
+		!strings.Contains(gates.Problems[1], "no-such-linter") {
+		t.Errorf("gate problems = %q, want build script and linter missing", gates.Problems)
+	}
+	for _, name := range []string{CheckConfig, CheckPaths, CheckAgent} {
+		if c := checkNamed(t, report, name); !c.OK {
+			t.Errorf("%s check failed: %q", name, c.Problems)
+		}
+	}
+
+	// The build script appearing in the clone satisfies its gate.
+	script := filepath.Join(r.Path, "refinery", "rig", "scripts", "build.sh")
+	if err := os.MkdirAll(filepath.Dir(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if gates := checkNamed(t, CheckRig(townRoot, r), CheckGates); len(gates.Problems) != 1 {
+		t.Errorf("gate problems = %q, want only the linter missing", gates.Problems)
+	}
+}
+
+func TestCheckRig_MissingAgentAndClone(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	townRoot := t.TempDir()
+	rigPath := filepath.Join(townRoot, "testrig")
+	if err := os.MkdirAll(rigPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	report := CheckRig(townRoot, &rig.Rig{Name: "testrig", Path: rigPath})
+	for _, name := range []string{CheckBranches, CheckPaths, CheckAgent} {
+		if c := checkNamed(t, report, name); c.OK {
+			t.Errorf("%s check passed without a clone or agent", name)
+		}
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	for cmd, want := range map[string]string{
+		"go test ./...":             "go",
+		"CGO_ENABLED=0 go build":    "go",
+		"A=1 B=2 ./run.sh --fast":   "./run.sh",
+		`"make" check`:              "make",
+		"  ":                        "",
+		"/usr/bin/env python3 -m x": "/usr/bin/env",
+	} {
+		if got := commandName(cmd); got != want {
+			t.Errorf("commandName(%q) = %q, want %q", cmd, got, want)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	townRoot := t.TempDir()
+	if reports, err := Load(townRoot); err != nil || len(reports) != 0 {
+		t.Fatalf("Load() with nothing stored = %v, %v", reports, err)
+	}
+	want := map[string]*Report{"a": {Rig: "a", Ready: true, Checks: []Check{{Name: CheckAgent, OK: true}}}}
+	if err := Save(townRoot, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["a"] == nil || !got["a"].Ready || len(got["a"].Checks) != 1 {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,221 @@
+package reaper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// gcDeletedBy is recorded in deleted_by on issues tombstoned by GC.
+const gcDeletedBy = "reaper-gc"
+
+// gcKeepFields are the description fields a tombstone keeps. They are the
+// references other records follow back to the issue: merge commits and MR
+// beads (merge_commit, source_issue, branch), convoys, agent beads, and the
+// molecule or formula that was attached. Everything else in the description
+// — prose, attached_args, attached_vars — is dropped.
+var gcKeepFields = map[string]bool{
+	"branch":            true,
+	"target":            true,
+	"source_issue":      true,
+	"worker":            true,
+	"rig":               true,
+	"merge_commit":      true,
+	"close_reason":      true,
+	"convoy_id":         true,
+	"agent_bead":        true,
+	"attached_molecule": true,
+	"attached_formula":  true,
+}
+
+// GCResult holds the results of a GC operation.
+type GCResult struct {
+	Database        string    `json:"database"`
+	Tombstoned      int       `json:"tombstoned"`
+	CommentsDropped int       `json:"comments_dropped"`
+	BytesCompacted  int       `json:"bytes_compacted"`
+	DryRun          bool      `json:"dry_run,omitempty"`
+	Anomalies       []Anomaly `json:"anomalies,omitempty"`
+}
+
+// compactDescription reduces a closed issue's description to the reference
+// fields a tombstone keeps, in their original order.
+func compactDescription(description string) string {
+	var kept []string
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		colonIdx := strings.Index(line, ":")
+		if colonIdx == -1 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:colonIdx]))
+		key = strings.ReplaceAll(key, "-", "_")
+		if gcKeepFields[key] && strings.TrimSpace(line[colonIdx+1:]) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// gcWhere returns the WHERE condition selecting GC candidates: closed issues
+// past retention that nothing live still needs. Issues with persistent
+// identity (agents, keep/standing-order/role/rig labels) are excluded, as is
+// mail, which Purge deletes outright. Issues that an open issue depends on
+// are excluded so its dependency keeps resolving to the full record.
+func gcWhere(dbName string) string {
+	return fmt.Sprintf(`
+		i.status = 'closed'
+		AND i.closed_at < ?
+		AND i.issue_type != 'agent'
+		AND i.id NOT IN (
+			SELECT DISTINCT l.issue_id FROM `+"`%s`"+`.labels l
+			WHERE l.label IN ('gt:keep', 'gt:standing-orders', 'gt:role', 'gt:rig', 'gt:message')
+		)
+		AND i.id NOT IN (
+			SELECT DISTINCT d.depends_on_id FROM `+"`%s`"+`.dependencies d
+			INNER JOIN `+"`%s`"+`.issues live ON d.issue_id = live.id
+			WHERE live.status NOT IN ('closed', 'tombstone')
+		)`, dbName, dbName, dbName)
+}
+
+// GC tombstones closed issues past retention. Rows are never deleted: the
+// issue keeps its ID, title, labels, dependencies and events, so merge
+// commits, MR beads and audit logs that name it still resolve. What goes is
+// the bulk — comments, design, notes, acceptance criteria, and all of the
+// description except the reference fields in gcKeepFields. The pre-GC size
+// is recorded in original_size.
+//
+// Run before history compaction: the flatten and dolt_gc that follow are
+// what actually reclaim the storage.
+func GC(db *sql.DB, dbName string, retention time.Duration, dryRun bool) (*GCResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	cutoff := time.Now().UTC().Add(-retention)
+	whereClause := gcWhere(dbName)
+	result := &GCResult{Database: dbName, DryRun: dryRun}
+
+	result.Anomalies = append(result.Anomalies, danglingIssueRefs(ctx, db, dbName)...)
+
+	if dryRun {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM `%s`.issues i WHERE %s", dbName, whereClause)
+		if err := db.QueryRowContext(ctx, countQuery, cutoff).Scan(&result.Tombstoned); err != nil {
+			if isTableNotFound(err) {
+				return result, nil // issues/labels/dependencies not on this server
+			}
+			return nil, fmt.Errorf("dry-run count: %w", err)
+		}
+		return result, nil
+	}
+
+	if _, err := db.ExecContext(ctx, "SET @@autocommit = 0"); err != nil {
+		return nil, fmt.Errorf("disable autocommit: %w", err)
+	}
+	defer func() {
+		_, _ = db.ExecContext(context.Background(), "SET @@autocommit = 1")
+	}()
+
+	// Tombstoned issues leave the candidate set, so each pass selects the
+	// next batch.
+	selectQuery := fmt.Sprintf(
+		"SELECT i.id, i.description, i.design, i.acceptance_criteria, i.notes FROM `%s`.issues i WHERE %s LIMIT %d",
+		dbName, whereClause, DefaultBatchSize)
+	// original_size is assigned first: MySQL evaluates SET left to right, so
+	// it must read the columns before they are cleared.
+	updateQuery := fmt.Sprintf(`UPDATE `+"`%s`"+`.issues SET
+		original_size = COALESCE(original_size, LENGTH(description) + LENGTH(design) + LENGTH(acceptance_criteria) + LENGTH(notes)),
+		status = 'tombstone', original_type = issue_type,
+		description = ?, design = '', acceptance_criteria = '', notes = '',
+		compaction_level = COALESCE(compaction_level, 0) + 1, compacted_at = NOW(),
+		deleted_at = NOW(), deleted_by = ?, delete_reason = ?
+		WHERE id = ?`, dbName)
+	reason := fmt.Sprintf("gc: closed longer than %s", retention)
+
+	for {
+		rows, err := db.QueryContext(ctx, selectQuery, cutoff)
+		if err != nil {
+			if isTableNotFound(err) && result.Tombstoned == 0 {
+				return result, nil // issues/labels/dependencies not on this server
+			}
+			return result, fmt.Errorf("select gc batch: %w", err)
+		}
+
+		var ids []string
+		descriptions := make(map[string]string)
+		for rows.Next() {
+			var id, description, design, acceptance, notes string
+			if err := rows.Scan(&id, &description, &design, &acceptance, &notes); err != nil {
+				rows.Close()
+				return result, fmt.Errorf("scan gc candidate: %w", err)
+			}
+			compacted := compactDescription(description)
+			ids = append(ids, id)
+			descriptions[id] = compacted
+			result.BytesCompacted += len(description) + len(design) + len(acceptance) + len(notes) - len(compacted)
+		}
+		rows.Close()
+
+		if len(ids) == 0 {
+			break
+		}
+
+		placeholders := make([]string, len(ids))
+		args := make([]interface{}, len(ids))
+		for i, id := range ids {
+			placeholders[i] = "?"
+			args[i] = id
+		}
+		delComments := fmt.Sprintf("DELETE FROM `%s`.comments WHERE issue_id IN (%s)", dbName, strings.Join(placeholders, ","))
+		if sqlResult, err := db.ExecContext(ctx, delComments, args...); err == nil {
+			affected, _ := sqlResult.RowsAffected()
+			result.CommentsDropped += int(affected)
+		} else if !isTableNotFound(err) {
+			return result, fmt.Errorf("drop comments: %w", err)
+		}
+
+		for _, id := range ids {
+			if _, err := db.ExecContext(ctx, updateQuery, descriptions[id], gcDeletedBy, reason, id); err != nil {
+				return result, fmt.Errorf("tombstone %s: %w", id, err)
+			}
+			result.Tombstoned++
+		}
+	}
+
+	if result.Tombstoned > 0 {
+		// Flush SQL transaction to working set before DOLT_COMMIT.
+		if _, err := db.ExecContext(ctx, "COMMIT"); err != nil {
+			return result, fmt.Errorf("sql commit: %w", err)
+		}
+		commitMsg := fmt.Sprintf("reaper: gc tombstone %d closed issues in %s", result.Tombstoned, dbName)
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("CALL DOLT_COMMIT('-Am', '%s')", commitMsg)); err != nil { //nolint:gosec // G201: commitMsg from safe values
+			result.Anomalies = append(result.Anomalies, Anomaly{
+				Type:    "dolt_commit_failed",
+				Message: fmt.Sprintf("dolt commit after gc failed: %v", err),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// danglingIssueRefs reports dependencies naming issues that no longer exist.
+// Cross-rig (external:) references and wisps are not counted. GC never creates these, but hard deletes elsewhere (mail purge, manual
+// cleanup) can, and GC is where referential integrity is checked.
+func danglingIssueRefs(ctx context.Context, db *sql.DB, dbName string) []Anomaly {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) FROM `+"`%s`"+`.dependencies d
+		LEFT JOIN `+"`%s`"+`.issues target ON target.id = d.depends_on_id
+		LEFT JOIN `+"`%s`"+`.wisps w ON w.id = d.depends_on_id
+		WHERE target.id IS NULL AND w.id IS NULL AND d.depends_on_id NOT LIKE 'external:%%'`, dbName, dbName, dbName)
+	var count int
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil || count == 0 {
+		return nil
+	}
+	return []Anomaly{{
+		Type:    "dangling_issue_ref",
+		Message: fmt.Sprintf("%d dependency record(s) point to missing issues", count),
+		Count:   count,
+	}}
+}
@@ -0,0 +1,82 @@
+package reaper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompactDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "MR keeps references, drops prose",
+			in: `Merge polecat work into main
+
+branch: polecat/nux/gt-abc
+target: main
+source_issue: gt-abc
+merge_commit: deadbeef
+close_reason: merged
+retry_count: 2
+
+Lots of review discussion that nobody needs in a year.`,
+			want: "branch: polecat/nux/gt-abc\ntarget: main\nsource_issue: gt-abc\nmerge_commit: deadbeef\nclose_reason: merged",
+		},
+		{
+			name: "attachment keeps molecule, drops args and vars",
+			in: `Fix the thing
+attached_molecule: gt-wisp-123
+attached_formula: mol-polecat-work
+attached_at: 2026-01-02T03:04:05Z
+attached_args: a very long argument string
+attached_vars: {"huge":"blob"}`,
+			want: "attached_molecule: gt-wisp-123\nattached_formula: mol-polecat-work",
+		},
+		{
+			name: "key variants are normalized",
+			in:   "Merge-Commit: abc123\nSource-Issue: gt-1",
+			want: "Merge-Commit: abc123\nSource-Issue: gt-1",
+		},
+		{
+			name: "empty values are dropped",
+			in:   "merge_commit:\nbranch: feature",
+			want: "branch: feature",
+		},
+		{
+			name: "plain prose compacts to nothing",
+			in:   "Just a description\nwith no fields at all",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compactDescription(tt.in); got != tt.want {
+				t.Errorf("compactDescription() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGCWhereProtectsLiveReferences(t *testing.T) {
+	where := gcWhere("testdb")
+
+	for _, want := range []string{
+		"i.status = 'closed'",
+		"i.closed_at < ?",
+		"i.issue_type != 'agent'",
+		"'gt:keep'",
+		"'gt:message'",
+		"`testdb`.dependencies",
+		"live.status NOT IN ('closed', 'tombstone')",
+	} {
+		if !strings.Contains(where, want) {
+			t.Errorf("gcWhere() missing %q:\n%s", want, where)
+		}
+	}
+	if strings.Contains(where, "DELETE") {
+		t.Error("gcWhere() must only select; GC never deletes issue rows")
+	}
+}
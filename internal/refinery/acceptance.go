@@ -0,0 +1,145 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// acceptanceGateTimeout bounds each acceptance criterion run by the refinery.
+const acceptanceGateTimeout = 10 * time.Minute
+
+// errInvalidAcceptance marks an issue whose acceptance criteria are malformed,
+// as opposed to one whose criteria could not be loaded.
+var errInvalidAcceptance = errors.New("invalid acceptance criteria")
+
+// loadAcceptanceFromBeads reads the acceptance criteria declared on issueID.
+func (e *Engineer) loadAcceptanceFromBeads(issueID string) ([]beads.AcceptanceCriterion, error) {
+	issue, err := e.beads.Show(issueID)
+	if err != nil {
+		return nil, err
+	}
+	criteria, err := beads.ParseAcceptanceCriteria(issue.Description)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidAcceptance, err)
+	}
+	return criteria, nil
+}
+
+// acceptanceFor returns the acceptance criteria of mr's source issue.
+// Results are cached per issue until the next batch (see
+// resetAcceptance); lookup failures are not, so a transient beads error is
+// retried on the next gate run.
+func (e *Engineer) acceptanceFor(mr *MRInfo) ([]beads.AcceptanceCriterion, error) {
+	if mr.SourceIssue == "" {
+		return nil, nil
+	}
+	e.acceptanceMu.Lock()
+	cached, ok := e.acceptance[mr.SourceIssue]
+	e.acceptanceMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	criteria, err := e.loadAcceptance(mr.SourceIssue)
+	if err != nil {
+		return nil, err
+	}
+	e.acceptanceMu.Lock()
+	e.acceptance[mr.SourceIssue] = criteria
+	e.acceptanceMu.Unlock()
+	return criteria, nil
+}
+
+// resetAcceptance forgets the cached acceptance criteria, so criteria
+// edited on an issue apply from the next batch or MR on. Within one, every
+// gate run sees the same criteria.
+func (e *Engineer) resetAcceptance() {
+	e.acceptanceMu.Lock()
+	clear(e.acceptance)
+	e.acceptanceMu.Unlock()
+}
+
+// acceptanceGate converts a criterion into a gate run in the refinery worktree.
+func acceptanceGate(c beads.AcceptanceCriterion) *GateConfig {
+	cmd := c.Spec
+	if c.Kind == beads.AcceptanceFile {
+		cmd = "test -e " + shellQuote(c.Spec)
+	}
+	return &GateConfig{Cmd: cmd, Timeout: acceptanceGateTimeout}
+}
+
+// runAcceptance runs the acceptance criteria of every MR's source issue
+// against the current working tree, as issue-specific gates. A failing
+// criterion fails the run like any gate, so batches bisect to the MR whose
+// issue declared it.
+//
+// Issues whose criteria can't be loaded are skipped with a warning, and an
+// issue with malformed criteria fails the run.
+func (e *Engineer) runAcceptance(ctx context.Context, mrs []*MRInfo) ProcessResult {
+	return e.runAcceptanceIn(ctx, e.workDir, mrs)
+}
+
+// runAcceptanceIn runs the acceptance criteria of mrs against the tree in dir.
+func (e *Engineer) runAcceptanceIn(ctx context.Context, dir string, mrs []*MRInfo) ProcessResult {
+	for _, mr := range mrs {
+		criteria, err := e.acceptanceFor(mr)
+		if err != nil {
+			if errors.Is(err, errInvalidAcceptance) {
+				return ProcessResult{
+					Success:     false,
+					TestsFailed: true,
+					Error:       fmt.Sprintf("%s: %v", mr.SourceIssue, err),
+				}
+			}
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: could not load acceptance criteria for %s: %v\n", mr.SourceIssue, err)
+			continue
+		}
+		for i, c := range criteria {
+			name := fmt.Sprintf("acceptance:%s#%d", mr.SourceIssue, i+1)
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: starting (%s)\n", name, c)
+			r := e.runGateIn(ctx, dir, name, acceptanceGate(c))
+			if !r.Success {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: FAILED (%v) - %s\n", name, r.Elapsed.Truncate(time.Millisecond), r.Error)
+				return ProcessResult{
+					Success:     false,
+					TestsFailed: true,
+					Error:       fmt.Sprintf("acceptance criterion %q of %s failed: %s", c, mr.SourceIssue, r.Error),
+				}
+			}
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: passed (%v)\n", name, r.Elapsed.Truncate(time.Millisecond))
+		}
+	}
+	return ProcessResult{Success: true}
+}
+
+// markAcceptanceVerified labels the source issue of each landed MR that
+// declared acceptance criteria as verified. Failures are logged, not fatal:
+// the code has already landed.
+func (e *Engineer) markAcceptanceVerified(mrs []*MRInfo) {
+	for _, mr := range mrs {
+		if mr.SourceIssue == "" {
+			continue
+		}
+		e.acceptanceMu.Lock()
+		criteria := e.acceptance[mr.SourceIssue]
+		e.acceptanceMu.Unlock()
+		if len(criteria) == 0 {
+			continue
+		}
+		if err := e.markVerified(mr.SourceIssue); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to mark %s verified: %v\n", mr.SourceIssue, err)
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Acceptance criteria passed: %s marked verified\n", mr.SourceIssue)
+	}
+}
+
+// shellQuote single-quotes s for use in an sh -c command line.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,123 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// stubAcceptance makes e read acceptance criteria from criteria (by source
+// issue) and records issues marked verified.
+func stubAcceptance(e *Engineer, criteria map[string]string) (verified *[]string) {
+	verified = &[]string{}
+	e.loadAcceptance = func(issueID string) ([]beads.AcceptanceCriterion, error) {
+		return beads.ParseAcceptanceCriteria(criteria[issueID])
+	}
+	e.markVerified = func(issueID string) error {
+		*verified = append(*verified, issueID)
+		return nil
+	}
+	return verified
+}
+
+func TestRunAcceptance(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	stubAcceptance(e, map[string]string{
+		"gt-ok":   "acceptance: file: README.md\nacceptance: cmd: true",
+		"gt-fail": "acceptance: file: missing.md",
+	})
+
+	ok := &MRInfo{ID: "mr-1", SourceIssue: "gt-ok"}
+	if r := e.runAcceptance(context.Background(), []*MRInfo{ok}); !r.Success {
+		t.Fatalf("expected acceptance to pass, got %s", r.Error)
+	}
+
+	bad := &MRInfo{ID: "mr-2", SourceIssue: "gt-fail"}
+	r := e.runAcceptance(context.Background(), []*MRInfo{ok, bad})
+	if r.Success || !r.TestsFailed {
+		t.Fatalf("expected test failure, got %+v", r)
+	}
+	if !strings.Contains(r.Error, "gt-fail") {
+		t.Errorf("expected error to name the issue, got %q", r.Error)
+	}
+}
+
+func TestRunAcceptance_LoadErrorSkipsButInvalidFails(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	e.loadAcceptance = func(issueID string) ([]beads.AcceptanceCriterion, error) {
+		return nil, fmt.Errorf("bd unavailable")
+	}
+	mr := &MRInfo{ID: "mr-1", SourceIssue: "gt-1"}
+	if r := e.runAcceptance(context.Background(), []*MRInfo{mr}); !r.Success {
+		t.Errorf("expected load errors to be skipped, got %s", r.Error)
+	}
+
+	e.loadAcceptance = func(issueID string) ([]beads.AcceptanceCriterion, error) {
+		return nil, fmt.Errorf("%w: bad line", errInvalidAcceptance)
+	}
+	if r := e.runAcceptance(context.Background(), []*MRInfo{mr}); r.Success || !r.TestsFailed {
+		t.Errorf("expected malformed criteria to fail, got %+v", r)
+	}
+}
+
+func TestProcessBatch_AcceptanceCulpritAndVerified(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+	createFeatureBranch(t, workDir, "polecat/c", "c.txt", "c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	verified := stubAcceptance(e, map[string]string{
+		"gt-a": "acceptance: file: a.txt",
+		"gt-b": "acceptance: file: docs/never-written.md",
+	})
+
+	mrA := makeMR("mr-a", "polecat/a", "main")
+	mrA.SourceIssue = "gt-a"
+	mrB := makeMR("mr-b", "polecat/b", "main")
+	mrB.SourceIssue = "gt-b"
+	mrC := makeMR("mr-c", "polecat/c", "main")
+
+	result := e.ProcessBatch(context.Background(), []*MRInfo{mrA, mrB, mrC}, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if got := stackedIDs(result.Culprits); len(got) != 1 || got[0] != "mr-b" {
+		t.Errorf("expected mr-b as culprit, got %v", got)
+	}
+	if got := stackedIDs(result.Merged); len(got) != 2 {
+		t.Errorf("expected mr-a and mr-c merged, got %v", got)
+	}
+	if len(*verified) != 1 || (*verified)[0] != "gt-a" {
+		t.Errorf("expected only gt-a verified, got %v", *verified)
+	}
+}
+
+func TestProcessBatch_ReloadsEditedAcceptance(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	criteria := map[string]string{"gt-a": "acceptance: file: docs/never-written.md"}
+	stubAcceptance(e, criteria)
+
+	mr := makeMR("mr-a", "polecat/a", "main")
+	mr.SourceIssue = "gt-a"
+	if result := e.ProcessBatch(context.Background(), []*MRInfo{mr}, "main", DefaultBatchConfig()); len(result.Merged) != 0 {
+		t.Fatalf("expected the unmet criterion to block mr-a, got %+v", result)
+	}
+
+	// The issue's criteria are fixed; the next batch sees the edit.
+	criteria["gt-a"] = "acceptance: file: a.txt"
+	mr = makeMR("mr-a", "polecat/a", "main")
+	mr.SourceIssue = "gt-a"
+	if result := e.ProcessBatch(context.Background(), []*MRInfo{mr}, "main", DefaultBatchConfig()); len(result.Merged) != 1 {
+		t.Errorf("expected mr-a to land with the edited criteria, got %+v", result)
+	}
+}
@@ -0,0 +1,56 @@
+package refinery
+
+// DefaultAdaptiveWindow is how many recent batches adaptive batch sizing
+// replays by default.
+const DefaultAdaptiveWindow = 20
+
+// defaultMaxBatchSize caps batches whose config leaves MaxBatchSize unset.
+const defaultMaxBatchSize = 5
+
+// EffectiveBatchSize returns how many MRs the next batch targeting target
+// may hold. Without AdaptiveSize it is MaxBatchSize. With it, the target's
+// last AdaptiveWindow batches are replayed from the batch log (see History)
+// starting at MaxBatchSize: a batch that landed MRs without blaming any
+// adds one, a batch whose gates blamed an MR halves the size, and other
+// batches (conflicts only, errors, deferrals) leave it alone. The result is
+// kept within MinBatchSize and MaxBatchSize.
+//
+// Replaying the log rather than keeping a counter means the size survives
+// refinery restarts and needs no state of its own.
+func (e *Engineer) EffectiveBatchSize(target string, config *BatchConfig) int {
+	if config == nil {
+		config = e.defaultBatchConfig()
+	}
+	maxSize := config.MaxBatchSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxBatchSize
+	}
+	if !config.AdaptiveSize || e.rig == nil {
+		return maxSize
+	}
+	minSize := min(max(config.MinBatchSize, 1), maxSize)
+	window := config.AdaptiveWindow
+	if window <= 0 {
+		window = DefaultAdaptiveWindow
+	}
+	records, err := e.History(HistoryQuery{Target: target, Limit: window})
+	if err != nil {
+		return maxSize
+	}
+	return adaptBatchSize(records, minSize, maxSize)
+}
+
+// adaptBatchSize replays records, oldest first, from maxSize: additive
+// increase on a clean landing, multiplicative decrease on a culprit.
+func adaptBatchSize(records []*BatchRecord, minSize, maxSize int) int {
+	size := maxSize
+	for _, r := range records {
+		switch {
+		case len(r.Culprits) > 0:
+			size = max(size/2, minSize)
+		case len(r.Merged) > 0 && r.Error == "":
+			size = min(size+1, maxSize)
+		}
+	}
+	return size
+}
@@ -0,0 +1,75 @@
+package refinery
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestAdaptBatchSize(t *testing.T) {
+	clean := &BatchRecord{Merged: []string{"mr-1"}}
+	blamed := &BatchRecord{Merged: []string{"mr-1"}, Culprits: []string{"mr-2"}}
+	conflicted := &BatchRecord{Conflicts: []string{"mr-3"}}
+	failed := &BatchRecord{Merged: []string{"mr-1"}, Error: "push to origin: rejected"}
+
+	tests := []struct {
+		name    string
+		records []*BatchRecord
+		want    int
+	}{
+		{"no history starts at max", nil, 8},
+		{"clean batches stay at max", []*BatchRecord{clean, clean}, 8},
+		{"culprit halves", []*BatchRecord{blamed}, 4},
+		{"halving stops at min", []*BatchRecord{blamed, blamed, blamed, blamed}, 2},
+		{"clean batches grow by one", []*BatchRecord{blamed, blamed, clean, clean}, 4},
+		{"conflicts and errors leave it alone", []*BatchRecord{blamed, conflicted, failed}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adaptBatchSize(tt.records, 2, 8); got != tt.want {
+				t.Errorf("adaptBatchSize = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffectiveBatchSize(t *testing.T) {
+	dir := t.TempDir()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: dir})
+	for _, r := range []*BatchRecord{
+		{Target: "main", Merged: []string{"mr-1"}, Culprits: []string{"mr-2"}},
+		{Target: "main", Merged: []string{"mr-3"}, Culprits: []string{"mr-4"}},
+		{Target: "release", Merged: []string{"mr-5"}},
+	} {
+		if err := appendBatchRecord(BatchLogPath(dir), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &BatchConfig{MaxBatchSize: 8, AdaptiveSize: true}
+	if got := e.EffectiveBatchSize("main", cfg); got != 2 {
+		t.Errorf("main = %d, want 2", got)
+	}
+	if got := e.EffectiveBatchSize("release", cfg); got != 8 {
+		t.Errorf("release = %d, want 8", got)
+	}
+	// Only the window is replayed: the last batch alone halves once.
+	if got := e.EffectiveBatchSize("main", &BatchConfig{MaxBatchSize: 8, AdaptiveSize: true, AdaptiveWindow: 1}); got != 4 {
+		t.Errorf("window 1 = %d, want 4", got)
+	}
+	if got := e.EffectiveBatchSize("main", &BatchConfig{MaxBatchSize: 8, AdaptiveSize: true, MinBatchSize: 3}); got != 3 {
+		t.Errorf("min 3 = %d, want 3", got)
+	}
+	if got := e.EffectiveBatchSize("main", &BatchConfig{MaxBatchSize: 8}); got != 8 {
+		t.Errorf("not adaptive = %d, want 8", got)
+	}
+
+	mrs := make([]*MRInfo, 10)
+	for i := range mrs {
+		mrs[i] = makeMR(fmt.Sprintf("mr-%d", i), fmt.Sprintf("branch-%d", i), "main")
+	}
+	if batch := e.AssembleBatch(mrs, cfg); len(batch) != 2 {
+		t.Errorf("AssembleBatch took %d MRs, want 2", len(batch))
+	}
+}
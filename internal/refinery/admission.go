@@ -0,0 +1,232 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// HeldForReview marks an MR held because its diff exceeds the admission
+// limits or touches a protected path (see MRInfo.HeldFor). There is no
+// task: the MR is flagged in beads and admitted once a human approves it
+// or its author pushes a branch within the limits.
+const HeldForReview = "review"
+
+// Admission limit defaults.
+const (
+	DefaultAdmissionReviewLabel  = "gt:needs-review"
+	DefaultAdmissionApproveLabel = "gt:review-approved"
+)
+
+// AdmissionLimitsConfig keeps MRs that are too large, or that touch paths
+// only humans should change, out of automatic batches. Unlike the split
+// policy no agent is asked to fix them: they are labeled for human review.
+type AdmissionLimitsConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// MaxLines is the number of changed lines (added + deleted) above which
+	// an MR is held. Zero means no limit.
+	MaxLines int `json:"max_lines,omitempty"`
+
+	// MaxFiles is the number of changed files above which an MR is held.
+	// Zero means no limit.
+	MaxFiles int `json:"max_files,omitempty"`
+
+	// ProtectedPaths match files an MR may not change without review
+	// (e.g. ".github/**", "*.sql"). Same syntax as
+	// TestPolicyConfig.TestPatterns.
+	ProtectedPaths []string `json:"protected_paths,omitempty"`
+
+	// IgnorePatterns match files left out of the line and file counts, but
+	// not out of the protected path check. Default:
+	// DefaultSplitIgnorePatterns.
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+
+	// ReviewLabel is added to held MRs' beads. Default:
+	// DefaultAdmissionReviewLabel.
+	ReviewLabel string `json:"review_label,omitempty"`
+
+	// ApproveLabel on an MR's bead admits it whatever its diff. Default:
+	// DefaultAdmissionApproveLabel.
+	ApproveLabel string `json:"approve_label,omitempty"`
+}
+
+// validateAdmissionLimits checks that the limits are non-negative and the
+// labels distinct.
+func validateAdmissionLimits(cfg *AdmissionLimitsConfig) error {
+	if cfg.MaxLines < 0 {
+		return fmt.Errorf("admission_limits max_lines must be non-negative, got %d", cfg.MaxLines)
+	}
+	if cfg.MaxFiles < 0 {
+		return fmt.Errorf("admission_limits max_files must be non-negative, got %d", cfg.MaxFiles)
+	}
+	if cfg.reviewLabel() == cfg.approveLabel() {
+		return fmt.Errorf("admission_limits review_label and approve_label must differ")
+	}
+	return nil
+}
+
+func (c *AdmissionLimitsConfig) ignorePatterns() []string {
+	if c.IgnorePatterns != nil {
+		return c.IgnorePatterns
+	}
+	return DefaultSplitIgnorePatterns
+}
+
+func (c *AdmissionLimitsConfig) reviewLabel() string {
+	if c.ReviewLabel != "" {
+		return c.ReviewLabel
+	}
+	return DefaultAdmissionReviewLabel
+}
+
+func (c *AdmissionLimitsConfig) approveLabel() string {
+	if c.ApproveLabel != "" {
+		return c.ApproveLabel
+	}
+	return DefaultAdmissionApproveLabel
+}
+
+// admissionReasons lists the limits a diff exceeds and the protected paths
+// it changes.
+func admissionReasons(stats []git.DiffStat, cfg *AdmissionLimitsConfig) []string {
+	var files, lines int
+	var protected []string
+	for _, st := range stats {
+		if matchAnyPattern(cfg.ProtectedPaths, st.Path) {
+			protected = append(protected, st.Path)
+		}
+		if matchAnyPattern(cfg.ignorePatterns(), st.Path) {
+			continue
+		}
+		files++
+		lines += st.Added + st.Deleted
+	}
+	var reasons []string
+	if cfg.MaxLines > 0 && lines > cfg.MaxLines {
+		reasons = append(reasons, fmt.Sprintf("%d lines changed (max %d)", lines, cfg.MaxLines))
+	}
+	if cfg.MaxFiles > 0 && files > cfg.MaxFiles {
+		reasons = append(reasons, fmt.Sprintf("%d files changed (max %d)", files, cfg.MaxFiles))
+	}
+	if len(protected) > 0 {
+		reasons = append(reasons, "changes protected paths: "+strings.Join(protected, ", "))
+	}
+	return reasons
+}
+
+// ReviewHold records an MR flagged for review by the admission limits.
+type ReviewHold struct {
+	MR        string    `json:"mr"`
+	Head      string    `json:"head"` // Branch head that was flagged
+	Reasons   []string  `json:"reasons"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+func (e *Engineer) reviewHoldsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "review-holds.json")
+}
+
+// ReviewHolds returns the MRs currently flagged for review, keyed by MR ID.
+func (e *Engineer) ReviewHolds() (map[string]*ReviewHold, error) {
+	holds := make(map[string]*ReviewHold)
+	data, err := os.ReadFile(e.reviewHoldsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return holds, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &holds); err != nil {
+		return nil, fmt.Errorf("parsing review holds: %w", err)
+	}
+	return holds, nil
+}
+
+// admitLimited applies the admission limits. MRs over a limit or touching
+// a protected path are held and labeled for review in beads, once per
+// branch head; a human admits one by adding the approve label to its bead.
+// An MR whose author brings it back within the limits is admitted and its
+// review label removed.
+//
+// Like the split and test policies it fails open: MRs whose diff can't be
+// read are admitted with a warning.
+func (e *Engineer) admitLimited(ready []*MRInfo) (admitted, held []*MRInfo) {
+	cfg := e.config.AdmissionLimits
+	if cfg == nil || !cfg.Enabled || len(ready) == 0 {
+		return ready, nil
+	}
+	holds, err := e.ReviewHolds()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Admission] Warning: %v (admitting all MRs)\n", err)
+		return ready, nil
+	}
+
+	changed := false
+	for _, mr := range ready {
+		if hasAnyLabel(mr.Labels, []string{cfg.approveLabel()}) {
+			admitted = append(admitted, mr)
+			if holds[mr.ID] != nil {
+				delete(holds, mr.ID)
+				changed = true
+			}
+			continue
+		}
+		stats, err := e.mrDiffStats(mr)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Admission] Warning: MR %s: reading diff: %v (admitting)\n", mr.ID, err)
+			admitted = append(admitted, mr)
+			continue
+		}
+		reasons := admissionReasons(stats, cfg)
+		if len(reasons) == 0 {
+			admitted = append(admitted, mr)
+			if holds[mr.ID] != nil || hasAnyLabel(mr.Labels, []string{cfg.reviewLabel()}) {
+				delete(holds, mr.ID)
+				changed = true
+				if err := e.labelMR(mr.ID, nil, []string{cfg.reviewLabel()}); err != nil {
+					_, _ = fmt.Fprintf(e.output, "[Admission] Warning: MR %s: removing %s: %v\n", mr.ID, cfg.reviewLabel(), err)
+				}
+			}
+			continue
+		}
+
+		mr.HeldFor = HeldForReview
+		held = append(held, mr)
+		why := strings.Join(reasons, "; ")
+		e.recordProgress(StageHeld, "needs review: "+why, "", mr)
+
+		head, _ := e.git.Rev(e.branchHead(mr.Branch))
+		if h := holds[mr.ID]; h != nil && h.Head == head {
+			continue
+		}
+		if err := e.labelMR(mr.ID, []string{cfg.reviewLabel()}, nil); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Admission] Warning: MR %s: adding %s: %v\n", mr.ID, cfg.reviewLabel(), err)
+			continue
+		}
+		holds[mr.ID] = &ReviewHold{MR: mr.ID, Head: head, Reasons: reasons, FlaggedAt: time.Now().UTC()}
+		changed = true
+		_, _ = fmt.Fprintf(e.output, "[Admission] MR %s held for review: %s (add %s to admit)\n", mr.ID, why, cfg.approveLabel())
+		e.notifyDesktop(config.DesktopEventApproval, "Review waiting: "+mr.ID,
+			fmt.Sprintf("%s MR %s (%s) is held for review: %s", e.rig.Name, mr.ID, mr.Branch, why))
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.reviewHoldsPath(), holds); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Admission] Warning: saving review holds: %v\n", err)
+		}
+	}
+	return admitted, held
+}
+
+// labelMRInBeads adds and removes labels on an MR bead.
+func (e *Engineer) labelMRInBeads(id string, add, remove []string) error {
+	return e.beads.Update(id, beads.UpdateOptions{AddLabels: add, RemoveLabels: remove})
+}
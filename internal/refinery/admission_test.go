@@ -0,0 +1,102 @@
+package refinery
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+func TestAdmissionReasons(t *testing.T) {
+	cfg := &AdmissionLimitsConfig{Enabled: true, MaxLines: 100, MaxFiles: 2, ProtectedPaths: []string{".github/**", "*.sql"}}
+	stats := []git.DiffStat{
+		{Path: "main.go", Added: 60, Deleted: 10},
+		{Path: "go.sum", Added: 500},
+		{Path: "util.go", Added: 20},
+	}
+	if reasons := admissionReasons(stats, cfg); len(reasons) != 0 {
+		t.Errorf("within limits: reasons = %v", reasons)
+	}
+
+	stats = append(stats, git.DiffStat{Path: "db/migrations/001.sql", Added: 40})
+	reasons := admissionReasons(stats, cfg)
+	want := []string{"130 lines changed (max 100)", "3 files changed (max 2)", "changes protected paths: db/migrations/001.sql"}
+	if strings.Join(reasons, "|") != strings.Join(want, "|") {
+		t.Errorf("reasons = %q, want %q", reasons, want)
+	}
+
+	// Ignored files are still protected.
+	cfg = &AdmissionLimitsConfig{Enabled: true, ProtectedPaths: []string{"vendor/**"}}
+	if reasons := admissionReasons([]git.DiffStat{{Path: "vendor/x/y.go", Added: 1}}, cfg); len(reasons) != 1 {
+		t.Errorf("vendored protected path: reasons = %v", reasons)
+	}
+}
+
+func TestAdmitLimited(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "small", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "schema", "schema.sql", "create table t (id int);\n")
+
+	e := newTestEngineer(t, workDir, g)
+	labels := make(map[string][]string)
+	e.labelMR = func(id string, add, remove []string) error {
+		labels[id] = append(labels[id], add...)
+		for _, r := range remove {
+			labels[id] = append(labels[id], "-"+r)
+		}
+		return nil
+	}
+	e.config.AdmissionLimits = &AdmissionLimitsConfig{Enabled: true, ProtectedPaths: []string{"*.sql"}}
+	small, schema := makeMR("mr-small", "small", "main"), makeMR("mr-schema", "schema", "main")
+
+	admitted, held := e.AdmitMRs([]*MRInfo{small, schema})
+	if len(admitted) != 1 || admitted[0] != small || len(held) != 1 || held[0] != schema {
+		t.Fatalf("admitted %v, held %v; want mr-small admitted and mr-schema held", mrIDs(admitted), mrIDs(held))
+	}
+	if schema.HeldFor != HeldForReview {
+		t.Errorf("HeldFor = %q, want %q", schema.HeldFor, HeldForReview)
+	}
+	if got := strings.Join(labels["mr-schema"], ","); got != DefaultAdmissionReviewLabel {
+		t.Errorf("mr-schema labels = %s, want %s added", got, DefaultAdmissionReviewLabel)
+	}
+	holds, err := e.ReviewHolds()
+	if err != nil || holds["mr-schema"] == nil || !strings.Contains(holds["mr-schema"].Reasons[0], "schema.sql") {
+		t.Fatalf("ReviewHolds() = %+v, %v", holds, err)
+	}
+
+	// Held again on the next pass, but flagged only once per branch head.
+	schema.HeldFor = ""
+	if _, held := e.AdmitMRs([]*MRInfo{schema}); len(held) != 1 || len(labels["mr-schema"]) != 1 {
+		t.Errorf("second pass: held %v, labels %v", mrIDs(held), labels["mr-schema"])
+	}
+
+	// A human approves it.
+	schema.Labels = []string{DefaultAdmissionReviewLabel, DefaultAdmissionApproveLabel}
+	if admitted, _ := e.AdmitMRs([]*MRInfo{schema}); len(admitted) != 1 {
+		t.Errorf("approved MR not admitted")
+	}
+	if holds, _ := e.ReviewHolds(); holds["mr-schema"] != nil {
+		t.Errorf("approved MR still recorded as held")
+	}
+}
+
+func TestLoadConfig_AdmissionLimits(t *testing.T) {
+	cfg := DefaultMergeQueueConfig()
+	err := applyMergeQueueConfig(cfg, []byte(`{"admission_limits": {"enabled": true, "max_lines": 2000, "protected_paths": ["*.sql"]}}`))
+	if err != nil {
+		t.Fatalf("applyMergeQueueConfig: %v", err)
+	}
+	if a := cfg.AdmissionLimits; a == nil || a.MaxLines != 2000 || len(a.ProtectedPaths) != 1 {
+		t.Errorf("admission_limits = %+v", cfg.AdmissionLimits)
+	}
+	for _, bad := range []string{
+		`{"max_lines": -1}`,
+		`{"max_files": -5}`,
+		`{"approve_label": "gt:needs-review"}`,
+	} {
+		if err := applyMergeQueueConfig(DefaultMergeQueueConfig(), []byte(`{"admission_limits": `+bad+`}`)); err == nil {
+			t.Errorf("admission_limits %s accepted", bad)
+		}
+	}
+}
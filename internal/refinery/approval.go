@@ -0,0 +1,133 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// HeldForApproval marks an MR held because its source issue isn't approved
+// (see MRInfo.HeldFor). There is no task: the MR is admitted on the first
+// pass after someone approves the issue.
+const HeldForApproval = "approval"
+
+// ApprovalConfig requires an MR's source issue to be approved in beads
+// before the MR is batched. Approval is either a label on the issue or a
+// "key: value" field of its description, read afresh on every pass.
+type ApprovalConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Label approves the issue when present, e.g. "approved".
+	Label string `json:"label,omitempty"`
+
+	// Field approves the issue when its description has it set, e.g.
+	// "approved_by". Used when Label is empty.
+	Field string `json:"field,omitempty"`
+
+	// Value, when set, is what Field must be set to (case-insensitive).
+	// Empty accepts any value.
+	Value string `json:"value,omitempty"`
+}
+
+// validateApproval checks that an enabled approval config says what
+// constitutes approval.
+func validateApproval(cfg *ApprovalConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if (cfg.Label == "") == (cfg.Field == "") {
+		return fmt.Errorf("approval: exactly one of label or field is required")
+	}
+	if cfg.Value != "" && cfg.Field == "" {
+		return fmt.Errorf("approval: value requires field")
+	}
+	return nil
+}
+
+// approved reports whether issue is approved, or what it lacks.
+func (c *ApprovalConfig) approved(issue *beads.Issue) (bool, string) {
+	if c.Label != "" {
+		if beads.HasLabel(issue, c.Label) {
+			return true, ""
+		}
+		return false, "no " + c.Label + " label"
+	}
+	value := beads.GetDescriptionField(issue.Description, c.Field)
+	switch {
+	case value == "":
+		return false, c.Field + " not set"
+	case c.Value != "" && !strings.EqualFold(value, c.Value):
+		return false, fmt.Sprintf("%s is %q, not %q", c.Field, value, c.Value)
+	}
+	return true, ""
+}
+
+// admitApproved applies the approval requirement. Unlike the other
+// policies it fails closed: an MR without a source issue, or whose issue
+// can't be read, is held, as nothing says it was approved.
+func (e *Engineer) admitApproved(ready []*MRInfo) (admitted, held []*MRInfo) {
+	cfg := e.config.Approval
+	if cfg == nil || !cfg.Enabled || len(ready) == 0 {
+		return ready, nil
+	}
+	notified := make(map[string]bool)
+	if data, err := os.ReadFile(e.approvalHoldsPath()); err == nil {
+		_ = json.Unmarshal(data, &notified)
+	}
+
+	changed := false
+	for _, mr := range ready {
+		why := e.unapproved(mr, cfg)
+		if why == "" {
+			admitted = append(admitted, mr)
+			if notified[mr.ID] {
+				delete(notified, mr.ID)
+				changed = true
+			}
+			continue
+		}
+		mr.HeldFor = HeldForApproval
+		held = append(held, mr)
+		e.recordProgress(StageHeld, why, "", mr)
+		if !notified[mr.ID] {
+			notified[mr.ID] = true
+			changed = true
+			e.notifyDesktop(config.DesktopEventApproval, "Approval waiting: "+mr.ID,
+				fmt.Sprintf("%s MR %s (%s) is held: %s", e.rig.Name, mr.ID, mr.Branch, why))
+		}
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.approvalHoldsPath(), notified); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Approval] Warning: saving approval holds: %v\n", err)
+		}
+	}
+	return admitted, held
+}
+
+// approvalHoldsPath records the MRs held for approval that a human has
+// been notified of, so each hold is announced once.
+func (e *Engineer) approvalHoldsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "approval-holds.json")
+}
+
+// unapproved returns why mr's source issue isn't approved, or "" if it is.
+func (e *Engineer) unapproved(mr *MRInfo, cfg *ApprovalConfig) string {
+	if mr.SourceIssue == "" {
+		return "no source issue to approve"
+	}
+	issue, err := e.showIssue(mr.SourceIssue)
+	if err != nil || issue == nil {
+		_, _ = fmt.Fprintf(e.output, "[Approval] Warning: MR %s: reading %s: %v (holding)\n", mr.ID, mr.SourceIssue, err)
+		return "approval of " + mr.SourceIssue + " unknown"
+	}
+	if ok, lacks := cfg.approved(issue); !ok {
+		return mr.SourceIssue + " not approved: " + lacks
+	}
+	return ""
+}
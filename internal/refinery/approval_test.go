@@ -0,0 +1,107 @@
+package refinery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+func TestAdmitApproved(t *testing.T) {
+	issues := map[string]*beads.Issue{
+		"gt-ok":      {ID: "gt-ok", Labels: []string{"approved"}},
+		"gt-pending": {ID: "gt-pending", Labels: []string{"needs-review"}},
+	}
+	e := newTestEngineer(t, t.TempDir(), nil)
+	e.showIssue = func(id string) (*beads.Issue, error) {
+		if issue, ok := issues[id]; ok {
+			return issue, nil
+		}
+		return nil, errors.New("not found")
+	}
+	ready := []*MRInfo{
+		{ID: "mr-1", SourceIssue: "gt-ok"},
+		{ID: "mr-2", SourceIssue: "gt-pending"},
+		{ID: "mr-3"},
+		{ID: "mr-4", SourceIssue: "gt-missing"},
+	}
+
+	if admitted, held := e.AdmitMRs(ready); len(admitted) != 4 || len(held) != 0 {
+		t.Fatalf("without approval config: admitted %v, held %v", mrIDs(admitted), mrIDs(held))
+	}
+
+	e.config.Approval = &ApprovalConfig{Enabled: true, Label: "approved"}
+	admitted, held := e.AdmitMRs(ready)
+	if got := strings.Join(mrIDs(admitted), " "); got != "mr-1" {
+		t.Errorf("admitted = %s, want mr-1", got)
+	}
+	if len(held) != 3 {
+		t.Fatalf("held = %v, want the other three", mrIDs(held))
+	}
+	for _, mr := range held {
+		if mr.HeldFor != HeldForApproval {
+			t.Errorf("%s HeldFor = %q, want %q", mr.ID, mr.HeldFor, HeldForApproval)
+		}
+	}
+
+	// Each hold is announced once; approving the issue clears it.
+	data, err := os.ReadFile(e.approvalHoldsPath())
+	if err != nil || !strings.Contains(string(data), `"mr-2"`) {
+		t.Fatalf("approval holds = %s, %v", data, err)
+	}
+	issues["gt-pending"].Labels = append(issues["gt-pending"].Labels, "approved")
+	e.AdmitMRs(ready)
+	if data, _ := os.ReadFile(e.approvalHoldsPath()); strings.Contains(string(data), `"mr-2"`) {
+		t.Errorf("approved MR still recorded as held: %s", data)
+	}
+}
+
+func TestApprovalConfig_Field(t *testing.T) {
+	cfg := &ApprovalConfig{Enabled: true, Field: "review", Value: "approved"}
+	issue := &beads.Issue{Description: "Fix the thing.\n\nreview: Approved\n"}
+	if ok, lacks := cfg.approved(issue); !ok {
+		t.Errorf("review: Approved not accepted: %s", lacks)
+	}
+	issue.Description = "review: changes-requested"
+	if ok, lacks := cfg.approved(issue); ok || !strings.Contains(lacks, "changes-requested") {
+		t.Errorf("changes-requested = %v, %q", ok, lacks)
+	}
+
+	cfg = &ApprovalConfig{Enabled: true, Field: "approved_by"}
+	if ok, _ := cfg.approved(&beads.Issue{Description: "approved_by: mayor"}); !ok {
+		t.Error("any approved_by value should approve")
+	}
+	if ok, _ := cfg.approved(&beads.Issue{}); ok {
+		t.Error("issue without approved_by approved")
+	}
+}
+
+func TestEngineer_LoadConfig_Approval(t *testing.T) {
+	for _, tc := range []struct {
+		json    string
+		wantErr bool
+	}{
+		{`{"enabled": true, "label": "approved"}`, false},
+		{`{"enabled": true, "field": "review", "value": "approved"}`, false},
+		{`{"enabled": true}`, true},
+		{`{"enabled": true, "label": "approved", "field": "review"}`, true},
+		{`{"enabled": true, "label": "approved", "value": "yes"}`, true},
+	} {
+		dir := t.TempDir()
+		data := `{"type": "rig", "version": 1, "name": "test-rig", "merge_queue": {"approval": ` + tc.json + `}}`
+		if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		e := newTestEngineer(t, dir, nil)
+		err := e.LoadConfig()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: LoadConfig error = %v, want error %v", tc.json, err, tc.wantErr)
+		}
+		if err == nil && e.config.Approval == nil {
+			t.Errorf("%s: approval config not loaded", tc.json)
+		}
+	}
+}
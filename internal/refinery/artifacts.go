@@ -0,0 +1,216 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// maxArtifactBatches is how many batches keep their gate logs.
+const maxArtifactBatches = 100
+
+// GateLog locates the captured output of one gate run in a batch. Logs
+// are keyed by the last MR of the tree the gate ran on: bisection blames
+// an MR by gating the MRs found good with it on top, so a culprit's logs
+// are those of the run that blamed it.
+type GateLog struct {
+	MR      string `json:"mr"`
+	Gate    string `json:"gate"`
+	Path    string `json:"path"`
+	SHA     string `json:"sha,omitempty"` // Tree tip the gate ran on
+	Success bool   `json:"success"`
+}
+
+type gateArtifactKey struct{}
+
+// withGateArtifacts makes gate runs under ctx save their output as logs of
+// mr in mr's batch. MRs outside a batch get no logs.
+func withGateArtifacts(ctx context.Context, mr *MRInfo) context.Context {
+	if mr == nil || mr.batchID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, gateArtifactKey{}, mr)
+}
+
+// artifactsDir returns the directory gate logs are kept under.
+func (e *Engineer) artifactsDir() string {
+	return filepath.Join(e.rig.Path, ".runtime", "artifacts")
+}
+
+// gateLogPath returns where the log of gate for mrID in batchID is kept.
+func (e *Engineer) gateLogPath(batchID, mrID, gate string) string {
+	return filepath.Join(e.artifactsDir(), batchID, mrID, gate+".log")
+}
+
+// validArtifactName reports whether name can be used as one path element.
+func validArtifactName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// saveGateLog writes r's output, from a run on the tree at sha, as a log of
+// the MR in ctx (see withGateArtifacts), replacing the log of an earlier
+// run on the same tree tip. Failures are logged and otherwise ignored.
+func (e *Engineer) saveGateLog(ctx context.Context, r GateResult, gate *GateConfig, sha string) {
+	mr, _ := ctx.Value(gateArtifactKey{}).(*MRInfo)
+	if mr == nil || e.rig == nil || !validArtifactName(r.Name) {
+		return
+	}
+	stack, _ := ctx.Value(gateStackKey{}).([]string)
+	if len(stack) == 0 {
+		stack = []string{mr.ID}
+	}
+	status := "passed"
+	switch {
+	case r.Success:
+	case r.TimedOut:
+		status = "timed out"
+	default:
+		status = "failed"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# gate: %s\n", r.Name)
+	fmt.Fprintf(&b, "# cmd: %s\n", gate.Cmd)
+	if gate.Dir != "" {
+		fmt.Fprintf(&b, "# dir: %s\n", gate.Dir)
+	}
+	if gate.Image != "" {
+		fmt.Fprintf(&b, "# image: %s\n", gate.Image)
+	}
+	fmt.Fprintf(&b, "# batch: %s\n", mr.batchID)
+	fmt.Fprintf(&b, "# stack: %s\n", strings.Join(stack, " "))
+	if sha != "" {
+		fmt.Fprintf(&b, "# commit: %s\n", sha)
+	}
+	fmt.Fprintf(&b, "# result: %s (%v)\n", status, r.Elapsed.Truncate(time.Millisecond))
+	if r.Error != "" {
+		fmt.Fprintf(&b, "# error: %s\n", strings.SplitN(r.Error, "\n", 2)[0])
+	}
+	b.WriteString("\n--- stdout ---\n")
+	b.Write(r.Stdout)
+	b.WriteString("\n--- stderr ---\n")
+	b.Write(r.Stderr)
+
+	path := e.gateLogPath(mr.batchID, mr.ID, r.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: saving %s log: %v\n", r.Name, err)
+		return
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: saving %s log: %v\n", r.Name, err)
+		return
+	}
+	if rec := batchRecorderFrom(ctx); rec != nil {
+		rec.mu.Lock()
+		rec.recordGateLog(&GateLog{MR: mr.ID, Gate: r.Name, Path: path, SHA: sha, Success: r.Success})
+		rec.mu.Unlock()
+	}
+}
+
+// recordGateLog notes log, replacing an earlier one for the same MR and
+// gate. The caller holds rec.mu.
+func (rec *batchRecorder) recordGateLog(log *GateLog) {
+	for i, l := range rec.gateLogs {
+		if l.MR == log.MR && l.Gate == log.Gate {
+			rec.gateLogs[i] = log
+			return
+		}
+	}
+	rec.gateLogs = append(rec.gateLogs, log)
+}
+
+// GateLog returns the captured output of gate's last run on a tree ending
+// at mrID in batchID.
+func (e *Engineer) GateLog(batchID, mrID, gate string) ([]byte, error) {
+	for _, name := range []string{batchID, mrID, gate} {
+		if !validArtifactName(name) {
+			return nil, fmt.Errorf("invalid gate log name %q", name)
+		}
+	}
+	data, err := os.ReadFile(e.gateLogPath(batchID, mrID, gate))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no %s log for %s in batch %s", gate, mrID, batchID)
+	}
+	return data, err
+}
+
+// GateLogs returns the gates with logs for mrID in batchID, sorted.
+func (e *Engineer) GateLogs(batchID, mrID string) ([]string, error) {
+	if !validArtifactName(batchID) || !validArtifactName(mrID) {
+		return nil, fmt.Errorf("invalid gate log name %q/%q", batchID, mrID)
+	}
+	entries, err := os.ReadDir(filepath.Join(e.artifactsDir(), batchID, mrID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no gate logs for %s in batch %s", mrID, batchID)
+		}
+		return nil, err
+	}
+	var gates []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".log"); ok && !entry.IsDir() {
+			gates = append(gates, name)
+		}
+	}
+	sort.Strings(gates)
+	return gates, nil
+}
+
+// linkCulpritLogs records where the logs that blamed each culprit are on
+// its MR bead (gate_logs), so whoever fixes it can find them. Failures are
+// logged and otherwise ignored.
+func (e *Engineer) linkCulpritLogs(result *BatchResult) {
+	if e.beads == nil || result.BatchID == "" {
+		return
+	}
+	for _, mr := range result.Culprits {
+		failed := false
+		for _, l := range result.GateLogs {
+			failed = failed || (l.MR == mr.ID && !l.Success)
+		}
+		if !failed {
+			continue
+		}
+		bead, err := e.beads.Show(mr.ID)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: linking gate logs to %s: %v\n", mr.ID, err)
+			continue
+		}
+		fields := beads.ParseMRFields(bead)
+		if fields == nil {
+			fields = &beads.MRFields{}
+		}
+		fields.GateLogs = filepath.Join(e.artifactsDir(), result.BatchID, mr.ID)
+		desc := beads.SetMRFields(bead, fields)
+		if err := e.beads.Update(mr.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: linking gate logs to %s: %v\n", mr.ID, err)
+		}
+	}
+}
+
+// pruneArtifacts removes the gate logs of all but the newest
+// maxArtifactBatches batches.
+func (e *Engineer) pruneArtifacts() {
+	entries, err := os.ReadDir(e.artifactsDir())
+	if err != nil || len(entries) <= maxArtifactBatches {
+		return
+	}
+	modTime := func(entry os.DirEntry) time.Time {
+		if info, err := entry.Info(); err == nil {
+			return info.ModTime()
+		}
+		return time.Time{}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return modTime(entries[i]).After(modTime(entries[j]))
+	})
+	for _, entry := range entries[maxArtifactBatches:] {
+		_ = os.RemoveAll(filepath.Join(e.artifactsDir(), entry.Name()))
+	}
+}
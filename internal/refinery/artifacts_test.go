@@ -0,0 +1,56 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestProcessBatch_GateLogs(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{
+		"test": {Cmd: "echo checking tree; " + failMarkerGateCmd() + " || { echo marker found >&2; exit 1; }"},
+	}
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if len(result.Culprits) != 1 || result.Culprits[0].ID != "mr-b" {
+		t.Fatalf("culprits = %v, want [mr-b]", stackedIDs(result.Culprits))
+	}
+
+	logs := make(map[string]*GateLog)
+	for _, l := range result.GateLogs {
+		logs[l.MR+"/"+l.Gate] = l
+	}
+	if l := logs["mr-b/test"]; l == nil || l.Success {
+		t.Errorf("mr-b/test log = %+v, want a failed run", l)
+	}
+	if l := logs["mr-a/test"]; l == nil || !l.Success {
+		t.Errorf("mr-a/test log = %+v, want a passed run", l)
+	}
+
+	data, err := e.GateLog(result.BatchID, "mr-b", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# result: failed", "# stack: mr-a mr-b", "checking tree", "marker found"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("log missing %q:\n%s", want, data)
+		}
+	}
+	if gates, err := e.GateLogs(result.BatchID, "mr-b"); err != nil || len(gates) != 1 || gates[0] != "test" {
+		t.Errorf("GateLogs = %v, %v; want [test]", gates, err)
+	}
+
+	if _, err := e.GateLog(result.BatchID, "mr-c", "test"); err == nil {
+		t.Error("expected error for an MR without logs")
+	}
+	if _, err := e.GateLog("..", "mr-b", "test"); err == nil {
+		t.Error("expected error for a path outside the artifacts")
+	}
+}
@@ -0,0 +1,357 @@
+package refinery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// HeldForAssets marks an MR held because its binary files exceed the asset
+// policy's size limits (see MRInfo.HeldFor). Unlike the other holds there
+// is no task: the MR is admitted once its author pushes a smaller branch.
+const HeldForAssets = "assets"
+
+// DefaultAssetShare is the share of an MR's changed files that must be
+// binaries or assets for the MR to count as asset-heavy.
+const DefaultAssetShare = 0.5
+
+// AssetPolicyConfig configures the handling of MRs dominated by binary
+// assets (images, models, fixtures). Text merges and code gates say little
+// about such MRs, while every byte they add stays in the repository's
+// history for good.
+type AssetPolicyConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// AssetShare is the share of changed files that must be binaries or
+	// assets for an MR to be asset-heavy. Default: DefaultAssetShare.
+	AssetShare float64 `json:"asset_share,omitempty"`
+
+	// AssetPatterns count matching files as assets even though git diffs
+	// them as text (e.g. "*.svg", "assets/**").
+	AssetPatterns []string `json:"asset_patterns,omitempty"`
+
+	// Gates replace the target's gates for stacks made only of
+	// asset-heavy MRs, e.g. an image optimizer or a size check.
+	// Empty means the target's gates apply.
+	Gates map[string]*GateConfig `json:"gates,omitempty"`
+
+	// MaxFileBytes holds an MR with a binary or asset file larger than
+	// this. Zero means no limit.
+	MaxFileBytes int64 `json:"max_file_bytes,omitempty"`
+
+	// MaxAddedBytes holds an MR whose binary and asset files total more
+	// than this. Zero means no limit.
+	MaxAddedBytes int64 `json:"max_added_bytes,omitempty"`
+
+	// WarnAddedBytes warns the MR's author, without holding it, when its
+	// binary and asset files total more than this. Zero means no warning.
+	WarnAddedBytes int64 `json:"warn_added_bytes,omitempty"`
+
+	// LFSPatterns are moved to Git LFS on an asset-heavy MR's branch
+	// before it is stacked (git lfs migrate import), so they never enter
+	// the target's history as blobs. Needs git-lfs in the refinery clone.
+	LFSPatterns []string `json:"lfs_patterns,omitempty"`
+}
+
+// assetPolicyRaw is the JSON form of AssetPolicyConfig with string gate
+// timeouts.
+type assetPolicyRaw struct {
+	AssetPolicyConfig
+	Gates map[string]*gateConfigRaw `json:"gates"`
+}
+
+// parseAssetPolicy converts the JSON asset policy, parsing its gates.
+func parseAssetPolicy(raw *assetPolicyRaw) (*AssetPolicyConfig, error) {
+	cfg := raw.AssetPolicyConfig
+	if cfg.AssetShare < 0 || cfg.AssetShare > 1 {
+		return nil, fmt.Errorf("asset_policy asset_share must be between 0 and 1, got %v", cfg.AssetShare)
+	}
+	if cfg.MaxFileBytes < 0 || cfg.MaxAddedBytes < 0 || cfg.WarnAddedBytes < 0 {
+		return nil, fmt.Errorf("asset_policy byte limits must be non-negative")
+	}
+	if raw.Gates != nil {
+		gates, err := parseGates(raw.Gates)
+		if err != nil {
+			return nil, fmt.Errorf("asset_policy: %w", err)
+		}
+		cfg.Gates = gates
+	}
+	return &cfg, nil
+}
+
+func (c *AssetPolicyConfig) assetShare() float64 {
+	if c.AssetShare > 0 {
+		return c.AssetShare
+	}
+	return DefaultAssetShare
+}
+
+// AssetProfile describes the binary and asset files of an MR.
+type AssetProfile struct {
+	Files        int      // Files changed
+	Assets       []string // Changed binary or asset files, sorted
+	AddedBytes   int64    // Total size of the assets at the branch head
+	Largest      string   // Largest asset
+	LargestBytes int64
+}
+
+// Heavy reports whether the MR's changes are mostly assets.
+func (p *AssetProfile) Heavy(share float64) bool {
+	return p.Files > 0 && float64(len(p.Assets)) >= share*float64(p.Files)
+}
+
+// mrAssets profiles the binary and asset files mr changes, caching the
+// profile on mr. Deleted assets count as changed but add no bytes.
+func (e *Engineer) mrAssets(mr *MRInfo, cfg *AssetPolicyConfig) (*AssetProfile, error) {
+	if mr.assets != nil {
+		return mr.assets, nil
+	}
+	stats, err := e.mrDiffStats(mr)
+	if err != nil {
+		return nil, err
+	}
+	p := &AssetProfile{Files: len(stats)}
+	for _, s := range stats {
+		if s.Binary || matchAnyPattern(cfg.AssetPatterns, s.Path) {
+			p.Assets = append(p.Assets, s.Path)
+		}
+	}
+	sort.Strings(p.Assets)
+	sizes, err := e.git.FileSizes(e.branchHead(mr.Branch), p.Assets)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range p.Assets {
+		size := sizes[path]
+		p.AddedBytes += size
+		if size > p.LargestBytes {
+			p.Largest, p.LargestBytes = path, size
+		}
+	}
+	mr.assets = p
+	return p, nil
+}
+
+// assetHeavy reports whether mr is asset-heavy under the rig's asset
+// policy. MRs are never asset-heavy without one.
+func (e *Engineer) assetHeavy(mr *MRInfo) bool {
+	cfg := e.config.AssetPolicy
+	if cfg == nil || !cfg.Enabled {
+		return false
+	}
+	p, err := e.mrAssets(mr, cfg)
+	return err == nil && p.Heavy(cfg.assetShare())
+}
+
+// assetGates returns the asset policy's gates when every MR of stacked is
+// asset-heavy, and nil otherwise.
+func (e *Engineer) assetGates(stacked []*MRInfo) map[string]*GateConfig {
+	cfg := e.config.AssetPolicy
+	if cfg == nil || !cfg.Enabled || len(cfg.Gates) == 0 || len(stacked) == 0 {
+		return nil
+	}
+	for _, mr := range stacked {
+		if !e.assetHeavy(mr) {
+			return nil
+		}
+	}
+	return cfg.Gates
+}
+
+// mrGates returns the gates a single MR landing on target runs: the asset
+// policy's if it is asset-heavy, otherwise the target's (see gatesFor).
+func (e *Engineer) mrGates(mr *MRInfo, target string) map[string]*GateConfig {
+	if gates := e.assetGates([]*MRInfo{mr}); gates != nil {
+		return gates
+	}
+	return e.gatesFor(target)
+}
+
+// assetConflicts predicts the conflicts of an asset-heavy MR without a test
+// merge: binary files can't be merged line by line, so an asset conflicts
+// exactly when target changed it too since the branch forked. Text files
+// are left to the real merge.
+func (e *Engineer) assetConflicts(mr *MRInfo, target string) ([]string, error) {
+	p, err := e.mrAssets(mr, e.config.AssetPolicy)
+	if err != nil {
+		return nil, err
+	}
+	landed, err := e.git.DiffNumstat(mr.Branch, target)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool, len(landed))
+	for _, s := range landed {
+		changed[s.Path] = true
+	}
+	var conflicts []string
+	for _, path := range p.Assets {
+		if changed[path] {
+			conflicts = append(conflicts, path)
+		}
+	}
+	return conflicts, nil
+}
+
+// checkMRConflicts returns the files mr conflicts on with target: by an
+// in-memory merge (see git.CheckConflictsMergeTree), or for asset-heavy MRs
+// by assetConflicts. Neither touches the index or the working tree. With a
+// git too old for merge-tree it falls back to a test merge in the working
+// tree (see git.CheckConflicts) and sets mergeTreeUnsupported.
+func (e *Engineer) checkMRConflicts(mr *MRInfo, target string) ([]string, error) {
+	if e.assetHeavy(mr) {
+		_, _ = fmt.Fprintf(e.output, "[Assets] MR %s is asset-heavy, checking conflicts by path\n", mr.ID)
+		return e.assetConflicts(mr, target)
+	}
+	if !e.mergeTreeUnsupported {
+		files, err := e.git.CheckConflictsMergeTree(mr.Branch, target)
+		if !errors.Is(err, git.ErrMergeTreeUnsupported) {
+			return files, err
+		}
+		_, _ = fmt.Fprintln(e.output, "[Engineer] git merge-tree unavailable (needs git 2.38), checking conflicts by test merge")
+		e.mergeTreeUnsupported = true
+	}
+	return e.git.CheckConflicts(mr.Branch, target)
+}
+
+// migrateAssetsToLFS moves the LFS patterns of an asset-heavy MR's branch
+// into Git LFS before it is stacked. The branch is rewritten locally only;
+// failures are logged and the branch is stacked as it is.
+func (e *Engineer) migrateAssetsToLFS(mr *MRInfo, target string) {
+	cfg := e.config.AssetPolicy
+	if cfg == nil || len(cfg.LFSPatterns) == 0 || !e.assetHeavy(mr) {
+		return
+	}
+	var matched bool
+	for _, path := range mr.assets.Assets {
+		if matchAnyPattern(cfg.LFSPatterns, path) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return
+	}
+	if exists, err := e.git.BranchExists(mr.Branch); err != nil || !exists {
+		return
+	}
+	if err := e.git.LFSMigrateImport(mr.Branch, "refs/remotes/origin/"+target, cfg.LFSPatterns); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Assets] Warning: MR %s: LFS migration failed, stacking as is: %v\n", mr.ID, err)
+		return
+	}
+	_, _ = fmt.Fprintf(e.output, "[Assets] MR %s: moved %s to LFS\n", mr.ID, strings.Join(cfg.LFSPatterns, ", "))
+	mr.assets = nil // The blobs are pointers now
+}
+
+// assetWarningsPath is where the branch heads whose authors were warned
+// about asset sizes are kept, so each head is reported once.
+func (e *Engineer) assetWarningsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "asset-warnings.json")
+}
+
+// admitAssets applies the asset policy's size limits. MRs over a limit are
+// held until their author pushes a smaller branch; MRs over the warning
+// threshold are admitted. The author is nudged once per branch head.
+//
+// Like the other policies it fails open: MRs whose diff can't be read are
+// admitted with a warning.
+func (e *Engineer) admitAssets(ready []*MRInfo) (admitted, held []*MRInfo) {
+	cfg := e.config.AssetPolicy
+	if cfg == nil || !cfg.Enabled || (cfg.MaxFileBytes == 0 && cfg.MaxAddedBytes == 0 && cfg.WarnAddedBytes == 0) {
+		return ready, nil
+	}
+	warned := make(map[string]string)
+	if data, err := os.ReadFile(e.assetWarningsPath()); err == nil {
+		_ = json.Unmarshal(data, &warned)
+	}
+
+	changed := false
+	for _, mr := range ready {
+		p, err := e.mrAssets(mr, cfg)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Assets] Warning: MR %s: reading diff: %v (admitting)\n", mr.ID, err)
+			admitted = append(admitted, mr)
+			continue
+		}
+		reasons := assetLimitReasons(p, cfg)
+		warn := len(reasons) == 0 && cfg.WarnAddedBytes > 0 && p.AddedBytes > cfg.WarnAddedBytes
+		if len(reasons) == 0 {
+			admitted = append(admitted, mr)
+		} else {
+			mr.HeldFor = HeldForAssets
+			held = append(held, mr)
+			e.recordProgress(StageHeld, "assets too large: "+strings.Join(reasons, "; "), "", mr)
+		}
+		if len(reasons) == 0 && !warn {
+			continue
+		}
+		head, _ := e.git.Rev(e.branchHead(mr.Branch))
+		if head == "" || warned[mr.ID] == head {
+			continue
+		}
+		warned[mr.ID] = head
+		changed = true
+		msg := fmt.Sprintf("ASSETS_WARNING: mr=%s branch=%s adds %s of binary files (largest %s, %s) — consider Git LFS or smaller assets",
+			mr.ID, mr.Branch, formatBytes(p.AddedBytes), p.Largest, formatBytes(p.LargestBytes))
+		if len(reasons) > 0 {
+			msg = fmt.Sprintf("ASSETS_HELD: mr=%s branch=%s held: %s — shrink the assets or move them to Git LFS and resubmit",
+				mr.ID, mr.Branch, strings.Join(reasons, "; "))
+		}
+		_, _ = fmt.Fprintf(e.output, "[Assets] %s\n", msg)
+		e.nudgeAssetAuthor(mr, msg)
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.assetWarningsPath(), warned); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Assets] Warning: saving asset warnings: %v\n", err)
+		}
+	}
+	return admitted, held
+}
+
+// assetLimitReasons lists the size limits p exceeds.
+func assetLimitReasons(p *AssetProfile, cfg *AssetPolicyConfig) []string {
+	var reasons []string
+	if cfg.MaxFileBytes > 0 && p.LargestBytes > cfg.MaxFileBytes {
+		reasons = append(reasons, fmt.Sprintf("%s is %s (max %s)", p.Largest, formatBytes(p.LargestBytes), formatBytes(cfg.MaxFileBytes)))
+	}
+	if cfg.MaxAddedBytes > 0 && p.AddedBytes > cfg.MaxAddedBytes {
+		reasons = append(reasons, fmt.Sprintf("binary files total %s (max %s)", formatBytes(p.AddedBytes), formatBytes(cfg.MaxAddedBytes)))
+	}
+	return reasons
+}
+
+// formatBytes renders n in binary units, e.g. 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// nudgeAssetAuthor nudges the polecat that submitted mr. MRs without a worker
+// are skipped.
+func (e *Engineer) nudgeAssetAuthor(mr *MRInfo, msg string) {
+	if mr.Worker == "" {
+		return
+	}
+	polecatName := strings.TrimPrefix(mr.Worker, "polecats/")
+	nudgeCmd := exec.Command("gt", "nudge", fmt.Sprintf("%s/%s", e.rig.Name, polecatName), msg)
+	nudgeCmd.Dir = e.workDir
+	if err := nudgeCmd.Run(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Assets] Warning: failed to nudge %s: %v\n", polecatName, err)
+	}
+}
@@ -0,0 +1,167 @@
+package refinery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// binaryContent returns n bytes git treats as binary.
+func binaryContent(n int, fill byte) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = fill
+	}
+	b[0] = 0
+	return string(b)
+}
+
+// createAssetBranch creates a branch adding a binary file and a text file.
+func createAssetBranch(t *testing.T, workDir, branch, asset string, size int) {
+	t.Helper()
+	run(t, workDir, "git", "checkout", "-b", branch, "main")
+	writeFile(t, workDir, asset, binaryContent(size, 'x'))
+	writeFile(t, workDir, strings.ReplaceAll(branch, "/", "-")+".txt", "notes\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "add "+asset)
+	run(t, workDir, "git", "checkout", "main")
+}
+
+func TestEngineer_LoadConfig_AssetPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"asset_policy": {"enabled": true, "asset_patterns": ["*.svg"], "max_file_bytes": 1048576,
+		"gates": {"size": {"cmd": "du -s .", "timeout": "1m"}}, "lfs_patterns": ["*.psd"]}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	ap := e.config.AssetPolicy
+	if ap == nil || !ap.Enabled || ap.MaxFileBytes != 1<<20 || ap.Gates["size"] == nil || len(ap.LFSPatterns) != 1 {
+		t.Fatalf("AssetPolicy = %+v", ap)
+	}
+	if ap.assetShare() != DefaultAssetShare {
+		t.Errorf("assetShare() = %v, want %v", ap.assetShare(), DefaultAssetShare)
+	}
+
+	data = []byte(`{"merge_queue": {"asset_policy": {"enabled": true, "asset_share": 2}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+		t.Error("expected error for asset_share above 1")
+	}
+}
+
+func TestMRAssets(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	e.config.AssetPolicy = &AssetPolicyConfig{Enabled: true, AssetPatterns: []string{"*.svg"}}
+
+	createAssetBranch(t, workDir, "polecat/art", "logo.png", 2000)
+	mr := makeMR("mr-1", "polecat/art", "main")
+	p, err := e.mrAssets(mr, e.config.AssetPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Files != 2 || len(p.Assets) != 1 || p.Largest != "logo.png" || p.AddedBytes != 2000 {
+		t.Errorf("profile = %+v", p)
+	}
+	if !e.assetHeavy(mr) {
+		t.Error("half binary MR should be asset-heavy")
+	}
+
+	createFeatureBranch(t, workDir, "polecat/code", "main.go", "package main\n")
+	if e.assetHeavy(makeMR("mr-2", "polecat/code", "main")) {
+		t.Error("code MR is not asset-heavy")
+	}
+	createFeatureBranch(t, workDir, "polecat/icon", "icon.svg", "<svg/>\n")
+	if !e.assetHeavy(makeMR("mr-3", "polecat/icon", "main")) {
+		t.Error("asset_patterns should count icon.svg as an asset")
+	}
+}
+
+func TestAssetConflicts(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	e.config.AssetPolicy = &AssetPolicyConfig{Enabled: true}
+
+	createAssetBranch(t, workDir, "polecat/a", "logo.png", 100)
+	createAssetBranch(t, workDir, "polecat/b", "banner.png", 100)
+	// main changes logo.png after both branches forked.
+	writeFile(t, workDir, "logo.png", binaryContent(100, 'y'))
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "main: logo")
+
+	conflicts, err := e.checkMRConflicts(makeMR("mr-a", "polecat/a", "main"), "main")
+	if err != nil || len(conflicts) != 1 || conflicts[0] != "logo.png" {
+		t.Errorf("polecat/a conflicts = %v, %v; want [logo.png]", conflicts, err)
+	}
+	conflicts, err = e.checkMRConflicts(makeMR("mr-b", "polecat/b", "main"), "main")
+	if err != nil || len(conflicts) != 0 {
+		t.Errorf("polecat/b conflicts = %v, %v; want none", conflicts, err)
+	}
+}
+
+func TestAdmitAssets(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	e.config.AssetPolicy = &AssetPolicyConfig{Enabled: true, MaxFileBytes: 1000, WarnAddedBytes: 200}
+
+	createAssetBranch(t, workDir, "polecat/huge", "huge.bin", 5000)
+	createAssetBranch(t, workDir, "polecat/big", "big.bin", 500)
+	createAssetBranch(t, workDir, "polecat/small", "small.bin", 100)
+	huge := makeMR("mr-huge", "polecat/huge", "main")
+	big := makeMR("mr-big", "polecat/big", "main")
+	small := makeMR("mr-small", "polecat/small", "main")
+
+	admitted, held := e.AdmitMRs([]*MRInfo{huge, big, small})
+	if len(held) != 1 || held[0] != huge || huge.HeldFor != HeldForAssets {
+		t.Errorf("held = %v, want [mr-huge] held for assets", mrIDs(held))
+	}
+	if len(admitted) != 2 {
+		t.Errorf("admitted = %v, want [mr-big mr-small]", mrIDs(admitted))
+	}
+	out := testOutput(e).String()
+	if !strings.Contains(out, "ASSETS_HELD: mr=mr-huge") || !strings.Contains(out, "ASSETS_WARNING: mr=mr-big") || strings.Contains(out, "mr=mr-small") {
+		t.Errorf("output = %s", out)
+	}
+
+	// Each branch head is reported once.
+	testOutput(e).Reset()
+	for _, mr := range []*MRInfo{huge, big} {
+		mr.HeldFor = ""
+	}
+	e.AdmitMRs([]*MRInfo{huge, big})
+	if out := testOutput(e).String(); strings.Contains(out, "ASSETS_") {
+		t.Errorf("reported twice: %s", out)
+	}
+}
+
+func TestAssetGates(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	sizeGate := map[string]*GateConfig{"size": {Cmd: "true"}}
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: "go test ./..."}}
+	e.config.AssetPolicy = &AssetPolicyConfig{Enabled: true, Gates: sizeGate}
+
+	createAssetBranch(t, workDir, "polecat/art", "logo.png", 100)
+	createFeatureBranch(t, workDir, "polecat/code", "main.go", "package main\n")
+	art := makeMR("mr-art", "polecat/art", "main")
+	code := makeMR("mr-code", "polecat/code", "main")
+
+	if gates := e.mrGates(art, "main"); gates["size"] == nil {
+		t.Errorf("asset-heavy MR gates = %v, want the asset gates", gates)
+	}
+	if gates := e.mrGates(code, "main"); gates["test"] == nil {
+		t.Errorf("code MR gates = %v, want the rig's", gates)
+	}
+	if gates := e.assetGates([]*MRInfo{art, code}); gates != nil {
+		t.Errorf("mixed stack gates = %v, want nil", gates)
+	}
+}
@@ -0,0 +1,438 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// maxLandingHistory bounds the landings kept per rig.
+const maxLandingHistory = 500
+
+// AutoRevertConfig configures automatic reverts of bad landings. After a
+// batch lands, its post-merge gates run on the target tip; if they fail,
+// the refinery finds the MR that broke them, lands a revert of it, reopens
+// its source issue and nudges its author. Reverts can also be requested
+// directly with gt mq revert, whether or not this is enabled.
+type AutoRevertConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Gates run on the target after each landing: slow or flaky-prone
+	// checks (integration, e2e) that would hold the queue up if they gated
+	// every batch. No gates means reverts only happen on request.
+	Gates map[string]*GateConfig `json:"gates,omitempty"`
+}
+
+// autoRevertRaw is the JSON form of AutoRevertConfig with string gate
+// timeouts.
+type autoRevertRaw struct {
+	Enabled bool                      `json:"enabled"`
+	Gates   map[string]*gateConfigRaw `json:"gates"`
+}
+
+// parseAutoRevert converts the JSON auto-revert config, parsing its gates.
+func parseAutoRevert(raw *autoRevertRaw) (*AutoRevertConfig, error) {
+	cfg := &AutoRevertConfig{Enabled: raw.Enabled}
+	if raw.Gates != nil {
+		gates, err := parseGates(raw.Gates)
+		if err != nil {
+			return nil, fmt.Errorf("auto_revert: %w", err)
+		}
+		cfg.Gates = gates
+	}
+	return cfg, nil
+}
+
+// Landing records the commits one MR added to its target, so they can be
+// reverted later.
+type Landing struct {
+	MR          string    `json:"mr"`
+	Target      string    `json:"target"`
+	Branch      string    `json:"branch"`
+	Title       string    `json:"title,omitempty"`
+	SourceIssue string    `json:"source_issue,omitempty"`
+	Worker      string    `json:"worker,omitempty"`
+	BatchID     string    `json:"batch_id,omitempty"`
+	Base        string    `json:"base"` // Target commit the MR landed on
+	Tip         string    `json:"tip"`  // Last commit the MR added
+	LandedAt    time.Time `json:"landed_at"`
+
+	// Commits maps each commit of a cherry-picked MR to the commit it
+	// landed as (see MergeStrategyCherryPick).
+	Commits []PickedCommit `json:"commits,omitempty"`
+
+	RevertCommit string     `json:"revert_commit,omitempty"`
+	RevertReason string     `json:"revert_reason,omitempty"`
+	RevertedAt   *time.Time `json:"reverted_at,omitempty"`
+}
+
+func (e *Engineer) landingsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "landings.json")
+}
+
+// Landings returns recorded landings, oldest first.
+func (e *Engineer) Landings() ([]*Landing, error) {
+	data, err := os.ReadFile(e.landingsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var landings []*Landing
+	if err := json.Unmarshal(data, &landings); err != nil {
+		return nil, fmt.Errorf("parsing landings: %w", err)
+	}
+	return landings, nil
+}
+
+func (e *Engineer) saveLandings(landings []*Landing) error {
+	if len(landings) > maxLandingHistory {
+		landings = landings[len(landings)-maxLandingHistory:]
+	}
+	return util.EnsureDirAndWriteJSON(e.landingsPath(), landings)
+}
+
+// recordLandings records the commits each merged MR of result added to
+// target, from the stack bases and tips noted while merging (see
+// recordStackTip). Gerrit changes are skipped: Gerrit lands its own
+// commits. Failures are logged and otherwise ignored.
+func (e *Engineer) recordLandings(result *BatchResult, target string) {
+	if e.rig == nil || result == nil || result.Error != nil || result.MergeCommit == "" {
+		return
+	}
+	now := time.Now().UTC()
+	var added []*Landing
+	e.stackTipsMu.Lock()
+	for _, mr := range result.Merged {
+		base, tip := e.stackBases[mr.ID], e.stackTips[mr.ID]
+		if mr.Change != nil || base == "" || tip == "" {
+			continue
+		}
+		added = append(added, &Landing{
+			MR:          mr.ID,
+			Target:      target,
+			Branch:      mr.Branch,
+			Title:       mr.Title,
+			SourceIssue: mr.SourceIssue,
+			Worker:      mr.Worker,
+			BatchID:     result.BatchID,
+			Base:        base,
+			Tip:         tip,
+			LandedAt:    now,
+			Commits:     e.stackPicks[mr.ID],
+		})
+	}
+	e.stackTipsMu.Unlock()
+	if len(added) == 0 {
+		return
+	}
+	landings, err := e.Landings()
+	if err == nil {
+		err = e.saveLandings(append(landings, added...))
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[AutoRevert] Warning: recording landings: %v\n", err)
+	}
+}
+
+// watchLanding runs the post-merge gates on the tip result landed. If they
+// fail, the landed MRs are bisected for the first one whose tip fails them,
+// and that MR is reverted. It reports whether a revert landed.
+func (e *Engineer) watchLanding(ctx context.Context, result *BatchResult, target string) bool {
+	cfg := e.config.AutoRevert
+	if cfg == nil || !cfg.Enabled || len(cfg.Gates) == 0 || result == nil || result.Error != nil || result.MergeCommit == "" {
+		return false
+	}
+	var landed []*Landing
+	if all, err := e.Landings(); err == nil {
+		for _, l := range all {
+			if l.BatchID == result.BatchID && l.Target == target {
+				landed = append(landed, l)
+			}
+		}
+	}
+	if len(landed) == 0 {
+		return false
+	}
+
+	dir := filepath.Join(e.rig.Path, ".runtime", "post-merge", result.BatchID)
+	if err := e.git.WorktreeAddDetached(dir, result.MergeCommit); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[AutoRevert] Warning: worktree for post-merge gates: %v\n", err)
+		return false
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(dir, true)
+	}()
+	g := git.NewGit(dir)
+	runAt := func(commit string) ProcessResult {
+		if err := g.Checkout(commit); err != nil {
+			return ProcessResult{Error: fmt.Sprintf("checkout %s: %v", shortSHA(commit), err)}
+		}
+		return e.runGateSetIn(ctx, dir, cfg.Gates)
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[AutoRevert] Running post-merge gates on %s (%s)\n", target, shortSHA(result.MergeCommit))
+	failed := runAt(result.MergeCommit)
+	if failed.Success {
+		return false
+	}
+
+	// The last landing fails; find the first that does.
+	lo, hi := 0, len(landed)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if r := runAt(landed[mid].Tip); r.Success {
+			lo = mid + 1
+		} else {
+			hi, failed = mid, r
+		}
+	}
+	culprit := landed[lo]
+	_, _ = fmt.Fprintf(e.output, "[AutoRevert] Post-merge gates first fail at %s (%s)\n", culprit.MR, shortSHA(culprit.Tip))
+	if _, err := e.RevertLanding(ctx, culprit.MR, "post-merge gates failed: "+failed.Error); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[AutoRevert] Warning: reverting %s: %v\n", culprit.MR, err)
+		return false
+	}
+	return true
+}
+
+// revertWorktree returns the scratch worktree a revert named name is built
+// in: an MR ID or, for a whole batch, its batch ID.
+func (e *Engineer) revertWorktree(name string) string {
+	return filepath.Join(e.rig.Path, ".runtime", "reverts", name)
+}
+
+// RevertLanding lands a revert of the commits mrID added to its target,
+// on top of whatever has landed since, then reopens the MR's source issue
+// and nudges its author with reason. The revert is built in a scratch
+// worktree and pushed like a landing; if it conflicts with later changes
+// nothing is pushed and an error is returned.
+func (e *Engineer) RevertLanding(ctx context.Context, mrID, reason string) (*Landing, error) {
+	landings, err := e.Landings()
+	if err != nil {
+		return nil, err
+	}
+	var l *Landing
+	for _, cand := range landings {
+		if cand.MR == mrID {
+			l = cand
+		}
+	}
+	if l == nil {
+		return nil, fmt.Errorf("no landing recorded for %s", mrID)
+	}
+	if l.RevertedAt != nil {
+		return nil, fmt.Errorf("%s was already reverted in %s", mrID, shortSHA(l.RevertCommit))
+	}
+
+	if err := e.git.FetchBranch("origin", l.Target); err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", l.Target, err)
+	}
+	commits, err := e.landedCommits(l)
+	if err != nil {
+		return nil, err
+	}
+	revert, err := e.pushRevert(ctx, mrID, l.Target, commits, revertMessage(l, reason))
+	if err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(e.output, "[AutoRevert] Reverted %s on %s in %s: %s\n", mrID, l.Target, shortSHA(revert), reason)
+	e.markReverted(landings, []*Landing{l}, revert, reason)
+	return l, nil
+}
+
+// RevertBatch lands a single revert of every MR batchID landed that has
+// not been reverted yet, on top of whatever has landed since, then reopens
+// their source issues and nudges their authors with reason. Like
+// RevertLanding it never rewrites the target: later landings are kept, and
+// if the revert conflicts with them nothing is pushed. It returns the
+// landings reverted.
+func (e *Engineer) RevertBatch(ctx context.Context, batchID, reason string) ([]*Landing, error) {
+	landings, err := e.Landings()
+	if err != nil {
+		return nil, err
+	}
+	var batch []*Landing
+	for _, l := range landings {
+		if l.BatchID == batchID {
+			batch = append(batch, l)
+		}
+	}
+	if len(batch) == 0 {
+		return nil, fmt.Errorf("no landings recorded for batch %s", batchID)
+	}
+	target := batch[0].Target
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", target, err)
+	}
+
+	// Undo the batch newest landing first, so each revert applies to the
+	// tree its landing left behind.
+	var pending []*Landing
+	var commits []string
+	for i := len(batch) - 1; i >= 0; i-- {
+		l := batch[i]
+		if l.RevertedAt != nil {
+			continue
+		}
+		cs, err := e.landedCommits(l)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, l)
+		commits = append(commits, cs...)
+	}
+	if len(pending) == 0 {
+		return nil, fmt.Errorf("every MR of batch %s was already reverted", batchID)
+	}
+	revert, err := e.pushRevert(ctx, batchID, target, commits, batchRevertMessage(batchID, pending, reason))
+	if err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(e.output, "[AutoRevert] Reverted batch %s (%d MR(s)) on %s in %s: %s\n", batchID, len(pending), target, shortSHA(revert), reason)
+	e.markReverted(landings, pending, revert, reason)
+	return pending, nil
+}
+
+// landedCommits returns the commits l added to its target, newest first.
+// The target must have been fetched.
+func (e *Engineer) landedCommits(l *Landing) ([]string, error) {
+	if ok, err := e.git.IsAncestor(l.Tip, "origin/"+l.Target); err != nil || !ok {
+		return nil, fmt.Errorf("%s (%s) is not on origin/%s", l.MR, shortSHA(l.Tip), l.Target)
+	}
+	commits, err := e.git.FirstParentCommits(l.Base, l.Tip)
+	if err != nil {
+		return nil, fmt.Errorf("listing commits of %s: %w", l.MR, err)
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%s added no commits to %s", l.MR, l.Target)
+	}
+	return commits, nil
+}
+
+// pushRevert reverts commits, in order, on top of origin/target in the
+// scratch worktree for name, commits the result with message and pushes it
+// like a landing. It returns the revert commit.
+func (e *Engineer) pushRevert(ctx context.Context, name, target string, commits []string, message string) (string, error) {
+	dir := e.revertWorktree(name)
+	_ = e.git.WorktreeRemove(dir, true)
+	if err := e.git.WorktreeAddDetached(dir, "origin/"+target); err != nil {
+		return "", fmt.Errorf("creating worktree: %w", err)
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(dir, true)
+	}()
+	g := git.NewGit(dir)
+	for _, commit := range commits {
+		if err := g.RevertNoCommit(commit); err != nil {
+			conflicts, _ := g.GetConflictingFiles()
+			if len(conflicts) > 0 {
+				return "", fmt.Errorf("revert of %s conflicts with later changes on %s: %s", name, target, strings.Join(conflicts, ", "))
+			}
+			return "", fmt.Errorf("reverting %s: %w", shortSHA(commit), err)
+		}
+	}
+	if err := g.Commit(message); err != nil {
+		return "", fmt.Errorf("committing revert of %s: %w", name, err)
+	}
+
+	if target == e.rig.DefaultBranch() {
+		holder, err := e.acquireMainPushSlot(ctx)
+		if err != nil {
+			return "", fmt.Errorf("acquire merge slot: %w", err)
+		}
+		defer func() {
+			if holder != "" {
+				e.endSlotLease(holder)
+				if err := e.mergeSlotRelease(holder); err != nil {
+					_, _ = fmt.Fprintf(e.output, "[AutoRevert] Warning: failed to release merge slot: %v\n", err)
+				}
+			}
+		}()
+	}
+	if err := g.Push("origin", "HEAD:refs/heads/"+target, false); err != nil {
+		return "", fmt.Errorf("pushing revert of %s: %w", name, err)
+	}
+	return g.Rev("HEAD")
+}
+
+// markReverted records that reverted, all among landings, were reverted in
+// revert, then reopens their source issues and nudges their authors.
+func (e *Engineer) markReverted(landings, reverted []*Landing, revert, reason string) {
+	now := time.Now().UTC()
+	for _, l := range reverted {
+		l.RevertCommit = revert
+		l.RevertReason = reason
+		l.RevertedAt = &now
+	}
+	if err := e.saveLandings(landings); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[AutoRevert] Warning: saving landings: %v\n", err)
+	}
+	for _, l := range reverted {
+		if l.SourceIssue != "" {
+			if err := e.beads.ReopenWithReason("landing reverted: "+reason, l.SourceIssue); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[AutoRevert] Warning: reopening %s: %v\n", l.SourceIssue, err)
+			}
+		}
+		e.notifyRevertedAuthor(l)
+		e.recordProgress(StageReverted, fmt.Sprintf("reverted in %s: %s", shortSHA(revert), reason), l.BatchID,
+			&MRInfo{ID: l.MR, Branch: l.Branch, Target: l.Target})
+	}
+}
+
+// revertMessage returns the message of the commit reverting l.
+func revertMessage(l *Landing, reason string) string {
+	subject := l.Title
+	if subject == "" {
+		subject = l.Branch
+	}
+	msg := fmt.Sprintf("Revert %q\n\nThis reverts %s (%s..%s), landed from %s.", subject, l.MR, shortSHA(l.Base), shortSHA(l.Tip), l.Branch)
+	if l.SourceIssue != "" {
+		msg += fmt.Sprintf("\nSource issue %s is reopened.", l.SourceIssue)
+	}
+	if reason != "" {
+		msg += "\n\nReason: " + reason
+	}
+	return msg
+}
+
+// batchRevertMessage returns the message of the commit reverting the
+// landings of batchID.
+func batchRevertMessage(batchID string, landings []*Landing, reason string) string {
+	msg := fmt.Sprintf("Revert batch %s\n\nThis reverts:", batchID)
+	for _, l := range landings {
+		msg += fmt.Sprintf("\n  %s (%s..%s), landed from %s", l.MR, shortSHA(l.Base), shortSHA(l.Tip), l.Branch)
+		if l.SourceIssue != "" {
+			msg += fmt.Sprintf("; source issue %s is reopened", l.SourceIssue)
+		}
+	}
+	if reason != "" {
+		msg += "\n\nReason: " + reason
+	}
+	return msg
+}
+
+// notifyRevertedAuthor nudges the polecat that did the reverted work.
+func (e *Engineer) notifyRevertedAuthor(l *Landing) {
+	if l.Worker == "" {
+		return
+	}
+	polecatName := strings.TrimPrefix(l.Worker, "polecats/")
+	nudgeTarget := fmt.Sprintf("%s/%s", e.rig.Name, polecatName)
+	nudgeMsg := fmt.Sprintf("LANDING_REVERTED: mr=%s branch=%s issue=%s revert=%s reason=%s — issue reopened; fix and resubmit with 'gt done'",
+		l.MR, l.Branch, l.SourceIssue, shortSHA(l.RevertCommit), l.RevertReason)
+	nudgeCmd := exec.Command("gt", "nudge", nudgeTarget, nudgeMsg)
+	nudgeCmd.Dir = e.workDir
+	if err := nudgeCmd.Run(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[AutoRevert] Warning: failed to nudge %s about revert: %v\n", polecatName, err)
+	}
+}
@@ -0,0 +1,167 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// originHas reports whether path exists on origin's main.
+func originHas(t *testing.T, workDir, path string) bool {
+	t.Helper()
+	run(t, workDir, "git", "fetch", "origin")
+	return exec.Command("git", "-C", workDir, "cat-file", "-e", "origin/main:"+path).Run() == nil
+}
+
+func TestEngineer_LoadConfig_AutoRevert(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"auto_revert": {"enabled": true, "gates": {"e2e": {"cmd": "make e2e", "timeout": "30m"}}}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	ar := e.config.AutoRevert
+	if ar == nil || !ar.Enabled || ar.Gates["e2e"] == nil || ar.Gates["e2e"].Cmd != "make e2e" || ar.Gates["e2e"].Timeout != 30*time.Minute {
+		t.Fatalf("AutoRevert = %+v", ar)
+	}
+
+	data = []byte(`{"merge_queue": {"auto_revert": {"enabled": true, "gates": {"e2e": {"cmd": "make e2e", "timeout": "soon"}}}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+		t.Error("expected error for invalid gate timeout")
+	}
+}
+
+func TestWatchLanding_RevertsCulprit(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.AutoRevert = &AutoRevertConfig{
+		Enabled: true,
+		Gates:   map[string]*GateConfig{"e2e": {Cmd: failMarkerGateCmd()}},
+	}
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main"), makeMR("mr-c", "feature-c", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if result.Error != nil || len(result.Merged) != 3 {
+		t.Fatalf("ProcessBatch: merged %v, err %v", stackedIDs(result.Merged), result.Error)
+	}
+
+	if originHas(t, workDir, "FAIL_MARKER") {
+		t.Error("FAIL_MARKER still on origin/main")
+	}
+	for _, path := range []string{"a.txt", "c.txt"} {
+		if !originHas(t, workDir, path) {
+			t.Errorf("%s missing from origin/main", path)
+		}
+	}
+
+	landings, err := e.Landings()
+	if err != nil || len(landings) != 3 {
+		t.Fatalf("Landings = %d, %v; want 3", len(landings), err)
+	}
+	for _, l := range landings {
+		if reverted := l.RevertedAt != nil; reverted != (l.MR == "mr-b") {
+			t.Errorf("%s: reverted = %v", l.MR, reverted)
+		}
+	}
+	if b := landings[1]; !strings.Contains(b.RevertReason, "post-merge gates failed") {
+		t.Errorf("revert reason = %q", b.RevertReason)
+	}
+}
+
+func TestRevertLanding(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	e := newTestEngineer(t, workDir, g)
+	mr := makeMR("mr-a", "feature-a", "main")
+	mr.Title = "Add a.txt"
+	if result := e.ProcessBatch(context.Background(), []*MRInfo{mr}, "main", DefaultBatchConfig()); result.Error != nil || len(result.Merged) != 1 {
+		t.Fatalf("ProcessBatch: %+v", result)
+	}
+
+	// Something lands after the MR; the revert keeps it.
+	writeFile(t, workDir, "later.txt", "later\n")
+	run(t, workDir, "git", "add", "later.txt")
+	run(t, workDir, "git", "commit", "-m", "later change")
+	run(t, workDir, "git", "push", "origin", "main")
+
+	l, err := e.RevertLanding(context.Background(), "mr-a", "breaks staging")
+	if err != nil {
+		t.Fatalf("RevertLanding: %v", err)
+	}
+	if originHas(t, workDir, "a.txt") || !originHas(t, workDir, "later.txt") {
+		t.Error("origin/main should have later.txt and not a.txt")
+	}
+	msg, err := exec.Command("git", "-C", workDir, "log", "-1", "--format=%B", "origin/main").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(msg), `Revert "Add a.txt"`) || !strings.Contains(string(msg), "Reason: breaks staging") {
+		t.Errorf("revert message = %q", msg)
+	}
+	if l.RevertCommit == "" || l.RevertedAt == nil {
+		t.Errorf("landing not marked reverted: %+v", l)
+	}
+	if _, err := os.Stat(e.revertWorktree("mr-a")); !os.IsNotExist(err) {
+		t.Errorf("revert worktree left behind: %v", err)
+	}
+
+	if _, err := e.RevertLanding(context.Background(), "mr-a", "again"); err == nil || !strings.Contains(err.Error(), "already reverted") {
+		t.Errorf("second revert: err = %v, want already reverted", err)
+	}
+	if _, err := e.RevertLanding(context.Background(), "mr-x", "unknown"); err == nil {
+		t.Error("expected error for an MR with no landing")
+	}
+}
+
+func TestRevertBatch(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+	e := newTestEngineer(t, workDir, g)
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main"), makeMR("mr-c", "feature-c", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if result.Error != nil || len(result.Merged) != 3 {
+		t.Fatalf("ProcessBatch: %+v", result)
+	}
+	later := pushFromClone(t, workDir, "main", "later.txt")
+
+	// An MR already reverted on its own is left out of the batch revert.
+	if _, err := e.RevertLanding(context.Background(), "mr-b", "flaky"); err != nil {
+		t.Fatalf("RevertLanding: %v", err)
+	}
+	reverted, err := e.RevertBatch(context.Background(), result.BatchID, "bad deploy")
+	if err != nil {
+		t.Fatalf("RevertBatch: %v", err)
+	}
+	if len(reverted) != 2 || reverted[0].MR != "mr-c" || reverted[1].MR != "mr-a" {
+		t.Errorf("reverted = %+v, want mr-c then mr-a", reverted)
+	}
+	for _, f := range []string{"a.txt", "b.txt", "c.txt"} {
+		if originHas(t, workDir, f) {
+			t.Errorf("origin/main still has %s", f)
+		}
+	}
+	if !originHas(t, workDir, "later.txt") {
+		t.Errorf("later push %s was dropped", later)
+	}
+
+	if _, err := e.RevertBatch(context.Background(), result.BatchID, "again"); err == nil || !strings.Contains(err.Error(), "already reverted") {
+		t.Errorf("second revert: err = %v, want already reverted", err)
+	}
+}
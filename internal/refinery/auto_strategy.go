@@ -0,0 +1,192 @@
+package refinery
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// Auto strategy defaults.
+const (
+	DefaultAutoMinSubjectLength = 10
+	DefaultAutoMaxCommits       = 20
+)
+
+// DefaultNoiseSubjects match commit subjects that record how a change was
+// made rather than a step of it: fixups, work in progress and review
+// churn. Matched case-insensitively.
+var DefaultNoiseSubjects = []string{
+	`^(fixup|squash|amend)!`,
+	`^wip\b`,
+	`^(fix(es)?|typo|oops|tmp|temp|update|changes|more|stuff|cleanup|lint|fmt|format)\.?$`,
+	`^(address(ed|ing)?|appl(y|ied)) .*(review|feedback|comments)`,
+	`^fix(ed)? (typo|lint|build|ci|formatting)\b`,
+}
+
+var defaultNoiseSubjects = compileNoiseSubjects(DefaultNoiseSubjects)
+
+func compileNoiseSubjects(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		res[i] = regexp.MustCompile("(?i)" + p)
+	}
+	return res
+}
+
+// AutoStrategyConfig tunes MergeStrategyAuto, which looks at an MR's
+// commits when it lands: a well-structured series (each commit a
+// described, separate step) keeps its commits with the Preserve strategy,
+// and anything else is squashed.
+type AutoStrategyConfig struct {
+	// Preserve is how a well-structured series lands: "rebase-ff"
+	// (default), "merge-commit" or "cherry-pick".
+	Preserve string `json:"preserve,omitempty"`
+
+	// MinSubjectLength is the shortest acceptable commit subject.
+	// Default: DefaultAutoMinSubjectLength.
+	MinSubjectLength int `json:"min_subject_length,omitempty"`
+
+	// MaxCommits is the most commits a series may have and be preserved.
+	// Default: DefaultAutoMaxCommits.
+	MaxCommits int `json:"max_commits,omitempty"`
+
+	// NoiseSubjects are regular expressions matching subjects of commits
+	// that aren't steps of their own. Default: DefaultNoiseSubjects.
+	NoiseSubjects []string `json:"noise_subjects,omitempty"`
+
+	noise []*regexp.Regexp
+}
+
+// parseAutoStrategy validates an auto strategy config and compiles its
+// noise subjects.
+func parseAutoStrategy(cfg *AutoStrategyConfig) error {
+	switch cfg.Preserve {
+	case "", MergeStrategyRebaseFF, MergeStrategyMergeCommit, MergeStrategyCherryPick:
+	default:
+		return fmt.Errorf("auto_strategy: invalid preserve %q: want rebase-ff, merge-commit or cherry-pick", cfg.Preserve)
+	}
+	if cfg.MinSubjectLength < 0 || cfg.MaxCommits < 0 {
+		return fmt.Errorf("auto_strategy: min_subject_length and max_commits must be non-negative")
+	}
+	cfg.noise = nil
+	for _, p := range cfg.NoiseSubjects {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return fmt.Errorf("auto_strategy: invalid noise subject %q: %w", p, err)
+		}
+		cfg.noise = append(cfg.noise, re)
+	}
+	return nil
+}
+
+func (c *AutoStrategyConfig) preserve() string {
+	if c.Preserve != "" {
+		return c.Preserve
+	}
+	return MergeStrategyRebaseFF
+}
+
+func (c *AutoStrategyConfig) minSubjectLength() int {
+	if c.MinSubjectLength > 0 {
+		return c.MinSubjectLength
+	}
+	return DefaultAutoMinSubjectLength
+}
+
+func (c *AutoStrategyConfig) maxCommits() int {
+	if c.MaxCommits > 0 {
+		return c.MaxCommits
+	}
+	return DefaultAutoMaxCommits
+}
+
+func (c *AutoStrategyConfig) noiseSubjects() []*regexp.Regexp {
+	if c.NoiseSubjects != nil {
+		return c.noise
+	}
+	return defaultNoiseSubjects
+}
+
+// judgeCommits decides whether a branch's commits are worth preserving,
+// returning why they aren't if not. A series is preserved when it has
+// several commits, none of them merges, and each is a step of its own: a
+// subject that says what it does, not shared with another commit, not
+// reverting one, and not a bare follow-up touching only files of the
+// commit before it.
+func (c *AutoStrategyConfig) judgeCommits(commits []git.SeriesCommit) (preserve bool, why string) {
+	switch {
+	case len(commits) < 2:
+		return false, "single commit"
+	case len(commits) > c.maxCommits():
+		return false, fmt.Sprintf("%d commits (max %d)", len(commits), c.maxCommits())
+	}
+	subjects := make(map[string]string, len(commits))
+	for i, commit := range commits {
+		sha := shortSHA(commit.SHA)
+		if commit.Merge {
+			return false, fmt.Sprintf("%s is a merge commit", sha)
+		}
+		if len(commit.Files) == 0 {
+			return false, fmt.Sprintf("%s changes nothing", sha)
+		}
+		subject, body := splitMessage(commit.Message)
+		if len(subject) < c.minSubjectLength() {
+			return false, fmt.Sprintf("%s has a short subject %q", sha, subject)
+		}
+		for _, re := range c.noiseSubjects() {
+			if re.MatchString(subject) {
+				return false, fmt.Sprintf("%s is not a step of its own: %q", sha, subject)
+			}
+		}
+		if prev, ok := subjects[strings.ToLower(subject)]; ok {
+			return false, fmt.Sprintf("%s repeats the subject of %s", sha, prev)
+		}
+		subjects[strings.ToLower(subject)] = sha
+		if reverted, ok := strings.CutPrefix(subject, `Revert "`); ok {
+			if prev, ok := subjects[strings.ToLower(strings.TrimSuffix(reverted, `"`))]; ok {
+				return false, fmt.Sprintf("%s reverts %s", sha, prev)
+			}
+		}
+		if i > 0 && strings.TrimSpace(body) == "" && subsetOf(commit.Files, commits[i-1].Files) {
+			return false, fmt.Sprintf("%s only amends %s", sha, shortSHA(commits[i-1].SHA))
+		}
+	}
+	return true, ""
+}
+
+// subsetOf reports whether every file in a is also in b.
+func subsetOf(a, b []string) bool {
+	in := make(map[string]bool, len(b))
+	for _, f := range b {
+		in[f] = true
+	}
+	for _, f := range a {
+		if !in[f] {
+			return false
+		}
+	}
+	return true
+}
+
+// chooseMergeStrategy resolves MergeStrategyAuto for mr, judging the
+// commits of its branch not yet in g's HEAD (see judgeCommits).
+func (e *Engineer) chooseMergeStrategy(g *git.Git, mr *MRInfo, out io.Writer) string {
+	cfg := e.config.AutoStrategy
+	if cfg == nil {
+		cfg = &AutoStrategyConfig{}
+	}
+	commits, err := g.CommitSeries("HEAD", mr.Branch)
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "[Engineer] Warning: MR %s: reading commits: %v (squashing)\n", mr.ID, err)
+		return MergeStrategySquash
+	}
+	if ok, why := cfg.judgeCommits(commits); !ok {
+		_, _ = fmt.Fprintf(out, "[Engineer] MR %s: squashing (%s)\n", mr.ID, why)
+		return MergeStrategySquash
+	}
+	_, _ = fmt.Fprintf(out, "[Engineer] MR %s: preserving %d well-structured commits (%s)\n", mr.ID, len(commits), cfg.preserve())
+	return cfg.preserve()
+}
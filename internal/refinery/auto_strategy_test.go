@@ -0,0 +1,134 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+func TestJudgeCommits(t *testing.T) {
+	step := func(sha, msg string, files ...string) git.SeriesCommit {
+		return git.SeriesCommit{SHA: sha, Message: msg, Files: files}
+	}
+	cfg := &AutoStrategyConfig{}
+	tests := []struct {
+		name    string
+		commits []git.SeriesCommit
+		why     string // empty: preserved
+	}{
+		{"series", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go"),
+			step("a2", "Wire the parser into the CLI", "cmd.go", "parse.go"),
+		}, ""},
+		{"single", []git.SeriesCommit{step("a1", "Add the parser", "parse.go")}, "single commit"},
+		{"fixup", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go"),
+			step("a2", "fixup! Add the parser", "parse.go"),
+		}, "not a step of its own"},
+		{"review churn", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go"),
+			step("a2", "Address review feedback", "cmd.go"),
+		}, "not a step of its own"},
+		{"short subject", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go"),
+			step("a2", "more", "cmd.go"),
+		}, "short subject"},
+		{"repeated subject", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go"),
+			step("a2", "add the parser", "cmd.go"),
+		}, "repeats the subject"},
+		{"revert", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go"),
+			step("a2", "Revert \"Add the parser\"\n\nThis reverts commit a1.", "parse.go"),
+		}, "reverts a1"},
+		{"bare follow-up", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go", "cmd.go"),
+			step("a2", "Handle empty input", "parse.go"),
+		}, "only amends a1"},
+		{"described follow-up", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go", "cmd.go"),
+			step("a2", "Handle empty input\n\nAn empty file parses to no rules.", "parse.go"),
+		}, ""},
+		{"merge", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go"),
+			{SHA: "a2", Message: "Merge main into feature", Files: []string{"x.go"}, Merge: true},
+		}, "merge commit"},
+		{"empty", []git.SeriesCommit{
+			step("a1", "Add the parser", "parse.go"),
+			step("a2", "Trigger another CI run"),
+		}, "changes nothing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, why := cfg.judgeCommits(tt.commits)
+			if tt.why == "" && !ok {
+				t.Errorf("squashed (%s), want preserved", why)
+			}
+			if tt.why != "" && (ok || !strings.Contains(why, tt.why)) {
+				t.Errorf("judgeCommits = %v, %q; want squashed for %q", ok, why, tt.why)
+			}
+		})
+	}
+
+	limited := &AutoStrategyConfig{MaxCommits: 1}
+	if ok, _ := limited.judgeCommits(tests[0].commits); ok {
+		t.Error("series over max_commits preserved")
+	}
+}
+
+func TestLoadConfig_AutoStrategy(t *testing.T) {
+	cfg := DefaultMergeQueueConfig()
+	err := applyMergeQueueConfig(cfg, []byte(`{"merge_strategy": "auto", "auto_strategy": {"preserve": "merge-commit", "noise_subjects": ["^chore"]}}`))
+	if err != nil {
+		t.Fatalf("applyMergeQueueConfig: %v", err)
+	}
+	a := cfg.AutoStrategy
+	if cfg.MergeStrategy != MergeStrategyAuto || a == nil || a.preserve() != MergeStrategyMergeCommit {
+		t.Fatalf("auto_strategy = %+v", a)
+	}
+	if noise := a.noiseSubjects(); len(noise) != 1 || !noise[0].MatchString("Chore: bump deps") {
+		t.Errorf("noise subjects = %v", noise)
+	}
+	for _, bad := range []string{
+		`{"preserve": "squash"}`,
+		`{"preserve": "auto"}`,
+		`{"max_commits": -1}`,
+		`{"noise_subjects": ["("]}`,
+	} {
+		if err := applyMergeQueueConfig(DefaultMergeQueueConfig(), []byte(`{"auto_strategy": `+bad+`}`)); err == nil {
+			t.Errorf("auto_strategy %s accepted", bad)
+		}
+	}
+}
+
+func TestMergeStrategy_Auto(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createMultiCommitBranch(t, workDir, "feature-series", "s1.txt", "s2.txt")
+	run(t, workDir, "git", "checkout", "-q", "-b", "feature-fixup", "main")
+	writeFile(t, workDir, "f.txt", "f\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-q", "-m", "add f.txt")
+	writeFile(t, workDir, "f.txt", "f fixed\n")
+	run(t, workDir, "git", "commit", "-q", "-am", "fixup! add f.txt")
+	run(t, workDir, "git", "checkout", "-q", "main")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.MergeStrategy = MergeStrategyAuto
+	batch := []*MRInfo{makeMR("mr-series", "feature-series", "main"), makeMR("mr-fixup", "feature-fixup", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5})
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+	}
+
+	// The series keeps both commits; the fixup pair lands as one.
+	want := []string{"fixup! add f.txt", "add s2.txt", "add s1.txt", "initial commit"}
+	if got := originLog(t, workDir, "%s"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("origin/main =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if batch[0].MergeStrategy != "" {
+		t.Errorf("MR strategy rewritten to %q", batch[0].MergeStrategy)
+	}
+}
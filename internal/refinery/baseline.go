@@ -0,0 +1,202 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// BaselineGate is one gate's result on the bare target branch.
+type BaselineGate struct {
+	Cmd    string    `json:"cmd"` // Command the result is for; a changed gate is rerun
+	Failed bool      `json:"failed"`
+	Error  string    `json:"error,omitempty"`
+	RanAt  time.Time `json:"ran_at"`
+}
+
+// GateBaseline holds the gate results of one target branch commit. Gates
+// are run on it lazily, the first time they fail on a tree built on it.
+type GateBaseline struct {
+	Commit string                   `json:"commit"`
+	Gates  map[string]*BaselineGate `json:"gates"`
+}
+
+type gateBaselineKey struct{}
+
+// withGateBaseline makes gate runs under ctx compare their failures with
+// target's baseline (see failsOnBaseline). It returns ctx unchanged unless
+// GateBaseline is enabled.
+func (e *Engineer) withGateBaseline(ctx context.Context, target string) context.Context {
+	if !e.config.GateBaseline || target == "" || e.rig == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, gateBaselineKey{}, target)
+}
+
+func (e *Engineer) gateBaselinePath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "gate-baseline.json")
+}
+
+// GateBaselines returns the cached baseline gate results, keyed by target
+// branch.
+func (e *Engineer) GateBaselines() (map[string]*GateBaseline, error) {
+	baselines := make(map[string]*GateBaseline)
+	data, err := os.ReadFile(e.gateBaselinePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return baselines, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &baselines); err != nil {
+		return nil, fmt.Errorf("parsing gate baseline: %w", err)
+	}
+	return baselines, nil
+}
+
+// failsOnBaseline reports whether gate, which just failed under ctx, fails
+// on the bare target too. Such a failure was already on the target before
+// any MR in the tree, so blaming one of them for it would be wrong.
+//
+// The result is cached per target commit and gate command, so a broken
+// target costs one extra run of each failing gate until it moves. Errors
+// reaching the baseline fail closed: the failure blocks as before.
+func (e *Engineer) failsOnBaseline(ctx context.Context, name string, gate *GateConfig) bool {
+	target, _ := ctx.Value(gateBaselineKey{}).(string)
+	if target == "" {
+		return false
+	}
+	e.baselineMu.Lock()
+	defer e.baselineMu.Unlock()
+
+	commit, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Warning: resolving origin/%s: %v\n", target, err)
+		return false
+	}
+	baselines, err := e.GateBaselines()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Warning: %v (starting afresh)\n", err)
+		baselines = make(map[string]*GateBaseline)
+	}
+	b := baselines[target]
+	if b == nil || b.Commit != commit {
+		b = &GateBaseline{Commit: commit, Gates: make(map[string]*BaselineGate)}
+		baselines[target] = b
+	}
+	if g := b.Gates[name]; g != nil && g.Cmd == gate.signature() {
+		return g.Failed
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Baseline] Gate %q failed, running it on %s (%s) to compare\n", name, target, shortSHA(commit))
+	dir := filepath.Join(e.rig.Path, ".runtime", "baseline", target)
+	_ = e.git.WorktreeRemove(dir, true)
+	if err := e.git.WorktreeAddDetached(dir, commit); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Warning: worktree for %s: %v\n", target, err)
+		return false
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(dir, true)
+	}()
+	r := e.runGateIn(ctx, dir, name, gate)
+	if ctx.Err() != nil {
+		return false
+	}
+	b.Gates[name] = &BaselineGate{Cmd: gate.signature(), Failed: !r.Success, Error: r.Error, RanAt: time.Now().UTC()}
+	unlock := e.lockState()
+	if latest, err := e.GateBaselines(); err == nil {
+		// Another target's pipeline may have saved its baseline meanwhile.
+		latest[target] = b
+		baselines = latest
+	}
+	err = util.EnsureDirAndWriteJSON(e.gateBaselinePath(), baselines)
+	unlock()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Warning: saving baseline: %v\n", err)
+	}
+	if r.Success {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Gate %q passes on %s: the failure is new\n", name, target)
+	} else {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Gate %q fails on %s too: not blaming the tree\n", name, target)
+	}
+	return !r.Success
+}
+
+// ErrTargetBroken is returned (wrapped, with a diagnostic) for a batch whose
+// failing gates fail on the bare target branch too (see
+// holdForBrokenTarget).
+var ErrTargetBroken = errors.New("target branch broken")
+
+// holdForBrokenTarget implements BaselineCheck for a batch of mrs that just
+// failed its gates with failed. It reruns the failed gates on the bare tip
+// of target; if every one of them fails there too, no MR in the batch is to
+// blame. The mrs are deferred instead of bisected, the local target is reset,
+// and the queue is paused with a diagnostic until an operator fixes target
+// and resumes it. It reports whether it held the batch.
+func (e *Engineer) holdForBrokenTarget(ctx context.Context, target string, failed ProcessResult, mrs []*MRInfo, result *BatchResult) bool {
+	if !e.config.BaselineCheck || !failed.TestsFailed || ctx.Err() != nil {
+		return false
+	}
+	diag := e.targetBreakage(ctx, target, failed.FailedGates)
+	if diag == "" {
+		return false
+	}
+	_, _ = fmt.Fprintf(e.output, "[Baseline] %s: deferring %d MRs and pausing the merge queue\n", diag, len(mrs))
+	e.resetLocalTarget(target)
+	result.Deferred = append(result.Deferred, mrs...)
+	result.Error = fmt.Errorf("%w: %s", ErrTargetBroken, diag)
+	e.recordProgress(StageDeferred, diag, result.BatchID, mrs...)
+	if _, err := e.Pause("refinery", diag+"; fix "+target+", then run 'gt mq resume'"); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Warning: pausing the merge queue: %v\n", err)
+	}
+	return true
+}
+
+// targetBreakage runs the named gates on the bare tip of target and, if all
+// of them fail there, returns a diagnostic naming them and the commit. It
+// returns "" if any passes, or if the check itself can't be done: a failure
+// nobody can attribute is left to bisection as before.
+func (e *Engineer) targetBreakage(ctx context.Context, target string, names []string) string {
+	gates := e.gatesFor(target)
+	if len(names) == 0 || len(gates) == 0 {
+		return ""
+	}
+	e.baselineMu.Lock()
+	defer e.baselineMu.Unlock()
+
+	commit, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Warning: resolving origin/%s: %v\n", target, err)
+		return ""
+	}
+	_, _ = fmt.Fprintf(e.output, "[Baseline] Running failed gates %s on %s (%s)\n", strings.Join(names, ", "), target, shortSHA(commit))
+	dir := filepath.Join(e.rig.Path, ".runtime", "baseline", target)
+	_ = e.git.WorktreeRemove(dir, true)
+	if err := e.git.WorktreeAddDetached(dir, commit); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Baseline] Warning: worktree for %s: %v\n", target, err)
+		return ""
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(dir, true)
+	}()
+	var broken []string
+	for _, name := range names {
+		gate := gates[name]
+		if gate == nil {
+			return ""
+		}
+		r := e.runGateIn(ctx, dir, name, gate)
+		if ctx.Err() != nil || r.Success {
+			return ""
+		}
+		broken = append(broken, name)
+	}
+	return fmt.Sprintf("gates %s fail on %s at %s without any MR", strings.Join(broken, ", "), target, shortSHA(commit))
+}
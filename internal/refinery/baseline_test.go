@@ -0,0 +1,105 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProcessBatch_GateBaseline(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	// main is already broken for the legacy gate.
+	writeFile(t, workDir, "FAIL_MARKER", "broken on main\n")
+	run(t, workDir, "git", "add", "FAIL_MARKER")
+	run(t, workDir, "git", "commit", "-m", "break main")
+	run(t, workDir, "git", "push", "origin", "main")
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "NEW_FAIL", "broken by b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.GatesParallel = false
+	e.config.Gates = map[string]*GateConfig{
+		"legacy": {Cmd: failMarkerGateCmd()},
+		"new":    {Cmd: "test ! -f NEW_FAIL"},
+	}
+	e.config.GateBaseline = true
+
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if ids := stackedIDs(result.Merged); len(ids) != 1 || ids[0] != "mr-a" {
+		t.Errorf("merged = %v, want [mr-a]", ids)
+	}
+	if ids := stackedIDs(result.Culprits); len(ids) != 1 || ids[0] != "mr-b" {
+		t.Errorf("culprits = %v, want [mr-b]", ids)
+	}
+
+	baselines, err := e.GateBaselines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := baselines["main"]
+	if b == nil || b.Gates["legacy"] == nil || !b.Gates["legacy"].Failed {
+		t.Fatalf("baseline = %+v, want legacy failing", b)
+	}
+	if b.Gates["new"] == nil || b.Gates["new"].Failed {
+		t.Errorf("baseline new = %+v, want passing", b.Gates["new"])
+	}
+}
+
+func TestProcessBatch_GateBaselineDisabled(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	writeFile(t, workDir, "FAIL_MARKER", "broken on main\n")
+	run(t, workDir, "git", "add", "FAIL_MARKER")
+	run(t, workDir, "git", "commit", "-m", "break main")
+	run(t, workDir, "git", "push", "origin", "main")
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"legacy": {Cmd: failMarkerGateCmd()}}
+
+	result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "feature-a", "main")}, "main", DefaultBatchConfig())
+	if len(result.Merged) != 0 || len(result.Culprits) != 1 {
+		t.Errorf("merged %v, culprits %v; want mr-a blamed", stackedIDs(result.Merged), stackedIDs(result.Culprits))
+	}
+}
+
+func TestProcessBatch_BaselineCheck(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+	// main breaks after the branches were cut.
+	writeFile(t, workDir, "FAIL_MARKER", "broken on main\n")
+	run(t, workDir, "git", "add", "FAIL_MARKER")
+	run(t, workDir, "git", "commit", "-m", "break main")
+	run(t, workDir, "git", "push", "origin", "main")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: failMarkerGateCmd()}}
+	e.config.BaselineCheck = true
+
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if !errors.Is(result.Error, ErrTargetBroken) {
+		t.Fatalf("ProcessBatch error = %v, want ErrTargetBroken", result.Error)
+	}
+	if len(result.Culprits) != 0 || len(result.Merged) != 0 || len(result.Deferred) != 2 {
+		t.Errorf("merged %v, culprits %v, deferred %v; want both deferred", stackedIDs(result.Merged), stackedIDs(result.Culprits), stackedIDs(result.Deferred))
+	}
+	p, err := e.Paused()
+	if err != nil || p == nil || !strings.Contains(p.Reason, "gates test fail on main") {
+		t.Fatalf("pause = %+v, %v; want the queue paused with a diagnostic", p, err)
+	}
+	if got, want := run(t, workDir, "git", "rev-parse", "main"), run(t, workDir, "git", "rev-parse", "origin/main"); got != want {
+		t.Errorf("main at %s, want reset to origin/main %s", got, want)
+	}
+
+	// Nothing lands until an operator resumes the queue.
+	result = e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if len(result.Deferred) != 2 || result.Error != nil {
+		t.Errorf("paused queue: deferred %v, %v", stackedIDs(result.Deferred), result.Error)
+	}
+}
@@ -2,7 +2,10 @@ package refinery
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -21,8 +24,85 @@ type BatchConfig struct {
 
 	// RetryBatchOnFlaky controls whether to retry the full batch once before
 	// bisecting when tests fail. This avoids blaming an innocent MR for a
-	// flaky test. Default: true.
+	// flaky test. Default: true. Gates that timed out (rather than failed)
+	// are always retried once, as a hang is more often the machine than the
+	// stack. Once any gate is marked Flaky (see GateConfig), only failures
+	// of flaky gates are retried (see flakyRetries).
 	RetryBatchOnFlaky bool `json:"retry_batch_on_flaky"`
+
+	// MaxRetries is how many times a failed batch is retried before
+	// bisecting. Zero means one retry if RetryBatchOnFlaky is set.
+	MaxRetries int `json:"max_retries,omitempty"`
+
+	// RetryBackoff is how long to wait before the first retry, doubling
+	// with each retry after it. Zero retries immediately.
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
+	// PrewarmNextBatch fetches the branches of the predicted next batch while
+	// the current batch's gates run, so stacking can start as soon as the
+	// merge slot frees up. Branches that only exist on origin become local
+	// (and therefore stackable) when this is enabled. Default: false.
+	PrewarmNextBatch bool `json:"prewarm_next_batch"`
+
+	// PipelineNextBatch goes one step further than PrewarmNextBatch: while
+	// the current batch's gates run, the predicted next batch is fetched and
+	// stacked on the current stack tip in a temporary worktree. If the
+	// current batch lands as stacked, the next ProcessBatch adopts that stack
+	// and starts its gates right away; otherwise the stack is discarded (see
+	// startPipeline). Not used with MergeTrain, where the landed commit
+	// isn't known in advance. Default: false.
+	PipelineNextBatch bool `json:"pipeline_next_batch"`
+
+	// MergeTrain gates every prefix of the stack at once, each in its own
+	// temporary worktree, and lands the longest prefix that passes instead
+	// of retrying and bisecting serially (see runMergeTrain). Trades CPU for
+	// latency when the queue is deep. Default: false.
+	MergeTrain bool `json:"merge_train"`
+
+	// QoSReserve maps a QoS class (interactive, batch, background) to the
+	// fraction of MaxBatchSize held for MRs of that class, so a backlog of
+	// one class can't crowd the others out of a batch. Reserved slots a
+	// class doesn't use go to the rest of the queue. Nil disables
+	// reservations. Default: DefaultQoSReserve().
+	QoSReserve map[string]float64 `json:"qos_reserve,omitempty"`
+
+	// BisectStrategy selects how a failed stack is searched for culprits:
+	// "linear" (one MR at a time), "binary" (recursive halving), or
+	// "parallel-group" (failing groups split and gated concurrently in
+	// temporary worktrees, see bisectParallelGroup). Default: "binary".
+	BisectStrategy string `json:"bisect_strategy,omitempty"`
+
+	// AdaptiveSize tunes the batch size to the target's recent batches,
+	// TCP-style: starting from MaxBatchSize, every batch that lands without
+	// a culprit grows it by one and every batch whose gates blamed an MR
+	// halves it, within MinBatchSize and MaxBatchSize (see
+	// EffectiveBatchSize). Default: false.
+	AdaptiveSize bool `json:"adaptive_size,omitempty"`
+
+	// MinBatchSize is the smallest size AdaptiveSize shrinks to. Default: 1.
+	MinBatchSize int `json:"min_batch_size,omitempty"`
+
+	// AdaptiveWindow is how many of the target's most recent batches
+	// AdaptiveSize replays. Default: DefaultAdaptiveWindow.
+	AdaptiveWindow int `json:"adaptive_window,omitempty"`
+
+	// StarvationAge is how long an MR can wait in the queue before
+	// AssembleBatch gives it a batch of its own, so it either lands or gets
+	// a definitive conflict or culprit verdict instead of being bumped
+	// again (see starvingMR). Zero disables the age check. Default: 24h.
+	StarvationAge time.Duration `json:"starvation_age,omitempty"`
+
+	// StarvationRetries is how many conflict retries (MRInfo.RetryCount)
+	// an MR can accumulate before it gets a batch of its own. Zero
+	// disables the retry check. Default: 3.
+	StarvationRetries int `json:"starvation_retries,omitempty"`
+
+	// SeparateOverlaps keeps MRs changing the same paths out of the same
+	// batch: AssembleBatch passes over an MR whose branch changes a path
+	// that an MR already in the batch changes, leaving it queued for a
+	// later batch, and takes the next MR in the queue instead. This avoids
+	// stacking the MRs likeliest to conflict mid-stack. Default: true.
+	SeparateOverlaps bool `json:"separate_overlaps"`
 }
 
 // DefaultBatchConfig returns sensible defaults for batch processing.
@@ -31,11 +111,19 @@ func DefaultBatchConfig() *BatchConfig {
 		MaxBatchSize:      5,
 		BatchWaitTime:     30 * time.Second,
 		RetryBatchOnFlaky: true,
+		QoSReserve:        DefaultQoSReserve(),
+		StarvationAge:     24 * time.Hour,
+		StarvationRetries: 3,
+		SeparateOverlaps:  true,
 	}
 }
 
 // BatchResult holds the outcome of processing a batch of MRs.
 type BatchResult struct {
+	// BatchID uniquely identifies this batch run. Pre-batch ref values are
+	// journaled under this ID (see RollbackToJournal).
+	BatchID string
+
 	// Merged is the set of MRs that were successfully merged.
 	Merged []*MRInfo
 
@@ -45,60 +133,301 @@ type BatchResult struct {
 	// Conflicts is the set of MRs that had merge conflicts during stack construction.
 	Conflicts []*MRInfo
 
+	// Deferred is the set of MRs left queued for a later batch without
+	// being gated (see splitByPrediction and landMergeTrain).
+	Deferred []*MRInfo
+
 	// MergeCommit is the final SHA pushed to the target branch (empty if nothing merged).
 	MergeCommit string
 
+	// GateLogs locate the captured output of the batch's gate runs, the
+	// latest run per MR and gate (see GateLog).
+	GateLogs []*GateLog
+
+	// SkippedGates are the gates the stack tip didn't run because the
+	// batch changes none of their paths (see GateConfig.Paths).
+	SkippedGates []string
+
 	// Error is set if the batch processing encountered an infrastructure error.
 	Error error
+
+	// Cancelled is set when the context was cancelled before the batch
+	// landed. The tree was rolled back and the batch's unsettled MRs are in
+	// Deferred; Error wraps the context's error.
+	Cancelled bool
+
+	// Preempted are the urgent MRs that landed alone ahead of the batch,
+	// interrupting it if it was running (see Preempt).
+	Preempted []string
+
+	preempted   bool // Cancelled by a preemption, to be re-stacked
+	webhooksDue bool // Decided, so ProcessBatch notifies the webhooks
 }
 
+// ErrDependencyCycle is returned (wrapped, naming the MRs involved) for a
+// batch whose MRs block each other in a loop.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
 // AssembleBatch selects up to MaxBatchSize MRs from the ready queue.
-// MRs are assumed to be pre-sorted by score (highest first).
-// MRs that are blocked by other MRs not in the batch are excluded.
+// MRs are assumed to be pre-sorted by score (highest first) and to share a
+// target branch (see SplitQueues).
+//
+// An MR blocked by another ready MR is taken together with its blocker, and
+// stacked after it, as long as both fit. MRs blocked by something outside
+// the ready queue are excluded, as are MRs blocked by a dependency cycle,
+// which are reported (see reportDependencyCycles). An MR kept
+// out of the queue by another MR has passed its priority on to that MR
+// (see ListReadyMRs), so the blocker is pulled forward rather than waiting
+// behind less urgent MRs.
+//
+// When QoSReserve is set, each class's reserved slots are filled first with
+// its highest-scoring MRs, then the remaining capacity in score order.
+//
+// With AdaptiveSize set, the batch is capped at EffectiveBatchSize rather
+// than MaxBatchSize.
+//
+// With SeparateOverlaps set, an MR changing the same paths as an MR
+// already taken is passed over, together with its blockers.
+//
+// An urgent MR preempts all of this and is batched alone, after any of its
+// blockers in the queue. A starving MR (see StarvationAge and
+// StarvationRetries) is batched alone too.
+//
+// While the merge queue is paused the batch is still assembled, and
+// reported as what resuming would pick up; ProcessBatch won't land it.
 func (e *Engineer) AssembleBatch(readyMRs []*MRInfo, config *BatchConfig) []*MRInfo {
+	e.reportDependencyCycles(readyMRs)
+	batch := e.assembleBatch(readyMRs, config, e.output)
+	if len(readyMRs) > 0 {
+		e.recordQueueDepth(readyMRs[0].Target, len(readyMRs))
+	}
+	if p := e.activePause(); p != nil && len(batch) > 0 {
+		_, _ = fmt.Fprintf(e.output, "[Batch] %s; on resume would batch %s\n", p, strings.Join(mrIDs(batch), ", "))
+	}
+	return batch
+}
+
+// reportDependencyCycles reports the MRs in ready that can never be
+// batched because their blockers loop: the members of a dependency cycle
+// and the MRs waiting on one. Each is logged and recorded as an error on
+// every call. Only a person can break the loop, by changing an MR's
+// blocked_by, so each MR's bead is also told, once per cycle.
+func (e *Engineer) reportDependencyCycles(ready []*MRInfo) {
+	queued := make(map[string]*MRInfo, len(ready))
+	for _, mr := range ready {
+		queued[mr.ID] = mr
+	}
+	for _, mr := range ready {
+		_, _, err := blockerChain(mr, queued)
+		if err == nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Batch] MR %s can't be batched: %v\n", mr.ID, err)
+		e.recordProgress(StageError, err.Error(), "", mr)
+
+		e.cycleMu.Lock()
+		noted := e.cycleNoted[mr.ID] == err.Error()
+		e.cycleMu.Unlock()
+		if noted {
+			continue
+		}
+		id := mr.SourceIssue
+		if id == "" {
+			id = mr.ID
+		}
+		if cerr := e.commentIssue(id, cycleComment(mr, err)); cerr != nil {
+			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: MR %s: commenting on %s: %v\n", mr.ID, id, cerr)
+			continue
+		}
+		e.cycleMu.Lock()
+		if e.cycleNoted == nil {
+			e.cycleNoted = make(map[string]string)
+		}
+		e.cycleNoted[mr.ID] = err.Error()
+		e.cycleMu.Unlock()
+	}
+}
+
+// cycleComment tells the author of mr that cycle keeps it out of batches.
+func cycleComment(mr *MRInfo, cycle error) string {
+	return fmt.Sprintf("Refinery: MR %s (branch %s) can't be batched: %v. "+
+		"Change blocked_by on one of these MRs to break the loop; it is batched once its blockers can land.",
+		mr.ID, mr.Branch, cycle)
+}
+
+// assembleBatch picks the batch for AssembleBatch, explaining its choices
+// on out. It records nothing, so it also serves to predict later batches.
+func (e *Engineer) assembleBatch(readyMRs []*MRInfo, config *BatchConfig, out io.Writer) []*MRInfo {
 	if config == nil {
-		config = DefaultBatchConfig()
+		config = e.defaultBatchConfig()
+	}
+	if len(readyMRs) == 0 {
+		return []*MRInfo{}
+	}
+	queued := make(map[string]*MRInfo, len(readyMRs))
+	for _, mr := range readyMRs {
+		queued[mr.ID] = mr
+	}
+	for _, mr := range readyMRs {
+		if !mr.Urgent {
+			continue
+		}
+		// An urgent MR still can't land ahead of its blockers: it takes
+		// those in the queue along, and waits for any that aren't.
+		chain, external, err := blockerChain(mr, queued)
+		switch {
+		case err != nil:
+			continue
+		case external != "":
+			_, _ = fmt.Fprintf(out, "[Batch] MR %s is urgent but waits on %s, which isn't queued\n", mr.ID, external)
+			continue
+		case len(chain) > 1:
+			_, _ = fmt.Fprintf(out, "[Batch] MR %s is urgent, batching it with its blockers %s\n", mr.ID, strings.Join(mrIDs(chain[:len(chain)-1]), ", "))
+		default:
+			_, _ = fmt.Fprintf(out, "[Batch] MR %s is urgent, batching it alone\n", mr.ID)
+		}
+		return chain
+	}
+	if mr, why := config.starvingMR(readyMRs, time.Now()); mr != nil {
+		_, _ = fmt.Fprintf(out, "[Batch] MR %s is starving (%s), batching it alone\n", mr.ID, why)
+		return []*MRInfo{mr}
 	}
-	maxSize := config.MaxBatchSize
-	if maxSize <= 0 {
-		maxSize = 5
+	maxSize := e.EffectiveBatchSize(readyMRs[0].Target, config)
+
+	var overlaps *overlapGuard
+	if config.SeparateOverlaps {
+		overlaps = e.newOverlapGuard(readyMRs[0].Target)
 	}
+	passedOver := make(map[string]bool)
 
 	batch := make([]*MRInfo, 0, maxSize)
-	for _, mr := range readyMRs {
-		if len(batch) >= maxSize {
-			break
+	taken := make(map[string]bool, maxSize)
+	add := func(mr *MRInfo) bool {
+		if taken[mr.ID] {
+			return false
+		}
+		chain, external, err := blockerChain(mr, queued)
+		if err != nil || external != "" {
+			return false
+		}
+		need := 0
+		for _, c := range chain {
+			if !taken[c.ID] {
+				need++
+			}
+		}
+		if len(batch)+need > maxSize {
+			return false
 		}
-		// Skip MRs blocked by something not already in this batch
-		if mr.BlockedBy != "" {
-			inBatch := false
-			for _, b := range batch {
-				if b.ID == mr.BlockedBy {
-					inBatch = true
-					break
+		if overlaps != nil {
+			if member, paths := overlaps.conflict(chain); member != "" {
+				if !passedOver[mr.ID] {
+					passedOver[mr.ID] = true
+					_, _ = fmt.Fprintf(out, "[Batch] Holding MR %s for a later batch: changes %s, like %s\n",
+						mr.ID, describeOverlap(paths), member)
 				}
+				return false
 			}
-			if !inBatch {
-				continue
+			overlaps.take(chain)
+		}
+		// Blockers come first in chain, so they are stacked first.
+		for _, c := range chain {
+			if !taken[c.ID] {
+				batch = append(batch, c)
+				taken[c.ID] = true
+			}
+		}
+		return true
+	}
+
+	if config.QoSReserve != nil {
+		slots := qosSlots(config.QoSReserve, maxSize)
+		for _, mr := range readyMRs {
+			class := normalizeQoS(mr.QoS)
+			if slots[class] > 0 && add(mr) {
+				slots[class]--
 			}
 		}
-		batch = append(batch, mr)
+	}
+	for _, mr := range readyMRs {
+		if len(batch) >= maxSize {
+			break
+		}
+		add(mr)
 	}
 	return batch
 }
 
-// BuildRebaseStack constructs a squash-merge stack on the target branch.
-// Each MR is squash-merged sequentially: target ← MR1 ← MR2 ← MR3.
+// blockerChain follows mr's BlockedBy links through queued and returns the
+// MRs visited, outermost blocker first and mr last. external is the first
+// blocker not in queued ("" if the chain ends in queued). Returns an error
+// wrapping ErrDependencyCycle if the links loop.
+func blockerChain(mr *MRInfo, queued map[string]*MRInfo) (chain []*MRInfo, external string, err error) {
+	seen := make(map[string]bool)
+	for cur := mr; cur != nil; {
+		if seen[cur.ID] {
+			ids := append(mrIDs(chain), cur.ID)
+			return nil, "", fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(ids, " → "))
+		}
+		seen[cur.ID] = true
+		chain = append(chain, cur)
+		if cur.BlockedBy == "" {
+			break
+		}
+		next, ok := queued[cur.BlockedBy]
+		if !ok {
+			external = cur.BlockedBy
+			break
+		}
+		cur = next
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, external, nil
+}
+
+// orderByDependencies returns batch stably reordered so each MR comes after
+// the batch member blocking it. Blockers outside the batch are ignored.
+// Returns an error wrapping ErrDependencyCycle if batch members block each
+// other in a loop.
+func orderByDependencies(batch []*MRInfo) ([]*MRInfo, error) {
+	inBatch := make(map[string]*MRInfo, len(batch))
+	for _, mr := range batch {
+		inBatch[mr.ID] = mr
+	}
+	ordered := make([]*MRInfo, 0, len(batch))
+	placed := make(map[string]bool, len(batch))
+	for _, mr := range batch {
+		chain, _, err := blockerChain(mr, inBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range chain {
+			if !placed[c.ID] {
+				ordered = append(ordered, c)
+				placed[c.ID] = true
+			}
+		}
+	}
+	return ordered, nil
+}
+
+// BuildRebaseStack constructs a merge stack on the target branch.
+// Each MR is merged sequentially with its merge strategy (squash unless
+// configured otherwise, see merge_strategy.go): target ← MR1 ← MR2 ← MR3.
 // Returns the list of MRs that were successfully stacked, and any that
 // conflicted (which are removed from the stack and the stack is rebuilt).
 //
 // On return, the git working directory is on the target branch with all
-// successful MR squash-merges applied (but not pushed).
+// successful MR merges applied (but not pushed).
 func (e *Engineer) BuildRebaseStack(ctx context.Context, batch []*MRInfo, target string) (stacked []*MRInfo, conflicts []*MRInfo, err error) {
 	if len(batch) == 0 {
 		return nil, nil, nil
 	}
 
+	e.ensureMergeDrivers()
+
 	// Checkout target and ensure it's up to date
 	if checkoutErr := e.git.Checkout(target); checkoutErr != nil {
 		return nil, nil, fmt.Errorf("checkout target %s: %w", target, checkoutErr)
@@ -106,6 +435,9 @@ func (e *Engineer) BuildRebaseStack(ctx context.Context, batch []*MRInfo, target
 	if pullErr := e.git.Pull("origin", target); pullErr != nil {
 		_, _ = fmt.Fprintf(e.output, "[Batch] Warning: pull origin/%s: %v (continuing)\n", target, pullErr)
 	}
+	if pw := e.takePrewarm(batch, target); pw != nil {
+		_, _ = fmt.Fprintf(e.output, "[Batch] Branches pre-fetched at %s\n", pw.PreparedAt.Format(time.RFC3339))
+	}
 
 	// Remember the base SHA to reset on retry
 	baseSHA, err := e.git.Rev("HEAD")
@@ -113,7 +445,7 @@ func (e *Engineer) BuildRebaseStack(ctx context.Context, batch []*MRInfo, target
 		return nil, nil, fmt.Errorf("get base SHA: %w", err)
 	}
 
-	// Try to stack each MR via squash-merge
+	// Try to stack each MR
 	for _, mr := range batch {
 		_, _ = fmt.Fprintf(e.output, "[Batch] Stacking MR %s (branch %s)...\n", mr.ID, mr.Branch)
 
@@ -126,10 +458,15 @@ func (e *Engineer) BuildRebaseStack(ctx context.Context, batch []*MRInfo, target
 		}
 
 		// Check for conflicts before merging
-		conflictFiles, conflictErr := e.git.CheckConflicts(mr.Branch, target)
+		e.migrateAssetsToLFS(mr, target)
+		conflictFiles, conflictErr := e.checkMRConflicts(mr, target)
 		if conflictErr != nil || len(conflictFiles) > 0 {
 			_, _ = fmt.Fprintf(e.output, "[Batch] MR %s: conflicts detected, removing from batch\n", mr.ID)
 			conflicts = append(conflicts, mr)
+			if !e.mergeTreeUnsupported {
+				// Checked in memory: the stack is untouched.
+				continue
+			}
 
 			// Reset to base and rebuild stack without this MR
 			if resetErr := e.git.ResetHard(baseSHA); resetErr != nil {
@@ -137,17 +474,15 @@ func (e *Engineer) BuildRebaseStack(ctx context.Context, batch []*MRInfo, target
 			}
 			// Rebuild the stack with MRs stacked so far (minus the conflicting one)
 			for _, prev := range stacked {
-				msg := e.getMergeMessage(prev)
-				if mergeErr := e.git.MergeSquash(prev.Branch, msg); mergeErr != nil {
+				if mergeErr := e.mergeMR(prev); mergeErr != nil {
 					return nil, nil, fmt.Errorf("rebuild stack for %s: %w", prev.ID, mergeErr)
 				}
 			}
 			continue
 		}
 
-		// Squash-merge this MR onto the stack
-		msg := e.getMergeMessage(mr)
-		if mergeErr := e.git.MergeSquash(mr.Branch, msg); mergeErr != nil {
+		// Merge this MR onto the stack with its merge strategy
+		if mergeErr := e.mergeMR(mr); mergeErr != nil {
 			_, _ = fmt.Fprintf(e.output, "[Batch] MR %s: merge failed: %v, removing from batch\n", mr.ID, mergeErr)
 			conflicts = append(conflicts, mr)
 
@@ -156,8 +491,7 @@ func (e *Engineer) BuildRebaseStack(ctx context.Context, batch []*MRInfo, target
 				return nil, nil, fmt.Errorf("reset after merge failure: %w", resetErr)
 			}
 			for _, prev := range stacked {
-				prevMsg := e.getMergeMessage(prev)
-				if rebuildErr := e.git.MergeSquash(prev.Branch, prevMsg); rebuildErr != nil {
+				if rebuildErr := e.mergeMR(prev); rebuildErr != nil {
 					return nil, nil, fmt.Errorf("rebuild stack for %s: %w", prev.ID, rebuildErr)
 				}
 			}
@@ -191,12 +525,187 @@ func (e *Engineer) getMergeMessage(mr *MRInfo) string {
 //  1. Build the rebase stack (target ← MR1 ← MR2 ← ... ← MRn)
 //  2. Run gates once on the stack tip
 //  3. If green: push (fast-forward all MRs to target)
-//  4. If red and RetryBatchOnFlaky: retry the full batch once
-//  5. If still red: bisect to isolate the culprit
-//  6. Re-batch good MRs for the next cycle
+//  4. If red because target itself fails the gates: hold the batch (see
+//     holdForBrokenTarget)
+//  5. If red and the failure may be flaky, retry the batch (see flakyRetries)
+//  6. If still red: bisect to isolate the culprit
+//  7. Re-batch good MRs for the next cycle
+//
+// With MergeTrain set, steps 2-7 are replaced by runMergeTrain.
+//
+// Config files changed since the last batch are reloaded first (see
+// ReloadConfig); batchCfg nil means the rig's batch config. Only one batch
+// per target runs at a time, across processes, and batches a crash left
+// unfinished are recovered before it (see resumeBatches). Urgent MRs land
+// alone before the batch, or preempt it mid-run, in which case it is
+// re-stacked on their tip (see Preempt); the result covers all of them.
 func (e *Engineer) ProcessBatch(ctx context.Context, batch []*MRInfo, target string, batchCfg *BatchConfig) *BatchResult {
+	e.reloadConfig(ctx)
+	runs, err := e.runBatches(ctx, batch, target, batchCfg)
+	if err != nil {
+		return &BatchResult{Error: err}
+	}
+
+	// Webhooks are notified once the batch lock is released: an unreachable
+	// one takes a while to give up on, and mustn't hold up target's queue.
+	for _, r := range runs {
+		if r.webhooksDue {
+			e.notifyWebhooks(ctx, r, target)
+		}
+	}
+	result := runs[len(runs)-1]
+	for i := len(runs) - 2; i >= 0; i-- {
+		result.absorb(runs[i])
+	}
+	return result
+}
+
+// runBatches runs batch for ProcessBatch under target's batch lock,
+// returning each run in order, batch's own last. Urgent MRs land alone
+// first, including those preempting the batch midway, which is then
+// re-stacked on their tip (see Preempt).
+func (e *Engineer) runBatches(ctx context.Context, batch []*MRInfo, target string, batchCfg *BatchConfig) ([]*BatchResult, error) {
+	unlockBatch, err := e.flock(filepath.Join("batches", target+".lock"))
+	if err != nil {
+		return nil, fmt.Errorf("batch lock: %w", err)
+	}
+	defer unlockBatch()
+	if landed := e.resumeBatches(target, batch); landed != nil {
+		return []*BatchResult{landed}, nil
+	}
+
+	var runs []*BatchResult
+	for {
+		if p := e.takePreemption(target, batch); p != nil {
+			runs = append(runs, e.landUrgent(ctx, p, target, batchCfg))
+			continue
+		}
+		result := e.runBatch(ctx, batch, target, batchCfg)
+		runs = append(runs, result)
+		if !result.preempted {
+			return runs, nil
+		}
+		batch, result.Deferred = result.Deferred, nil
+	}
+}
+
+// rejectBatch fails batch with err before anything was stacked, recording
+// it like any other batch.
+func (e *Engineer) rejectBatch(batch []*MRInfo, target string, started time.Time, rec *batchRecorder, err error) *BatchResult {
+	_, _ = fmt.Fprintf(e.output, "[Batch] Rejecting batch: %v\n", err)
+	e.recordProgress(StageError, err.Error(), "", batch...)
+	result := &BatchResult{Error: err}
+	e.recordBatch(batch, target, started, rec, result)
+	e.recordBatchMetrics(batch, target, started, rec, result)
+	e.notifyBlocked(result, target)
+	return result
+}
+
+// runBatch processes batch for ProcessBatch, which holds the target's
+// batch lock. The batch is deferred untouched while the queue is paused or
+// target is frozen, and isn't landed outside target's merge windows (see
+// checkMergeWindow). Otherwise it is ordered by dependencies, trimmed by
+// the batch predictor (see splitByPrediction) and processed. The outcome is
+// then recorded and reported (webhooks are left to ProcessBatch, once the
+// lock is released), and a landing is watched by the post-merge
+// gates (see watchLanding) before the post-merge and deploy hooks run.
+func (e *Engineer) runBatch(ctx context.Context, batch []*MRInfo, target string, batchCfg *BatchConfig) *BatchResult {
+	started := time.Now()
+	if p := e.activePause(); p != nil {
+		_, _ = fmt.Fprintf(e.output, "[Batch] Deferring %d MRs: %s\n", len(batch), p)
+		e.recordProgress(StageDeferred, p.String(), "", batch...)
+		return &BatchResult{Deferred: batch}
+	}
+	if fw := e.ActiveFreeze(target, started); fw != nil {
+		msg := fmt.Sprintf("merge freeze on %s until %s", target, fw.Until(started).Format(time.RFC3339))
+		if fw.Reason != "" {
+			msg += ": " + fw.Reason
+		}
+		_, _ = fmt.Fprintf(e.output, "[Batch] Deferring %d MRs: %s\n", len(batch), msg)
+		e.recordProgress(StageDeferred, msg, "", batch...)
+		return &BatchResult{Deferred: batch}
+	}
+	held, prevalidatedBy := e.checkMergeWindow(batch, target)
+	if held {
+		return &BatchResult{Deferred: batch}
+	}
+	ctx = withPrevalidated(ctx, prevalidatedBy)
+	rec := &batchRecorder{gateSet: e.snapshotGateSet(e.gatesFor(target))}
+	ctx = withBatchRecorder(ctx, rec)
+	e.startBatchEnergy(ctx, rec)
+	e.resetAcceptance()
+	ordered, err := orderByDependencies(batch)
+	if err != nil {
+		return e.rejectBatch(batch, target, started, rec, err)
+	}
+	batch = ordered
+	if err := e.isolateStacking(); err != nil {
+		return e.rejectBatch(batch, target, started, rec, err)
+	}
+	if err := e.prepareSigning(); err != nil {
+		return e.rejectBatch(batch, target, started, rec, err)
+	}
+	origBranch, _ := e.git.CurrentBranch()
+	batch, deferred, prob := e.splitByPrediction(ctx, batch, target)
+	e.recordProgress(StageDeferred, "split off by batch predictor", "", deferred...)
+	e.recordProgress(StageBatched, fmt.Sprintf("batch of %d targeting %s", len(batch), target), "", batch...)
+	batchCtx, stopWatching := e.watchPreemption(ctx, target, batch)
+	result := e.processBatch(batchCtx, batch, target, batchCfg)
+	preemption := stopWatching()
+	if err := ctx.Err(); err != nil {
+		e.rollbackCancelled(err, batch, origBranch, target, result)
+	} else if preemption != nil {
+		e.rollbackCancelled(fmt.Errorf("%w by urgent MR %s", ErrPreempted, preemption.MR.ID), batch, origBranch, target, result)
+		result.preempted = result.Cancelled
+	}
+	result.Deferred = append(deferred, result.Deferred...)
+	if len(result.Merged) > 0 {
+		e.clearHeldLanding(target)
+	}
+	e.finishBatchJournal(result, target, batchOutcome(result))
+	e.finishJournal(result, target)
+	rec.mu.Lock()
+	result.GateLogs = rec.gateLogs
+	rec.mu.Unlock()
+	unlock := e.lockState()
+	e.linkCulpritLogs(result)
+	e.pruneArtifacts()
+	e.recordBatchProgress(batch, result)
+	e.recordBatch(batch, target, started, rec, result)
+	e.recordBatchMetrics(batch, target, started, rec, result)
+	e.recordLandings(result, target)
+	e.recordHotfixLandings(result)
+	if result.Cancelled {
+		// Nothing was decided, so there's nothing to learn or announce.
+		unlock()
+		return result
+	}
+	if e.batchPredictor() != nil {
+		e.recordBatchOutcome(batch, target, result, prob)
+	}
+	e.trackFailurePatterns(rec, result)
+	unlock()
+	result.webhooksDue = true
+	e.sendCulpritFeedback(result, target)
+	e.notifyBlocked(result, target)
+	e.reportToGitHub(ctx, result, target)
+	e.reportToGerrit(ctx, result, target)
+	if e.watchLanding(ctx, result, target) {
+		_, _ = fmt.Fprintln(e.output, "[Batch] Landing reverted, skipping post-merge and deploy hooks")
+		return result
+	}
+	if result.Error == nil {
+		e.runPostMergeHooks(ctx, result.MergeCommit, target, result.BatchID, result.Merged)
+	}
+	e.runDeployHooks(ctx, result, target)
+	return result
+}
+
+// processBatch journals target's refs and the batch (see recordJournal and
+// journalBatch), then runs the algorithm described at ProcessBatch.
+func (e *Engineer) processBatch(ctx context.Context, batch []*MRInfo, target string, batchCfg *BatchConfig) *BatchResult {
 	if batchCfg == nil {
-		batchCfg = DefaultBatchConfig()
+		batchCfg = e.defaultBatchConfig()
 	}
 
 	result := &BatchResult{}
@@ -205,63 +714,151 @@ func (e *Engineer) ProcessBatch(ctx context.Context, batch []*MRInfo, target str
 		return result
 	}
 
-	// Single MR: use existing doMerge path (no batch overhead)
-	if len(batch) == 1 {
-		return e.processSingleMR(ctx, batch[0], target)
+	// Journal the target refs before anything rewrites them.
+	batchID := e.nextBatchID(batch, target)
+	for _, mr := range batch {
+		mr.batchID = batchID
+	}
+	if err := e.recordJournal(batchID, targetJournalRefs(target)...); err != nil {
+		result.Error = fmt.Errorf("record rollback journal: %w", err)
+		return result
+	}
+	base, _ := e.git.Rev("origin/" + target)
+	if err := e.journalBatch(BatchJournalEntry{Batch: batchID, Target: target, State: BatchAssembled, MRs: mrIDs(batch), Base: base}); err != nil {
+		result.BatchID = batchID
+		result.Error = err
+		return result
+	}
+
+	// Single MR: use existing doMerge path (no batch overhead). Gerrit
+	// changes are submitted, not pushed, so they always take the batch path.
+	if len(batch) == 1 && batch[0].Change == nil {
+		e.discardPipeline()
+		result = e.processSingleMR(ctx, batch[0], target)
+		result.BatchID = batchID
+		return result
 	}
+	result.BatchID = batchID
 
 	_, _ = fmt.Fprintf(e.output, "[Batch] Processing batch of %d MRs targeting %s\n", len(batch), target)
 
-	// Step 1: Build the stack
-	stacked, conflicts, err := e.BuildRebaseStack(ctx, batch, target)
-	if err != nil {
-		result.Error = fmt.Errorf("build rebase stack: %w", err)
-		return result
+	// Step 1: Build the stack, unless it was stacked while the previous
+	// batch's gates ran
+	e.recordBatchEvent(StageStacking, fmt.Sprintf("%d MRs onto %s", len(batch), target), batchID)
+	stacked, conflicts, adopted := e.adoptPipeline(batch, target)
+	if !adopted {
+		var err error
+		stacked, conflicts, err = e.BuildRebaseStack(ctx, batch, target)
+		if err != nil {
+			result.Error = fmt.Errorf("build rebase stack: %w", err)
+			return result
+		}
 	}
+	stacked, conflicts = e.graceConflicts(ctx, stacked, conflicts, target)
 	result.Conflicts = conflicts
+	e.requestConflictResolutions(conflicts, target)
+	recordStacked(ctx, stacked)
+	e.recordProgress(StageStacked, fmt.Sprintf("%d of %d MRs stacked", len(stacked), len(batch)), result.BatchID, stacked...)
+	e.warnJournal(e.journalStack(BatchStacked, stacked, target))
 
 	if len(stacked) == 0 {
 		_, _ = fmt.Fprintln(e.output, "[Batch] No MRs could be stacked (all conflicted)")
 		return result
 	}
 
+	// Validated while the merge window was closed: land it as is
+	if prevalidated(ctx, stacked) {
+		return e.fastForwardBatch(ctx, stacked, target, result)
+	}
+
 	// If only one MR survived after conflict removal, just process it directly
 	if len(stacked) == 1 {
 		_, _ = fmt.Fprintln(e.output, "[Batch] Only 1 MR survived stack construction, processing directly")
-		// We already have the squash merge on the target branch, run gates and push
-		return e.verifyAndPush(ctx, stacked, target)
+		// We already have the merge on the target branch, run gates and push
+		pushed := e.verifyAndPush(ctx, stacked, target)
+		pushed.BatchID = result.BatchID
+		return pushed
+	}
+
+	// Merge-train mode replaces steps 2-6 with parallel prefix gating
+	if batchCfg.MergeTrain {
+		e.recordProgress(StageGating, "merge train", result.BatchID, stacked...)
+		stopPrewarm := e.startPrewarm(ctx, batch, target, batchCfg)
+		passed, err := e.runMergeTrain(ctx, result.BatchID, stacked, batchCfg)
+		stopPrewarm()
+		if err != nil {
+			result.Error = fmt.Errorf("merge train: %w", err)
+			return result
+		}
+		return e.landMergeTrain(ctx, stacked, passed, target, result)
 	}
 
 	// Step 2: Run gates on the stack tip
 	_, _ = fmt.Fprintf(e.output, "[Batch] Running gates on stack tip (%d MRs)...\n", len(stacked))
-	gateResult := e.runBatchGates(ctx)
+	e.recordProgress(StageGating, "stack tip", result.BatchID, stacked...)
+	stopPrewarm, stopPipeline := func() {}, func(bool) {}
+	if batchCfg.PipelineNextBatch {
+		stopPipeline = e.startPipeline(ctx, result.BatchID, batch, target, batchCfg)
+	} else {
+		stopPrewarm = e.startPrewarm(ctx, batch, target, batchCfg)
+	}
+	gateResult := e.runBatchGates(ctx, stacked)
+	stopPrewarm()
+	stopPipeline(gateResult.Success)
+	result.SkippedGates = gateResult.SkippedGates
 
 	// Step 3: Happy path — all green
 	if gateResult.Success {
+		e.recordProgress(StageGatesPassed, "stack tip", result.BatchID, stacked...)
 		return e.fastForwardBatch(ctx, stacked, target, result)
 	}
+	e.recordProgress(StageGatesFailed, "stack tip", result.BatchID, stacked...)
+	if ctx.Err() != nil {
+		// The gates were cut short; ProcessBatch rolls the batch back.
+		return result
+	}
+	if e.holdForBrokenTarget(ctx, target, gateResult, stacked, result) {
+		return result
+	}
 
-	// Step 4: Retry if flaky test handling is enabled
-	if batchCfg.RetryBatchOnFlaky {
-		_, _ = fmt.Fprintln(e.output, "[Batch] Gates failed, retrying full batch (flaky test check)...")
+	// Step 4: Retry if the failure may be flaky or the gates timed out
+	if retries := e.flakyRetries(batchCfg, stacked, gateResult); retries > 0 {
+		for attempt := 1; attempt <= retries; attempt++ {
+			if gateResult.GateTimedOut {
+				_, _ = fmt.Fprintf(e.output, "[Batch] Gates timed out, retrying full batch (%d/%d)...\n", attempt, retries)
+			} else {
+				_, _ = fmt.Fprintf(e.output, "[Batch] Gates failed, retrying full batch (flaky test check %d/%d)...\n", attempt, retries)
+			}
+			if !waitRetry(ctx, batchCfg, attempt) {
+				result.Error = fmt.Errorf("retry: %w", ctx.Err())
+				return result
+			}
+			e.recordProgress(StageGating, "flaky retry", result.BatchID, stacked...)
 
-		// Rebuild the stack from scratch for a clean retry
-		if resetErr := e.resetAndRebuildStack(stacked, target); resetErr != nil {
-			result.Error = fmt.Errorf("rebuild for retry: %w", resetErr)
-			return result
-		}
+			// Rebuild the stack from scratch for a clean retry
+			if resetErr := e.resetAndRebuildStack(stacked, target); resetErr != nil {
+				result.Error = fmt.Errorf("rebuild for retry: %w", resetErr)
+				return result
+			}
 
-		retryResult := e.runBatchGates(ctx)
-		if retryResult.Success {
-			_, _ = fmt.Fprintln(e.output, "[Batch] Retry succeeded (was flaky)")
-			return e.fastForwardBatch(ctx, stacked, target, result)
+			gateResult = e.runBatchGates(ctx, stacked)
+			if gateResult.Success {
+				_, _ = fmt.Fprintln(e.output, "[Batch] Retry succeeded (was flaky)")
+				e.recordProgress(StageGatesPassed, "flaky retry", result.BatchID, stacked...)
+				return e.fastForwardBatch(ctx, stacked, target, result)
+			}
+			if e.flakyRetries(batchCfg, stacked, gateResult) == 0 {
+				// A deterministic gate failed this time.
+				break
+			}
 		}
 		_, _ = fmt.Fprintln(e.output, "[Batch] Retry also failed, proceeding to bisection")
 	}
 
 	// Step 5: Bisect to find the culprit
-	_, _ = fmt.Fprintf(e.output, "[Batch] Bisecting %d MRs to isolate failure...\n", len(stacked))
-	good, culprits := e.bisectBatch(ctx, stacked, target)
+	_, _ = fmt.Fprintf(e.output, "[Batch] Bisecting %d MRs to isolate failure (%s)...\n", len(stacked), batchCfg.bisectStrategy())
+	e.recordProgress(StageBisecting, "", result.BatchID, stacked...)
+	good, culprits := e.bisect(withBisecting(ctx), result.BatchID, stacked, target, batchCfg)
 
 	result.Culprits = culprits
 
@@ -273,7 +870,7 @@ func (e *Engineer) ProcessBatch(ctx context.Context, batch []*MRInfo, target str
 			return result
 		}
 		// Verify the good subset actually passes
-		verifyResult := e.runBatchGates(ctx)
+		verifyResult := e.runBatchGates(ctx, good)
 		if verifyResult.Success {
 			return e.fastForwardBatch(ctx, good, target, result)
 		}
@@ -288,32 +885,78 @@ func (e *Engineer) ProcessBatch(ctx context.Context, batch []*MRInfo, target str
 // processSingleMR handles the degenerate case of a batch with one MR.
 func (e *Engineer) processSingleMR(ctx context.Context, mr *MRInfo, target string) *BatchResult {
 	result := &BatchResult{}
-	processResult := e.doMerge(ctx, mr.Branch, target, mr.SourceIssue)
+	e.recordProgress(StageGating, "single MR", "", mr)
+	processResult := e.doMerge(ctx, mr, target, prevalidated(ctx, []*MRInfo{mr}))
 	if processResult.Success {
 		result.Merged = []*MRInfo{mr}
 		result.MergeCommit = processResult.MergeCommit
 	} else if processResult.Conflict {
 		result.Conflicts = []*MRInfo{mr}
+		e.requestConflictResolutions(result.Conflicts, target)
 	} else if processResult.TestsFailed {
-		result.Culprits = []*MRInfo{mr}
+		if !e.holdForBrokenTarget(ctx, target, processResult, []*MRInfo{mr}, result) {
+			result.Culprits = []*MRInfo{mr}
+		}
 	} else if processResult.BranchNotFound {
 		// Branch was cleaned up before we could process it (e.g. cherry-picked to target).
 		// Treat as a skip: log and move on rather than halting the queue.
 		_, _ = fmt.Fprintf(e.output, "[Batch] MR %s: branch %s not found, skipping\n", mr.ID, mr.Branch)
 		result.Conflicts = []*MRInfo{mr}
+	} else if processResult.Held {
+		result.Deferred = []*MRInfo{mr}
 	} else {
 		result.Error = fmt.Errorf("merge failed: %s", processResult.Error)
 	}
 	return result
 }
 
-// runBatchGates runs quality gates (or legacy tests) on the current working tree.
-func (e *Engineer) runBatchGates(ctx context.Context) ProcessResult {
-	if len(e.config.Gates) > 0 {
-		return e.runGates(ctx)
+// runBatchGates runs quality gates (or legacy tests) on the current working
+// tree, followed by the acceptance criteria of the stacked MRs' source issues.
+func (e *Engineer) runBatchGates(ctx context.Context, stacked []*MRInfo) ProcessResult {
+	return e.runBatchGatesIn(ctx, e.workDir, stacked)
+}
+
+// runBatchGatesIn runs runBatchGates against the tree in dir.
+func (e *Engineer) runBatchGatesIn(ctx context.Context, dir string, stacked []*MRInfo) ProcessResult {
+	if len(stacked) > 0 && ctx.Value(bisectingKey{}) != nil {
+		e.recordProgress(StageBisectStep, "gating "+strings.Join(mrIDs(stacked), " + "), stacked[0].batchID, stacked...)
+	}
+	ctx = withGateStack(ctx, stacked)
+	target := ""
+	if len(stacked) > 0 {
+		target = stacked[0].Target
+	}
+	ctx = e.withGateBaseline(ctx, target)
+	if len(stacked) > 0 {
+		ctx = withGateArtifacts(ctx, stacked[len(stacked)-1])
+	}
+	var gated ProcessResult
+	if gates := e.assetGates(stacked); gates != nil {
+		gated = e.runGateSetIn(ctx, dir, gates)
+	} else {
+		gated = e.runTargetGatesIn(ctx, dir, target)
+	}
+	if !gated.Success {
+		return gated
+	}
+	result := e.runAcceptanceIn(ctx, dir, stacked)
+	result.SkippedGates = gated.SkippedGates
+	return result
+}
+
+// runTargetGates runs the quality gates for target (see gatesFor), or the
+// legacy test command when no gates are configured.
+func (e *Engineer) runTargetGates(ctx context.Context, target string) ProcessResult {
+	return e.runTargetGatesIn(ctx, e.workDir, target)
+}
+
+// runTargetGatesIn runs runTargetGates against the tree in dir.
+func (e *Engineer) runTargetGatesIn(ctx context.Context, dir, target string) ProcessResult {
+	if gates := e.gatesFor(target); len(gates) > 0 {
+		return e.runGateSetIn(ctx, dir, gates)
 	}
 	if e.config.RunTests && e.config.TestCommand != "" {
-		result := e.runTests(ctx)
+		result := e.runTestsIn(ctx, dir)
 		if !result.Success {
 			return ProcessResult{
 				Success:     false,
@@ -321,9 +964,7 @@ func (e *Engineer) runBatchGates(ctx context.Context) ProcessResult {
 				Error:       result.Error,
 			}
 		}
-		return ProcessResult{Success: true}
 	}
-	// No gates configured — pass by default
 	return ProcessResult{Success: true}
 }
 
@@ -331,8 +972,12 @@ func (e *Engineer) runBatchGates(ctx context.Context) ProcessResult {
 func (e *Engineer) verifyAndPush(ctx context.Context, stacked []*MRInfo, target string) *BatchResult {
 	result := &BatchResult{}
 
-	gateResult := e.runBatchGates(ctx)
+	gateResult := e.runBatchGates(ctx, stacked)
+	result.SkippedGates = gateResult.SkippedGates
 	if !gateResult.Success {
+		if e.holdForBrokenTarget(ctx, target, gateResult, stacked, result) {
+			return result
+		}
 		if gateResult.TestsFailed {
 			result.Culprits = stacked
 		} else {
@@ -345,8 +990,18 @@ func (e *Engineer) verifyAndPush(ctx context.Context, stacked []*MRInfo, target
 }
 
 // fastForwardBatch pushes the current state to the target branch.
-// The working tree must already be on the target branch with all squash-merges applied.
+// The working tree must already be on the target branch with all MR merges applied.
 func (e *Engineer) fastForwardBatch(ctx context.Context, stacked []*MRInfo, target string, result *BatchResult) *BatchResult {
+	if ctx.Err() != nil {
+		// Cancelled after the gates passed: don't land a half-finished batch.
+		return result
+	}
+	if closed, opens := e.MergeWindowClosed(target, time.Now()); closed {
+		e.holdLanding(stacked, target, stacked[0].batchID, opens)
+		result.Deferred = append(result.Deferred, stacked...)
+		return result
+	}
+
 	// Get the tip SHA
 	tipSHA, err := e.git.Rev("HEAD")
 	if err != nil {
@@ -355,10 +1010,10 @@ func (e *Engineer) fastForwardBatch(ctx context.Context, stacked []*MRInfo, targ
 	}
 
 	// Acquire merge slot for default branch pushes
-	var pushHolder string
+	var slots *landingSlots
 	if target == e.rig.DefaultBranch() {
 		var slotErr error
-		pushHolder, slotErr = e.acquireMainPushSlot(ctx)
+		slots, slotErr = e.acquireLandingSlots(ctx, target)
 		if slotErr != nil {
 			if resetErr := e.git.ResetHard("origin/" + target); resetErr != nil {
 				_, _ = fmt.Fprintf(e.output, "[Batch] Warning: failed to reset %s after slot failure: %v\n", target, resetErr)
@@ -366,30 +1021,46 @@ func (e *Engineer) fastForwardBatch(ctx context.Context, stacked []*MRInfo, targ
 			result.Error = fmt.Errorf("acquire merge slot: %w", slotErr)
 			return result
 		}
-		defer func() {
-			if pushHolder != "" {
-				if releaseErr := e.mergeSlotRelease(pushHolder); releaseErr != nil {
-					_, _ = fmt.Fprintf(e.output, "[Batch] Warning: failed to release merge slot: %v\n", releaseErr)
-				}
-			}
-		}()
+		defer slots.release()
+	}
+
+	// Journal the landing before it happens, so a crash mid-push is
+	// recovered by checking origin for the tip (see resumeBatches).
+	if err := e.journalStack(BatchGated, stacked, target); err != nil {
+		if resetErr := e.git.ResetHard("origin/" + target); resetErr != nil {
+			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: failed to reset %s after journal failure: %v\n", target, resetErr)
+		}
+		result.Error = err
+		return result
+	}
+
+	// Gerrit owns its branches: submit the changes instead of pushing.
+	if hasGerritChanges(stacked) {
+		return e.submitToGerrit(ctx, stacked, target, result)
 	}
 
 	// Push to origin
 	_, _ = fmt.Fprintf(e.output, "[Batch] Pushing %d merged MRs to origin/%s...\n", len(stacked), target)
-	if pushErr := e.git.Push("origin", target, false); pushErr != nil {
+	if pushErr := e.pushLanding(target, slots); pushErr != nil {
 		if resetErr := e.git.ResetHard("origin/" + target); resetErr != nil {
 			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: failed to reset %s after push failure: %v\n", target, resetErr)
 		}
 		result.Error = fmt.Errorf("push to origin: %w", pushErr)
 		return result
 	}
+	if sha, err := e.git.Rev("HEAD"); err == nil {
+		tipSHA = sha // Rebased when pushLanding caught up with other partitions
+	}
+	e.warnJournal(e.journalStack(BatchPushed, stacked, target))
 
 	ids := make([]string, len(stacked))
 	for i, mr := range stacked {
 		ids[i] = mr.ID
 	}
 	_, _ = fmt.Fprintf(e.output, "[Batch] Successfully merged batch: %s (commit %s)\n", strings.Join(ids, ", "), tipSHA[:8])
+	e.recordProgress(StagePushed, fmt.Sprintf("origin/%s at %s", target, shortSHA(tipSHA)), stacked[0].batchID, stacked...)
+
+	e.markAcceptanceVerified(stacked)
 
 	result.Merged = stacked
 	result.MergeCommit = tipSHA
@@ -417,7 +1088,7 @@ func (e *Engineer) bisectBatch(ctx context.Context, batch []*MRInfo, target stri
 		return nil, batch
 	}
 
-	leftResult := e.runBatchGates(ctx)
+	leftResult := e.runBatchGates(ctx, left)
 
 	if leftResult.Success {
 		// Left half is green — culprit is in right half
@@ -441,7 +1112,7 @@ func (e *Engineer) bisectBatch(ctx context.Context, batch []*MRInfo, target stri
 			_, _ = fmt.Fprintf(e.output, "[Bisect] Error testing right with good left: %v\n", resetErr)
 			return leftGood, append(leftCulprits, right...)
 		}
-		combinedResult := e.runBatchGates(ctx)
+		combinedResult := e.runBatchGates(ctx, combined)
 		if combinedResult.Success {
 			return append(leftGood, right...), leftCulprits
 		}
@@ -454,7 +1125,7 @@ func (e *Engineer) bisectBatch(ctx context.Context, batch []*MRInfo, target stri
 	if resetErr := e.resetAndRebuildStack(right, target); resetErr != nil {
 		return nil, batch
 	}
-	rightResult := e.runBatchGates(ctx)
+	rightResult := e.runBatchGates(ctx, right)
 	if rightResult.Success {
 		return right, leftCulprits
 	}
@@ -481,7 +1152,7 @@ func (e *Engineer) bisectRight(ctx context.Context, knownGood []*MRInfo, right [
 		return nil, right
 	}
 
-	result := e.runBatchGates(ctx)
+	result := e.runBatchGates(ctx, testBatch)
 	if result.Success {
 		// rLeft is fine in context of knownGood — culprit is in rRight
 		_, _ = fmt.Fprintf(e.output, "[Bisect-R] knownGood+rLeft passed → culprit in rRight=%v\n", mrIDs(rRight))
@@ -501,7 +1172,7 @@ func (e *Engineer) bisectRight(ctx context.Context, knownGood []*MRInfo, right [
 	if resetErr := e.resetAndRebuildStack(testBatch2, target); resetErr != nil {
 		return rLeftGood, append(rLeftCulprits, rRight...)
 	}
-	result2 := e.runBatchGates(ctx)
+	result2 := e.runBatchGates(ctx, testBatch2)
 	if result2.Success {
 		_, _ = fmt.Fprintf(e.output, "[Bisect-R] rRight passed → good=%v, culprits=%v\n", mrIDs(append(rLeftGood, rRight...)), mrIDs(rLeftCulprits))
 		return append(rLeftGood, rRight...), rLeftCulprits
@@ -519,7 +1190,7 @@ func mrIDs(mrs []*MRInfo) []string {
 	return ids
 }
 
-// resetAndRebuildStack resets the target branch and rebuilds the squash-merge stack.
+// resetAndRebuildStack resets the target branch and rebuilds the merge stack.
 func (e *Engineer) resetAndRebuildStack(mrs []*MRInfo, target string) error {
 	// Reset target to origin
 	if err := e.git.Checkout(target); err != nil {
@@ -531,9 +1202,8 @@ func (e *Engineer) resetAndRebuildStack(mrs []*MRInfo, target string) error {
 
 	// Rebuild the stack
 	for _, mr := range mrs {
-		msg := e.getMergeMessage(mr)
-		if err := e.git.MergeSquash(mr.Branch, msg); err != nil {
-			return fmt.Errorf("squash merge %s: %w", mr.ID, err)
+		if err := e.mergeMR(mr); err != nil {
+			return fmt.Errorf("merge %s: %w", mr.ID, err)
 		}
 	}
 	return nil
@@ -0,0 +1,341 @@
+package refinery
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Batch journal states, in the order a batch passes through them. A batch
+// may finish from any state; it is pushed only after it was gated.
+const (
+	BatchAssembled = "assembled" // MRs picked and the batch ID assigned; nothing touched yet
+	BatchStacked   = "stacked"   // Stack built on the local target branch
+	BatchGated     = "gated"     // Gates passed on Tip, about to push it
+	BatchPushed    = "pushed"    // Landed on origin at Tip
+	BatchFinished  = "finished"  // Result handed back, or the batch recovered
+)
+
+// batchJournalKeep is how many landed batches the journal remembers after
+// they finish, to recognize their MRs should the caller have crashed
+// before closing them.
+const batchJournalKeep = 100
+
+// BatchJournalEntry is one line of the batch journal: a state a batch
+// reached. Each entry is synced to disk before the refinery goes on, so
+// after a crash the journal tells how far an interrupted batch got (see
+// resumeBatches).
+//
+// This is not the rollback journal (see recordJournal), which keeps the
+// pre-batch values of the target refs for undoing a landing by hand.
+type BatchJournalEntry struct {
+	Batch  string    `json:"batch"`
+	Target string    `json:"target"`
+	State  string    `json:"state"`
+	MRs    []string  `json:"mrs,omitempty"`    // Assembled: the batch; stacked, gated, pushed: the MRs in the stack
+	Base   string    `json:"base,omitempty"`   // Assembled: origin/<target> when the batch started
+	Tip    string    `json:"tip,omitempty"`    // Stacked, gated, pushed: the stack's tip commit
+	Detail string    `json:"detail,omitempty"` // Finished: the outcome
+	Time   time.Time `json:"time"`
+}
+
+// BatchJournalPath returns the batch journal for the rig at rigPath.
+func BatchJournalPath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "batch-journal.jsonl")
+}
+
+// journaledBatch is a batch as replayed from the journal.
+type journaledBatch struct {
+	ID, Target string
+	State      string   // Last state reached before finishing
+	MRs        []string // As assembled
+	Stack      []string // MRs of the last stack journaled
+	Base, Tip  string
+	Finished   bool
+}
+
+// replayBatchJournal folds entries into their batches, in the order the
+// batches started.
+func replayBatchJournal(entries []BatchJournalEntry) []*journaledBatch {
+	byID := make(map[string]*journaledBatch)
+	var batches []*journaledBatch
+	for _, entry := range entries {
+		b := byID[entry.Batch]
+		if b == nil {
+			b = &journaledBatch{ID: entry.Batch, Target: entry.Target}
+			byID[entry.Batch] = b
+			batches = append(batches, b)
+		}
+		switch entry.State {
+		case BatchAssembled:
+			b.MRs, b.Base = entry.MRs, entry.Base
+		case BatchStacked, BatchGated, BatchPushed:
+			b.Stack, b.Tip = entry.MRs, entry.Tip
+		case BatchFinished:
+			b.Finished = true
+			continue
+		}
+		b.State = entry.State
+	}
+	return batches
+}
+
+// readBatchJournal returns the entries of the batch journal at path. A
+// partially written last line, left by a crash mid-write, is skipped.
+func readBatchJournal(path string) ([]BatchJournalEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []BatchJournalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry BatchJournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Batch == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// appendBatchJournal appends entry to the batch journal at path and syncs
+// it to disk.
+func appendBatchJournal(path string, entry *BatchJournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// journalBatch appends entry to the rig's batch journal. Appends are
+// serialized with compaction (see compactBatchJournal).
+func (e *Engineer) journalBatch(entry BatchJournalEntry) error {
+	if e.rig == nil {
+		return nil
+	}
+	entry.Time = time.Now().UTC()
+	defer e.lockState()()
+	if err := appendBatchJournal(BatchJournalPath(e.rig.Path), &entry); err != nil {
+		return fmt.Errorf("batch journal: %w", err)
+	}
+	return nil
+}
+
+// journalStack journals that the stack mrs, tipped by HEAD, reached state.
+// MRs processed outside a batch (see ProcessMRInfo) aren't journaled.
+func (e *Engineer) journalStack(state string, mrs []*MRInfo, target string) error {
+	if len(mrs) == 0 || mrs[0].batchID == "" {
+		return nil
+	}
+	tip, err := e.git.Rev("HEAD")
+	if err != nil {
+		return fmt.Errorf("batch journal: %w", err)
+	}
+	return e.journalBatch(BatchJournalEntry{Batch: mrs[0].batchID, Target: target, State: state, MRs: mrIDs(mrs), Tip: tip})
+}
+
+// warnJournal logs a failure to journal a state the batch goes on without.
+func (e *Engineer) warnJournal(err error) {
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Batch] Warning: %v\n", err)
+	}
+}
+
+// finishBatchJournal journals that result's batch finished and compacts
+// the journal.
+func (e *Engineer) finishBatchJournal(result *BatchResult, target, detail string) {
+	if e.rig == nil || result.BatchID == "" {
+		return
+	}
+	e.warnJournal(e.journalBatch(BatchJournalEntry{Batch: result.BatchID, Target: target, State: BatchFinished, Tip: result.MergeCommit, Detail: detail}))
+	e.compactBatchJournal()
+}
+
+// compactBatchJournal drops finished batches from the journal, keeping the
+// pushed and finished entries of the last batchJournalKeep that landed.
+func (e *Engineer) compactBatchJournal() {
+	defer e.lockState()()
+	path := BatchJournalPath(e.rig.Path)
+	entries, err := readBatchJournal(path)
+	if err != nil {
+		e.warnJournal(fmt.Errorf("batch journal: %w", err))
+		return
+	}
+	batches := replayBatchJournal(entries)
+	byID := make(map[string]*journaledBatch, len(batches))
+	landed := 0
+	for i := len(batches) - 1; i >= 0; i-- {
+		b := batches[i]
+		if !b.Finished || b.State == BatchPushed && landed < batchJournalKeep {
+			byID[b.ID] = b
+		}
+		if b.Finished && b.State == BatchPushed {
+			landed++
+		}
+	}
+	var buf bytes.Buffer
+	kept := 0
+	for _, entry := range entries {
+		b := byID[entry.Batch]
+		if b == nil || b.Finished && entry.State != BatchPushed && entry.State != BatchFinished {
+			continue
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		buf.Write(append(data, '\n'))
+		kept++
+	}
+	if kept == len(entries) {
+		return
+	}
+	if err := util.AtomicWriteFile(path, buf.Bytes(), 0644); err != nil {
+		e.warnJournal(fmt.Errorf("batch journal: %w", err))
+	}
+}
+
+// resumeBatches recovers the batches on target that a crashed process left
+// unfinished, then settles the MRs of batch the journal shows landed: they
+// are returned as merged, at the commit they landed in, so they are closed
+// rather than merged again. The rest of batch is deferred to the next call.
+// It returns nil when none of batch has landed. The caller holds target's
+// batch lock (see ProcessBatch), so no other batch on target is running.
+func (e *Engineer) resumeBatches(target string, batch []*MRInfo) *BatchResult {
+	if e.rig == nil {
+		return nil
+	}
+	entries, err := readBatchJournal(BatchJournalPath(e.rig.Path))
+	if err != nil {
+		e.warnJournal(fmt.Errorf("batch journal: %w", err))
+		return nil
+	}
+	landedAt := make(map[string]string)
+	for _, b := range replayBatchJournal(entries) {
+		if b.Target != target {
+			continue
+		}
+		if !b.Finished && e.recoverBatch(b) {
+			b.State = BatchPushed
+		}
+		if b.State == BatchPushed {
+			for _, id := range b.Stack {
+				landedAt[id] = b.Tip
+			}
+		}
+	}
+
+	result := &BatchResult{}
+	for _, mr := range batch {
+		if tip, ok := landedAt[mr.ID]; ok {
+			result.Merged = append(result.Merged, mr)
+			result.MergeCommit = tip
+		} else {
+			result.Deferred = append(result.Deferred, mr)
+		}
+	}
+	if len(result.Merged) == 0 {
+		return nil
+	}
+	_, _ = fmt.Fprintf(e.output, "[Batch] %s already landed on %s at %s; not merging again\n",
+		strings.Join(mrIDs(result.Merged), ", "), target, shortSHA(result.MergeCommit))
+	e.recordProgress(StageMerged, "landed before a crash at "+shortSHA(result.MergeCommit), "", result.Merged...)
+	return result
+}
+
+// recoverBatch finishes b, a batch interrupted by a crash, and reports
+// whether it landed. A batch that was gated landed if its tip, or an
+// equivalent rebased series, is on origin. Either way the local target
+// branch is reset to origin, so no stacked commit is left to leak into the
+// next batch, and the bisection worktrees b left are removed. The MRs of a
+// batch that didn't land are still queued and are batched again.
+func (e *Engineer) recoverBatch(b *journaledBatch) bool {
+	_, _ = fmt.Fprintf(e.output, "[Batch] Recovering %s on %s, interrupted after %s\n", b.ID, b.Target, b.State)
+	_ = e.git.AbortMerge()
+	_ = e.git.AbortRebase()
+	_ = e.git.AbortCherryPick()
+	if err := e.git.FetchBranch("origin", b.Target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Batch] Warning: fetching %s: %v\n", b.Target, err)
+	}
+
+	landed := b.State == BatchPushed || b.State == BatchGated && e.landedOn(b.Target, b.Base, b.Tip)
+	if landed && b.State == BatchGated {
+		e.warnJournal(e.journalBatch(BatchJournalEntry{Batch: b.ID, Target: b.Target, State: BatchPushed, MRs: b.Stack, Tip: b.Tip}))
+	}
+	e.resetLocalTarget(b.Target)
+	dir := filepath.Join(e.rig.Path, ".runtime", "bisect", b.ID)
+	if _, err := os.Stat(dir); err == nil {
+		_ = os.RemoveAll(dir)
+		_ = e.git.WorktreePrune()
+	}
+
+	detail := "rolled back; MRs stay queued"
+	if landed {
+		detail = "landed at " + shortSHA(b.Tip)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Batch] Recovered %s: %s\n", b.ID, detail)
+	e.finishBatchJournal(&BatchResult{BatchID: b.ID}, b.Target, "recovered: "+detail)
+	return landed
+}
+
+// landedOn reports whether the stack from base to tip is on origin/target,
+// as pushed or rebased onto commits that landed meanwhile.
+func (e *Engineer) landedOn(target, base, tip string) bool {
+	if tip == "" {
+		return false
+	}
+	if ok, err := e.git.IsAncestor(tip, "origin/"+target); err == nil && ok {
+		return true
+	}
+	if base == "" {
+		return false
+	}
+	unlanded, err := e.git.Unlanded("origin/"+target, tip, base)
+	return err == nil && len(unlanded) == 0
+}
+
+// resetLocalTarget points the local target branch back at origin/target,
+// resetting the tree when it is checked out here.
+func (e *Engineer) resetLocalTarget(target string) {
+	var err error
+	if branch, _ := e.git.CurrentBranch(); branch == target {
+		err = e.git.ResetHard("origin/" + target)
+	} else if exists, _ := e.git.RefExists("refs/heads/" + target); exists {
+		var sha string
+		if sha, err = e.git.Rev("origin/" + target); err == nil {
+			err = e.git.UpdateRef("refs/heads/"+target, sha)
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Batch] Warning: resetting %s to origin: %v\n", target, err)
+	}
+}
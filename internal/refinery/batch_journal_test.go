@@ -0,0 +1,133 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestProcessBatch_JournalsLanding(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	mrs := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), mrs, "main", DefaultBatchConfig())
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("ProcessBatch: merged %v, %v", stackedIDs(result.Merged), result.Error)
+	}
+
+	// Compaction leaves a landed batch's pushed and finished entries.
+	entries, err := readBatchJournal(BatchJournalPath(workDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var states []string
+	for _, entry := range entries {
+		if entry.Batch != result.BatchID {
+			t.Errorf("entry for batch %s, want %s", entry.Batch, result.BatchID)
+		}
+		states = append(states, entry.State)
+	}
+	if got := strings.Join(states, " "); got != "pushed finished" {
+		t.Errorf("journaled states = %q, want \"pushed finished\"", got)
+	}
+	if entries[0].Tip != result.MergeCommit || strings.Join(entries[0].MRs, " ") != "mr-a mr-b" {
+		t.Errorf("pushed entry = %+v, want mr-a mr-b at %s", entries[0], result.MergeCommit)
+	}
+}
+
+func TestProcessBatch_RecoversUnpushedBatch(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+	base := run(t, workDir, "git", "rev-parse", "origin/main")
+	// A crash left a stack commit on the local target branch.
+	writeFile(t, workDir, "stranded.txt", "stack\n")
+	run(t, workDir, "git", "add", "stranded.txt")
+	run(t, workDir, "git", "commit", "-m", "stacked before the crash")
+	tip := run(t, workDir, "git", "rev-parse", "HEAD")
+
+	path := BatchJournalPath(workDir)
+	for _, entry := range []BatchJournalEntry{
+		{Batch: "batch-crashed", Target: "main", State: BatchAssembled, MRs: []string{"mr-a", "mr-b"}, Base: base},
+		{Batch: "batch-crashed", Target: "main", State: BatchStacked, MRs: []string{"mr-a"}, Tip: tip},
+	} {
+		if err := appendBatchJournal(path, &entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := newTestEngineer(t, workDir, g)
+	mrs := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), mrs, "main", DefaultBatchConfig())
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("ProcessBatch: merged %v, %v", stackedIDs(result.Merged), result.Error)
+	}
+	run(t, workDir, "git", "fetch", "origin")
+	files := run(t, workDir, "git", "ls-tree", "--name-only", "origin/main")
+	if strings.Contains(files, "stranded.txt") || !strings.Contains(files, "b.txt") {
+		t.Errorf("origin/main files = %q, want the batch without the stranded commit", files)
+	}
+
+	entries, err := readBatchJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Batch == "batch-crashed" {
+			t.Errorf("recovered batch left in the journal: %+v", entry)
+		}
+	}
+}
+
+func TestProcessBatch_ResumesLandedBatch(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+	base := run(t, workDir, "git", "rev-parse", "origin/main")
+	// A crash came after mr-a was pushed but before the batch finished.
+	run(t, workDir, "git", "merge", "--squash", "feature-a")
+	run(t, workDir, "git", "commit", "-m", "mr-a")
+	tip := run(t, workDir, "git", "rev-parse", "HEAD")
+	run(t, workDir, "git", "push", "origin", "main")
+
+	path := BatchJournalPath(workDir)
+	for _, entry := range []BatchJournalEntry{
+		{Batch: "batch-crashed", Target: "main", State: BatchAssembled, MRs: []string{"mr-a"}, Base: base},
+		{Batch: "batch-crashed", Target: "main", State: BatchGated, MRs: []string{"mr-a"}, Tip: tip},
+	} {
+		if err := appendBatchJournal(path, &entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := newTestEngineer(t, workDir, g)
+	mrs := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), mrs, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if got := stackedIDs(result.Merged); len(got) != 1 || got[0] != "mr-a" || result.MergeCommit != tip {
+		t.Errorf("merged = %v at %s, want [mr-a] at %s", got, result.MergeCommit, tip)
+	}
+	if got := stackedIDs(result.Deferred); len(got) != 1 || got[0] != "mr-b" {
+		t.Errorf("deferred = %v, want [mr-b]", got)
+	}
+	if got := run(t, workDir, "git", "rev-parse", "origin/main"); got != tip {
+		t.Errorf("origin/main moved to %s; mr-a landed twice?", got)
+	}
+
+	// The next batch lands mr-b alone.
+	result = e.ProcessBatch(context.Background(), mrs[1:], "main", DefaultBatchConfig())
+	if result.Error != nil || len(result.Merged) != 1 {
+		t.Fatalf("next batch: merged %v, %v", stackedIDs(result.Merged), result.Error)
+	}
+	if n := run(t, workDir, "git", "rev-list", "--count", base+"..origin/main"); n != "2" {
+		t.Errorf("%s commits landed, want 2", n)
+	}
+}
@@ -3,6 +3,7 @@ package refinery
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -93,7 +94,7 @@ func newTestEngineer(t *testing.T, workDir string, g *gitpkg.Git) *Engineer {
 	e := NewEngineer(r)
 	e.git = g
 	e.workDir = workDir
-	e.output = &bytes.Buffer{}
+	e.SetOutput(&bytes.Buffer{})
 	// No-op merge slot functions for tests
 	e.mergeSlotEnsureExists = func() (string, error) { return "test-slot", nil }
 	e.mergeSlotAcquire = func(holder string, addWaiter bool) (*beads.MergeSlotStatus, error) {
@@ -209,6 +210,153 @@ func TestAssembleBatch_IncludesBlockedByBatchMember(t *testing.T) {
 	}
 }
 
+func TestAssembleBatch_StacksBlockerFirst(t *testing.T) {
+	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
+	e := NewEngineer(r)
+
+	// mr-2 scores higher than its blocker, and mr-1's blocker mr-0 too.
+	mrs := []*MRInfo{
+		{ID: "mr-2", Branch: "branch-2", Target: "main", BlockedBy: "mr-1"},
+		makeMR("mr-3", "branch-3", "main"),
+		{ID: "mr-1", Branch: "branch-1", Target: "main", BlockedBy: "mr-0"},
+		makeMR("mr-0", "branch-0", "main"),
+	}
+
+	batch := e.AssembleBatch(mrs, &BatchConfig{MaxBatchSize: 5})
+	got := stackedIDs(batch)
+	want := []string{"mr-0", "mr-1", "mr-2", "mr-3"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestAssembleBatch_BlockerChainMustFit(t *testing.T) {
+	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
+	e := NewEngineer(r)
+
+	mrs := []*MRInfo{
+		makeMR("mr-1", "branch-1", "main"),
+		{ID: "mr-3", Branch: "branch-3", Target: "main", BlockedBy: "mr-2"},
+		makeMR("mr-2", "branch-2", "main"),
+	}
+
+	// mr-3 and its blocker need two slots but only one is left, so mr-2
+	// takes it alone.
+	batch := e.AssembleBatch(mrs, &BatchConfig{MaxBatchSize: 2})
+	if got := stackedIDs(batch); len(got) != 2 || got[0] != "mr-1" || got[1] != "mr-2" {
+		t.Errorf("expected [mr-1 mr-2], got %v", got)
+	}
+}
+
+func TestAssembleBatch_SkipsDependencyCycle(t *testing.T) {
+	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
+	e := NewEngineer(r)
+	var out bytes.Buffer
+	e.SetOutput(&out)
+	comments := make(map[string][]string)
+	e.commentIssue = func(id, text string) error {
+		comments[id] = append(comments[id], text)
+		return nil
+	}
+
+	mrs := []*MRInfo{
+		{ID: "mr-1", Branch: "branch-1", Target: "main", BlockedBy: "mr-2"},
+		{ID: "mr-2", Branch: "branch-2", Target: "main", BlockedBy: "mr-1"},
+		makeMR("mr-3", "branch-3", "main"),
+	}
+
+	for i := 0; i < 2; i++ {
+		batch := e.AssembleBatch(mrs, &BatchConfig{MaxBatchSize: 5})
+		if got := stackedIDs(batch); len(got) != 1 || got[0] != "mr-3" {
+			t.Errorf("expected only mr-3, got %v", got)
+		}
+	}
+
+	// The cycle is reported, and each member's bead told about it once.
+	if !strings.Contains(out.String(), "MR mr-1 can't be batched: dependency cycle: mr-1 → mr-2 → mr-1") {
+		t.Errorf("cycle not reported:\n%s", out.String())
+	}
+	for _, id := range []string{"mr-1", "mr-2"} {
+		if len(comments[id]) != 1 || !strings.Contains(comments[id][0], "dependency cycle") {
+			t.Errorf("comments on %s = %q, want one about the cycle", id, comments[id])
+		}
+	}
+	if len(comments["mr-3"]) != 0 {
+		t.Errorf("mr-3 commented on: %q", comments["mr-3"])
+	}
+}
+
+func TestOrderByDependencies(t *testing.T) {
+	batch := []*MRInfo{
+		{ID: "mr-b", BlockedBy: "mr-a"},
+		{ID: "mr-c", BlockedBy: "mr-99"}, // blocker outside the batch
+		{ID: "mr-a"},
+	}
+	ordered, err := orderByDependencies(batch)
+	if err != nil {
+		t.Fatalf("orderByDependencies: %v", err)
+	}
+	if got := strings.Join(stackedIDs(ordered), ","); got != "mr-a,mr-b,mr-c" {
+		t.Errorf("ordered = %s, want mr-a,mr-b,mr-c", got)
+	}
+
+	cyclic := []*MRInfo{{ID: "mr-a", BlockedBy: "mr-b"}, {ID: "mr-b", BlockedBy: "mr-a"}}
+	_, err = orderByDependencies(cyclic)
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "mr-a → mr-b → mr-a") {
+		t.Errorf("error should name the cycle, got %q", err)
+	}
+}
+
+func TestProcessBatch_RejectsDependencyCycle(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	a := makeMR("mr-a", "feature-a", "main")
+	b := makeMR("mr-b", "feature-b", "main")
+	a.BlockedBy, b.BlockedBy = "mr-b", "mr-a"
+
+	result := e.ProcessBatch(context.Background(), []*MRInfo{a, b}, "main", DefaultBatchConfig())
+	if !errors.Is(result.Error, ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got %v", result.Error)
+	}
+	if len(result.Merged) != 0 {
+		t.Errorf("nothing should merge, got %v", stackedIDs(result.Merged))
+	}
+	// The rejected batch is still recorded with its members.
+	recs, err := e.History(HistoryQuery{})
+	if err != nil || len(recs) != 1 || len(recs[0].Members) != 2 {
+		t.Errorf("History() = %+v, %v; want the rejected batch of 2", recs, err)
+	}
+}
+
+func TestProcessBatch_StacksDependentAfterBlocker(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	a := makeMR("mr-a", "feature-a", "main")
+	b := makeMR("mr-b", "feature-b", "main")
+	b.BlockedBy = "mr-a"
+
+	result := e.ProcessBatch(context.Background(), []*MRInfo{b, a}, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if got := stackedIDs(result.Merged); len(got) != 2 || got[0] != "mr-a" || got[1] != "mr-b" {
+		t.Errorf("merged = %v, want [mr-a mr-b]", got)
+	}
+}
+
 func TestAssembleBatch_NilConfig(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
@@ -223,6 +371,59 @@ func TestAssembleBatch_NilConfig(t *testing.T) {
 	}
 }
 
+func qosMR(id, class string) *MRInfo {
+	mr := makeMR(id, "branch-"+id, "main")
+	mr.QoS = class
+	return mr
+}
+
+func TestAssembleBatch_QoSReserveAdmitsEachClass(t *testing.T) {
+	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
+	e := NewEngineer(r)
+
+	// A long run of background refactors ahead of an interactive fix and
+	// ordinary work must not fill the batch on its own.
+	var mrs []*MRInfo
+	for i := 0; i < 6; i++ {
+		mrs = append(mrs, qosMR(fmt.Sprintf("bg-%d", i), QoSBackground))
+	}
+	mrs = append(mrs, qosMR("fix", QoSInteractive), qosMR("feat", ""))
+
+	batch := e.AssembleBatch(mrs, &BatchConfig{
+		MaxBatchSize: 4,
+		QoSReserve:   map[string]float64{QoSInteractive: 0.25, QoSBatch: 0.25},
+	})
+	got := stackedIDs(batch)
+	want := []string{"fix", "feat", "bg-0", "bg-1"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("batch = %v, want %v", got, want)
+	}
+}
+
+func TestAssembleBatch_QoSReserveUnusedSlotsGoToQueue(t *testing.T) {
+	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
+	e := NewEngineer(r)
+
+	mrs := []*MRInfo{qosMR("a", QoSInteractive), qosMR("b", QoSInteractive), qosMR("c", QoSInteractive)}
+	batch := e.AssembleBatch(mrs, &BatchConfig{MaxBatchSize: 3, QoSReserve: DefaultQoSReserve()})
+	if len(batch) != 3 {
+		t.Errorf("expected unused background/batch reservations to be filled, got %v", stackedIDs(batch))
+	}
+}
+
+func TestQoSSlots(t *testing.T) {
+	slots := qosSlots(map[string]float64{QoSInteractive: 0.5, QoSBatch: 0.5, QoSBackground: 0.1}, 2)
+	if slots[QoSInteractive] != 1 || slots[QoSBatch] != 1 || slots[QoSBackground] != 0 {
+		t.Errorf("slots = %v, want interactive=1 batch=1 background=0 (capped at batch size)", slots)
+	}
+	if got := normalizeQoS(" Interactive "); got != QoSInteractive {
+		t.Errorf("normalizeQoS = %q, want %q", got, QoSInteractive)
+	}
+	if got := normalizeQoS("urgent"); got != QoSBatch {
+		t.Errorf("normalizeQoS(unknown) = %q, want %q", got, QoSBatch)
+	}
+}
+
 // --- BuildRebaseStack tests (require real git) ---
 
 func TestBuildRebaseStack_SingleMR(t *testing.T) {
@@ -365,7 +566,7 @@ func TestBuildRebaseStack_MissingBranch(t *testing.T) {
 func TestProcessBatch_EmptyBatch(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
-	e.output = &bytes.Buffer{}
+	e.SetOutput(&bytes.Buffer{})
 
 	result := e.ProcessBatch(context.Background(), nil, "main", DefaultBatchConfig())
 	if result.Error != nil {
@@ -566,6 +767,43 @@ func TestProcessBatch_RetryOnFlaky_MultipleMRs(t *testing.T) {
 	}
 }
 
+func TestProcessBatch_RetriesTimedOutGatesWithoutFlakyRetry(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+
+	e := newTestEngineer(t, workDir, g)
+
+	// Hangs the first time, passes the second
+	marker := filepath.Join(t.TempDir(), "hung")
+	e.config.Gates = map[string]*GateConfig{
+		"hangs": {
+			Cmd:     fmt.Sprintf(`test -f %s && exit 0; touch %s; sleep 10`, marker, marker),
+			Timeout: 300 * time.Millisecond,
+		},
+	}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+	}
+	cfg := &BatchConfig{MaxBatchSize: 5, RetryBatchOnFlaky: false}
+
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if len(result.Merged) != 2 || len(result.Culprits) != 0 {
+		t.Errorf("merged %v culprits %v, want both merged after timeout retry",
+			stackedIDs(result.Merged), stackedIDs(result.Culprits))
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "Gates timed out, retrying") {
+		t.Errorf("expected a timeout retry, output:\n%s", out)
+	}
+}
+
 func TestProcessBatch_AllConflict(t *testing.T) {
 	workDir, g, cleanup := testGitRepo(t)
 	defer cleanup()
@@ -683,7 +921,7 @@ func TestBisectBatch_FourMRs_ThirdBad(t *testing.T) {
 	createFeatureBranch(t, workDir, "feature-d", "d.txt", "hello d\n")
 
 	e := newTestEngineer(t, workDir, g)
-	e.output = os.Stderr
+	e.SetOutput(os.Stderr)
 	e.config.Gates = map[string]*GateConfig{
 		"check": {Cmd: failMarkerGateCmd()},
 	}
@@ -813,7 +1051,7 @@ func TestGetMergeMessage_FromBranch(t *testing.T) {
 func TestGetMergeMessage_Fallback(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
-	e.output = &bytes.Buffer{}
+	e.SetOutput(&bytes.Buffer{})
 
 	mr := &MRInfo{
 		ID:          "mr-x",
@@ -856,7 +1094,7 @@ func TestProcessBatch_SingleMR_BranchNotFound(t *testing.T) {
 		t.Errorf("expected mr-gone in conflicts (skipped), got %v", stackedIDs(result.Conflicts))
 	}
 	// Verify the log message says "skipping" not "fatal".
-	log := e.output.(*bytes.Buffer).String()
+	log := testOutput(e).String()
 	if !strings.Contains(log, "skipping") {
 		t.Errorf("expected 'skipping' in log output, got: %s", log)
 	}
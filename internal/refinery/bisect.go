@@ -0,0 +1,297 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// Bisection strategies for BatchConfig.BisectStrategy.
+const (
+	// BisectLinear gates the stack one MR at a time, each on top of the MRs
+	// found good so far. n gate runs, but it blames exactly the MRs that
+	// break the stack, interactions included.
+	BisectLinear = "linear"
+
+	// BisectBinary halves the stack recursively (see bisectBatch). The
+	// default: O(log n) gate runs per culprit, one at a time.
+	BisectBinary = "binary"
+
+	// BisectParallelGroup gates disjoint groups of the stack concurrently,
+	// each on the target in its own worktree, and splits only the groups
+	// that fail (see bisectParallelGroup). O(log n) rounds of wall time.
+	BisectParallelGroup = "parallel-group"
+)
+
+// bisectStrategy returns the configured strategy, defaulting to binary.
+func (c *BatchConfig) bisectStrategy() string {
+	switch c.BisectStrategy {
+	case BisectLinear, BisectParallelGroup:
+		return c.BisectStrategy
+	}
+	return BisectBinary
+}
+
+// bisect isolates the MRs in a failed stack that break the gates, using
+// the batch's bisection strategy.
+func (e *Engineer) bisect(ctx context.Context, batchID string, stacked []*MRInfo, target string, batchCfg *BatchConfig) (good []*MRInfo, culprits []*MRInfo) {
+	strategy := batchCfg.bisectStrategy()
+	e.metrics.add("gastown_refinery_bisections_total", e.metricLabels(target, "strategy", strategy), 1)
+	defer func(started time.Time) {
+		e.metrics.observe("gastown_refinery_bisection_duration_seconds", e.metricLabels(target), time.Since(started).Seconds())
+	}(time.Now())
+	switch strategy {
+	case BisectLinear:
+		return e.bisectLinear(ctx, stacked, target)
+	case BisectParallelGroup:
+		return e.bisectParallelGroup(ctx, batchID, stacked, target)
+	}
+	return e.bisectBatch(ctx, stacked, target)
+}
+
+// bisectLinear gates the MRs of batch one at a time, each stacked on the
+// MRs that passed before it. An MR whose addition fails the gates is a
+// culprit and is left out of the stack for the MRs after it.
+func (e *Engineer) bisectLinear(ctx context.Context, batch []*MRInfo, target string) (good []*MRInfo, culprits []*MRInfo) {
+	for i, mr := range batch {
+		candidate := append(append([]*MRInfo{}, good...), mr)
+		if resetErr := e.resetAndRebuildStack(candidate, target); resetErr != nil {
+			_, _ = fmt.Fprintf(e.output, "[Bisect] Error rebuilding stack with %s: %v, treating rest as culprits\n", mr.ID, resetErr)
+			return good, append(culprits, batch[i:]...)
+		}
+		if e.runBatchGates(ctx, candidate).Success {
+			_, _ = fmt.Fprintf(e.output, "[Bisect-L] %s passed on %v\n", mr.ID, mrIDs(good))
+			good = candidate
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Bisect-L] %s failed on %v → culprit\n", mr.ID, mrIDs(good))
+		culprits = append(culprits, mr)
+	}
+	return good, culprits
+}
+
+// bisectGroup is one subset of a failed batch under test.
+type bisectGroup struct {
+	mrs []*MRInfo // MRs under test
+	dir string    // Worktree holding the group's stack
+}
+
+// bisectParallelGroup isolates culprits by group testing. Each round gates
+// a set of disjoint groups concurrently, each stacked alone on the target
+// in a temporary worktree under .runtime/bisect/<batchID>. A group that
+// passes is good; a failing group of one MR is a culprit; any other failing
+// group is halved for the next round. The first round halves the batch.
+//
+// Testing in isolation can't see failures that only arise from MRs in
+// different groups combining. When both halves of a failing group pass,
+// the group is bisected with bisectBatch instead (the whole batch, if it
+// was the first round's split), as it is when a group can't be stacked on
+// its own. The good MRs are gated together before landing (see
+// processBatch), which catches the rest.
+//
+// Groups are stacked with their in-batch blockers (see groupStack), which
+// are context rather than under test. A failure is attributed to a
+// culprit blocker rather than to its dependents (see blamedOnBlockers).
+func (e *Engineer) bisectParallelGroup(ctx context.Context, batchID string, batch []*MRInfo, target string) (good []*MRInfo, culprits []*MRInfo) {
+	if len(batch) <= 1 {
+		return nil, append([]*MRInfo{}, batch...)
+	}
+	root := filepath.Join(e.rig.Path, ".runtime", "bisect", batchID)
+	defer func() {
+		_ = os.RemoveAll(root)
+		_ = e.git.WorktreePrune()
+	}()
+
+	goodSet := make(map[string]bool, len(batch))
+	culpritSet := make(map[string]bool)
+	// splits holds every group that failed and was halved; parents maps
+	// each group of the round to the split it came from.
+	splits := [][]*MRInfo{batch}
+	groups := splitHalves(batch)
+	parents := []int{0, 0}
+	for round := 1; len(groups) > 0; round++ {
+		_, _ = fmt.Fprintf(e.output, "[Bisect-P] Round %d: gating %d groups in parallel...\n", round, len(groups))
+		tested, err := e.stackBisectGroups(root, round, batch, groups, target)
+		if err != nil {
+			e.removeBisectGroups(tested)
+			_, _ = fmt.Fprintf(e.output, "[Bisect-P] %v, falling back to binary bisection\n", err)
+			return e.bisectBatch(ctx, batch, target)
+		}
+
+		passed := make([]bool, len(tested))
+		var wg sync.WaitGroup
+		for i := range tested {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				stack := groupStack(batch, tested[i].mrs)
+				passed[i] = e.runBatchGatesIn(ctx, tested[i].dir, stack).Success
+			}(i)
+		}
+		wg.Wait()
+		e.removeBisectGroups(tested)
+
+		var next [][]*MRInfo
+		var nextParents []int
+		splitFailed := make(map[int]bool)
+		for i, grp := range tested {
+			switch {
+			case passed[i]:
+				_, _ = fmt.Fprintf(e.output, "[Bisect-P] %v passed\n", mrIDs(grp.mrs))
+				for _, mr := range grp.mrs {
+					goodSet[mr.ID] = true
+				}
+				continue
+			case len(grp.mrs) == 1:
+				_, _ = fmt.Fprintf(e.output, "[Bisect-P] %v failed → culprit\n", mrIDs(grp.mrs))
+				culpritSet[grp.mrs[0].ID] = true
+			default:
+				_, _ = fmt.Fprintf(e.output, "[Bisect-P] %v failed, splitting\n", mrIDs(grp.mrs))
+				halves := splitHalves(grp.mrs)
+				next = append(next, halves...)
+				for range halves {
+					nextParents = append(nextParents, len(splits))
+				}
+				splits = append(splits, grp.mrs)
+			}
+			splitFailed[parents[i]] = true
+		}
+
+		// A split whose groups all passed fails only as a whole.
+		for i := range tested {
+			split := parents[i]
+			if splitFailed[split] {
+				continue
+			}
+			splitFailed[split] = true // bisect each split once
+			if split == 0 {
+				_, _ = fmt.Fprintln(e.output, "[Bisect-P] Every group passed alone (failure needs MRs from several groups), falling back to binary bisection")
+				return e.bisectBatch(ctx, batch, target)
+			}
+			e.bisectInteraction(ctx, batch, splits[split], target, goodSet, culpritSet)
+		}
+		groups, parents = next, nextParents
+	}
+
+	for _, mr := range batch {
+		switch {
+		case culpritSet[mr.ID] && blamedOnBlockers(batch, mr, culpritSet):
+			_, _ = fmt.Fprintf(e.output, "[Bisect-P] %s failed only on a culprit blocker, leaving it queued\n", mr.ID)
+		case culpritSet[mr.ID]:
+			culprits = append(culprits, mr)
+		case goodSet[mr.ID]:
+			good = append(good, mr)
+		}
+	}
+	return good, culprits
+}
+
+// bisectInteraction bisects a group that fails as a whole although each of
+// its halves passes alone, using bisectBatch on the group's stack in the
+// Engineer's worktree. Verdicts are recorded for the group's MRs only; its
+// blockers are under test in their own groups.
+func (e *Engineer) bisectInteraction(ctx context.Context, batch, group []*MRInfo, target string, goodSet, culpritSet map[string]bool) {
+	_, _ = fmt.Fprintf(e.output, "[Bisect-P] %v fails only as a whole, falling back to binary bisection for it\n", mrIDs(group))
+	inGroup := make(map[string]bool, len(group))
+	for _, mr := range group {
+		inGroup[mr.ID] = true
+	}
+	_, culprits := e.bisectBatch(ctx, groupStack(batch, group), target)
+	for _, mr := range culprits {
+		if inGroup[mr.ID] {
+			delete(goodSet, mr.ID)
+			culpritSet[mr.ID] = true
+		}
+	}
+}
+
+// blamedOnBlockers reports whether mr was gated on top of a blocker that is
+// itself a culprit, so its failure can't be told apart from the blocker's.
+func blamedOnBlockers(batch []*MRInfo, mr *MRInfo, culpritSet map[string]bool) bool {
+	for _, c := range groupStack(batch, []*MRInfo{mr}) {
+		if c.ID != mr.ID && culpritSet[c.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// stackBisectGroups creates a worktree per group on origin/target and
+// stacks the group in it. Stacking runs one group at a time, as merges
+// share the merge driver marker. On error, the groups stacked so far are
+// returned for removal.
+func (e *Engineer) stackBisectGroups(root string, round int, batch []*MRInfo, groups [][]*MRInfo, target string) ([]bisectGroup, error) {
+	tested := make([]bisectGroup, 0, len(groups))
+	for i, mrs := range groups {
+		dir := filepath.Join(root, fmt.Sprintf("round-%d-group-%d", round, i+1))
+		if err := e.git.WorktreeAddDetached(dir, "origin/"+target); err != nil {
+			return tested, fmt.Errorf("worktree for %v: %w", mrIDs(mrs), err)
+		}
+		tested = append(tested, bisectGroup{mrs: mrs, dir: dir})
+		g := git.NewGit(dir)
+		for _, mr := range groupStack(batch, mrs) {
+			if err := e.mergeMRIn(g, dir, mr, e.output); err != nil {
+				return tested, fmt.Errorf("%s does not stack without the rest of the batch: %w", mr.ID, err)
+			}
+		}
+	}
+	return tested, nil
+}
+
+// removeBisectGroups removes the worktrees of a bisection round. Failures
+// are logged: a leftover worktree is pruned once its directory is gone.
+func (e *Engineer) removeBisectGroups(groups []bisectGroup) {
+	for _, grp := range groups {
+		if err := e.git.WorktreeRemove(grp.dir, true); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Bisect-P] Warning: remove worktree %s: %v\n", grp.dir, err)
+		}
+	}
+}
+
+// groupStack returns the MRs to stack when testing group: the group plus
+// its in-batch blockers, in batch order. A dependent MR can't be merged
+// without the MRs it builds on.
+func groupStack(batch, group []*MRInfo) []*MRInfo {
+	inBatch := make(map[string]*MRInfo, len(batch))
+	for _, mr := range batch {
+		inBatch[mr.ID] = mr
+	}
+	need := make(map[string]bool, len(group))
+	for _, mr := range group {
+		chain, _, err := blockerChain(mr, inBatch)
+		if err != nil {
+			// Batches are ordered by dependencies, so cycles were
+			// rejected before stacking; stack the MR alone.
+			need[mr.ID] = true
+			continue
+		}
+		for _, c := range chain {
+			need[c.ID] = true
+		}
+	}
+	stack := make([]*MRInfo, 0, len(need))
+	for _, mr := range batch {
+		if need[mr.ID] {
+			stack = append(stack, mr)
+		}
+	}
+	return stack
+}
+
+// splitHalves splits mrs into two non-empty halves, or returns it whole if
+// it has a single MR.
+func splitHalves(mrs []*MRInfo) [][]*MRInfo {
+	if len(mrs) <= 1 {
+		return [][]*MRInfo{mrs}
+	}
+	mid := len(mrs) / 2
+	return [][]*MRInfo{
+		append([]*MRInfo{}, mrs[:mid]...),
+		append([]*MRInfo{}, mrs[mid:]...),
+	}
+}
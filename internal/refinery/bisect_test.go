@@ -0,0 +1,230 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBisectStrategy_Default(t *testing.T) {
+	for _, s := range []string{"", "binary", "bogus"} {
+		if got := (&BatchConfig{BisectStrategy: s}).bisectStrategy(); got != BisectBinary {
+			t.Errorf("bisectStrategy(%q) = %q, want %q", s, got, BisectBinary)
+		}
+	}
+	for _, s := range []string{BisectLinear, BisectParallelGroup} {
+		if got := (&BatchConfig{BisectStrategy: s}).bisectStrategy(); got != s {
+			t.Errorf("bisectStrategy(%q) = %q", s, got)
+		}
+	}
+}
+
+func TestGroupStack_IncludesInBatchBlockers(t *testing.T) {
+	a := makeMR("mr-a", "feature-a", "main")
+	b := makeMR("mr-b", "feature-b", "main")
+	b.BlockedBy = "mr-a"
+	c := makeMR("mr-c", "feature-c", "main")
+	c.BlockedBy = "mr-outside"
+	batch := []*MRInfo{a, b, c}
+
+	if got := stackedIDs(groupStack(batch, []*MRInfo{b, c})); !reflect.DeepEqual(got, []string{"mr-a", "mr-b", "mr-c"}) {
+		t.Errorf("groupStack([b c]) = %v, want [mr-a mr-b mr-c]", got)
+	}
+	if got := stackedIDs(groupStack(batch, []*MRInfo{c})); !reflect.DeepEqual(got, []string{"mr-c"}) {
+		t.Errorf("groupStack([c]) = %v, want [mr-c]", got)
+	}
+}
+
+func TestBisectLinear_BlamesEachFailingMR(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "fail\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+	}
+	good, culprits := e.bisectLinear(context.Background(), batch, "main")
+	if ids := stackedIDs(good); !reflect.DeepEqual(ids, []string{"mr-a", "mr-c"}) {
+		t.Errorf("good = %v, want [mr-a mr-c]", ids)
+	}
+	if ids := stackedIDs(culprits); !reflect.DeepEqual(ids, []string{"mr-b"}) {
+		t.Errorf("culprits = %v, want [mr-b]", ids)
+	}
+}
+
+func TestBisectParallelGroup_FindsCulprits(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+	createFeatureBranch(t, workDir, "feature-c", "FAIL_MARKER", "fail\n")
+	createFeatureBranch(t, workDir, "feature-d", "d.txt", "hello d\n")
+	createFeatureBranch(t, workDir, "feature-e", "e.txt", "hello e\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.SetOutput(io.Discard) // Groups gate concurrently
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+		makeMR("mr-d", "feature-d", "main"),
+		makeMR("mr-e", "feature-e", "main"),
+	}
+	good, culprits := e.bisectParallelGroup(context.Background(), "test-batch", batch, "main")
+	if ids := stackedIDs(good); !reflect.DeepEqual(ids, []string{"mr-a", "mr-b", "mr-d", "mr-e"}) {
+		t.Errorf("good = %v, want [mr-a mr-b mr-d mr-e]", ids)
+	}
+	if ids := stackedIDs(culprits); !reflect.DeepEqual(ids, []string{"mr-c"}) {
+		t.Errorf("culprits = %v, want [mr-c]", ids)
+	}
+	assertBisectCleanedUp(t, workDir)
+}
+
+func TestBisectParallelGroup_FallsBackOnInteraction(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	// Each MR passes alone; together they fail.
+	createFeatureBranch(t, workDir, "feature-a", "x.txt", "x\n")
+	createFeatureBranch(t, workDir, "feature-b", "y.txt", "y\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: "test ! -f x.txt || test ! -f y.txt"}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+	}
+	good, culprits := e.bisectParallelGroup(context.Background(), "test-batch", batch, "main")
+	if ids := stackedIDs(good); !reflect.DeepEqual(ids, []string{"mr-a"}) {
+		t.Errorf("good = %v, want [mr-a]", ids)
+	}
+	if ids := stackedIDs(culprits); !reflect.DeepEqual(ids, []string{"mr-b"}) {
+		t.Errorf("culprits = %v, want [mr-b]", ids)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "falling back to binary bisection") {
+		t.Errorf("expected fallback to binary bisection, output:\n%s", out)
+	}
+	assertBisectCleanedUp(t, workDir)
+}
+
+func TestBisectParallelGroup_BisectsLaterRoundInteraction(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	// mr-c and mr-d each pass alone but fail together; the first round
+	// still finds their group failing, so the fallback is per group.
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+	createFeatureBranch(t, workDir, "feature-c", "x.txt", "x\n")
+	createFeatureBranch(t, workDir, "feature-d", "y.txt", "y\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.SetOutput(io.Discard)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: "test ! -f x.txt || test ! -f y.txt"}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+		makeMR("mr-d", "feature-d", "main"),
+	}
+	good, culprits := e.bisectParallelGroup(context.Background(), "test-batch", batch, "main")
+	if ids := stackedIDs(good); !reflect.DeepEqual(ids, []string{"mr-a", "mr-b", "mr-c"}) {
+		t.Errorf("good = %v, want [mr-a mr-b mr-c]", ids)
+	}
+	if ids := stackedIDs(culprits); !reflect.DeepEqual(ids, []string{"mr-d"}) {
+		t.Errorf("culprits = %v, want [mr-d]", ids)
+	}
+	assertBisectCleanedUp(t, workDir)
+}
+
+func TestBisectParallelGroup_BlamesBlockerNotDependent(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "fail\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+	createFeatureBranch(t, workDir, "feature-d", "d.txt", "hello d\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.SetOutput(io.Discard)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	d := makeMR("mr-d", "feature-d", "main")
+	d.BlockedBy = "mr-b"
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+		d,
+	}
+	good, culprits := e.bisectParallelGroup(context.Background(), "test-batch", batch, "main")
+	if ids := stackedIDs(good); !reflect.DeepEqual(ids, []string{"mr-a", "mr-c"}) {
+		t.Errorf("good = %v, want [mr-a mr-c]", ids)
+	}
+	if ids := stackedIDs(culprits); !reflect.DeepEqual(ids, []string{"mr-b"}) {
+		t.Errorf("culprits = %v, want [mr-b]", ids)
+	}
+	assertBisectCleanedUp(t, workDir)
+}
+
+func TestProcessBatch_ParallelGroupBisect(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "fail\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.SetOutput(io.Discard)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+	}
+	cfg := &BatchConfig{MaxBatchSize: 5, BisectStrategy: BisectParallelGroup}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if ids := stackedIDs(result.Merged); !reflect.DeepEqual(ids, []string{"mr-a", "mr-c"}) {
+		t.Errorf("merged = %v, want [mr-a mr-c]", ids)
+	}
+	if ids := stackedIDs(result.Culprits); !reflect.DeepEqual(ids, []string{"mr-b"}) {
+		t.Errorf("culprits = %v, want [mr-b]", ids)
+	}
+	assertBisectCleanedUp(t, workDir)
+}
+
+func assertBisectCleanedUp(t *testing.T, workDir string) {
+	t.Helper()
+	if out := run(t, workDir, "git", "worktree", "list"); strings.Count(out, "\n") != 0 {
+		t.Errorf("bisect worktrees left behind:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, ".runtime", "bisect")); err == nil {
+		entries, _ := os.ReadDir(filepath.Join(workDir, ".runtime", "bisect"))
+		if len(entries) != 0 {
+			t.Errorf("bisect directories left behind: %d", len(entries))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package refinery
+
+import (
+	"fmt"
+)
+
+// rollbackCancelled undoes a batch whose context was cancelled before it
+// landed, so the next batch starts from a clean tree: it aborts any merge,
+// rebase or cherry-pick left in progress, resets target to origin, returns
+// to origBranch and releases the push slot if it is still held.
+//
+// The MRs that neither landed nor conflicted go back to the queue as
+// Deferred. Culprits are dropped: gates cut short by the cancellation fail,
+// so bisection under a cancelled context blames MRs at random. A batch that
+// was already pushed stays landed; only the tree is tidied.
+func (e *Engineer) rollbackCancelled(cause error, batch []*MRInfo, origBranch, target string, result *BatchResult) {
+	_ = e.git.AbortMerge()
+	_ = e.git.AbortRebase()
+	_ = e.git.AbortCherryPick()
+
+	if result.MergeCommit == "" {
+		if err := e.git.Checkout(target); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: checkout %s after cancellation: %v\n", target, err)
+		} else if err := e.git.ResetHard("origin/" + target); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: reset %s after cancellation: %v\n", target, err)
+		}
+	}
+	if origBranch != "" && origBranch != target {
+		if err := e.git.Checkout(origBranch); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: return to %s after cancellation: %v\n", origBranch, err)
+		}
+	}
+	if e.pushSlot != "" {
+		e.releasePushSlot(e.pushSlot)
+	}
+
+	if result.MergeCommit != "" {
+		return
+	}
+	settled := make(map[string]bool)
+	for _, mr := range result.Conflicts {
+		settled[mr.ID] = true
+	}
+	result.Culprits = nil
+	result.Deferred = nil
+	for _, mr := range batch {
+		if !settled[mr.ID] {
+			result.Deferred = append(result.Deferred, mr)
+		}
+	}
+	result.Cancelled = true
+	e.recordProgress(StageCancelled, "batch rolled back, still queued", result.BatchID, result.Deferred...)
+	result.Error = fmt.Errorf("batch cancelled: %w", cause)
+	_, _ = fmt.Fprintf(e.output, "[Batch] Cancelled, rolled back; %d MRs stay queued\n", len(result.Deferred))
+}
+
+// releasePushSlot releases the push slot taken by acquireMainPushSlot.
+func (e *Engineer) releasePushSlot(holder string) {
+	if holder == e.pushSlot {
+		e.pushSlot = ""
+	}
+	e.endSlotLease(holder)
+	if err := e.mergeSlotRelease(holder); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to release merge slot for push (%s): %v\n", holder, err)
+	}
+}
@@ -0,0 +1,78 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProcessBatch_CancelledMidGatesRollsBack(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+	run(t, workDir, "git", "checkout", "-b", "scratch")
+	base := run(t, workDir, "git", "rev-parse", "origin/main")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"slow": {Cmd: "sleep 5"}}
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+	batch := []*MRInfo{makeMR("mr-a", "polecat/a", "main"), makeMR("mr-b", "polecat/b", "main")}
+	result := e.ProcessBatch(ctx, batch, "main", cfg)
+
+	if !result.Cancelled || !errors.Is(result.Error, context.Canceled) {
+		t.Fatalf("Cancelled = %v, Error = %v; want cancelled batch", result.Cancelled, result.Error)
+	}
+	if len(result.Merged) != 0 || len(result.Culprits) != 0 {
+		t.Errorf("merged %v, culprits %v; want neither", mrIDs(result.Merged), mrIDs(result.Culprits))
+	}
+	if got := mrIDs(result.Deferred); len(got) != 2 {
+		t.Errorf("Deferred = %v, want both MRs back in the queue", got)
+	}
+	if got := run(t, workDir, "git", "rev-parse", "--abbrev-ref", "HEAD"); got != "scratch" {
+		t.Errorf("on branch %s, want scratch", got)
+	}
+	if got := run(t, workDir, "git", "rev-parse", "main"); got != base {
+		t.Errorf("local main at %s, want the half-built stack discarded (%s)", got, base)
+	}
+	if got := run(t, workDir, "git", "rev-parse", "origin/main"); got != base {
+		t.Errorf("origin/main moved to %s", got)
+	}
+}
+
+func TestRollbackCancelled_AbortsMergeAndReleasesSlot(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createConflictingBranch(t, workDir, "polecat/a", "README.md", "a\n")
+	createConflictingBranch(t, workDir, "polecat/b", "README.md", "b\n")
+	run(t, workDir, "git", "checkout", "polecat/a")
+	// Leave a conflicted merge in progress, as a cancelled stack would.
+	_ = g.Merge("polecat/b")
+
+	e := newTestEngineer(t, workDir, g)
+	var released []string
+	e.mergeSlotRelease = func(holder string) error {
+		released = append(released, holder)
+		return nil
+	}
+	e.pushSlot = "test-rig/refinery/push/1"
+
+	result := &BatchResult{}
+	e.rollbackCancelled(context.Canceled, []*MRInfo{makeMR("mr-a", "polecat/a", "main")}, "polecat/a", "main", result)
+
+	if got := run(t, workDir, "git", "status", "--porcelain", "--untracked-files=no"); got != "" {
+		t.Errorf("tree not clean after rollback:\n%s", got)
+	}
+	if got := run(t, workDir, "git", "rev-parse", "--abbrev-ref", "HEAD"); got != "polecat/a" {
+		t.Errorf("on branch %s, want polecat/a", got)
+	}
+	if len(released) != 1 || released[0] != "test-rig/refinery/push/1" || e.pushSlot != "" {
+		t.Errorf("released %v, pushSlot %q; want the held slot released once", released, e.pushSlot)
+	}
+	if !result.Cancelled || len(result.Deferred) != 1 {
+		t.Errorf("result = %+v, want cancelled with mr-a deferred", result)
+	}
+}
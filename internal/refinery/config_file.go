@@ -0,0 +1,165 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Refinery config files. A rig may keep its merge queue settings in one of
+// these instead of (or on top of) config.json's merge_queue section: the
+// keys are the same, at top level, and override config.json's. The TOML
+// form is read as the equivalent JSON, so tables nest as objects do:
+//
+//	batch = { max_batch_size = 8, max_retries = 2, retry_backoff = "30s" }
+//
+//	[gates.test]
+//	cmd = "go test ./..."
+//	timeout = "10m"
+const (
+	refineryConfigJSON = "refinery.json"
+	refineryConfigTOML = "refinery.toml"
+)
+
+// refineryConfigFile returns the name and contents, as JSON, of the rig's
+// refinery config file, or a nil raw if it has none. Having both forms is
+// an error, as which one wins would be a guess.
+func (e *Engineer) refineryConfigFile() (name string, raw json.RawMessage, err error) {
+	jsonData, jsonErr := os.ReadFile(filepath.Join(e.rig.Path, refineryConfigJSON))
+	tomlData, tomlErr := os.ReadFile(filepath.Join(e.rig.Path, refineryConfigTOML))
+	for _, err := range []error{jsonErr, tomlErr} {
+		if err != nil && !os.IsNotExist(err) {
+			return "", nil, fmt.Errorf("reading refinery config: %w", err)
+		}
+	}
+	switch {
+	case jsonErr == nil && tomlErr == nil:
+		return "", nil, fmt.Errorf("rig has both %s and %s; keep one", refineryConfigJSON, refineryConfigTOML)
+	case jsonErr == nil:
+		return refineryConfigJSON, jsonData, nil
+	case tomlErr == nil:
+		var doc map[string]interface{}
+		if err := toml.Unmarshal(tomlData, &doc); err != nil {
+			return "", nil, fmt.Errorf("parsing %s: %w", refineryConfigTOML, err)
+		}
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return "", nil, fmt.Errorf("parsing %s: %w", refineryConfigTOML, err)
+		}
+		return refineryConfigTOML, raw, nil
+	}
+	return "", nil, nil
+}
+
+// configStamp identifies the current state of the rig's config files, by
+// size and modification time.
+func (e *Engineer) configStamp() string {
+	var stamp string
+	for _, name := range []string{"config.json", refineryConfigJSON, refineryConfigTOML} {
+		if fi, err := os.Stat(filepath.Join(e.rig.Path, name)); err == nil {
+			stamp += fmt.Sprintf("%s:%d:%d;", name, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return stamp
+}
+
+// ReloadConfig reloads the merge queue configuration if the rig's config
+// files have changed since it was last loaded, and reports whether it did.
+// The new configuration replaces the old one whole, starting from the
+// defaults, so a setting removed from the files reverts to its default. A
+// configuration that doesn't parse is reported and the old one kept; it is
+// not retried until the files change again. An engineer whose
+// configuration was never loaded with LoadConfig is left alone.
+func (e *Engineer) ReloadConfig() (bool, error) {
+	if e.loadedConfig == "" || e.rig == nil {
+		return false, nil
+	}
+	stamp := e.configStamp()
+	if stamp == e.loadedConfig {
+		return false, nil
+	}
+	e.loadedConfig = stamp
+	cfg := DefaultMergeQueueConfig()
+	if err := e.readConfig(cfg); err != nil {
+		return false, err
+	}
+	*e.config = *cfg
+	return true, nil
+}
+
+// reloadConfig runs ReloadConfig between batches, logging the outcome, and
+// applies live gate changes (see LiveGateChange). It does nothing while
+// ProcessTargets is running batches concurrently, as the target engineers
+// share the configuration; ProcessTargets reloads before starting them.
+func (e *Engineer) reloadConfig(ctx context.Context) {
+	if ctx.Value(targetsRunKey{}) != nil {
+		return
+	}
+	reloaded, err := e.ReloadConfig()
+	switch {
+	case err != nil:
+		_, _ = fmt.Fprintf(e.output, "[Config] Warning: not reloading changed config: %v; keeping the previous one\n", err)
+	case reloaded:
+		_, _ = fmt.Fprintln(e.output, "[Config] Config files changed, reloaded")
+	}
+	e.applyLiveGates()
+}
+
+// targetsRunKey marks a context passed to the batches of one ProcessTargets
+// run.
+type targetsRunKey struct{}
+
+// batchConfigRaw is the JSON form of BatchConfig with string durations.
+// Fields it doesn't set keep DefaultBatchConfig's values.
+type batchConfigRaw struct {
+	BatchConfig
+	BatchWaitTime string `json:"batch_wait_time"`
+	RetryBackoff  string `json:"retry_backoff"`
+	StarvationAge string `json:"starvation_age"`
+}
+
+// parseBatchConfig parses a batch section of the merge queue config.
+func parseBatchConfig(data json.RawMessage) (*BatchConfig, error) {
+	raw := batchConfigRaw{BatchConfig: *DefaultBatchConfig()}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing batch config: %w", err)
+	}
+	cfg := raw.BatchConfig
+	for _, d := range []struct {
+		name, value string
+		dst         *time.Duration
+	}{
+		{"batch_wait_time", raw.BatchWaitTime, &cfg.BatchWaitTime},
+		{"retry_backoff", raw.RetryBackoff, &cfg.RetryBackoff},
+		{"starvation_age", raw.StarvationAge, &cfg.StarvationAge},
+	} {
+		if d.value == "" {
+			continue
+		}
+		dur, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch %s %q: %w", d.name, d.value, err)
+		}
+		if dur < 0 {
+			return nil, fmt.Errorf("batch %s must not be negative, got %v", d.name, dur)
+		}
+		*d.dst = dur
+	}
+	if cfg.MaxBatchSize < 1 {
+		return nil, fmt.Errorf("batch max_batch_size must be at least 1, got %d", cfg.MaxBatchSize)
+	}
+	if cfg.MaxRetries < 0 || cfg.MinBatchSize < 0 || cfg.StarvationRetries < 0 {
+		return nil, fmt.Errorf("batch max_retries, min_batch_size and starvation_retries must not be negative")
+	}
+	switch cfg.BisectStrategy {
+	case "", BisectLinear, BisectBinary, BisectParallelGroup:
+	default:
+		return nil, fmt.Errorf("invalid batch bisect_strategy %q: want linear, binary or parallel-group", cfg.BisectStrategy)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,124 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestLoadConfig_RefineryTOML(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile(t, tmpDir, "config.json", `{"merge_queue": {"merge_strategy": "rebase-ff", "gates": {"lint": {"cmd": "make lint"}}}}`)
+	writeFile(t, tmpDir, refineryConfigTOML, `
+batch = { max_batch_size = 8, max_retries = 2, retry_backoff = "30s", bisect_strategy = "linear" }
+
+[gates.test]
+cmd = "go test ./..."
+timeout = "10m"
+`)
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if e.config.MergeStrategy != MergeStrategyRebaseFF {
+		t.Errorf("merge_strategy = %q, want config.json's", e.config.MergeStrategy)
+	}
+	if len(e.config.Gates) != 1 || e.config.Gates["test"].Timeout != 10*time.Minute {
+		t.Errorf("gates = %v, want refinery.toml's test gate", e.config.Gates)
+	}
+	b := e.config.Batch
+	if b == nil || b.MaxBatchSize != 8 || b.MaxRetries != 2 || b.RetryBackoff != 30*time.Second || b.BisectStrategy != BisectLinear {
+		t.Fatalf("batch = %+v", b)
+	}
+	if !b.SeparateOverlaps || b.StarvationAge != 24*time.Hour {
+		t.Errorf("batch lost its defaults: %+v", b)
+	}
+	if got := e.batchConfigFor("main", DefaultBatchConfig()); got != b {
+		t.Error("rig batch config not used in place of the caller's")
+	}
+
+	writeFile(t, tmpDir, refineryConfigJSON, `{}`)
+	if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+		t.Error("LoadConfig accepted both refinery.json and refinery.toml")
+	}
+}
+
+func TestLoadConfig_BatchValidation(t *testing.T) {
+	for _, body := range []string{
+		`{"max_batch_size": 0}`,
+		`{"retry_backoff": "soon"}`,
+		`{"starvation_age": "-1h"}`,
+		`{"bisect_strategy": "random"}`,
+	} {
+		tmpDir := t.TempDir()
+		writeFile(t, tmpDir, refineryConfigJSON, `{"batch": `+body+`}`)
+		if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+			t.Errorf("LoadConfig accepted batch %s", body)
+		}
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, refineryConfigJSON)
+	write := func(body string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write(`{"gates": {"test": {"cmd": "make test"}}, "batch": {"max_batch_size": 4}}`, now.Add(-time.Hour))
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	out := &bytes.Buffer{}
+	e.SetOutput(out)
+	if reloaded, err := e.ReloadConfig(); reloaded || err != nil {
+		t.Fatalf("ReloadConfig before LoadConfig = %v, %v", reloaded, err)
+	}
+	if err := e.LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	shared := e.config
+	if reloaded, _ := e.ReloadConfig(); reloaded {
+		t.Error("reloaded unchanged config")
+	}
+
+	// A removed setting reverts to its default.
+	write(`{"gates": {"test": {"cmd": "make check"}}}`, now.Add(-time.Minute))
+	e.reloadConfig(context.Background())
+	if e.config != shared || e.config.Gates["test"].Cmd != "make check" || e.config.Batch != nil {
+		t.Fatalf("after reload: gates %v, batch %+v", e.config.Gates, e.config.Batch)
+	}
+	if !strings.Contains(out.String(), "reloaded") {
+		t.Errorf("reload not logged: %q", out.String())
+	}
+
+	// A broken edit keeps the previous config, and is reported once.
+	write(`{"gates": {"test": {"cmd": "make check", "timeout": "forever"}}}`, now)
+	if _, err := e.ReloadConfig(); err == nil {
+		t.Fatal("ReloadConfig accepted a bad timeout")
+	}
+	if e.config.Gates["test"].Cmd != "make check" {
+		t.Error("bad config replaced the previous one")
+	}
+	if _, err := e.ReloadConfig(); err != nil {
+		t.Errorf("unchanged bad config reported again: %v", err)
+	}
+
+	// Batches of one ProcessTargets run don't reload under each other.
+	write(`{"gates": {"test": {"cmd": "make all"}}}`, now.Add(time.Minute))
+	e.reloadConfig(context.WithValue(context.Background(), targetsRunKey{}, true))
+	if e.config.Gates["test"].Cmd != "make check" {
+		t.Error("reloaded during a ProcessTargets run")
+	}
+}
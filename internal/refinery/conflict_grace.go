@@ -0,0 +1,276 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// DefaultConflictGraceWindow is how long a batch waits for rebases by
+// default.
+const DefaultConflictGraceWindow = 5 * time.Minute
+
+// maxConflictGraceWindow caps the window: the batch, and the queue behind
+// it, waits for the whole of it.
+const maxConflictGraceWindow = time.Hour
+
+// conflictGracePoll is how often the origin branches of MRs being rebased
+// are checked for a new head.
+var conflictGracePoll = 10 * time.Second
+
+// ConflictGraceConfig gives the agents behind MRs that conflict while a
+// batch is stacked a short window to rebase them and rejoin the same batch,
+// instead of removing them from it at once. The refinery pours a rebase
+// molecule for each such MR and holds the batch until every one has been
+// rebased or declined, or the window closes. An MR that isn't back in time
+// leaves the batch as before: to conflict resolution when that's enabled,
+// otherwise to its author.
+type ConflictGraceConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Window is how long the batch waits, as a Go duration ("5m").
+	// Default: DefaultConflictGraceWindow.
+	Window string `json:"window,omitempty"`
+
+	// Formula is poured on each rebase task.
+	// Default: mol-rebase-mr.
+	Formula string `json:"formula,omitempty"`
+
+	window time.Duration
+}
+
+// validateConflictGrace parses the window, which must be positive and at
+// most an hour.
+func validateConflictGrace(cfg *ConflictGraceConfig) error {
+	cfg.window = DefaultConflictGraceWindow
+	if cfg.Window == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(cfg.Window)
+	if err != nil {
+		return fmt.Errorf("conflict_grace window: %w", err)
+	}
+	if d <= 0 || d > maxConflictGraceWindow {
+		return fmt.Errorf("conflict_grace window must be between 0 and %s, got %s", maxConflictGraceWindow, cfg.Window)
+	}
+	cfg.window = d
+	return nil
+}
+
+func (c *ConflictGraceConfig) formula() string {
+	if c.Formula != "" {
+		return c.Formula
+	}
+	return constants.MolRebaseMR
+}
+
+// graceOffer is an MR the batch is waiting on to be rebased.
+type graceOffer struct {
+	mr   *MRInfo
+	task string
+	head string // Branch head when it conflicted
+}
+
+// graceConflicts gives the agents behind conflicts the grace window to
+// rebase them onto target, when conflict grace is enabled. It must be
+// called with the stack built on the target branch; each MR rebased in time
+// that stacks cleanly is merged onto the stack. It returns the stack and the
+// conflicts that remain.
+//
+// Only MRs from a polecat can be offered a rebase. MRs whose branches live
+// in Gerrit or GitHub, or whose branch is gone, are returned as conflicts
+// right away.
+func (e *Engineer) graceConflicts(ctx context.Context, stacked, conflicts []*MRInfo, target string) ([]*MRInfo, []*MRInfo) {
+	cfg := e.config.ConflictGrace
+	if cfg == nil || !cfg.Enabled || len(conflicts) == 0 {
+		return stacked, conflicts
+	}
+	window := cfg.window
+	if window == 0 {
+		window = DefaultConflictGraceWindow
+	}
+	deadline := time.Now().Add(window)
+
+	var offers []*graceOffer
+	for _, mr := range conflicts {
+		if mr.Change != nil || mr.PullRequest != nil || strings.TrimPrefix(mr.Worker, "polecats/") == "" {
+			continue
+		}
+		head, err := e.git.Rev(mr.Branch)
+		if err != nil {
+			continue
+		}
+		task, err := e.requestRebase(mr, target, deadline)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[ConflictGrace] Warning: MR %s: %v\n", mr.ID, err)
+			continue
+		}
+		offers = append(offers, &graceOffer{mr: mr, task: task, head: head})
+		e.recordProgress(StageConflict, fmt.Sprintf("rebase requested (%s), batch waits until %s", task, deadline.Format(time.Kitchen)), mr.batchID, mr)
+	}
+	if len(offers) == 0 {
+		return stacked, conflicts
+	}
+	_, _ = fmt.Fprintf(e.output, "[ConflictGrace] Waiting up to %s for %d conflicting MRs to be rebased\n", window, len(offers))
+
+	rejoined := make(map[*MRInfo]bool)
+wait:
+	for len(offers) > 0 {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			break
+		}
+		if wait > conflictGracePoll {
+			wait = conflictGracePoll
+		}
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-time.After(wait):
+		}
+		var waiting []*graceOffer
+		for _, o := range offers {
+			switch e.checkRebase(ctx, o, target) {
+			case rebaseRejoined:
+				rejoined[o.mr] = true
+				stacked = append(stacked, o.mr)
+				e.closeRebaseTask(o.task, "Rebased into the batch")
+			case rebaseDeclined:
+				_, _ = fmt.Fprintf(e.output, "[ConflictGrace] MR %s: rebase %s declined\n", o.mr.ID, o.task)
+			default:
+				waiting = append(waiting, o)
+			}
+		}
+		offers = waiting
+	}
+	for _, o := range offers {
+		_, _ = fmt.Fprintf(e.output, "[ConflictGrace] MR %s: not rebased in time, removing from batch\n", o.mr.ID)
+		e.closeRebaseTask(o.task, "Grace window closed before a clean rebase arrived")
+	}
+
+	var remaining []*MRInfo
+	for _, mr := range conflicts {
+		if !rejoined[mr] {
+			remaining = append(remaining, mr)
+		}
+	}
+	return stacked, remaining
+}
+
+const (
+	rebasePending = iota
+	rebaseRejoined
+	rebaseDeclined
+)
+
+// checkRebase fetches o's branch and, if it has a new head, tries to stack
+// it. A new head that still conflicts keeps the MR pending: the agent may
+// push again before the window closes.
+func (e *Engineer) checkRebase(ctx context.Context, o *graceOffer, target string) int {
+	if err := e.git.FetchRefToBranch(ctx, "origin", "refs/heads/"+o.mr.Branch, o.mr.Branch); err != nil {
+		return rebasePending
+	}
+	head, err := e.git.Rev(o.mr.Branch)
+	if err != nil {
+		return rebasePending
+	}
+	if head == o.head {
+		if issue, err := e.showIssue(o.task); err == nil && issue != nil && issue.Status == "closed" {
+			return rebaseDeclined
+		}
+		return rebasePending
+	}
+	o.head = head
+
+	tip, err := e.git.Rev("HEAD")
+	if err != nil {
+		return rebasePending
+	}
+	if files, err := e.checkMRConflicts(o.mr, target); err != nil || len(files) > 0 {
+		_, _ = fmt.Fprintf(e.output, "[ConflictGrace] MR %s: rebased head %s still conflicts with the stack\n", o.mr.ID, shortSHA(head))
+		return rebasePending
+	}
+	if err := e.mergeMR(o.mr); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[ConflictGrace] MR %s: merging rebased head %s: %v\n", o.mr.ID, shortSHA(head), err)
+		if resetErr := e.git.ResetHard(tip); resetErr != nil {
+			_, _ = fmt.Fprintf(e.output, "[ConflictGrace] Warning: reset stack to %s: %v\n", shortSHA(tip), resetErr)
+		}
+		return rebasePending
+	}
+	_, _ = fmt.Fprintf(e.output, "[ConflictGrace] MR %s rebased to %s, rejoined the batch\n", o.mr.ID, shortSHA(head))
+	return rebaseRejoined
+}
+
+// requestGraceRebase creates the rebase task for mr and slings the grace
+// formula to mr's worker, returning the task. Unlike the split and test
+// policies, the MR isn't blocked on the task: it stays in its batch.
+func (e *Engineer) requestGraceRebase(mr *MRInfo, target string, deadline time.Time) (string, error) {
+	cfg := e.config.ConflictGrace
+	description := fmt.Sprintf(`Rebase branch %s onto %s before %s
+
+## Metadata
+- Original MR: %s
+- Branch: %s
+- Target: %s
+- Original issue: %s
+- Deadline: %s
+
+## Instructions
+The MR conflicted while the refinery stacked its batch. The batch is waiting
+for it until the deadline. Rebase the branch onto origin/%s, resolve the
+conflicts and force-push it; the refinery stacks the new head into the same
+batch. Don't run gt done.
+
+If the branch rebases with no conflicts, it conflicts with another MR in the
+batch and rebasing can't help; close this task. Close it with a reason too
+if you can't rebase in time: the MR then leaves the batch.`,
+		mr.Branch, target, deadline.Format(time.RFC3339),
+		mr.ID,
+		mr.Branch,
+		target,
+		mr.SourceIssue,
+		deadline.Format(time.RFC3339),
+		target,
+	)
+	title := mr.Title
+	if title == "" {
+		title = mr.Branch
+	}
+	task, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Rebase MR: " + title,
+		Labels:      []string{"gt:task"},
+		Priority:    mr.Priority,
+		Description: description,
+		Actor:       e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating rebase task: %w", err)
+	}
+
+	agent := fmt.Sprintf("%s/%s", e.rig.Name, strings.TrimPrefix(mr.Worker, "polecats/"))
+	slingCmd := exec.Command("gt", "sling", cfg.formula(), agent, "--on", task.ID, //nolint:gosec // G204: formula is from trusted rig config
+		"--var", "task="+task.ID,
+		"--var", "original_mr="+mr.ID,
+		"--var", "branch="+mr.Branch,
+		"--var", "base_branch="+target,
+		"--var", "deadline="+deadline.Format(time.RFC3339))
+	slingCmd.Dir = e.workDir
+	if out, err := slingCmd.CombinedOutput(); err != nil {
+		// Nobody is rebasing, so there's nothing to wait for.
+		e.closeRebaseTask(task.ID, "Could not reach the MR's agent")
+		return "", fmt.Errorf("sling %s to %s: %v: %s", cfg.formula(), agent, err, strings.TrimSpace(string(out)))
+	}
+	_, _ = fmt.Fprintf(e.output, "[ConflictGrace] MR %s: rebase requested from %s (%s)\n", mr.ID, agent, task.ID)
+	return task.ID, nil
+}
+
+func (e *Engineer) closeRebaseTask(task, reason string) {
+	if err := e.beads.CloseWithReason(reason, task); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[ConflictGrace] Warning: closing %s: %v\n", task, err)
+	}
+}
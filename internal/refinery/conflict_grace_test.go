@@ -0,0 +1,130 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+func TestProcessBatch_ConflictGraceRejoinsRebasedMR(t *testing.T) {
+	defer func(d time.Duration) { conflictGracePoll = d }(conflictGracePoll)
+	conflictGracePoll = 20 * time.Millisecond
+
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createConflictingBranch(t, workDir, "polecat/b", "README.md", "# From b\n")
+	createConflictingBranch(t, workDir, "polecat/c", "README.md", "# From c\n")
+	run(t, workDir, "git", "push", "origin", "polecat/b", "polecat/c")
+	writeFile(t, workDir, "README.md", "# Moved on\n")
+	run(t, workDir, "git", "commit", "-am", "main moves on")
+	run(t, workDir, "git", "push", "origin", "main")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.ConflictGrace = &ConflictGraceConfig{Enabled: true, Window: "2s"}
+	if err := validateConflictGrace(e.config.ConflictGrace); err != nil {
+		t.Fatal(err)
+	}
+	e.showIssue = func(id string) (*beads.Issue, error) { return &beads.Issue{ID: id, Status: "open"}, nil }
+	var asked []string
+	e.requestRebase = func(mr *MRInfo, target string, deadline time.Time) (string, error) {
+		asked = append(asked, mr.ID)
+		if mr.ID == "mr-b" {
+			// b's agent rebases in a clone of its own; c's never does.
+			agent := filepath.Join(t.TempDir(), "agent")
+			run(t, workDir, "git", "clone", "-q", filepath.Join(filepath.Dir(workDir), "origin.git"), agent)
+			run(t, agent, "git", "checkout", "-q", "-B", "polecat/b", "origin/"+target)
+			writeFile(t, agent, "README.md", "# Moved on\n# From b\n")
+			run(t, agent, "git", "-c", "user.email=b@test.com", "-c", "user.name=B", "commit", "-qam", "feat: b on top")
+			run(t, agent, "git", "push", "-q", "-f", "origin", "polecat/b")
+		}
+		return "task-" + mr.ID, nil
+	}
+
+	batch := []*MRInfo{makeMR("mr-a", "polecat/a", "main"), makeMR("mr-b", "polecat/b", "main"), makeMR("mr-c", "polecat/c", "main")}
+	for _, mr := range batch {
+		mr.Worker = "polecats/nux"
+	}
+	start := time.Now()
+	result := e.ProcessBatch(context.Background(), batch, "main", nil)
+	out := testOutput(e).String()
+	if got := strings.Join(mrIDs(result.Merged), " "); got != "mr-a mr-b" {
+		t.Fatalf("merged = %q, want mr-a mr-b\n%s", got, out)
+	}
+	if got := strings.Join(mrIDs(result.Conflicts), " "); got != "mr-c" {
+		t.Errorf("conflicts = %q, want mr-c", got)
+	}
+	if got := strings.Join(asked, " "); got != "mr-b mr-c" {
+		t.Errorf("rebases requested for %q, want mr-b mr-c", got)
+	}
+	if elapsed := time.Since(start); elapsed < 2*time.Second {
+		t.Errorf("batch finished after %s, before the grace window closed on mr-c", elapsed)
+	}
+	if !strings.Contains(out, "MR mr-c: not rebased in time") {
+		t.Errorf("output missing mr-c timing out:\n%s", out)
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, "README.md"))
+	if err != nil || string(data) != "# Moved on\n# From b\n" {
+		t.Errorf("README.md = %q, %v", data, err)
+	}
+}
+
+func TestProcessBatch_ConflictGraceStopsWaitingWhenDeclined(t *testing.T) {
+	defer func(d time.Duration) { conflictGracePoll = d }(conflictGracePoll)
+	conflictGracePoll = 20 * time.Millisecond
+
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/a2", "a2.txt", "a2\n")
+	createConflictingBranch(t, workDir, "polecat/b", "README.md", "# From b\n")
+	run(t, workDir, "git", "push", "origin", "polecat/b")
+	writeFile(t, workDir, "README.md", "# Moved on\n")
+	run(t, workDir, "git", "commit", "-am", "main moves on")
+	run(t, workDir, "git", "push", "origin", "main")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.ConflictGrace = &ConflictGraceConfig{Enabled: true, Window: "1m"}
+	if err := validateConflictGrace(e.config.ConflictGrace); err != nil {
+		t.Fatal(err)
+	}
+	e.showIssue = func(id string) (*beads.Issue, error) { return &beads.Issue{ID: id, Status: "closed"}, nil }
+	e.requestRebase = func(mr *MRInfo, target string, deadline time.Time) (string, error) { return "task-" + mr.ID, nil }
+
+	batch := []*MRInfo{makeMR("mr-a", "polecat/a", "main"), makeMR("mr-a2", "polecat/a2", "main"), makeMR("mr-b", "polecat/b", "main")}
+	batch[2].Worker = "polecats/nux"
+	start := time.Now()
+	result := e.ProcessBatch(context.Background(), batch, "main", nil)
+	if len(result.Merged) != 2 || len(result.Conflicts) != 1 {
+		t.Fatalf("result = %+v, want mr-b conflicted and the rest merged", result)
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("batch waited %s for a declined rebase", elapsed)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "rebase task-mr-b declined") {
+		t.Errorf("output missing declined rebase:\n%s", out)
+	}
+}
+
+func TestEngineer_LoadConfig_ConflictGrace(t *testing.T) {
+	for window, wantErr := range map[string]bool{"": false, "90s": false, "soon": true, "-1m": true, "2h": true} {
+		dir := t.TempDir()
+		data := `{"type": "rig", "version": 1, "name": "test-rig", "merge_queue": {"conflict_grace": {"enabled": true, "window": "` + window + `"}}}`
+		if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		e := newTestEngineer(t, dir, nil)
+		if err := e.LoadConfig(); (err != nil) != wantErr {
+			t.Errorf("window %q: LoadConfig error = %v, want error %v", window, err, wantErr)
+		}
+	}
+	cfg := &ConflictGraceConfig{Enabled: true}
+	if err := validateConflictGrace(cfg); err != nil || cfg.window != DefaultConflictGraceWindow || cfg.formula() != "mol-rebase-mr" {
+		t.Errorf("defaults = %s, %q, %v", cfg.window, cfg.formula(), err)
+	}
+}
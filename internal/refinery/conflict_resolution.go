@@ -0,0 +1,459 @@
+package refinery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// HeldForConflict marks an MR waiting on an agent to resolve its merge
+// conflicts (see MRInfo.HeldFor).
+const HeldForConflict = "conflict"
+
+// DefaultConflictMaxAttempts is how many resolutions an MR gets by default.
+const DefaultConflictMaxAttempts = 2
+
+// conflictHunkLines caps the conflict hunks quoted in a resolution task.
+// The full diff is kept next to the scratch worktree.
+const conflictHunkLines = 150
+
+// ConflictResolutionConfig configures agent-assisted conflict resolution.
+// When an MR conflicts with its target while a batch is stacked, the
+// refinery merges it onto the target in a scratch worktree, leaving the
+// conflict markers, and pours a molecule handing the conflicting hunks to
+// an agent. If the agent's resolution is clean and passes the gates, the
+// MR is re-queued on the resolved branch. Without it, a conflicting MR
+// stays in the queue until its author rebases it.
+type ConflictResolutionConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// MaxAttempts is how many resolutions an MR gets before it is left to
+	// its author. Default: DefaultConflictMaxAttempts.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Assignee is who the molecule is slung to. Default: the rig, which
+	// spawns a fresh polecat.
+	Assignee string `json:"assignee,omitempty"`
+
+	// Formula is poured on the resolution task.
+	// Default: mol-resolve-conflict.
+	Formula string `json:"formula,omitempty"`
+}
+
+// validateConflictResolution checks that max_attempts is non-negative.
+func validateConflictResolution(cfg *ConflictResolutionConfig) error {
+	if cfg.MaxAttempts < 0 {
+		return fmt.Errorf("conflict_resolution max_attempts must be non-negative, got %d", cfg.MaxAttempts)
+	}
+	return nil
+}
+
+func (c *ConflictResolutionConfig) maxAttempts() int {
+	if c.MaxAttempts > 0 {
+		return c.MaxAttempts
+	}
+	return DefaultConflictMaxAttempts
+}
+
+func (c *ConflictResolutionConfig) formula() string {
+	if c.Formula != "" {
+		return c.Formula
+	}
+	return constants.MolResolveConflict
+}
+
+// ConflictRequest records a conflict resolution raised for an MR. A new
+// request replaces the MR's last one, carrying its attempt count.
+type ConflictRequest struct {
+	MR            string     `json:"mr"`
+	Task          string     `json:"task"`
+	Attempt       int        `json:"attempt"`
+	Branch        string     `json:"branch"`         // The conflicting MR branch
+	Head          string     `json:"head"`           // Its head when the conflict was found
+	Target        string     `json:"target"`         // The branch it conflicts with
+	Base          string     `json:"base"`           // Target SHA the resolution starts from
+	ResolveBranch string     `json:"resolve_branch"` // Branch the agent commits the resolution to
+	Worktree      string     `json:"worktree"`       // Scratch worktree holding the conflicted merge
+	Files         []string   `json:"files"`          // Conflicting files
+	Slung         bool       `json:"slung"`          // Formula reached an agent; false leaves the task for dispatch
+	RequestedAt   time.Time  `json:"requested_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+}
+
+func (e *Engineer) conflictRequestsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "conflict-requests.json")
+}
+
+// ConflictRequests returns the conflict resolutions raised so far, keyed
+// by MR ID.
+func (e *Engineer) ConflictRequests() (map[string]*ConflictRequest, error) {
+	data, err := os.ReadFile(e.conflictRequestsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]*ConflictRequest), nil
+		}
+		return nil, err
+	}
+	reqs := make(map[string]*ConflictRequest)
+	if err := json.Unmarshal(data, &reqs); err != nil {
+		return nil, fmt.Errorf("parsing conflict requests: %w", err)
+	}
+	return reqs, nil
+}
+
+// requestConflictResolutions hands the MRs that conflicted while stacking
+// for target to agents, when conflict resolution is enabled. Each MR that
+// conflicts with target itself (not just with MRs stacked ahead of it) is
+// held: it is blocked on a resolution task and has HeldFor set. An MR whose
+// last resolution was declined, or that has used up its attempts, is left
+// to its author, as are MRs whose branches live in Gerrit or GitHub.
+func (e *Engineer) requestConflictResolutions(conflicts []*MRInfo, target string) {
+	cfg := e.config.ConflictResolution
+	if cfg == nil || !cfg.Enabled || len(conflicts) == 0 {
+		return
+	}
+	defer e.lockState()()
+	reqs, err := e.ConflictRequests()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Warning: %v\n", err)
+		return
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Warning: resolving origin/%s: %v\n", target, err)
+		return
+	}
+
+	changed := false
+	for _, mr := range conflicts {
+		if mr.Change != nil || mr.PullRequest != nil {
+			continue
+		}
+		attempt := 1
+		if prev, ok := reqs[mr.ID]; ok {
+			if prev.ResolvedAt == nil {
+				if open, _ := e.IsBeadOpen(prev.Task); open {
+					continue
+				}
+				// The agent declined. Leave the MR to its author until
+				// they push a new head.
+				if head, err := e.git.Rev(e.branchHead(mr.Branch)); err != nil || head == prev.Head {
+					_, _ = fmt.Fprintf(e.output, "[ConflictResolution] MR %s: resolution %s declined, leaving to its author\n", mr.ID, prev.Task)
+					continue
+				}
+			}
+			attempt = prev.Attempt + 1
+		}
+		if attempt > cfg.maxAttempts() {
+			_, _ = fmt.Fprintf(e.output, "[ConflictResolution] MR %s: %d resolutions tried, leaving to its author\n", mr.ID, attempt-1)
+			continue
+		}
+		req, err := e.requestConflictResolution(mr, target, base, attempt, cfg)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Warning: MR %s: %v\n", mr.ID, err)
+			continue
+		}
+		if req == nil {
+			continue
+		}
+		reqs[mr.ID] = req
+		changed = true
+		mr.BlockedBy = req.Task
+		mr.HeldFor = HeldForConflict
+		e.recordProgress(StageHeld, "waiting on conflict resolution "+req.Task, "", mr)
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.conflictRequestsPath(), reqs); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Warning: saving conflict requests: %v\n", err)
+		}
+	}
+}
+
+// conflictWorktree returns the scratch worktree for an MR's resolution.
+func (e *Engineer) conflictWorktree(mrID string) string {
+	return filepath.Join(e.rig.Path, ".runtime", "conflicts", mrID)
+}
+
+// requestConflictResolution merges mr onto base in a scratch worktree on
+// a new resolve branch, leaving the conflict markers in place, then
+// creates the resolution task, blocks mr on it and slings the formula.
+// It returns nil, without a request, if mr merges cleanly onto base: the
+// conflict was with an MR stacked ahead of it, and mr is retried after
+// that one lands.
+func (e *Engineer) requestConflictResolution(mr *MRInfo, target, base string, attempt int, cfg *ConflictResolutionConfig) (*ConflictRequest, error) {
+	head, err := e.git.Rev(e.branchHead(mr.Branch))
+	if err != nil {
+		return nil, fmt.Errorf("branch %s not found", mr.Branch)
+	}
+	dir := e.conflictWorktree(mr.ID)
+	e.removeConflictWorktree(dir)
+	resolveBranch := fmt.Sprintf("resolve/%s-%d", mr.ID, attempt)
+	_ = e.git.DeleteBranch(resolveBranch, true)
+	if err := e.git.WorktreeAddFromRef(dir, resolveBranch, base); err != nil {
+		return nil, fmt.Errorf("creating scratch worktree: %w", err)
+	}
+
+	g := git.NewGit(dir)
+	mergeErr := g.MergeNoFF(head, fmt.Sprintf("Merge %s into %s (conflicts resolved)", mr.Branch, target))
+	if mergeErr == nil {
+		_, _ = fmt.Fprintf(e.output, "[ConflictResolution] MR %s merges cleanly onto %s; retrying after the MRs ahead of it land\n", mr.ID, target)
+		e.removeConflictWorktree(dir)
+		_ = e.git.DeleteBranch(resolveBranch, true)
+		return nil, nil
+	}
+	files, err := g.GetConflictingFiles()
+	if err != nil || len(files) == 0 {
+		e.removeConflictWorktree(dir)
+		_ = e.git.DeleteBranch(resolveBranch, true)
+		return nil, fmt.Errorf("merging %s: %v", mr.Branch, mergeErr)
+	}
+	hunks, _ := g.DiffUnmerged()
+	hunksPath := dir + ".diff"
+	if err := os.WriteFile(hunksPath, []byte(hunks+"\n"), 0644); err != nil { //nolint:gosec // G306: diff of the rig's own code
+		_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Warning: saving hunks: %v\n", err)
+	}
+
+	description := fmt.Sprintf(`Resolve merge conflicts between %s and %s
+
+## Metadata
+- Original MR: %s
+- Branch: %s
+- Target: %s@%s
+- Original issue: %s
+- Attempt: %d of %d
+- Worktree: %s
+- Resolve branch: %s
+- Conflicting files: %s
+
+## Conflicting hunks
+%s
+
+## Instructions
+The refinery merged the MR onto its target in the scratch worktree above and
+stopped at the conflicts. Resolve them there, keeping the intent of both
+sides, and commit the merge:
+
+    cd %s
+    git add <files> && git commit --no-edit
+
+Then run:
+
+    gt mq resolve %s
+
+That checks the resolution is clean, runs the rig's gates on it, and
+re-queues the MR on the resolve branch. If the conflict can't be resolved
+safely, close this task with a reason; the MR goes back to its author.`,
+		mr.Branch, target,
+		mr.ID,
+		mr.Branch,
+		target, shortSHA(base),
+		mr.SourceIssue,
+		attempt, cfg.maxAttempts(),
+		dir,
+		resolveBranch,
+		strings.Join(files, ", "),
+		quoteHunks(hunks, hunksPath),
+		dir,
+		mr.ID,
+	)
+	title := mr.Title
+	if title == "" {
+		title = mr.Branch
+	}
+	task, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Resolve MR conflicts: " + title,
+		Labels:      []string{"gt:task"},
+		Priority:    mr.Priority,
+		Description: description,
+		Actor:       e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		e.removeConflictWorktree(dir)
+		return nil, fmt.Errorf("creating conflict resolution task: %w", err)
+	}
+	if err := e.beads.AddDependency(mr.ID, task.ID); err != nil {
+		return nil, fmt.Errorf("blocking MR on conflict task %s: %w", task.ID, err)
+	}
+
+	req := &ConflictRequest{
+		MR:            mr.ID,
+		Task:          task.ID,
+		Attempt:       attempt,
+		Branch:        mr.Branch,
+		Head:          head,
+		Target:        target,
+		Base:          base,
+		ResolveBranch: resolveBranch,
+		Worktree:      dir,
+		Files:         files,
+		RequestedAt:   time.Now().UTC(),
+	}
+	assignee := cfg.Assignee
+	if assignee == "" {
+		assignee = e.rig.Name
+	}
+	slingCmd := exec.Command("gt", "sling", cfg.formula(), assignee, "--on", task.ID, //nolint:gosec // G204: formula is from trusted rig config
+		"--var", "task="+task.ID,
+		"--var", "original_mr="+mr.ID,
+		"--var", "branch="+mr.Branch,
+		"--var", "base_branch="+target,
+		"--var", "worktree="+dir,
+		"--var", "resolve_branch="+resolveBranch,
+		"--var", "attempt="+strconv.Itoa(attempt))
+	slingCmd.Dir = e.workDir
+	if out, err := slingCmd.CombinedOutput(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Warning: sling %s to %s: %v: %s (task left for dispatch)\n",
+			cfg.formula(), assignee, err, strings.TrimSpace(string(out)))
+	} else {
+		req.Slung = true
+	}
+	_, _ = fmt.Fprintf(e.output, "[ConflictResolution] MR %s held: %d conflicting files, blocked on %s\n",
+		mr.ID, len(files), task.ID)
+	return req, nil
+}
+
+// quoteHunks returns hunks as an indented block for a task description,
+// cut short if long.
+func quoteHunks(hunks, path string) string {
+	lines := strings.Split(strings.TrimRight(hunks, "\n"), "\n")
+	more := ""
+	if len(lines) > conflictHunkLines {
+		more = fmt.Sprintf("\n(%d more lines in %s)", len(lines)-conflictHunkLines, path)
+		lines = lines[:conflictHunkLines]
+	}
+	return "    " + strings.Join(lines, "\n    ") + more
+}
+
+func (e *Engineer) removeConflictWorktree(dir string) {
+	_ = e.git.WorktreeRemove(dir, true)
+	_ = os.RemoveAll(dir)
+	_ = e.git.WorktreePrune()
+}
+
+// checkResolution verifies the agent's resolution in req's worktree: the
+// merge is committed with nothing left over, no conflict markers remain in
+// the conflicting files, and the resolve branch builds on the base and
+// contains the MR's commits.
+func checkResolution(req *ConflictRequest) error {
+	g := git.NewGit(req.Worktree)
+	if _, err := g.Rev("MERGE_HEAD"); err == nil {
+		return fmt.Errorf("the merge in %s is not committed yet: resolve the conflicts, git add them and commit", req.Worktree)
+	}
+	if dirty, err := g.HasUncommittedChanges(); err != nil {
+		return err
+	} else if dirty {
+		return fmt.Errorf("%s has uncommitted changes: commit or discard them", req.Worktree)
+	}
+	if branch, err := g.CurrentBranch(); err != nil || branch != req.ResolveBranch {
+		return fmt.Errorf("%s must be on %s, is on %q", req.Worktree, req.ResolveBranch, branch)
+	}
+	var marked []string
+	for _, file := range req.Files {
+		if hasConflictMarkers(filepath.Join(req.Worktree, file)) {
+			marked = append(marked, file)
+		}
+	}
+	if len(marked) > 0 {
+		return fmt.Errorf("conflict markers left in %s", strings.Join(marked, ", "))
+	}
+	for what, ref := range map[string]string{"the target": req.Base, req.Branch: req.Head} {
+		ok, err := g.IsAncestor(ref, "HEAD")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("the resolution must build on %s (%s): merge it, don't rebase or drop it", what, shortSHA(ref))
+		}
+	}
+	return nil
+}
+
+// hasConflictMarkers reports whether a file still has conflict markers. A
+// file the resolution deleted has none.
+func hasConflictMarkers(path string) bool {
+	f, err := os.Open(path) //nolint:gosec // G304: path is a conflicting file in the scratch worktree
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveConflict accepts an agent's resolution of mrID's conflicts. The
+// resolution is checked (see checkResolution) and gated like a batch on
+// the MR's target; if it passes, the resolve branch is pushed, the MR is
+// re-pointed at it and its resolution task closed, which returns the MR to
+// the queue. The scratch worktree is removed; the resolve branch stays.
+//
+// On failure nothing changes, so the agent can fix the resolution and run
+// it again.
+func (e *Engineer) ResolveConflict(ctx context.Context, mrID string) (*MRInfo, error) {
+	reqs, err := e.ConflictRequests()
+	if err != nil {
+		return nil, err
+	}
+	req, ok := reqs[mrID]
+	if !ok || req.ResolvedAt != nil {
+		return nil, fmt.Errorf("no conflict resolution open for %s", mrID)
+	}
+	if err := checkResolution(req); err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Running %s gates on the resolution of %s...\n", req.Target, mrID)
+	if result := e.runTargetGatesIn(ctx, req.Worktree, req.Target); !result.Success {
+		return nil, fmt.Errorf("gates failed on the resolution: %s", result.Error)
+	}
+
+	g := git.NewGit(req.Worktree)
+	if err := g.Push("origin", req.ResolveBranch, true); err != nil {
+		return nil, fmt.Errorf("pushing %s: %w", req.ResolveBranch, err)
+	}
+	issue, err := e.beads.Show(mrID)
+	if err != nil {
+		return nil, fmt.Errorf("loading MR %s: %w", mrID, err)
+	}
+	fields := beads.ParseMRFields(issue)
+	if fields == nil {
+		return nil, fmt.Errorf("%s is not a merge request", mrID)
+	}
+	fields.Branch = req.ResolveBranch
+	fields.RetryCount = req.Attempt
+	fields.LastConflictSHA = req.Base
+	fields.ConflictTaskID = req.Task
+	desc := beads.SetMRFields(issue, fields)
+	if err := e.beads.Update(mrID, beads.UpdateOptions{Description: &desc}); err != nil {
+		return nil, fmt.Errorf("re-pointing MR %s at %s: %w", mrID, req.ResolveBranch, err)
+	}
+	if err := e.beads.CloseWithReason("resolved: MR re-queued on "+req.ResolveBranch, req.Task); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Warning: closing task %s: %v\n", req.Task, err)
+	}
+
+	e.removeConflictWorktree(req.Worktree)
+	now := time.Now().UTC()
+	req.ResolvedAt = &now
+	if err := util.EnsureDirAndWriteJSON(e.conflictRequestsPath(), reqs); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[ConflictResolution] Warning: saving conflict requests: %v\n", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[ConflictResolution] MR %s re-queued on %s\n", mrID, req.ResolveBranch)
+	return issueToMRInfo(issue, fields), nil
+}
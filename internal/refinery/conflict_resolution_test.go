@@ -0,0 +1,125 @@
+package refinery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gitpkg "github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestEngineer_LoadConfig_ConflictResolution(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"conflict_resolution": {"enabled": true, "assignee": "gastown/crew/max"}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cr := e.config.ConflictResolution
+	if cr == nil || !cr.Enabled || cr.Assignee != "gastown/crew/max" || cr.maxAttempts() != DefaultConflictMaxAttempts {
+		t.Fatalf("ConflictResolution = %+v", cr)
+	}
+	if cr.formula() != "mol-resolve-conflict" {
+		t.Errorf("formula() = %q, want mol-resolve-conflict", cr.formula())
+	}
+
+	data = []byte(`{"merge_queue": {"conflict_resolution": {"enabled": true, "max_attempts": -1}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+		t.Error("expected error for negative max_attempts")
+	}
+}
+
+func TestRequestConflictResolution_CleanOntoTarget(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	cfg := &ConflictResolutionConfig{Enabled: true}
+
+	// The MR only conflicted with a batch-mate: it merges cleanly onto the
+	// target, so no agent is needed.
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	run(t, workDir, "git", "push", "origin", "polecat/a")
+	base, err := g.Rev("origin/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := makeMR("mr-1", "polecat/a", "main")
+	req, err := e.requestConflictResolution(mr, "main", base, 1, cfg)
+	if err != nil || req != nil {
+		t.Fatalf("requestConflictResolution = %+v, %v; want no request", req, err)
+	}
+	if _, err := os.Stat(e.conflictWorktree("mr-1")); !os.IsNotExist(err) {
+		t.Errorf("scratch worktree left behind: %v", err)
+	}
+	if exists, _ := g.BranchExists("resolve/mr-1-1"); exists {
+		t.Error("resolve branch left behind")
+	}
+}
+
+func TestCheckResolution(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+
+	createConflictingBranch(t, workDir, "polecat/a", "README.md", "# Branch\n")
+	writeFile(t, workDir, "README.md", "# Main\n")
+	run(t, workDir, "git", "commit", "-am", "main: retitle")
+	run(t, workDir, "git", "push", "origin", "main")
+	base, _ := g.Rev("main")
+	head, _ := g.Rev("polecat/a")
+
+	dir := e.conflictWorktree("mr-1")
+	if err := g.WorktreeAddFromRef(dir, "resolve/mr-1-1", base); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.removeConflictWorktree(dir) })
+	wg := gitpkg.NewGit(dir)
+	if err := wg.MergeNoFF(head, "Merge polecat/a into main"); err == nil {
+		t.Fatal("expected a conflict")
+	}
+	req := &ConflictRequest{MR: "mr-1", Branch: "polecat/a", Head: head, Target: "main", Base: base,
+		ResolveBranch: "resolve/mr-1-1", Worktree: dir, Files: []string{"README.md"}}
+
+	if err := checkResolution(req); err == nil || !strings.Contains(err.Error(), "not committed") {
+		t.Errorf("mid-merge: err = %v, want not committed", err)
+	}
+
+	// Committing the markers is caught.
+	run(t, dir, "git", "commit", "-am", "merge with markers")
+	if err := checkResolution(req); err == nil || !strings.Contains(err.Error(), "conflict markers") {
+		t.Errorf("markers committed: err = %v, want conflict markers", err)
+	}
+
+	writeFile(t, dir, "README.md", "# Main branch\n")
+	run(t, dir, "git", "commit", "-am", "resolve README")
+	if err := checkResolution(req); err != nil {
+		t.Errorf("resolved: %v", err)
+	}
+
+	// A resolution that drops the MR's commits is rejected.
+	run(t, dir, "git", "reset", "--hard", base)
+	if err := checkResolution(req); err == nil || !strings.Contains(err.Error(), "polecat/a") {
+		t.Errorf("MR dropped: err = %v, want it to mention polecat/a", err)
+	}
+}
+
+func TestHasConflictMarkers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "marked.go", "a\n<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> feature\n")
+	writeFile(t, dir, "clean.go", "// <<<<<<< in a comment is fine\nx := \"=======\"\n")
+	if !hasConflictMarkers(filepath.Join(dir, "marked.go")) {
+		t.Error("markers not found in marked.go")
+	}
+	if hasConflictMarkers(filepath.Join(dir, "clean.go")) {
+		t.Error("markers found in clean.go")
+	}
+	if hasConflictMarkers(filepath.Join(dir, "deleted.go")) {
+		t.Error("markers found in a deleted file")
+	}
+}
@@ -0,0 +1,32 @@
+package refinery
+
+// QueueStatus summarizes the state of a rig's merge queue that operators
+// steer (see gt refinery status). The queue itself is in beads; see
+// ListReadyMRs.
+type QueueStatus struct {
+	Rig         string        `json:"rig"`
+	Paused      *PauseState   `json:"paused,omitempty"`
+	Preemptions []*Preemption `json:"preemptions,omitempty"`
+	LastBatch   *BatchRecord  `json:"last_batch,omitempty"`
+}
+
+// QueueStatus returns the merge queue's status: its pause, pending
+// preemptions and the last batch processed.
+func (e *Engineer) QueueStatus() (*QueueStatus, error) {
+	status := &QueueStatus{Rig: e.rig.Name}
+	var err error
+	if status.Paused, err = e.Paused(); err != nil {
+		return nil, err
+	}
+	if status.Preemptions, err = e.Preemptions(); err != nil {
+		return nil, err
+	}
+	last, err := e.History(HistoryQuery{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(last) == 1 {
+		status.LastBatch = last[0]
+	}
+	return status, nil
+}
@@ -0,0 +1,196 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Culprit feedback channels (see CulpritFeedbackConfig.Via).
+const (
+	FeedbackViaNudge   = "nudge"
+	FeedbackViaComment = "comment"
+	FeedbackViaBoth    = "both"
+)
+
+// DefaultFeedbackExcerptLines is how much of a failing gate's log culprit
+// feedback quotes by default.
+const DefaultFeedbackExcerptLines = 20
+
+// CulpritFeedbackConfig sends each MR a batch blames for failing its gates
+// straight back to the agent that wrote it, with what it needs to fix it
+// without a human dispatching the work: the failing gates, the end of
+// their logs, and the stacked commit they failed on.
+type CulpritFeedbackConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Via is how feedback is sent: "nudge" (default) types it into the
+	// author's session with gt nudge, "comment" adds it to the MR's source
+	// issue (or the MR bead when it has none), "both" does both.
+	Via string `json:"via,omitempty"`
+
+	// ExcerptLines is how many lines from the end of each failing gate's
+	// log are quoted. Default: DefaultFeedbackExcerptLines.
+	ExcerptLines int `json:"excerpt_lines,omitempty"`
+}
+
+// validateCulpritFeedback checks Via and ExcerptLines.
+func validateCulpritFeedback(cfg *CulpritFeedbackConfig) error {
+	switch cfg.Via {
+	case "", FeedbackViaNudge, FeedbackViaComment, FeedbackViaBoth:
+	default:
+		return fmt.Errorf("culprit_feedback: invalid via %q: want nudge, comment or both", cfg.Via)
+	}
+	if cfg.ExcerptLines < 0 {
+		return fmt.Errorf("culprit_feedback excerpt_lines must be non-negative, got %d", cfg.ExcerptLines)
+	}
+	return nil
+}
+
+func (c *CulpritFeedbackConfig) via() string {
+	if c.Via != "" {
+		return c.Via
+	}
+	return FeedbackViaNudge
+}
+
+func (c *CulpritFeedbackConfig) excerptLines() int {
+	if c.ExcerptLines > 0 {
+		return c.ExcerptLines
+	}
+	return DefaultFeedbackExcerptLines
+}
+
+// CulpritFeedback tells the author of a culprit MR why it was blamed.
+type CulpritFeedback struct {
+	MR      string
+	Branch  string
+	Issue   string // The MR's source issue
+	BatchID string
+	Target  string
+	Gates   []*FailedGate // Empty when only the legacy test command ran
+}
+
+// FailedGate is one gate that failed on a culprit's stacked tree.
+type FailedGate struct {
+	Name    string
+	SHA     string // Stacked commit the gate failed on
+	Log     string // Path of the full log
+	Excerpt string // Last lines of the log's output
+}
+
+// Nudge renders f on one line, for typing into an agent's session.
+func (f *CulpritFeedback) Nudge() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GATES_FAILED: mr=%s branch=%s issue=%s batch=%s target=%s", f.MR, f.Branch, f.Issue, f.BatchID, f.Target)
+	for _, g := range f.Gates {
+		fmt.Fprintf(&b, " | gate=%s repro=%s log=%s", g.Name, g.SHA, g.Log)
+		if g.Excerpt != "" {
+			excerpt := strings.Join(strings.Fields(g.Excerpt), " ")
+			if len(excerpt) > 300 {
+				excerpt = "…" + excerpt[len(excerpt)-300:]
+			}
+			fmt.Fprintf(&b, " output: %s", excerpt)
+		}
+	}
+	b.WriteString(" — check out the repro commit, fix and resubmit with 'gt done'")
+	return b.String()
+}
+
+// Comment renders f as a bead comment.
+func (f *CulpritFeedback) Comment() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Merge queue: MR %s (%s) failed gates in batch %s targeting %s and was removed from the batch.\n", f.MR, f.Branch, f.BatchID, f.Target)
+	if len(f.Gates) == 0 {
+		b.WriteString("\nNo gate logs were kept; rerun the rig's test command on the branch rebased onto the target.\n")
+	}
+	for _, g := range f.Gates {
+		fmt.Fprintf(&b, "\nGate %s failed on stacked commit %s (full log: %s).\n", g.Name, g.SHA, g.Log)
+		fmt.Fprintf(&b, "Reproduce with: git checkout %s\n", g.SHA)
+		if g.Excerpt != "" {
+			fmt.Fprintf(&b, "\n```\n%s\n```\n", g.Excerpt)
+		}
+	}
+	return b.String()
+}
+
+// sendCulpritFeedback sends feedback to the author of each culprit in
+// result (see CulpritFeedbackConfig). Failures are logged and otherwise
+// ignored.
+func (e *Engineer) sendCulpritFeedback(result *BatchResult, target string) {
+	cfg := e.config.CulpritFeedback
+	if cfg == nil || !cfg.Enabled || result == nil {
+		return
+	}
+	for _, mr := range result.Culprits {
+		fb := &CulpritFeedback{
+			MR:      mr.ID,
+			Branch:  mr.Branch,
+			Issue:   mr.SourceIssue,
+			BatchID: result.BatchID,
+			Target:  target,
+		}
+		for _, l := range result.GateLogs {
+			if l.MR != mr.ID || l.Success {
+				continue
+			}
+			fb.Gates = append(fb.Gates, &FailedGate{
+				Name:    l.Gate,
+				SHA:     l.SHA,
+				Log:     l.Path,
+				Excerpt: logExcerpt(l.Path, cfg.excerptLines()),
+			})
+		}
+		if err := e.deliverFeedback(mr, fb); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Batch] Warning: culprit feedback for %s: %v\n", mr.ID, err)
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Batch] Sent culprit feedback for %s (%d failed gates)\n", mr.ID, len(fb.Gates))
+	}
+}
+
+// deliverCulpritFeedback sends fb about mr by the configured channels.
+// Nudges are skipped for MRs without a worker.
+func (e *Engineer) deliverCulpritFeedback(mr *MRInfo, fb *CulpritFeedback) error {
+	via := e.config.CulpritFeedback.via()
+	if via != FeedbackViaComment && mr.Worker != "" {
+		polecatName := strings.TrimPrefix(mr.Worker, "polecats/")
+		nudgeCmd := exec.Command("gt", "nudge", fmt.Sprintf("%s/%s", e.rig.Name, polecatName), fb.Nudge())
+		nudgeCmd.Dir = e.workDir
+		if err := nudgeCmd.Run(); err != nil {
+			return fmt.Errorf("nudging %s: %w", polecatName, err)
+		}
+	}
+	if via != FeedbackViaNudge && e.beads != nil {
+		id := mr.SourceIssue
+		if id == "" {
+			id = mr.ID
+		}
+		if _, err := e.beads.Run("comments", "add", id, fb.Comment()); err != nil {
+			return fmt.Errorf("commenting on %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// logExcerpt returns the last n non-blank output lines of the gate log at
+// path (see saveGateLog), or "" if it can't be read.
+func logExcerpt(path string, n int) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	_, output, _ := strings.Cut(string(data), "\n--- stdout ---\n")
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" || line == "--- stderr ---" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
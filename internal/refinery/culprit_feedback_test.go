@@ -0,0 +1,72 @@
+package refinery
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestProcessBatch_CulpritFeedback(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{
+		"test": {Cmd: "echo checking tree; " + failMarkerGateCmd() + " || { echo marker found >&2; exit 1; }"},
+	}
+	e.config.CulpritFeedback = &CulpritFeedbackConfig{Enabled: true}
+	sent := make(map[string]*CulpritFeedback)
+	e.deliverFeedback = func(mr *MRInfo, fb *CulpritFeedback) error {
+		sent[mr.ID] = fb
+		return nil
+	}
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if len(result.Culprits) != 1 || result.Culprits[0].ID != "mr-b" {
+		t.Fatalf("culprits = %v, want [mr-b]", stackedIDs(result.Culprits))
+	}
+
+	fb := sent["mr-b"]
+	if len(sent) != 1 || fb == nil {
+		t.Fatalf("feedback sent for %v, want mr-b only", sent)
+	}
+	if fb.BatchID != result.BatchID || fb.Target != "main" || len(fb.Gates) != 1 {
+		t.Fatalf("feedback = %+v", fb)
+	}
+	gate := fb.Gates[0]
+	if gate.Name != "test" || !strings.Contains(gate.Excerpt, "marker found") || strings.Contains(gate.Excerpt, "# result") {
+		t.Errorf("failed gate = %+v", gate)
+	}
+	// The repro commit is the stacked tree the gate failed on: both MRs.
+	run(t, workDir, "git", "cat-file", "-e", gate.SHA+":FAIL_MARKER")
+	run(t, workDir, "git", "cat-file", "-e", gate.SHA+":a.txt")
+
+	for _, msg := range []string{fb.Nudge(), fb.Comment()} {
+		for _, want := range []string{gate.SHA, "mr-b", "marker found"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("message missing %q:\n%s", want, msg)
+			}
+		}
+	}
+	if strings.Contains(fb.Nudge(), "\n") {
+		t.Errorf("nudge spans lines:\n%s", fb.Nudge())
+	}
+}
+
+func TestLoadConfig_CulpritFeedback(t *testing.T) {
+	cfg := DefaultMergeQueueConfig()
+	if err := applyMergeQueueConfig(cfg, []byte(`{"culprit_feedback": {"enabled": true, "via": "both", "excerpt_lines": 5}}`)); err != nil {
+		t.Fatalf("applyMergeQueueConfig: %v", err)
+	}
+	if c := cfg.CulpritFeedback; c == nil || c.via() != FeedbackViaBoth || c.excerptLines() != 5 {
+		t.Errorf("culprit_feedback = %+v", cfg.CulpritFeedback)
+	}
+	for _, bad := range []string{`{"via": "mail"}`, `{"excerpt_lines": -1}`} {
+		if err := applyMergeQueueConfig(DefaultMergeQueueConfig(), []byte(`{"culprit_feedback": `+bad+`}`)); err == nil {
+			t.Errorf("culprit_feedback %s accepted", bad)
+		}
+	}
+}
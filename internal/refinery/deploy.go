@@ -0,0 +1,526 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// DeployConfig configures post-merge deployments.
+type DeployConfig struct {
+	// Environments are deploy targets, in promotion order by convention
+	// (e.g. staging, then production).
+	Environments []*DeployEnvironment `json:"environments"`
+}
+
+// DeployEnvironment is one deploy target. Exactly one of Command, Webhook
+// or Molecule says how to deploy.
+type DeployEnvironment struct {
+	Name string `json:"name"`
+
+	// Branch is the target branch whose merges deploy here.
+	// Default: the rig's default branch.
+	Branch string `json:"branch,omitempty"`
+
+	// Command runs via sh -c in the refinery clone with GT_DEPLOY_*
+	// variables describing the deploy.
+	Command string `json:"command,omitempty"`
+
+	// Webhook receives a JSON POST describing the deploy (a CD trigger).
+	// Any non-2xx response fails the deploy.
+	Webhook string `json:"webhook,omitempty"`
+
+	// Molecule is a formula poured as a wisp with the deploy as vars, for
+	// deploys an agent carries out. The deploy is recorded as triggered.
+	Molecule string `json:"molecule,omitempty"`
+
+	// Timeout bounds the command or webhook. Default: 10m.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// PromoteFrom makes this environment a promotion target: it deploys
+	// a commit only after PromoteFrom deployed it successfully, never
+	// directly on merge.
+	PromoteFrom string `json:"promote_from,omitempty"`
+
+	// PromoteAfter is the soak time after the PromoteFrom deploy before
+	// promotion is due.
+	PromoteAfter time.Duration `json:"promote_after,omitempty"`
+
+	// Manual disables automatic promotion; gt deploy promote <env> is
+	// required.
+	Manual bool `json:"manual,omitempty"`
+}
+
+type deployConfigRaw struct {
+	Environments []*deployEnvironmentRaw `json:"environments"`
+}
+
+type deployEnvironmentRaw struct {
+	DeployEnvironment
+	Timeout      string `json:"timeout"`
+	PromoteAfter string `json:"promote_after"`
+}
+
+// defaultDeployTimeout bounds deploy commands and webhooks.
+const defaultDeployTimeout = 10 * time.Minute
+
+// Deployment statuses.
+const (
+	DeploySucceeded = "succeeded"
+	DeployFailed    = "failed"
+	DeployTriggered = "triggered" // Handed off to a molecule
+)
+
+// deploySeq disambiguates deployment IDs within one second.
+var deploySeq uint64
+
+// maxDeployHistory bounds the deployments kept per rig.
+const maxDeployHistory = 200
+
+// Deployment records one deploy of a commit to an environment.
+type Deployment struct {
+	ID          string    `json:"id"`
+	Environment string    `json:"environment"`
+	Commit      string    `json:"commit"`
+	Target      string    `json:"target"`
+	BatchID     string    `json:"batch_id,omitempty"` // Rollback journal of the merge that produced Commit
+	MRs         []string  `json:"mrs,omitempty"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	Wisp        string    `json:"wisp,omitempty"` // Molecule deploys
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	PromotedBy  string    `json:"promoted_by,omitempty"` // Deployment ID this was promoted from
+	RollbackOf  string    `json:"rollback_of,omitempty"` // Deployment ID this deploy rolled back
+	RolledBack  bool      `json:"rolled_back,omitempty"`
+}
+
+// parseDeployConfig converts the JSON deploy config, parsing durations.
+func parseDeployConfig(raw *deployConfigRaw) (*DeployConfig, error) {
+	cfg := &DeployConfig{}
+	seen := make(map[string]bool)
+	for _, r := range raw.Environments {
+		if r == nil {
+			continue
+		}
+		env := r.DeployEnvironment
+		if env.Name == "" {
+			return nil, fmt.Errorf("deploy environment without a name")
+		}
+		if seen[env.Name] {
+			return nil, fmt.Errorf("deploy environment %q declared twice", env.Name)
+		}
+		seen[env.Name] = true
+		kinds := 0
+		for _, s := range []string{env.Command, env.Webhook, env.Molecule} {
+			if s != "" {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			return nil, fmt.Errorf("deploy environment %q needs exactly one of command, webhook, molecule", env.Name)
+		}
+		if r.Timeout != "" {
+			d, err := time.ParseDuration(r.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for deploy environment %q: %w", env.Name, err)
+			}
+			env.Timeout = d
+		}
+		if r.PromoteAfter != "" {
+			d, err := time.ParseDuration(r.PromoteAfter)
+			if err != nil {
+				return nil, fmt.Errorf("invalid promote_after for deploy environment %q: %w", env.Name, err)
+			}
+			env.PromoteAfter = d
+		}
+		cfg.Environments = append(cfg.Environments, &env)
+	}
+	for _, env := range cfg.Environments {
+		if env.PromoteFrom != "" && !seen[env.PromoteFrom] {
+			return nil, fmt.Errorf("deploy environment %q promotes from unknown environment %q", env.Name, env.PromoteFrom)
+		}
+	}
+	return cfg, nil
+}
+
+// DeployEnvironment returns the named environment.
+func (e *Engineer) DeployEnvironment(name string) (*DeployEnvironment, bool) {
+	if e.config.Deploy == nil {
+		return nil, false
+	}
+	for _, env := range e.config.Deploy.Environments {
+		if env.Name == name {
+			return env, true
+		}
+	}
+	return nil, false
+}
+
+func (e *Engineer) deployPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "deployments.json")
+}
+
+// Deployments returns recorded deployments, oldest first.
+func (e *Engineer) Deployments() ([]*Deployment, error) {
+	data, err := os.ReadFile(e.deployPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var deps []*Deployment
+	if err := json.Unmarshal(data, &deps); err != nil {
+		return nil, fmt.Errorf("parsing deployments: %w", err)
+	}
+	return deps, nil
+}
+
+func (e *Engineer) saveDeployments(deps []*Deployment) error {
+	if len(deps) > maxDeployHistory {
+		deps = deps[len(deps)-maxDeployHistory:]
+	}
+	return util.EnsureDirAndWriteJSON(e.deployPath(), deps)
+}
+
+func (e *Engineer) recordDeployment(d *Deployment) error {
+	defer e.lockState()()
+	deps, err := e.Deployments()
+	if err != nil {
+		return err
+	}
+	return e.saveDeployments(append(deps, d))
+}
+
+// lastSuccessful returns the latest successful (or triggered) deployment to
+// env that has not been rolled back, optionally excluding one commit.
+func lastSuccessful(deps []*Deployment, env, excludeCommit string) *Deployment {
+	for i := len(deps) - 1; i >= 0; i-- {
+		d := deps[i]
+		if d.Environment != env || d.RolledBack || d.Commit == excludeCommit {
+			continue
+		}
+		if d.Status == DeploySucceeded || d.Status == DeployTriggered {
+			return d
+		}
+	}
+	return nil
+}
+
+// runDeployHooks deploys a successful merge to every environment fed
+// directly by target. Promotion targets wait for gt deploy promote.
+func (e *Engineer) runDeployHooks(ctx context.Context, result *BatchResult, target string) {
+	if e.config.Deploy == nil || result == nil || result.Error != nil || result.MergeCommit == "" {
+		return
+	}
+	var mrs []string
+	for _, mr := range result.Merged {
+		mrs = append(mrs, mr.ID)
+	}
+	for _, env := range e.config.Deploy.Environments {
+		if env.PromoteFrom != "" || e.deployBranch(env) != target {
+			continue
+		}
+		d := &Deployment{
+			Environment: env.Name,
+			Commit:      result.MergeCommit,
+			Target:      target,
+			BatchID:     result.BatchID,
+			MRs:         mrs,
+		}
+		if err := e.Deploy(ctx, env, d); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Deploy] Warning: %s: %v\n", env.Name, err)
+		}
+	}
+}
+
+func (e *Engineer) deployBranch(env *DeployEnvironment) string {
+	if env.Branch != "" {
+		return env.Branch
+	}
+	return e.rig.DefaultBranch()
+}
+
+// Deploy runs env's deploy hook for d and records the outcome. A failed
+// hook is recorded and returned as an error; the merge itself stands —
+// use RollbackDeployment to undo it.
+func (e *Engineer) Deploy(ctx context.Context, env *DeployEnvironment, d *Deployment) error {
+	d.Environment = env.Name
+	d.StartedAt = time.Now().UTC()
+	d.ID = fmt.Sprintf("deploy-%s-%s-%d", env.Name, d.StartedAt.Format("20060102T150405Z"), atomic.AddUint64(&deploySeq, 1))
+	_, _ = fmt.Fprintf(e.output, "[Deploy] %s ← %s\n", env.Name, shortSHA(d.Commit))
+
+	timeout := env.Timeout
+	if timeout <= 0 {
+		timeout = defaultDeployTimeout
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var err error
+	switch {
+	case env.Command != "":
+		err = e.deployCommand(hookCtx, env, d)
+	case env.Webhook != "":
+		err = e.deployWebhook(hookCtx, env, d)
+	case env.Molecule != "":
+		err = e.deployMolecule(hookCtx, env, d)
+	}
+	d.FinishedAt = time.Now().UTC()
+	switch {
+	case err != nil:
+		d.Status = DeployFailed
+		d.Error = err.Error()
+	case env.Molecule != "":
+		d.Status = DeployTriggered
+	default:
+		d.Status = DeploySucceeded
+	}
+	if recErr := e.recordDeployment(d); recErr != nil {
+		return fmt.Errorf("record deployment: %w", recErr)
+	}
+	if err != nil {
+		return fmt.Errorf("deploy %s to %s: %w", shortSHA(d.Commit), env.Name, err)
+	}
+	return nil
+}
+
+// deployEnv returns the GT_DEPLOY_* variables describing d.
+func (e *Engineer) deployEnv(d *Deployment) map[string]string {
+	return map[string]string{
+		"GT_DEPLOY_ENV":    d.Environment,
+		"GT_DEPLOY_COMMIT": d.Commit,
+		"GT_DEPLOY_TARGET": d.Target,
+		"GT_DEPLOY_BATCH":  d.BatchID,
+		"GT_DEPLOY_MRS":    strings.Join(d.MRs, ","),
+		"GT_DEPLOY_RIG":    e.rig.Name,
+	}
+}
+
+func (e *Engineer) deployCommand(ctx context.Context, env *DeployEnvironment, d *Deployment) error {
+	// Trust boundary: deploy commands come from the rig's config.json
+	// (operator-controlled), like gate commands.
+	cmd := exec.CommandContext(ctx, "sh", "-c", env.Command) //nolint:gosec // G204: deploy command is from trusted rig config
+	cmd.Dir = e.workDir
+	cmd.Env = os.Environ()
+	for k, v := range e.deployEnv(d) {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out")
+		}
+		msg := strings.TrimSpace(out.String())
+		if len(msg) > 500 {
+			msg = msg[len(msg)-500:]
+		}
+		return fmt.Errorf("%v: %s", err, msg)
+	}
+	return nil
+}
+
+func (e *Engineer) deployWebhook(ctx context.Context, env *DeployEnvironment, d *Deployment) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"rig":         e.rig.Name,
+		"environment": d.Environment,
+		"commit":      d.Commit,
+		"target":      d.Target,
+		"batch_id":    d.BatchID,
+		"mrs":         d.MRs,
+		"rollback_of": d.RollbackOf,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, env.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *Engineer) deployMolecule(ctx context.Context, env *DeployEnvironment, d *Deployment) error {
+	args := []string{"mol", "wisp", env.Molecule}
+	for k, v := range e.deployEnv(d) {
+		args = append(args, "--var", strings.ToLower(strings.TrimPrefix(k, "GT_DEPLOY_"))+"="+v)
+	}
+	cmd := exec.CommandContext(ctx, "bd", args...) //nolint:gosec // G204: molecule name is from trusted rig config
+	cmd.Dir = e.rig.Path
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pour %s: %v: %s", env.Molecule, err, strings.TrimSpace(string(out)))
+	}
+	d.Wisp = strings.TrimSpace(string(out))
+	if first, _, ok := strings.Cut(d.Wisp, "\n"); ok {
+		d.Wisp = first
+	}
+	return nil
+}
+
+// TriggerDeploy deploys commit to env on demand, bypassing promotion rules.
+// An empty commit deploys the head of env's branch on origin.
+func (e *Engineer) TriggerDeploy(ctx context.Context, env *DeployEnvironment, commit string) (*Deployment, error) {
+	branch := e.deployBranch(env)
+	if err := e.git.Fetch("origin"); err != nil {
+		return nil, fmt.Errorf("fetch origin: %w", err)
+	}
+	if commit == "" {
+		commit = "origin/" + branch
+	}
+	sha, err := e.git.Rev(commit)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", commit, err)
+	}
+	d := &Deployment{Commit: sha, Target: branch}
+	return d, e.Deploy(ctx, env, d)
+}
+
+// Promotion is a deploy waiting on a promotion rule.
+type Promotion struct {
+	Environment string
+	From        *Deployment // Source environment deployment being promoted
+	DueAt       time.Time
+}
+
+// PendingPromotions returns, for each promotion environment, the latest
+// source deployment it has not yet received. Due reports whether the
+// soak time has passed and the environment promotes automatically.
+func (e *Engineer) PendingPromotions(now time.Time) (pending []Promotion, due []Promotion, err error) {
+	if e.config.Deploy == nil {
+		return nil, nil, nil
+	}
+	deps, err := e.Deployments()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, env := range e.config.Deploy.Environments {
+		if env.PromoteFrom == "" {
+			continue
+		}
+		src := lastSuccessful(deps, env.PromoteFrom, "")
+		if src == nil || src.Status != DeploySucceeded {
+			continue
+		}
+		if cur := lastSuccessful(deps, env.Name, ""); cur != nil && cur.Commit == src.Commit {
+			continue
+		}
+		p := Promotion{Environment: env.Name, From: src, DueAt: src.FinishedAt.Add(env.PromoteAfter)}
+		pending = append(pending, p)
+		if !env.Manual && !now.Before(p.DueAt) {
+			due = append(due, p)
+		}
+	}
+	return pending, due, nil
+}
+
+// Promote deploys the latest successful deployment of env's PromoteFrom
+// environment to env.
+func (e *Engineer) Promote(ctx context.Context, envName string) (*Deployment, error) {
+	env, ok := e.DeployEnvironment(envName)
+	if !ok {
+		return nil, fmt.Errorf("unknown deploy environment %q", envName)
+	}
+	if env.PromoteFrom == "" {
+		return nil, fmt.Errorf("%s has no promote_from; it deploys on merge", envName)
+	}
+	deps, err := e.Deployments()
+	if err != nil {
+		return nil, err
+	}
+	src := lastSuccessful(deps, env.PromoteFrom, "")
+	if src == nil || src.Status != DeploySucceeded {
+		return nil, fmt.Errorf("nothing to promote: no successful %s deployment", env.PromoteFrom)
+	}
+	d := &Deployment{
+		Commit:     src.Commit,
+		Target:     src.Target,
+		BatchID:    src.BatchID,
+		MRs:        src.MRs,
+		PromotedBy: src.ID,
+	}
+	return d, e.Deploy(ctx, env, d)
+}
+
+// RollbackDeployment undoes a deployment: the batch that produced it is
+// reverted on the target branch with a new commit (see RevertBatch), every
+// environment running that commit is marked rolled back, and each is
+// redeployed at its previous good commit. Batches landed since are kept.
+func (e *Engineer) RollbackDeployment(ctx context.Context, id string) ([]*Deployment, error) {
+	deps, err := e.Deployments()
+	if err != nil {
+		return nil, err
+	}
+	var bad *Deployment
+	for _, d := range deps {
+		if d.ID == id {
+			bad = d
+		}
+	}
+	if bad == nil {
+		return nil, fmt.Errorf("deployment %s not found", id)
+	}
+	if bad.BatchID == "" {
+		return nil, fmt.Errorf("deployment %s has no batch to roll back", id)
+	}
+	if _, err := e.RevertBatch(ctx, bad.BatchID, "deployment "+id+" rolled back"); err != nil {
+		return nil, fmt.Errorf("revert batch %s: %w", bad.BatchID, err)
+	}
+
+	affected := make(map[string]bool)
+	for _, d := range deps {
+		if d.Commit == bad.Commit && !d.RolledBack {
+			d.RolledBack = true
+			affected[d.Environment] = true
+		}
+	}
+	if err := e.saveDeployments(deps); err != nil {
+		return nil, err
+	}
+
+	var redeploys []*Deployment
+	for _, env := range e.config.Deploy.Environments {
+		if !affected[env.Name] {
+			continue
+		}
+		prev := lastSuccessful(deps, env.Name, bad.Commit)
+		if prev == nil {
+			_, _ = fmt.Fprintf(e.output, "[Deploy] %s: no earlier deployment to restore\n", env.Name)
+			continue
+		}
+		d := &Deployment{
+			Commit:     prev.Commit,
+			Target:     prev.Target,
+			BatchID:    prev.BatchID,
+			MRs:        prev.MRs,
+			RollbackOf: bad.ID,
+		}
+		if err := e.Deploy(ctx, env, d); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Deploy] Warning: %v\n", err)
+		}
+		redeploys = append(redeploys, d)
+	}
+	return redeploys, nil
+}
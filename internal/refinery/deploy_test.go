@@ -0,0 +1,230 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestEngineer_LoadConfig_Deploy(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"deploy": {"environments": [
+		{"name": "staging", "command": "make deploy-staging", "timeout": "5m"},
+		{"name": "production", "webhook": "https://cd.example/hook", "promote_from": "staging", "promote_after": "1h"}
+	]}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	staging, ok := e.DeployEnvironment("staging")
+	if !ok || staging.Timeout != 5*time.Minute {
+		t.Errorf("staging = %+v", staging)
+	}
+	prod, ok := e.DeployEnvironment("production")
+	if !ok || prod.PromoteFrom != "staging" || prod.PromoteAfter != time.Hour {
+		t.Errorf("production = %+v", prod)
+	}
+}
+
+func TestEngineer_LoadConfig_DeployInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no hook":        `[{"name": "staging"}]`,
+		"two hooks":      `[{"name": "staging", "command": "x", "webhook": "http://x"}]`,
+		"unknown source": `[{"name": "prod", "command": "x", "promote_from": "staging"}]`,
+		"duplicate":      `[{"name": "a", "command": "x"}, {"name": "a", "command": "y"}]`,
+		"bad timeout":    `[{"name": "a", "command": "x", "timeout": "soon"}]`,
+	}
+	for name, envs := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			data := []byte(`{"merge_queue": {"deploy": {"environments": ` + envs + `}}}`)
+			if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+			if err := e.LoadConfig(); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestProcessBatch_RunsDeployCommand(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.rig.Path = t.TempDir() // Keep deploy records out of the work tree
+	marker := filepath.Join(t.TempDir(), "deployed")
+	e.config.Deploy = &DeployConfig{Environments: []*DeployEnvironment{
+		{Name: "staging", Command: `echo "$GT_DEPLOY_ENV $GT_DEPLOY_COMMIT $GT_DEPLOY_MRS" > ` + marker},
+		{Name: "production", Command: "true", PromoteFrom: "staging"},
+		{Name: "other", Command: "true", Branch: "release"},
+	}}
+
+	batch := []*MRInfo{makeMR("mr-a", "polecat/a", "main"), makeMR("mr-b", "polecat/b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+
+	got, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("deploy command did not run: %v", err)
+	}
+	if want := "staging " + result.MergeCommit + " mr-a,mr-b\n"; string(got) != want {
+		t.Errorf("deploy env = %q, want %q", got, want)
+	}
+
+	deps, err := e.Deployments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only staging deploys on merge: production waits for promotion and
+	// other follows a different branch.
+	if len(deps) != 1 {
+		t.Fatalf("deployments = %d, want 1", len(deps))
+	}
+	if d := deps[0]; d.Environment != "staging" || d.Status != DeploySucceeded || d.BatchID != result.BatchID {
+		t.Errorf("deployment = %+v", d)
+	}
+}
+
+// newDeployEngineer returns an engineer for deploys that don't touch git.
+func newDeployEngineer(t *testing.T) *Engineer {
+	t.Helper()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.workDir = t.TempDir()
+	e.SetOutput(&bytes.Buffer{})
+	return e
+}
+
+func TestDeploy_Webhook(t *testing.T) {
+	var payload map[string]interface{}
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	e := newDeployEngineer(t)
+	env := &DeployEnvironment{Name: "staging", Webhook: srv.URL}
+
+	if err := e.Deploy(context.Background(), env, &Deployment{Commit: "abc123", Target: "main"}); err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+	if payload["commit"] != "abc123" || payload["environment"] != "staging" || payload["rig"] != "test-rig" {
+		t.Errorf("payload = %v", payload)
+	}
+
+	status = http.StatusBadGateway
+	err := e.Deploy(context.Background(), env, &Deployment{Commit: "def456", Target: "main"})
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("expected 502 failure, got %v", err)
+	}
+	deps, _ := e.Deployments()
+	if len(deps) != 2 || deps[1].Status != DeployFailed {
+		t.Errorf("failed webhook not recorded: %+v", deps)
+	}
+}
+
+func TestPromotion(t *testing.T) {
+	e := newDeployEngineer(t)
+	e.config.Deploy = &DeployConfig{Environments: []*DeployEnvironment{
+		{Name: "staging", Command: "true"},
+		{Name: "production", Command: "true", PromoteFrom: "staging", PromoteAfter: time.Hour},
+	}}
+	staging, _ := e.DeployEnvironment("staging")
+
+	if _, err := e.Promote(context.Background(), "production"); err == nil {
+		t.Fatal("expected error promoting with nothing deployed to staging")
+	}
+	if err := e.Deploy(context.Background(), staging, &Deployment{Commit: "abc123", Target: "main"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, due, err := e.PendingPromotions(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || len(due) != 0 {
+		t.Fatalf("before soak: pending=%d due=%d, want 1/0", len(pending), len(due))
+	}
+	if _, due, _ = e.PendingPromotions(time.Now().Add(2 * time.Hour)); len(due) != 1 {
+		t.Fatalf("after soak: due=%d, want 1", len(due))
+	}
+
+	d, err := e.Promote(context.Background(), "production")
+	if err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	if d.Commit != "abc123" || d.PromotedBy == "" {
+		t.Errorf("promotion = %+v", d)
+	}
+	if pending, _, _ = e.PendingPromotions(time.Now().Add(2 * time.Hour)); len(pending) != 0 {
+		t.Errorf("promoted commit still pending: %+v", pending)
+	}
+}
+
+func TestRollbackDeployment(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.rig.Path = t.TempDir()
+	e.config.Deploy = &DeployConfig{Environments: []*DeployEnvironment{
+		{Name: "staging", Command: "true"},
+	}}
+
+	first := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "polecat/a", "main")}, "main", DefaultBatchConfig())
+	if first.Error != nil {
+		t.Fatalf("first batch: %v", first.Error)
+	}
+	second := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-b", "polecat/b", "main")}, "main", DefaultBatchConfig())
+	if second.Error != nil {
+		t.Fatalf("second batch: %v", second.Error)
+	}
+
+	deps, _ := e.Deployments()
+	if len(deps) != 2 {
+		t.Fatalf("deployments = %d, want 2", len(deps))
+	}
+	bad := deps[1]
+
+	// Another pusher lands after the bad batch; the rollback keeps it.
+	later := pushFromClone(t, workDir, "main", "later.txt")
+
+	redeploys, err := e.RollbackDeployment(context.Background(), bad.ID)
+	if err != nil {
+		t.Fatalf("RollbackDeployment: %v", err)
+	}
+	if originHas(t, workDir, "b.txt") || !originHas(t, workDir, "a.txt") || !originHas(t, workDir, "later.txt") {
+		t.Error("origin/main should have a.txt and later.txt and not b.txt")
+	}
+	if err := exec.Command("git", "-C", workDir, "merge-base", "--is-ancestor", later, "origin/main").Run(); err != nil {
+		t.Errorf("later push %s was rewound off origin/main", later)
+	}
+	if len(redeploys) != 1 || redeploys[0].Commit != first.MergeCommit || redeploys[0].RollbackOf != bad.ID {
+		t.Errorf("redeploys = %+v", redeploys)
+	}
+	deps, _ = e.Deployments()
+	if !deps[1].RolledBack {
+		t.Error("rolled-back deployment not marked")
+	}
+}
@@ -0,0 +1,458 @@
+package refinery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnergyMeter reports the energy and compute used so far, as a cumulative
+// reading. The usage of a batch or gate is the difference between readings
+// taken when it starts and when it ends, so a meter only needs a counter
+// that doesn't go backwards: RAPL's energy counters, a cgroup's CPU time,
+// or an external power meter.
+type EnergyMeter interface {
+	ReadEnergy(ctx context.Context) (EnergyReading, error)
+}
+
+// EnergyReading is a cumulative meter reading. A meter that measures only
+// one of the quantities leaves the other zero.
+type EnergyReading struct {
+	Joules     float64 `json:"joules,omitempty"`
+	CPUSeconds float64 `json:"cpu_seconds,omitempty"`
+}
+
+// EnergyUsage is the energy and compute used by a batch or gate.
+type EnergyUsage struct {
+	Joules      float64 `json:"joules,omitempty"`
+	CPUSeconds  float64 `json:"cpu_seconds,omitempty"`
+	CarbonGrams float64 `json:"carbon_grams,omitempty"` // CO2e, from EnergyConfig.CarbonIntensity
+}
+
+// add returns the sum of u and v, either of which may be nil.
+func (u *EnergyUsage) add(v *EnergyUsage) *EnergyUsage {
+	if u == nil {
+		return v
+	}
+	if v == nil {
+		return u
+	}
+	return &EnergyUsage{
+		Joules:      u.Joules + v.Joules,
+		CPUSeconds:  u.CPUSeconds + v.CPUSeconds,
+		CarbonGrams: u.CarbonGrams + v.CarbonGrams,
+	}
+}
+
+// String describes u, e.g. "12.4 kJ, 85.2 CPU-s, 1.6 gCO2e", leaving out
+// quantities that weren't measured.
+func (u EnergyUsage) String() string {
+	var parts []string
+	switch {
+	case u.Joules >= 1e6:
+		parts = append(parts, fmt.Sprintf("%.2f MJ", u.Joules/1e6))
+	case u.Joules >= 1e3:
+		parts = append(parts, fmt.Sprintf("%.1f kJ", u.Joules/1e3))
+	case u.Joules > 0:
+		parts = append(parts, fmt.Sprintf("%.0f J", u.Joules))
+	}
+	if u.CPUSeconds > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f CPU-s", u.CPUSeconds))
+	}
+	if u.CarbonGrams > 0 {
+		parts = append(parts, fmt.Sprintf("%.2f gCO2e", u.CarbonGrams))
+	}
+	if len(parts) == 0 {
+		return "nothing measurable"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Built-in energy meters.
+const (
+	EnergyMeterRAPL    = "rapl"    // Package energy counters under /sys/class/powercap
+	EnergyMeterCgroup  = "cgroup"  // CPU time of the refinery's cgroup (v2), which includes its gates
+	EnergyMeterCommand = "command" // An external meter (see EnergyConfig.Command)
+)
+
+// EnergyConfig configures energy accounting: each batch in the batch log,
+// and each gate run in it, records the energy and compute it used (see
+// EnergyUsage), for organizations tracking the footprint of their merge
+// queue. Gates that run in parallel overlap, so each is charged with
+// everything the meter saw while it ran; the batch total counts everything
+// once.
+type EnergyConfig struct {
+	// Meter is the built-in meter to read: "rapl", "cgroup" or "command".
+	Meter string `json:"meter"`
+
+	// Command runs via sh -c in the refinery clone for each reading, and
+	// prints the cumulative reading on stdout: a bare number of joules, or
+	// JSON like {"joules": j, "cpu_seconds": s}. Required for the command
+	// meter.
+	Command string `json:"command,omitempty"`
+
+	// Timeout bounds each command reading. Default: 10s.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// CarbonIntensity is the grid's carbon intensity in grams of CO2e per
+	// kWh. When set, usage records the carbon its energy stands for.
+	CarbonIntensity float64 `json:"carbon_intensity,omitempty"`
+}
+
+type energyConfigRaw struct {
+	EnergyConfig
+	Timeout string `json:"timeout"`
+}
+
+// defaultEnergyCommandTimeout bounds a command reading when no timeout is
+// configured.
+const defaultEnergyCommandTimeout = 10 * time.Second
+
+func parseEnergyConfig(raw *energyConfigRaw) (*EnergyConfig, error) {
+	cfg := raw.EnergyConfig
+	switch cfg.Meter {
+	case EnergyMeterRAPL, EnergyMeterCgroup:
+		if cfg.Command != "" {
+			return nil, fmt.Errorf("energy command is only used by the command meter, not %s", cfg.Meter)
+		}
+	case EnergyMeterCommand:
+		if strings.TrimSpace(cfg.Command) == "" {
+			return nil, fmt.Errorf("energy meter command needs a command")
+		}
+	default:
+		return nil, fmt.Errorf("invalid energy meter %q: want rapl, cgroup or command", cfg.Meter)
+	}
+	if raw.Timeout != "" {
+		dur, err := time.ParseDuration(raw.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid energy timeout %q: %w", raw.Timeout, err)
+		}
+		if dur <= 0 {
+			return nil, fmt.Errorf("energy timeout must be positive, got %v", dur)
+		}
+		cfg.Timeout = dur
+	}
+	if cfg.CarbonIntensity < 0 {
+		return nil, fmt.Errorf("energy carbon_intensity must not be negative, got %v", cfg.CarbonIntensity)
+	}
+	return &cfg, nil
+}
+
+// SetEnergyMeter installs a meter in place of the configured one.
+// EnergyConfig.CarbonIntensity still applies to its readings.
+func (e *Engineer) SetEnergyMeter(m EnergyMeter) {
+	e.energy = m
+}
+
+// energyMeter returns the installed meter, the configured one, or nil.
+// The configured meter is built once, as the RAPL meter keeps state
+// between readings.
+func (e *Engineer) energyMeter() EnergyMeter {
+	if e.energy != nil {
+		return e.energy
+	}
+	cfg := e.config.Energy
+	if cfg == nil {
+		return nil
+	}
+	e.energyMu.Lock()
+	defer e.energyMu.Unlock()
+	if e.energyFor != cfg {
+		switch cfg.Meter {
+		case EnergyMeterRAPL:
+			e.energyConfigured = &raplMeter{root: raplRoot}
+		case EnergyMeterCgroup:
+			e.energyConfigured = &cgroupMeter{root: cgroupRoot, self: "/proc/self/cgroup"}
+		case EnergyMeterCommand:
+			e.energyConfigured = &commandMeter{cfg: cfg, dir: e.workDir}
+		}
+		e.energyFor = cfg
+	}
+	return e.energyConfigured
+}
+
+// energyUsage returns the usage between two readings.
+func (e *Engineer) energyUsage(start, end EnergyReading) *EnergyUsage {
+	u := &EnergyUsage{
+		Joules:     max(end.Joules-start.Joules, 0),
+		CPUSeconds: max(end.CPUSeconds-start.CPUSeconds, 0),
+	}
+	if cfg := e.config.Energy; cfg != nil && cfg.CarbonIntensity > 0 {
+		u.CarbonGrams = u.Joules / 3.6e6 * cfg.CarbonIntensity
+	}
+	return u
+}
+
+// meterGate runs a gate, recording the energy it used in its result. A
+// reading that fails leaves the gate unmetered; the batch reports meter
+// errors.
+func (e *Engineer) meterGate(ctx context.Context, run func() GateResult) GateResult {
+	m := e.energyMeter()
+	if m == nil {
+		return run()
+	}
+	start, err := m.ReadEnergy(ctx)
+	result := run()
+	if err != nil {
+		return result
+	}
+	if end, err := m.ReadEnergy(ctx); err == nil {
+		result.Energy = e.energyUsage(start, end)
+	}
+	return result
+}
+
+// startBatchEnergy takes the reading a batch's usage is measured from.
+func (e *Engineer) startBatchEnergy(ctx context.Context, rec *batchRecorder) {
+	m := e.energyMeter()
+	if m == nil {
+		return
+	}
+	start, err := m.ReadEnergy(ctx)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Energy] Warning: batch not metered: %v\n", err)
+		return
+	}
+	rec.energyStart = &start
+}
+
+// batchEnergy returns the usage of a batch started with startBatchEnergy,
+// or nil if it isn't metered.
+func (e *Engineer) batchEnergy(rec *batchRecorder) *EnergyUsage {
+	m := e.energyMeter()
+	if m == nil || rec.energyStart == nil {
+		return nil
+	}
+	end, err := m.ReadEnergy(context.Background())
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Energy] Warning: batch not metered: %v\n", err)
+		return nil
+	}
+	return e.energyUsage(*rec.energyStart, end)
+}
+
+// raplRoot holds the RAPL powercap zones.
+const raplRoot = "/sys/class/powercap"
+
+// raplMeter sums the energy counters of the top-level RAPL zones (one per
+// CPU package; their subzones are already included). The counters wrap at
+// max_energy_range_uj, so the meter accumulates the increments it sees:
+// usage between readings further apart than one wrap is undercounted.
+type raplMeter struct {
+	root string
+
+	mu    sync.Mutex
+	last  map[string]float64 // Zone → counter at the last reading, in µJ
+	total float64            // Accumulated µJ
+}
+
+func (m *raplMeter) ReadEnergy(ctx context.Context) (EnergyReading, error) {
+	zones, err := filepath.Glob(filepath.Join(m.root, "intel-rapl:*"))
+	if err != nil {
+		return EnergyReading{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == nil {
+		m.last = make(map[string]float64)
+	}
+	read := 0
+	for _, zone := range zones {
+		if strings.Count(filepath.Base(zone), ":") != 1 {
+			continue // Subzone
+		}
+		uj, err := readFloatFile(filepath.Join(zone, "energy_uj"))
+		if err != nil {
+			return EnergyReading{}, fmt.Errorf("rapl: %w", err)
+		}
+		read++
+		last, seen := m.last[zone]
+		m.last[zone] = uj
+		if !seen {
+			continue
+		}
+		delta := uj - last
+		if delta < 0 {
+			wrap, err := readFloatFile(filepath.Join(zone, "max_energy_range_uj"))
+			if err != nil {
+				return EnergyReading{}, fmt.Errorf("rapl: %w", err)
+			}
+			delta += wrap
+		}
+		m.total += delta
+	}
+	if read == 0 {
+		return EnergyReading{}, fmt.Errorf("rapl: no energy counters under %s", m.root)
+	}
+	return EnergyReading{Joules: m.total / 1e6}, nil
+}
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupMeter reads the CPU time of the refinery's cgroup. Gates run in
+// containers belong to the container runtime's cgroups and aren't counted.
+type cgroupMeter struct {
+	root string
+	self string // /proc/self/cgroup
+}
+
+func (m *cgroupMeter) ReadEnergy(ctx context.Context) (EnergyReading, error) {
+	data, err := os.ReadFile(m.self)
+	if err != nil {
+		return EnergyReading{}, fmt.Errorf("cgroup: %w", err)
+	}
+	var group string
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "0::"); ok {
+			group = rest
+			break
+		}
+	}
+	if group == "" {
+		return EnergyReading{}, fmt.Errorf("cgroup: not in a cgroup v2 hierarchy")
+	}
+	f, err := os.Open(filepath.Join(m.root, group, "cpu.stat"))
+	if err != nil {
+		return EnergyReading{}, fmt.Errorf("cgroup: %w", err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if v, ok := strings.CutPrefix(s.Text(), "usage_usec "); ok {
+			usec, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return EnergyReading{}, fmt.Errorf("cgroup: parsing cpu.stat: %w", err)
+			}
+			return EnergyReading{CPUSeconds: usec / 1e6}, nil
+		}
+	}
+	return EnergyReading{}, fmt.Errorf("cgroup: no usage_usec in %s", f.Name())
+}
+
+// commandMeter takes readings from an external command.
+type commandMeter struct {
+	cfg *EnergyConfig
+	dir string
+}
+
+func (m *commandMeter) ReadEnergy(ctx context.Context) (EnergyReading, error) {
+	timeout := m.cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultEnergyCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// Trust boundary: the command comes from the rig's config.json
+	// (operator-controlled), like gate commands.
+	cmd := exec.CommandContext(ctx, "sh", "-c", m.cfg.Command) //nolint:gosec // G204: energy command is from trusted rig config
+	cmd.Dir = m.dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return EnergyReading{}, fmt.Errorf("energy command: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseEnergyReading(stdout.Bytes())
+}
+
+// parseEnergyReading reads a meter command's output: a bare number of
+// joules or an EnergyReading as JSON.
+func parseEnergyReading(out []byte) (EnergyReading, error) {
+	text := strings.TrimSpace(string(out))
+	if j, err := strconv.ParseFloat(text, 64); err == nil {
+		return EnergyReading{Joules: j}, nil
+	}
+	var r EnergyReading
+	if err := json.Unmarshal([]byte(text), &r); err != nil {
+		return EnergyReading{}, fmt.Errorf("energy command printed %q: want joules or {\"joules\": j, \"cpu_seconds\": s}", text)
+	}
+	return r, nil
+}
+
+func readFloatFile(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
+// EnergySummary totals the energy use recorded in a set of batch records.
+type EnergySummary struct {
+	Batches        int                     `json:"batches"`         // Batches in the records
+	MeteredBatches int                     `json:"metered_batches"` // Of those, batches with usage recorded
+	Merged         int                     `json:"merged"`          // MRs merged by the metered batches
+	Total          EnergyUsage             `json:"total"`
+	PerMerged      *EnergyUsage            `json:"per_merged,omitempty"` // Total per merged MR
+	Targets        map[string]*EnergyUsage `json:"targets,omitempty"`    // Target branch → usage
+	Gates          []*GateEnergy           `json:"gates,omitempty"`      // Most energy first
+}
+
+// GateEnergy is one gate's recorded energy use across batches.
+type GateEnergy struct {
+	Gate  string      `json:"gate"`
+	Runs  int         `json:"runs"` // Metered runs
+	Total EnergyUsage `json:"total"`
+}
+
+// SummarizeEnergy totals the energy use recorded in records (see History).
+func SummarizeEnergy(records []*BatchRecord) *EnergySummary {
+	s := &EnergySummary{Batches: len(records), Targets: make(map[string]*EnergyUsage)}
+	gates := make(map[string]*GateEnergy)
+	for _, r := range records {
+		for _, run := range r.GateRuns {
+			for _, g := range run.Gates {
+				if g.Energy == nil {
+					continue
+				}
+				ge := gates[g.Name]
+				if ge == nil {
+					ge = &GateEnergy{Gate: g.Name}
+					gates[g.Name] = ge
+				}
+				ge.Runs++
+				ge.Total = *ge.Total.add(g.Energy)
+			}
+		}
+		if r.Energy == nil {
+			continue
+		}
+		s.MeteredBatches++
+		s.Merged += len(r.Merged)
+		s.Total = *s.Total.add(r.Energy)
+		s.Targets[r.Target] = s.Targets[r.Target].add(r.Energy)
+	}
+	if s.Merged > 0 {
+		n := float64(s.Merged)
+		s.PerMerged = &EnergyUsage{
+			Joules:      s.Total.Joules / n,
+			CPUSeconds:  s.Total.CPUSeconds / n,
+			CarbonGrams: s.Total.CarbonGrams / n,
+		}
+	}
+	for _, ge := range gates {
+		s.Gates = append(s.Gates, ge)
+	}
+	sort.Slice(s.Gates, func(i, j int) bool {
+		a, b := s.Gates[i].Total, s.Gates[j].Total
+		if a.Joules != b.Joules {
+			return a.Joules > b.Joules
+		}
+		if a.CPUSeconds != b.CPUSeconds {
+			return a.CPUSeconds > b.CPUSeconds
+		}
+		return s.Gates[i].Gate < s.Gates[j].Gate
+	})
+	return s
+}
@@ -0,0 +1,210 @@
+package refinery
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stepMeter reads 100 J and 2 CPU-seconds more each time it is read.
+type stepMeter struct {
+	mu    sync.Mutex
+	reads int
+}
+
+func (m *stepMeter) ReadEnergy(ctx context.Context) (EnergyReading, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads++
+	return EnergyReading{Joules: float64(m.reads) * 100, CPUSeconds: float64(m.reads) * 2}, nil
+}
+
+func TestProcessBatch_RecordsEnergy(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: "true"}}
+	e.config.Energy = &EnergyConfig{CarbonIntensity: 360}
+	meter := &stepMeter{}
+	e.SetEnergyMeter(meter)
+
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	if result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5}); result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+
+	records, err := e.History(HistoryQuery{})
+	if err != nil || len(records) != 1 {
+		t.Fatalf("History() = %d records, %v; want 1", len(records), err)
+	}
+	r := records[0]
+	// The batch spans every reading: its own two and the gate's two.
+	if r.Energy == nil || r.Energy.Joules != 300 || r.Energy.CPUSeconds != 6 {
+		t.Fatalf("batch energy = %+v, want 300 J and 6 CPU-s", r.Energy)
+	}
+	if want := 300 / 3.6e6 * 360; math.Abs(r.Energy.CarbonGrams-want) > 1e-9 {
+		t.Errorf("batch carbon = %v g, want %v", r.Energy.CarbonGrams, want)
+	}
+	if len(r.GateRuns) != 1 || len(r.GateRuns[0].Gates) != 1 {
+		t.Fatalf("gate runs = %+v, want one run of check", r.GateRuns)
+	}
+	if ge := r.GateRuns[0].Gates[0].Energy; ge == nil || ge.Joules != 100 || ge.CPUSeconds != 2 {
+		t.Errorf("gate energy = %+v, want 100 J and 2 CPU-s", ge)
+	}
+
+	s := SummarizeEnergy(records)
+	if s.MeteredBatches != 1 || s.Merged != 2 || s.PerMerged == nil || s.PerMerged.Joules != 150 {
+		t.Errorf("summary = %+v, want one batch of two merged at 150 J each", s)
+	}
+	if len(s.Gates) != 1 || s.Gates[0].Gate != "check" || s.Gates[0].Runs != 1 || s.Targets["main"].Joules != 300 {
+		t.Errorf("summary gates = %+v, targets = %+v", s.Gates, s.Targets)
+	}
+}
+
+func TestProcessBatch_UnmeteredWithoutConfig(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: "true"}}
+	e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "feature-a", "main")}, "main", &BatchConfig{MaxBatchSize: 5})
+
+	records, _ := e.History(HistoryQuery{})
+	if len(records) != 1 || records[0].Energy != nil || records[0].GateRuns[0].Gates[0].Energy != nil {
+		t.Errorf("records = %+v, want one unmetered batch", records)
+	}
+}
+
+func TestRAPLMeter(t *testing.T) {
+	root := t.TempDir()
+	zone := filepath.Join(root, "intel-rapl:0")
+	sub := filepath.Join(root, "intel-rapl:0:0")
+	for _, dir := range []string{zone, sub} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, dir, "max_energy_range_uj", "10000000\n")
+	}
+	writeFile(t, sub, "energy_uj", "5\n")
+	m := &raplMeter{root: root}
+	read := func(uj string) float64 {
+		t.Helper()
+		writeFile(t, zone, "energy_uj", uj+"\n")
+		r, err := m.ReadEnergy(context.Background())
+		if err != nil {
+			t.Fatalf("ReadEnergy: %v", err)
+		}
+		return r.Joules
+	}
+	if j := read("9000000"); j != 0 {
+		t.Errorf("first reading = %v J, want 0", j)
+	}
+	if j := read("9500000"); j != 0.5 {
+		t.Errorf("after 0.5 J = %v J, want 0.5", j)
+	}
+	// The counter wraps at 10 J: 9.5 → 1.0 is 1.5 J.
+	if j := read("1000000"); j != 2 {
+		t.Errorf("after wrapping = %v J, want 2", j)
+	}
+
+	if _, err := (&raplMeter{root: t.TempDir()}).ReadEnergy(context.Background()); err == nil {
+		t.Error("ReadEnergy() without counters succeeded")
+	}
+}
+
+func TestCgroupMeter(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "system.slice", "gt.service"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "system.slice", "gt.service"), "cpu.stat", "usage_usec 2500000\nuser_usec 2000000\n")
+	writeFile(t, root, "self", "0::/system.slice/gt.service\n")
+
+	r, err := (&cgroupMeter{root: root, self: filepath.Join(root, "self")}).ReadEnergy(context.Background())
+	if err != nil || r.CPUSeconds != 2.5 || r.Joules != 0 {
+		t.Errorf("ReadEnergy() = %+v, %v; want 2.5 CPU-s", r, err)
+	}
+
+	writeFile(t, root, "v1", "4:cpu,cpuacct:/gt\n")
+	if _, err := (&cgroupMeter{root: root, self: filepath.Join(root, "v1")}).ReadEnergy(context.Background()); err == nil {
+		t.Error("ReadEnergy() outside cgroup v2 succeeded")
+	}
+}
+
+func TestCommandMeter(t *testing.T) {
+	dir := t.TempDir()
+	for cmd, want := range map[string]EnergyReading{
+		"echo 1234.5": {Joules: 1234.5},
+		`echo '{"joules": 10, "cpu_seconds": 3}'`: {Joules: 10, CPUSeconds: 3},
+	} {
+		m := &commandMeter{cfg: &EnergyConfig{Meter: EnergyMeterCommand, Command: cmd}, dir: dir}
+		if got, err := m.ReadEnergy(context.Background()); err != nil || got != want {
+			t.Errorf("%s: ReadEnergy() = %+v, %v; want %+v", cmd, got, err, want)
+		}
+	}
+	for _, cmd := range []string{"echo lots", "exit 3"} {
+		m := &commandMeter{cfg: &EnergyConfig{Meter: EnergyMeterCommand, Command: cmd}, dir: dir}
+		if _, err := m.ReadEnergy(context.Background()); err == nil {
+			t.Errorf("%s: ReadEnergy() succeeded", cmd)
+		}
+	}
+}
+
+func TestLoadConfig_Energy(t *testing.T) {
+	e := newTestEngineer(t, t.TempDir(), nil)
+	cfg := DefaultMergeQueueConfig()
+	err := applyMergeQueueConfig(cfg, []byte(`{"energy": {"meter": "command", "command": "read-meter", "timeout": "3s", "carbon_intensity": 250}}`))
+	if err != nil {
+		t.Fatalf("applyMergeQueueConfig: %v", err)
+	}
+	if en := cfg.Energy; en == nil || en.Meter != EnergyMeterCommand || en.Timeout.Seconds() != 3 || en.CarbonIntensity != 250 {
+		t.Errorf("energy = %+v", cfg.Energy)
+	}
+
+	for _, bad := range []string{
+		`{"meter": "wattmeter"}`,
+		`{"meter": "command"}`,
+		`{"meter": "rapl", "command": "x"}`,
+		`{"meter": "cgroup", "timeout": "-1s"}`,
+		`{"meter": "rapl", "carbon_intensity": -1}`,
+	} {
+		if err := applyMergeQueueConfig(DefaultMergeQueueConfig(), []byte(`{"energy": `+bad+`}`)); err == nil {
+			t.Errorf("energy %s accepted", bad)
+		} else if !strings.Contains(err.Error(), "energy") {
+			t.Errorf("energy %s: error %q doesn't mention energy", bad, err)
+		}
+	}
+
+	// A reloaded config builds its meter afresh.
+	e.config.Energy = &EnergyConfig{Meter: EnergyMeterCgroup}
+	first := e.energyMeter()
+	if _, ok := first.(*cgroupMeter); !ok || e.energyMeter() != first {
+		t.Fatalf("energyMeter() = %T, want one cgroup meter", first)
+	}
+	e.config.Energy = &EnergyConfig{Meter: EnergyMeterRAPL}
+	if _, ok := e.energyMeter().(*raplMeter); !ok {
+		t.Errorf("energyMeter() after reload = %T, want rapl", e.energyMeter())
+	}
+}
+
+func TestEnergyUsageString(t *testing.T) {
+	for u, want := range map[EnergyUsage]string{
+		{Joules: 12400, CPUSeconds: 85.24, CarbonGrams: 1.6}: "12.4 kJ, 85.2 CPU-s, 1.60 gCO2e",
+		{Joules: 2.5e6}: "2.50 MJ",
+		{CPUSeconds: 3}: "3.0 CPU-s",
+		{}:              "nothing measurable",
+	} {
+		if got := u.String(); got != want {
+			t.Errorf("%+v.String() = %q, want %q", u, got, want)
+		}
+	}
+}
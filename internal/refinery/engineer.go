@@ -10,6 +10,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -18,11 +19,13 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/crew"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 // DefaultStaleClaimTimeout is the default duration after which a claimed MR
@@ -51,17 +54,66 @@ type GateConfig struct {
 	// Cmd is the shell command to execute.
 	Cmd string `json:"cmd"`
 
-	// Timeout is the maximum time the gate command may run.
+	// Timeout is the maximum time the gate command may run. When it is
+	// exceeded the gate's whole process group is killed and the gate is
+	// reported as timed out rather than failed.
 	// Zero means no timeout (inherits context deadline).
 	Timeout time.Duration `json:"timeout"`
+
+	// Flaky marks the gate as known to fail intermittently. Once any gate
+	// is marked, a failed batch is retried only when its failures are all
+	// in flaky gates (see flakyRetries).
+	Flaky bool `json:"flaky,omitempty"`
+
+	// Dir is the directory the gate runs in, relative to the root of the
+	// tree under test, e.g. a package of a monorepo. Default: the root.
+	Dir string `json:"dir,omitempty"`
+
+	// Env sets environment variables for the gate, on top of the
+	// refinery's own and those it sets for every gate (see gateVars).
+	// Values may refer to the latter, as in "${MR_IDS}".
+	Env map[string]string `json:"env,omitempty"`
+
+	// Shell runs Cmd: a program and any arguments to put before "-c", as
+	// in "bash -o pipefail". Default: "sh".
+	Shell string `json:"shell,omitempty"`
+
+	// Image runs the gate in a container of this image instead of on the
+	// host, for a hermetic toolchain. The tree under test is mounted
+	// read-only at /workspace, so the gate can't change the rig's checkout;
+	// of the environment only the gate's own variables are passed in.
+	Image string `json:"image,omitempty"`
+
+	// Runtime is the container runtime for Image, "docker" or "podman".
+	// Default: whichever is installed, docker first.
+	Runtime string `json:"runtime,omitempty"`
+
+	// Paths limits the gate to trees whose MRs change a matching file,
+	// with the test policy's patterns ("*.go", "docs/**"). A batch that
+	// changes none of them skips the gate, e.g. a docs-only batch skipping
+	// the integration tests (see unaffectedGates). Empty means always run.
+	Paths []string `json:"paths,omitempty"`
 }
 
+// gateWaitDelay bounds how long a killed gate may hold its output pipes
+// open, for descendants that escaped its process group.
+const gateWaitDelay = 5 * time.Second
+
 // GateResult holds the outcome of a single gate execution.
 type GateResult struct {
-	Name    string
-	Success bool
-	Error   string
-	Elapsed time.Duration
+	Name        string
+	Success     bool
+	TimedOut    bool   // Killed after exceeding the gate's Timeout
+	Flaky       bool   // Failed, then passed on retry (see QuarantineConfig)
+	Quarantined bool   // Gate is quarantined as flaky: a failure is reported but doesn't block
+	Baseline    bool   // Failed on the bare target too (see failsOnBaseline): reported but doesn't block
+	SkippedBy   string // Not run: ID of the skip token that bypassed it (see GateSkipToken)
+	Unaffected  bool   // Not run: the tree changes none of the gate's Paths
+	Error       string
+	Elapsed     time.Duration
+	Stdout      []byte // Captured output, saved as a gate log (see saveGateLog)
+	Stderr      []byte
+	Energy      *EnergyUsage // Energy used while the gate ran, when metered (see EnergyConfig)
 }
 
 // MergeQueueConfig holds configuration for the merge queue processor.
@@ -77,6 +129,42 @@ type MergeQueueConfig struct {
 	// OnConflict is the strategy for handling conflicts: "assign_back" or "auto_rebase".
 	OnConflict string `json:"on_conflict"`
 
+	// MergeStrategy is how MRs land unless they declare their own:
+	// "squash" (default), "merge-commit", "rebase-ff", "cherry-pick" or
+	// "auto" (see merge_strategy.go).
+	MergeStrategy string `json:"merge_strategy,omitempty"`
+
+	// AutoStrategy tunes how the "auto" merge strategy judges a branch's
+	// commits (see AutoStrategyConfig).
+	AutoStrategy *AutoStrategyConfig `json:"auto_strategy,omitempty"`
+
+	// MergeMessage is a text/template for the commit message of squash and
+	// merge-commit landings, executed with MergeMessageData. Empty keeps
+	// the default messages (see merge_message.go).
+	MergeMessage string `json:"merge_message,omitempty"`
+
+	// MergeTrailers limits the branch trailers a merge message template
+	// sees in MergeMessageData.Trailers to these keys. Empty passes them all.
+	MergeTrailers []string `json:"merge_trailers,omitempty"`
+
+	// RequiredTrailers are trailer keys every squash and merge commit
+	// message must end with, such as "Signed-off-by". An MR whose message
+	// lacks one is not landed.
+	RequiredTrailers []string `json:"required_trailers,omitempty"`
+
+	// SignMode is whether the commits the merge step creates are signed:
+	// "off" (default), "auto" (when signing works) or "required" (see
+	// signing.go).
+	SignMode string `json:"sign_mode,omitempty"`
+
+	// SigningKey is the key commits are signed with: a GPG key ID or, with
+	// git's gpg.format=ssh, an SSH key. Empty uses git's user.signingkey.
+	SigningKey string `json:"signing_key,omitempty"`
+
+	// IssueURL links MRs' source issues for merge message templates, with
+	// {issue} standing for the issue ID (MergeMessageData.IssueLink).
+	IssueURL string `json:"issue_url,omitempty"`
+
 	// RunTests controls whether to run tests before merging.
 	RunTests bool `json:"run_tests"`
 
@@ -89,6 +177,12 @@ type MergeQueueConfig struct {
 	// RetryFlakyTests is the number of times to retry flaky tests.
 	RetryFlakyTests int `json:"retry_flaky_tests"`
 
+	// IsolatedWorktree stacks and gates the default branch's MRs in a
+	// dedicated worktree (.runtime/refinery-work) instead of the rig's
+	// clone, so agents working in the clone never see the queue check out,
+	// merge or reset its branch. See isolateStacking.
+	IsolatedWorktree bool `json:"isolated_worktree"`
+
 	// PollInterval is how often to check for new MRs.
 	PollInterval time.Duration `json:"poll_interval"`
 
@@ -103,6 +197,19 @@ type MergeQueueConfig struct {
 	// in manager.go), so concurrent re-claim is not a concern in practice.
 	StaleClaimTimeout time.Duration `json:"stale_claim_timeout"`
 
+	// MergeSlotLeaseTTL is how long the push slot stays held by an engineer
+	// that stops renewing its lease, e.g. one that crashed mid-push, before
+	// the next engineer reclaims it (see MergeSlotLease). Zero turns leases
+	// off. Default: DefaultMergeSlotLeaseTTL.
+	MergeSlotLeaseTTL time.Duration `json:"merge_slot_lease_ttl"`
+
+	// SlotPartitions splits the default branch's merge slot by path for
+	// monorepos, e.g. {"frontend": {"paths": ["web"]}, "backend": {"paths":
+	// ["server"]}}: landings touching only disjoint partitions hold only
+	// their partitions' slots and land concurrently (see
+	// acquireLandingSlots). Empty: one slot for the whole repository.
+	SlotPartitions map[string]*SlotPartitionConfig `json:"slot_partitions,omitempty"`
+
 	// Gates defines named quality gate commands to run before merging.
 	// When non-empty, gates replace the legacy RunTests/TestCommand path.
 	// Each gate runs as a shell command with an optional per-gate timeout.
@@ -112,6 +219,17 @@ type MergeQueueConfig struct {
 	// When true, all gates start simultaneously; any failure = overall failure.
 	GatesParallel bool `json:"gates_parallel"`
 
+	// GateBaseline reruns a gate that fails on a tree on the bare target
+	// branch, and doesn't block on failures the target already has (see
+	// failsOnBaseline).
+	GateBaseline bool `json:"gate_baseline,omitempty"`
+
+	// BaselineCheck reruns the gates that failed a batch on the bare target
+	// tip before blaming any of its MRs. If they fail there too, the target
+	// or the gates' infrastructure is broken: the batch is deferred and the
+	// queue paused until an operator resumes it (see holdForBrokenTarget).
+	BaselineCheck bool `json:"baseline_check,omitempty"`
+
 	// StaleClaimWarningAfter is how long a claimed MR can sit without updates
 	// before it triggers a "warning" severity anomaly.
 	StaleClaimWarningAfter time.Duration `json:"stale_claim_warning_after"`
@@ -124,9 +242,110 @@ type MergeQueueConfig struct {
 	// before escalation to Mayor.
 	MaxRetryCount int `json:"max_retry_count"`
 
-	// Batch holds configuration for the batch-then-bisect merge queue.
-	// When nil or MaxBatchSize <= 1, batching is disabled and MRs process sequentially.
+	// Batch holds configuration for the batch-then-bisect merge queue:
+	// batch sizes, retry and bisection policy. When set it replaces the
+	// batch config ProcessTargets is given, and is the default where none
+	// is. Durations are strings in the config files ("30s").
 	Batch *BatchConfig `json:"batch,omitempty"`
+
+	// ProtectedBranches registers long-lived feature branches, keyed by
+	// branch name, that accept MRs alongside the default branch.
+	ProtectedBranches map[string]*ProtectedBranchConfig `json:"protected_branches,omitempty"`
+
+	// Deploy configures deploy hooks run after successful merges.
+	Deploy *DeployConfig `json:"deploy,omitempty"`
+
+	// PostMergeHooks run after every push that lands MRs, with the merge
+	// described in their environment (see runPostMergeHooks).
+	PostMergeHooks []*PostMergeHookConfig `json:"post_merge_hooks,omitempty"`
+
+	// TestPolicy holds MRs that change code without tests out of batches
+	// until their agent writes tests (see AdmitMRs).
+	TestPolicy *TestPolicyConfig `json:"test_policy,omitempty"`
+
+	// SplitPolicy holds oversized MRs out of batches until their agent
+	// splits them into stacked MRs (see admitSized).
+	SplitPolicy *SplitPolicyConfig `json:"split_policy,omitempty"`
+
+	// ConflictResolution hands MRs that conflict with their target to an
+	// agent instead of leaving them to their author (see
+	// requestConflictResolutions).
+	ConflictResolution *ConflictResolutionConfig `json:"conflict_resolution,omitempty"`
+
+	// ConflictGrace gives the agent behind an MR that conflicts while its
+	// batch is stacked a window to rebase it back into the batch (see
+	// graceConflicts).
+	ConflictGrace *ConflictGraceConfig `json:"conflict_grace,omitempty"`
+
+	// Hotfix fans hotfix MRs out to the supported release branches (see
+	// hotfix.go).
+	Hotfix *HotfixConfig `json:"hotfix,omitempty"`
+
+	// Approval holds MRs whose source issue isn't approved in beads (see
+	// admitApproved).
+	Approval *ApprovalConfig `json:"approval,omitempty"`
+
+	// AdmissionLimits holds MRs that are too large or touch protected
+	// paths for human review (see admitLimited).
+	AdmissionLimits *AdmissionLimitsConfig `json:"admission_limits,omitempty"`
+
+	// Freshness bounces MRs whose branches are too far behind their target
+	// back to their authors to rebase (see admitFresh).
+	Freshness *FreshnessConfig `json:"freshness,omitempty"`
+
+	// AssetPolicy handles MRs dominated by binary assets: size limits,
+	// path-based conflict checks, their own gates and LFS migration (see
+	// admitAssets).
+	AssetPolicy *AssetPolicyConfig `json:"asset_policy,omitempty"`
+
+	// AutoRevert runs post-merge gates on each landing and reverts the MR
+	// that broke them (see watchLanding).
+	AutoRevert *AutoRevertConfig `json:"auto_revert,omitempty"`
+
+	// MergeDrivers resolve conflicts in lockfiles and generated files,
+	// keyed by name. Entries replace the built-in driver of the same name
+	// (see DefaultMergeDrivers).
+	MergeDrivers map[string]*MergeDriverConfig `json:"merge_drivers,omitempty"`
+
+	// Predictor scores assembled batches so unlikely-to-pass ones are split
+	// before stacking (see splitByPrediction).
+	Predictor *PredictorConfig `json:"predictor,omitempty"`
+
+	// Quarantine retries failed gates once and makes gates that keep
+	// failing then passing non-blocking (see QuarantineConfig).
+	Quarantine *QuarantineConfig `json:"quarantine,omitempty"`
+
+	// FailurePatterns opens one issue for a gate failure that recurs across
+	// batches (see FailurePatternConfig).
+	FailurePatterns *FailurePatternConfig `json:"failure_patterns,omitempty"`
+
+	// CulpritFeedback sends the failing gates, log excerpts and stacked
+	// commit behind each culprit to its author (see sendCulpritFeedback).
+	CulpritFeedback *CulpritFeedbackConfig `json:"culprit_feedback,omitempty"`
+
+	// Webhooks are notified with a JSON summary of every processed batch
+	// (see notifyWebhooks).
+	Webhooks []*WebhookConfig `json:"webhooks,omitempty"`
+
+	// GitHub makes the queue take pull requests from a GitHub repository
+	// and report batch results back on them (see GitHubConfig).
+	GitHub *GitHubConfig `json:"github,omitempty"`
+
+	// Gerrit makes the queue take ready changes from a Gerrit project and
+	// submit them through Gerrit once gates pass (see GerritConfig).
+	Gerrit *GerritConfig `json:"gerrit,omitempty"`
+
+	// FreezeWindows are recurring merge freezes during which batches are
+	// deferred (see ActiveFreeze).
+	FreezeWindows []*FreezeWindowConfig `json:"freeze_windows,omitempty"`
+
+	// MergeWindows are recurring periods batches may land in; outside
+	// them validated batches are held (see MergeWindowConfig).
+	MergeWindows []*MergeWindowConfig `json:"merge_windows,omitempty"`
+
+	// Energy records the energy and compute each batch and gate uses in
+	// the batch log (see EnergyConfig).
+	Energy *EnergyConfig `json:"energy,omitempty"`
 }
 
 // DefaultMergeQueueConfig returns sensible defaults for merge queue configuration.
@@ -142,6 +361,7 @@ func DefaultMergeQueueConfig() *MergeQueueConfig {
 		PollInterval:            30 * time.Second,
 		MaxConcurrent:           1,
 		StaleClaimTimeout:       DefaultStaleClaimTimeout,
+		MergeSlotLeaseTTL:       DefaultMergeSlotLeaseTTL,
 		StaleClaimWarningAfter:  2 * time.Hour,
 		StaleClaimCriticalAfter: 6 * time.Hour,
 		MaxRetryCount:           5,
@@ -165,6 +385,10 @@ type MRInfo struct {
 	ConvoyCreatedAt *time.Time // Convoy creation time
 	CreatedAt       time.Time  // MR creation time
 	BlockedBy       string     // Task ID blocking this MR
+	InheritedFrom   string     // MR whose priority this one inherited, as its blocker (see inheritPriorities)
+	Labels          []string   // Bead labels (e.g. "hotfix")
+	HeldFor         string     // Policy holding the MR: HeldForSplit, HeldForAssets, HeldForTests, HeldForReview, HeldForStale or HeldForConflict
+	Urgent          bool       // Labeled UrgentLabel: batched alone, and may preempt a running batch (see Preempt)
 
 	// Pre-verification fields (Phase 3: polecat-owned rebasing)
 	// When set, the refinery can skip gates if VerifiedBase matches target HEAD.
@@ -172,6 +396,25 @@ type MRInfo struct {
 	PreVerifiedAt   time.Time // When verification completed
 	PreVerifiedBase string    // Target branch SHA at verification time
 
+	// QoS is the service class used to reserve batch capacity (see qos.go).
+	QoS string
+
+	// MergeStrategy is how the MR lands; empty means the rig default (see
+	// merge_strategy.go).
+	MergeStrategy string
+
+	// SplitFrom is the MR this one was split out of (see SplitMR).
+	SplitFrom string
+
+	// PullRequest is set for MRs ingested from GitHub (see github.go).
+	PullRequest *PullRequestRef
+
+	// Change is set for MRs ingested from Gerrit (see gerrit.go).
+	Change *GerritChangeRef
+
+	batchID string        // Batch the MR is being stacked in, for merge message templates
+	assets  *AssetProfile // Cached by mrAssets
+
 	// Raw data for agent-side queue health analysis (ZFC: agent decides, Go transports)
 	UpdatedAt          time.Time // When the MR was last updated
 	Assignee           string    // Who claimed this MR (empty = unclaimed)
@@ -189,7 +432,6 @@ type MRAnomaly struct {
 	Detail   string        `json:"detail"`
 }
 
-
 // errMergeSlotTimeout is returned by acquireMainPushSlot when retries are
 // exhausted due to slot contention. Infrastructure errors (beads down,
 // permission errors) return a different error so callers can distinguish
@@ -208,6 +450,9 @@ type Engineer struct {
 	beads                 *beads.Beads
 	git                   *git.Git
 	config                *MergeQueueConfig
+	loadedConfig          string                 // configStamp when config was last loaded ("" = never, see ReloadConfig)
+	baseGates             map[string]*GateConfig // Configured gates live changes were applied to (see applyLiveGates)
+	liveGates             map[string]*GateConfig // Gate set installed with live changes (nil = none)
 	workDir               string
 	output                io.Writer    // Output destination for user-facing messages
 	router                *mail.Router // Mail router for sending protocol messages
@@ -216,6 +461,64 @@ type Engineer struct {
 	mergeSlotRelease      func(holder string) error
 	mergeSlotMaxRetries   int           // Max retries for slot acquisition (0 = no retry)
 	mergeSlotRetryBackoff time.Duration // Initial backoff between retries
+	pushSlot              string        // Push slot holder while held (see rollbackCancelled)
+	leaseMu               sync.Mutex
+	leases                map[string]func() // Push slot holder → ends its lease heartbeat (see holdSlotLease)
+	listReadyMRs          func() ([]*MRInfo, error)
+	loadAcceptance        func(issueID string) ([]beads.AcceptanceCriterion, error)
+	showIssue             func(id string) (*beads.Issue, error)
+	markVerified          func(issueID string) error
+	findOpenMR            func(branch string) (*beads.Issue, error)                           // Open MR bead for a branch (see findOpenMRInBeads)
+	createMRBead          func(opts beads.CreateOptions) (*beads.Issue, error)                // Creates a queued MR bead (see createMRBeadInBeads)
+	assignMR              func(id, assignee string) error                                     // Claims or releases an MR bead (see assignMRInBeads)
+	closeMR               func(id, reason string) error                                       // Closes an MR bead (see closeMRInBeads)
+	labelMR               func(id string, add, remove []string) error                         // Adds and removes MR bead labels (see labelMRInBeads)
+	commentIssue          func(id, text string) error                                         // Comments on a bead (see commentInBeads)
+	createMR              func(mr *MRInfo, branch, target string) (string, error)             // Enqueues a backport of mr (see createBackportMR)
+	requestRebase         func(mr *MRInfo, target string, deadline time.Time) (string, error) // Asks mr's agent to rebase it (see requestGraceRebase)
+	openFailureIssue      func(p *FailurePattern) (string, error)                             // Opens the issue tracking p (see createFailureIssue)
+	deliverFeedback       func(mr *MRInfo, fb *CulpritFeedback) error                         // Sends fb to mr's author (see deliverCulpritFeedback)
+	execGate              func(ctx context.Context, dir, name string, gate *GateConfig) GateResult
+
+	acceptanceMu sync.Mutex
+	acceptance   map[string][]beads.AcceptanceCriterion // Source issue → criteria, for the current batch
+
+	prewarmMu sync.Mutex
+	prewarm   *Prewarm // Predicted next batch prepared during gates (nil = none)
+
+	pipelineMu sync.Mutex
+	pipeline   *Pipeline // Next batch stacked speculatively during gates (nil = none)
+
+	predictor BatchPredictor // Overrides config.Predictor (nil = use config)
+
+	energy           EnergyMeter // Overrides config.Energy's meter (nil = use config)
+	energyMu         sync.Mutex
+	energyFor        *EnergyConfig // The config energyConfigured was built from
+	energyConfigured EnergyMeter
+
+	quarantineMu sync.Mutex // Serializes updates to the gate quarantine record
+	baselineMu   sync.Mutex // Serializes baseline gate runs and their cache
+
+	stackTipsMu sync.Mutex
+	stackTips   map[string]string         // MR ID → commit its stack ends at (see recordStackTip)
+	stackBases  map[string]string         // MR ID → commit its stack starts from
+	stackPicks  map[string][]PickedCommit // MR ID → its cherry-picked commits
+
+	cycleMu    sync.Mutex
+	cycleNoted map[string]string // MR ID → dependency cycle its bead was told about (see reportDependencyCycles)
+
+	mergeDriversInstalled bool
+	mergeTreeUnsupported  bool   // git lacks merge-tree --write-tree (see checkMRConflicts)
+	isolated              bool   // Stacking in the .runtime/refinery-work worktree (see isolateStacking)
+	signCommits           bool   // Sign the merges made next (see prepareSigning)
+	mergeDriverMarker     string // File merge drivers append resolved paths to
+
+	target    string               // Target branch e is bound to (see BindTarget); "" = all
+	stateMu   *sync.Mutex          // Shared with target engineers; serializes .runtime state updates (nil = none)
+	progress  *progressHub         // Shared with target engineers; progress subscribers (see Subscribe)
+	metrics   *Metrics             // Shared with target engineers; recorded since the last flush (see flushMetrics)
+	targetsMu sync.Mutex           // Guards targets
+	targets   map[string]*Engineer // Target branch → engineer working in its own worktree (see ProcessTargets)
 }
 
 // NewEngineer creates a new Engineer for the given rig.
@@ -231,13 +534,13 @@ func NewEngineer(r *rig.Rig) *Engineer {
 	}
 	beadsClient := beads.New(r.Path)
 
-	return &Engineer{
+	e := &Engineer{
 		rig:     r,
 		beads:   beadsClient,
 		git:     git.NewGit(gitDir),
 		config:  cfg,
 		workDir: gitDir,
-		output:  os.Stdout,
+		output:  &lockedWriter{w: os.Stdout},
 		router:  mail.NewRouter(r.Path),
 		mergeSlotEnsureExists: func() (string, error) {
 			return beadsClient.MergeSlotEnsureExists()
@@ -250,88 +553,298 @@ func NewEngineer(r *rig.Rig) *Engineer {
 		},
 		mergeSlotMaxRetries:   10,
 		mergeSlotRetryBackoff: 500 * time.Millisecond,
+		markVerified:          beadsClient.MarkVerified,
+		showIssue:             beadsClient.Show,
+		acceptance:            make(map[string][]beads.AcceptanceCriterion),
+		progress:              &progressHub{},
+		metrics:               &Metrics{},
+	}
+	e.listReadyMRs = e.ListReadyMRs
+	e.loadAcceptance = e.loadAcceptanceFromBeads
+	e.execGate = e.execGateCmd
+	e.createMR = e.createBackportMR
+	e.requestRebase = e.requestGraceRebase
+	e.openFailureIssue = e.createFailureIssue
+	e.deliverFeedback = e.deliverCulpritFeedback
+	e.findOpenMR = e.findOpenMRInBeads
+	e.createMRBead = e.createMRBeadInBeads
+	e.assignMR = e.assignMRInBeads
+	e.closeMR = e.closeMRInBeads
+	e.labelMR = e.labelMRInBeads
+	e.commentIssue = e.commentInBeads
+	return e
+}
+
+// sharedEngineer returns a new Engineer sharing e's collaborators, hooks
+// and shared state, with fresh per-engineer state (see targetEngineer). A
+// field added to Engineer is copied here unless it belongs to a single
+// engineer; TestSharedEngineer_CopiesSharedFields lists those.
+func (e *Engineer) sharedEngineer() *Engineer {
+	return &Engineer{
+		rig:                   e.rig,
+		beads:                 e.beads,
+		git:                   e.git,
+		config:                e.config,
+		workDir:               e.workDir,
+		output:                e.output,
+		router:                e.router,
+		mergeSlotEnsureExists: e.mergeSlotEnsureExists,
+		mergeSlotAcquire:      e.mergeSlotAcquire,
+		mergeSlotRelease:      e.mergeSlotRelease,
+		mergeSlotMaxRetries:   e.mergeSlotMaxRetries,
+		mergeSlotRetryBackoff: e.mergeSlotRetryBackoff,
+		listReadyMRs:          e.listReadyMRs,
+		loadAcceptance:        e.loadAcceptance,
+		showIssue:             e.showIssue,
+		markVerified:          e.markVerified,
+		findOpenMR:            e.findOpenMR,
+		createMRBead:          e.createMRBead,
+		assignMR:              e.assignMR,
+		closeMR:               e.closeMR,
+		labelMR:               e.labelMR,
+		commentIssue:          e.commentIssue,
+		createMR:              e.createMR,
+		requestRebase:         e.requestRebase,
+		openFailureIssue:      e.openFailureIssue,
+		deliverFeedback:       e.deliverFeedback,
+		execGate:              e.execGate,
+		acceptance:            make(map[string][]beads.AcceptanceCriterion),
+		predictor:             e.predictor,
+		energy:                e.energy,
+		stateMu:               e.stateMu,
+		progress:              e.progress,
+		metrics:               e.metrics,
 	}
 }
 
 // SetOutput sets the output writer for user-facing messages.
-// This is useful for testing or redirecting output.
+// This is useful for testing or redirecting output. Writes to w are
+// serialized, since gates and bisection probes report concurrently.
 func (e *Engineer) SetOutput(w io.Writer) {
-	e.output = w
+	e.output = &lockedWriter{w: w}
+}
+
+// UseLocalMergeSlot replaces the beads-backed merge slot with an in-process
+// one. Pushes are then serialized only among callers sharing this Engineer,
+// which suits rigs without a beads database, such as test harnesses.
+func (e *Engineer) UseLocalMergeSlot() {
+	(&localMergeSlot{id: e.rig.Name + "/merge-slot"}).install(e)
 }
 
-// LoadConfig loads merge queue configuration from the rig's config.json.
+// localMergeSlot is an in-process merge slot.
+type localMergeSlot struct {
+	id     string
+	mu     sync.Mutex
+	holder string
+}
+
+// install makes e acquire and release s instead of its current slot.
+func (s *localMergeSlot) install(e *Engineer) {
+	e.mergeSlotEnsureExists = func() (string, error) {
+		return s.id, nil
+	}
+	e.mergeSlotAcquire = func(h string, _ bool) (*beads.MergeSlotStatus, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.holder != "" && s.holder != h {
+			return &beads.MergeSlotStatus{Available: false, Holder: s.holder}, nil
+		}
+		s.holder = h
+		return &beads.MergeSlotStatus{Available: true, Holder: h}, nil
+	}
+	e.mergeSlotRelease = func(h string) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.holder == h {
+			s.holder = ""
+		}
+		return nil
+	}
+}
+
+// LoadConfig loads merge queue configuration from the rig's config.json,
+// overridden by its refinery config file if it has one (see
+// refineryConfigFile). Nothing is changed unless both parse. Once loaded,
+// the configuration is reloaded between batches whenever the files change
+// (see ReloadConfig).
 func (e *Engineer) LoadConfig() error {
+	stamp := e.configStamp()
+	cfg := *e.config
+	if err := e.readConfig(&cfg); err != nil {
+		return err
+	}
+	*e.config = cfg
+	e.loadedConfig = stamp
+	return nil
+}
+
+// readConfig applies the rig's config.json merge_queue section and then its
+// refinery config file to cfg.
+func (e *Engineer) readConfig(cfg *MergeQueueConfig) error {
 	configPath := filepath.Join(e.rig.Path, "config.json")
 	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Use defaults if no config file
-			return nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("reading config: %w", err)
 	}
-
-	// Parse config file to extract merge_queue section
-	var rawConfig struct {
-		MergeQueue json.RawMessage `json:"merge_queue"`
-	}
-	if err := json.Unmarshal(data, &rawConfig); err != nil {
-		return fmt.Errorf("parsing config: %w", err)
+	if err == nil {
+		// Parse config file to extract merge_queue section
+		var rawConfig struct {
+			MergeQueue json.RawMessage `json:"merge_queue"`
+		}
+		if err := json.Unmarshal(data, &rawConfig); err != nil {
+			return fmt.Errorf("parsing config: %w", err)
+		}
+		if rawConfig.MergeQueue != nil {
+			if err := applyMergeQueueConfig(cfg, rawConfig.MergeQueue); err != nil {
+				return err
+			}
+		}
 	}
 
-	if rawConfig.MergeQueue == nil {
-		// No merge_queue section, use defaults
-		return nil
+	name, raw, err := e.refineryConfigFile()
+	if err != nil || raw == nil {
+		return err
 	}
+	if err := applyMergeQueueConfig(cfg, raw); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
 
+// applyMergeQueueConfig applies the settings in data, a merge_queue
+// section, to cfg, keeping what data doesn't set.
+func applyMergeQueueConfig(cfg *MergeQueueConfig, data json.RawMessage) error {
 	// Parse merge_queue section into our config struct
 	// We need special handling for poll_interval (string -> Duration)
 	var mqRaw struct {
-		Enabled              *bool                      `json:"enabled"`
-		OnConflict           *string                    `json:"on_conflict"`
-		RunTests             *bool                      `json:"run_tests"`
-		TestCommand          *string                    `json:"test_command"`
-		DeleteMergedBranches *bool                      `json:"delete_merged_branches"`
-		RetryFlakyTests      *int                       `json:"retry_flaky_tests"`
-		PollInterval         *string                    `json:"poll_interval"`
-		MaxConcurrent        *int                       `json:"max_concurrent"`
-		StaleClaimTimeout    *string                    `json:"stale_claim_timeout"`
-		Gates                map[string]*gateConfigRaw  `json:"gates"`
-		GatesParallel        *bool                      `json:"gates_parallel"`
-	}
-
-	if err := json.Unmarshal(rawConfig.MergeQueue, &mqRaw); err != nil {
+		Enabled              *bool                           `json:"enabled"`
+		OnConflict           *string                         `json:"on_conflict"`
+		MergeStrategy        *string                         `json:"merge_strategy"`
+		AutoStrategy         *AutoStrategyConfig             `json:"auto_strategy"`
+		MergeMessage         *string                         `json:"merge_message"`
+		MergeTrailers        []string                        `json:"merge_trailers"`
+		RequiredTrailers     []string                        `json:"required_trailers"`
+		SignMode             *string                         `json:"sign_mode"`
+		SigningKey           *string                         `json:"signing_key"`
+		IssueURL             *string                         `json:"issue_url"`
+		RunTests             *bool                           `json:"run_tests"`
+		TestCommand          *string                         `json:"test_command"`
+		DeleteMergedBranches *bool                           `json:"delete_merged_branches"`
+		RetryFlakyTests      *int                            `json:"retry_flaky_tests"`
+		IsolatedWorktree     *bool                           `json:"isolated_worktree"`
+		PollInterval         *string                         `json:"poll_interval"`
+		MaxConcurrent        *int                            `json:"max_concurrent"`
+		StaleClaimTimeout    *string                         `json:"stale_claim_timeout"`
+		MergeSlotLeaseTTL    *string                         `json:"merge_slot_lease_ttl"`
+		SlotPartitions       map[string]*SlotPartitionConfig `json:"slot_partitions"`
+		Gates                map[string]*gateConfigRaw       `json:"gates"`
+		GatesParallel        *bool                           `json:"gates_parallel"`
+		GateBaseline         *bool                           `json:"gate_baseline"`
+		BaselineCheck        *bool                           `json:"baseline_check"`
+		ProtectedBranches    map[string]*protectedBranchRaw  `json:"protected_branches"`
+		Deploy               *deployConfigRaw                `json:"deploy"`
+		PostMergeHooks       []*postMergeHookRaw             `json:"post_merge_hooks"`
+		TestPolicy           *TestPolicyConfig               `json:"test_policy"`
+		SplitPolicy          *SplitPolicyConfig              `json:"split_policy"`
+		ConflictResolution   *ConflictResolutionConfig       `json:"conflict_resolution"`
+		ConflictGrace        *ConflictGraceConfig            `json:"conflict_grace"`
+		AutoRevert           *autoRevertRaw                  `json:"auto_revert"`
+		Approval             *ApprovalConfig                 `json:"approval"`
+		AdmissionLimits      *AdmissionLimitsConfig          `json:"admission_limits"`
+		Freshness            *FreshnessConfig                `json:"freshness"`
+		Hotfix               *HotfixConfig                   `json:"hotfix"`
+		AssetPolicy          *assetPolicyRaw                 `json:"asset_policy"`
+		MergeDrivers         map[string]*MergeDriverConfig   `json:"merge_drivers"`
+		Predictor            *predictorConfigRaw             `json:"predictor"`
+		Quarantine           *QuarantineConfig               `json:"quarantine"`
+		FailurePatterns      *FailurePatternConfig           `json:"failure_patterns"`
+		CulpritFeedback      *CulpritFeedbackConfig          `json:"culprit_feedback"`
+		Webhooks             []*webhookConfigRaw             `json:"webhooks"`
+		GitHub               *GitHubConfig                   `json:"github"`
+		Gerrit               *GerritConfig                   `json:"gerrit"`
+		FreezeWindows        []*FreezeWindowConfig           `json:"freeze_windows"`
+		MergeWindows         []*MergeWindowConfig            `json:"merge_windows"`
+		Batch                json.RawMessage                 `json:"batch"`
+		Energy               *energyConfigRaw                `json:"energy"`
+	}
+
+	if err := json.Unmarshal(data, &mqRaw); err != nil {
 		return fmt.Errorf("parsing merge_queue config: %w", err)
 	}
 
 	// Apply non-nil values to config (preserving defaults for missing fields)
 	if mqRaw.Enabled != nil {
-		e.config.Enabled = *mqRaw.Enabled
+		cfg.Enabled = *mqRaw.Enabled
 	}
 	if mqRaw.OnConflict != nil {
-		e.config.OnConflict = *mqRaw.OnConflict
+		cfg.OnConflict = *mqRaw.OnConflict
+	}
+	if mqRaw.MergeStrategy != nil {
+		if !validMergeStrategy(*mqRaw.MergeStrategy) {
+			return fmt.Errorf("invalid merge_strategy %q: want squash, merge-commit, rebase-ff, cherry-pick or auto", *mqRaw.MergeStrategy)
+		}
+		cfg.MergeStrategy = *mqRaw.MergeStrategy
+	}
+	if mqRaw.AutoStrategy != nil {
+		if err := parseAutoStrategy(mqRaw.AutoStrategy); err != nil {
+			return err
+		}
+		cfg.AutoStrategy = mqRaw.AutoStrategy
+	}
+	if mqRaw.MergeMessage != nil {
+		if _, err := parseMergeMessage(*mqRaw.MergeMessage); err != nil {
+			return fmt.Errorf("invalid merge_message: %w", err)
+		}
+		cfg.MergeMessage = *mqRaw.MergeMessage
+	}
+	if mqRaw.MergeTrailers != nil {
+		if err := validateTrailerKeys("merge_trailers", mqRaw.MergeTrailers); err != nil {
+			return err
+		}
+		cfg.MergeTrailers = mqRaw.MergeTrailers
+	}
+	if mqRaw.RequiredTrailers != nil {
+		if err := validateTrailerKeys("required_trailers", mqRaw.RequiredTrailers); err != nil {
+			return err
+		}
+		cfg.RequiredTrailers = mqRaw.RequiredTrailers
+	}
+	if mqRaw.SignMode != nil {
+		if !validSignMode(*mqRaw.SignMode) {
+			return fmt.Errorf("invalid sign_mode %q: want off, auto or required", *mqRaw.SignMode)
+		}
+		cfg.SignMode = *mqRaw.SignMode
+	}
+	if mqRaw.SigningKey != nil {
+		cfg.SigningKey = *mqRaw.SigningKey
+	}
+	if mqRaw.IssueURL != nil {
+		cfg.IssueURL = *mqRaw.IssueURL
 	}
 	if mqRaw.RunTests != nil {
-		e.config.RunTests = *mqRaw.RunTests
+		cfg.RunTests = *mqRaw.RunTests
 	}
 	if mqRaw.TestCommand != nil {
-		e.config.TestCommand = *mqRaw.TestCommand
+		cfg.TestCommand = *mqRaw.TestCommand
 	}
 	if mqRaw.DeleteMergedBranches != nil {
-		e.config.DeleteMergedBranches = *mqRaw.DeleteMergedBranches
+		cfg.DeleteMergedBranches = *mqRaw.DeleteMergedBranches
 	}
 	if mqRaw.RetryFlakyTests != nil {
-		e.config.RetryFlakyTests = *mqRaw.RetryFlakyTests
+		cfg.RetryFlakyTests = *mqRaw.RetryFlakyTests
+	}
+	if mqRaw.IsolatedWorktree != nil {
+		cfg.IsolatedWorktree = *mqRaw.IsolatedWorktree
 	}
 	if mqRaw.MaxConcurrent != nil {
-		e.config.MaxConcurrent = *mqRaw.MaxConcurrent
+		cfg.MaxConcurrent = *mqRaw.MaxConcurrent
 	}
 	if mqRaw.PollInterval != nil {
 		dur, err := time.ParseDuration(*mqRaw.PollInterval)
 		if err != nil {
 			return fmt.Errorf("invalid poll_interval %q: %w", *mqRaw.PollInterval, err)
 		}
-		e.config.PollInterval = dur
+		cfg.PollInterval = dur
 	}
 	if mqRaw.StaleClaimTimeout != nil {
 		dur, err := time.ParseDuration(*mqRaw.StaleClaimTimeout)
@@ -341,39 +854,286 @@ func (e *Engineer) LoadConfig() error {
 		if dur <= 0 {
 			return fmt.Errorf("stale_claim_timeout must be positive, got %v", dur)
 		}
-		e.config.StaleClaimTimeout = dur
+		cfg.StaleClaimTimeout = dur
+	}
+	if mqRaw.MergeSlotLeaseTTL != nil {
+		dur, err := time.ParseDuration(*mqRaw.MergeSlotLeaseTTL)
+		if err != nil {
+			return fmt.Errorf("invalid merge_slot_lease_ttl %q: %w", *mqRaw.MergeSlotLeaseTTL, err)
+		}
+		if dur < 0 {
+			return fmt.Errorf("merge_slot_lease_ttl must not be negative, got %v", dur)
+		}
+		cfg.MergeSlotLeaseTTL = dur
+	}
+	if mqRaw.SlotPartitions != nil {
+		if err := validateSlotPartitions(mqRaw.SlotPartitions); err != nil {
+			return err
+		}
+		cfg.SlotPartitions = mqRaw.SlotPartitions
 	}
 
 	// Parse gates configuration
 	if mqRaw.Gates != nil {
-		e.config.Gates = make(map[string]*GateConfig, len(mqRaw.Gates))
-		for name, raw := range mqRaw.Gates {
-			gc := &GateConfig{Cmd: raw.Cmd}
-			if raw.Timeout != "" {
-				dur, err := time.ParseDuration(raw.Timeout)
+		gates, err := parseGates(mqRaw.Gates)
+		if err != nil {
+			return err
+		}
+		cfg.Gates = gates
+	}
+	if mqRaw.GatesParallel != nil {
+		cfg.GatesParallel = *mqRaw.GatesParallel
+	}
+	if mqRaw.GateBaseline != nil {
+		cfg.GateBaseline = *mqRaw.GateBaseline
+	}
+	if mqRaw.BaselineCheck != nil {
+		cfg.BaselineCheck = *mqRaw.BaselineCheck
+	}
+
+	// Parse protected branches
+	if mqRaw.ProtectedBranches != nil {
+		cfg.ProtectedBranches = make(map[string]*ProtectedBranchConfig, len(mqRaw.ProtectedBranches))
+		for branch, raw := range mqRaw.ProtectedBranches {
+			if raw == nil {
+				raw = &protectedBranchRaw{}
+			}
+			pb := &ProtectedBranchConfig{SyncFrom: raw.SyncFrom, Batch: raw.Batch}
+			if raw.Gates != nil {
+				gates, err := parseGates(raw.Gates)
 				if err != nil {
-					return fmt.Errorf("invalid timeout for gate %q: %w", name, err)
+					return fmt.Errorf("protected branch %q: %w", branch, err)
 				}
-				if dur <= 0 {
-					return fmt.Errorf("gate %q timeout must be positive, got %v", name, dur)
+				pb.Gates = gates
+			}
+			if raw.SyncInterval != "" {
+				dur, err := time.ParseDuration(raw.SyncInterval)
+				if err != nil {
+					return fmt.Errorf("invalid sync_interval for protected branch %q: %w", branch, err)
 				}
-				gc.Timeout = dur
+				pb.SyncInterval = dur
 			}
-			e.config.Gates[name] = gc
+			cfg.ProtectedBranches[branch] = pb
 		}
 	}
-	if mqRaw.GatesParallel != nil {
-		e.config.GatesParallel = *mqRaw.GatesParallel
+
+	// Parse deploy hooks
+	if mqRaw.Deploy != nil {
+		deploy, err := parseDeployConfig(mqRaw.Deploy)
+		if err != nil {
+			return err
+		}
+		cfg.Deploy = deploy
+	}
+
+	if mqRaw.PostMergeHooks != nil {
+		hooks, err := parsePostMergeHooks(mqRaw.PostMergeHooks)
+		if err != nil {
+			return err
+		}
+		cfg.PostMergeHooks = hooks
+	}
+
+	if mqRaw.TestPolicy != nil {
+		if mqRaw.TestPolicy.MinCodeLines < 0 {
+			return fmt.Errorf("test_policy min_code_lines must be non-negative, got %d", mqRaw.TestPolicy.MinCodeLines)
+		}
+		cfg.TestPolicy = mqRaw.TestPolicy
+	}
+
+	if mqRaw.SplitPolicy != nil {
+		if err := validateSplitPolicy(mqRaw.SplitPolicy); err != nil {
+			return err
+		}
+		cfg.SplitPolicy = mqRaw.SplitPolicy
+	}
+
+	if mqRaw.ConflictResolution != nil {
+		if err := validateConflictResolution(mqRaw.ConflictResolution); err != nil {
+			return err
+		}
+		cfg.ConflictResolution = mqRaw.ConflictResolution
+	}
+	if mqRaw.ConflictGrace != nil {
+		if err := validateConflictGrace(mqRaw.ConflictGrace); err != nil {
+			return err
+		}
+		cfg.ConflictGrace = mqRaw.ConflictGrace
+	}
+
+	if mqRaw.Hotfix != nil {
+		if err := validateHotfix(mqRaw.Hotfix); err != nil {
+			return err
+		}
+		cfg.Hotfix = mqRaw.Hotfix
+	}
+	if mqRaw.Approval != nil {
+		if err := validateApproval(mqRaw.Approval); err != nil {
+			return err
+		}
+		cfg.Approval = mqRaw.Approval
+	}
+	if mqRaw.AdmissionLimits != nil {
+		if err := validateAdmissionLimits(mqRaw.AdmissionLimits); err != nil {
+			return err
+		}
+		cfg.AdmissionLimits = mqRaw.AdmissionLimits
+	}
+	if mqRaw.Freshness != nil {
+		if err := validateFreshness(mqRaw.Freshness); err != nil {
+			return err
+		}
+		cfg.Freshness = mqRaw.Freshness
+	}
+
+	if mqRaw.AssetPolicy != nil {
+		assetPolicy, err := parseAssetPolicy(mqRaw.AssetPolicy)
+		if err != nil {
+			return err
+		}
+		cfg.AssetPolicy = assetPolicy
+	}
+
+	if mqRaw.AutoRevert != nil {
+		autoRevert, err := parseAutoRevert(mqRaw.AutoRevert)
+		if err != nil {
+			return err
+		}
+		cfg.AutoRevert = autoRevert
+	}
+
+	if mqRaw.MergeDrivers != nil {
+		if err := validateMergeDrivers(mqRaw.MergeDrivers); err != nil {
+			return err
+		}
+		cfg.MergeDrivers = mqRaw.MergeDrivers
+	}
+
+	if mqRaw.Predictor != nil {
+		predictor, err := parsePredictorConfig(mqRaw.Predictor)
+		if err != nil {
+			return err
+		}
+		cfg.Predictor = predictor
+	}
+
+	if mqRaw.Quarantine != nil {
+		if err := validateQuarantineConfig(mqRaw.Quarantine); err != nil {
+			return err
+		}
+		cfg.Quarantine = mqRaw.Quarantine
+	}
+
+	if mqRaw.FailurePatterns != nil {
+		if err := validateFailurePatterns(mqRaw.FailurePatterns); err != nil {
+			return err
+		}
+		cfg.FailurePatterns = mqRaw.FailurePatterns
+	}
+
+	if mqRaw.CulpritFeedback != nil {
+		if err := validateCulpritFeedback(mqRaw.CulpritFeedback); err != nil {
+			return err
+		}
+		cfg.CulpritFeedback = mqRaw.CulpritFeedback
+	}
+
+	if mqRaw.Webhooks != nil {
+		webhooks, err := parseWebhooks(mqRaw.Webhooks)
+		if err != nil {
+			return err
+		}
+		cfg.Webhooks = webhooks
+	}
+
+	if mqRaw.GitHub != nil {
+		if err := validateGitHubConfig(mqRaw.GitHub); err != nil {
+			return err
+		}
+		cfg.GitHub = mqRaw.GitHub
+	}
+
+	if mqRaw.Gerrit != nil {
+		if err := validateGerritConfig(mqRaw.Gerrit); err != nil {
+			return err
+		}
+		cfg.Gerrit = mqRaw.Gerrit
+	}
+
+	if mqRaw.FreezeWindows != nil {
+		if err := parseFreezeWindows(mqRaw.FreezeWindows); err != nil {
+			return err
+		}
+		cfg.FreezeWindows = mqRaw.FreezeWindows
+	}
+
+	if mqRaw.MergeWindows != nil {
+		if err := parseMergeWindows(mqRaw.MergeWindows); err != nil {
+			return err
+		}
+		cfg.MergeWindows = mqRaw.MergeWindows
+	}
+
+	if mqRaw.Batch != nil {
+		batch, err := parseBatchConfig(mqRaw.Batch)
+		if err != nil {
+			return err
+		}
+		cfg.Batch = batch
+	}
+
+	if mqRaw.Energy != nil {
+		energy, err := parseEnergyConfig(mqRaw.Energy)
+		if err != nil {
+			return err
+		}
+		cfg.Energy = energy
 	}
 
 	return nil
 }
 
+// parseGates converts JSON gate configs, parsing their timeouts.
+func parseGates(raws map[string]*gateConfigRaw) (map[string]*GateConfig, error) {
+	gates := make(map[string]*GateConfig, len(raws))
+	for name, raw := range raws {
+		gc := &GateConfig{Cmd: raw.Cmd, Flaky: raw.Flaky, Dir: raw.Dir, Env: raw.Env, Shell: raw.Shell,
+			Image: raw.Image, Runtime: raw.Runtime, Paths: raw.Paths}
+		if err := validateGateRun(name, gc); err != nil {
+			return nil, err
+		}
+		for _, p := range gc.Paths {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("gate %q: bad path pattern %q: %w", name, p, err)
+			}
+		}
+		if raw.Timeout != "" {
+			dur, err := time.ParseDuration(raw.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for gate %q: %w", name, err)
+			}
+			if dur <= 0 {
+				return nil, fmt.Errorf("gate %q timeout must be positive, got %v", name, dur)
+			}
+			gc.Timeout = dur
+		}
+		gates[name] = gc
+	}
+	return gates, nil
+}
+
 // gateConfigRaw is the JSON-friendly representation of a gate config
 // with timeout as a string duration.
 type gateConfigRaw struct {
-	Cmd     string `json:"cmd"`
-	Timeout string `json:"timeout"`
+	Cmd     string            `json:"cmd"`
+	Timeout string            `json:"timeout"`
+	Flaky   bool              `json:"flaky"`
+	Dir     string            `json:"dir"`
+	Env     map[string]string `json:"env"`
+	Shell   string            `json:"shell"`
+	Image   string            `json:"image"`
+	Runtime string            `json:"runtime"`
+	Paths   []string          `json:"paths"`
 }
 
 // Config returns the current merge queue configuration.
@@ -388,12 +1148,17 @@ type ProcessResult struct {
 	Error          string
 	Conflict       bool
 	TestsFailed    bool
-	SlotTimeout    bool // Merge slot contention timeout (distinct from build/test failure)
-	BranchNotFound bool // Source branch no longer exists (e.g. cleaned up after cherry-pick)
+	SlotTimeout    bool     // Merge slot contention timeout (distinct from build/test failure)
+	GateTimedOut   bool     // Every failing gate timed out rather than failed (see GateResult.TimedOut)
+	BranchNotFound bool     // Source branch no longer exists (e.g. cleaned up after cherry-pick)
+	Held           bool     // Validated, but held until the target's merge window opens
+	FailedGates    []string // Gates whose failure blocked, sorted
+	SkippedGates   []string // Gates not run because the tree changes none of their Paths, sorted
 }
 
-// doMerge performs the actual git merge operation.
-func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue string, skipGates ...bool) ProcessResult {
+// doMerge performs the actual git merge operation, landing mr on target.
+func (e *Engineer) doMerge(ctx context.Context, mr *MRInfo, target string, skipGates ...bool) ProcessResult {
+	branch, sourceIssue := mr.Branch, mr.SourceIssue
 	// Step 1: Verify source branch exists locally (shared .repo.git with polecats)
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking local branch %s...\n", branch)
 	exists, err := e.git.BranchExists(branch)
@@ -426,9 +1191,11 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: pull from origin/%s: %v (continuing)\n", target, err)
 	}
 
-	// Step 3: Check for merge conflicts (using local branch)
+	// Step 3: Check for merge conflicts (using local branch). Asset-heavy
+	// MRs move their assets to LFS first and are checked by path.
+	e.migrateAssetsToLFS(mr, target)
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking for conflicts...\n")
-	conflicts, err := e.git.CheckConflicts(branch, target)
+	conflicts, err := e.checkMRConflicts(mr, target)
 	if err != nil {
 		return ProcessResult{
 			Success:  false,
@@ -482,9 +1249,16 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 	shouldSkipGates := len(skipGates) > 0 && skipGates[0]
 	if shouldSkipGates {
 		_, _ = fmt.Fprintln(e.output, "[Engineer] Skipping gates (pre-verified by polecat)")
-	} else if len(e.config.Gates) > 0 {
+	} else if gates := e.mrGates(mr, target); len(gates) > 0 {
 		// New gates system: run configured quality gates
-		gateResult := e.runGates(ctx)
+		ctx := withGateArtifacts(e.withGateBaseline(ctx, target), mr)
+		gateResult := e.runGateSet(ctx, gates)
+		if gateResult.GateTimedOut {
+			// A hung gate says little about the MR; give it one more run
+			// before blaming the MR.
+			_, _ = fmt.Fprintln(e.output, "[Engineer] Gates timed out, retrying once...")
+			gateResult = e.runGateSet(ctx, gates)
+		}
 		if !gateResult.Success {
 			return gateResult
 		}
@@ -502,24 +1276,18 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 		_, _ = fmt.Fprintln(e.output, "[Engineer] Tests passed")
 	}
 
-	// Step 5: Perform the actual merge using squash merge
-	// Get the original commit message from the polecat branch to preserve the
-	// conventional commit format (feat:/fix:) instead of creating redundant merge commits
-	originalMsg, err := e.git.GetBranchCommitMessage(branch)
-	if err != nil {
-		// Fallback to a descriptive message if we can't get the original
-		originalMsg = fmt.Sprintf("Squash merge %s into %s", branch, target)
-		if sourceIssue != "" {
-			originalMsg = fmt.Sprintf("Squash merge %s into %s (%s)", branch, target, sourceIssue)
-		}
-		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: could not get original commit message: %v\n", err)
-	}
-	_, _ = fmt.Fprintf(e.output, "[Engineer] Squash merging with message: %s\n", strings.TrimSpace(originalMsg))
-	if err := e.git.MergeSquash(branch, originalMsg); err != nil {
+	// Step 5: Land the branch with the MR's merge strategy (see merge_strategy.go).
+	// Squash merges keep the polecat's conventional commit message (feat:/fix:)
+	// instead of creating redundant merge commits.
+	landing := *mr
+	landing.Target = target
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Merging %s into %s (%s)\n", branch, target, e.mergeStrategy(mr))
+	e.ensureMergeDrivers()
+	if err := e.mergeMR(&landing); err != nil {
 		// ZFC: Use git's porcelain output to detect conflicts instead of parsing stderr.
 		// GetConflictingFiles() uses `git diff --diff-filter=U` which is proper.
 		conflicts, conflictErr := e.git.GetConflictingFiles()
-		if conflictErr == nil && len(conflicts) > 0 {
+		if errors.Is(err, errMergeConflict) || (conflictErr == nil && len(conflicts) > 0) {
 			_ = e.git.AbortMerge()
 			return ProcessResult{
 				Success:  false,
@@ -535,6 +1303,18 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 		}
 	}
 
+	// Step 5.5: Run the source issue's acceptance criteria on the merged tree.
+	// These run even for pre-verified MRs: polecat verification only covers
+	// the rig's configured gates.
+	if sourceIssue != "" {
+		if acceptResult := e.runAcceptance(ctx, []*MRInfo{&landing}); !acceptResult.Success {
+			if resetErr := e.git.ResetHard("origin/" + target); resetErr != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to reset %s after acceptance failure: %v\n", target, resetErr)
+			}
+			return acceptResult
+		}
+	}
+
 	// Step 6: Get the merge commit SHA
 	mergeCommit, err := e.git.Rev("HEAD")
 	if err != nil {
@@ -544,13 +1324,19 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 		}
 	}
 
+	// Outside the target's merge windows, hold the validated merge.
+	if closed, opens := e.MergeWindowClosed(target, time.Now()); closed {
+		e.holdLanding([]*MRInfo{mr}, target, mr.batchID, opens)
+		return ProcessResult{Success: false, Held: true, Error: "merge window closed for " + target}
+	}
+
 	// Step 7: Acquire merge slot before push to serialize writes to the default branch.
 	// Only serialize pushes to the rig's default branch (typically main).
 	// Integration-branch and feature-branch pushes don't need serialization.
-	var pushHolder string
+	var slots *landingSlots
 	if target == e.rig.DefaultBranch() {
 		var slotErr error
-		pushHolder, slotErr = e.acquireMainPushSlot(ctx)
+		slots, slotErr = e.acquireLandingSlots(ctx, target)
 		if slotErr != nil {
 			// Reset the checked-out target branch to origin to undo the local squash commit.
 			// ResetHard is required because target is the current branch (checked out in Step 2).
@@ -566,20 +1352,19 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 				Error:       fmt.Sprintf("failed to acquire merge slot before push: %v", slotErr),
 			}
 		}
-		defer func() {
-			// pushHolder is empty when the self-conflict bypass fires — conflict-resolution
-			// owns the slot, so we must not release it here.
-			if pushHolder != "" {
-				if releaseErr := e.mergeSlotRelease(pushHolder); releaseErr != nil {
-					_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to release merge slot for push (%s): %v\n", pushHolder, releaseErr)
-				}
-			}
-		}()
+		defer slots.release()
+	}
+
+	if err := e.journalStack(BatchGated, []*MRInfo{mr}, target); err != nil {
+		if resetErr := e.git.ResetHard("origin/" + target); resetErr != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to reset %s after journal failure: %v\n", target, resetErr)
+		}
+		return ProcessResult{Success: false, Error: err.Error()}
 	}
 
 	// Step 8: Push to origin
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Pushing to origin/%s...\n", target)
-	if err := e.git.Push("origin", target, false); err != nil {
+	if err := e.pushLanding(target, slots); err != nil {
 		// Reset the checked-out target branch to undo the local squash commit.
 		// Without this, the next retry could see stale local state from the failed push.
 		if resetErr := e.git.ResetHard("origin/" + target); resetErr != nil {
@@ -591,7 +1376,14 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 		}
 	}
 
+	if sha, err := e.git.Rev("HEAD"); err == nil {
+		mergeCommit = sha // Rebased when pushLanding caught up with other partitions
+	}
+	e.warnJournal(e.journalStack(BatchPushed, []*MRInfo{mr}, target))
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Successfully merged: %s\n", mergeCommit[:8])
+	if sourceIssue != "" {
+		e.markAcceptanceVerified([]*MRInfo{{SourceIssue: sourceIssue}})
+	}
 	return ProcessResult{
 		Success:     true,
 		MergeCommit: mergeCommit,
@@ -636,7 +1428,15 @@ func (e *Engineer) acquireMainPushSlot(ctx context.Context) (string, error) {
 		if status == nil {
 			return "", fmt.Errorf("acquire merge slot %s (%s): empty status", slotID, holder)
 		}
+		// Slot held under an expired lease — its holder died mid-push.
+		if !status.Available && status.Holder != holder && e.reclaimExpiredSlot(slotID, status.Holder) {
+			if status, err = e.mergeSlotAcquire(holder, false); err != nil || status == nil {
+				continue
+			}
+		}
 		if status.Available || status.Holder == holder {
+			e.pushSlot = holder
+			e.holdSlotLease(slotID, holder)
 			return holder, nil
 		}
 		// Slot held by our own conflict-resolution path — safe to proceed.
@@ -662,6 +1462,12 @@ func ValidateTestCommand(cmd string) error {
 
 // runTests runs the configured test command and returns the result.
 func (e *Engineer) runTests(ctx context.Context) ProcessResult {
+	return e.runTestsIn(ctx, e.workDir)
+}
+
+// runTestsIn runs the configured test command in dir.
+func (e *Engineer) runTestsIn(ctx context.Context, dir string) (result ProcessResult) {
+	defer func() { recordGateRun(ctx, result, nil) }()
 	if err := ValidateTestCommand(e.config.TestCommand); err != nil {
 		return ProcessResult{
 			Success: false,
@@ -686,7 +1492,7 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 		// is intentional for flexibility (pipes, env vars, etc).
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Executing test command: %s\n", e.config.TestCommand)
 		cmd := exec.CommandContext(ctx, "sh", "-c", e.config.TestCommand) //nolint:gosec // G204: TestCommand is from trusted rig config
-		cmd.Dir = e.workDir
+		cmd.Dir = dir
 		var stdout, stderr bytes.Buffer
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
@@ -715,6 +1521,17 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 
 // runGate executes a single quality gate command and returns the result.
 func (e *Engineer) runGate(ctx context.Context, name string, gate *GateConfig) GateResult {
+	return e.runGateIn(ctx, e.workDir, name, gate)
+}
+
+// runGateIn executes a quality gate command in dir.
+func (e *Engineer) runGateIn(ctx context.Context, dir, name string, gate *GateConfig) GateResult {
+	return e.meterGate(ctx, func() GateResult { return e.execGate(ctx, dir, name, gate) })
+}
+
+// execGateCmd runs gate's shell command in dir. It is the default execGate;
+// tests replace it to replay recorded gate outcomes (see fixture_test.go).
+func (e *Engineer) execGateCmd(ctx context.Context, dir, name string, gate *GateConfig) GateResult {
 	start := time.Now()
 
 	if strings.TrimSpace(gate.Cmd) == "" {
@@ -734,8 +1551,44 @@ func (e *Engineer) runGate(ctx context.Context, name string, gate *GateConfig) G
 		defer cancel()
 	}
 
-	cmd := exec.CommandContext(gateCtx, "sh", "-c", gate.Cmd) //nolint:gosec // G204: Gate commands are from trusted rig config
-	cmd.Dir = e.workDir
+	var cmd *exec.Cmd
+	if gate.Image != "" {
+		runtime, err := containerRuntime(gate)
+		if err != nil {
+			return GateResult{Name: name, Success: false, Error: err.Error(), Elapsed: time.Since(start)}
+		}
+		container := gateContainerName(name)
+		cmd = exec.CommandContext(gateCtx, runtime, containerGateArgs(container, dir, gate, gateEnv(ctx, gate))...) //nolint:gosec // G204: Gate commands are from trusted rig config
+		cmd.Env = os.Environ()
+		util.SetProcessGroup(cmd)
+		// Killing the runtime's client leaves the container running.
+		killGroup := cmd.Cancel
+		cmd.Cancel = func() error {
+			_ = exec.Command(runtime, "rm", "--force", container).Run() //nolint:gosec // G204: runtime from trusted rig config
+			if killGroup != nil {
+				return killGroup()
+			}
+			return cmd.Process.Kill()
+		}
+	} else {
+		shell := gateShell(gate)
+		cmd = exec.CommandContext(gateCtx, shell[0], append(shell[1:], "-c", gate.Cmd)...) //nolint:gosec // G204: Gate commands are from trusted rig config
+		cmd.Dir = dir
+		if gate.Dir != "" {
+			cmd.Dir = filepath.Join(dir, gate.Dir)
+		}
+		cmd.Env = os.Environ()
+		// Run on the rig's pinned toolchain, like its agents (see
+		// config.ToolchainConfig).
+		for k, v := range e.toolchainEnv() {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+		cmd.Env = append(cmd.Env, gateEnv(ctx, gate)...)
+		// Kill the whole tree on timeout: test runners fork workers that would
+		// otherwise outlive the shell and keep running against the next stack.
+		util.SetProcessGroup(cmd)
+	}
+	cmd.WaitDelay = gateWaitDelay
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -748,11 +1601,16 @@ func (e *Engineer) runGate(ctx context.Context, name string, gate *GateConfig) G
 			Name:    name,
 			Success: true,
 			Elapsed: elapsed,
+			Stdout:  stdout.Bytes(),
+			Stderr:  stderr.Bytes(),
 		}
 	}
 
 	errMsg := fmt.Sprintf("%v", err)
-	if gateCtx.Err() == context.DeadlineExceeded {
+	// Only the gate's own deadline counts as a timeout; an expired or
+	// cancelled parent context is the caller giving up.
+	timedOut := gate.Timeout > 0 && gateCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+	if timedOut {
 		errMsg = fmt.Sprintf("timed out after %v", gate.Timeout)
 	}
 	if stderrStr := strings.TrimSpace(stderr.String()); stderrStr != "" {
@@ -764,32 +1622,81 @@ func (e *Engineer) runGate(ctx context.Context, name string, gate *GateConfig) G
 	}
 
 	return GateResult{
-		Name:    name,
-		Success: false,
-		Error:   errMsg,
-		Elapsed: elapsed,
+		Name:     name,
+		Success:  false,
+		TimedOut: timedOut,
+		Error:    errMsg,
+		Elapsed:  elapsed,
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
 	}
 }
 
+// toolchainEnv returns the rig's toolchain pins for gate commands.
+func (e *Engineer) toolchainEnv() map[string]string {
+	if e.rig == nil {
+		return nil
+	}
+	return config.RigToolchainEnv(e.rig.Path)
+}
+
 // runGates executes all configured quality gates and returns a ProcessResult.
 // Gates run in parallel if GatesParallel is true; otherwise sequentially.
 // Any single gate failure means overall failure.
 func (e *Engineer) runGates(ctx context.Context) ProcessResult {
-	gates := e.config.Gates
+	return e.runGateSet(ctx, e.config.Gates)
+}
+
+// runGateSet executes the given quality gates, as runGates does.
+func (e *Engineer) runGateSet(ctx context.Context, gates map[string]*GateConfig) ProcessResult {
+	return e.runGateSetIn(ctx, e.workDir, gates)
+}
+
+// runGateSetIn executes the given quality gates in dir.
+func (e *Engineer) runGateSetIn(ctx context.Context, dir string, gates map[string]*GateConfig) (result ProcessResult) {
 	if len(gates) == 0 {
 		return ProcessResult{Success: true}
 	}
 
-	// Sort gate names for deterministic ordering
+	// Sort gate names for deterministic ordering, leaving out gates a
+	// human issued a skip token for (see GateSkipToken).
+	skipped := e.useSkipTokens(ctx, dir, gates)
+	unaffected := e.unaffectedGates(ctx, gates)
 	names := make([]string, 0, len(gates))
 	for name := range gates {
-		names = append(names, name)
+		if skipped[name] == nil && !unaffected[name] {
+			names = append(names, name)
+		}
 	}
 	sort.Strings(names)
+	var unaffectedNames []string
+	for _, name := range sortedGateNames(unaffected) {
+		if skipped[name] == nil {
+			unaffectedNames = append(unaffectedNames, name)
+		}
+	}
 
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Running %d quality gate(s) (parallel=%v)\n", len(names), e.config.GatesParallel)
 
 	var results []GateResult
+	defer func() {
+		for _, name := range skippedGateNames(skipped) {
+			results = append(results, GateResult{Name: name, Success: true, SkippedBy: skipped[name].ID})
+		}
+		for _, name := range unaffectedNames {
+			results = append(results, GateResult{Name: name, Success: true, Unaffected: true})
+		}
+		recordGateRun(ctx, result, results)
+		result.SkippedGates = unaffectedNames
+	}()
+	for _, name := range skippedGateNames(skipped) {
+		t := skipped[name]
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: SKIPPED for %s by token %s (issued by %s: %s)\n", name, t.MR, t.ID, t.IssuedBy, t.Reason)
+	}
+	for _, name := range unaffectedNames {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: SKIPPED, no changes under %s\n", name, strings.Join(gates[name].Paths, ", "))
+	}
+	quarantined := e.quarantinedGateSet()
 
 	if e.config.GatesParallel {
 		results = make([]GateResult, len(names))
@@ -799,38 +1706,74 @@ func (e *Engineer) runGates(ctx context.Context) ProcessResult {
 			go func(idx int, gateName string) {
 				defer wg.Done()
 				_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: starting (%s)\n", gateName, gates[gateName].Cmd)
-				results[idx] = e.runGate(ctx, gateName, gates[gateName])
+				e.recordGateProgress(ctx, StageGateStarted, gateName, gateName)
+				results[idx] = e.runGateTracked(ctx, dir, gateName, gates[gateName])
+				results[idx].Quarantined = quarantined[gateName]
+				if !results[idx].Success && !results[idx].Quarantined {
+					results[idx].Baseline = e.failsOnBaseline(ctx, gateName, gates[gateName])
+				}
 			}(i, name)
 		}
 		wg.Wait()
 	} else {
 		for _, name := range names {
 			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: starting (%s)\n", name, gates[name].Cmd)
-			result := e.runGate(ctx, name, gates[name])
+			e.recordGateProgress(ctx, StageGateStarted, name, name)
+			result := e.runGateTracked(ctx, dir, name, gates[name])
+			result.Quarantined = quarantined[name]
+			if !result.Success && !result.Quarantined {
+				result.Baseline = e.failsOnBaseline(ctx, name, gates[name])
+			}
 			results = append(results, result)
-			if !result.Success {
-				// Sequential mode: stop on first failure
+			if !result.Success && !result.Quarantined && !result.Baseline {
+				// Sequential mode: stop on first blocking failure
 				break
 			}
 		}
 	}
 
 	// Report results
-	var failures []string
+	var tip string
+	if ctx.Value(gateArtifactKey{}) != nil {
+		tip, _ = git.NewGit(dir).Rev("HEAD")
+	}
+	var failures, failed []string
+	timedOut := true
 	for _, r := range results {
-		if r.Success {
+		e.saveGateLog(ctx, r, gates[r.Name], tip)
+		e.recordGateResult(ctx, r)
+		switch {
+		case r.Success && r.Flaky:
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: passed on retry, flaky (%v)\n", r.Name, r.Elapsed.Truncate(time.Millisecond))
+		case r.Success:
 			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: passed (%v)\n", r.Name, r.Elapsed.Truncate(time.Millisecond))
-		} else {
+		case r.Quarantined:
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: FAILED, quarantined as flaky, not blocking (%v) - %s\n", r.Name, r.Elapsed.Truncate(time.Millisecond), r.Error)
+		case r.Baseline:
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: FAILED, failing on the target too, not blocking (%v) - %s\n", r.Name, r.Elapsed.Truncate(time.Millisecond), r.Error)
+		case r.TimedOut:
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: TIMED OUT (%v) - %s\n", r.Name, r.Elapsed.Truncate(time.Millisecond), r.Error)
+			failures = append(failures, fmt.Sprintf("%s: %s", r.Name, r.Error))
+			failed = append(failed, r.Name)
+		default:
 			_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: FAILED (%v) - %s\n", r.Name, r.Elapsed.Truncate(time.Millisecond), r.Error)
 			failures = append(failures, fmt.Sprintf("%s: %s", r.Name, r.Error))
+			failed = append(failed, r.Name)
+			timedOut = false
 		}
 	}
 
 	if len(failures) > 0 {
+		verb := "failed"
+		if timedOut {
+			verb = "timed out"
+		}
 		return ProcessResult{
-			Success:     false,
-			TestsFailed: true,
-			Error:       fmt.Sprintf("quality gates failed: %s", strings.Join(failures, "; ")),
+			Success:      false,
+			TestsFailed:  true,
+			GateTimedOut: timedOut,
+			FailedGates:  failed,
+			Error:        fmt.Sprintf("quality gates %s: %s", verb, strings.Join(failures, "; ")),
 		}
 	}
 
@@ -872,6 +1815,7 @@ func (e *Engineer) syncCrewWorkspaces() {
 
 // ProcessMRInfo processes a merge request from MRInfo.
 func (e *Engineer) ProcessMRInfo(ctx context.Context, mr *MRInfo) ProcessResult {
+	e.resetAcceptance()
 	// MR fields are directly on the struct
 	_, _ = fmt.Fprintln(e.output, "[Engineer] Processing MR:")
 	_, _ = fmt.Fprintf(e.output, "  Branch: %s\n", mr.Branch)
@@ -898,8 +1842,42 @@ func (e *Engineer) ProcessMRInfo(ctx context.Context, mr *MRInfo) ProcessResult
 		}
 	}
 
+	held, prevalidatedBy := e.checkMergeWindow([]*MRInfo{mr}, mr.Target)
+	if held {
+		return ProcessResult{Success: false, Held: true, Error: "merge window closed for " + mr.Target}
+	}
+	if prevalidatedBy != nil {
+		skipGates = true
+	}
+
+	if err := e.isolateStacking(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Not merging: %v\n", err)
+		e.recordProgress(StageError, err.Error(), "", mr)
+		return ProcessResult{Success: false, Error: err.Error()}
+	}
+	if err := e.prepareSigning(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Not merging: %v\n", err)
+		e.recordProgress(StageError, err.Error(), "", mr)
+		return ProcessResult{Success: false, Error: err.Error()}
+	}
+
 	// Use the shared merge logic
-	return e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue, skipGates)
+	e.recordProgress(StageGating, "single MR", "", mr)
+	result := e.doMerge(ctx, mr, mr.Target, skipGates)
+	switch {
+	case result.Success:
+		e.recordProgress(StageMerged, shortSHA(result.MergeCommit), "", mr)
+		e.runPostMergeHooks(ctx, result.MergeCommit, mr.Target, mr.batchID, []*MRInfo{mr})
+	case result.Conflict:
+		e.recordProgress(StageConflict, result.Error, "", mr)
+	case result.TestsFailed:
+		e.recordProgress(StageCulprit, result.Error, "", mr)
+	case result.Held:
+		// Recorded as deferred when held.
+	default:
+		e.recordProgress(StageError, result.Error, "", mr)
+	}
+	return result
 }
 
 // HandleMRInfoSuccess handles a successful merge from MRInfo.
@@ -1024,6 +2002,12 @@ func (e *Engineer) HandleMRInfoFailure(mr *MRInfo, result ProcessResult) {
 		return
 	}
 
+	// Held for a merge window: validated, and lands once the window opens.
+	if result.Held {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] MR %s remains in queue: %s\n", mr.ID, result.Error)
+		return
+	}
+
 	// Branch-not-found means the remote branch was cleaned up before we could process it
 	// (e.g. cherry-picked to target directly). Skip polecat nudge — the polecat is gone.
 	if result.BranchNotFound {
@@ -1054,8 +2038,9 @@ func (e *Engineer) HandleMRInfoFailure(mr *MRInfo, result ProcessResult) {
 	}
 
 	// If this was a conflict, create a conflict-resolution task for dispatch
-	// and block the MR until the task is resolved (non-blocking delegation)
-	if result.Conflict {
+	// and block the MR until the task is resolved (non-blocking delegation),
+	// unless an agent is already resolving it (see requestConflictResolutions)
+	if result.Conflict && mr.HeldFor != HeldForConflict {
 		taskID, err := e.createConflictResolutionTaskForMR(mr, result)
 		if err != nil {
 			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to create conflict resolution task: %v\n", err)
@@ -1254,6 +2239,8 @@ func issueToMRInfo(issue *beads.Issue, fields *beads.MRFields) *MRInfo {
 		Worker:          fields.Worker,
 		Rig:             fields.Rig,
 		Title:           issue.Title,
+		Labels:          issue.Labels,
+		Urgent:          beads.HasLabel(issue, UrgentLabel),
 		Priority:        issue.Priority,
 		AgentBead:       fields.AgentBead,
 		RetryCount:      fields.RetryCount,
@@ -1262,6 +2249,9 @@ func issueToMRInfo(issue *beads.Issue, fields *beads.MRFields) *MRInfo {
 		PreVerified:     fields.PreVerified,
 		PreVerifiedAt:   preVerifiedAt,
 		PreVerifiedBase: fields.PreVerifiedBase,
+		QoS:             normalizeQoS(fields.QoS),
+		MergeStrategy:   normalizeMergeStrategy(fields.MergeStrategy),
+		SplitFrom:       fields.SplitFrom,
 		CreatedAt:       createdAt,
 		UpdatedAt:       updatedAt,
 		Assignee:        issue.Assignee,
@@ -1283,7 +2273,9 @@ func (e *Engineer) firstOpenBlocker(issue *beads.Issue) string {
 // ListReadyMRs returns MRs that are ready for processing:
 // - Not claimed by another worker (checked via assignee field)
 // - Not blocked by an open task (checked via firstOpenBlocker)
-// Sorted by priority (highest first).
+// Sorted by priority (highest first). An MR blocking a more urgent one,
+// however indirectly, inherits its priority (see inheritPriorities), so
+// the urgent MR isn't held up by a blocker waiting its turn.
 //
 // Uses bd list instead of bd ready because MRs are ephemeral beads and
 // bd ready filters out ephemeral issues (see gt-t5t6y). This matches the
@@ -1302,15 +2294,31 @@ func (e *Engineer) ListReadyMRs() ([]*MRInfo, error) {
 	}
 
 	// Convert beads issues to MRInfo
-	var mrs []*MRInfo
+	prios := e.labelPriorities()
+	openMRs := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		if issue.Status == "open" {
+			openMRs[issue.ID] = true
+		}
+	}
+	var mrs, waiting []*MRInfo
 	for _, issue := range issues {
 		// Skip closed MRs (workaround for bd list not respecting --status filter)
 		if issue.Status != "open" {
 			continue
 		}
 
-		// Skip blocked MRs (replaces bd ready's blocker filtering)
+		// Skip blocked MRs (replaces bd ready's blocker filtering). An MR
+		// waiting on another MR passes its priority on to it.
 		if blockedBy := e.firstOpenBlocker(issue); blockedBy != "" {
+			if fields := beads.ParseMRFields(issue); fields != nil && openMRs[blockedBy] {
+				mr := issueToMRInfo(issue, fields)
+				mr.BlockedBy = blockedBy
+				if len(prios) > 0 {
+					e.applyLabelPriorities(mr, issue.Labels, prios)
+				}
+				waiting = append(waiting, mr)
+			}
 			continue
 		}
 
@@ -1322,6 +2330,12 @@ func (e *Engineer) ListReadyMRs() ([]*MRInfo, error) {
 			continue
 		}
 
+		// Skip MRs forwarded to another town: the peer merges them and
+		// gt federation sync reflects the outcome here.
+		if beads.HasLabel(issue, "gt:federated") && strings.HasPrefix(issue.Assignee, "federation/") {
+			continue
+		}
+
 		fields := beads.ParseMRFields(issue)
 		if fields == nil {
 			continue // Skip issues without MR fields
@@ -1343,9 +2357,23 @@ func (e *Engineer) ListReadyMRs() ([]*MRInfo, error) {
 				issue.ID, issue.Assignee, issue.UpdatedAt)
 		}
 
-		mrs = append(mrs, issueToMRInfo(issue, fields))
+		mr := issueToMRInfo(issue, fields)
+		if !e.boundTo(mr) {
+			continue
+		}
+		if len(prios) > 0 {
+			e.applyLabelPriorities(mr, issue.Labels, prios)
+		}
+		mrs = append(mrs, mr)
 	}
 
+	inheritPriorities(mrs, waiting)
+	for _, mr := range mrs {
+		if mr.InheritedFrom != "" {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] MR %s inherits P%d from %s, which it blocks\n", mr.ID, mr.Priority, mr.InheritedFrom)
+		}
+	}
+	sortByPriority(mrs)
 	return mrs, nil
 }
 
@@ -1509,18 +2537,19 @@ func detectQueueAnomalies(
 // ClaimMR claims an MR for processing by setting the assignee field.
 // This replaces mrqueue.Claim() for beads-based MRs.
 // The workerID is typically the refinery's identifier (e.g., "gastown/refinery").
+// Only an MR waiting in the queue can be claimed (see QueueUpdate.Claim).
 func (e *Engineer) ClaimMR(mrID, workerID string) error {
-	return e.beads.Update(mrID, beads.UpdateOptions{
-		Assignee: &workerID,
+	return e.UpdateQueue(func(u *QueueUpdate) error {
+		_, err := u.Claim(mrID, workerID)
+		return err
 	})
 }
 
 // ReleaseMR releases a claimed MR back to the queue by clearing the assignee.
 // This replaces mrqueue.Release() for beads-based MRs.
 func (e *Engineer) ReleaseMR(mrID string) error {
-	empty := ""
-	return e.beads.Update(mrID, beads.UpdateOptions{
-		Assignee: &empty,
+	return e.UpdateQueue(func(u *QueueUpdate) error {
+		return u.Release(mrID)
 	})
 }
 
@@ -347,3 +347,22 @@ func TestAcquireMainPushSlot_NilStatus_NotTimeout(t *testing.T) {
 		t.Errorf("nil-status error should NOT be errMergeSlotTimeout, got: %v", err)
 	}
 }
+
+func TestUseLocalMergeSlot(t *testing.T) {
+	e := &Engineer{rig: &rig.Rig{Name: "testrig"}, output: io.Discard, mergeSlotRetryBackoff: time.Millisecond}
+	e.UseLocalMergeSlot()
+
+	first, err := e.acquireMainPushSlot(context.Background())
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if _, err := e.acquireMainPushSlot(context.Background()); !errors.Is(err, errMergeSlotTimeout) {
+		t.Errorf("second acquire while held = %v, want contention timeout", err)
+	}
+	if err := e.mergeSlotRelease(first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.acquireMainPushSlot(context.Background()); err != nil {
+		t.Errorf("acquire after release: %v", err)
+	}
+}
@@ -9,11 +9,14 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/rig"
 )
 
@@ -395,6 +398,119 @@ func TestRunGate_Failure(t *testing.T) {
 	}
 }
 
+func TestRunGate_PinnedToolchain(t *testing.T) {
+	rigPath := t.TempDir()
+	settingsPath := config.RigSettingsPath(rigPath)
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"type": "rig-settings", "version": 1, "toolchain": {"manager": "asdf", "tools": {"node": "20.11.1"}}}`)
+	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.workDir = t.TempDir()
+
+	result := e.runGate(context.Background(), "node-version", &GateConfig{
+		Cmd: `test "$ASDF_NODEJS_VERSION" = 20.11.1`,
+	})
+	if !result.Success {
+		t.Errorf("gate did not see the pinned toolchain: %s", result.Error)
+	}
+}
+
+func TestRunGate_DirEnvAndShell(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.workDir = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(e.workDir, "services", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(e.workDir, "services", "api"), "marker", "")
+
+	mrs := []*MRInfo{{ID: "mr-1", batchID: "batch-7"}, {ID: "mr-2", batchID: "batch-7"}}
+	ctx := withGateStack(context.Background(), mrs)
+	result := e.runGate(ctx, "api", &GateConfig{
+		Cmd:   `test -f marker && test "$BATCH_ID" = batch-7 && test "$FILTER" = "mr-1 mr-2" && test "$MODE" = ci && [[ -n "$BASH_VERSION" ]]`,
+		Dir:   "services/api",
+		Env:   map[string]string{"FILTER": "${MR_IDS}", "MODE": "ci"},
+		Shell: "bash -o pipefail",
+	})
+	if !result.Success {
+		t.Errorf("gate did not run in its dir with its env and shell: %s", result.Error)
+	}
+}
+
+func TestContainerGateArgs(t *testing.T) {
+	gate := &GateConfig{Cmd: "go test ./...", Dir: "services/api", Shell: "bash -o pipefail", Image: "golang:1.23"}
+	got := strings.Join(containerGateArgs("gt-gate-api-1", "/rig/tree", gate, []string{"BATCH_ID=b-1"}), " ")
+	want := "run --rm --init --name gt-gate-api-1 --volume /rig/tree:/workspace:ro --workdir /workspace/services/api" +
+		" --env BATCH_ID=b-1 golang:1.23 bash -o pipefail -c go test ./..."
+	if got != want {
+		t.Errorf("args = %q\nwant   %q", got, want)
+	}
+	if name := gateContainerName("unit tests"); !strings.HasPrefix(name, "gt-gate-unit-tests-") {
+		t.Errorf("container name = %q", name)
+	}
+}
+
+func TestRunGate_Container(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake runtime is a shell script")
+	}
+	// A fake runtime that records its arguments in place of a container.
+	bin := t.TempDir()
+	argsFile := filepath.Join(t.TempDir(), "args")
+	writeFile(t, bin, "podman", "#!/bin/sh\necho \"$@\" > "+argsFile+"\n")
+	if err := os.Chmod(filepath.Join(bin, "podman"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.workDir = t.TempDir()
+	result := e.runGate(context.Background(), "test", &GateConfig{Cmd: "make test", Image: "alpine:3", Runtime: "podman"})
+	if !result.Success {
+		t.Fatalf("gate failed: %s", result.Error)
+	}
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(args), e.workDir+":/workspace:ro") || !strings.HasSuffix(strings.TrimSpace(string(args)), "alpine:3 sh -c make test") {
+		t.Errorf("runtime ran with %q", args)
+	}
+
+	result = e.runGate(context.Background(), "test", &GateConfig{Cmd: "true", Image: "alpine:3", Runtime: "docker-missing"})
+	if result.Success {
+		t.Error("gate ran without its runtime")
+	}
+}
+
+func TestLoadConfig_GateRunValidation(t *testing.T) {
+	for name, gate := range map[string]map[string]interface{}{
+		"escaping dir": {"cmd": "true", "dir": "../other"},
+		"absolute dir": {"cmd": "true", "dir": "/tmp"},
+		"bad env name": {"cmd": "true", "env": map[string]string{"A=B": "x"}},
+		"blank shell":  {"cmd": "true", "shell": "  "},
+		"flag image":   {"cmd": "true", "image": "--privileged"},
+		"bad runtime":  {"cmd": "true", "image": "golang:1.23", "runtime": "lxc"},
+		"no image":     {"cmd": "true", "runtime": "docker"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			data, _ := json.Marshal(map[string]interface{}{
+				"merge_queue": map[string]interface{}{"gates": map[string]interface{}{"g": gate}},
+			})
+			if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+				t.Errorf("LoadConfig accepted gate %v", gate)
+			}
+		})
+	}
+}
+
 func TestRunGate_EmptyCmd(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
@@ -425,13 +541,108 @@ func TestRunGate_Timeout(t *testing.T) {
 	if !strings.Contains(result.Error, "timed out") {
 		t.Errorf("expected timeout error, got: %s", result.Error)
 	}
+	if !result.TimedOut {
+		t.Error("expected TimedOut to be true")
+	}
+}
+
+func TestRunGate_TimeoutKillsProcessGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process groups are not supported on Windows")
+	}
+	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
+	e := NewEngineer(r)
+	e.workDir = t.TempDir()
+
+	// The background sleep inherits the gate's stdout; if it survived the
+	// kill, Run would block until it exited.
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	result := e.runGate(context.Background(), "forks", &GateConfig{
+		Cmd:     fmt.Sprintf("sleep 30 & echo $! > %s; wait", pidFile),
+		Timeout: 200 * time.Millisecond,
+	})
+
+	if !result.TimedOut {
+		t.Fatalf("expected timeout, got: %+v", result)
+	}
+	if result.Elapsed > 5*time.Second {
+		t.Errorf("gate took %v, child kept its output open", result.Elapsed)
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processAlive(pid) {
+		proc, _ := os.FindProcess(pid)
+		_ = proc.Kill()
+		t.Error("background child survived the gate timeout")
+	}
+}
+
+// processAlive reports whether pid is running. A killed child orphaned to an
+// init that doesn't reap (as in some containers) lingers as a zombie, which
+// still accepts signal 0, so zombies count as dead where /proc shows them.
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil || proc.Signal(syscall.Signal(0)) != nil {
+		return false
+	}
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return true
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if state, ok := strings.CutPrefix(line, "State:"); ok {
+			return !strings.HasPrefix(strings.TrimSpace(state), "Z")
+		}
+	}
+	return true
+}
+
+func TestRunGates_TimeoutDistinctFromFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		gates        map[string]*GateConfig
+		wantTimedOut bool
+	}{
+		{"only timeout", map[string]*GateConfig{
+			"ok":   {Cmd: "true"},
+			"hang": {Cmd: "sleep 10", Timeout: 100 * time.Millisecond},
+		}, true},
+		{"timeout and failure", map[string]*GateConfig{
+			"fail": {Cmd: "exit 1"},
+			"hang": {Cmd: "sleep 10", Timeout: 100 * time.Millisecond},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
+			e := NewEngineer(r)
+			e.workDir = t.TempDir()
+			e.SetOutput(io.Discard)
+			e.config.Gates = tt.gates
+			e.config.GatesParallel = true
+
+			result := e.runGates(context.Background())
+			if result.Success || !result.TestsFailed {
+				t.Fatalf("expected gate failure, got %+v", result)
+			}
+			if result.GateTimedOut != tt.wantTimedOut {
+				t.Errorf("GateTimedOut = %v, want %v (%s)", result.GateTimedOut, tt.wantTimedOut, result.Error)
+			}
+		})
+	}
 }
 
 func TestRunGates_Sequential_AllPass(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
 	e.workDir = t.TempDir()
-	e.output = io.Discard
+	e.SetOutput(io.Discard)
 	e.config.Gates = map[string]*GateConfig{
 		"a": {Cmd: "true"},
 		"b": {Cmd: "true"},
@@ -452,7 +663,7 @@ func TestRunGates_Sequential_StopsOnFirstFailure(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
 	e.workDir = t.TempDir()
-	e.output = io.Discard
+	e.SetOutput(io.Discard)
 
 	// Create a marker file to track which gates ran
 	markerDir := t.TempDir()
@@ -482,7 +693,7 @@ func TestRunGates_Parallel_AllPass(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
 	e.workDir = t.TempDir()
-	e.output = io.Discard
+	e.SetOutput(io.Discard)
 	e.config.Gates = map[string]*GateConfig{
 		"a": {Cmd: "true"},
 		"b": {Cmd: "true"},
@@ -500,7 +711,7 @@ func TestRunGates_Parallel_AnyFailure(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
 	e.workDir = t.TempDir()
-	e.output = io.Discard
+	e.SetOutput(io.Discard)
 	e.config.Gates = map[string]*GateConfig{
 		"pass1": {Cmd: "true"},
 		"fail1": {Cmd: "exit 1"},
@@ -524,7 +735,7 @@ func TestRunGates_Empty(t *testing.T) {
 	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
 	e := NewEngineer(r)
 	e.workDir = t.TempDir()
-	e.output = io.Discard
+	e.SetOutput(io.Discard)
 	e.config.Gates = nil
 
 	result := e.runGates(context.Background())
@@ -651,11 +862,7 @@ func TestNotifyDeaconConvoyFeeding_SkipsWhenNoConvoyID(t *testing.T) {
 func TestNotifyDeaconConvoyFeeding_AttemptsWhenConvoyID(t *testing.T) {
 	// notifyDeaconConvoyFeeding should attempt to send mail when ConvoyID is set.
 	// The send will fail (no beads setup in tmpdir) but we verify the attempt via output.
-	tmpDir, err := os.MkdirTemp("", "engineer-notify-test-*")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.RemoveAll(tmpDir)
+	tmpDir := isolateTownEvents(t)
 
 	rigDir := filepath.Join(tmpDir, "testrig")
 	if err := os.MkdirAll(rigDir, 0755); err != nil {
@@ -683,6 +890,9 @@ func TestNotifyDeaconConvoyFeeding_AttemptsWhenConvoyID(t *testing.T) {
 	if !strings.Contains(output, "CONVOY_NEEDS_FEEDING") && !strings.Contains(output, "convoy feeding") {
 		t.Errorf("expected output mentioning convoy notification, got: %s", output)
 	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".events.jsonl")); err != nil {
+		t.Errorf("expected the feed event in the test town: %v", err)
+	}
 }
 
 func TestConvoyInfoDescriptionParsing(t *testing.T) {
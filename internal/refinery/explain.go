@@ -0,0 +1,198 @@
+package refinery
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FindBatch returns the batch log record of batchID.
+func (e *Engineer) FindBatch(batchID string) (*BatchRecord, error) {
+	records, err := e.History(HistoryQuery{BatchID: batchID})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("batch %s not found in the batch log", batchID)
+	}
+	return records[len(records)-1], nil
+}
+
+// ExplainBatch writes a narrative of what happened to batchID to w: what
+// was stacked, each gate run in order, the bisection path, why each
+// culprit was blamed, and where its gate logs are. It is built from the
+// batch log (see History) and the batch's gate logs (see GateLog).
+func (e *Engineer) ExplainBatch(w io.Writer, batchID string) error {
+	r, err := e.FindBatch(batchID)
+	if err != nil {
+		return err
+	}
+	logs := make(map[string][]string)
+	for _, id := range r.Members {
+		if gates, err := e.GateLogs(batchID, id); err == nil {
+			for _, gate := range gates {
+				logs[id] = append(logs[id], e.gateLogPath(batchID, id, gate))
+			}
+		}
+	}
+	writeExplanation(w, r, logs)
+	return nil
+}
+
+// writeExplanation renders r. logs holds the gate log paths of each MR.
+func writeExplanation(w io.Writer, r *BatchRecord, logs map[string][]string) {
+	p := func(format string, args ...any) { _, _ = fmt.Fprintf(w, format, args...) }
+
+	p("Batch %s targeting %s\n", r.BatchID, r.Target)
+	p("Ran %s for %v.\n", r.StartedAt.Local().Format(time.RFC3339), (time.Duration(r.DurationMs) * time.Millisecond).Truncate(time.Millisecond))
+	if r.Energy != nil {
+		p("Used %s.\n", r.Energy)
+	}
+	if r.GateSet != "" {
+		p("Gate set: %s.\n", r.GateSet)
+	}
+	p("\n")
+
+	p("Members, in dependency order: %s\n", listIDs(r.Members))
+	if len(r.Deferred) > 0 {
+		p("Deferred to a later batch without gating: %s\n", listIDs(r.Deferred))
+	}
+	if len(r.Conflicts) > 0 {
+		p("Could not be stacked on %s (conflicts): %s\n", r.Target, listIDs(r.Conflicts))
+	}
+	if len(r.Stacked) > 0 {
+		p("Stacked on %s in this order: %s\n", r.Target, listIDs(r.Stacked))
+	}
+
+	runs := gateRunStacks(r)
+	if len(runs) > 0 {
+		p("\nGate runs:\n")
+		seen := make(map[string]int)
+		for i, run := range r.GateRuns {
+			stack := runs[i]
+			key := strings.Join(stack, " ")
+			what := "bisection probe"
+			switch {
+			case i == 0:
+				what = "stack tip"
+			case seen[key] > 0:
+				what = "retry"
+			}
+			seen[key]++
+			p("  %d. %s [%s]: %s\n", i+1, what, strings.Join(stack, " "), describeGateRun(run))
+		}
+	}
+
+	p("\nOutcome:\n")
+	if len(r.Merged) > 0 {
+		landed := "landed"
+		if r.MergeCommit != "" {
+			landed += " at " + shortSHA(r.MergeCommit)
+		}
+		p("  %s %s on %s.\n", listIDs(r.Merged), landed, r.Target)
+	}
+	for _, id := range r.Culprits {
+		p("  %s was blamed: %s.\n", id, culpritRationale(r, runs, id))
+		for _, path := range logs[id] {
+			p("    log: %s\n", path)
+		}
+	}
+	if r.Error != "" {
+		p("  The batch stopped on an error: %s\n", r.Error)
+	}
+	if len(r.Merged) == 0 && len(r.Culprits) == 0 && r.Error == "" {
+		p("  Nothing landed.\n")
+	}
+}
+
+// gateRunStacks returns the MRs in the tree of each of r's gate runs. Runs
+// of single-MR batches record no stack.
+func gateRunStacks(r *BatchRecord) [][]string {
+	stacks := make([][]string, len(r.GateRuns))
+	for i, run := range r.GateRuns {
+		stacks[i] = run.Stack
+		if len(stacks[i]) == 0 && len(r.Members) == 1 {
+			stacks[i] = r.Members
+		}
+	}
+	return stacks
+}
+
+// describeGateRun summarizes run's gates: which passed, which failed and
+// which failures didn't block.
+func describeGateRun(run *GateRunRecord) string {
+	if len(run.Gates) == 0 {
+		if run.Success {
+			return "passed"
+		}
+		return "failed: " + run.Error
+	}
+	var failed, notes []string
+	for _, g := range run.Gates {
+		switch {
+		case g.SkippedBy != "":
+			notes = append(notes, g.Name+" skipped by token "+g.SkippedBy)
+		case g.Unaffected:
+			notes = append(notes, g.Name+" skipped, no changes in its paths")
+		case g.Success && g.Flaky:
+			notes = append(notes, g.Name+" passed on retry (flaky)")
+		case g.Success:
+		case g.Quarantined:
+			notes = append(notes, g.Name+" failed but is quarantined")
+		case g.Baseline:
+			notes = append(notes, g.Name+" failed but fails on the target too")
+		case g.TimedOut:
+			failed = append(failed, g.Name+" (timed out)")
+		default:
+			failed = append(failed, g.Name)
+		}
+	}
+	s := "passed"
+	if !run.Success {
+		s = "failed"
+		if len(failed) > 0 {
+			s += " (" + strings.Join(failed, ", ") + ")"
+		} else if run.Error != "" {
+			s += ": " + run.Error
+		}
+	}
+	if len(notes) > 0 {
+		s += "; " + strings.Join(notes, "; ")
+	}
+	return s
+}
+
+// culpritRationale explains why id was blamed from the gate runs: the last
+// failing tree ending at id, set against a passing tree without it.
+func culpritRationale(r *BatchRecord, runs [][]string, id string) string {
+	last := -1
+	for i, stack := range runs {
+		if !r.GateRuns[i].Success && len(stack) > 0 && stack[len(stack)-1] == id {
+			last = i
+		}
+	}
+	if last < 0 {
+		return "its gates failed"
+	}
+	stack := runs[last]
+	failed := describeGateRun(r.GateRuns[last])
+	if len(stack) == 1 {
+		return fmt.Sprintf("the gates %s with %s alone on %s", failed, id, r.Target)
+	}
+	base := stack[:len(stack)-1]
+	for i, s := range runs {
+		if r.GateRuns[i].Success && slices.Equal(s, base) {
+			return fmt.Sprintf("the gates passed on [%s] and %s once %s was added", strings.Join(base, " "), failed, id)
+		}
+	}
+	return fmt.Sprintf("the gates %s on [%s], which ends at %s", failed, strings.Join(stack, " "), id)
+}
+
+func listIDs(ids []string) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	return strings.Join(ids, ", ")
+}
@@ -0,0 +1,71 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExplainBatch(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: failMarkerGateCmd()}}
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+	cfg.BisectStrategy = "linear"
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main"), makeMR("mr-c", "feature-c", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if len(result.Culprits) != 1 {
+		t.Fatalf("culprits = %v, want [mr-b]", stackedIDs(result.Culprits))
+	}
+
+	var out bytes.Buffer
+	if err := e.ExplainBatch(&out, result.BatchID); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Batch " + result.BatchID + " targeting main",
+		"Stacked on main in this order: mr-a, mr-b, mr-c",
+		"1. stack tip [mr-a mr-b mr-c]: failed (test)",
+		"mr-b was blamed: the gates passed on [mr-a] and failed (test) once mr-b was added",
+		"log: " + e.gateLogPath(result.BatchID, "mr-b", "test"),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("explanation missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if err := e.ExplainBatch(&out, "batch-unknown"); err == nil {
+		t.Error("expected error for an unknown batch")
+	}
+}
+
+func TestWriteExplanation_SingleMR(t *testing.T) {
+	r := &BatchRecord{
+		BatchID: "batch-1",
+		Target:  "main",
+		Members: []string{"mr-a"},
+		GateRuns: []*GateRunRecord{{
+			Gates: []*GateOutcomeRecord{
+				{Name: "lint", Success: true, Flaky: true},
+				{Name: "test", TimedOut: true},
+			},
+		}},
+		Culprits: []string{"mr-a"},
+	}
+	var out bytes.Buffer
+	writeExplanation(&out, r, nil)
+	for _, want := range []string{
+		"1. stack tip [mr-a]: failed (test (timed out)); lint passed on retry (flaky)",
+		"mr-a was blamed: the gates failed (test (timed out)); lint passed on retry (flaky) with mr-a alone on main",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("explanation missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package refinery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Failure pattern defaults.
+const (
+	defaultFailurePatternBatches = 3
+	defaultFailurePatternWindow  = 7 * 24 * time.Hour
+)
+
+// maxFailureOccurrences caps the occurrences kept per failure pattern.
+const maxFailureOccurrences = 20
+
+// FailurePatternConfig makes the refinery open a single beads issue for a
+// gate failure that keeps recurring across batches, rather than leaving it
+// to be rediscovered on each culprit MR. Failures are clustered by
+// signature: the gate and its error message with what varies from run to
+// run (paths, hashes, numbers) normalized away. Once a signature has failed
+// in Batches distinct batches within Window, an issue tracking it is opened.
+// Closing the issue starts the count over.
+type FailurePatternConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Batches is the number of distinct batches a failure must occur in
+	// before an issue is opened. Default: 3.
+	Batches int `json:"batches,omitempty"`
+
+	// Window is how far back occurrences count, as a Go duration ("72h").
+	// Default: a week.
+	Window string `json:"window,omitempty"`
+
+	window time.Duration
+}
+
+// validateFailurePatterns checks a FailurePatternConfig from config.json
+// and parses its window.
+func validateFailurePatterns(cfg *FailurePatternConfig) error {
+	if cfg.Batches < 0 {
+		return fmt.Errorf("failure_patterns batches must be non-negative, got %d", cfg.Batches)
+	}
+	cfg.window = defaultFailurePatternWindow
+	if cfg.Window == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(cfg.Window)
+	if err != nil {
+		return fmt.Errorf("failure_patterns window: %w", err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("failure_patterns window must be positive, got %s", cfg.Window)
+	}
+	cfg.window = d
+	return nil
+}
+
+func (c *FailurePatternConfig) batches() int {
+	if c.Batches > 0 {
+		return c.Batches
+	}
+	return defaultFailurePatternBatches
+}
+
+func (c *FailurePatternConfig) windowDuration() time.Duration {
+	if c.window > 0 {
+		return c.window
+	}
+	return defaultFailurePatternWindow
+}
+
+// FailurePattern is a gate failure seen in one or more batches.
+type FailurePattern struct {
+	Signature string `json:"signature"`
+	Gate      string `json:"gate"`
+	// Message is the normalized error message the signature is taken from;
+	// Sample is the latest error as reported.
+	Message     string               `json:"message"`
+	Sample      string               `json:"sample,omitempty"`
+	Occurrences []*FailureOccurrence `json:"occurrences"`
+
+	// Issue tracks the failure once it has recurred enough.
+	Issue         string    `json:"issue,omitempty"`
+	IssueOpenedAt time.Time `json:"issue_opened_at,omitempty"`
+}
+
+// FailureOccurrence is one batch a failure pattern occurred in.
+type FailureOccurrence struct {
+	Batch string    `json:"batch"`
+	At    time.Time `json:"at"`
+	MRs   []string  `json:"mrs,omitempty"` // MRs in the tree when it first failed
+}
+
+// LastSeen returns when the pattern last occurred.
+func (p *FailurePattern) LastSeen() time.Time {
+	if len(p.Occurrences) == 0 {
+		return time.Time{}
+	}
+	return p.Occurrences[len(p.Occurrences)-1].At
+}
+
+// gateFailure is a failed gate collected while a batch is processed.
+type gateFailure struct {
+	signature string
+	gate      string
+	message   string // Normalized
+	sample    string
+	stack     []string
+}
+
+// Run-specific noise in gate error messages. Absolute directories are
+// dropped but file names kept, so "/tmp/x123/pkg/a_test.go:41" and
+// "/work/rig/pkg/a_test.go:57" both become "a_test.go:N".
+var failureNoise = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`(?:/[^\s/:'"()\[\]]+)+/`), ""},
+	{regexp.MustCompile(`\b[0-9a-f]{7,64}\b`), "<hash>"},
+	{regexp.MustCompile(`0x[0-9a-fA-F]+`), "<addr>"},
+	{regexp.MustCompile(`\d+(?:\.\d+)?`), "N"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// normalizeFailure strips what varies between runs of the same failure
+// from a gate error message.
+func normalizeFailure(msg string) string {
+	for _, n := range failureNoise {
+		msg = n.re.ReplaceAllString(msg, n.repl)
+	}
+	return strings.TrimSpace(msg)
+}
+
+// failureSignature identifies a gate failure across runs.
+func failureSignature(gate, normalized string) string {
+	sum := sha256.Sum256([]byte(gate + "\x00" + normalized))
+	return gate + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+func (e *Engineer) failurePatternsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "failure-patterns.json")
+}
+
+func (e *Engineer) loadFailurePatterns() (map[string]*FailurePattern, error) {
+	patterns := make(map[string]*FailurePattern)
+	data, err := os.ReadFile(e.failurePatternsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return patterns, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return nil, fmt.Errorf("parsing failure patterns: %w", err)
+	}
+	return patterns, nil
+}
+
+// FailurePatterns returns the recorded gate failure patterns, most recently
+// seen first.
+func (e *Engineer) FailurePatterns() ([]*FailurePattern, error) {
+	patterns, err := e.loadFailurePatterns()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*FailurePattern, 0, len(patterns))
+	for _, p := range patterns {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if a, b := list[i].LastSeen(), list[j].LastSeen(); !a.Equal(b) {
+			return a.After(b)
+		}
+		return list[i].Signature < list[j].Signature
+	})
+	return list, nil
+}
+
+// trackFailurePatterns records the batch's gate failures by signature and
+// opens an issue for any that has now failed in enough batches. Each
+// signature counts once per batch, however often bisection reran it.
+// Failures are logged and otherwise ignored. Callers hold the state lock.
+func (e *Engineer) trackFailurePatterns(rec *batchRecorder, result *BatchResult) {
+	cfg := e.config.FailurePatterns
+	if cfg == nil || !cfg.Enabled || e.rig == nil || result.BatchID == "" {
+		return
+	}
+	rec.mu.Lock()
+	failures := rec.failures
+	rec.mu.Unlock()
+	if len(failures) == 0 {
+		return
+	}
+
+	patterns, err := e.loadFailurePatterns()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Patterns] Warning: %v\n", err)
+		return
+	}
+	now := time.Now().UTC()
+	cutoff := now.Add(-cfg.windowDuration())
+	sigs := make([]string, 0, len(failures))
+	for sig := range failures {
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+	for _, sig := range sigs {
+		f := failures[sig]
+		p := patterns[sig]
+		if p == nil {
+			p = &FailurePattern{Signature: sig, Gate: f.gate, Message: f.message}
+			patterns[sig] = p
+		}
+		if p.Issue != "" && e.failureIssueClosed(p.Issue) {
+			// Fixed, as far as anyone knew: count the recurrence afresh.
+			p.Issue, p.IssueOpenedAt, p.Occurrences = "", time.Time{}, nil
+		}
+		p.Sample = f.sample
+		var kept []*FailureOccurrence
+		for _, o := range p.Occurrences {
+			if p.Issue != "" || o.At.After(cutoff) {
+				kept = append(kept, o)
+			}
+		}
+		p.Occurrences = append(kept, &FailureOccurrence{Batch: result.BatchID, At: now, MRs: f.stack})
+		if len(p.Occurrences) > maxFailureOccurrences {
+			p.Occurrences = p.Occurrences[len(p.Occurrences)-maxFailureOccurrences:]
+		}
+
+		switch {
+		case p.Issue != "":
+			_, _ = fmt.Fprintf(e.output, "[Patterns] Gate %q failure is a known pattern, tracked in %s\n", p.Gate, p.Issue)
+		case len(p.Occurrences) >= cfg.batches():
+			id, err := e.openFailureIssue(p)
+			if err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Patterns] Warning: opening issue for gate %q failure: %v\n", p.Gate, err)
+				continue
+			}
+			p.Issue, p.IssueOpenedAt = id, now
+			_, _ = fmt.Fprintf(e.output, "[Patterns] Gate %q failed the same way in %d batches; opened %s\n", p.Gate, len(p.Occurrences), id)
+		}
+	}
+	for sig, p := range patterns {
+		if p.Issue == "" && !p.LastSeen().After(cutoff) {
+			delete(patterns, sig)
+		}
+	}
+	if err := util.EnsureDirAndWriteJSON(e.failurePatternsPath(), patterns); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Patterns] Warning: %v\n", err)
+	}
+}
+
+// failureIssueClosed reports whether a pattern's issue has been closed.
+// An issue that can't be looked up is assumed open, so a beads outage
+// doesn't open duplicates.
+func (e *Engineer) failureIssueClosed(id string) bool {
+	issue, err := e.showIssue(id)
+	return err == nil && issue != nil && beads.IssueStatus(issue.Status).IsTerminal()
+}
+
+// createFailureIssue opens the beads issue tracking a failure pattern. It
+// is the default openFailureIssue.
+func (e *Engineer) createFailureIssue(p *FailurePattern) (string, error) {
+	var batches strings.Builder
+	for _, o := range p.Occurrences {
+		fmt.Fprintf(&batches, "- %s (%s): %s\n", o.Batch, o.At.Format(time.RFC3339), strings.Join(o.MRs, ", "))
+	}
+	description := fmt.Sprintf(`Gate %q has failed the same way in %d batches of the %s merge queue,
+with different MRs in the tree, so the cause is probably not any one MR:
+a broken target, a flaky test, or the environment the gates run in.
+
+Failure (normalized):
+    %s
+
+Latest error:
+    %s
+
+Batches:
+%s
+Gate logs: %s
+Signature: %s`,
+		p.Gate, len(p.Occurrences), e.rig.Name,
+		p.Message,
+		p.Sample,
+		batches.String(),
+		e.artifactsDir(),
+		p.Signature,
+	)
+	// A human may have filed the failure already; flag the issue if so.
+	issue, _, err := e.beads.CreateChecked(beads.CreateOptions{
+		Title:       fmt.Sprintf("Recurring %s gate failure: %s", p.Gate, truncateRunes(p.Message, 80)),
+		Labels:      []string{"gt:bug", "gt:gate-failure"},
+		Priority:    2,
+		Description: description,
+		Actor:       e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		return "", err
+	}
+	return issue.ID, nil
+}
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
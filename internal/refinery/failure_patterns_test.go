@@ -0,0 +1,112 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestNormalizeFailure(t *testing.T) {
+	a := normalizeFailure("exit status 1: --- FAIL: TestParse (0.42s)\n    /tmp/gt-123/pkg/parse_test.go:41: got 7, commit 3f9c2ab1e")
+	b := normalizeFailure("exit status 1: --- FAIL: TestParse (1.07s)\n    /home/rig/refinery/rig/pkg/parse_test.go:58: got 9, commit 88e01dd4c")
+	if a != b {
+		t.Errorf("same failure normalized differently:\n%q\n%q", a, b)
+	}
+	if c := normalizeFailure("exit status 1: --- FAIL: TestRender (0.42s)"); c == a {
+		t.Errorf("different failures normalized the same: %q", c)
+	}
+	if failureSignature("test", a) == failureSignature("lint", a) {
+		t.Error("signature ignores the gate")
+	}
+}
+
+// failPatternBatch records a batch in which gate failed with msg, and
+// tracks it.
+func failPatternBatch(e *Engineer, batchID, gate, msg string) {
+	rec := &batchRecorder{}
+	ctx := withBatchRecorder(context.Background(), rec)
+	ctx = withGateStack(ctx, []*MRInfo{{ID: batchID + "-mr"}})
+	for i := 0; i < 2; i++ { // Bisection reruns count once
+		recordGateRun(ctx, ProcessResult{Error: msg}, []GateResult{{Name: gate, Error: msg}, {Name: "build", Success: true}})
+	}
+	e.trackFailurePatterns(rec, &BatchResult{BatchID: batchID})
+}
+
+func TestTrackFailurePatterns_OpensOneIssue(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.SetOutput(&bytes.Buffer{})
+	e.config.FailurePatterns = &FailurePatternConfig{Enabled: true, Batches: 3}
+	var opened []*FailurePattern
+	e.openFailureIssue = func(p *FailurePattern) (string, error) {
+		opened = append(opened, p)
+		return "gt-issue-1", nil
+	}
+	status := "open"
+	e.showIssue = func(id string) (*beads.Issue, error) { return &beads.Issue{ID: id, Status: status}, nil }
+
+	failPatternBatch(e, "b1", "test", "FAIL: TestDB (0.5s): dial tcp 127.0.0.1:5432: connection refused")
+	failPatternBatch(e, "b2", "test", "FAIL: TestDB (0.9s): dial tcp 127.0.0.1:5433: connection refused")
+	failPatternBatch(e, "b3", "lint", "unused variable x")
+	if len(opened) != 0 {
+		t.Fatalf("opened an issue after two batches")
+	}
+	failPatternBatch(e, "b4", "test", "FAIL: TestDB (1.2s): dial tcp 127.0.0.1:5432: connection refused")
+	if len(opened) != 1 || opened[0].Gate != "test" || len(opened[0].Occurrences) != 3 {
+		t.Fatalf("opened = %+v, want one issue for the test gate after 3 batches", opened)
+	}
+	failPatternBatch(e, "b5", "test", "FAIL: TestDB (0.1s): dial tcp 127.0.0.1:5432: connection refused")
+	if len(opened) != 1 {
+		t.Fatalf("opened %d issues for one pattern", len(opened))
+	}
+
+	patterns, err := e.FailurePatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 2 || patterns[0].Issue != "gt-issue-1" || len(patterns[0].Occurrences) != 4 {
+		t.Fatalf("patterns = %+v", patterns)
+	}
+
+	// Once the issue is closed, a recurrence starts the count over.
+	status = "closed"
+	failPatternBatch(e, "b6", "test", "FAIL: TestDB (0.3s): dial tcp 127.0.0.1:5432: connection refused")
+	patterns, _ = e.FailurePatterns()
+	if p := patterns[0]; p.Issue != "" || len(p.Occurrences) != 1 {
+		t.Errorf("after close: %+v, want a fresh count", p)
+	}
+}
+
+func TestRecordGateRun_Signature(t *testing.T) {
+	rec := &batchRecorder{}
+	ctx := withBatchRecorder(context.Background(), rec)
+	recordGateRun(ctx, ProcessResult{}, []GateResult{{Name: "test", Error: "exit status 2"}, {Name: "build", Success: true}})
+	gates := rec.gateRuns[0].Gates
+	if gates[0].Signature != failureSignature("test", "exit status N") || gates[1].Signature != "" {
+		t.Errorf("signatures = %q, %q", gates[0].Signature, gates[1].Signature)
+	}
+}
+
+func TestLoadConfig_FailurePatterns(t *testing.T) {
+	for body, ok := range map[string]bool{
+		`{"enabled": true}`:                   true,
+		`{"enabled": true, "window": "72h"}`:  true,
+		`{"enabled": true, "window": "soon"}`: false,
+		`{"enabled": true, "window": "-1h"}`:  false,
+		`{"enabled": true, "batches": -1}`:    false,
+	} {
+		tmpDir := t.TempDir()
+		data := []byte(`{"merge_queue": {"failure_patterns": ` + body + `}}`)
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+		if err := e.LoadConfig(); (err == nil) != ok {
+			t.Errorf("LoadConfig(%s) = %v, want ok=%v", body, err, ok)
+		}
+	}
+}
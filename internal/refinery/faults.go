@@ -0,0 +1,58 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// FaultInjector injects failures into a refinery so resilience tests can
+// exercise its recovery paths (see internal/testutil/chaos). Production
+// refineries never have one.
+type FaultInjector interface {
+	// GateFault returns a non-nil error to fail the named gate instead of
+	// running it.
+	GateFault(name string) error
+
+	// MergeSlotFault returns a non-nil error to fail a merge slot
+	// operation ("acquire" or "release"), as if the slot had been dropped.
+	MergeSlotFault(op string) error
+}
+
+// errInjected marks failures that came from a FaultInjector.
+var errInjected = errors.New("injected fault")
+
+// SetFaultInjector installs f, wrapping the gate runner and merge slot so
+// f can fail them. Install it once, after any other overrides.
+func (e *Engineer) SetFaultInjector(f FaultInjector) {
+	execGate := e.execGate
+	e.execGate = func(ctx context.Context, dir, name string, gate *GateConfig) GateResult {
+		if err := f.GateFault(name); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Chaos] Failing gate %s: %v\n", name, err)
+			return GateResult{Name: name, Error: fmt.Sprintf("%v: %v", errInjected, err)}
+		}
+		return execGate(ctx, dir, name, gate)
+	}
+
+	acquire := e.mergeSlotAcquire
+	e.mergeSlotAcquire = func(holder string, addWaiter bool) (*beads.MergeSlotStatus, error) {
+		if err := f.MergeSlotFault("acquire"); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Chaos] Dropping merge slot on acquire: %v\n", err)
+			return nil, fmt.Errorf("%w: %v", errInjected, err)
+		}
+		return acquire(holder, addWaiter)
+	}
+	release := e.mergeSlotRelease
+	e.mergeSlotRelease = func(holder string) error {
+		if err := f.MergeSlotFault("release"); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Chaos] Dropping merge slot on release: %v\n", err)
+			// The slot is still released underneath, as a backend that
+			// lost the holder would; only the caller sees a failure.
+			_ = release(holder)
+			return fmt.Errorf("%w: %v", errInjected, err)
+		}
+		return release(holder)
+	}
+}
@@ -0,0 +1,44 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+type stubFaults struct {
+	gate, slot error
+}
+
+func (s *stubFaults) GateFault(string) error      { return s.gate }
+func (s *stubFaults) MergeSlotFault(string) error { return s.slot }
+
+func TestSetFaultInjector(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "testrig", Path: t.TempDir()})
+	e.SetOutput(io.Discard)
+	e.UseLocalMergeSlot()
+	e.mergeSlotMaxRetries = 0
+
+	ran := false
+	e.execGate = func(context.Context, string, string, *GateConfig) GateResult {
+		ran = true
+		return GateResult{Success: true}
+	}
+	faults := &stubFaults{gate: errors.New("boom"), slot: errors.New("gone")}
+	e.SetFaultInjector(faults)
+
+	if r := e.runGate(context.Background(), "build", &GateConfig{Cmd: "true"}); r.Success || ran {
+		t.Errorf("faulted gate: result %+v, ran %v; want failure without running", r, ran)
+	}
+	if _, err := e.acquireMainPushSlot(context.Background()); !errors.Is(err, errInjected) {
+		t.Errorf("acquire with a dropped slot = %v, want an injected fault", err)
+	}
+
+	*faults = stubFaults{}
+	if r := e.runGate(context.Background(), "build", &GateConfig{Cmd: "true"}); !r.Success || !ran {
+		t.Errorf("unfaulted gate: result %+v, ran %v", r, ran)
+	}
+}
@@ -0,0 +1,426 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	gitpkg "github.com/steveyegge/gastown/internal/git"
+)
+
+// Batch fixtures are recorded batch runs under testdata/batches. Each holds
+// the git state a batch started from, the outcome of every gate run keyed by
+// the MRs stacked when it ran, and the batch result. TestBatchFixtures
+// rebuilds the repository from the fixture and replays the gate outcomes
+// instead of running gate commands, so a queue bug seen once can be pinned
+// as a hermetic regression test.
+//
+// To add one, write testdata/batches/<name>.json with the target, the gates
+// and batch config, and either:
+//
+//   - "base" and "mrs" with file contents, to describe the repo by hand, or
+//   - "source": {"repo": "/path/to/refinery/rig"} and "mrs" with only id and
+//     branch, to capture the files each branch changes from a real clone;
+//     branches must exist as local branches there.
+//
+// then record it with real gates:
+//
+//	go test ./internal/refinery -run 'TestBatchFixtures/<name>' -record
+//
+// Recording pushes to a bare copy, never to the source repository, and
+// drops "source" so the committed fixture is self-contained. Re-record after
+// changing the batch algorithm if the sequence of gate runs changes.
+var recordFixtures = flag.Bool("record", false, "run batch fixtures with real gates and rewrite testdata/batches")
+
+type batchFixture struct {
+	Description string                    `json:"description,omitempty"`
+	Source      *fixtureSource            `json:"source,omitempty"`
+	Target      string                    `json:"target"`
+	Base        map[string]string         `json:"base"`
+	MRs         []*fixtureMR              `json:"mrs"`
+	Gates       map[string]*gateConfigRaw `json:"gates"`
+	Batch       json.RawMessage           `json:"batch,omitempty"` // Overrides DefaultBatchConfig
+	GateRuns    []*fixtureGateRun         `json:"gate_runs"`
+	Expect      *fixtureResult            `json:"expect"`
+}
+
+// fixtureSource is a real repository to capture a fixture's git state from.
+type fixtureSource struct {
+	Repo string `json:"repo"`
+}
+
+type fixtureMR struct {
+	ID        string             `json:"id"`
+	Branch    string             `json:"branch"`
+	BlockedBy string             `json:"blocked_by,omitempty"`
+	Message   string             `json:"message"`
+	Files     map[string]*string `json:"files"` // null content deletes the file
+}
+
+type fixtureGateRun struct {
+	Gate     string   `json:"gate"`
+	Stack    []string `json:"stack"` // MR IDs stacked on the target, oldest first
+	Passed   bool     `json:"passed"`
+	TimedOut bool     `json:"timed_out,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// fixtureResult is a BatchResult by MR ID. Error messages can carry temp
+// paths and SHAs, so replay only compares whether there was one.
+type fixtureResult struct {
+	Merged    []string `json:"merged"`
+	Culprits  []string `json:"culprits"`
+	Conflicts []string `json:"conflicts"`
+	Deferred  []string `json:"deferred"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func TestBatchFixtures(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "batches", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			f := loadBatchFixture(t, path)
+			if *recordFixtures {
+				recordBatchFixture(t, path, f)
+				return
+			}
+			if f.Source != nil || f.Expect == nil {
+				t.Fatalf("%s has not been recorded; run with -record", path)
+			}
+			replayBatchFixture(t, f)
+		})
+	}
+}
+
+func loadBatchFixture(t *testing.T, path string) *batchFixture {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f batchFixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	if f.Target == "" || len(f.MRs) == 0 {
+		t.Fatalf("%s: fixture needs a target and at least one MR", path)
+	}
+	return &f
+}
+
+func replayBatchFixture(t *testing.T, f *batchFixture) {
+	workDir, g := buildFixtureRepo(t, f)
+	e, mrs, cfg := fixtureEngineer(t, workDir, g, f)
+	replay := &gateReplayer{t: t, target: f.Target, bySubject: fixtureSubjects(t, f), runs: make(map[string][]*fixtureGateRun)}
+	for _, gr := range f.GateRuns {
+		key := gateRunKey(gr.Gate, gr.Stack)
+		replay.runs[key] = append(replay.runs[key], gr)
+	}
+	e.execGate = replay.exec
+
+	got := *fixtureResultOf(e.ProcessBatch(context.Background(), mrs, f.Target, cfg))
+	want := *f.Expect
+	if (got.Error != "") != (want.Error != "") {
+		t.Errorf("error = %q, want %q", got.Error, want.Error)
+	}
+	got.Error, want.Error = "", ""
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("result = %+v\nwant     %+v\noutput:\n%s", got, want, testOutput(e).String())
+	}
+}
+
+func recordBatchFixture(t *testing.T, path string, f *batchFixture) {
+	var workDir string
+	var g *gitpkg.Git
+	if f.Source != nil {
+		workDir, g = captureFixtureRepo(t, f)
+	} else {
+		workDir, g = buildFixtureRepo(t, f)
+	}
+	e, mrs, cfg := fixtureEngineer(t, workDir, g, f)
+	rec := &gateRecorder{t: t, target: f.Target, bySubject: fixtureSubjects(t, f), runGate: e.execGateCmd}
+	e.execGate = rec.exec
+
+	f.Expect = fixtureResultOf(e.ProcessBatch(context.Background(), mrs, f.Target, cfg))
+	f.GateRuns = rec.runs
+	f.Source = nil
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("recorded %d gate runs into %s", len(f.GateRuns), path)
+}
+
+// buildFixtureRepo creates a bare origin and a working clone holding the
+// fixture's base files on the target branch and one commit per MR branch.
+func buildFixtureRepo(t *testing.T, f *batchFixture) (string, *gitpkg.Git) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	bareDir := filepath.Join(tmpDir, "origin.git")
+	workDir := filepath.Join(tmpDir, "work")
+
+	run(t, tmpDir, "git", "init", "--bare", "--initial-branch="+f.Target, bareDir)
+	run(t, tmpDir, "git", "clone", bareDir, workDir)
+	run(t, workDir, "git", "config", "user.email", "test@test.com")
+	run(t, workDir, "git", "config", "user.name", "Test")
+	run(t, workDir, "git", "checkout", "-b", f.Target)
+
+	writeFixtureFiles(t, workDir, f.Base)
+	run(t, workDir, "git", "add", "-A")
+	run(t, workDir, "git", "commit", "--allow-empty", "-m", "fixture base")
+	run(t, workDir, "git", "push", "-u", "origin", f.Target)
+
+	for _, mr := range f.MRs {
+		run(t, workDir, "git", "checkout", "-b", mr.Branch, f.Target)
+		files := make(map[string]string)
+		for path, content := range mr.Files {
+			if content == nil {
+				if err := os.Remove(filepath.Join(workDir, path)); err != nil && !os.IsNotExist(err) {
+					t.Fatal(err)
+				}
+				continue
+			}
+			files[path] = *content
+		}
+		writeFixtureFiles(t, workDir, files)
+		run(t, workDir, "git", "add", "-A")
+		run(t, workDir, "git", "commit", "--allow-empty", "-m", mr.Message)
+		run(t, workDir, "git", "checkout", f.Target)
+	}
+	return workDir, gitpkg.NewGit(workDir)
+}
+
+func writeFixtureFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// captureFixtureRepo clones f.Source into a bare copy and a working clone,
+// and fills in f's base files and MR files and messages from it. Only paths
+// an MR changes relative to the target are captured, which is enough to
+// reproduce stacking; gates are replayed rather than run on replay.
+func captureFixtureRepo(t *testing.T, f *batchFixture) (string, *gitpkg.Git) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	bareDir := filepath.Join(tmpDir, "origin.git")
+	workDir := filepath.Join(tmpDir, "work")
+
+	run(t, tmpDir, "git", "clone", "--bare", f.Source.Repo, bareDir)
+	run(t, tmpDir, "git", "clone", bareDir, workDir)
+	run(t, workDir, "git", "config", "user.email", "test@test.com")
+	run(t, workDir, "git", "config", "user.name", "Test")
+	run(t, workDir, "git", "checkout", "-B", f.Target, "origin/"+f.Target)
+
+	f.Base = make(map[string]string)
+	for _, mr := range f.MRs {
+		ref := "origin/" + mr.Branch
+		run(t, workDir, "git", "branch", "-f", mr.Branch, ref)
+		mr.Message = run(t, workDir, "git", "log", "-1", "--format=%B", ref)
+		mr.Files = make(map[string]*string)
+		diff := run(t, workDir, "git", "diff", "--name-only", "origin/"+f.Target+"..."+ref)
+		for _, path := range strings.Fields(diff) {
+			if content, ok := gitShowFile(t, workDir, ref, path); ok {
+				mr.Files[path] = &content
+			} else {
+				mr.Files[path] = nil
+			}
+			if _, seen := f.Base[path]; seen {
+				continue
+			}
+			if content, ok := gitShowFile(t, workDir, "origin/"+f.Target, path); ok {
+				f.Base[path] = content
+			}
+		}
+	}
+	return workDir, gitpkg.NewGit(workDir)
+}
+
+// gitShowFile returns path's content at rev, or false if it does not exist there.
+func gitShowFile(t *testing.T, dir, rev, path string) (string, bool) {
+	t.Helper()
+	cmd := exec.Command("git", "show", rev+":"+path)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return string(out), true
+}
+
+func fixtureEngineer(t *testing.T, workDir string, g *gitpkg.Git, f *batchFixture) (*Engineer, []*MRInfo, *BatchConfig) {
+	t.Helper()
+	e := newTestEngineer(t, workDir, g)
+	gates, err := parseGates(f.Gates)
+	if err != nil {
+		t.Fatalf("fixture gates: %v", err)
+	}
+	e.config.Gates = gates
+
+	cfg := DefaultBatchConfig()
+	if len(f.Batch) > 0 {
+		if err := json.Unmarshal(f.Batch, cfg); err != nil {
+			t.Fatalf("fixture batch config: %v", err)
+		}
+	}
+
+	var mrs []*MRInfo
+	for _, m := range f.MRs {
+		mr := makeMR(m.ID, m.Branch, f.Target)
+		mr.BlockedBy = m.BlockedBy
+		mrs = append(mrs, mr)
+	}
+	e.listReadyMRs = func() ([]*MRInfo, error) { return mrs, nil }
+	return e, mrs, cfg
+}
+
+// fixtureSubjects maps each MR's commit subject to its ID. Squash commits
+// keep the branch's message, so the subjects on top of the target identify
+// which MRs a gate ran against.
+func fixtureSubjects(t *testing.T, f *batchFixture) map[string]string {
+	t.Helper()
+	bySubject := make(map[string]string, len(f.MRs))
+	for _, mr := range f.MRs {
+		subject := commitSubject(mr.Message)
+		if subject == "" {
+			t.Fatalf("MR %s has no commit message", mr.ID)
+		}
+		if other, dup := bySubject[subject]; dup {
+			t.Fatalf("MRs %s and %s share the subject %q; fixtures need distinct subjects", other, mr.ID, subject)
+		}
+		bySubject[subject] = mr.ID
+	}
+	return bySubject
+}
+
+// commitSubject returns msg's subject as git log's %s prints it: the first
+// paragraph, lines joined by spaces.
+func commitSubject(msg string) string {
+	para, _, _ := strings.Cut(strings.TrimSpace(msg), "\n\n")
+	return strings.Join(strings.Fields(para), " ")
+}
+
+// fixtureStack returns the IDs of the MRs stacked on origin/target in dir.
+func fixtureStack(dir, target string, bySubject map[string]string) ([]string, error) {
+	cmd := exec.Command("git", "log", "--reverse", "--format=%s", "origin/"+target+"..HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing stack in %s: %w", dir, err)
+	}
+	stack := []string{}
+	for _, subject := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if subject == "" {
+			continue
+		}
+		id, ok := bySubject[commitSubject(subject)]
+		if !ok {
+			return nil, fmt.Errorf("commit %q matches no fixture MR", subject)
+		}
+		stack = append(stack, id)
+	}
+	return stack, nil
+}
+
+func gateRunKey(gate string, stack []string) string {
+	return gate + "@" + strings.Join(stack, ",")
+}
+
+// gateRecorder runs gates for real and records their outcomes.
+type gateRecorder struct {
+	t         *testing.T
+	target    string
+	bySubject map[string]string
+	runGate   func(ctx context.Context, dir, name string, gate *GateConfig) GateResult
+
+	mu   sync.Mutex
+	runs []*fixtureGateRun
+}
+
+func (r *gateRecorder) exec(ctx context.Context, dir, name string, gate *GateConfig) GateResult {
+	stack, err := fixtureStack(dir, r.target, r.bySubject)
+	if err != nil {
+		r.t.Errorf("gate %s: %v", name, err)
+	}
+	result := r.runGate(ctx, dir, name, gate)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, &fixtureGateRun{
+		Gate:     name,
+		Stack:    stack,
+		Passed:   result.Success,
+		TimedOut: result.TimedOut,
+		Error:    result.Error,
+	})
+	return result
+}
+
+// gateReplayer serves recorded gate outcomes. Several runs recorded for the
+// same gate and stack (a flaky gate, a retry) are served in order, and the
+// last one repeats.
+type gateReplayer struct {
+	t         *testing.T
+	target    string
+	bySubject map[string]string
+
+	mu   sync.Mutex
+	runs map[string][]*fixtureGateRun
+}
+
+func (r *gateReplayer) exec(_ context.Context, dir, name string, _ *GateConfig) GateResult {
+	stack, err := fixtureStack(dir, r.target, r.bySubject)
+	if err != nil {
+		r.t.Errorf("gate %s: %v", name, err)
+		return GateResult{Name: name, Error: err.Error()}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := gateRunKey(name, stack)
+	runs := r.runs[key]
+	if len(runs) == 0 {
+		r.t.Errorf("no recorded run of gate %s on %v; re-record with -record", name, stack)
+		return GateResult{Name: name, Error: "unrecorded gate run"}
+	}
+	if len(runs) > 1 {
+		r.runs[key] = runs[1:]
+	}
+	gr := runs[0]
+	return GateResult{Name: name, Success: gr.Passed, TimedOut: gr.TimedOut, Error: gr.Error}
+}
+
+func fixtureResultOf(result *BatchResult) *fixtureResult {
+	r := &fixtureResult{
+		Merged:    mrIDs(result.Merged),
+		Culprits:  mrIDs(result.Culprits),
+		Conflicts: mrIDs(result.Conflicts),
+		Deferred:  mrIDs(result.Deferred),
+	}
+	if result.Error != nil {
+		r.Error = result.Error.Error()
+	}
+	return r
+}
@@ -0,0 +1,94 @@
+package refinery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/wallclock"
+)
+
+// FreezeWindowConfig is a recurring merge freeze: while it is in effect,
+// batches targeting its branches are deferred instead of landing.
+//
+//	{"start": "17:00", "duration": "64h", "days": ["fri"],
+//	 "timezone": "America/New_York", "reason": "weekend freeze"}
+type FreezeWindowConfig struct {
+	// Start is when the freeze begins, as HH:MM in Timezone.
+	Start string `json:"start"`
+
+	// Duration is how long the freeze lasts, in elapsed time (e.g., "64h").
+	Duration string `json:"duration"`
+
+	// Days are the weekdays the freeze begins on ("mon", "friday", ...).
+	// Default: every day.
+	Days []string `json:"days,omitempty"`
+
+	// Timezone is the IANA time zone Start is in. Default: local.
+	Timezone string `json:"timezone,omitempty"`
+
+	// Targets are the branches frozen. Default: all targets.
+	Targets []string `json:"targets,omitempty"`
+
+	// Reason is shown when batches are deferred.
+	Reason string `json:"reason,omitempty"`
+
+	window *wallclock.Window
+}
+
+// parseFreezeWindows validates freeze windows and parses their schedules.
+func parseFreezeWindows(windows []*FreezeWindowConfig) error {
+	for i, fw := range windows {
+		if fw == nil {
+			return fmt.Errorf("freeze window %d: empty", i)
+		}
+		w, err := wallclock.ParseWindow(fw.Start, fw.Duration, fw.Days, fw.Timezone)
+		if err != nil {
+			return fmt.Errorf("freeze window %d: %w", i, err)
+		}
+		fw.window = w
+	}
+	return nil
+}
+
+func (fw *FreezeWindowConfig) appliesTo(target string) bool {
+	if len(fw.Targets) == 0 {
+		return true
+	}
+	for _, t := range fw.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Until returns when the occurrence of the freeze containing now ends, or
+// the zero time if the freeze is not in effect at now.
+func (fw *FreezeWindowConfig) Until(now time.Time) time.Time {
+	if fw.window == nil {
+		return time.Time{}
+	}
+	start, ok := fw.window.Current(now)
+	if !ok {
+		return time.Time{}
+	}
+	return start.Add(fw.window.Duration)
+}
+
+func (fw *FreezeWindowConfig) String() string {
+	if fw.window == nil {
+		return fw.Start
+	}
+	return fw.window.String()
+}
+
+// ActiveFreeze returns the freeze window in effect for target at now, or
+// nil if merges to target are open.
+func (e *Engineer) ActiveFreeze(target string, now time.Time) *FreezeWindowConfig {
+	for _, fw := range e.config.FreezeWindows {
+		if fw.appliesTo(target) && !fw.Until(now).IsZero() {
+			return fw
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestEngineer_LoadConfig_FreezeWindows(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"freeze_windows": [
+		{"start": "17:00", "duration": "64h", "days": ["fri"], "timezone": "UTC", "targets": ["main"], "reason": "weekend"}
+	]}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	saturday := time.Date(2026, 5, 16, 12, 0, 0, 0, time.UTC)
+	fw := e.ActiveFreeze("main", saturday)
+	if fw == nil || fw.Reason != "weekend" {
+		t.Fatalf("ActiveFreeze(main, Saturday) = %+v, want the weekend freeze", fw)
+	}
+	if want := time.Date(2026, 5, 18, 9, 0, 0, 0, time.UTC); !fw.Until(saturday).Equal(want) {
+		t.Errorf("Until = %v, want %v", fw.Until(saturday), want)
+	}
+	if e.ActiveFreeze("release", saturday) != nil {
+		t.Error("freeze should only apply to its targets")
+	}
+	if e.ActiveFreeze("main", saturday.AddDate(0, 0, 3)) != nil {
+		t.Error("freeze should not be in effect on Tuesday")
+	}
+
+	for _, bad := range []string{
+		`[{"start": "5pm", "duration": "1h"}]`,
+		`[{"start": "17:00", "duration": "-1h"}]`,
+		`[{"start": "17:00", "duration": "1h", "timezone": "Nowhere/Special"}]`,
+	} {
+		data := []byte(`{"merge_queue": {"freeze_windows": ` + bad + `}}`)
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+			t.Errorf("LoadConfig accepted freeze_windows %s", bad)
+		}
+	}
+}
+
+func TestProcessBatch_DefersDuringFreeze(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	// A freeze that is always in effect.
+	e.config.FreezeWindows = []*FreezeWindowConfig{{Start: "00:00", Duration: "48h", Reason: "release week"}}
+	if err := parseFreezeWindows(e.config.FreezeWindows); err != nil {
+		t.Fatal(err)
+	}
+
+	before := run(t, workDir, "git", "rev-parse", "origin/main")
+	mr := makeMR("mr-a", "polecat/a", "main")
+	result := e.ProcessBatch(context.Background(), []*MRInfo{mr}, "main", nil)
+	if len(result.Merged) != 0 || len(result.Deferred) != 1 || result.Deferred[0] != mr {
+		t.Errorf("result = %+v, want the MR deferred", result)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "release week") {
+		t.Errorf("expected freeze reason in output:\n%s", out)
+	}
+	if after := run(t, workDir, "git", "rev-parse", "origin/main"); after != before {
+		t.Errorf("origin/main moved during a freeze: %s -> %s", before, after)
+	}
+}
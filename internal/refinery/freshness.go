@@ -0,0 +1,176 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// HeldForStale marks an MR held because its branch has fallen too far
+// behind its target (see MRInfo.HeldFor). There is no task: its author is
+// asked to rebase in a comment, and the MR is admitted once its branch is
+// fresh again.
+const HeldForStale = "stale"
+
+// FreshnessConfig bounces MRs whose branches have fallen too far behind
+// their target back to their authors, instead of spending a batch slot on
+// a merge that will almost certainly conflict.
+type FreshnessConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// MaxBehind is how many target commits an MR's branch may lack. Zero
+	// means no limit.
+	MaxBehind int `json:"max_behind,omitempty"`
+
+	// MaxDays is how many days the target may have been moving on without
+	// the branch, counted from the oldest target commit it lacks. Zero
+	// means no limit.
+	MaxDays int `json:"max_days,omitempty"`
+}
+
+// validateFreshness checks that an enabled freshness policy has a
+// non-negative limit.
+func validateFreshness(cfg *FreshnessConfig) error {
+	if cfg.MaxBehind < 0 {
+		return fmt.Errorf("freshness max_behind must be non-negative, got %d", cfg.MaxBehind)
+	}
+	if cfg.MaxDays < 0 {
+		return fmt.Errorf("freshness max_days must be non-negative, got %d", cfg.MaxDays)
+	}
+	if cfg.Enabled && cfg.MaxBehind == 0 && cfg.MaxDays == 0 {
+		return fmt.Errorf("freshness: max_behind or max_days is required")
+	}
+	return nil
+}
+
+// staleness describes how far a branch behind by behind commits, the
+// oldest committed at since, exceeds the limits; "" means it doesn't.
+func (c *FreshnessConfig) staleness(behind int, since, now time.Time) string {
+	var reasons []string
+	if c.MaxBehind > 0 && behind > c.MaxBehind {
+		reasons = append(reasons, fmt.Sprintf("%d commits behind (max %d)", behind, c.MaxBehind))
+	}
+	if days := int(now.Sub(since).Hours() / 24); c.MaxDays > 0 && behind > 0 && days > c.MaxDays {
+		reasons = append(reasons, fmt.Sprintf("missing %d days of changes (max %d)", days, c.MaxDays))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// StaleBounce records an MR sent back to its author to rebase.
+type StaleBounce struct {
+	MR        string    `json:"mr"`
+	Head      string    `json:"head"` // Branch head that was bounced
+	Target    string    `json:"target"`
+	Reason    string    `json:"reason"`
+	BouncedAt time.Time `json:"bounced_at"`
+}
+
+func (e *Engineer) staleBouncesPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "stale-mrs.json")
+}
+
+// StaleBounces returns the MRs currently bounced for rebasing, keyed by MR
+// ID.
+func (e *Engineer) StaleBounces() (map[string]*StaleBounce, error) {
+	bounces := make(map[string]*StaleBounce)
+	data, err := os.ReadFile(e.staleBouncesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return bounces, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &bounces); err != nil {
+		return nil, fmt.Errorf("parsing stale MRs: %w", err)
+	}
+	return bounces, nil
+}
+
+// admitFresh applies the freshness policy. MRs whose branches are further
+// behind their target than the limits are held, and their author is asked
+// to rebase in a comment on the MR's source issue (or the MR, if it has
+// none), once per branch head. An MR whose author rebases it is admitted
+// on the next pass.
+//
+// Like the other admission policies it fails open: MRs whose branch can't
+// be compared with the target are admitted with a warning.
+func (e *Engineer) admitFresh(ready []*MRInfo) (admitted, held []*MRInfo) {
+	cfg := e.config.Freshness
+	if cfg == nil || !cfg.Enabled || len(ready) == 0 {
+		return ready, nil
+	}
+	bounces, err := e.StaleBounces()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Freshness] Warning: %v (admitting all MRs)\n", err)
+		return ready, nil
+	}
+
+	now := time.Now()
+	changed := false
+	for _, mr := range ready {
+		target := mr.Target
+		if target == "" {
+			target = e.rig.DefaultBranch()
+		}
+		head := e.branchHead(mr.Branch)
+		behind, since, err := e.git.BehindSince(head, "origin/"+target)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Freshness] Warning: MR %s: comparing with %s: %v (admitting)\n", mr.ID, target, err)
+			admitted = append(admitted, mr)
+			continue
+		}
+		reason := cfg.staleness(behind, since, now)
+		if reason == "" {
+			admitted = append(admitted, mr)
+			if bounces[mr.ID] != nil {
+				delete(bounces, mr.ID)
+				changed = true
+			}
+			continue
+		}
+
+		mr.HeldFor = HeldForStale
+		held = append(held, mr)
+		e.recordProgress(StageHeld, "stale: "+reason, "", mr)
+
+		sha, _ := e.git.Rev(head)
+		if b := bounces[mr.ID]; b != nil && b.Head == sha {
+			continue
+		}
+		id := mr.SourceIssue
+		if id == "" {
+			id = mr.ID
+		}
+		if err := e.commentIssue(id, staleComment(mr, target, reason)); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Freshness] Warning: MR %s: commenting on %s: %v\n", mr.ID, id, err)
+			continue
+		}
+		bounces[mr.ID] = &StaleBounce{MR: mr.ID, Head: sha, Target: target, Reason: reason, BouncedAt: now.UTC()}
+		changed = true
+		_, _ = fmt.Fprintf(e.output, "[Freshness] MR %s bounced: %s; asked its author to rebase onto %s\n", mr.ID, reason, target)
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.staleBouncesPath(), bounces); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Freshness] Warning: saving stale MRs: %v\n", err)
+		}
+	}
+	return admitted, held
+}
+
+// staleComment asks the author of mr, stale by reason, to rebase it.
+func staleComment(mr *MRInfo, target, reason string) string {
+	return fmt.Sprintf("Refinery: MR %s (branch %s) is too far behind %s to batch: %s. "+
+		"Rebase it onto origin/%s and push; it rejoins the queue once it is fresh.",
+		mr.ID, mr.Branch, target, reason, target)
+}
+
+// commentInBeads adds a comment to a bead.
+func (e *Engineer) commentInBeads(id, text string) error {
+	_, err := e.beads.Run("comments", "add", id, text)
+	return err
+}
@@ -0,0 +1,80 @@
+package refinery
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFreshnessStaleness(t *testing.T) {
+	cfg := &FreshnessConfig{Enabled: true, MaxBehind: 20, MaxDays: 3}
+	now := time.Now()
+	if got := cfg.staleness(20, now.Add(-72*time.Hour), now); got != "" {
+		t.Errorf("at the limits: %q", got)
+	}
+	got := cfg.staleness(25, now.Add(-5*24*time.Hour), now)
+	if want := "25 commits behind (max 20), missing 5 days of changes (max 3)"; got != want {
+		t.Errorf("staleness = %q, want %q", got, want)
+	}
+	if got := (&FreshnessConfig{Enabled: true, MaxDays: 3}).staleness(0, time.Time{}, now); got != "" {
+		t.Errorf("not behind: %q", got)
+	}
+
+	if err := validateFreshness(&FreshnessConfig{Enabled: true}); err == nil {
+		t.Error("enabled without limits accepted")
+	}
+	if err := validateFreshness(&FreshnessConfig{MaxBehind: -1}); err == nil {
+		t.Error("negative max_behind accepted")
+	}
+}
+
+func TestAdmitFresh(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "old", "old.txt", "old\n")
+	for _, f := range []string{"m1.txt", "m2.txt", "m3.txt"} {
+		writeFile(t, workDir, f, f+"\n")
+		run(t, workDir, "git", "add", f)
+		run(t, workDir, "git", "commit", "-m", "main: "+f)
+	}
+	run(t, workDir, "git", "push", "origin", "main")
+	createFeatureBranch(t, workDir, "fresh", "fresh.txt", "fresh\n")
+
+	e := newTestEngineer(t, workDir, g)
+	comments := make(map[string][]string)
+	e.commentIssue = func(id, text string) error {
+		comments[id] = append(comments[id], text)
+		return nil
+	}
+	e.config.Freshness = &FreshnessConfig{Enabled: true, MaxBehind: 2}
+	old, fresh := makeMR("mr-old", "old", "main"), makeMR("mr-fresh", "fresh", "main")
+	old.SourceIssue = "gt-old"
+
+	admitted, held := e.AdmitMRs([]*MRInfo{old, fresh})
+	if len(admitted) != 1 || admitted[0] != fresh || len(held) != 1 || held[0] != old {
+		t.Fatalf("admitted %v, held %v; want mr-fresh admitted and mr-old held", mrIDs(admitted), mrIDs(held))
+	}
+	if old.HeldFor != HeldForStale {
+		t.Errorf("HeldFor = %q, want %q", old.HeldFor, HeldForStale)
+	}
+	if c := comments["gt-old"]; len(c) != 1 || !strings.Contains(c[0], "3 commits behind (max 2)") || !strings.Contains(c[0], "Rebase it onto origin/main") {
+		t.Fatalf("comments on gt-old = %q", c)
+	}
+
+	// Held again on the next pass, but bounced only once per branch head.
+	old.HeldFor = ""
+	if _, held := e.AdmitMRs([]*MRInfo{old}); len(held) != 1 || len(comments["gt-old"]) != 1 {
+		t.Errorf("second pass: held %v, %d comments", mrIDs(held), len(comments["gt-old"]))
+	}
+
+	// Its author rebases it.
+	run(t, workDir, "git", "checkout", "old")
+	run(t, workDir, "git", "rebase", "main")
+	run(t, workDir, "git", "checkout", "main")
+	if admitted, _ := e.AdmitMRs([]*MRInfo{old}); len(admitted) != 1 {
+		t.Errorf("rebased MR not admitted")
+	}
+	if bounces, err := e.StaleBounces(); err != nil || bounces["mr-old"] != nil {
+		t.Errorf("rebased MR still recorded as stale: %+v, %v", bounces, err)
+	}
+}
@@ -0,0 +1,66 @@
+package refinery
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// containerRuntimes are the runtimes tried, in order, for a gate with an
+// Image that doesn't name one.
+var containerRuntimes = []string{"docker", "podman"}
+
+// gateMount is where the tree under test appears in a gate's container.
+const gateMount = "/workspace"
+
+// containerRuntime returns the runtime that runs gate's container.
+func containerRuntime(gate *GateConfig) (string, error) {
+	if gate.Runtime != "" {
+		if _, err := exec.LookPath(gate.Runtime); err != nil {
+			return "", fmt.Errorf("container runtime %s: %w", gate.Runtime, err)
+		}
+		return gate.Runtime, nil
+	}
+	for _, rt := range containerRuntimes {
+		if _, err := exec.LookPath(rt); err == nil {
+			return rt, nil
+		}
+	}
+	return "", fmt.Errorf("gate needs a container runtime (%s), none found", strings.Join(containerRuntimes, " or "))
+}
+
+// containerGateArgs returns the runtime arguments that run gate in its
+// image as container, with dir mounted read-only at gateMount and env set.
+// Nothing else of the host's environment is passed in.
+func containerGateArgs(container, dir string, gate *GateConfig, env []string) []string {
+	workdir := gateMount
+	if gate.Dir != "" {
+		workdir = path.Join(workdir, filepath.ToSlash(gate.Dir))
+	}
+	args := []string{"run", "--rm", "--init", "--name", container,
+		"--volume", dir + ":" + gateMount + ":ro", "--workdir", workdir}
+	for _, kv := range env {
+		args = append(args, "--env", kv)
+	}
+	args = append(args, gate.Image)
+	args = append(args, gateShell(gate)...)
+	return append(args, "-c", gate.Cmd)
+}
+
+// gateContainerName returns a unique container name for a run of the gate
+// called name.
+func gateContainerName(name string) string {
+	clean := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, name)
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return "gt-gate-" + clean + "-" + hex.EncodeToString(b)
+}
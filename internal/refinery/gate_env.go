@@ -0,0 +1,107 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// gateVars returns the variables the refinery sets for every gate run under
+// ctx: BATCH_ID, the batch being gated, and MR_IDS, the space-separated MRs
+// in the tree in stacking order. Both are empty for a gate run outside a
+// batch.
+func gateVars(ctx context.Context) map[string]string {
+	stack := gateTreeMRs(ctx)
+	vars := map[string]string{"BATCH_ID": "", "MR_IDS": strings.Join(mrIDs(stack), " ")}
+	if len(stack) > 0 {
+		vars["BATCH_ID"] = stack[0].batchID
+	}
+	return vars
+}
+
+// gateEnv returns the "KEY=value" pairs to add to gate's environment: the
+// gate variables, then gate's Env with references to them expanded.
+func gateEnv(ctx context.Context, gate *GateConfig) []string {
+	vars := gateVars(ctx)
+	env := make([]string, 0, len(vars)+len(gate.Env))
+	for _, k := range sortedKeys(vars) {
+		env = append(env, k+"="+vars[k])
+	}
+	lookup := func(k string) string {
+		if v, ok := vars[k]; ok {
+			return v
+		}
+		return os.Getenv(k)
+	}
+	for _, k := range sortedKeys(gate.Env) {
+		env = append(env, k+"="+os.Expand(gate.Env[k], lookup))
+	}
+	return env
+}
+
+// gateShell returns the program and arguments that run gate's Cmd.
+func gateShell(gate *GateConfig) []string {
+	if shell := strings.Fields(gate.Shell); len(shell) > 0 {
+		return shell
+	}
+	return []string{"sh"}
+}
+
+// validateGateRun checks gate's dir, env, shell and container: the dir must
+// stay inside the tree under test, and env names must be usable.
+func validateGateRun(name string, gate *GateConfig) error {
+	if gate.Dir != "" && !filepath.IsLocal(gate.Dir) {
+		return fmt.Errorf("gate %q dir %q must be a relative path inside the repository", name, gate.Dir)
+	}
+	for k := range gate.Env {
+		if k == "" || strings.ContainsAny(k, "= \t\n") {
+			return fmt.Errorf("gate %q has an invalid env name %q", name, k)
+		}
+	}
+	if gate.Shell != "" && strings.TrimSpace(gate.Shell) == "" {
+		return fmt.Errorf("gate %q shell is blank", name)
+	}
+	if gate.Image != "" && (strings.TrimSpace(gate.Image) != gate.Image || strings.HasPrefix(gate.Image, "-")) {
+		return fmt.Errorf("gate %q has an invalid image %q", name, gate.Image)
+	}
+	if gate.Runtime != "" {
+		if gate.Image == "" {
+			return fmt.Errorf("gate %q sets a runtime without an image", name)
+		}
+		if gate.Runtime != "docker" && gate.Runtime != "podman" {
+			return fmt.Errorf("gate %q runtime must be docker or podman, got %q", name, gate.Runtime)
+		}
+	}
+	return nil
+}
+
+// signature identifies what gate runs, for telling whether a stored result
+// still applies: its command, and its dir, shell, image and env when set.
+func (g *GateConfig) signature() string {
+	sig := g.Cmd
+	if g.Dir != "" {
+		sig += " [dir " + g.Dir + "]"
+	}
+	if g.Shell != "" {
+		sig += " [shell " + g.Shell + "]"
+	}
+	if g.Image != "" {
+		sig += " [image " + g.Image + "]"
+	}
+	for _, k := range sortedKeys(g.Env) {
+		sig += " [" + k + "=" + g.Env[k] + "]"
+	}
+	return sig
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,79 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// gateTreeMRs returns the MRs in the tree gated under ctx, in stacking
+// order: the batch's stack, or the single MR being landed.
+func gateTreeMRs(ctx context.Context) []*MRInfo {
+	if stack, _ := ctx.Value(gateMRsKey{}).([]*MRInfo); len(stack) > 0 {
+		return stack
+	}
+	if mr, _ := ctx.Value(gateArtifactKey{}).(*MRInfo); mr != nil {
+		return []*MRInfo{mr}
+	}
+	return nil
+}
+
+// unaffectedGates returns the names of the gates in gates whose Paths
+// match none of the files changed by the MRs in the tree under ctx (see
+// GateConfig.Paths). Such gates can't be broken by the tree, so they are
+// skipped.
+//
+// Gates run whenever the tree's changes aren't known: outside a batch, or
+// when an MR's diff can't be read.
+func (e *Engineer) unaffectedGates(ctx context.Context, gates map[string]*GateConfig) map[string]bool {
+	scoped := false
+	for _, gate := range gates {
+		if len(gate.Paths) > 0 {
+			scoped = true
+			break
+		}
+	}
+	mrs := gateTreeMRs(ctx)
+	if !scoped || len(mrs) == 0 || e.rig == nil {
+		return nil
+	}
+
+	var changed []string
+	for _, mr := range mrs {
+		stats, err := e.mrDiffStats(mr)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: listing changes of MR %s: %v (running all gates)\n", mr.ID, err)
+			return nil
+		}
+		for _, s := range stats {
+			changed = append(changed, s.Path)
+		}
+	}
+	unaffected := make(map[string]bool)
+	for name, gate := range gates {
+		if len(gate.Paths) > 0 && !matchAnyPath(gate.Paths, changed) {
+			unaffected[name] = true
+		}
+	}
+	return unaffected
+}
+
+// matchAnyPath reports whether any of files matches any of patterns.
+func matchAnyPath(patterns, files []string) bool {
+	for _, file := range files {
+		if matchAnyPattern(patterns, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedGateNames returns the names set in gates, sorted.
+func sortedGateNames(gates map[string]bool) []string {
+	names := make([]string, 0, len(gates))
+	for name := range gates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
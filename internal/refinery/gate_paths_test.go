@@ -0,0 +1,51 @@
+package refinery
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProcessBatch_GatePaths(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "docs-a", "CHANGELOG.md", "a\n")
+	createFeatureBranch(t, workDir, "docs-b", "README.md", "b\n")
+	createFeatureBranch(t, workDir, "code", "main.go", "package main\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{
+		"lint":        {Cmd: "true"},
+		"integration": {Cmd: "false", Paths: []string{"*.go"}},
+	}
+
+	docs := []*MRInfo{makeMR("mr-a", "docs-a", "main"), makeMR("mr-b", "docs-b", "main")}
+	result := e.ProcessBatch(context.Background(), docs, "main", DefaultBatchConfig())
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("docs batch: merged %v, %v", stackedIDs(result.Merged), result.Error)
+	}
+	if len(result.SkippedGates) != 1 || result.SkippedGates[0] != "integration" {
+		t.Errorf("SkippedGates = %v, want [integration]", result.SkippedGates)
+	}
+	records, err := e.History(HistoryQuery{BatchID: result.BatchID})
+	if err != nil || len(records) != 1 || len(records[0].GateRuns) != 1 {
+		t.Fatalf("history = %+v, %v", records, err)
+	}
+	for _, gate := range records[0].GateRuns[0].Gates {
+		if gate.Unaffected != (gate.Name == "integration") {
+			t.Errorf("gate %s recorded unaffected=%v", gate.Name, gate.Unaffected)
+		}
+	}
+
+	// A batch touching Go code runs the integration gate.
+	result = e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-code", "code", "main")}, "main", DefaultBatchConfig())
+	if len(result.Merged) != 0 || len(result.Culprits) != 1 {
+		t.Errorf("code batch: merged %v, culprits %v; want mr-code blamed", stackedIDs(result.Merged), stackedIDs(result.Culprits))
+	}
+}
+
+func TestParseGates_BadPathPattern(t *testing.T) {
+	_, err := parseGates(map[string]*gateConfigRaw{"lint": {Cmd: "true", Paths: []string{"src/[a"}}})
+	if err == nil {
+		t.Error("bad path pattern accepted")
+	}
+}
@@ -0,0 +1,432 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// GerritConfig makes the merge queue act on changes of a Gerrit project:
+// open changes carrying every ReadyLabels vote are ingested as MRs (see
+// IngestChanges), stacked in their relation-chain order, and submitted
+// through Gerrit's REST API once their batch passes gates, instead of
+// being pushed (see submitToGerrit). The rig's origin remote must be the
+// project, so change refs can be fetched from it.
+type GerritConfig struct {
+	// URL is the Gerrit server, e.g. "https://review.example.com".
+	URL string `json:"url"`
+
+	// Project is the Gerrit project name, e.g. "platform/tools".
+	Project string `json:"project"`
+
+	// ReadyLabels are the votes a change needs to be queued, in Gerrit
+	// query form ("Code-Review=MAX", "Verified>=1").
+	// Default: ["Code-Review=MAX"].
+	ReadyLabels []string `json:"ready_labels,omitempty"`
+
+	// Query adds search terms to the ready-change query (e.g. "-hashtag:hold").
+	Query string `json:"query,omitempty"`
+
+	// UserEnv and PasswordEnv name the environment variables holding the
+	// HTTP credentials. Defaults: GERRIT_USER, GERRIT_HTTP_PASSWORD.
+	// Without credentials, requests are anonymous and submits will fail.
+	UserEnv     string `json:"user_env,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"`
+
+	// NoComments skips review messages on changes the queue rejects.
+	NoComments bool `json:"no_comments,omitempty"`
+}
+
+const (
+	defaultGerritReadyLabel  = "Code-Review=MAX"
+	defaultGerritUserEnv     = "GERRIT_USER"
+	defaultGerritPasswordEnv = "GERRIT_HTTP_PASSWORD"
+
+	// gerritTimeout bounds each Gerrit API request.
+	gerritTimeout = 30 * time.Second
+
+	// gerritPageSize is how many changes each query page asks for.
+	gerritPageSize = 100
+)
+
+// gerritXSSIPrefix is prepended to every Gerrit JSON response.
+const gerritXSSIPrefix = ")]}'"
+
+// GerritChangeRef identifies the Gerrit change an MR was ingested from.
+type GerritChangeRef struct {
+	Number   int    `json:"number"`
+	ChangeID string `json:"change_id"` // The Change-Id footer, "I..."
+	Project  string `json:"project"`
+	Patchset int    `json:"patchset"`
+	Revision string `json:"revision"` // Current patchset's commit SHA
+	Ref      string `json:"ref"`      // Current patchset's ref, refs/changes/NN/N/P
+	URL      string `json:"url"`
+	Owner    string `json:"owner"`
+}
+
+func validateGerritConfig(cfg *GerritConfig) error {
+	if u, err := url.Parse(cfg.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("gerrit: invalid url %q", cfg.URL)
+	}
+	if cfg.Project == "" {
+		return fmt.Errorf("gerrit: project is required")
+	}
+	for _, l := range cfg.ReadyLabels {
+		if l == "" || strings.ContainsAny(l, " \t") {
+			return fmt.Errorf("gerrit: invalid ready label %q", l)
+		}
+	}
+	return nil
+}
+
+func (c *GerritConfig) readyLabels() []string {
+	if len(c.ReadyLabels) > 0 {
+		return c.ReadyLabels
+	}
+	return []string{defaultGerritReadyLabel}
+}
+
+func (c *GerritConfig) credentials() (user, password string) {
+	userEnv, passwordEnv := c.UserEnv, c.PasswordEnv
+	if userEnv == "" {
+		userEnv = defaultGerritUserEnv
+	}
+	if passwordEnv == "" {
+		passwordEnv = defaultGerritPasswordEnv
+	}
+	return os.Getenv(userEnv), os.Getenv(passwordEnv)
+}
+
+// query returns the search for changes ready for the queue.
+func (c *GerritConfig) query() string {
+	terms := []string{"status:open", "-is:wip", fmt.Sprintf("project:%q", c.Project)}
+	for _, l := range c.readyLabels() {
+		terms = append(terms, "label:"+l)
+	}
+	if c.Query != "" {
+		terms = append(terms, c.Query)
+	}
+	return strings.Join(terms, " ")
+}
+
+// changePath is the REST path of change number in the project.
+func (c *GerritConfig) changePath(number int) string {
+	return fmt.Sprintf("/changes/%s~%d", url.PathEscape(c.Project), number)
+}
+
+// GerritChangeBranch is the local branch a change's current patchset is
+// fetched into.
+func GerritChangeBranch(number int) string {
+	return fmt.Sprintf("gerrit/%d", number)
+}
+
+// gerritChangeMRID is the MR ID of an ingested change.
+func gerritChangeMRID(number int) string {
+	return fmt.Sprintf("gerrit-%d", number)
+}
+
+// gerritTime parses Gerrit's "2006-01-02 15:04:05.000000000" UTC timestamps.
+type gerritTime struct{ time.Time }
+
+func (t *gerritTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse("2006-01-02 15:04:05.999999999", s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// gerritChange is the subset of Gerrit's ChangeInfo the queue uses.
+type gerritChange struct {
+	Number          int        `json:"_number"`
+	ChangeID        string     `json:"change_id"`
+	Project         string     `json:"project"`
+	Branch          string     `json:"branch"`
+	Subject         string     `json:"subject"`
+	Created         gerritTime `json:"created"`
+	Updated         gerritTime `json:"updated"`
+	CurrentRevision string     `json:"current_revision"`
+	MoreChanges     bool       `json:"_more_changes"`
+	Owner           struct {
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"owner"`
+	Revisions map[string]struct {
+		Number int    `json:"_number"`
+		Ref    string `json:"ref"`
+		Commit struct {
+			Parents []struct {
+				Commit string `json:"commit"`
+			} `json:"parents"`
+		} `json:"commit"`
+	} `json:"revisions"`
+}
+
+// gerritRelated is Gerrit's RelatedChangesInfo.
+type gerritRelated struct {
+	Changes []struct {
+		Number int    `json:"_change_number"`
+		Status string `json:"status"`
+		Commit struct {
+			Commit string `json:"commit"`
+		} `json:"commit"`
+	} `json:"changes"`
+}
+
+// ListChanges returns the open changes ready for the queue, as MRs. A
+// change stacked on another open change is BlockedBy that change's MR, so
+// relation chains are batched and submitted parent first; a change whose
+// parent is open but not ready waits for it. Branches are not fetched;
+// see IngestChanges.
+func (c *GerritConfig) ListChanges(ctx context.Context, rigName string) ([]*MRInfo, error) {
+	var changes []*gerritChange
+	for start := 0; ; start += gerritPageSize {
+		var page []*gerritChange
+		path := fmt.Sprintf("/changes/?q=%s&o=CURRENT_REVISION&o=CURRENT_COMMIT&o=DETAILED_ACCOUNTS&n=%d&S=%d",
+			url.QueryEscape(c.query()), gerritPageSize, start)
+		if err := c.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, fmt.Errorf("querying changes: %w", err)
+		}
+		changes = append(changes, page...)
+		if len(page) == 0 || !page[len(page)-1].MoreChanges {
+			break
+		}
+	}
+
+	// Ready changes by current revision, to link chains without lookups.
+	byRevision := make(map[string]int, len(changes))
+	for _, ch := range changes {
+		byRevision[ch.CurrentRevision] = ch.Number
+	}
+
+	mrs := make([]*MRInfo, 0, len(changes))
+	for _, ch := range changes {
+		rev := ch.Revisions[ch.CurrentRevision]
+		owner := ch.Owner.Username
+		if owner == "" {
+			owner = ch.Owner.Name
+		}
+		mr := &MRInfo{
+			ID:        gerritChangeMRID(ch.Number),
+			Branch:    GerritChangeBranch(ch.Number),
+			Target:    ch.Branch,
+			Worker:    owner,
+			Rig:       rigName,
+			Title:     ch.Subject,
+			Priority:  2,
+			CreatedAt: ch.Created.Time,
+			UpdatedAt: ch.Updated.Time,
+			Change: &GerritChangeRef{
+				Number:   ch.Number,
+				ChangeID: ch.ChangeID,
+				Project:  ch.Project,
+				Patchset: rev.Number,
+				Revision: ch.CurrentRevision,
+				Ref:      rev.Ref,
+				URL:      fmt.Sprintf("%s/c/%s/+/%d", strings.TrimSuffix(c.URL, "/"), ch.Project, ch.Number),
+				Owner:    owner,
+			},
+		}
+		if len(rev.Commit.Parents) > 0 {
+			parent := rev.Commit.Parents[0].Commit
+			if n, ok := byRevision[parent]; ok {
+				mr.BlockedBy = gerritChangeMRID(n)
+			} else if n, err := c.openParent(ctx, ch.Number, parent); err != nil {
+				return nil, fmt.Errorf("relation chain of change %d: %w", ch.Number, err)
+			} else if n != 0 {
+				mr.BlockedBy = gerritChangeMRID(n)
+			}
+		}
+		mrs = append(mrs, mr)
+	}
+	return mrs, nil
+}
+
+// openParent returns the number of the open change whose patchset is
+// parent in change number's relation chain, or 0 if parent is not an open
+// change (it is merged, or on the branch).
+func (c *GerritConfig) openParent(ctx context.Context, number int, parent string) (int, error) {
+	var related gerritRelated
+	if err := c.do(ctx, http.MethodGet, c.changePath(number)+"/revisions/current/related", nil, &related); err != nil {
+		return 0, err
+	}
+	for _, r := range related.Changes {
+		if r.Commit.Commit == parent && r.Status == "NEW" {
+			return r.Number, nil
+		}
+	}
+	return 0, nil
+}
+
+// Submit submits a change, merging it into its branch.
+func (c *GerritConfig) Submit(ctx context.Context, ch *GerritChangeRef) error {
+	return c.do(ctx, http.MethodPost, c.changePath(ch.Number)+"/submit", map[string]string{}, nil)
+}
+
+// comment posts a review message on the change's ingested patchset.
+func (c *GerritConfig) comment(ctx context.Context, ch *GerritChangeRef, message string) error {
+	path := fmt.Sprintf("%s/revisions/%s/review", c.changePath(ch.Number), ch.Revision)
+	return c.do(ctx, http.MethodPost, path, map[string]string{"message": message}, nil)
+}
+
+// do makes a Gerrit REST request, encoding body as JSON and decoding the
+// response into out when they are non-nil. With credentials, requests go
+// to the authenticated /a/ endpoints.
+func (c *GerritConfig) do(ctx context.Context, method, path string, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, gerritTimeout)
+	defer cancel()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	user, password := c.credentials()
+	endpoint := strings.TrimSuffix(c.URL, "/")
+	if user != "" {
+		endpoint += "/a"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes.TrimPrefix(data, []byte(gerritXSSIPrefix)), out)
+}
+
+// IngestChanges lists the changes ready for the queue (see ListChanges)
+// and fetches each change's current patchset from origin into its local
+// gerrit/<number> branch, so batches can stack it like any other MR.
+// Changes that can't be fetched are skipped with a warning.
+func (e *Engineer) IngestChanges(ctx context.Context) ([]*MRInfo, error) {
+	if e.config.Gerrit == nil {
+		return nil, nil
+	}
+	mrs, err := e.config.Gerrit.ListChanges(ctx, e.rig.Name)
+	if err != nil {
+		return nil, err
+	}
+	ingested := mrs[:0]
+	for _, mr := range mrs {
+		if err := e.git.FetchRefToBranch(ctx, "origin", mr.Change.Ref, mr.Branch); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Gerrit] Warning: fetching change %d: %v\n", mr.Change.Number, err)
+			continue
+		}
+		ingested = append(ingested, mr)
+	}
+	return ingested, nil
+}
+
+// hasGerritChanges reports whether any of mrs was ingested from Gerrit.
+func hasGerritChanges(mrs []*MRInfo) bool {
+	for _, mr := range mrs {
+		if mr.Change != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// submitToGerrit lands a gated stack of Gerrit changes by submitting each
+// through the REST API, parent first, instead of pushing the local squash
+// stack: Gerrit owns the branch and records the changes as merged. If a
+// submit fails, the changes before it are merged and the rest stay queued.
+// The target is then reset to what Gerrit wrote.
+func (e *Engineer) submitToGerrit(ctx context.Context, stacked []*MRInfo, target string, result *BatchResult) *BatchResult {
+	cfg := e.config.Gerrit
+	for _, mr := range stacked {
+		if mr.Change == nil || cfg == nil {
+			_ = e.git.ResetHard("origin/" + target)
+			result.Error = fmt.Errorf("batch mixes Gerrit changes with other MRs (or merge_queue.gerrit is not configured); %s can't be pushed to a Gerrit branch", mr.ID)
+			return result
+		}
+	}
+
+	var merged []*MRInfo
+	for _, mr := range stacked {
+		_, _ = fmt.Fprintf(e.output, "[Gerrit] Submitting change %d (%s)...\n", mr.Change.Number, mr.Title)
+		if err := cfg.Submit(ctx, mr.Change); err != nil {
+			result.Error = fmt.Errorf("submitting change %d: %w", mr.Change.Number, err)
+			break
+		}
+		merged = append(merged, mr)
+	}
+
+	// Whatever Gerrit merged is now the target; drop the local squash stack.
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Gerrit] Warning: fetching %s after submit: %v\n", target, err)
+	}
+	if err := e.git.ResetHard("origin/" + target); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Gerrit] Warning: resetting %s after submit: %v\n", target, err)
+	}
+	if len(merged) == 0 {
+		return result
+	}
+	if tip, err := e.git.Rev("origin/" + target); err == nil {
+		result.MergeCommit = tip
+	}
+	_, _ = fmt.Fprintf(e.output, "[Gerrit] Submitted %d changes: %s\n", len(merged), strings.Join(mrIDs(merged), ", "))
+	e.markAcceptanceVerified(merged)
+	result.Merged = merged
+	return result
+}
+
+// reportToGerrit posts a review message on changes the queue rejected:
+// those that conflict with the target and those that failed gates.
+// Merged changes need no message; Gerrit shows them merged. Reporting
+// failures are logged and never affect the batch.
+func (e *Engineer) reportToGerrit(ctx context.Context, result *BatchResult, target string) {
+	cfg := e.config.Gerrit
+	if cfg == nil || cfg.NoComments || result == nil {
+		return
+	}
+	report := func(mrs []*MRInfo, message string) {
+		for _, mr := range mrs {
+			if mr.Change == nil {
+				continue
+			}
+			if err := cfg.comment(ctx, mr.Change, message); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Gerrit] Warning: commenting on change %d: %v\n", mr.Change.Number, err)
+			}
+		}
+	}
+	report(result.Conflicts, fmt.Sprintf("Merge queue: this change conflicts with %s. Rebase it and it will be picked up again.", target))
+	report(result.Culprits, fmt.Sprintf("Merge queue: removed from batch %s, the quality gates failed with this change applied to %s.", result.BatchID, target))
+}
@@ -0,0 +1,250 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestEngineer_LoadConfig_Gerrit(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"gerrit": {"url": "https://review.example.com", "project": "platform/tools"}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	gr := e.config.Gerrit
+	if gr == nil || gr.Project != "platform/tools" {
+		t.Fatalf("gerrit config = %+v", gr)
+	}
+	if q := gr.query(); q != `status:open -is:wip project:"platform/tools" label:Code-Review=MAX` {
+		t.Errorf("query = %q", q)
+	}
+
+	for _, bad := range []string{
+		`{"project": "tools"}`,
+		`{"url": "review.example.com", "project": "tools"}`,
+		`{"url": "https://review.example.com"}`,
+		`{"url": "https://review.example.com", "project": "tools", "ready_labels": ["Code-Review = 2"]}`,
+	} {
+		data := []byte(`{"merge_queue": {"gerrit": ` + bad + `}}`)
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+			t.Errorf("LoadConfig accepted gerrit %s", bad)
+		}
+	}
+}
+
+// fakeGerrit serves the query, related-changes, submit and review
+// endpoints the queue uses. Submitting a change moves the origin branch to
+// its revision, as Gerrit's fast-forward submit would.
+type fakeGerrit struct {
+	t      *testing.T
+	origin string         // Bare repository standing in for the project
+	revs   map[int]string // Change number → current revision
+	parent string         // Revision of the unready change 9
+
+	mu        sync.Mutex
+	writes    []string // "METHOD path body"
+	rejectSub int      // Change whose submit fails
+}
+
+func (f *fakeGerrit) change(n int, subject string, parent string) string {
+	return fmt.Sprintf(`{"_number": %d, "change_id": "I%d", "project": "tools", "branch": "main",
+		"subject": %q, "created": "2026-03-01 10:00:00.000000000", "updated": "2026-03-02 10:00:00.000000000",
+		"owner": {"username": "alice"}, "current_revision": %q,
+		"revisions": {%q: {"_number": 2, "ref": "refs/changes/%02d/%d/2",
+			"commit": {"parents": [{"commit": %q}]}}}}`,
+		n, n, subject, f.revs[n], f.revs[n], n%100, n, parent)
+}
+
+func (f *fakeGerrit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "queue" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path := r.URL.EscapedPath()
+	switch {
+	case r.Method == http.MethodGet && path == "/a/changes/":
+		if q := r.URL.Query().Get("q"); q != `status:open -is:wip project:"tools" label:Code-Review=MAX` {
+			f.t.Errorf("query = %q", q)
+		}
+		_, _ = io.WriteString(w, gerritXSSIPrefix+"\n["+
+			f.change(10, "Add gears", "base")+","+
+			f.change(11, "Add sprockets", f.revs[10])+","+
+			f.change(12, "Add cogs", f.parent)+"]")
+	case r.Method == http.MethodGet && path == "/a/changes/tools~12/revisions/current/related":
+		_, _ = fmt.Fprintf(w, "%s\n{\"changes\": [{\"_change_number\": 12, \"status\": \"NEW\", \"commit\": {\"commit\": %q}},"+
+			"{\"_change_number\": 9, \"status\": \"NEW\", \"commit\": {\"commit\": %q}}]}", gerritXSSIPrefix, f.revs[12], f.parent)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/related"):
+		_, _ = io.WriteString(w, gerritXSSIPrefix+`{"changes": []}`)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/submit"):
+		var n int
+		_, _ = fmt.Sscanf(path, "/a/changes/tools~%d/submit", &n)
+		f.record(r)
+		if n == f.rejectSub {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = io.WriteString(w, "change is new but could not be merged")
+			return
+		}
+		run(f.t, f.origin, "git", "update-ref", "refs/heads/main", f.revs[n])
+		_, _ = io.WriteString(w, gerritXSSIPrefix+`{"status": "MERGED"}`)
+	case r.Method == http.MethodPost:
+		f.record(r)
+		_, _ = io.WriteString(w, gerritXSSIPrefix+`{}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeGerrit) record(r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes = append(f.writes, fmt.Sprintf("%s %s %s", r.Method, r.URL.EscapedPath(), body))
+}
+
+// gerritRepo sets up a repo whose origin holds a relation chain 10 ← 11 on
+// main, and change 12 stacked on an unready change 9.
+func gerritRepo(t *testing.T) (string, *fakeGerrit, *Engineer) {
+	workDir, g, cleanup := testGitRepo(t)
+	t.Cleanup(cleanup)
+	fake := &fakeGerrit{t: t, origin: filepath.Join(filepath.Dir(workDir), "origin.git"), revs: map[int]string{}}
+
+	createFeatureBranch(t, workDir, "c10", "gears.go", "package gears\n")
+	run(t, workDir, "git", "checkout", "-q", "-b", "c11", "c10")
+	writeFile(t, workDir, "sprockets.go", "package sprockets\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-q", "-m", "Add sprockets")
+	createFeatureBranch(t, workDir, "c9", "base.go", "package base\n")
+	run(t, workDir, "git", "checkout", "-q", "-b", "c12", "c9")
+	writeFile(t, workDir, "cogs.go", "package cogs\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-q", "-m", "Add cogs")
+	run(t, workDir, "git", "checkout", "-q", "main")
+	for _, n := range []int{10, 11, 12} {
+		run(t, workDir, "git", "push", "-q", "origin", fmt.Sprintf("c%d:refs/changes/%d/%d/2", n, n, n))
+		fake.revs[n] = run(t, workDir, "git", "rev-parse", fmt.Sprintf("c%d", n))
+	}
+	fake.parent = run(t, workDir, "git", "rev-parse", "c9")
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	t.Setenv("GERRIT_USER", "queue")
+	t.Setenv("GERRIT_HTTP_PASSWORD", "secret")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gerrit = &GerritConfig{URL: srv.URL, Project: "tools"}
+	return workDir, fake, e
+}
+
+func TestGerrit_IngestChain(t *testing.T) {
+	_, _, e := gerritRepo(t)
+
+	mrs, err := e.IngestChanges(context.Background())
+	if err != nil {
+		t.Fatalf("IngestChanges: %v", err)
+	}
+	if len(mrs) != 3 {
+		t.Fatalf("ingested %d changes, want 3", len(mrs))
+	}
+	blockedBy := map[string]string{}
+	for _, mr := range mrs {
+		blockedBy[mr.ID] = mr.BlockedBy
+		if exists, err := e.git.BranchExists(mr.Branch); err != nil || !exists {
+			t.Errorf("change %s not fetched into %s (exists=%v, err=%v)", mr.ID, mr.Branch, exists, err)
+		}
+	}
+	want := map[string]string{"gerrit-10": "", "gerrit-11": "gerrit-10", "gerrit-12": "gerrit-9"}
+	for id, b := range want {
+		if blockedBy[id] != b {
+			t.Errorf("%s BlockedBy = %q, want %q", id, blockedBy[id], b)
+		}
+	}
+	mr := mrs[1]
+	if mr.Branch != "gerrit/11" || mr.Worker != "alice" || mr.Change.Patchset != 2 ||
+		mr.Change.Ref != "refs/changes/11/11/2" || mr.CreatedAt.Year() != 2026 {
+		t.Errorf("ingested MR = %+v, change %+v", mr, mr.Change)
+	}
+}
+
+func TestGerrit_SubmitsStackInOrder(t *testing.T) {
+	workDir, fake, e := gerritRepo(t)
+	mrs, err := e.IngestChanges(context.Background())
+	if err != nil {
+		t.Fatalf("IngestChanges: %v", err)
+	}
+
+	// Queued child first: the chain must still be submitted parent first.
+	result := e.ProcessBatch(context.Background(), []*MRInfo{mrs[1], mrs[0]}, "main", nil)
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v\n%s", result.Error, e.output)
+	}
+	if len(result.Merged) != 2 || result.Merged[0].ID != "gerrit-10" || result.Merged[1].ID != "gerrit-11" {
+		t.Fatalf("merged = %v", mrIDs(result.Merged))
+	}
+	if result.MergeCommit != fake.revs[11] {
+		t.Errorf("merge commit = %s, want change 11's revision %s", result.MergeCommit, fake.revs[11])
+	}
+	if head := run(t, workDir, "git", "rev-parse", "HEAD"); head != fake.revs[11] {
+		t.Errorf("local main not reset to what Gerrit merged: %s", head)
+	}
+	got := strings.Join(fake.writes, "\n")
+	if want := "POST /a/changes/tools~10/submit {}\nPOST /a/changes/tools~11/submit {}"; got != want {
+		t.Errorf("writes =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGerrit_SubmitFailureKeepsRest(t *testing.T) {
+	_, fake, e := gerritRepo(t)
+	fake.rejectSub = 11
+	mrs, err := e.IngestChanges(context.Background())
+	if err != nil {
+		t.Fatalf("IngestChanges: %v", err)
+	}
+
+	result := e.ProcessBatch(context.Background(), mrs[:2], "main", nil)
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "submitting change 11") {
+		t.Errorf("error = %v, want change 11's submit failure", result.Error)
+	}
+	if len(result.Merged) != 1 || result.Merged[0].ID != "gerrit-10" || result.MergeCommit != fake.revs[10] {
+		t.Errorf("merged = %v at %s, want only gerrit-10", mrIDs(result.Merged), result.MergeCommit)
+	}
+}
+
+func TestGerrit_ReportRejected(t *testing.T) {
+	_, fake, e := gerritRepo(t)
+
+	culprit := makeMR("gerrit-12", "gerrit/12", "main")
+	culprit.Change = &GerritChangeRef{Number: 12, Revision: "abc123"}
+	e.reportToGerrit(context.Background(), &BatchResult{
+		BatchID:  "batch-3",
+		Merged:   []*MRInfo{makeMR("gerrit-10", "gerrit/10", "main")},
+		Culprits: []*MRInfo{culprit, makeMR("gt-local", "polecat/x", "main")},
+	}, "main")
+	if len(fake.writes) != 1 || !strings.HasPrefix(fake.writes[0], "POST /a/changes/tools~12/revisions/abc123/review ") ||
+		!strings.Contains(fake.writes[0], "batch-3") {
+		t.Errorf("writes = %q, want one review message on change 12", fake.writes)
+	}
+
+	fake.writes = nil
+	e.config.Gerrit.NoComments = true
+	e.reportToGerrit(context.Background(), &BatchResult{Culprits: []*MRInfo{culprit}}, "main")
+	if len(fake.writes) != 0 {
+		t.Errorf("NoComments still wrote %q", fake.writes)
+	}
+}
@@ -0,0 +1,376 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// GitHubConfig makes the merge queue act on pull requests of a GitHub
+// repository: open PRs carrying Label are ingested as MRs (see
+// IngestPullRequests), and each batch's outcome is reported back on them
+// as a commit status and a comment (see reportToGitHub). The rig's origin
+// remote must be the repository, so PR heads can be fetched from it.
+type GitHubConfig struct {
+	// Repo is the repository, as "owner/name".
+	Repo string `json:"repo"`
+
+	// Label marks PRs for the queue. Default: "merge-queue".
+	Label string `json:"label,omitempty"`
+
+	// MinApprovals is how many approving reviews a PR needs to be queued.
+	MinApprovals int `json:"min_approvals,omitempty"`
+
+	// TokenEnv names the environment variable holding the API token.
+	// Default: GITHUB_TOKEN.
+	TokenEnv string `json:"token_env,omitempty"`
+
+	// APIURL is the API endpoint; set it for GitHub Enterprise, e.g.
+	// "https://github.example.com/api/v3". Default: https://api.github.com.
+	APIURL string `json:"api_url,omitempty"`
+
+	// StatusContext names the commit status the queue reports.
+	// Default: "gastown/merge-queue".
+	StatusContext string `json:"status_context,omitempty"`
+
+	// NoComments reports outcomes with commit statuses only.
+	NoComments bool `json:"no_comments,omitempty"`
+}
+
+const (
+	defaultGitHubLabel         = "merge-queue"
+	defaultGitHubTokenEnv      = "GITHUB_TOKEN"
+	defaultGitHubAPIURL        = "https://api.github.com"
+	defaultGitHubStatusContext = "gastown/merge-queue"
+
+	// githubTimeout bounds each GitHub API request.
+	githubTimeout = 30 * time.Second
+)
+
+// PullRequestRef identifies the GitHub pull request an MR was ingested from.
+type PullRequestRef struct {
+	Number    int    `json:"number"`
+	URL       string `json:"url"`
+	HeadSHA   string `json:"head_sha"`
+	Author    string `json:"author"`
+	Approvals int    `json:"approvals"`
+}
+
+func validateGitHubConfig(cfg *GitHubConfig) error {
+	owner, name, ok := strings.Cut(cfg.Repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("github: repo must be \"owner/name\", got %q", cfg.Repo)
+	}
+	if cfg.MinApprovals < 0 {
+		return fmt.Errorf("github: min_approvals must be non-negative, got %d", cfg.MinApprovals)
+	}
+	if cfg.APIURL != "" {
+		if u, err := url.Parse(cfg.APIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("github: invalid api_url %q", cfg.APIURL)
+		}
+	}
+	return nil
+}
+
+func (c *GitHubConfig) label() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	return defaultGitHubLabel
+}
+
+func (c *GitHubConfig) token() string {
+	if c.TokenEnv != "" {
+		return os.Getenv(c.TokenEnv)
+	}
+	return os.Getenv(defaultGitHubTokenEnv)
+}
+
+func (c *GitHubConfig) apiURL() string {
+	if c.APIURL != "" {
+		return strings.TrimSuffix(c.APIURL, "/")
+	}
+	return defaultGitHubAPIURL
+}
+
+func (c *GitHubConfig) statusContext() string {
+	if c.StatusContext != "" {
+		return c.StatusContext
+	}
+	return defaultGitHubStatusContext
+}
+
+// PullRequestBranch is the local branch a PR's head is fetched into.
+func PullRequestBranch(number int) string {
+	return fmt.Sprintf("pr/%d", number)
+}
+
+// githubPull is the subset of the GitHub pull request object the queue uses.
+type githubPull struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	HTMLURL   string    `json:"html_url"`
+	Draft     bool      `json:"draft"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Head struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+type githubReview struct {
+	State string `json:"state"`
+	User  struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// ListPullRequests returns the open, non-draft pull requests labeled for
+// the queue that have at least MinApprovals approvals, as MRs. Their
+// branches are not fetched; see IngestPullRequests.
+func (c *GitHubConfig) ListPullRequests(ctx context.Context, rigName string) ([]*MRInfo, error) {
+	var mrs []*MRInfo
+	for page := 1; ; page++ {
+		var pulls []githubPull
+		path := fmt.Sprintf("/repos/%s/pulls?state=open&sort=created&direction=asc&per_page=100&page=%d", c.Repo, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &pulls); err != nil {
+			return nil, fmt.Errorf("listing pull requests: %w", err)
+		}
+		for i := range pulls {
+			pull := &pulls[i]
+			if pull.Draft || !pull.hasLabel(c.label()) {
+				continue
+			}
+			approvals, err := c.approvals(ctx, pull.Number)
+			if err != nil {
+				return nil, fmt.Errorf("reviews of PR #%d: %w", pull.Number, err)
+			}
+			if approvals < c.MinApprovals {
+				continue
+			}
+			mrs = append(mrs, &MRInfo{
+				ID:        fmt.Sprintf("gh-pr-%d", pull.Number),
+				Branch:    PullRequestBranch(pull.Number),
+				Target:    pull.Base.Ref,
+				Worker:    pull.User.Login,
+				Rig:       rigName,
+				Title:     pull.Title,
+				Priority:  2,
+				CreatedAt: pull.CreatedAt,
+				UpdatedAt: pull.UpdatedAt,
+				PullRequest: &PullRequestRef{
+					Number:    pull.Number,
+					URL:       pull.HTMLURL,
+					HeadSHA:   pull.Head.SHA,
+					Author:    pull.User.Login,
+					Approvals: approvals,
+				},
+			})
+		}
+		if len(pulls) < 100 {
+			return mrs, nil
+		}
+	}
+}
+
+func (p *githubPull) hasLabel(label string) bool {
+	for _, l := range p.Labels {
+		if l.Name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// approvals counts the reviewers whose latest decisive review of PR number
+// approves it. A later "changes requested" review withdraws an approval;
+// comments leave it standing.
+func (c *GitHubConfig) approvals(ctx context.Context, number int) (int, error) {
+	latest := make(map[string]string)
+	for page := 1; ; page++ {
+		var reviews []githubReview
+		path := fmt.Sprintf("/repos/%s/pulls/%d/reviews?per_page=100&page=%d", c.Repo, number, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &reviews); err != nil {
+			return 0, err
+		}
+		for _, r := range reviews {
+			switch r.State {
+			case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+				latest[r.User.Login] = r.State
+			}
+		}
+		if len(reviews) < 100 {
+			break
+		}
+	}
+	n := 0
+	for _, state := range latest {
+		if state == "APPROVED" {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// do makes a GitHub API request, encoding body as JSON and decoding the
+// response into out when they are non-nil.
+func (c *GitHubConfig) do(ctx context.Context, method, path string, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, githubTimeout)
+	defer cancel()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL()+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// IngestPullRequests lists the pull requests ready for the queue (see
+// ListPullRequests) and fetches each PR's head from origin into its local
+// pr/<number> branch, so batches can stack it like any other MR. PRs
+// whose head can't be fetched are skipped with a warning.
+func (e *Engineer) IngestPullRequests(ctx context.Context) ([]*MRInfo, error) {
+	if e.config.GitHub == nil {
+		return nil, nil
+	}
+	mrs, err := e.config.GitHub.ListPullRequests(ctx, e.rig.Name)
+	if err != nil {
+		return nil, err
+	}
+	ingested := mrs[:0]
+	for _, mr := range mrs {
+		ref := fmt.Sprintf("refs/pull/%d/head", mr.PullRequest.Number)
+		if err := e.git.FetchRefToBranch(ctx, "origin", ref, mr.Branch); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[GitHub] Warning: fetching PR #%d: %v\n", mr.PullRequest.Number, err)
+			continue
+		}
+		ingested = append(ingested, mr)
+	}
+	return ingested, nil
+}
+
+// pullRequestOutcome is how a batch result is reported on a pull request.
+type pullRequestOutcome struct {
+	state       string // Commit status state: success, failure or pending
+	description string
+	comment     string // Empty: status only
+	close       bool
+}
+
+// reportToGitHub reports a processed batch back on the pull requests in
+// it: merged PRs get a success status and are closed, since their squashed
+// commit doesn't mark them merged; conflicting and culprit PRs get a
+// failure status; deferred PRs stay pending. Reporting failures are
+// logged and never affect the batch.
+func (e *Engineer) reportToGitHub(ctx context.Context, result *BatchResult, target string) {
+	cfg := e.config.GitHub
+	if cfg == nil || result == nil {
+		return
+	}
+	report := func(mrs []*MRInfo, outcome func(*MRInfo) pullRequestOutcome) {
+		for _, mr := range mrs {
+			if mr.PullRequest == nil {
+				continue
+			}
+			if err := cfg.report(ctx, mr.PullRequest, outcome(mr)); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[GitHub] Warning: reporting on PR #%d: %v\n", mr.PullRequest.Number, err)
+			}
+		}
+	}
+	report(result.Merged, func(*MRInfo) pullRequestOutcome {
+		desc := "Merged into " + target
+		comment := fmt.Sprintf("Merged into `%s` by the merge queue", target)
+		if result.MergeCommit != "" {
+			desc += " as " + shortSHA(result.MergeCommit)
+			comment += " as " + result.MergeCommit
+		}
+		return pullRequestOutcome{state: "success", description: desc, comment: comment + ".", close: true}
+	})
+	report(result.Conflicts, func(*MRInfo) pullRequestOutcome {
+		return pullRequestOutcome{
+			state:       "failure",
+			description: "Conflicts with " + target,
+			comment:     fmt.Sprintf("The merge queue could not apply this PR to `%s`: it conflicts. Rebase it and it will be picked up again.", target),
+		}
+	})
+	report(result.Culprits, func(*MRInfo) pullRequestOutcome {
+		return pullRequestOutcome{
+			state:       "failure",
+			description: "Failed merge queue gates",
+			comment:     fmt.Sprintf("The merge queue removed this PR from batch %s: the quality gates failed with it applied to `%s`.", result.BatchID, target),
+		}
+	})
+	report(result.Deferred, func(*MRInfo) pullRequestOutcome {
+		return pullRequestOutcome{state: "pending", description: "Deferred to a later batch"}
+	})
+}
+
+func (c *GitHubConfig) report(ctx context.Context, pr *PullRequestRef, outcome pullRequestOutcome) error {
+	if pr.HeadSHA != "" {
+		status := map[string]string{
+			"state":       outcome.state,
+			"description": outcome.description,
+			"context":     c.statusContext(),
+		}
+		if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", c.Repo, pr.HeadSHA), status, nil); err != nil {
+			return err
+		}
+	}
+	if outcome.comment != "" && !c.NoComments {
+		comment := map[string]string{"body": outcome.comment}
+		if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", c.Repo, pr.Number), comment, nil); err != nil {
+			return err
+		}
+	}
+	if outcome.close {
+		state := map[string]string{"state": "closed"}
+		if err := c.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/pulls/%d", c.Repo, pr.Number), state, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestEngineer_LoadConfig_GitHub(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"github": {"repo": "acme/widgets", "min_approvals": 2}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	gh := e.config.GitHub
+	if gh == nil || gh.Repo != "acme/widgets" || gh.MinApprovals != 2 {
+		t.Fatalf("github config = %+v", gh)
+	}
+	if gh.label() != defaultGitHubLabel || gh.apiURL() != defaultGitHubAPIURL || gh.statusContext() != defaultGitHubStatusContext {
+		t.Errorf("defaults not applied: %+v", gh)
+	}
+
+	for _, bad := range []string{
+		`{"repo": "widgets"}`,
+		`{"repo": "acme/widgets/extra"}`,
+		`{"repo": "acme/widgets", "min_approvals": -1}`,
+		`{"repo": "acme/widgets", "api_url": "not a url"}`,
+	} {
+		data := []byte(`{"merge_queue": {"github": ` + bad + `}}`)
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+			t.Errorf("LoadConfig accepted github %s", bad)
+		}
+	}
+}
+
+// fakeGitHub serves the pull request, review, status, comment and update
+// endpoints the queue uses, recording every write.
+type fakeGitHub struct {
+	mu     sync.Mutex
+	writes []string // "METHOD path body"
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer tok" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/pulls":
+		_, _ = io.WriteString(w, `[
+			{"number": 1, "title": "Add gears", "html_url": "https://gh/acme/widgets/pull/1",
+			 "user": {"login": "alice"}, "labels": [{"name": "merge-queue"}],
+			 "head": {"sha": "aaa111"}, "base": {"ref": "main"}},
+			{"number": 2, "title": "Draft", "draft": true, "user": {"login": "bob"},
+			 "labels": [{"name": "merge-queue"}], "head": {"sha": "bbb"}, "base": {"ref": "main"}},
+			{"number": 3, "title": "Unlabeled", "user": {"login": "bob"},
+			 "head": {"sha": "ccc"}, "base": {"ref": "main"}},
+			{"number": 4, "title": "Changes requested", "user": {"login": "carol"},
+			 "labels": [{"name": "merge-queue"}], "head": {"sha": "ddd"}, "base": {"ref": "main"}}
+		]`)
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/pulls/1/reviews":
+		_, _ = io.WriteString(w, `[
+			{"state": "APPROVED", "user": {"login": "rev1"}},
+			{"state": "COMMENTED", "user": {"login": "rev1"}},
+			{"state": "APPROVED", "user": {"login": "rev2"}}
+		]`)
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/widgets/pulls/4/reviews":
+		_, _ = io.WriteString(w, `[
+			{"state": "APPROVED", "user": {"login": "rev1"}},
+			{"state": "CHANGES_REQUESTED", "user": {"login": "rev1"}}
+		]`)
+	case r.Method == http.MethodGet:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.writes = append(f.writes, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{}`)
+	}
+}
+
+func TestGitHub_IngestAndReport(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "alice-gears", "gears.go", "package gears\n")
+	run(t, workDir, "git", "push", "origin", "alice-gears:refs/pull/1/head")
+
+	fake := &fakeGitHub{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	t.Setenv("GH_QUEUE_TOKEN", "tok")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.GitHub = &GitHubConfig{Repo: "acme/widgets", MinApprovals: 1, TokenEnv: "GH_QUEUE_TOKEN", APIURL: srv.URL}
+
+	mrs, err := e.IngestPullRequests(context.Background())
+	if err != nil {
+		t.Fatalf("IngestPullRequests: %v", err)
+	}
+	if len(mrs) != 1 {
+		t.Fatalf("ingested %d PRs, want only #1 (#2 draft, #3 unlabeled, #4 approval withdrawn)", len(mrs))
+	}
+	mr := mrs[0]
+	if mr.ID != "gh-pr-1" || mr.Branch != "pr/1" || mr.Target != "main" || mr.Worker != "alice" ||
+		mr.PullRequest.Approvals != 2 || mr.PullRequest.HeadSHA != "aaa111" {
+		t.Errorf("ingested MR = %+v, PR %+v", mr, mr.PullRequest)
+	}
+	if exists, err := g.BranchExists("pr/1"); err != nil || !exists {
+		t.Errorf("PR head not fetched into pr/1 (exists=%v, err=%v)", exists, err)
+	}
+
+	culprit := makeMR("gh-pr-4", "pr/4", "main")
+	culprit.PullRequest = &PullRequestRef{Number: 4, HeadSHA: "ddd"}
+	e.reportToGitHub(context.Background(), &BatchResult{
+		BatchID:     "batch-7",
+		Merged:      []*MRInfo{mr, makeMR("gt-local", "polecat/x", "main")},
+		Culprits:    []*MRInfo{culprit},
+		MergeCommit: "0123456789abcdef",
+	}, "main")
+
+	got := strings.Join(fake.writes, "\n")
+	for _, want := range []string{
+		`POST /repos/acme/widgets/statuses/aaa111 {"context":"gastown/merge-queue","description":"Merged into main as 01234567","state":"success"}`,
+		`POST /repos/acme/widgets/issues/1/comments`,
+		`PATCH /repos/acme/widgets/pulls/1 {"state":"closed"}`,
+		`POST /repos/acme/widgets/statuses/ddd {"context":"gastown/merge-queue","description":"Failed merge queue gates","state":"failure"}`,
+		`POST /repos/acme/widgets/issues/4/comments`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing write %q in:\n%s", want, got)
+		}
+	}
+	if len(fake.writes) != 5 {
+		t.Errorf("got %d writes, want 5 (local MRs aren't reported, culprits aren't closed):\n%s", len(fake.writes), got)
+	}
+	if out := testOutput(e).String(); strings.Contains(out, "Warning") {
+		t.Errorf("unexpected warnings:\n%s", out)
+	}
+}
+
+func TestGitHub_ReportStatusOnly(t *testing.T) {
+	fake := &fakeGitHub{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	t.Setenv("GITHUB_TOKEN", "tok")
+
+	cfg := &GitHubConfig{Repo: "acme/widgets", APIURL: srv.URL, NoComments: true}
+	if err := cfg.report(context.Background(), &PullRequestRef{Number: 9, HeadSHA: "fff"}, pullRequestOutcome{
+		state: "failure", description: "Conflicts with main", comment: "conflicts",
+	}); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if len(fake.writes) != 1 || !strings.HasPrefix(fake.writes[0], "POST /repos/acme/widgets/statuses/fff ") {
+		t.Errorf("writes = %q, want only the status", fake.writes)
+	}
+	var status map[string]string
+	if err := json.Unmarshal([]byte(strings.SplitN(fake.writes[0], " ", 3)[2]), &status); err != nil || status["state"] != "failure" {
+		t.Errorf("status body = %v (%v)", status, err)
+	}
+}
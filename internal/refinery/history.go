@@ -0,0 +1,288 @@
+package refinery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// BatchRecord is one processed batch in the rig's batch log.
+type BatchRecord struct {
+	BatchID    string    `json:"batch_id"`
+	Target     string    `json:"target"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+
+	// Members are the batch's MRs in dependency order; Stacked is the order
+	// they were stacked in, without the MRs that conflicted.
+	Members []string `json:"members"`
+	Stacked []string `json:"stacked,omitempty"`
+
+	Merged      []string `json:"merged,omitempty"`
+	Culprits    []string `json:"culprits,omitempty"`
+	Conflicts   []string `json:"conflicts,omitempty"`
+	Deferred    []string `json:"deferred,omitempty"`
+	MergeCommit string   `json:"merge_commit,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	Cancelled   bool     `json:"cancelled,omitempty"`
+
+	// GateSet is the version of the gate set the batch ran, whose snapshot
+	// GateSet returns.
+	GateSet string `json:"gate_set,omitempty"`
+
+	// GateRuns are the gate sets run for the batch, in the order they
+	// finished: the stack tip, retries, bisection probes.
+	GateRuns []*GateRunRecord `json:"gate_runs,omitempty"`
+
+	// Energy is what the batch used, start to finish, when metered (see
+	// EnergyConfig).
+	Energy *EnergyUsage `json:"energy,omitempty"`
+}
+
+// GateRunRecord is one run of a target's gate set.
+type GateRunRecord struct {
+	Stack   []string             `json:"stack,omitempty"` // MRs in the tree; empty for a single MR
+	Success bool                 `json:"success"`
+	Gates   []*GateOutcomeRecord `json:"gates,omitempty"` // Empty when the legacy test command ran
+	Error   string               `json:"error,omitempty"`
+}
+
+// GateOutcomeRecord is one gate's result within a GateRunRecord.
+type GateOutcomeRecord struct {
+	Name        string `json:"name"`
+	Success     bool   `json:"success"`
+	TimedOut    bool   `json:"timed_out,omitempty"`
+	Flaky       bool   `json:"flaky,omitempty"`
+	Quarantined bool   `json:"quarantined,omitempty"`
+	Baseline    bool   `json:"baseline,omitempty"`
+	SkippedBy   string `json:"skipped_by,omitempty"` // Skip token that bypassed the gate
+	Unaffected  bool   `json:"unaffected,omitempty"` // Skipped: the tree changes none of the gate's paths
+	ElapsedMs   int64  `json:"elapsed_ms"`
+	Signature   string `json:"signature,omitempty"` // Identifies the failure across batches (see FailurePattern)
+
+	Energy *EnergyUsage `json:"energy,omitempty"` // When metered
+}
+
+// HistoryQuery filters Engineer.History. Zero fields match everything.
+type HistoryQuery struct {
+	BatchID string    // The batch with this ID
+	Target  string    // Batches targeting this branch
+	MR      string    // Batches that included this MR
+	Since   time.Time // Batches finished at or after this time
+	Limit   int       // Keep only the most recent Limit matches
+}
+
+func (q HistoryQuery) matches(r *BatchRecord) bool {
+	if q.BatchID != "" && r.BatchID != q.BatchID {
+		return false
+	}
+	if q.Target != "" && r.Target != q.Target {
+		return false
+	}
+	if !q.Since.IsZero() && r.FinishedAt.Before(q.Since) {
+		return false
+	}
+	if q.MR != "" {
+		for _, id := range r.Members {
+			if id == q.MR {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// BatchLogPath returns the batch log of the rig at rigPath. Unlike the
+// progress log it is never rotated: it is the rig's merge history.
+func BatchLogPath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "batches.jsonl")
+}
+
+// History returns the batches in the rig's batch log that match q, oldest
+// first. A partially written last line is skipped.
+func (e *Engineer) History(q HistoryQuery) ([]*BatchRecord, error) {
+	f, err := os.Open(BatchLogPath(e.rig.Path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*BatchRecord
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		var rec BatchRecord
+		if json.Unmarshal(line, &rec) != nil || !q.matches(&rec) {
+			continue
+		}
+		records = append(records, &rec)
+	}
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[len(records)-q.Limit:]
+	}
+	return records, nil
+}
+
+// batchRecorder collects what a batch does while it is processed. It
+// travels in the batch's context so gate runs anywhere below ProcessBatch,
+// including the parallel ones of bisection and merge trains, reach it.
+type batchRecorder struct {
+	mu       sync.Mutex
+	stacked  []string
+	gateSet  string
+	gateRuns []*GateRunRecord
+	gateLogs []*GateLog
+	failures map[string]*gateFailure // Signature → first failure with it
+
+	energyStart *EnergyReading // Meter reading the batch started at (nil = not metered)
+}
+
+type batchRecorderKey struct{}
+type gateStackKey struct{}
+type gateMRsKey struct{} // The MRs behind gateStackKey, for progress events
+
+func withBatchRecorder(ctx context.Context, rec *batchRecorder) context.Context {
+	return context.WithValue(ctx, batchRecorderKey{}, rec)
+}
+
+type bisectingKey struct{}
+
+// withBisecting marks gate runs under ctx as bisection steps.
+func withBisecting(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bisectingKey{}, true)
+}
+
+// withGateStack tags gate runs under ctx with the MRs in the tree.
+func withGateStack(ctx context.Context, stack []*MRInfo) context.Context {
+	ctx = context.WithValue(ctx, gateMRsKey{}, stack)
+	return context.WithValue(ctx, gateStackKey{}, mrIDs(stack))
+}
+
+// batchRecorderFrom returns the recorder in ctx, or nil.
+func batchRecorderFrom(ctx context.Context) *batchRecorder {
+	if ctx == nil {
+		return nil
+	}
+	rec, _ := ctx.Value(batchRecorderKey{}).(*batchRecorder)
+	return rec
+}
+
+// recordStacked notes the batch's stacking order.
+func recordStacked(ctx context.Context, stacked []*MRInfo) {
+	if rec := batchRecorderFrom(ctx); rec != nil {
+		rec.mu.Lock()
+		rec.stacked = mrIDs(stacked)
+		rec.mu.Unlock()
+	}
+}
+
+// recordGateRun notes a gate set run under ctx. gates holds the individual
+// gates' results, or is empty when the legacy test command ran.
+func recordGateRun(ctx context.Context, result ProcessResult, gates []GateResult) {
+	rec := batchRecorderFrom(ctx)
+	if rec == nil {
+		return
+	}
+	run := &GateRunRecord{Success: result.Success, Error: result.Error}
+	run.Stack, _ = ctx.Value(gateStackKey{}).([]string)
+	var failures []*gateFailure
+	for _, g := range gates {
+		outcome := &GateOutcomeRecord{
+			Name:        g.Name,
+			Success:     g.Success,
+			TimedOut:    g.TimedOut,
+			Flaky:       g.Flaky,
+			Quarantined: g.Quarantined,
+			Baseline:    g.Baseline,
+			SkippedBy:   g.SkippedBy,
+			Unaffected:  g.Unaffected,
+			ElapsedMs:   g.Elapsed.Milliseconds(),
+			Energy:      g.Energy,
+		}
+		if !g.Success {
+			f := &gateFailure{gate: g.Name, message: normalizeFailure(g.Error), sample: g.Error, stack: run.Stack}
+			f.signature = failureSignature(f.gate, f.message)
+			outcome.Signature = f.signature
+			failures = append(failures, f)
+		}
+		run.Gates = append(run.Gates, outcome)
+	}
+	rec.mu.Lock()
+	rec.gateRuns = append(rec.gateRuns, run)
+	for _, f := range failures {
+		if rec.failures == nil {
+			rec.failures = make(map[string]*gateFailure)
+		}
+		if rec.failures[f.signature] == nil {
+			rec.failures[f.signature] = f
+		}
+	}
+	rec.mu.Unlock()
+}
+
+// recordBatch appends a processed batch to the batch log. Failures are
+// logged and otherwise ignored, like progress.
+func (e *Engineer) recordBatch(batch []*MRInfo, target string, started time.Time, rec *batchRecorder, result *BatchResult) {
+	if e.rig == nil || (len(batch) == 0 && len(result.Deferred) == 0) {
+		return
+	}
+	finished := time.Now().UTC()
+	rec.mu.Lock()
+	r := &BatchRecord{
+		BatchID:     result.BatchID,
+		Target:      target,
+		StartedAt:   started.UTC(),
+		FinishedAt:  finished,
+		DurationMs:  finished.Sub(started).Milliseconds(),
+		Members:     mrIDs(batch),
+		Stacked:     rec.stacked,
+		Merged:      mrIDs(result.Merged),
+		Culprits:    mrIDs(result.Culprits),
+		Conflicts:   mrIDs(result.Conflicts),
+		Deferred:    mrIDs(result.Deferred),
+		MergeCommit: result.MergeCommit,
+		Cancelled:   result.Cancelled,
+		GateSet:     rec.gateSet,
+		GateRuns:    rec.gateRuns,
+	}
+	rec.mu.Unlock()
+	r.Energy = e.batchEnergy(rec)
+	if result.Error != nil {
+		r.Error = result.Error.Error()
+	}
+	if err := appendBatchRecord(BatchLogPath(e.rig.Path), r); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Batch] Warning: record batch history: %v\n", err)
+	}
+}
+
+func appendBatchRecord(path string, r *BatchRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,117 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProcessBatch_RecordsHistory(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "this causes test failure\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+	}
+	result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5})
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+
+	records, err := e.History(HistoryQuery{})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("recorded %d batches, want 1", len(records))
+	}
+	r := records[0]
+	if r.BatchID != result.BatchID || r.Target != "main" || r.MergeCommit != result.MergeCommit {
+		t.Errorf("record = %+v, want batch %s on main landing %s", r, result.BatchID, result.MergeCommit)
+	}
+	if len(r.Members) != 3 || len(r.Stacked) != 3 || r.Stacked[1] != "mr-b" {
+		t.Errorf("members = %v, stacked = %v, want all three stacked in order", r.Members, r.Stacked)
+	}
+	if len(r.Culprits) != 1 || r.Culprits[0] != "mr-b" || len(r.Merged) != 2 {
+		t.Errorf("culprits = %v, merged = %v, want mr-b blamed and two merged", r.Culprits, r.Merged)
+	}
+	if r.FinishedAt.Before(r.StartedAt) {
+		t.Errorf("finished %v before started %v", r.FinishedAt, r.StartedAt)
+	}
+
+	// The stack tip fails, bisection probes, and the good subset passes.
+	if len(r.GateRuns) < 3 {
+		t.Fatalf("recorded %d gate runs, want the tip, probes and verification", len(r.GateRuns))
+	}
+	tip := r.GateRuns[0]
+	if tip.Success || len(tip.Stack) != 3 || len(tip.Gates) != 1 || tip.Gates[0].Name != "check" || tip.Gates[0].Success {
+		t.Errorf("tip run = %+v, want failed check on all three MRs", tip)
+	}
+	if last := r.GateRuns[len(r.GateRuns)-1]; !last.Success || len(last.Stack) != 2 {
+		t.Errorf("last run = %+v, want passing run on the good pair", last)
+	}
+}
+
+func TestHistory_Query(t *testing.T) {
+	e := newTestEngineer(t, t.TempDir(), nil)
+	path := BatchLogPath(e.rig.Path)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, r := range []*BatchRecord{
+		{BatchID: "b1", Target: "main", Members: []string{"mr-1", "mr-2"}, FinishedAt: base},
+		{BatchID: "b2", Target: "release", Members: []string{"mr-2"}, FinishedAt: base.Add(time.Hour)},
+		{BatchID: "b3", Target: "main", Members: []string{"mr-3"}, FinishedAt: base.Add(2 * time.Hour)},
+	} {
+		if err := appendBatchRecord(path, r); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+	// A torn last line is skipped.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"batch_id":"b4"`)
+	_ = f.Close()
+
+	tests := []struct {
+		name string
+		q    HistoryQuery
+		want []string
+	}{
+		{"all", HistoryQuery{}, []string{"b1", "b2", "b3"}},
+		{"target", HistoryQuery{Target: "main"}, []string{"b1", "b3"}},
+		{"mr", HistoryQuery{MR: "mr-2"}, []string{"b1", "b2"}},
+		{"since", HistoryQuery{Since: base.Add(time.Hour)}, []string{"b2", "b3"}},
+		{"limit keeps most recent", HistoryQuery{Limit: 2}, []string{"b2", "b3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := e.History(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range records {
+				got = append(got, r.BatchID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
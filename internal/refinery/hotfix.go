@@ -0,0 +1,382 @@
+package refinery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Hotfix member states (see HotfixMember.Status).
+const (
+	// HotfixPending is a member queued to land.
+	HotfixPending = "pending"
+	// HotfixLanded is a member that has landed on its branch.
+	HotfixLanded = "landed"
+	// HotfixFailed is a backport that couldn't be prepared, such as one
+	// whose commits conflict with the release branch. It needs a human.
+	HotfixFailed = "failed"
+)
+
+// HotfixConfig fans hotfix MRs out to the release branches they must also
+// land on. When an MR carrying Label is first admitted, the refinery
+// cherry-picks its commits onto each supported release branch, enqueues
+// one backport MR per branch, each depending on the hotfix MR, and tracks
+// the lot as a HotfixGroup until every member has landed.
+type HotfixConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Label marks an MR as a hotfix. Default "hotfix".
+	Label string `json:"label,omitempty"`
+
+	// Releases is the support matrix: the release branches hotfixes are
+	// backported to.
+	Releases []*SupportedRelease `json:"releases"`
+}
+
+// SupportedRelease is one row of the hotfix support matrix.
+type SupportedRelease struct {
+	// Branch is the release branch, e.g. "release/2.4".
+	Branch string `json:"branch"`
+
+	// Until is the last day (YYYY-MM-DD, UTC) the release gets hotfixes.
+	// Empty means it is supported until removed from the matrix.
+	Until string `json:"until,omitempty"`
+
+	// Labels, when set, limit the release to hotfixes carrying one of
+	// them, e.g. "security" for a release in security-only support.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// validateHotfix checks an enabled hotfix config's support matrix.
+func validateHotfix(cfg *HotfixConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if len(cfg.Releases) == 0 {
+		return fmt.Errorf("hotfix: releases is required")
+	}
+	seen := make(map[string]bool)
+	for _, r := range cfg.Releases {
+		if r == nil || r.Branch == "" {
+			return fmt.Errorf("hotfix: release without a branch")
+		}
+		if seen[r.Branch] {
+			return fmt.Errorf("hotfix: release %s listed twice", r.Branch)
+		}
+		seen[r.Branch] = true
+		if r.Until != "" {
+			if _, err := time.Parse("2006-01-02", r.Until); err != nil {
+				return fmt.Errorf("hotfix: release %s: invalid until %q: want YYYY-MM-DD", r.Branch, r.Until)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *HotfixConfig) label() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	return "hotfix"
+}
+
+// targets returns the release branches mr, which lands on target, must be
+// backported to on day now: the supported releases whose labels, if any,
+// mr carries, other than target itself.
+func (c *HotfixConfig) targets(mr *MRInfo, target string, now time.Time) []string {
+	today := now.UTC().Format("2006-01-02")
+	var targets []string
+	for _, r := range c.Releases {
+		if r.Branch == target || (r.Until != "" && r.Until < today) {
+			continue
+		}
+		if len(r.Labels) > 0 && !hasAnyLabel(mr.Labels, r.Labels) {
+			continue
+		}
+		targets = append(targets, r.Branch)
+	}
+	return targets
+}
+
+func hasAnyLabel(labels, want []string) bool {
+	for _, l := range labels {
+		for _, w := range want {
+			if l == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HotfixGroup is a hotfix MR and its backports, tracked as one unit until
+// all of them land.
+type HotfixGroup struct {
+	MR          string          `json:"mr"`
+	SourceIssue string          `json:"source_issue,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Members     []*HotfixMember `json:"members"`             // The hotfix MR first, then one per release branch
+	LandedAt    *time.Time      `json:"landed_at,omitempty"` // When the last member landed
+}
+
+// HotfixMember is the landing of a hotfix on one branch.
+type HotfixMember struct {
+	Target string `json:"target"`
+	MR     string `json:"mr,omitempty"` // Empty when the backport couldn't be prepared
+	Branch string `json:"branch,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Pending returns the members not yet landed.
+func (g *HotfixGroup) Pending() []*HotfixMember {
+	var pending []*HotfixMember
+	for _, m := range g.Members {
+		if m.Status != HotfixLanded {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// hotfixGroupsPath records hotfix groups by hotfix MR ID.
+func (e *Engineer) hotfixGroupsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "hotfix-groups.json")
+}
+
+// HotfixGroups returns the recorded hotfix groups, newest first.
+func (e *Engineer) HotfixGroups() ([]*HotfixGroup, error) {
+	groups, err := e.loadHotfixGroups()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*HotfixGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (e *Engineer) loadHotfixGroups() (map[string]*HotfixGroup, error) {
+	groups := make(map[string]*HotfixGroup)
+	data, err := os.ReadFile(e.hotfixGroupsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return groups, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading hotfix groups: %w", err)
+	}
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("parsing hotfix groups: %w", err)
+	}
+	return groups, nil
+}
+
+// enqueueHotfixes fans each hotfix MR in ready out to its release branches
+// the first time it is seen. It holds nothing: the hotfix MR goes on to the
+// other policies, and its backports wait on it, so no release gets a fix
+// before the default branch does. A backport whose commits don't apply to
+// its release is recorded as failed for a human to backport by hand.
+func (e *Engineer) enqueueHotfixes(ready []*MRInfo) {
+	cfg := e.config.Hotfix
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+	var hotfixes []*MRInfo
+	for _, mr := range ready {
+		if hasAnyLabel(mr.Labels, []string{cfg.label()}) {
+			hotfixes = append(hotfixes, mr)
+		}
+	}
+	if len(hotfixes) == 0 {
+		return
+	}
+
+	unlock := e.lockState()
+	defer unlock()
+	groups, err := e.loadHotfixGroups()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Hotfix] Warning: %v (not backporting)\n", err)
+		return
+	}
+	changed := false
+	for _, mr := range hotfixes {
+		if groups[mr.ID] != nil {
+			continue
+		}
+		target := mr.Target
+		if target == "" {
+			target = e.rig.DefaultBranch()
+		}
+		groups[mr.ID] = e.backportHotfix(mr, target, cfg.targets(mr, target, time.Now()))
+		changed = true
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.hotfixGroupsPath(), groups); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Hotfix] Warning: saving hotfix groups: %v\n", err)
+		}
+	}
+}
+
+// backportHotfix enqueues a backport of mr, which lands on target, to each
+// of releases and returns the group tracking them.
+func (e *Engineer) backportHotfix(mr *MRInfo, target string, releases []string) *HotfixGroup {
+	group := &HotfixGroup{
+		MR:          mr.ID,
+		SourceIssue: mr.SourceIssue,
+		CreatedAt:   time.Now().UTC(),
+		Members:     []*HotfixMember{{Target: target, MR: mr.ID, Branch: mr.Branch, Status: HotfixPending}},
+	}
+	if len(releases) == 0 {
+		_, _ = fmt.Fprintf(e.output, "[Hotfix] MR %s: no supported release needs it\n", mr.ID)
+		return group
+	}
+	if err := e.git.Fetch("origin"); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Hotfix] Warning: fetching origin: %v\n", err)
+	}
+	for _, release := range releases {
+		member := &HotfixMember{Target: release, Status: HotfixPending}
+		branch, err := e.prepareBackport(mr, target, release)
+		if err == nil {
+			member.Branch = branch
+			member.MR, err = e.createMR(mr, branch, release)
+		}
+		if err != nil {
+			member.Status = HotfixFailed
+			member.Error = err.Error()
+			_, _ = fmt.Fprintf(e.output, "[Hotfix] MR %s: backport to %s failed: %v\n", mr.ID, release, err)
+		} else {
+			_, _ = fmt.Fprintf(e.output, "[Hotfix] MR %s: backport to %s queued as %s (after %s)\n", mr.ID, release, member.MR, mr.ID)
+		}
+		group.Members = append(group.Members, member)
+	}
+	return group
+}
+
+// backportBranch names the branch carrying an MR's backport to release.
+func backportBranch(mrID, release string) string {
+	return "backport/" + mrID + "/" + strings.ReplaceAll(release, "/", "-")
+}
+
+// prepareBackport cherry-picks the commits of mr's branch that aren't on
+// target onto release in a scratch worktree, and pushes the result as mr's
+// backport branch.
+func (e *Engineer) prepareBackport(mr *MRInfo, target, release string) (string, error) {
+	commits, err := e.git.BranchCommits("origin/"+target, e.branchHead(mr.Branch))
+	if err != nil {
+		return "", fmt.Errorf("listing commits of %s: %w", mr.Branch, err)
+	}
+	if len(commits) == 0 {
+		return "", fmt.Errorf("branch %s has no commits to backport", mr.Branch)
+	}
+
+	branch := backportBranch(mr.ID, release)
+	dir := filepath.Join(e.rig.Path, ".runtime", "backports", mr.ID+"-"+strings.ReplaceAll(release, "/", "-"))
+	e.removeConflictWorktree(dir)
+	_ = e.git.DeleteBranch(branch, true)
+	if err := e.git.WorktreeAddFromRef(dir, branch, "origin/"+release); err != nil {
+		return "", fmt.Errorf("creating worktree on %s: %w", release, err)
+	}
+	defer e.removeConflictWorktree(dir)
+
+	g := git.NewGit(dir)
+	if err := g.CherryPickRecorded(commits...); err != nil {
+		conflicts, _ := g.GetConflictingFiles()
+		_ = g.AbortCherryPick()
+		if len(conflicts) > 0 {
+			return "", fmt.Errorf("conflicts with %s: %s", release, strings.Join(conflicts, ", "))
+		}
+		return "", err
+	}
+	if err := g.Push("origin", branch, true); err != nil {
+		return "", fmt.Errorf("pushing %s: %w", branch, err)
+	}
+	return branch, nil
+}
+
+// createBackportMR creates the MR bead landing branch, mr's backport, on
+// target. It copies mr's fields and depends on mr, so it lands after it.
+func (e *Engineer) createBackportMR(mr *MRInfo, branch, target string) (string, error) {
+	fields := &beads.MRFields{
+		Branch:        branch,
+		Target:        target,
+		SourceIssue:   mr.SourceIssue,
+		Worker:        mr.Worker,
+		Rig:           mr.Rig,
+		AgentBead:     mr.AgentBead,
+		QoS:           mr.QoS,
+		MergeStrategy: mr.MergeStrategy,
+	}
+	issue, err := e.beads.Create(beads.CreateOptions{
+		Title:       fmt.Sprintf("%s (backport to %s)", mr.Title, target),
+		Labels:      []string{"gt:merge-request"},
+		Priority:    mr.Priority,
+		Description: fmt.Sprintf("%s\n\nBackport of hotfix %s to %s.", beads.FormatMRFields(fields), mr.ID, target),
+		Actor:       e.rig.Name + "/refinery",
+		Ephemeral:   true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating MR: %w", err)
+	}
+	if err := e.beads.AddDependency(issue.ID, mr.ID); err != nil {
+		return issue.ID, fmt.Errorf("ordering %s after %s: %w", issue.ID, mr.ID, err)
+	}
+	return issue.ID, nil
+}
+
+// recordHotfixLandings marks the hotfix group members in result.Merged as
+// landed, and announces groups whose last member just landed. Callers hold
+// the state lock.
+func (e *Engineer) recordHotfixLandings(result *BatchResult) {
+	if e.config.Hotfix == nil || result == nil || len(result.Merged) == 0 {
+		return
+	}
+	groups, err := e.loadHotfixGroups()
+	if err != nil || len(groups) == 0 {
+		return
+	}
+	merged := make(map[string]bool)
+	for _, mr := range result.Merged {
+		merged[mr.ID] = true
+	}
+	changed := false
+	for _, g := range groups {
+		if g.LandedAt != nil {
+			continue
+		}
+		landed := false
+		for _, m := range g.Members {
+			if m.Status == HotfixPending && merged[m.MR] {
+				m.Status = HotfixLanded
+				landed = true
+				if pending := g.Pending(); len(pending) > 0 {
+					_, _ = fmt.Fprintf(e.output, "[Hotfix] %s landed on %s; %d of %d branches to go\n", g.MR, m.Target, len(pending), len(g.Members))
+				}
+			}
+		}
+		if !landed {
+			continue
+		}
+		changed = true
+		if len(g.Pending()) == 0 {
+			now := time.Now().UTC()
+			g.LandedAt = &now
+			_, _ = fmt.Fprintf(e.output, "[Hotfix] %s landed on all %d branches\n", g.MR, len(g.Members))
+		}
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.hotfixGroupsPath(), groups); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Hotfix] Warning: saving hotfix groups: %v\n", err)
+		}
+	}
+}
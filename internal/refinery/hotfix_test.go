@@ -0,0 +1,117 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHotfixConfig_Targets(t *testing.T) {
+	cfg := &HotfixConfig{Enabled: true, Releases: []*SupportedRelease{
+		{Branch: "release/1.0", Until: "2026-03-31"},
+		{Branch: "release/1.1", Labels: []string{"security"}},
+		{Branch: "release/1.2"},
+		{Branch: "main"},
+	}}
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	mr := &MRInfo{ID: "mr-1", Labels: []string{"hotfix"}}
+	if got := strings.Join(cfg.targets(mr, "main", now), " "); got != "release/1.2" {
+		t.Errorf("targets = %q, want release/1.2", got)
+	}
+	mr.Labels = append(mr.Labels, "security")
+	if got := strings.Join(cfg.targets(mr, "main", now.AddDate(0, 0, -1)), " "); got != "release/1.0 release/1.1 release/1.2" {
+		t.Errorf("security targets on the last supported day = %q", got)
+	}
+}
+
+func TestAdmitMRs_BackportsHotfix(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	writeFile(t, workDir, "app.txt", "v1\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "app v1")
+	run(t, workDir, "git", "push", "origin", "main")
+	run(t, workDir, "git", "push", "origin", "main:release/1")
+	// release/2 has moved on, so the fix won't apply there.
+	run(t, workDir, "git", "checkout", "-b", "release/2")
+	writeFile(t, workDir, "app.txt", "v2\n")
+	run(t, workDir, "git", "commit", "-am", "app v2")
+	run(t, workDir, "git", "push", "origin", "release/2")
+	run(t, workDir, "git", "checkout", "main")
+	createFeatureBranch(t, workDir, "polecat/fix", "app.txt", "v1 fixed\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Hotfix = &HotfixConfig{Enabled: true, Releases: []*SupportedRelease{{Branch: "release/1"}, {Branch: "release/2"}}}
+	var created []string
+	e.createMR = func(mr *MRInfo, branch, target string) (string, error) {
+		created = append(created, branch+"→"+target)
+		return fmt.Sprintf("mr-bp-%d", len(created)), nil
+	}
+
+	hotfix := makeMR("mr-1", "polecat/fix", "main")
+	hotfix.Labels = []string{"gt:merge-request", "hotfix"}
+	ready := []*MRInfo{hotfix, makeMR("mr-2", "polecat/other", "main")}
+	if admitted, held := e.AdmitMRs(ready); len(admitted) != 2 || len(held) != 0 {
+		t.Fatalf("admitted %v, held %v: fan-out must not hold", mrIDs(admitted), mrIDs(held))
+	}
+	if got := strings.Join(created, " "); got != "backport/mr-1/release-1→release/1" {
+		t.Errorf("created = %q, want only the release/1 backport", got)
+	}
+	msg := run(t, workDir, "git", "log", "-1", "--format=%B", "origin/backport/mr-1/release-1")
+	if !strings.Contains(msg, "cherry picked from commit") {
+		t.Errorf("backport commit message = %q", msg)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "backport to release/2 failed: conflicts with release/2: app.txt") {
+		t.Errorf("output missing release/2 conflict:\n%s", out)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(e.rig.Path, ".runtime", "backports")); len(entries) != 0 {
+		t.Errorf("scratch worktrees left behind: %v", entries)
+	}
+
+	// A second pass doesn't fan out again.
+	e.AdmitMRs(ready)
+	if len(created) != 1 {
+		t.Errorf("hotfix fanned out twice: %v", created)
+	}
+
+	groups, err := e.HotfixGroups()
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("groups = %v, %v", groups, err)
+	}
+	group := groups[0]
+	if len(group.Members) != 3 || group.Members[1].MR != "mr-bp-1" || group.Members[2].Status != HotfixFailed {
+		t.Fatalf("members = %+v %+v %+v", group.Members[0], group.Members[1], group.Members[2])
+	}
+
+	e.recordHotfixLandings(&BatchResult{Merged: []*MRInfo{hotfix}})
+	e.recordHotfixLandings(&BatchResult{Merged: []*MRInfo{{ID: "mr-bp-1"}}})
+	groups, _ = e.HotfixGroups()
+	if pending := groups[0].Pending(); len(pending) != 1 || pending[0].Target != "release/2" || groups[0].LandedAt != nil {
+		t.Errorf("after landing main and release/1: pending %+v, landed at %v", pending, groups[0].LandedAt)
+	}
+}
+
+func TestEngineer_LoadConfig_Hotfix(t *testing.T) {
+	for _, tc := range []struct {
+		json    string
+		wantErr bool
+	}{
+		{`{"enabled": true, "releases": [{"branch": "release/1", "until": "2026-12-31"}, {"branch": "release/2", "labels": ["security"]}]}`, false},
+		{`{"enabled": true, "releases": []}`, true},
+		{`{"enabled": true, "releases": [{"branch": "release/1"}, {"branch": "release/1"}]}`, true},
+		{`{"enabled": true, "releases": [{"branch": "release/1", "until": "next year"}]}`, true},
+	} {
+		dir := t.TempDir()
+		data := `{"type": "rig", "version": 1, "name": "test-rig", "merge_queue": {"hotfix": ` + tc.json + `}}`
+		if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		e := newTestEngineer(t, dir, nil)
+		if err := e.LoadConfig(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: LoadConfig error = %v, want error %v", tc.json, err, tc.wantErr)
+		}
+	}
+}
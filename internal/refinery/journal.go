@@ -0,0 +1,237 @@
+package refinery
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// journalRefPrefix is where the rollback journal keeps pre-batch ref values.
+// A ref such as refs/heads/main recorded for batch B is stored as
+// refs/gastown/journal/B/heads/main. Unlike the reflog, these refs are never
+// expired by gc and are visible to every worktree sharing the repo.
+const journalRefPrefix = "refs/gastown/journal/"
+
+// journalKeep is how many batches keep their rollback journal. Batch IDs
+// sort by start time, so the oldest journals are pruned first.
+const journalKeep = 50
+
+// batchSeq disambiguates batch IDs created by this process within the
+// same second.
+var batchSeq uint64
+
+// newBatchID returns a unique, ref-safe identifier for a batch on target.
+// Engineers for different targets may share a clone from separate
+// processes (see BindTarget), so the ID names the target and the
+// process as well as the start time.
+func newBatchID(target string) string {
+	seq := atomic.AddUint64(&batchSeq, 1)
+	return fmt.Sprintf("batch-%s-%s-%d-%d", time.Now().UTC().Format("20060102T150405Z"),
+		strings.ReplaceAll(target, "/", "-"), os.Getpid(), seq)
+}
+
+// JournalEntry is a ref value recorded before a batch modified it.
+type JournalEntry struct {
+	Ref string // Full ref name, e.g. "refs/remotes/origin/main"
+	SHA string // Value before the batch ran
+}
+
+// targetJournalRefs returns the refs a batch landing on target may rewrite:
+// the local target branch and its remote-tracking ref.
+func targetJournalRefs(target string) []string {
+	return []string{"refs/heads/" + target, "refs/remotes/origin/" + target}
+}
+
+// recordJournal saves the current value of each ref under the journal for
+// batchID. Refs that don't exist yet are skipped — there is nothing to
+// restore them to.
+func (e *Engineer) recordJournal(batchID string, refs ...string) error {
+	for _, ref := range refs {
+		exists, err := e.git.RefExists(ref)
+		if err != nil {
+			return fmt.Errorf("check %s: %w", ref, err)
+		}
+		if !exists {
+			continue
+		}
+		sha, err := e.git.Rev(ref)
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", ref, err)
+		}
+		if err := e.git.UpdateRef(journalRef(batchID, ref), sha); err != nil {
+			return fmt.Errorf("journal %s: %w", ref, err)
+		}
+	}
+	return nil
+}
+
+// Journal returns the refs recorded for batchID, sorted by ref name.
+func (e *Engineer) Journal(batchID string) ([]JournalEntry, error) {
+	prefix := journalRefPrefix + batchID + "/"
+	refs, err := e.git.ListRefs(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list journal %s: %w", batchID, err)
+	}
+	entries := make([]JournalEntry, 0, len(refs))
+	for name, sha := range refs {
+		entries = append(entries, JournalEntry{
+			Ref: "refs/" + strings.TrimPrefix(name, prefix),
+			SHA: sha,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Ref < entries[j].Ref })
+	return entries, nil
+}
+
+// ListJournals returns the IDs of all batches with a rollback journal.
+func (e *Engineer) ListJournals() ([]string, error) {
+	refs, err := e.git.ListRefs(journalRefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("list journals: %w", err)
+	}
+	seen := make(map[string]bool)
+	var ids []string
+	for name := range refs {
+		id, _, _ := strings.Cut(strings.TrimPrefix(name, journalRefPrefix), "/")
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// DropJournal deletes the journal entries for batchID that record target's
+// refs (see targetJournalRefs); target "" drops the whole journal.
+func (e *Engineer) DropJournal(batchID, target string) error {
+	entries, err := e.Journal(batchID)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if target != "" && !slices.Contains(targetJournalRefs(target), entry.Ref) {
+			continue
+		}
+		if err := e.git.DeleteRef(journalRef(batchID, entry.Ref)); err != nil {
+			return fmt.Errorf("drop journal entry %s: %w", entry.Ref, err)
+		}
+	}
+	return nil
+}
+
+// RollbackToJournal restores every ref recorded for batchID to its pre-batch
+// value. Origin is only rewound if, read fresh with ls-remote, its branch
+// still points at the tip the batch landed: if anything landed since, the
+// rollback fails instead of discarding it, and the push leases on that tip
+// in case something lands meanwhile. To undo a batch under later work, use
+// RevertBatch.
+//
+// This is a manual safety net for buggy merges; it rewrites published
+// history and is never invoked automatically.
+func (e *Engineer) RollbackToJournal(batchID string) error {
+	entries, err := e.Journal(batchID)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no journal for batch %s", batchID)
+	}
+	// Restore origin first: if it is refused, local refs stay untouched.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.HasPrefix(entries[i].Ref, "refs/remotes/") && !strings.HasPrefix(entries[j].Ref, "refs/remotes/")
+	})
+
+	current, _ := e.git.CurrentBranch()
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry.Ref, "refs/remotes/origin/"):
+			branch := strings.TrimPrefix(entry.Ref, "refs/remotes/origin/")
+			remoteSHA, err := e.git.RemoteBranchSHA("origin", branch)
+			if err != nil {
+				return fmt.Errorf("read origin/%s: %w", branch, err)
+			}
+			if remoteSHA == entry.SHA {
+				continue
+			}
+			tip := e.landedTip(batchID, branch)
+			if tip == "" {
+				return fmt.Errorf("batch %s has no recorded landing on %s to roll back", batchID, branch)
+			}
+			if remoteSHA != tip {
+				return fmt.Errorf("origin/%s is at %s, not batch %s's tip %s: rolling back would discard later commits", branch, shortSHA(remoteSHA), batchID, shortSHA(tip))
+			}
+			_, _ = fmt.Fprintf(e.output, "[Journal] Restoring origin/%s %s → %s\n", branch, shortSHA(remoteSHA), shortSHA(entry.SHA))
+			if err := e.git.PushForceWithLease("origin", entry.SHA, branch, tip); err != nil {
+				return fmt.Errorf("restore origin/%s: %w", branch, err)
+			}
+			if err := e.git.UpdateRef(entry.Ref, entry.SHA); err != nil {
+				return fmt.Errorf("restore %s: %w", entry.Ref, err)
+			}
+		case strings.HasPrefix(entry.Ref, "refs/heads/"):
+			branch := strings.TrimPrefix(entry.Ref, "refs/heads/")
+			_, _ = fmt.Fprintf(e.output, "[Journal] Restoring %s → %s\n", branch, shortSHA(entry.SHA))
+			if branch == current {
+				err = e.git.ResetHard(entry.SHA)
+			} else {
+				err = e.git.UpdateRef(entry.Ref, entry.SHA)
+			}
+			if err != nil {
+				return fmt.Errorf("restore %s: %w", entry.Ref, err)
+			}
+		default:
+			if err := e.git.UpdateRef(entry.Ref, entry.SHA); err != nil {
+				return fmt.Errorf("restore %s: %w", entry.Ref, err)
+			}
+		}
+	}
+	return nil
+}
+
+// landedTip returns the merge commit batchID landed on target, or "" if
+// its history has none.
+func (e *Engineer) landedTip(batchID, target string) string {
+	recs, err := e.History(HistoryQuery{BatchID: batchID, Target: target})
+	if err != nil || len(recs) == 0 {
+		return ""
+	}
+	return recs[len(recs)-1].MergeCommit
+}
+
+// finishJournal drops the rollback journal of a batch that landed nothing,
+// since there is nothing to undo, then prunes the journals of all but the
+// last journalKeep batches. Failures are logged and otherwise ignored.
+func (e *Engineer) finishJournal(result *BatchResult, target string) {
+	if result.BatchID == "" {
+		return
+	}
+	if result.MergeCommit == "" {
+		if err := e.DropJournal(result.BatchID, target); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Journal] Warning: %v\n", err)
+		}
+	}
+	ids, err := e.ListJournals()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Journal] Warning: %v\n", err)
+		return
+	}
+	for _, id := range ids[:max(0, len(ids)-journalKeep)] {
+		if err := e.DropJournal(id, ""); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Journal] Warning: %v\n", err)
+		}
+	}
+}
+
+// journalRef maps ref to its location in the journal for batchID.
+func journalRef(batchID, ref string) string {
+	return journalRefPrefix + batchID + "/" + strings.TrimPrefix(ref, "refs/")
+}
+
+// shortSHA abbreviates sha for log output.
+func shortSHA(sha string) string {
+	return sha[:min(8, len(sha))]
+}
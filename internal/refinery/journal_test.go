@@ -0,0 +1,201 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewBatchID_UniqueAndRefSafe(t *testing.T) {
+	a, b := newBatchID("release/1.x"), newBatchID("release/1.x")
+	if a == b {
+		t.Errorf("expected unique batch IDs, got %q twice", a)
+	}
+	// The ID is one journal ref component (see ListJournals).
+	if strings.ContainsAny(a, " ~^:?*[\\/") {
+		t.Errorf("batch ID %q is not ref-safe", a)
+	}
+	// Engineers for other targets, in this or another process, get other IDs.
+	if !strings.Contains(a, "-release-1.x-"+strconv.Itoa(os.Getpid())+"-") {
+		t.Errorf("batch ID %q doesn't name its target and process", a)
+	}
+}
+
+func TestProcessBatch_RecordsJournalAndRollsBack(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+	before := run(t, workDir, "git", "rev-parse", "main")
+
+	e := newTestEngineer(t, workDir, g)
+	batch := []*MRInfo{
+		makeMR("mr-a", "polecat/a", "main"),
+		makeMR("mr-b", "polecat/b", "main"),
+	}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if result.BatchID == "" {
+		t.Fatal("expected BatchID to be set")
+	}
+
+	entries, err := e.Journal(result.BatchID)
+	if err != nil {
+		t.Fatalf("Journal: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 journal entries, got %+v", entries)
+	}
+	for _, entry := range entries {
+		if entry.SHA != before {
+			t.Errorf("journal %s = %s, want pre-batch %s", entry.Ref, entry.SHA, before)
+		}
+	}
+
+	ids, err := e.ListJournals()
+	if err != nil || len(ids) != 1 || ids[0] != result.BatchID {
+		t.Errorf("ListJournals = %v, %v; want [%s]", ids, err, result.BatchID)
+	}
+
+	if err := e.RollbackToJournal(result.BatchID); err != nil {
+		t.Fatalf("RollbackToJournal: %v", err)
+	}
+	if got := run(t, workDir, "git", "rev-parse", "main"); got != before {
+		t.Errorf("local main = %s, want %s", got, before)
+	}
+	if got := run(t, workDir, "git", "ls-remote", "origin", "refs/heads/main"); !strings.HasPrefix(got, before) {
+		t.Errorf("origin main = %s, want %s", got, before)
+	}
+
+	if err := e.DropJournal(result.BatchID, "main"); err != nil {
+		t.Fatalf("DropJournal: %v", err)
+	}
+	if entries, _ := e.Journal(result.BatchID); len(entries) != 0 {
+		t.Errorf("expected journal dropped, got %+v", entries)
+	}
+}
+
+func TestRollbackToJournal_RefusesToClobberLaterPush(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	batch := []*MRInfo{
+		makeMR("mr-a", "polecat/a", "main"),
+		makeMR("mr-b", "polecat/b", "main"),
+	}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+
+	// Another pusher lands after the batch.
+	later := pushFromClone(t, workDir, "main", "later.txt")
+
+	if err := e.RollbackToJournal(result.BatchID); err == nil {
+		t.Fatal("expected rollback to be rejected after a later push")
+	}
+	if got := run(t, workDir, "git", "ls-remote", "origin", "refs/heads/main"); !strings.HasPrefix(got, later) {
+		t.Errorf("origin main = %s, want later push %s preserved", got, later)
+	}
+	if got := run(t, workDir, "git", "rev-parse", "main"); got != result.MergeCommit {
+		t.Errorf("local main = %s, want untouched %s", got, result.MergeCommit)
+	}
+}
+
+func TestRollbackToJournal_RefusesAfterFetchingLaterPush(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "polecat/a", "main")}, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+
+	// The refinery has seen the later push, so a lease on its tracking ref
+	// would not protect it.
+	later := pushFromClone(t, workDir, "main", "later.txt")
+	run(t, workDir, "git", "fetch", "origin")
+
+	err := e.RollbackToJournal(result.BatchID)
+	if err == nil || !strings.Contains(err.Error(), "discard later commits") {
+		t.Fatalf("RollbackToJournal: err = %v, want refusal", err)
+	}
+	if got := run(t, workDir, "git", "ls-remote", "origin", "refs/heads/main"); !strings.HasPrefix(got, later) {
+		t.Errorf("origin main = %s, want later push %s preserved", got, later)
+	}
+}
+
+func TestFinishJournal(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+
+	// A batch that landed nothing has nothing to roll back.
+	if err := e.recordJournal("batch-failed", "refs/heads/main"); err != nil {
+		t.Fatal(err)
+	}
+	e.finishJournal(&BatchResult{BatchID: "batch-failed"}, "main")
+	if entries, _ := e.Journal("batch-failed"); len(entries) != 0 {
+		t.Errorf("journal of unlanded batch kept: %+v", entries)
+	}
+
+	var last string
+	for i := 0; i < journalKeep+2; i++ {
+		last = fmt.Sprintf("batch-%03d", i)
+		if err := e.recordJournal(last, "refs/heads/main"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.finishJournal(&BatchResult{BatchID: last, MergeCommit: "abc123"}, "main")
+	ids, err := e.ListJournals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != journalKeep || ids[0] != "batch-002" || ids[len(ids)-1] != last {
+		t.Errorf("journals after pruning = %d (%s..%s), want the last %d", len(ids), ids[0], ids[len(ids)-1], journalKeep)
+	}
+}
+
+func TestDropJournal_KeepsOtherTargets(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	run(t, workDir, "git", "branch", "release")
+	e := newTestEngineer(t, workDir, g)
+
+	const id = "batch-shared"
+	refs := append(targetJournalRefs("main"), targetJournalRefs("release")...)
+	if err := e.recordJournal(id, refs...); err != nil {
+		t.Fatalf("recordJournal: %v", err)
+	}
+	if err := e.DropJournal(id, "main"); err != nil {
+		t.Fatalf("DropJournal: %v", err)
+	}
+	entries, err := e.Journal(id)
+	if err != nil {
+		t.Fatalf("Journal: %v", err)
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Ref, "/release") {
+			t.Errorf("DropJournal(main) left %s", entry.Ref)
+		}
+	}
+	if len(entries) == 0 {
+		t.Error("DropJournal(main) dropped release's journal")
+	}
+}
+
+func TestRollbackToJournal_UnknownBatch(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+
+	if err := e.RollbackToJournal("batch-missing"); err == nil {
+		t.Error("expected error for unknown batch")
+	}
+}
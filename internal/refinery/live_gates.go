@@ -0,0 +1,252 @@
+package refinery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ErrLiveGateNotFound is returned when removing a gate that isn't in the
+// gate set.
+var ErrLiveGateNotFound = errors.New("gate not found")
+
+// LiveGateChange is an operator's change to the rig's gates on a running
+// refinery: a gate added or retuned (Config set) or removed (Config nil).
+// Changes are kept in the rig's .runtime, on top of the configured gates,
+// until reset, and are picked up at the next batch boundary, so a batch in
+// flight, bisection included, keeps the gate set it started with. A
+// protected branch with gates of its own isn't affected.
+type LiveGateChange struct {
+	Config *GateConfig `json:"config,omitempty"`
+	By     string      `json:"by,omitempty"`
+	At     time.Time   `json:"at"`
+}
+
+func (e *Engineer) liveGatesPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "gate-overrides.json")
+}
+
+// LiveGateChanges returns the pending and applied live gate changes, by
+// gate name.
+func (e *Engineer) LiveGateChanges() (map[string]*LiveGateChange, error) {
+	data, err := os.ReadFile(e.liveGatesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading live gate changes: %w", err)
+	}
+	var changes map[string]*LiveGateChange
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, fmt.Errorf("parsing live gate changes: %w", err)
+	}
+	return changes, nil
+}
+
+// Gates returns the gate set the next batch will run: the configured gates
+// with the live changes applied.
+func (e *Engineer) Gates() (map[string]*GateConfig, error) {
+	changes, err := e.LiveGateChanges()
+	if err != nil {
+		return nil, err
+	}
+	return withLiveChanges(e.configuredGates(), changes), nil
+}
+
+// SetGate adds gate name, or replaces it, from the next batch on.
+func (e *Engineer) SetGate(name string, gate *GateConfig, by string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("a gate needs a name")
+	}
+	if strings.TrimSpace(gate.Cmd) == "" {
+		return fmt.Errorf("gate %q needs a command", name)
+	}
+	if gate.Timeout < 0 {
+		return fmt.Errorf("gate %q timeout must not be negative, got %v", name, gate.Timeout)
+	}
+	if err := validateGateRun(name, gate); err != nil {
+		return err
+	}
+	return e.changeLiveGates(func(changes map[string]*LiveGateChange) error {
+		changes[name] = &LiveGateChange{Config: gate, By: by, At: time.Now().UTC()}
+		return nil
+	})
+}
+
+// RemoveGate removes gate name from the next batch on.
+func (e *Engineer) RemoveGate(name, by string) error {
+	return e.changeLiveGates(func(changes map[string]*LiveGateChange) error {
+		if withLiveChanges(e.configuredGates(), changes)[name] == nil {
+			return fmt.Errorf("%w: %s", ErrLiveGateNotFound, name)
+		}
+		changes[name] = &LiveGateChange{By: by, At: time.Now().UTC()}
+		return nil
+	})
+}
+
+// ResetGates drops every live gate change, so the next batch runs the
+// configured gates again. It returns the gates whose changes were dropped.
+func (e *Engineer) ResetGates() ([]string, error) {
+	defer e.lockState()()
+	changes, err := e.LiveGateChanges()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(e.liveGatesPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("removing live gate changes: %w", err)
+	}
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (e *Engineer) changeLiveGates(change func(map[string]*LiveGateChange) error) error {
+	defer e.lockState()()
+	changes, err := e.LiveGateChanges()
+	if err != nil {
+		return err
+	}
+	if changes == nil {
+		changes = make(map[string]*LiveGateChange)
+	}
+	if err := change(changes); err != nil {
+		return err
+	}
+	if err := util.EnsureDirAndWriteJSON(e.liveGatesPath(), changes); err != nil {
+		return fmt.Errorf("saving live gate changes: %w", err)
+	}
+	return nil
+}
+
+// configuredGates returns the rig's gates as configured, without live
+// changes.
+func (e *Engineer) configuredGates() map[string]*GateConfig {
+	if e.liveGates != nil && sameMap(e.config.Gates, e.liveGates) {
+		return e.baseGates
+	}
+	return e.config.Gates
+}
+
+// withLiveChanges returns gates with changes applied, or gates itself when
+// there are none.
+func withLiveChanges(gates map[string]*GateConfig, changes map[string]*LiveGateChange) map[string]*GateConfig {
+	if len(changes) == 0 {
+		return gates
+	}
+	live := make(map[string]*GateConfig, len(gates)+len(changes))
+	for name, gate := range gates {
+		live[name] = gate
+	}
+	for name, c := range changes {
+		if c.Config == nil {
+			delete(live, name)
+		} else {
+			live[name] = c.Config
+		}
+	}
+	return live
+}
+
+// applyLiveGates installs the live gate changes in the configuration at a
+// batch boundary (see reloadConfig), logging the gates they changed. When
+// the changes can't be read the gate set is left as it is.
+func (e *Engineer) applyLiveGates() {
+	if e.rig == nil {
+		return
+	}
+	changes, err := e.LiveGateChanges()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Gates] Warning: %v; keeping the current gate set\n", err)
+		return
+	}
+	base := e.configuredGates()
+	if len(changes) == 0 {
+		if e.liveGates != nil {
+			e.config.Gates = base
+			e.baseGates, e.liveGates = nil, nil
+			_, _ = fmt.Fprintln(e.output, "[Gates] Live gate changes reset, running the configured gates")
+		}
+		return
+	}
+	live := withLiveChanges(base, changes)
+	if e.liveGates != nil && sameMap(e.config.Gates, e.liveGates) && gateSetVersion(live) == gateSetVersion(e.liveGates) {
+		return
+	}
+	var changed []string
+	for name := range changes {
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+	e.config.Gates = live
+	e.baseGates, e.liveGates = base, live
+	_, _ = fmt.Fprintf(e.output, "[Gates] Applied live gate changes to %s (gate set %s)\n", strings.Join(changed, ", "), gateSetVersion(live))
+}
+
+// sameMap reports whether a and b are the same map, not merely equal.
+func sameMap(a, b map[string]*GateConfig) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// gateSetVersion identifies a gate set by its content: "gs-" and the start
+// of the SHA-256 of its JSON form.
+func gateSetVersion(gates map[string]*GateConfig) string {
+	if gates == nil {
+		gates = map[string]*GateConfig{}
+	}
+	data, _ := json.Marshal(gates) // Map keys marshal sorted
+	sum := sha256.Sum256(data)
+	return "gs-" + hex.EncodeToString(sum[:6])
+}
+
+func (e *Engineer) gateSetPath(version string) string {
+	return filepath.Join(e.rig.Path, ".runtime", "gate-sets", version+".json")
+}
+
+// snapshotGateSet saves gates under their version, unless already saved,
+// and returns the version, for a batch record to name the exact gates it
+// ran (see GateSet). It returns "" when there are no gates.
+func (e *Engineer) snapshotGateSet(gates map[string]*GateConfig) string {
+	if e.rig == nil || len(gates) == 0 {
+		return ""
+	}
+	version := gateSetVersion(gates)
+	path := e.gateSetPath(version)
+	if _, err := os.Stat(path); err == nil {
+		return version
+	}
+	if err := util.EnsureDirAndWriteJSON(path, gates); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Gates] Warning: saving gate set %s: %v\n", version, err)
+	}
+	return version
+}
+
+// GateSet returns the gates of a snapshot recorded by a batch, to rerun
+// a batch's gates exactly as they were.
+func (e *Engineer) GateSet(version string) (map[string]*GateConfig, error) {
+	if !strings.HasPrefix(version, "gs-") || !filepath.IsLocal(version) {
+		return nil, fmt.Errorf("invalid gate set version %q", version)
+	}
+	data, err := os.ReadFile(e.gateSetPath(version))
+	if err != nil {
+		return nil, fmt.Errorf("reading gate set %s: %w", version, err)
+	}
+	var gates map[string]*GateConfig
+	if err := json.Unmarshal(data, &gates); err != nil {
+		return nil, fmt.Errorf("parsing gate set %s: %w", version, err)
+	}
+	return gates, nil
+}
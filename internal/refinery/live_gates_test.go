@@ -0,0 +1,93 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLiveGates_AppliedAtBatchBoundary(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	configured := map[string]*GateConfig{"lint": {Cmd: "true"}}
+	e.config.Gates = configured
+	if err := e.SetGate("test", &GateConfig{Cmd: failMarkerGateCmd(), Timeout: time.Minute}, "overseer"); err != nil {
+		t.Fatalf("SetGate: %v", err)
+	}
+	if len(e.Config().Gates) != 1 {
+		t.Fatalf("gate change applied before a batch boundary: %v", e.Config().Gates)
+	}
+
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if len(result.Culprits) != 1 || result.Culprits[0].ID != "mr-b" {
+		t.Fatalf("added gate not run: culprits %v", mrIDs(result.Culprits))
+	}
+	first, err := e.FindBatch(result.BatchID)
+	if err != nil || first.GateSet == "" {
+		t.Fatalf("FindBatch = %+v, %v", first, err)
+	}
+	snapshot, err := e.GateSet(first.GateSet)
+	if err != nil || len(snapshot) != 2 || snapshot["test"].Timeout != time.Minute {
+		t.Fatalf("GateSet(%s) = %v, %v", first.GateSet, snapshot, err)
+	}
+
+	if err := e.RemoveGate("test", "overseer"); err != nil {
+		t.Fatalf("RemoveGate: %v", err)
+	}
+	if err := e.RemoveGate("nope", "overseer"); !errors.Is(err, ErrLiveGateNotFound) {
+		t.Errorf("RemoveGate(nope) = %v", err)
+	}
+	batch = []*MRInfo{makeMR("mr-b2", "feature-b", "main"), makeMR("mr-c", "feature-c", "main")}
+	result = e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("removed gate still run: merged %v, culprits %v", mrIDs(result.Merged), mrIDs(result.Culprits))
+	}
+	second, _ := e.FindBatch(result.BatchID)
+	if second.GateSet == first.GateSet || second.GateSet != gateSetVersion(configured) {
+		t.Errorf("gate set after removal = %s, first %s", second.GateSet, first.GateSet)
+	}
+
+	dropped, err := e.ResetGates()
+	if err != nil || len(dropped) != 1 || dropped[0] != "test" {
+		t.Fatalf("ResetGates = %v, %v", dropped, err)
+	}
+	e.applyLiveGates()
+	if !sameMap(e.Config().Gates, configured) {
+		t.Errorf("gates after reset = %v, want the configured ones", e.Config().Gates)
+	}
+}
+
+func TestLiveGates_SurviveConfigReload(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"lint": {Cmd: "true"}}
+	if err := e.SetGate("lint", &GateConfig{Cmd: "true", Flaky: true}, "overseer"); err != nil {
+		t.Fatal(err)
+	}
+	e.applyLiveGates()
+	if !e.Config().Gates["lint"].Flaky {
+		t.Fatalf("retuned gate not applied: %+v", e.Config().Gates["lint"])
+	}
+
+	// A config reload replaces the gates; the live change stays on top.
+	e.config.Gates = map[string]*GateConfig{"lint": {Cmd: "true"}, "unit": {Cmd: "true"}}
+	e.applyLiveGates()
+	gates := e.Config().Gates
+	if len(gates) != 2 || !gates["lint"].Flaky {
+		t.Errorf("gates after reload = %v", gates)
+	}
+
+	for _, bad := range []*GateConfig{{Cmd: " "}, {Cmd: "true", Timeout: -time.Second}, {Cmd: "true", Dir: "../x"}} {
+		if err := e.SetGate("unit", bad, "overseer"); err == nil {
+			t.Errorf("SetGate(%+v) accepted", bad)
+		}
+	}
+}
@@ -69,9 +69,11 @@ func (m *Manager) SessionName() string {
 // ZFC: tmux session existence is the source of truth for session state,
 // but agent liveness determines if the session is actually functional.
 func (m *Manager) IsRunning() (bool, error) {
-	t := tmux.NewTmux()
-	sessionName := m.SessionName()
-	status := t.CheckSessionHealth(sessionName, 0)
+	mux, err := tmux.NewMultiplexer()
+	if err != nil {
+		return false, err
+	}
+	status := tmux.CheckHealth(mux, m.SessionName(), 0)
 	return status == tmux.SessionHealthy, nil
 }
 
@@ -81,14 +83,16 @@ func (m *Manager) IsRunning() (bool, error) {
 // Returns the detailed ZombieStatus for callers that need to distinguish
 // between different failure modes.
 func (m *Manager) IsHealthy(maxInactivity time.Duration) tmux.ZombieStatus {
-	t := tmux.NewTmux()
-	return t.CheckSessionHealth(m.SessionName(), maxInactivity)
+	return tmux.CheckHealth(tmux.Selected(), m.SessionName(), maxInactivity)
 }
 
 // Status returns information about the refinery session.
 // ZFC-compliant: tmux session is the source of truth.
 func (m *Manager) Status() (*tmux.SessionInfo, error) {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("refinery session info")
+	if err != nil {
+		return nil, err
+	}
 	sessionID := m.SessionName()
 
 	running, err := t.HasSession(sessionID)
@@ -108,8 +112,14 @@ func (m *Manager) Status() (*tmux.SessionInfo, error) {
 // The agentOverride parameter allows specifying an agent alias to use instead of the town default.
 // ZFC-compliant: no state file, tmux session is source of truth.
 func (m *Manager) Start(foreground bool, agentOverride string) error {
-	t := tmux.NewTmux()
-	sessionID := m.SessionName()
+	t, err := tmux.RequireTmux("starting the refinery")
+	if err != nil {
+		return err
+	}
+	sessionID, err := session.ResolveSessionName(&session.AgentIdentity{Role: session.RoleRefinery, Rig: m.rig.Name, Prefix: session.PrefixFor(m.rig.Name)})
+	if err != nil {
+		return fmt.Errorf("resolving session name: %w", err)
+	}
 
 	if foreground {
 		// Foreground mode is deprecated - the Refinery agent handles merge processing
@@ -239,7 +249,10 @@ func (m *Manager) Start(foreground bool, agentOverride string) error {
 // Stop stops the refinery.
 // ZFC-compliant: tmux session is the source of truth.
 func (m *Manager) Stop() error {
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	sessionID := m.SessionName()
 
 	// Check if tmux session exists
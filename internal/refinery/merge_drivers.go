@@ -0,0 +1,308 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// Merge driver strategies. Union concatenates both sides line by line (safe
+// for files like go.sum); ours and theirs keep one side whole and rely on
+// Regenerate to rebuild the file from the merged sources.
+const (
+	MergeDriverUnion  = "union"
+	MergeDriverOurs   = "ours"
+	MergeDriverTheirs = "theirs"
+)
+
+// MergeDriverConfig resolves conflicts in lockfiles and generated files,
+// which otherwise cause most false-positive conflicts in batches.
+type MergeDriverConfig struct {
+	// Patterns are gitattributes patterns the driver applies to.
+	Patterns []string `json:"patterns"`
+
+	// Strategy is union, ours (the target side) or theirs (the MR side).
+	Strategy string `json:"strategy"`
+
+	// Regenerate runs via sh -c in the refinery clone after the driver
+	// resolved a conflict, and its changes to tracked files are folded into
+	// the MR's squash commit. Required for ours and theirs, as keeping one
+	// side of a lockfile whole is only correct once it is regenerated.
+	Regenerate string `json:"regenerate,omitempty"`
+
+	// Disabled turns off a built-in driver.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// regenerateTimeout bounds each regenerate command.
+const regenerateTimeout = 10 * time.Minute
+
+// mergeDriverAttributesHeader marks the block of info/attributes the
+// refinery owns.
+const mergeDriverAttributesHeader = "# gastown merge drivers (managed by the refinery)"
+
+// DefaultMergeDrivers returns the built-in drivers. The protobuf driver has
+// no regenerate command, so it stays inactive until the rig sets one (e.g.
+// "buf generate").
+func DefaultMergeDrivers() map[string]*MergeDriverConfig {
+	return map[string]*MergeDriverConfig{
+		"gosum": {
+			Patterns:   []string{"go.sum"},
+			Strategy:   MergeDriverUnion,
+			Regenerate: "go mod tidy",
+		},
+		"npm-lock": {
+			Patterns:   []string{"package-lock.json"},
+			Strategy:   MergeDriverTheirs,
+			Regenerate: "npm install --package-lock-only --ignore-scripts",
+		},
+		"protobuf": {
+			Patterns: []string{"*.pb.go", "*_pb2.py", "*_pb.js", "*_pb.d.ts"},
+			Strategy: MergeDriverTheirs,
+		},
+	}
+}
+
+// mergeDrivers returns the active drivers: the built-ins, replaced by name
+// with the rig's own, minus disabled ones and those that can't run.
+func (e *Engineer) mergeDrivers() map[string]*MergeDriverConfig {
+	drivers := DefaultMergeDrivers()
+	for name, d := range e.config.MergeDrivers {
+		drivers[name] = d
+	}
+	for name, d := range drivers {
+		if d == nil || d.Disabled || len(d.Patterns) == 0 ||
+			(d.Strategy != MergeDriverUnion && d.Regenerate == "") {
+			delete(drivers, name)
+		}
+	}
+	return drivers
+}
+
+// validateMergeDrivers checks rig-configured drivers.
+func validateMergeDrivers(drivers map[string]*MergeDriverConfig) error {
+	for name, d := range drivers {
+		if d == nil || d.Disabled {
+			continue
+		}
+		switch d.Strategy {
+		case MergeDriverUnion, MergeDriverOurs, MergeDriverTheirs:
+		default:
+			return fmt.Errorf("merge driver %q: strategy must be union, ours or theirs, got %q", name, d.Strategy)
+		}
+		if strings.ContainsAny(name, " \t\n=") {
+			return fmt.Errorf("merge driver %q: name must not contain whitespace or '='", name)
+		}
+	}
+	return nil
+}
+
+// InstallMergeDrivers registers the active drivers in the refinery clone:
+// a merge.gt-<name>.driver entry in its git config and a managed block in
+// its info/attributes (so MR branches can't change which files they cover).
+// Each driver appends the paths it resolved to a marker file, which
+// squashMerge reads to decide what to regenerate.
+func (e *Engineer) InstallMergeDrivers() error {
+	marker, err := e.git.GitPath("gt-merge-driver-resolved")
+	if err != nil {
+		return fmt.Errorf("resolve marker path: %w", err)
+	}
+	attrPath, err := e.git.GitPath("info/attributes")
+	if err != nil {
+		return fmt.Errorf("resolve attributes path: %w", err)
+	}
+
+	drivers := e.mergeDrivers()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var block strings.Builder
+	block.WriteString(mergeDriverAttributesHeader + "\n")
+	for _, name := range names {
+		d := drivers[name]
+		var resolve string
+		switch d.Strategy {
+		case MergeDriverUnion:
+			resolve = "git merge-file --union -L ours -L base -L theirs %A %O %B"
+		case MergeDriverOurs:
+			resolve = "true"
+		case MergeDriverTheirs:
+			resolve = "cp %B %A"
+		}
+		driver := fmt.Sprintf("%s && echo %%P >> %s", resolve, shellQuote(marker))
+		if err := e.git.ConfigSet("merge.gt-"+name+".name", "gastown "+name+" merge driver"); err != nil {
+			return fmt.Errorf("configure merge driver %s: %w", name, err)
+		}
+		if err := e.git.ConfigSet("merge.gt-"+name+".driver", driver); err != nil {
+			return fmt.Errorf("configure merge driver %s: %w", name, err)
+		}
+		for _, p := range d.Patterns {
+			fmt.Fprintf(&block, "%s merge=gt-%s\n", p, name)
+		}
+	}
+
+	existing, err := os.ReadFile(attrPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read %s: %w", attrPath, err)
+	}
+	// Keep everything before our block; the block always comes last.
+	kept := string(existing)
+	if i := strings.Index(kept, mergeDriverAttributesHeader); i >= 0 {
+		kept = kept[:i]
+	}
+	if kept != "" && !strings.HasSuffix(kept, "\n") {
+		kept += "\n"
+	}
+	if len(names) == 0 {
+		block.Reset()
+	}
+	if err := os.MkdirAll(filepath.Dir(attrPath), 0755); err != nil {
+		return fmt.Errorf("create info dir: %w", err)
+	}
+	if err := os.WriteFile(attrPath, []byte(kept+block.String()), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", attrPath, err)
+	}
+	e.mergeDriverMarker = marker
+	return nil
+}
+
+// ensureMergeDrivers installs merge drivers once per Engineer. Failure is
+// logged, not fatal: merges then conflict as they would without drivers.
+func (e *Engineer) ensureMergeDrivers() {
+	if e.mergeDriversInstalled {
+		return
+	}
+	e.mergeDriversInstalled = true
+	if err := e.InstallMergeDrivers(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: installing merge drivers: %v\n", err)
+	}
+}
+
+// squashMerge squash-merges branch onto the current branch, like
+// git.MergeSquash, then regenerates any files a merge driver resolved and
+// folds the result into the squash commit. A failed regenerate resets the
+// commit and is returned as an error, so callers drop the MR as they would
+// for a conflict.
+func (e *Engineer) squashMerge(branch, message string) error {
+	return e.squashMergeIn(e.git, e.workDir, branch, message, e.output)
+}
+
+// squashMergeIn runs squashMerge in the worktree g (rooted at dir), logging
+// to out. Merge drivers share one marker file across worktrees, so only one
+// squash merge may run at a time.
+func (e *Engineer) squashMergeIn(g *git.Git, dir, branch, message string, out io.Writer) error {
+	return e.mergeWithDriversIn(g, dir, branch, out, func() error {
+		return e.mergeSquash(g, branch, message)
+	})
+}
+
+// mergeWithDriversIn runs merge, which lands branch in the worktree g, then
+// regenerates any files a merge driver resolved and folds the result into
+// the last commit merge made. A failed regenerate drops what merge landed.
+func (e *Engineer) mergeWithDriversIn(g *git.Git, dir, branch string, out io.Writer, merge func() error) error {
+	if e.mergeDriverMarker != "" {
+		_ = os.Remove(e.mergeDriverMarker)
+	}
+	before, err := g.Rev("HEAD")
+	if err != nil {
+		return err
+	}
+	if err := merge(); err != nil {
+		return err
+	}
+	resolved := e.takeDriverResolved()
+	if len(resolved) == 0 {
+		return nil
+	}
+	if err := e.regenerateIn(g, dir, resolved, out); err != nil {
+		if resetErr := g.ResetHard(before); resetErr != nil {
+			_, _ = fmt.Fprintf(out, "[Engineer] Warning: failed to drop merge of %s: %v\n", branch, resetErr)
+		}
+		return fmt.Errorf("regenerate after merge driver: %w", err)
+	}
+	return nil
+}
+
+// takeDriverResolved returns and clears the paths merge drivers resolved.
+func (e *Engineer) takeDriverResolved() []string {
+	if e.mergeDriverMarker == "" {
+		return nil
+	}
+	data, err := os.ReadFile(e.mergeDriverMarker)
+	_ = os.Remove(e.mergeDriverMarker)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
+}
+
+// regenerateIn runs the regenerate command of each driver covering a
+// resolved path in dir, once per command, and amends HEAD of g with the
+// changes.
+func (e *Engineer) regenerateIn(g *git.Git, dir string, resolved []string, out io.Writer) error {
+	drivers := e.mergeDrivers()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ran := make(map[string]bool)
+	for _, name := range names {
+		d := drivers[name]
+		if d.Regenerate == "" || ran[d.Regenerate] || !driverCovers(d, resolved) {
+			continue
+		}
+		ran[d.Regenerate] = true
+		_, _ = fmt.Fprintf(out, "[Engineer] Merge driver %s resolved a conflict, regenerating: %s\n", name, d.Regenerate)
+		ctx, cancel := context.WithTimeout(context.Background(), regenerateTimeout)
+		// Trust boundary: regenerate commands come from the rig's config.json
+		// (operator-controlled) or the built-in defaults, like gate commands.
+		cmd := exec.CommandContext(ctx, "sh", "-c", d.Regenerate) //nolint:gosec // G204: regenerate command is from trusted rig config
+		cmd.Dir = dir
+		var buf bytes.Buffer
+		cmd.Stdout = &buf
+		cmd.Stderr = &buf
+		err := cmd.Run()
+		cancel()
+		if err != nil {
+			msg := strings.TrimSpace(buf.String())
+			if len(msg) > 500 {
+				msg = msg[len(msg)-500:]
+			}
+			return fmt.Errorf("%s: %v: %s", d.Regenerate, err, msg)
+		}
+	}
+	if len(ran) == 0 {
+		return nil
+	}
+	return e.amendTracked(g)
+}
+
+// driverCovers reports whether any resolved path matches one of d's patterns.
+func driverCovers(d *MergeDriverConfig, resolved []string) bool {
+	for _, p := range resolved {
+		if matchAnyPattern(d.Patterns, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package refinery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeDrivers_Filtering(t *testing.T) {
+	e := &Engineer{config: DefaultMergeQueueConfig()}
+	drivers := e.mergeDrivers()
+	if _, ok := drivers["gosum"]; !ok {
+		t.Error("gosum should be active by default")
+	}
+	if _, ok := drivers["protobuf"]; ok {
+		t.Error("protobuf has no regenerate command and should be inactive by default")
+	}
+
+	e.config.MergeDrivers = map[string]*MergeDriverConfig{
+		"gosum":    {Disabled: true},
+		"protobuf": {Patterns: []string{"*.pb.go"}, Strategy: MergeDriverTheirs, Regenerate: "buf generate"},
+	}
+	drivers = e.mergeDrivers()
+	if _, ok := drivers["gosum"]; ok {
+		t.Error("disabled gosum driver should be dropped")
+	}
+	if d, ok := drivers["protobuf"]; !ok || d.Regenerate != "buf generate" {
+		t.Errorf("protobuf = %+v, want rig override active", d)
+	}
+}
+
+func TestValidateMergeDrivers(t *testing.T) {
+	tests := []struct {
+		name    string
+		drivers map[string]*MergeDriverConfig
+		wantErr bool
+	}{
+		{"valid", map[string]*MergeDriverConfig{"lock": {Patterns: []string{"x.lock"}, Strategy: MergeDriverOurs}}, false},
+		{"disabled skips checks", map[string]*MergeDriverConfig{"gosum": {Disabled: true}}, false},
+		{"bad strategy", map[string]*MergeDriverConfig{"lock": {Strategy: "merge"}}, true},
+		{"bad name", map[string]*MergeDriverConfig{"my lock": {Strategy: MergeDriverUnion}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMergeDrivers(tt.drivers)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMergeDrivers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInstallMergeDrivers_KeepsExistingAttributes(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	attrPath := filepath.Join(workDir, ".git", "info", "attributes")
+	if err := os.MkdirAll(filepath.Dir(attrPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Dir(attrPath), "attributes", "*.bin binary\n")
+
+	e := newTestEngineer(t, workDir, g)
+	for i := 0; i < 2; i++ { // Reinstalling replaces the managed block
+		if err := e.InstallMergeDrivers(); err != nil {
+			t.Fatalf("InstallMergeDrivers: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(attrPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := string(data)
+	if !strings.HasPrefix(attrs, "*.bin binary\n") {
+		t.Errorf("existing attributes lost:\n%s", attrs)
+	}
+	if strings.Count(attrs, mergeDriverAttributesHeader) != 1 {
+		t.Errorf("managed block duplicated:\n%s", attrs)
+	}
+	if !strings.Contains(attrs, "go.sum merge=gt-gosum") {
+		t.Errorf("gosum driver not registered:\n%s", attrs)
+	}
+	if out := run(t, workDir, "git", "config", "merge.gt-gosum.driver"); !strings.Contains(out, "merge-file --union") {
+		t.Errorf("merge.gt-gosum.driver = %q", out)
+	}
+}
+
+func TestSquashMerge_DriverResolvesAndRegenerates(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	writeFile(t, workDir, "go.sum", "a v1 h1:a\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "add go.sum")
+	run(t, workDir, "git", "push", "origin", "main")
+
+	createConflictingBranch(t, workDir, "feature-a", "go.sum", "a v1 h1:a\nb v1 h1:b\n")
+	createConflictingBranch(t, workDir, "feature-b", "go.sum", "a v1 h1:a\nc v1 h1:c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.MergeDrivers = map[string]*MergeDriverConfig{
+		"gosum": {Patterns: []string{"go.sum"}, Strategy: MergeDriverUnion, Regenerate: "echo regenerated > REGEN"},
+	}
+	e.ensureMergeDrivers()
+
+	if err := e.squashMerge("feature-a", "merge a"); err != nil {
+		t.Fatalf("squashMerge(feature-a): %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "REGEN")); !os.IsNotExist(err) {
+		t.Error("regenerate should not run for a clean merge")
+	}
+
+	conflicts, err := g.CheckConflicts("feature-b", "main")
+	if err != nil {
+		t.Fatalf("CheckConflicts: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Fatalf("conflicts = %v, want none with merge driver", conflicts)
+	}
+
+	// Regenerate only folds changes to tracked files into the squash commit.
+	run(t, workDir, "git", "checkout", "main")
+	writeFile(t, workDir, "REGEN", "")
+	run(t, workDir, "git", "add", "REGEN")
+	run(t, workDir, "git", "commit", "-m", "track REGEN")
+	if err := e.squashMerge("feature-b", "merge b"); err != nil {
+		t.Fatalf("squashMerge(feature-b): %v", err)
+	}
+
+	sum, err := os.ReadFile(filepath.Join(workDir, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"a v1 h1:a", "b v1 h1:b", "c v1 h1:c"} {
+		if !strings.Contains(string(sum), line) {
+			t.Errorf("go.sum missing %q after union merge:\n%s", line, sum)
+		}
+	}
+	if out := run(t, workDir, "git", "show", "HEAD:REGEN"); out != "regenerated" {
+		t.Errorf("HEAD:REGEN = %q, want regenerate output amended into the squash commit", out)
+	}
+	if status := run(t, workDir, "git", "status", "--porcelain"); status != "" {
+		t.Errorf("work dir not clean after squashMerge:\n%s", status)
+	}
+}
+
+func TestSquashMerge_RegenerateFailureDropsCommit(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	writeFile(t, workDir, "go.sum", "a v1 h1:a\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "add go.sum")
+
+	createConflictingBranch(t, workDir, "feature-a", "go.sum", "a v1 h1:a\nb v1 h1:b\n")
+	run(t, workDir, "git", "checkout", "main")
+	writeFile(t, workDir, "go.sum", "a v1 h1:a\nc v1 h1:c\n")
+	run(t, workDir, "git", "commit", "-am", "main changes go.sum")
+	before := run(t, workDir, "git", "rev-parse", "HEAD")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.MergeDrivers = map[string]*MergeDriverConfig{
+		"gosum": {Patterns: []string{"go.sum"}, Strategy: MergeDriverUnion, Regenerate: "exit 1"},
+	}
+	e.ensureMergeDrivers()
+
+	if err := e.squashMerge("feature-a", "merge a"); err == nil {
+		t.Fatal("expected error when regenerate fails")
+	}
+	if after := run(t, workDir, "git", "rev-parse", "HEAD"); after != before {
+		t.Errorf("HEAD = %s, want squash commit dropped (%s)", after, before)
+	}
+}
@@ -0,0 +1,292 @@
+package refinery
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// MergeMessageData is what a MergeQueueConfig.MergeMessage template is
+// executed with.
+type MergeMessageData struct {
+	ID          string // MR bead ID
+	Branch      string // Source branch
+	Target      string // Target branch
+	SourceIssue string // The work item being merged
+	Author      string // The MR's worker
+	Title       string // MR title
+	Strategy    string // Merge strategy the MR lands with
+
+	// Message is the message the refinery would use without a template:
+	// the squashed commit messages, or "Merge <branch> into <target>".
+	Message string
+
+	// Subject is the first line of Message, and Body the rest of it with
+	// its trailer paragraphs removed, so a template can re-emit Trailers
+	// without repeating them.
+	Subject string
+	Body    string
+
+	// CoAuthors lists everyone who authored a commit on Branch or is
+	// named in a Co-authored-by trailer of one, as "Name <email>", in order
+	// of first appearance and without repeats.
+	CoAuthors []string
+
+	// Trailers are the other trailers of the commits on Branch, oldest
+	// first and without repeats, limited to MergeQueueConfig.MergeTrailers
+	// when the rig sets it.
+	Trailers []Trailer
+
+	// IssueLink is SourceIssue formatted with MergeQueueConfig.IssueURL,
+	// or empty when either is unset.
+	IssueLink string
+
+	// Gates lists the blocking gates an MR must pass to land on Target,
+	// sorted. Quarantined gates, whose failures don't block, are left out.
+	Gates []string
+
+	// BatchID is the batch the MR lands in (see BatchResult.BatchID).
+	BatchID string
+}
+
+// Trailer is a "Key: Value" line from the trailer paragraph of a commit
+// message, such as "Signed-off-by: Max <max@example.com>".
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// coAuthoredBy is the trailer key folded into MergeMessageData.CoAuthors.
+const coAuthoredBy = "Co-authored-by"
+
+var (
+	trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s+(\S.*)$`)
+	trailerKey  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+)
+
+// trailerParagraph parses para as a trailer paragraph: one where every
+// non-blank line is a trailer. It returns nil for any other paragraph.
+func trailerParagraph(para string) []Trailer {
+	var trailers []Trailer
+	for _, line := range strings.Split(para, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		m := trailerLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: m[1], Value: strings.TrimSpace(m[2])})
+	}
+	return trailers
+}
+
+// parseTrailers returns the trailers of a commit message: those in its
+// last paragraph, if that isn't the subject.
+func parseTrailers(msg string) []Trailer {
+	msg = strings.TrimSpace(msg)
+	i := strings.LastIndex(msg, "\n\n")
+	if i < 0 {
+		return nil
+	}
+	return trailerParagraph(msg[i+2:])
+}
+
+// splitMessage returns the subject of msg and its body without trailer
+// paragraphs. Squash messages quote each squashed commit's trailers in a
+// paragraph of their own, so those are removed too.
+func splitMessage(msg string) (subject, body string) {
+	subject, rest, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	var kept []string
+	for _, para := range strings.Split(strings.TrimSpace(rest), "\n\n") {
+		if strings.TrimSpace(para) != "" && trailerParagraph(para) == nil {
+			kept = append(kept, strings.TrimRight(para, " \t\n"))
+		}
+	}
+	return subject, strings.Join(kept, "\n\n")
+}
+
+// hasKey reports whether keys include key, ignoring case as git does for
+// trailer keys.
+func hasKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTrailerKeys checks that each of keys is a well-formed trailer key.
+func validateTrailerKeys(field string, keys []string) error {
+	for _, key := range keys {
+		if !trailerKey.MatchString(key) {
+			return fmt.Errorf("%s: invalid trailer key %q", field, key)
+		}
+	}
+	return nil
+}
+
+// mergeMessageFuncs are the functions available to merge message templates.
+var mergeMessageFuncs = template.FuncMap{
+	"firstLine": func(s string) string {
+		line, _, _ := strings.Cut(s, "\n")
+		return line
+	},
+	"body": func(s string) string {
+		_, rest, _ := strings.Cut(s, "\n")
+		return strings.TrimSpace(rest)
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+// parseMergeMessage parses a merge message template.
+func parseMergeMessage(text string) (*template.Template, error) {
+	return template.New("merge_message").Funcs(mergeMessageFuncs).Option("missingkey=error").Parse(text)
+}
+
+// renderMergeMessage returns the commit message mr lands with: the rig's
+// merge message template executed for mr, or msg when no template is set.
+// A template that fails or renders nothing falls back to msg with a
+// warning, so a bad template can't stop merges. The branch's co-authors
+// and trailers are read in g, the worktree mr is about to land in; with a
+// nil g they are left empty.
+func (e *Engineer) renderMergeMessage(g *git.Git, mr *MRInfo, msg string, out io.Writer) string {
+	if e.config.MergeMessage == "" {
+		return msg
+	}
+	tmpl, err := parseMergeMessage(e.config.MergeMessage)
+	if err != nil {
+		_, _ = fmt.Fprintf(out, "[Engineer] Warning: merge_message: %v (using default message)\n", err)
+		return msg
+	}
+	data := MergeMessageData{
+		ID:          mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		SourceIssue: mr.SourceIssue,
+		Author:      mr.Worker,
+		Title:       mr.Title,
+		Strategy:    e.mergeStrategy(mr),
+		Message:     msg,
+		Gates:       e.blockingGateNames(mr.Target),
+		BatchID:     mr.batchID,
+	}
+	data.Subject, data.Body = splitMessage(msg)
+	if g != nil {
+		data.CoAuthors, data.Trailers = e.branchTrailers(g, mr)
+	}
+	if e.config.IssueURL != "" && mr.SourceIssue != "" {
+		data.IssueLink = strings.ReplaceAll(e.config.IssueURL, "{issue}", mr.SourceIssue)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		_, _ = fmt.Fprintf(out, "[Engineer] Warning: merge_message for %s: %v (using default message)\n", mr.ID, err)
+		return msg
+	}
+	rendered := strings.TrimSpace(b.String())
+	if rendered == "" {
+		_, _ = fmt.Fprintf(out, "[Engineer] Warning: merge_message for %s rendered empty (using default message)\n", mr.ID)
+		return msg
+	}
+	return rendered
+}
+
+// branchTrailers returns the co-authors and other trailers of the commits
+// on mr's branch that are not yet in g's HEAD (see MergeMessageData).
+func (e *Engineer) branchTrailers(g *git.Git, mr *MRInfo) (coAuthors []string, trailers []Trailer) {
+	seenAuthor := make(map[string]bool)
+	addAuthor := func(author string) {
+		key := strings.ToLower(author)
+		if _, email, ok := strings.Cut(key, "<"); ok {
+			key = strings.TrimSuffix(email, ">")
+		}
+		if !seenAuthor[key] {
+			seenAuthor[key] = true
+			coAuthors = append(coAuthors, author)
+		}
+	}
+	authors, _ := g.CommitAuthors("HEAD", mr.Branch)
+	for _, author := range authors {
+		addAuthor(author)
+	}
+
+	msgs, _ := g.CommitMessages("HEAD", mr.Branch)
+	seen := make(map[Trailer]bool)
+	for _, msg := range msgs {
+		for _, t := range parseTrailers(msg) {
+			if strings.EqualFold(t.Key, coAuthoredBy) {
+				addAuthor(t.Value)
+				continue
+			}
+			if len(e.config.MergeTrailers) > 0 && !hasKey(e.config.MergeTrailers, t.Key) {
+				continue
+			}
+			if !seen[t] {
+				seen[t] = true
+				trailers = append(trailers, t)
+			}
+		}
+	}
+	return coAuthors, trailers
+}
+
+// checkRequiredTrailers returns an error naming the trailers the rig
+// requires (MergeQueueConfig.RequiredTrailers) that msg lacks, so an MR
+// isn't landed without them.
+func (e *Engineer) checkRequiredTrailers(msg string) error {
+	var present []string
+	for _, t := range parseTrailers(msg) {
+		present = append(present, t.Key)
+	}
+	var missing []string
+	for _, key := range e.config.RequiredTrailers {
+		if !hasKey(present, key) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("merge message lacks required trailers: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// blockingGateNames returns the sorted names of target's gates whose
+// failures block a merge. Rigs on the legacy test command report "tests".
+func (e *Engineer) blockingGateNames(target string) []string {
+	gates := e.gatesFor(target)
+	if len(gates) == 0 {
+		if e.config.RunTests && e.config.TestCommand != "" {
+			return []string{"tests"}
+		}
+		return nil
+	}
+	quarantined := make(map[string]bool)
+	if q := e.config.Quarantine; q != nil && q.Enabled {
+		names, _ := e.QuarantinedGates()
+		for _, name := range names {
+			quarantined[name] = true
+		}
+	}
+	names := make([]string, 0, len(gates))
+	for name := range gates {
+		if !quarantined[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,198 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+const conventionalMergeMessage = `{{if .SourceIssue}}feat({{.SourceIssue}}){{else}}chore{{end}}: {{firstLine .Message | lower}}
+
+MR: {{.ID}} by {{.Author}}
+Batch: {{.BatchID}}
+Gates: {{join .Gates ", "}}`
+
+func TestEngineer_LoadConfig_MergeMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(tmpl string) error {
+		data := []byte(`{"merge_queue": {"merge_message": "` + tmpl + `"}}`)
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig()
+	}
+	if err := write(`{{firstLine .Message}} ({{.ID}})`); err != nil {
+		t.Errorf("LoadConfig: %v", err)
+	}
+	if err := write(`{{.ID`); err == nil {
+		t.Error("LoadConfig accepted an unterminated template")
+	}
+	if err := write(`{{shout .ID}}`); err == nil {
+		t.Error("LoadConfig accepted an unknown function")
+	}
+}
+
+func TestMergeMessage_BatchTemplate(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.MergeMessage = conventionalMergeMessage
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: "true"}, "lint": {Cmd: "true"}}
+
+	a := makeMR("mr-a", "feature-a", "main")
+	a.SourceIssue = "gt-1"
+	a.Worker = "polecats/nux"
+	b := makeMR("mr-b", "feature-b", "main")
+	b.Worker = "polecats/toast"
+	result := e.ProcessBatch(context.Background(), []*MRInfo{a, b}, "main", &BatchConfig{MaxBatchSize: 5})
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+	}
+
+	origin := filepath.Join(filepath.Dir(workDir), "origin.git")
+	want := "chore: feat: add b.txt\n\nMR: mr-b by polecats/toast\nBatch: " + result.BatchID + "\nGates: lint, test"
+	if got := run(t, origin, "git", "log", "-1", "--format=%B", "main"); got != want {
+		t.Errorf("tip message =\n%s\nwant\n%s", got, want)
+	}
+	if got := run(t, origin, "git", "log", "-1", "--format=%s", "main~1"); got != "feat(gt-1): feat: add a.txt" {
+		t.Errorf("first MR subject = %q", got)
+	}
+}
+
+func TestMergeMessage_SingleMRAndMergeCommit(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.MergeMessage = `{{.Strategy}} {{.ID}} -> {{.Target}}: {{.Message}}`
+	mr := makeMR("mr-a", "feature-a", "main")
+	mr.MergeStrategy = MergeStrategyMergeCommit
+	result := e.ProcessBatch(context.Background(), []*MRInfo{mr}, "main", nil)
+	if len(result.Merged) != 1 {
+		t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+	}
+	if got := originLog(t, workDir, "%s")[0]; got != "merge-commit mr-a -> main: Merge feature-a into main" {
+		t.Errorf("merge commit subject = %q", got)
+	}
+}
+
+func TestMergeMessage_FallsBackOnError(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	var out bytes.Buffer
+	mr := makeMR("mr-a", "feature-a", "main")
+
+	e.config.MergeMessage = `{{.Nope}}`
+	if got := e.renderMergeMessage(nil, mr, "feat: default", &out); got != "feat: default" {
+		t.Errorf("failed template rendered %q, want the default message", got)
+	}
+	if !strings.Contains(out.String(), "mr-a") {
+		t.Errorf("expected a warning naming the MR, got %q", out.String())
+	}
+
+	e.config.MergeMessage = `{{if .SourceIssue}}{{.SourceIssue}}{{end}}`
+	if got := e.renderMergeMessage(nil, mr, "feat: default", &out); got != "feat: default" {
+		t.Errorf("empty render gave %q, want the default message", got)
+	}
+
+	e.config.MergeMessage = ""
+	if got := e.renderMergeMessage(nil, mr, "feat: default", &out); got != "feat: default" {
+		t.Errorf("no template gave %q", got)
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	msg := "feat: add widget\n\nWires the widget in.\n\nSigned-off-by: Max <max@example.com>\nRefs: gt-1"
+	if got := parseTrailers(msg); len(got) != 2 || got[1] != (Trailer{Key: "Refs", Value: "gt-1"}) {
+		t.Errorf("parseTrailers = %v", got)
+	}
+	subject, body := splitMessage(msg)
+	if subject != "feat: add widget" || body != "Wires the widget in." {
+		t.Errorf("splitMessage = %q, %q", subject, body)
+	}
+	// A subject alone or a prose last paragraph has no trailers.
+	for _, msg := range []string{"Refs: gt-1", "fix: it\n\nThis note: explains why it broke."} {
+		if got := parseTrailers(msg); got != nil {
+			t.Errorf("parseTrailers(%q) = %v, want none", msg, got)
+		}
+	}
+}
+
+func TestMergeMessage_CoAuthorsAndTrailers(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	run(t, workDir, "git", "checkout", "-b", "feature-a", "main")
+	writeFile(t, workDir, "a.txt", "a\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "-c", "user.name=Nux", "-c", "user.email=nux@example.com", "commit", "-m",
+		"feat: add a\n\nCo-authored-by: Toast <toast@example.com>\nSigned-off-by: Nux <nux@example.com>")
+	writeFile(t, workDir, "a.txt", "aa\n")
+	run(t, workDir, "git", "-c", "user.name=Toast", "-c", "user.email=TOAST@example.com", "commit", "-am",
+		"fix: tidy a\n\nReviewed-by: Max <max@example.com>\nSigned-off-by: Nux <nux@example.com>")
+	run(t, workDir, "git", "checkout", "main")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.MergeMessage = `{{.Subject}} ({{.IssueLink}})
+
+{{.Body}}
+
+{{range .Trailers}}{{.}}
+{{end}}{{range .CoAuthors}}Co-authored-by: {{.}}
+{{end}}`
+	e.config.MergeTrailers = []string{"signed-off-by"}
+	e.config.RequiredTrailers = []string{"Signed-off-by"}
+	e.config.IssueURL = "https://issues.example.com/{issue}"
+	mr := makeMR("mr-a", "feature-a", "main")
+	mr.SourceIssue = "gt-1"
+	result := e.ProcessBatch(context.Background(), []*MRInfo{mr}, "main", nil)
+	if len(result.Merged) != 1 {
+		t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+	}
+
+	want := `fix: tidy a (https://issues.example.com/gt-1)
+
+Squashed commits:
+
+* feat: add a
+
+Signed-off-by: Nux <nux@example.com>
+Co-authored-by: Nux <nux@example.com>
+Co-authored-by: Toast <TOAST@example.com>`
+	origin := filepath.Join(filepath.Dir(workDir), "origin.git")
+	if got := run(t, origin, "git", "log", "-1", "--format=%B", "main"); got != want {
+		t.Errorf("message =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMergeMessage_RequiredTrailers(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.RequiredTrailers = []string{"Signed-off-by"}
+	result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "feature-a", "main")}, "main", nil)
+	if len(result.Merged) != 0 || result.Error == nil || !strings.Contains(result.Error.Error(), "lacks required trailers: Signed-off-by") {
+		t.Fatalf("merged = %v, error %v; want the MR held back for its trailer", mrIDs(result.Merged), result.Error)
+	}
+	if got := originLog(t, workDir, "%s"); len(got) != 1 {
+		t.Errorf("origin/main = %q, want only the initial commit", got)
+	}
+
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"required_trailers": ["Signed off by"]}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+		t.Error("LoadConfig accepted a malformed trailer key")
+	}
+}
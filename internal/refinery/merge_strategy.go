@@ -0,0 +1,283 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// Merge strategies: how an MR's branch lands on its target. An MR declares
+// its strategy with a "merge_strategy: <name>" line in its description;
+// MRs without one use the rig's MergeQueueConfig.MergeStrategy.
+const (
+	// MergeStrategySquash lands the branch as one commit, whose message
+	// collects the messages of every commit on the branch.
+	MergeStrategySquash = "squash"
+	// MergeStrategyMergeCommit lands the branch's commits as they are,
+	// joined to the target by a merge commit.
+	MergeStrategyMergeCommit = "merge-commit"
+	// MergeStrategyRebaseFF replays the branch's commits onto the target
+	// one by one, keeping history linear. Branches containing merge
+	// commits are rejected.
+	MergeStrategyRebaseFF = "rebase-ff"
+	// MergeStrategyCherryPick cherry-picks the branch's own commits onto
+	// the target one by one, keeping their authors, and records the commit
+	// each landed as (see Landing.Commits). Merge commits on the branch are
+	// skipped rather than rejected.
+	MergeStrategyCherryPick = "cherry-pick"
+	// MergeStrategyAuto squashes the branch unless its commits form a
+	// well-structured series, which lands with AutoStrategyConfig.Preserve
+	// instead (see judgeCommits).
+	MergeStrategyAuto = "auto"
+)
+
+// errMergeConflict marks a merge strategy failing on conflicting changes.
+var errMergeConflict = errors.New("merge conflict")
+
+// validMergeStrategy reports whether s names a merge strategy.
+func validMergeStrategy(s string) bool {
+	switch s {
+	case MergeStrategySquash, MergeStrategyMergeCommit, MergeStrategyRebaseFF, MergeStrategyCherryPick, MergeStrategyAuto:
+		return true
+	}
+	return false
+}
+
+// normalizeMergeStrategy maps a declared strategy to a known one. Unknown
+// or empty strategies are left empty, meaning the rig default.
+func normalizeMergeStrategy(s string) string {
+	if s = strings.ToLower(strings.TrimSpace(s)); validMergeStrategy(s) {
+		return s
+	}
+	return ""
+}
+
+// mergeStrategy returns the strategy mr lands with: its own, else the
+// rig's, else squash.
+func (e *Engineer) mergeStrategy(mr *MRInfo) string {
+	if mr.MergeStrategy != "" {
+		return mr.MergeStrategy
+	}
+	if e.config.MergeStrategy != "" {
+		return e.config.MergeStrategy
+	}
+	return MergeStrategySquash
+}
+
+// mergeMR lands mr's branch onto the current branch of the work directory
+// with the MR's merge strategy.
+func (e *Engineer) mergeMR(mr *MRInfo) error {
+	return e.mergeMRIn(e.git, e.workDir, mr, e.output)
+}
+
+// mergeMRIn runs mergeMR in the worktree g (rooted at dir), logging to out.
+// MergeStrategyAuto is resolved against the branch's commits first.
+// Squash and merge commits take their message from the rig's merge message
+// template when it sets one (see renderMergeMessage) and must carry the
+// rig's required trailers; rebased commits keep their own.
+// On failure the worktree is left at the commit it started from, except
+// that a failed squash or merge commit may leave the merge in progress, as
+// git.MergeSquash does.
+func (e *Engineer) mergeMRIn(g *git.Git, dir string, mr *MRInfo, out io.Writer) error {
+	base, _ := g.Rev("HEAD")
+	if e.mergeStrategy(mr) == MergeStrategyAuto {
+		// Land a copy declaring the chosen strategy, which the merge
+		// message template sees.
+		chosen := *mr
+		chosen.MergeStrategy = e.chooseMergeStrategy(g, mr, out)
+		mr = &chosen
+	}
+	var err error
+	switch e.mergeStrategy(mr) {
+	case MergeStrategyMergeCommit:
+		msg := fmt.Sprintf("Merge %s into %s", mr.Branch, mr.Target)
+		if mr.SourceIssue != "" {
+			msg = fmt.Sprintf("Merge %s into %s (%s)", mr.Branch, mr.Target, mr.SourceIssue)
+		}
+		msg = e.renderMergeMessage(g, mr, msg, out)
+		if err := e.checkRequiredTrailers(msg); err != nil {
+			return err
+		}
+		err = e.mergeWithDriversIn(g, dir, mr.Branch, out, func() error {
+			return e.mergeNoFF(g, mr.Branch, msg)
+		})
+	case MergeStrategyRebaseFF:
+		err = e.rebaseFFIn(g, dir, mr, out)
+	case MergeStrategyCherryPick:
+		var picks []PickedCommit
+		if picks, err = e.cherryPickIn(g, dir, mr, out); err == nil {
+			e.recordPicks(mr, picks)
+		}
+	default:
+		msg := e.renderMergeMessage(g, mr, e.squashMessage(g, mr), out)
+		if err := e.checkRequiredTrailers(msg); err != nil {
+			return err
+		}
+		err = e.squashMergeIn(g, dir, mr.Branch, msg, out)
+	}
+	if err == nil {
+		e.recordStackTip(g, mr, base)
+	}
+	return err
+}
+
+// squashMessage returns the message of mr's squash commit: the message of
+// the branch's last commit, followed by those of the commits before it, so
+// squashing loses no history. A single-commit branch keeps its message.
+func (e *Engineer) squashMessage(g *git.Git, mr *MRInfo) string {
+	msgs, err := g.CommitMessages("HEAD", mr.Branch)
+	if err != nil || len(msgs) < 2 {
+		return e.getMergeMessage(mr)
+	}
+	var b strings.Builder
+	b.WriteString(msgs[len(msgs)-1])
+	b.WriteString("\n\nSquashed commits:\n")
+	for _, msg := range msgs[:len(msgs)-1] {
+		lines := strings.Split(msg, "\n")
+		b.WriteString("\n* " + lines[0] + "\n")
+		for _, line := range lines[1:] {
+			if line = strings.TrimRight(line, " \t"); line != "" {
+				line = "  " + line
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// rebaseFFIn replays the commits of mr's branch onto the current branch.
+// The branch's history must be linear: merge commits can't be replayed
+// faithfully, so a branch with any is rejected before anything lands.
+func (e *Engineer) rebaseFFIn(g *git.Git, dir string, mr *MRInfo, out io.Writer) error {
+	merges, err := g.MergeCommits("HEAD", mr.Branch)
+	if err != nil {
+		return err
+	}
+	if len(merges) > 0 {
+		return fmt.Errorf("branch %s is not linear (%d merge commits); rebase it onto %s", mr.Branch, len(merges), mr.Target)
+	}
+	commits, err := g.UnpickedCommits(mr.Branch)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("branch %s has no commits to land", mr.Branch)
+	}
+	_, _ = fmt.Fprintf(out, "[Engineer] Rebasing %d commits of %s\n", len(commits), mr.Branch)
+	return e.mergeWithDriversIn(g, dir, mr.Branch, out, func() error {
+		if err := e.cherryPick(g, commits...); err != nil {
+			conflicts, _ := g.GetConflictingFiles()
+			_ = g.AbortCherryPick()
+			if len(conflicts) > 0 {
+				return fmt.Errorf("%w: %s", errMergeConflict, strings.Join(conflicts, ", "))
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// PickedCommit maps a commit of a cherry-picked MR to the commit it landed
+// as. They are the same when the commit could be fast-forwarded to.
+type PickedCommit struct {
+	Original string `json:"original"`
+	Landed   string `json:"landed"`
+}
+
+// cherryPickIn cherry-picks the commits of mr's branch that aren't on the
+// current branch onto it, one at a time, returning what each landed as.
+// Merge commits are skipped: the branch's own changes are in its other
+// commits. On failure the worktree is reset to where it started.
+func (e *Engineer) cherryPickIn(g *git.Git, dir string, mr *MRInfo, out io.Writer) ([]PickedCommit, error) {
+	base, err := g.Rev("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	commits, err := g.UnpickedCommits(mr.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("branch %s has no commits to land", mr.Branch)
+	}
+	_, _ = fmt.Fprintf(out, "[Engineer] Cherry-picking %d commits of %s\n", len(commits), mr.Branch)
+	picks := make([]PickedCommit, 0, len(commits))
+	err = e.mergeWithDriversIn(g, dir, mr.Branch, out, func() error {
+		for _, c := range commits {
+			if err := e.cherryPick(g, c); err != nil {
+				conflicts, _ := g.GetConflictingFiles()
+				_ = g.AbortCherryPick()
+				if len(conflicts) > 0 {
+					return fmt.Errorf("%w: %s", errMergeConflict, strings.Join(conflicts, ", "))
+				}
+				return fmt.Errorf("cherry-pick %s: %w", shortSHA(c), err)
+			}
+			landed, err := g.Rev("HEAD")
+			if err != nil {
+				return err
+			}
+			picks = append(picks, PickedCommit{Original: c, Landed: landed})
+		}
+		return nil
+	})
+	if err != nil {
+		_ = g.ResetHard(base)
+		return nil, err
+	}
+	return picks, nil
+}
+
+// recordPicks remembers what the commits of cherry-picked mr landed as,
+// for recordLandings.
+func (e *Engineer) recordPicks(mr *MRInfo, picks []PickedCommit) {
+	if mr.ID == "" {
+		return
+	}
+	e.stackTipsMu.Lock()
+	defer e.stackTipsMu.Unlock()
+	if e.stackPicks == nil {
+		e.stackPicks = make(map[string][]PickedCommit)
+	}
+	e.stackPicks[mr.ID] = picks
+}
+
+// recordStackTip remembers the commit mr's branch landed as, so a stack
+// with more than one commit per MR can still be cut after any MR (see
+// stackPrefix), and base, the commit it landed on, so the landing can be
+// reverted (see recordLandings).
+func (e *Engineer) recordStackTip(g *git.Git, mr *MRInfo, base string) {
+	if mr.ID == "" {
+		return
+	}
+	tip, err := g.Rev("HEAD")
+	if err != nil {
+		return
+	}
+	e.stackTipsMu.Lock()
+	defer e.stackTipsMu.Unlock()
+	if e.stackTips == nil {
+		e.stackTips = make(map[string]string)
+		e.stackBases = make(map[string]string)
+	}
+	e.stackTips[mr.ID] = tip
+	e.stackBases[mr.ID] = base
+}
+
+// stackPrefix returns the commit at which the stack in the work directory
+// holds only the first k (≥ 1) of stacked. Squash and merge commits add one
+// first-parent commit per MR; rebased MRs add one per commit, so recorded
+// tips are used where there are any.
+func (e *Engineer) stackPrefix(stacked []*MRInfo, k int) string {
+	if k > 0 {
+		e.stackTipsMu.Lock()
+		tip, ok := e.stackTips[stacked[k-1].ID]
+		e.stackTipsMu.Unlock()
+		if ok {
+			return tip
+		}
+	}
+	return fmt.Sprintf("HEAD~%d", len(stacked)-k)
+}
@@ -0,0 +1,257 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// createMultiCommitBranch creates a branch off main with one commit per file.
+func createMultiCommitBranch(t *testing.T, workDir, branchName string, files ...string) {
+	t.Helper()
+	run(t, workDir, "git", "checkout", "-q", "-b", branchName, "main")
+	for _, f := range files {
+		writeFile(t, workDir, f, f+"\n")
+		run(t, workDir, "git", "add", ".")
+		run(t, workDir, "git", "commit", "-q", "-m", "add "+f, "-m", "Body of "+f+".")
+	}
+	run(t, workDir, "git", "checkout", "-q", "main")
+}
+
+func originLog(t *testing.T, workDir, format string) []string {
+	t.Helper()
+	origin := filepath.Join(filepath.Dir(workDir), "origin.git")
+	return strings.Split(run(t, origin, "git", "log", "--topo-order", "--format="+format, "main"), "\n")
+}
+
+func TestEngineer_LoadConfig_MergeStrategy(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(strategy string) error {
+		data := []byte(`{"merge_queue": {"merge_strategy": "` + strategy + `"}}`)
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+		if err := e.LoadConfig(); err != nil {
+			return err
+		}
+		if got := e.mergeStrategy(&MRInfo{}); got != strategy {
+			t.Errorf("default strategy = %q, want %q", got, strategy)
+		}
+		if got := e.mergeStrategy(&MRInfo{MergeStrategy: MergeStrategySquash}); got != MergeStrategySquash {
+			t.Errorf("MR strategy overridden by rig default: %q", got)
+		}
+		return nil
+	}
+	if err := write(MergeStrategyRebaseFF); err != nil {
+		t.Errorf("LoadConfig: %v", err)
+	}
+	if err := write("octopus"); err == nil {
+		t.Error("LoadConfig accepted merge_strategy octopus")
+	}
+	if got := normalizeMergeStrategy(" Merge-Commit "); got != MergeStrategyMergeCommit {
+		t.Errorf("normalizeMergeStrategy = %q", got)
+	}
+}
+
+func TestMergeStrategy_SquashCollectsMessages(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createMultiCommitBranch(t, workDir, "feature-a", "a1.txt", "a2.txt", "a3.txt")
+
+	e := newTestEngineer(t, workDir, g)
+	result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "feature-a", "main")}, "main", nil)
+	if len(result.Merged) != 1 {
+		t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+	}
+
+	msg := run(t, filepath.Join(filepath.Dir(workDir), "origin.git"), "git", "log", "-1", "--format=%B", "main")
+	want := "add a3.txt\n\nBody of a3.txt.\n\nSquashed commits:\n\n* add a1.txt\n\n  Body of a1.txt.\n\n* add a2.txt\n\n  Body of a2.txt."
+	if msg != want {
+		t.Errorf("squash message =\n%s\nwant\n%s", msg, want)
+	}
+	if n := len(originLog(t, workDir, "%h")); n != 2 {
+		t.Errorf("main has %d commits, want initial + 1 squash", n)
+	}
+}
+
+func TestMergeStrategy_MixedBatch(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createMultiCommitBranch(t, workDir, "feature-merge", "m1.txt", "m2.txt")
+	createMultiCommitBranch(t, workDir, "feature-rebase", "r1.txt", "r2.txt")
+	createFeatureBranch(t, workDir, "feature-squash", "s.txt", "s\n")
+
+	e := newTestEngineer(t, workDir, g)
+	mergeMR := makeMR("mr-merge", "feature-merge", "main")
+	mergeMR.MergeStrategy = MergeStrategyMergeCommit
+	rebaseMR := makeMR("mr-rebase", "feature-rebase", "main")
+	rebaseMR.MergeStrategy = MergeStrategyRebaseFF
+	batch := []*MRInfo{mergeMR, rebaseMR, makeMR("mr-squash", "feature-squash", "main")}
+
+	result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5})
+	if result.Error != nil || len(result.Merged) != 3 {
+		t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+	}
+
+	// Newest first: squash, the two rebased commits, the merge commit
+	// with the merged branch's commits behind it, and the initial commit.
+	want := []string{
+		"feat: add s.txt",
+		"add r2.txt",
+		"add r1.txt",
+		"Merge feature-merge into main",
+		"add m2.txt",
+		"add m1.txt",
+		"initial commit",
+	}
+	if got := originLog(t, workDir, "%s"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("origin/main =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if parents := strings.Fields(run(t, workDir, "git", "log", "-1", "--format=%P", "main~3")); len(parents) != 2 {
+		t.Errorf("merge commit has parents %v, want 2", parents)
+	}
+}
+
+func TestMergeStrategy_RebaseFF(t *testing.T) {
+	t.Run("fast-forwards", func(t *testing.T) {
+		workDir, g, cleanup := testGitRepo(t)
+		defer cleanup()
+		createMultiCommitBranch(t, workDir, "feature-a", "a1.txt", "a2.txt")
+		tip := run(t, workDir, "git", "rev-parse", "feature-a")
+
+		e := newTestEngineer(t, workDir, g)
+		e.config.MergeStrategy = MergeStrategyRebaseFF
+		result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "feature-a", "main")}, "main", nil)
+		if len(result.Merged) != 1 {
+			t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+		}
+		if head := originLog(t, workDir, "%H")[0]; head != tip {
+			t.Errorf("origin/main = %s, want the branch tip %s unchanged", head, tip)
+		}
+	})
+
+	t.Run("rejects merge commits", func(t *testing.T) {
+		workDir, g, cleanup := testGitRepo(t)
+		defer cleanup()
+		createFeatureBranch(t, workDir, "side", "side.txt", "side\n")
+		createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+		run(t, workDir, "git", "checkout", "-q", "feature-a")
+		run(t, workDir, "git", "merge", "-q", "--no-ff", "-m", "merge side", "side")
+		run(t, workDir, "git", "checkout", "-q", "main")
+		before := originLog(t, workDir, "%H")
+
+		e := newTestEngineer(t, workDir, g)
+		mr := makeMR("mr-a", "feature-a", "main")
+		mr.MergeStrategy = MergeStrategyRebaseFF
+		err := e.mergeMR(mr)
+		if err == nil || !strings.Contains(err.Error(), "not linear") {
+			t.Fatalf("mergeMR = %v, want non-linear history rejected", err)
+		}
+		if head := run(t, workDir, "git", "rev-parse", "HEAD"); head != before[0] {
+			t.Errorf("HEAD moved to %s on a rejected MR", head)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		workDir, g, cleanup := testGitRepo(t)
+		defer cleanup()
+		createConflictingBranch(t, workDir, "feature-a", "README.md", "a\n")
+		createConflictingBranch(t, workDir, "feature-b", "README.md", "b\n")
+
+		e := newTestEngineer(t, workDir, g)
+		a := makeMR("mr-a", "feature-a", "main")
+		b := makeMR("mr-b", "feature-b", "main")
+		b.MergeStrategy = MergeStrategyRebaseFF
+		if err := e.mergeMR(a); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.mergeMR(b); err == nil || !strings.Contains(err.Error(), errMergeConflict.Error()) {
+			t.Fatalf("mergeMR = %v, want a conflict", err)
+		}
+		if status := run(t, workDir, "git", "status", "--porcelain"); status != "" {
+			t.Errorf("cherry-pick not cleaned up:\n%s", status)
+		}
+	})
+}
+
+func TestMergeStrategy_TrainCutsRebasedStack(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createMultiCommitBranch(t, workDir, "feature-a", "a1.txt", "a2.txt", "a3.txt")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "this causes test failure\n")
+	createMultiCommitBranch(t, workDir, "feature-c", "c1.txt", "c2.txt")
+
+	e := newTestEngineer(t, workDir, g)
+	e.SetOutput(io.Discard)
+	e.config.MergeStrategy = MergeStrategyRebaseFF
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+	}
+	result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5, MergeTrain: true})
+	if ids := mrIDs(result.Merged); len(ids) != 1 || ids[0] != "mr-a" {
+		t.Fatalf("merged = %v, want [mr-a] (error %v)", ids, result.Error)
+	}
+	want := "add a3.txt\nadd a2.txt\nadd a1.txt\ninitial commit"
+	if got := strings.Join(originLog(t, workDir, "%s"), "\n"); got != want {
+		t.Errorf("origin/main =\n%s\nwant all of mr-a's commits and nothing else", got)
+	}
+}
+
+func TestMergeStrategy_CherryPick(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+	createFeatureBranch(t, workDir, "side", "side.txt", "side\n")
+	run(t, workDir, "git", "checkout", "-q", "-b", "feature-a", "main")
+	writeFile(t, workDir, "a.txt", "a\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-q", "--author", "Ada <ada@example.com>", "-m", "add a.txt")
+	run(t, workDir, "git", "merge", "-q", "--no-ff", "-m", "merge side", "side")
+	run(t, workDir, "git", "checkout", "-q", "main")
+	originals := strings.Fields(run(t, workDir, "git", "rev-list", "--reverse", "--no-merges", "main..feature-a"))
+
+	e := newTestEngineer(t, workDir, g)
+	a := makeMR("mr-a", "feature-a", "main")
+	a.MergeStrategy = MergeStrategyCherryPick
+	result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-b", "feature-b", "main"), a}, "main", &BatchConfig{MaxBatchSize: 5})
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+	}
+
+	// The merge commit is skipped; the side commit it brought in is picked.
+	if got := originLog(t, workDir, "%s"); len(got) != 4 || got[3] != "initial commit" {
+		t.Errorf("origin/main = %v, want initial, b and the two picked commits", got)
+	}
+	landings, err := e.Landings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var picks []PickedCommit
+	for _, l := range landings {
+		if l.MR == "mr-a" {
+			picks = l.Commits
+		}
+	}
+	if len(picks) != len(originals) {
+		t.Fatalf("landing records %d commits, want %d: %+v", len(picks), len(originals), picks)
+	}
+	for i, p := range picks {
+		if p.Original != originals[i] || p.Landed == p.Original {
+			t.Errorf("pick %d = %+v, want %s recreated on the new target", i, p, originals[i])
+		}
+		author := run(t, workDir, "git", "log", "-1", "--format=%an <%ae>", p.Original)
+		if landed := run(t, workDir, "git", "log", "-1", "--format=%an <%ae>", p.Landed); landed != author {
+			t.Errorf("pick %d author = %q, want %q", i, landed, author)
+		}
+	}
+}
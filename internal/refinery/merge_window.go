@@ -0,0 +1,328 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+	"github.com/steveyegge/gastown/internal/wallclock"
+)
+
+// MergeWindowConfig is a recurring period during which batches may land
+// on its targets. A target with merge windows only lands while one of them
+// is open; outside them the refinery still assembles, stacks and gates
+// batches, but holds the final push (see HeldLanding). Targets no window
+// applies to land at any time.
+//
+//	{"schedule": "0 22 * * mon-fri", "duration": "8h", "timezone": "Europe/Berlin"}
+//	{"schedule": "* 9-16 * * mon-fri", "targets": ["main"]}
+type MergeWindowConfig struct {
+	// Schedule is a five-field cron expression (see wallclock.Cron) in
+	// Timezone. With a Duration, each time it fires opens the window for
+	// Duration; without one, the window is open during every minute it
+	// matches, so "* 9-16 * * mon-fri" is weekdays 09:00-16:59.
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open each time Schedule fires
+	// (e.g., "8h"), up to a week.
+	Duration string `json:"duration,omitempty"`
+
+	// Timezone is the IANA time zone Schedule is in. Default: local.
+	Timezone string `json:"timezone,omitempty"`
+
+	// Targets are the branches the window governs. Default: all targets.
+	Targets []string `json:"targets,omitempty"`
+
+	cron     *wallclock.Cron
+	duration time.Duration
+}
+
+// maxMergeWindowDuration bounds a merge window's duration, which bounds
+// how far back an open window is looked for.
+const maxMergeWindowDuration = 7 * 24 * time.Hour
+
+// parseMergeWindows validates merge windows and parses their schedules.
+func parseMergeWindows(windows []*MergeWindowConfig) error {
+	for i, w := range windows {
+		if w == nil {
+			return fmt.Errorf("merge window %d: empty", i)
+		}
+		c, err := wallclock.ParseCron(w.Schedule, w.Timezone)
+		if err != nil {
+			return fmt.Errorf("merge window %d: %w", i, err)
+		}
+		w.cron = c
+		w.duration = 0
+		if w.Duration != "" {
+			d, err := time.ParseDuration(w.Duration)
+			if err != nil {
+				return fmt.Errorf("merge window %d: invalid duration %q: %w", i, w.Duration, err)
+			}
+			if d <= 0 || d > maxMergeWindowDuration {
+				return fmt.Errorf("merge window %d: duration must be positive and at most %v, got %v", i, maxMergeWindowDuration, d)
+			}
+			w.duration = d
+		}
+		if w.cron.Next(time.Now()).IsZero() {
+			return fmt.Errorf("merge window %d: schedule %q never fires", i, w.Schedule)
+		}
+	}
+	return nil
+}
+
+func (w *MergeWindowConfig) appliesTo(target string) bool {
+	if len(w.Targets) == 0 {
+		return true
+	}
+	for _, t := range w.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Open reports whether the window is open at now.
+func (w *MergeWindowConfig) Open(now time.Time) bool {
+	if w.cron == nil {
+		return false
+	}
+	if w.duration == 0 {
+		return w.cron.Matches(now)
+	}
+	start, ok := w.cron.Latest(now, w.duration)
+	return ok && now.Before(start.Add(w.duration))
+}
+
+// NextOpen returns when the window next opens after now.
+func (w *MergeWindowConfig) NextOpen(now time.Time) time.Time {
+	if w.cron == nil {
+		return time.Time{}
+	}
+	return w.cron.Next(now)
+}
+
+func (w *MergeWindowConfig) String() string {
+	if w.cron == nil {
+		return w.Schedule
+	}
+	if w.duration > 0 {
+		return fmt.Sprintf("%s for %v", w.cron, w.duration)
+	}
+	return w.cron.String()
+}
+
+// MergeWindowClosed reports whether target has merge windows and none is
+// open at now, and if so when the first of them opens next.
+func (e *Engineer) MergeWindowClosed(target string, now time.Time) (closed bool, opens time.Time) {
+	for _, w := range e.config.MergeWindows {
+		if !w.appliesTo(target) {
+			continue
+		}
+		if w.Open(now) {
+			return false, time.Time{}
+		}
+		closed = true
+		if next := w.NextOpen(now); !next.IsZero() && (opens.IsZero() || next.Before(opens)) {
+			opens = next
+		}
+	}
+	return closed, opens
+}
+
+// HeldLanding is a set of MRs that passed gates on a target while its
+// merge windows were closed. While the target and the MRs' branches stay
+// where they were, the MRs aren't gated again: they stay queued until a
+// window opens, then land on the strength of that validation.
+type HeldLanding struct {
+	BatchID string            `json:"batch_id,omitempty"`
+	Target  string            `json:"target"`
+	Base    string            `json:"base"`  // origin/<target> the MRs were gated on
+	Heads   map[string]string `json:"heads"` // MR ID → its branch head when gated
+	HeldAt  time.Time         `json:"held_at"`
+	Opens   time.Time         `json:"opens,omitempty"` // When a window was next due to open
+}
+
+// MRs returns the IDs of the held MRs, sorted.
+func (h *HeldLanding) MRs() []string {
+	ids := make([]string, 0, len(h.Heads))
+	for id := range h.Heads {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (e *Engineer) heldLandingsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "merge-window-holds.json")
+}
+
+// HeldLandings returns the rig's held landings by target.
+func (e *Engineer) HeldLandings() (map[string]*HeldLanding, error) {
+	holds := make(map[string]*HeldLanding)
+	if e.rig == nil {
+		return holds, nil
+	}
+	data, err := os.ReadFile(e.heldLandingsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return holds, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &holds); err != nil {
+		return nil, fmt.Errorf("parsing held landings: %w", err)
+	}
+	return holds, nil
+}
+
+// updateHeldLandings applies update to the held landings and saves them.
+func (e *Engineer) updateHeldLandings(update func(map[string]*HeldLanding)) {
+	if e.rig == nil {
+		return
+	}
+	unlock := e.lockState()
+	defer unlock()
+	holds, err := e.HeldLandings()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Window] Warning: %v\n", err)
+		holds = make(map[string]*HeldLanding)
+	}
+	update(holds)
+	if err := util.EnsureDirAndWriteJSON(e.heldLandingsPath(), holds); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Window] Warning: saving held landings: %v\n", err)
+	}
+}
+
+// branchHeads returns the head of each MR's branch.
+func (e *Engineer) branchHeads(mrs []*MRInfo) (map[string]string, error) {
+	heads := make(map[string]string, len(mrs))
+	for _, mr := range mrs {
+		sha, err := e.git.Rev(mr.Branch)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", mr.Branch, err)
+		}
+		heads[mr.ID] = sha
+	}
+	return heads, nil
+}
+
+// holdLanding resets target, whose tree holds the validated mrs, back to
+// origin and records the hold.
+func (e *Engineer) holdLanding(mrs []*MRInfo, target, batchID string, opens time.Time) {
+	base, baseErr := e.git.Rev("origin/" + target)
+	if resetErr := e.git.ResetHard("origin/" + target); resetErr != nil {
+		_, _ = fmt.Fprintf(e.output, "[Window] Warning: failed to reset %s after holding: %v\n", target, resetErr)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Window] Merge window for %s closed (%s): holding %d validated MRs: %s\n",
+		target, heldUntil(opens), len(mrs), strings.Join(mrIDs(mrs), ", "))
+	e.recordProgress(StageDeferred, "validated, held for merge window "+heldUntil(opens), batchID, mrs...)
+
+	heads, err := e.branchHeads(mrs)
+	if baseErr != nil || err != nil {
+		// Still held; they'll be gated again when the window opens.
+		return
+	}
+	e.updateHeldLandings(func(holds map[string]*HeldLanding) {
+		holds[target] = &HeldLanding{BatchID: batchID, Target: target, Base: base, Heads: heads, HeldAt: time.Now().UTC(), Opens: opens}
+	})
+}
+
+// heldValidation returns the held landing of target if it is exactly mrs
+// and neither the target nor their branches have moved since it was
+// validated, or nil. The target is fetched first, as landing on the
+// strength of a stale base would skip gating the tree that lands.
+func (e *Engineer) heldValidation(mrs []*MRInfo, target string) *HeldLanding {
+	holds, err := e.HeldLandings()
+	if err != nil || holds[target] == nil || len(mrs) != len(holds[target].Heads) {
+		return nil
+	}
+	h := holds[target]
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		return nil
+	}
+	if base, err := e.git.Rev("origin/" + target); err != nil || base != h.Base {
+		return nil
+	}
+	heads, err := e.branchHeads(mrs)
+	if err != nil {
+		return nil
+	}
+	for id, sha := range heads {
+		if h.Heads[id] != sha {
+			return nil
+		}
+	}
+	return h
+}
+
+// clearHeldLanding forgets target's held landing once something lands.
+func (e *Engineer) clearHeldLanding(target string) {
+	if holds, err := e.HeldLandings(); err != nil || holds[target] == nil {
+		return
+	}
+	e.updateHeldLandings(func(holds map[string]*HeldLanding) { delete(holds, target) })
+}
+
+// checkMergeWindow runs before mrs for target are gated. If its windows
+// are closed and mrs are a held landing still valid, they are held again
+// without gating. If the windows are open and they are, the held landing
+// is returned: its gates needn't run again.
+func (e *Engineer) checkMergeWindow(mrs []*MRInfo, target string) (held bool, prevalidated *HeldLanding) {
+	if len(e.config.MergeWindows) == 0 {
+		return false, nil
+	}
+	closed, opens := e.MergeWindowClosed(target, time.Now())
+	h := e.heldValidation(mrs, target)
+	if h == nil {
+		return false, nil
+	}
+	if !closed {
+		_, _ = fmt.Fprintf(e.output, "[Window] Merge window for %s open: landing %d MRs validated at %s without gating again\n",
+			target, len(mrs), h.HeldAt.Local().Format(time.RFC3339))
+		return false, h
+	}
+	msg := "validated, held for merge window " + heldUntil(opens)
+	_, _ = fmt.Fprintf(e.output, "[Window] Merge window for %s closed (%s): %d validated MRs stay held\n", target, heldUntil(opens), len(mrs))
+	e.recordProgress(StageDeferred, msg, h.BatchID, mrs...)
+	return true, nil
+}
+
+func heldUntil(opens time.Time) string {
+	if opens.IsZero() {
+		return "no window scheduled"
+	}
+	return "until " + opens.Format(time.RFC3339)
+}
+
+type prevalidatedKey struct{}
+
+// withPrevalidated marks ctx as processing the held landing h, whose MRs
+// land without gating again.
+func withPrevalidated(ctx context.Context, h *HeldLanding) context.Context {
+	if h == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, prevalidatedKey{}, h)
+}
+
+// prevalidated reports whether mrs are exactly the held landing ctx is
+// processing. Split or trimmed batches are gated as usual.
+func prevalidated(ctx context.Context, mrs []*MRInfo) bool {
+	h, _ := ctx.Value(prevalidatedKey{}).(*HeldLanding)
+	if h == nil || len(mrs) != len(h.Heads) {
+		return false
+	}
+	for _, mr := range mrs {
+		if _, ok := h.Heads[mr.ID]; !ok {
+			return false
+		}
+	}
+	return true
+}
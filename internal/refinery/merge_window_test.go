@@ -0,0 +1,156 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_MergeWindows(t *testing.T) {
+	e := newTestEngineer(t, t.TempDir(), nil)
+	err := applyMergeQueueConfig(e.config, []byte(`{"merge_windows": [
+		{"schedule": "0 22 * * mon-fri", "duration": "8h", "timezone": "UTC", "targets": ["main"]},
+		{"schedule": "* 12 * * sat", "timezone": "UTC", "targets": ["release"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("applyMergeQueueConfig: %v", err)
+	}
+
+	fri := func(h int) time.Time { return time.Date(2026, time.March, 6, h, 30, 0, 0, time.UTC) }
+	for _, tt := range []struct {
+		target string
+		at     time.Time
+		closed bool
+	}{
+		{"main", fri(23), false},                        // Friday's window
+		{"main", fri(23).Add(5 * time.Hour), false},     // Still open Saturday 04:30
+		{"main", fri(23).Add(9 * time.Hour), true},      // Closed Saturday 08:30
+		{"main", fri(12), true},                         // Before Friday's window
+		{"release", fri(12).Add(24 * time.Hour), false}, // Saturday noon
+		{"release", fri(13).Add(24 * time.Hour), true},  // Saturday 13:30
+		{"feature", fri(12), false},                     // No window applies
+	} {
+		if closed, _ := e.MergeWindowClosed(tt.target, tt.at); closed != tt.closed {
+			t.Errorf("MergeWindowClosed(%s, %v) = %v, want %v", tt.target, tt.at, closed, tt.closed)
+		}
+	}
+	if _, opens := e.MergeWindowClosed("main", fri(12)); !opens.Equal(fri(22).Add(-30 * time.Minute)) {
+		t.Errorf("main opens at %v, want Friday 22:00", opens)
+	}
+
+	for _, bad := range []string{
+		`[{"schedule": "0 22 * *"}]`,
+		`[{"schedule": "0 22 * * *", "duration": "0s"}]`,
+		`[{"schedule": "0 22 * * *", "duration": "200h"}]`,
+		`[{"schedule": "0 22 * * *", "timezone": "Nowhere/Special"}]`,
+		`[{"schedule": "0 0 31 2 *"}]`,
+	} {
+		if err := applyMergeQueueConfig(DefaultMergeQueueConfig(), []byte(`{"merge_windows": `+bad+`}`)); err == nil {
+			t.Errorf("merge_windows %s accepted", bad)
+		}
+	}
+}
+
+// countingGate returns a gate command that counts its runs in a file
+// outside the repo.
+func countingGate(t *testing.T) (cmd string, runs func() int) {
+	t.Helper()
+	counter := filepath.Join(t.TempDir(), "runs")
+	return "echo run >> " + counter, func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+}
+
+func TestProcessBatch_HoldsOutsideMergeWindow(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	gate, runs := countingGate(t)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: gate}}
+	// Open for one minute a year.
+	if err := applyMergeQueueConfig(e.config, []byte(`{"merge_windows": [{"schedule": "@yearly"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := g.Rev("origin/main")
+	batch := func() []*MRInfo {
+		return []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	}
+
+	result := e.ProcessBatch(context.Background(), batch(), "main", &BatchConfig{MaxBatchSize: 5})
+	if result.Error != nil || len(result.Merged) != 0 || len(result.Deferred) != 2 {
+		t.Fatalf("closed window: merged %d, deferred %d, err %v; want 2 deferred", len(result.Merged), len(result.Deferred), result.Error)
+	}
+	if after, _ := g.Rev("origin/main"); after != before {
+		t.Error("origin/main moved while the merge window was closed")
+	}
+	holds, err := e.HeldLandings()
+	if err != nil || holds["main"] == nil || strings.Join(holds["main"].MRs(), ",") != "mr-a,mr-b" || holds["main"].Base != before {
+		t.Fatalf("HeldLandings() = %+v, %v; want mr-a and mr-b held on %s", holds, err, before)
+	}
+	if runs() != 1 {
+		t.Fatalf("gate ran %d times, want 1", runs())
+	}
+
+	// Polled again while closed: still held, without gating again.
+	result = e.ProcessBatch(context.Background(), batch(), "main", &BatchConfig{MaxBatchSize: 5})
+	if len(result.Deferred) != 2 || runs() != 1 {
+		t.Fatalf("second pass: deferred %d, gate runs %d; want 2 held and no new run", len(result.Deferred), runs())
+	}
+
+	// The window opens: the batch lands on its earlier validation.
+	e.config.MergeWindows = nil
+	if err := applyMergeQueueConfig(e.config, []byte(`{"merge_windows": [{"schedule": "* * * * *"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	result = e.ProcessBatch(context.Background(), batch(), "main", &BatchConfig{MaxBatchSize: 5})
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("open window: merged %d, err %v; want 2", len(result.Merged), result.Error)
+	}
+	if runs() != 1 {
+		t.Errorf("gate ran %d times, want the held validation reused", runs())
+	}
+	if holds, _ := e.HeldLandings(); holds["main"] != nil {
+		t.Errorf("hold not cleared after landing: %+v", holds["main"])
+	}
+}
+
+func TestProcessBatch_RegatesHoldWhenTargetMoves(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	gate, runs := countingGate(t)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: gate}}
+	if err := applyMergeQueueConfig(e.config, []byte(`{"merge_windows": [{"schedule": "@yearly"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	mr := func() []*MRInfo { return []*MRInfo{makeMR("mr-a", "feature-a", "main")} }
+
+	if result := e.ProcessBatch(context.Background(), mr(), "main", nil); len(result.Deferred) != 1 || runs() != 1 {
+		t.Fatalf("closed window: deferred %d, gate runs %d; want 1 held after 1 run", len(result.Deferred), runs())
+	}
+
+	// Someone else lands on main: the held validation no longer holds.
+	writeFile(t, workDir, "other.txt", "other\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "other change")
+	run(t, workDir, "git", "push", "origin", "main")
+
+	if err := applyMergeQueueConfig(e.config, []byte(`{"merge_windows": [{"schedule": "* * * * *"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if result := e.ProcessBatch(context.Background(), mr(), "main", nil); len(result.Merged) != 1 {
+		t.Fatalf("open window: merged %d, err %v; want 1", len(result.Merged), result.Error)
+	}
+	if runs() != 2 {
+		t.Errorf("gate ran %d times, want the MR gated again on the moved target", runs())
+	}
+}
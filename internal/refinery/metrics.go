@@ -0,0 +1,408 @@
+package refinery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+type metricKind string
+
+const (
+	metricCounter   metricKind = "counter"
+	metricGauge     metricKind = "gauge"
+	metricHistogram metricKind = "histogram"
+)
+
+type metricDef struct {
+	kind    metricKind
+	help    string
+	buckets []float64 // Upper bounds, for histograms
+}
+
+var durationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+// refineryMetrics are the metrics the refinery records, every series
+// labeled with its rig and target branch. Conflict and culprit rates are
+// shares of gastown_refinery_mrs_total, e.g.
+//
+//	sum(rate(gastown_refinery_mrs_total{result="conflict"}[1d])) / sum(rate(gastown_refinery_mrs_total[1d]))
+var refineryMetrics = map[string]metricDef{
+	"gastown_refinery_queue_depth": {kind: metricGauge,
+		help: "MRs ready to merge when the last batch was assembled."},
+	"gastown_refinery_batches_total": {kind: metricCounter,
+		help: "Batches processed, by outcome (landed, partial, failed, conflicted, deferred, error, cancelled)."},
+	"gastown_refinery_batch_size": {kind: metricHistogram,
+		help:    "MRs per processed batch.",
+		buckets: []float64{1, 2, 3, 4, 6, 8, 12, 16, 24, 32}},
+	"gastown_refinery_batch_duration_seconds": {kind: metricHistogram,
+		help:    "Time to process a batch, from assembly to landing or rejection.",
+		buckets: durationBuckets},
+	"gastown_refinery_gate_duration_seconds": {kind: metricHistogram,
+		help:    "Time a gate took to run, by gate.",
+		buckets: durationBuckets},
+	"gastown_refinery_mrs_total": {kind: metricCounter,
+		help: "MRs settled by processed batches, by result (merged, culprit, conflict, deferred)."},
+	"gastown_refinery_bisections_total": {kind: metricCounter,
+		help: "Bisections run to isolate the culprits of a failing batch, by strategy."},
+	"gastown_refinery_bisection_duration_seconds": {kind: metricHistogram,
+		help:    "Time a bisection took.",
+		buckets: durationBuckets},
+	"gastown_refinery_time_to_land_seconds": {kind: metricHistogram,
+		help:    "Time from an MR's submission to its landing.",
+		buckets: []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 86400, 259200}},
+}
+
+// Metrics is a registry of refinery metrics in the Prometheus data model.
+// Engineers record into an in-memory Metrics and merge it into the rig's
+// persisted registry after each batch (see flushMetrics), since the
+// refinery runs as a series of short-lived processes; the daemon serves
+// every rig's persisted registry from one endpoint (see WritePrometheus).
+type Metrics struct {
+	mu     sync.Mutex
+	Series map[string]*MetricSeries `json:"series"` // Keyed by name and labels
+}
+
+// MetricSeries is one labeled series of a metric. Buckets holds a
+// histogram's per-bucket counts (not cumulative), the last one for
+// observations above every bound.
+type MetricSeries struct {
+	Name    string            `json:"name"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Value   float64           `json:"value,omitempty"`
+	Buckets []uint64          `json:"buckets,omitempty"`
+	Count   uint64            `json:"count,omitempty"`
+	Sum     float64           `json:"sum,omitempty"`
+}
+
+func seriesKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", k, labels[k])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func (m *Metrics) series(name string, labels map[string]string) *MetricSeries {
+	if m.Series == nil {
+		m.Series = make(map[string]*MetricSeries)
+	}
+	key := seriesKey(name, labels)
+	s := m.Series[key]
+	if s == nil {
+		s = &MetricSeries{Name: name, Labels: labels}
+		if def := refineryMetrics[name]; def.kind == metricHistogram {
+			s.Buckets = make([]uint64, len(def.buckets)+1)
+		}
+		m.Series[key] = s
+	}
+	return s
+}
+
+// add increments a counter.
+func (m *Metrics) add(name string, labels map[string]string, v float64) {
+	if m == nil || v == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.series(name, labels).Value += v
+}
+
+// set sets a gauge.
+func (m *Metrics) set(name string, labels map[string]string, v float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.series(name, labels).Value = v
+}
+
+// observe records an observation in a histogram.
+func (m *Metrics) observe(name string, labels map[string]string, v float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.series(name, labels)
+	bounds := refineryMetrics[name].buckets
+	i := sort.SearchFloat64s(bounds, v)
+	s.Buckets[i]++
+	s.Count++
+	s.Sum += v
+}
+
+// merge adds delta's counters and histograms into m and takes its gauges.
+func (m *Metrics) merge(delta *Metrics) {
+	for key, d := range delta.Series {
+		s := m.Series[key]
+		if s == nil {
+			if m.Series == nil {
+				m.Series = make(map[string]*MetricSeries)
+			}
+			c := *d
+			c.Buckets = append([]uint64(nil), d.Buckets...)
+			m.Series[key] = &c
+			continue
+		}
+		switch refineryMetrics[d.Name].kind {
+		case metricGauge:
+			s.Value = d.Value
+		case metricHistogram:
+			if len(s.Buckets) != len(d.Buckets) {
+				// Bucket bounds changed since s was persisted: start over.
+				s.Buckets = make([]uint64, len(d.Buckets))
+				s.Count, s.Sum = 0, 0
+			}
+			for i, n := range d.Buckets {
+				s.Buckets[i] += n
+			}
+			s.Count += d.Count
+			s.Sum += d.Sum
+		default:
+			s.Value += d.Value
+		}
+	}
+}
+
+// MetricsPath returns the persisted refinery metrics of the rig at rigPath.
+func MetricsPath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "refinery-metrics.json")
+}
+
+// LoadMetrics returns the persisted refinery metrics of the rig at
+// rigPath, empty if it has none yet.
+func LoadMetrics(rigPath string) (*Metrics, error) {
+	m := &Metrics{}
+	data, err := os.ReadFile(MetricsPath(rigPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading refinery metrics: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing refinery metrics: %w", err)
+	}
+	return m, nil
+}
+
+// WritePrometheus writes the series of registries in the Prometheus text
+// exposition format. Series of the same name from several registries (one
+// per rig) are grouped under one HELP and TYPE.
+func WritePrometheus(w io.Writer, registries ...*Metrics) error {
+	byName := make(map[string][]*MetricSeries)
+	for _, m := range registries {
+		if m == nil {
+			continue
+		}
+		m.mu.Lock()
+		for _, s := range m.Series {
+			if _, ok := refineryMetrics[s.Name]; ok {
+				c := *s
+				byName[s.Name] = append(byName[s.Name], &c)
+			}
+		}
+		m.mu.Unlock()
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		def := refineryMetrics[name]
+		series := byName[name]
+		sort.Slice(series, func(i, j int) bool {
+			return seriesKey(name, series[i].Labels) < seriesKey(name, series[j].Labels)
+		})
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, def.help, name, def.kind)
+		for _, s := range series {
+			if def.kind != metricHistogram {
+				fmt.Fprintf(&b, "%s%s %s\n", name, promLabels(s.Labels, ""), promFloat(s.Value))
+				continue
+			}
+			var cum uint64
+			for i, n := range s.Buckets {
+				cum += n
+				le := "+Inf"
+				if i < len(def.buckets) {
+					le = promFloat(def.buckets[i])
+				}
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, promLabels(s.Labels, le), cum)
+			}
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, promLabels(s.Labels, ""), promFloat(s.Sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, promLabels(s.Labels, ""), s.Count)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func promLabels(labels map[string]string, le string) string {
+	keys := make([]string, 0, len(labels)+1)
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if le != "" {
+		keys = append(keys, "le")
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		v := labels[k]
+		if k == "le" {
+			v = le
+		}
+		parts[i] = k + `="` + promEscaper.Replace(v) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func promFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// metricLabels returns the labels of a series about target, plus extra
+// name/value pairs.
+func (e *Engineer) metricLabels(target string, extra ...string) map[string]string {
+	labels := map[string]string{"target": target}
+	if e.rig != nil {
+		labels["rig"] = e.rig.Name
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		labels[extra[i]] = extra[i+1]
+	}
+	return labels
+}
+
+// recordQueueDepth records how many MRs were ready when a batch for target
+// was assembled.
+func (e *Engineer) recordQueueDepth(target string, ready int) {
+	e.metrics.set("gastown_refinery_queue_depth", e.metricLabels(target), float64(ready))
+	e.flushMetrics()
+}
+
+// recordBatchMetrics records a processed batch: its outcome and size, how
+// long it and its gates took, what became of its MRs, and how long the
+// landed ones waited.
+func (e *Engineer) recordBatchMetrics(batch []*MRInfo, target string, started time.Time, rec *batchRecorder, result *BatchResult) {
+	if len(batch) == 0 && len(result.Deferred) == 0 {
+		return
+	}
+	m := e.metrics
+	now := time.Now()
+	m.add("gastown_refinery_batches_total", e.metricLabels(target, "outcome", batchOutcome(result)), 1)
+	m.observe("gastown_refinery_batch_size", e.metricLabels(target), float64(len(batch)))
+	m.observe("gastown_refinery_batch_duration_seconds", e.metricLabels(target), now.Sub(started).Seconds())
+	for res, mrs := range map[string][]*MRInfo{
+		"merged": result.Merged, "culprit": result.Culprits, "conflict": result.Conflicts, "deferred": result.Deferred,
+	} {
+		m.add("gastown_refinery_mrs_total", e.metricLabels(target, "result", res), float64(len(mrs)))
+	}
+	for _, mr := range result.Merged {
+		if !mr.CreatedAt.IsZero() {
+			m.observe("gastown_refinery_time_to_land_seconds", e.metricLabels(target), now.Sub(mr.CreatedAt).Seconds())
+		}
+	}
+	rec.mu.Lock()
+	for _, run := range rec.gateRuns {
+		for _, g := range run.Gates {
+			if g.SkippedBy == "" && !g.Unaffected {
+				m.observe("gastown_refinery_gate_duration_seconds", e.metricLabels(target, "gate", g.Name), float64(g.ElapsedMs)/1000)
+			}
+		}
+	}
+	rec.mu.Unlock()
+	e.flushMetrics()
+}
+
+// batchOutcome classifies a processed batch for gastown_refinery_batches_total.
+func batchOutcome(result *BatchResult) string {
+	switch {
+	case result.Cancelled:
+		return "cancelled"
+	case result.Error != nil:
+		return "error"
+	case len(result.Merged) > 0 && len(result.Culprits)+len(result.Conflicts) == 0:
+		return "landed"
+	case len(result.Merged) > 0:
+		return "partial"
+	case len(result.Culprits) > 0:
+		return "failed"
+	case len(result.Conflicts) > 0:
+		return "conflicted"
+	}
+	return "deferred"
+}
+
+// flushMetrics merges what was recorded since the last flush into the
+// rig's persisted metrics. Failures are logged and the recordings kept for
+// the next flush.
+func (e *Engineer) flushMetrics() {
+	if e.rig == nil || e.rig.Path == "" || e.metrics == nil {
+		return
+	}
+	delta := e.metrics
+	delta.mu.Lock()
+	defer delta.mu.Unlock()
+	if len(delta.Series) == 0 {
+		return
+	}
+	path := MetricsPath(e.rig.Path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: saving refinery metrics: %v\n", err)
+		return
+	}
+	// Refinery processes of the rig, and gt commands, may flush at once.
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: saving refinery metrics: %v\n", err)
+		return
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	persisted, err := LoadMetrics(e.rig.Path)
+	if err != nil {
+		// An unreadable registry would fail every flush: start over.
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v; resetting it\n", err)
+		persisted = &Metrics{}
+	}
+	persisted.merge(delta)
+	if err := util.AtomicWriteJSON(path, persisted); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: saving refinery metrics: %v\n", err)
+		return
+	}
+	delta.Series = nil
+}
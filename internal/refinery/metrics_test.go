@@ -0,0 +1,64 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_RecordedAndPersisted(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: failMarkerGateCmd()}}
+
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+	a, b := makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")
+	a.CreatedAt = time.Now().Add(-10 * time.Minute)
+	batch := e.AssembleBatch([]*MRInfo{a, b}, cfg)
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if len(result.Merged) != 1 || len(result.Culprits) != 1 {
+		t.Fatalf("merged %v, culprits %v", mrIDs(result.Merged), mrIDs(result.Culprits))
+	}
+
+	// A later process's recordings add to what's persisted.
+	other := newTestEngineer(t, workDir, g)
+	other.recordQueueDepth("main", 7)
+	other.metrics.add("gastown_refinery_mrs_total", other.metricLabels("main", "result", "merged"), 2)
+	other.flushMetrics()
+
+	m, err := LoadMetrics(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE gastown_refinery_batch_size histogram\n",
+		`gastown_refinery_queue_depth{rig="test-rig",target="main"} 7`,
+		`gastown_refinery_batches_total{outcome="partial",rig="test-rig",target="main"} 1`,
+		`gastown_refinery_batch_size_bucket{rig="test-rig",target="main",le="1"} 0`,
+		`gastown_refinery_batch_size_bucket{rig="test-rig",target="main",le="2"} 1`,
+		`gastown_refinery_batch_size_count{rig="test-rig",target="main"} 1`,
+		`gastown_refinery_mrs_total{result="merged",rig="test-rig",target="main"} 3`,
+		`gastown_refinery_mrs_total{result="culprit",rig="test-rig",target="main"} 1`,
+		`gastown_refinery_bisections_total{rig="test-rig",strategy="binary",target="main"} 1`,
+		`gastown_refinery_time_to_land_seconds_bucket{rig="test-rig",target="main",le="300"} 0`,
+		`gastown_refinery_time_to_land_seconds_bucket{rig="test-rig",target="main",le="900"} 1`,
+		`gastown_refinery_gate_duration_seconds_count{gate="test",rig="test-rig",target="main"} `,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "# TYPE gastown_refinery_mrs_total") != 1 {
+		t.Errorf("metric family repeated:\n%s", out)
+	}
+}
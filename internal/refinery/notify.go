@@ -0,0 +1,261 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/desktop"
+)
+
+// WebhookConfig is a URL notified of every processed batch.
+type WebhookConfig struct {
+	URL string `json:"url"`
+
+	// Secret signs each delivery: the X-Gastown-Signature header carries
+	// "sha256=" and the hex HMAC-SHA256 of the body under this key.
+	// SecretEnv names an environment variable holding the secret instead,
+	// keeping it out of the rig's config.json.
+	Secret    string `json:"secret,omitempty"`
+	SecretEnv string `json:"secret_env,omitempty"`
+
+	// OnlyMerged skips batches that landed nothing.
+	OnlyMerged bool `json:"only_merged,omitempty"`
+
+	// Retries is how many times a failed delivery is retried, with
+	// doubling backoff. Default: 3.
+	Retries *int `json:"retries,omitempty"`
+
+	// Timeout bounds each delivery attempt. Default: 10s.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+type webhookConfigRaw struct {
+	WebhookConfig
+	Timeout string `json:"timeout"`
+}
+
+const (
+	defaultWebhookRetries = 3
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// webhookRetryDelay is the wait before the first retry; it doubles after
+// each attempt.
+var webhookRetryDelay = time.Second
+
+// WebhookEventBatch is the X-Gastown-Event of batch notifications.
+const WebhookEventBatch = "batch.completed"
+
+// BatchNotification is the JSON body POSTed to webhooks after a batch.
+type BatchNotification struct {
+	Event       string           `json:"event"`
+	Rig         string           `json:"rig"`
+	Target      string           `json:"target"`
+	BatchID     string           `json:"batch_id,omitempty"`
+	Time        time.Time        `json:"time"`
+	Merged      []NotificationMR `json:"merged"`
+	Conflicts   []NotificationMR `json:"conflicts"`
+	Culprits    []NotificationMR `json:"culprits"`
+	Deferred    []NotificationMR `json:"deferred"`
+	MergeCommit string           `json:"merge_commit,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// NotificationMR describes an MR in a BatchNotification.
+type NotificationMR struct {
+	ID          string `json:"id"`
+	Branch      string `json:"branch"`
+	SourceIssue string `json:"source_issue,omitempty"`
+	Worker      string `json:"worker,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// parseWebhooks converts the JSON webhook configs, parsing their timeouts.
+func parseWebhooks(raws []*webhookConfigRaw) ([]*WebhookConfig, error) {
+	hooks := make([]*WebhookConfig, 0, len(raws))
+	for i, raw := range raws {
+		if raw == nil {
+			continue
+		}
+		hook := raw.WebhookConfig
+		if hook.URL == "" {
+			return nil, fmt.Errorf("webhook %d: url is required", i)
+		}
+		if hook.Retries != nil && *hook.Retries < 0 {
+			return nil, fmt.Errorf("webhook %s: retries must be non-negative, got %d", hook.URL, *hook.Retries)
+		}
+		if raw.Timeout != "" {
+			dur, err := time.ParseDuration(raw.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for webhook %s: %w", hook.URL, err)
+			}
+			if dur <= 0 {
+				return nil, fmt.Errorf("webhook %s: timeout must be positive, got %v", hook.URL, dur)
+			}
+			hook.Timeout = dur
+		}
+		hooks = append(hooks, &hook)
+	}
+	return hooks, nil
+}
+
+func (h *WebhookConfig) retries() int {
+	if h.Retries != nil {
+		return *h.Retries
+	}
+	return defaultWebhookRetries
+}
+
+func (h *WebhookConfig) timeout() time.Duration {
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+	return defaultWebhookTimeout
+}
+
+func (h *WebhookConfig) secret() string {
+	if h.SecretEnv != "" {
+		return os.Getenv(h.SecretEnv)
+	}
+	return h.Secret
+}
+
+// SignWebhookBody returns the X-Gastown-Signature value for body, for
+// receivers verifying deliveries.
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func notificationMRs(mrs []*MRInfo) []NotificationMR {
+	out := make([]NotificationMR, 0, len(mrs))
+	for _, mr := range mrs {
+		out = append(out, NotificationMR{
+			ID:          mr.ID,
+			Branch:      mr.Branch,
+			SourceIssue: mr.SourceIssue,
+			Worker:      mr.Worker,
+			Title:       mr.Title,
+		})
+	}
+	return out
+}
+
+// notifyWebhooks POSTs a summary of a processed batch to every configured
+// webhook. Failed deliveries are retried, then logged; they never affect
+// the batch.
+func (e *Engineer) notifyWebhooks(ctx context.Context, result *BatchResult, target string) {
+	if len(e.config.Webhooks) == 0 || result == nil {
+		return
+	}
+	n := &BatchNotification{
+		Event:       WebhookEventBatch,
+		Rig:         e.rig.Name,
+		Target:      target,
+		BatchID:     result.BatchID,
+		Time:        time.Now().UTC(),
+		Merged:      notificationMRs(result.Merged),
+		Conflicts:   notificationMRs(result.Conflicts),
+		Culprits:    notificationMRs(result.Culprits),
+		Deferred:    notificationMRs(result.Deferred),
+		MergeCommit: result.MergeCommit,
+	}
+	if result.Error != nil {
+		n.Error = result.Error.Error()
+	}
+	body, err := json.Marshal(n)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Notify] Warning: %v\n", err)
+		return
+	}
+	for _, hook := range e.config.Webhooks {
+		if hook.OnlyMerged && len(result.Merged) == 0 {
+			continue
+		}
+		if err := deliverWebhook(ctx, hook, result.BatchID, body); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Notify] Warning: webhook %s: %v\n", hook.URL, err)
+		}
+	}
+}
+
+// deliverWebhook POSTs body to hook, retrying network errors, 429s and 5xx
+// responses. Other 4xx responses are not retried.
+func deliverWebhook(ctx context.Context, hook *WebhookConfig, deliveryID string, body []byte) error {
+	delay := webhookRetryDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = postWebhook(ctx, hook, deliveryID, body)
+		if err == nil || !retry || attempt >= hook.retries() {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func postWebhook(ctx context.Context, hook *WebhookConfig, deliveryID string, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, hook.timeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gastown-Event", WebhookEventBatch)
+	if deliveryID != "" {
+		req.Header.Set("X-Gastown-Delivery", deliveryID)
+	}
+	if secret := hook.secret(); secret != "" {
+		req.Header.Set("X-Gastown-Signature", SignWebhookBody(secret, body))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+// notifyBlocked raises a desktop notification for a batch that ended in an
+// error rather than a verdict: nothing landed, and the queue needs a human.
+func (e *Engineer) notifyBlocked(result *BatchResult, target string) {
+	if result == nil || result.Error == nil {
+		return
+	}
+	e.notifyDesktop(config.DesktopEventBatchBlocked, "Batch blocked on "+target,
+		fmt.Sprintf("%s: %v", e.rig.Name, result.Error))
+}
+
+// notifyDesktop shows a desktop notification if the town has them on for
+// event (see desktop.NotifyEvent). Failures are logged.
+func (e *Engineer) notifyDesktop(event, title, body string) {
+	if e.rig == nil {
+		return
+	}
+	townRoot := filepath.Dir(e.rig.Path)
+	if err := desktop.NotifyEvent(townRoot, event, desktop.Notification{Title: title, Body: body}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Notify] Warning: desktop notification: %v\n", err)
+	}
+}
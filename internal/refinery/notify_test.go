@@ -0,0 +1,180 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestEngineer_LoadConfig_Webhooks(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"webhooks": [
+		{"url": "https://ci.example/hook", "secret_env": "HOOK_SECRET", "timeout": "5s", "retries": 1},
+		{"url": "https://chat.example/hook", "only_merged": true}
+	]}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	hooks := e.config.Webhooks
+	if len(hooks) != 2 {
+		t.Fatalf("got %d webhooks, want 2", len(hooks))
+	}
+	if hooks[0].timeout() != 5*time.Second || hooks[0].retries() != 1 || hooks[0].SecretEnv != "HOOK_SECRET" {
+		t.Errorf("first webhook = %+v", hooks[0])
+	}
+	if !hooks[1].OnlyMerged || hooks[1].timeout() != defaultWebhookTimeout || hooks[1].retries() != defaultWebhookRetries {
+		t.Errorf("second webhook = %+v", hooks[1])
+	}
+
+	for _, bad := range []string{
+		`[{"secret": "x"}]`,
+		`[{"url": "https://x", "timeout": "soon"}]`,
+		`[{"url": "https://x", "retries": -1}]`,
+	} {
+		data := []byte(`{"merge_queue": {"webhooks": ` + bad + `}}`)
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+			t.Errorf("LoadConfig accepted webhooks %s", bad)
+		}
+	}
+}
+
+func newNotifyEngineer(t *testing.T, hooks ...*WebhookConfig) *Engineer {
+	t.Helper()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.SetOutput(&bytes.Buffer{})
+	e.config.Webhooks = hooks
+	old := webhookRetryDelay
+	webhookRetryDelay = time.Millisecond
+	t.Cleanup(func() { webhookRetryDelay = old })
+	return e
+}
+
+func TestNotifyWebhooks_SignedSummary(t *testing.T) {
+	var body []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer srv.Close()
+
+	e := newNotifyEngineer(t, &WebhookConfig{URL: srv.URL, Secret: "s3cret"})
+	e.notifyWebhooks(context.Background(), &BatchResult{
+		BatchID:     "batch-1",
+		Merged:      []*MRInfo{makeMR("mr-a", "polecat/a", "main")},
+		Culprits:    []*MRInfo{makeMR("mr-b", "polecat/b", "main")},
+		MergeCommit: "abc123",
+	}, "main")
+
+	if got, want := header.Get("X-Gastown-Signature"), SignWebhookBody("s3cret", body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if header.Get("X-Gastown-Event") != WebhookEventBatch || header.Get("X-Gastown-Delivery") != "batch-1" {
+		t.Errorf("headers = %v", header)
+	}
+	var n BatchNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if n.Rig != "test-rig" || n.Target != "main" || n.MergeCommit != "abc123" ||
+		len(n.Merged) != 1 || n.Merged[0].ID != "mr-a" || len(n.Culprits) != 1 || n.Culprits[0].Branch != "polecat/b" {
+		t.Errorf("notification = %+v", n)
+	}
+	if n.Conflicts == nil {
+		t.Error("empty MR lists should encode as [], not null")
+	}
+}
+
+func TestNotifyWebhooks_Retries(t *testing.T) {
+	var calls int32
+	failures := int32(2)
+	status := http.StatusBadGateway
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(status)
+		}
+	}))
+	defer srv.Close()
+
+	e := newNotifyEngineer(t, &WebhookConfig{URL: srv.URL})
+	result := &BatchResult{Merged: []*MRInfo{makeMR("mr-a", "polecat/a", "main")}}
+	e.notifyWebhooks(context.Background(), result, "main")
+	if calls != 3 {
+		t.Errorf("server errors: %d calls, want 2 failures then a success", calls)
+	}
+	if out := testOutput(e).String(); strings.Contains(out, "Warning") {
+		t.Errorf("delivered on retry, but warned:\n%s", out)
+	}
+
+	// Client errors are not retried.
+	calls, failures, status = 0, 10, http.StatusBadRequest
+	e.notifyWebhooks(context.Background(), result, "main")
+	if calls != 1 {
+		t.Errorf("client error: %d calls, want 1", calls)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "400") {
+		t.Errorf("expected failed delivery to be logged, output:\n%s", out)
+	}
+}
+
+func TestNotifyWebhooks_OnlyMerged(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer srv.Close()
+
+	e := newNotifyEngineer(t, &WebhookConfig{URL: srv.URL, OnlyMerged: true})
+	e.notifyWebhooks(context.Background(), &BatchResult{Conflicts: []*MRInfo{makeMR("mr-a", "polecat/a", "main")}}, "main")
+	if calls != 0 {
+		t.Errorf("only_merged webhook called for a batch that landed nothing")
+	}
+}
+
+func TestProcessBatch_WebhooksAfterBatchLock(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	e := newTestEngineer(t, workDir, g)
+
+	var calls, lockHeld int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		unlock, err := e.tryFlock(filepath.Join("batches", "main.lock"))
+		if err != nil || unlock == nil {
+			atomic.AddInt32(&lockHeld, 1)
+			return
+		}
+		unlock()
+	}))
+	defer srv.Close()
+	e.config.Webhooks = []*WebhookConfig{{URL: srv.URL}}
+
+	result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "feature-a", "main")}, "main", DefaultBatchConfig())
+	if result.Error != nil || len(result.Merged) != 1 {
+		t.Fatalf("ProcessBatch: merged %v, err %v", stackedIDs(result.Merged), result.Error)
+	}
+	if calls != 1 {
+		t.Errorf("webhook called %d times, want 1", calls)
+	}
+	if lockHeld != 0 {
+		t.Error("webhook delivered while the batch lock was held")
+	}
+}
@@ -0,0 +1,94 @@
+package refinery
+
+import (
+	"fmt"
+	"sort"
+)
+
+// overlapGuard keeps MRs that change the same paths out of the same batch
+// (see BatchConfig.SeparateOverlaps): stacked on top of each other they are
+// the likeliest to conflict mid-stack, costing a restack and a retry. Paths
+// are what each MR's branch changed since it diverged from the target,
+// read once per MR.
+type overlapGuard struct {
+	e       *Engineer
+	target  string
+	paths   map[string][]string
+	touched map[string]string // path -> ID of the batch member changing it
+}
+
+func (e *Engineer) newOverlapGuard(target string) *overlapGuard {
+	if target == "" && e.rig != nil {
+		target = e.rig.DefaultBranch()
+	}
+	return &overlapGuard{
+		e:       e,
+		target:  target,
+		paths:   make(map[string][]string),
+		touched: make(map[string]string),
+	}
+}
+
+// changedPaths returns the paths mr changes. MRs whose diff can't be read
+// change nothing as far as the guard is concerned.
+func (g *overlapGuard) changedPaths(mr *MRInfo) []string {
+	if paths, ok := g.paths[mr.ID]; ok {
+		return paths
+	}
+	var paths []string
+	if g.e.git != nil && g.target != "" {
+		paths, _ = g.e.git.DiffNameOnly("origin/"+g.target, mr.Branch)
+	}
+	g.paths[mr.ID] = paths
+	return paths
+}
+
+// conflict returns the first batch member changing a path that one of
+// chain changes, with the paths they share. chain is an MR with its
+// blockers, which are stacked together anyway and may overlap each other
+// and the blockers already taken.
+func (g *overlapGuard) conflict(chain []*MRInfo) (string, []string) {
+	inChain := make(map[string]bool, len(chain))
+	for _, mr := range chain {
+		inChain[mr.ID] = true
+	}
+	var member string
+	shared := make(map[string]bool)
+	for _, mr := range chain {
+		for _, path := range g.changedPaths(mr) {
+			id, ok := g.touched[path]
+			if ok && !inChain[id] && (member == "" || id == member) {
+				member = id
+				shared[path] = true
+			}
+		}
+	}
+	if member == "" {
+		return "", nil
+	}
+	paths := make([]string, 0, len(shared))
+	for path := range shared {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return member, paths
+}
+
+// take records the paths of chain as changed by the batch.
+func (g *overlapGuard) take(chain []*MRInfo) {
+	for _, mr := range chain {
+		for _, path := range g.changedPaths(mr) {
+			if _, ok := g.touched[path]; !ok {
+				g.touched[path] = mr.ID
+			}
+		}
+	}
+}
+
+// describeOverlap summarizes shared paths for the batch log.
+func describeOverlap(paths []string) string {
+	if len(paths) == 1 {
+		return paths[0]
+	}
+	return fmt.Sprintf("%s and %d more", paths[0], len(paths)-1)
+}
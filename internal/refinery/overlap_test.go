@@ -0,0 +1,61 @@
+package refinery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssembleBatch_SeparatesOverlaps(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "shared.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "shared.txt", "b\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	ready := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+	}
+
+	batch := e.AssembleBatch(ready, DefaultBatchConfig())
+	if got := strings.Join(mrIDs(batch), " "); got != "mr-a mr-c" {
+		t.Errorf("batch = %s, want mr-a mr-c", got)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "Holding MR mr-b for a later batch: changes shared.txt, like mr-a") {
+		t.Errorf("output missing hold notice:\n%s", out)
+	}
+
+	// The held MR leads the next batch.
+	if batch := e.AssembleBatch(ready[1:2], DefaultBatchConfig()); len(batch) != 1 || batch[0].ID != "mr-b" {
+		t.Errorf("next batch = %v, want mr-b", mrIDs(batch))
+	}
+
+	cfg := DefaultBatchConfig()
+	cfg.SeparateOverlaps = false
+	if batch := e.AssembleBatch(ready, cfg); len(batch) != 3 {
+		t.Errorf("without SeparateOverlaps batch = %v, want all three", mrIDs(batch))
+	}
+}
+
+func TestAssembleBatch_OverlapWithBlockerAllowed(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "shared.txt", "a\n")
+	run(t, workDir, "git", "checkout", "-b", "feature-b", "feature-a")
+	writeFile(t, workDir, "shared.txt", "a\nb\n")
+	run(t, workDir, "git", "commit", "-am", "feat: extend shared.txt")
+	run(t, workDir, "git", "checkout", "main")
+	createFeatureBranch(t, workDir, "feature-c", "shared.txt", "c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	mrB := makeMR("mr-b", "feature-b", "main")
+	mrB.BlockedBy = "mr-a"
+	ready := []*MRInfo{mrB, makeMR("mr-a", "feature-a", "main"), makeMR("mr-c", "feature-c", "main")}
+
+	batch := e.AssembleBatch(ready, DefaultBatchConfig())
+	if got := strings.Join(mrIDs(batch), " "); got != "mr-a mr-b" {
+		t.Errorf("batch = %s, want mr-a mr-b", got)
+	}
+}
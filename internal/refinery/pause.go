@@ -0,0 +1,99 @@
+package refinery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ErrNotPaused is returned by Resume when the merge queue isn't paused.
+var ErrNotPaused = errors.New("merge queue is not paused")
+
+// PauseState records an operator pausing the merge queue, typically during
+// an incident. While paused, no batch lands on any target (see
+// ProcessBatch); batches are still assembled, so the operator can see what
+// resuming would pick up.
+type PauseState struct {
+	By     string    `json:"by,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// String describes the pause for logs: who paused it, since when, and why.
+func (p *PauseState) String() string {
+	s := "merge queue paused"
+	if p.By != "" {
+		s += " by " + p.By
+	}
+	s += " since " + p.At.Local().Format(time.RFC3339)
+	if p.Reason != "" {
+		s += ": " + p.Reason
+	}
+	return s
+}
+
+// pausePath records the pause; it exists only while the queue is paused.
+func (e *Engineer) pausePath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "merge-queue-paused.json")
+}
+
+// Pause pauses the merge queue until Resume. Pausing a paused queue
+// replaces its reason.
+func (e *Engineer) Pause(by, reason string) (*PauseState, error) {
+	p := &PauseState{By: by, Reason: strings.TrimSpace(reason), At: time.Now().UTC()}
+	if err := util.EnsureDirAndWriteJSON(e.pausePath(), p); err != nil {
+		return nil, fmt.Errorf("saving pause: %w", err)
+	}
+	return p, nil
+}
+
+// Resume lifts the pause, returning the pause lifted.
+func (e *Engineer) Resume() (*PauseState, error) {
+	p, err := e.Paused()
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrNotPaused
+	}
+	if err := os.Remove(e.pausePath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("removing pause: %w", err)
+	}
+	return p, nil
+}
+
+// Paused returns the merge queue's pause, or nil if it isn't paused.
+func (e *Engineer) Paused() (*PauseState, error) {
+	data, err := os.ReadFile(e.pausePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading pause: %w", err)
+	}
+	var p PauseState
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing pause: %w", err)
+	}
+	return &p, nil
+}
+
+// activePause returns the pause in effect, if any. A pause record that
+// can't be read counts as a pause: landing during an incident is worse
+// than waiting for an operator to fix the record.
+func (e *Engineer) activePause() *PauseState {
+	if e.rig == nil {
+		return nil
+	}
+	p, err := e.Paused()
+	if err != nil {
+		return &PauseState{Reason: err.Error(), At: time.Now().UTC()}
+	}
+	return p
+}
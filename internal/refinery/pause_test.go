@@ -0,0 +1,68 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEngineer_PauseResume(t *testing.T) {
+	e := newTestEngineer(t, t.TempDir(), nil)
+	if p, err := e.Paused(); p != nil || err != nil {
+		t.Fatalf("Paused() = %v, %v before pausing", p, err)
+	}
+	if _, err := e.Resume(); !errors.Is(err, ErrNotPaused) {
+		t.Errorf("Resume() error = %v, want ErrNotPaused", err)
+	}
+	if _, err := e.Pause("mayor", " incident "); err != nil {
+		t.Fatal(err)
+	}
+	p, err := e.Paused()
+	if err != nil || p == nil || p.By != "mayor" || p.Reason != "incident" {
+		t.Fatalf("Paused() = %+v, %v", p, err)
+	}
+	if !strings.Contains(p.String(), "paused by mayor since ") {
+		t.Errorf("String() = %q", p)
+	}
+	if lifted, err := e.Resume(); err != nil || lifted.Reason != "incident" {
+		t.Errorf("Resume() = %+v, %v", lifted, err)
+	}
+	if p, _ := e.Paused(); p != nil {
+		t.Errorf("still paused after Resume: %+v", p)
+	}
+}
+
+func TestProcessBatch_DefersWhilePaused(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	if _, err := e.Pause("ops", "prod incident"); err != nil {
+		t.Fatal(err)
+	}
+	mr := makeMR("mr-a", "polecat/a", "main")
+	if batch := e.AssembleBatch([]*MRInfo{mr}, &BatchConfig{MaxBatchSize: 5}); len(batch) != 1 {
+		t.Errorf("AssembleBatch while paused = %v, want mr-a", mrIDs(batch))
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "on resume would batch mr-a") {
+		t.Errorf("expected the would-be batch in output:\n%s", out)
+	}
+
+	before := run(t, workDir, "git", "rev-parse", "origin/main")
+	result := e.ProcessBatch(context.Background(), []*MRInfo{mr}, "main", nil)
+	if len(result.Merged) != 0 || len(result.Deferred) != 1 {
+		t.Errorf("result = %+v, want the MR deferred", result)
+	}
+	if after := run(t, workDir, "git", "rev-parse", "origin/main"); after != before {
+		t.Errorf("origin/main moved while paused: %s -> %s", before, after)
+	}
+
+	if _, err := e.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if result := e.ProcessBatch(context.Background(), []*MRInfo{mr}, "main", nil); len(result.Merged) != 1 {
+		t.Errorf("after resume result = %+v, want mr-a merged", result)
+	}
+}
@@ -0,0 +1,294 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// Pipeline is the predicted next batch, stacked speculatively on the current
+// batch's stack tip while the current batch's gates were running.
+//
+// The stack lives in a detached worktree under .runtime/pipeline/<batchID>
+// until the next ProcessBatch adopts or discards it.
+type Pipeline struct {
+	// Target is the branch the predicted batch will land on.
+	Target string
+
+	// Base is the stack tip the batch was stacked on: the merge commit
+	// predicted to land on Target.
+	Base string
+
+	// Tip is the speculative stack's tip commit.
+	Tip string
+
+	// MRs is the predicted next batch, in stacking order.
+	MRs []*MRInfo
+
+	// Stacked and Conflicts split MRs as BuildRebaseStack would.
+	Stacked   []*MRInfo
+	Conflicts []*MRInfo
+
+	// Heads maps each stacked MR's ID to the branch commit that was stacked.
+	Heads map[string]string
+
+	// BatchID is reserved for the batch that adopts the stack, so merge
+	// messages rendered while stacking name the batch that lands them.
+	BatchID string
+
+	// PreparedAt is when stacking completed.
+	PreparedAt time.Time
+
+	dir string // Worktree holding the stack
+}
+
+// Pipelined returns the pending speculative stack, or nil.
+func (e *Engineer) Pipelined() *Pipeline {
+	e.pipelineMu.Lock()
+	defer e.pipelineMu.Unlock()
+	return e.pipeline
+}
+
+// startPipeline stacks the predicted next batch on the current HEAD in the
+// background when PipelineNextBatch is enabled. Branches are fetched first,
+// as with PrewarmNextBatch.
+//
+// The returned stop func must be called once gates have returned. With keep
+// set (the gates passed, so HEAD is about to land) it waits for stacking to
+// finish and records the result for the next ProcessBatch; otherwise it
+// cancels stacking and discards the stack. Either way it then copies the log
+// lines to e.output.
+func (e *Engineer) startPipeline(ctx context.Context, batchID string, current []*MRInfo, target string, config *BatchConfig) (stop func(keep bool)) {
+	if !config.PipelineNextBatch || e.listReadyMRs == nil {
+		return func(bool) {}
+	}
+	base, err := e.git.Rev("HEAD")
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Pipeline] Warning: get stack tip: %v\n", err)
+		return func(bool) {}
+	}
+
+	plCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	var log bytes.Buffer // owned by the goroutine until done is closed
+	var pl *Pipeline
+
+	go func() {
+		defer close(done)
+		ready, err := e.listReadyMRs()
+		if err != nil {
+			_, _ = fmt.Fprintf(&log, "[Pipeline] Warning: list ready MRs: %v\n", err)
+			return
+		}
+		pw, err := e.prewarmNextBatch(plCtx, ready, current, target, config, &log)
+		if err != nil {
+			_, _ = fmt.Fprintf(&log, "[Pipeline] Abandoned: %v\n", err)
+			return
+		}
+		// A single MR goes through doMerge, which builds its own merge.
+		if pw == nil || len(pw.MRs) < 2 {
+			return
+		}
+		dir := filepath.Join(e.rig.Path, ".runtime", "pipeline", batchID)
+		pl, err = e.stackPipeline(plCtx, dir, base, target, pw.MRs, &log)
+		if err != nil {
+			_, _ = fmt.Fprintf(&log, "[Pipeline] Abandoned: %v\n", err)
+		}
+	}()
+
+	return func(keep bool) {
+		if !keep {
+			cancel()
+		}
+		<-done
+		cancel()
+		_, _ = e.output.Write(log.Bytes())
+		if pl == nil {
+			return
+		}
+		if !keep {
+			_, _ = fmt.Fprintln(e.output, "[Pipeline] Gates failed, discarding speculative stack")
+			e.removePipeline(pl, e.output)
+			return
+		}
+		e.pipelineMu.Lock()
+		prev := e.pipeline
+		e.pipeline = pl
+		e.pipelineMu.Unlock()
+		if prev != nil {
+			e.removePipeline(prev, e.output)
+		}
+	}
+}
+
+// stackPipeline merges next onto base in a new detached worktree at
+// dir. MRs that are missing or don't merge cleanly are left out, as in
+// BuildRebaseStack. The worktree is removed on error.
+func (e *Engineer) stackPipeline(ctx context.Context, dir, base, target string, next []*MRInfo, out io.Writer) (*Pipeline, error) {
+	if err := e.git.WorktreeAddDetached(dir, base); err != nil {
+		return nil, fmt.Errorf("worktree: %w", err)
+	}
+	pl := &Pipeline{
+		Target:  target,
+		Base:    base,
+		MRs:     next,
+		Heads:   make(map[string]string, len(next)),
+		BatchID: newBatchID(target),
+		dir:     dir,
+	}
+	g := git.NewGit(dir)
+
+	for _, mr := range next {
+		mr.batchID = pl.BatchID
+		if ctx.Err() != nil {
+			e.removePipeline(pl, out)
+			return nil, ctx.Err()
+		}
+		head, err := g.Rev(mr.Branch)
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "[Pipeline] MR %s: branch %s not found, skipping\n", mr.ID, mr.Branch)
+			pl.Conflicts = append(pl.Conflicts, mr)
+			continue
+		}
+		before, err := g.Rev("HEAD")
+		if err != nil {
+			e.removePipeline(pl, out)
+			return nil, fmt.Errorf("get stack tip: %w", err)
+		}
+		if err := e.mergeMRIn(g, dir, mr, out); err != nil {
+			_, _ = fmt.Fprintf(out, "[Pipeline] MR %s: merge failed: %v, removing from batch\n", mr.ID, err)
+			pl.Conflicts = append(pl.Conflicts, mr)
+			if resetErr := g.ResetHard(before); resetErr != nil {
+				e.removePipeline(pl, out)
+				return nil, fmt.Errorf("reset after merge failure: %w", resetErr)
+			}
+			continue
+		}
+		pl.Stacked = append(pl.Stacked, mr)
+		pl.Heads[mr.ID] = head
+	}
+
+	tip, err := g.Rev("HEAD")
+	if err != nil {
+		e.removePipeline(pl, out)
+		return nil, fmt.Errorf("get stack tip: %w", err)
+	}
+	pl.Tip = tip
+	pl.PreparedAt = time.Now()
+	_, _ = fmt.Fprintf(out, "[Pipeline] Next batch stacked on %s: %d MRs stacked, %d conflicts\n",
+		shortSHA(base), len(pl.Stacked), len(pl.Conflicts))
+	return pl, nil
+}
+
+// adoptPipeline takes the pending speculative stack and, if it was built for
+// batch on what is now origin/target, checks it out in the work directory in
+// place of BuildRebaseStack. A stack that doesn't match is discarded and ok
+// is false; the caller then builds the stack as usual.
+func (e *Engineer) adoptPipeline(batch []*MRInfo, target string) (stacked, conflicts []*MRInfo, ok bool) {
+	e.pipelineMu.Lock()
+	pl := e.pipeline
+	e.pipeline = nil
+	e.pipelineMu.Unlock()
+	if pl == nil {
+		return nil, nil, false
+	}
+	defer e.removePipeline(pl, e.output)
+
+	if reason := e.pipelineMismatch(pl, batch, target); reason != "" {
+		_, _ = fmt.Fprintf(e.output, "[Pipeline] Discarding speculative stack: %s\n", reason)
+		return nil, nil, false
+	}
+	if err := e.git.ResetHard(pl.Tip); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Pipeline] Discarding speculative stack: %v\n", err)
+		return nil, nil, false
+	}
+	// The pre-warm recorded alongside the stack has served its purpose.
+	_ = e.takePrewarm(batch, target)
+
+	_, _ = fmt.Fprintf(e.output, "[Pipeline] Adopted speculative stack: %d MRs stacked, %d conflicts (prepared %s)\n",
+		len(pl.Stacked), len(pl.Conflicts), pl.PreparedAt.Format(time.RFC3339))
+	return pl.Stacked, pl.Conflicts, true
+}
+
+// pipelineMismatch returns why pl can't stand in for batch on target, or ""
+// if it can. It leaves the work directory on target, refreshed from origin.
+func (e *Engineer) pipelineMismatch(pl *Pipeline, batch []*MRInfo, target string) string {
+	if pl.Target != target {
+		return fmt.Sprintf("built for %s", pl.Target)
+	}
+	if !sameMRs(pl.MRs, batch) {
+		return "batch changed"
+	}
+	for _, mr := range pl.Stacked {
+		if head, err := e.git.Rev(mr.Branch); err != nil || head != pl.Heads[mr.ID] {
+			return fmt.Sprintf("branch %s moved", mr.Branch)
+		}
+	}
+
+	if err := e.git.Checkout(target); err != nil {
+		return fmt.Sprintf("checkout %s: %v", target, err)
+	}
+	if err := e.git.Pull("origin", target); err != nil {
+		return fmt.Sprintf("pull origin/%s: %v", target, err)
+	}
+	head, err := e.git.Rev("HEAD")
+	if err != nil {
+		return fmt.Sprintf("get %s: %v", target, err)
+	}
+	if head != pl.Base {
+		return fmt.Sprintf("%s is at %s, not the predicted %s", target, shortSHA(head), shortSHA(pl.Base))
+	}
+	return ""
+}
+
+// sameMRs reports whether a and b hold the same MRs in the same order.
+func sameMRs(a, b []*MRInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// nextBatchID returns the ID for batch: the one reserved by the pending
+// speculative stack when it was built for batch, otherwise a new one.
+func (e *Engineer) nextBatchID(batch []*MRInfo, target string) string {
+	e.pipelineMu.Lock()
+	pl := e.pipeline
+	e.pipelineMu.Unlock()
+	if pl != nil && pl.BatchID != "" && pl.Target == target && sameMRs(pl.MRs, batch) {
+		return pl.BatchID
+	}
+	return newBatchID(target)
+}
+
+// discardPipeline drops the pending speculative stack, if any.
+func (e *Engineer) discardPipeline() {
+	e.pipelineMu.Lock()
+	pl := e.pipeline
+	e.pipeline = nil
+	e.pipelineMu.Unlock()
+	if pl != nil {
+		e.removePipeline(pl, e.output)
+	}
+}
+
+// removePipeline removes pl's worktree. Failures are logged: a leftover
+// worktree is pruned once its directory is gone.
+func (e *Engineer) removePipeline(pl *Pipeline, out io.Writer) {
+	if err := e.git.WorktreeRemove(pl.dir, true); err != nil {
+		_, _ = fmt.Fprintf(out, "[Pipeline] Warning: remove worktree %s: %v\n", pl.dir, err)
+	}
+	_ = os.RemoveAll(pl.dir)
+	_ = e.git.WorktreePrune()
+}
@@ -0,0 +1,122 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pipelineFixture sets up two batches of two MRs each, with the queue
+// listing all four.
+func pipelineFixture(t *testing.T) (e *Engineer, workDir string, first, second []*MRInfo) {
+	t.Helper()
+	workDir, g, _ := testGitRepo(t)
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		createFeatureBranch(t, workDir, "polecat/"+name, name+".txt", name+"\n")
+	}
+
+	e = newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	first = []*MRInfo{makeMR("mr-a", "polecat/a", "main"), makeMR("mr-b", "polecat/b", "main")}
+	second = []*MRInfo{makeMR("mr-c", "polecat/c", "main"), makeMR("mr-d", "polecat/d", "main")}
+	e.listReadyMRs = func() ([]*MRInfo, error) {
+		return append(append([]*MRInfo{}, first...), second...), nil
+	}
+	return e, workDir, first, second
+}
+
+func pipelineConfig() *BatchConfig {
+	cfg := DefaultBatchConfig()
+	cfg.PipelineNextBatch = true
+	return cfg
+}
+
+func TestProcessBatch_PipelineAdoptedAfterLanding(t *testing.T) {
+	e, workDir, first, second := pipelineFixture(t)
+
+	result := e.ProcessBatch(context.Background(), first, "main", pipelineConfig())
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("first batch: merged=%v err=%v", stackedIDs(result.Merged), result.Error)
+	}
+	pl := e.Pipelined()
+	if pl == nil {
+		t.Fatal("expected the next batch to be stacked during gates")
+	}
+	if pl.Base != result.MergeCommit {
+		t.Errorf("pipeline base = %s, want landed commit %s", pl.Base, result.MergeCommit)
+	}
+	if got := stackedIDs(pl.Stacked); len(got) != 2 || got[0] != "mr-c" || got[1] != "mr-d" {
+		t.Errorf("speculatively stacked %v, want [mr-c mr-d]", got)
+	}
+
+	result = e.ProcessBatch(context.Background(), second, "main", pipelineConfig())
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("second batch: merged=%v err=%v", stackedIDs(result.Merged), result.Error)
+	}
+	out := testOutput(e).String()
+	if !strings.Contains(out, "[Pipeline] Adopted speculative stack") {
+		t.Errorf("expected the second batch to adopt the speculative stack, output:\n%s", out)
+	}
+	if result.MergeCommit != pl.Tip {
+		t.Errorf("landed %s, want speculative tip %s", result.MergeCommit, pl.Tip)
+	}
+	for _, f := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		if _, err := os.Stat(filepath.Join(workDir, f)); err != nil {
+			t.Errorf("expected %s after both batches: %v", f, err)
+		}
+	}
+	assertPipelineCleanedUp(t, e, workDir)
+}
+
+func TestProcessBatch_PipelineDiscardedWhenGatesFail(t *testing.T) {
+	e, workDir, first, _ := pipelineFixture(t)
+	createFeatureBranch(t, workDir, "polecat/bad", "FAIL_MARKER", "fail\n")
+	first[1] = makeMR("mr-bad", "polecat/bad", "main")
+
+	cfg := pipelineConfig()
+	cfg.RetryBatchOnFlaky = false
+	e.ProcessBatch(context.Background(), first, "main", cfg)
+
+	if e.Pipelined() != nil {
+		t.Error("speculative stack should be discarded when gates fail")
+	}
+	assertPipelineCleanedUp(t, e, workDir)
+}
+
+func TestProcessBatch_PipelineDiscardedWhenTargetMoved(t *testing.T) {
+	e, workDir, first, second := pipelineFixture(t)
+
+	if result := e.ProcessBatch(context.Background(), first, "main", pipelineConfig()); result.Error != nil {
+		t.Fatalf("first batch: %v", result.Error)
+	}
+	if e.Pipelined() == nil {
+		t.Fatal("expected the next batch to be stacked during gates")
+	}
+	pushFromClone(t, workDir, "main", "other.txt")
+
+	result := e.ProcessBatch(context.Background(), second, "main", pipelineConfig())
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("second batch: merged=%v err=%v", stackedIDs(result.Merged), result.Error)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "[Pipeline] Discarding speculative stack") {
+		t.Errorf("expected the stale stack to be discarded, output:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "other.txt")); err != nil {
+		t.Errorf("rebuilt stack should include the concurrent push: %v", err)
+	}
+	assertPipelineCleanedUp(t, e, workDir)
+}
+
+func assertPipelineCleanedUp(t *testing.T, e *Engineer, workDir string) {
+	t.Helper()
+	// A stack prepared for a batch that never comes is dropped by the next
+	// single-MR batch; drop it here so only leaks are reported.
+	e.discardPipeline()
+	if out := run(t, workDir, "git", "worktree", "list"); strings.Count(out, "\n") != 0 {
+		t.Errorf("pipeline worktrees left behind:\n%s", out)
+	}
+}
@@ -0,0 +1,185 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// PostMergeHookConfig is a command or molecule run after every push that
+// lands MRs, for changelogs, notifications or deploys driven from the
+// refinery itself. Exactly one of Command or Molecule is set.
+//
+// Unlike deploy environments (see DeployConfig) hooks keep no history and
+// have no promotion or rollback: a failed hook is logged and the merge
+// stands.
+type PostMergeHookConfig struct {
+	Name string `json:"name"`
+
+	// Command runs via sh -c in the refinery clone with GT_MERGE_*
+	// variables describing the merge (see postMergeEnv).
+	Command string `json:"command,omitempty"`
+
+	// Molecule is a formula poured as a wisp with the merge as vars
+	// (sha, target, mrs, ...), for hooks an agent carries out.
+	Molecule string `json:"molecule,omitempty"`
+
+	// Targets are the branches whose merges run the hook. Default: all
+	// targets.
+	Targets []string `json:"targets,omitempty"`
+
+	// Timeout bounds the command or pour. Default: 10m.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+type postMergeHookRaw struct {
+	PostMergeHookConfig
+	Timeout string `json:"timeout"`
+}
+
+// defaultPostMergeTimeout bounds post-merge hooks.
+const defaultPostMergeTimeout = 10 * time.Minute
+
+// parsePostMergeHooks converts the JSON hook configs, parsing timeouts.
+func parsePostMergeHooks(raws []*postMergeHookRaw) ([]*PostMergeHookConfig, error) {
+	hooks := make([]*PostMergeHookConfig, 0, len(raws))
+	seen := make(map[string]bool)
+	for _, raw := range raws {
+		if raw == nil {
+			continue
+		}
+		hook := raw.PostMergeHookConfig
+		if hook.Name == "" {
+			return nil, fmt.Errorf("post-merge hook without a name")
+		}
+		if seen[hook.Name] {
+			return nil, fmt.Errorf("post-merge hook %q declared twice", hook.Name)
+		}
+		seen[hook.Name] = true
+		if (hook.Command == "") == (hook.Molecule == "") {
+			return nil, fmt.Errorf("post-merge hook %q needs exactly one of command, molecule", hook.Name)
+		}
+		if raw.Timeout != "" {
+			d, err := time.ParseDuration(raw.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for post-merge hook %q: %w", hook.Name, err)
+			}
+			if d <= 0 {
+				return nil, fmt.Errorf("post-merge hook %q: timeout must be positive, got %v", hook.Name, d)
+			}
+			hook.Timeout = d
+		}
+		hooks = append(hooks, &hook)
+	}
+	return hooks, nil
+}
+
+func (h *PostMergeHookConfig) appliesTo(target string) bool {
+	if len(h.Targets) == 0 {
+		return true
+	}
+	for _, t := range h.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *PostMergeHookConfig) timeout() time.Duration {
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+	return defaultPostMergeTimeout
+}
+
+// postMergeEnv returns the GT_MERGE_* variables describing a merge of mrs
+// into target at sha.
+func (e *Engineer) postMergeEnv(sha, target, batchID string, mrs []*MRInfo) map[string]string {
+	var ids, issues, branches []string
+	for _, mr := range mrs {
+		ids = append(ids, mr.ID)
+		branches = append(branches, mr.Branch)
+		if mr.SourceIssue != "" {
+			issues = append(issues, mr.SourceIssue)
+		}
+	}
+	return map[string]string{
+		"GT_MERGE_SHA":      sha,
+		"GT_MERGE_TARGET":   target,
+		"GT_MERGE_BATCH":    batchID,
+		"GT_MERGE_MRS":      strings.Join(ids, ","),
+		"GT_MERGE_BRANCHES": strings.Join(branches, ","),
+		"GT_MERGE_ISSUES":   strings.Join(issues, ","),
+		"GT_MERGE_RIG":      e.rig.Name,
+	}
+}
+
+// runPostMergeHooks runs every post-merge hook for target, in config
+// order, after mrs landed on it at sha. Failures are logged; they never
+// affect the merge.
+func (e *Engineer) runPostMergeHooks(ctx context.Context, sha, target, batchID string, mrs []*MRInfo) {
+	if len(e.config.PostMergeHooks) == 0 || sha == "" || len(mrs) == 0 {
+		return
+	}
+	env := e.postMergeEnv(sha, target, batchID, mrs)
+	for _, hook := range e.config.PostMergeHooks {
+		if !hook.appliesTo(target) {
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[PostMerge] %s ← %s\n", hook.Name, shortSHA(sha))
+		hookCtx, cancel := context.WithTimeout(ctx, hook.timeout())
+		var err error
+		if hook.Command != "" {
+			err = e.postMergeCommand(hookCtx, hook, env)
+		} else {
+			err = e.postMergeMolecule(hookCtx, hook, env)
+		}
+		cancel()
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[PostMerge] Warning: %s: %v\n", hook.Name, err)
+		}
+	}
+}
+
+func (e *Engineer) postMergeCommand(ctx context.Context, hook *PostMergeHookConfig, env map[string]string) error {
+	// Trust boundary: hook commands come from the rig's config.json
+	// (operator-controlled), like deploy and gate commands.
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command) //nolint:gosec // G204: hook command is from trusted rig config
+	cmd.Dir = e.workDir
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out")
+		}
+		msg := strings.TrimSpace(out.String())
+		if len(msg) > 500 {
+			msg = msg[len(msg)-500:]
+		}
+		return fmt.Errorf("%v: %s", err, msg)
+	}
+	return nil
+}
+
+func (e *Engineer) postMergeMolecule(ctx context.Context, hook *PostMergeHookConfig, env map[string]string) error {
+	args := []string{"mol", "wisp", hook.Molecule}
+	for k, v := range env {
+		args = append(args, "--var", strings.ToLower(strings.TrimPrefix(k, "GT_MERGE_"))+"="+v)
+	}
+	cmd := exec.CommandContext(ctx, "bd", args...) //nolint:gosec // G204: molecule name is from trusted rig config
+	cmd.Dir = e.rig.Path
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pour %s: %v: %s", hook.Molecule, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
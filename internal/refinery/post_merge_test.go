@@ -0,0 +1,75 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePostMergeHooks(t *testing.T) {
+	cfg := DefaultMergeQueueConfig()
+	err := applyMergeQueueConfig(cfg, []byte(`{"post_merge_hooks": [
+		{"name": "changelog", "command": "make changelog", "targets": ["main"], "timeout": "2m"},
+		{"name": "announce", "molecule": "mol-announce-merge"}
+	]}`))
+	if err != nil {
+		t.Fatalf("applyMergeQueueConfig: %v", err)
+	}
+	if len(cfg.PostMergeHooks) != 2 || cfg.PostMergeHooks[0].timeout().Minutes() != 2 || cfg.PostMergeHooks[1].timeout() != defaultPostMergeTimeout {
+		t.Fatalf("post_merge_hooks = %+v", cfg.PostMergeHooks)
+	}
+	if h := cfg.PostMergeHooks[0]; !h.appliesTo("main") || h.appliesTo("release/1.0") {
+		t.Errorf("targets %v applied wrongly", h.Targets)
+	}
+	for _, bad := range []string{
+		`[{"command": "true"}]`,
+		`[{"name": "x"}]`,
+		`[{"name": "x", "command": "true", "molecule": "mol-x"}]`,
+		`[{"name": "x", "command": "true"}, {"name": "x", "command": "false"}]`,
+		`[{"name": "x", "command": "true", "timeout": "soon"}]`,
+	} {
+		if err := applyMergeQueueConfig(DefaultMergeQueueConfig(), []byte(`{"post_merge_hooks": `+bad+`}`)); err == nil {
+			t.Errorf("post_merge_hooks %s accepted", bad)
+		}
+	}
+}
+
+func TestPostMergeHooks_RunAfterBatch(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+
+	out := filepath.Join(t.TempDir(), "hook.out")
+	e := newTestEngineer(t, workDir, g)
+	e.config.PostMergeHooks = []*PostMergeHookConfig{
+		{Name: "record", Command: `echo "$GT_MERGE_SHA $GT_MERGE_TARGET $GT_MERGE_MRS" >> ` + out},
+		{Name: "broken", Command: "exit 3"},
+		{Name: "release-only", Command: "echo release >> " + out, Targets: []string{"release"}},
+	}
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5})
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("merged = %v, error %v", mrIDs(result.Merged), result.Error)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	want := result.MergeCommit + " main mr-a,mr-b"
+	if got := strings.TrimSpace(string(data)); got != want {
+		t.Errorf("hook saw %q, want %q", got, want)
+	}
+
+	// Nothing landed: no hooks.
+	if err := os.Remove(out); err != nil {
+		t.Fatal(err)
+	}
+	e.ProcessBatch(context.Background(), nil, "main", nil)
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("hook ran for an empty batch")
+	}
+}
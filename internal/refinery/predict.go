@@ -0,0 +1,412 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// BatchPredictor scores an assembled batch's probability of passing gates.
+// The Engineer splits batches scored below PredictorConfig.SplitBelow before
+// stacking them (see splitByPrediction).
+type BatchPredictor interface {
+	PredictBatch(ctx context.Context, features *BatchFeatures) (float64, error)
+}
+
+// PredictorConfig configures an external batch predictor. Exactly one of
+// Command or URL says how to reach it.
+type PredictorConfig struct {
+	// Command runs via sh -c in the refinery clone with the batch's
+	// BatchFeatures as JSON on stdin, and prints the pass probability
+	// (a bare number, or JSON like the URL response) on stdout.
+	Command string `json:"command,omitempty"`
+
+	// URL receives the batch's BatchFeatures as a JSON POST and responds
+	// with {"pass_probability": p}.
+	URL string `json:"url,omitempty"`
+
+	// Timeout bounds each prediction. Default: 30s.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// SplitBelow is the pass probability under which a batch is halved,
+	// repeatedly, until it scores at least this. Default: 0.5.
+	SplitBelow float64 `json:"split_below,omitempty"`
+
+	// MinBatchSize is the smallest batch a split may produce. Default: 1.
+	MinBatchSize int `json:"min_batch_size,omitempty"`
+}
+
+type predictorConfigRaw struct {
+	PredictorConfig
+	Timeout string `json:"timeout"`
+}
+
+// defaultPredictorTimeout bounds a prediction when no timeout is configured.
+const defaultPredictorTimeout = 30 * time.Second
+
+// maxBatchHistory bounds the batch outcomes kept per rig.
+const maxBatchHistory = 500
+
+// recentBatchWindow is how many of a target's latest batches feed its
+// recent pass rate.
+const recentBatchWindow = 50
+
+// parsePredictorConfig converts the JSON predictor config, parsing its timeout.
+func parsePredictorConfig(raw *predictorConfigRaw) (*PredictorConfig, error) {
+	cfg := raw.PredictorConfig
+	if (cfg.Command == "") == (cfg.URL == "") {
+		return nil, fmt.Errorf("predictor: exactly one of command or url is required")
+	}
+	if raw.Timeout != "" {
+		dur, err := time.ParseDuration(raw.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid predictor timeout %q: %w", raw.Timeout, err)
+		}
+		if dur <= 0 {
+			return nil, fmt.Errorf("predictor timeout must be positive, got %v", dur)
+		}
+		cfg.Timeout = dur
+	}
+	if cfg.SplitBelow < 0 || cfg.SplitBelow > 1 {
+		return nil, fmt.Errorf("predictor split_below must be between 0 and 1, got %v", cfg.SplitBelow)
+	}
+	if cfg.MinBatchSize < 0 {
+		return nil, fmt.Errorf("predictor min_batch_size must be non-negative, got %d", cfg.MinBatchSize)
+	}
+	return &cfg, nil
+}
+
+func (c *PredictorConfig) splitBelow() float64 {
+	if c == nil || c.SplitBelow == 0 {
+		return 0.5
+	}
+	return c.SplitBelow
+}
+
+func (c *PredictorConfig) minBatchSize() int {
+	if c == nil || c.MinBatchSize < 1 {
+		return 1
+	}
+	return c.MinBatchSize
+}
+
+// BatchFeatures describes an assembled batch to a predictor.
+type BatchFeatures struct {
+	Rig       string       `json:"rig"`
+	Target    string       `json:"target"`
+	BatchSize int          `json:"batch_size"`
+	MRs       []MRFeatures `json:"mrs"`
+
+	// TargetRecentBatches and TargetRecentPassRate summarize the target's
+	// latest recorded batches (up to 50).
+	TargetRecentBatches  int     `json:"target_recent_batches"`
+	TargetRecentPassRate float64 `json:"target_recent_pass_rate"`
+}
+
+// MRFeatures describes one MR of a batch to a predictor.
+type MRFeatures struct {
+	ID           string  `json:"id"`
+	Branch       string  `json:"branch"`
+	Worker       string  `json:"worker,omitempty"`
+	Priority     int     `json:"priority"`
+	QoS          string  `json:"qos,omitempty"`
+	RetryCount   int     `json:"retry_count"`
+	AgeSeconds   float64 `json:"age_seconds"`
+	PreVerified  bool    `json:"pre_verified"`
+	FilesChanged int     `json:"files_changed"`
+	LinesAdded   int     `json:"lines_added"`
+	LinesDeleted int     `json:"lines_deleted"`
+
+	// WorkerBatches and WorkerCulprits count the recorded batches that
+	// included an MR by the same worker, and how often that MR was blamed.
+	WorkerBatches  int `json:"worker_batches"`
+	WorkerCulprits int `json:"worker_culprits"`
+}
+
+// BatchOutcome records how a batch went, as history for predictors.
+type BatchOutcome struct {
+	BatchID  string            `json:"batch_id"`
+	Target   string            `json:"target"`
+	At       time.Time         `json:"at"`
+	Workers  map[string]string `json:"workers"` // MR ID → worker
+	Merged   []string          `json:"merged,omitempty"`
+	Culprits []string          `json:"culprits,omitempty"`
+	Passed   bool              `json:"passed"` // Merged without culprits or errors
+
+	// PassProbability is what the predictor scored the batch, if it did.
+	PassProbability *float64 `json:"pass_probability,omitempty"`
+}
+
+// SetBatchPredictor installs a predictor in place of the configured one.
+func (e *Engineer) SetBatchPredictor(p BatchPredictor) {
+	e.predictor = p
+}
+
+// batchPredictor returns the installed predictor, the configured external
+// one, or nil.
+func (e *Engineer) batchPredictor() BatchPredictor {
+	if e.predictor != nil {
+		return e.predictor
+	}
+	if e.config.Predictor != nil {
+		return &externalPredictor{cfg: e.config.Predictor, dir: e.workDir}
+	}
+	return nil
+}
+
+// splitByPrediction scores batch and, while the score is below SplitBelow,
+// halves it, keeping the higher-scoring front half. Dropped MRs are
+// returned as deferred and stay queued for later batches. Predictor errors
+// leave the batch as it is. prob is the kept batch's score, or nil.
+func (e *Engineer) splitByPrediction(ctx context.Context, batch []*MRInfo, target string) (kept, deferred []*MRInfo, prob *float64) {
+	p := e.batchPredictor()
+	if p == nil || len(batch) < 2 {
+		return batch, nil, nil
+	}
+	cfg := e.config.Predictor
+	history, err := e.BatchHistory()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Predict] Warning: read batch history: %v\n", err)
+	}
+
+	kept = batch
+	for {
+		score, err := p.PredictBatch(ctx, e.batchFeatures(kept, target, history))
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Predict] Warning: %v (batch left as is)\n", err)
+			break
+		}
+		prob = &score
+		_, _ = fmt.Fprintf(e.output, "[Predict] Batch %v: pass probability %.2f\n", mrIDs(kept), score)
+		half := len(kept) / 2
+		if score >= cfg.splitBelow() || half < cfg.minBatchSize() {
+			break
+		}
+		kept = kept[:half]
+	}
+
+	if deferred = batch[len(kept):]; len(deferred) > 0 {
+		_, _ = fmt.Fprintf(e.output, "[Predict] Split batch preemptively, deferring %v to a later batch\n", mrIDs(deferred))
+	}
+	return kept, deferred, prob
+}
+
+// batchFeatures gathers the features of batch for a predictor. Diff sizes
+// that can't be read are left zero.
+func (e *Engineer) batchFeatures(batch []*MRInfo, target string, history []*BatchOutcome) *BatchFeatures {
+	f := &BatchFeatures{
+		Rig:       e.rig.Name,
+		Target:    target,
+		BatchSize: len(batch),
+	}
+
+	recent, passed := 0, 0
+	for i := len(history) - 1; i >= 0 && recent < recentBatchWindow; i-- {
+		if history[i].Target != target {
+			continue
+		}
+		recent++
+		if history[i].Passed {
+			passed++
+		}
+	}
+	f.TargetRecentBatches = recent
+	if recent > 0 {
+		f.TargetRecentPassRate = float64(passed) / float64(recent)
+	}
+
+	workerBatches := make(map[string]int)
+	workerCulprits := make(map[string]int)
+	for _, o := range history {
+		seen := make(map[string]bool)
+		for _, worker := range o.Workers {
+			if worker != "" && !seen[worker] {
+				seen[worker] = true
+				workerBatches[worker]++
+			}
+		}
+		for _, id := range o.Culprits {
+			if worker := o.Workers[id]; worker != "" {
+				workerCulprits[worker]++
+			}
+		}
+	}
+
+	now := time.Now()
+	for _, mr := range batch {
+		mf := MRFeatures{
+			ID:             mr.ID,
+			Branch:         mr.Branch,
+			Worker:         mr.Worker,
+			Priority:       mr.Priority,
+			QoS:            mr.QoS,
+			RetryCount:     mr.RetryCount,
+			PreVerified:    mr.PreVerified,
+			WorkerBatches:  workerBatches[mr.Worker],
+			WorkerCulprits: workerCulprits[mr.Worker],
+		}
+		if !mr.CreatedAt.IsZero() {
+			mf.AgeSeconds = now.Sub(mr.CreatedAt).Seconds()
+		}
+		if stats, err := e.git.DiffNumstat("origin/"+target, mr.Branch); err == nil {
+			mf.FilesChanged = len(stats)
+			for _, s := range stats {
+				mf.LinesAdded += s.Added
+				mf.LinesDeleted += s.Deleted
+			}
+		}
+		f.MRs = append(f.MRs, mf)
+	}
+	return f
+}
+
+func (e *Engineer) batchHistoryPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "batch-history.json")
+}
+
+// BatchHistory returns recorded batch outcomes, oldest first.
+func (e *Engineer) BatchHistory() ([]*BatchOutcome, error) {
+	data, err := os.ReadFile(e.batchHistoryPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var history []*BatchOutcome
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("parsing batch history: %w", err)
+	}
+	return history, nil
+}
+
+// recordBatchOutcome appends the outcome of a processed batch to the
+// history predictors learn from. Batches that merged nothing and blamed
+// nothing (all conflicts, infrastructure errors) say nothing about gates
+// and are skipped.
+func (e *Engineer) recordBatchOutcome(batch []*MRInfo, target string, result *BatchResult, prob *float64) {
+	if len(result.Merged) == 0 && len(result.Culprits) == 0 {
+		return
+	}
+	o := &BatchOutcome{
+		BatchID:         result.BatchID,
+		Target:          target,
+		At:              time.Now().UTC(),
+		Workers:         make(map[string]string, len(batch)),
+		Merged:          mrIDs(result.Merged),
+		Culprits:        mrIDs(result.Culprits),
+		Passed:          result.Error == nil && len(result.Culprits) == 0,
+		PassProbability: prob,
+	}
+	for _, mr := range batch {
+		o.Workers[mr.ID] = mr.Worker
+	}
+
+	history, err := e.BatchHistory()
+	if err == nil {
+		history = append(history, o)
+		if len(history) > maxBatchHistory {
+			history = history[len(history)-maxBatchHistory:]
+		}
+		err = util.EnsureDirAndWriteJSON(e.batchHistoryPath(), history)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Predict] Warning: record batch outcome: %v\n", err)
+	}
+}
+
+// externalPredictor runs a PredictorConfig's command or calls its URL.
+type externalPredictor struct {
+	cfg *PredictorConfig
+	dir string
+}
+
+func (p *externalPredictor) PredictBatch(ctx context.Context, features *BatchFeatures) (float64, error) {
+	body, err := json.Marshal(features)
+	if err != nil {
+		return 0, err
+	}
+	timeout := p.cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultPredictorTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out []byte
+	if p.cfg.Command != "" {
+		out, err = p.runCommand(ctx, body)
+	} else {
+		out, err = p.post(ctx, body)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("predictor: %w", err)
+	}
+	return parsePrediction(out)
+}
+
+func (p *externalPredictor) runCommand(ctx context.Context, body []byte) ([]byte, error) {
+	// Trust boundary: the command comes from the rig's config.json
+	// (operator-controlled), like gate commands.
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.cfg.Command) //nolint:gosec // G204: predictor command is from trusted rig config
+	cmd.Dir = p.dir
+	cmd.Stdin = bytes.NewReader(body)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func (p *externalPredictor) post(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("predictor returned %s", resp.Status)
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parsePrediction reads a pass probability: a bare number or
+// {"pass_probability": p}, between 0 and 1.
+func parsePrediction(out []byte) (float64, error) {
+	text := strings.TrimSpace(string(out))
+	prob, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		var resp struct {
+			PassProbability *float64 `json:"pass_probability"`
+		}
+		if jsonErr := json.Unmarshal([]byte(text), &resp); jsonErr != nil || resp.PassProbability == nil {
+			return 0, fmt.Errorf("predictor output %q is not a probability", text)
+		}
+		prob = *resp.PassProbability
+	}
+	if prob < 0 || prob > 1 {
+		return 0, fmt.Errorf("predictor probability %v out of range [0, 1]", prob)
+	}
+	return prob, nil
+}
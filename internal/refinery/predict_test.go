@@ -0,0 +1,189 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// predictorFunc adapts a function to BatchPredictor.
+type predictorFunc func(*BatchFeatures) (float64, error)
+
+func (f predictorFunc) PredictBatch(_ context.Context, features *BatchFeatures) (float64, error) {
+	return f(features)
+}
+
+func TestProcessBatch_SplitsUnlikelyBatch(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		createFeatureBranch(t, workDir, "polecat/"+name, name+".txt", name+"\n")
+	}
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+	var sizes []int
+	e.SetBatchPredictor(predictorFunc(func(f *BatchFeatures) (float64, error) {
+		sizes = append(sizes, f.BatchSize)
+		if f.BatchSize > 2 {
+			return 0.2, nil
+		}
+		return 0.9, nil
+	}))
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "polecat/a", "main"),
+		makeMR("mr-b", "polecat/b", "main"),
+		makeMR("mr-c", "polecat/c", "main"),
+		makeMR("mr-d", "polecat/d", "main"),
+	}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if len(sizes) != 2 || sizes[0] != 4 || sizes[1] != 2 {
+		t.Errorf("predicted batch sizes %v, want [4 2]", sizes)
+	}
+	if ids := stackedIDs(result.Merged); len(ids) != 2 || ids[0] != "mr-a" || ids[1] != "mr-b" {
+		t.Errorf("merged = %v, want [mr-a mr-b]", ids)
+	}
+	if ids := stackedIDs(result.Deferred); len(ids) != 2 || ids[0] != "mr-c" || ids[1] != "mr-d" {
+		t.Errorf("deferred = %v, want [mr-c mr-d]", ids)
+	}
+
+	history, err := e.BatchHistory()
+	if err != nil {
+		t.Fatalf("BatchHistory: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("recorded %d outcomes, want 1", len(history))
+	}
+	o := history[0]
+	if !o.Passed || o.PassProbability == nil || *o.PassProbability != 0.9 || len(o.Workers) != 2 {
+		t.Errorf("outcome = %+v, want passed 2-MR batch scored 0.9", o)
+	}
+}
+
+func TestSplitByPrediction_PredictorErrorKeepsBatch(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	e.SetBatchPredictor(predictorFunc(func(*BatchFeatures) (float64, error) {
+		return 0, errors.New("model offline")
+	}))
+
+	batch := []*MRInfo{makeMR("mr-a", "polecat/a", "main"), makeMR("mr-b", "polecat/b", "main")}
+	kept, deferred, prob := e.splitByPrediction(context.Background(), batch, "main")
+	if len(kept) != 2 || len(deferred) != 0 || prob != nil {
+		t.Errorf("got kept=%v deferred=%v prob=%v, want batch unchanged", stackedIDs(kept), stackedIDs(deferred), prob)
+	}
+}
+
+func TestSplitByPrediction_RespectsMinBatchSize(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	e.config.Predictor = &PredictorConfig{Command: "unused", MinBatchSize: 2}
+	e.SetBatchPredictor(predictorFunc(func(*BatchFeatures) (float64, error) { return 0.1, nil }))
+
+	var batch []*MRInfo
+	for _, id := range []string{"mr-1", "mr-2", "mr-3", "mr-4", "mr-5"} {
+		batch = append(batch, makeMR(id, "polecat/"+id, "main"))
+	}
+	kept, deferred, _ := e.splitByPrediction(context.Background(), batch, "main")
+	if len(kept) != 2 || len(deferred) != 3 {
+		t.Errorf("kept %v deferred %v, want split stopped at 2", stackedIDs(kept), stackedIDs(deferred))
+	}
+}
+
+func TestBatchFeatures_History(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "one\ntwo\n")
+
+	e := newTestEngineer(t, workDir, g)
+	history := []*BatchOutcome{
+		{Target: "main", Passed: true, Workers: map[string]string{"mr-1": "nux"}},
+		{Target: "main", Passed: false, Workers: map[string]string{"mr-2": "nux", "mr-3": "toast"}, Culprits: []string{"mr-2"}},
+		{Target: "develop", Passed: true, Workers: map[string]string{"mr-4": "nux"}},
+	}
+	mr := makeMR("mr-a", "polecat/a", "main")
+	mr.Worker = "nux"
+
+	f := e.batchFeatures([]*MRInfo{mr}, "main", history)
+	if f.TargetRecentBatches != 2 || f.TargetRecentPassRate != 0.5 {
+		t.Errorf("recent = %d @ %v, want 2 @ 0.5", f.TargetRecentBatches, f.TargetRecentPassRate)
+	}
+	got := f.MRs[0]
+	if got.WorkerBatches != 3 || got.WorkerCulprits != 1 {
+		t.Errorf("worker history = %d batches / %d culprits, want 3 / 1", got.WorkerBatches, got.WorkerCulprits)
+	}
+	if got.FilesChanged != 1 || got.LinesAdded != 2 {
+		t.Errorf("diff = %d files / +%d, want 1 / +2", got.FilesChanged, got.LinesAdded)
+	}
+}
+
+func TestExternalPredictor_Command(t *testing.T) {
+	dir := t.TempDir()
+	p := &externalPredictor{
+		cfg: &PredictorConfig{Command: `cat > features.json && echo '{"pass_probability": 0.75}'`},
+		dir: dir,
+	}
+	prob, err := p.PredictBatch(context.Background(), &BatchFeatures{Target: "main", BatchSize: 3})
+	if err != nil {
+		t.Fatalf("PredictBatch: %v", err)
+	}
+	if prob != 0.75 {
+		t.Errorf("prob = %v, want 0.75", prob)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "features.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f BatchFeatures
+	if err := json.Unmarshal(data, &f); err != nil || f.BatchSize != 3 {
+		t.Errorf("features on stdin = %s (%v), want batch_size 3", data, err)
+	}
+}
+
+func TestParsePrediction(t *testing.T) {
+	tests := []struct {
+		out     string
+		want    float64
+		wantErr bool
+	}{
+		{"0.3\n", 0.3, false},
+		{`{"pass_probability": 1}`, 1, false},
+		{"1.5", 0, true},
+		{"maybe", 0, true},
+		{`{"score": 0.3}`, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePrediction([]byte(tt.out))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePrediction(%q) = %v, %v; want %v, err=%v", tt.out, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParsePredictorConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     predictorConfigRaw
+		wantErr bool
+	}{
+		{"command", predictorConfigRaw{PredictorConfig: PredictorConfig{Command: "predict"}, Timeout: "5s"}, false},
+		{"neither", predictorConfigRaw{}, true},
+		{"both", predictorConfigRaw{PredictorConfig: PredictorConfig{Command: "predict", URL: "http://x"}}, true},
+		{"bad timeout", predictorConfigRaw{PredictorConfig: PredictorConfig{Command: "predict"}, Timeout: "soon"}, true},
+		{"bad threshold", predictorConfigRaw{PredictorConfig: PredictorConfig{URL: "http://x", SplitBelow: 2}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePredictorConfig(&tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePredictorConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// UrgentLabel marks an MR bead as urgent (see MRInfo.Urgent).
+const UrgentLabel = "urgent"
+
+// ErrNotUrgent is returned by Preempt for an MR that isn't urgent.
+var ErrNotUrgent = errors.New("MR is not urgent")
+
+// ErrPreempted is the cause of a batch cancelled for an urgent MR (see
+// Preempt).
+var ErrPreempted = errors.New("preempted")
+
+// preemptPollInterval is how often a running batch checks for preemption.
+var preemptPollInterval = time.Second
+
+// Preemption is an urgent MR waiting to interrupt the batch running on its
+// target.
+type Preemption struct {
+	MR     *MRInfo   `json:"mr"`
+	Target string    `json:"target"`
+	By     string    `json:"by,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+func (e *Engineer) preemptionsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "preemptions.json")
+}
+
+// Preemptions returns the urgent MRs waiting to preempt a batch, oldest
+// first.
+func (e *Engineer) Preemptions() ([]*Preemption, error) {
+	data, err := os.ReadFile(e.preemptionsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ps []*Preemption
+	if err := json.Unmarshal(data, &ps); err != nil {
+		return nil, fmt.Errorf("parsing preemptions: %w", err)
+	}
+	return ps, nil
+}
+
+// Preempt lands the urgent mr ahead of the batch running on its target,
+// from this process or another. At its next safe point, before it pushes,
+// the running batch is aborted and rolled back as if cancelled; mr then
+// lands alone and the interrupted batch is re-stacked on the new tip,
+// all within the same ProcessBatch call. If no batch is running, mr lands
+// alone ahead of the next one.
+//
+// Preempting with an MR already waiting to preempt is a no-op. An MR
+// waiting on a blocker can't preempt: landing alone would put it ahead of
+// the blocker. It is batched with its blockers instead (see AssembleBatch).
+func (e *Engineer) Preempt(mr *MRInfo, by string) error {
+	if !mr.Urgent {
+		return fmt.Errorf("preempt %s: %w", mr.ID, ErrNotUrgent)
+	}
+	if mr.BlockedBy != "" {
+		return fmt.Errorf("preempt %s: blocked by %s", mr.ID, mr.BlockedBy)
+	}
+	target := mr.Target
+	if target == "" {
+		target = e.rig.DefaultBranch()
+	}
+	defer e.lockState()()
+	ps, err := e.Preemptions()
+	if err != nil {
+		return err
+	}
+	for _, p := range ps {
+		if p.MR.ID == mr.ID {
+			return nil
+		}
+	}
+	ps = append(ps, &Preemption{MR: mr, Target: target, By: by, At: time.Now().UTC()})
+	if err := util.EnsureDirAndWriteJSON(e.preemptionsPath(), ps); err != nil {
+		return fmt.Errorf("saving preemption: %w", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Preempt] Urgent MR %s will preempt the batch on %s\n", mr.ID, target)
+	return nil
+}
+
+// pendingPreemption returns the oldest preemption of target by an MR that
+// isn't in batch, or nil.
+func (e *Engineer) pendingPreemption(target string, batch []*MRInfo) *Preemption {
+	ps, err := e.Preemptions()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Preempt] Warning: %v\n", err)
+		return nil
+	}
+	for _, p := range ps {
+		if p.Target == target && !containsMR(batch, p.MR.ID) {
+			return p
+		}
+	}
+	return nil
+}
+
+// takePreemption removes and returns the preemption pendingPreemption
+// would. Preemptions by members of batch are dropped: they land with it.
+func (e *Engineer) takePreemption(target string, batch []*MRInfo) *Preemption {
+	defer e.lockState()()
+	ps, err := e.Preemptions()
+	if err != nil || len(ps) == 0 {
+		return nil
+	}
+	var taken *Preemption
+	kept := ps[:0]
+	for _, p := range ps {
+		switch {
+		case p.Target != target:
+		case containsMR(batch, p.MR.ID):
+			continue
+		case taken == nil:
+			taken = p
+			continue
+		}
+		kept = append(kept, p)
+	}
+	if len(kept) == len(ps) {
+		return nil
+	}
+	if err := util.EnsureDirAndWriteJSON(e.preemptionsPath(), kept); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Preempt] Warning: saving preemptions: %v\n", err)
+	}
+	return taken
+}
+
+// watchPreemption returns a context for running batch on target that is
+// cancelled with ErrPreempted as soon as an urgent MR outside the batch
+// asks to preempt it, and a function to stop watching, which returns the
+// preemption that cancelled the batch, if one did.
+func (e *Engineer) watchPreemption(ctx context.Context, target string, batch []*MRInfo) (context.Context, func() *Preemption) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	fired := make(chan *Preemption, 1)
+	go func() {
+		ticker := time.NewTicker(preemptPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if p := e.pendingPreemption(target, batch); p != nil {
+					fired <- p
+					cancel(fmt.Errorf("%w by urgent MR %s", ErrPreempted, p.MR.ID))
+					return
+				}
+			}
+		}
+	}()
+	return ctx, func() *Preemption {
+		close(done)
+		cancel(nil)
+		select {
+		case p := <-fired:
+			return p
+		default:
+			return nil
+		}
+	}
+}
+
+// landUrgent lands the urgent MR of p alone on target.
+func (e *Engineer) landUrgent(ctx context.Context, p *Preemption, target string, batchCfg *BatchConfig) *BatchResult {
+	_, _ = fmt.Fprintf(e.output, "[Preempt] Landing urgent MR %s alone on %s\n", p.MR.ID, target)
+	result := e.runBatch(ctx, []*MRInfo{p.MR}, target, batchCfg)
+	result.Preempted = append(result.Preempted, p.MR.ID)
+	return result
+}
+
+// absorb folds o, an earlier result of the same ProcessBatch call, into r:
+// an urgent MR's solo batch, or a preempted batch whose unsettled MRs were
+// re-stacked into r (and so are dropped from o.Deferred by the caller).
+func (r *BatchResult) absorb(o *BatchResult) {
+	r.Merged = append(append([]*MRInfo(nil), o.Merged...), r.Merged...)
+	r.Culprits = append(append([]*MRInfo(nil), o.Culprits...), r.Culprits...)
+	r.Conflicts = append(append([]*MRInfo(nil), o.Conflicts...), r.Conflicts...)
+	r.Deferred = append(append([]*MRInfo(nil), o.Deferred...), r.Deferred...)
+	r.GateLogs = append(append([]*GateLog(nil), o.GateLogs...), r.GateLogs...)
+	r.Preempted = append(append([]string(nil), o.Preempted...), r.Preempted...)
+	if r.MergeCommit == "" {
+		r.MergeCommit = o.MergeCommit
+	}
+	if r.Error == nil && !o.Cancelled {
+		r.Error = o.Error
+	}
+}
+
+func containsMR(mrs []*MRInfo, id string) bool {
+	for _, mr := range mrs {
+		if mr.ID == id {
+			return true
+		}
+	}
+	return false
+}
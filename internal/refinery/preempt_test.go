@@ -0,0 +1,109 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessBatch_Preempt(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+	createFeatureBranch(t, workDir, "hotfix", "hot.txt", "hot\n")
+
+	oldPoll := preemptPollInterval
+	preemptPollInterval = 20 * time.Millisecond
+	defer func() { preemptPollInterval = oldPoll }()
+
+	e := newTestEngineer(t, workDir, g)
+	// Slow on the batch until the hotfix is under it.
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: "test ! -f a.txt || test -f hot.txt || sleep 10"}}
+	hot := makeMR("mr-hot", "hotfix", "main")
+	if err := e.Preempt(hot, "oncall"); !errors.Is(err, ErrNotUrgent) {
+		t.Fatalf("Preempt of a non-urgent MR: %v", err)
+	}
+	hot.Urgent = true
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		if err := e.Preempt(hot, "oncall"); err != nil {
+			t.Errorf("Preempt: %v", err)
+		}
+	}()
+	started := time.Now()
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", DefaultBatchConfig())
+	if elapsed := time.Since(started); elapsed > 8*time.Second {
+		t.Fatalf("batch took %v: not preempted\n%s", elapsed, e.output)
+	}
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if got := strings.Join(stackedIDs(result.Merged), " "); got != "mr-hot mr-a mr-b" {
+		t.Errorf("merged = %q, want the hotfix first, then the re-stacked batch", got)
+	}
+	if len(result.Preempted) != 1 || result.Preempted[0] != "mr-hot" || len(result.Deferred) != 0 {
+		t.Errorf("preempted %v, deferred %v", result.Preempted, stackedIDs(result.Deferred))
+	}
+	log := run(t, workDir, "git", "log", "--format=%s", "--reverse", "origin/main")
+	if hotAt, aAt := strings.Index(log, "hot.txt"), strings.Index(log, "a.txt"); hotAt < 0 || aAt < hotAt {
+		t.Errorf("origin/main history:\n%s\nwant the hotfix landed before the batch", log)
+	}
+	if ps, err := e.Preemptions(); err != nil || len(ps) != 0 {
+		t.Errorf("preemptions left: %v, %v", ps, err)
+	}
+
+	// The interrupted attempt is in the batch log as cancelled.
+	records, err := e.History(HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cancelled int
+	for _, r := range records {
+		if r.Cancelled && strings.Contains(r.Error, "preempted by urgent MR mr-hot") {
+			cancelled++
+		}
+	}
+	if cancelled != 1 {
+		t.Errorf("%d preempted batches in the log, want 1", cancelled)
+	}
+}
+
+func TestAssembleBatch_UrgentAlone(t *testing.T) {
+	e := &Engineer{config: DefaultMergeQueueConfig(), output: &strings.Builder{}}
+	hot := makeMR("mr-hot", "hotfix", "main")
+	hot.Urgent = true
+	ready := []*MRInfo{makeMR("mr-a", "a", "main"), hot, makeMR("mr-b", "b", "main")}
+	if batch := e.assembleBatch(ready, DefaultBatchConfig(), io.Discard); len(batch) != 1 || batch[0] != hot {
+		t.Errorf("batch = %v, want [mr-hot]", mrIDs(batch))
+	}
+}
+
+func TestAssembleBatch_UrgentAfterBlockers(t *testing.T) {
+	e := &Engineer{config: DefaultMergeQueueConfig(), output: &strings.Builder{}}
+	a := makeMR("mr-a", "a", "main")
+	hot := makeMR("mr-hot", "hotfix", "main")
+	hot.Urgent = true
+	hot.BlockedBy = "mr-a"
+	ready := []*MRInfo{makeMR("mr-b", "b", "main"), hot, a}
+	batch := e.assembleBatch(ready, DefaultBatchConfig(), io.Discard)
+	if len(batch) != 2 || batch[0] != a || batch[1] != hot {
+		t.Errorf("batch = %v, want [mr-a mr-hot]", mrIDs(batch))
+	}
+
+	// A blocker outside the queue keeps the urgent MR waiting.
+	hot.BlockedBy = "mr-elsewhere"
+	for _, mr := range e.assembleBatch(ready, DefaultBatchConfig(), io.Discard) {
+		if mr == hot {
+			t.Errorf("batched mr-hot ahead of its blocker mr-elsewhere")
+		}
+	}
+	if err := e.Preempt(hot, "ops"); err == nil {
+		t.Error("Preempt of a blocked MR succeeded, want error")
+	}
+}
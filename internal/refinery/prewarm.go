@@ -0,0 +1,184 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// prewarmJoinGrace bounds how long ProcessBatch waits for a cancelled
+// pre-warm to wind down after gates return. A pre-warm still running after
+// this is abandoned and its result dropped.
+const prewarmJoinGrace = 2 * time.Second
+
+// Prewarm describes the predicted next batch whose branches were fetched
+// while the current batch's gates were running.
+//
+// Only branch fetches are pre-warmed. The target itself is always refreshed
+// from origin when the next stack is built, so pushes that land after the
+// pre-warm are never missed.
+type Prewarm struct {
+	// Target is the branch the predicted batch will land on.
+	Target string
+
+	// MRs is the predicted next batch, in stacking order.
+	MRs []*MRInfo
+
+	// Missing lists predicted MRs whose branch could not be fetched.
+	Missing []*MRInfo
+
+	// PreparedAt is when pre-warming completed.
+	PreparedAt time.Time
+}
+
+// Covers reports whether every MR in batch was part of this pre-warm and
+// its branch was fetched successfully.
+func (p *Prewarm) Covers(batch []*MRInfo) bool {
+	if p == nil {
+		return false
+	}
+	ready := make(map[string]bool, len(p.MRs))
+	for _, mr := range p.MRs {
+		ready[mr.ID] = true
+	}
+	for _, mr := range p.Missing {
+		delete(ready, mr.ID)
+	}
+	for _, mr := range batch {
+		if !ready[mr.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// PredictNextBatch returns the batch AssembleBatch would pick once the MRs in
+// current have left the queue.
+func (e *Engineer) PredictNextBatch(readyMRs, current []*MRInfo, config *BatchConfig) []*MRInfo {
+	inCurrent := make(map[string]bool, len(current))
+	for _, mr := range current {
+		inCurrent[mr.ID] = true
+	}
+	remaining := make([]*MRInfo, 0, len(readyMRs))
+	for _, mr := range readyMRs {
+		if !inCurrent[mr.ID] {
+			remaining = append(remaining, mr)
+		}
+	}
+	return e.assembleBatch(remaining, config, io.Discard)
+}
+
+// PrewarmNextBatch fetches the branches of the predicted next batch from
+// origin into local branches, so stacking that batch needs no per-branch
+// network round-trips.
+//
+// Each branch is fetched into refs/heads/<branch> without force: branches
+// that only exist on origin are created locally, and local branches are
+// fast-forwarded to newer origin commits. A local branch that cannot be
+// fast-forwarded (diverged, checked out in a polecat worktree, or absent on
+// origin) is left as-is and still counts as pre-warmed.
+//
+// Note that with pre-warming enabled, an MR whose branch only exists on
+// origin becomes stackable; without it, BuildRebaseStack skips such MRs as
+// "branch not found".
+//
+// It never touches the refinery's working tree, so it is safe to call while
+// gates are running. Fetches are killed when ctx is done, in which case the
+// partial result is discarded. Returns nil if there is nothing to pre-warm.
+func (e *Engineer) PrewarmNextBatch(ctx context.Context, readyMRs, current []*MRInfo, target string, config *BatchConfig) (*Prewarm, error) {
+	return e.prewarmNextBatch(ctx, readyMRs, current, target, config, e.output)
+}
+
+func (e *Engineer) prewarmNextBatch(ctx context.Context, readyMRs, current []*MRInfo, target string, config *BatchConfig, out io.Writer) (*Prewarm, error) {
+	next := e.PredictNextBatch(readyMRs, current, config)
+	if len(next) == 0 {
+		return nil, nil
+	}
+
+	pw := &Prewarm{
+		Target: target,
+		MRs:    next,
+	}
+
+	for _, mr := range next {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err := e.git.FetchBranchToLocal(ctx, "origin", mr.Branch); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if exists, _ := e.git.BranchExists(mr.Branch); !exists {
+				_, _ = fmt.Fprintf(out, "[Prewarm] MR %s: fetch branch %s: %v\n", mr.ID, mr.Branch, err)
+				pw.Missing = append(pw.Missing, mr)
+			}
+		}
+	}
+
+	pw.PreparedAt = time.Now()
+	e.prewarmMu.Lock()
+	e.prewarm = pw
+	e.prewarmMu.Unlock()
+
+	_, _ = fmt.Fprintf(out, "[Prewarm] Next batch fetched: %v (%d missing)\n", mrIDs(next), len(pw.Missing))
+	return pw, nil
+}
+
+// Prewarmed returns the most recent pre-warm result, or nil.
+func (e *Engineer) Prewarmed() *Prewarm {
+	e.prewarmMu.Lock()
+	defer e.prewarmMu.Unlock()
+	return e.prewarm
+}
+
+// takePrewarm returns and clears the pre-warm if it was prepared for target
+// and covers batch. Mismatched pre-warms are discarded.
+func (e *Engineer) takePrewarm(batch []*MRInfo, target string) *Prewarm {
+	e.prewarmMu.Lock()
+	defer e.prewarmMu.Unlock()
+	pw := e.prewarm
+	e.prewarm = nil
+	if pw == nil || pw.Target != target || !pw.Covers(batch) {
+		return nil
+	}
+	return pw
+}
+
+// startPrewarm runs the pre-warm in the background when enabled. The returned
+// stop func must be called once gates have returned: it cancels any fetch
+// still in flight, waits up to prewarmJoinGrace for the goroutine to finish,
+// and then copies its log lines to e.output. A pre-warm that has not finished
+// by then is abandoned and its log discarded.
+func (e *Engineer) startPrewarm(ctx context.Context, current []*MRInfo, target string, config *BatchConfig) (stop func()) {
+	if !config.PrewarmNextBatch || e.listReadyMRs == nil {
+		return func() {}
+	}
+
+	pwCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	var log bytes.Buffer // owned by the goroutine until done is closed
+
+	go func() {
+		defer close(done)
+		ready, err := e.listReadyMRs()
+		if err != nil {
+			_, _ = fmt.Fprintf(&log, "[Prewarm] Warning: list ready MRs: %v\n", err)
+			return
+		}
+		if _, err := e.prewarmNextBatch(pwCtx, ready, current, target, config, &log); err != nil {
+			_, _ = fmt.Fprintf(&log, "[Prewarm] Abandoned: %v\n", err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		select {
+		case <-done:
+			_, _ = e.output.Write(log.Bytes())
+		case <-time.After(prewarmJoinGrace):
+			_, _ = fmt.Fprintln(e.output, "[Prewarm] Abandoned: still running after gates finished")
+		}
+	}
+}
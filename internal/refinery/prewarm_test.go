@@ -0,0 +1,307 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// pushFromClone clones origin next to workDir, commits filename on branch
+// there and pushes it, simulating another pusher. Returns the pushed SHA.
+func pushFromClone(t *testing.T, workDir, branch, filename string) string {
+	t.Helper()
+	origin := filepath.Join(filepath.Dir(workDir), "origin.git")
+	clone := filepath.Join(t.TempDir(), "clone")
+	run(t, filepath.Dir(clone), "git", "clone", "--branch", branch, origin, clone)
+	run(t, clone, "git", "config", "user.email", "other@test.com")
+	run(t, clone, "git", "config", "user.name", "Other")
+	writeFile(t, clone, filename, "pushed elsewhere\n")
+	run(t, clone, "git", "add", ".")
+	run(t, clone, "git", "commit", "-m", "chore: push from another clone")
+	run(t, clone, "git", "push", "origin", branch)
+	return run(t, clone, "git", "rev-parse", "HEAD")
+}
+
+func TestPredictNextBatch_ExcludesCurrent(t *testing.T) {
+	r := &rig.Rig{Name: "test-rig", Path: t.TempDir()}
+	e := NewEngineer(r)
+
+	ready := []*MRInfo{
+		makeMR("mr-1", "branch-1", "main"),
+		makeMR("mr-2", "branch-2", "main"),
+		makeMR("mr-3", "branch-3", "main"),
+		makeMR("mr-4", "branch-4", "main"),
+	}
+	current := ready[:2]
+
+	next := e.PredictNextBatch(ready, current, &BatchConfig{MaxBatchSize: 5})
+	if got := stackedIDs(next); len(got) != 2 || got[0] != "mr-3" || got[1] != "mr-4" {
+		t.Errorf("expected [mr-3 mr-4], got %v", got)
+	}
+}
+
+func TestPredictNextBatch_ReportsNothing(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.SetOutput(&bytes.Buffer{})
+
+	ready := []*MRInfo{makeMR("mr-1", "branch-1", "main"), makeMR("mr-2", "branch-2", "main")}
+	ready[1].Urgent = true
+	if next := e.PredictNextBatch(ready, ready[:1], nil); len(next) != 1 || next[0].ID != "mr-2" {
+		t.Errorf("expected [mr-2], got %v", stackedIDs(next))
+	}
+	if out := testOutput(e).String(); out != "" {
+		t.Errorf("prediction should not report, got %q", out)
+	}
+}
+
+func TestPrewarm_Covers(t *testing.T) {
+	mr1 := makeMR("mr-1", "branch-1", "main")
+	mr2 := makeMR("mr-2", "branch-2", "main")
+	pw := &Prewarm{MRs: []*MRInfo{mr1, mr2}, Missing: []*MRInfo{mr2}}
+
+	if !pw.Covers([]*MRInfo{mr1}) {
+		t.Error("expected pre-warm to cover mr-1")
+	}
+	if pw.Covers([]*MRInfo{mr1, mr2}) {
+		t.Error("expected pre-warm not to cover mr-2 (missing branch)")
+	}
+	var nilPW *Prewarm
+	if nilPW.Covers([]*MRInfo{mr1}) {
+		t.Error("nil pre-warm must not cover anything")
+	}
+}
+
+func TestPrewarmNextBatch_FetchesRemoteOnlyBranch(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+	// polecat/b only exists on origin
+	run(t, workDir, "git", "push", "origin", "polecat/b")
+	run(t, workDir, "git", "branch", "-D", "polecat/b")
+
+	e := newTestEngineer(t, workDir, g)
+	current := []*MRInfo{makeMR("mr-0", "polecat/current", "main")}
+	ready := []*MRInfo{
+		current[0],
+		makeMR("mr-1", "polecat/a", "main"),
+		makeMR("mr-2", "polecat/b", "main"),
+		makeMR("mr-3", "polecat/gone", "main"),
+	}
+
+	pw, err := e.PrewarmNextBatch(context.Background(), ready, current, "main", DefaultBatchConfig())
+	if err != nil {
+		t.Fatalf("PrewarmNextBatch: %v", err)
+	}
+	if pw == nil {
+		t.Fatal("expected pre-warm result")
+	}
+	if got := stackedIDs(pw.MRs); len(got) != 3 || got[0] != "mr-1" {
+		t.Errorf("expected next batch [mr-1 mr-2 mr-3], got %v", got)
+	}
+	// polecat/a is local-only: it still counts as pre-warmed.
+	if got := stackedIDs(pw.Missing); len(got) != 1 || got[0] != "mr-3" {
+		t.Errorf("expected only mr-3 missing, got %v", got)
+	}
+	if exists, _ := g.BranchExists("polecat/b"); !exists {
+		t.Error("expected polecat/b to be fetched into a local branch")
+	}
+	if e.Prewarmed() != pw {
+		t.Error("expected Prewarmed to return the latest result")
+	}
+}
+
+func TestPrewarmNextBatch_FastForwardsExistingBranch(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	run(t, workDir, "git", "push", "origin", "polecat/a")
+	newer := pushFromClone(t, workDir, "polecat/a", "a2.txt")
+
+	e := newTestEngineer(t, workDir, g)
+	ready := []*MRInfo{makeMR("mr-1", "polecat/a", "main")}
+
+	pw, err := e.PrewarmNextBatch(context.Background(), ready, nil, "main", DefaultBatchConfig())
+	if err != nil {
+		t.Fatalf("PrewarmNextBatch: %v", err)
+	}
+	if !pw.Covers(ready) {
+		t.Errorf("expected mr-1 pre-warmed, missing=%v", stackedIDs(pw.Missing))
+	}
+	if got := run(t, workDir, "git", "rev-parse", "polecat/a"); got != newer {
+		t.Errorf("expected local polecat/a fast-forwarded to %s, got %s", newer, got)
+	}
+}
+
+func TestPrewarmNextBatch_NothingQueued(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+
+	current := []*MRInfo{makeMR("mr-1", "polecat/a", "main")}
+	pw, err := e.PrewarmNextBatch(context.Background(), current, current, "main", DefaultBatchConfig())
+	if err != nil || pw != nil {
+		t.Errorf("expected (nil, nil), got (%v, %v)", pw, err)
+	}
+}
+
+func TestPrewarmNextBatch_CancelledDropsResult(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ready := []*MRInfo{makeMR("mr-1", "polecat/a", "main")}
+	pw, err := e.PrewarmNextBatch(ctx, ready, nil, "main", DefaultBatchConfig())
+	if err == nil || pw != nil {
+		t.Errorf("expected cancellation error, got (%v, %v)", pw, err)
+	}
+	if e.Prewarmed() != nil {
+		t.Error("cancelled pre-warm must not be recorded")
+	}
+}
+
+func TestProcessBatch_PrewarmsNextBatchDuringGates(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+	createFeatureBranch(t, workDir, "polecat/c", "c.txt", "c\n")
+	run(t, workDir, "git", "push", "origin", "polecat/c")
+	run(t, workDir, "git", "branch", "-D", "polecat/c")
+
+	e := newTestEngineer(t, workDir, g)
+
+	// Hold the gate open until the pre-warm has been recorded, so the test
+	// does not depend on how fast gates run relative to the fetch.
+	marker := filepath.ToSlash(filepath.Join(t.TempDir(), "prewarmed"))
+	e.config.Gates = map[string]*GateConfig{"wait": {
+		Cmd:     fmt.Sprintf("while [ ! -f %q ]; do sleep 0.05; done", marker),
+		Timeout: 30 * time.Second,
+	}}
+	stopWatch := make(chan struct{})
+	defer close(stopWatch)
+	go func() {
+		for {
+			select {
+			case <-stopWatch:
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+			if e.Prewarmed() != nil {
+				_ = os.WriteFile(marker, nil, 0644)
+				return
+			}
+		}
+	}()
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "polecat/a", "main"),
+		makeMR("mr-b", "polecat/b", "main"),
+	}
+	next := makeMR("mr-c", "polecat/c", "main")
+	e.listReadyMRs = func() ([]*MRInfo, error) {
+		return append(append([]*MRInfo{}, batch...), next), nil
+	}
+
+	cfg := DefaultBatchConfig()
+	cfg.PrewarmNextBatch = true
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+
+	pw := e.Prewarmed()
+	if pw == nil || !pw.Covers([]*MRInfo{next}) {
+		t.Fatalf("expected mr-c to be pre-warmed, got %+v", pw)
+	}
+	if !strings.Contains(testOutput(e).String(), "[Prewarm] Next batch fetched") {
+		t.Error("expected pre-warm log to be flushed to output")
+	}
+
+	// The next stack build consumes the pre-warm.
+	stacked, _, err := e.BuildRebaseStack(context.Background(), []*MRInfo{next}, "main")
+	if err != nil {
+		t.Fatalf("BuildRebaseStack: %v", err)
+	}
+	if len(stacked) != 1 {
+		t.Errorf("expected mr-c stacked, got %v", stackedIDs(stacked))
+	}
+	if e.Prewarmed() != nil {
+		t.Error("expected pre-warm to be consumed by the next stack build")
+	}
+}
+
+func TestProcessBatch_PrewarmAbandonedWhenGatesFinish(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: "true"}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "polecat/a", "main"),
+		makeMR("mr-b", "polecat/b", "main"),
+	}
+	// Listing the queue outlives the gates, so the pre-warm never starts
+	// fetching and landing must not wait for it beyond the grace period.
+	release := make(chan struct{})
+	defer close(release)
+	e.listReadyMRs = func() ([]*MRInfo, error) {
+		<-release
+		return []*MRInfo{makeMR("mr-c", "polecat/c", "main")}, nil
+	}
+
+	cfg := DefaultBatchConfig()
+	cfg.PrewarmNextBatch = true
+	start := time.Now()
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if len(result.Merged) != 2 {
+		t.Errorf("expected batch to land, merged=%v", stackedIDs(result.Merged))
+	}
+	if elapsed := time.Since(start); elapsed > prewarmJoinGrace+10*time.Second {
+		t.Errorf("landing waited too long for pre-warm: %v", elapsed)
+	}
+	if e.Prewarmed() != nil {
+		t.Error("abandoned pre-warm must not be recorded")
+	}
+}
+
+func TestBuildRebaseStack_RefreshesBaseAfterPrewarm(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/b", "b.txt", "b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	batch := []*MRInfo{
+		makeMR("mr-a", "polecat/a", "main"),
+		makeMR("mr-b", "polecat/b", "main"),
+	}
+	if _, err := e.PrewarmNextBatch(context.Background(), batch, nil, "main", DefaultBatchConfig()); err != nil {
+		t.Fatalf("PrewarmNextBatch: %v", err)
+	}
+
+	// Someone else lands on main after the pre-warm.
+	landed := pushFromClone(t, workDir, "main", "other.txt")
+
+	stacked, _, err := e.BuildRebaseStack(context.Background(), batch, "main")
+	if err != nil {
+		t.Fatalf("BuildRebaseStack: %v", err)
+	}
+	if len(stacked) != 2 {
+		t.Fatalf("expected 2 MRs stacked, got %v", stackedIDs(stacked))
+	}
+	run(t, workDir, "git", "merge-base", "--is-ancestor", landed, "HEAD")
+}
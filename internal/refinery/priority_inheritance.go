@@ -0,0 +1,52 @@
+package refinery
+
+import "sort"
+
+// inheritPriorities raises the priority of every MR in ready that blocks a
+// more urgent MR, directly or through a chain of blockers, to that MR's
+// priority, so the queue doesn't leave an urgent MR waiting behind a
+// low-priority one. waiting holds the MRs kept out of the queue by another
+// open MR (BlockedBy set to its ID); ready MRs blocking each other are
+// followed too. Each raised MR records the MR it inherited from in
+// InheritedFrom. Cycles are followed no further than once round.
+func inheritPriorities(ready, waiting []*MRInfo) {
+	byID := make(map[string]*MRInfo, len(ready)+len(waiting))
+	for _, mr := range waiting {
+		byID[mr.ID] = mr
+	}
+	for _, mr := range ready {
+		byID[mr.ID] = mr
+	}
+	own := make(map[string]int, len(byID))
+	for id, mr := range byID {
+		own[id] = mr.Priority
+	}
+	// Most urgent first, so each chain is raised by the MR it will keep.
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if own[ids[i]] != own[ids[j]] {
+			return own[ids[i]] < own[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		prio := own[id]
+		seen := map[string]bool{id: true}
+		for cur := byID[byID[id].BlockedBy]; cur != nil && !seen[cur.ID]; cur = byID[cur.BlockedBy] {
+			seen[cur.ID] = true
+			if prio < cur.Priority {
+				cur.Priority = prio
+				cur.InheritedFrom = id
+			}
+		}
+	}
+}
+
+// sortByPriority orders mrs most urgent first, keeping the order of MRs of
+// equal priority.
+func sortByPriority(mrs []*MRInfo) {
+	sort.SliceStable(mrs, func(i, j int) bool { return mrs[i].Priority < mrs[j].Priority })
+}
@@ -0,0 +1,37 @@
+package refinery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInheritPriorities(t *testing.T) {
+	// urgent (P0) waits on mid (P2), which waits on base (P4); low (P3)
+	// and other (P1) are independent; loop-a and loop-b block each other.
+	base := &MRInfo{ID: "base", Priority: 4}
+	low := &MRInfo{ID: "low", Priority: 3}
+	other := &MRInfo{ID: "other", Priority: 1}
+	loopA := &MRInfo{ID: "loop-a", Priority: 4, BlockedBy: "loop-b"}
+	ready := []*MRInfo{low, base, other, loopA}
+	waiting := []*MRInfo{
+		{ID: "mid", Priority: 2, BlockedBy: "base"},
+		{ID: "urgent", Priority: 0, BlockedBy: "mid"},
+		{ID: "loop-b", Priority: 2, BlockedBy: "loop-a"},
+	}
+
+	inheritPriorities(ready, waiting)
+	sortByPriority(ready)
+
+	if base.Priority != 0 || base.InheritedFrom != "urgent" {
+		t.Errorf("base = P%d from %q, want P0 from urgent", base.Priority, base.InheritedFrom)
+	}
+	if loopA.Priority != 2 || loopA.InheritedFrom != "loop-b" {
+		t.Errorf("loop-a = P%d from %q, want P2 from loop-b", loopA.Priority, loopA.InheritedFrom)
+	}
+	if low.InheritedFrom != "" || other.InheritedFrom != "" {
+		t.Error("unblocking MRs inherited a priority")
+	}
+	if got := strings.Join(mrIDs(ready), " "); got != "base other loop-a low" {
+		t.Errorf("ready order = %s, want base other loop-a low", got)
+	}
+}
@@ -0,0 +1,275 @@
+package refinery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress stages recorded for each MR as it moves through the queue.
+const (
+	StageHeld        = "held"
+	StageAdmitted    = "admitted"
+	StageBatched     = "batched"
+	StageDeferred    = "deferred"
+	StageStacking    = "stacking" // Batch-level: stacking started
+	StageStacked     = "stacked"
+	StageConflict    = "conflict"
+	StageGating      = "gating"
+	StageGatesPassed = "gates_passed"
+	StageGatesFailed = "gates_failed"
+	StageGateStarted = "gate_started" // One gate of a gate run; see ProgressEvent.Gate
+	StageGatePassed  = "gate_passed"
+	StageGateFailed  = "gate_failed"
+	StageBisecting   = "bisecting"
+	StageBisectStep  = "bisect_step" // The MRs under test in one bisection step
+	StagePushed      = "pushed"
+	StageMerged      = "merged"
+	StageCulprit     = "culprit"
+	StageReverted    = "reverted"
+	StageCancelled   = "cancelled" // Batch rolled back, MR still queued
+	StageError       = "error"
+)
+
+// maxProgressBytes is the size at which the progress log is rotated to
+// mr-progress.jsonl.1. Watchers only need recent events.
+const maxProgressBytes = 1 << 20
+
+// ProgressEvent is one line of the MR progress log. Events about a whole
+// batch rather than its MRs (StageStacking) have no MR.
+type ProgressEvent struct {
+	Time    time.Time `json:"time"`
+	MR      string    `json:"mr"`
+	BatchID string    `json:"batch_id,omitempty"`
+	Stage   string    `json:"stage"`
+	Gate    string    `json:"gate,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+}
+
+// Terminal reports whether the event ends the MR's run through the queue.
+// An error is not terminal: the MR stays queued and is retried.
+func (ev ProgressEvent) Terminal() bool {
+	switch ev.Stage {
+	case StageMerged, StageCulprit, StageConflict:
+		return true
+	}
+	return false
+}
+
+// ProgressPath returns the MR progress log for the rig at rigPath.
+func ProgressPath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "mr-progress.jsonl")
+}
+
+// progressHub fans progress events out to in-process subscribers (see
+// Engineer.Subscribe). It is shared by an engineer and its target engineers.
+type progressHub struct {
+	mu   sync.Mutex // Also serializes appends to the progress log
+	subs map[chan ProgressEvent]struct{}
+}
+
+// Subscribe returns a channel that receives every progress event the
+// engineer, or any of its target engineers, records from now on, and a
+// function that ends the subscription and closes the channel. A subscriber
+// more than buffer events behind misses events: progress must never hold
+// up processing. The same events are appended to the progress log (see
+// ProgressPath) for watchers in other processes.
+func (e *Engineer) Subscribe(buffer int) (<-chan ProgressEvent, func()) {
+	ch := make(chan ProgressEvent, buffer)
+	h := e.progress
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan ProgressEvent]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// recordProgress appends a stage event for each of mrs to the progress log
+// and sends them to subscribers. Failures are logged and otherwise
+// ignored: progress is for watchers and must never affect processing.
+func (e *Engineer) recordProgress(stage, detail, batchID string, mrs ...*MRInfo) {
+	if len(mrs) == 0 {
+		return
+	}
+	e.publishProgress(progressEvents(stage, "", detail, batchID, mrs))
+}
+
+// recordBatchEvent records an event about a whole batch.
+func (e *Engineer) recordBatchEvent(stage, detail, batchID string) {
+	e.publishProgress([]ProgressEvent{{Time: time.Now().UTC(), BatchID: batchID, Stage: stage, Detail: detail}})
+}
+
+// recordGateProgress records a gate event for the stack being gated under
+// ctx (see withGateStack). Gates run outside a batch record nothing.
+func (e *Engineer) recordGateProgress(ctx context.Context, stage, gate, detail string) {
+	stack, _ := ctx.Value(gateMRsKey{}).([]*MRInfo)
+	if len(stack) == 0 {
+		return
+	}
+	e.publishProgress(progressEvents(stage, gate, detail, stack[0].batchID, stack))
+}
+
+// recordGateResult records a gate's outcome under ctx. A failure that
+// doesn't block the stack (quarantined, or failing on the target too) is
+// recorded as failed, with why it doesn't block.
+func (e *Engineer) recordGateResult(ctx context.Context, r GateResult) {
+	elapsed := r.Elapsed.Truncate(time.Millisecond).String()
+	switch {
+	case r.Success:
+		e.recordGateProgress(ctx, StageGatePassed, r.Name, r.Name+" in "+elapsed)
+	case r.Quarantined:
+		e.recordGateProgress(ctx, StageGateFailed, r.Name, r.Name+" (quarantined, not blocking): "+r.Error)
+	case r.Baseline:
+		e.recordGateProgress(ctx, StageGateFailed, r.Name, r.Name+" (failing on the target too, not blocking): "+r.Error)
+	default:
+		e.recordGateProgress(ctx, StageGateFailed, r.Name, r.Name+": "+r.Error)
+	}
+}
+
+func (e *Engineer) publishProgress(events []ProgressEvent) {
+	if e.rig == nil {
+		return
+	}
+	h := e.progress
+	if h == nil {
+		h = &progressHub{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := writeProgress(ProgressPath(e.rig.Path), events); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Progress] Warning: %v\n", err)
+	}
+	for ch := range h.subs {
+		for _, ev := range events {
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+}
+
+func progressEvents(stage, gate, detail, batchID string, mrs []*MRInfo) []ProgressEvent {
+	now := time.Now().UTC()
+	events := make([]ProgressEvent, len(mrs))
+	for i, mr := range mrs {
+		events[i] = ProgressEvent{Time: now, MR: mr.ID, BatchID: batchID, Stage: stage, Gate: gate, Detail: detail}
+	}
+	return events
+}
+
+func appendProgress(path, stage, detail, batchID string, mrs []*MRInfo) error {
+	return writeProgress(path, progressEvents(stage, "", detail, batchID, mrs))
+}
+
+func writeProgress(path string, events []ProgressEvent) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > maxProgressBytes {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return fmt.Errorf("rotating progress log: %w", err)
+		}
+	}
+
+	var buf strings.Builder
+	for _, ev := range events {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(buf.String()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadProgress returns the events in the progress log at path written
+// after byte offset, and the offset to resume from. If the log was rotated
+// since offset was taken, reading restarts from the top of the new log.
+// A partially written last line is left for the next call.
+func ReadProgress(path string, offset int64) ([]ProgressEvent, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, offset, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, 0); err != nil {
+		return nil, offset, err
+	}
+
+	var events []ProgressEvent
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// EOF, possibly mid-line: resume from the last full line.
+			break
+		}
+		offset += int64(len(line))
+		var ev ProgressEvent
+		if json.Unmarshal(line, &ev) == nil {
+			events = append(events, ev)
+		}
+	}
+	return events, offset, nil
+}
+
+// recordBatchProgress records the final stage of every MR in a processed
+// batch. When the batch failed, MRs it neither landed nor blamed are
+// recorded as errors; they stay queued for the next batch.
+func (e *Engineer) recordBatchProgress(batch []*MRInfo, result *BatchResult) {
+	e.recordProgress(StageMerged, shortSHA(result.MergeCommit), result.BatchID, result.Merged...)
+	e.recordProgress(StageCulprit, "failed gates", result.BatchID, result.Culprits...)
+	e.recordProgress(StageConflict, "could not be stacked", result.BatchID, result.Conflicts...)
+	if result.Error == nil || result.Cancelled {
+		return
+	}
+	settled := make(map[string]bool)
+	for _, mr := range append(append(append([]*MRInfo{}, result.Merged...), result.Culprits...), result.Conflicts...) {
+		settled[mr.ID] = true
+	}
+	var pending []*MRInfo
+	for _, mr := range batch {
+		if !settled[mr.ID] {
+			pending = append(pending, mr)
+		}
+	}
+	e.recordProgress(StageError, result.Error.Error(), result.BatchID, pending...)
+}
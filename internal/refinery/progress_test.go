@@ -0,0 +1,126 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadProgress_ResumesAndSkipsPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mr-progress.jsonl")
+	if err := appendProgress(path, StageBatched, "", "", []*MRInfo{{ID: "mr-a"}, {ID: "mr-b"}}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"mr":"mr-a","sta`)
+	_ = f.Close()
+
+	events, offset, err := ReadProgress(path, 0)
+	if err != nil {
+		t.Fatalf("ReadProgress: %v", err)
+	}
+	if len(events) != 2 || events[0].MR != "mr-a" || events[1].Stage != StageBatched {
+		t.Fatalf("events = %+v, want two batched events", events)
+	}
+
+	events, again, err := ReadProgress(path, offset)
+	if err != nil || len(events) != 0 || again != offset {
+		t.Errorf("re-read = %d events at %d (%v), want none at %d", len(events), again, err, offset)
+	}
+
+	// A rotated (shorter) log is read from the top.
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendProgress(path, StageMerged, "abc", "", []*MRInfo{{ID: "mr-a"}}); err != nil {
+		t.Fatal(err)
+	}
+	events, _, err = ReadProgress(path, offset)
+	if err != nil || len(events) != 1 || !events[0].Terminal() {
+		t.Errorf("after rotation got %+v (%v), want the merged event", events, err)
+	}
+}
+
+func TestProcessBatch_RecordsProgress(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/bad", "FAIL_MARKER", "fail\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+
+	batch := []*MRInfo{makeMR("mr-a", "polecat/a", "main"), makeMR("mr-bad", "polecat/bad", "main")}
+	e.ProcessBatch(context.Background(), batch, "main", cfg)
+
+	events, _, err := ReadProgress(ProgressPath(workDir), 0)
+	if err != nil {
+		t.Fatalf("ReadProgress: %v", err)
+	}
+	stages := make(map[string][]string)
+	for _, ev := range events {
+		stages[ev.MR] = append(stages[ev.MR], ev.Stage)
+	}
+	for mr, want := range map[string]string{"mr-a": StageMerged, "mr-bad": StageCulprit} {
+		got := stages[mr]
+		if len(got) < 4 || got[0] != StageBatched || got[1] != StageStacked || got[2] != StageGating {
+			t.Errorf("%s stages = %v, want batched, stacked, gating first", mr, got)
+			continue
+		}
+		if got[len(got)-1] != want {
+			t.Errorf("%s final stage = %s, want %s", mr, got[len(got)-1], want)
+		}
+	}
+}
+
+func TestEngineer_SubscribeStreamsBatch(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "polecat/bad", "FAIL_MARKER", "fail\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+
+	events, stop := e.Subscribe(256)
+	batch := []*MRInfo{makeMR("mr-a", "polecat/a", "main"), makeMR("mr-bad", "polecat/bad", "main")}
+	e.ProcessBatch(context.Background(), batch, "main", cfg)
+	stop()
+	stop() // Idempotent
+
+	var stages []string
+	seen := make(map[string]ProgressEvent)
+	for ev := range events {
+		if _, ok := seen[ev.Stage]; !ok {
+			stages = append(stages, ev.Stage)
+		}
+		seen[ev.Stage] = ev
+	}
+	for _, want := range []string{StageStacking, StageStacked, StageGateStarted, StageGateFailed, StageBisectStep, StageGatePassed, StagePushed, StageMerged, StageCulprit} {
+		if _, ok := seen[want]; !ok {
+			t.Errorf("no %s event in %v", want, stages)
+		}
+	}
+	if ev := seen[StageStacking]; ev.MR != "" || ev.BatchID == "" || ev.Detail != "2 MRs onto main" {
+		t.Errorf("stacking event = %+v, want a batch-level event", ev)
+	}
+	if ev := seen[StageGateFailed]; ev.Gate != "check" || ev.BatchID == "" {
+		t.Errorf("gate_failed event = %+v", ev)
+	}
+	if ev := seen[StagePushed]; ev.MR != "mr-a" || !strings.HasPrefix(ev.Detail, "origin/main at ") {
+		t.Errorf("pushed event = %+v", ev)
+	}
+
+	// Subscribers see what the progress log records.
+	logged, _, err := ReadProgress(ProgressPath(workDir), 0)
+	if err != nil || len(logged) == 0 || logged[0].Stage != StageBatched {
+		t.Errorf("progress log = %d events (%v)", len(logged), err)
+	}
+}
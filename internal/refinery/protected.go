@@ -0,0 +1,227 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ProtectedBranchConfig configures a long-lived feature branch that the
+// refinery merges into alongside the rig's default branch. MRs targeting it
+// form their own queue and run its gates, and the default branch is merged
+// into it periodically so it doesn't drift.
+type ProtectedBranchConfig struct {
+	// Gates replace the rig's gates for MRs targeting this branch.
+	// Empty means the rig's gates apply.
+	Gates map[string]*GateConfig `json:"gates,omitempty"`
+
+	// SyncFrom is the branch periodically merged into this one.
+	// Default: the rig's default branch.
+	SyncFrom string `json:"sync_from,omitempty"`
+
+	// SyncInterval is how often SyncFrom is merged in. Zero disables
+	// periodic syncs (gt mq protected sync still works on demand).
+	SyncInterval time.Duration `json:"sync_interval,omitempty"`
+
+	// Batch replaces the batch config passed to ProcessTargets for this
+	// branch's batches. Nil means that config applies.
+	Batch *BatchConfig `json:"batch,omitempty"`
+}
+
+// protectedBranchRaw is the JSON form of ProtectedBranchConfig with string
+// durations.
+type protectedBranchRaw struct {
+	Gates        map[string]*gateConfigRaw `json:"gates"`
+	SyncFrom     string                    `json:"sync_from"`
+	SyncInterval string                    `json:"sync_interval"`
+	Batch        *BatchConfig              `json:"batch"`
+}
+
+// Protected branch sync outcomes.
+const (
+	SyncMerged      = "merged"
+	SyncUpToDate    = "up-to-date"
+	SyncConflict    = "conflict"
+	SyncGatesFailed = "gates-failed"
+)
+
+// ProtectedSyncStatus records the last sync of a protected branch.
+type ProtectedSyncStatus struct {
+	LastAttempt time.Time `json:"last_attempt"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	Result      string    `json:"result"`
+	Commit      string    `json:"commit,omitempty"` // Branch head after the sync
+	Error       string    `json:"error,omitempty"`
+}
+
+// IsProtectedBranch reports whether branch is a registered protected branch.
+func (e *Engineer) IsProtectedBranch(branch string) bool {
+	_, ok := e.config.ProtectedBranches[branch]
+	return ok
+}
+
+// ProtectedBranches returns the registered protected branches, sorted.
+func (e *Engineer) ProtectedBranches() []string {
+	names := make([]string, 0, len(e.config.ProtectedBranches))
+	for name := range e.config.ProtectedBranches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// gatesFor returns the gates for MRs landing on target: the protected
+// branch's own gates when it defines any, otherwise the rig's.
+func (e *Engineer) gatesFor(target string) map[string]*GateConfig {
+	if pb := e.config.ProtectedBranches[target]; pb != nil && len(pb.Gates) > 0 {
+		return pb.Gates
+	}
+	return e.config.Gates
+}
+
+// SplitQueues partitions a score-ordered MR list into one queue per target
+// branch, each keeping score order. Targets are returned in the order of
+// their best-scoring MR. A batch must only ever be assembled from a single
+// queue.
+func SplitQueues(mrs []*MRInfo) (targets []string, queues map[string][]*MRInfo) {
+	queues = make(map[string][]*MRInfo)
+	for _, mr := range mrs {
+		if _, ok := queues[mr.Target]; !ok {
+			targets = append(targets, mr.Target)
+		}
+		queues[mr.Target] = append(queues[mr.Target], mr)
+	}
+	return targets, queues
+}
+
+// protectedSyncPath is where per-branch sync status is kept.
+func (e *Engineer) protectedSyncPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "protected-branches.json")
+}
+
+// ProtectedSyncStatuses returns the last sync status of each protected
+// branch that has been synced.
+func (e *Engineer) ProtectedSyncStatuses() (map[string]*ProtectedSyncStatus, error) {
+	statuses := make(map[string]*ProtectedSyncStatus)
+	data, err := os.ReadFile(e.protectedSyncPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return statuses, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("parsing protected branch status: %w", err)
+	}
+	return statuses, nil
+}
+
+func (e *Engineer) saveProtectedSyncStatus(branch string, status *ProtectedSyncStatus) error {
+	statuses, err := e.ProtectedSyncStatuses()
+	if err != nil {
+		return err
+	}
+	if prev := statuses[branch]; prev != nil && status.LastSuccess.IsZero() {
+		status.LastSuccess = prev.LastSuccess
+	}
+	statuses[branch] = status
+	return util.EnsureDirAndWriteJSON(e.protectedSyncPath(), statuses)
+}
+
+// DueProtectedSyncs returns the protected branches whose sync interval has
+// elapsed since their last sync attempt, sorted.
+func (e *Engineer) DueProtectedSyncs(now time.Time) ([]string, error) {
+	statuses, err := e.ProtectedSyncStatuses()
+	if err != nil {
+		return nil, err
+	}
+	var due []string
+	for _, name := range e.ProtectedBranches() {
+		interval := e.config.ProtectedBranches[name].SyncInterval
+		if interval <= 0 {
+			continue
+		}
+		if s := statuses[name]; s == nil || now.Sub(s.LastAttempt) >= interval {
+			due = append(due, name)
+		}
+	}
+	return due, nil
+}
+
+// SyncProtectedBranch merges the branch's SyncFrom branch into it, runs the
+// branch's gates on the result, and pushes it. A conflict or gate failure
+// leaves the branch untouched and is reported in the returned status (not
+// as an error); errors are infrastructure failures.
+func (e *Engineer) SyncProtectedBranch(ctx context.Context, branch string) (*ProtectedSyncStatus, error) {
+	pb := e.config.ProtectedBranches[branch]
+	if pb == nil {
+		return nil, fmt.Errorf("%s is not a protected branch", branch)
+	}
+	from := pb.SyncFrom
+	if from == "" {
+		from = e.rig.DefaultBranch()
+	}
+	status := &ProtectedSyncStatus{LastAttempt: time.Now().UTC()}
+
+	if err := e.git.Fetch("origin"); err != nil {
+		return nil, fmt.Errorf("fetch origin: %w", err)
+	}
+	if err := e.git.Checkout(branch); err != nil {
+		return nil, fmt.Errorf("checkout %s: %w", branch, err)
+	}
+	if err := e.git.Pull("origin", branch); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Protected] Warning: pull origin/%s: %v (continuing)\n", branch, err)
+	}
+	base, err := e.git.Rev("HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", branch, err)
+	}
+
+	upToDate, err := e.git.IsAncestor("origin/"+from, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("compare %s with origin/%s: %w", branch, from, err)
+	}
+	if upToDate {
+		status.Result = SyncUpToDate
+		status.Commit = base
+		status.LastSuccess = status.LastAttempt
+		return status, e.saveProtectedSyncStatus(branch, status)
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Protected] Merging origin/%s into %s\n", from, branch)
+	if err := e.git.MergeNoFF("origin/"+from, fmt.Sprintf("Merge %s into %s", from, branch)); err != nil {
+		_ = e.git.AbortMerge()
+		status.Result = SyncConflict
+		status.Error = err.Error()
+		return status, e.saveProtectedSyncStatus(branch, status)
+	}
+
+	if result := e.runTargetGates(ctx, branch); !result.Success {
+		if resetErr := e.git.ResetHard(base); resetErr != nil {
+			return nil, fmt.Errorf("restore %s after failed gates: %w", branch, resetErr)
+		}
+		status.Result = SyncGatesFailed
+		status.Error = result.Error
+		return status, e.saveProtectedSyncStatus(branch, status)
+	}
+
+	if err := e.git.Push("origin", branch, false); err != nil {
+		_ = e.git.ResetHard(base)
+		return nil, fmt.Errorf("push %s: %w", branch, err)
+	}
+	head, err := e.git.Rev("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	status.Result = SyncMerged
+	status.Commit = head
+	status.LastSuccess = status.LastAttempt
+	_, _ = fmt.Fprintf(e.output, "[Protected] %s synced with %s at %s\n", branch, from, shortSHA(head))
+	return status, e.saveProtectedSyncStatus(branch, status)
+}
@@ -0,0 +1,204 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestEngineer_LoadConfig_ProtectedBranches(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := map[string]interface{}{
+		"merge_queue": map[string]interface{}{
+			"gates": map[string]interface{}{
+				"test": map[string]interface{}{"cmd": "go test ./..."},
+			},
+			"protected_branches": map[string]interface{}{
+				"feature/search": map[string]interface{}{
+					"gates": map[string]interface{}{
+						"e2e": map[string]interface{}{"cmd": "make e2e", "timeout": "20m"},
+					},
+					"sync_interval": "24h",
+				},
+				"feature/billing": map[string]interface{}{"sync_from": "develop"},
+			},
+		},
+	}
+	data, _ := json.Marshal(config)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	if got := e.ProtectedBranches(); len(got) != 2 || got[0] != "feature/billing" || got[1] != "feature/search" {
+		t.Fatalf("ProtectedBranches() = %v", got)
+	}
+	search := e.config.ProtectedBranches["feature/search"]
+	if search.SyncInterval != 24*time.Hour || search.Gates["e2e"].Timeout != 20*time.Minute {
+		t.Errorf("feature/search config = %+v", search)
+	}
+	if e.config.ProtectedBranches["feature/billing"].SyncFrom != "develop" {
+		t.Errorf("feature/billing sync_from not parsed")
+	}
+
+	// Protected branches with gates use their own; others inherit the rig's.
+	if _, ok := e.gatesFor("feature/search")["e2e"]; !ok {
+		t.Error("feature/search should use its own gates")
+	}
+	if _, ok := e.gatesFor("feature/billing")["test"]; !ok {
+		t.Error("feature/billing should inherit rig gates")
+	}
+	if _, ok := e.gatesFor("main")["test"]; !ok {
+		t.Error("main should use rig gates")
+	}
+}
+
+func TestEngineer_LoadConfig_ProtectedBranchBadInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"protected_branches": {"feature/x": {"sync_interval": "daily"}}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err == nil {
+		t.Fatal("expected error for invalid sync_interval")
+	}
+}
+
+func TestSplitQueues(t *testing.T) {
+	mrs := []*MRInfo{
+		makeMR("mr-1", "polecat/a", "feature/x"),
+		makeMR("mr-2", "polecat/b", "main"),
+		makeMR("mr-3", "polecat/c", "feature/x"),
+		makeMR("mr-4", "polecat/d", "main"),
+	}
+	targets, queues := SplitQueues(mrs)
+	if len(targets) != 2 || targets[0] != "feature/x" || targets[1] != "main" {
+		t.Fatalf("targets = %v", targets)
+	}
+	if got := stackedIDs(queues["feature/x"]); len(got) != 2 || got[0] != "mr-1" || got[1] != "mr-3" {
+		t.Errorf("feature/x queue = %v", got)
+	}
+	if got := stackedIDs(queues["main"]); len(got) != 2 || got[0] != "mr-2" || got[1] != "mr-4" {
+		t.Errorf("main queue = %v", got)
+	}
+}
+
+// protectedTestRepo creates a repo with a protected feature/x branch and a
+// newer commit on main that the branch doesn't have yet.
+func protectedTestRepo(t *testing.T, mainFile, mainContent string) *Engineer {
+	t.Helper()
+	workDir, g, _ := testGitRepo(t)
+	run(t, workDir, "git", "checkout", "-b", "feature/x", "main")
+	writeFile(t, workDir, "feature.txt", "feature work\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "feature work")
+	run(t, workDir, "git", "push", "-u", "origin", "feature/x")
+	run(t, workDir, "git", "checkout", "main")
+	writeFile(t, workDir, mainFile, mainContent)
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "main moves on")
+	run(t, workDir, "git", "push", "origin", "main")
+
+	e := newTestEngineer(t, workDir, g)
+	e.rig.Path = t.TempDir() // Keep sync status out of the work tree
+	e.config.ProtectedBranches = map[string]*ProtectedBranchConfig{
+		"feature/x": {SyncFrom: "main", SyncInterval: time.Hour},
+	}
+	return e
+}
+
+func TestSyncProtectedBranch_MergesAndPushes(t *testing.T) {
+	e := protectedTestRepo(t, "main.txt", "main work\n")
+	mainHead, _ := e.git.Rev("origin/main")
+
+	status, err := e.SyncProtectedBranch(context.Background(), "feature/x")
+	if err != nil {
+		t.Fatalf("SyncProtectedBranch: %v", err)
+	}
+	if status.Result != SyncMerged {
+		t.Fatalf("result = %s (%s), want %s", status.Result, status.Error, SyncMerged)
+	}
+	if ok, _ := e.git.IsAncestor(mainHead, "origin/feature/x"); !ok {
+		t.Error("origin/feature/x should contain main after sync")
+	}
+
+	// A second sync has nothing to merge.
+	status, err = e.SyncProtectedBranch(context.Background(), "feature/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Result != SyncUpToDate {
+		t.Errorf("second sync result = %s, want %s", status.Result, SyncUpToDate)
+	}
+
+	due, err := e.DueProtectedSyncs(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("just-synced branch should not be due, got %v", due)
+	}
+	due, _ = e.DueProtectedSyncs(time.Now().Add(2 * time.Hour))
+	if len(due) != 1 || due[0] != "feature/x" {
+		t.Errorf("DueProtectedSyncs after interval = %v", due)
+	}
+}
+
+func TestSyncProtectedBranch_ConflictLeavesBranch(t *testing.T) {
+	e := protectedTestRepo(t, "feature.txt", "main edits the same file\n")
+	before, _ := e.git.Rev("origin/feature/x")
+
+	status, err := e.SyncProtectedBranch(context.Background(), "feature/x")
+	if err != nil {
+		t.Fatalf("SyncProtectedBranch: %v", err)
+	}
+	if status.Result != SyncConflict {
+		t.Fatalf("result = %s, want %s", status.Result, SyncConflict)
+	}
+	if after, _ := e.git.Rev("origin/feature/x"); after != before {
+		t.Error("conflicting sync must not push")
+	}
+}
+
+func TestSyncProtectedBranch_GateFailureResets(t *testing.T) {
+	e := protectedTestRepo(t, "FAIL_MARKER", "x\n")
+	e.config.ProtectedBranches["feature/x"].Gates = map[string]*GateConfig{
+		"check": {Cmd: failMarkerGateCmd()},
+	}
+	before, _ := e.git.Rev("feature/x")
+
+	status, err := e.SyncProtectedBranch(context.Background(), "feature/x")
+	if err != nil {
+		t.Fatalf("SyncProtectedBranch: %v", err)
+	}
+	if status.Result != SyncGatesFailed {
+		t.Fatalf("result = %s, want %s", status.Result, SyncGatesFailed)
+	}
+	if after, _ := e.git.Rev("feature/x"); after != before {
+		t.Error("failed sync should reset the local branch")
+	}
+	statuses, err := e.ProtectedSyncStatuses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses["feature/x"]; s == nil || s.Result != SyncGatesFailed || !s.LastSuccess.IsZero() {
+		t.Errorf("recorded status = %+v", s)
+	}
+}
+
+func TestSyncProtectedBranch_Unknown(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	if _, err := e.SyncProtectedBranch(context.Background(), "feature/nope"); err == nil {
+		t.Fatal("expected error for unregistered branch")
+	}
+}
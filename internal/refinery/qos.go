@@ -0,0 +1,57 @@
+package refinery
+
+import (
+	"math"
+	"strings"
+)
+
+// Merge queue quality-of-service classes. An MR declares its class with a
+// "qos: <class>" line in its description.
+const (
+	// QoSInteractive is for small, urgent fixes someone is waiting on.
+	QoSInteractive = "interactive"
+	// QoSBatch is ordinary feature work. MRs without a class are batch.
+	QoSBatch = "batch"
+	// QoSBackground is for long-running refactors and bulk changes.
+	QoSBackground = "background"
+)
+
+// DefaultQoSReserve is the share of batch capacity held for each class.
+// The remainder is filled in score order regardless of class.
+func DefaultQoSReserve() map[string]float64 {
+	return map[string]float64{
+		QoSInteractive: 0.2,
+		QoSBatch:       0.2,
+		QoSBackground:  0.2,
+	}
+}
+
+// normalizeQoS maps a declared class to a known one. Unknown or empty
+// classes are treated as batch.
+func normalizeQoS(class string) string {
+	switch c := strings.ToLower(strings.TrimSpace(class)); c {
+	case QoSInteractive, QoSBackground:
+		return c
+	default:
+		return QoSBatch
+	}
+}
+
+// qosSlots converts per-class reserve fractions into slot counts for a batch
+// of maxSize. A positive fraction always reserves at least one slot, and the
+// total never exceeds maxSize (interactive is served first when it would).
+func qosSlots(reserve map[string]float64, maxSize int) map[string]int {
+	slots := make(map[string]int, len(reserve))
+	remaining := maxSize
+	for _, class := range []string{QoSInteractive, QoSBatch, QoSBackground} {
+		frac := reserve[class]
+		if frac <= 0 || remaining == 0 {
+			continue
+		}
+		n := max(int(math.Floor(frac*float64(maxSize))), 1)
+		n = min(n, remaining)
+		slots[class] = n
+		remaining -= n
+	}
+	return slots
+}
@@ -0,0 +1,244 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// QuarantineConfig configures flaky gate quarantine. A gate that fails is
+// retried once; a failure that passes on retry is a flake. Once a gate's
+// flake rate over its recent runs reaches FlakeRate it is quarantined: it
+// still runs and is reported, but its failures no longer block merges, so
+// bisection can't blame an MR for them. Quarantined gates stay that way
+// until released (gt mq quarantine release).
+type QuarantineConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// FlakeRate is the fraction of a gate's recent runs that must be flakes
+	// for it to be quarantined. Default: 0.2.
+	FlakeRate float64 `json:"flake_rate,omitempty"`
+
+	// MinRuns is the number of recorded runs a gate needs before it can be
+	// quarantined. Default: 10.
+	MinRuns int `json:"min_runs,omitempty"`
+
+	// Window is the number of recent runs per gate the flake rate is
+	// computed over. Default: 50.
+	Window int `json:"window,omitempty"`
+}
+
+// Quarantine defaults.
+const (
+	defaultQuarantineFlakeRate = 0.2
+	defaultQuarantineMinRuns   = 10
+	defaultQuarantineWindow    = 50
+)
+
+// Gate run outcomes recorded for quarantine.
+const (
+	GateOutcomePass  = "pass"
+	GateOutcomeFlake = "flake" // Failed, then passed on retry
+	GateOutcomeFail  = "fail"
+)
+
+func (c *QuarantineConfig) flakeRate() float64 {
+	if c.FlakeRate > 0 {
+		return c.FlakeRate
+	}
+	return defaultQuarantineFlakeRate
+}
+
+func (c *QuarantineConfig) minRuns() int {
+	if c.MinRuns > 0 {
+		return c.MinRuns
+	}
+	return defaultQuarantineMinRuns
+}
+
+func (c *QuarantineConfig) window() int {
+	if c.Window > 0 {
+		return c.Window
+	}
+	return defaultQuarantineWindow
+}
+
+// validateQuarantineConfig checks a QuarantineConfig from config.json.
+func validateQuarantineConfig(c *QuarantineConfig) error {
+	if c.FlakeRate < 0 || c.FlakeRate > 1 {
+		return fmt.Errorf("quarantine flake_rate must be between 0 and 1, got %v", c.FlakeRate)
+	}
+	if c.MinRuns < 0 {
+		return fmt.Errorf("quarantine min_runs must be non-negative, got %d", c.MinRuns)
+	}
+	if c.Window < 0 {
+		return fmt.Errorf("quarantine window must be non-negative, got %d", c.Window)
+	}
+	return nil
+}
+
+// GateQuarantineStatus is the flake record of one gate.
+type GateQuarantineStatus struct {
+	// Outcomes are the gate's recent run outcomes, oldest first.
+	Outcomes      []string  `json:"outcomes,omitempty"`
+	Quarantined   bool      `json:"quarantined"`
+	QuarantinedAt time.Time `json:"quarantined_at,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+// Flakes returns the number of flakes among the recorded outcomes.
+func (s *GateQuarantineStatus) Flakes() int {
+	n := 0
+	for _, o := range s.Outcomes {
+		if o == GateOutcomeFlake {
+			n++
+		}
+	}
+	return n
+}
+
+func (e *Engineer) quarantinePath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "gate-quarantine.json")
+}
+
+// GateQuarantine returns the flake record of every gate that has one,
+// keyed by gate name.
+func (e *Engineer) GateQuarantine() (map[string]*GateQuarantineStatus, error) {
+	statuses := make(map[string]*GateQuarantineStatus)
+	data, err := os.ReadFile(e.quarantinePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return statuses, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("parsing gate quarantine: %w", err)
+	}
+	return statuses, nil
+}
+
+// QuarantinedGates returns the names of the quarantined gates, sorted.
+func (e *Engineer) QuarantinedGates() ([]string, error) {
+	statuses, err := e.GateQuarantine()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name, s := range statuses {
+		if s.Quarantined {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// UnquarantineGate releases a gate from quarantine, making its failures
+// blocking again. Its outcome history is cleared so that the flakes that
+// got it quarantined don't put it straight back.
+func (e *Engineer) UnquarantineGate(name string) error {
+	e.quarantineMu.Lock()
+	defer e.quarantineMu.Unlock()
+
+	statuses, err := e.GateQuarantine()
+	if err != nil {
+		return err
+	}
+	if s := statuses[name]; s == nil || !s.Quarantined {
+		return fmt.Errorf("gate %q is not quarantined", name)
+	}
+	statuses[name] = &GateQuarantineStatus{}
+	return util.EnsureDirAndWriteJSON(e.quarantinePath(), statuses)
+}
+
+// recordGateOutcome appends a run outcome to the gate's record and
+// quarantines the gate if its flake rate has reached the threshold.
+func (e *Engineer) recordGateOutcome(name, outcome string) {
+	cfg := e.config.Quarantine
+	e.quarantineMu.Lock()
+	defer e.quarantineMu.Unlock()
+	defer e.lockState()()
+
+	statuses, err := e.GateQuarantine()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Quarantine] Warning: record gate outcome: %v\n", err)
+		return
+	}
+	s := statuses[name]
+	if s == nil {
+		s = &GateQuarantineStatus{}
+		statuses[name] = s
+	}
+	s.Outcomes = append(s.Outcomes, outcome)
+	if len(s.Outcomes) > cfg.window() {
+		s.Outcomes = s.Outcomes[len(s.Outcomes)-cfg.window():]
+	}
+	if !s.Quarantined && len(s.Outcomes) >= cfg.minRuns() {
+		flakes := s.Flakes()
+		if float64(flakes)/float64(len(s.Outcomes)) >= cfg.flakeRate() {
+			s.Quarantined = true
+			s.QuarantinedAt = time.Now().UTC()
+			s.Reason = fmt.Sprintf("%d of last %d runs flaky", flakes, len(s.Outcomes))
+			_, _ = fmt.Fprintf(e.output, "[Quarantine] Gate %q quarantined (%s); its failures no longer block merges\n", name, s.Reason)
+		}
+	}
+	if err := util.EnsureDirAndWriteJSON(e.quarantinePath(), statuses); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Quarantine] Warning: record gate outcome: %v\n", err)
+	}
+}
+
+// runGateTracked runs a gate of a gate set in dir. With quarantine enabled,
+// a failed gate is retried once and the outcome recorded. Timeouts are
+// neither retried here nor recorded: the caller retries a hung gate set,
+// and a hang says more about the machine than the gate.
+func (e *Engineer) runGateTracked(ctx context.Context, dir, name string, gate *GateConfig) GateResult {
+	result := e.runGateIn(ctx, dir, name, gate)
+	if e.config.Quarantine == nil || !e.config.Quarantine.Enabled || result.TimedOut || ctx.Err() != nil {
+		return result
+	}
+	outcome := GateOutcomePass
+	if !result.Success {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Gate %q: failed, retrying once to check for a flake...\n", name)
+		retry := e.runGateIn(ctx, dir, name, gate)
+		switch {
+		case retry.Success:
+			outcome = GateOutcomeFlake
+			retry.Flaky = true
+			retry.Elapsed += result.Elapsed
+			retry.Energy = retry.Energy.add(result.Energy)
+			result = retry
+		case retry.TimedOut || ctx.Err() != nil:
+			return result
+		default:
+			outcome = GateOutcomeFail
+		}
+	}
+	e.recordGateOutcome(name, outcome)
+	return result
+}
+
+// quarantinedGateSet returns the quarantined gates as a set, or nil when
+// quarantine is disabled. Errors reading the record are logged and treated
+// as no gates quarantined, leaving every gate blocking.
+func (e *Engineer) quarantinedGateSet() map[string]bool {
+	if e.config.Quarantine == nil || !e.config.Quarantine.Enabled {
+		return nil
+	}
+	names, err := e.QuarantinedGates()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Quarantine] Warning: %v (treating all gates as blocking)\n", err)
+		return nil
+	}
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
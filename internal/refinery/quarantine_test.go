@@ -0,0 +1,103 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// scriptedGates replays gate outcomes in order per gate name; once a
+// gate's script runs out, its last outcome repeats.
+func scriptedGates(e *Engineer, scripts map[string][]bool) {
+	calls := make(map[string]int)
+	e.execGate = func(ctx context.Context, dir, name string, gate *GateConfig) GateResult {
+		script := scripts[name]
+		i := calls[name]
+		calls[name]++
+		if i >= len(script) {
+			i = len(script) - 1
+		}
+		if script[i] {
+			return GateResult{Name: name, Success: true}
+		}
+		return GateResult{Name: name, Error: "exit status 1"}
+	}
+}
+
+func newQuarantineEngineer(t *testing.T, cfg *QuarantineConfig) *Engineer {
+	t.Helper()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.SetOutput(&bytes.Buffer{})
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: "true"}}
+	e.config.Quarantine = cfg
+	return e
+}
+
+func TestQuarantine_FlakePassesAndIsRecorded(t *testing.T) {
+	e := newQuarantineEngineer(t, &QuarantineConfig{Enabled: true})
+	scriptedGates(e, map[string][]bool{"test": {false, true}})
+
+	if result := e.runGates(context.Background()); !result.Success {
+		t.Fatalf("flaky gate should pass on retry, got: %s", result.Error)
+	}
+	statuses, err := e.GateQuarantine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses["test"]; s == nil || len(s.Outcomes) != 1 || s.Outcomes[0] != GateOutcomeFlake || s.Quarantined {
+		t.Errorf("status = %+v, want one flake, not quarantined", s)
+	}
+}
+
+func TestQuarantine_DemotesFlakyGate(t *testing.T) {
+	e := newQuarantineEngineer(t, &QuarantineConfig{Enabled: true, MinRuns: 4, FlakeRate: 0.5})
+	// pass, flake, pass, flake (quarantined), then fails for real.
+	scriptedGates(e, map[string][]bool{"test": {true, false, true, true, false, true, false}})
+
+	for i := 0; i < 4; i++ {
+		if result := e.runGates(context.Background()); !result.Success {
+			t.Fatalf("run %d: unexpected failure: %s", i+1, result.Error)
+		}
+	}
+	quarantined, err := e.QuarantinedGates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 1 || quarantined[0] != "test" {
+		t.Fatalf("QuarantinedGates() = %v, want [test]", quarantined)
+	}
+
+	// A quarantined gate's failure is reported but doesn't block.
+	if result := e.runGates(context.Background()); !result.Success {
+		t.Errorf("quarantined gate failure should not block, got: %s", result.Error)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "quarantined as flaky, not blocking") {
+		t.Errorf("expected non-blocking failure to be reported, output:\n%s", out)
+	}
+
+	// Released, the gate blocks again.
+	if err := e.UnquarantineGate("test"); err != nil {
+		t.Fatalf("UnquarantineGate: %v", err)
+	}
+	if result := e.runGates(context.Background()); result.Success {
+		t.Error("released gate failure should block")
+	}
+	if err := e.UnquarantineGate("test"); err == nil {
+		t.Error("UnquarantineGate on a gate that isn't quarantined should fail")
+	}
+}
+
+func TestQuarantine_DisabledDoesNotRetry(t *testing.T) {
+	e := newQuarantineEngineer(t, nil)
+	scriptedGates(e, map[string][]bool{"test": {false, true}})
+
+	if result := e.runGates(context.Background()); result.Success {
+		t.Error("without quarantine, a failed gate should not be retried")
+	}
+	if statuses, _ := e.GateQuarantine(); len(statuses) != 0 {
+		t.Errorf("without quarantine, nothing should be recorded, got %v", statuses)
+	}
+}
@@ -0,0 +1,140 @@
+package refinery
+
+import (
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// The merge queue lives in beads: every open merge-request bead is a queued
+// MR, so the queue survives refinery and daemon restarts, and any process
+// with access to the rig's beads can submit to it. Dequeuing claims MRs by
+// assignee (see ClaimMR), which hides them from ListReadyMRs until they are
+// requeued, closed, or their claim goes stale. Enqueue, Dequeue and
+// Requeue each run as one update under the rig's queue lock (see
+// UpdateQueue).
+
+// EnqueueRequest describes an MR to submit to the merge queue.
+type EnqueueRequest struct {
+	Branch        string // Source branch; required
+	Target        string // Target branch; default: the rig's default branch
+	SourceIssue   string // The work item being merged
+	Worker        string // Who did the work
+	Priority      int    // 0 (highest) to 4
+	QoS           string // Service class (see beads.MRFields.QoS)
+	MergeStrategy string // How the MR lands (see merge_strategy.go)
+}
+
+// Enqueue submits req to the merge queue, creating its MR bead. It is
+// idempotent per branch: if an open MR already exists for req.Branch, that
+// MR is returned and created is false.
+func (e *Engineer) Enqueue(req *EnqueueRequest) (mr *MRInfo, created bool, err error) {
+	if req.Branch == "" {
+		return nil, false, fmt.Errorf("enqueue: branch is required")
+	}
+	if req.Priority < 0 || req.Priority > 4 {
+		return nil, false, fmt.Errorf("enqueue: priority must be 0-4, got %d", req.Priority)
+	}
+	fields := &beads.MRFields{
+		Branch:        req.Branch,
+		Target:        req.Target,
+		SourceIssue:   req.SourceIssue,
+		Worker:        req.Worker,
+		Rig:           e.rig.Name,
+		QoS:           req.QoS,
+		MergeStrategy: req.MergeStrategy,
+	}
+	if fields.Target == "" {
+		fields.Target = e.rig.DefaultBranch()
+	}
+	title := "Merge: " + req.SourceIssue
+	if req.SourceIssue == "" {
+		title = "Merge: " + req.Branch
+	}
+	err = e.UpdateQueue(func(u *QueueUpdate) error {
+		issue, isNew, err := u.Submit(req.Branch, beads.CreateOptions{
+			Title:       title,
+			Labels:      []string{"gt:merge-request"},
+			Priority:    req.Priority,
+			Description: beads.FormatMRFields(fields),
+			Ephemeral:   true,
+		})
+		if err != nil {
+			return err
+		}
+		mr, created = issueMRInfo(issue), isNew
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return mr, created, nil
+}
+
+// issueMRInfo converts an MR bead, whose fields may be missing, to MRInfo.
+func issueMRInfo(issue *beads.Issue) *MRInfo {
+	fields := beads.ParseMRFields(issue)
+	if fields == nil {
+		fields = &beads.MRFields{}
+	}
+	return issueToMRInfo(issue, fields)
+}
+
+// Peek returns up to n of the MRs at the head of the queue, the ones
+// Dequeue would take, without claiming them. n <= 0 returns them all.
+func (e *Engineer) Peek(n int) ([]*MRInfo, error) {
+	mrs, err := e.listReadyMRs()
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 && len(mrs) > n {
+		mrs = mrs[:n]
+	}
+	return mrs, nil
+}
+
+// Dequeue claims up to n MRs from the head of the queue for worker and
+// returns them; n <= 0 claims all that are ready. Consumers take turns
+// under the queue lock, so each sees the claims made before it.
+//
+// Claimed MRs leave the queue until Requeue releases them or they are
+// closed. A consumer that crashes holding claims doesn't lose them: a claim
+// that goes stale (StaleClaimTimeout) is eligible to be dequeued again.
+// If a claim fails, Dequeue releases the ones it made and returns none.
+func (e *Engineer) Dequeue(worker string, n int) ([]*MRInfo, error) {
+	if worker == "" {
+		return nil, fmt.Errorf("dequeue: worker is required")
+	}
+	var claimed []*MRInfo
+	err := e.UpdateQueue(func(u *QueueUpdate) error {
+		ready, err := u.Ready()
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(ready))
+		for _, mr := range ready {
+			if n > 0 && len(ids) == n {
+				break
+			}
+			ids = append(ids, mr.ID)
+		}
+		for _, id := range ids {
+			mr, err := u.Claim(id, worker)
+			if err != nil {
+				return err
+			}
+			claimed = append(claimed, mr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// Requeue returns a dequeued MR to the queue by releasing its claim (see
+// ReleaseMR). It takes its place by priority again on the next Dequeue.
+func (e *Engineer) Requeue(mrID string) error {
+	return e.ReleaseMR(mrID)
+}
@@ -0,0 +1,221 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// The merge queue is the rig's open MR beads. Beads has no compare-and-set
+// and no transactions, so every read-modify-write of the queue runs under
+// the rig's queue lock (see UpdateQueue): two producers can't both find no
+// MR for a branch and create one each, and two consumers can't both claim
+// the same MR.
+
+// queueLock is the rig file lock serializing queue updates.
+const queueLock = "queue.lock"
+
+// ErrMRNotReady is returned when claiming an MR that isn't waiting in the
+// queue: it is claimed, blocked, closed, or not a merge request.
+var ErrMRNotReady = errors.New("merge request is not ready")
+
+// QueueUpdate is one serialized update of a rig's merge queue (see
+// UpdateQueue). It remembers the MRs it reads and writes, so repeated
+// lookups within the update don't go back to beads, and how to undo each
+// write. A QueueUpdate is only valid inside the function it was passed to.
+type QueueUpdate struct {
+	e        *Engineer
+	ready    []*MRInfo               // Ready MRs in queue order; nil = not loaded
+	byID     map[string]*MRInfo      // ready, by MR ID
+	byBranch map[string]*beads.Issue // Open MR beads read or created, by branch
+	undo     []func() error          // Compensating writes, oldest first
+}
+
+// UpdateQueue runs fn under the rig's queue lock. Updates on a rig run one
+// at a time, whichever process (refinery, daemon patrol, or CLI) they come
+// from, so fn sees every write made before it and none made during it.
+//
+// This is serialization, not a transaction. If fn returns an error,
+// UpdateQueue makes compensating writes before returning it: claims and
+// releases are reverted and created MRs are closed (they stay in beads).
+// A process that dies mid-update leaves the writes made so far; each is a
+// valid queue state on its own.
+func (e *Engineer) UpdateQueue(fn func(u *QueueUpdate) error) error {
+	unlock, err := e.flock(queueLock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	u := &QueueUpdate{e: e, byBranch: make(map[string]*beads.Issue)}
+	if err := fn(u); err != nil {
+		if rerr := u.compensate(); rerr != nil {
+			return fmt.Errorf("%w (undoing writes: %v)", err, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// compensate undoes u's writes, newest first.
+func (u *QueueUpdate) compensate() error {
+	var errs []error
+	for i := len(u.undo) - 1; i >= 0; i-- {
+		if err := u.undo[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	u.undo = nil
+	return errors.Join(errs...)
+}
+
+// Ready returns the MRs waiting in the queue, highest priority first, less
+// any claimed in this update. The caller must not modify them.
+func (u *QueueUpdate) Ready() ([]*MRInfo, error) {
+	if u.ready == nil {
+		mrs, err := u.e.listReadyMRs()
+		if err != nil {
+			return nil, err
+		}
+		u.ready = append([]*MRInfo{}, mrs...)
+		u.byID = make(map[string]*MRInfo, len(mrs))
+		for _, mr := range mrs {
+			u.byID[mr.ID] = mr
+		}
+	}
+	return u.ready, nil
+}
+
+// OpenMR returns the open MR bead for branch, or nil.
+func (u *QueueUpdate) OpenMR(branch string) (*beads.Issue, error) {
+	if issue, ok := u.byBranch[branch]; ok {
+		return issue, nil
+	}
+	issue, err := u.e.findOpenMR(branch)
+	if err != nil {
+		return nil, err
+	}
+	if issue != nil {
+		u.byBranch[branch] = issue
+	}
+	return issue, nil
+}
+
+// Submit creates the MR bead described by opts unless branch already has
+// an open MR, which it returns instead with created false. Submitting is
+// idempotent per branch: a branch has at most one open MR.
+func (u *QueueUpdate) Submit(branch string, opts beads.CreateOptions) (issue *beads.Issue, created bool, err error) {
+	if branch == "" {
+		return nil, false, fmt.Errorf("submit: branch is required")
+	}
+	existing, err := u.OpenMR(branch)
+	if err != nil {
+		return nil, false, fmt.Errorf("checking for an existing MR: %w", err)
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+	issue, err = u.e.createMRBead(opts)
+	if err != nil {
+		return nil, false, fmt.Errorf("creating merge request bead: %w", err)
+	}
+	u.byBranch[branch] = issue
+	u.ready = nil // The new MR may be ready
+	u.undo = append(u.undo, func() error {
+		return u.e.closeMR(issue.ID, "merge queue update failed")
+	})
+	return issue, true, nil
+}
+
+// Claim assigns the ready MR id to worker, taking it out of the queue. It
+// returns ErrMRNotReady if id isn't in Ready.
+func (u *QueueUpdate) Claim(id, worker string) (*MRInfo, error) {
+	if _, err := u.Ready(); err != nil {
+		return nil, err
+	}
+	mr, ok := u.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("claiming %s: %w", id, ErrMRNotReady)
+	}
+	if err := u.e.assignMR(id, worker); err != nil {
+		return nil, fmt.Errorf("claiming %s: %w", id, err)
+	}
+	prev := mr.Assignee // Set when re-claiming a stale claim
+	u.undo = append(u.undo, func() error {
+		return u.e.assignMR(id, prev)
+	})
+	delete(u.byID, id)
+	for i, r := range u.ready {
+		if r.ID == id {
+			u.ready = append(u.ready[:i:i], u.ready[i+1:]...)
+			break
+		}
+	}
+	claimed := *mr
+	claimed.Assignee = worker
+	return &claimed, nil
+}
+
+// Release returns the open MR id to the queue by clearing its claim.
+func (u *QueueUpdate) Release(id string) error {
+	issue, err := u.e.showIssue(id)
+	if err != nil {
+		return fmt.Errorf("releasing %s: %w", id, err)
+	}
+	if !beads.HasLabel(issue, "gt:merge-request") {
+		return fmt.Errorf("releasing %s: not a merge request", id)
+	}
+	if issue.Status != "open" {
+		return fmt.Errorf("releasing %s: merge request is %s", id, issue.Status)
+	}
+	if err := u.e.assignMR(id, ""); err != nil {
+		return fmt.Errorf("releasing %s: %w", id, err)
+	}
+	prev := issue.Assignee
+	u.undo = append(u.undo, func() error {
+		return u.e.assignMR(id, prev)
+	})
+	u.ready = nil // The MR may be ready again
+	return nil
+}
+
+// Queue is a rig's merge queue as seen from outside the refinery, by
+// commands such as gt mq submit and gt done.
+type Queue struct {
+	e *Engineer
+}
+
+// OpenQueue returns r's merge queue, read and written through bd.
+func OpenQueue(r *rig.Rig, bd *beads.Beads) *Queue {
+	e := NewEngineer(r)
+	e.beads = bd
+	e.showIssue = bd.Show
+	return &Queue{e: e}
+}
+
+// Update runs fn as one serialized update of the queue (see
+// Engineer.UpdateQueue).
+func (q *Queue) Update(fn func(u *QueueUpdate) error) error {
+	return q.e.UpdateQueue(fn)
+}
+
+// findOpenMRInBeads returns the open MR bead for branch, or nil.
+func (e *Engineer) findOpenMRInBeads(branch string) (*beads.Issue, error) {
+	return e.beads.FindMRForBranch(branch)
+}
+
+// createMRBeadInBeads creates an MR bead.
+func (e *Engineer) createMRBeadInBeads(opts beads.CreateOptions) (*beads.Issue, error) {
+	return e.beads.Create(opts)
+}
+
+// assignMRInBeads sets an MR bead's assignee ("" releases it).
+func (e *Engineer) assignMRInBeads(id, assignee string) error {
+	return e.beads.Update(id, beads.UpdateOptions{Assignee: &assignee})
+}
+
+// closeMRInBeads closes an MR bead with reason.
+func (e *Engineer) closeMRInBeads(id, reason string) error {
+	return e.beads.CloseWithReason(reason, id)
+}
@@ -0,0 +1,208 @@
+package refinery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// fakeQueueBeads is an in-memory stand-in for the MR beads the queue
+// works on. Each call yields first, so unserialized producers and
+// consumers interleave between reading the queue and writing to it.
+type fakeQueueBeads struct {
+	mu     sync.Mutex
+	mrs    []*beads.Issue
+	claims map[string][]string // MR ID → every worker that claimed it
+}
+
+// newFakeQueueEngineer returns an engineer for the rig at rigPath whose
+// queue is q.
+func newFakeQueueEngineer(rigPath string, q *fakeQueueBeads) *Engineer {
+	e := NewEngineer(&rig.Rig{Name: "testrig", Path: rigPath})
+	e.SetOutput(&bytes.Buffer{})
+	e.findOpenMR = func(branch string) (*beads.Issue, error) {
+		runtime.Gosched()
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for _, issue := range q.mrs {
+			if f := beads.ParseMRFields(issue); f != nil && f.Branch == branch && issue.Status == "open" {
+				return issue, nil
+			}
+		}
+		return nil, nil
+	}
+	e.createMRBead = func(opts beads.CreateOptions) (*beads.Issue, error) {
+		runtime.Gosched()
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		issue := &beads.Issue{
+			ID:          fmt.Sprintf("gt-mr-%d", len(q.mrs)+1),
+			Title:       opts.Title,
+			Status:      "open",
+			Labels:      opts.Labels,
+			Priority:    opts.Priority,
+			Description: opts.Description,
+		}
+		q.mrs = append(q.mrs, issue)
+		return issue, nil
+	}
+	e.listReadyMRs = func() ([]*MRInfo, error) {
+		runtime.Gosched()
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		var ready []*MRInfo
+		for _, issue := range q.mrs {
+			if issue.Assignee == "" && issue.Status == "open" {
+				f := beads.ParseMRFields(issue)
+				ready = append(ready, &MRInfo{ID: issue.ID, Branch: f.Branch, Priority: issue.Priority})
+			}
+		}
+		return ready, nil
+	}
+	e.assignMR = func(id, assignee string) error {
+		runtime.Gosched()
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for _, issue := range q.mrs {
+			if issue.ID == id {
+				issue.Assignee = assignee
+				if assignee != "" {
+					q.claims[id] = append(q.claims[id], assignee)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("%s not found", id)
+	}
+	e.closeMR = func(id, _ string) error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for _, issue := range q.mrs {
+			if issue.ID == id {
+				issue.Status = "closed"
+				return nil
+			}
+		}
+		return fmt.Errorf("%s not found", id)
+	}
+	return e
+}
+
+// submitMR submits an MR for branch through e's queue.
+func submitMR(e *Engineer, branch string) (issue *beads.Issue, created bool, err error) {
+	err = e.UpdateQueue(func(u *QueueUpdate) error {
+		issue, created, err = u.Submit(branch, beads.CreateOptions{
+			Labels:      []string{"gt:merge-request"},
+			Description: beads.FormatMRFields(&beads.MRFields{Branch: branch}),
+		})
+		return err
+	})
+	return issue, created, err
+}
+
+func TestUpdateQueue_ConcurrentSubmitAndClaim(t *testing.T) {
+	q := &fakeQueueBeads{claims: make(map[string][]string)}
+	// Producers and consumers are separate engineers on the same rig, as
+	// they would be in separate processes.
+	rigPath := t.TempDir()
+	engineer := func() *Engineer { return newFakeQueueEngineer(rigPath, q) }
+
+	const branches, producersPerBranch = 4, 4
+	var wg sync.WaitGroup
+	for i := 0; i < branches*producersPerBranch; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			branch := fmt.Sprintf("polecat/p%d/gt-%d", i%branches, i%branches)
+			if _, _, err := submitMR(engineer(), branch); err != nil {
+				t.Errorf("Submit(%s): %v", branch, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(q.mrs) != branches {
+		t.Fatalf("queue has %d MRs, want one per branch (%d)", len(q.mrs), branches)
+	}
+
+	// Consumers all go for the head of the queue.
+	const consumers = 6
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := engineer().UpdateQueue(func(u *QueueUpdate) error {
+				ready, err := u.Ready()
+				if err != nil || len(ready) == 0 {
+					return err
+				}
+				_, err = u.Claim(ready[0].ID, fmt.Sprintf("testrig/worker-%d", i))
+				return err
+			})
+			if err != nil {
+				t.Errorf("claiming: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, issue := range q.mrs {
+		if c := q.claims[issue.ID]; len(c) != 1 {
+			t.Errorf("%s claimed %d times (%v), want once", issue.ID, len(c), c)
+		}
+	}
+}
+
+func TestUpdateQueue_UndoesWritesOnError(t *testing.T) {
+	q := &fakeQueueBeads{claims: make(map[string][]string)}
+	e := newFakeQueueEngineer(t.TempDir(), q)
+	kept, _, err := submitMR(e, "polecat/a/gt-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errBoom := fmt.Errorf("boom")
+	err = e.UpdateQueue(func(u *QueueUpdate) error {
+		if _, err := u.Claim(kept.ID, "testrig/worker"); err != nil {
+			return err
+		}
+		issue, created, err := u.Submit("polecat/b/gt-2", beads.CreateOptions{
+			Labels:      []string{"gt:merge-request"},
+			Description: beads.FormatMRFields(&beads.MRFields{Branch: "polecat/b/gt-2"}),
+		})
+		if err != nil || !created {
+			return fmt.Errorf("Submit: created=%v, %v", created, err)
+		}
+		// The new MR is visible to the rest of the update.
+		if again, _ := u.OpenMR("polecat/b/gt-2"); again == nil || again.ID != issue.ID {
+			return fmt.Errorf("OpenMR after Submit = %v, want %s", again, issue.ID)
+		}
+		ready, err := u.Ready()
+		if err != nil {
+			return err
+		}
+		if len(ready) != 1 || ready[0].ID != issue.ID {
+			return fmt.Errorf("Ready = %v, want [%s]", mrIDs(ready), issue.ID)
+		}
+		// Claiming an MR already claimed in this update fails.
+		if _, err := u.Claim(kept.ID, "testrig/other"); !errors.Is(err, ErrMRNotReady) {
+			return fmt.Errorf("second Claim = %v, want ErrMRNotReady", err)
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("UpdateQueue = %v, want %v", err, errBoom)
+	}
+
+	// The claim was released and the submitted MR closed, not removed.
+	if kept.Assignee != "" {
+		t.Errorf("claim not released: assignee %q", kept.Assignee)
+	}
+	if len(q.mrs) != 2 || q.mrs[1].Status != "closed" {
+		t.Errorf("submitted MR not closed: %+v", q.mrs)
+	}
+}
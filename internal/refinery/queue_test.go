@@ -0,0 +1,122 @@
+package refinery
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/testutil"
+)
+
+func TestQueue_EnqueueDequeueRequeue(t *testing.T) {
+	testutil.RequireDoltContainer(t)
+	port, _ := strconv.Atoi(testutil.DoltContainerPort())
+	rigPath := t.TempDir()
+	b := beads.NewIsolatedWithPort(rigPath, port)
+	if err := b.Init("gt"); err != nil {
+		t.Skipf("bd init unavailable in test environment: %v", err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "testrig", Path: rigPath})
+	e.beads = b
+	e.showIssue = b.Show
+	e.SetOutput(&bytes.Buffer{})
+
+	low, created, err := e.Enqueue(&EnqueueRequest{Branch: "polecat/a/gt-1", SourceIssue: "gt-1", Priority: 3})
+	if err != nil || !created {
+		t.Fatalf("Enqueue: created=%v, %v", created, err)
+	}
+	urgent, _, err := e.Enqueue(&EnqueueRequest{Branch: "polecat/b/gt-2", SourceIssue: "gt-2", Priority: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Submitting the same branch again is a no-op.
+	again, created, err := e.Enqueue(&EnqueueRequest{Branch: "polecat/a/gt-1", SourceIssue: "gt-1", Priority: 3})
+	if err != nil || created || again.ID != low.ID {
+		t.Fatalf("re-Enqueue = %s, created=%v, %v; want %s", again.ID, created, err, low.ID)
+	}
+
+	head, err := e.Peek(1)
+	if err != nil || len(head) != 1 || head[0].ID != urgent.ID {
+		t.Fatalf("Peek(1) = %v, %v; want [%s]", mrIDs(head), err, urgent.ID)
+	}
+	claimed, err := e.Dequeue("testrig/refinery", 1)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != urgent.ID {
+		t.Fatalf("Dequeue = %v, %v; want [%s]", mrIDs(claimed), err, urgent.ID)
+	}
+	if rest, _ := e.Peek(0); len(rest) != 1 || rest[0].ID != low.ID {
+		t.Errorf("after Dequeue, queue = %v; want [%s]", mrIDs(rest), low.ID)
+	}
+
+	if err := e.Requeue(urgent.ID); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := e.Peek(0); len(all) != 2 || all[0].ID != urgent.ID {
+		t.Errorf("after Requeue, queue = %v; want %s first", mrIDs(all), urgent.ID)
+	}
+}
+
+func TestQueue_ConcurrentProducersAndConsumers(t *testing.T) {
+	q := &fakeQueueBeads{claims: make(map[string][]string)}
+	// Producers and consumers are separate engineers on the same rig, as
+	// they would be in separate processes.
+	rigPath := t.TempDir()
+	engineer := func() *Engineer { return newFakeQueueEngineer(rigPath, q) }
+
+	const branches, producersPerBranch = 4, 4
+	var wg sync.WaitGroup
+	var created sync.Map
+	for i := 0; i < branches*producersPerBranch; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			branch := fmt.Sprintf("polecat/p%d/gt-%d", i%branches, i%branches)
+			mr, isNew, err := engineer().Enqueue(&EnqueueRequest{Branch: branch, Priority: 2})
+			if err != nil {
+				t.Errorf("Enqueue(%s): %v", branch, err)
+				return
+			}
+			if isNew {
+				if prev, dup := created.LoadOrStore(branch, mr.ID); dup {
+					t.Errorf("%s enqueued twice: %s and %s", branch, prev, mr.ID)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(q.mrs) != branches {
+		t.Fatalf("queue has %d MRs, want one per branch (%d)", len(q.mrs), branches)
+	}
+
+	const consumers = 6
+	got := make([][]*MRInfo, consumers)
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			claimed, err := engineer().Dequeue(fmt.Sprintf("testrig/worker-%d", i), 1)
+			if err != nil {
+				t.Errorf("Dequeue: %v", err)
+			}
+			got[i] = claimed
+		}(i)
+	}
+	wg.Wait()
+	kept := make(map[string]int)
+	for _, claimed := range got {
+		for _, mr := range claimed {
+			kept[mr.ID]++
+		}
+	}
+	for _, issue := range q.mrs {
+		if n := kept[issue.ID]; n != 1 {
+			t.Errorf("%s kept by %d consumers, want 1", issue.ID, n)
+		}
+		if c := q.claims[issue.ID]; len(c) != 1 {
+			t.Errorf("%s claimed %d times (%v), want once", issue.ID, len(c), c)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package refinery
+
+import (
+	"context"
+	"time"
+)
+
+// maxRetries returns how many times a failed stack is regated before
+// bisection: MaxRetries, or one retry under the legacy RetryBatchOnFlaky.
+func (c *BatchConfig) maxRetries() int {
+	if c.MaxRetries > 0 {
+		return c.MaxRetries
+	}
+	if c.RetryBatchOnFlaky {
+		return 1
+	}
+	return 0
+}
+
+// retryDelay returns how long to wait before the given retry (1-based):
+// RetryBackoff, doubling with each retry.
+func (c *BatchConfig) retryDelay(attempt int) time.Duration {
+	if c.RetryBackoff <= 0 || attempt < 1 {
+		return 0
+	}
+	return c.RetryBackoff << min(attempt-1, 16)
+}
+
+// flakyRetries returns how many times a stack whose gates failed with result
+// is retried before bisection. Gates that only timed out are always retried
+// at least once. Otherwise, once any of the stack's gates is marked Flaky,
+// only failures confined to flaky gates are retried: a deterministic gate
+// failing goes straight to bisection. With no gate marked, any failure is
+// retried, as RetryBatchOnFlaky always did.
+func (e *Engineer) flakyRetries(cfg *BatchConfig, stacked []*MRInfo, result ProcessResult) int {
+	retries := cfg.maxRetries()
+	if result.GateTimedOut {
+		return max(retries, 1)
+	}
+	if retries == 0 || !e.mayBeFlaky(stacked, result) {
+		return 0
+	}
+	return retries
+}
+
+// mayBeFlaky reports whether result's failure is one flakyRetries retries.
+func (e *Engineer) mayBeFlaky(stacked []*MRInfo, result ProcessResult) bool {
+	gates := e.assetGates(stacked)
+	if gates == nil && len(stacked) > 0 {
+		gates = e.gatesFor(stacked[0].Target)
+	}
+	marked := false
+	for _, g := range gates {
+		marked = marked || g.Flaky
+	}
+	if !marked {
+		return true
+	}
+	if len(result.FailedGates) == 0 {
+		// Something other than a gate failed, e.g. acceptance criteria.
+		return false
+	}
+	for _, name := range result.FailedGates {
+		if g := gates[name]; g == nil || !g.Flaky {
+			return false
+		}
+	}
+	return true
+}
+
+// waitRetry sleeps before the given retry, returning false if ctx is done
+// first.
+func waitRetry(ctx context.Context, cfg *BatchConfig, attempt int) bool {
+	delay := cfg.retryDelay(attempt)
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
@@ -0,0 +1,108 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchConfig_RetryPolicy(t *testing.T) {
+	if got := (&BatchConfig{RetryBatchOnFlaky: true}).maxRetries(); got != 1 {
+		t.Errorf("legacy maxRetries = %d, want 1", got)
+	}
+	if got := (&BatchConfig{MaxRetries: 3}).maxRetries(); got != 3 {
+		t.Errorf("maxRetries = %d, want 3", got)
+	}
+	if got := (&BatchConfig{}).maxRetries(); got != 0 {
+		t.Errorf("no retries = %d, want 0", got)
+	}
+	cfg := &BatchConfig{RetryBackoff: time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second} {
+		if got := cfg.retryDelay(attempt); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestFlakyRetries(t *testing.T) {
+	e := newTestEngineer(t, t.TempDir(), nil)
+	stacked := []*MRInfo{makeMR("mr-1", "b1", "main")}
+	cfg := &BatchConfig{MaxRetries: 2}
+
+	e.config.Gates = map[string]*GateConfig{"unit": {Cmd: "true"}, "e2e": {Cmd: "true"}}
+	if got := e.flakyRetries(cfg, stacked, ProcessResult{FailedGates: []string{"unit"}}); got != 2 {
+		t.Errorf("no gate marked: retries = %d, want 2", got)
+	}
+
+	e.config.Gates["e2e"].Flaky = true
+	tests := []struct {
+		name   string
+		result ProcessResult
+		want   int
+	}{
+		{"flaky gate", ProcessResult{FailedGates: []string{"e2e"}}, 2},
+		{"deterministic gate", ProcessResult{FailedGates: []string{"unit"}}, 0},
+		{"both", ProcessResult{FailedGates: []string{"e2e", "unit"}}, 0},
+		{"acceptance", ProcessResult{TestsFailed: true}, 0},
+		{"timed out", ProcessResult{GateTimedOut: true, FailedGates: []string{"unit"}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.flakyRetries(cfg, stacked, tt.result); got != tt.want {
+				t.Errorf("retries = %d, want %d", got, tt.want)
+			}
+		})
+	}
+	if got := e.flakyRetries(&BatchConfig{}, stacked, ProcessResult{GateTimedOut: true}); got != 1 {
+		t.Errorf("timed out without retries = %d, want 1", got)
+	}
+}
+
+func TestProcessBatch_MaxRetries(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+	count := filepath.Join(t.TempDir(), "count")
+
+	e := newTestEngineer(t, workDir, g)
+	// Fails twice, then passes.
+	e.config.Gates = map[string]*GateConfig{
+		"e2e":  {Cmd: fmt.Sprintf(`n=$(cat %q 2>/dev/null || echo 0); echo $((n+1)) > %q; test "$n" -ge 2`, count, count), Flaky: true},
+		"unit": {Cmd: "true"},
+	}
+	cfg := DefaultBatchConfig()
+	cfg.MaxRetries = 3
+	cfg.RetryBackoff = time.Millisecond
+
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("merged %v, culprits %v, err %v\n%s", stackedIDs(result.Merged), stackedIDs(result.Culprits), result.Error, testOutput(e).String())
+	}
+}
+
+func TestProcessBatch_DeterministicGateSkipsRetry(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{
+		"unit": {Cmd: failMarkerGateCmd()},
+		"e2e":  {Cmd: "true", Flaky: true},
+	}
+	cfg := DefaultBatchConfig()
+	cfg.MaxRetries = 3
+
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if len(result.Culprits) != 1 || result.Culprits[0].ID != "mr-b" {
+		t.Errorf("culprits = %v, want [mr-b]", stackedIDs(result.Culprits))
+	}
+	if out := testOutput(e).String(); strings.Contains(out, "retrying full batch") {
+		t.Errorf("deterministic failure was retried:\n%s", out)
+	}
+}
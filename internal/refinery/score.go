@@ -5,7 +5,12 @@ package refinery
 
 import (
 	"log"
+	"path/filepath"
+	"slices"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/scheduler/capacity"
 )
 
 // ScoreConfig contains tunable weights for MR priority scoring.
@@ -153,3 +158,26 @@ func (mr *MRInfo) ScoreAt(now time.Time) float64 {
 	}
 	return ScoreMRWithDefaults(input)
 }
+
+// labelPriorities returns the town's label to priority mapping (scheduler
+// label_priorities in town settings), or nil when there is none.
+func (e *Engineer) labelPriorities() capacity.LabelPriorities {
+	townRoot := filepath.Dir(e.rig.Path)
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil || settings.Scheduler == nil {
+		return nil
+	}
+	return settings.Scheduler.LabelPriorities
+}
+
+// applyLabelPriorities raises mr's priority to the most urgent that its
+// labels, or its source issue's, map to. Labels are read on every listing,
+// so triage in the tracker reorders the queue on the next pass.
+func (e *Engineer) applyLabelPriorities(mr *MRInfo, labels []string, prios capacity.LabelPriorities) {
+	if mr.SourceIssue != "" {
+		if src, err := e.beads.Show(mr.SourceIssue); err == nil && src != nil {
+			labels = append(slices.Clone(labels), src.Labels...)
+		}
+	}
+	mr.Priority = prios.Apply(mr.Priority, labels)
+}
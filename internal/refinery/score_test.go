@@ -1,8 +1,12 @@
 package refinery
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
 )
 
 func TestScoreMR_PriorityClamping(t *testing.T) {
@@ -76,3 +80,37 @@ func TestScoreMR_PriorityClamping(t *testing.T) {
 		}
 	})
 }
+
+func TestLabelPriorities_RaiseMRPriority(t *testing.T) {
+	townRoot := t.TempDir()
+	settingsDir := filepath.Join(townRoot, "settings")
+	if err := os.MkdirAll(settingsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"type": "town-settings", "version": 1, "scheduler": {"label_priorities": {"P0": 0, "customer-facing": 1}}}`)
+	if err := os.WriteFile(filepath.Join(settingsDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: filepath.Join(townRoot, "test-rig")})
+
+	prios := e.labelPriorities()
+	if len(prios) != 2 {
+		t.Fatalf("labelPriorities() = %v, want the configured mapping", prios)
+	}
+	now := time.Now()
+	triaged := &MRInfo{ID: "mr-1", Priority: 3, CreatedAt: now}
+	plain := &MRInfo{ID: "mr-2", Priority: 2, CreatedAt: now}
+	e.applyLabelPriorities(triaged, []string{"gt:merge-request", "customer-facing"}, prios)
+	e.applyLabelPriorities(plain, []string{"gt:merge-request"}, prios)
+	if triaged.Priority != 1 || plain.Priority != 2 {
+		t.Errorf("priorities = %d, %d, want 1, 2", triaged.Priority, plain.Priority)
+	}
+	if triaged.ScoreAt(now) <= plain.ScoreAt(now) {
+		t.Error("customer-facing MR should score above the unlabeled one")
+	}
+
+	e = NewEngineer(&rig.Rig{Name: "test-rig", Path: filepath.Join(t.TempDir(), "test-rig")})
+	if prios := e.labelPriorities(); prios != nil {
+		t.Errorf("labelPriorities() without settings = %v, want nil", prios)
+	}
+}
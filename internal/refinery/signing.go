@@ -0,0 +1,87 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// Sign modes: whether the commits the merge step creates are signed (see
+// MergeQueueConfig.SignMode). Signing uses git's own configuration
+// (gpg.format, gpg.program, ...), so GPG and SSH keys both work.
+const (
+	// SignModeOff lands commits unsigned.
+	SignModeOff = "off"
+	// SignModeAuto signs commits when signing works, and lands them
+	// unsigned, with a warning, when it doesn't.
+	SignModeAuto = "auto"
+	// SignModeRequired signs every commit; a batch that can't be signed
+	// is not landed.
+	SignModeRequired = "required"
+)
+
+// errSigningUnavailable marks a batch rejected because signing is required
+// but doesn't work.
+var errSigningUnavailable = errors.New("commit signing required but unavailable")
+
+// validSignMode reports whether s names a sign mode.
+func validSignMode(s string) bool {
+	switch s {
+	case SignModeOff, SignModeAuto, SignModeRequired:
+		return true
+	}
+	return false
+}
+
+// prepareSigning decides whether the merges e makes next are signed, by
+// signing a probe commit with the configured key. It is checked afresh for
+// every batch, as agents and keys come and go. In required mode an
+// unusable key is an error; in auto mode it is a warning.
+func (e *Engineer) prepareSigning() error {
+	e.signCommits = false
+	if e.config.SignMode != SignModeAuto && e.config.SignMode != SignModeRequired {
+		return nil
+	}
+	if err := e.git.CanSign(e.config.SigningKey); err != nil {
+		if e.config.SignMode == SignModeRequired {
+			return fmt.Errorf("%w: %v", errSigningUnavailable, err)
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: commit signing unavailable, landing unsigned: %v\n", err)
+		return nil
+	}
+	e.signCommits = true
+	return nil
+}
+
+// mergeNoFF is g.MergeNoFF, signed when e signs commits.
+func (e *Engineer) mergeNoFF(g *git.Git, branch, message string) error {
+	if e.signCommits {
+		return g.MergeNoFFSigned(branch, message, e.config.SigningKey)
+	}
+	return g.MergeNoFF(branch, message)
+}
+
+// mergeSquash is g.MergeSquash, signed when e signs commits.
+func (e *Engineer) mergeSquash(g *git.Git, branch, message string) error {
+	if e.signCommits {
+		return g.MergeSquashSigned(branch, message, e.config.SigningKey)
+	}
+	return g.MergeSquash(branch, message)
+}
+
+// cherryPick is g.CherryPick, re-signing every commit when e signs commits.
+func (e *Engineer) cherryPick(g *git.Git, commits ...string) error {
+	if e.signCommits {
+		return g.CherryPickSigned(e.config.SigningKey, commits...)
+	}
+	return g.CherryPick(commits...)
+}
+
+// amendTracked is g.AmendTracked, signed when e signs commits.
+func (e *Engineer) amendTracked(g *git.Git) error {
+	if e.signCommits {
+		return g.AmendTrackedSigned(e.config.SigningKey)
+	}
+	return g.AmendTracked()
+}
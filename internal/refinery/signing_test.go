@@ -0,0 +1,97 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sshSigningRepo configures workDir to sign with a fresh SSH key and
+// returns the key's path.
+func sshSigningRepo(t *testing.T, workDir string) string {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not installed")
+	}
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	run(t, workDir, "ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key)
+	run(t, workDir, "git", "config", "gpg.format", "ssh")
+	return key
+}
+
+func TestProcessBatch_SignsMerges(t *testing.T) {
+	for _, strategy := range []string{MergeStrategySquash, MergeStrategyMergeCommit, MergeStrategyRebaseFF} {
+		t.Run(strategy, func(t *testing.T) {
+			workDir, g, cleanup := testGitRepo(t)
+			defer cleanup()
+			key := sshSigningRepo(t, workDir)
+			createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+
+			e := newTestEngineer(t, workDir, g)
+			e.config.MergeStrategy = strategy
+			e.config.SignMode = SignModeRequired
+			e.config.SigningKey = key
+			result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "polecat/a", "main")}, "main", nil)
+			if len(result.Merged) != 1 {
+				t.Fatalf("result = %+v\n%s", result, testOutput(e).String())
+			}
+			if commit := run(t, workDir, "git", "cat-file", "commit", "origin/main"); !strings.Contains(commit, "gpgsig -----BEGIN SSH SIGNATURE-----") {
+				t.Errorf("landed commit not signed:\n%s", commit)
+			}
+		})
+	}
+}
+
+func TestProcessBatch_SigningRequiredButUnavailable(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	sshSigningRepo(t, workDir)
+	createFeatureBranch(t, workDir, "polecat/a", "a.txt", "a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.SignMode = SignModeRequired
+	e.config.SigningKey = filepath.Join(t.TempDir(), "missing-key")
+	before := run(t, workDir, "git", "rev-parse", "origin/main")
+	result := e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "polecat/a", "main")}, "main", nil)
+	if !errors.Is(result.Error, errSigningUnavailable) || len(result.Merged) != 0 {
+		t.Errorf("result = %+v, want the batch rejected as unsignable", result)
+	}
+	if after := run(t, workDir, "git", "rev-parse", "origin/main"); after != before {
+		t.Errorf("origin/main moved without a signature: %s -> %s", before, after)
+	}
+
+	// In auto mode the batch lands unsigned, with a warning.
+	e.config.SignMode = SignModeAuto
+	result = e.ProcessBatch(context.Background(), []*MRInfo{makeMR("mr-a", "polecat/a", "main")}, "main", nil)
+	if len(result.Merged) != 1 {
+		t.Fatalf("auto mode result = %+v", result)
+	}
+	if out := testOutput(e).String(); !strings.Contains(out, "landing unsigned") {
+		t.Errorf("output missing unsigned warning:\n%s", out)
+	}
+	if commit := run(t, workDir, "git", "cat-file", "commit", "origin/main"); strings.Contains(commit, "gpgsig") {
+		t.Errorf("auto mode commit signed with a missing key:\n%s", commit)
+	}
+}
+
+func TestEngineer_LoadConfig_SignMode(t *testing.T) {
+	for mode, wantErr := range map[string]bool{"off": false, "auto": false, "required": false, "always": true} {
+		dir := t.TempDir()
+		data := `{"type": "rig", "version": 1, "name": "test-rig", "merge_queue": {"sign_mode": "` + mode + `", "signing_key": "ABCD1234"}}`
+		if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		e := newTestEngineer(t, dir, nil)
+		err := e.LoadConfig()
+		if (err != nil) != wantErr {
+			t.Errorf("sign_mode %q: LoadConfig error = %v, want error %v", mode, err, wantErr)
+		}
+		if err == nil && (e.config.SignMode != mode || e.config.SigningKey != "ABCD1234") {
+			t.Errorf("sign_mode %q: config = %q, %q", mode, e.config.SignMode, e.config.SigningKey)
+		}
+	}
+}
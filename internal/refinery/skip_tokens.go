@@ -0,0 +1,384 @@
+package refinery
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/state"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// SkipTokenSecretEnv names the environment variable holding the key gate
+// skip tokens are signed with. When unset, a key is generated on first use
+// and kept in the user's gastown config directory (see skipTokenKeyPath),
+// outside the rig tree agent sessions work in.
+const SkipTokenSecretEnv = "GT_GATE_SKIP_SECRET"
+
+// DefaultSkipTokenTTL is how long an unused gate skip token stays valid.
+const DefaultSkipTokenTTL = 24 * time.Hour
+
+// ErrSkipTokenNotFound is returned for an unknown gate skip token ID.
+var ErrSkipTokenNotFound = errors.New("gate skip token not found")
+
+// GateSkipToken lets one gate be bypassed for one MR, for emergencies where
+// a known-flaky gate blocks a critical fix. A human issues it (gt gate
+// skip); it is signed so it can't be forged or altered by editing the token
+// file, and single-use: the first batch gating the MR spends it (bisection
+// and retries in that batch still skip the gate). Tokens are never deleted,
+// so the file is the audit trail of every skip.
+//
+// Spending and revoking a token are signed events in the skip token log
+// (see skipTokenEvent), which sits beside the key rather than in the rig.
+// UsedIn, UsedAt, RevokedBy and RevokedAt are filled in from the log, so
+// editing them in the token file has no effect.
+type GateSkipToken struct {
+	ID        string    `json:"id"`
+	Gate      string    `json:"gate"`
+	MR        string    `json:"mr"`
+	Reason    string    `json:"reason"`
+	IssuedBy  string    `json:"issued_by"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Signature string    `json:"signature"`
+
+	UsedIn string    `json:"used_in,omitempty"` // Batch ID, or the commit gated outside a batch
+	UsedAt time.Time `json:"used_at,omitempty"`
+
+	RevokedBy string    `json:"revoked_by,omitempty"`
+	RevokedAt time.Time `json:"revoked_at,omitempty"`
+}
+
+// Status describes the token at now: "unused", "used", "revoked" or
+// "expired".
+func (t *GateSkipToken) Status(now time.Time) string {
+	switch {
+	case !t.RevokedAt.IsZero():
+		return "revoked"
+	case t.UsedIn != "":
+		return "used"
+	case !now.Before(t.ExpiresAt):
+		return "expired"
+	default:
+		return "unused"
+	}
+}
+
+// sign returns the token's signature under key: "sha256=" and the hex
+// HMAC-SHA256 of its issued fields, one per line.
+func (t *GateSkipToken) sign(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s\n%d\n%d", t.ID, t.Gate, t.MR, t.Reason, t.IssuedBy, t.IssuedAt.Unix(), t.ExpiresAt.Unix())
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// skipTokenEvent is a line of the skip token log: token was spent in a
+// batch, or revoked. Its signature covers the token's own, so an event
+// only counts for the token it was made for and can't be forged without
+// the key.
+type skipTokenEvent struct {
+	Token     string    `json:"token"`
+	Event     string    `json:"event"` // "used" or "revoked"
+	In        string    `json:"in,omitempty"`
+	By        string    `json:"by,omitempty"`
+	At        time.Time `json:"at"`
+	Signature string    `json:"signature"`
+}
+
+// sign returns the event's signature under key for a token signed
+// tokenSig, in the form of GateSkipToken.sign.
+func (ev *skipTokenEvent) sign(key []byte, tokenSig string) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s\n%d", tokenSig, ev.Token, ev.Event, ev.In, ev.By, ev.At.UnixNano())
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (e *Engineer) skipTokensPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "gate-skip-tokens.json")
+}
+
+// skipTokenKeyPath is the default signing key's file.
+func skipTokenKeyPath() string {
+	return filepath.Join(state.ConfigDir(), "gate-skip.key")
+}
+
+// skipTokenLogPath is the log of skip token events, shared by every rig:
+// event signatures tie each event to its token.
+func skipTokenLogPath() string {
+	return filepath.Join(state.ConfigDir(), "gate-skip-log.jsonl")
+}
+
+// skipTokenKey returns the signing key: SkipTokenSecretEnv, or the key
+// file, generated when missing if create is set.
+func (e *Engineer) skipTokenKey(create bool) ([]byte, error) {
+	if secret := os.Getenv(SkipTokenSecretEnv); secret != "" {
+		return []byte(secret), nil
+	}
+	path := skipTokenKeyPath()
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, fmt.Errorf("reading gate skip key: %w", err)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("saving gate skip key: %w", err)
+	}
+	return key, nil
+}
+
+// SkipTokens returns every gate skip token issued, oldest first, with
+// their use and revocation from the skip token log.
+func (e *Engineer) SkipTokens() ([]*GateSkipToken, error) {
+	tokens, err := e.readSkipTokens()
+	if err != nil || len(tokens) == 0 {
+		return tokens, err
+	}
+	key, err := e.skipTokenKey(false)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySkipTokenLog(key, tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// readSkipTokens returns the tokens in the rig's token file as issued.
+func (e *Engineer) readSkipTokens() ([]*GateSkipToken, error) {
+	data, err := os.ReadFile(e.skipTokensPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var tokens []*GateSkipToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("parsing gate skip tokens: %w", err)
+	}
+	for _, t := range tokens {
+		t.UsedIn, t.UsedAt, t.RevokedBy, t.RevokedAt = "", time.Time{}, "", time.Time{}
+	}
+	return tokens, nil
+}
+
+// applySkipTokenLog fills in tokens' use and revocation from the events
+// in the skip token log signed for them under key. Other events are
+// ignored: they belong to another rig's tokens, or were forged.
+func applySkipTokenLog(key []byte, tokens []*GateSkipToken) error {
+	data, err := os.ReadFile(skipTokenLogPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading gate skip log: %w", err)
+	}
+	byID := make(map[string]*GateSkipToken, len(tokens))
+	for _, t := range tokens {
+		byID[t.ID] = t
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		var ev skipTokenEvent
+		if line == "" || json.Unmarshal([]byte(line), &ev) != nil {
+			continue
+		}
+		t := byID[ev.Token]
+		if t == nil || !hmac.Equal([]byte(ev.sign(key, t.Signature)), []byte(ev.Signature)) {
+			continue
+		}
+		switch {
+		case ev.Event == "used" && t.UsedIn == "":
+			t.UsedIn, t.UsedAt = ev.In, ev.At
+		case ev.Event == "revoked" && t.RevokedAt.IsZero():
+			t.RevokedBy, t.RevokedAt = ev.By, ev.At
+		}
+	}
+	return nil
+}
+
+// logSkipTokenEvent signs ev for t and appends it to the skip token log.
+func logSkipTokenEvent(key []byte, t *GateSkipToken, ev skipTokenEvent) error {
+	ev.Token = t.ID
+	ev.Signature = ev.sign(key, t.Signature)
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	path := skipTokenLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening gate skip log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing gate skip log: %w", err)
+	}
+	return f.Close()
+}
+
+// IssueSkipToken issues a token letting gate be skipped for mr, valid for
+// ttl (DefaultSkipTokenTTL when zero). A reason is required.
+func (e *Engineer) IssueSkipToken(gate, mr, reason, by string, ttl time.Duration) (*GateSkipToken, error) {
+	gate, mr, reason = strings.TrimSpace(gate), strings.TrimSpace(mr), strings.TrimSpace(reason)
+	if gate == "" || mr == "" {
+		return nil, fmt.Errorf("a gate skip token needs a gate and an MR")
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("a gate skip token needs a reason")
+	}
+	if ttl <= 0 {
+		ttl = DefaultSkipTokenTTL
+	}
+	defer e.lockState()()
+	key, err := e.skipTokenKey(true)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := e.readSkipTokens()
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 4)
+	_, _ = rand.Read(id)
+	now := time.Now().UTC().Truncate(time.Second)
+	t := &GateSkipToken{
+		ID:        "skip-" + hex.EncodeToString(id),
+		Gate:      gate,
+		MR:        mr,
+		Reason:    reason,
+		IssuedBy:  by,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+	t.Signature = t.sign(key)
+	if err := util.EnsureDirAndWriteJSON(e.skipTokensPath(), append(tokens, t)); err != nil {
+		return nil, fmt.Errorf("saving gate skip tokens: %w", err)
+	}
+	return t, nil
+}
+
+// RevokeSkipToken revokes an unused token.
+func (e *Engineer) RevokeSkipToken(id, by string) (*GateSkipToken, error) {
+	defer e.lockState()()
+	tokens, err := e.SkipTokens()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		if t.ID != id {
+			continue
+		}
+		if status := t.Status(time.Now()); status != "unused" {
+			return nil, fmt.Errorf("gate skip token %s is %s", id, status)
+		}
+		key, err := e.skipTokenKey(false)
+		if err != nil {
+			return nil, err
+		}
+		t.RevokedBy, t.RevokedAt = by, time.Now().UTC()
+		if err := logSkipTokenEvent(key, t, skipTokenEvent{Event: "revoked", By: by, At: t.RevokedAt}); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSkipTokenNotFound, id)
+}
+
+// useSkipTokens returns, by gate name, the tokens letting gates run under
+// ctx on the tree in dir be skipped, spending them. A token applies when it
+// names one of gates and an MR in the tree, and is unused, or was spent by
+// the same batch. Tokens whose signature doesn't verify are ignored with a
+// warning.
+func (e *Engineer) useSkipTokens(ctx context.Context, dir string, gates map[string]*GateConfig) map[string]*GateSkipToken {
+	if e.rig == nil {
+		return nil
+	}
+	if _, err := os.Stat(e.skipTokensPath()); err != nil {
+		return nil
+	}
+	vars := gateVars(ctx)
+	inTree := make(map[string]bool)
+	for _, id := range strings.Fields(vars["MR_IDS"]) {
+		inTree[id] = true
+	}
+	if len(inTree) == 0 {
+		return nil
+	}
+
+	defer e.lockState()()
+	tokens, err := e.readSkipTokens()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v (skipping no gates)\n", err)
+		return nil
+	}
+	key, err := e.skipTokenKey(false)
+	if err == nil {
+		err = applySkipTokenLog(key, tokens)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v (skipping no gates)\n", err)
+		return nil
+	}
+	use := vars["BATCH_ID"]
+	if use == "" {
+		use, _ = git.NewGit(dir).Rev("HEAD")
+	}
+	now := time.Now().UTC()
+	skip := make(map[string]*GateSkipToken)
+	for _, t := range tokens {
+		if gates[t.Gate] == nil || !inTree[t.MR] || skip[t.Gate] != nil {
+			continue
+		}
+		if status := t.Status(now); status != "unused" && (status != "used" || t.UsedIn != use) {
+			continue
+		}
+		if !hmac.Equal([]byte(t.sign(key)), []byte(t.Signature)) {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: gate skip token %s has a bad signature, ignoring it\n", t.ID)
+			continue
+		}
+		if t.UsedIn == "" {
+			// Spend it before skipping: a token the log can't record as
+			// used would be usable again.
+			if err := logSkipTokenEvent(key, t, skipTokenEvent{Event: "used", In: use, At: now}); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v (not skipping %s)\n", err, t.Gate)
+				continue
+			}
+			t.UsedIn, t.UsedAt = use, now
+		}
+		skip[t.Gate] = t
+	}
+	return skip
+}
+
+// skippedGateNames returns the gates in skip, sorted.
+func skippedGateNames(skip map[string]*GateSkipToken) []string {
+	names := make([]string, 0, len(skip))
+	for name := range skip {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
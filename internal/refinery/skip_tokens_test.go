@@ -0,0 +1,195 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSkipTokens_SkipGateForOneBatch(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{
+		"test": {Cmd: failMarkerGateCmd()},
+		"lint": {Cmd: "true"},
+	}
+	tok, err := e.IssueSkipToken("test", "mr-b", "flaky, blocking the outage fix", "overseer", 0)
+	if err != nil {
+		t.Fatalf("IssueSkipToken: %v", err)
+	}
+	if tok.Status(time.Now()) != "unused" || !tok.ExpiresAt.Equal(tok.IssuedAt.Add(DefaultSkipTokenTTL)) {
+		t.Fatalf("issued token = %+v", tok)
+	}
+
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if result.Error != nil || len(result.Merged) != 2 {
+		t.Fatalf("merged = %v, culprits %v, error %v", mrIDs(result.Merged), mrIDs(result.Culprits), result.Error)
+	}
+
+	tokens, _ := e.SkipTokens()
+	if len(tokens) != 1 || tokens[0].UsedIn != result.BatchID || tokens[0].Status(time.Now()) != "used" {
+		t.Fatalf("token after batch = %+v", tokens[0])
+	}
+	records, err := e.History(HistoryQuery{BatchID: result.BatchID})
+	if err != nil || len(records) != 1 {
+		t.Fatalf("History = %v, %v", records, err)
+	}
+	var skippedBy, ran []string
+	for _, g := range records[0].GateRuns[0].Gates {
+		if g.SkippedBy != "" {
+			skippedBy = append(skippedBy, g.Name+"="+g.SkippedBy)
+		} else {
+			ran = append(ran, g.Name)
+		}
+	}
+	if strings.Join(skippedBy, ",") != "test="+tok.ID || strings.Join(ran, ",") != "lint" {
+		t.Errorf("gate run: skipped %v, ran %v", skippedBy, ran)
+	}
+
+	// Spent: the next batch with the same MR runs the gate.
+	createFeatureBranch(t, workDir, "feature-b2", "b2.txt", "b2\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "c\n")
+	batch = []*MRInfo{makeMR("mr-b", "feature-b2", "main"), makeMR("mr-c", "feature-c", "main")}
+	result = e.ProcessBatch(context.Background(), batch, "main", cfg)
+	records, _ = e.History(HistoryQuery{BatchID: result.BatchID})
+	if len(records) != 1 || len(records[0].GateRuns) == 0 {
+		t.Fatalf("second batch History = %v", records)
+	}
+	for _, g := range records[0].GateRuns[0].Gates {
+		if g.SkippedBy != "" {
+			t.Errorf("spent token skipped %s again", g.Name)
+		}
+	}
+}
+
+func TestSkipTokens_Tampered(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	workDir, g, _ := testGitRepo(t)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "broken\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: failMarkerGateCmd()}}
+	if _, err := e.IssueSkipToken("test", "mr-other", "emergency", "overseer", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	// An agent retargets the token at its own MR.
+	data, err := os.ReadFile(e.skipTokensPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tokens []*GateSkipToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		t.Fatal(err)
+	}
+	tokens[0].MR = "mr-b"
+	data, _ = json.Marshal(tokens)
+	if err := os.WriteFile(e.skipTokensPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultBatchConfig()
+	cfg.RetryBatchOnFlaky = false
+	batch := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), batch, "main", cfg)
+	if len(result.Culprits) != 1 || result.Culprits[0].ID != "mr-b" {
+		t.Errorf("tampered token honored: culprits %v", mrIDs(result.Culprits))
+	}
+	if tokens, _ := e.SkipTokens(); tokens[0].UsedIn != "" {
+		t.Errorf("tampered token spent in %s", tokens[0].UsedIn)
+	}
+}
+
+func TestSkipTokens_IssueAndRevoke(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	for _, bad := range [][3]string{{"", "mr-a", "why"}, {"test", "", "why"}, {"test", "mr-a", " "}} {
+		if _, err := e.IssueSkipToken(bad[0], bad[1], bad[2], "overseer", 0); err == nil {
+			t.Errorf("IssueSkipToken(%q) accepted", bad)
+		}
+	}
+	tok, err := e.IssueSkipToken("test", "mr-a", "emergency", "overseer", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := e.RevokeSkipToken(tok.ID, "overseer")
+	if err != nil || revoked.Status(time.Now()) != "revoked" {
+		t.Fatalf("RevokeSkipToken = %+v, %v", revoked, err)
+	}
+	if _, err := e.RevokeSkipToken(tok.ID, "overseer"); err == nil {
+		t.Error("revoked a token twice")
+	}
+	if _, err := e.RevokeSkipToken("skip-nope", "overseer"); err == nil {
+		t.Error("revoked an unknown token")
+	}
+	if got := tok.Status(tok.ExpiresAt); got != "expired" {
+		t.Errorf("Status at expiry = %q", got)
+	}
+}
+
+func TestSkipTokens_LogOutsideRig(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+
+	revoked, err := e.IssueSkipToken("test", "mr-a", "emergency", "overseer", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := e.IssueSkipToken("test", "mr-b", "emergency", "overseer", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.RevokeSkipToken(revoked.ID, "overseer"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key and the log are in the user's config directory, and the
+	// rig's token file doesn't record the revocation.
+	for _, name := range []string{"gate-skip.key", "gate-skip-log.jsonl"} {
+		if _, err := os.Stat(filepath.Join(config, "gastown", name)); err != nil {
+			t.Errorf("%s not in the config directory: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(e.rig.Path, ".runtime", "gate-skip.key")); err == nil {
+		t.Error("signing key written to the rig")
+	}
+	if data, _ := os.ReadFile(e.skipTokensPath()); strings.Contains(string(data), `"revoked_by"`) {
+		t.Errorf("token file records the revocation: %s", data)
+	}
+
+	// An event signed for one token doesn't count for another, and an
+	// unsigned one doesn't count at all.
+	data, err := os.ReadFile(skipTokenLogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := strings.Replace(string(data), revoked.ID, kept.ID, 1) +
+		`{"token":"` + kept.ID + `","event":"used","in":"batch-x","at":"2026-01-01T00:00:00Z"}` + "\n"
+	if err := os.WriteFile(skipTokenLogPath(), append(data, forged...), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := e.SkipTokens()
+	if err != nil || len(tokens) != 2 {
+		t.Fatalf("SkipTokens = %v, %v", tokens, err)
+	}
+	if got := tokens[0].Status(time.Now()); got != "revoked" {
+		t.Errorf("revoked token is %s", got)
+	}
+	if got := tokens[1].Status(time.Now()); got != "unused" {
+		t.Errorf("forged events made the other token %s", got)
+	}
+}
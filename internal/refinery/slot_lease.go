@@ -0,0 +1,152 @@
+package refinery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// DefaultMergeSlotLeaseTTL is how long a push slot holder may go without
+// renewing its lease before the next engineer wanting the slot reclaims it.
+// Can be overridden per-rig via MergeQueueConfig.MergeSlotLeaseTTL.
+const DefaultMergeSlotLeaseTTL = 5 * time.Minute
+
+// MergeSlotLease records who holds a merge slot for pushing, and until
+// when. The slot itself (a beads merge slot, or a file lock for targets)
+// has no notion of liveness, so an engineer that crashed mid-push would
+// hold it forever; the lease gives the hold an expiry, renewed by a
+// heartbeat while the holder is alive (see holdSlotLease). An engineer
+// finding the slot held under an expired lease releases it on the dead
+// holder's behalf and takes it (see reclaimExpiredSlot).
+type MergeSlotLease struct {
+	Slot     string    `json:"slot"`
+	Holder   string    `json:"holder"`
+	PID      int       `json:"pid"`
+	Acquired time.Time `json:"acquired"`
+	Renewed  time.Time `json:"renewed"`
+	Expires  time.Time `json:"expires"`
+}
+
+// slotLeaseTTL returns the lease TTL, or 0 when leases are off.
+func (e *Engineer) slotLeaseTTL() time.Duration {
+	if e.config == nil || e.rig == nil || e.rig.Path == "" {
+		return 0
+	}
+	return e.config.MergeSlotLeaseTTL
+}
+
+func (e *Engineer) slotLeasePath(slot string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(slot)
+	return filepath.Join(e.rig.Path, ".runtime", "merge-slot-leases", name+".json")
+}
+
+// SlotLease returns the lease on slot, or nil if it has none.
+func (e *Engineer) SlotLease(slot string) (*MergeSlotLease, error) {
+	data, err := os.ReadFile(e.slotLeasePath(slot))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading merge slot lease: %w", err)
+	}
+	var l MergeSlotLease
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("parsing merge slot lease: %w", err)
+	}
+	return &l, nil
+}
+
+// holdSlotLease records holder's lease on slot and renews it every third of
+// the TTL until endSlotLease. A renewal finding the lease taken over
+// (reclaimed while this engineer was stalled) stops with a warning.
+func (e *Engineer) holdSlotLease(slot, holder string) {
+	ttl := e.slotLeaseTTL()
+	if ttl <= 0 {
+		return
+	}
+	now := time.Now().UTC()
+	lease := &MergeSlotLease{Slot: slot, Holder: holder, PID: os.Getpid(), Acquired: now, Renewed: now, Expires: now.Add(ttl)}
+	path := e.slotLeasePath(slot)
+	if err := util.EnsureDirAndWriteJSON(path, lease); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: recording merge slot lease: %v\n", err)
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	e.leaseMu.Lock()
+	if e.leases == nil {
+		e.leases = make(map[string]func())
+	}
+	e.leases[holder] = func() {
+		close(stop)
+		<-done
+		if cur, err := e.SlotLease(slot); err == nil && cur != nil && cur.Holder == holder {
+			_ = os.Remove(path)
+		}
+	}
+	e.leaseMu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			cur, err := e.SlotLease(slot)
+			if err != nil || cur == nil || cur.Holder != holder {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: lost merge slot lease on %s (%s); it was reclaimed\n", slot, holder)
+				return
+			}
+			cur.Renewed = time.Now().UTC()
+			cur.Expires = cur.Renewed.Add(ttl)
+			if err := util.EnsureDirAndWriteJSON(path, cur); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: renewing merge slot lease: %v\n", err)
+			}
+		}
+	}()
+}
+
+// endSlotLease stops holder's heartbeat and removes its lease, unless the
+// lease has since been taken over.
+func (e *Engineer) endSlotLease(holder string) {
+	e.leaseMu.Lock()
+	end := e.leases[holder]
+	delete(e.leases, holder)
+	e.leaseMu.Unlock()
+	if end != nil {
+		end()
+	}
+}
+
+// reclaimExpiredSlot releases slot on behalf of holder if holder's lease on
+// it has expired, reporting whether it did. A holder without a lease (one
+// that predates leases, or a conflict resolution, which holds the slot
+// while a polecat works) is never reclaimed.
+func (e *Engineer) reclaimExpiredSlot(slot, holder string) bool {
+	if e.slotLeaseTTL() <= 0 {
+		return false
+	}
+	lease, err := e.SlotLease(slot)
+	if err != nil || lease == nil || lease.Holder != holder || time.Now().Before(lease.Expires) {
+		return false
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Merge slot lease of %s expired at %s (last renewed %s); reclaiming the slot\n",
+		holder, lease.Expires.Local().Format(time.RFC3339), lease.Renewed.Local().Format(time.RFC3339))
+	if err := e.mergeSlotRelease(holder); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: reclaiming merge slot from %s: %v\n", holder, err)
+		return false
+	}
+	_ = os.Remove(e.slotLeasePath(slot))
+	return true
+}
@@ -0,0 +1,109 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+func newLeaseTestEngineer(t *testing.T, ttl time.Duration) (*Engineer, *localMergeSlot) {
+	t.Helper()
+	cfg := DefaultMergeQueueConfig()
+	cfg.MergeSlotLeaseTTL = ttl
+	e := &Engineer{
+		rig:                   &rig.Rig{Name: "testrig", Path: t.TempDir()},
+		config:                cfg,
+		output:                io.Discard,
+		mergeSlotRetryBackoff: time.Millisecond,
+	}
+	slot := &localMergeSlot{id: "merge-slot"}
+	slot.install(e)
+	return e, slot
+}
+
+func TestSlotLease_ReclaimsExpiredLease(t *testing.T) {
+	e, slot := newLeaseTestEngineer(t, time.Minute)
+	// A crashed engineer left the slot held, its lease long expired.
+	slot.holder = "testrig/refinery/push/dead"
+	expired := time.Now().Add(-time.Hour).UTC()
+	if err := util.EnsureDirAndWriteJSON(e.slotLeasePath("merge-slot"), &MergeSlotLease{
+		Slot: "merge-slot", Holder: slot.holder, Renewed: expired, Expires: expired,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	holder, err := e.acquireMainPushSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireMainPushSlot: %v", err)
+	}
+	if slot.holder != holder {
+		t.Fatalf("slot held by %q, want %q", slot.holder, holder)
+	}
+	lease, err := e.SlotLease("merge-slot")
+	if err != nil || lease == nil || lease.Holder != holder || !lease.Expires.After(time.Now()) {
+		t.Fatalf("lease after reclaim = %+v, %v", lease, err)
+	}
+
+	e.releasePushSlot(holder)
+	if lease, _ := e.SlotLease("merge-slot"); lease != nil {
+		t.Errorf("lease left after release: %+v", lease)
+	}
+}
+
+func TestSlotLease_LiveLeaseNotReclaimed(t *testing.T) {
+	other, slot := newLeaseTestEngineer(t, time.Minute)
+	held, err := other.acquireMainPushSlot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.releasePushSlot(held)
+
+	e := &Engineer{rig: other.rig, config: other.config, output: io.Discard, mergeSlotRetryBackoff: time.Millisecond}
+	slot.install(e)
+	if _, err := e.acquireMainPushSlot(context.Background()); !errors.Is(err, errMergeSlotTimeout) {
+		t.Errorf("acquired a slot under a live lease: %v", err)
+	}
+
+	// Without a lease (leases off, or a conflict resolution), never reclaimed.
+	e.config = DefaultMergeQueueConfig()
+	e.config.MergeSlotLeaseTTL = 0
+	if e.reclaimExpiredSlot("merge-slot", held) {
+		t.Error("reclaimed with leases off")
+	}
+}
+
+func TestSlotLease_Heartbeat(t *testing.T) {
+	e, _ := newLeaseTestEngineer(t, 90*time.Millisecond)
+	holder, err := e.acquireMainPushSlot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := e.SlotLease("merge-slot")
+	time.Sleep(200 * time.Millisecond)
+	renewed, _ := e.SlotLease("merge-slot")
+	if renewed == nil || !renewed.Expires.After(first.Expires) || !renewed.Acquired.Equal(first.Acquired) {
+		t.Fatalf("lease not renewed: first %+v, now %+v", first, renewed)
+	}
+	if e.reclaimExpiredSlot("merge-slot", holder) {
+		t.Error("reclaimed a lease being renewed")
+	}
+	e.releasePushSlot(holder)
+}
+
+func TestLoadConfig_MergeSlotLeaseTTL(t *testing.T) {
+	cfg := DefaultMergeQueueConfig()
+	if cfg.MergeSlotLeaseTTL != DefaultMergeSlotLeaseTTL {
+		t.Errorf("default merge_slot_lease_ttl = %v", cfg.MergeSlotLeaseTTL)
+	}
+	if err := applyMergeQueueConfig(cfg, []byte(`{"merge_slot_lease_ttl": "90s"}`)); err != nil || cfg.MergeSlotLeaseTTL != 90*time.Second {
+		t.Errorf("merge_slot_lease_ttl = %v, %v", cfg.MergeSlotLeaseTTL, err)
+	}
+	if err := applyMergeQueueConfig(DefaultMergeQueueConfig(), []byte(`{"merge_slot_lease_ttl": "-1s"}`)); err == nil {
+		t.Error("negative merge_slot_lease_ttl accepted")
+	}
+}
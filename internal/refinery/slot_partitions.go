@@ -0,0 +1,223 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// SlotPartitionConfig is one path partition of a monorepo's default branch
+// (see MergeQueueConfig.SlotPartitions): the paths it owns, each a file or
+// a directory and everything under it, relative to the repository root.
+type SlotPartitionConfig struct {
+	Paths []string `json:"paths"`
+}
+
+// owns reports whether file is one of p's paths or under one.
+func (p *SlotPartitionConfig) owns(file string) bool {
+	for _, dir := range p.Paths {
+		if file == dir || strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSlotPartitions checks and normalizes the partitions' paths.
+func validateSlotPartitions(parts map[string]*SlotPartitionConfig) error {
+	for name, p := range parts {
+		if name == "" || !filepath.IsLocal(name) || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("invalid slot partition name %q", name)
+		}
+		if p == nil || len(p.Paths) == 0 {
+			return fmt.Errorf("slot partition %q needs paths", name)
+		}
+		for i, dir := range p.Paths {
+			dir = path.Clean(strings.Trim(dir, "/"))
+			if dir == "." || !filepath.IsLocal(dir) {
+				return fmt.Errorf("slot partition %q path %q must be a directory or file inside the repository", name, p.Paths[i])
+			}
+			p.Paths[i] = dir
+		}
+	}
+	return nil
+}
+
+// partitionsOf returns the partitions files touch, sorted. whole is set
+// when a file belongs to no partition, so the change needs the whole
+// repository.
+func partitionsOf(parts map[string]*SlotPartitionConfig, files []string) (names []string, whole bool) {
+	touched := make(map[string]bool)
+	for _, file := range files {
+		owned := false
+		for name, p := range parts {
+			if p.owns(file) {
+				touched[name], owned = true, true
+			}
+		}
+		if !owned {
+			whole = true
+		}
+	}
+	for name := range touched {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, whole
+}
+
+// landingSlots are the merge slots a landing on the default branch holds
+// (see acquireLandingSlots).
+type landingSlots struct {
+	partitions []string // Partitions held alone; nil = the whole repository
+	release    func()
+}
+
+// acquireLandingSlots takes the merge slots for landing the checked-out
+// tree on target, the rig's default branch. Without slot partitions that is
+// the rig's merge slot (see acquireMainPushSlot). With them, a landing whose
+// changes all fall in partitions takes only those partitions' slots, so
+// landings in disjoint partitions don't wait for each other; any other
+// landing takes the rig's slot and every partition's. Partition slots are
+// file locks under .runtime/locks/merge-slot/<target>/, taken in name
+// order, all or none, retried like the rig's slot.
+func (e *Engineer) acquireLandingSlots(ctx context.Context, target string) (*landingSlots, error) {
+	parts := e.config.SlotPartitions
+	if len(parts) == 0 {
+		holder, err := e.acquireMainPushSlot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &landingSlots{release: func() {
+			// holder is empty when the self-conflict bypass fires — conflict-resolution
+			// owns the slot, so we must not release it here.
+			if holder != "" {
+				e.releasePushSlot(holder)
+			}
+		}}, nil
+	}
+
+	files, err := e.git.DiffNameOnly("origin/"+target, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("listing landing changes: %w", err)
+	}
+	names, whole := partitionsOf(parts, files)
+	if !whole && len(names) > 0 {
+		release, err := e.acquirePartitionSlots(ctx, target, names)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Landing touches partitions %s; holding their merge slots\n", strings.Join(names, ", "))
+		return &landingSlots{partitions: names, release: release}, nil
+	}
+
+	holder, err := e.acquireMainPushSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]string, 0, len(parts))
+	for name := range parts {
+		all = append(all, name)
+	}
+	sort.Strings(all)
+	releaseParts, err := e.acquirePartitionSlots(ctx, target, all)
+	if err != nil {
+		if holder != "" {
+			e.releasePushSlot(holder)
+		}
+		return nil, err
+	}
+	return &landingSlots{release: func() {
+		releaseParts()
+		if holder != "" {
+			e.releasePushSlot(holder)
+		}
+	}}, nil
+}
+
+// acquirePartitionSlots takes the slots of partitions names on target,
+// returning the func releasing them.
+func (e *Engineer) acquirePartitionSlots(ctx context.Context, target string, names []string) (func(), error) {
+	backoff := e.mergeSlotRetryBackoff
+	if backoff == 0 {
+		backoff = 500 * time.Millisecond
+	}
+	var busy string
+	for attempt := 0; attempt <= e.mergeSlotMaxRetries; attempt++ {
+		if attempt > 0 {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Partition merge slot %s held, retrying in %v (attempt %d/%d)...\n", busy, backoff, attempt, e.mergeSlotMaxRetries)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			backoff = min(backoff*2, 10*time.Second)
+		}
+		var held []func()
+		releaseAll := func() {
+			for i := len(held) - 1; i >= 0; i-- {
+				held[i]()
+			}
+		}
+		busy = ""
+		for _, name := range names {
+			release, err := e.tryFlock(filepath.Join("merge-slot", target, name+".lock"))
+			if err != nil {
+				releaseAll()
+				return nil, fmt.Errorf("acquire partition merge slot %s: %w", name, err)
+			}
+			if release == nil {
+				busy = name
+				break
+			}
+			held = append(held, release)
+		}
+		if busy == "" {
+			return releaseAll, nil
+		}
+		releaseAll()
+	}
+	return nil, fmt.Errorf("partition merge slot %s on %s: %w after %d retries", busy, target, errMergeSlotTimeout, e.mergeSlotMaxRetries)
+}
+
+// pushLanding pushes the checked-out tree to target. A landing holding
+// partition slots alone may find target moved by a landing in other
+// partitions; when everything that landed meanwhile is in partitions
+// disjoint from its own, it rebases onto it and pushes again, as those
+// changes can't affect what its gates checked. Signed commits and merge
+// commits aren't rebased, as that would drop their signatures or flatten
+// them.
+func (e *Engineer) pushLanding(target string, slots *landingSlots) error {
+	err := e.git.Push("origin", target, false)
+	if err == nil || slots == nil || slots.partitions == nil || e.signCommits {
+		return err
+	}
+	if fetchErr := e.git.FetchBranch("origin", target); fetchErr != nil {
+		return err
+	}
+	landed, diffErr := e.git.DiffNameOnly("HEAD", "origin/"+target)
+	if diffErr != nil || len(landed) == 0 {
+		return err
+	}
+	theirs, whole := partitionsOf(e.config.SlotPartitions, landed)
+	if whole || slices.ContainsFunc(theirs, func(name string) bool { return slices.Contains(slots.partitions, name) }) {
+		return err
+	}
+	ours, seriesErr := e.git.CommitSeries("origin/"+target, "HEAD")
+	if seriesErr != nil || slices.ContainsFunc(ours, func(c git.SeriesCommit) bool { return c.Merge }) {
+		return err
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] origin/%s moved in partitions %s meanwhile; rebasing onto it\n", target, strings.Join(theirs, ", "))
+	if rebaseErr := e.git.Rebase("origin/" + target); rebaseErr != nil {
+		_ = e.git.AbortRebase()
+		return fmt.Errorf("%w (rebasing onto origin/%s: %v)", err, target, rebaseErr)
+	}
+	return e.git.Push("origin", target, false)
+}
@@ -0,0 +1,151 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+func TestPartitionsOf(t *testing.T) {
+	parts := map[string]*SlotPartitionConfig{
+		"frontend": {Paths: []string{"/web/", "shared/ui.ts"}},
+		"backend":  {Paths: []string{"server"}},
+	}
+	if err := validateSlotPartitions(parts); err != nil {
+		t.Fatalf("validateSlotPartitions: %v", err)
+	}
+	for _, tc := range []struct {
+		files []string
+		want  string
+		whole bool
+	}{
+		{[]string{"web/app.ts", "shared/ui.ts"}, "frontend", false},
+		{[]string{"web/app.ts", "server/main.go"}, "backend,frontend", false},
+		{[]string{"server/main.go", "go.mod"}, "backend", true},
+		{[]string{"webby/x"}, "", true},
+	} {
+		names, whole := partitionsOf(parts, tc.files)
+		if strings.Join(names, ",") != tc.want || whole != tc.whole {
+			t.Errorf("partitionsOf(%v) = %v, %v; want %s, %v", tc.files, names, whole, tc.want, tc.whole)
+		}
+	}
+	for _, bad := range []map[string]*SlotPartitionConfig{
+		{"a/b": {Paths: []string{"x"}}},
+		{"a": {}},
+		{"a": {Paths: []string{"../x"}}},
+		{"a": {Paths: []string{"/"}}},
+	} {
+		if err := validateSlotPartitions(bad); err == nil {
+			t.Errorf("validateSlotPartitions(%v) accepted", bad)
+		}
+	}
+}
+
+func TestSlotPartitions_DisjointLandingNotBlocked(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	for _, dir := range []string{"server", "web"} {
+		if err := os.MkdirAll(filepath.Join(workDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createFeatureBranch(t, workDir, "feature-server", "server/main.go", "package main\n")
+	createFeatureBranch(t, workDir, "feature-web", "web/app.ts", "app\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.mergeSlotMaxRetries = 0
+	e.config.SlotPartitions = map[string]*SlotPartitionConfig{
+		"frontend": {Paths: []string{"web"}},
+		"backend":  {Paths: []string{"server"}},
+	}
+	mainSlotTaken := false
+	acquire := e.mergeSlotAcquire
+	e.mergeSlotAcquire = func(holder string, addWaiter bool) (*beads.MergeSlotStatus, error) {
+		mainSlotTaken = true
+		return acquire(holder, addWaiter)
+	}
+
+	// Another engineer is landing in the frontend.
+	releaseWeb, err := e.tryFlock(filepath.Join("merge-slot", "main", "frontend.lock"))
+	if err != nil || releaseWeb == nil {
+		t.Fatalf("taking frontend slot: %v", err)
+	}
+
+	result := e.ProcessMRInfo(context.Background(), makeMR("mr-server", "feature-server", "main"))
+	if !result.Success || mainSlotTaken {
+		t.Fatalf("backend landing: %+v, took the rig's slot %v", result, mainSlotTaken)
+	}
+
+	result = e.ProcessMRInfo(context.Background(), makeMR("mr-web", "feature-web", "main"))
+	if result.Success || !result.SlotTimeout {
+		t.Errorf("frontend landing while its slot is held: %+v", result)
+	}
+	releaseWeb()
+	result = e.ProcessMRInfo(context.Background(), makeMR("mr-web", "feature-web", "main"))
+	if !result.Success {
+		t.Errorf("frontend landing after release: %+v", result)
+	}
+}
+
+func TestPushLanding_RebasesOverOtherPartitions(t *testing.T) {
+	workDir, g, _ := testGitRepo(t)
+	e := newTestEngineer(t, workDir, g)
+	e.config.SlotPartitions = map[string]*SlotPartitionConfig{
+		"frontend": {Paths: []string{"web"}},
+		"backend":  {Paths: []string{"server"}},
+	}
+	other := filepath.Join(t.TempDir(), "other")
+	run(t, workDir, "git", "clone", filepath.Join(filepath.Dir(workDir), "origin.git"), other)
+	run(t, other, "git", "config", "user.email", "other@test.com")
+	run(t, other, "git", "config", "user.name", "Other")
+	land := func(dir, file string) {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, dir, file, file+"\n")
+	}
+	landElsewhere := func(file string) {
+		run(t, other, "git", "pull", "--rebase", "origin", "main")
+		land(other, file)
+		run(t, other, "git", "add", ".")
+		run(t, other, "git", "commit", "-m", "land "+file)
+		run(t, other, "git", "push", "origin", "main")
+	}
+	landHere := func(file string) {
+		land(workDir, file)
+		run(t, workDir, "git", "add", file)
+		run(t, workDir, "git", "commit", "-m", "land "+file)
+	}
+	backend := &landingSlots{partitions: []string{"backend"}}
+
+	// The frontend landed meanwhile: rebase and push.
+	landElsewhere("web/a.ts")
+	landHere("server/a.go")
+	if err := e.pushLanding("main", backend); err != nil {
+		t.Fatalf("pushLanding over a frontend landing: %v", err)
+	}
+	if log := originLog(t, workDir, "%s"); len(log) != 3 || log[0] != "land server/a.go" {
+		t.Errorf("origin log = %v", log)
+	}
+
+	// The backend landed meanwhile: our gates didn't see it, so no push.
+	landElsewhere("server/b.go")
+	landHere("server/c.go")
+	if err := e.pushLanding("main", backend); err == nil {
+		t.Error("pushed over a landing in the same partition")
+	}
+	// Nor over a landing outside every partition.
+	run(t, workDir, "git", "reset", "--hard", "HEAD~1")
+	run(t, workDir, "git", "pull", "--rebase", "origin", "main")
+	landElsewhere("go.mod")
+	landHere("server/d.go")
+	if err := e.pushLanding("main", backend); err == nil {
+		t.Error("pushed over a landing outside the partitions")
+	}
+	if err := e.pushLanding("main", &landingSlots{}); err == nil {
+		t.Error("whole-repository landing rebased")
+	}
+}
@@ -0,0 +1,510 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Admission policies an MR can be held for (see MRInfo.HeldFor).
+const (
+	HeldForSplit = "split"
+	HeldForTests = "tests"
+)
+
+// Split policy defaults.
+const (
+	DefaultSplitMaxLines     = 1000
+	DefaultSplitMaxFiles     = 40
+	DefaultSplitMaxRiskLines = 200
+)
+
+// SplitPolicyConfig configures split admission: MRs whose diff exceeds the
+// size or risk thresholds are held out of batches, and the originating
+// agent is handed a molecule to split the branch into a stack of smaller
+// MRs. Small MRs keep batches small and bisection cheap.
+type SplitPolicyConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// MaxLines is the number of changed lines (added + deleted) above which
+	// an MR is split. Default: DefaultSplitMaxLines.
+	MaxLines int `json:"max_lines,omitempty"`
+
+	// MaxFiles is the number of changed files above which an MR is split.
+	// Default: DefaultSplitMaxFiles.
+	MaxFiles int `json:"max_files,omitempty"`
+
+	// RiskPatterns match files whose changes are risky (schema migrations,
+	// auth, build config). Same syntax as TestPolicyConfig.TestPatterns.
+	// Default: none, so only size is considered.
+	RiskPatterns []string `json:"risk_patterns,omitempty"`
+
+	// MaxRiskLines is the number of changed lines in risky files above
+	// which an MR is split. Default: DefaultSplitMaxRiskLines.
+	MaxRiskLines int `json:"max_risk_lines,omitempty"`
+
+	// IgnorePatterns match files left out of the size (lockfiles, vendored
+	// and generated code). Default: DefaultSplitIgnorePatterns.
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+
+	// Formula is poured to the originating agent on the split task.
+	// Default: mol-split-mr.
+	Formula string `json:"formula,omitempty"`
+}
+
+// DefaultSplitIgnorePatterns match files whose size says nothing about how
+// hard a change is to review.
+var DefaultSplitIgnorePatterns = []string{
+	"go.sum", "*.lock", "package-lock.json", "pnpm-lock.yaml",
+	"*.pb.go", "*_generated.go", "vendor/**", "testdata/**",
+}
+
+// validateSplitPolicy checks that the thresholds are non-negative.
+func validateSplitPolicy(cfg *SplitPolicyConfig) error {
+	for name, v := range map[string]int{
+		"max_lines":      cfg.MaxLines,
+		"max_files":      cfg.MaxFiles,
+		"max_risk_lines": cfg.MaxRiskLines,
+	} {
+		if v < 0 {
+			return fmt.Errorf("split_policy %s must be non-negative, got %d", name, v)
+		}
+	}
+	return nil
+}
+
+func (c *SplitPolicyConfig) maxLines() int {
+	if c.MaxLines > 0 {
+		return c.MaxLines
+	}
+	return DefaultSplitMaxLines
+}
+
+func (c *SplitPolicyConfig) maxFiles() int {
+	if c.MaxFiles > 0 {
+		return c.MaxFiles
+	}
+	return DefaultSplitMaxFiles
+}
+
+func (c *SplitPolicyConfig) maxRiskLines() int {
+	if c.MaxRiskLines > 0 {
+		return c.MaxRiskLines
+	}
+	return DefaultSplitMaxRiskLines
+}
+
+func (c *SplitPolicyConfig) ignorePatterns() []string {
+	if c.IgnorePatterns != nil {
+		return c.IgnorePatterns
+	}
+	return DefaultSplitIgnorePatterns
+}
+
+func (c *SplitPolicyConfig) formula() string {
+	if c.Formula != "" {
+		return c.Formula
+	}
+	return constants.MolSplitMR
+}
+
+// MRSize measures an MR's diff for the split policy.
+type MRSize struct {
+	Files     int      `json:"files"`
+	Lines     int      `json:"lines"` // Added + deleted lines
+	RiskFiles []string `json:"risk_files,omitempty"`
+	RiskLines int      `json:"risk_lines,omitempty"`
+}
+
+// measureDiff sizes a diff, leaving out ignored files. Binary files count
+// towards the file total but have no lines.
+func measureDiff(stats []git.DiffStat, cfg *SplitPolicyConfig) MRSize {
+	var size MRSize
+	for _, st := range stats {
+		if matchAnyPattern(cfg.ignorePatterns(), st.Path) {
+			continue
+		}
+		size.Files++
+		size.Lines += st.Added + st.Deleted
+		if matchAnyPattern(cfg.RiskPatterns, st.Path) {
+			size.RiskFiles = append(size.RiskFiles, st.Path)
+			size.RiskLines += st.Added + st.Deleted
+		}
+	}
+	return size
+}
+
+// Oversized returns the thresholds the diff exceeds, or nil if it is small
+// enough to merge as one MR.
+func (s MRSize) Oversized(cfg *SplitPolicyConfig) []string {
+	var reasons []string
+	if s.Lines > cfg.maxLines() {
+		reasons = append(reasons, fmt.Sprintf("%d lines changed (max %d)", s.Lines, cfg.maxLines()))
+	}
+	if s.Files > cfg.maxFiles() {
+		reasons = append(reasons, fmt.Sprintf("%d files changed (max %d)", s.Files, cfg.maxFiles()))
+	}
+	if s.RiskLines > cfg.maxRiskLines() {
+		reasons = append(reasons, fmt.Sprintf("%d lines changed in risky files (max %d)", s.RiskLines, cfg.maxRiskLines()))
+	}
+	return reasons
+}
+
+// SplitRequest records a split task raised for an MR. Like TestRequest,
+// each MR gets at most one: an agent that judges the MR can't be split
+// closes the task and the MR is admitted whole.
+type SplitRequest struct {
+	MR          string    `json:"mr"`
+	Task        string    `json:"task"`
+	Worker      string    `json:"worker,omitempty"`
+	Slung       bool      `json:"slung"` // Formula reached the worker; false leaves the task for dispatch
+	Size        MRSize    `json:"size"`
+	Reasons     []string  `json:"reasons"`
+	Parts       []string  `json:"parts,omitempty"` // MRs the original was split into, once split
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+func (e *Engineer) splitRequestsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "split-requests.json")
+}
+
+// SplitRequests returns the split tasks raised so far, keyed by MR ID.
+func (e *Engineer) SplitRequests() (map[string]*SplitRequest, error) {
+	data, err := os.ReadFile(e.splitRequestsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]*SplitRequest), nil
+		}
+		return nil, err
+	}
+	reqs := make(map[string]*SplitRequest)
+	if err := json.Unmarshal(data, &reqs); err != nil {
+		return nil, fmt.Errorf("parsing split requests: %w", err)
+	}
+	return reqs, nil
+}
+
+// admitSized applies the split policy. MRs whose diff exceeds a threshold
+// are held: a split task is created, the MR is blocked on it, and the
+// policy's formula is slung to the MR's worker. The worker splits the
+// branch into stacked part branches and hands them to SplitMR, which
+// retires the original. If the task instead closes without a split, the
+// MR is admitted whole on its next pass.
+//
+// Like the test policy, the split policy fails open.
+func (e *Engineer) admitSized(ready []*MRInfo) (admitted, held []*MRInfo) {
+	cfg := e.config.SplitPolicy
+	if cfg == nil || !cfg.Enabled || len(ready) == 0 {
+		return ready, nil
+	}
+	reqs, err := e.SplitRequests()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: %v (admitting all MRs)\n", err)
+		return ready, nil
+	}
+
+	changed := false
+	for _, mr := range ready {
+		if req, ok := reqs[mr.ID]; ok {
+			_, _ = fmt.Fprintf(e.output, "[SplitPolicy] MR %s: split task %s closed, admitting whole\n", mr.ID, req.Task)
+			e.recordProgress(StageAdmitted, "split task "+req.Task+" closed", "", mr)
+			admitted = append(admitted, mr)
+			continue
+		}
+		stats, err := e.mrDiffStats(mr)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: MR %s: reading diff: %v (admitting)\n", mr.ID, err)
+			admitted = append(admitted, mr)
+			continue
+		}
+		size := measureDiff(stats, cfg)
+		reasons := size.Oversized(cfg)
+		if len(reasons) == 0 {
+			admitted = append(admitted, mr)
+			continue
+		}
+		req, err := e.requestSplit(mr, size, reasons, cfg)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: MR %s: %v (admitting)\n", mr.ID, err)
+			admitted = append(admitted, mr)
+			continue
+		}
+		reqs[mr.ID] = req
+		changed = true
+		mr.BlockedBy = req.Task
+		mr.HeldFor = HeldForSplit
+		held = append(held, mr)
+		e.recordProgress(StageHeld, "waiting on split task "+req.Task, "", mr)
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.splitRequestsPath(), reqs); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: saving split requests: %v\n", err)
+		}
+	}
+	return admitted, held
+}
+
+// requestSplit creates the split task for mr, blocks mr on it and slings
+// the policy formula to mr's worker. A failed sling leaves the task open
+// for dispatch like any other gt:task.
+func (e *Engineer) requestSplit(mr *MRInfo, size MRSize, reasons []string, cfg *SplitPolicyConfig) (*SplitRequest, error) {
+	risk := "none"
+	if len(size.RiskFiles) > 0 {
+		risk = strings.Join(size.RiskFiles, ", ")
+	}
+	description := fmt.Sprintf(`Split branch %s into reviewable pieces
+
+## Metadata
+- Original MR: %s
+- Branch: %s
+- Target: %s
+- Original issue: %s
+- Size: %d files, %d lines changed
+- Risky files: %s
+
+## Why
+- %s
+
+## Instructions
+The refinery holds this MR out of the merge queue because it is too large to
+batch and bisect well. Split the change into a stack of branches, each
+building on the one before and each under %d lines and %d files, then run:
+
+    gt mq split %s <part-1> <part-2> ...
+
+That queues one MR per part, chained in order, retires the original MR and
+closes this task. If the change can't be split sensibly, close this task
+with a reason saying why; the MR is then admitted whole.`,
+		mr.Branch,
+		mr.ID,
+		mr.Branch,
+		mr.Target,
+		mr.SourceIssue,
+		size.Files, size.Lines,
+		risk,
+		strings.Join(reasons, "\n- "),
+		cfg.maxLines(), cfg.maxFiles(),
+		mr.ID,
+	)
+
+	title := mr.Title
+	if title == "" {
+		title = mr.Branch
+	}
+	task, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Split MR: " + title,
+		Labels:      []string{"gt:task"},
+		Priority:    mr.Priority,
+		Description: description,
+		Actor:       e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating split task: %w", err)
+	}
+	if err := e.beads.AddDependency(mr.ID, task.ID); err != nil {
+		return nil, fmt.Errorf("blocking MR on split task %s: %w", task.ID, err)
+	}
+
+	req := &SplitRequest{
+		MR:          mr.ID,
+		Task:        task.ID,
+		Worker:      mr.Worker,
+		Size:        size,
+		Reasons:     reasons,
+		RequestedAt: time.Now().UTC(),
+	}
+	if polecat := strings.TrimPrefix(mr.Worker, "polecats/"); polecat != "" {
+		target := fmt.Sprintf("%s/%s", e.rig.Name, polecat)
+		slingCmd := exec.Command("gt", "sling", cfg.formula(), target, "--on", task.ID, //nolint:gosec // G204: formula is from trusted rig config
+			"--var", "task="+task.ID,
+			"--var", "original_mr="+mr.ID,
+			"--var", "branch="+mr.Branch,
+			"--var", "base_branch="+mr.Target,
+			"--var", "max_lines="+strconv.Itoa(cfg.maxLines()),
+			"--var", "max_files="+strconv.Itoa(cfg.maxFiles()))
+		slingCmd.Dir = e.workDir
+		if out, err := slingCmd.CombinedOutput(); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: sling %s to %s: %v: %s (task left for dispatch)\n",
+				cfg.formula(), target, err, strings.TrimSpace(string(out)))
+		} else {
+			req.Slung = true
+		}
+	}
+	_, _ = fmt.Fprintf(e.output, "[SplitPolicy] MR %s held: %s, blocked on %s\n",
+		mr.ID, strings.Join(reasons, ", "), task.ID)
+	return req, nil
+}
+
+// checkSplitParts verifies that parts form a stack splitting mr: each part
+// builds on the one before, and unless partial is set, the last part makes
+// the same changes as mr's branch.
+func (e *Engineer) checkSplitParts(mr *MRInfo, parts []string, partial bool) error {
+	if len(parts) < 2 {
+		return fmt.Errorf("need at least 2 parts to split %s, got %d", mr.ID, len(parts))
+	}
+	refs := make([]string, len(parts))
+	for i, part := range parts {
+		if part == mr.Branch {
+			return fmt.Errorf("part %s is the original branch", part)
+		}
+		refs[i] = e.branchHead(part)
+		if _, err := e.git.Rev(refs[i]); err != nil {
+			return fmt.Errorf("part %s not found (push it to origin first)", part)
+		}
+		if i == 0 {
+			continue
+		}
+		stacked, err := e.git.IsAncestor(refs[i-1], refs[i])
+		if err != nil {
+			return err
+		}
+		if !stacked {
+			return fmt.Errorf("part %s does not build on %s: each part must be branched from the one before", part, parts[i-1])
+		}
+	}
+	if partial {
+		return nil
+	}
+
+	last := *mr
+	last.Branch = parts[len(parts)-1]
+	want, err := e.mrDiffStats(mr)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", mr.Branch, err)
+	}
+	got, err := e.mrDiffStats(&last)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", last.Branch, err)
+	}
+	if differ := diffStatsDiffer(want, got); len(differ) > 0 {
+		return fmt.Errorf("parts don't add up to %s: %s differ (use --partial to split off only part of the change)",
+			mr.Branch, strings.Join(differ, ", "))
+	}
+	return nil
+}
+
+// diffStatsDiffer returns the files whose line counts differ between two
+// diffs.
+func diffStatsDiffer(a, b []git.DiffStat) []string {
+	counts := make(map[string]git.DiffStat, len(a))
+	for _, st := range a {
+		counts[st.Path] = st
+	}
+	var differ []string
+	for _, st := range b {
+		if want, ok := counts[st.Path]; !ok || want != st {
+			differ = append(differ, st.Path)
+		}
+		delete(counts, st.Path)
+	}
+	for path := range counts {
+		differ = append(differ, path)
+	}
+	sort.Strings(differ)
+	return differ
+}
+
+// SplitMR replaces the open MR mrID with one MR per part branch. The parts
+// must form a stack (see checkSplitParts); each part's MR carries the
+// original's fields with split_from set, and depends on the MR before it,
+// so the parts land in order, each in a small batch of its own. The
+// original MR is then closed as superseded, along with its split task.
+//
+// If creating a part's MR fails, the parts created so far are returned
+// with the error and the original MR is left open.
+func (e *Engineer) SplitMR(mrID string, parts []string, partial bool) ([]*MRInfo, error) {
+	issue, err := e.beads.Show(mrID)
+	if err != nil {
+		return nil, fmt.Errorf("loading MR %s: %w", mrID, err)
+	}
+	fields := beads.ParseMRFields(issue)
+	if fields == nil || !beads.HasLabel(issue, "gt:merge-request") {
+		return nil, fmt.Errorf("%s is not a merge request", mrID)
+	}
+	if issue.Status == "closed" {
+		return nil, fmt.Errorf("MR %s is already closed", mrID)
+	}
+	orig := issueToMRInfo(issue, fields)
+	if orig.Target == "" {
+		orig.Target = e.rig.DefaultBranch()
+	}
+
+	if err := e.git.Fetch("origin"); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: fetching origin: %v\n", err)
+	}
+	if err := e.checkSplitParts(orig, parts, partial); err != nil {
+		return nil, err
+	}
+
+	var created []*MRInfo
+	for i, part := range parts {
+		childFields := &beads.MRFields{
+			Branch:          part,
+			Target:          fields.Target,
+			SourceIssue:     fields.SourceIssue,
+			Worker:          fields.Worker,
+			Rig:             fields.Rig,
+			AgentBead:       fields.AgentBead,
+			ConvoyID:        fields.ConvoyID,
+			ConvoyCreatedAt: fields.ConvoyCreatedAt,
+			QoS:             fields.QoS,
+			MergeStrategy:   fields.MergeStrategy,
+			SplitFrom:       mrID,
+		}
+		description := fmt.Sprintf("%s\n\nPart %d of %d split from %s.", beads.FormatMRFields(childFields), i+1, len(parts), mrID)
+		child, err := e.beads.Create(beads.CreateOptions{
+			Title:       fmt.Sprintf("%s (part %d/%d)", issue.Title, i+1, len(parts)),
+			Labels:      []string{"gt:merge-request"},
+			Priority:    issue.Priority,
+			Description: description,
+			Actor:       e.rig.Name + "/refinery",
+			Ephemeral:   true,
+		})
+		if err != nil {
+			return created, fmt.Errorf("creating MR for part %s: %w", part, err)
+		}
+		mr := issueToMRInfo(child, childFields)
+		if i > 0 {
+			prev := created[i-1].ID
+			if err := e.beads.AddDependency(child.ID, prev); err != nil {
+				return append(created, mr), fmt.Errorf("stacking %s on %s: %w", child.ID, prev, err)
+			}
+			mr.BlockedBy = prev
+		}
+		created = append(created, mr)
+	}
+
+	ids := mrIDs(created)
+	reason := fmt.Sprintf("%s: split into %s", CloseReasonSuperseded, strings.Join(ids, ", "))
+	if err := e.beads.CloseWithReason(reason, mrID); err != nil {
+		return created, fmt.Errorf("closing %s: %w", mrID, err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[SplitPolicy] MR %s split into %s\n", mrID, strings.Join(ids, ", "))
+
+	reqs, err := e.SplitRequests()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: %v\n", err)
+		return created, nil
+	}
+	if req, ok := reqs[mrID]; ok {
+		req.Parts = ids
+		if err := e.beads.CloseWithReason("split into "+strings.Join(ids, ", "), req.Task); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: closing split task %s: %v\n", req.Task, err)
+		}
+		if err := util.EnsureDirAndWriteJSON(e.splitRequestsPath(), reqs); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[SplitPolicy] Warning: saving split requests: %v\n", err)
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,142 @@
+package refinery
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+func TestMeasureDiff(t *testing.T) {
+	cfg := &SplitPolicyConfig{Enabled: true, MaxLines: 100, MaxFiles: 3, RiskPatterns: []string{"migrations/**"}, MaxRiskLines: 20}
+	stats := []git.DiffStat{
+		{Path: "internal/store/store.go", Added: 40, Deleted: 10},
+		{Path: "migrations/0042_add_index.sql", Added: 25},
+		{Path: "go.sum", Added: 500},
+		{Path: "assets/logo.png", Binary: true},
+	}
+	size := measureDiff(stats, cfg)
+	if size.Files != 3 || size.Lines != 75 || size.RiskLines != 25 || len(size.RiskFiles) != 1 {
+		t.Fatalf("measureDiff = %+v, want 3 files, 75 lines, 25 risky", size)
+	}
+	reasons := size.Oversized(cfg)
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "risky") {
+		t.Errorf("Oversized = %q, want only the risk threshold", reasons)
+	}
+
+	stats = append(stats, git.DiffStat{Path: "internal/store/index.go", Added: 60})
+	if reasons := measureDiff(stats, cfg).Oversized(cfg); len(reasons) != 3 {
+		t.Errorf("Oversized = %q, want lines, files and risk", reasons)
+	}
+
+	// Defaults apply when thresholds are unset, and no file is risky.
+	if reasons := measureDiff(stats, &SplitPolicyConfig{Enabled: true}).Oversized(&SplitPolicyConfig{}); reasons != nil {
+		t.Errorf("Oversized with defaults = %q, want nil", reasons)
+	}
+}
+
+func TestEngineer_LoadConfig_SplitPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"split_policy": {"enabled": true, "max_lines": 400, "risk_patterns": ["migrations/**"]}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	sp := e.config.SplitPolicy
+	if sp == nil || !sp.Enabled || sp.maxLines() != 400 || sp.maxFiles() != DefaultSplitMaxFiles {
+		t.Fatalf("SplitPolicy = %+v", sp)
+	}
+	if sp.formula() != "mol-split-mr" {
+		t.Errorf("formula() = %q, want mol-split-mr", sp.formula())
+	}
+
+	data = []byte(`{"merge_queue": {"split_policy": {"enabled": true, "max_files": -1}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+		t.Error("expected error for negative max_files")
+	}
+}
+
+func TestAdmitMRs_SplitAdmitsAfterRequest(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	var out bytes.Buffer
+	e.SetOutput(&out)
+	e.config.SplitPolicy = &SplitPolicyConfig{Enabled: true}
+
+	// An MR whose split task closed without a split is admitted whole.
+	reqs := map[string]*SplitRequest{"mr-1": {MR: "mr-1", Task: "gt-task1"}}
+	if err := util.EnsureDirAndWriteJSON(e.splitRequestsPath(), reqs); err != nil {
+		t.Fatal(err)
+	}
+	admitted, held := e.AdmitMRs([]*MRInfo{makeMR("mr-1", "polecat/a", "main")})
+	if len(admitted) != 1 || len(held) != 0 {
+		t.Errorf("AdmitMRs = %d admitted, %d held; want 1, 0\n%s", len(admitted), len(held), out.String())
+	}
+}
+
+func TestAdmitMRs_SplitAdmitsSmallMRs(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.SplitPolicy = &SplitPolicyConfig{Enabled: true, MaxLines: 10}
+	admitted, held := e.AdmitMRs([]*MRInfo{makeMR("mr-a", "feature-a", "main")})
+	if len(admitted) != 1 || len(held) != 0 {
+		t.Errorf("AdmitMRs = %d admitted, %d held; want 1, 0", len(admitted), len(held))
+	}
+}
+
+func TestCheckSplitParts(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createMultiCommitBranch(t, workDir, "big", "a.txt", "b.txt", "c.txt")
+	createMultiCommitBranch(t, workDir, "part-1", "a.txt")
+	run(t, workDir, "git", "checkout", "-q", "-b", "part-2", "part-1")
+	for _, f := range []string{"b.txt", "c.txt"} {
+		writeFile(t, workDir, f, f+"\n")
+	}
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-q", "-m", "add b and c")
+	run(t, workDir, "git", "checkout", "-q", "main")
+	createMultiCommitBranch(t, workDir, "loose", "b.txt", "c.txt")
+
+	e := newTestEngineer(t, workDir, g)
+	mr := makeMR("mr-big", "big", "main")
+
+	if err := e.checkSplitParts(mr, []string{"part-1", "part-2"}, false); err != nil {
+		t.Errorf("stacked parts covering the change: %v", err)
+	}
+	if err := e.checkSplitParts(mr, []string{"part-1"}, false); err == nil {
+		t.Error("accepted a split into one part")
+	}
+	if err := e.checkSplitParts(mr, []string{"part-1", "loose"}, false); err == nil || !strings.Contains(err.Error(), "does not build on") {
+		t.Errorf("unstacked parts: %v, want rejected", err)
+	}
+	if err := e.checkSplitParts(mr, []string{"part-1", "missing"}, false); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing part: %v, want rejected", err)
+	}
+
+	// Parts that drop c.txt don't add up, unless the split is partial.
+	run(t, workDir, "git", "checkout", "-q", "-b", "part-2b", "part-1")
+	writeFile(t, workDir, "b.txt", "b.txt\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-q", "-m", "add b")
+	run(t, workDir, "git", "checkout", "-q", "main")
+	if err := e.checkSplitParts(mr, []string{"part-1", "part-2b"}, false); err == nil || !strings.Contains(err.Error(), "c.txt") {
+		t.Errorf("incomplete parts: %v, want c.txt reported", err)
+	}
+	if err := e.checkSplitParts(mr, []string{"part-1", "part-2b"}, true); err != nil {
+		t.Errorf("partial split: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package refinery
+
+import (
+	"fmt"
+	"time"
+)
+
+// starvingMR returns the ready MR that has waited longest among those that
+// are starving, with the reason, or nil. An MR starves once it has waited
+// StarvationAge or been retried StarvationRetries times: the retry penalty
+// in ScoreMR and conflicts with newer MRs can otherwise keep bumping it
+// forever. MRs waiting on a blocker can't be batched alone and are skipped.
+func (c *BatchConfig) starvingMR(ready []*MRInfo, now time.Time) (*MRInfo, string) {
+	var oldest *MRInfo
+	var reason string
+	for _, mr := range ready {
+		if mr.BlockedBy != "" {
+			continue
+		}
+		why := c.starving(mr, now)
+		if why == "" {
+			continue
+		}
+		if oldest == nil || mr.CreatedAt.Before(oldest.CreatedAt) {
+			oldest, reason = mr, why
+		}
+	}
+	return oldest, reason
+}
+
+// starving returns why mr is starving, or "" if it isn't.
+func (c *BatchConfig) starving(mr *MRInfo, now time.Time) string {
+	if c.StarvationRetries > 0 && mr.RetryCount >= c.StarvationRetries {
+		return fmt.Sprintf("%d retries", mr.RetryCount)
+	}
+	if c.StarvationAge > 0 && !mr.CreatedAt.IsZero() {
+		if waited := now.Sub(mr.CreatedAt); waited >= c.StarvationAge {
+			return fmt.Sprintf("queued %v", waited.Truncate(time.Minute))
+		}
+	}
+	return ""
+}
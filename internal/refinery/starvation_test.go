@@ -0,0 +1,59 @@
+package refinery
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestAssembleBatch_StarvingMRBatchedAlone(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.SetOutput(io.Discard)
+	now := time.Now()
+
+	fresh := func(id string) *MRInfo {
+		mr := makeMR(id, "branch-"+id, "main")
+		mr.CreatedAt = now.Add(-time.Hour)
+		return mr
+	}
+	bumped := fresh("bumped")
+	bumped.RetryCount = 3
+	old := fresh("old")
+	old.CreatedAt = now.Add(-30 * time.Hour)
+	older := fresh("older")
+	older.CreatedAt = now.Add(-40 * time.Hour)
+	older.BlockedBy = "elsewhere"
+
+	cfg := DefaultBatchConfig()
+	tests := []struct {
+		name  string
+		ready []*MRInfo
+		want  []string
+	}{
+		{"none starving", []*MRInfo{fresh("a"), fresh("b")}, []string{"a", "b"}},
+		{"retried too often", []*MRInfo{fresh("a"), bumped, fresh("b")}, []string{"bumped"}},
+		{"oldest starving wins", []*MRInfo{fresh("a"), bumped, old}, []string{"old"}},
+		{"blocked MR skipped", []*MRInfo{fresh("a"), older, fresh("b")}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stackedIDs(e.AssembleBatch(tt.ready, cfg))
+			if len(got) != len(tt.want) {
+				t.Fatalf("batch = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("batch = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	off := DefaultBatchConfig()
+	off.StarvationAge, off.StarvationRetries = 0, 0
+	if got := e.AssembleBatch([]*MRInfo{fresh("a"), bumped, old}, off); len(got) != 3 {
+		t.Errorf("with starvation checks off, batch = %v, want all 3", stackedIDs(got))
+	}
+}
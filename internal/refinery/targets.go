@@ -0,0 +1,233 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// ProcessTargets runs one batch from each target branch's queue (see
+// SplitQueues) concurrently and returns the results by target. Each
+// target's pipeline is independent: a red batch on one doesn't hold up
+// another.
+//
+// The pipelines never share a working tree. The rig's default branch is
+// processed in the refinery's clone (or its isolated worktree, see
+// isolateStacking); every other target has its own
+// worktree under .runtime/targets/<target>, kept across calls so
+// speculative stacks and build caches survive. Once a target has been
+// processed here, keep processing it here, as its worktree has its branch
+// checked out.
+//
+// Each target has its own merge slot: the default branch keeps the rig's
+// slot, and other targets use a file-lock slot of their own (see
+// BindTarget). A protected branch's Batch config, when set, replaces
+// batchCfg for its batches, as does the rig's otherwise.
+//
+// Changed config files are reloaded first (see ReloadConfig), rather than
+// between the concurrent batches.
+func (e *Engineer) ProcessTargets(ctx context.Context, mrs []*MRInfo, batchCfg *BatchConfig) map[string]*BatchResult {
+	e.reloadConfig(ctx)
+	ctx = context.WithValue(ctx, targetsRunKey{}, true)
+	targets, queues := SplitQueues(mrs)
+	results := make(map[string]*BatchResult, len(targets))
+	if len(targets) == 0 {
+		return results
+	}
+
+	if e.stateMu == nil {
+		e.stateMu = &sync.Mutex{}
+	}
+	out := &lockedWriter{w: e.output}
+	prevOutput := e.output
+	e.output = out
+	defer func() { e.output = prevOutput }()
+
+	engineers := make(map[string]*Engineer, len(targets))
+	for _, target := range targets {
+		te, err := e.targetEngineer(target)
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "[Targets] %s: %v\n", target, err)
+			results[target] = &BatchResult{Error: err}
+			continue
+		}
+		te.output = out
+		engineers[target] = te
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for target, te := range engineers {
+		wg.Add(1)
+		go func(target string, te *Engineer) {
+			defer wg.Done()
+			cfg := e.batchConfigFor(target, batchCfg)
+			batch := te.AssembleBatch(queues[target], cfg)
+			_, _ = fmt.Fprintf(out, "[Targets] %s: batch of %d in %s\n", target, len(batch), te.workDir)
+			result := te.ProcessBatch(ctx, batch, target, cfg)
+			mu.Lock()
+			results[target] = result
+			mu.Unlock()
+		}(target, te)
+	}
+	wg.Wait()
+	return results
+}
+
+// batchConfigFor returns the batch config for target: its protected
+// branch's own, if any, otherwise the rig's, otherwise def.
+func (e *Engineer) batchConfigFor(target string, def *BatchConfig) *BatchConfig {
+	if pb := e.config.ProtectedBranches[target]; pb != nil && pb.Batch != nil {
+		return pb.Batch
+	}
+	if e.config.Batch != nil {
+		return e.config.Batch
+	}
+	return def
+}
+
+// defaultBatchConfig returns the rig's batch config, or the defaults.
+func (e *Engineer) defaultBatchConfig() *BatchConfig {
+	if e.config.Batch != nil {
+		return e.config.Batch
+	}
+	return DefaultBatchConfig()
+}
+
+// targetEngineer returns the engineer that processes target's batches:
+// e itself for the rig's default branch, otherwise an engineer sharing e's
+// collaborators and hooks (see sharedEngineer) bound to target (see BindTarget), created on first use. The
+// binding is held for as long as e keeps the engineer.
+func (e *Engineer) targetEngineer(target string) (*Engineer, error) {
+	if target == e.rig.DefaultBranch() {
+		return e, nil
+	}
+	e.targetsMu.Lock()
+	defer e.targetsMu.Unlock()
+	if te := e.targets[target]; te != nil {
+		return te, nil
+	}
+
+	te := e.sharedEngineer()
+	if _, err := te.BindTarget(target); err != nil {
+		return nil, err
+	}
+
+	if e.targets == nil {
+		e.targets = make(map[string]*Engineer)
+	}
+	e.targets[target] = te
+	return te, nil
+}
+
+// targetWorktree creates a fresh worktree for target under
+// .runtime/targets, sharing the clone's object store. Worktree changes are
+// serialized across processes, as they rewrite the clone's worktree list.
+func (e *Engineer) targetWorktree(target string) (string, error) {
+	unlock, err := e.flock("worktrees.lock")
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	// Merge drivers live in the shared git config, so install them once
+	// from the clone rather than from each worktree.
+	e.ensureMergeDrivers()
+
+	// A branch can only be checked out in one worktree.
+	if branch, err := e.git.CurrentBranch(); err == nil && branch == target {
+		if err := e.git.Checkout(e.rig.DefaultBranch()); err != nil {
+			return "", fmt.Errorf("freeing %s in %s: %w", target, e.workDir, err)
+		}
+	}
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		return "", fmt.Errorf("fetching %s: %w", target, err)
+	}
+	dir := filepath.Join(e.rig.Path, ".runtime", "targets", target)
+	if err := e.freshWorktree(dir, "origin/"+target); err != nil {
+		return "", fmt.Errorf("worktree for %s: %w", target, err)
+	}
+	return dir, nil
+}
+
+// freshWorktree replaces whatever is at dir, worktree or not, with a new
+// worktree detached at ref. The caller holds worktrees.lock.
+func (e *Engineer) freshWorktree(dir, ref string) error {
+	_ = e.git.WorktreeRemove(dir, true)
+	_ = os.RemoveAll(dir)
+	_ = e.git.WorktreePrune()
+	return e.git.WorktreeAddDetached(dir, ref)
+}
+
+// isolateStacking moves the default branch's stacking and gate runs out of
+// the clone into a fresh worktree under .runtime/refinery-work when
+// merge_queue.isolated_worktree is set. A branch can only be checked out in
+// one worktree, so a clone on the default branch is detached at its commit
+// first; its files and index are left as they are. Once isolated, e stays
+// in the worktree for its lifetime.
+func (e *Engineer) isolateStacking() error {
+	target := e.rig.DefaultBranch()
+	if !e.config.IsolatedWorktree || e.isolated || (e.target != "" && e.target != target) {
+		return nil
+	}
+	unlock, err := e.flock("worktrees.lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	e.ensureMergeDrivers()
+	if branch, err := e.git.CurrentBranch(); err == nil && branch == target {
+		if err := e.git.CheckoutDetach(); err != nil {
+			return fmt.Errorf("freeing %s in %s: %w", target, e.workDir, err)
+		}
+	}
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		return fmt.Errorf("fetching %s: %w", target, err)
+	}
+	dir := filepath.Join(e.rig.Path, ".runtime", "refinery-work")
+	if err := e.freshWorktree(dir, "origin/"+target); err != nil {
+		return fmt.Errorf("stacking worktree: %w", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Stacking %s in %s\n", target, dir)
+	e.git = git.NewGit(dir)
+	e.workDir = dir
+	e.isolated = true
+	return nil
+}
+
+// lockState serializes read-modify-write updates of the rig's .runtime
+// state files among the engineers of concurrent target pipelines, in this
+// process and others. It returns the unlock function.
+func (e *Engineer) lockState() func() {
+	if e.stateMu == nil {
+		return func() {}
+	}
+	e.stateMu.Lock()
+	unlock, err := e.flock("state.lock")
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v (locking this process only)\n", err)
+		return e.stateMu.Unlock
+	}
+	return func() {
+		unlock()
+		e.stateMu.Unlock()
+	}
+}
+
+// lockedWriter serializes writes from concurrent target pipelines.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
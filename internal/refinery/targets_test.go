@@ -0,0 +1,235 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// createReleaseBranch pushes a release-1.x branch forked from main with a
+// commit of its own.
+func createReleaseBranch(t *testing.T, workDir string) {
+	t.Helper()
+	run(t, workDir, "git", "checkout", "-b", "release-1.x", "main")
+	writeFile(t, workDir, "VERSION", "1.0\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "release 1.x")
+	run(t, workDir, "git", "push", "-u", "origin", "release-1.x")
+	run(t, workDir, "git", "checkout", "main")
+}
+
+// createReleaseFeatureBranch creates a branch off release-1.x adding filename.
+func createReleaseFeatureBranch(t *testing.T, workDir, branchName, filename string) {
+	t.Helper()
+	run(t, workDir, "git", "checkout", "-b", branchName, "release-1.x")
+	writeFile(t, workDir, filename, branchName+"\n")
+	run(t, workDir, "git", "add", ".")
+	run(t, workDir, "git", "commit", "-m", "feat: add "+filename)
+	run(t, workDir, "git", "checkout", "main")
+}
+
+func TestProcessTargets_IndependentPipelines(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createReleaseBranch(t, workDir)
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+	createReleaseFeatureBranch(t, workDir, "fix-c", "c.txt")
+	createReleaseFeatureBranch(t, workDir, "fix-d", "FAIL_MARKER")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: failMarkerGateCmd()}}
+	mrs := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-c", "fix-c", "release-1.x"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-d", "fix-d", "release-1.x"),
+	}
+
+	results := e.ProcessTargets(context.Background(), mrs, DefaultBatchConfig())
+	if len(results) != 2 {
+		t.Fatalf("got results for %d targets, want 2", len(results))
+	}
+	main, release := results["main"], results["release-1.x"]
+	if main.Error != nil || release.Error != nil {
+		t.Fatalf("errors: main %v, release-1.x %v", main.Error, release.Error)
+	}
+	if got := stackedIDs(main.Merged); len(got) != 2 {
+		t.Errorf("main merged = %v, want [mr-a mr-b]", got)
+	}
+	// The culprit on release-1.x must not hold up main, and vice versa.
+	if got := stackedIDs(release.Merged); len(got) != 1 || got[0] != "mr-c" {
+		t.Errorf("release-1.x merged = %v, want [mr-c]", got)
+	}
+	if got := stackedIDs(release.Culprits); len(got) != 1 || got[0] != "mr-d" {
+		t.Errorf("release-1.x culprits = %v, want [mr-d]", got)
+	}
+
+	run(t, workDir, "git", "fetch", "origin")
+	files := run(t, workDir, "git", "ls-tree", "--name-only", "origin/main")
+	if !strings.Contains(files, "b.txt") || strings.Contains(files, "c.txt") {
+		t.Errorf("origin/main files = %q", files)
+	}
+	files = run(t, workDir, "git", "ls-tree", "--name-only", "origin/release-1.x")
+	if !strings.Contains(files, "c.txt") || strings.Contains(files, "a.txt") || strings.Contains(files, "FAIL_MARKER") {
+		t.Errorf("origin/release-1.x files = %q", files)
+	}
+
+	// release-1.x ran in its own worktree; main in the refinery's clone.
+	worktrees := run(t, workDir, "git", "worktree", "list")
+	if !strings.Contains(worktrees, filepath.Join(".runtime", "targets", "release-1.x")) {
+		t.Errorf("no release-1.x worktree in:\n%s", worktrees)
+	}
+	if branch := run(t, workDir, "git", "rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("clone is on %s, want main", branch)
+	}
+}
+
+func TestProcessTargets_FreesBranchCheckedOutInClone(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createReleaseBranch(t, workDir)
+	createReleaseFeatureBranch(t, workDir, "fix-c", "c.txt")
+	createReleaseFeatureBranch(t, workDir, "fix-d", "d.txt")
+	run(t, workDir, "git", "checkout", "release-1.x")
+
+	e := newTestEngineer(t, workDir, g)
+	mrs := []*MRInfo{makeMR("mr-c", "fix-c", "release-1.x"), makeMR("mr-d", "fix-d", "release-1.x")}
+	result := e.ProcessTargets(context.Background(), mrs, DefaultBatchConfig())["release-1.x"]
+	if result.Error != nil {
+		t.Fatalf("ProcessTargets: %v", result.Error)
+	}
+	if got := stackedIDs(result.Merged); len(got) != 2 {
+		t.Errorf("merged = %v, want [mr-c mr-d]", got)
+	}
+}
+
+func TestTargetEngineer_SharesDeps(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createReleaseBranch(t, workDir)
+
+	e := newTestEngineer(t, workDir, g)
+	var labelled []string
+	e.labelMR = func(id string, _, _ []string) error {
+		labelled = append(labelled, id)
+		return nil
+	}
+	te, err := e.targetEngineer("release-1.x")
+	if err != nil {
+		t.Fatalf("targetEngineer: %v", err)
+	}
+	if te.workDir == e.workDir {
+		t.Errorf("target engineer works in the clone %s, want its own worktree", te.workDir)
+	}
+	if te.config != e.config || te.output != e.output || te.metrics != e.metrics {
+		t.Error("target engineer doesn't share its parent's config, output and metrics")
+	}
+	if err := te.labelMR("mr-x", nil, nil); err != nil || len(labelled) != 1 {
+		t.Errorf("target engineer's labelMR hook isn't its parent's: labelled %v, err %v", labelled, err)
+	}
+}
+
+func TestSharedEngineer_CopiesSharedFields(t *testing.T) {
+	// Fields that belong to a single engineer; every other one is shared.
+	perEngineer := map[string]bool{
+		"loadedConfig": true, "baseGates": true, "liveGates": true,
+		"pushSlot": true, "leaseMu": true, "leases": true,
+		"acceptanceMu": true, "acceptance": true,
+		"prewarmMu": true, "prewarm": true, "pipelineMu": true, "pipeline": true,
+		"energyMu": true, "energyFor": true, "energyConfigured": true,
+		"quarantineMu": true, "baselineMu": true,
+		"stackTipsMu": true, "stackTips": true, "stackBases": true, "stackPicks": true,
+		"cycleMu": true, "cycleNoted": true,
+		"mergeDriversInstalled": true, "mergeTreeUnsupported": true, "isolated": true,
+		"signCommits": true, "mergeDriverMarker": true,
+		"target": true, "targetsMu": true, "targets": true,
+	}
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.predictor = predictorFunc(func(*BatchFeatures) (float64, error) { return 1, nil })
+	e.energy = &stepMeter{}
+	e.stateMu = &sync.Mutex{}
+	te := e.sharedEngineer()
+
+	parent, shared := reflect.ValueOf(e).Elem(), reflect.ValueOf(te).Elem()
+	for i := 0; i < parent.NumField(); i++ {
+		name := parent.Type().Field(i).Name
+		if perEngineer[name] {
+			continue
+		}
+		if parent.Field(i).IsZero() {
+			t.Errorf("%s is unset on the parent; set it above so its sharing is checked", name)
+			continue
+		}
+		if shared.Field(i).IsZero() {
+			t.Errorf("sharedEngineer drops %s", name)
+		}
+	}
+}
+
+func TestProcessBatch_IsolatedWorktree(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+	head := run(t, workDir, "git", "rev-parse", "HEAD")
+	// An agent's uncommitted edit in the clone.
+	writeFile(t, workDir, "README.md", "# Edited\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.config.IsolatedWorktree = true
+	mrs := []*MRInfo{makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")}
+	result := e.ProcessBatch(context.Background(), mrs, "main", DefaultBatchConfig())
+	if result.Error != nil {
+		t.Fatalf("ProcessBatch: %v", result.Error)
+	}
+	if got := stackedIDs(result.Merged); len(got) != 2 {
+		t.Errorf("merged = %v, want [mr-a mr-b]", got)
+	}
+	run(t, workDir, "git", "fetch", "origin")
+	if files := run(t, workDir, "git", "ls-tree", "--name-only", "origin/main"); !strings.Contains(files, "b.txt") {
+		t.Errorf("origin/main files = %q", files)
+	}
+
+	// The batch was stacked in .runtime/refinery-work; the clone's commit,
+	// files and edit are untouched.
+	if e.workDir != filepath.Join(workDir, ".runtime", "refinery-work") {
+		t.Errorf("stacked in %s", e.workDir)
+	}
+	if got := run(t, workDir, "git", "rev-parse", "HEAD"); got != head {
+		t.Errorf("clone moved from %s to %s", head, got)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "a.txt")); err == nil {
+		t.Error("stacked file a.txt appeared in the clone")
+	}
+	if got, _ := os.ReadFile(filepath.Join(workDir, "README.md")); string(got) != "# Edited\n" {
+		t.Errorf("clone's README.md = %q, want the agent's edit", got)
+	}
+}
+
+func TestBatchConfigFor(t *testing.T) {
+	e := newTestEngineer(t, t.TempDir(), nil)
+	release := &BatchConfig{MaxBatchSize: 2}
+	e.config.ProtectedBranches = map[string]*ProtectedBranchConfig{
+		"release-1.x": {Batch: release},
+		"feature-x":   {},
+	}
+	def := DefaultBatchConfig()
+	if got := e.batchConfigFor("release-1.x", def); got != release {
+		t.Errorf("release-1.x config = %+v, want its own", got)
+	}
+	if got := e.batchConfigFor("feature-x", def); got != def {
+		t.Errorf("feature-x config = %+v, want the default", got)
+	}
+	if got := e.batchConfigFor("main", def); got != def {
+		t.Errorf("main config = %+v, want the default", got)
+	}
+}
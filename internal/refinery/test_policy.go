@@ -0,0 +1,346 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// TestPolicyConfig configures test admission: MRs that change code without
+// touching tests are held out of batches, and the originating agent is
+// handed a molecule to write tests for the diff.
+type TestPolicyConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// TestPatterns match test files. A pattern without a slash matches the
+	// file's base name, "dir/**" matches anything under a directory named
+	// dir, and other patterns match the full path.
+	// Default: DefaultTestPatterns.
+	TestPatterns []string `json:"test_patterns,omitempty"`
+
+	// IgnorePatterns match files that count as neither code nor tests
+	// (docs, lockfiles, CI config). Same syntax as TestPatterns.
+	// Default: DefaultTestIgnorePatterns.
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+
+	// MinCodeLines is the number of changed code lines below which an MR is
+	// admitted without tests. Default: 1 (any code change needs tests).
+	MinCodeLines int `json:"min_code_lines,omitempty"`
+
+	// Formula is poured to the originating agent on the write-tests task.
+	// Default: mol-write-tests.
+	Formula string `json:"formula,omitempty"`
+}
+
+// DefaultTestPatterns match test files in common layouts.
+var DefaultTestPatterns = []string{
+	"*_test.go", "test_*.py", "*_test.py", "*.test.*", "*.spec.*", "*_spec.rb",
+	"test/**", "tests/**", "__tests__/**", "spec/**", "testdata/**",
+}
+
+// DefaultTestIgnorePatterns match files that need no tests.
+var DefaultTestIgnorePatterns = []string{
+	"*.md", "*.txt", "*.rst", "docs/**", ".github/**",
+	"go.mod", "go.sum", "*.lock", "package-lock.json", "LICENSE*",
+}
+
+// TestCoverage classifies the files an MR changes.
+type TestCoverage struct {
+	CodeFiles []string `json:"code_files,omitempty"`
+	TestFiles []string `json:"test_files,omitempty"`
+	CodeLines int      `json:"code_lines"` // Added + deleted lines across CodeFiles
+}
+
+// NeedsTests reports whether the diff changes at least minLines lines of
+// code and no tests.
+func (c TestCoverage) NeedsTests(minLines int) bool {
+	if minLines < 1 {
+		minLines = 1
+	}
+	return len(c.TestFiles) == 0 && len(c.CodeFiles) > 0 && c.CodeLines >= minLines
+}
+
+// TestRequest records a write-tests task raised for an MR. Each MR gets at
+// most one: once the task closes the MR is admitted whatever its diff, so
+// an agent that judges tests unwarranted can say so by closing the task.
+type TestRequest struct {
+	MR          string       `json:"mr"`
+	Task        string       `json:"task"`
+	Worker      string       `json:"worker,omitempty"`
+	Slung       bool         `json:"slung"` // Formula reached the worker; false leaves the task for dispatch
+	Coverage    TestCoverage `json:"coverage"`
+	RequestedAt time.Time    `json:"requested_at"`
+}
+
+func (c *TestPolicyConfig) testPatterns() []string {
+	if len(c.TestPatterns) > 0 {
+		return c.TestPatterns
+	}
+	return DefaultTestPatterns
+}
+
+func (c *TestPolicyConfig) ignorePatterns() []string {
+	if c.IgnorePatterns != nil {
+		return c.IgnorePatterns
+	}
+	return DefaultTestIgnorePatterns
+}
+
+func (c *TestPolicyConfig) formula() string {
+	if c.Formula != "" {
+		return c.Formula
+	}
+	return constants.MolWriteTests
+}
+
+// matchPathPattern reports whether file matches a test policy pattern.
+func matchPathPattern(pattern, file string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return strings.HasPrefix(file, dir+"/") || strings.Contains(file, "/"+dir+"/")
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(file))
+		return ok
+	}
+	ok, _ := path.Match(pattern, file)
+	return ok
+}
+
+func matchAnyPattern(patterns []string, file string) bool {
+	for _, p := range patterns {
+		if matchPathPattern(p, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyDiff splits a diff into code and test files. Test patterns win
+// over ignore patterns, so tests/README.md counts as a test change.
+func classifyDiff(stats []git.DiffStat, cfg *TestPolicyConfig) TestCoverage {
+	var cov TestCoverage
+	for _, st := range stats {
+		switch {
+		case matchAnyPattern(cfg.testPatterns(), st.Path):
+			cov.TestFiles = append(cov.TestFiles, st.Path)
+		case st.Binary || matchAnyPattern(cfg.ignorePatterns(), st.Path):
+		default:
+			cov.CodeFiles = append(cov.CodeFiles, st.Path)
+			cov.CodeLines += st.Added + st.Deleted
+		}
+	}
+	return cov
+}
+
+func (e *Engineer) testRequestsPath() string {
+	return filepath.Join(e.rig.Path, ".runtime", "test-requests.json")
+}
+
+// TestRequests returns the write-tests tasks raised so far, keyed by MR ID.
+func (e *Engineer) TestRequests() (map[string]*TestRequest, error) {
+	data, err := os.ReadFile(e.testRequestsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]*TestRequest), nil
+		}
+		return nil, err
+	}
+	reqs := make(map[string]*TestRequest)
+	if err := json.Unmarshal(data, &reqs); err != nil {
+		return nil, fmt.Errorf("parsing test requests: %w", err)
+	}
+	return reqs, nil
+}
+
+// branchHead returns the ref to read branch from. The local branch is
+// preferred, as batches stack from it.
+func (e *Engineer) branchHead(branch string) string {
+	if exists, err := e.git.BranchExists(branch); err != nil || !exists {
+		return "origin/" + branch
+	}
+	return branch
+}
+
+// mrDiffStats returns the changes on mr's branch relative to its target.
+func (e *Engineer) mrDiffStats(mr *MRInfo) ([]git.DiffStat, error) {
+	target := mr.Target
+	if target == "" {
+		target = e.rig.DefaultBranch()
+	}
+	return e.git.DiffNumstat("origin/"+target, e.branchHead(mr.Branch))
+}
+
+// mrDiffCoverage classifies the changes on mr's branch relative to its
+// target.
+func (e *Engineer) mrDiffCoverage(mr *MRInfo, cfg *TestPolicyConfig) (TestCoverage, error) {
+	stats, err := e.mrDiffStats(mr)
+	if err != nil {
+		return TestCoverage{}, err
+	}
+	return classifyDiff(stats, cfg), nil
+}
+
+// AdmitMRs applies the admission policies to ready MRs before batching.
+// Hotfix MRs are first fanned out to their release branches (see
+// enqueueHotfixes), which holds nothing. MRs whose source issue isn't approved are held first (see
+// admitApproved), so no work is spent on them; then MRs too far behind their
+// target are bounced to their authors to rebase (see admitFresh); then MRs over the admission
+// limits or touching protected paths are held for human review (see
+// admitLimited); then oversized MRs are held
+// for splitting (see admitSized), so no tests are written for a change
+// about to be broken up; then MRs with assets over the asset policy's
+// limits are held (see admitAssets); the rest go through the test policy
+// (see admitTested). Each held MR has HeldFor set.
+func (e *Engineer) AdmitMRs(ready []*MRInfo) (admitted, held []*MRInfo) {
+	e.enqueueHotfixes(ready)
+	ready, heldForApproval := e.admitApproved(ready)
+	ready, heldForStale := e.admitFresh(ready)
+	ready, heldForReview := e.admitLimited(ready)
+	ready, heldForSplit := e.admitSized(ready)
+	ready, heldForAssets := e.admitAssets(ready)
+	admitted, heldForTests := e.admitTested(ready)
+	held = append(append(append(heldForApproval, heldForStale...), heldForReview...), heldForSplit...)
+	return admitted, append(append(held, heldForAssets...), heldForTests...)
+}
+
+// admitTested applies the test policy. MRs whose diff changes code but no
+// tests are held: a write-tests task is created, the MR is blocked on it,
+// and the policy's formula is slung to the MR's worker on that task. When
+// the task closes the MR unblocks and is admitted on its next pass.
+//
+// The policy fails open: MRs whose diff can't be read, or whose task
+// can't be created, are admitted with a warning.
+func (e *Engineer) admitTested(ready []*MRInfo) (admitted, held []*MRInfo) {
+	cfg := e.config.TestPolicy
+	if cfg == nil || !cfg.Enabled || len(ready) == 0 {
+		return ready, nil
+	}
+	reqs, err := e.TestRequests()
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[TestPolicy] Warning: %v (admitting all MRs)\n", err)
+		return ready, nil
+	}
+
+	changed := false
+	for _, mr := range ready {
+		if req, ok := reqs[mr.ID]; ok {
+			_, _ = fmt.Fprintf(e.output, "[TestPolicy] MR %s: test task %s closed, admitting\n", mr.ID, req.Task)
+			e.recordProgress(StageAdmitted, "test task "+req.Task+" closed", "", mr)
+			admitted = append(admitted, mr)
+			continue
+		}
+		cov, err := e.mrDiffCoverage(mr, cfg)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[TestPolicy] Warning: MR %s: reading diff: %v (admitting)\n", mr.ID, err)
+			admitted = append(admitted, mr)
+			continue
+		}
+		if !cov.NeedsTests(cfg.MinCodeLines) {
+			admitted = append(admitted, mr)
+			continue
+		}
+		req, err := e.requestTests(mr, cov, cfg)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[TestPolicy] Warning: MR %s: %v (admitting)\n", mr.ID, err)
+			admitted = append(admitted, mr)
+			continue
+		}
+		reqs[mr.ID] = req
+		changed = true
+		mr.BlockedBy = req.Task
+		mr.HeldFor = HeldForTests
+		held = append(held, mr)
+		e.recordProgress(StageHeld, "waiting on test task "+req.Task, "", mr)
+	}
+	if changed {
+		if err := util.EnsureDirAndWriteJSON(e.testRequestsPath(), reqs); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[TestPolicy] Warning: saving test requests: %v\n", err)
+		}
+	}
+	return admitted, held
+}
+
+// requestTests creates the write-tests task for mr, blocks mr on it and
+// slings the policy formula to mr's worker. A failed sling leaves the task
+// open for dispatch like any other gt:task.
+func (e *Engineer) requestTests(mr *MRInfo, cov TestCoverage, cfg *TestPolicyConfig) (*TestRequest, error) {
+	description := fmt.Sprintf(`Write tests for the changes on branch %s
+
+## Metadata
+- Original MR: %s
+- Branch: %s
+- Target: %s
+- Original issue: %s
+- Code lines changed: %d
+
+## Changed code
+- %s
+
+## Instructions
+The refinery holds this MR out of the merge queue because it changes code
+without changing any tests. Add tests covering the diff to the branch and
+push it, then close this task. If the change genuinely needs no tests,
+close this task with a reason saying why; the MR is then admitted as is.`,
+		mr.Branch,
+		mr.ID,
+		mr.Branch,
+		mr.Target,
+		mr.SourceIssue,
+		cov.CodeLines,
+		strings.Join(cov.CodeFiles, "\n- "),
+	)
+
+	title := mr.Title
+	if title == "" {
+		title = mr.Branch
+	}
+	task, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Write tests: " + title,
+		Labels:      []string{"gt:task"},
+		Priority:    mr.Priority,
+		Description: description,
+		Actor:       e.rig.Name + "/refinery",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating test task: %w", err)
+	}
+	if err := e.beads.AddDependency(mr.ID, task.ID); err != nil {
+		return nil, fmt.Errorf("blocking MR on test task %s: %w", task.ID, err)
+	}
+
+	req := &TestRequest{
+		MR:          mr.ID,
+		Task:        task.ID,
+		Worker:      mr.Worker,
+		Coverage:    cov,
+		RequestedAt: time.Now().UTC(),
+	}
+	if polecat := strings.TrimPrefix(mr.Worker, "polecats/"); polecat != "" {
+		target := fmt.Sprintf("%s/%s", e.rig.Name, polecat)
+		slingCmd := exec.Command("gt", "sling", cfg.formula(), target, "--on", task.ID, //nolint:gosec // G204: formula is from trusted rig config
+			"--var", "task="+task.ID,
+			"--var", "original_mr="+mr.ID,
+			"--var", "branch="+mr.Branch,
+			"--var", "base_branch="+mr.Target)
+		slingCmd.Dir = e.workDir
+		if out, err := slingCmd.CombinedOutput(); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[TestPolicy] Warning: sling %s to %s: %v: %s (task left for dispatch)\n",
+				cfg.formula(), target, err, strings.TrimSpace(string(out)))
+		} else {
+			req.Slung = true
+		}
+	}
+	_, _ = fmt.Fprintf(e.output, "[TestPolicy] MR %s held: %d code line(s) changed without tests, blocked on %s\n",
+		mr.ID, cov.CodeLines, task.ID)
+	return req, nil
+}
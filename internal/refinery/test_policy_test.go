@@ -0,0 +1,138 @@
+package refinery
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+func TestMatchPathPattern(t *testing.T) {
+	tests := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"*_test.go", "internal/refinery/batch_test.go", true},
+		{"*_test.go", "internal/refinery/batch.go", false},
+		{"tests/**", "tests/unit/a.py", true},
+		{"tests/**", "pkg/tests/a.py", true},
+		{"tests/**", "pkg/contests/a.py", false},
+		{"docs/*.md", "docs/guide.md", true},
+		{"docs/*.md", "pkg/docs/guide.md", false},
+	}
+	for _, tt := range tests {
+		if got := matchPathPattern(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("matchPathPattern(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyDiff(t *testing.T) {
+	cfg := &TestPolicyConfig{Enabled: true}
+	stats := []git.DiffStat{
+		{Path: "internal/refinery/batch.go", Added: 10, Deleted: 2},
+		{Path: "README.md", Added: 50},
+		{Path: "assets/logo.png", Binary: true},
+	}
+	cov := classifyDiff(stats, cfg)
+	if len(cov.CodeFiles) != 1 || cov.CodeLines != 12 || len(cov.TestFiles) != 0 {
+		t.Fatalf("classifyDiff = %+v, want one code file with 12 lines", cov)
+	}
+	if !cov.NeedsTests(cfg.MinCodeLines) {
+		t.Error("code change without tests should need tests")
+	}
+	if cov.NeedsTests(20) {
+		t.Error("change below min_code_lines should not need tests")
+	}
+
+	stats = append(stats, git.DiffStat{Path: "internal/refinery/batch_test.go", Added: 30})
+	if cov := classifyDiff(stats, cfg); cov.NeedsTests(0) {
+		t.Errorf("diff with test changes should not need tests: %+v", cov)
+	}
+
+	// Docs-only changes need no tests.
+	if cov := classifyDiff([]git.DiffStat{{Path: "docs/guide.md", Added: 5}}, cfg); cov.NeedsTests(0) {
+		t.Errorf("docs-only diff should not need tests: %+v", cov)
+	}
+
+	// Custom patterns replace the defaults.
+	custom := &TestPolicyConfig{Enabled: true, TestPatterns: []string{"checks/**"}, IgnorePatterns: []string{}}
+	cov = classifyDiff([]git.DiffStat{{Path: "README.md", Added: 1}, {Path: "checks/a.sh", Added: 1}}, custom)
+	if len(cov.CodeFiles) != 1 || len(cov.TestFiles) != 1 {
+		t.Errorf("custom patterns: %+v, want README.md as code and checks/a.sh as test", cov)
+	}
+}
+
+func TestEngineer_LoadConfig_TestPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := []byte(`{"merge_queue": {"test_policy": {"enabled": true, "min_code_lines": 5, "test_patterns": ["*_test.go"]}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	tp := e.config.TestPolicy
+	if tp == nil || !tp.Enabled || tp.MinCodeLines != 5 || len(tp.TestPatterns) != 1 {
+		t.Fatalf("TestPolicy = %+v", tp)
+	}
+	if tp.formula() != "mol-write-tests" {
+		t.Errorf("formula() = %q, want mol-write-tests", tp.formula())
+	}
+
+	data = []byte(`{"merge_queue": {"test_policy": {"enabled": true, "min_code_lines": -1}}}`)
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir}).LoadConfig(); err == nil {
+		t.Error("expected error for negative min_code_lines")
+	}
+}
+
+func TestAdmitMRs_PolicyDisabled(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	ready := []*MRInfo{makeMR("mr-1", "polecat/a", "main")}
+	admitted, held := e.AdmitMRs(ready)
+	if len(admitted) != 1 || len(held) != 0 {
+		t.Errorf("AdmitMRs with no policy = %d admitted, %d held; want 1, 0", len(admitted), len(held))
+	}
+}
+
+func TestAdmitMRs_AdmitsAfterRequest(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	var out bytes.Buffer
+	e.SetOutput(&out)
+	e.config.TestPolicy = &TestPolicyConfig{Enabled: true}
+
+	// An MR whose write-tests task already closed is admitted without
+	// re-reading its diff, so the policy can't loop on it.
+	reqs := map[string]*TestRequest{"mr-1": {MR: "mr-1", Task: "gt-task1"}}
+	if err := util.EnsureDirAndWriteJSON(e.testRequestsPath(), reqs); err != nil {
+		t.Fatal(err)
+	}
+	admitted, held := e.AdmitMRs([]*MRInfo{makeMR("mr-1", "polecat/a", "main")})
+	if len(admitted) != 1 || len(held) != 0 {
+		t.Errorf("AdmitMRs = %d admitted, %d held; want 1, 0\n%s", len(admitted), len(held), out.String())
+	}
+}
+
+func TestAdmitMRs_FailsOpenOnDiffError(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	var out bytes.Buffer
+	e.SetOutput(&out)
+	e.config.TestPolicy = &TestPolicyConfig{Enabled: true}
+	e.git = git.NewGit(t.TempDir()) // Not a repo: the diff can't be read
+
+	admitted, held := e.AdmitMRs([]*MRInfo{makeMR("mr-1", "polecat/a", "main")})
+	if len(admitted) != 1 || len(held) != 0 {
+		t.Errorf("AdmitMRs = %d admitted, %d held; want 1, 0", len(admitted), len(held))
+	}
+	if !bytes.Contains(out.Bytes(), []byte("reading diff")) {
+		t.Errorf("expected diff warning, got %q", out.String())
+	}
+}
@@ -1,7 +1,10 @@
 package refinery
 
 import (
+	"bytes"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/steveyegge/gastown/internal/testutil"
@@ -12,3 +15,29 @@ func TestMain(m *testing.M) {
 	testutil.TerminateDoltContainer()
 	os.Exit(code)
 }
+
+// isolateTownEvents runs the test from an empty town in a temp dir, so the
+// events it logs land there rather than in a town found above the package
+// directory. It returns the town root.
+func isolateTownEvents(t *testing.T) string {
+	t.Helper()
+	town := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(town, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(town)
+	return town
+}
+
+// testOutput returns the buffer e's output was set to (see SetOutput).
+func testOutput(e *Engineer) *bytes.Buffer {
+	var w io.Writer = e.output
+	for {
+		lw, ok := w.(*lockedWriter)
+		if !ok {
+			break
+		}
+		w = lw.w
+	}
+	return w.(*bytes.Buffer)
+}
@@ -0,0 +1,142 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// runMergeTrain gates every prefix of stacked in parallel and returns the
+// length of the longest prefix that passed (0 if none did).
+//
+// The work directory holds the full stack, and prefix k ends where its last
+// MR landed (see stackPrefix): prefixes shorter than the full stack are
+// checked out detached in temporary worktrees under .runtime/trains/<batchID>, and
+// the full stack gates in place. When a prefix passes, shorter prefixes
+// still running are cancelled, as they can no longer be the one landed.
+//
+// When its failure may be flaky, or its gates only timed out, the prefix
+// just past the longest passing one is regated up to MaxRetries times
+// before its last MR is blamed (see flakyRetries).
+func (e *Engineer) runMergeTrain(ctx context.Context, batchID string, stacked []*MRInfo, batchCfg *BatchConfig) (int, error) {
+	n := len(stacked)
+	root := filepath.Join(e.rig.Path, ".runtime", "trains", batchID)
+	dirs := make([]string, n+1) // dirs[k] holds prefix k
+	dirs[n] = e.workDir
+	defer e.removeTrainWorktrees(root, dirs[1:n])
+
+	for k := 1; k < n; k++ {
+		dir := filepath.Join(root, fmt.Sprintf("prefix-%d", k))
+		if err := e.git.WorktreeAddDetached(dir, e.stackPrefix(stacked, k)); err != nil {
+			return 0, fmt.Errorf("worktree for prefix %d: %w", k, err)
+		}
+		dirs[k] = dir
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Train] Gating %d prefixes in parallel...\n", n)
+	passed := make([]bool, n+1)
+	results := make([]ProcessResult, n+1)
+	cancels := make([]context.CancelFunc, n+1)
+	ctxs := make([]context.Context, n+1)
+	for k := 1; k <= n; k++ {
+		ctxs[k], cancels[k] = context.WithCancel(ctx)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for k := 1; k <= n; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			defer cancels[k]()
+			r := e.runBatchGatesIn(ctxs[k], dirs[k], stacked[:k])
+			mu.Lock()
+			defer mu.Unlock()
+			if ctxs[k].Err() != nil && !r.Success {
+				_, _ = fmt.Fprintf(e.output, "[Train] Prefix %d/%d: cancelled (longer prefix passed)\n", k, n)
+				return
+			}
+			if !r.Success {
+				_, _ = fmt.Fprintf(e.output, "[Train] Prefix %d/%d %v: FAILED - %s\n", k, n, mrIDs(stacked[:k]), r.Error)
+				results[k] = r
+				return
+			}
+			_, _ = fmt.Fprintf(e.output, "[Train] Prefix %d/%d %v: passed\n", k, n, mrIDs(stacked[:k]))
+			passed[k] = true
+			for j := 1; j < k; j++ {
+				cancels[j]()
+			}
+		}(k)
+	}
+	wg.Wait()
+
+	best := 0
+	for k := n; k > 0; k-- {
+		if passed[k] {
+			best = k
+			break
+		}
+	}
+
+	if best < n {
+		next := best + 1
+		r := results[next]
+		for attempt := 1; attempt <= e.flakyRetries(batchCfg, stacked[:next], r); attempt++ {
+			_, _ = fmt.Fprintf(e.output, "[Train] Retrying prefix %d/%d (flaky test check %d)...\n", next, n, attempt)
+			if !waitRetry(ctx, batchCfg, attempt) {
+				return 0, fmt.Errorf("retry: %w", ctx.Err())
+			}
+			if r = e.runBatchGatesIn(ctx, dirs[next], stacked[:next]); r.Success {
+				_, _ = fmt.Fprintf(e.output, "[Train] Prefix %d/%d passed on retry (was flaky)\n", next, n)
+				best = next
+				break
+			}
+		}
+	}
+	return best, nil
+}
+
+// removeTrainWorktrees removes a merge train's worktrees. Failures are
+// logged: a leftover worktree is pruned once its directory is gone.
+func (e *Engineer) removeTrainWorktrees(root string, dirs []string) {
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if err := e.git.WorktreeRemove(dir, true); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Train] Warning: remove worktree %s: %v\n", dir, err)
+		}
+	}
+	_ = os.RemoveAll(root)
+	_ = e.git.WorktreePrune()
+}
+
+// landMergeTrain lands the first passed MRs of stacked. The MR right after
+// them broke the next prefix and is the culprit; any MRs after it were
+// never gated on a green base and stay queued for the next batch.
+func (e *Engineer) landMergeTrain(ctx context.Context, stacked []*MRInfo, passed int, target string, result *BatchResult) *BatchResult {
+	n := len(stacked)
+	if passed < n {
+		result.Culprits = []*MRInfo{stacked[passed]}
+		if deferred := stacked[passed+1:]; len(deferred) > 0 {
+			_, _ = fmt.Fprintf(e.output, "[Train] Deferring %v to the next batch\n", mrIDs(deferred))
+			result.Deferred = deferred
+		}
+	}
+	if passed == 0 {
+		_, _ = fmt.Fprintln(e.output, "[Train] No prefix passed, nothing to land")
+		if err := e.git.ResetHard("origin/" + target); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Train] Warning: failed to reset %s: %v\n", target, err)
+		}
+		return result
+	}
+	if passed < n {
+		if err := e.git.ResetHard(e.stackPrefix(stacked, passed)); err != nil {
+			result.Error = fmt.Errorf("reset to prefix %d: %w", passed, err)
+			return result
+		}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Train] Landing prefix %d/%d\n", passed, n)
+	return e.fastForwardBatch(ctx, stacked[:passed], target, result)
+}
@@ -0,0 +1,120 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessBatch_MergeTrain_AllPass(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.SetOutput(io.Discard) // Prefixes gate concurrently
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+	}
+	result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5, MergeTrain: true})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if len(result.Merged) != 3 || len(result.Culprits) != 0 {
+		t.Fatalf("merged=%v culprits=%v, want all 3 merged", stackedIDs(result.Merged), stackedIDs(result.Culprits))
+	}
+	assertTrainCleanedUp(t, workDir)
+}
+
+func TestProcessBatch_MergeTrain_LandsLongestPassingPrefix(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "hello a\n")
+	createFeatureBranch(t, workDir, "feature-b", "FAIL_MARKER", "this causes test failure\n")
+	createFeatureBranch(t, workDir, "feature-c", "c.txt", "hello c\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.SetOutput(io.Discard)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+		makeMR("mr-c", "feature-c", "main"),
+	}
+	result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5, MergeTrain: true})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if ids := stackedIDs(result.Merged); len(ids) != 1 || ids[0] != "mr-a" {
+		t.Errorf("merged = %v, want [mr-a]", ids)
+	}
+	if ids := stackedIDs(result.Culprits); len(ids) != 1 || ids[0] != "mr-b" {
+		t.Errorf("culprits = %v, want [mr-b]", ids)
+	}
+
+	// mr-c was never gated on a green base, so it is neither merged nor blamed.
+	verifyDir := filepath.Join(filepath.Dir(workDir), "verify")
+	run(t, filepath.Dir(workDir), "git", "clone", filepath.Join(filepath.Dir(workDir), "origin.git"), verifyDir)
+	if _, err := os.Stat(filepath.Join(verifyDir, "a.txt")); err != nil {
+		t.Errorf("expected a.txt on origin: %v", err)
+	}
+	for _, f := range []string{"FAIL_MARKER", "c.txt"} {
+		if _, err := os.Stat(filepath.Join(verifyDir, f)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be on origin", f)
+		}
+	}
+	assertTrainCleanedUp(t, workDir)
+}
+
+func TestProcessBatch_MergeTrain_FirstMRFails(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+
+	createFeatureBranch(t, workDir, "feature-a", "FAIL_MARKER", "this causes test failure\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "hello b\n")
+
+	e := newTestEngineer(t, workDir, g)
+	e.SetOutput(io.Discard)
+	e.config.Gates = map[string]*GateConfig{"check": {Cmd: failMarkerGateCmd()}}
+
+	batch := []*MRInfo{
+		makeMR("mr-a", "feature-a", "main"),
+		makeMR("mr-b", "feature-b", "main"),
+	}
+	result := e.ProcessBatch(context.Background(), batch, "main", &BatchConfig{MaxBatchSize: 5, MergeTrain: true, RetryBatchOnFlaky: true})
+	if len(result.Merged) != 0 || result.MergeCommit != "" {
+		t.Errorf("merged = %v, want none", stackedIDs(result.Merged))
+	}
+	if ids := stackedIDs(result.Culprits); len(ids) != 1 || ids[0] != "mr-a" {
+		t.Errorf("culprits = %v, want [mr-a]", ids)
+	}
+	if head, origin := run(t, workDir, "git", "rev-parse", "HEAD"), run(t, workDir, "git", "rev-parse", "origin/main"); head != origin {
+		t.Errorf("work dir should be reset to origin/main after a failed train")
+	}
+	assertTrainCleanedUp(t, workDir)
+}
+
+func assertTrainCleanedUp(t *testing.T, workDir string) {
+	t.Helper()
+	if out := run(t, workDir, "git", "worktree", "list"); strings.Count(out, "\n") != 0 {
+		t.Errorf("train worktrees left behind:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, ".runtime", "trains")); err == nil {
+		entries, _ := os.ReadDir(filepath.Join(workDir, ".runtime", "trains"))
+		if len(entries) != 0 {
+			t.Errorf("train directories left behind: %d", len(entries))
+		}
+	}
+}
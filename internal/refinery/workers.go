@@ -0,0 +1,150 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/lock"
+)
+
+// ErrTargetBusy is returned by BindTarget when another Engineer, in this
+// process or another, is already bound to the target.
+var ErrTargetBusy = errors.New("another engineer is bound to this target")
+
+// BindTarget dedicates e to target's queue, so several Engineers (separate
+// refinery workers, say) can work a rig's target branches side by side:
+//
+//   - Only one Engineer is bound to a target at a time, across processes.
+//   - ListReadyMRs returns only MRs targeting target.
+//   - For a target other than the rig's default branch, e works in its own
+//     worktree under .runtime/targets/<target>, sharing the clone's object
+//     store, and pushes under a merge slot of the target's own.
+//   - Updates to the rig's .runtime state files are serialized with the
+//     other Engineers (see lockState).
+//
+// The default branch keeps the clone and the rig's merge slot, as before.
+// The returned func releases the binding.
+func (e *Engineer) BindTarget(target string) (func(), error) {
+	release, err := e.tryFlock(filepath.Join("engineers", target+".lock"))
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return nil, fmt.Errorf("%s: %w", target, ErrTargetBusy)
+	}
+	if target != e.rig.DefaultBranch() {
+		dir, err := e.targetWorktree(target)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		e.git = git.NewGit(dir)
+		e.workDir = dir
+		e.targetMergeSlot(target).install(e)
+	}
+	if e.stateMu == nil {
+		e.stateMu = &sync.Mutex{}
+	}
+	e.target = target
+	return release, nil
+}
+
+// boundTo reports whether mr is in the queue e works (see BindTarget).
+func (e *Engineer) boundTo(mr *MRInfo) bool {
+	if e.target == "" {
+		return true
+	}
+	target := mr.Target
+	if target == "" {
+		target = e.rig.DefaultBranch()
+	}
+	return target == e.target
+}
+
+// locksDir holds the rig's refinery file locks.
+func (e *Engineer) locksDir() string {
+	return filepath.Join(e.rig.Path, ".runtime", "locks")
+}
+
+// flock takes the rig file lock name, waiting for it, and returns the
+// unlock function.
+func (e *Engineer) flock(name string) (func(), error) {
+	path := filepath.Join(e.locksDir(), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating lock dir: %w", err)
+	}
+	return lock.FlockAcquire(path)
+}
+
+// tryFlock takes the rig file lock name without waiting. It returns a nil
+// unlock function when the lock is held elsewhere.
+func (e *Engineer) tryFlock(name string) (func(), error) {
+	path := filepath.Join(e.locksDir(), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating lock dir: %w", err)
+	}
+	unlock, ok, err := lock.FlockTryAcquire(path)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// targetMergeSlot returns the merge slot of a target other than the rig's
+// default branch.
+func (e *Engineer) targetMergeSlot(target string) *fileMergeSlot {
+	return &fileMergeSlot{
+		id:     e.rig.Name + "/merge-slot/" + target,
+		engine: e,
+		name:   filepath.Join("merge-slot", target+".lock"),
+	}
+}
+
+// fileMergeSlot is a merge slot held as a file lock, so it serializes pushes
+// across processes without a beads database.
+type fileMergeSlot struct {
+	id     string
+	engine *Engineer
+	name   string
+
+	mu      sync.Mutex
+	holder  string
+	release func()
+}
+
+// install makes e acquire and release s instead of its current slot.
+func (s *fileMergeSlot) install(e *Engineer) {
+	e.mergeSlotEnsureExists = func() (string, error) {
+		return s.id, nil
+	}
+	e.mergeSlotAcquire = func(h string, _ bool) (*beads.MergeSlotStatus, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.holder != "" {
+			return &beads.MergeSlotStatus{Available: s.holder == h, Holder: s.holder}, nil
+		}
+		release, err := s.engine.tryFlock(s.name)
+		if err != nil {
+			return nil, err
+		}
+		if release == nil {
+			return &beads.MergeSlotStatus{Available: false, Holder: "another engineer"}, nil
+		}
+		s.holder, s.release = h, release
+		return &beads.MergeSlotStatus{Available: true, Holder: h}, nil
+	}
+	e.mergeSlotRelease = func(h string) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.holder == h {
+			s.release()
+			s.holder, s.release = "", nil
+		}
+		return nil
+	}
+}
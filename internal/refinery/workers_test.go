@@ -0,0 +1,138 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBindTarget_OneEngineerPerTarget(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createReleaseBranch(t, workDir)
+
+	e1 := newTestEngineer(t, workDir, g)
+	release, err := e1.BindTarget("release-1.x")
+	if err != nil {
+		t.Fatalf("BindTarget: %v", err)
+	}
+	if want := filepath.Join(workDir, ".runtime", "targets", "release-1.x"); e1.workDir != want {
+		t.Errorf("workDir = %s, want %s", e1.workDir, want)
+	}
+
+	e2 := newTestEngineer(t, workDir, g)
+	if _, err := e2.BindTarget("release-1.x"); !errors.Is(err, ErrTargetBusy) {
+		t.Fatalf("second BindTarget = %v, want ErrTargetBusy", err)
+	}
+	mainRelease, err := e2.BindTarget("main")
+	if err != nil {
+		t.Fatalf("BindTarget(main): %v", err)
+	}
+	defer mainRelease()
+	if e2.workDir != workDir {
+		t.Errorf("main engineer workDir = %s, want the clone", e2.workDir)
+	}
+
+	release()
+	e3 := newTestEngineer(t, workDir, g)
+	release, err = e3.BindTarget("release-1.x")
+	if err != nil {
+		t.Fatalf("BindTarget after release: %v", err)
+	}
+	release()
+}
+
+func TestBindTarget_FiltersQueue(t *testing.T) {
+	e := newTestEngineer(t, t.TempDir(), nil)
+	e.target = "release-1.x"
+	if !e.boundTo(makeMR("mr-1", "fix", "release-1.x")) {
+		t.Error("MR targeting the bound branch filtered out")
+	}
+	if e.boundTo(makeMR("mr-2", "feat", "main")) || e.boundTo(makeMR("mr-3", "feat", "")) {
+		t.Error("MR targeting another branch kept")
+	}
+	e.target = ""
+	if !e.boundTo(makeMR("mr-2", "feat", "main")) {
+		t.Error("unbound engineer filtered an MR out")
+	}
+}
+
+func TestBindTarget_ConcurrentEngineers(t *testing.T) {
+	workDir, g, cleanup := testGitRepo(t)
+	defer cleanup()
+	createReleaseBranch(t, workDir)
+	createFeatureBranch(t, workDir, "feature-a", "a.txt", "a\n")
+	createFeatureBranch(t, workDir, "feature-b", "b.txt", "b\n")
+	createReleaseFeatureBranch(t, workDir, "fix-c", "c.txt")
+	createReleaseFeatureBranch(t, workDir, "fix-d", "d.txt")
+
+	engineers := map[string]*Engineer{
+		"main":        newTestEngineer(t, workDir, g),
+		"release-1.x": newTestEngineer(t, workDir, g),
+	}
+	batches := map[string][]*MRInfo{
+		"main":        {makeMR("mr-a", "feature-a", "main"), makeMR("mr-b", "feature-b", "main")},
+		"release-1.x": {makeMR("mr-c", "fix-c", "release-1.x"), makeMR("mr-d", "fix-d", "release-1.x")},
+	}
+	for target, e := range engineers {
+		release, err := e.BindTarget(target)
+		if err != nil {
+			t.Fatalf("BindTarget(%s): %v", target, err)
+		}
+		defer release()
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]*BatchResult)
+	var wg sync.WaitGroup
+	for target, e := range engineers {
+		wg.Add(1)
+		go func(target string, e *Engineer) {
+			defer wg.Done()
+			r := e.ProcessBatch(context.Background(), batches[target], target, DefaultBatchConfig())
+			mu.Lock()
+			results[target] = r
+			mu.Unlock()
+		}(target, e)
+	}
+	wg.Wait()
+
+	for target, r := range results {
+		if r.Error != nil || len(r.Merged) != 2 {
+			t.Errorf("%s: merged %v, error %v", target, stackedIDs(r.Merged), r.Error)
+		}
+	}
+	run(t, workDir, "git", "fetch", "origin")
+	if files := run(t, workDir, "git", "ls-tree", "--name-only", "origin/release-1.x"); !strings.Contains(files, "d.txt") || strings.Contains(files, "a.txt") {
+		t.Errorf("origin/release-1.x files = %q", files)
+	}
+}
+
+func TestFileMergeSlot_ExclusiveAcrossEngineers(t *testing.T) {
+	dir := t.TempDir()
+	e1 := newTestEngineer(t, dir, nil)
+	e2 := newTestEngineer(t, dir, nil)
+	e1.targetMergeSlot("release-1.x").install(e1)
+	e2.targetMergeSlot("release-1.x").install(e2)
+
+	status, err := e1.mergeSlotAcquire("one", false)
+	if err != nil || !status.Available {
+		t.Fatalf("first acquire = %+v, %v", status, err)
+	}
+	if status, _ := e1.mergeSlotAcquire("one", false); !status.Available {
+		t.Error("holder re-acquire refused")
+	}
+	if status, err := e2.mergeSlotAcquire("two", false); err != nil || status.Available {
+		t.Fatalf("acquire while held = %+v, %v", status, err)
+	}
+	if err := e1.mergeSlotRelease("one"); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := e2.mergeSlotAcquire("two", false); err != nil || !status.Available {
+		t.Fatalf("acquire after release = %+v, %v", status, err)
+	}
+	_ = e2.mergeSlotRelease("two")
+}
@@ -28,6 +28,12 @@ type SchedulerConfig struct {
 	// SpawnDelay is the delay between spawns to prevent Dolt lock contention.
 	// Default: "0s".
 	SpawnDelay string `json:"spawn_delay,omitempty"`
+
+	// LabelPriorities maps bead labels to priorities (0 = P0 ... 4 = P4),
+	// e.g. {"P0": 0, "customer-facing": 1}. A labeled bead is dispatched,
+	// and its MR merged, at the most urgent of its own priority and those
+	// of its labels. Empty keeps dispatch in enqueue order.
+	LabelPriorities LabelPriorities `json:"label_priorities,omitempty"`
 }
 
 // DefaultSchedulerConfig returns a SchedulerConfig with sensible defaults.
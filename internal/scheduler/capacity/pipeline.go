@@ -10,6 +10,7 @@ type PendingBead struct {
 	TargetRig   string
 	Description string
 	Labels      []string
+	Priority    int                 // Work bead priority after label mapping (see LabelPriorities)
 	Context     *SlingContextFields // Parsed sling params from context bead
 }
 
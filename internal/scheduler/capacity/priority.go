@@ -0,0 +1,31 @@
+package capacity
+
+import "sort"
+
+// LabelPriorities maps bead labels to priorities, so triage in the tracker
+// (labeling a bead P0 or customer-facing) reorders dispatch and the merge
+// queue without touching each bead's priority field.
+type LabelPriorities map[string]int
+
+// Apply returns the most urgent (lowest) of priority and the priorities
+// labels map to. Mapped priorities outside 0-4 are ignored.
+func (m LabelPriorities) Apply(priority int, labels []string) int {
+	for _, label := range labels {
+		p, ok := m[label]
+		if !ok || p < 0 || p > 4 {
+			continue
+		}
+		if p < priority {
+			priority = p
+		}
+	}
+	return priority
+}
+
+// SortByPriority orders pending beads most urgent first, keeping the
+// existing (enqueue) order among beads of equal priority.
+func SortByPriority(pending []PendingBead) {
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Priority < pending[j].Priority
+	})
+}
@@ -0,0 +1,47 @@
+package capacity
+
+import "testing"
+
+func TestLabelPriorities_Apply(t *testing.T) {
+	m := LabelPriorities{"P0": 0, "P1": 1, "customer-facing": 1, "bogus": 7}
+	tests := []struct {
+		name     string
+		priority int
+		labels   []string
+		want     int
+	}{
+		{"no labels", 2, nil, 2},
+		{"unmapped labels", 2, []string{"gt:task", "docs"}, 2},
+		{"label raises", 3, []string{"customer-facing"}, 1},
+		{"most urgent label wins", 3, []string{"customer-facing", "P0"}, 0},
+		{"label never lowers", 0, []string{"P1"}, 0},
+		{"out of range ignored", 2, []string{"bogus"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Apply(tt.priority, tt.labels); got != tt.want {
+				t.Errorf("Apply(%d, %v) = %d, want %d", tt.priority, tt.labels, got, tt.want)
+			}
+		})
+	}
+	if got := LabelPriorities(nil).Apply(2, []string{"P0"}); got != 2 {
+		t.Errorf("nil mapping Apply = %d, want 2", got)
+	}
+}
+
+func TestSortByPriority(t *testing.T) {
+	pending := []PendingBead{
+		{ID: "a", Priority: 2},
+		{ID: "b", Priority: 0},
+		{ID: "c", Priority: 2},
+		{ID: "d", Priority: 1},
+	}
+	SortByPriority(pending)
+	var got string
+	for _, b := range pending {
+		got += b.ID
+	}
+	if got != "bdac" {
+		t.Errorf("order = %s, want bdac", got)
+	}
+}
@@ -97,12 +97,21 @@ func ParseAddress(address string) (*AgentIdentity, error) {
 // The prefix is the rig's beads prefix (e.g., "gt" for gastown, "dolt" for beads).
 // The rig name is resolved from the default PrefixRegistry. If the prefix is
 // not in the registry, the prefix itself is used as the rig name.
+//
+// A session bound to an agent in the default SessionNames registry (e.g. after
+// gt session rename) parses as that agent, whatever its name looks like.
 func ParseSessionName(session string) (*AgentIdentity, error) {
+	if r := DefaultSessionNames(); r != nil {
+		if b, ok := r.Lookup(session); ok {
+			return ParseAddress(b.Address)
+		}
+	}
 	return ParseSessionNameWithRegistry(session, DefaultRegistry())
 }
 
 // ParseSessionNameWithRegistry parses a tmux session name using a specific registry.
 // If registry is nil, an empty registry is used (prefix will not resolve to rig name).
+// Only canonical names are understood; session name bindings are not consulted.
 func ParseSessionNameWithRegistry(session string, registry *PrefixRegistry) (*AgentIdentity, error) {
 	if registry == nil {
 		registry = NewPrefixRegistry()
@@ -169,8 +178,15 @@ func ParseSessionNameWithRegistry(session string, registry *PrefixRegistry) (*Ag
 	return &AgentIdentity{Role: RolePolecat, Rig: rig, Name: rest, Prefix: prefix}, nil
 }
 
-// SessionName returns the tmux session name for this identity.
+// SessionName returns the tmux session name for this identity: its binding
+// in the default SessionNames registry, or the canonical name.
 func (a *AgentIdentity) SessionName() string {
+	return boundSessionName(a, a.canonicalSessionName())
+}
+
+// canonicalSessionName returns the session name formatted from the identity,
+// ignoring any binding.
+func (a *AgentIdentity) canonicalSessionName() string {
 	switch a.Role {
 	case RoleMayor:
 		return MayorSessionName()
@@ -182,15 +198,15 @@ func (a *AgentIdentity) SessionName() string {
 	case RoleOverseer:
 		return OverseerSessionName()
 	case RoleWitness:
-		return WitnessSessionName(a.prefix())
+		return canonicalWitnessName(a.prefix())
 	case RoleRefinery:
-		return RefinerySessionName(a.prefix())
+		return canonicalRefineryName(a.prefix())
 	case RoleCrew:
-		return CrewSessionName(a.prefix(), a.Name)
+		return canonicalCrewName(a.prefix(), a.Name)
 	case RolePolecat:
-		return PolecatSessionName(a.prefix(), a.Name)
+		return canonicalPolecatName(a.prefix(), a.Name)
 	case RoleDog:
-		return DogSessionName(a.Name)
+		return canonicalDogName(a.Name)
 	default:
 		return ""
 	}
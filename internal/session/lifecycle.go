@@ -124,6 +124,11 @@ type StartResult struct {
 	// waterfall correlation across prompts, BD calls, mail operations, and
 	// agent conversation events.
 	RunID string
+
+	// SessionID is the tmux session that was created. It differs from
+	// SessionConfig.SessionID when the agent's canonical name collided with
+	// another agent's and the session name registry disambiguated it.
+	SessionID string
 }
 
 // StartSession creates a tmux session following the standard Gas Town lifecycle.
@@ -158,6 +163,16 @@ func StartSession(t *tmux.Tmux, cfg SessionConfig) (_ *StartResult, retErr error
 		return nil, fmt.Errorf("Role is required")
 	}
 
+	// Bind the agent in the session name registry before creating its
+	// session, unless the caller asked for a name other than the canonical one.
+	if id := cfg.identity(); id != nil && cfg.SessionID == id.canonicalSessionName() {
+		name, err := ResolveSessionName(id)
+		if err != nil {
+			return nil, fmt.Errorf("resolving session name: %w", err)
+		}
+		cfg.SessionID = name
+	}
+
 	// 1. Resolve runtime config.
 	runtimeConfig := config.ResolveRoleAgentConfig(cfg.Role, cfg.TownRoot, cfg.RigPath)
 
@@ -302,7 +317,33 @@ func StartSession(t *tmux.Tmux, cfg SessionConfig) (_ *StartResult, retErr error
 	RecordAgentInstantiateFromDir(ctx, runID, runtimeConfig.ResolvedAgent,
 		cfg.Role, cfg.AgentName, cfg.SessionID, cfg.RigName, cfg.TownRoot, "", cfg.WorkDir)
 
-	return &StartResult{RuntimeConfig: runtimeConfig, RunID: runID}, nil
+	return &StartResult{RuntimeConfig: runtimeConfig, RunID: runID, SessionID: cfg.SessionID}, nil
+}
+
+// identity returns the identity of the agent being started, or nil for
+// roles whose session names aren't registered (see bindable).
+func (cfg SessionConfig) identity() *AgentIdentity {
+	id := &AgentIdentity{Role: Role(cfg.Role), Rig: cfg.RigName, Name: cfg.AgentName}
+	switch id.Role {
+	case RoleWitness, RoleRefinery:
+		if id.Rig == "" {
+			return nil
+		}
+	case RoleCrew, RolePolecat:
+		if id.Rig == "" || id.Name == "" {
+			return nil
+		}
+	case RoleDog:
+		if id.Name == "" {
+			return nil
+		}
+	default:
+		return nil
+	}
+	if id.Rig != "" {
+		id.Prefix = PrefixFor(id.Rig)
+	}
+	return id
 }
 
 // RecordAgentInstantiateFromDir resolves the git branch/commit from workDir and
@@ -337,12 +378,13 @@ func RecordAgentInstantiateFromDir(ctx context.Context, runID, resolvedAgent, ro
 	})
 }
 
-// StopSession stops a tmux session with optional graceful shutdown.
+// StopSession stops a session with optional graceful shutdown.
 //
 // If graceful is true, sends Ctrl-C first and waits for the session to exit
-// before force-killing. This allows the agent to clean up.
-func StopSession(t *tmux.Tmux, sessionID string, graceful bool) error {
-	running, err := t.HasSession(sessionID)
+// before force-killing. This allows the agent to clean up. Graceful shutdown
+// needs tmux; other backends are killed straight away.
+func StopSession(mux tmux.Multiplexer, sessionID string, graceful bool) error {
+	running, err := mux.HasSession(sessionID)
 	if err != nil {
 		return fmt.Errorf("checking session: %w", err)
 	}
@@ -350,7 +392,7 @@ func StopSession(t *tmux.Tmux, sessionID string, graceful bool) error {
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
 
-	if graceful {
+	if t, ok := mux.(*tmux.Tmux); ok && graceful {
 		_ = t.SendKeysRaw(sessionID, "C-c")
 		WaitForSessionExit(t, sessionID, constants.GracefulShutdownTimeout)
 	}
@@ -359,13 +401,22 @@ func StopSession(t *tmux.Tmux, sessionID string, graceful bool) error {
 	// the tmux session, to avoid orphan processes accumulating over time.
 	DeactivateAgentLogging(sessionID)
 
-	if err := t.KillSessionWithProcesses(sessionID); err != nil {
+	if err := killSession(mux, sessionID); err != nil {
 		return fmt.Errorf("killing session: %w", err)
 	}
 
 	return nil
 }
 
+// killSession kills a session. On tmux every descendant process is killed
+// too (see Tmux.KillSessionWithProcesses).
+func killSession(mux tmux.Multiplexer, sessionID string) error {
+	if t, ok := mux.(*tmux.Tmux); ok {
+		return t.KillSessionWithProcesses(sessionID)
+	}
+	return mux.KillSession(sessionID)
+}
+
 func mapKeysSorted(m map[string]string) []string {
 	if len(m) == 0 {
 		return nil
@@ -424,8 +475,10 @@ func MergeRuntimeLivenessEnv(envVars map[string]string, runtimeConfig *config.Ru
 // If checkAlive is true, only kills zombie sessions (tmux alive but agent dead).
 // If the session exists and the agent is alive, returns ErrAlreadyRunning.
 // If checkAlive is false, kills any existing session unconditionally.
-func KillExistingSession(t *tmux.Tmux, sessionID string, checkAlive bool) (bool, error) {
-	running, err := t.HasSession(sessionID)
+// Only tmux can tell a zombie from a live agent; on other backends an
+// existing session is always treated as alive.
+func KillExistingSession(mux tmux.Multiplexer, sessionID string, checkAlive bool) (bool, error) {
+	running, err := mux.HasSession(sessionID)
 	if err != nil {
 		return false, fmt.Errorf("checking session: %w", err)
 	}
@@ -433,11 +486,13 @@ func KillExistingSession(t *tmux.Tmux, sessionID string, checkAlive bool) (bool,
 		return false, nil
 	}
 
-	if checkAlive && t.IsAgentAlive(sessionID) {
-		return false, fmt.Errorf("session already running: %s", sessionID)
+	if checkAlive {
+		if t, ok := mux.(*tmux.Tmux); !ok || t.IsAgentAlive(sessionID) {
+			return false, fmt.Errorf("session already running: %s", sessionID)
+		}
 	}
 
-	if err := t.KillSessionWithProcesses(sessionID); err != nil {
+	if err := killSession(mux, sessionID); err != nil {
 		return false, fmt.Errorf("killing session %s: %w", sessionID, err)
 	}
 
@@ -2,8 +2,10 @@ package session
 
 import (
 	"testing"
+	"time"
 
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/testutil/fakemux"
 )
 
 func TestStartSession_RequiresSessionID(t *testing.T) {
@@ -110,10 +112,64 @@ func TestBuildCommand_WithAgentOverride(t *testing.T) {
 }
 
 func TestKillExistingSession_NoSession(t *testing.T) {
-	// KillExistingSession with nil tmux would panic, but we test the logic
-	// by verifying it's callable. Full integration tests need a real tmux.
-	// This test verifies the function signature and basic flow.
-	t.Skip("requires tmux for integration testing")
+	mux := fakemux.New()
+	killed, err := KillExistingSession(mux, "gt-nux", true)
+	if err != nil || killed {
+		t.Errorf("KillExistingSession = %v, %v; want false, nil", killed, err)
+	}
+}
+
+func TestKillExistingSession(t *testing.T) {
+	mux := fakemux.New()
+	if err := mux.NewSessionWithCommand("gt-nux", t.TempDir(), "claude"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without tmux a live agent can't be told from a zombie.
+	if killed, err := KillExistingSession(mux, "gt-nux", true); err == nil || killed {
+		t.Errorf("checkAlive: KillExistingSession = %v, %v; want an already-running error", killed, err)
+	}
+	killed, err := KillExistingSession(mux, "gt-nux", false)
+	if err != nil || !killed {
+		t.Fatalf("KillExistingSession = %v, %v; want true, nil", killed, err)
+	}
+	if ok, _ := mux.HasSession("gt-nux"); ok {
+		t.Error("session still exists after KillExistingSession")
+	}
+}
+
+func TestStopSession(t *testing.T) {
+	mux := fakemux.New()
+	if err := StopSession(mux, "gt-test-stop-session", true); err == nil {
+		t.Error("StopSession of a missing session: want an error")
+	}
+	if err := mux.NewSession("gt-test-stop-session", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := StopSession(mux, "gt-test-stop-session", true); err != nil {
+		t.Fatalf("StopSession: %v", err)
+	}
+	if ok, _ := mux.HasSession("gt-test-stop-session"); ok {
+		t.Error("session still exists after StopSession")
+	}
+}
+
+func TestWaitForSessionExit(t *testing.T) {
+	mux := fakemux.New()
+	if err := mux.NewSession("gt-nux", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if WaitForSessionExit(mux, "gt-nux", 50*time.Millisecond) {
+		t.Error("WaitForSessionExit = true for a session that never exits")
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = mux.KillSession("gt-nux")
+	}()
+	if !WaitForSessionExit(mux, "gt-nux", 5*time.Second) {
+		t.Error("WaitForSessionExit = false after the session exited")
+	}
 }
 
 func TestMapKeysSorted(t *testing.T) {
@@ -25,26 +25,28 @@ func DeaconSessionName() string {
 
 // WitnessSessionName returns the session name for a rig's Witness agent.
 // rigPrefix is the rig's beads prefix (e.g., "gt" for gastown, "bd" for beads).
+// Like the other agent formatters, it returns the agent's registered session
+// name instead when it has one (see SessionNames).
 func WitnessSessionName(rigPrefix string) string {
-	return fmt.Sprintf("%s-witness", rigPrefix)
+	return boundSessionName(rigAgent(RoleWitness, rigPrefix, ""), canonicalWitnessName(rigPrefix))
 }
 
 // RefinerySessionName returns the session name for a rig's Refinery agent.
 // rigPrefix is the rig's beads prefix (e.g., "gt" for gastown, "bd" for beads).
 func RefinerySessionName(rigPrefix string) string {
-	return fmt.Sprintf("%s-refinery", rigPrefix)
+	return boundSessionName(rigAgent(RoleRefinery, rigPrefix, ""), canonicalRefineryName(rigPrefix))
 }
 
 // CrewSessionName returns the session name for a crew worker in a rig.
 // rigPrefix is the rig's beads prefix (e.g., "gt" for gastown, "bd" for beads).
 func CrewSessionName(rigPrefix, name string) string {
-	return fmt.Sprintf("%s-crew-%s", rigPrefix, name)
+	return boundSessionName(rigAgent(RoleCrew, rigPrefix, name), canonicalCrewName(rigPrefix, name))
 }
 
 // PolecatSessionName returns the session name for a polecat in a rig.
 // rigPrefix is the rig's beads prefix (e.g., "gt" for gastown, "bd" for beads).
 func PolecatSessionName(rigPrefix, name string) string {
-	return fmt.Sprintf("%s-%s", rigPrefix, name)
+	return boundSessionName(rigAgent(RolePolecat, rigPrefix, name), canonicalPolecatName(rigPrefix, name))
 }
 
 // OverseerSessionName returns the session name for the human operator.
@@ -64,5 +66,31 @@ func BootSessionName() string {
 // Dogs are town-level (managed by deacon), so they use the hq- prefix.
 // Pattern: hq-dog-<name> (e.g., hq-dog-alpha).
 func DogSessionName(name string) string {
+	return boundSessionName(&AgentIdentity{Role: RoleDog, Name: name}, canonicalDogName(name))
+}
+
+// rigAgent returns the identity of a rig-level agent, resolving the rig from
+// the default PrefixRegistry.
+func rigAgent(role Role, rigPrefix, name string) *AgentIdentity {
+	return &AgentIdentity{Role: role, Rig: DefaultRegistry().RigForPrefix(rigPrefix), Name: name, Prefix: rigPrefix}
+}
+
+func canonicalWitnessName(rigPrefix string) string {
+	return fmt.Sprintf("%s-witness", rigPrefix)
+}
+
+func canonicalRefineryName(rigPrefix string) string {
+	return fmt.Sprintf("%s-refinery", rigPrefix)
+}
+
+func canonicalCrewName(rigPrefix, name string) string {
+	return fmt.Sprintf("%s-crew-%s", rigPrefix, name)
+}
+
+func canonicalPolecatName(rigPrefix, name string) string {
+	return fmt.Sprintf("%s-%s", rigPrefix, name)
+}
+
+func canonicalDogName(name string) string {
 	return fmt.Sprintf("%sdog-%s", HQPrefix, name)
 }
@@ -0,0 +1,380 @@
+// Package session provides polecat session lifecycle management.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/lock"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ErrSessionNameCollision is returned when a session name is already bound to
+// another agent, or would be parsed as a different agent's session.
+var ErrSessionNameCollision = errors.New("session name collision")
+
+// SessionBinding binds a logical agent to its tmux session name.
+type SessionBinding struct {
+	Address     string    `json:"address"`                // Mail-style agent address (e.g., "gastown/polecats/nux")
+	Session     string    `json:"session"`                // Current tmux session name
+	ID          string    `json:"id"`                     // Stable identifier, kept across restarts and renames
+	CreatedAt   time.Time `json:"created_at"`             // When the agent was first bound
+	RenamedFrom []string  `json:"renamed_from,omitempty"` // Previous session names, oldest first
+}
+
+// SessionNames is the town's registry of agent → tmux session name bindings.
+// Agents resolve their session name here instead of formatting it, so a name
+// that collides with another agent is disambiguated once and then reused for
+// every restart.
+//
+// The registry file is shared by every gt process in the town: changes are
+// made under a flock on the file and re-read from disk first, and reads pick
+// up other processes' changes when the file's mtime moves.
+type SessionNames struct {
+	mu       sync.Mutex
+	path     string
+	prefixes *PrefixRegistry
+	bindings map[string]*SessionBinding // keyed by address
+	modTime  time.Time                  // mtime of the file when bindings were read
+}
+
+// defaultNames is the session name registry of the town loaded by
+// InitRegistry. nil until then, in which case agents use canonical names.
+var (
+	defaultNames   *SessionNames
+	defaultNamesMu sync.RWMutex
+)
+
+// DefaultSessionNames returns the session name registry loaded by
+// InitRegistry, or nil if none is loaded.
+func DefaultSessionNames() *SessionNames {
+	defaultNamesMu.RLock()
+	defer defaultNamesMu.RUnlock()
+	return defaultNames
+}
+
+// SetDefaultSessionNames replaces the default session name registry.
+// Pass nil to go back to canonical names.
+func SetDefaultSessionNames(r *SessionNames) {
+	defaultNamesMu.Lock()
+	defaultNames = r
+	defaultNamesMu.Unlock()
+}
+
+// SessionNamesPath returns the location of the town's session name registry.
+func SessionNamesPath(townRoot string) string {
+	return filepath.Join(townRoot, ".runtime", "session-names.json")
+}
+
+// LoadSessionNames loads the session name registry for a town. A missing
+// file yields an empty registry. Prefixes are resolved with the default
+// PrefixRegistry.
+func LoadSessionNames(townRoot string) (*SessionNames, error) {
+	r := &SessionNames{
+		path:     SessionNamesPath(townRoot),
+		prefixes: DefaultRegistry(),
+		bindings: make(map[string]*SessionBinding),
+	}
+	if err := r.readLocked(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ResolveSessionName returns the session name to create for an agent: its
+// binding in the default registry, created on first use (see Resolve), or
+// the canonical name when no registry is loaded. Town-level singletons
+// (mayor, deacon, boot) always use their canonical hq- names.
+func ResolveSessionName(id *AgentIdentity) (string, error) {
+	r := DefaultSessionNames()
+	if r == nil || !bindable(id) {
+		return id.canonicalSessionName(), nil
+	}
+	b, err := r.Resolve(id)
+	if err != nil {
+		return "", err
+	}
+	return b.Session, nil
+}
+
+// Resolve returns the binding for an agent, creating it on first use. A new
+// binding gets the canonical session name (see AgentIdentity.SessionName),
+// or the canonical name suffixed with part of the agent's ID when the
+// canonical name collides.
+func (r *SessionNames) Resolve(id *AgentIdentity) (*SessionBinding, error) {
+	address := id.Address()
+	if address == "" {
+		return nil, fmt.Errorf("agent %+v has no address", id)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unlock, err := r.lockFile()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if b, ok := r.bindings[address]; ok {
+		cp := *b
+		return &cp, nil
+	}
+
+	agentID, err := newAgentID()
+	if err != nil {
+		return nil, err
+	}
+	name := id.canonicalSessionName()
+	if r.collisionLocked(address, name) != nil {
+		name = name + "-" + agentID[len("ag-"):len("ag-")+4]
+		if err := r.collisionLocked(address, name); err != nil {
+			return nil, err
+		}
+	}
+
+	b := &SessionBinding{Address: address, Session: name, ID: agentID, CreatedAt: time.Now().UTC()}
+	r.bindings[address] = b
+	if err := r.saveLocked(); err != nil {
+		delete(r.bindings, address)
+		return nil, err
+	}
+	cp := *b
+	return &cp, nil
+}
+
+// Lookup returns the binding whose current session name is session.
+func (r *SessionNames) Lookup(session string) (*SessionBinding, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshLocked()
+	for _, b := range r.bindings {
+		if b.Session == session {
+			cp := *b
+			return &cp, true
+		}
+	}
+	return nil, false
+}
+
+// BindingFor returns the binding for an agent address, if it has one.
+// Unlike Resolve it never creates a binding.
+func (r *SessionNames) BindingFor(address string) (*SessionBinding, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshLocked()
+	b, ok := r.bindings[address]
+	if !ok {
+		return nil, false
+	}
+	cp := *b
+	return &cp, true
+}
+
+// Rename binds an agent to a new session name, keeping its ID. The caller
+// renames the live tmux session, if any.
+func (r *SessionNames) Rename(address, session string) (*SessionBinding, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unlock, err := r.lockFile()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	b, ok := r.bindings[address]
+	if !ok {
+		return nil, fmt.Errorf("no session name registered for %s", address)
+	}
+	if b.Session == session {
+		cp := *b
+		return &cp, nil
+	}
+	if err := r.collisionLocked(address, session); err != nil {
+		return nil, err
+	}
+
+	prev := *b
+	b.RenamedFrom = append(b.RenamedFrom, b.Session)
+	b.Session = session
+	if err := r.saveLocked(); err != nil {
+		*b = prev
+		return nil, err
+	}
+	cp := *b
+	return &cp, nil
+}
+
+// Release removes an agent's binding, e.g. when a polecat is nuked.
+func (r *SessionNames) Release(address string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unlock, err := r.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	b, ok := r.bindings[address]
+	if !ok {
+		return nil
+	}
+	delete(r.bindings, address)
+	if err := r.saveLocked(); err != nil {
+		r.bindings[address] = b
+		return err
+	}
+	return nil
+}
+
+// Bindings returns all bindings sorted by address.
+func (r *SessionNames) Bindings() []SessionBinding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshLocked()
+	out := make([]SessionBinding, 0, len(r.bindings))
+	for _, b := range r.bindings {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// collisionLocked reports whether session can't be used by address: it is
+// bound to another agent, or it parses as the session of a different
+// non-polecat agent (e.g. a polecat named "witness" would get gt-witness).
+// Polecat is the catch-all parse, so polecat-shaped names only collide
+// through the registry. Caller must hold r.mu.
+func (r *SessionNames) collisionLocked(address, session string) error {
+	if session == "" {
+		return fmt.Errorf("empty session name for %s", address)
+	}
+	for _, b := range r.bindings {
+		if b.Address != address && b.Session == session {
+			return fmt.Errorf("%w: %s is already used by %s", ErrSessionNameCollision, session, b.Address)
+		}
+	}
+	if parsed, err := ParseSessionNameWithRegistry(session, r.prefixes); err == nil &&
+		parsed.Role != RolePolecat && parsed.Address() != address {
+		return fmt.Errorf("%w: %s is the session name of %s", ErrSessionNameCollision, session, parsed.Address())
+	}
+	return nil
+}
+
+// lockFile takes the cross-process lock on the registry file and re-reads
+// the bindings under it, so a change is made against what other processes
+// last wrote. Caller must hold r.mu and call the returned func when done.
+func (r *SessionNames) lockFile() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return nil, fmt.Errorf("creating session names directory: %w", err)
+	}
+	unlock, err := lock.FlockAcquire(r.path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("locking session names: %w", err)
+	}
+	if err := r.readLocked(); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// refreshLocked re-reads the registry if another process has written it
+// since it was last read. Read errors keep the bindings already loaded.
+// Caller must hold r.mu.
+func (r *SessionNames) refreshLocked() {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		if os.IsNotExist(err) && !r.modTime.IsZero() {
+			r.bindings = make(map[string]*SessionBinding)
+			r.modTime = time.Time{}
+		}
+		return
+	}
+	if !info.ModTime().Equal(r.modTime) {
+		_ = r.readLocked()
+	}
+}
+
+// readLocked replaces the bindings with the registry file's contents. A
+// missing file yields no bindings. Caller must hold r.mu.
+func (r *SessionNames) readLocked() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			r.bindings = make(map[string]*SessionBinding)
+			r.modTime = time.Time{}
+			return nil
+		}
+		return fmt.Errorf("reading session names: %w", err)
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("reading session names: %w", err)
+	}
+	var list []*SessionBinding
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parsing session names: %w", err)
+	}
+	bindings := make(map[string]*SessionBinding, len(list))
+	for _, b := range list {
+		bindings[b.Address] = b
+	}
+	r.bindings = bindings
+	r.modTime = info.ModTime()
+	return nil
+}
+
+// saveLocked writes the registry to disk. Caller must hold r.mu and the
+// file lock.
+func (r *SessionNames) saveLocked() error {
+	list := make([]*SessionBinding, 0, len(r.bindings))
+	for _, b := range r.bindings {
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	if err := util.EnsureDirAndWriteJSON(r.path, list); err != nil {
+		return fmt.Errorf("writing session names: %w", err)
+	}
+	if info, err := os.Stat(r.path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// boundSessionName returns the session name an agent is bound to in the
+// default registry, or canonical if it has no binding.
+func boundSessionName(id *AgentIdentity, canonical string) string {
+	r := DefaultSessionNames()
+	if r == nil || !bindable(id) {
+		return canonical
+	}
+	if b, ok := r.BindingFor(id.Address()); ok {
+		return b.Session
+	}
+	return canonical
+}
+
+// bindable reports whether an agent's session name goes through the
+// registry. The hq- singletons don't: boot and the deacon share an address.
+func bindable(id *AgentIdentity) bool {
+	switch id.Role {
+	case RoleWitness, RoleRefinery, RoleCrew, RolePolecat, RoleDog:
+		return true
+	default:
+		return false
+	}
+}
+
+// newAgentID returns a random stable agent identifier ("ag-" + 12 hex chars).
+func newAgentID() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating agent ID: %w", err)
+	}
+	return "ag-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,167 @@
+package session
+
+import (
+	"errors"
+	"testing"
+)
+
+func testSessionNames(t *testing.T, townRoot string) *SessionNames {
+	t.Helper()
+	r, err := LoadSessionNames(townRoot)
+	if err != nil {
+		t.Fatalf("LoadSessionNames: %v", err)
+	}
+	prefixes := NewPrefixRegistry()
+	prefixes.Register("gt", "gastown")
+	r.prefixes = prefixes
+	return r
+}
+
+func TestSessionNames_ResolveIsStableAcrossReloads(t *testing.T) {
+	town := t.TempDir()
+	r := testSessionNames(t, town)
+
+	nux := &AgentIdentity{Role: RolePolecat, Rig: "gastown", Name: "nux", Prefix: "gt"}
+	first, err := r.Resolve(nux)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if first.Session != "gt-nux" || first.Address != "gastown/polecats/nux" || first.ID == "" {
+		t.Fatalf("unexpected binding %+v", first)
+	}
+
+	again, err := testSessionNames(t, town).Resolve(nux)
+	if err != nil {
+		t.Fatalf("Resolve after reload: %v", err)
+	}
+	if again.ID != first.ID || again.Session != first.Session {
+		t.Errorf("binding changed across reload: %+v vs %+v", again, first)
+	}
+}
+
+func TestSessionNames_ResolveDisambiguatesReservedName(t *testing.T) {
+	r := testSessionNames(t, t.TempDir())
+
+	// A polecat named "witness" would otherwise take the witness's session.
+	polecat := &AgentIdentity{Role: RolePolecat, Rig: "gastown", Name: "witness", Prefix: "gt"}
+	b, err := r.Resolve(polecat)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if b.Session == "gt-witness" {
+		t.Fatalf("polecat was given the witness session name")
+	}
+	if got, ok := r.Lookup(b.Session); !ok || got.Address != "gastown/polecats/witness" {
+		t.Errorf("Lookup(%s) = %+v, %v", b.Session, got, ok)
+	}
+
+	witness, err := r.Resolve(&AgentIdentity{Role: RoleWitness, Rig: "gastown", Prefix: "gt"})
+	if err != nil || witness.Session != "gt-witness" {
+		t.Errorf("witness binding = %+v, %v; want gt-witness", witness, err)
+	}
+}
+
+func TestSessionNames_Rename(t *testing.T) {
+	town := t.TempDir()
+	r := testSessionNames(t, town)
+	nux, _ := r.Resolve(&AgentIdentity{Role: RolePolecat, Rig: "gastown", Name: "nux", Prefix: "gt"})
+	if _, err := r.Resolve(&AgentIdentity{Role: RolePolecat, Rig: "gastown", Name: "toast", Prefix: "gt"}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if _, err := r.Rename(nux.Address, "gt-toast"); !errors.Is(err, ErrSessionNameCollision) {
+		t.Errorf("rename onto a bound name: err = %v, want collision", err)
+	}
+	if _, err := r.Rename(nux.Address, "gt-refinery"); !errors.Is(err, ErrSessionNameCollision) {
+		t.Errorf("rename onto a reserved name: err = %v, want collision", err)
+	}
+
+	renamed, err := r.Rename(nux.Address, "gt-nux-debug")
+	if err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if renamed.ID != nux.ID || len(renamed.RenamedFrom) != 1 || renamed.RenamedFrom[0] != "gt-nux" {
+		t.Errorf("unexpected renamed binding %+v", renamed)
+	}
+	if _, ok := testSessionNames(t, town).Lookup("gt-nux-debug"); !ok {
+		t.Error("rename was not persisted")
+	}
+
+	if err := r.Release(nux.Address); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, ok := r.Lookup("gt-nux-debug"); ok {
+		t.Error("binding still present after Release")
+	}
+}
+
+func TestSessionNames_ChangesSeeOtherProcesses(t *testing.T) {
+	town := t.TempDir()
+	// Two registries loaded before either writes, as in two gt processes.
+	a := testSessionNames(t, town)
+	b := testSessionNames(t, town)
+
+	nux, err := a.Resolve(&AgentIdentity{Role: RolePolecat, Rig: "gastown", Name: "nux", Prefix: "gt"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if _, err := b.Resolve(&AgentIdentity{Role: RolePolecat, Rig: "gastown", Name: "toast", Prefix: "gt"}); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if got, ok := b.BindingFor(nux.Address); !ok || got.ID != nux.ID {
+		t.Errorf("second registry sees nux as %+v, %v; want ID %s", got, ok, nux.ID)
+	}
+	if got := len(testSessionNames(t, town).Bindings()); got != 2 {
+		t.Errorf("%d bindings on disk, want 2: one process overwrote the other", got)
+	}
+}
+
+func TestDefaultSessionNames_BindsFormattersAndParsing(t *testing.T) {
+	prefixes := NewPrefixRegistry()
+	prefixes.Register("gt", "gastown")
+	old := DefaultRegistry()
+	SetDefaultRegistry(prefixes)
+	defer SetDefaultRegistry(old)
+
+	names, err := LoadSessionNames(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadSessionNames: %v", err)
+	}
+	SetDefaultSessionNames(names)
+	defer SetDefaultSessionNames(nil)
+
+	if got := PolecatSessionName("gt", "nux"); got != "gt-nux" {
+		t.Errorf("unbound polecat session = %q, want gt-nux", got)
+	}
+	nux := &AgentIdentity{Role: RolePolecat, Rig: "gastown", Name: "nux", Prefix: "gt"}
+	if _, err := ResolveSessionName(nux); err != nil {
+		t.Fatalf("ResolveSessionName: %v", err)
+	}
+	if _, err := names.Rename(nux.Address(), "gt-nux-debug"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+
+	if got := PolecatSessionName("gt", "nux"); got != "gt-nux-debug" {
+		t.Errorf("PolecatSessionName = %q, want the renamed session", got)
+	}
+	if got := nux.SessionName(); got != "gt-nux-debug" {
+		t.Errorf("SessionName = %q, want the renamed session", got)
+	}
+	parsed, err := ParseSessionName("gt-nux-debug")
+	if err != nil || parsed.Role != RolePolecat || parsed.Rig != "gastown" || parsed.Name != "nux" {
+		t.Errorf("ParseSessionName(gt-nux-debug) = %+v, %v; want polecat gastown/nux", parsed, err)
+	}
+
+	// A polecat named "witness" is created under a disambiguated name and
+	// leaves the witness's session name alone.
+	name, err := ResolveSessionName(&AgentIdentity{Role: RolePolecat, Rig: "gastown", Name: "witness", Prefix: "gt"})
+	if err != nil || name == "gt-witness" {
+		t.Fatalf("ResolveSessionName(polecat witness) = %q, %v", name, err)
+	}
+	if got := WitnessSessionName("gt"); got != "gt-witness" {
+		t.Errorf("WitnessSessionName = %q, want gt-witness", got)
+	}
+	if got := PolecatSessionName("gt", "witness"); got != name {
+		t.Errorf("PolecatSessionName(witness) = %q, want %q", got, name)
+	}
+}
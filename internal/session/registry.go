@@ -139,6 +139,15 @@ func InitRegistry(townRoot string) error {
 		SetDefaultRegistry(r)
 	}
 
+	// Load the session name registry so agents are created and found under
+	// the names they are bound to, including renamed ones.
+	names, err := LoadSessionNames(townRoot)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("session names: %w", err))
+	} else {
+		SetDefaultSessionNames(names)
+	}
+
 	// Load agent registry so all entry points (CLI, daemon, witness) respect
 	// user-configured overrides like custom process_names.
 	if err := config.LoadAgentRegistry(config.DefaultAgentRegistryPath(townRoot)); err != nil {
@@ -10,7 +10,10 @@ import (
 
 // SessionCreatedAt returns the time a tmux session was created.
 func SessionCreatedAt(sessionName string) (time.Time, error) {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("session creation times")
+	if err != nil {
+		return time.Time{}, err
+	}
 	info, err := t.GetSessionInfo(sessionName)
 	if err != nil {
 		return time.Time{}, err
@@ -0,0 +1,255 @@
+// Package chaos injects failures into test towns — killed agent sessions,
+// slow git pushes, failing gates and a dropped merge slot — and checks the
+// merge queue's invariants afterwards, so recovery paths stay honest.
+//
+// Faults are drawn from a seeded source: a failing run can be replayed by
+// rerunning with the seed it logged.
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Config sets how often each fault is injected. Rates are probabilities
+// from 0 (never) to 1 (always).
+type Config struct {
+	Seed int64 // Fault source seed; 0 picks one from the clock
+
+	KillRate        float64       // Chance Kill takes down an agent session
+	GateFailureRate float64       // Chance a gate run fails without running
+	SlotDropRate    float64       // Chance a merge slot acquire or release fails
+	PushDelay       time.Duration // How long a delayed git push stalls
+	PushDelayRate   float64       // Chance a git push is delayed
+}
+
+// Event is an injected fault.
+type Event struct {
+	Kind   string // "kill", "gate", "slot"
+	Detail string
+}
+
+func (e Event) String() string {
+	return e.Kind + ": " + e.Detail
+}
+
+// Injector injects the faults described by its Config. It implements
+// refinery.FaultInjector.
+type Injector struct {
+	cfg Config
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	events []Event
+}
+
+var _ refinery.FaultInjector = (*Injector)(nil)
+
+// New returns an injector for cfg.
+func New(cfg Config) *Injector {
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	return &Injector{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))} //nolint:gosec // G404: reproducible faults, not security
+}
+
+// Seed returns the seed faults are drawn from.
+func (i *Injector) Seed() int64 {
+	return i.cfg.Seed
+}
+
+// roll reports whether a fault with the given rate fires, recording it.
+func (i *Injector) roll(rate float64, kind, detail string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if rate <= 0 || i.rng.Float64() >= rate {
+		return false
+	}
+	i.events = append(i.events, Event{Kind: kind, Detail: detail})
+	return true
+}
+
+// GateFault fails gate runs at the configured rate.
+func (i *Injector) GateFault(name string) error {
+	if i.roll(i.cfg.GateFailureRate, "gate", name) {
+		return fmt.Errorf("chaos: gate %s failed", name)
+	}
+	return nil
+}
+
+// MergeSlotFault drops the merge slot at the configured rate.
+func (i *Injector) MergeSlotFault(op string) error {
+	if i.roll(i.cfg.SlotDropRate, "slot", op) {
+		return fmt.Errorf("chaos: merge slot dropped during %s", op)
+	}
+	return nil
+}
+
+// Kill takes down one of sessions, chosen at random, at the configured
+// rate. It returns the session killed, if any.
+func (i *Injector) Kill(t *tmux.Tmux, sessions []string) (string, bool) {
+	if len(sessions) == 0 {
+		return "", false
+	}
+	i.mu.Lock()
+	victim := sessions[i.rng.Intn(len(sessions))]
+	i.mu.Unlock()
+	if !i.roll(i.cfg.KillRate, "kill", victim) {
+		return "", false
+	}
+	if err := t.KillSession(victim); err != nil {
+		return "", false
+	}
+	return victim, true
+}
+
+// Events returns the faults injected so far, in order.
+func (i *Injector) Events() []Event {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]Event(nil), i.events...)
+}
+
+// pushDelayHook stalls a git push with probability rate percent. Hooks
+// run in their own process, so the pid stands in for a random draw.
+const pushDelayHook = `#!/bin/sh
+# Installed by internal/testutil/chaos: stalls some pushes.
+if [ $(( $$ %% 100 )) -lt %d ]; then
+	sleep %s
+fi
+exit 0
+`
+
+// InstallPushDelay installs a pre-push hook in the repository containing
+// dir that stalls pushes at the configured rate. Worktrees share hooks,
+// so one install covers every worktree of the repository.
+func (i *Injector) InstallPushDelay(dir string) error {
+	if i.cfg.PushDelay <= 0 || i.cfg.PushDelayRate <= 0 {
+		return nil
+	}
+	cmd := exec.Command("git", "rev-parse", "--git-common-dir")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("finding git dir: %w", err)
+	}
+	gitDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	hooks := filepath.Join(gitDir, "hooks")
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		return err
+	}
+	percent := int(i.cfg.PushDelayRate * 100)
+	seconds := fmt.Sprintf("%.3f", i.cfg.PushDelay.Seconds())
+	hook := fmt.Sprintf(pushDelayHook, percent, seconds)
+	return os.WriteFile(filepath.Join(hooks, "pre-push"), []byte(hook), 0755) //nolint:gosec // G306: hook must be executable
+}
+
+// Ledger tracks what happened to every merge request across refinery
+// runs, for checking invariants.
+type Ledger struct {
+	mu        sync.Mutex
+	titles    map[string]string // Submitted MR ID → title
+	merged    map[string]int    // MR ID → times reported merged
+	rejected  map[string]bool   // MR ID → reported as a culprit or conflict
+	submitted []string          // MR IDs in submission order
+}
+
+// NewLedger returns an empty ledger.
+func NewLedger() *Ledger {
+	return &Ledger{
+		titles:   make(map[string]string),
+		merged:   make(map[string]int),
+		rejected: make(map[string]bool),
+	}
+}
+
+// Submitted records a merge request entering the queue. Recording the
+// same one again is harmless.
+func (l *Ledger) Submitted(id, title string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.titles[id]; !ok {
+		l.submitted = append(l.submitted, id)
+	}
+	l.titles[id] = title
+}
+
+// Record records the outcome of a batch.
+func (l *Ledger) Record(result *refinery.BatchResult) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, mr := range result.Merged {
+		l.merged[mr.ID]++
+	}
+	for _, mr := range append(append([]*refinery.MRInfo(nil), result.Culprits...), result.Conflicts...) {
+		l.rejected[mr.ID] = true
+	}
+}
+
+// Merged returns the IDs of merge requests that landed.
+func (l *Ledger) Merged() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var ids []string
+	for id := range l.merged {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Check verifies the merge queue's invariants, given the IDs still queued
+// and the commit subjects on the target branch:
+//
+//   - no lost MRs: every submitted MR was merged, rejected, or is queued
+//   - no duplicate merges: no MR was merged twice, and no merged MR's
+//     commit appears on the target more than once
+//   - merges are real: every merged MR's commit is on the target
+//   - no double outcomes: no MR was both merged and rejected
+func (l *Ledger) Check(queued []string, targetSubjects []string) []error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inQueue := make(map[string]bool, len(queued))
+	for _, id := range queued {
+		inQueue[id] = true
+	}
+	onTarget := make(map[string]int)
+	for _, s := range targetSubjects {
+		onTarget[s]++
+	}
+
+	var errs []error
+	for _, id := range l.submitted {
+		merged, rejected := l.merged[id], l.rejected[id]
+		title := l.titles[id]
+		switch {
+		case merged == 0 && !rejected && !inQueue[id]:
+			errs = append(errs, fmt.Errorf("lost MR %s: neither merged, rejected nor queued", id))
+		case merged > 1:
+			errs = append(errs, fmt.Errorf("MR %s merged %d times", id, merged))
+		case merged == 1 && rejected:
+			errs = append(errs, fmt.Errorf("MR %s both merged and rejected", id))
+		}
+		if merged > 0 && onTarget[title] == 0 {
+			errs = append(errs, fmt.Errorf("MR %s reported merged but %q is not on the target", id, title))
+		}
+		if onTarget[title] > 1 {
+			errs = append(errs, fmt.Errorf("MR %s landed %d times (%q)", id, onTarget[title], title))
+		}
+	}
+	return errs
+}
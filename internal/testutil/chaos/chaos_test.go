@@ -0,0 +1,98 @@
+package chaos
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/refinery"
+)
+
+func TestInjector_ReproducibleFromSeed(t *testing.T) {
+	cfg := Config{Seed: 42, GateFailureRate: 0.5, SlotDropRate: 0.5}
+	draw := func() []Event {
+		i := New(cfg)
+		for n := 0; n < 20; n++ {
+			_ = i.GateFault("build")
+			_ = i.MergeSlotFault("acquire")
+		}
+		return i.Events()
+	}
+	first, second := draw(), draw()
+	if len(first) == 0 || len(first) == 40 {
+		t.Fatalf("rate 0.5 injected %d of 40 faults", len(first))
+	}
+	if len(first) != len(second) {
+		t.Fatalf("same seed gave %d then %d faults", len(first), len(second))
+	}
+	for n := range first {
+		if first[n] != second[n] {
+			t.Errorf("fault %d differs: %v vs %v", n, first[n], second[n])
+		}
+	}
+
+	never := New(Config{Seed: 1})
+	if never.GateFault("build") != nil || never.MergeSlotFault("release") != nil {
+		t.Error("zero rates should never inject")
+	}
+}
+
+func TestInstallPushDelay(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	i := New(Config{Seed: 1, PushDelay: 250 * time.Millisecond, PushDelayRate: 0.4})
+	if err := i.InstallPushDelay(dir); err != nil {
+		t.Fatal(err)
+	}
+	hook, err := os.ReadFile(filepath.Join(dir, ".git", "hooks", "pre-push"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(hook), "-lt 40") || !strings.Contains(string(hook), "sleep 0.250") {
+		t.Errorf("unexpected hook:\n%s", hook)
+	}
+}
+
+func TestLedger_Check(t *testing.T) {
+	mr := func(id string) *refinery.MRInfo { return &refinery.MRInfo{ID: id} }
+	l := NewLedger()
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		l.Submitted(id, "feat: "+id)
+	}
+	l.Record(&refinery.BatchResult{Merged: []*refinery.MRInfo{mr("a"), mr("d"), mr("f")}, Culprits: []*refinery.MRInfo{mr("b")}})
+	l.Record(&refinery.BatchResult{Merged: []*refinery.MRInfo{mr("d")}, Conflicts: []*refinery.MRInfo{mr("f")}})
+
+	// c queued; e lost; d merged twice; f merged and rejected; a landed twice.
+	errs := l.Check([]string{"c"}, []string{"feat: a", "feat: a", "feat: d", "feat: f", "initial commit"})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	joined := strings.Join(got, "\n")
+	for _, want := range []string{"lost MR e", "MR d merged 2 times", "MR f both merged and rejected", "MR a landed 2 times"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing %q in:\n%s", want, joined)
+		}
+	}
+	if len(errs) != 4 {
+		t.Errorf("got %d violations, want 4:\n%s", len(errs), joined)
+	}
+
+	clean := NewLedger()
+	clean.Submitted("a", "feat: a")
+	clean.Record(&refinery.BatchResult{Merged: []*refinery.MRInfo{mr("a")}})
+	if errs := clean.Check(nil, []string{"feat: a"}); len(errs) != 0 {
+		t.Errorf("clean run reported %v", errs)
+	}
+	if errs := clean.Check(nil, nil); len(errs) != 1 {
+		t.Errorf("merge missing from target should be reported, got %v", errs)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build integration
+
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/testutil/chaos"
+)
+
+// TestChaos_QueueInvariantsHold runs agents and the refinery under injected
+// faults and checks that every MR is accounted for and lands at most once.
+func TestChaos_QueueInvariantsHold(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3} {
+		t.Run(fmt.Sprintf("seed-%d", seed), func(t *testing.T) {
+			h := New(t)
+			h.Configure(`{"gates": {"build": {"cmd": "true"}}}`)
+			h.EnableChaos(chaos.Config{
+				Seed:            seed,
+				KillRate:        0.5,
+				GateFailureRate: 0.2,
+				SlotDropRate:    0.3,
+				PushDelay:       50 * time.Millisecond,
+				PushDelayRate:   0.5,
+			})
+
+			for i := 1; i <= 4; i++ {
+				worker := fmt.Sprintf("p%d", i)
+				h.Assign(worker, fmt.Sprintf(`
+branch polecat/%[1]s
+sleep 100ms
+write %[1]s.txt %[1]s\n
+commit feat: %[1]s work
+push
+submit
+`, worker))
+			}
+
+			// Kill agents mid-script; the witness restarts them.
+			for round := 0; round < 3; round++ {
+				if worker, ok := h.KillRandomAgent(); ok {
+					t.Logf("killed %s", worker)
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+			for _, worker := range h.Workers() {
+				for attempt := 0; ; attempt++ {
+					err := h.WaitForAgent(worker, time.Minute)
+					if err == nil {
+						break
+					}
+					if attempt == 3 || !strings.Contains(err.Error(), "session gone") {
+						t.Fatal(err)
+					}
+					h.Restart(worker)
+				}
+			}
+			h.WaitForSubmissions(4, 10*time.Second)
+
+			// Keep running the refinery until the queue drains; MRs left
+			// queued by a dropped slot are retried.
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+			for round := 0; round < 10 && len(h.Submissions()) > 0; round++ {
+				h.RunRefinery(ctx)
+			}
+			if errs := h.CheckInvariants("main"); len(errs) > 0 {
+				t.Fatalf("invariants violated (faults %v): %v", h.Chaos.Events(), errors.Join(errs...))
+			}
+			if len(h.Ledger.Merged()) == 0 {
+				t.Errorf("nothing merged under chaos (faults %v)", h.Chaos.Events())
+			}
+		})
+	}
+}
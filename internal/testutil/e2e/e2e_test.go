@@ -0,0 +1,73 @@
+//go:build integration
+
+package e2e
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAssignmentToMerge drives the whole pipeline with scripted agents: two
+// polecats land clean work, a third breaks the build and is bisected out.
+func TestAssignmentToMerge(t *testing.T) {
+	h := New(t)
+	h.Configure(`{"gates": {"build": {"cmd": "test ! -f BROKEN"}}}`)
+
+	h.Assign("nux", `
+say picking up gt-1
+branch polecat/nux
+write nux.txt nux\n
+commit feat: nux work
+push
+submit
+`)
+	h.Assign("slit", `
+branch polecat/slit
+write slit.txt slit\n
+commit feat: slit work
+push
+submit main
+`)
+	h.Assign("dag", `
+branch polecat/dag
+write BROKEN oops\n
+commit feat: dag work
+push
+submit
+`)
+	for _, worker := range []string{"nux", "slit", "dag"} {
+		if err := h.WaitForAgent(worker, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !strings.Contains(h.Capture("nux"), "picking up gt-1") {
+		t.Errorf("nux pane missing its output:\n%s", h.Capture("nux"))
+	}
+	if subs := h.WaitForSubmissions(3, 10*time.Second); len(subs) != 3 {
+		t.Fatalf("submissions = %d, want 3", len(subs))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	result := h.RunRefinery(ctx)["main"]
+	if result == nil || result.Error != nil {
+		t.Fatalf("batch failed: %+v", result)
+	}
+	if len(result.Merged) != 2 || len(result.Culprits) != 1 || result.Culprits[0].Worker != "dag" {
+		t.Fatalf("merged %d, culprits %v; want nux and slit merged, dag rejected", len(result.Merged), result.Culprits)
+	}
+
+	for _, file := range []string{"nux.txt", "slit.txt"} {
+		if _, ok := h.OriginFile("main", file); !ok {
+			t.Errorf("origin/main missing %s; log: %v", file, h.OriginLog("main"))
+		}
+	}
+	if _, ok := h.OriginFile("main", "BROKEN"); ok {
+		t.Error("culprit's change landed on origin/main")
+	}
+	if rest := h.Submissions(); len(rest) != 0 {
+		t.Errorf("queue not drained: %d left", len(rest))
+	}
+}
@@ -0,0 +1,466 @@
+// Package e2e boots a miniature town for pipeline tests: a rig whose
+// polecats are gt-fake-agent processes in an isolated tmux server, and a
+// refinery that batches and merges what they submit, in process.
+//
+// A test assigns scripted work, waits for the merge requests, and runs the
+// refinery against them, all without an LLM:
+//
+//	h := e2e.New(t)
+//	h.Assign("nux", "branch polecat/nux\nwrite a.txt a\ncommit add a\nsubmit")
+//	h.WaitForSubmissions(1, time.Minute)
+//	result := h.RunRefinery(ctx)["main"]
+//
+// The harness covers agent sessions, git, batching, gates and landing on
+// origin. It does not boot the daemon or use beads: Assign starts the
+// agent directly rather than through gt sling, a submit step writes the
+// merge request to a file queue (fakeagent.EnvQueue) in place of gt mq
+// submit, and RunRefinery processes one batch per call in place of the
+// refinery patrol.
+//
+// The tests are built only with -tags=integration (make test-e2e-agents).
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/testutil/chaos"
+	"github.com/steveyegge/gastown/internal/testutil/fakeagent"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// RigName is the name of the harness rig.
+const RigName = "e2erig"
+
+// Harness is a running miniature town.
+type Harness struct {
+	t testing.TB
+
+	Root     string // Town root
+	Origin   string // Bare repository the rig tracks
+	RigPath  string // <Root>/<RigName>
+	Queue    string // Directory fake agents submit merge requests to
+	Socket   string // Isolated tmux socket name
+	Tmux     *tmux.Tmux
+	Engineer *refinery.Engineer
+	Ledger   *chaos.Ledger   // Every MR submitted and what became of it
+	Chaos    *chaos.Injector // Set by EnableChaos
+
+	agentBin string
+	workers  []string
+
+	mu     sync.Mutex
+	output bytes.Buffer // Refinery output
+}
+
+// New creates a town with one rig, tracking a fresh origin with a single
+// commit on main. It skips the test if git or tmux is unavailable.
+// Everything is torn down when the test ends.
+func New(t testing.TB) *Harness {
+	t.Helper()
+	for _, bin := range []string{"git", "tmux", "go"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("e2e harness needs %s: %v", bin, err)
+		}
+	}
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolving temp dir: %v", err)
+	}
+	h := &Harness{
+		t:        t,
+		Root:     root,
+		Origin:   filepath.Join(root, "origin.git"),
+		RigPath:  filepath.Join(root, RigName),
+		Queue:    filepath.Join(root, ".runtime", "fake-agent-queue"),
+		Socket:   fmt.Sprintf("gt-e2e-%d-%d", os.Getpid(), time.Now().UnixNano()%1e6),
+		Ledger:   chaos.NewLedger(),
+		agentBin: buildFakeAgent(t),
+	}
+	h.Tmux = tmux.NewTmuxWithSocket(h.Socket)
+	t.Cleanup(h.Close)
+
+	// Origin with a deterministic initial commit on main.
+	seed := filepath.Join(root, "seed")
+	h.git(root, "init", "--bare", "--initial-branch=main", h.Origin)
+	h.git(root, "clone", "-q", h.Origin, seed)
+	h.git(seed, "checkout", "-q", "-b", "main")
+	h.writeFile(filepath.Join(seed, "README.md"), "# e2e\n")
+	h.git(seed, "add", ".")
+	h.git(seed, "commit", "-q", "-m", "initial commit")
+	h.git(seed, "push", "-q", "-u", "origin", "main")
+
+	// The refinery's clone. Polecats are worktrees of it, as in a real rig,
+	// so the branches they create are visible to the refinery.
+	refineryDir := filepath.Join(h.RigPath, "refinery", "rig")
+	if err := os.MkdirAll(filepath.Dir(refineryDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	h.git(root, "clone", "-q", h.Origin, refineryDir)
+	h.git(refineryDir, "config", "user.name", "Refinery")
+	h.git(refineryDir, "config", "user.email", "refinery@gastown.invalid")
+
+	h.Engineer = refinery.NewEngineer(&rig.Rig{Name: RigName, Path: h.RigPath})
+	h.Engineer.SetOutput(&lockedWriter{mu: &h.mu, w: &h.output})
+	h.Engineer.UseLocalMergeSlot()
+	return h
+}
+
+// Configure writes the rig's merge_queue config (a JSON object) and
+// reloads it into the refinery.
+func (h *Harness) Configure(mergeQueue string) {
+	h.t.Helper()
+	h.writeFile(filepath.Join(h.RigPath, "config.json"), `{"merge_queue": `+mergeQueue+`}`)
+	if err := h.Engineer.LoadConfig(); err != nil {
+		h.t.Fatalf("loading merge queue config: %v", err)
+	}
+}
+
+// Assign starts a polecat named worker in its own worktree and tmux
+// session, running script (see package fakeagent) in place of an agent.
+func (h *Harness) Assign(worker, script string) {
+	h.t.Helper()
+	if _, err := fakeagent.Parse(strings.NewReader(script)); err != nil {
+		h.t.Fatalf("script for %s: %v", worker, err)
+	}
+	h.writeFile(h.scriptPath(worker), script)
+
+	workDir := h.workDir(worker)
+	if err := os.MkdirAll(filepath.Dir(workDir), 0755); err != nil {
+		h.t.Fatal(err)
+	}
+	refineryDir := filepath.Join(h.RigPath, "refinery", "rig")
+	h.git(refineryDir, "fetch", "-q", "origin")
+	h.git(refineryDir, "worktree", "add", "-q", "--detach", workDir, "origin/main")
+
+	h.startAgent(worker)
+	h.workers = append(h.workers, worker)
+}
+
+// Restart starts a worker's agent again in its existing worktree, as the
+// witness does for a polecat whose session died. The script reruns from
+// the top and picks up where the last run stopped.
+func (h *Harness) Restart(worker string) {
+	h.t.Helper()
+	_ = h.Tmux.KillSession(h.SessionName(worker))
+	// A git command killed mid-flight leaves its lock behind.
+	gitDir := h.git(h.workDir(worker), "rev-parse", "--absolute-git-dir")
+	_ = os.Remove(filepath.Join(gitDir, "index.lock"))
+	h.startAgent(worker)
+}
+
+func (h *Harness) startAgent(worker string) {
+	h.t.Helper()
+	session := h.SessionName(worker)
+	env := map[string]string{
+		"GT_ROLE":             "polecat",
+		"GT_RIG":              RigName,
+		"GT_POLECAT":          worker,
+		"GT_TOWN_ROOT":        h.Root,
+		fakeagent.EnvScript:   h.scriptPath(worker),
+		fakeagent.EnvQueue:    h.Queue,
+		fakeagent.EnvName:     worker,
+		"GIT_TERMINAL_PROMPT": "0",
+	}
+	if err := h.Tmux.NewSessionWithCommandAndEnv(session, h.workDir(worker), "exec "+h.agentBin, env); err != nil {
+		h.t.Fatalf("starting agent %s: %v", worker, err)
+	}
+	// Keep the pane readable if the script exits.
+	_ = h.Tmux.SetRemainOnExit(session, true)
+}
+
+func (h *Harness) scriptPath(worker string) string {
+	return filepath.Join(h.Root, ".runtime", "scripts", worker+".fake")
+}
+
+func (h *Harness) workDir(worker string) string {
+	return filepath.Join(h.RigPath, "polecats", worker, RigName)
+}
+
+// Workers returns the workers assigned so far.
+func (h *Harness) Workers() []string {
+	return append([]string(nil), h.workers...)
+}
+
+// SessionName returns the tmux session a worker's agent runs in.
+func (h *Harness) SessionName(worker string) string {
+	return "gt-" + RigName + "-" + worker
+}
+
+// WaitForAgent waits for a worker's agent to finish its script. It
+// returns an error if the script failed, or the agent exited without
+// finishing it.
+func (h *Harness) WaitForAgent(worker string, timeout time.Duration) error {
+	h.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		pane, err := h.Tmux.CapturePaneAll(h.SessionName(worker))
+		switch {
+		case err != nil:
+			return fmt.Errorf("agent %s: session gone: %w", worker, err)
+		case strings.Contains(pane, fakeagent.DoneMarker):
+			return nil
+		case strings.Contains(pane, fakeagent.FailedMarker):
+			return fmt.Errorf("agent %s failed:\n%s", worker, pane)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("agent %s still running after %v:\n%s", worker, timeout, pane)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Capture returns what a worker's agent has printed.
+func (h *Harness) Capture(worker string) string {
+	out, err := h.Tmux.CapturePaneAll(h.SessionName(worker))
+	if err != nil {
+		return fmt.Sprintf("(capture failed: %v)", err)
+	}
+	return out
+}
+
+// Submissions returns the merge requests waiting in the queue.
+func (h *Harness) Submissions() []*fakeagent.Submission {
+	h.t.Helper()
+	subs, err := fakeagent.ReadQueue(h.Queue)
+	if err != nil {
+		h.t.Fatalf("reading queue: %v", err)
+	}
+	return subs
+}
+
+// WaitForSubmissions waits until at least n merge requests are queued.
+func (h *Harness) WaitForSubmissions(n int, timeout time.Duration) []*fakeagent.Submission {
+	h.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		if subs := h.Submissions(); len(subs) >= n {
+			return subs
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("%d of %d merge requests submitted after %v", len(h.Submissions()), n, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// RunRefinery processes one batch of the queued merge requests for each
+// target, as the refinery patrol does, and removes them from the queue.
+// It returns the results by target.
+func (h *Harness) RunRefinery(ctx context.Context) map[string]*refinery.BatchResult {
+	h.t.Helper()
+	byTarget := make(map[string][]*refinery.MRInfo)
+	var targets []string
+	for _, sub := range h.Submissions() {
+		h.Ledger.Submitted(sub.ID, sub.Title)
+		if _, ok := byTarget[sub.Target]; !ok {
+			targets = append(targets, sub.Target)
+		}
+		byTarget[sub.Target] = append(byTarget[sub.Target], &refinery.MRInfo{
+			ID:        sub.ID,
+			Branch:    sub.Branch,
+			Target:    sub.Target,
+			Worker:    sub.Worker,
+			Rig:       RigName,
+			Title:     sub.Title,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	results := make(map[string]*refinery.BatchResult)
+	for _, target := range targets {
+		result := h.Engineer.ProcessBatch(ctx, byTarget[target], target, nil)
+		results[target] = result
+		h.Ledger.Record(result)
+		for _, mrs := range [][]*refinery.MRInfo{result.Merged, result.Culprits, result.Conflicts} {
+			for _, mr := range mrs {
+				if err := fakeagent.Remove(h.Queue, mr.ID); err != nil {
+					h.t.Fatalf("dequeuing %s: %v", mr.ID, err)
+				}
+			}
+		}
+	}
+	return results
+}
+
+// EnableChaos starts injecting the faults cfg describes into the refinery
+// and git pushes. Agent sessions are killed by KillRandomAgent. The seed is
+// logged so a failing run can be replayed.
+func (h *Harness) EnableChaos(cfg chaos.Config) *chaos.Injector {
+	h.t.Helper()
+	h.Chaos = chaos.New(cfg)
+	h.t.Logf("chaos seed: %d", h.Chaos.Seed())
+	if err := h.Chaos.InstallPushDelay(filepath.Join(h.RigPath, "refinery", "rig")); err != nil {
+		h.t.Fatalf("installing push delay: %v", err)
+	}
+	h.Engineer.SetFaultInjector(h.Chaos)
+	return h.Chaos
+}
+
+// KillRandomAgent may kill one agent's session, at the chaos kill rate.
+// It returns the worker killed, if any.
+func (h *Harness) KillRandomAgent() (string, bool) {
+	if h.Chaos == nil {
+		return "", false
+	}
+	sessions := make([]string, len(h.workers))
+	for i, worker := range h.workers {
+		sessions[i] = h.SessionName(worker)
+	}
+	session, ok := h.Chaos.Kill(h.Tmux, sessions)
+	return strings.TrimPrefix(session, "gt-"+RigName+"-"), ok
+}
+
+// CheckInvariants checks the ledger against the queue and target branch
+// (see chaos.Ledger.Check).
+func (h *Harness) CheckInvariants(target string) []error {
+	h.t.Helper()
+	var queued []string
+	for _, sub := range h.Submissions() {
+		h.Ledger.Submitted(sub.ID, sub.Title)
+		queued = append(queued, sub.ID)
+	}
+	return h.Ledger.Check(queued, h.OriginLog(target))
+}
+
+// RefineryOutput returns everything the refinery has printed.
+func (h *Harness) RefineryOutput() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.output.String()
+}
+
+// OriginLog returns the subjects of the commits on a branch of origin,
+// newest first.
+func (h *Harness) OriginLog(branch string) []string {
+	h.t.Helper()
+	out := h.git(h.Origin, "log", "--format=%s", branch)
+	return strings.Split(out, "\n")
+}
+
+// OriginFile returns a file's content on a branch of origin.
+func (h *Harness) OriginFile(branch, path string) (string, bool) {
+	cmd := exec.Command("git", "show", branch+":"+path)
+	cmd.Dir = h.Origin
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return string(out), true
+}
+
+// Close kills the harness's tmux server. It is registered as a test
+// cleanup by New.
+func (h *Harness) Close() {
+	if h.t.Failed() {
+		for _, worker := range h.workers {
+			h.t.Logf("agent %s:\n%s", worker, h.Capture(worker))
+		}
+		h.t.Logf("refinery:\n%s", h.RefineryOutput())
+		if h.Chaos != nil {
+			h.t.Logf("chaos seed %d, faults: %v", h.Chaos.Seed(), h.Chaos.Events())
+		}
+	}
+	if h.Tmux != nil {
+		_ = h.Tmux.KillServer()
+	}
+}
+
+func (h *Harness) git(dir string, args ...string) string {
+	h.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Harness", "GIT_AUTHOR_EMAIL=harness@gastown.invalid",
+		"GIT_COMMITTER_NAME=Harness", "GIT_COMMITTER_EMAIL=harness@gastown.invalid",
+		"GIT_AUTHOR_DATE=2026-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2026-01-01T00:00:00Z",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		h.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (h *Harness) writeFile(path, content string) {
+	h.t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil { //nolint:gosec // G306: test fixture
+		h.t.Fatal(err)
+	}
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  *bytes.Buffer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+var (
+	buildOnce sync.Once
+	builtBin  string
+	buildErr  error
+)
+
+// buildFakeAgent builds gt-fake-agent once per test binary.
+func buildFakeAgent(t testing.TB) string {
+	t.Helper()
+	buildOnce.Do(func() {
+		root, err := projectRoot()
+		if err != nil {
+			buildErr = err
+			return
+		}
+		dir, err := os.MkdirTemp("", "gt-fake-agent-")
+		if err != nil {
+			buildErr = err
+			return
+		}
+		builtBin = filepath.Join(dir, "gt-fake-agent")
+		cmd := exec.Command("go", "build", "-o", builtBin, "./internal/testutil/fakeagent/cmd/gt-fake-agent")
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			buildErr = fmt.Errorf("building gt-fake-agent: %v\n%s", err, out)
+		}
+	})
+	if buildErr != nil {
+		t.Fatal(buildErr)
+	}
+	return builtBin
+}
+
+// projectRoot walks up from the working directory to the module root.
+func projectRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("could not find project root (go.mod)")
+		}
+		dir = parent
+	}
+}
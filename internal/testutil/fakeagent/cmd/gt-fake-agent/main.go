@@ -0,0 +1,71 @@
+// gt-fake-agent runs a fakeagent script in place of an LLM agent. It is
+// started in an agent's tmux session by end-to-end tests.
+//
+// The script path is the first argument, or GT_FAKE_AGENT_SCRIPT. The agent
+// works in the current directory, submits to GT_FAKE_AGENT_QUEUE and
+// records itself as GT_FAKE_AGENT_NAME (default: GT_POLECAT). When the
+// script ends it prints a status line and, unless the script ran an exit
+// step, idles until signalled, as an agent waiting at its prompt would.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/steveyegge/gastown/internal/testutil/fakeagent"
+)
+
+func main() {
+	path := os.Getenv(fakeagent.EnvScript)
+	if len(os.Args) > 1 {
+		path = os.Args[1]
+	}
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "usage: gt-fake-agent <script> (or set %s)\n", fakeagent.EnvScript)
+		os.Exit(2)
+	}
+	script, err := fakeagent.ParseFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gt-fake-agent: %v\n", err)
+		os.Exit(2)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gt-fake-agent: %v\n", err)
+		os.Exit(1)
+	}
+	name := os.Getenv(fakeagent.EnvName)
+	if name == "" {
+		name = os.Getenv("GT_POLECAT")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+
+	err = script.Run(ctx, fakeagent.Env{
+		Dir:   dir,
+		Name:  name,
+		Queue: os.Getenv(fakeagent.EnvQueue),
+		Out:   os.Stdout,
+	})
+	var exitErr *fakeagent.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.Code == 0 {
+			fmt.Println(fakeagent.DoneMarker)
+		} else {
+			fmt.Printf("%s exit %d\n", fakeagent.FailedMarker, exitErr.Code)
+		}
+		os.Exit(exitErr.Code)
+	}
+	if err != nil {
+		fmt.Printf("%s %v\n", fakeagent.FailedMarker, err)
+	} else {
+		fmt.Println(fakeagent.DoneMarker)
+	}
+	<-ctx.Done()
+}
@@ -0,0 +1,329 @@
+// Package fakeagent is a scripted stand-in for an LLM agent, for end-to-end
+// tests that exercise assignment → MR → batch → merge without a model.
+//
+// A script is a list of steps, one per line; blank lines and lines starting
+// with # are ignored:
+//
+//	say <text>               print text to the agent's pane
+//	branch <name>            create (or reset to HEAD) and switch to a branch
+//	write <path> <content>   write a file (\n and \t in content are unescaped)
+//	commit <message>         stage everything and commit, if anything changed
+//	push                     push the current branch to origin
+//	submit [target]          queue the current branch as a merge request
+//	run <command>            run a shell command; a failure fails the script
+//	sleep <duration>         pause (Go duration, e.g. 500ms)
+//	idle                     wait until killed, like an agent at its prompt
+//	exit <code>              stop with the given exit status
+//
+// Commits use a fixed author and dates, so the same script against the same
+// base always produces the same commit SHAs. Scripts can be rerun in the
+// same worktree after being killed part way: every step picks up where the
+// previous run left off, and a resubmitted merge request keeps its ID.
+package fakeagent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by the gt-fake-agent binary.
+const (
+	EnvScript = "GT_FAKE_AGENT_SCRIPT" // Path to the script to run
+	EnvQueue  = "GT_FAKE_AGENT_QUEUE"  // Directory submit writes merge requests to
+	EnvName   = "GT_FAKE_AGENT_NAME"   // Worker name recorded on submissions
+)
+
+// Status lines gt-fake-agent prints when its script ends. Like a real agent
+// back at its prompt, it then stays up until its session is killed.
+const (
+	DoneMarker   = "gt-fake-agent: done"
+	FailedMarker = "gt-fake-agent: failed:"
+)
+
+// commitDate is the author and committer date of every scripted commit.
+const commitDate = "2026-01-01T00:00:00Z"
+
+// Step is one parsed script line.
+type Step struct {
+	Line int
+	Verb string
+	Args []string // Verb-specific; write has {path, content}
+}
+
+// Script is a parsed fake agent script.
+type Script struct {
+	Steps []Step
+}
+
+// ExitError is returned by Run when the script reaches an exit step.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit %d", e.Code)
+}
+
+// Parse reads a script, validating each step.
+func Parse(r io.Reader) (*Script, error) {
+	s := &Script{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		verb, rest, _ := strings.Cut(text, " ")
+		rest = strings.TrimSpace(rest)
+		step := Step{Line: line, Verb: verb}
+		switch verb {
+		case "say", "commit", "run":
+			if rest == "" && verb != "say" {
+				return nil, fmt.Errorf("line %d: %s needs an argument", line, verb)
+			}
+			step.Args = []string{rest}
+		case "branch":
+			if rest == "" || strings.Contains(rest, " ") {
+				return nil, fmt.Errorf("line %d: branch needs a single name", line)
+			}
+			step.Args = []string{rest}
+		case "write":
+			path, content, ok := strings.Cut(rest, " ")
+			if !ok || path == "" {
+				return nil, fmt.Errorf("line %d: write needs a path and content", line)
+			}
+			step.Args = []string{path, unescape(content)}
+		case "push", "idle":
+			if rest != "" {
+				return nil, fmt.Errorf("line %d: %s takes no arguments", line, verb)
+			}
+		case "submit":
+			if rest != "" {
+				step.Args = []string{rest}
+			}
+		case "sleep":
+			if _, err := time.ParseDuration(rest); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			step.Args = []string{rest}
+		case "exit":
+			if _, err := strconv.Atoi(rest); err != nil {
+				return nil, fmt.Errorf("line %d: exit needs a status code", line)
+			}
+			step.Args = []string{rest}
+		default:
+			return nil, fmt.Errorf("line %d: unknown step %q", line, verb)
+		}
+		s.Steps = append(s.Steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParseFile parses the script at path.
+func ParseFile(path string) (*Script, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: test script path
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\\`, `\`).Replace(s)
+}
+
+// Env is where and as whom a script runs.
+type Env struct {
+	Dir   string    // Git working tree the agent works in
+	Name  string    // Worker name, recorded on submissions
+	Queue string    // Directory submissions are written to
+	Out   io.Writer // Agent output (say, command output)
+}
+
+// Run executes the script's steps in order, stopping at the first failure.
+// An exit step ends the run with an *ExitError carrying its status.
+func (s *Script) Run(ctx context.Context, env Env) error {
+	out := env.Out
+	if out == nil {
+		out = io.Discard
+	}
+	submitted := 0
+	for _, step := range s.Steps {
+		var err error
+		switch step.Verb {
+		case "say":
+			_, _ = fmt.Fprintln(out, step.Args[0])
+		case "branch":
+			err = env.git(ctx, out, "checkout", "-q", "-B", step.Args[0])
+		case "write":
+			path := filepath.Join(env.Dir, step.Args[0])
+			if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+				err = os.WriteFile(path, []byte(step.Args[1]), 0644) //nolint:gosec // G306: test fixture
+			}
+		case "commit":
+			if err = env.git(ctx, out, "add", "-A"); err != nil {
+				break
+			}
+			// Nothing staged: already committed by an earlier run.
+			if env.git(ctx, io.Discard, "diff", "--cached", "--quiet") == nil {
+				break
+			}
+			err = env.git(ctx, out, "commit", "-q", "-m", step.Args[0])
+		case "push":
+			err = env.git(ctx, out, "push", "-q", "-u", "origin", "HEAD")
+		case "submit":
+			target := "main"
+			if len(step.Args) > 0 {
+				target = step.Args[0]
+			}
+			submitted++
+			err = env.submit(ctx, submitted, target)
+		case "run":
+			cmd := exec.CommandContext(ctx, "sh", "-c", step.Args[0])
+			cmd.Dir = env.Dir
+			cmd.Stdout = out
+			cmd.Stderr = out
+			err = cmd.Run()
+		case "sleep":
+			d, _ := time.ParseDuration(step.Args[0])
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		case "idle":
+			<-ctx.Done()
+			return nil
+		case "exit":
+			code, _ := strconv.Atoi(step.Args[0])
+			return &ExitError{Code: code}
+		}
+		if err != nil {
+			return fmt.Errorf("line %d (%s): %w", step.Line, step.Verb, err)
+		}
+	}
+	return nil
+}
+
+func (env Env) git(ctx context.Context, out io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = env.Dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Fake Agent", "GIT_AUTHOR_EMAIL=fake-agent@gastown.invalid",
+		"GIT_COMMITTER_NAME=Fake Agent", "GIT_COMMITTER_EMAIL=fake-agent@gastown.invalid",
+		"GIT_AUTHOR_DATE="+commitDate, "GIT_COMMITTER_DATE="+commitDate,
+	)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+func (env Env) gitOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = env.Dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// Submission is a merge request queued by a submit step.
+type Submission struct {
+	ID     string `json:"id"`
+	Worker string `json:"worker"`
+	Branch string `json:"branch"`
+	Target string `json:"target"`
+	Commit string `json:"commit"`
+	Title  string `json:"title"`
+}
+
+func (env Env) submit(ctx context.Context, n int, target string) error {
+	if env.Queue == "" {
+		return fmt.Errorf("no queue directory (set %s)", EnvQueue)
+	}
+	branch, err := env.gitOutput(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return fmt.Errorf("current branch: %w", err)
+	}
+	commit, err := env.gitOutput(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("current commit: %w", err)
+	}
+	title, _ := env.gitOutput(ctx, "log", "-1", "--format=%s")
+	name := env.Name
+	if name == "" {
+		name = "agent"
+	}
+	sub := &Submission{
+		ID:     fmt.Sprintf("fake-%s-%d", name, n),
+		Worker: name,
+		Branch: branch,
+		Target: target,
+		Commit: commit,
+		Title:  title,
+	}
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(env.Queue, 0755); err != nil {
+		return err
+	}
+	// Write then rename, so readers never see a partial submission.
+	path := filepath.Join(env.Queue, sub.ID+".json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil { //nolint:gosec // G306: test fixture
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// ReadQueue returns the submissions in dir, ordered by ID.
+func ReadQueue(dir string) ([]*Submission, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var subs []*Submission
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var sub Submission
+		if err := json.Unmarshal(data, &sub); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		subs = append(subs, &sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs, nil
+}
+
+// Remove deletes a submission from the queue in dir.
+func Remove(dir, id string) error {
+	err := os.Remove(filepath.Join(dir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
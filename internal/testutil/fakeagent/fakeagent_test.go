@@ -0,0 +1,166 @@
+package fakeagent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse(strings.NewReader(`
+# comment
+say hello
+branch polecat/nux
+write src/a.txt one\ntwo
+commit feat: add a
+push
+submit
+sleep 10ms
+exit 3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verbs []string
+	for _, step := range s.Steps {
+		verbs = append(verbs, step.Verb)
+	}
+	if got := strings.Join(verbs, " "); got != "say branch write commit push submit sleep exit" {
+		t.Errorf("verbs = %s", got)
+	}
+	if w := s.Steps[2]; w.Args[0] != "src/a.txt" || w.Args[1] != "one\ntwo" {
+		t.Errorf("write args = %q", w.Args)
+	}
+
+	for _, bad := range []string{"dance", "branch", "branch a b", "write a.txt", "sleep soon", "exit now", "push origin"} {
+		if _, err := Parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestRun_DeterministicSubmission(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	script, err := Parse(strings.NewReader("say working\nbranch polecat/nux\nwrite a.txt a\\n\ncommit feat: add a\npush\nsubmit\nexit 0\nsay unreachable\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pin the base commit too, so both runs start from the same SHA.
+	t.Setenv("GIT_AUTHOR_DATE", "2026-01-01T00:00:00Z")
+	t.Setenv("GIT_COMMITTER_DATE", "2026-01-01T00:00:00Z")
+
+	run := func() *Submission {
+		tmp := t.TempDir()
+		origin := filepath.Join(tmp, "origin.git")
+		work := filepath.Join(tmp, "work")
+		git(t, tmp, "init", "-q", "--bare", "--initial-branch=main", origin)
+		git(t, tmp, "clone", "-q", origin, work)
+		git(t, work, "checkout", "-q", "-b", "main")
+		git(t, work, "-c", "user.name=T", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "base")
+
+		var out bytes.Buffer
+		queue := filepath.Join(tmp, "queue")
+		env := Env{Dir: work, Name: "nux", Queue: queue, Out: &out}
+		var exitErr *ExitError
+		if err := script.Run(context.Background(), env); !errors.As(err, &exitErr) || exitErr.Code != 0 {
+			t.Fatalf("Run: %v\n%s", err, out.String())
+		}
+		if !strings.Contains(out.String(), "working") || strings.Contains(out.String(), "unreachable") {
+			t.Errorf("unexpected output:\n%s", out.String())
+		}
+		if got := git(t, origin, "rev-parse", "polecat/nux"); got == "" {
+			t.Error("branch not pushed")
+		}
+		subs, err := ReadQueue(queue)
+		if err != nil || len(subs) != 1 {
+			t.Fatalf("ReadQueue = %v, %v", subs, err)
+		}
+		if err := Remove(queue, subs[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if rest, _ := ReadQueue(queue); len(rest) != 0 {
+			t.Errorf("queue not empty after Remove: %v", rest)
+		}
+		return subs[0]
+	}
+
+	first, second := run(), run()
+	if first.ID != "fake-nux-1" || first.Branch != "polecat/nux" || first.Target != "main" || first.Title != "feat: add a" {
+		t.Errorf("submission = %+v", first)
+	}
+	if first.Commit != second.Commit {
+		t.Errorf("commits differ between runs: %s vs %s", first.Commit, second.Commit)
+	}
+}
+
+func TestRun_ExitAndFailure(t *testing.T) {
+	script, err := Parse(strings.NewReader("exit 7"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exitErr *ExitError
+	if err := script.Run(context.Background(), Env{Dir: t.TempDir()}); !errors.As(err, &exitErr) || exitErr.Code != 7 {
+		t.Errorf("Run = %v, want exit 7", err)
+	}
+
+	script, err = Parse(strings.NewReader("run false\nsay unreachable"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := script.Run(context.Background(), Env{Dir: t.TempDir()}); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Run = %v, want a line 1 failure", err)
+	}
+
+	script, _ = Parse(strings.NewReader("submit"))
+	if err := script.Run(context.Background(), Env{Dir: t.TempDir()}); err == nil {
+		t.Error("submit without a queue should fail")
+	}
+}
+
+// A script killed part way can be rerun in the same worktree.
+func TestRun_Rerun(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	work := t.TempDir()
+	git(t, work, "init", "-q", "--initial-branch=main")
+	git(t, work, "-c", "user.name=T", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "base")
+
+	queue := filepath.Join(t.TempDir(), "queue")
+	env := Env{Dir: work, Name: "nux", Queue: queue}
+	partial, _ := Parse(strings.NewReader("branch polecat/nux\nwrite a.txt a\ncommit feat: a"))
+	full, _ := Parse(strings.NewReader("branch polecat/nux\nwrite a.txt a\ncommit feat: a\nsubmit"))
+	if err := partial.Run(context.Background(), env); err != nil {
+		t.Fatal(err)
+	}
+	if err := full.Run(context.Background(), env); err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if err := full.Run(context.Background(), env); err != nil {
+		t.Fatalf("second rerun: %v", err)
+	}
+	if n := git(t, work, "rev-list", "--count", "main..polecat/nux"); n != "1" {
+		t.Errorf("branch has %s commits after reruns, want 1", n)
+	}
+	if subs, _ := ReadQueue(queue); len(subs) != 1 {
+		t.Errorf("queue has %d submissions after reruns, want 1", len(subs))
+	}
+}
@@ -0,0 +1,245 @@
+// Package fakemux is an in-memory tmux.Multiplexer for tests that only need
+// session plumbing: creating sessions, typing into them, reading their
+// panes back and killing them. It needs no tmux server, so such tests run
+// hermetically and in CI.
+//
+// Pane content is scripted: set it with SetPane or AppendPane, or react to
+// keys as they are sent with OnKeys. Every SendKeys is recorded and can be
+// inspected with Keys.
+package fakemux
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Session is a fake session's state.
+type Session struct {
+	Name    string
+	WorkDir string
+	Command string   // Empty for a plain shell (NewSession)
+	Pane    []string // Pane content, one entry per line
+	Keys    []string // Everything sent with SendKeys, in order
+}
+
+// Mux is a fake multiplexer. The zero value is not usable; call New. It is
+// safe for concurrent use.
+type Mux struct {
+	mu        sync.Mutex
+	sessions  map[string]*Session
+	onKeys    func(m *Mux, session, keys string)
+	errs      map[string]error
+	available bool
+}
+
+var _ tmux.Multiplexer = (*Mux)(nil)
+
+// New returns a fake multiplexer with no sessions.
+func New() *Mux {
+	return &Mux{sessions: make(map[string]*Session), errs: make(map[string]error), available: true}
+}
+
+// OnKeys sets a function called after each SendKeys is recorded, to script
+// the session's response, e.g. by appending to its pane. It is called
+// without the Mux's lock held, so it may call any Mux method.
+func (m *Mux) OnKeys(fn func(m *Mux, session, keys string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onKeys = fn
+}
+
+// FailOn makes every call of the named Multiplexer method (e.g.
+// "SendKeys") return err, until cleared with a nil err.
+func (m *Mux) FailOn(method string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		delete(m.errs, method)
+		return
+	}
+	m.errs[method] = err
+}
+
+// SetAvailable sets what IsAvailable reports (true by default).
+func (m *Mux) SetAvailable(available bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.available = available
+}
+
+// SetPane replaces a session's pane content.
+func (m *Mux) SetPane(session, content string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[session]
+	if !ok {
+		return tmux.ErrSessionNotFound
+	}
+	s.Pane = splitLines(content)
+	return nil
+}
+
+// AppendPane adds lines to the end of a session's pane content.
+func (m *Mux) AppendPane(session string, lines ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[session]
+	if !ok {
+		return tmux.ErrSessionNotFound
+	}
+	for _, l := range lines {
+		s.Pane = append(s.Pane, splitLines(l)...)
+	}
+	return nil
+}
+
+// Keys returns everything sent to a session with SendKeys, in order.
+func (m *Mux) Keys(session string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[session]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), s.Keys...)
+}
+
+// Session returns a copy of a session's state, or nil if it doesn't exist.
+func (m *Mux) Session(name string) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[name]
+	if !ok {
+		return nil
+	}
+	c := *s
+	c.Pane = append([]string(nil), s.Pane...)
+	c.Keys = append([]string(nil), s.Keys...)
+	return &c
+}
+
+// NewSession creates a session with a shell in workDir.
+func (m *Mux) NewSession(name, workDir string) error {
+	return m.create("NewSession", name, workDir, "")
+}
+
+// NewSessionWithCommand creates a session running command in workDir.
+// Nothing is run: the command is only recorded.
+func (m *Mux) NewSessionWithCommand(name, workDir, command string) error {
+	return m.create("NewSessionWithCommand", name, workDir, command)
+}
+
+func (m *Mux) create(method, name, workDir, command string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs[method]; err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("%w: empty", tmux.ErrInvalidSessionName)
+	}
+	if _, ok := m.sessions[name]; ok {
+		return tmux.ErrSessionExists
+	}
+	m.sessions[name] = &Session{Name: name, WorkDir: workDir, Command: command}
+	return nil
+}
+
+// HasSession reports whether a session exists.
+func (m *Mux) HasSession(name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["HasSession"]; err != nil {
+		return false, err
+	}
+	_, ok := m.sessions[name]
+	return ok, nil
+}
+
+// ListSessions returns the session names, sorted.
+func (m *Mux) ListSessions() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["ListSessions"]; err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range m.sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SendKeys records keys as sent to the session, then calls the OnKeys
+// function, if any.
+func (m *Mux) SendKeys(session, keys string) error {
+	m.mu.Lock()
+	if err := m.errs["SendKeys"]; err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	s, ok := m.sessions[session]
+	if !ok {
+		m.mu.Unlock()
+		return tmux.ErrSessionNotFound
+	}
+	s.Keys = append(s.Keys, keys)
+	onKeys := m.onKeys
+	m.mu.Unlock()
+
+	if onKeys != nil {
+		onKeys(m, session, keys)
+	}
+	return nil
+}
+
+// CapturePane returns the last lines of the session's pane content (all of
+// it if lines <= 0).
+func (m *Mux) CapturePane(session string, lines int) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["CapturePane"]; err != nil {
+		return "", err
+	}
+	s, ok := m.sessions[session]
+	if !ok {
+		return "", tmux.ErrSessionNotFound
+	}
+	pane := s.Pane
+	if lines > 0 && len(pane) > lines {
+		pane = pane[len(pane)-lines:]
+	}
+	return strings.Join(pane, "\n"), nil
+}
+
+// KillSession removes a session. Like the real backends, killing a session
+// that doesn't exist is not an error.
+func (m *Mux) KillSession(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.errs["KillSession"]; err != nil {
+		return err
+	}
+	delete(m.sessions, name)
+	return nil
+}
+
+// IsAvailable reports what SetAvailable set, true by default.
+func (m *Mux) IsAvailable() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.available
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,100 @@
+package fakemux
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+func TestMux_SessionLifecycle(t *testing.T) {
+	m := New()
+
+	if err := m.NewSessionWithCommand("gt-refinery", "/rig", "claude"); err != nil {
+		t.Fatalf("NewSessionWithCommand() error: %v", err)
+	}
+	if err := m.NewSession("gt-refinery", ""); !errors.Is(err, tmux.ErrSessionExists) {
+		t.Errorf("NewSession() of an existing session = %v, want ErrSessionExists", err)
+	}
+	if err := m.NewSession("gt-witness", "/rig"); err != nil {
+		t.Fatalf("NewSession() error: %v", err)
+	}
+	if s := m.Session("gt-refinery"); s.WorkDir != "/rig" || s.Command != "claude" {
+		t.Errorf("Session() = %+v, want workdir /rig running claude", s)
+	}
+
+	names, err := m.ListSessions()
+	if err != nil || !reflect.DeepEqual(names, []string{"gt-refinery", "gt-witness"}) {
+		t.Errorf("ListSessions() = %v, %v", names, err)
+	}
+
+	if err := m.KillSession("gt-witness"); err != nil {
+		t.Fatalf("KillSession() error: %v", err)
+	}
+	if ok, _ := m.HasSession("gt-witness"); ok {
+		t.Error("HasSession() = true after KillSession")
+	}
+	if err := m.KillSession("gt-witness"); err != nil {
+		t.Errorf("KillSession() of a gone session = %v, want nil", err)
+	}
+}
+
+func TestMux_ScriptedPane(t *testing.T) {
+	m := New()
+	if err := m.NewSession("gt-polecat", ""); err != nil {
+		t.Fatal(err)
+	}
+	m.OnKeys(func(m *Mux, session, keys string) {
+		_ = m.AppendPane(session, "> "+keys, "ok: "+strings.ToUpper(keys))
+	})
+
+	if err := m.SendKeys("gt-polecat", "hello"); err != nil {
+		t.Fatalf("SendKeys() error: %v", err)
+	}
+	if err := m.SendKeys("gt-polecat", "again"); err != nil {
+		t.Fatalf("SendKeys() error: %v", err)
+	}
+	if got := m.Keys("gt-polecat"); !reflect.DeepEqual(got, []string{"hello", "again"}) {
+		t.Errorf("Keys() = %v, want [hello again]", got)
+	}
+	out, err := m.CapturePane("gt-polecat", 2)
+	if err != nil || out != "> again\nok: AGAIN" {
+		t.Errorf("CapturePane(2) = %q, %v", out, err)
+	}
+
+	if err := m.SetPane("gt-polecat", "❯ \n"); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := m.CapturePane("gt-polecat", 0); out != "❯ " {
+		t.Errorf("CapturePane() after SetPane = %q", out)
+	}
+	if _, err := m.CapturePane("nope", 10); !errors.Is(err, tmux.ErrSessionNotFound) {
+		t.Errorf("CapturePane() of a missing session = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestMux_FailOn(t *testing.T) {
+	m := New()
+	if err := m.NewSession("gt-mayor", ""); err != nil {
+		t.Fatal(err)
+	}
+	boom := errors.New("boom")
+	m.FailOn("SendKeys", boom)
+	if err := m.SendKeys("gt-mayor", "x"); !errors.Is(err, boom) {
+		t.Errorf("SendKeys() = %v, want injected error", err)
+	}
+	if len(m.Keys("gt-mayor")) != 0 {
+		t.Error("failed SendKeys was recorded")
+	}
+	m.FailOn("SendKeys", nil)
+	if err := m.SendKeys("gt-mayor", "x"); err != nil {
+		t.Errorf("SendKeys() after clearing = %v", err)
+	}
+
+	m.SetAvailable(false)
+	if m.IsAvailable() {
+		t.Error("IsAvailable() = true after SetAvailable(false)")
+	}
+}
@@ -0,0 +1,150 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// EnvMultiplexer selects the terminal multiplexer backend NewMultiplexer
+// returns: "tmux" (the default) or "zellij".
+const EnvMultiplexer = "GT_MULTIPLEXER"
+
+// Multiplexer is the core of what Gas Town needs from a terminal multiplexer
+// to drive agent sessions: create them, type into them, read them back and
+// tear them down. Satisfied by *Tmux and *Zellij.
+//
+// Tmux remains the full-featured backend (themes, bindings, process-tree
+// kills, zombie detection); code that only needs session plumbing should
+// depend on Multiplexer so it runs on either, and can be tested without a
+// tmux server against testutil/fakemux.
+type Multiplexer interface {
+	// NewSession creates a detached session with a shell in workDir.
+	NewSession(name, workDir string) error
+
+	// NewSessionWithCommand creates a detached session running command
+	// in workDir.
+	NewSessionWithCommand(name, workDir, command string) error
+
+	// HasSession reports whether a session with exactly this name exists.
+	HasSession(name string) (bool, error)
+
+	// ListSessions returns the names of all sessions.
+	ListSessions() ([]string, error)
+
+	// SendKeys types keys into the session and presses Enter.
+	SendKeys(session, keys string) error
+
+	// CapturePane returns the last lines of the session's visible content.
+	CapturePane(session string, lines int) (string, error)
+
+	// KillSession terminates a session. Idempotent: returns nil if the
+	// session is already gone.
+	KillSession(name string) error
+
+	// IsAvailable reports whether the backend is installed.
+	IsAvailable() bool
+}
+
+var (
+	_ Multiplexer = (*Tmux)(nil)
+	_ Multiplexer = (*Zellij)(nil)
+)
+
+// NewMultiplexer returns the multiplexer backend selected by GT_MULTIPLEXER,
+// tmux by default.
+func NewMultiplexer() (Multiplexer, error) {
+	switch backend := os.Getenv(EnvMultiplexer); backend {
+	case "", "tmux":
+		return NewTmux(), nil
+	case "zellij":
+		return NewZellij(), nil
+	default:
+		return nil, fmt.Errorf("unknown %s backend %q (want tmux or zellij)", EnvMultiplexer, backend)
+	}
+}
+
+// Selected returns the multiplexer backend selected by GT_MULTIPLEXER, for
+// callers that pick it where they can't return an error, such as
+// constructors. If the backend is unknown, every operation on the returned
+// Multiplexer fails with NewMultiplexer's error.
+func Selected() Multiplexer {
+	m, err := NewMultiplexer()
+	if err != nil {
+		return failedMultiplexer{err: err}
+	}
+	return m
+}
+
+// failedMultiplexer is the Multiplexer of an unknown backend.
+type failedMultiplexer struct {
+	err error
+}
+
+func (f failedMultiplexer) NewSession(string, string) error                    { return f.err }
+func (f failedMultiplexer) NewSessionWithCommand(string, string, string) error { return f.err }
+func (f failedMultiplexer) HasSession(string) (bool, error)                    { return false, f.err }
+func (f failedMultiplexer) ListSessions() ([]string, error)                    { return nil, f.err }
+func (f failedMultiplexer) SendKeys(string, string) error                      { return f.err }
+func (f failedMultiplexer) CapturePane(string, int) (string, error)            { return "", f.err }
+func (f failedMultiplexer) KillSession(string) error                           { return f.err }
+func (f failedMultiplexer) IsAvailable() bool                                  { return false }
+
+// Nudge types message into an agent's session and submits it. On tmux this
+// is NudgeSession, which serializes nudges per session, leaves copy mode and
+// chunks long messages; other backends get the sanitized message via SendKeys.
+func Nudge(m Multiplexer, session, message string) error {
+	if t, ok := m.(*Tmux); ok {
+		return t.NudgeSession(session, message)
+	}
+	return m.SendKeys(session, sanitizeNudgeMessage(message))
+}
+
+// KillWithProcesses terminates an agent's session. On tmux this is
+// KillSessionWithProcesses, which also kills the pane's process tree so no
+// orphaned agent survives; other backends just kill the session.
+func KillWithProcesses(m Multiplexer, session string) error {
+	if t, ok := m.(*Tmux); ok {
+		return t.KillSessionWithProcesses(session)
+	}
+	return m.KillSession(session)
+}
+
+// CheckHealth reports whether an agent's session is healthy. On tmux this is
+// CheckSessionHealth, which also sees dead and hung agents inside a live
+// session; other backends can only tell SessionHealthy from SessionDead.
+// An unreachable backend counts as SessionDead.
+func CheckHealth(m Multiplexer, session string, maxInactivity time.Duration) ZombieStatus {
+	if t, ok := m.(*Tmux); ok {
+		return t.CheckSessionHealth(session, maxInactivity)
+	}
+	if alive, err := m.HasSession(session); err != nil || !alive {
+		return SessionDead
+	}
+	return SessionHealthy
+}
+
+// ErrNeedsTmux is returned for features only tmux provides (themes, pane
+// respawns, session environment and the like) when GT_MULTIPLEXER selects
+// another backend.
+var ErrNeedsTmux = errors.New("needs tmux")
+
+// NeedsTmux returns ErrNeedsTmux for feature, naming the selected backend.
+func NeedsTmux(feature string) error {
+	return fmt.Errorf("%s %w (%s=%s)", feature, ErrNeedsTmux, EnvMultiplexer, os.Getenv(EnvMultiplexer))
+}
+
+// RequireTmux returns the tmux backend for feature, which only tmux
+// provides, or fails fast with NeedsTmux if another backend is selected.
+func RequireTmux(feature string) (*Tmux, error) {
+	m, err := NewMultiplexer()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := m.(*Tmux)
+	if !ok {
+		return nil, NeedsTmux(feature)
+	}
+	return t, nil
+}
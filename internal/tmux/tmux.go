@@ -2795,6 +2795,33 @@ func (t *Tmux) ClearHistory(pane string) error {
 	return err
 }
 
+// PipePane pipes everything the session's first pane outputs from now on to
+// shellCommand's stdin, replacing any pipe already open. Input typed into the
+// pane is not piped.
+func (t *Tmux) PipePane(session, shellCommand string) error {
+	_, err := t.run("pipe-pane", "-t", session+":0.0", "-O", shellCommand)
+	return err
+}
+
+// StopPipePane closes the pipe opened by PipePane, if any. The piped
+// command sees EOF and exits.
+func (t *Tmux) StopPipePane(session string) error {
+	_, err := t.run("pipe-pane", "-t", session+":0.0")
+	return err
+}
+
+// GetPaneSize returns the width and height of the session's first pane.
+func (t *Tmux) GetPaneSize(session string) (width, height int, err error) {
+	out, err := t.run("display-message", "-t", session+":0.0", "-p", "#{pane_width} #{pane_height}")
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(strings.TrimSpace(out), "%d %d", &width, &height); err != nil {
+		return 0, 0, fmt.Errorf("parsing pane size %q: %w", strings.TrimSpace(out), err)
+	}
+	return width, height, nil
+}
+
 // SetRemainOnExit controls whether a pane stays around after its process exits.
 // When on, the pane remains with "[Exited]" status, allowing respawn-pane to restart it.
 // When off (default), the pane is destroyed when its process exits.
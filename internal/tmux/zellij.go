@@ -0,0 +1,217 @@
+package tmux
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// Zellij drives agent sessions with Zellij (0.40+) instead of tmux, for
+// machines where tmux isn't available or wanted. It implements Multiplexer
+// through the zellij CLI; the tmux-only features (themes, key bindings,
+// process-tree kills) have no Zellij equivalent here.
+type Zellij struct {
+	socketDir string // ZELLIJ_SOCKET_DIR, empty = zellij's default
+}
+
+// NewZellij creates a Zellij wrapper on the user's default zellij server.
+func NewZellij() *Zellij {
+	return &Zellij{}
+}
+
+// NewZellijWithSocketDir creates a Zellij wrapper whose sessions live in
+// socketDir, isolated from the user's own. Primarily used in tests.
+func NewZellijWithSocketDir(socketDir string) *Zellij {
+	return &Zellij{socketDir: socketDir}
+}
+
+// run executes a zellij command in dir (if non-empty) and returns stdout.
+func (z *Zellij) run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("zellij", args...)
+	cmd.Dir = dir
+	if z.socketDir != "" {
+		cmd.Env = append(os.Environ(), "ZELLIJ_SOCKET_DIR="+z.socketDir)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", z.wrapError(err, stderr.String(), args)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// action runs a zellij action against session.
+func (z *Zellij) action(session string, args ...string) (string, error) {
+	return z.run("", append([]string{"--session", session, "action"}, args...)...)
+}
+
+// zellijSessionNotFound matches zellij's reports of a missing session:
+// `No session named "x" found.` from attach and kill-session, and
+// `Session 'x' not found.` from actions on --session.
+var zellijSessionNotFound = regexp.MustCompile(`^(No session named "[^"]*" found|Session '[^']*' not found)`)
+
+// wrapError maps zellij errors onto the tmux package's errors.
+func (z *Zellij) wrapError(err error, stderr string, args []string) error {
+	stderr = strings.TrimSpace(stderr)
+	lower := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(lower, "no active zellij sessions"):
+		return ErrNoServer
+	case strings.Contains(lower, "already exists"):
+		return ErrSessionExists
+	case zellijSessionNotFound.MatchString(stderr):
+		return ErrSessionNotFound
+	}
+	if stderr != "" {
+		return fmt.Errorf("zellij %s: %s", args[0], stderr)
+	}
+	return fmt.Errorf("zellij %s: %w", args[0], err)
+}
+
+// NewSession creates a new detached zellij session with a shell in workDir.
+func (z *Zellij) NewSession(name, workDir string) error {
+	if err := validateSessionName(name); err != nil {
+		return err
+	}
+	if ok, err := z.HasSession(name); err != nil {
+		return err
+	} else if ok {
+		return ErrSessionExists
+	}
+	_, err := z.run(workDir, "attach", "--create-background", name)
+	return err
+}
+
+// NewSessionWithCommand creates a new detached zellij session running
+// command in workDir. Zellij can't start a background session on a
+// command, so the command is exec'd from the session's shell: like tmux,
+// the session then ends when the command does.
+func (z *Zellij) NewSessionWithCommand(name, workDir, command string) error {
+	if workDir != "" {
+		info, err := os.Stat(workDir)
+		if err != nil {
+			return fmt.Errorf("invalid work directory %q: %w", workDir, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("work directory %q is not a directory", workDir)
+		}
+	}
+	if err := validateCommandBinary(command); err != nil {
+		return err
+	}
+	if err := z.NewSession(name, workDir); err != nil {
+		return err
+	}
+	if err := z.SendKeys(name, "exec "+command); err != nil {
+		_ = z.KillSession(name)
+		return fmt.Errorf("starting command in session %s: %w", name, err)
+	}
+	return nil
+}
+
+// HasSession checks if a session exists (exact match).
+func (z *Zellij) HasSession(name string) (bool, error) {
+	sessions, err := z.ListSessions()
+	if err != nil {
+		return false, err
+	}
+	for _, s := range sessions {
+		if s == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListSessions returns all running session names. Exited sessions that
+// zellij keeps around for resurrection are not included.
+func (z *Zellij) ListSessions() ([]string, error) {
+	out, err := z.run("", "list-sessions", "--no-formatting")
+	if err != nil {
+		if errors.Is(err, ErrNoServer) {
+			return nil, nil // No sessions
+		}
+		return nil, err
+	}
+	return parseZellijSessions(out), nil
+}
+
+// parseZellijSessions parses the output of zellij list-sessions
+// --no-formatting: one session per line, its name followed by details
+// such as "[Created 2m ago]" and, for resurrectable sessions, "(EXITED".
+func parseZellijSessions(out string) []string {
+	var sessions []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.Contains(line, "(EXITED") {
+			continue
+		}
+		sessions = append(sessions, fields[0])
+	}
+	return sessions
+}
+
+// SendKeys types keys into the session's focused pane and presses Enter,
+// with the same debounce between the two as Tmux.SendKeys.
+func (z *Zellij) SendKeys(session, keys string) error {
+	if _, err := z.action(session, "write-chars", keys); err != nil {
+		return err
+	}
+	time.Sleep(time.Duration(constants.DefaultDebounceMs) * time.Millisecond)
+	_, err := z.action(session, "write", "13") // Enter
+	return err
+}
+
+// CapturePane returns the last lines of the session's focused pane,
+// scrollback included.
+func (z *Zellij) CapturePane(session string, lines int) (string, error) {
+	dir, err := os.MkdirTemp("", "gt-zellij-dump-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	dump := filepath.Join(dir, "screen.txt")
+	if _, err := z.action(session, "dump-screen", "--full", dump); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(dump)
+	if err != nil {
+		return "", fmt.Errorf("reading screen dump: %w", err)
+	}
+	return lastLines(string(data), lines), nil
+}
+
+// lastLines returns the last n lines of s, ignoring trailing blank lines
+// (the unused part of the screen).
+func lastLines(s string, n int) string {
+	all := strings.Split(strings.TrimRight(s, " \t\n"), "\n")
+	if n > 0 && len(all) > n {
+		all = all[len(all)-n:]
+	}
+	return strings.Join(all, "\n")
+}
+
+// KillSession terminates a zellij session and deletes it, so it isn't kept
+// for resurrection. Idempotent: returns nil if the session is already gone.
+func (z *Zellij) KillSession(name string) error {
+	_, err := z.run("", "kill-session", name)
+	if err != nil && !errors.Is(err, ErrSessionNotFound) && !errors.Is(err, ErrNoServer) {
+		return err
+	}
+	_, _ = z.run("", "delete-session", name)
+	return nil
+}
+
+// IsAvailable checks if zellij is installed and can be invoked.
+func (z *Zellij) IsAvailable() bool {
+	return exec.Command("zellij", "--version").Run() == nil
+}
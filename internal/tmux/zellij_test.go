@@ -0,0 +1,143 @@
+package tmux
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseZellijSessions(t *testing.T) {
+	out := strings.Join([]string{
+		"gt-refinery [Created 2m 3s ago]",
+		"hq-mayor [Created 1h ago] (current)",
+		"gt-old [Created 3days ago] (EXITED - attach to resurrect)",
+		"",
+	}, "\n")
+	got := parseZellijSessions(out)
+	want := []string{"gt-refinery", "hq-mayor"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseZellijSessions() = %v, want %v", got, want)
+	}
+	if got := parseZellijSessions(""); got != nil {
+		t.Errorf("parseZellijSessions(\"\") = %v, want nil", got)
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	screen := "one\ntwo\nthree\n\n   \n"
+	if got := lastLines(screen, 2); got != "two\nthree" {
+		t.Errorf("lastLines(2) = %q, want %q", got, "two\nthree")
+	}
+	if got := lastLines(screen, 10); got != "one\ntwo\nthree" {
+		t.Errorf("lastLines(10) = %q, want all lines", got)
+	}
+}
+
+func TestZellij_WrapErrorSessionNotFound(t *testing.T) {
+	z := NewZellij()
+	for stderr, want := range map[string]error{
+		`No session named "gt-witness" found.`:                               ErrSessionNotFound,
+		"Session 'gt-witness' not found. The following sessions are active:": ErrSessionNotFound,
+		"No active zellij sessions found.":                                   ErrNoServer,
+		// Other "not found" errors aren't about the session.
+		"Error: layout file not found": nil,
+		"zellij: command not found":    nil,
+	} {
+		err := z.wrapError(errors.New("exit status 1"), stderr, []string{"action"})
+		if want == nil {
+			if errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("wrapError(%q) = ErrSessionNotFound, want a plain error", stderr)
+			}
+		} else if !errors.Is(err, want) {
+			t.Errorf("wrapError(%q) = %v, want %v", stderr, err, want)
+		}
+	}
+}
+
+func TestNewMultiplexer(t *testing.T) {
+	for backend, want := range map[string]string{"": "*tmux.Tmux", "tmux": "*tmux.Tmux", "zellij": "*tmux.Zellij"} {
+		t.Setenv(EnvMultiplexer, backend)
+		m, err := NewMultiplexer()
+		if err != nil {
+			t.Fatalf("NewMultiplexer() with %q: %v", backend, err)
+		}
+		if got := reflect.TypeOf(m).String(); got != want {
+			t.Errorf("NewMultiplexer() with %q = %s, want %s", backend, got, want)
+		}
+	}
+	t.Setenv(EnvMultiplexer, "screen")
+	if _, err := NewMultiplexer(); err == nil {
+		t.Error("NewMultiplexer() with an unknown backend succeeded")
+	}
+}
+
+func TestRequireTmux(t *testing.T) {
+	t.Setenv(EnvMultiplexer, "")
+	if tm, err := RequireTmux("themes"); err != nil || tm == nil {
+		t.Fatalf("RequireTmux() on tmux = %v, %v", tm, err)
+	}
+
+	t.Setenv(EnvMultiplexer, "zellij")
+	_, err := RequireTmux("themes")
+	if !errors.Is(err, ErrNeedsTmux) {
+		t.Fatalf("RequireTmux() on zellij = %v, want ErrNeedsTmux", err)
+	}
+	if want := "themes needs tmux (GT_MULTIPLEXER=zellij)"; err.Error() != want {
+		t.Errorf("RequireTmux() error = %q, want %q", err, want)
+	}
+
+	t.Setenv(EnvMultiplexer, "screen")
+	if _, err := Selected().HasSession("hq-mayor"); err == nil {
+		t.Error("Selected() with an unknown backend succeeded")
+	}
+	if got := CheckHealth(Selected(), "hq-mayor", 0); got != SessionDead {
+		t.Errorf("CheckHealth() on an unknown backend = %v, want %v", got, SessionDead)
+	}
+}
+
+func TestZellij_SessionLifecycle(t *testing.T) {
+	if _, err := exec.LookPath("zellij"); err != nil {
+		t.Skip("zellij not installed")
+	}
+	z := NewZellijWithSocketDir(t.TempDir())
+	name := "gt-test-zellij"
+	t.Cleanup(func() { _ = z.KillSession(name) })
+
+	if err := z.NewSession(name, t.TempDir()); err != nil {
+		t.Fatalf("NewSession() error: %v", err)
+	}
+	if ok, err := z.HasSession(name); err != nil || !ok {
+		t.Fatalf("HasSession() = %v, %v; want true", ok, err)
+	}
+	if err := z.NewSession(name, ""); err != ErrSessionExists {
+		t.Errorf("NewSession() of an existing session = %v, want ErrSessionExists", err)
+	}
+
+	if err := z.SendKeys(name, "echo zellij-marker-$((6*7))"); err != nil {
+		t.Fatalf("SendKeys() error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		out, err := z.CapturePane(name, 50)
+		if err == nil && strings.Contains(out, "zellij-marker-42") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("CapturePane() = %q, %v; want the echoed marker", out, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := z.KillSession(name); err != nil {
+		t.Fatalf("KillSession() error: %v", err)
+	}
+	if ok, _ := z.HasSession(name); ok {
+		t.Error("HasSession() = true after KillSession")
+	}
+	if err := z.KillSession(name); err != nil {
+		t.Errorf("KillSession() of a gone session = %v, want nil", err)
+	}
+}
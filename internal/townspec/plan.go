@@ -0,0 +1,277 @@
+package townspec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ActionKind is a kind of reconcile step.
+type ActionKind string
+
+// Reconcile steps, in the order Plan emits them for a rig.
+const (
+	ActionAddRig       ActionKind = "add-rig"
+	ActionSetGates     ActionKind = "set-gates"
+	ActionAddCrew      ActionKind = "add-crew"
+	ActionStartCrew    ActionKind = "start-crew"
+	ActionStopCrew     ActionKind = "stop-crew"
+	ActionStopWitness  ActionKind = "stop-witness"
+	ActionStopRefinery ActionKind = "stop-refinery"
+	ActionSetPatrol    ActionKind = "set-patrol"
+	ActionSetBudget    ActionKind = "set-budget"
+)
+
+// Action is one step toward the declared state.
+type Action struct {
+	Kind   ActionKind
+	Rig    string
+	Name   string              // Crew member or patrol name
+	GitURL string              // add-rig
+	Prefix string              // add-rig
+	Gates  map[string]GateSpec // set-gates
+	Patrol *PatrolState        // set-patrol
+	Budget *BudgetSpec         // set-budget
+}
+
+// String describes the action for plan output.
+func (a Action) String() string {
+	switch a.Kind {
+	case ActionAddRig:
+		return fmt.Sprintf("add rig %s (%s)", a.Rig, a.GitURL)
+	case ActionSetGates:
+		names := make([]string, 0, len(a.Gates))
+		for n := range a.Gates {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Sprintf("clear gates on %s", a.Rig)
+		}
+		return fmt.Sprintf("set gates on %s: %s", a.Rig, strings.Join(names, ", "))
+	case ActionAddCrew:
+		return fmt.Sprintf("add crew %s/%s", a.Rig, a.Name)
+	case ActionStartCrew:
+		return fmt.Sprintf("start crew %s/%s", a.Rig, a.Name)
+	case ActionStopCrew:
+		return fmt.Sprintf("stop crew %s/%s", a.Rig, a.Name)
+	case ActionStopWitness:
+		return fmt.Sprintf("stop witness %s", a.Rig)
+	case ActionStopRefinery:
+		return fmt.Sprintf("stop refinery %s", a.Rig)
+	case ActionSetPatrol:
+		desc := fmt.Sprintf("set patrol %s enabled=%t", a.Name, a.Patrol.Enabled)
+		if a.Patrol.Interval != "" {
+			desc += " interval=" + a.Patrol.Interval
+		}
+		if len(a.Patrol.Rigs) > 0 {
+			desc += " rigs=" + strings.Join(a.Patrol.Rigs, ",")
+		}
+		return desc
+	case ActionSetBudget:
+		var parts []string
+		if a.Budget.MaxPolecats != nil {
+			parts = append(parts, fmt.Sprintf("max_polecats=%d", *a.Budget.MaxPolecats))
+		}
+		if a.Budget.BatchSize != nil {
+			parts = append(parts, fmt.Sprintf("batch_size=%d", *a.Budget.BatchSize))
+		}
+		return "set budget " + strings.Join(parts, " ")
+	}
+	return string(a.Kind)
+}
+
+// State is the observed town, as far as a spec can describe it.
+type State struct {
+	Rigs        map[string]*RigState
+	Patrols     map[string]PatrolState // Configured patrols; absent means default (enabled, all rigs)
+	MaxPolecats *int
+	BatchSize   *int
+}
+
+// RigState is the observed state of one rig.
+type RigState struct {
+	Gates           map[string]GateSpec
+	Crew            []string // Crew workspaces that exist
+	RunningCrew     []string // Crew with a live session
+	WitnessRunning  bool
+	RefineryRunning bool
+}
+
+// PatrolState is a patrol's config in mayor/daemon.json.
+type PatrolState struct {
+	Enabled  bool
+	Interval string
+	Rigs     []string // Empty means all rigs
+}
+
+// Plan returns the actions that move actual toward the spec. An empty plan
+// means the town matches the declaration.
+func Plan(spec *Spec, actual *State) []Action {
+	var actions []Action
+
+	for _, r := range spec.Rigs {
+		rs := actual.Rigs[r.Name]
+		if rs == nil {
+			actions = append(actions, Action{Kind: ActionAddRig, Rig: r.Name, GitURL: r.GitURL, Prefix: r.Prefix})
+			rs = &RigState{}
+		}
+		if r.Gates != nil && !reflect.DeepEqual(normalizeGates(r.Gates), normalizeGates(rs.Gates)) {
+			actions = append(actions, Action{Kind: ActionSetGates, Rig: r.Name, Gates: r.Gates})
+		}
+
+		declared := make(map[string]bool, len(r.Agents.Crew))
+		for _, c := range r.Agents.Crew {
+			declared[c] = true
+			if !contains(rs.Crew, c) {
+				actions = append(actions, Action{Kind: ActionAddCrew, Rig: r.Name, Name: c})
+			}
+			if !contains(rs.RunningCrew, c) {
+				actions = append(actions, Action{Kind: ActionStartCrew, Rig: r.Name, Name: c})
+			}
+		}
+		for _, c := range sorted(rs.RunningCrew) {
+			if !declared[c] {
+				actions = append(actions, Action{Kind: ActionStopCrew, Rig: r.Name, Name: c})
+			}
+		}
+		if rs.WitnessRunning && !r.Agents.WitnessEnabled() {
+			actions = append(actions, Action{Kind: ActionStopWitness, Rig: r.Name})
+		}
+		if rs.RefineryRunning && !r.Agents.RefineryEnabled() {
+			actions = append(actions, Action{Kind: ActionStopRefinery, Rig: r.Name})
+		}
+	}
+
+	// Rigs the spec no longer declares keep their files but lose their agents.
+	var undeclared []string
+	for name := range actual.Rigs {
+		if _, ok := spec.Rig(name); !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		rs := actual.Rigs[name]
+		for _, c := range sorted(rs.RunningCrew) {
+			actions = append(actions, Action{Kind: ActionStopCrew, Rig: name, Name: c})
+		}
+		if rs.WitnessRunning {
+			actions = append(actions, Action{Kind: ActionStopWitness, Rig: name})
+		}
+		if rs.RefineryRunning {
+			actions = append(actions, Action{Kind: ActionStopRefinery, Rig: name})
+		}
+	}
+
+	for _, name := range sortedKeys(knownPatrols) {
+		want, manage := desiredPatrol(spec, actual, name)
+		if !manage {
+			continue
+		}
+		if have, ok := actual.Patrols[name]; ok && reflect.DeepEqual(normalizePatrol(have), normalizePatrol(want)) {
+			continue
+		}
+		p := want
+		actions = append(actions, Action{Kind: ActionSetPatrol, Name: name, Patrol: &p})
+	}
+
+	budget := BudgetSpec{}
+	if b := spec.Budgets.MaxPolecats; b != nil && !intPtrEqual(b, actual.MaxPolecats) {
+		budget.MaxPolecats = b
+	}
+	if b := spec.Budgets.BatchSize; b != nil && !intPtrEqual(b, actual.BatchSize) {
+		budget.BatchSize = b
+	}
+	if budget.MaxPolecats != nil || budget.BatchSize != nil {
+		actions = append(actions, Action{Kind: ActionSetBudget, Budget: &budget})
+	}
+
+	return actions
+}
+
+// desiredPatrol returns the patrol config the spec asks for. Witness and
+// refinery are always managed, since their rig lists follow the declared
+// rigs; other patrols only when the spec mentions them.
+func desiredPatrol(spec *Spec, actual *State, name string) (PatrolState, bool) {
+	ps, inSpec := spec.Patrols[name]
+	want, configured := actual.Patrols[name]
+	if !configured {
+		want = PatrolState{Enabled: true}
+	}
+	if ps.Enabled != nil {
+		want.Enabled = *ps.Enabled
+	}
+	if ps.Interval != "" {
+		want.Interval = ps.Interval
+	}
+
+	switch name {
+	case "witness", "refinery":
+		var rigs []string
+		for _, r := range spec.Rigs {
+			if (name == "witness" && r.Agents.WitnessEnabled()) || (name == "refinery" && r.Agents.RefineryEnabled()) {
+				rigs = append(rigs, r.Name)
+			}
+		}
+		sort.Strings(rigs)
+		want.Rigs = rigs
+		// An empty rig list means "all rigs" to the daemon, so with no
+		// rigs wanting the agent the patrol is turned off instead.
+		if ps.Enabled == nil || len(rigs) == 0 {
+			want.Enabled = len(rigs) > 0
+		}
+		return want, true
+	}
+	return want, inSpec
+}
+
+// normalizeGates makes nil and empty gate sets compare equal.
+func normalizeGates(g map[string]GateSpec) map[string]GateSpec {
+	out := make(map[string]GateSpec, len(g))
+	for k, v := range g {
+		out[k] = v
+	}
+	return out
+}
+
+func normalizePatrol(p PatrolState) PatrolState {
+	if len(p.Rigs) == 0 {
+		p.Rigs = nil
+	} else {
+		p.Rigs = sorted(p.Rigs)
+	}
+	return p
+}
+
+func intPtrEqual(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sorted(list []string) []string {
+	out := append([]string(nil), list...)
+	sort.Strings(out)
+	return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
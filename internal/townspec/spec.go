@@ -0,0 +1,190 @@
+// Package townspec implements the declarative town description applied by
+// `gt apply`.
+//
+// A town spec is a YAML file declaring the rigs a town should have, which
+// agents run in each, the merge queue gates per rig, the daemon patrols and
+// the scheduler budget. Plan diffs a spec against the observed town and
+// returns the actions that move the town toward it; the daemon re-applies
+// the stored spec every heartbeat so drift is corrected, Kubernetes-style.
+//
+// Reconciliation only adds and stops. Rigs and crew workspaces missing from
+// the spec are never deleted, only their agents are stopped.
+package townspec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion and Kind identify a town spec document.
+const (
+	APIVersion = "gastown/v1"
+	Kind       = "Town"
+)
+
+// Patrols a spec can configure. These are the daemon patrols with a plain
+// enabled/interval config in mayor/daemon.json.
+var knownPatrols = map[string]bool{
+	"deacon":   true,
+	"witness":  true,
+	"refinery": true,
+	"handler":  true,
+}
+
+var rigNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// Spec is a declarative description of a town.
+type Spec struct {
+	APIVersion string                `yaml:"apiVersion"`
+	Kind       string                `yaml:"kind"`
+	Rigs       []RigSpec             `yaml:"rigs"`
+	Patrols    map[string]PatrolSpec `yaml:"patrols,omitempty"`
+	Budgets    BudgetSpec            `yaml:"budgets,omitempty"`
+}
+
+// RigSpec declares one rig.
+type RigSpec struct {
+	Name   string              `yaml:"name"`
+	GitURL string              `yaml:"git_url"`
+	Prefix string              `yaml:"prefix,omitempty"`
+	Agents AgentsSpec          `yaml:"agents,omitempty"`
+	Gates  map[string]GateSpec `yaml:"gates,omitempty"`
+}
+
+// AgentsSpec declares which agents run in a rig. Witness and refinery
+// default to running; crew lists the crew members whose sessions are kept
+// running.
+type AgentsSpec struct {
+	Witness  *bool    `yaml:"witness,omitempty"`
+	Refinery *bool    `yaml:"refinery,omitempty"`
+	Crew     []string `yaml:"crew,omitempty"`
+}
+
+// WitnessEnabled reports whether the rig's witness should run.
+func (a AgentsSpec) WitnessEnabled() bool { return a.Witness == nil || *a.Witness }
+
+// RefineryEnabled reports whether the rig's refinery should run.
+func (a AgentsSpec) RefineryEnabled() bool { return a.Refinery == nil || *a.Refinery }
+
+// GateSpec is a merge queue gate, as in the rig's merge_queue.gates config.
+type GateSpec struct {
+	Cmd     string `yaml:"cmd" json:"cmd"`
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// PatrolSpec configures a daemon patrol.
+type PatrolSpec struct {
+	Enabled  *bool  `yaml:"enabled,omitempty"`
+	Interval string `yaml:"interval,omitempty"`
+}
+
+// BudgetSpec caps town-wide resource use.
+type BudgetSpec struct {
+	// MaxPolecats is scheduler.max_polecats (-1 or 0 for direct dispatch).
+	MaxPolecats *int `yaml:"max_polecats,omitempty"`
+	// BatchSize is scheduler.batch_size, polecats dispatched per heartbeat.
+	BatchSize *int `yaml:"batch_size,omitempty"`
+}
+
+// Path returns where the applied spec is stored for the daemon to
+// reconcile against.
+func Path(townRoot string) string {
+	return filepath.Join(townRoot, "settings", "town.yaml")
+}
+
+// Parse decodes and validates a spec.
+func Parse(data []byte) (*Spec, error) {
+	var s Spec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("parsing town spec: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Load reads and validates a spec file.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is user-provided spec file
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Validate checks the spec for errors that would make it unappliable.
+func (s *Spec) Validate() error {
+	if s.APIVersion != APIVersion {
+		return fmt.Errorf("town spec: apiVersion must be %q, got %q", APIVersion, s.APIVersion)
+	}
+	if s.Kind != Kind {
+		return fmt.Errorf("town spec: kind must be %q, got %q", Kind, s.Kind)
+	}
+	seen := make(map[string]bool)
+	for _, r := range s.Rigs {
+		if !rigNamePattern.MatchString(r.Name) {
+			return fmt.Errorf("town spec: invalid rig name %q", r.Name)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("town spec: rig %q declared twice", r.Name)
+		}
+		seen[r.Name] = true
+		if r.GitURL == "" {
+			return fmt.Errorf("town spec: rig %q has no git_url", r.Name)
+		}
+		crew := make(map[string]bool)
+		for _, c := range r.Agents.Crew {
+			if !rigNamePattern.MatchString(c) {
+				return fmt.Errorf("town spec: rig %q: invalid crew name %q", r.Name, c)
+			}
+			if crew[c] {
+				return fmt.Errorf("town spec: rig %q: crew %q declared twice", r.Name, c)
+			}
+			crew[c] = true
+		}
+		for name, g := range r.Gates {
+			if g.Cmd == "" {
+				return fmt.Errorf("town spec: rig %q: gate %q has no cmd", r.Name, name)
+			}
+			if g.Timeout != "" {
+				if _, err := time.ParseDuration(g.Timeout); err != nil {
+					return fmt.Errorf("town spec: rig %q: gate %q: invalid timeout %q", r.Name, name, g.Timeout)
+				}
+			}
+		}
+	}
+	for name, p := range s.Patrols {
+		if !knownPatrols[name] {
+			return fmt.Errorf("town spec: unknown patrol %q (known: %s)", name, strings.Join(sortedKeys(knownPatrols), ", "))
+		}
+		if p.Interval != "" {
+			if _, err := time.ParseDuration(p.Interval); err != nil {
+				return fmt.Errorf("town spec: patrol %q: invalid interval %q", name, p.Interval)
+			}
+		}
+	}
+	if b := s.Budgets.BatchSize; b != nil && *b < 1 {
+		return fmt.Errorf("town spec: budgets.batch_size must be at least 1")
+	}
+	return nil
+}
+
+// Rig returns the declared rig with the given name.
+func (s *Spec) Rig(name string) (RigSpec, bool) {
+	for _, r := range s.Rigs {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return RigSpec{}, false
+}
@@ -0,0 +1,170 @@
+package townspec
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleSpec = `
+apiVersion: gastown/v1
+kind: Town
+rigs:
+  - name: gastown
+    git_url: https://github.com/steveyegge/gastown
+    prefix: gt
+    agents:
+      crew: [max, joe]
+    gates:
+      test: {cmd: "go test ./...", timeout: 10m}
+  - name: beads
+    git_url: https://github.com/steveyegge/beads
+    agents:
+      refinery: false
+patrols:
+  handler: {enabled: false}
+  deacon: {interval: 5m}
+budgets:
+  max_polecats: 6
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(sampleSpec))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(s.Rigs) != 2 {
+		t.Fatalf("rigs = %d, want 2", len(s.Rigs))
+	}
+	gt, ok := s.Rig("gastown")
+	if !ok {
+		t.Fatal("gastown rig not found")
+	}
+	if gt.Gates["test"].Timeout != "10m" || len(gt.Agents.Crew) != 2 {
+		t.Errorf("gastown rig = %+v", gt)
+	}
+	beads, _ := s.Rig("beads")
+	if beads.Agents.RefineryEnabled() || !beads.Agents.WitnessEnabled() {
+		t.Errorf("beads agents: refinery=%t witness=%t", beads.Agents.RefineryEnabled(), beads.Agents.WitnessEnabled())
+	}
+	if s.Budgets.MaxPolecats == nil || *s.Budgets.MaxPolecats != 6 {
+		t.Errorf("max_polecats = %v", s.Budgets.MaxPolecats)
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name, doc, want string
+	}{
+		{"wrong kind", "apiVersion: gastown/v1\nkind: Rig\n", "kind"},
+		{"unknown field", "apiVersion: gastown/v1\nkind: Town\nrigz: []\n", "rigz"},
+		{"duplicate rig", "apiVersion: gastown/v1\nkind: Town\nrigs:\n- {name: a, git_url: u}\n- {name: a, git_url: u}\n", "twice"},
+		{"missing url", "apiVersion: gastown/v1\nkind: Town\nrigs:\n- {name: a}\n", "git_url"},
+		{"bad timeout", "apiVersion: gastown/v1\nkind: Town\nrigs:\n- {name: a, git_url: u, gates: {t: {cmd: x, timeout: soon}}}\n", "timeout"},
+		{"unknown patrol", "apiVersion: gastown/v1\nkind: Town\npatrols: {mailman: {}}\n", "unknown patrol"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func kinds(actions []Action) []string {
+	var out []string
+	for _, a := range actions {
+		out = append(out, a.String())
+	}
+	return out
+}
+
+func TestPlanFromEmptyTown(t *testing.T) {
+	s, err := Parse([]byte(sampleSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := kinds(Plan(s, &State{}))
+	want := []string{
+		"add rig gastown (https://github.com/steveyegge/gastown)",
+		"set gates on gastown: test",
+		"add crew gastown/max",
+		"start crew gastown/max",
+		"add crew gastown/joe",
+		"start crew gastown/joe",
+		"add rig beads (https://github.com/steveyegge/beads)",
+		"set patrol deacon enabled=true interval=5m",
+		"set patrol handler enabled=false",
+		"set patrol refinery enabled=true rigs=gastown",
+		"set patrol witness enabled=true rigs=beads,gastown",
+		"set budget max_polecats=6",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("plan:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPlanConvergedIsEmpty(t *testing.T) {
+	s, err := Parse([]byte(sampleSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	six := 6
+	actual := &State{
+		Rigs: map[string]*RigState{
+			"gastown": {
+				Gates:           map[string]GateSpec{"test": {Cmd: "go test ./...", Timeout: "10m"}},
+				Crew:            []string{"joe", "max"},
+				RunningCrew:     []string{"max", "joe"},
+				WitnessRunning:  true,
+				RefineryRunning: true,
+			},
+			"beads": {WitnessRunning: true},
+		},
+		Patrols: map[string]PatrolState{
+			"deacon":   {Enabled: true, Interval: "5m"},
+			"handler":  {Enabled: false},
+			"refinery": {Enabled: true, Rigs: []string{"gastown"}},
+			"witness":  {Enabled: true, Rigs: []string{"gastown", "beads"}},
+		},
+		MaxPolecats: &six,
+	}
+	if got := Plan(s, actual); len(got) != 0 {
+		t.Errorf("converged town produced plan:\n%s", strings.Join(kinds(got), "\n"))
+	}
+}
+
+func TestPlanStopsRemovedAgents(t *testing.T) {
+	s, err := Parse([]byte(`
+apiVersion: gastown/v1
+kind: Town
+rigs:
+  - name: gastown
+    git_url: u
+    agents:
+      crew: [max]
+      witness: false
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := &State{
+		Rigs: map[string]*RigState{
+			"gastown": {Crew: []string{"max", "joe"}, RunningCrew: []string{"max", "joe"}, WitnessRunning: true, RefineryRunning: true},
+			"old":     {RunningCrew: []string{"zed"}, WitnessRunning: true},
+		},
+	}
+	got := kinds(Plan(s, actual))
+	want := []string{
+		"stop crew gastown/joe",
+		"stop witness gastown",
+		"stop crew old/zed",
+		"stop witness old",
+		"set patrol refinery enabled=true rigs=gastown",
+		"set patrol witness enabled=false",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("plan:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
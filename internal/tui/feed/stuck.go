@@ -153,9 +153,11 @@ type StuckDetector struct {
 
 // NewStuckDetector creates a new stuck detector with default data sources.
 func NewStuckDetector(bd *beads.Beads) *StuckDetector {
+	mux, err := tmux.NewMultiplexer()
 	return NewStuckDetectorWithSource(&defaultHealthSource{
-		bd:   bd,
-		tmux: tmux.NewTmux(),
+		bd:     bd,
+		mux:    mux,
+		muxErr: err,
 	})
 }
 
@@ -326,10 +328,12 @@ func sortProblemAgents(agents []*ProblemAgent) {
 	}
 }
 
-// defaultHealthSource implements HealthDataSource using real beads and tmux.
+// defaultHealthSource implements HealthDataSource using real beads and the
+// selected multiplexer.
 type defaultHealthSource struct {
-	bd   *beads.Beads
-	tmux *tmux.Tmux
+	bd     *beads.Beads
+	mux    tmux.Multiplexer
+	muxErr error // Why mux is nil: GT_MULTIPLEXER names an unknown backend
 }
 
 func (s *defaultHealthSource) ListAgentBeads() (map[string]*beads.Issue, error) {
@@ -337,9 +341,12 @@ func (s *defaultHealthSource) ListAgentBeads() (map[string]*beads.Issue, error)
 }
 
 func (s *defaultHealthSource) IsSessionAlive(sessionName string) (bool, error) {
+	if s.muxErr != nil {
+		return false, s.muxErr
+	}
 	// Check both session existence AND agent process liveness.
 	// HasSession alone misses zombie sessions where tmux is alive
 	// but Claude has crashed inside the pane.
-	status := s.tmux.CheckSessionHealth(sessionName, 0)
+	status := tmux.CheckHealth(s.mux, sessionName, 0)
 	return status == tmux.SessionHealthy, nil
 }
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Redacted replaces each secret found by a Redactor.
+const Redacted = "[REDACTED]"
+
+// secretPatterns match common credentials in free text. Matches stop at
+// quotes, backslashes and angle brackets, so redacting JSON or HTML leaves
+// it well-formed.
+var secretPatterns = []*regexp.Regexp{
+	// Private key blocks.
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	// Vendor tokens with recognizable prefixes: GitHub, Anthropic/OpenAI,
+	// Slack, AWS access keys, Google API keys.
+	regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`),
+	regexp.MustCompile(`\bAIza[A-Za-z0-9_-]{35}`),
+	// Bearer tokens and credentials in URLs.
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]{16,}`),
+	regexp.MustCompile(`://[^\s:/@"'<>\\]+:[^\s@/"'<>\\]+@`),
+	// key=value and key: value assignments of secret-looking names.
+	regexp.MustCompile(`(?i)\b[A-Za-z0-9_]*(?:password|passwd|secret|token|api_?key|access_?key)\s*[=:]\s*["']?[^\s"'<>\\,;&]{4,}`),
+}
+
+// Redactor masks secrets in text shown outside the town, such as to
+// observers (see config.ObserverConfig).
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor for the built-in secret patterns plus the
+// extra regular expressions given.
+func NewRedactor(extra []string) (*Redactor, error) {
+	r := &Redactor{patterns: append([]*regexp.Regexp{}, secretPatterns...)}
+	for _, p := range extra {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns s with every secret replaced by Redacted. A URL keeps its
+// scheme and host.
+func (r *Redactor) Redact(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, func(m string) string {
+			if len(m) > 3 && m[:3] == "://" {
+				return "://" + Redacted + "@"
+			}
+			return Redacted
+		})
+	}
+	return s
+}
@@ -0,0 +1,246 @@
+package wallclock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a five-field cron schedule, "minute hour day-of-month month
+// day-of-week", evaluated on the wall clock of Location. Fields take *,
+// numbers, ranges (1-5), lists (1,3,5) and steps (*/15, 9-17/2); months and
+// weekdays also take names (jan, mon). Day-of-week 0 and 7 are Sunday. As
+// in cron, when both day fields are restricted a day matching either
+// matches. The macros @hourly, @daily, @weekly, @monthly and @yearly are
+// accepted too.
+//
+// Occurrences follow TimeOfDay.On across DST changes: a skipped minute
+// occurs when the clock jumps past it, and a repeated one only once.
+type Cron struct {
+	Location *time.Location
+
+	expr                          string
+	minute, hour, dom, month, dow uint64 // Bit n set: value n matches
+	domAny, dowAny                bool   // The day field started with *
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMonths = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronDays   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// ParseCron parses a cron expression to be evaluated in the time zone tz
+// (empty for local).
+func ParseCron(expr, tz string) (*Cron, error) {
+	loc, err := LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	spec := strings.TrimSpace(expr)
+	if m, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day month weekday)", expr)
+	}
+	c := &Cron{Location: loc, expr: strings.TrimSpace(expr)}
+	for _, f := range []struct {
+		name     string
+		text     string
+		min, max int
+		names    []string
+		nameBase int
+		bits     *uint64
+		any      *bool
+	}{
+		{"minute", fields[0], 0, 59, nil, 0, &c.minute, nil},
+		{"hour", fields[1], 0, 23, nil, 0, &c.hour, nil},
+		{"day of month", fields[2], 1, 31, nil, 0, &c.dom, &c.domAny},
+		{"month", fields[3], 1, 12, cronMonths, 1, &c.month, nil},
+		{"day of week", fields[4], 0, 7, cronDays, 0, &c.dow, &c.dowAny},
+	} {
+		bits, err := parseCronField(f.text, f.min, f.max, f.names, f.nameBase)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %s %q: %w", f.name, f.text, err)
+		}
+		*f.bits = bits
+		if f.any != nil {
+			*f.any = strings.HasPrefix(f.text, "*")
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	return c, nil
+}
+
+// parseCronField parses one field into a bitset of the values it matches.
+func parseCronField(text string, lo, hi int, names []string, nameBase int) (uint64, error) {
+	value := func(s string) (int, error) {
+		for i, n := range names {
+			if strings.EqualFold(s, n) {
+				return i + nameBase, nil
+			}
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil || v < lo || v > hi {
+			return 0, fmt.Errorf("%q is not in %d-%d", s, lo, hi)
+		}
+		return v, nil
+	}
+	var bits uint64
+	for _, part := range strings.Split(text, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			s, err := strconv.Atoi(stepText)
+			if err != nil || s < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+			step = s
+		}
+		from, to := lo, hi
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if from, err = value(a); err != nil {
+				return 0, err
+			}
+			if to, err = value(b); err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, fmt.Errorf("range %s runs backwards", rng)
+			}
+		default:
+			v, err := value(rng)
+			if err != nil {
+				return 0, err
+			}
+			from = v
+			if !hasStep {
+				to = v
+			}
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *Cron) location() *time.Location {
+	if c.Location != nil {
+		return c.Location
+	}
+	return time.Local
+}
+
+// matchesDay reports whether the schedule fires on the given calendar day.
+func (c *Cron) matchesDay(day time.Time) bool {
+	if c.month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	domOK := c.dom&(1<<uint(day.Day())) != 0
+	dowOK := c.dow&(1<<uint(day.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowOK
+	case c.dowAny:
+		return domOK
+	}
+	return domOK || dowOK
+}
+
+// occurrences returns the instants the schedule fires at on the calendar
+// day of day (noon of it in the schedule's zone), earliest first.
+func (c *Cron) occurrences(day time.Time) []time.Time {
+	if !c.matchesDay(day) {
+		return nil
+	}
+	var out []time.Time
+	seen := make(map[time.Time]bool)
+	for h := 0; h < 24; h++ {
+		if c.hour&(1<<uint(h)) == 0 {
+			continue
+		}
+		for m := 0; m < 60; m++ {
+			if c.minute&(1<<uint(m)) == 0 {
+				continue
+			}
+			s := TimeOfDay{h, m}.On(day.Year(), day.Month(), day.Day(), c.location())
+			if !seen[s] { // Minutes skipped by DST all occur at the jump
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// maxCronSearchDays bounds searches for occurrences; a schedule like
+// "0 0 29 2 *" fires every four years.
+const maxCronSearchDays = 366*4 + 1
+
+// Next returns the first occurrence after t, or the zero time if the
+// schedule never fires (e.g., "0 0 31 2 *").
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := c.location()
+	local := t.In(loc)
+	for i := 0; i <= maxCronSearchDays; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 12, 0, 0, 0, loc)
+		for _, s := range c.occurrences(day) {
+			if s.After(t) {
+				return s
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Latest returns the last occurrence at or before t, looking back no
+// further than within, or false if there is none.
+func (c *Cron) Latest(t time.Time, within time.Duration) (time.Time, bool) {
+	loc := c.location()
+	local := t.In(loc)
+	back := int(within/(24*time.Hour)) + 1
+	for i := 0; i <= back; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()-i, 12, 0, 0, 0, loc)
+		occ := c.occurrences(day)
+		for j := len(occ) - 1; j >= 0; j-- {
+			if !occ[j].After(t) {
+				if t.Sub(occ[j]) > within {
+					return time.Time{}, false
+				}
+				return occ[j], true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Matches reports whether the schedule fires in the minute containing t.
+func (c *Cron) Matches(t time.Time) bool {
+	s, ok := c.Latest(t, time.Minute)
+	return ok && t.Sub(s) < time.Minute
+}
+
+func (c *Cron) String() string {
+	return fmt.Sprintf("%s (%s)", c.expr, c.location())
+}
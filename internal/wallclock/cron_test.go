@@ -0,0 +1,120 @@
+package wallclock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, good := range []string{
+		"* * * * *", "*/15 9-17 * * mon-fri", "0 22 * * 1,3,5", "30 2 1 jan-jun/2 *",
+		"0 0 * * 7", "@daily", "@Hourly", "5/10 * * * *",
+	} {
+		if _, err := ParseCron(good, "UTC"); err != nil {
+			t.Errorf("ParseCron(%q): %v", good, err)
+		}
+	}
+	for _, bad := range []string{
+		"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "* * * * funday", "@fortnightly",
+	} {
+		if _, err := ParseCron(bad, "UTC"); err == nil {
+			t.Errorf("ParseCron(%q) should fail", bad)
+		}
+	}
+	if _, err := ParseCron("* * * * *", "Mars/Olympus_Mons"); err == nil {
+		t.Error("ParseCron with an unknown zone should fail")
+	}
+}
+
+func TestCron_Matches(t *testing.T) {
+	c, err := ParseCron("*/15 9-16 * * mon-fri", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, h, m, s int) time.Time { return time.Date(2026, time.March, day, h, m, s, 0, time.UTC) }
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(2, 9, 0, 0), true},    // Monday
+		{at(2, 9, 0, 59), true},   // Same minute
+		{at(2, 9, 1, 0), false},   // Not a quarter hour
+		{at(2, 16, 45, 0), true},  // Last slot
+		{at(2, 17, 0, 0), false},  // Past the hours
+		{at(7, 10, 30, 0), false}, // Saturday
+		{at(6, 10, 30, 0), true},  // Friday
+	}
+	for _, tt := range tests {
+		if got := c.Matches(tt.t); got != tt.want {
+			t.Errorf("Matches(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestCron_DayFields(t *testing.T) {
+	// Both day fields restricted: either matches.
+	c, err := ParseCron("0 0 13 * fri", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for day, want := range map[int]bool{13: true, 6: true, 14: false} { // Fri Mar 6, Fri Mar 13, Sat Mar 14
+		if got := c.Matches(time.Date(2026, time.March, day, 0, 0, 0, 0, time.UTC)); got != want {
+			t.Errorf("Mar %d: Matches = %v, want %v", day, got, want)
+		}
+	}
+	// Only the day of month restricted: the weekday doesn't widen it.
+	c, _ = ParseCron("0 0 13 * *", "UTC")
+	if c.Matches(time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Error("0 0 13 * * matched the 6th")
+	}
+}
+
+func TestCron_NextAndLatest(t *testing.T) {
+	c, err := ParseCron("0 22 * * mon-fri", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fri := time.Date(2026, time.March, 6, 23, 0, 0, 0, time.UTC)
+	if got, want := c.Next(fri), time.Date(2026, time.March, 9, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next(Fri 23:00) = %v, want Monday 22:00 %v", got, want)
+	}
+	if got, ok := c.Latest(fri, 8*time.Hour); !ok || !got.Equal(time.Date(2026, time.March, 6, 22, 0, 0, 0, time.UTC)) {
+		t.Errorf("Latest(Fri 23:00, 8h) = %v, %v; want Friday 22:00", got, ok)
+	}
+	sat := time.Date(2026, time.March, 7, 12, 0, 0, 0, time.UTC)
+	if _, ok := c.Latest(sat, 8*time.Hour); ok {
+		t.Error("Latest(Sat 12:00, 8h) found Friday 22:00, 14h before")
+	}
+	if got, ok := c.Latest(sat, 72*time.Hour); !ok || got.Day() != 6 {
+		t.Errorf("Latest(Sat 12:00, 72h) = %v, %v; want Friday 22:00", got, ok)
+	}
+
+	never, _ := ParseCron("0 0 31 2 *", "UTC")
+	if got := never.Next(fri); !got.IsZero() {
+		t.Errorf("Next of Feb 31 = %v, want zero", got)
+	}
+	leap, _ := ParseCron("0 0 29 2 *", "UTC")
+	if got := leap.Next(fri); got.Year() != 2028 {
+		t.Errorf("Next of Feb 29 = %v, want 2028", got)
+	}
+}
+
+func TestCron_DST(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	c, err := ParseCron("30 2 * * *", "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-03-08 skips 02:00-03:00: 02:30 occurs at 03:00 EDT.
+	before := time.Date(2026, time.March, 8, 0, 0, 0, 0, ny)
+	if got, want := c.Next(before), time.Date(2026, time.March, 8, 3, 0, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("Next across spring-forward = %v, want %v", got, want)
+	}
+	// 2026-11-01 repeats 01:00-02:00: 01:30 occurs once.
+	c, _ = ParseCron("30 1 * * *", "America/New_York")
+	first := c.Next(time.Date(2026, time.November, 1, 0, 0, 0, 0, ny))
+	if second := c.Next(first); second.Day() != 2 {
+		t.Errorf("01:30 occurred again at %v on the fall-back day", second)
+	}
+}
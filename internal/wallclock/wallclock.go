@@ -0,0 +1,211 @@
+// Package wallclock evaluates wall-clock schedules, such as "02:00 daily" or
+// "Fridays from 17:00 for 64h", in an explicit time zone.
+//
+// Schedules are DST-safe: a wall time skipped by a spring-forward change
+// occurs at the moment the clock jumps past it, and a wall time repeated by
+// a fall-back change occurs only at its first instance, so a daily schedule
+// fires exactly once on every calendar day.
+package wallclock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeOfDay is a wall-clock time, to the minute.
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+}
+
+// ParseTimeOfDay parses a 24-hour "HH:MM" time.
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok {
+		return TimeOfDay{}, fmt.Errorf("invalid time %q: expected HH:MM", s)
+	}
+	hour, err := strconv.Atoi(h)
+	if err != nil || hour < 0 || hour > 23 {
+		return TimeOfDay{}, fmt.Errorf("invalid hour in %q: expected 0-23", s)
+	}
+	minute, err := strconv.Atoi(m)
+	if err != nil || minute < 0 || minute > 59 {
+		return TimeOfDay{}, fmt.Errorf("invalid minute in %q: expected 0-59", s)
+	}
+	return TimeOfDay{Hour: hour, Minute: minute}, nil
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// LoadLocation loads an IANA time zone such as "America/New_York". An empty
+// name, or "Local", is the machine's local zone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// On returns the instant t occurs on the given calendar day in loc.
+//
+// If a DST change skips t that day, it occurs when the clock jumps past
+// it (02:30 on a day that goes from 02:00 straight to 03:00 occurs at
+// 03:00). If a DST change repeats t, it occurs at the first instance.
+func (t TimeOfDay) On(year int, month time.Month, day int, loc *time.Location) time.Time {
+	// The wall time read as UTC; subtracting the zone's UTC offset gives
+	// the instant. At most one offset change falls within a day, so the
+	// offsets a day either side are the only candidates.
+	naive := time.Date(year, month, day, t.Hour, t.Minute, 0, 0, time.UTC)
+	var first time.Time
+	for _, probe := range []time.Time{naive.Add(-24 * time.Hour), naive.Add(24 * time.Hour)} {
+		_, offset := probe.In(loc).Zone()
+		inst := naive.Add(-time.Duration(offset) * time.Second).In(loc)
+		if inst.Hour() == t.Hour && inst.Minute() == t.Minute && (first.IsZero() || inst.Before(first)) {
+			first = inst
+		}
+	}
+	if !first.IsZero() {
+		return first
+	}
+
+	// Skipped: at the earlier offset, the wall time reads as an instant
+	// already past the change, which is where its zone begins.
+	_, before := naive.Add(-24 * time.Hour).In(loc).Zone()
+	start, _ := naive.Add(-time.Duration(before) * time.Second).In(loc).ZoneBounds()
+	return start
+}
+
+// Window is a recurring span of time: Duration long, starting at Start on
+// each of Days (every day if empty), in Location.
+type Window struct {
+	Start    TimeOfDay
+	Duration time.Duration
+	Days     []time.Weekday // Days the window starts on; empty means every day
+	Location *time.Location
+}
+
+// ParseWindow builds a window from its config form: a start "HH:MM", a Go
+// duration, weekday names ("mon", "Friday"; empty for every day) and a
+// time zone (empty for local).
+func ParseWindow(start, duration string, days []string, tz string) (*Window, error) {
+	tod, err := ParseTimeOfDay(start)
+	if err != nil {
+		return nil, err
+	}
+	dur, err := time.ParseDuration(duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q: %w", duration, err)
+	}
+	if dur <= 0 {
+		return nil, fmt.Errorf("duration must be positive, got %v", dur)
+	}
+	loc, err := LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	w := &Window{Start: tod, Duration: dur, Location: loc}
+	for _, name := range days {
+		day, err := ParseWeekday(name)
+		if err != nil {
+			return nil, err
+		}
+		w.Days = append(w.Days, day)
+	}
+	return w, nil
+}
+
+// ParseWeekday parses a weekday name or its three-letter abbreviation,
+// ignoring case.
+func ParseWeekday(name string) (time.Weekday, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if n == full || n == full[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", name)
+}
+
+func (w *Window) location() *time.Location {
+	if w.Location != nil {
+		return w.Location
+	}
+	return time.Local
+}
+
+func (w *Window) startsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Current returns the start of the occurrence of the window that contains
+// now, if any. The window ends Duration after it starts in elapsed time,
+// however the wall clock moves in between.
+func (w *Window) Current(now time.Time) (start time.Time, ok bool) {
+	loc := w.location()
+	local := now.In(loc)
+	// Occurrences started on earlier days may still be open; look back as
+	// many days as the window can span.
+	back := int(w.Duration/(24*time.Hour)) + 1
+	for i := 0; i <= back; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()-i, 12, 0, 0, 0, loc)
+		if !w.startsOn(day.Weekday()) {
+			continue
+		}
+		s := w.Start.On(day.Year(), day.Month(), day.Day(), loc)
+		if !now.Before(s) && now.Before(s.Add(w.Duration)) {
+			return s, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Contains reports whether now falls within an occurrence of the window.
+func (w *Window) Contains(now time.Time) bool {
+	_, ok := w.Current(now)
+	return ok
+}
+
+// Next returns the start of the first occurrence of the window after t.
+func (w *Window) Next(t time.Time) time.Time {
+	loc := w.location()
+	local := t.In(loc)
+	for i := 0; i <= 8; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 12, 0, 0, 0, loc)
+		if !w.startsOn(day.Weekday()) {
+			continue
+		}
+		if s := w.Start.On(day.Year(), day.Month(), day.Day(), loc); s.After(t) {
+			return s
+		}
+	}
+	return time.Time{}
+}
+
+func (w *Window) String() string {
+	s := w.Start.String()
+	if len(w.Days) > 0 {
+		names := make([]string, len(w.Days))
+		for i, d := range w.Days {
+			names[i] = d.String()[:3]
+		}
+		s = strings.Join(names, ",") + " " + s
+	}
+	return fmt.Sprintf("%s for %v (%s)", s, w.Duration, w.location())
+}
@@ -0,0 +1,129 @@
+package wallclock
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	tod, err := ParseTimeOfDay("7:05")
+	if err != nil || tod != (TimeOfDay{7, 5}) || tod.String() != "07:05" {
+		t.Errorf("ParseTimeOfDay(7:05) = %v, %v", tod, err)
+	}
+	for _, bad := range []string{"", "0700", "24:00", "12:60", "ab:cd"} {
+		if _, err := ParseTimeOfDay(bad); err == nil {
+			t.Errorf("ParseTimeOfDay(%q) should fail", bad)
+		}
+	}
+}
+
+func TestTimeOfDay_On_DST(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	utc := func(h, m int, day int, month time.Month) time.Time {
+		return time.Date(2026, month, day, h, m, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		tod   TimeOfDay
+		month time.Month
+		day   int
+		want  time.Time
+	}{
+		{"ordinary day", TimeOfDay{2, 0}, time.January, 15, utc(7, 0, 15, time.January)},
+		// 2026-03-08: clocks go from 02:00 EST straight to 03:00 EDT.
+		{"skipped time runs at the jump", TimeOfDay{2, 30}, time.March, 8, utc(7, 0, 8, time.March)},
+		{"just after the jump", TimeOfDay{3, 0}, time.March, 8, utc(7, 0, 8, time.March)},
+		// 2026-11-01: clocks go from 02:00 EDT back to 01:00 EST.
+		{"repeated time runs once, first", TimeOfDay{1, 30}, time.November, 1, utc(5, 30, 1, time.November)},
+		{"after the repeat", TimeOfDay{2, 0}, time.November, 1, utc(7, 0, 1, time.November)},
+	}
+	for _, tt := range tests {
+		got := tt.tod.On(2026, tt.month, tt.day, ny)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: %v.On = %v, want %v", tt.name, tt.tod, got.UTC(), tt.want)
+		}
+	}
+}
+
+// A daily window must open exactly once per calendar day, including the
+// days the clocks change, however often it is polled.
+func TestWindow_DailyFiresOncePerDayAcrossDST(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	for _, tc := range []struct {
+		start string
+		from  time.Time
+	}{
+		{"02:30", time.Date(2026, 3, 6, 0, 0, 0, 0, ny)},   // Skipped on 03-08
+		{"01:30", time.Date(2026, 10, 30, 0, 0, 0, 0, ny)}, // Repeated on 11-01
+	} {
+		w, err := ParseWindow(tc.start, "1h", nil, "America/New_York")
+		if err != nil {
+			t.Fatal(err)
+		}
+		starts := make(map[time.Time]bool)
+		for now := tc.from; now.Before(tc.from.Add(5 * 24 * time.Hour)); now = now.Add(5 * time.Minute) {
+			if s, ok := w.Current(now); ok {
+				starts[s] = true
+			}
+		}
+		if len(starts) != 5 {
+			t.Errorf("window %s opened %d times in 5 days, want 5: %v", tc.start, len(starts), starts)
+		}
+	}
+}
+
+func TestWindow_DaysAndSpan(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	// Friday 17:00 for 64h: the weekend, until Monday 09:00.
+	w, err := ParseWindow("17:00", "64h", []string{"Fri"}, "Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour int) time.Time { return time.Date(2026, 5, day, hour, 0, 0, 0, berlin) }
+	for _, tc := range []struct {
+		now  time.Time
+		want bool
+	}{
+		{at(15, 16), false}, // Friday before
+		{at(15, 17), true},  // Friday start
+		{at(17, 12), true},  // Sunday
+		{at(18, 8), true},   // Monday morning
+		{at(18, 9), false},  // Monday 09:00, over
+		{at(20, 18), false}, // Wednesday evening
+	} {
+		if got := w.Contains(tc.now); got != tc.want {
+			t.Errorf("Contains(%v) = %v, want %v", tc.now, got, tc.want)
+		}
+	}
+	// Evaluated in the window's zone, not the caller's.
+	if !w.Contains(at(15, 17).In(time.UTC)) {
+		t.Error("window should be evaluated in its own time zone")
+	}
+	if next := w.Next(at(18, 9)); !next.Equal(at(22, 17)) {
+		t.Errorf("Next = %v, want Friday 22nd 17:00", next)
+	}
+
+	for _, bad := range [][4]string{
+		{"17:00", "0s", "", ""},
+		{"17:00", "1h", "Funday", ""},
+		{"17:00", "1h", "", "Mars/Olympus"},
+	} {
+		var days []string
+		if bad[2] != "" {
+			days = []string{bad[2]}
+		}
+		if _, err := ParseWindow(bad[0], bad[1], days, bad[3]); err == nil {
+			t.Errorf("ParseWindow(%q) should fail", bad)
+		}
+	}
+}
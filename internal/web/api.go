@@ -137,6 +137,8 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleReady(w, r)
 	case path == "/events" && r.Method == http.MethodGet:
 		h.handleSSE(w, r)
+	case path == "/mq/activity" && r.Method == http.MethodGet:
+		h.handleMQActivity(w, r)
 	case path == "/session/preview" && r.Method == http.MethodGet:
 		h.handleSessionPreview(w, r)
 	default:
@@ -1982,18 +1984,10 @@ func (h *APIHandler) handleSessionPreview(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Validate session name: must start with a known prefix and contain only safe characters
-	hasValidPrefix := session.HasKnownPrefix(sessionName)
-	if !hasValidPrefix {
-		h.sendError(w, "Invalid session name: must start with a known rig prefix", http.StatusBadRequest)
+	if msg := checkSessionName(sessionName); msg != "" {
+		h.sendError(w, msg, http.StatusBadRequest)
 		return
 	}
-	for _, c := range sessionName {
-		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_') {
-			h.sendError(w, "Invalid session name: contains invalid characters", http.StatusBadRequest)
-			return
-		}
-	}
 
 	// Run tmux capture-pane to get the last 30 lines
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
@@ -2022,6 +2016,21 @@ func (h *APIHandler) handleSessionPreview(w http.ResponseWriter, r *http.Request
 	})
 }
 
+// checkSessionName validates a session name from a request: it must start
+// with a known prefix and contain only safe characters. It returns the
+// problem, or "" if the name is valid.
+func checkSessionName(name string) string {
+	if !session.HasKnownPrefix(name) {
+		return "Invalid session name: must start with a known rig prefix"
+	}
+	for _, c := range name {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_') {
+			return "Invalid session name: contains invalid characters"
+		}
+	}
+	return ""
+}
+
 // parseCommandArgs splits a command string into args, respecting quotes.
 func parseCommandArgs(command string) []string {
 	var args []string
@@ -0,0 +1,67 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/approval"
+)
+
+// maxSlackBodyBytes bounds Slack interactivity request bodies.
+const maxSlackBodyBytes = 1 << 20
+
+// ApprovalDecider records an approval decision and delivers it to the agent.
+type ApprovalDecider func(id string, approve bool, by string) error
+
+// SlackApprovalHandler serves Slack interactivity callbacks for the
+// Approve/Reject buttons on plan approval messages. Requests must carry a
+// valid Slack signature; the dashboard CSRF token does not apply.
+type SlackApprovalHandler struct {
+	secret string
+	decide ApprovalDecider
+	now    func() time.Time
+}
+
+// NewSlackApprovalHandler creates a handler verifying requests with the
+// Slack app's signing secret.
+func NewSlackApprovalHandler(secret string, decide ApprovalDecider) *SlackApprovalHandler {
+	return &SlackApprovalHandler{secret: secret, decide: decide, now: time.Now}
+}
+
+// ServeHTTP handles a Slack button click.
+func (h *SlackApprovalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSlackBodyBytes))
+	if err != nil {
+		http.Error(w, "reading body", http.StatusBadRequest)
+		return
+	}
+	if err := approval.VerifySlackSignature(h.secret, r.Header.Get("X-Slack-Request-Timestamp"),
+		body, r.Header.Get("X-Slack-Signature"), h.now()); err != nil {
+		log.Printf("slack approval: rejected request: %v", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	d, err := approval.ParseSlackAction(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	text := "Approved " + d.ID + " (" + d.User + ")"
+	if !d.Approve {
+		text = "Rejected " + d.ID + " (" + d.User + ")"
+	}
+	if err := h.decide(d.ID, d.Approve, d.User); err != nil {
+		text = "Could not record decision for " + d.ID + ": " + err.Error()
+	}
+	// Slack shows the response text to the user who clicked.
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"text": text, "replace_original": false})
+}
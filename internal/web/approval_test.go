@@ -0,0 +1,52 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/approval"
+)
+
+func TestSlackApprovalHandler(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	var gotID, gotBy string
+	var gotApprove bool
+	h := NewSlackApprovalHandler("s3cret", func(id string, approve bool, by string) error {
+		gotID, gotApprove, gotBy = id, approve, by
+		return nil
+	})
+	h.now = func() time.Time { return now }
+
+	payload := `{"user":{"username":"ada"},"actions":[{"action_id":"` + approval.SlackActionApprove + `","value":"ap-1"}]}`
+	body := "payload=" + url.QueryEscape(payload)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+
+	req := httptest.NewRequest(http.MethodPost, "/slack/approvals", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || gotID != "ap-1" || !gotApprove || gotBy != "slack:ada" {
+		t.Errorf("code=%d decision=(%s,%v,%s) body=%s", rec.Code, gotID, gotApprove, gotBy, rec.Body)
+	}
+
+	gotID = ""
+	req = httptest.NewRequest(http.MethodPost, "/slack/approvals", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0=bad")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || gotID != "" {
+		t.Errorf("unsigned request: code=%d, decided=%q", rec.Code, gotID)
+	}
+}
@@ -46,7 +46,11 @@ func runCmd(timeout time.Duration, name string, args ...string) (*bytes.Buffer,
 
 var fetcherRunCmd = runCmd
 var fetcherGetSessionEnv = func(sessionName, key string) (string, error) {
-	return tmux.NewTmux().GetEnvironment(sessionName, key)
+	t, err := tmux.RequireTmux("reading session environment")
+	if err != nil {
+		return "", err
+	}
+	return t.GetEnvironment(sessionName, key)
 }
 
 // runBdCmd executes a bd command with the configured cmdTimeout in the specified beads directory.
@@ -1677,6 +1681,37 @@ func (f *LiveConvoyFetcher) FetchIssues() ([]IssueRow, error) {
 	return rows, nil
 }
 
+// FetchMilestones returns progress rollups for open milestones.
+func (f *LiveConvoyFetcher) FetchMilestones() ([]MilestoneRow, error) {
+	b := beads.New(f.townRoot)
+	milestones, err := b.ListMilestones(false)
+	if err != nil {
+		return nil, fmt.Errorf("listing milestones: %w", err)
+	}
+	rollup, err := b.MilestoneRollup(milestones, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]MilestoneRow, 0, len(rollup))
+	for _, p := range rollup {
+		row := MilestoneRow{
+			ID:          p.ID,
+			Title:       p.Title,
+			Done:        p.Done,
+			Total:       p.Total,
+			InProgress:  p.InProgress,
+			MRsInFlight: p.MRsInFlight,
+			ProgressPct: p.Percent,
+		}
+		if p.ETA != nil {
+			row.ETA = p.ETA.Local().Format("Jan 2")
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 // FetchActivity returns recent activity from the event log.
 func (f *LiveConvoyFetcher) FetchActivity() ([]ActivityRow, error) {
 	eventsPath := filepath.Join(f.townRoot, ".events.jsonl")
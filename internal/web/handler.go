@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 //go:embed static
@@ -35,6 +36,7 @@ type ConvoyFetcher interface {
 	FetchMayor() (*MayorStatus, error)
 	FetchIssues() ([]IssueRow, error)
 	FetchActivity() ([]ActivityRow, error)
+	FetchMilestones() ([]MilestoneRow, error)
 }
 
 // ConvoyHandler handles HTTP requests for the convoy dashboard.
@@ -43,6 +45,9 @@ type ConvoyHandler struct {
 	template     *template.Template
 	fetchTimeout time.Duration
 	csrfToken    string
+	// redactor, if set, renders the read-only observer view: mail is
+	// withheld and secrets are masked (see NewObserverMux).
+	redactor *util.Redactor
 }
 
 // NewConvoyHandler creates a new convoy handler with the given fetcher, fetch timeout, and CSRF token.
@@ -84,11 +89,12 @@ func (h *ConvoyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		mayor       *MayorStatus
 		issues      []IssueRow
 		activity    []ActivityRow
+		milestones  []MilestoneRow
 		wg          sync.WaitGroup
 	)
 
 	// Run all fetches in parallel with error logging
-	wg.Add(14)
+	wg.Add(15)
 
 	go func() {
 		defer wg.Done()
@@ -202,6 +208,14 @@ func (h *ConvoyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			log.Printf("dashboard: FetchActivity failed: %v", err)
 		}
 	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		milestones, err = h.fetcher.FetchMilestones()
+		if err != nil {
+			log.Printf("dashboard: FetchMilestones failed: %v", err)
+		}
+	}()
 
 	// Wait for fetches or timeout
 	done := make(chan struct{})
@@ -220,6 +234,10 @@ func (h *ConvoyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		<-done
 	}
 
+	if h.redactor != nil {
+		mail = nil
+	}
+
 	// Compute summary from already-fetched data
 	summary := computeSummary(workers, hooks, issues, convoys, escalations, activity)
 
@@ -238,8 +256,10 @@ func (h *ConvoyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Mayor:       mayor,
 		Issues:      enrichIssuesWithAssignees(issues, hooks),
 		Activity:    activity,
+		Milestones:  milestones,
 		Summary:     summary,
 		Expand:      expandPanel,
+		Observer:    h.redactor != nil,
 		CSRFToken:   h.csrfToken,
 	}
 
@@ -253,6 +273,12 @@ func (h *ConvoyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.redactor != nil {
+		redacted := h.redactor.Redact(buf.String())
+		buf.Reset()
+		buf.WriteString(redacted)
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if _, err := buf.WriteTo(w); err != nil {
 		log.Printf("dashboard: response write failed: %v", err)
@@ -32,6 +32,7 @@ type MockConvoyFetcher struct {
 	Mayor       *MayorStatus
 	Issues      []IssueRow
 	Activity    []ActivityRow
+	Milestones  []MilestoneRow
 	Error       error
 }
 
@@ -91,6 +92,10 @@ func (m *MockConvoyFetcher) FetchActivity() ([]ActivityRow, error) {
 	return m.Activity, nil
 }
 
+func (m *MockConvoyFetcher) FetchMilestones() ([]MilestoneRow, error) {
+	return m.Milestones, nil
+}
+
 func TestConvoyHandler_RendersTemplate(t *testing.T) {
 	mock := &MockConvoyFetcher{
 		Convoys: []ConvoyRow{
@@ -132,6 +137,30 @@ func TestConvoyHandler_RendersTemplate(t *testing.T) {
 	}
 }
 
+func TestConvoyHandler_RendersMilestones(t *testing.T) {
+	mock := &MockConvoyFetcher{
+		Milestones: []MilestoneRow{
+			{ID: "gt-m1", Title: "Q3 release", Done: 3, Total: 4, ProgressPct: 75, ETA: "Sep 30"},
+		},
+	}
+
+	handler, err := NewConvoyHandler(mock, 8*time.Second, "test-token")
+	if err != nil {
+		t.Fatalf("NewConvoyHandler() error = %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{"milestones-panel", "gt-m1", "Q3 release", "3/4", "75%", "Sep 30"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Response should contain %q", want)
+		}
+	}
+}
+
 func TestConvoyHandler_LastActivityColors(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -1060,6 +1089,10 @@ func (m *MockConvoyFetcherWithErrors) FetchActivity() ([]ActivityRow, error) {
 	return nil, nil
 }
 
+func (m *MockConvoyFetcherWithErrors) FetchMilestones() ([]MilestoneRow, error) {
+	return nil, nil
+}
+
 // TestConvoyHandler_TemplateErrorReturns500 verifies that template execution errors
 // return a proper 500 status code, not 200 (which would happen if we wrote directly
 // to the ResponseWriter and it failed mid-execution).
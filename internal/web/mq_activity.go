@@ -0,0 +1,93 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"time"
+)
+
+// handleMQActivity streams live merge queue activity to the dashboard as
+// Server-Sent Events: one mq-progress event per refinery progress event,
+// its data the JSON line from `gt mq activity --json` (a progress event
+// tagged with its rig). An optional ?rig= limits the stream to one rig;
+// otherwise every rig in the town is followed.
+//
+// The gt process lives as long as the client is connected, so it doesn't
+// take a command slot (see cmdSem).
+func (h *APIHandler) handleMQActivity(w http.ResponseWriter, r *http.Request) {
+	args := []string{"mq", "activity", "--json"}
+	if rigName := r.URL.Query().Get("rig"); rigName != "" {
+		if !isValidRigName(rigName) {
+			h.sendError(w, "Invalid rig name", http.StatusBadRequest)
+			return
+		}
+		args = append(args, rigName)
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "SSE not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	cmd := exec.CommandContext(ctx, h.gtPath, args...) //nolint:gosec // G204: fixed gt subcommand, rig name validated
+	if h.workDir != "" {
+		cmd.Dir = h.workDir
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		h.sendError(w, "Failed to follow merge queue: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		h.sendError(w, "Failed to follow merge queue: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprintf(w, "event: connected\ndata: ok\n\n")
+	flusher.Flush()
+
+	lines := make(chan string)
+	defer func() {
+		// Once ctx is done gt is killed; finish reading before reaping it.
+		for range lines {
+		}
+		_ = cmd.Wait()
+	}()
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(stdout)
+		for sc.Scan() {
+			select {
+			case lines <- sc.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprintf(w, ": keepalive\n\n")
+			flusher.Flush()
+		case line, ok := <-lines:
+			if !ok {
+				// gt exited; the client's EventSource reconnects.
+				return
+			}
+			fmt.Fprintf(w, "event: mq-progress\ndata: %s\n\n", line)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIHandler_MQActivity_RelaysEvents(t *testing.T) {
+	dir := t.TempDir()
+	gt := filepath.Join(dir, "gt")
+	script := "#!/bin/sh\necho \"$@\" > args\necho '{\"rig\":\"alpha\",\"mr\":\"\",\"stage\":\"stacking\"}'\n"
+	if err := os.WriteFile(gt, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	h := &APIHandler{gtPath: gt, workDir: dir, cmdSem: make(chan struct{}, 1)}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/mq/activity?rig=alpha", nil)
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	defer cancel()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req.WithContext(ctx))
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "event: mq-progress\ndata: {\"rig\":\"alpha\",\"mr\":\"\",\"stage\":\"stacking\"}\n\n") {
+		t.Errorf("body missing relayed event:\n%s", body)
+	}
+	if args, _ := os.ReadFile(filepath.Join(dir, "args")); strings.TrimSpace(string(args)) != "mq activity --json alpha" {
+		t.Errorf("gt args = %q", args)
+	}
+}
+
+func TestAPIHandler_MQActivity_RejectsBadRig(t *testing.T) {
+	h := &APIHandler{gtPath: "false", workDir: t.TempDir(), cmdSem: make(chan struct{}, 1)}
+	req := httptest.NewRequest(http.MethodGet, "/api/mq/activity?rig=../etc", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
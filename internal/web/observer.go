@@ -0,0 +1,326 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/agentlog"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// observerCookie remembers an observer's token after a ?token= visit.
+const observerCookie = "gt_observer"
+
+// maxTranscriptEvents and maxTranscriptLine bound a transcript summary.
+const (
+	maxTranscriptEvents = 30
+	maxTranscriptLine   = 200
+)
+
+// ObserverCommands are the read-only commands observers may run from the
+// command palette: town state, but not mail, logs or the workspace layout.
+var ObserverCommands = map[string]bool{
+	"status":             true,
+	"agents list":        true,
+	"convoy list":        true,
+	"convoy show":        true,
+	"convoy status":      true,
+	"rig list":           true,
+	"rig show":           true,
+	"hooks list":         true,
+	"activity":           true,
+	"polecat list --all": true,
+	"polecat show":       true,
+	"crew list --all":    true,
+	"crew show":          true,
+}
+
+// observerReadRoutes are the GET API routes observers may use unchanged,
+// apart from redaction.
+var observerReadRoutes = map[string]bool{
+	"/issues/show": true,
+	"/pr/show":     true,
+	"/crew":        true,
+	"/ready":       true,
+	"/events":      true,
+	"/mq/activity": true,
+}
+
+// TranscriptSummary is the JSON response from /api/observer/transcript.
+type TranscriptSummary struct {
+	Session string           `json:"session"`
+	Events  []TranscriptLine `json:"events"`
+}
+
+// TranscriptLine summarizes one transcript event: what the agent said or
+// which tool it used, cut to its first line.
+type TranscriptLine struct {
+	Time string `json:"time,omitempty"`
+	Kind string `json:"kind"` // "said", "asked" or "tool"
+	Text string `json:"text"`
+}
+
+// ObserverHandler serves the API for the read-only observer dashboard. It
+// passes read routes through to an APIHandler with secrets redacted, and
+// refuses everything that could change the town.
+type ObserverHandler struct {
+	api             *APIHandler
+	redactor        *util.Redactor
+	hideTranscripts bool
+}
+
+// NewObserverHandler creates an observer API handler over api.
+func NewObserverHandler(api *APIHandler, redactor *util.Redactor, hideTranscripts bool) *ObserverHandler {
+	return &ObserverHandler{api: api, redactor: redactor, hideTranscripts: hideTranscripts}
+}
+
+// ServeHTTP routes observer API requests.
+func (h *ObserverHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api")
+	rw := &redactingWriter{ResponseWriter: w, redactor: h.redactor}
+	switch {
+	case path == "/run" && r.Method == http.MethodPost:
+		if r.Header.Get("X-Dashboard-Token") != h.api.csrfToken {
+			h.api.sendError(w, "Invalid or missing dashboard token", http.StatusForbidden)
+			return
+		}
+		h.handleRun(rw, r)
+	case path == "/commands" && r.Method == http.MethodGet:
+		h.handleCommands(w, r)
+	case path == "/session/preview" && r.Method == http.MethodGet && !h.hideTranscripts:
+		h.api.handleSessionPreview(rw, r)
+	case path == "/observer/transcript" && r.Method == http.MethodGet && !h.hideTranscripts:
+		h.handleTranscript(rw, r)
+	case observerReadRoutes[path] && r.Method == http.MethodGet:
+		h.api.ServeHTTP(rw, r)
+	default:
+		h.api.sendError(w, "Read-only observer view: this action is disabled", http.StatusForbidden)
+	}
+}
+
+// handleRun runs an observer command.
+func (h *ObserverHandler) handleRun(w http.ResponseWriter, r *http.Request) {
+	var req CommandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.api.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if _, err := ValidateCommand(req.Command); err != nil {
+		h.api.sendError(w, fmt.Sprintf("Command blocked: %v", err), http.StatusForbidden)
+		return
+	}
+	if base := extractBaseCommand(strings.TrimSpace(req.Command)); !ObserverCommands[base] {
+		h.api.sendError(w, fmt.Sprintf("Command blocked: %s is not available to observers", base), http.StatusForbidden)
+		return
+	}
+	// Observer commands are all read-only, so confirmation never applies.
+	req.Confirmed = false
+	body, _ := json.Marshal(req)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	h.api.handleRun(w, r)
+}
+
+// handleCommands lists the observer commands for the palette.
+func (h *ObserverHandler) handleCommands(w http.ResponseWriter, _ *http.Request) {
+	var commands []CommandInfo
+	for _, c := range GetCommandList() {
+		if ObserverCommands[c.Name] {
+			commands = append(commands, c)
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(CommandListResponse{Commands: commands})
+}
+
+// handleTranscript summarizes the recent transcript of the agent running
+// in a session: what it said and which tools it used, without tool output
+// or thinking.
+func (h *ObserverHandler) handleTranscript(w http.ResponseWriter, r *http.Request) {
+	sessionName := r.URL.Query().Get("session")
+	if sessionName == "" {
+		h.api.sendError(w, "Missing session parameter", http.StatusBadRequest)
+		return
+	}
+	if msg := checkSessionName(sessionName); msg != "" {
+		h.api.sendError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "tmux", "display-message", "-p", "-t", sessionName, "#{pane_current_path}").Output()
+	if err != nil {
+		h.api.sendError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	path, err := agentlog.ClaudeTranscriptPath(strings.TrimSpace(string(out)), time.Time{})
+	if err != nil {
+		h.api.sendError(w, "No transcript for session", http.StatusNotFound)
+		return
+	}
+	events, err := agentlog.ReadClaudeTranscript(path, sessionName)
+	if err != nil {
+		h.api.sendError(w, "Failed to read transcript", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(TranscriptSummary{
+		Session: sessionName,
+		Events:  summarizeTranscript(events),
+	})
+}
+
+// summarizeTranscript keeps the last maxTranscriptEvents text and tool_use
+// events, each cut to its first line.
+func summarizeTranscript(events []agentlog.AgentEvent) []TranscriptLine {
+	lines := []TranscriptLine{}
+	for _, ev := range events {
+		var kind string
+		switch {
+		case ev.EventType == "text" && ev.Role == "user":
+			kind = "asked"
+		case ev.EventType == "text":
+			kind = "said"
+		case ev.EventType == "tool_use":
+			kind = "tool"
+		default:
+			continue
+		}
+		text := strings.TrimSpace(ev.Content)
+		if text == "" {
+			continue
+		}
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[:i] + " …"
+		}
+		if r := []rune(text); len(r) > maxTranscriptLine {
+			text = string(r[:maxTranscriptLine]) + "…"
+		}
+		line := TranscriptLine{Kind: kind, Text: text}
+		if !ev.Timestamp.IsZero() {
+			line.Time = ev.Timestamp.Format(time.RFC3339)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > maxTranscriptEvents {
+		lines = lines[len(lines)-maxTranscriptEvents:]
+	}
+	return lines
+}
+
+// redactingWriter masks secrets in everything written through it. Each
+// Write is redacted on its own, which suits the API: handlers encode a
+// response, or an SSE event, in a single write.
+type redactingWriter struct {
+	http.ResponseWriter
+	redactor *util.Redactor
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := w.ResponseWriter.Write([]byte(w.redactor.Redact(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush lets SSE handlers stream through the writer.
+func (w *redactingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// requireObserverToken wraps next so that only requests presenting token
+// get through: as a bearer token, as the observer cookie, or as ?token=,
+// which then sets the cookie.
+func requireObserverToken(token string, next http.Handler) http.Handler {
+	matches := func(s string) bool {
+		return subtle.ConstantTimeCompare([]byte(s), []byte(token)) == 1
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("token"); q != "" && matches(q) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     observerCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			next.ServeHTTP(w, r)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") && matches(strings.TrimPrefix(auth, "Bearer ")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if c, err := r.Cookie(observerCookie); err == nil && matches(c.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, "Observer token required", http.StatusUnauthorized)
+	})
+}
+
+// NewObserverMux creates an HTTP handler for the read-only observer
+// dashboard: status, the merge queue, activity and transcript summaries
+// with secrets redacted, and no control actions. webCfg and obs may be
+// nil, in which case defaults are used.
+func NewObserverMux(fetcher ConvoyFetcher, webCfg *config.WebTimeoutsConfig, obs *config.ObserverConfig) (http.Handler, error) {
+	if webCfg == nil {
+		webCfg = config.DefaultWebTimeoutsConfig()
+	}
+	if obs == nil {
+		obs = &config.ObserverConfig{}
+	}
+	redactor, err := util.NewRedactor(obs.Redact)
+	if err != nil {
+		return nil, fmt.Errorf("observer: %w", err)
+	}
+
+	csrfToken := generateCSRFToken()
+
+	fetchTimeout := config.ParseDurationOrDefault(webCfg.FetchTimeout, 8*time.Second)
+	convoyHandler, err := NewConvoyHandler(fetcher, fetchTimeout, csrfToken)
+	if err != nil {
+		return nil, err
+	}
+	convoyHandler.redactor = redactor
+
+	defaultRunTimeout := config.ParseDurationOrDefault(webCfg.DefaultRunTimeout, 30*time.Second)
+	maxRunTimeout := config.ParseDurationOrDefault(webCfg.MaxRunTimeout, 60*time.Second)
+	apiHandler := NewAPIHandler(defaultRunTimeout, maxRunTimeout, csrfToken)
+
+	staticFS, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		return nil, err
+	}
+	staticHandler := http.FileServer(http.FS(staticFS))
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", NewObserverHandler(apiHandler, redactor, obs.HideTranscripts))
+	mux.Handle("/static/", http.StripPrefix("/static/", staticHandler))
+	mux.Handle("/", convoyHandler)
+
+	if obs.Token == "" {
+		return mux, nil
+	}
+	return requireObserverToken(obs.Token, mux), nil
+}
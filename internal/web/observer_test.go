@@ -0,0 +1,182 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/agentlog"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+func newTestObserverHandler(t *testing.T, hideTranscripts bool) *ObserverHandler {
+	t.Helper()
+	redactor, err := util.NewRedactor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPIHandler(30*time.Second, 60*time.Second, "test-token")
+	return NewObserverHandler(api, redactor, hideTranscripts)
+}
+
+func TestObserverMux_RendersReadOnlyView(t *testing.T) {
+	fetcher := &MockConvoyFetcher{
+		Convoys: []ConvoyRow{{ID: "hq-cv-1", Title: "Rotate keys, api_key=s3cr3tvalue99", Status: "open"}},
+		Mail:    []MailRow{{ID: "hq-msg-1", Subject: "Private note to the mayor"}},
+	}
+	mux, err := NewObserverMux(fetcher, nil, &config.ObserverConfig{Redact: []string{`Rotate`}})
+	if err != nil {
+		t.Fatalf("NewObserverMux: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET / status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `<body class="observer">`) || !strings.Contains(body, "observer-banner") {
+		t.Error("observer view not marked read-only")
+	}
+	for _, leaked := range []string{"s3cr3tvalue99", "Rotate keys", "Private note to the mayor"} {
+		if strings.Contains(body, leaked) {
+			t.Errorf("observer view shows %q", leaked)
+		}
+	}
+	if !strings.Contains(body, util.Redacted) {
+		t.Error("observer view has no redactions")
+	}
+}
+
+func TestObserverMux_BadRedactPattern(t *testing.T) {
+	if _, err := NewObserverMux(&MockConvoyFetcher{}, nil, &config.ObserverConfig{Redact: []string{"("}}); err == nil {
+		t.Fatal("NewObserverMux accepted an invalid redact pattern")
+	}
+}
+
+func TestObserverHandler_RefusesControlActions(t *testing.T) {
+	h := newTestObserverHandler(t, false)
+	tests := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/api/mail/send", `{"to":"mayor/","subject":"x","body":"y"}`},
+		{http.MethodPost, "/api/issues/create", `{"title":"x"}`},
+		{http.MethodPost, "/api/issues/close", `{"id":"gt-1"}`},
+		{http.MethodGet, "/api/mail/inbox", ""},
+		{http.MethodGet, "/api/options", ""},
+		{http.MethodPost, "/api/run", `{"command":"sling gt-1 gastown","confirmed":true}`},
+		{http.MethodPost, "/api/run", `{"command":"mail inbox"}`},
+		{http.MethodPost, "/api/run", `{"command":"log"}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("X-Dashboard-Token", "test-token")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s %s status = %d, want 403", tt.method, tt.path, tt.body, w.Code)
+		}
+	}
+}
+
+func TestObserverHandler_Commands(t *testing.T) {
+	h := newTestObserverHandler(t, false)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/commands", nil))
+
+	var resp CommandListResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Commands) != len(ObserverCommands) {
+		t.Errorf("got %d commands, want %d", len(resp.Commands), len(ObserverCommands))
+	}
+	for _, c := range resp.Commands {
+		if !c.Safe || !ObserverCommands[c.Name] {
+			t.Errorf("observer offered %q", c.Name)
+		}
+	}
+}
+
+func TestObserverHandler_HideTranscripts(t *testing.T) {
+	h := newTestObserverHandler(t, true)
+	for _, path := range []string{"/api/session/preview?session=hq-mayor", "/api/observer/transcript?session=hq-mayor"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("GET %s status = %d, want 403", path, w.Code)
+		}
+	}
+}
+
+func TestObserverMux_Token(t *testing.T) {
+	mux, err := NewObserverMux(&MockConvoyFetcher{}, nil, &config.ObserverConfig{Token: "let-me-in"})
+	if err != nil {
+		t.Fatalf("NewObserverMux: %v", err)
+	}
+	get := func(path string, prep func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if prep != nil {
+			prep(req)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("/", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d, want 401", w.Code)
+	}
+	if w := get("/?token=wrong", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", w.Code)
+	}
+	w := get("/?token=let-me-in", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("?token=: status = %d, want 200", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != observerCookie || !cookies[0].HttpOnly {
+		t.Fatalf("?token= set cookies %v", cookies)
+	}
+	if w := get("/api/commands", func(r *http.Request) { r.AddCookie(cookies[0]) }); w.Code != http.StatusOK {
+		t.Errorf("cookie: status = %d, want 200", w.Code)
+	}
+	if w := get("/api/commands", func(r *http.Request) { r.Header.Set("Authorization", "Bearer let-me-in") }); w.Code != http.StatusOK {
+		t.Errorf("bearer: status = %d, want 200", w.Code)
+	}
+}
+
+func TestSummarizeTranscript(t *testing.T) {
+	var events []agentlog.AgentEvent
+	events = append(events,
+		agentlog.AgentEvent{EventType: "text", Role: "user", Content: "Fix the build"},
+		agentlog.AgentEvent{EventType: "thinking", Role: "assistant", Content: "hmm"},
+		agentlog.AgentEvent{EventType: "tool_use", Role: "assistant", Content: "Bash: go build ./...\nmore"},
+		agentlog.AgentEvent{EventType: "tool_result", Role: "user", Content: "ok"},
+		agentlog.AgentEvent{EventType: "usage", Role: "assistant"},
+		agentlog.AgentEvent{EventType: "text", Role: "assistant", Content: strings.Repeat("é", maxTranscriptLine+10)},
+	)
+	for i := 0; i < maxTranscriptEvents; i++ {
+		events = append([]agentlog.AgentEvent{{EventType: "text", Role: "assistant", Content: "old"}}, events...)
+	}
+
+	lines := summarizeTranscript(events)
+	if len(lines) != maxTranscriptEvents {
+		t.Fatalf("got %d lines, want %d", len(lines), maxTranscriptEvents)
+	}
+	tail := lines[len(lines)-3:]
+	if tail[0].Kind != "asked" || tail[0].Text != "Fix the build" {
+		t.Errorf("line = %+v, want the user's request", tail[0])
+	}
+	if tail[1].Kind != "tool" || tail[1].Text != "Bash: go build ./... …" {
+		t.Errorf("line = %+v, want the tool call's first line", tail[1])
+	}
+	if tail[2].Kind != "said" || len([]rune(tail[2].Text)) != maxTranscriptLine+1 {
+		t.Errorf("line = %q, want it cut to %d runes", tail[2].Text, maxTranscriptLine)
+	}
+}
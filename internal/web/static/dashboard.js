@@ -3051,12 +3051,35 @@
                 var now = new Date();
                 var timeStr = now.getHours() + ':' + (now.getMinutes() < 10 ? '0' : '') + now.getMinutes() + ':' + (now.getSeconds() < 10 ? '0' : '') + now.getSeconds();
                 statusEl.textContent = 'refreshed ' + timeStr;
+                fetchTranscriptSummary(sessionName);
             })
             .catch(function(err) {
                 contentEl.textContent = 'Failed to load preview: ' + err.message;
             });
     }
 
+    // Observer view: summarize what the agent has been saying and doing.
+    function fetchTranscriptSummary(sessionName) {
+        var el = document.getElementById('session-transcript');
+        if (!el) return;
+        fetch('/api/observer/transcript?session=' + encodeURIComponent(sessionName))
+            .then(function(r) { return r.json(); })
+            .then(function(data) {
+                el.textContent = '';
+                if (data.error || !data.events || data.events.length === 0) {
+                    el.textContent = data.error || 'No transcript yet';
+                    return;
+                }
+                data.events.forEach(function(ev) {
+                    var line = document.createElement('div');
+                    line.className = 'session-transcript-line transcript-' + ev.kind;
+                    line.textContent = (ev.kind === 'tool' ? '● ' : ev.kind === 'asked' ? '> ' : '') + ev.text;
+                    el.appendChild(line);
+                });
+            })
+            .catch(function() {});
+    }
+
     function closeSessionPreview() {
         if (sessionPreviewInterval) {
             clearInterval(sessionPreviewInterval);
@@ -3229,4 +3252,53 @@
         cell.innerHTML = html;
     }
 
+    // ============================================
+    // MERGE QUEUE ACTIVITY (live refinery progress)
+    // ============================================
+    // Streams /api/mq/activity into #mq-activity. Events are kept here
+    // because the dashboard re-render replaces the element.
+    var mqActivity = [];
+    var mqActivityMax = 15;
+    var mqActivitySource = null;
+
+    function renderMQActivity() {
+        var el = document.getElementById('mq-activity');
+        if (!el) return;
+        if (mqActivity.length === 0) {
+            el.innerHTML = '<div class="mq-activity-empty">No refinery activity yet</div>';
+            return;
+        }
+        el.innerHTML = mqActivity.map(function(ev) {
+            var t = new Date(ev.time).toLocaleTimeString();
+            return '<div class="mq-activity-line mq-stage-' + escapeHtml(ev.stage) + '">' +
+                '<span class="mq-activity-time">' + escapeHtml(t) + '</span> ' +
+                '<span class="mq-activity-rig">' + escapeHtml(ev.rig) + '</span> ' +
+                '<span class="mq-activity-mr">' + escapeHtml(ev.mr || 'batch') + '</span> ' +
+                '<span class="mq-activity-stage">' + escapeHtml(ev.stage) + '</span> ' +
+                escapeHtml(ev.detail || '') + '</div>';
+        }).join('');
+    }
+
+    function connectMQActivity() {
+        if (!document.getElementById('mq-activity') || mqActivitySource) return;
+        mqActivitySource = new EventSource('/api/mq/activity');
+        mqActivitySource.addEventListener('mq-progress', function(e) {
+            try {
+                mqActivity.unshift(JSON.parse(e.data));
+            } catch (err) {
+                return;
+            }
+            mqActivity.length = Math.min(mqActivity.length, mqActivityMax);
+            renderMQActivity();
+        });
+        // EventSource reconnects on its own after errors.
+    }
+
+    connectMQActivity();
+    renderMQActivity();
+    document.body.addEventListener('htmx:afterSwap', function() {
+        connectMQActivity();
+        renderMQActivity();
+    });
+
 })();
@@ -29,8 +29,10 @@ type ConvoyData struct {
 	Mayor       *MayorStatus
 	Issues      []IssueRow
 	Activity    []ActivityRow
+	Milestones  []MilestoneRow
 	Summary     *DashboardSummary
 	Expand      string // Panel to show fullscreen (from ?expand=name)
+	Observer    bool   // Read-only observer view (see NewObserverMux)
 	CSRFToken   string // Token for CSRF protection on POST requests
 }
 
@@ -124,6 +126,18 @@ type IssueRow struct {
 	Assignee string // Who it's hooked to (empty if unassigned)
 }
 
+// MilestoneRow represents a milestone rollup in the dashboard.
+type MilestoneRow struct {
+	ID          string // Milestone bead ID
+	Title       string // Milestone title
+	Done        int    // Closed child issues
+	Total       int    // All child issues
+	InProgress  int    // Children being worked on
+	MRsInFlight int    // Children with an open merge request
+	ProgressPct int    // Done as a percentage of Total
+	ETA         string // Projected completion date, or "" if unknown
+}
+
 // ActivityRow represents an event in the activity feed.
 type ActivityRow struct {
 	Time         string // Formatted time (e.g., "2m ago")
@@ -417,8 +417,7 @@ func HandleMergeFailed(workDir, rigName string, msg *mail.Message, router *mail.
 	sessionName := session.PolecatSessionName(session.PrefixFor(rigName), payload.PolecatName)
 	nudgeMsg := fmt.Sprintf("MERGE_FAILED: branch=%s issue=%s type=%s error=%s — fix and resubmit with 'gt done'",
 		payload.Branch, payload.IssueID, payload.FailureType, payload.Error)
-	t := tmux.NewTmux()
-	if err := t.NudgeSession(sessionName, nudgeMsg); err != nil {
+	if err := tmux.Nudge(tmux.Selected(), sessionName, nudgeMsg); err != nil {
 		result.Error = fmt.Errorf("nudging polecat about failure: %w", err)
 		return result
 	}
@@ -639,7 +638,10 @@ func nudgeRefinery(townRoot, rigName string) error {
 	sessionName := session.RefinerySessionName(session.PrefixFor(rigName))
 
 	// Check if refinery is running
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	running, err := t.HasSession(sessionName)
 	if err != nil {
 		return fmt.Errorf("checking refinery session: %w", err)
@@ -655,7 +657,7 @@ func nudgeRefinery(townRoot, rigName string) error {
 	// No cooperative queue — idle agents never call Drain(), so queued
 	// nudges would be stuck forever. Direct delivery is safe: if the
 	// agent is busy, text buffers in tmux and is processed at next prompt.
-	return t.NudgeSession(sessionName, "New MR available - check merge queue for pending work")
+	return tmux.Nudge(t, sessionName, "New MR available - check merge queue for pending work")
 }
 
 // RecoveryPayload contains data for RECOVERY_NEEDED escalation.
@@ -678,8 +680,7 @@ func EscalateRecoveryNeeded(workDir, rigName string, payload *RecoveryPayload) (
 	sessionName := session.DeaconSessionName()
 	nudgeMsg := fmt.Sprintf("RECOVERY_NEEDED: %s/%s cleanup_status=%s branch=%s issue=%s detected=%s — coordinate recovery before authorizing cleanup",
 		rigName, payload.PolecatName, payload.CleanupStatus, payload.Branch, payload.IssueID, payload.DetectedAt.Format(time.RFC3339))
-	t := tmux.NewTmux()
-	if err := t.NudgeSession(sessionName, nudgeMsg); err != nil {
+	if err := tmux.Nudge(tmux.Selected(), sessionName, nudgeMsg); err != nil {
 		return "", fmt.Errorf("nudging deacon about recovery: %w", err)
 	}
 	return "nudge", nil
@@ -764,16 +765,18 @@ func NukePolecat(bd *BdCli, workDir, rigName, polecatName string) error {
 	// session due to rig loading issues or race conditions with IsRunning checks.
 	// See: gt-g9ft5 - sessions were piling up because nuke wasn't killing them.
 	sessionName := session.PolecatSessionName(session.PrefixFor(rigName), polecatName)
-	t := tmux.NewTmux()
+	mux := tmux.Selected()
 
 	// Check if session exists and kill it
-	if running, _ := t.HasSession(sessionName); running {
+	if running, _ := mux.HasSession(sessionName); running {
 		// Try graceful shutdown first (Ctrl-C), then force kill
-		_ = t.SendKeysRaw(sessionName, "C-c")
-		// Brief delay for graceful handling
-		time.Sleep(100 * time.Millisecond)
+		if t, ok := mux.(*tmux.Tmux); ok {
+			_ = t.SendKeysRaw(sessionName, "C-c")
+			// Brief delay for graceful handling
+			time.Sleep(100 * time.Millisecond)
+		}
 		// Force kill the session
-		if err := t.KillSession(sessionName); err != nil {
+		if err := mux.KillSession(sessionName); err != nil {
 			// Log but continue - session might already be dead
 			// The important thing is we tried
 		}
@@ -988,7 +991,12 @@ func DetectZombiePolecats(bd *BdCli, workDir, rigName string, router *mail.Route
 		return result
 	}
 
-	t := tmux.NewTmux()
+	// Telling a zombie from a live agent needs the pane's process tree.
+	t, err := tmux.RequireTmux("zombie detection")
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result
+	}
 
 	for _, entry := range entries {
 		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
@@ -1448,7 +1456,11 @@ func DetectStalledPolecats(workDir, rigName string) *DetectStalledPolecatsResult
 		return result // No polecats directory
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("stall detection")
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result
+	}
 	now := time.Now()
 
 	for _, entry := range entries {
@@ -1929,10 +1941,9 @@ then either close the bead or reset the respawn counter.`,
 			if err := router.Send(msg); err != nil {
 				fmt.Fprintf(os.Stderr, "witness: failed to send SPAWN_BLOCKED mail for %s: %v, attempting nudge fallback\n", hookBead, err)
 				// Nudge mayor as fallback — nudges are more reliable than mail
-				t := tmux.NewTmux()
 				nudgeMsg := fmt.Sprintf("SPAWN_BLOCKED %s (respawn limit reached) from %s/%s — mail send failed, investigate spawn storm",
 					hookBead, rigName, polecatName)
-				if nudgeErr := t.NudgeSession(session.MayorSessionName(), nudgeMsg); nudgeErr != nil {
+				if nudgeErr := tmux.Nudge(tmux.Selected(), session.MayorSessionName(), nudgeMsg); nudgeErr != nil {
 					fmt.Fprintf(os.Stderr, "witness: nudge fallback to mayor also failed for %s: %v\n", hookBead, nudgeErr)
 				}
 			}
@@ -1980,10 +1991,9 @@ Please re-dispatch to an available polecat.`,
 		if err := router.Send(msg); err != nil {
 			fmt.Fprintf(os.Stderr, "witness: failed to send RECOVERED_BEAD mail for %s: %v, attempting nudge fallback\n", hookBead, err)
 			// Nudge deacon as fallback — nudges are more reliable than mail
-			t := tmux.NewTmux()
 			nudgeMsg := fmt.Sprintf("RECOVERED_BEAD %s from %s/%s (status=%s, respawns=%d) — mail send failed, please re-dispatch",
 				hookBead, rigName, polecatName, status, respawnCount)
-			if nudgeErr := t.NudgeSession(session.DeaconSessionName(), nudgeMsg); nudgeErr != nil {
+			if nudgeErr := tmux.Nudge(tmux.Selected(), session.DeaconSessionName(), nudgeMsg); nudgeErr != nil {
 				fmt.Fprintf(os.Stderr, "witness: nudge fallback to deacon also failed for %s: %v\n", hookBead, nudgeErr)
 			}
 		}
@@ -2048,7 +2058,11 @@ func DetectOrphanedBeads(bd *BdCli, workDir, rigName string, router *mail.Router
 		beadList = append(beadList, batch...)
 	}
 
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result
+	}
 
 	for _, bead := range beadList {
 		if bead.Assignee == "" {
@@ -2190,7 +2204,11 @@ func DetectOrphanedMolecules(bd *BdCli, workDir, rigName string, router *mail.Ro
 
 	// Step 2: Check each polecat-assigned bead
 	polecatPrefix := rigName + "/polecats/"
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result
+	}
 	polecatsDir := filepath.Join(townRoot, rigName, "polecats")
 
 	for _, b := range allBeads {
@@ -2424,8 +2442,8 @@ func getAgentBeadLabels(bd *BdCli, workDir, agentBeadID string) []string {
 // sessionRecreated checks whether a tmux session was (re)created after the
 // given timestamp. Returns true if the session exists and was created after
 // detectedAt, indicating a new session replaced the dead one (TOCTOU guard).
-func sessionRecreated(t *tmux.Tmux, sessionName string, detectedAt time.Time) bool {
-	alive, err := t.HasSession(sessionName)
+func sessionRecreated(mux tmux.Multiplexer, sessionName string, detectedAt time.Time) bool {
+	alive, err := mux.HasSession(sessionName)
 	if err != nil || !alive {
 		return false // Still dead — not recreated
 	}
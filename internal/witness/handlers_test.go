@@ -13,7 +13,7 @@ import (
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/polecat"
-	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/testutil/fakemux"
 )
 
 func TestHandlePolecatDoneFromBead_NilFields(t *testing.T) {
@@ -208,7 +208,7 @@ func TestSessionRecreated_NoSession(t *testing.T) {
 	t.Parallel()
 	// When the session doesn't exist, sessionRecreated should return false
 	// (the session wasn't recreated, it's still dead)
-	tm := tmux.NewTmux()
+	tm := fakemux.New()
 	detectedAt := time.Now()
 
 	recreated := sessionRecreated(tm, "gt-nonexistent-session-xyz", detectedAt)
@@ -221,7 +221,7 @@ func TestSessionRecreated_DetectedAtEdgeCases(t *testing.T) {
 	t.Parallel()
 	// Verify that sessionRecreated returns false when session is dead
 	// regardless of the detectedAt timestamp
-	tm := tmux.NewTmux()
+	tm := fakemux.New()
 
 	// Try with a past timestamp
 	recreated := sessionRecreated(tm, "gt-test-nosession-abc", time.Now().Add(-1*time.Hour))
@@ -47,8 +47,11 @@ func NewManager(r *rig.Rig) *Manager {
 // ZFC: tmux session existence is the source of truth for session state,
 // but agent liveness determines if the session is actually functional.
 func (m *Manager) IsRunning() (bool, error) {
-	t := tmux.NewTmux()
-	status := t.CheckSessionHealth(m.SessionName(), 0)
+	mux, err := tmux.NewMultiplexer()
+	if err != nil {
+		return false, err
+	}
+	status := tmux.CheckHealth(mux, m.SessionName(), 0)
 	return status == tmux.SessionHealthy, nil
 }
 
@@ -58,8 +61,7 @@ func (m *Manager) IsRunning() (bool, error) {
 // Returns the detailed ZombieStatus for callers that need to distinguish
 // between different failure modes.
 func (m *Manager) IsHealthy(maxInactivity time.Duration) tmux.ZombieStatus {
-	t := tmux.NewTmux()
-	return t.CheckSessionHealth(m.SessionName(), maxInactivity)
+	return tmux.CheckHealth(tmux.Selected(), m.SessionName(), maxInactivity)
 }
 
 // SessionName returns the tmux session name for this witness.
@@ -70,7 +72,10 @@ func (m *Manager) SessionName() string {
 // Status returns information about the witness session.
 // ZFC-compliant: tmux session is the source of truth.
 func (m *Manager) Status() (*tmux.SessionInfo, error) {
-	t := tmux.NewTmux()
+	t, err := tmux.RequireTmux("witness session info")
+	if err != nil {
+		return nil, err
+	}
 	sessionID := m.SessionName()
 
 	running, err := t.HasSession(sessionID)
@@ -107,8 +112,14 @@ func (m *Manager) witnessDir() string {
 // envOverrides are KEY=VALUE pairs that override all other env var sources.
 // ZFC-compliant: no state file, tmux session is source of truth.
 func (m *Manager) Start(foreground bool, agentOverride string, envOverrides []string) error {
-	t := tmux.NewTmux()
-	sessionID := m.SessionName()
+	t, err := tmux.RequireTmux("starting the witness")
+	if err != nil {
+		return err
+	}
+	sessionID, err := session.ResolveSessionName(&session.AgentIdentity{Role: session.RoleWitness, Rig: m.rig.Name, Prefix: session.PrefixFor(m.rig.Name)})
+	if err != nil {
+		return fmt.Errorf("resolving session name: %w", err)
+	}
 
 	if foreground {
 		// Foreground mode is deprecated - patrol logic moved to mol-witness-patrol
@@ -347,7 +358,10 @@ func buildWitnessStartCommand(rigPath, rigName, townRoot, sessionName, agentOver
 // Stop stops the witness.
 // ZFC-compliant: tmux session is the source of truth.
 func (m *Manager) Stop() error {
-	t := tmux.NewTmux()
+	t, err := tmux.NewMultiplexer()
+	if err != nil {
+		return err
+	}
 	sessionID := m.SessionName()
 
 	// Check if tmux session exists